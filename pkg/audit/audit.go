@@ -0,0 +1,505 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"kubectl-multi/pkg/platform"
+)
+
+// EnvAuditLog overrides the location of the local audit log
+const EnvAuditLog = "KUBECTL_MULTI_AUDIT_LOG"
+
+// ClusterResult is the outcome of a mutating operation on one cluster
+type ClusterResult struct {
+	Cluster string `json:"cluster"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+	// DurationMs is how long the operation took on the cluster
+	DurationMs int64 `json:"durationMs,omitempty"`
+}
+
+// Undo actions understood by UndoStep
+const (
+	UndoDelete        = "delete"
+	UndoRestore       = "restore"
+	UndoRestoreLabels = "restore-labels"
+	UndoRestoreTaints = "restore-taints"
+	UndoHelmRollback  = "helm-rollback"
+	UndoHelmUninstall = "helm-uninstall"
+	UndoCordon        = "cordon"
+	UndoUncordon      = "uncordon"
+)
+
+// UndoStep describes how to reverse one change made on one cluster
+type UndoStep struct {
+	Cluster   string `json:"cluster"`
+	Action    string `json:"action"`
+	Group     string `json:"group,omitempty"`
+	Version   string `json:"version,omitempty"`
+	Resource  string `json:"resource,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+	// Object is the state captured before the change, for restore
+	Object map[string]interface{} `json:"object,omitempty"`
+	// Labels holds previous label values for restore-labels; null means the label was absent
+	Labels map[string]*string `json:"labels,omitempty"`
+	// Taints holds the previous taints of a ManagedCluster for restore-taints
+	Taints []interface{} `json:"taints,omitempty"`
+	// Revision is the helm release revision to roll back to
+	Revision int `json:"revision,omitempty"`
+	// Context and Kubeconfig locate the cluster of a helm step
+	Context    string `json:"context,omitempty"`
+	Kubeconfig string `json:"kubeconfig,omitempty"`
+}
+
+// Entry is one audited fleet mutation
+type Entry struct {
+	ID       string          `json:"id"`
+	Time     time.Time       `json:"time"`
+	User     string          `json:"user"`
+	Command  string          `json:"command"`
+	Args     []string        `json:"args"`
+	Clusters []string        `json:"clusters"`
+	Results  []ClusterResult `json:"results,omitempty"`
+	Undo     []UndoStep      `json:"undo,omitempty"`
+	Error    string          `json:"error,omitempty"`
+	// DurationMs is how long the whole command took
+	DurationMs int64 `json:"durationMs,omitempty"`
+}
+
+// Succeeded reports whether the operation and every per-cluster step succeeded
+func (e Entry) Succeeded() bool {
+	if e.Error != "" {
+		return false
+	}
+	for _, r := range e.Results {
+		if r.Status != "ok" {
+			return false
+		}
+	}
+	return true
+}
+
+// Progress is the state of an operation on many clusters while it runs
+type Progress struct {
+	Total     int
+	Completed int
+	Failed    int
+	InFlight  int
+	Elapsed   time.Duration
+	// Remaining extrapolates the elapsed time per completed cluster to the
+	// clusters left; it is zero until the first cluster completes
+	Remaining time.Duration
+}
+
+// Recorder collects the per-cluster results of a single mutating command.
+// It is safe for use by goroutines working on different clusters.
+type Recorder struct {
+	mu    sync.Mutex
+	entry Entry
+
+	started time.Time
+	// last is when the previous cluster completed; clusters that were not
+	// begun explicitly are timed from it, as a sequential loop runs them
+	last       time.Time
+	begun      map[string]time.Time
+	failed     map[string]bool
+	total      int
+	onProgress func(Progress)
+	now        func() time.Time
+}
+
+// Start begins recording a mutating command. Args are the command line
+// arguments as given by the user; values of sensitive flags are redacted.
+func Start(command string, args []string) *Recorder {
+	now := time.Now().UTC()
+	return &Recorder{
+		entry: Entry{
+			ID:       strconv.FormatInt(now.UnixNano(), 36),
+			Time:     now,
+			User:     currentUser(),
+			Command:  command,
+			Args:     RedactArgs(args),
+			Clusters: []string{},
+		},
+		started: time.Now(),
+		last:    time.Now(),
+		begun:   map[string]time.Time{},
+		failed:  map[string]bool{},
+		now:     time.Now,
+	}
+}
+
+// Command returns the recorded command, e.g. "apply"
+func (r *Recorder) Command() string {
+	if r == nil {
+		return ""
+	}
+	return r.entry.Command
+}
+
+// User returns the user running the recorded command
+func (r *Recorder) User() string {
+	if r == nil {
+		return ""
+	}
+	return r.entry.User
+}
+
+// Expect announces that the operation runs on total clusters and calls
+// onProgress whenever a cluster begins or completes
+func (r *Recorder) Expect(total int, onProgress func(Progress)) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.total, r.onProgress = total, onProgress
+}
+
+// Begin marks the start of the operation on one cluster, which is then
+// timed from here rather than from the completion of the previous cluster
+func (r *Recorder) Begin(cluster string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, running := r.begun[cluster]; !running {
+		r.begun[cluster] = r.now()
+		r.report()
+	}
+}
+
+// Progress returns the state of the operation so far
+func (r *Recorder) Progress() Progress {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.progress()
+}
+
+func (r *Recorder) progress() Progress {
+	now := r.now()
+	p := Progress{
+		Total:     r.total,
+		Completed: len(r.entry.Clusters),
+		Failed:    len(r.failed),
+		InFlight:  len(r.begun),
+		Elapsed:   now.Sub(r.started),
+	}
+	if p.Total < p.Completed+p.InFlight {
+		p.Total = p.Completed + p.InFlight
+	}
+	if p.Completed > 0 {
+		p.Remaining = p.Elapsed / time.Duration(p.Completed) * time.Duration(p.Total-p.Completed)
+	}
+	return p
+}
+
+func (r *Recorder) report() {
+	if r.onProgress != nil {
+		r.onProgress(r.progress())
+	}
+}
+
+// Record stores the outcome of the operation on one cluster. Recording on a
+// nil Recorder does nothing, so callers can pass nil when auditing is off.
+func (r *Recorder) Record(cluster string, err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	since, running := r.begun[cluster]
+	if !running {
+		since = r.last
+	}
+	delete(r.begun, cluster)
+	r.last = now
+
+	result := ClusterResult{Cluster: cluster, Status: "ok", DurationMs: now.Sub(since).Milliseconds()}
+	if err != nil {
+		result.Status = "error"
+		result.Error = err.Error()
+		r.failed[cluster] = true
+	}
+	r.entry.Results = append(r.entry.Results, result)
+	r.entry.Clusters = appendCluster(r.entry.Clusters, cluster)
+	r.report()
+}
+
+// AddUndo stores a step that reverses part of the recorded operation
+func (r *Recorder) AddUndo(step UndoStep) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entry.Undo = append(r.entry.Undo, step)
+}
+
+// Finish completes the entry with the overall error of the command
+func (r *Recorder) Finish(err error) Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		r.entry.Error = err.Error()
+	}
+	r.entry.DurationMs = r.now().Sub(r.started).Milliseconds()
+	return r.entry
+}
+
+// Path returns the location of the local audit log
+func Path() string {
+	if p := os.Getenv(EnvAuditLog); p != "" {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".kube", "kubectl-multi-audit.jsonl")
+	}
+	return filepath.Join(home, ".kube", "kubectl-multi-audit.jsonl")
+}
+
+// Append writes one entry as a JSON line to the local audit log
+func Append(entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %v", err)
+	}
+	path := Path()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %v", err)
+	}
+	// Parallel invocations append to the same log; the lock keeps their
+	// lines whole on systems without atomic appends, such as Windows
+	unlock, err := platform.LockFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log %s: %v", path, err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log %s: %v", path, err)
+	}
+	return nil
+}
+
+// Read returns every entry of the local audit log, oldest first. Lines that
+// cannot be decoded are skipped.
+func Read() ([]Entry, error) {
+	f, err := os.Open(Path())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open audit log %s: %v", Path(), err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return entries, fmt.Errorf("failed to read audit log %s: %v", Path(), err)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+	return entries, nil
+}
+
+// Find returns the entry with the given operation ID
+func Find(id string) (*Entry, error) {
+	entries, err := Read()
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		if entries[i].ID == id {
+			return &entries[i], nil
+		}
+	}
+	return nil, fmt.Errorf("operation %q not found in audit log %s", id, Path())
+}
+
+// redactedFlags take values that may carry credentials or other secrets
+var redactedFlags = map[string]bool{
+	"--env":             true,
+	"--overrides":       true,
+	"--from-literal":    true,
+	"--set":             true,
+	"--set-string":      true,
+	"--set-json":        true,
+	"--var":             true,
+	"--token":           true,
+	"--password":        true,
+	"--client-key-data": true,
+}
+
+// Redacted replaces a sensitive flag value in the audit trail
+const Redacted = "REDACTED"
+
+// RedactArgs returns a copy of args with the values of sensitive flags
+// replaced. For KEY=VALUE values only VALUE is hidden, so the history still
+// shows which keys were set.
+func RedactArgs(args []string) []string {
+	redacted := make([]string, len(args))
+	redactNext := false
+	for i, arg := range args {
+		if redactNext {
+			redacted[i] = redactValue(arg)
+			redactNext = false
+			continue
+		}
+		redacted[i] = arg
+		if arg == "--" {
+			// Arguments after -- belong to the container or chart, not to the plugin
+			copy(redacted[i+1:], args[i+1:])
+			break
+		}
+		name, value, hasValue := strings.Cut(arg, "=")
+		if !redactedFlags[name] {
+			continue
+		}
+		if hasValue {
+			redacted[i] = name + "=" + redactValue(value)
+		} else {
+			redactNext = true
+		}
+	}
+	return redacted
+}
+
+// Shared returns a copy of the entry fit for storage others can read, such
+// as the audit ConfigMap: the contents of the Secrets captured for undo are
+// replaced with Redacted, keeping their keys. Undoing a Secret change
+// therefore needs the local audit log, which holds the entry unredacted.
+func (e Entry) Shared() Entry {
+	shared := e
+	shared.Undo = make([]UndoStep, len(e.Undo))
+	for i, step := range e.Undo {
+		if step.Object != nil && isSecret(step) {
+			step.Object = redactSecret(step.Object)
+		}
+		shared.Undo[i] = step
+	}
+	if e.Undo == nil {
+		shared.Undo = nil
+	}
+	return shared
+}
+
+func isSecret(step UndoStep) bool {
+	return step.Group == "" && step.Resource == "secrets" || step.Object["kind"] == "Secret"
+}
+
+// redactSecret returns a copy of a Secret with its values redacted and
+// without the last-applied annotation, which repeats them
+func redactSecret(obj map[string]interface{}) map[string]interface{} {
+	redacted := runtime.DeepCopyJSON(obj)
+	for _, field := range []string{"data", "stringData"} {
+		values, ok := redacted[field].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for key := range values {
+			values[key] = Redacted
+		}
+	}
+	if metadata, ok := redacted["metadata"].(map[string]interface{}); ok {
+		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+			delete(annotations, lastAppliedAnnotation)
+		}
+	}
+	return redacted
+}
+
+// lastAppliedAnnotation is where kubectl apply keeps the applied object
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// redactValue hides a flag value, keeping the key of a KEY=VALUE pair
+func redactValue(value string) string {
+	if key, _, ok := strings.Cut(value, "="); ok && key != "" && !strings.ContainsAny(key, "{[\"") {
+		return key + "=" + Redacted
+	}
+	return Redacted
+}
+
+// PruneEntries removes the oldest entries from data, which maps operation
+// IDs to encoded entries, until at most maxEntries remain and their total
+// size is within maxBytes. A limit of zero or less is not enforced. It
+// returns the removed IDs, oldest first.
+func PruneEntries(data map[string]string, maxEntries, maxBytes int) []string {
+	ids := make([]string, 0, len(data))
+	size := 0
+	for id, v := range data {
+		ids = append(ids, id)
+		size += len(id) + len(v)
+	}
+	sort.Slice(ids, func(i, j int) bool { return idTime(ids[i]) < idTime(ids[j]) })
+
+	var removed []string
+	for _, id := range ids {
+		overCount := maxEntries > 0 && len(data) > maxEntries
+		overSize := maxBytes > 0 && size > maxBytes
+		if !overCount && !overSize {
+			break
+		}
+		size -= len(id) + len(data[id])
+		delete(data, id)
+		removed = append(removed, id)
+	}
+	return removed
+}
+
+// idTime recovers the creation time encoded in an operation ID; IDs that are
+// not ours sort first so they are pruned before real entries
+func idTime(id string) int64 {
+	n, err := strconv.ParseInt(id, 36, 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "unknown"
+}
+
+func appendCluster(clusters []string, name string) []string {
+	for _, c := range clusters {
+		if c == name {
+			return clusters
+		}
+	}
+	return append(clusters, name)
+}
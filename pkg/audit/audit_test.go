@@ -0,0 +1,212 @@
+package audit
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRedactArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{name: "nothing sensitive", args: []string{"apply", "-f", "app.yaml"}, want: []string{"apply", "-f", "app.yaml"}},
+		{
+			name: "separate values",
+			args: []string{"run", "web", "--image", "nginx", "--env", "DB_PASSWORD=hunter2", "--overrides", `{"spec":{}}`},
+			want: []string{"run", "web", "--image", "nginx", "--env", "DB_PASSWORD=REDACTED", "--overrides", "REDACTED"},
+		},
+		{
+			name: "inline values",
+			args: []string{"helm", "install", "--set=auth.password=s3cret", "--var", "region", "--token=abc"},
+			want: []string{"helm", "install", "--set=auth.password=REDACTED", "--var", "REDACTED", "--token=REDACTED"},
+		},
+		{
+			name: "json with equals",
+			args: []string{"--overrides", `{"a":"b=c"}`},
+			want: []string{"--overrides", "REDACTED"},
+		},
+		{
+			name: "after double dash",
+			args: []string{"run", "job", "--", "--password", "plain"},
+			want: []string{"run", "job", "--", "--password", "plain"},
+		},
+		{name: "trailing flag", args: []string{"--env"}, want: []string{"--env"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orig := append([]string{}, tt.args...)
+			got := RedactArgs(tt.args)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RedactArgs() = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(tt.args, orig) {
+				t.Errorf("RedactArgs() modified its input: %q", tt.args)
+			}
+		})
+	}
+}
+
+func TestPruneEntries(t *testing.T) {
+	id := func(n int64) string { return strconv.FormatInt(n, 36) }
+	entries := func() map[string]string {
+		return map[string]string{
+			id(1000):    "aaaa",
+			id(3000):    "cccc",
+			id(2000):    "bbbb",
+			"not-an-id": "x",
+		}
+	}
+
+	tests := []struct {
+		name       string
+		maxEntries int
+		maxBytes   int
+		want       []string
+	}{
+		{name: "within limits", maxEntries: 10, maxBytes: 1000, want: nil},
+		{name: "no limits", want: nil},
+		{name: "entry limit", maxEntries: 2, want: []string{"not-an-id", id(1000)}},
+		{name: "size limit", maxBytes: 19, want: []string{"not-an-id", id(1000)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := entries()
+			got := PruneEntries(data, tt.maxEntries, tt.maxBytes)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PruneEntries() = %v, want %v", got, tt.want)
+			}
+			for _, removed := range got {
+				if _, ok := data[removed]; ok {
+					t.Errorf("PruneEntries() left %s in data", removed)
+				}
+			}
+		})
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	var nilRec *Recorder
+	nilRec.Record("cluster1", nil)
+	nilRec.AddUndo(UndoStep{Cluster: "cluster1"})
+
+	rec := Start("run", []string{"run", "--env", "KEY=value"})
+	rec.Record("cluster1", nil)
+	rec.Record("cluster2", errors.New("boom"))
+	rec.Record("cluster1", nil)
+	entry := rec.Finish(nil)
+
+	if want := []string{"run", "--env", "KEY=REDACTED"}; !reflect.DeepEqual(entry.Args, want) {
+		t.Errorf("Args = %q, want %q", entry.Args, want)
+	}
+	if want := []string{"cluster1", "cluster2"}; !reflect.DeepEqual(entry.Clusters, want) {
+		t.Errorf("Clusters = %v, want %v", entry.Clusters, want)
+	}
+	if entry.Succeeded() {
+		t.Errorf("Succeeded() = true with a failed cluster")
+	}
+}
+
+func TestAppendRead(t *testing.T) {
+	t.Setenv(EnvAuditLog, t.TempDir()+"/audit.jsonl")
+
+	if entries, err := Read(); err != nil || entries != nil {
+		t.Fatalf("Read() of a missing log = %v, %v", entries, err)
+	}
+	first := Start("apply", nil).Finish(nil)
+	second := Start("delete", nil).Finish(errors.New("failed"))
+	for _, e := range []Entry{second, first} {
+		if err := Append(e); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	entries, err := Read()
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(entries) != 2 || entries[0].ID != first.ID || entries[1].ID != second.ID {
+		t.Fatalf("Read() = %+v, want the two entries oldest first", entries)
+	}
+	found, err := Find(second.ID)
+	if err != nil || found.Error != "failed" {
+		t.Errorf("Find() = %+v, %v", found, err)
+	}
+	if _, err := Find("missing"); err == nil {
+		t.Errorf("Find() of an unknown ID succeeded")
+	}
+}
+
+func TestRecorderProgress(t *testing.T) {
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := Start("apply", nil)
+	r.now = func() time.Time { return clock }
+	r.started, r.last = clock, clock
+
+	var reports []Progress
+	r.Expect(4, func(p Progress) { reports = append(reports, p) })
+
+	// A sequential loop is timed from the previous completion
+	clock = clock.Add(2 * time.Second)
+	r.Record("cluster1", nil)
+	r.Begin("cluster2")
+	clock = clock.Add(4 * time.Second)
+	r.Record("cluster2", errors.New("boom"))
+
+	want := []Progress{
+		{Total: 4, Completed: 1, Elapsed: 2 * time.Second, Remaining: 6 * time.Second},
+		{Total: 4, Completed: 1, InFlight: 1, Elapsed: 2 * time.Second, Remaining: 6 * time.Second},
+		{Total: 4, Completed: 2, Failed: 1, Elapsed: 6 * time.Second, Remaining: 6 * time.Second},
+	}
+	if !reflect.DeepEqual(reports, want) {
+		t.Errorf("progress reports = %+v, want %+v", reports, want)
+	}
+
+	clock = clock.Add(time.Second)
+	entry := r.Finish(nil)
+	if entry.DurationMs != 7000 || entry.Results[0].DurationMs != 2000 || entry.Results[1].DurationMs != 4000 {
+		t.Errorf("durations = %d, %+v", entry.DurationMs, entry.Results)
+	}
+}
+
+func TestEntryShared(t *testing.T) {
+	secret := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata": map[string]interface{}{
+			"name":        "db",
+			"annotations": map[string]interface{}{lastAppliedAnnotation: `{"data":{"password":"aHVudGVyMg=="}}`, "team": "web"},
+		},
+		"data":       map[string]interface{}{"password": "aHVudGVyMg=="},
+		"stringData": map[string]interface{}{"user": "admin"},
+	}
+	configMap := map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap", "data": map[string]interface{}{"mode": "fast"}}
+	e := Entry{ID: "1", Undo: []UndoStep{
+		{Action: UndoRestore, Version: "v1", Resource: "secrets", Name: "db", Object: secret},
+		{Action: UndoRestore, Version: "v1", Resource: "configmaps", Name: "settings", Object: configMap},
+		{Action: UndoDelete, Version: "v1", Resource: "secrets", Name: "new"},
+	}}
+
+	shared := e.Shared()
+	got := shared.Undo[0].Object
+	want := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   map[string]interface{}{"name": "db", "annotations": map[string]interface{}{"team": "web"}},
+		"data":       map[string]interface{}{"password": Redacted},
+		"stringData": map[string]interface{}{"user": Redacted},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("shared secret = %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(shared.Undo[1:], e.Undo[1:]) {
+		t.Errorf("shared steps = %+v, want the other steps unchanged", shared.Undo[1:])
+	}
+	if secret["data"].(map[string]interface{})["password"] != "aHVudGVyMg==" {
+		t.Errorf("Shared() changed the entry's own secret: %v", secret)
+	}
+}
@@ -0,0 +1,50 @@
+package cluster
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/disk"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/homedir"
+)
+
+// discoveryCacheTTL matches kubectl, so both share fresh cache entries
+const discoveryCacheTTL = 6 * time.Hour
+
+// illegalCacheDirChars matches the characters kubectl replaces in the
+// per-server cache directory name
+var illegalCacheDirChars = regexp.MustCompile(`[^(\w/.)]`)
+
+// newCachedDiscoveryClient returns a discovery client that keeps API
+// resources in memory for the process and on disk, in the same
+// ~/.kube/cache (or $KUBECACHEDIR) layout as kubectl, across runs.
+func newCachedDiscoveryClient(restCfg *rest.Config) (discovery.CachedDiscoveryInterface, error) {
+	cacheDir := cacheBaseDir()
+	diskClient, err := disk.NewCachedDiscoveryClientForConfig(rest.CopyConfig(restCfg),
+		DiscoveryCacheDir(cacheDir, restCfg.Host), filepath.Join(cacheDir, "http"), discoveryCacheTTL)
+	if err != nil {
+		return nil, err
+	}
+	return memory.NewMemCacheClient(diskClient), nil
+}
+
+// cacheBaseDir is $KUBECACHEDIR, or ~/.kube/cache like kubectl
+func cacheBaseDir() string {
+	if dir := os.Getenv("KUBECACHEDIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(homedir.HomeDir(), ".kube", "cache")
+}
+
+// DiscoveryCacheDir returns the discovery cache directory of an API server
+// below cacheDir, named after its host the way kubectl names it
+func DiscoveryCacheDir(cacheDir, host string) string {
+	schemeless := strings.Replace(strings.Replace(host, "https://", "", 1), "http://", "", 1)
+	return filepath.Join(cacheDir, "discovery", illegalCacheDirChars.ReplaceAllString(schemeless, "_"))
+}
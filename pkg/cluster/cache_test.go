@@ -0,0 +1,60 @@
+package cluster
+
+import (
+	"path/filepath"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestDiscoveryCacheDir(t *testing.T) {
+	tests := []struct {
+		name string
+		host string
+		want string
+	}{
+		{name: "https host and port", host: "https://127.0.0.1:6443", want: "127.0.0.1_6443"},
+		{name: "http", host: "http://localhost:8080", want: "localhost_8080"},
+		{name: "path kept", host: "https://gw.example.com/clusters/its1", want: "gw.example.com/clusters/its1"},
+		{name: "ipv6", host: "https://[::1]:6443", want: "___1__6443"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := filepath.Join("/cache", "discovery", tt.want)
+			if got := DiscoveryCacheDir("/cache", tt.host); got != want {
+				t.Errorf("DiscoveryCacheDir(%q) = %q, want %q", tt.host, got, want)
+			}
+		})
+	}
+}
+
+func TestClusterInfoMapper(t *testing.T) {
+	disc := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: []*metav1.APIResourceList{{
+		GroupVersion: "apps/v1",
+		APIResources: []metav1.APIResource{{Name: "deployments", Kind: "Deployment", Namespaced: true}},
+	}}}}
+	tests := []struct {
+		name    string
+		gvk     schema.GroupVersionKind
+		want    string
+		wantErr bool
+	}{
+		{name: "served kind", gvk: schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, want: "deployments"},
+		{name: "unknown kind", gvk: schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}, wantErr: true},
+	}
+	mapper := ClusterInfo{DiscoveryClient: disc}.Mapper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapping, err := mapper.RESTMapping(tt.gvk.GroupKind(), tt.gvk.Version)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RESTMapping() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && mapping.Resource.Resource != tt.want {
+				t.Errorf("RESTMapping() resource = %q, want %q", mapping.Resource.Resource, tt.want)
+			}
+		})
+	}
+}
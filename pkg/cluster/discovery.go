@@ -3,63 +3,131 @@ package cluster
 import (
 	"context"
 	"fmt"
+	"os"
+	"path"
 	"sort"
 	"strings"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/clientcmd"
+
+	"kubectl-multi/pkg/network"
+	"kubectl-multi/pkg/telemetry"
 )
 
+// ManagedClusterGVR identifies the OCM ManagedCluster resource served by an ITS
+var ManagedClusterGVR = schema.GroupVersionResource{
+	Group:    "cluster.open-cluster-management.io",
+	Version:  "v1",
+	Resource: "managedclusters",
+}
+
 // ClusterInfo contains information about a discovered cluster
 type ClusterInfo struct {
 	Name            string
 	Context         string
-	Client          *kubernetes.Clientset
+	Client          kubernetes.Interface
 	DynamicClient   dynamic.Interface
 	DiscoveryClient discovery.DiscoveryInterface
 	RestConfig      *rest.Config
+	// RESTMapper resolves kinds and resources from the cached discovery
+	// client; it is shared by every handler working on the cluster
+	RESTMapper meta.RESTMapper
+	// ITS is the context of the ITS holding the cluster's ManagedCluster;
+	// empty for the local cluster
+	ITS string
 }
 
 // DiscoverClusters finds all clusters including the local cluster and managed clusters
-func DiscoverClusters(kubeconfig, remoteCtx string) ([]ClusterInfo, error) {
+func DiscoverClusters(ctx context.Context, kubeconfig, remoteCtx string) ([]ClusterInfo, error) {
+	var itsContexts []string
+	if remoteCtx != "" {
+		itsContexts = []string{remoteCtx}
+	}
+	return DiscoverClustersFromITSes(ctx, kubeconfig, itsContexts, "")
+}
+
+// DiscoverClustersFromITSes merges the managed clusters of several ITSes and
+// adds the local cluster. A cluster name registered in more than one ITS is
+// reported as ITS/NAME and reached through a kubeconfig context of that name
+// (or ITS-NAME); without one the cluster is skipped with a warning.
+//
+// When none of the ITSes serves the ManagedCluster resource - OCM is not
+// installed - and contextPattern is not empty, the fleet is instead every
+// kubeconfig context matching the contextPattern glob, listed on stderr.
+func DiscoverClustersFromITSes(ctx context.Context, kubeconfig string, itsContexts []string, contextPattern string) ([]ClusterInfo, error) {
 	var clusters []ClusterInfo
 
 	// Add managed clusters first (excluding WDS clusters)
-	if remoteCtx != "" {
-		managedClusters, err := listManagedClusters(kubeconfig, remoteCtx)
+	var inventories []ITSInventory
+	missingCRD := 0
+	for _, its := range itsContexts {
+		managedClusters, err := listManagedClusters(ctx, kubeconfig, its)
 		if err != nil {
-			fmt.Printf("Warning: could not list managed clusters: %v\n", err)
-		} else {
-			for _, mcName := range managedClusters {
-				// Skip WDS clusters - they are for workflow staging, not workload execution
-				if isWDSCluster(mcName) {
+			if IsManagedClusterCRDMissing(err) {
+				missingCRD++
+				if contextPattern != "" {
 					continue
 				}
+				fmt.Fprintf(os.Stderr, "Warning: ITS %s has no ManagedCluster resource; set --context-pattern to use kubeconfig contexts as the fleet instead\n", its)
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "Warning: could not list managed clusters of ITS %s: %v\n", its, err)
+			continue
+		}
+		inventories = append(inventories, ITSInventory{ITS: its, Clusters: managedClusters})
+	}
+	if contextPattern != "" && missingCRD > 0 && missingCRD == len(itsContexts) {
+		fallback, err := DiscoverClustersFromContexts(kubeconfig, contextPattern)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(os.Stderr, "Warning: no ManagedCluster resource on ITS %s; using the kubeconfig contexts matching %q as the fleet: %s\n",
+			strings.Join(itsContexts, ","), contextPattern, clusterContextList(fallback))
+		return fallback, nil
+	}
 
-				// Use the managed cluster name as the context, not remoteCtx
-				_, _, cs, dyn, disc, restCfg := buildClusterClient(kubeconfig, mcName)
-				if cs != nil { // Only add if we can connect
-					clusters = append(clusters, ClusterInfo{
-						Name:            mcName,
-						Context:         mcName, // Use mcName as context, not remoteCtx
-						Client:          cs,
-						DynamicClient:   dyn,
-						DiscoveryClient: disc,
-						RestConfig:      restCfg,
-					})
-				}
+	contexts := KubeconfigContexts(kubeconfig)
+	for _, ref := range MergeInventories(inventories) {
+		contextName := ref.Cluster
+		if ref.Name != ref.Cluster {
+			contextName = qualifiedContext(contexts, ref.ITS, ref.Cluster)
+			if contextName == "" {
+				fmt.Fprintf(os.Stderr, "Warning: cluster %s is registered in several ITSes; add a kubeconfig context named %s to reach it\n", ref.Cluster, ref.Name)
+				continue
 			}
 		}
+
+		// Use the managed cluster's context, not the ITS context
+		_, _, cs, dyn, disc, restCfg := buildClusterClient(kubeconfig, contextName)
+		if cs != nil { // Only add if we can connect
+			clusters = append(clusters, ClusterInfo{
+				Name:            ref.Name,
+				Context:         contextName,
+				Client:          cs,
+				DynamicClient:   dyn,
+				DiscoveryClient: disc,
+				RestConfig:      restCfg,
+				RESTMapper:      newRESTMapper(disc),
+				ITS:             ref.ITS,
+			})
+		}
 	}
 
 	// Add local cluster (ITS cluster) - but check if it's not already included
 	localCtx, localCluster, localClient, localDynamic, localDiscovery, localRestConfig := buildClusterClient(kubeconfig, "")
-	if localClient != nil && !isWDSCluster(localCluster) {
+	if localClient != nil && !IsWDSCluster(localCluster) {
 		// Check if this cluster is already in the list (avoid duplicates)
 		found := false
 		for _, cluster := range clusters {
@@ -76,6 +144,7 @@ func DiscoverClusters(kubeconfig, remoteCtx string) ([]ClusterInfo, error) {
 				DynamicClient:   localDynamic,
 				DiscoveryClient: localDiscovery,
 				RestConfig:      localRestConfig,
+				RESTMapper:      newRESTMapper(localDiscovery),
 			})
 		}
 	}
@@ -83,8 +152,134 @@ func DiscoverClusters(kubeconfig, remoteCtx string) ([]ClusterInfo, error) {
 	return clusters, nil
 }
 
-// isWDSCluster checks if a cluster name indicates it's a Workload Description Space cluster
-func isWDSCluster(clusterName string) bool {
+// DiscoverClustersFromContexts treats every kubeconfig context matching the
+// glob pattern, other than WDS contexts, as a cluster of the fleet, named
+// after its context. Contexts that cannot be reached are skipped.
+func DiscoverClustersFromContexts(kubeconfig, pattern string) ([]ClusterInfo, error) {
+	names, err := MatchContexts(KubeconfigContexts(kubeconfig), pattern)
+	if err != nil {
+		return nil, err
+	}
+	var clusters []ClusterInfo
+	for _, name := range names {
+		_, _, cs, dyn, disc, restCfg := buildClusterClient(kubeconfig, name)
+		if cs == nil {
+			continue
+		}
+		clusters = append(clusters, ClusterInfo{
+			Name:            name,
+			Context:         name,
+			Client:          cs,
+			DynamicClient:   dyn,
+			DiscoveryClient: disc,
+			RestConfig:      restCfg,
+			RESTMapper:      newRESTMapper(disc),
+		})
+	}
+	return clusters, nil
+}
+
+// clusterContextList joins the contexts of clusters for messages, or says
+// there are none
+func clusterContextList(clusters []ClusterInfo) string {
+	if len(clusters) == 0 {
+		return "none"
+	}
+	contexts := make([]string, 0, len(clusters))
+	for _, c := range clusters {
+		contexts = append(contexts, c.Context)
+	}
+	return strings.Join(contexts, ", ")
+}
+
+// MatchContexts returns the sorted context names matching the glob pattern,
+// leaving out the WDS contexts
+func MatchContexts(contexts map[string]bool, pattern string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid context pattern %q: %v", pattern, err)
+	}
+	var names []string
+	for name := range contexts {
+		if matched, _ := path.Match(pattern, name); matched && !IsWDSCluster(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// IsManagedClusterCRDMissing reports whether err comes from an ITS that does
+// not serve the ManagedCluster resource at all
+func IsManagedClusterCRDMissing(err error) bool {
+	return apierrors.IsNotFound(err) || meta.IsNoMatchError(err)
+}
+
+// ITSInventory is the managed cluster names registered in one ITS
+type ITSInventory struct {
+	ITS      string
+	Clusters []string
+}
+
+// ManagedClusterRef is one managed cluster of a merged inventory. Name is
+// the cluster name, or ITS/CLUSTER when the name is registered in several ITSes.
+type ManagedClusterRef struct {
+	ITS     string
+	Cluster string
+	Name    string
+}
+
+// MergeInventories merges the inventories of several ITSes in order,
+// qualifying the names that appear in more than one ITS
+func MergeInventories(inventories []ITSInventory) []ManagedClusterRef {
+	seen := map[string]int{}
+	for _, inv := range inventories {
+		for _, name := range inv.Clusters {
+			seen[name]++
+		}
+	}
+	var refs []ManagedClusterRef
+	for _, inv := range inventories {
+		for _, name := range inv.Clusters {
+			ref := ManagedClusterRef{ITS: inv.ITS, Cluster: name, Name: name}
+			if seen[name] > 1 {
+				ref.Name = inv.ITS + "/" + name
+			}
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// qualifiedContext returns the kubeconfig context of a cluster whose name is
+// registered in several ITSes, or "" when there is none
+func qualifiedContext(contexts map[string]bool, its, name string) string {
+	for _, candidate := range []string{its + "/" + name, its + "-" + name} {
+		if contexts[candidate] {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// KubeconfigContexts returns the set of context names in the kubeconfig
+func KubeconfigContexts(kubeconfig string) map[string]bool {
+	loading := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfig != "" {
+		loading.ExplicitPath = kubeconfig
+	}
+	rawCfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loading, &clientcmd.ConfigOverrides{}).RawConfig()
+	if err != nil {
+		return nil
+	}
+	contexts := make(map[string]bool, len(rawCfg.Contexts))
+	for name := range rawCfg.Contexts {
+		contexts[name] = true
+	}
+	return contexts
+}
+
+// IsWDSCluster checks if a cluster name indicates it's a Workload Description Space cluster
+func IsWDSCluster(clusterName string) bool {
 	// WDS clusters typically have names like "wds1", "wds2", etc.
 	// or contain "wds" in their name
 	lowerName := strings.ToLower(clusterName)
@@ -105,71 +300,102 @@ func buildClusterClient(kcfg, ctxOverride string) (string, string, *kubernetes.C
 	cfg := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loading, overrides)
 	rawCfg, err := cfg.RawConfig()
 	if err != nil {
-		fmt.Printf("Warning: failed to load kubeconfig: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Warning: failed to load kubeconfig: %v\n", err)
 		return "", "", nil, nil, nil, nil
 	}
 
+	ctxName := rawCfg.CurrentContext
+	if ctxOverride != "" {
+		ctxName = ctxOverride
+	}
+	clusterName := "<unknown>"
+	if ctx, ok := rawCfg.Contexts[ctxName]; ok {
+		clusterName = ctx.Cluster
+	}
+
 	restCfg, err := cfg.ClientConfig()
 	if err != nil {
-		fmt.Printf("Warning: failed to create rest config: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Warning: failed to create rest config: %v\n", err)
 		return "", "", nil, nil, nil, nil
 	}
 
+	// Some clusters are only reachable through the proxy configured for them
+	if proxy, ok := network.ProxyFor(ctxName); ok {
+		restCfg.Proxy = network.ProxyFunc(proxy)
+	}
+
+	// Attribute API calls to the cluster name the commands report
+	telemetryName := clusterName
+	if ctxOverride != "" {
+		telemetryName = ctxOverride
+	}
+	if wrap := telemetry.WrapTransport(telemetryName); wrap != nil {
+		restCfg.Wrap(wrap)
+	}
+
 	cs, err := kubernetes.NewForConfig(restCfg)
 	if err != nil {
-		fmt.Printf("Warning: failed to create kubernetes client: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Warning: failed to create kubernetes client: %v\n", err)
 		return "", "", nil, nil, nil, nil
 	}
 
 	dyn, err := dynamic.NewForConfig(restCfg)
 	if err != nil {
-		fmt.Printf("Warning: failed to create dynamic client: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Warning: failed to create dynamic client: %v\n", err)
 		return "", "", nil, nil, nil, nil
 	}
 
-	disc, err := discovery.NewDiscoveryClientForConfig(restCfg)
+	disc, err := newCachedDiscoveryClient(restCfg)
 	if err != nil {
-		fmt.Printf("Warning: failed to create discovery client: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Warning: failed to create discovery client: %v\n", err)
 		return "", "", nil, nil, nil, nil
 	}
 
-	ctxName := rawCfg.CurrentContext
-	clusterName := "<unknown>"
-	if ctx, ok := rawCfg.Contexts[ctxName]; ok {
-		clusterName = ctx.Cluster
-	}
-
 	return ctxName, clusterName, cs, dyn, disc, restCfg
 }
 
-// listManagedClusters discovers KubeStellar managed clusters
-func listManagedClusters(kubeconfig, remoteCtx string) ([]string, error) {
-	_, _, _, dyn, _, _ := buildClusterClient(kubeconfig, remoteCtx)
-	if dyn == nil {
-		return nil, fmt.Errorf("failed to create dynamic client for remote context %s", remoteCtx)
+// Mapper returns the cluster's shared RESTMapper, or one built from its
+// discovery client when the cluster was assembled without one
+func (c ClusterInfo) Mapper() meta.RESTMapper {
+	if c.RESTMapper != nil {
+		return c.RESTMapper
 	}
+	return newRESTMapper(c.DiscoveryClient)
+}
 
-	gvr := schema.GroupVersionResource{
-		Group:    "cluster.open-cluster-management.io",
-		Version:  "v1",
-		Resource: "managedclusters",
+// newRESTMapper returns a RESTMapper that loads the API resources from the
+// discovery client on first use and reloads them when a kind is not found
+// in a stale cache
+func newRESTMapper(disc discovery.DiscoveryInterface) meta.RESTMapper {
+	cached, ok := disc.(discovery.CachedDiscoveryInterface)
+	if !ok {
+		cached = memory.NewMemCacheClient(disc)
 	}
+	return restmapper.NewDeferredDiscoveryRESTMapper(cached)
+}
 
-	mcs, err := dyn.Resource(gvr).List(context.TODO(), metav1.ListOptions{})
+// listManagedClusters discovers KubeStellar managed clusters
+func listManagedClusters(ctx context.Context, kubeconfig, remoteCtx string) ([]string, error) {
+	mcs, err := ListManagedClusterObjects(ctx, kubeconfig, remoteCtx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list managed clusters: %v", err)
+		return nil, err
 	}
+	return managedClusterNames(mcs), nil
+}
 
+// managedClusterNames returns the sorted names of the ManagedClusters,
+// leaving out the WDS entries
+func managedClusterNames(mcs []unstructured.Unstructured) []string {
 	var clusters []string
-	for _, mc := range mcs.Items {
+	for _, mc := range mcs {
 		clusterName := mc.GetName()
 		// Filter out WDS clusters at the discovery level too
-		if !isWDSCluster(clusterName) {
+		if !IsWDSCluster(clusterName) {
 			clusters = append(clusters, clusterName)
 		}
 	}
 	sort.Strings(clusters)
-	return clusters, nil
+	return clusters
 }
 
 // GetTargetNamespace determines the target namespace for operations
@@ -181,3 +407,138 @@ func GetTargetNamespace(namespace string) string {
 	// For now, default to "default"
 	return "default"
 }
+
+// SelectClusters narrows the discovered clusters down to the given names.
+// An empty selection keeps every cluster.
+func SelectClusters(clusters []ClusterInfo, names []string) ([]ClusterInfo, error) {
+	if len(names) == 0 {
+		return clusters, nil
+	}
+
+	byName := make(map[string]ClusterInfo, len(clusters))
+	for _, c := range clusters {
+		byName[c.Name] = c
+	}
+
+	var selected []ClusterInfo
+	for _, name := range names {
+		c, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("cluster %q is not a discovered managed cluster", name)
+		}
+		selected = append(selected, c)
+	}
+	return selected, nil
+}
+
+// ClientForContext builds a ClusterInfo for a single kubeconfig context, such
+// as an ITS or WDS control plane that is not part of the managed cluster list.
+func ClientForContext(kubeconfig, contextName string) (*ClusterInfo, error) {
+	ctxName, _, cs, dyn, disc, restCfg := buildClusterClient(kubeconfig, contextName)
+	if cs == nil {
+		return nil, fmt.Errorf("failed to build clients for context %s", contextName)
+	}
+	return &ClusterInfo{
+		Name:            ctxName,
+		Context:         ctxName,
+		Client:          cs,
+		DynamicClient:   dyn,
+		DiscoveryClient: disc,
+		RestConfig:      restCfg,
+		RESTMapper:      newRESTMapper(disc),
+	}, nil
+}
+
+// ClientForConfig builds a ClusterInfo for a cluster reached with restCfg
+// instead of a kubeconfig context, such as through a local proxy
+func ClientForConfig(name, contextName string, restCfg *rest.Config) (*ClusterInfo, error) {
+	if wrap := telemetry.WrapTransport(name); wrap != nil {
+		restCfg.Wrap(wrap)
+	}
+	cs, err := kubernetes.NewForConfig(restCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client for %s: %v", name, err)
+	}
+	dyn, err := dynamic.NewForConfig(restCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client for %s: %v", name, err)
+	}
+	disc, err := newCachedDiscoveryClient(restCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create discovery client for %s: %v", name, err)
+	}
+	return &ClusterInfo{
+		Name:            name,
+		Context:         contextName,
+		Client:          cs,
+		DynamicClient:   dyn,
+		DiscoveryClient: disc,
+		RestConfig:      restCfg,
+		RESTMapper:      newRESTMapper(disc),
+	}, nil
+}
+
+// ListManagedClusterObjects returns the full ManagedCluster objects held by the
+// ITS, including the WDS entries that cluster discovery filters out.
+func ListManagedClusterObjects(ctx context.Context, kubeconfig, remoteCtx string) ([]unstructured.Unstructured, error) {
+	_, _, _, dyn, _, _ := buildClusterClient(kubeconfig, remoteCtx)
+	if dyn == nil {
+		return nil, fmt.Errorf("failed to create dynamic client for remote context %s", remoteCtx)
+	}
+	return ListManagedClusters(ctx, dyn)
+}
+
+// ListManagedClusters returns the ManagedCluster objects served by the ITS
+// client dyn, sorted by name
+func ListManagedClusters(ctx context.Context, dyn dynamic.Interface) ([]unstructured.Unstructured, error) {
+	mcs, err := dyn.Resource(ManagedClusterGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list managed clusters: %w", err)
+	}
+	sort.Slice(mcs.Items, func(i, j int) bool { return mcs.Items[i].GetName() < mcs.Items[j].GetName() })
+	return mcs.Items, nil
+}
+
+// SelectClustersByLabels keeps the clusters whose ManagedCluster in their
+// ITS matches the label selector. Clusters without a ManagedCluster, such as
+// the local ITS context, never match. An empty selector keeps every cluster.
+func SelectClustersByLabels(ctx context.Context, clusters []ClusterInfo, kubeconfig, selector string) ([]ClusterInfo, error) {
+	if selector == "" {
+		return clusters, nil
+	}
+	sel, err := labels.Parse(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cluster selector %q: %v", selector, err)
+	}
+
+	matched := map[string]map[string]bool{}
+	for _, c := range clusters {
+		if c.ITS == "" || matched[c.ITS] != nil {
+			continue
+		}
+		mcs, err := ListManagedClusterObjects(ctx, kubeconfig, c.ITS)
+		if err != nil {
+			return nil, err
+		}
+		matched[c.ITS] = make(map[string]bool, len(mcs))
+		for _, mc := range mcs {
+			if sel.Matches(labels.Set(mc.GetLabels())) {
+				matched[c.ITS][mc.GetName()] = true
+			}
+		}
+	}
+
+	var selected []ClusterInfo
+	for _, c := range clusters {
+		if c.ITS != "" && matched[c.ITS][c.ManagedClusterName()] {
+			selected = append(selected, c)
+		}
+	}
+	return selected, nil
+}
+
+// ManagedClusterName returns the name of the cluster's ManagedCluster in its
+// ITS, without the ITS/ prefix added to names registered in several ITSes
+func (c ClusterInfo) ManagedClusterName() string {
+	return strings.TrimPrefix(c.Name, c.ITS+"/")
+}
@@ -0,0 +1,236 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestIsWDSCluster(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"wds1", true},
+		{"WDS2", true},
+		{"kind-wds-1", true},
+		{"edge_wds_east", true},
+		{"cluster1", false},
+		{"its1", false},
+		{"newds", false},
+		{"cluster-wds", false},
+	}
+	for _, tt := range tests {
+		if got := IsWDSCluster(tt.name); got != tt.want {
+			t.Errorf("IsWDSCluster(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMergeInventories(t *testing.T) {
+	tests := []struct {
+		name        string
+		inventories []ITSInventory
+		want        []string
+	}{
+		{
+			name:        "single ITS keeps names",
+			inventories: []ITSInventory{{ITS: "its1", Clusters: []string{"cluster1", "cluster2"}}},
+			want:        []string{"cluster1", "cluster2"},
+		},
+		{
+			name: "distinct names across ITSes",
+			inventories: []ITSInventory{
+				{ITS: "its1", Clusters: []string{"cluster1"}},
+				{ITS: "its2", Clusters: []string{"cluster2"}},
+			},
+			want: []string{"cluster1", "cluster2"},
+		},
+		{
+			name: "same name in two ITSes is qualified",
+			inventories: []ITSInventory{
+				{ITS: "its1", Clusters: []string{"cluster1", "edge"}},
+				{ITS: "its2", Clusters: []string{"cluster1"}},
+			},
+			want: []string{"its1/cluster1", "edge", "its2/cluster1"},
+		},
+		{
+			name: "empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refs := MergeInventories(tt.inventories)
+			var got []string
+			for _, ref := range refs {
+				got = append(got, ref.Name)
+				if ref.Name != ref.Cluster && ref.Name != ref.ITS+"/"+ref.Cluster {
+					t.Errorf("ref %+v: name is neither the cluster nor ITS/cluster", ref)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("MergeInventories() names = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQualifiedContext(t *testing.T) {
+	tests := []struct {
+		name     string
+		contexts map[string]bool
+		want     string
+	}{
+		{name: "slash form", contexts: map[string]bool{"its2/cluster1": true, "its2-cluster1": true}, want: "its2/cluster1"},
+		{name: "dash form", contexts: map[string]bool{"its2-cluster1": true}, want: "its2-cluster1"},
+		{name: "bare name is not enough", contexts: map[string]bool{"cluster1": true}, want: ""},
+		{name: "no kubeconfig", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := qualifiedContext(tt.contexts, "its2", "cluster1"); got != tt.want {
+				t.Errorf("qualifiedContext() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManagedClusterName(t *testing.T) {
+	tests := []struct {
+		name string
+		info ClusterInfo
+		want string
+	}{
+		{name: "plain", info: ClusterInfo{Name: "cluster1", ITS: "its1"}, want: "cluster1"},
+		{name: "qualified", info: ClusterInfo{Name: "its2/cluster1", ITS: "its2"}, want: "cluster1"},
+		{name: "local cluster", info: ClusterInfo{Name: "kind-its1"}, want: "kind-its1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.info.ManagedClusterName(); got != tt.want {
+				t.Errorf("ManagedClusterName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListManagedClusters(t *testing.T) {
+	managedCluster := func(name string) runtime.Object {
+		mc := &unstructured.Unstructured{}
+		mc.SetAPIVersion("cluster.open-cluster-management.io/v1")
+		mc.SetKind("ManagedCluster")
+		mc.SetName(name)
+		return mc
+	}
+	tests := []struct {
+		name      string
+		objects   []runtime.Object
+		fail      bool
+		wantAll   []string
+		wantNames []string
+	}{
+		{name: "empty ITS"},
+		{
+			name:      "sorted, WDS entries only dropped from names",
+			objects:   []runtime.Object{managedCluster("cluster2"), managedCluster("wds1"), managedCluster("cluster1")},
+			wantAll:   []string{"cluster1", "cluster2", "wds1"},
+			wantNames: []string{"cluster1", "cluster2"},
+		},
+		{name: "list error", fail: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listKinds := map[schema.GroupVersionResource]string{ManagedClusterGVR: "ManagedClusterList"}
+			dyn := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, tt.objects...)
+			if tt.fail {
+				dyn.PrependReactor("list", "*", func(clienttesting.Action) (bool, runtime.Object, error) {
+					return true, nil, fmt.Errorf("forbidden")
+				})
+			}
+			mcs, err := ListManagedClusters(context.Background(), dyn)
+			if (err != nil) != tt.fail {
+				t.Fatalf("ListManagedClusters() error = %v, wantErr %v", err, tt.fail)
+			}
+			var all []string
+			for _, mc := range mcs {
+				all = append(all, mc.GetName())
+			}
+			if !reflect.DeepEqual(all, tt.wantAll) {
+				t.Errorf("ListManagedClusters() = %v, want %v", all, tt.wantAll)
+			}
+			if got := managedClusterNames(mcs); !reflect.DeepEqual(got, tt.wantNames) {
+				t.Errorf("managedClusterNames() = %v, want %v", got, tt.wantNames)
+			}
+		})
+	}
+}
+
+func TestMatchContexts(t *testing.T) {
+	contexts := map[string]bool{"kind-cluster2": true, "kind-cluster1": true, "wds1": true, "prod-east": true}
+	tests := []struct {
+		pattern string
+		want    []string
+		wantErr bool
+	}{
+		{pattern: "*", want: []string{"kind-cluster1", "kind-cluster2", "prod-east"}},
+		{pattern: "kind-*", want: []string{"kind-cluster1", "kind-cluster2"}},
+		{pattern: "prod-east", want: []string{"prod-east"}},
+		{pattern: "staging-*"},
+		{pattern: "[", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			got, err := MatchContexts(contexts, tt.pattern)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MatchContexts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MatchContexts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsManagedClusterCRDMissing(t *testing.T) {
+	tests := []struct {
+		name    string
+		listErr error
+		want    bool
+	}{
+		{name: "resource not served", listErr: apierrors.NewNotFound(ManagedClusterGVR.GroupResource(), ""), want: true},
+		{name: "forbidden", listErr: apierrors.NewForbidden(ManagedClusterGVR.GroupResource(), "", fmt.Errorf("denied"))},
+		{name: "connection refused", listErr: fmt.Errorf("connection refused")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listKinds := map[schema.GroupVersionResource]string{ManagedClusterGVR: "ManagedClusterList"}
+			dyn := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds)
+			dyn.PrependReactor("list", "*", func(clienttesting.Action) (bool, runtime.Object, error) {
+				return true, nil, tt.listErr
+			})
+			_, err := ListManagedClusters(context.Background(), dyn)
+			if got := IsManagedClusterCRDMissing(err); got != tt.want {
+				t.Errorf("IsManagedClusterCRDMissing(%v) = %v, want %v", err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClusterContextList(t *testing.T) {
+	if got := clusterContextList(nil); got != "none" {
+		t.Errorf("clusterContextList(nil) = %q, want none", got)
+	}
+	clusters := []ClusterInfo{{Name: "kind-cluster1", Context: "kind-cluster1"}, {Name: "kind-cluster2", Context: "kind-cluster2"}}
+	if got, want := clusterContextList(clusters), "kind-cluster1, kind-cluster2"; got != want {
+		t.Errorf("clusterContextList() = %q, want %q", got, want)
+	}
+}
@@ -2,15 +2,29 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
 
+	"kubectl-multi/pkg/audit"
 	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+	"kubectl-multi/pkg/platform"
 	"kubectl-multi/pkg/util"
 
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
 )
 
 // Custom help function for apply command
@@ -19,7 +33,7 @@ func applyHelpFunc(cmd *cobra.Command, args []string) {
 	cmdInfo, err := util.GetKubectlCommandInfo("apply")
 	if err != nil {
 		// Fallback to default help if kubectl help is not available
-		cmd.Help()
+		defaultHelpFunc(cmd, args)
 		return
 	}
 
@@ -38,10 +52,28 @@ kubectl multi apply -k dir/
 kubectl multi apply -f deployment.yaml --dry-run=client
 
 # Apply resources recursively from a directory
-kubectl multi apply -f dir/ -R`
+kubectl multi apply -f dir/ -R
+
+# Apply to two clusters and write the equivalent BindingPolicy for later use
+kubectl multi apply -f app.yaml --clusters cluster1,cluster2 --emit-policy app-policy.yaml
+
+# Only generate the BindingPolicy and labeled manifests, without applying
+kubectl multi apply -f app.yaml --clusters cluster1 --emit-policy - --emit-only
+
+# Render per-cluster differences such as ingress hostnames from one manifest
+kubectl multi apply -f ingress.yaml --render go-template --var domain=example.com
+
+# Pull a manifest bundle published to a registry once and apply it everywhere
+kubectl multi apply --oci ghcr.io/org/bundle:v1.2.0
+
+# Roll out two clusters at a time, five minutes apart, halting on a failure
+kubectl multi apply -f app.yaml --strategy waves --wave-size 2 --wave-pause 5m
+
+# Apply to cluster1 first and only continue when it succeeded
+kubectl multi apply -f app.yaml --strategy canary --canary cluster1`
 
 	// Multi-cluster usage
-	multiClusterUsage := `kubectl multi apply (-f FILENAME | -k DIRECTORY) [flags]`
+	multiClusterUsage := `kubectl multi apply (-f FILENAME | -k DIRECTORY | --oci REFERENCE) [flags]`
 
 	// Format combined help using the new CommandInfo structure
 	combinedHelp := util.FormatMultiClusterHelp(cmdInfo, multiClusterInfo, multiClusterExamples, multiClusterUsage)
@@ -50,23 +82,82 @@ kubectl multi apply -f dir/ -R`
 
 func newApplyCommand() *cobra.Command {
 	var filename string
+	var kustomize string
+	var ociRef string
 	var recursive bool
 	var dryRun string
+	var forceConflicts bool
+	var force bool
+	var targets clusterTargets
+	var strategy fanOutStrategy
+	var emitPolicy string
+	var policyName string
+	var emitOnly bool
+	var render manifestRender
+	var fallback bool
 
 	cmd := &cobra.Command{
-		Use:   "apply (-f FILENAME | --filename=FILENAME)",
+		Use:   "apply (-f FILENAME | -k DIRECTORY | --oci REFERENCE)",
 		Short: "Apply a configuration to resources across all managed clusters",
 		Long: `Apply a configuration to resources across all managed clusters.
 This command applies manifests to all KubeStellar managed clusters.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			kubeconfig, remoteCtx, _, namespace, allNamespaces := GetGlobalFlags()
-			return handleApplyCommand(filename, recursive, dryRun, kubeconfig, remoteCtx, namespace, allNamespaces)
+			sources := 0
+			for _, s := range []string{filename, kustomize, ociRef} {
+				if s != "" {
+					sources++
+				}
+			}
+			if sources != 1 {
+				return fmt.Errorf("must specify one of -f, -k and --oci")
+			}
+			if kustomize != "" && recursive {
+				return fmt.Errorf("-R cannot be combined with -k")
+			}
+			if ociRef != "" && recursive {
+				return fmt.Errorf("-R cannot be combined with --oci")
+			}
+			if emitOnly && emitPolicy == "" {
+				return fmt.Errorf("--emit-only requires --emit-policy")
+			}
+			if err := util.ValidateDryRun(dryRun); err != nil {
+				return err
+			}
+			if err := render.validate(); err != nil {
+				return err
+			}
+			if err := strategy.validate(); err != nil {
+				return err
+			}
+			if render.enabled() && emitPolicy != "" {
+				return fmt.Errorf("--emit-policy cannot be combined with --render")
+			}
+			var rec *audit.Recorder
+			if (dryRun == "none" || dryRun == "") && !emitOnly {
+				rec = startAudit("apply")
+			}
+			err := handleApplyCommand(filename, kustomize, ociRef, recursive, dryRun, forceConflicts, force, targets, strategy, emitPolicy, policyName, emitOnly, render, fallback, rec, kubeconfig, remoteCtx, namespace, allNamespaces)
+			finishAudit(rec, err)
+			return err
 		},
 	}
 
 	cmd.Flags().StringVarP(&filename, "filename", "f", "", "filename, directory, or URL to files to use to apply the resource")
+	cmd.Flags().StringVarP(&kustomize, "kustomize", "k", "", "process the kustomization directory")
+	cmd.Flags().StringVar(&ociRef, "oci", "", "pull a bundle of manifests stored as an OCI artifact, such as ghcr.io/org/bundle:tag")
 	cmd.Flags().BoolVarP(&recursive, "recursive", "R", false, "process the directory used in -f, --filename recursively")
 	cmd.Flags().StringVar(&dryRun, "dry-run", "none", "must be \"none\", \"server\", or \"client\"")
+	cmd.Flags().BoolVar(&forceConflicts, "force-conflicts", false, "take ownership of fields another field manager set instead of failing with a conflict")
+	cmd.Flags().BoolVar(&force, "force", false, "write objects that KubeStellar downsyncs to the cluster, although the next downsync reverts the change")
+	targets.addFlags(cmd, "target")
+	targets.addPlacementFlag(cmd, "target")
+	strategy.addFlags(cmd)
+	cmd.Flags().StringVar(&emitPolicy, "emit-policy", "", "write an equivalent BindingPolicy and labeled manifests to this file (\"-\" for stdout)")
+	cmd.Flags().StringVar(&policyName, "policy-name", "", "name of the generated BindingPolicy (defaults to the manifest file name)")
+	cmd.Flags().BoolVar(&emitOnly, "emit-only", false, "only write the --emit-policy output, do not apply to clusters")
+	render.addFlags(cmd)
+	addKubectlFallbackFlag(cmd, &fallback)
 
 	// Set custom help function
 	cmd.SetHelpFunc(applyHelpFunc)
@@ -79,15 +170,85 @@ This command applies manifests to all KubeStellar managed clusters.`,
 	return cmd
 }
 
-func handleApplyCommand(filename string, recursive bool, dryRun, kubeconfig, remoteCtx, namespace string, allNamespaces bool) error {
-	clusters, err := cluster.DiscoverClusters(kubeconfig, remoteCtx)
+func handleApplyCommand(filename, kustomize, ociRef string, recursive bool, dryRun string, forceConflicts, force bool, targets clusterTargets, strategy fanOutStrategy, emitPolicy, policyName string, emitOnly bool, render manifestRender, fallback bool, rec *audit.Recorder, kubeconfig, remoteCtx, namespace string, allNamespaces bool) error {
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
 	if err != nil {
 		return fmt.Errorf("failed to discover clusters: %v", err)
 	}
+	clusters, err = targets.selectFrom(clusters, kubeconfig)
+	if err != nil {
+		return err
+	}
 	if len(clusters) == 0 {
 		return fmt.Errorf("no clusters discovered")
 	}
 
+	if emitPolicy != "" {
+		named := filename
+		if kustomize != "" {
+			named = kustomize
+		}
+		if ociRef != "" {
+			ref, err := util.ParseOCIReference(ociRef)
+			if err != nil {
+				return err
+			}
+			named = path.Base(ref.Repository)
+		}
+		policyName, err = resolvePolicyName(named, policyName)
+		if err != nil {
+			return err
+		}
+	}
+
+	// stdin can only be read once, but the policy, undo capture and kubectl
+	// all need the manifests; a kustomization is likewise built only once,
+	// and an OCI artifact pulled only once
+	source := filename
+	if filename == "-" {
+		source = "stdin"
+		spooled, err := util.SpoolStdin()
+		if err != nil {
+			return err
+		}
+		defer os.Remove(spooled)
+		filename = spooled
+	}
+	if kustomize != "" {
+		source = kustomize
+		built, err := util.SpoolKustomization(kustomize)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(built)
+		filename = built
+	}
+	if ociRef != "" {
+		source = ociRef
+		pulled, err := util.SpoolOCIArtifact(ociRef)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(pulled)
+		filename = pulled
+	}
+
+	if emitPolicy != "" {
+		objs, err := util.ReadManifests(filename, recursive)
+		if err != nil {
+			return err
+		}
+		if len(objs) == 0 {
+			return fmt.Errorf("no objects found in %s", source)
+		}
+		if err := emitBindingPolicy(objs, emitPolicy, policyName, namespace, clusters, remoteCtx); err != nil {
+			return err
+		}
+		if emitOnly {
+			return nil
+		}
+	}
+
 	// Find current context from kubeconfig
 	currentContext := ""
 	{
@@ -111,87 +272,229 @@ func handleApplyCommand(filename string, recursive bool, dryRun, kubeconfig, rem
 		contextToCluster[c.Context] = c
 	}
 
-	// 1. Run for current context (if present)
-	if cinfo, ok := contextToCluster[currentContext]; ok {
-		args := []string{"apply", "-f", filename, "--context", cinfo.Context}
-		if recursive {
-			args = append(args, "-R")
-		}
-		if dryRun != "none" && dryRun != "" {
-			args = append(args, "--dry-run="+dryRun)
-		}
-		if namespace != "" {
-			args = append(args, "-n", namespace)
+	// Manifests are read up front so they can be rendered per cluster, so
+	// each cluster's prior state can be captured for undo and checked for
+	// KubeStellar ownership, and so kubectl gets them with the
+	// kubestellar.io/applied-by annotation
+	var sources []util.ManifestSource
+	var clusterLabels map[string]map[string]string
+	if render.enabled() {
+		sources, err = util.ReadManifestSources(filename, recursive)
+		if err != nil {
+			return err
 		}
-		output, err := runKubectl(args, kubeconfig)
-		fmt.Printf("=== Cluster: %s ===\n", cinfo.Context)
+		clusterLabels = managedClusterLabels(kubeconfig, remoteCtx)
+	}
+	var manifestObjs []*unstructured.Unstructured
+	if !render.enabled() {
+		manifestObjs, err = util.ReadManifests(filename, recursive)
 		if err != nil {
-			fmt.Printf("Error: %v\n", err)
-		} else {
-			fmt.Print(output)
+			if !fallback {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Warning: undo information, KubeStellar ownership checks and the %s annotation are skipped: %v\n", util.AppliedByAnnotation, err)
 		}
-		fmt.Println()
 	}
 
-	// 2. Run for KubeStellar clusters (excluding ITS and current)
-	for _, c := range clusters {
-		if c.Context == currentContext || c.Context == itsContext {
-			continue
-		}
-		args := []string{"apply", "-f", filename, "--context", c.Context}
+	radius := blastRadius{Objects: len(manifestObjs) * fanOutTargets(clusters, itsContext), Namespaces: objectNamespaces(manifestObjs, namespace)}
+	if err := guardFanOut(rec, radius, clusters, itsContext); err != nil {
+		return err
+	}
+
+	trackFanOut(rec, fanOutTargets(clusters, itsContext))
+	applyToCluster := func(c cluster.ClusterInfo) error {
+		rec.Begin(c.Name)
+		fileArgs := []string{"-f", filename}
 		if recursive {
-			args = append(args, "-R")
+			fileArgs = append(fileArgs, "-R")
 		}
-		if dryRun != "none" && dryRun != "" {
-			args = append(args, "--dry-run="+dryRun)
+		objs := manifestObjs
+		if render.enabled() {
+			rendered, err := render.renderFor(sources, c.Name, clusterLabels[c.Name])
+			if err == nil {
+				defer os.Remove(rendered)
+				fileArgs = []string{"-f", rendered}
+				objs, err = util.ReadManifests(rendered, false)
+			}
+			if err != nil {
+				rec.Record(c.Name, err)
+				fmt.Printf("=== Cluster: %s ===\nError: %v\n\n", c.Context, err)
+				return err
+			}
 		}
-		if namespace != "" {
-			args = append(args, "-n", namespace)
+		var warnings strings.Builder
+		if objs != nil {
+			if err := guardDownsyncedObjects(c, objs, namespace, force, &warnings); err != nil {
+				rec.Record(c.Name, err)
+				fmt.Printf("=== Cluster: %s ===\nError: %v\n\n", c.Context, err)
+				return err
+			}
+			warnGitOpsNamespaces(c, objs, namespace, &warnings)
+			if err := captureApplyUndo(rec, c, objs, namespace); err != nil {
+				fmt.Printf("Warning: undo information for cluster %s not recorded: %v\n", c.Name, err)
+			}
 		}
-		output, err := runKubectl(args, kubeconfig)
+
+		var output string
+		var err error
+		if fallback {
+			if objs != nil {
+				marked, err := writeManagedManifests(objs)
+				if err != nil {
+					rec.Record(c.Name, err)
+					fmt.Printf("=== Cluster: %s ===\nError: %v\n\n", c.Context, err)
+					return err
+				}
+				defer os.Remove(marked)
+				fileArgs = []string{"-f", marked}
+			}
+			args := append([]string{"apply"}, fileArgs...)
+			args = append(args, "--context", c.Context, "--field-manager", util.FieldManager)
+			if dryRun != "none" && dryRun != "" {
+				args = append(args, "--dry-run="+dryRun)
+			}
+			if forceConflicts {
+				args = append(args, "--server-side", "--force-conflicts")
+			}
+			if namespace != "" {
+				args = append(args, "-n", namespace)
+			}
+			output, err = runKubectl(args, kubeconfig)
+		} else {
+			output, err = applyObjects(c, objs, namespace, dryRun, forceConflicts)
+		}
+		rec.Record(c.Name, err)
 		fmt.Printf("=== Cluster: %s ===\n", c.Context)
+		fmt.Print(warnings.String())
+		fmt.Print(output)
 		if err != nil {
 			fmt.Printf("Error: %v\n", err)
-		} else {
-			fmt.Print(output)
 		}
 		fmt.Println()
+		return err
 	}
 
-	// 3. Print warning for ITS (control) cluster
+	// The current context goes first, then the KubeStellar clusters
+	// (excluding the ITS), wave by wave as the strategy says
+	var ordered []cluster.ClusterInfo
+	if cinfo, ok := contextToCluster[currentContext]; ok {
+		ordered = append(ordered, cinfo)
+	}
+	for _, c := range clusters {
+		if c.Context == currentContext || c.Context == itsContext {
+			continue
+		}
+		ordered = append(ordered, c)
+	}
+	haltErr := strategy.run(ordered, applyToCluster)
+
+	// Print warning for ITS (control) cluster
 	if cinfo, ok := contextToCluster[itsContext]; ok {
 		fmt.Printf("=== Cluster: %s ===\n", cinfo.Context)
 		fmt.Printf("Cannot perform this operation on ITS (control) cluster: %s\n", cinfo.Context)
 		fmt.Println()
 	}
 
-	return nil
+	return haltErr
+}
+
+// applyObjects applies objs to one cluster with the built-in client and
+// returns one kubectl-style result line per object
+func applyObjects(c cluster.ClusterInfo, objs []*unstructured.Unstructured, namespace, dryRun string, force bool) (string, error) {
+	if c.DynamicClient == nil || c.DiscoveryClient == nil {
+		return "", fmt.Errorf("no clients available for cluster %s", c.Name)
+	}
+	mapper := c.Mapper()
+
+	var out strings.Builder
+	failed := 0
+	for _, obj := range objs {
+		line, err := util.ApplyObject(commandContext(), c.DynamicClient, mapper, obj, cluster.GetTargetNamespace(namespace), dryRun, force)
+		if err != nil {
+			fmt.Fprintf(&out, "error: %v\n", err)
+			failed++
+			continue
+		}
+		fmt.Fprintln(&out, line)
+	}
+	if failed > 0 {
+		return out.String(), fmt.Errorf("%d of %d object(s) failed to apply", failed, len(objs))
+	}
+	return out.String(), nil
+}
+
+// writeManagedManifests writes objs, marked with the kubestellar.io/applied-by
+// annotation, to a temporary file for kubectl. The caller removes the file.
+func writeManagedManifests(objs []*unstructured.Unstructured) (string, error) {
+	marked := make([]*unstructured.Unstructured, 0, len(objs))
+	for _, obj := range objs {
+		obj = obj.DeepCopy()
+		util.MarkManaged(obj)
+		marked = append(marked, obj)
+	}
+	data, err := util.EncodeManifests(marked)
+	if err != nil {
+		return "", err
+	}
+	path, err := platform.WriteTemp("kubectl-multi-apply-*.yaml", data)
+	if err != nil {
+		return "", fmt.Errorf("failed to write manifests for kubectl: %v", err)
+	}
+	return path, nil
 }
 
 func newViewLastAppliedCommand() *cobra.Command {
 	var filename string
 	var output string
 	var recursive bool
+	var fallback bool
 
 	cmd := &cobra.Command{
-		Use:   "view-last-applied",
+		Use:   "view-last-applied (TYPE/NAME | TYPE NAME | -f FILENAME)",
 		Short: "View the latest last-applied-configuration annotations across all managed clusters",
 		Long:  `View the latest last-applied-configuration annotations by type/name or file across all KubeStellar managed clusters.`,
+		Example: `# The last applied configuration of a deployment in every cluster
+kubectl multi apply view-last-applied deployment/nginx -n prod
+
+# The same for every object in a manifest, as json
+kubectl multi apply view-last-applied -f nginx.yaml -o json`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			kubeconfig, remoteCtx, _, namespace, allNamespaces := GetGlobalFlags()
-			return handleViewLastAppliedCommand(filename, output, recursive, args, kubeconfig, remoteCtx, namespace, allNamespaces)
+			if output != "yaml" && output != "json" {
+				return fmt.Errorf("--output must be one of yaml|json, got %q", output)
+			}
+			if filename == "" && len(args) == 0 {
+				return fmt.Errorf("expected TYPE/NAME, TYPE NAME or -f FILENAME")
+			}
+			kubeconfig, remoteCtx, _, namespace, _ := GetGlobalFlags()
+			return handleViewLastAppliedCommand(filename, output, recursive, fallback, args, kubeconfig, remoteCtx, namespace)
 		},
 	}
 
 	cmd.Flags().StringVarP(&filename, "filename", "f", "", "Filename, directory, or URL to files that contains the last-applied-configuration annotations")
 	cmd.Flags().StringVarP(&output, "output", "o", "yaml", "Output format. Must be one of yaml|json")
 	cmd.Flags().BoolVarP(&recursive, "recursive", "R", false, "Process the directory used in -f, --filename recursively")
+	addKubectlFallbackFlag(cmd, &fallback)
 
 	return cmd
 }
 
-func handleViewLastAppliedCommand(filename, output string, recursive bool, extraArgs []string, kubeconfig, remoteCtx, namespace string, allNamespaces bool) error {
-	clusters, err := cluster.DiscoverClusters(kubeconfig, remoteCtx)
+func handleViewLastAppliedCommand(filename, output string, recursive, fallback bool, extraArgs []string, kubeconfig, remoteCtx, namespace string) error {
+	var objs []*unstructured.Unstructured
+	var resourceType, name string
+	var err error
+	switch {
+	case fallback:
+	case filename != "":
+		if objs, err = util.ReadManifests(filename, recursive); err != nil {
+			return err
+		}
+	default:
+		if resourceType, name, err = parseTypeName(extraArgs); err != nil {
+			return err
+		}
+	}
+
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
 	if err != nil {
 		return fmt.Errorf("failed to discover clusters: %v", err)
 	}
@@ -199,19 +502,7 @@ func handleViewLastAppliedCommand(filename, output string, recursive bool, extra
 		return fmt.Errorf("no clusters discovered")
 	}
 
-	// Find current context from kubeconfig
-	currentContext := ""
-	{
-		loading := clientcmd.NewDefaultClientConfigLoadingRules()
-		if kubeconfig != "" {
-			loading.ExplicitPath = kubeconfig
-		}
-		cfg := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loading, &clientcmd.ConfigOverrides{})
-		rawCfg, err := cfg.RawConfig()
-		if err == nil {
-			currentContext = rawCfg.CurrentContext
-		}
-	}
+	currentContext := currentContextName(kubeconfig)
 
 	// Identify ITS (control) cluster context
 	itsContext := remoteCtx
@@ -222,62 +513,48 @@ func handleViewLastAppliedCommand(filename, output string, recursive bool, extra
 		contextToCluster[c.Context] = c
 	}
 
-	// 1. Run for current context (if present)
-	if cinfo, ok := contextToCluster[currentContext]; ok {
-		args := []string{"apply", "view-last-applied"}
-		if filename != "" {
-			args = append(args, "-f", filename)
-		}
-		if output != "" {
-			args = append(args, "-o", output)
-		}
-		if recursive {
-			args = append(args, "-R")
-		}
-		if len(extraArgs) > 0 {
+	view := func(c cluster.ClusterInfo) {
+		var cmdOutput string
+		var err error
+		if fallback {
+			args := []string{"apply", "view-last-applied", "-o", output}
+			if filename != "" {
+				args = append(args, "-f", filename)
+			}
+			if recursive {
+				args = append(args, "-R")
+			}
+			if namespace != "" {
+				args = append(args, "-n", namespace)
+			}
 			args = append(args, extraArgs...)
+			args = append(args, "--context", c.Context)
+			cmdOutput, err = runKubectl(args, kubeconfig)
+		} else {
+			cmdOutput, err = viewLastAppliedInCluster(c, objs, resourceType, name, namespace, output)
 		}
-		args = append(args, "--context", cinfo.Context)
-		cmdOutput, err := runKubectl(args, kubeconfig)
-		fmt.Printf("=== Cluster: %s ===\n", cinfo.Context)
+		fmt.Printf("=== Cluster: %s ===\n", c.Context)
+		fmt.Print(cmdOutput)
 		if err != nil {
 			fmt.Printf("Error: %v\n", err)
-		} else {
-			fmt.Print(cmdOutput)
 		}
 		fmt.Println()
 	}
 
+	// 1. Run for current context (if present)
+	if cinfo, ok := contextToCluster[currentContext]; ok && currentContext != itsContext {
+		view(cinfo)
+	}
+
 	// 2. Run for KubeStellar clusters (excluding ITS and current)
 	for _, c := range clusters {
 		if c.Context == currentContext || c.Context == itsContext {
 			continue
 		}
-		args := []string{"apply", "view-last-applied"}
-		if filename != "" {
-			args = append(args, "-f", filename)
-		}
-		if output != "" {
-			args = append(args, "-o", output)
-		}
-		if recursive {
-			args = append(args, "-R")
-		}
-		if len(extraArgs) > 0 {
-			args = append(args, extraArgs...)
-		}
-		args = append(args, "--context", c.Context)
-		cmdOutput, err := runKubectl(args, kubeconfig)
-		fmt.Printf("=== Cluster: %s ===\n", c.Context)
-		if err != nil {
-			fmt.Printf("Error: %v\n", err)
-		} else {
-			fmt.Print(cmdOutput)
-		}
-		fmt.Println()
+		view(c)
 	}
 
-	// 3. Print warning for ITS (control) cluster
+	// Print warning for ITS (control) cluster
 	if cinfo, ok := contextToCluster[itsContext]; ok {
 		fmt.Printf("=== Cluster: %s ===\n", cinfo.Context)
 		fmt.Printf("Cannot perform this operation on ITS (control) cluster: %s\n", cinfo.Context)
@@ -287,6 +564,76 @@ func handleViewLastAppliedCommand(filename, output string, recursive bool, extra
 	return nil
 }
 
+// viewLastAppliedInCluster returns the last-applied-configuration of objs,
+// or of resourceType/name when objs is empty, in one cluster, in output
+// format and separated by --- as kubectl apply view-last-applied prints them
+func viewLastAppliedInCluster(c cluster.ClusterInfo, objs []*unstructured.Unstructured, resourceType, name, namespace, output string) (string, error) {
+	if c.DynamicClient == nil || c.DiscoveryClient == nil {
+		return "", fmt.Errorf("no clients available for cluster %s", c.Name)
+	}
+
+	var lives []*unstructured.Unstructured
+	if len(objs) == 0 {
+		_, _, live, err := rolloutResource(c, resourceType, name, namespace)
+		if err != nil {
+			return "", err
+		}
+		lives = append(lives, live)
+	}
+	mapper := c.Mapper()
+	for _, obj := range objs {
+		gvk := obj.GroupVersionKind()
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return "", err
+		}
+		var client dynamic.ResourceInterface = c.DynamicClient.Resource(mapping.Resource)
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			ns := obj.GetNamespace()
+			if ns == "" {
+				ns = cluster.GetTargetNamespace(namespace)
+			}
+			client = c.DynamicClient.Resource(mapping.Resource).Namespace(ns)
+		}
+		live, err := client.Get(commandContext(), obj.GetName(), metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		lives = append(lives, live)
+	}
+
+	var docs []string
+	for _, live := range lives {
+		doc, err := formatLastApplied(live, output)
+		if err != nil {
+			return strings.Join(docs, "---\n"), err
+		}
+		docs = append(docs, doc)
+	}
+	return strings.Join(docs, "---\n"), nil
+}
+
+// formatLastApplied returns the last-applied-configuration annotation of obj
+// as yaml or indented json
+func formatLastApplied(obj *unstructured.Unstructured, output string) (string, error) {
+	annotation, ok := obj.GetAnnotations()[util.LastAppliedAnnotation]
+	if !ok {
+		return "", fmt.Errorf("no last-applied-configuration annotation found on resource: %s", obj.GetName())
+	}
+	if output == "json" {
+		var out bytes.Buffer
+		if err := json.Indent(&out, []byte(annotation), "", "  "); err != nil {
+			return "", err
+		}
+		return out.String() + "\n", nil
+	}
+	out, err := yaml.JSONToYAML([]byte(annotation))
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
 func newEditLastAppliedCommand() *cobra.Command {
 	var filename string
 	var output string
@@ -344,16 +691,71 @@ func handleSetLastAppliedCommand(filename, output string, createAnnotation bool,
 	return nil
 }
 
+// resolvePolicyName defaults the BindingPolicy name to the manifest file
+// name and checks that it is a valid object name
+func resolvePolicyName(filename, policyName string) (string, error) {
+	if policyName != "" {
+		if errs := validation.IsDNS1123Subdomain(policyName); len(errs) > 0 {
+			return "", fmt.Errorf("invalid --policy-name %q: %s", policyName, strings.Join(errs, "; "))
+		}
+		return policyName, nil
+	}
+	if filename == "-" {
+		return "", fmt.Errorf("--policy-name is required when reading manifests from stdin")
+	}
+	base := filepath.Base(strings.TrimSuffix(filename, string(filepath.Separator)))
+	policyName = strings.ToLower(strings.TrimSuffix(base, filepath.Ext(base)))
+	if errs := validation.IsDNS1123Subdomain(policyName); len(errs) > 0 {
+		return "", fmt.Errorf("policy name %q derived from %s is not a valid name (%s); set one with --policy-name", policyName, filename, strings.Join(errs, "; "))
+	}
+	return policyName, nil
+}
+
+// emitBindingPolicy writes a BindingPolicy selecting the target clusters,
+// followed by the manifests labeled so that the policy selects them
+func emitBindingPolicy(objs []*unstructured.Unstructured, emitPolicy, policyName, namespace string, clusters []cluster.ClusterInfo, itsContext string) error {
+	var targets []string
+	for _, c := range clusters {
+		if c.Context == itsContext {
+			continue
+		}
+		targets = append(targets, c.Name)
+	}
+	sort.Strings(targets)
+
+	for _, obj := range objs {
+		if namespace != "" && obj.GetNamespace() == "" {
+			obj.SetNamespace(namespace)
+		}
+	}
+
+	policy := kubestellar.GeneratePolicy(policyName, targets, objs)
+	data, err := util.EncodeManifests(append([]*unstructured.Unstructured{policy}, objs...))
+	if err != nil {
+		return err
+	}
+
+	if emitPolicy == "-" {
+		_, err = util.GetOutputStream().Write(data)
+		return err
+	}
+	if err := os.WriteFile(emitPolicy, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", emitPolicy, err)
+	}
+	fmt.Printf("BindingPolicy %s for %d cluster(s) written to %s\n", policyName, len(targets), emitPolicy)
+	return nil
+}
+
 // runKubectl runs a kubectl command with the given args and kubeconfig, returns output and error
 func runKubectl(args []string, kubeconfig string) (string, error) {
-	cmd := exec.Command("kubectl", args...)
+	cmd := exec.CommandContext(commandContext(), "kubectl", args...)
 	if kubeconfig != "" {
 		cmd.Env = append(os.Environ(), "KUBECONFIG="+kubeconfig)
 	}
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
-	if err := cmd.Run(); err != nil {
+	if err := runObserved(cmd, kubectlContext(args)); err != nil {
 		return stdout.String() + stderr.String(), err
 	}
 	return stdout.String(), nil
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"os"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"kubectl-multi/pkg/platform"
+	"kubectl-multi/pkg/util"
+)
+
+func TestResolvePolicyName(t *testing.T) {
+	tests := []struct {
+		name       string
+		filename   string
+		policyName string
+		want       string
+		wantErr    bool
+	}{
+		{name: "explicit", filename: "app.yaml", policyName: "web-policy", want: "web-policy"},
+		{name: "explicit invalid", filename: "app.yaml", policyName: "Web_Policy", wantErr: true},
+		{name: "from file", filename: "manifests/Nginx.yaml", want: "nginx"},
+		{name: "from directory", filename: "manifests/web-app/", want: "web-app"},
+		{name: "derived invalid", filename: "my_app.yaml", wantErr: true},
+		{name: "stdin", filename: "-", wantErr: true},
+		{name: "stdin with name", filename: "-", policyName: "web", want: "web"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolvePolicyName(tt.filename, tt.policyName)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolvePolicyName() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("resolvePolicyName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteManagedManifests(t *testing.T) {
+	tests := []struct {
+		name string
+		objs []*unstructured.Unstructured
+	}{
+		{name: "one object", objs: []*unstructured.Unstructured{testObject("v1", "ConfigMap", "default", "app")}},
+		{name: "several objects", objs: []*unstructured.Unstructured{
+			testObject("v1", "ConfigMap", "default", "app"),
+			testObject("apps/v1", "Deployment", "default", "web"),
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(platform.EnvTempDir, t.TempDir())
+			path, err := writeManagedManifests(tt.objs)
+			if err != nil {
+				t.Fatalf("writeManagedManifests() error = %v", err)
+			}
+			defer os.Remove(path)
+			got, err := util.ReadManifests(path, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.objs) {
+				t.Fatalf("wrote %d objects, want %d", len(got), len(tt.objs))
+			}
+			for i, obj := range got {
+				if obj.GetName() != tt.objs[i].GetName() || !util.IsManaged(obj) {
+					t.Errorf("object %d = %s with annotations %v, want %s marked as applied", i, obj.GetName(), obj.GetAnnotations(), tt.objs[i].GetName())
+				}
+				if util.IsManaged(tt.objs[i]) {
+					t.Errorf("the manifest object %s was modified", tt.objs[i].GetName())
+				}
+			}
+		})
+	}
+}
+
+func TestFormatLastApplied(t *testing.T) {
+	obj := testObject("v1", "ConfigMap", "prod", "settings")
+	obj.SetAnnotations(map[string]string{util.LastAppliedAnnotation: `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"settings"}}`})
+
+	got, err := formatLastApplied(obj, "yaml")
+	if want := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\n"; err != nil || got != want {
+		t.Errorf("formatLastApplied(yaml) = %q, %v; want %q", got, err, want)
+	}
+	got, err = formatLastApplied(obj, "json")
+	if want := "{\n  \"apiVersion\": \"v1\",\n  \"kind\": \"ConfigMap\",\n  \"metadata\": {\n    \"name\": \"settings\"\n  }\n}\n"; err != nil || got != want {
+		t.Errorf("formatLastApplied(json) = %q, %v; want %q", got, err, want)
+	}
+
+	obj.SetAnnotations(nil)
+	if _, err := formatLastApplied(obj, "yaml"); err == nil {
+		t.Error("formatLastApplied() without the annotation did not fail")
+	}
+}
+
+func TestViewLastAppliedInCluster(t *testing.T) {
+	annotated := func(name string) *unstructured.Unstructured {
+		obj := testObject("apps/v1", "Deployment", "prod", name)
+		obj.SetAnnotations(map[string]string{util.LastAppliedAnnotation: `{"kind":"Deployment","metadata":{"name":"` + name + `"}}`})
+		return obj
+	}
+	c := testTreeCluster(annotated("web"), annotated("api"))
+
+	got, err := viewLastAppliedInCluster(c, nil, "deployments", "web", "prod", "yaml")
+	if want := "kind: Deployment\nmetadata:\n  name: web\n"; err != nil || got != want {
+		t.Errorf("viewLastAppliedInCluster(deployments/web) = %q, %v; want %q", got, err, want)
+	}
+
+	objs := []*unstructured.Unstructured{testObject("apps/v1", "Deployment", "", "web"), testObject("apps/v1", "Deployment", "prod", "api")}
+	got, err = viewLastAppliedInCluster(c, objs, "", "", "prod", "yaml")
+	if want := "kind: Deployment\nmetadata:\n  name: web\n---\nkind: Deployment\nmetadata:\n  name: api\n"; err != nil || got != want {
+		t.Errorf("viewLastAppliedInCluster(-f) = %q, %v; want %q", got, err, want)
+	}
+}
@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+// Operations timed by bench
+const (
+	benchList = "list"
+	benchGet  = "get"
+)
+
+// benchResult is the latency of one operation against one cluster
+type benchResult struct {
+	Cluster   string  `json:"cluster"`
+	Operation string  `json:"operation"`
+	Requests  int     `json:"requests"`
+	Errors    int     `json:"errors"`
+	P50MS     float64 `json:"p50Ms"`
+	P95MS     float64 `json:"p95Ms"`
+	MaxMS     float64 `json:"maxMs"`
+	// LastError is the last error returned, if any request failed
+	LastError string `json:"lastError,omitempty"`
+}
+
+func newBenchCommand() *cobra.Command {
+	var outputFormat string
+	var requests int
+	var targets clusterTargets
+	var reach reachability
+
+	cmd := &cobra.Command{
+		Use:   "bench",
+		Short: "Measure API server list/get latency of every managed cluster",
+		Long: `Send --requests list and get calls to the API server of every managed
+cluster and print the p50, p95 and maximum latency per cluster. Clusters are
+measured in parallel and the requests to one cluster one after the other, so
+the slowest cluster behind an aggregated command stands out.
+
+The list call lists the pods of the namespace (-n, or every namespace with
+-A); the get call reads the namespace itself.`,
+		Example: `# 50 lists and 50 gets against each cluster
+kubectl multi bench
+
+# A quicker, rougher measurement
+kubectl multi bench --requests 10
+
+# Benchmark listing every pod of the fleet, as JSON
+kubectl multi bench -A -o json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch outputFormat {
+			case "", "json", util.TableFormatCSV, util.TableFormatMarkdown:
+			default:
+				return fmt.Errorf("unsupported output format %q, must be one of json|csv|markdown", outputFormat)
+			}
+			if requests <= 0 {
+				return fmt.Errorf("--requests must be positive")
+			}
+			kubeconfig, remoteCtx, _, namespace, allNamespaces := GetGlobalFlags()
+			return handleBenchCommand(outputFormat, requests, targets, reach, kubeconfig, remoteCtx, namespace, allNamespaces)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "output format (json|csv|markdown)")
+	cmd.Flags().IntVar(&requests, "requests", 50, "number of list and of get requests to send to each cluster")
+	targets.addFlags(cmd, "benchmark")
+	reach.addFlags(cmd)
+
+	return cmd
+}
+
+func handleBenchCommand(outputFormat string, requests int, targets clusterTargets, reach reachability, kubeconfig, remoteCtx, namespace string, allNamespaces bool) error {
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	clusters, err = targets.selectFrom(clusters, kubeconfig)
+	if err != nil {
+		return err
+	}
+	clusters, err = reach.filter(clusters)
+	if err != nil {
+		return err
+	}
+	defer printClusterIssuesToStderr()
+
+	listNS := cluster.GetTargetNamespace(namespace)
+	if allNamespaces {
+		listNS = ""
+	}
+	getNS := cluster.GetTargetNamespace(namespace)
+
+	perCluster := make([][]benchResult, len(clusters))
+	var wg sync.WaitGroup
+	for i, c := range clusters {
+		wg.Add(1)
+		go func(i int, c cluster.ClusterInfo) {
+			defer wg.Done()
+			perCluster[i] = benchCluster(commandContext(), c, requests, listNS, getNS)
+		}(i, c)
+	}
+	wg.Wait()
+
+	var results []benchResult
+	for _, r := range perCluster {
+		results = append(results, r...)
+	}
+	if outputFormat == "json" {
+		return printJSONArray(results)
+	}
+
+	tw := util.NewTableWriter(util.GetOutputStream(), outputFormat)
+	defer tw.Flush()
+	fmt.Fprintf(tw, "CLUSTER\tOPERATION\tREQUESTS\tERRORS\tP50\tP95\tMAX\n")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\t%s\t%s\n",
+			r.Cluster, r.Operation, r.Requests, r.Errors, formatMS(r, r.P50MS), formatMS(r, r.P95MS), formatMS(r, r.MaxMS))
+	}
+	return nil
+}
+
+func formatMS(r benchResult, ms float64) string {
+	if r.Errors == r.Requests {
+		return "-"
+	}
+	return fmt.Sprintf("%.1fms", ms)
+}
+
+// benchCluster times requests list and requests get calls against one
+// cluster, one after the other, stopping early when ctx is cancelled
+func benchCluster(ctx context.Context, c cluster.ClusterInfo, requests int, listNS, getNS string) []benchResult {
+	ops := []struct {
+		name string
+		call func() error
+	}{
+		{benchList, func() error {
+			_, err := c.Client.CoreV1().Pods(listNS).List(ctx, metav1.ListOptions{})
+			return err
+		}},
+		{benchGet, func() error {
+			_, err := c.Client.CoreV1().Namespaces().Get(ctx, getNS, metav1.GetOptions{})
+			return err
+		}},
+	}
+
+	var results []benchResult
+	for _, op := range ops {
+		r := benchResult{Cluster: c.Name, Operation: op.name}
+		var latencies []time.Duration
+		for i := 0; i < requests && ctx.Err() == nil; i++ {
+			start := time.Now()
+			err := op.call()
+			r.Requests++
+			if err != nil {
+				r.Errors++
+				r.LastError = err.Error()
+				continue
+			}
+			latencies = append(latencies, time.Since(start))
+		}
+		if r.Errors > 0 {
+			noteClusterIssue(c.Name, fmt.Sprintf("%d of %d %s requests failed: %s", r.Errors, r.Requests, op.name, r.LastError))
+		}
+		r.P50MS = milliseconds(percentile(latencies, 50))
+		r.P95MS = milliseconds(percentile(latencies, 95))
+		r.MaxMS = milliseconds(percentile(latencies, 100))
+		results = append(results, r)
+	}
+	return results
+}
+
+// percentile returns the nearest-rank p-th percentile of the latencies, or
+// zero when there are none
+func percentile(latencies []time.Duration, p int) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration{}, latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
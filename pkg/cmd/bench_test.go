@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"kubectl-multi/pkg/cluster"
+)
+
+func TestPercentile(t *testing.T) {
+	var latencies []time.Duration
+	for i := 20; i >= 1; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	tests := []struct {
+		p    int
+		want time.Duration
+	}{
+		{50, 10 * time.Millisecond},
+		{95, 19 * time.Millisecond},
+		{100, 20 * time.Millisecond},
+		{1, time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := percentile(latencies, tt.p); got != tt.want {
+			t.Errorf("percentile(%d) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+	if got := percentile(nil, 95); got != 0 {
+		t.Errorf("percentile of no latencies = %v, want 0", got)
+	}
+	if latencies[0] != 20*time.Millisecond {
+		t.Errorf("percentile sorted its input")
+	}
+}
+
+func TestBenchCluster(t *testing.T) {
+	defer resetClusterIssues()
+	client := fake.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}})
+	calls := 0
+	client.PrependReactor("get", "namespaces", func(clienttesting.Action) (bool, runtime.Object, error) {
+		calls++
+		if calls%2 == 0 {
+			return true, nil, fmt.Errorf("timeout")
+		}
+		return false, nil, nil
+	})
+
+	results := benchCluster(context.Background(), cluster.ClusterInfo{Name: "cluster1", Client: client}, 4, "", "default")
+	if len(results) != 2 {
+		t.Fatalf("benchCluster() returned %d results, want 2", len(results))
+	}
+	list, get := results[0], results[1]
+	if list.Operation != benchList || list.Requests != 4 || list.Errors != 0 {
+		t.Errorf("list result = %+v, want 4 requests without errors", list)
+	}
+	if get.Operation != benchGet || get.Requests != 4 || get.Errors != 2 || get.LastError != "timeout" {
+		t.Errorf("get result = %+v, want 4 requests with 2 errors", get)
+	}
+	if issues := takeClusterIssues(); len(issues) != 1 || issues[0].Cluster != "cluster1" {
+		t.Errorf("cluster issues = %v, want one for cluster1", issues)
+	}
+}
@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/client-go/dynamic"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+	"kubectl-multi/pkg/util"
+)
+
+func newBindingPolicyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "bindingpolicy",
+		Aliases: []string{"bp", "bindingpolicies"},
+		Short:   "Inspect, create and update KubeStellar BindingPolicies in the WDS",
+		Long: `Inspect the BindingPolicy objects held by the WDS (the --wds-context, or
+every WDS given with --wds) together with the clusters their Bindings
+resolved to, create new ones, change the resource types they downsync, or
+roll a change back to an earlier revision.`,
+	}
+	cmd.AddCommand(newBindingPolicyListCommand())
+	cmd.AddCommand(newBindingPolicyCreateCommand())
+	cmd.AddCommand(newBindingPolicyPresetsCommand())
+	cmd.AddCommand(newBindingPolicyAddResourcesCommand())
+	cmd.AddCommand(newBindingPolicyRemoveResourcesCommand())
+	cmd.AddCommand(newBindingPolicyWaitCommand())
+	cmd.AddCommand(newBindingPolicyHistoryCommand())
+	cmd.AddCommand(newBindingPolicyRollbackCommand())
+	return cmd
+}
+
+func newBindingPolicyListCommand() *cobra.Command {
+	var outputFormat string
+	var reach reachability
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List BindingPolicies with their selectors and resolved clusters",
+		Example: `# List binding policies
+kubectl multi bp list
+
+# Show downsync clauses and destination clusters
+kubectl multi bp list -o wide
+
+# Machine-readable policy state
+kubectl multi bp list -o yaml
+
+# Policies of two WDSes, with a WDS column
+kubectl multi bp list --wds wds1,wds2`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch outputFormat {
+			case "", "wide", "json", "yaml", util.TableFormatCSV, util.TableFormatMarkdown:
+			default:
+				return fmt.Errorf("unsupported output format %q, must be one of json|yaml|wide|csv|markdown", outputFormat)
+			}
+			kubeconfig, _, _, _, _ := GetGlobalFlags()
+			return handleBindingPolicyList(outputFormat, reach, kubeconfig, GetWDSContexts())
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "output format (json|yaml|wide|csv|markdown)")
+	reach.addFlags(cmd)
+
+	return cmd
+}
+
+// listPolicySummaries summarizes every BindingPolicy served by the client
+// wds of the WDS wdsContext, sorted by name
+func listPolicySummaries(wds dynamic.Interface, wdsContext string) ([]kubestellar.PolicySummary, error) {
+	policies, err := wds.Resource(kubestellar.BindingPolicyGVR).List(commandContext(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bindingpolicies in WDS %s: %v", wdsContext, err)
+	}
+
+	bindings := make(map[string]*unstructured.Unstructured)
+	bindingList, err := wds.Resource(kubestellar.BindingGVR).List(commandContext(), metav1.ListOptions{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to list bindings in WDS %s: %v\n", wdsContext, err)
+	} else {
+		for i := range bindingList.Items {
+			bindings[bindingList.Items[i].GetName()] = &bindingList.Items[i]
+		}
+	}
+
+	summaries := []kubestellar.PolicySummary{}
+	for i := range policies.Items {
+		s, err := kubestellar.SummarizePolicy(&policies.Items[i], bindings[policies.Items[i].GetName()])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		s.WDS = wdsContext
+		summaries = append(summaries, s)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Name < summaries[j].Name })
+	return summaries, nil
+}
+
+func handleBindingPolicyList(outputFormat string, reach reachability, kubeconfig string, wdsContexts []string) error {
+	summaries := []kubestellar.PolicySummary{}
+	for _, wdsContext := range wdsContexts {
+		if _, err := reach.controlPlane("WDS", kubeconfig, wdsContext, true); err != nil {
+			return err
+		}
+		wds, err := cluster.ClientForContext(kubeconfig, wdsContext)
+		if err != nil {
+			return err
+		}
+		wdsSummaries, err := listPolicySummaries(wds.DynamicClient, wdsContext)
+		if err != nil {
+			return err
+		}
+		summaries = append(summaries, wdsSummaries...)
+	}
+
+	if outputFormat == "json" || outputFormat == "yaml" {
+		return util.PrintStructured(util.GetOutputStream(), outputFormat, summaries)
+	}
+
+	tw := util.NewTableWriter(util.GetOutputStream(), outputFormat)
+	defer tw.Flush()
+
+	if len(summaries) == 0 {
+		fmt.Fprintf(tw, "No BindingPolicies found in WDS %s.\n", strings.Join(wdsContexts, ", "))
+		return nil
+	}
+
+	showWDS := len(wdsContexts) > 1
+	header := "NAME\tCLUSTER-SELECTORS\tCLUSTERS\tAGE"
+	if showWDS {
+		header = "NAME\tWDS\tCLUSTER-SELECTORS\tCLUSTERS\tAGE"
+	}
+	if outputFormat == "wide" {
+		header += "\tDOWNSYNC\tDESTINATIONS"
+	}
+	fmt.Fprintln(tw, header)
+	for _, s := range summaries {
+		selectors := kubestellar.FormatSelectors(s.ClusterSelectors)
+		if selectors == "" {
+			selectors = "<none>"
+		}
+		age := duration.HumanDuration(time.Since(s.Created))
+		fmt.Fprintf(tw, "%s\t", s.Name)
+		if showWDS {
+			fmt.Fprintf(tw, "%s\t", s.WDS)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s", selectors, len(s.Clusters), age)
+		if outputFormat == "wide" {
+			downsync := strings.Join(s.Downsync, "; ")
+			if downsync == "" {
+				downsync = "<none>"
+			}
+			destinations := strings.Join(s.Clusters, ",")
+			if destinations == "" {
+				destinations = "<none>"
+			}
+			fmt.Fprintf(tw, "\t%s\t%s", downsync, destinations)
+		}
+		fmt.Fprintln(tw)
+	}
+	return nil
+}
+
+// guardPolicyChange runs the guardrails of a change to count BindingPolicies
+// before it is made. Its blast radius is the ManagedClusters of the ITS that
+// any of policies, as they are and as they will be, selects.
+func guardPolicyChange(rec *audit.Recorder, count int, policies []*unstructured.Unstructured, kubeconfig, itsContext string) error {
+	if rec == nil {
+		return nil
+	}
+	mcs, err := cluster.ListManagedClusterObjects(commandContext(), kubeconfig, itsContext)
+	if err != nil {
+		return fmt.Errorf("failed to find the clusters the BindingPolicy selects: %v", err)
+	}
+	return guardManagedClusters(rec, blastRadius{Objects: count}, policySelectedClusters(policies, mcs), itsContext)
+}
+
+// policySelectedClusters returns the names of the ManagedClusters selected
+// by at least one of the policies, sorted
+func policySelectedClusters(policies []*unstructured.Unstructured, mcs []unstructured.Unstructured) []string {
+	var specs []kubestellar.BindingPolicySpec
+	for _, policy := range policies {
+		if spec, err := kubestellar.BindingPolicySpecFrom(policy); err == nil {
+			specs = append(specs, spec)
+		}
+	}
+	var selected []string
+	for i := range mcs {
+		for _, spec := range specs {
+			if ok, _ := kubestellar.MatchAnySelector(spec.ClusterSelectors, mcs[i].GetLabels()); ok {
+				selected = append(selected, mcs[i].GetName())
+				break
+			}
+		}
+	}
+	sort.Strings(selected)
+	return selected
+}
+
+// livePolicies returns the policy called name in each WDS holding it. The
+// WDSes it cannot be read from are left for the change itself to report.
+func livePolicies(wdses []*cluster.ClusterInfo, name string) []*unstructured.Unstructured {
+	var policies []*unstructured.Unstructured
+	for _, wds := range wdses {
+		if policy, err := wds.DynamicClient.Resource(kubestellar.BindingPolicyGVR).Get(commandContext(), name, metav1.GetOptions{}); err == nil {
+			policies = append(policies, policy)
+		}
+	}
+	return policies
+}
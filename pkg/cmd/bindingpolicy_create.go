@@ -0,0 +1,471 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/config"
+	"kubectl-multi/pkg/kubestellar"
+	"kubectl-multi/pkg/util"
+)
+
+// policyCreateOptions holds the flags of bp create
+type policyCreateOptions struct {
+	Interactive     bool
+	ClusterSelector string
+	ObjectSelector  string
+	Resources       []string
+	Namespaces      []string
+	Preset          string
+	DryRun          bool
+}
+
+func newBindingPolicyCreateCommand() *cobra.Command {
+	var o policyCreateOptions
+
+	cmd := &cobra.Command{
+		Use:   "create [NAME]",
+		Short: "Create a BindingPolicy from selectors, a preset or with an interactive wizard",
+		Long: `Create a BindingPolicy in the WDS (the --wds-context, or every WDS given with
+--wds).
+
+--preset starts from a curated set of resource types and an object selector
+for a common application shape (see 'kubectl multi bp presets'): web-app,
+batch or config-only, or a preset of your own in the kubectl-multi-presets
+directory next to the config file. --object-selector, --resources and
+--namespaces override what the preset sets.
+
+With --interactive a wizard walks through the choices instead of the selector
+flags: it lists the ManagedClusters of the ITS with their labels to pick the
+clusters from, the labels found on the workloads in the WDS to pick the
+objects from, and the resource types carrying those labels. The resulting
+YAML is shown for confirmation before anything is created.`,
+		Example: `# Walk through the policy step by step
+kubectl multi bp create --interactive
+
+# Place the nginx deployments and services on the edge clusters
+kubectl multi bp create nginx --cluster-selector location=edge \
+  --object-selector app=nginx --resources deployments,services
+
+# Downsync the deployment, services, ingress and configuration labeled app=shop
+kubectl multi bp create shop --preset web-app --cluster-selector location=edge
+
+# Only print the policy
+kubectl multi bp create nginx --cluster-selector location=edge --object-selector app=nginx --dry-run`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := ""
+			if len(args) == 1 {
+				name = args[0]
+			}
+			if err := o.validate(name); err != nil {
+				return err
+			}
+			kubeconfig, remoteCtx, _, _, _ := GetGlobalFlags()
+			var rec *audit.Recorder
+			if !o.DryRun {
+				rec = startAudit("bindingpolicy create")
+			}
+			err := handleBindingPolicyCreate(name, o, rec, kubeconfig, remoteCtx, GetWDSContexts())
+			finishAudit(rec, err)
+			return err
+		},
+	}
+
+	cmd.Flags().BoolVarP(&o.Interactive, "interactive", "i", false, "choose clusters, workloads and resource types step by step")
+	cmd.Flags().StringVar(&o.ClusterSelector, "cluster-selector", "", "label selector choosing the ManagedClusters to place on")
+	cmd.Flags().StringVar(&o.ObjectSelector, "object-selector", "", "label selector choosing the workload objects to downsync")
+	cmd.Flags().StringSliceVar(&o.Resources, "resources", nil, "comma-separated resource types to downsync (default any type)")
+	cmd.Flags().StringSliceVar(&o.Namespaces, "namespaces", nil, "comma-separated namespaces to downsync from (default all)")
+	cmd.Flags().StringVar(&o.Preset, "preset", "", "start from a preset of resource types and object selector, e.g. web-app|batch|config-only")
+	cmd.Flags().BoolVar(&o.DryRun, "dry-run", false, "only print the BindingPolicy that would be created")
+
+	return cmd
+}
+
+// validate checks the flags before any cluster is contacted
+func (o policyCreateOptions) validate(name string) error {
+	if o.Interactive {
+		if o.ClusterSelector != "" || o.ObjectSelector != "" || len(o.Resources) > 0 || len(o.Namespaces) > 0 || o.Preset != "" {
+			return fmt.Errorf("--interactive cannot be combined with the selector flags")
+		}
+		return nil
+	}
+	if name == "" {
+		return fmt.Errorf("a BindingPolicy name is required unless --interactive is set")
+	}
+	if o.ClusterSelector == "" {
+		return fmt.Errorf("--cluster-selector is required unless --interactive is set")
+	}
+	if o.ObjectSelector == "" && len(o.Resources) == 0 && o.Preset == "" {
+		return fmt.Errorf("--object-selector, --resources or --preset is required unless --interactive is set")
+	}
+	return nil
+}
+
+func handleBindingPolicyCreate(name string, o policyCreateOptions, rec *audit.Recorder, kubeconfig, remoteCtx string, wdsContexts []string) error {
+	var preset *kubestellar.Preset
+	if o.Preset != "" {
+		p, err := kubestellar.LookupPreset(config.PresetsDir(), o.Preset)
+		if err != nil {
+			return err
+		}
+		preset = &p
+	}
+
+	wdses := make([]*cluster.ClusterInfo, 0, len(wdsContexts))
+	for _, wdsContext := range wdsContexts {
+		wds, err := cluster.ClientForContext(kubeconfig, wdsContext)
+		if err != nil {
+			return err
+		}
+		wdses = append(wdses, wds)
+	}
+
+	var policy *unstructured.Unstructured
+	var err error
+	if o.Interactive {
+		policy, err = runPolicyWizard(name, o.DryRun, kubeconfig, remoteCtx, wdses)
+		if policy == nil || err != nil {
+			return err
+		}
+	} else {
+		policy, err = policyFromFlags(name, o, preset, wdses[0])
+		if err != nil {
+			return err
+		}
+		if o.DryRun {
+			return printPolicy(util.GetOutputStream(), policy)
+		}
+	}
+
+	if err := guardPolicyChange(rec, len(wdses), []*unstructured.Unstructured{policy}, kubeconfig, remoteCtx); err != nil {
+		return err
+	}
+	return createPolicyInWDSes(policy, wdses, rec)
+}
+
+// createPolicyInWDSes creates the policy in every WDS, reporting each outcome
+func createPolicyInWDSes(policy *unstructured.Unstructured, wdses []*cluster.ClusterInfo, rec *audit.Recorder) error {
+	policy = policy.DeepCopy()
+	util.MarkManaged(policy)
+	failed := 0
+	for _, wds := range wdses {
+		created, err := wds.DynamicClient.Resource(kubestellar.BindingPolicyGVR).Create(commandContext(), policy.DeepCopy(), metav1.CreateOptions{FieldManager: util.FieldManager})
+		rec.Record(wds.Context, err)
+		if apierrors.IsAlreadyExists(err) {
+			err = fmt.Errorf("BindingPolicy %s already exists", policy.GetName())
+		}
+		if err != nil {
+			fmt.Printf("%s: error: %v\n", wds.Context, err)
+			failed++
+			continue
+		}
+		fmt.Printf("BindingPolicy %s created in WDS %s\n", policy.GetName(), wds.Context)
+		notePolicyRevision(os.Stdout, wds, nil, created, "bindingpolicy create")
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to create BindingPolicy %s in %d of %d WDS(es)", policy.GetName(), failed, len(wdses))
+	}
+	return nil
+}
+
+// policyFromFlags builds the policy from the selector flags and the preset,
+// if any, resolving the resource types of the flags against the WDS
+func policyFromFlags(name string, o policyCreateOptions, preset *kubestellar.Preset, wds *cluster.ClusterInfo) (*unstructured.Unstructured, error) {
+	var resources []schema.GroupResource
+	namespaces := o.Namespaces
+	if preset != nil {
+		selector, presetResources, err := preset.Expand(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid preset %s: %v", preset.Name, err)
+		}
+		if o.ObjectSelector == "" {
+			o.ObjectSelector = selector
+		}
+		if len(o.Resources) == 0 {
+			resources = presetResources
+		}
+		if len(namespaces) == 0 {
+			namespaces = preset.Namespaces
+		}
+	}
+
+	clusterSelector, err := metav1.ParseToLabelSelector(o.ClusterSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cluster selector %q: %v", o.ClusterSelector, err)
+	}
+	var objectSelector *metav1.LabelSelector
+	if o.ObjectSelector != "" {
+		objectSelector, err = metav1.ParseToLabelSelector(o.ObjectSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid object selector %q: %v", o.ObjectSelector, err)
+		}
+	}
+
+	for _, r := range o.Resources {
+		gvr, _, err := util.DiscoverGVR(wds.DiscoveryClient, r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve resource type %s: %v", r, err)
+		}
+		resources = append(resources, gvr.GroupResource())
+	}
+
+	return kubestellar.NewPolicy(name, kubestellar.BindingPolicySpec{
+		ClusterSelectors: []metav1.LabelSelector{*clusterSelector},
+		Downsync:         kubestellar.DownsyncClauses(resources, namespaces, objectSelector),
+	})
+}
+
+// runPolicyWizard lists the live choices, walks the user through them and
+// returns the confirmed policy, or nil when the user declined or only
+// wanted to see it
+func runPolicyWizard(name string, dryRun bool, kubeconfig, remoteCtx string, wdses []*cluster.ClusterInfo) (*unstructured.Unstructured, error) {
+	mcs, err := cluster.ListManagedClusterObjects(commandContext(), kubeconfig, remoteCtx)
+	if err != nil {
+		return nil, err
+	}
+	var clusters []kubestellar.ClusterSummary
+	for i := range mcs {
+		clusters = append(clusters, kubestellar.SummarizeCluster(&mcs[i]))
+	}
+	if len(clusters) == 0 {
+		return nil, fmt.Errorf("no ManagedClusters found in ITS %s", remoteCtx)
+	}
+
+	var objs []wdsObject
+	for _, wds := range wdses {
+		objs = append(objs, scanWDSObjects(wds.DynamicClient, wds.Context)...)
+	}
+
+	w := &policyWizard{prompter{in: bufio.NewReader(os.Stdin), out: os.Stdout}}
+	policy, err := w.build(name, clusters, objs)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintln(w.out, "\nBindingPolicy to create:")
+	if err := printPolicy(w.out, policy); err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return nil, nil
+	}
+	ok, err := w.confirm(fmt.Sprintf("Create BindingPolicy %s in WDS %s?", policy.GetName(), wdsNamesOf(wdses)))
+	if err != nil || !ok {
+		if err == nil {
+			fmt.Fprintln(w.out, "Aborted, nothing was created.")
+		}
+		return nil, err
+	}
+	return policy, nil
+}
+
+// wdsNamesOf joins the contexts of the WDSes
+func wdsNamesOf(wdses []*cluster.ClusterInfo) string {
+	names := make([]string, 0, len(wdses))
+	for _, wds := range wdses {
+		names = append(names, wds.Context)
+	}
+	return strings.Join(names, ", ")
+}
+
+func printPolicy(w io.Writer, policy *unstructured.Unstructured) error {
+	data, err := util.EncodeManifests([]*unstructured.Unstructured{policy})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// wizardWorkloadGVRs are the resource types scanned for workload labels
+var wizardWorkloadGVRs = []schema.GroupVersionResource{
+	{Group: "apps", Version: "v1", Resource: "deployments"},
+	{Group: "apps", Version: "v1", Resource: "statefulsets"},
+	{Group: "apps", Version: "v1", Resource: "daemonsets"},
+	{Group: "batch", Version: "v1", Resource: "jobs"},
+	{Group: "batch", Version: "v1", Resource: "cronjobs"},
+	{Version: "v1", Resource: "services"},
+	{Version: "v1", Resource: "configmaps"},
+	{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"},
+}
+
+// wdsObject is a labeled workload object found in the WDS
+type wdsObject struct {
+	Resource  schema.GroupResource
+	Namespace string
+	Labels    map[string]string
+}
+
+// scanWDSObjects collects the labeled workload objects of the WDS outside
+// the kube-* system namespaces. Types that cannot be listed are skipped.
+func scanWDSObjects(dyn dynamic.Interface, wdsContext string) []wdsObject {
+	var objs []wdsObject
+	for _, gvr := range wizardWorkloadGVRs {
+		list, err := dyn.Resource(gvr).Namespace(metav1.NamespaceAll).List(commandContext(), metav1.ListOptions{})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to list %s in WDS %s: %v\n", gvr.Resource, wdsContext, err)
+			continue
+		}
+		for _, item := range list.Items {
+			if len(item.GetLabels()) == 0 || strings.HasPrefix(item.GetNamespace(), "kube-") {
+				continue
+			}
+			objs = append(objs, wdsObject{Resource: gvr.GroupResource(), Namespace: item.GetNamespace(), Labels: item.GetLabels()})
+		}
+	}
+	return objs
+}
+
+// labelChoice is a label found on workload objects, with how many carry it
+type labelChoice struct {
+	Key, Value string
+	Count      int
+}
+
+// labelChoices returns the labels of objs, most used first
+func labelChoices(objs []wdsObject) []labelChoice {
+	counts := map[[2]string]int{}
+	for _, obj := range objs {
+		for k, v := range obj.Labels {
+			counts[[2]string{k, v}]++
+		}
+	}
+	choices := make([]labelChoice, 0, len(counts))
+	for kv, n := range counts {
+		choices = append(choices, labelChoice{Key: kv[0], Value: kv[1], Count: n})
+	}
+	sort.Slice(choices, func(i, j int) bool {
+		a, b := choices[i], choices[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Key != b.Key {
+			return a.Key < b.Key
+		}
+		return a.Value < b.Value
+	})
+	return choices
+}
+
+// resourceChoice is a resource type with how many of its objects match the
+// chosen object selector
+type resourceChoice struct {
+	Resource schema.GroupResource
+	Count    int
+}
+
+// resourceChoices returns the resource types of the objs matching sel
+func resourceChoices(objs []wdsObject, sel labels.Selector) []resourceChoice {
+	counts := map[schema.GroupResource]int{}
+	for _, obj := range objs {
+		if sel.Matches(labels.Set(obj.Labels)) {
+			counts[obj.Resource]++
+		}
+	}
+	choices := make([]resourceChoice, 0, len(counts))
+	for r, n := range counts {
+		choices = append(choices, resourceChoice{Resource: r, Count: n})
+	}
+	sort.Slice(choices, func(i, j int) bool { return choices[i].Resource.String() < choices[j].Resource.String() })
+	return choices
+}
+
+// policyWizard asks the questions of bp create --interactive
+type policyWizard struct {
+	prompter
+}
+
+// build asks for the name (unless given), the clusters, the workload labels
+// and the resource types, and returns the resulting policy
+func (w *policyWizard) build(name string, clusters []kubestellar.ClusterSummary, objs []wdsObject) (*unstructured.Unstructured, error) {
+	var err error
+	for name == "" {
+		if name, err = w.ask("BindingPolicy name: "); err != nil {
+			return nil, err
+		}
+	}
+
+	// Clusters
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].Name < clusters[j].Name })
+	fmt.Fprintln(w.out, "\nManagedClusters:")
+	allLabels := map[string]map[string]string{}
+	for i, c := range clusters {
+		allLabels[c.Name] = c.Labels
+		fmt.Fprintf(w.out, "  %d) %s  %s\n", i+1, c.Name, util.FormatLabels(c.Labels))
+	}
+	picked, err := w.pick("Clusters to place on (e.g. 1,3-4 or all): ", len(clusters), false)
+	if err != nil {
+		return nil, err
+	}
+	var chosen []string
+	for _, i := range picked {
+		chosen = append(chosen, clusters[i].Name)
+	}
+	clusterSelector := kubestellar.SelectorForClusters(chosen, allLabels)
+	fmt.Fprintf(w.out, "Cluster selector: %s\n", kubestellar.FormatSelectors([]metav1.LabelSelector{clusterSelector}))
+
+	// Workloads
+	choices := labelChoices(objs)
+	if len(choices) == 0 {
+		return nil, fmt.Errorf("no labeled workload objects found in the WDS; label the workloads to place first")
+	}
+	fmt.Fprintln(w.out, "\nWorkload labels in the WDS:")
+	for i, c := range choices {
+		fmt.Fprintf(w.out, "  %d) %s=%s  (%d objects)\n", i+1, c.Key, c.Value, c.Count)
+	}
+	var matchLabels map[string]string
+	for matchLabels == nil {
+		picked, err := w.pick("Labels the workload objects must carry (e.g. 1,2): ", len(choices), false)
+		if err != nil {
+			return nil, err
+		}
+		matchLabels = map[string]string{}
+		for _, i := range picked {
+			if _, dup := matchLabels[choices[i].Key]; dup {
+				fmt.Fprintf(w.out, "Pick one value per label key, %s was chosen twice.\n", choices[i].Key)
+				matchLabels = nil
+				break
+			}
+			matchLabels[choices[i].Key] = choices[i].Value
+		}
+	}
+	objectSelector := &metav1.LabelSelector{MatchLabels: matchLabels}
+
+	// Resource types
+	var resources []schema.GroupResource
+	types := resourceChoices(objs, labels.SelectorFromSet(matchLabels))
+	if len(types) > 0 {
+		fmt.Fprintln(w.out, "\nResource types carrying those labels:")
+		for i, c := range types {
+			fmt.Fprintf(w.out, "  %d) %s  (%d objects)\n", i+1, c.Resource, c.Count)
+		}
+		picked, err := w.pick("Resource types to downsync (Enter for any type): ", len(types), true)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range picked {
+			resources = append(resources, types[i].Resource)
+		}
+	}
+
+	return kubestellar.NewPolicy(name, kubestellar.BindingPolicySpec{
+		ClusterSelectors: []metav1.LabelSelector{clusterSelector},
+		Downsync:         kubestellar.DownsyncClauses(resources, nil, objectSelector),
+	})
+}
@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+)
+
+func TestPolicyCreateValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  string
+		opts    policyCreateOptions
+		wantErr string
+	}{
+		{name: "interactive without name", opts: policyCreateOptions{Interactive: true}},
+		{name: "interactive with selectors", opts: policyCreateOptions{Interactive: true, ClusterSelector: "a=b"}, wantErr: "cannot be combined"},
+		{name: "missing name", opts: policyCreateOptions{ClusterSelector: "a=b", ObjectSelector: "app=x"}, wantErr: "name is required"},
+		{name: "missing cluster selector", policy: "p", opts: policyCreateOptions{ObjectSelector: "app=x"}, wantErr: "--cluster-selector"},
+		{name: "missing workload", policy: "p", opts: policyCreateOptions{ClusterSelector: "a=b"}, wantErr: "--object-selector, --resources or --preset"},
+		{name: "resources only", policy: "p", opts: policyCreateOptions{ClusterSelector: "a=b", Resources: []string{"deployments"}}},
+		{name: "preset only", policy: "p", opts: policyCreateOptions{ClusterSelector: "a=b", Preset: "web-app"}},
+		{name: "interactive with preset", opts: policyCreateOptions{Interactive: true, Preset: "web-app"}, wantErr: "cannot be combined"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.validate(tt.policy)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("validate() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPolicyFromFlags(t *testing.T) {
+	wds, _ := testClusterInfo()
+	apps, core := "apps", ""
+	preset := &kubestellar.Preset{Name: "app", ObjectSelector: "app={{.Name}}", Resources: []string{"deployments.apps", "services"}, Namespaces: []string{"prod"}}
+	tests := []struct {
+		name    string
+		opts    policyCreateOptions
+		preset  *kubestellar.Preset
+		want    kubestellar.BindingPolicySpec
+		wantErr bool
+	}{
+		{
+			name: "resources grouped by API group",
+			opts: policyCreateOptions{ClusterSelector: "location=edge", ObjectSelector: "app=nginx", Resources: []string{"deploy", "configmaps"}, Namespaces: []string{"web"}},
+			want: kubestellar.BindingPolicySpec{
+				ClusterSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"location": "edge"}}},
+				Downsync: []kubestellar.DownsyncClause{
+					{APIGroup: &core, Resources: []string{"configmaps"}, Namespaces: []string{"web"}, ObjectSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"app": "nginx"}}}},
+					{APIGroup: &apps, Resources: []string{"deployments"}, Namespaces: []string{"web"}, ObjectSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"app": "nginx"}}}},
+				},
+			},
+		},
+		{
+			name: "selector only",
+			opts: policyCreateOptions{ClusterSelector: "tier in (edge)", ObjectSelector: "app=nginx"},
+			want: kubestellar.BindingPolicySpec{
+				ClusterSelectors: []metav1.LabelSelector{{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "tier", Operator: metav1.LabelSelectorOpIn, Values: []string{"edge"}}}}},
+				Downsync:         []kubestellar.DownsyncClause{{ObjectSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"app": "nginx"}}}}},
+			},
+		},
+		{
+			name:   "preset",
+			opts:   policyCreateOptions{ClusterSelector: "location=edge"},
+			preset: preset,
+			want: kubestellar.BindingPolicySpec{
+				ClusterSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"location": "edge"}}},
+				Downsync: []kubestellar.DownsyncClause{
+					{APIGroup: &core, Resources: []string{"services"}, Namespaces: []string{"prod"}, ObjectSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"app": "nginx"}}}},
+					{APIGroup: &apps, Resources: []string{"deployments"}, Namespaces: []string{"prod"}, ObjectSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"app": "nginx"}}}},
+				},
+			},
+		},
+		{
+			name:   "flags override the preset",
+			opts:   policyCreateOptions{ClusterSelector: "location=edge", ObjectSelector: "tier=web", Resources: []string{"configmaps"}, Namespaces: []string{"web"}},
+			preset: preset,
+			want: kubestellar.BindingPolicySpec{
+				ClusterSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"location": "edge"}}},
+				Downsync: []kubestellar.DownsyncClause{
+					{APIGroup: &core, Resources: []string{"configmaps"}, Namespaces: []string{"web"}, ObjectSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"tier": "web"}}}},
+				},
+			},
+		},
+		{name: "invalid preset", opts: policyCreateOptions{ClusterSelector: "location=edge"}, preset: &kubestellar.Preset{Name: "bad", ObjectSelector: "app={{.Missing}}"}, wantErr: true},
+		{name: "invalid cluster selector", opts: policyCreateOptions{ClusterSelector: "a in", ObjectSelector: "app=nginx"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := policyFromFlags("nginx", tt.opts, tt.preset, &wds)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("policyFromFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got, err := kubestellar.BindingPolicySpecFrom(policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("policyFromFlags() spec = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyWizardBuild(t *testing.T) {
+	clusters := []kubestellar.ClusterSummary{
+		{Name: "edge2", Labels: map[string]string{"name": "edge2", "location": "edge"}},
+		{Name: "edge1", Labels: map[string]string{"name": "edge1", "location": "edge"}},
+		{Name: "cloud1", Labels: map[string]string{"name": "cloud1", "location": "cloud"}},
+	}
+	deployments := schema.GroupResource{Group: "apps", Resource: "deployments"}
+	services := schema.GroupResource{Resource: "services"}
+	objs := []wdsObject{
+		{Resource: deployments, Namespace: "web", Labels: map[string]string{"app": "nginx", "tier": "front"}},
+		{Resource: services, Namespace: "web", Labels: map[string]string{"app": "nginx"}},
+		{Resource: deployments, Namespace: "db", Labels: map[string]string{"app": "redis"}},
+	}
+	apps := "apps"
+	nginx := []metav1.LabelSelector{{MatchLabels: map[string]string{"app": "nginx"}}}
+
+	// Clusters are listed cloud1, edge1, edge2; labels app=nginx, app=redis,
+	// tier=front; the nginx resource types apps/deployments, services
+	tests := []struct {
+		name    string
+		policy  string
+		input   string
+		want    kubestellar.BindingPolicySpec
+		wantErr bool
+	}{
+		{
+			name:  "shared cluster label and one resource type",
+			input: "web\n2-3\n1\n1\n",
+			want: kubestellar.BindingPolicySpec{
+				ClusterSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"location": "edge"}}},
+				Downsync:         []kubestellar.DownsyncClause{{APIGroup: &apps, Resources: []string{"deployments"}, ObjectSelectors: nginx}},
+			},
+		},
+		{
+			name:   "invalid answers are asked again",
+			policy: "web",
+			input:  "\n9\n1,3\n1,2\n1\n\n",
+			want: kubestellar.BindingPolicySpec{
+				ClusterSelectors: []metav1.LabelSelector{{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "name", Operator: metav1.LabelSelectorOpIn, Values: []string{"cloud1", "edge2"}}}}},
+				Downsync:         []kubestellar.DownsyncClause{{ObjectSelectors: nginx}},
+			},
+		},
+		{name: "input ends early", policy: "web", input: "1\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &policyWizard{prompter{in: bufio.NewReader(strings.NewReader(tt.input)), out: io.Discard}}
+			policy, err := w.build(tt.policy, append([]kubestellar.ClusterSummary(nil), clusters...), objs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("build() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if policy.GetName() != "web" {
+				t.Errorf("build() name = %s, want web", policy.GetName())
+			}
+			got, err := kubestellar.BindingPolicySpecFrom(policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("build() spec = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreatePolicyInWDSes(t *testing.T) {
+	tests := []struct {
+		name        string
+		existing    map[string]bool
+		wantErr     string
+		wantResults []audit.ClusterResult
+	}{
+		{
+			name:        "created in every WDS",
+			wantResults: []audit.ClusterResult{{Cluster: "wds1", Status: "ok"}, {Cluster: "wds2", Status: "ok"}},
+		},
+		{
+			name:     "already exists in one WDS",
+			existing: map[string]bool{"wds2": true},
+			wantErr:  "failed to create BindingPolicy web in 1 of 2 WDS(es)",
+			wantResults: []audit.ClusterResult{
+				{Cluster: "wds1", Status: "ok"},
+				{Cluster: "wds2", Status: "error", Error: `bindingpolicies.control.kubestellar.io "web" already exists`},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var wdses []*cluster.ClusterInfo
+			for _, name := range []string{"wds1", "wds2"} {
+				var objects []runtime.Object
+				if tt.existing[name] {
+					objects = append(objects, testBindingPolicy("web", map[string]interface{}{"old": "true"}))
+				}
+				wdses = append(wdses, &cluster.ClusterInfo{Name: name, Context: name, DynamicClient: testWDSDynamic(objects...)})
+			}
+			policy := testBindingPolicy("web", map[string]interface{}{"location": "edge"})
+			rec := audit.Start("bindingpolicy create", nil)
+
+			err := createPolicyInWDSes(policy, wdses, rec)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("createPolicyInWDSes() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Fatalf("createPolicyInWDSes() error = %v, want %q", err, tt.wantErr)
+			}
+			if got := resultsWithoutDurations(rec.Finish(nil)); !reflect.DeepEqual(got, tt.wantResults) {
+				t.Errorf("audit results = %+v, want %+v", got, tt.wantResults)
+			}
+			for _, wds := range wdses {
+				got, err := wds.DynamicClient.Resource(kubestellar.BindingPolicyGVR).Get(context.TODO(), "web", metav1.GetOptions{})
+				if err != nil {
+					t.Fatalf("BindingPolicy missing in %s: %v", wds.Context, err)
+				}
+				wantLabel := "edge"
+				if tt.existing[wds.Context] {
+					wantLabel = ""
+				}
+				spec, err := kubestellar.BindingPolicySpecFrom(got)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if label := spec.ClusterSelectors[0].MatchLabels["location"]; label != wantLabel {
+					t.Errorf("%s policy selects location %q, want %q", wds.Context, label, wantLabel)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,424 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/client-go/util/retry"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+	"kubectl-multi/pkg/util"
+)
+
+// configMapGVR is the resource of the ConfigMaps holding policy histories
+var configMapGVR = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+// policyHistory is the machine-readable revision history of a policy in a WDS
+type policyHistory struct {
+	Policy    string                       `json:"policy"`
+	WDS       string                       `json:"wds"`
+	Current   int                          `json:"current"`
+	Revisions []kubestellar.PolicyRevision `json:"revisions"`
+}
+
+func newBindingPolicyHistoryCommand() *cobra.Command {
+	var outputFormat string
+	var revision int
+
+	cmd := &cobra.Command{
+		Use:   "history POLICY",
+		Short: "List the revisions of a BindingPolicy recorded by kubectl multi",
+		Long: `List the revisions of a BindingPolicy in the WDS (the --wds-context, or
+every WDS given with --wds). Every change kubectl multi makes to a policy, with
+bp create, bp add-resources, bp remove-resources, bp rollback or deploy, records
+the resulting spec as a new revision in the ConfigMap
+` + kubestellar.PolicyHistoryNamespace + `/bindingpolicy-history-POLICY of the WDS. The last ` + fmt.Sprint(kubestellar.MaxPolicyRevisions) + `
+revisions are kept.
+
+The revision matching the live policy is marked with '*'. When none matches,
+the policy was changed outside kubectl multi since the last revision.`,
+		Example: `# Revisions of the nginx policy
+kubectl multi bp history nginx
+
+# The spec of revision 2
+kubectl multi bp history nginx --revision 2
+
+# Machine-readable history
+kubectl multi bp history nginx -o yaml`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch outputFormat {
+			case "", "json", "yaml", util.TableFormatCSV, util.TableFormatMarkdown:
+			default:
+				return fmt.Errorf("unsupported output format %q, must be one of json|yaml|csv|markdown", outputFormat)
+			}
+			if revision < 0 {
+				return fmt.Errorf("--revision must be a positive revision number")
+			}
+			kubeconfig, _, _, _, _ := GetGlobalFlags()
+			wdses, err := wdsClients(kubeconfig, GetWDSContexts())
+			if err != nil {
+				return err
+			}
+			return handleBindingPolicyHistory(args[0], revision, outputFormat, wdses, util.GetOutputStream())
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "output format (json|yaml|csv|markdown)")
+	cmd.Flags().IntVar(&revision, "revision", 0, "print the spec of this revision")
+
+	return cmd
+}
+
+func newBindingPolicyRollbackCommand() *cobra.Command {
+	var toRevision int
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "rollback POLICY",
+		Short: "Restore the spec a BindingPolicy had at an earlier revision",
+		Long: `Restore the spec of a BindingPolicy in the WDS (the --wds-context, or every
+WDS given with --wds) from its revision history, as listed by bp history.
+Without --to-revision the policy goes back to the revision before the current
+one, which reverts the last change made through kubectl multi, such as a
+cluster selector that no longer matches any cluster.
+
+The restored spec is recorded as a new revision, so a rollback can itself be
+rolled back.`,
+		Example: `# Revert the last change of the nginx policy
+kubectl multi bp rollback nginx
+
+# Go back to revision 2
+kubectl multi bp rollback nginx --to-revision 2
+
+# Show the restored policy without updating it
+kubectl multi bp rollback nginx --to-revision 2 --dry-run`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if toRevision < 0 {
+				return fmt.Errorf("--to-revision must be a positive revision number, or 0 for the previous revision")
+			}
+			kubeconfig, remoteCtx, _, _, _ := GetGlobalFlags()
+			wdses, err := wdsClients(kubeconfig, GetWDSContexts())
+			if err != nil {
+				return err
+			}
+			var rec *audit.Recorder
+			if !dryRun {
+				rec = startAudit("bindingpolicy rollback")
+			}
+			err = guardPolicyChange(rec, len(wdses), rollbackPolicies(args[0], toRevision, wdses), kubeconfig, remoteCtx)
+			if err == nil {
+				err = rollbackPolicy(args[0], toRevision, dryRun, wdses, rec, util.GetOutputStream())
+			}
+			finishAudit(rec, err)
+			return err
+		},
+	}
+
+	cmd.Flags().IntVar(&toRevision, "to-revision", 0, "revision to restore (0 for the previous revision)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only print the BindingPolicy as it would be restored")
+
+	return cmd
+}
+
+// wdsClients connects to every WDS context
+func wdsClients(kubeconfig string, wdsContexts []string) ([]*cluster.ClusterInfo, error) {
+	wdses := make([]*cluster.ClusterInfo, 0, len(wdsContexts))
+	for _, wdsContext := range wdsContexts {
+		wds, err := cluster.ClientForContext(kubeconfig, wdsContext)
+		if err != nil {
+			return nil, err
+		}
+		wdses = append(wdses, wds)
+	}
+	return wdses, nil
+}
+
+func handleBindingPolicyHistory(name string, revision int, outputFormat string, wdses []*cluster.ClusterInfo, out io.Writer) error {
+	histories := []policyHistory{}
+	for _, wds := range wdses {
+		live, err := wds.DynamicClient.Resource(kubestellar.BindingPolicyGVR).Get(commandContext(), name, metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to get BindingPolicy %s in WDS %s: %v", name, wds.Context, err)
+		}
+		revisions, err := readPolicyRevisions(wds, name)
+		if err != nil {
+			return err
+		}
+		if revisions == nil {
+			revisions = []kubestellar.PolicyRevision{}
+		}
+		h := policyHistory{Policy: name, WDS: wds.Context, Revisions: revisions}
+		if live != nil {
+			h.Current = kubestellar.CurrentRevision(revisions, policySpec(live))
+		}
+		if len(revisions) == 0 {
+			fmt.Fprintf(os.Stderr, "No revisions of BindingPolicy %s recorded in WDS %s.\n", name, wds.Context)
+		} else if live == nil {
+			fmt.Fprintf(os.Stderr, "Warning: BindingPolicy %s no longer exists in WDS %s\n", name, wds.Context)
+		} else if h.Current == 0 {
+			fmt.Fprintf(os.Stderr, "Warning: BindingPolicy %s in WDS %s was changed outside kubectl multi after revision %d\n", name, wds.Context, revisions[len(revisions)-1].Revision)
+		}
+		histories = append(histories, h)
+	}
+
+	if revision > 0 {
+		return printPolicyRevision(out, histories, revision, outputFormat)
+	}
+	if outputFormat == "json" || outputFormat == "yaml" {
+		return util.PrintStructured(out, outputFormat, histories)
+	}
+	for _, h := range histories {
+		if len(h.Revisions) == 0 {
+			continue
+		}
+		if len(histories) > 1 {
+			fmt.Fprintf(out, "=== WDS: %s ===\n", h.WDS)
+		}
+		printPolicyHistory(out, h, outputFormat)
+	}
+	return nil
+}
+
+func printPolicyHistory(out io.Writer, h policyHistory, outputFormat string) {
+	tw := util.NewTableWriter(out, outputFormat)
+	defer tw.Flush()
+
+	fmt.Fprintln(tw, "REVISION\tCURRENT\tAGE\tCLUSTER-SELECTORS\tDOWNSYNC\tCAUSE")
+	for _, rev := range h.Revisions {
+		current := ""
+		if rev.Revision == h.Current {
+			current = "*"
+		}
+		selectors, downsync := describeRevisionSpec(rev.Spec)
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n", rev.Revision, current, duration.HumanDuration(time.Since(rev.Time)), selectors, downsync, orNone(rev.Cause))
+	}
+}
+
+// printPolicyRevision prints the spec of one revision from every WDS
+// holding it
+func printPolicyRevision(out io.Writer, histories []policyHistory, revision int, outputFormat string) error {
+	if outputFormat == "" || outputFormat == util.TableFormatCSV || outputFormat == util.TableFormatMarkdown {
+		outputFormat = "yaml"
+	}
+	found := 0
+	for _, h := range histories {
+		for _, rev := range h.Revisions {
+			if rev.Revision != revision {
+				continue
+			}
+			if len(histories) > 1 {
+				fmt.Fprintf(out, "=== WDS: %s ===\n", h.WDS)
+			}
+			if err := util.PrintStructured(out, outputFormat, rev); err != nil {
+				return err
+			}
+			found++
+		}
+	}
+	if found == 0 {
+		return fmt.Errorf("revision %d of BindingPolicy %s not found", revision, histories[0].Policy)
+	}
+	return nil
+}
+
+// describeRevisionSpec summarizes the cluster selectors and downsync clauses
+// of a revision like bp list does
+func describeRevisionSpec(spec map[string]interface{}) (string, string) {
+	decoded, err := kubestellar.BindingPolicySpecFrom(&unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}})
+	if err != nil {
+		return "<invalid>", "<invalid>"
+	}
+	var clauses []string
+	for _, clause := range decoded.Downsync {
+		clauses = append(clauses, kubestellar.DescribeClause(clause))
+	}
+	return orNone(kubestellar.FormatSelectors(decoded.ClusterSelectors)), orNone(strings.Join(clauses, "; "))
+}
+
+// rollbackPolicy restores the spec of a revision of the policy in every WDS,
+// retrying when the policy changed in between, and records the restored
+// spec as a new revision. Policies already at that revision are not written.
+func rollbackPolicy(name string, toRevision int, dryRun bool, wdses []*cluster.ClusterInfo, rec *audit.Recorder, out io.Writer) error {
+	failed := 0
+	for _, wds := range wdses {
+		policies := wds.DynamicClient.Resource(kubestellar.BindingPolicyGVR)
+		var live, restored *unstructured.Unstructured
+		var target *kubestellar.PolicyRevision
+		unchanged := false
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			var err error
+			if live, err = policies.Get(commandContext(), name, metav1.GetOptions{}); err != nil {
+				return err
+			}
+			revisions, err := readPolicyRevisions(wds, name)
+			if err != nil {
+				return err
+			}
+			current := kubestellar.CurrentRevision(revisions, policySpec(live))
+			if target, err = kubestellar.RollbackTarget(revisions, current, toRevision); err != nil {
+				return fmt.Errorf("cannot roll back BindingPolicy %s: %v", name, err)
+			}
+			restored = live.DeepCopy()
+			restored.Object["spec"] = runtime.DeepCopyJSONValue(target.Spec)
+			if unchanged = kubestellar.SameSpec(policySpec(live), target.Spec); unchanged || dryRun {
+				return nil
+			}
+			util.MarkManaged(restored)
+			restored, err = policies.Update(commandContext(), restored, metav1.UpdateOptions{FieldManager: util.FieldManager})
+			return err
+		})
+		if !dryRun && !unchanged || err != nil {
+			rec.Record(wds.Context, err)
+		}
+		switch {
+		case err != nil:
+			fmt.Fprintf(out, "%s: error: %v\n", wds.Context, err)
+			failed++
+		case dryRun:
+			if err := printPolicy(out, restored); err != nil {
+				return err
+			}
+		case unchanged:
+			fmt.Fprintf(out, "BindingPolicy %s in WDS %s already at revision %d\n", name, wds.Context, target.Revision)
+		default:
+			revision, err := recordPolicyRevision(wds, live, restored, fmt.Sprintf("bindingpolicy rollback to revision %d", target.Revision))
+			if err != nil {
+				fmt.Fprintf(out, "BindingPolicy %s in WDS %s rolled back to revision %d\n", name, wds.Context, target.Revision)
+				fmt.Fprintf(out, "Warning: revision history of BindingPolicy %s in WDS %s not recorded: %v\n", name, wds.Context, err)
+				continue
+			}
+			fmt.Fprintf(out, "BindingPolicy %s in WDS %s rolled back to revision %d as revision %d\n", name, wds.Context, target.Revision, revision)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to roll back BindingPolicy %s in %d of %d WDS(es)", name, failed, len(wdses))
+	}
+	return nil
+}
+
+// rollbackPolicies returns the policy called name in each WDS as it is and
+// as the rollback restores it, for the blast radius of the rollback
+func rollbackPolicies(name string, toRevision int, wdses []*cluster.ClusterInfo) []*unstructured.Unstructured {
+	var policies []*unstructured.Unstructured
+	for _, wds := range wdses {
+		live, err := wds.DynamicClient.Resource(kubestellar.BindingPolicyGVR).Get(commandContext(), name, metav1.GetOptions{})
+		if err != nil {
+			continue
+		}
+		policies = append(policies, live)
+		revisions, err := readPolicyRevisions(wds, name)
+		if err != nil {
+			continue
+		}
+		target, err := kubestellar.RollbackTarget(revisions, kubestellar.CurrentRevision(revisions, policySpec(live)), toRevision)
+		if err != nil {
+			continue
+		}
+		restored := live.DeepCopy()
+		restored.Object["spec"] = runtime.DeepCopyJSONValue(target.Spec)
+		policies = append(policies, restored)
+	}
+	return policies
+}
+
+// readPolicyRevisions returns the recorded revisions of a policy, none when
+// its history ConfigMap does not exist
+func readPolicyRevisions(wds *cluster.ClusterInfo, name string) ([]kubestellar.PolicyRevision, error) {
+	cmName := kubestellar.PolicyHistoryConfigMap(name)
+	cm, err := wds.DynamicClient.Resource(configMapGVR).Namespace(kubestellar.PolicyHistoryNamespace).Get(commandContext(), cmName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get history configmap %s/%s in WDS %s: %v", kubestellar.PolicyHistoryNamespace, cmName, wds.Context, err)
+	}
+	data, _, _ := unstructured.NestedStringMap(cm.Object, "data")
+	revisions, err := kubestellar.PolicyRevisions(data)
+	if err != nil {
+		return nil, fmt.Errorf("history configmap %s/%s in WDS %s: %v", kubestellar.PolicyHistoryNamespace, cmName, wds.Context, err)
+	}
+	return revisions, nil
+}
+
+// recordPolicyRevision adds the spec of policy to its history in the WDS and
+// returns its revision. The first time a policy changed by kubectl multi
+// gets a history, its spec before the change, previous, is recorded first so
+// the change can be rolled back.
+func recordPolicyRevision(wds *cluster.ClusterInfo, previous, policy *unstructured.Unstructured, cause string) (int, error) {
+	cmName := kubestellar.PolicyHistoryConfigMap(policy.GetName())
+	cms := wds.DynamicClient.Resource(configMapGVR).Namespace(kubestellar.PolicyHistoryNamespace)
+	revision := 0
+	// Not the command context: the policy already changed, so a cancelled
+	// command still records it
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, err := cms.Get(context.TODO(), cmName, metav1.GetOptions{})
+		exists := err == nil
+		if apierrors.IsNotFound(err) {
+			cm = &unstructured.Unstructured{}
+			cm.SetAPIVersion("v1")
+			cm.SetKind("ConfigMap")
+			cm.SetNamespace(kubestellar.PolicyHistoryNamespace)
+			cm.SetName(cmName)
+			cm.SetLabels(map[string]string{kubestellar.PolicyLabel: policy.GetName()})
+		} else if err != nil {
+			return err
+		}
+
+		data, _, _ := unstructured.NestedStringMap(cm.Object, "data")
+		if data == nil {
+			data = map[string]string{}
+		}
+		now := time.Now()
+		if previous != nil && len(data) == 0 {
+			if _, _, err := kubestellar.AddPolicyRevision(data, policySpec(previous), "before the first change by kubectl multi", now); err != nil {
+				return err
+			}
+		}
+		if revision, _, err = kubestellar.AddPolicyRevision(data, policySpec(policy), cause, now); err != nil {
+			return err
+		}
+		if err := unstructured.SetNestedStringMap(cm.Object, data, "data"); err != nil {
+			return err
+		}
+		util.MarkManaged(cm)
+		if exists {
+			_, err = cms.Update(context.TODO(), cm, metav1.UpdateOptions{FieldManager: util.FieldManager})
+		} else {
+			_, err = cms.Create(context.TODO(), cm, metav1.CreateOptions{FieldManager: util.FieldManager})
+		}
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to update history configmap %s/%s: %v", kubestellar.PolicyHistoryNamespace, cmName, err)
+	}
+	return revision, nil
+}
+
+// notePolicyRevision records a revision of the policy, warning when the
+// history cannot be written: the change itself succeeded
+func notePolicyRevision(out io.Writer, wds *cluster.ClusterInfo, previous, policy *unstructured.Unstructured, cause string) {
+	if _, err := recordPolicyRevision(wds, previous, policy, cause); err != nil {
+		fmt.Fprintf(out, "Warning: revision history of BindingPolicy %s in WDS %s not recorded: %v\n", policy.GetName(), wds.Context, err)
+	}
+}
+
+// policySpec returns a copy of the spec of a policy
+func policySpec(policy *unstructured.Unstructured) map[string]interface{} {
+	spec, _, _ := unstructured.NestedMap(policy.Object, "spec")
+	return spec
+}
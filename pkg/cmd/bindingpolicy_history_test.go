@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+)
+
+// policyResources returns the resources of the first downsync clause of the
+// web policy in the WDS
+func policyResources(t *testing.T, wds *cluster.ClusterInfo) []interface{} {
+	t.Helper()
+	got, err := wds.DynamicClient.Resource(kubestellar.BindingPolicyGVR).Get(context.TODO(), "web", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	downsync, _, _ := unstructured.NestedSlice(got.Object, "spec", "downsync")
+	return downsync[0].(map[string]interface{})["resources"].([]interface{})
+}
+
+func TestPolicyHistoryAndRollback(t *testing.T) {
+	wds := &cluster.ClusterInfo{Name: "wds1", Context: "wds1", DynamicClient: testWDSDynamic(testDownsyncBindingPolicy("services"))}
+	wdses := []*cluster.ClusterInfo{wds}
+	var out bytes.Buffer
+
+	if err := updatePolicyResources("web", []schema.GroupResource{{Resource: "configmaps"}}, false, false, wdses, nil, &out); err != nil {
+		t.Fatalf("updatePolicyResources() error = %v\n%s", err, out.String())
+	}
+	revisions, err := readPolicyRevisions(wds, "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 2 || revisions[0].Cause != "before the first change by kubectl multi" || revisions[1].Cause != "bindingpolicy add-resources configmaps" {
+		t.Fatalf("revisions after add-resources = %+v, want the spec before and after the change", revisions)
+	}
+
+	out.Reset()
+	rec := audit.Start("bindingpolicy rollback", nil)
+	if err := rollbackPolicy("web", 0, false, wdses, rec, &out); err != nil {
+		t.Fatalf("rollbackPolicy() error = %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "BindingPolicy web in WDS wds1 rolled back to revision 1 as revision 3") {
+		t.Errorf("output %q does not report the rollback", out.String())
+	}
+	if got := policyResources(t, wds); !reflect.DeepEqual(got, []interface{}{"services"}) {
+		t.Errorf("policy downsyncs %v after rollback, want [services]", got)
+	}
+	if got := resultsWithoutDurations(rec.Finish(nil)); !reflect.DeepEqual(got, []audit.ClusterResult{{Cluster: "wds1", Status: "ok"}}) {
+		t.Errorf("audit results = %+v", got)
+	}
+
+	// Rolling back the rollback restores the change
+	out.Reset()
+	if err := rollbackPolicy("web", 0, false, wdses, nil, &out); err != nil {
+		t.Fatalf("rollbackPolicy() error = %v\n%s", err, out.String())
+	}
+	if got := policyResources(t, wds); !reflect.DeepEqual(got, []interface{}{"configmaps", "services"}) {
+		t.Errorf("policy downsyncs %v after the second rollback, want [configmaps services]", got)
+	}
+
+	out.Reset()
+	if err := rollbackPolicy("web", 4, false, wdses, nil, &out); err != nil {
+		t.Fatalf("rollbackPolicy() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "already at revision 4") {
+		t.Errorf("output %q, want the policy already at revision 4", out.String())
+	}
+
+	out.Reset()
+	err = rollbackPolicy("web", 9, false, wdses, nil, &out)
+	if err == nil || !strings.Contains(out.String(), "revision 9 not found") {
+		t.Errorf("rollbackPolicy() to a missing revision = %v, output %q", err, out.String())
+	}
+
+	out.Reset()
+	if err := handleBindingPolicyHistory("web", 0, "", wdses, &out); err != nil {
+		t.Fatalf("handleBindingPolicyHistory() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("history table has %d lines, want a header and 4 revisions:\n%s", len(lines), out.String())
+	}
+	if fields := strings.Fields(lines[4]); fields[0] != "4" || fields[1] != "*" || !strings.Contains(lines[4], "bindingpolicy rollback to revision 2") {
+		t.Errorf("last revision line = %q, want revision 4 current", lines[4])
+	}
+}
+
+func TestRollbackPolicyDryRun(t *testing.T) {
+	wds := &cluster.ClusterInfo{Name: "wds1", Context: "wds1", DynamicClient: testWDSDynamic(testDownsyncBindingPolicy("services"))}
+	wdses := []*cluster.ClusterInfo{wds}
+	var out bytes.Buffer
+	if err := updatePolicyResources("web", []schema.GroupResource{{Resource: "configmaps"}}, false, false, wdses, nil, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	if err := rollbackPolicy("web", 1, true, wdses, nil, &out); err != nil {
+		t.Fatalf("rollbackPolicy() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "- services\n") || strings.Contains(out.String(), "- configmaps\n") {
+		t.Errorf("dry run output %q, want the policy of revision 1", out.String())
+	}
+	if got := policyResources(t, wds); !reflect.DeepEqual(got, []interface{}{"configmaps", "services"}) {
+		t.Errorf("dry run changed the policy to %v", got)
+	}
+}
+
+func TestRollbackPolicyWithoutHistory(t *testing.T) {
+	wds := &cluster.ClusterInfo{Name: "wds1", Context: "wds1", DynamicClient: testWDSDynamic(testDownsyncBindingPolicy("services"))}
+	var out bytes.Buffer
+	err := rollbackPolicy("web", 0, false, []*cluster.ClusterInfo{wds}, nil, &out)
+	if err == nil || !strings.Contains(out.String(), "cannot roll back BindingPolicy web: no revisions recorded") {
+		t.Errorf("rollbackPolicy() = %v, output %q", err, out.String())
+	}
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"kubectl-multi/pkg/config"
+	"kubectl-multi/pkg/kubestellar"
+	"kubectl-multi/pkg/util"
+)
+
+func newBindingPolicyPresetsCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "presets",
+		Short: "List the presets bp create --preset expands",
+		Long: `List the presets bp create --preset expands into resource types and an
+object selector, in which {{.Name}} stands for the policy name.
+
+Besides the built-in presets, every *.yaml file in the kubectl-multi-presets
+directory next to the config file (~/.kube/kubectl-multi-presets unless
+KUBECTL_MULTI_CONFIG moves it) is a preset named after the file, replacing a
+built-in one of the same name:
+
+  description: Deployments and their services
+  objectSelector: app.kubernetes.io/name={{.Name}}
+  resources:
+  - deployments.apps
+  - services
+  namespaces:
+  - prod`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			presets, err := kubestellar.LoadPresets(config.PresetsDir())
+			if err != nil {
+				return err
+			}
+			printPresets(util.GetOutputStream(), presets)
+			return nil
+		},
+	}
+}
+
+func printPresets(w io.Writer, presets map[string]kubestellar.Preset) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	defer tw.Flush()
+
+	orNone := func(s string) string {
+		if s == "" {
+			return "<none>"
+		}
+		return s
+	}
+	fmt.Fprintf(tw, "PRESET\tSOURCE\tOBJECT SELECTOR\tRESOURCES\tDESCRIPTION\n")
+	for _, name := range kubestellar.PresetNames(presets) {
+		p := presets[name]
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", name, p.Source, orNone(p.ObjectSelector), orNone(strings.Join(p.Resources, ",")), p.Description)
+	}
+}
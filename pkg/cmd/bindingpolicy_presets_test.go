@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"kubectl-multi/pkg/kubestellar"
+)
+
+func TestPrintPresets(t *testing.T) {
+	tests := []struct {
+		name    string
+		presets map[string]kubestellar.Preset
+		want    []string
+	}{
+		{
+			name:    "none",
+			presets: map[string]kubestellar.Preset{},
+			want:    []string{"PRESET  SOURCE  OBJECT SELECTOR  RESOURCES  DESCRIPTION"},
+		},
+		{
+			name: "sorted with placeholders",
+			presets: map[string]kubestellar.Preset{
+				"web":   {Source: "built-in", ObjectSelector: "app={{.Name}}", Resources: []string{"deployments.apps", "services"}, Description: "Web apps"},
+				"cache": {Source: "/home/me/.kube/kubectl-multi-presets/cache.yaml", ObjectSelector: "tier=cache"},
+			},
+			want: []string{
+				"PRESET  SOURCE                                           OBJECT SELECTOR  RESOURCES                  DESCRIPTION",
+				"cache   /home/me/.kube/kubectl-multi-presets/cache.yaml  tier=cache       <none>                     ",
+				"web     built-in                                         app={{.Name}}    deployments.apps,services  Web apps",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			printPresets(&out, tt.presets)
+			got := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("printPresets() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
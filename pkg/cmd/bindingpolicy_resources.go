@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+	"kubectl-multi/pkg/util"
+)
+
+func newBindingPolicyAddResourcesCommand() *cobra.Command {
+	return newBindingPolicyResourcesCommand("add-resources", false)
+}
+
+func newBindingPolicyRemoveResourcesCommand() *cobra.Command {
+	return newBindingPolicyResourcesCommand("remove-resources", true)
+}
+
+// newBindingPolicyResourcesCommand builds bp add-resources and bp
+// remove-resources, which differ only in the direction of the change
+func newBindingPolicyResourcesCommand(use string, remove bool) *cobra.Command {
+	var dryRun bool
+
+	short := "Add resource types to the downsync clauses of a BindingPolicy"
+	example := `# Also downsync the deployments and services the policy's selectors match
+kubectl multi bp add-resources nginx apps/v1:Deployment v1:Service
+
+# Resource names work too
+kubectl multi bp add-resources nginx configmaps ingresses.networking.k8s.io
+
+# Show the changed policy without updating it
+kubectl multi bp add-resources nginx v1:Secret --dry-run`
+	if remove {
+		short = "Remove resource types from the downsync clauses of a BindingPolicy"
+		example = `# Stop downsyncing the services of the policy
+kubectl multi bp remove-resources nginx v1:Service
+
+# Show the changed policy without updating it
+kubectl multi bp remove-resources nginx apps/v1:Deployment --dry-run`
+	}
+
+	cmd := &cobra.Command{
+		Use:   use + " POLICY TYPE...",
+		Short: short,
+		Long: short + ` in the WDS (the --wds-context, or every WDS given with
+--wds). TYPE is GROUP/VERSION:Kind, such as apps/v1:Deployment or v1:Service,
+or a resource name as kubectl get takes it.
+
+The label, namespace and object name selectors of the clauses are kept.
+Adding a type gives every distinct set of selectors a clause covering it,
+removing one takes it out of the resource lists and drops clauses left
+without a type. Types already added or removed are skipped, so the command
+can be repeated safely.`,
+		Example: example,
+		Args:    cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kubeconfig, remoteCtx, _, _, _ := GetGlobalFlags()
+			var rec *audit.Recorder
+			if !dryRun {
+				rec = startAudit("bindingpolicy " + use)
+			}
+			err := handleBindingPolicyResources(args[0], args[1:], remove, dryRun, rec, kubeconfig, remoteCtx, GetWDSContexts())
+			finishAudit(rec, err)
+			return err
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only print the BindingPolicy as it would be updated")
+
+	return cmd
+}
+
+func handleBindingPolicyResources(name string, typeArgs []string, remove, dryRun bool, rec *audit.Recorder, kubeconfig, remoteCtx string, wdsContexts []string) error {
+	wdses := make([]*cluster.ClusterInfo, 0, len(wdsContexts))
+	for _, wdsContext := range wdsContexts {
+		wds, err := cluster.ClientForContext(kubeconfig, wdsContext)
+		if err != nil {
+			return err
+		}
+		wdses = append(wdses, wds)
+	}
+	resources, err := resolveResourceTypes(typeArgs, wdses[0])
+	if err != nil {
+		return err
+	}
+	// The resource types change, not the clusters the policies select
+	if err := guardPolicyChange(rec, len(wdses), livePolicies(wdses, name), kubeconfig, remoteCtx); err != nil {
+		return err
+	}
+	return updatePolicyResources(name, resources, remove, dryRun, wdses, rec, util.GetOutputStream())
+}
+
+// resolveResourceTypes maps GROUP/VERSION:Kind arguments, or resource names,
+// to the resource types the WDS serves
+func resolveResourceTypes(args []string, wds *cluster.ClusterInfo) ([]schema.GroupResource, error) {
+	var mapper meta.RESTMapper
+	var resources []schema.GroupResource
+	for _, arg := range args {
+		gvString, kind, found := strings.Cut(arg, ":")
+		if !found {
+			gvr, _, err := util.DiscoverGVR(wds.DiscoveryClient, arg)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve resource type %s: %v", arg, err)
+			}
+			resources = append(resources, gvr.GroupResource())
+			continue
+		}
+		gv, err := schema.ParseGroupVersion(gvString)
+		if err != nil || gv.Version == "" || kind == "" {
+			return nil, fmt.Errorf("invalid resource type %q, must be GROUP/VERSION:Kind such as apps/v1:Deployment, or a resource name", arg)
+		}
+		if mapper == nil {
+			mapper = wds.Mapper()
+		}
+		mapping, err := mapper.RESTMapping(gv.WithKind(kind).GroupKind(), gv.Version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve resource type %s: %v", arg, err)
+		}
+		resources = append(resources, mapping.Resource.GroupResource())
+	}
+	return resources, nil
+}
+
+// updatePolicyResources adds or removes the resource types in the policy of
+// every WDS, retrying when the policy changed in between. Policies that
+// already have the requested types are not written.
+func updatePolicyResources(name string, resources []schema.GroupResource, remove, dryRun bool, wdses []*cluster.ClusterInfo, rec *audit.Recorder, out io.Writer) error {
+	use, verb, change := "add-resources", "added", kubestellar.AddDownsyncResources
+	if remove {
+		use, verb, change = "remove-resources", "removed", kubestellar.RemoveDownsyncResources
+	}
+
+	failed := 0
+	for _, wds := range wdses {
+		policies := wds.DynamicClient.Resource(kubestellar.BindingPolicyGVR)
+		var changed []schema.GroupResource
+		var live, updated *unstructured.Unstructured
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			var err error
+			if live, err = policies.Get(commandContext(), name, metav1.GetOptions{}); err != nil {
+				return err
+			}
+			updated = live.DeepCopy()
+			if changed, err = change(updated, resources); err != nil || len(changed) == 0 || dryRun {
+				return err
+			}
+			patch, err := policyDownsyncPatch(updated)
+			if err != nil {
+				return err
+			}
+			if patch, err = util.ManagedPatch(types.MergePatchType, patch, live); err != nil {
+				return err
+			}
+			updated, err = policies.Patch(commandContext(), name, types.MergePatchType, patch, metav1.PatchOptions{FieldManager: util.FieldManager})
+			return err
+		})
+		if !dryRun && len(changed) > 0 || err != nil {
+			rec.Record(wds.Context, err)
+		}
+		switch {
+		case err != nil:
+			fmt.Fprintf(out, "%s: error: %v\n", wds.Context, err)
+			failed++
+		case dryRun:
+			if err := printPolicy(out, updated); err != nil {
+				return err
+			}
+		case len(changed) == 0:
+			fmt.Fprintf(out, "BindingPolicy %s in WDS %s unchanged\n", name, wds.Context)
+		default:
+			fmt.Fprintf(out, "BindingPolicy %s in WDS %s: %s %s\n", name, wds.Context, verb, kubestellar.FormatGroupResources(changed))
+			notePolicyRevision(out, wds, live, updated, fmt.Sprintf("bindingpolicy %s %s", use, kubestellar.FormatGroupResources(changed)))
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to update BindingPolicy %s in %d of %d WDS(es)", name, failed, len(wdses))
+	}
+	return nil
+}
+
+// policyDownsyncPatch returns a merge patch replacing the downsync clauses of
+// the policy with its current ones. The resourceVersion makes the update
+// fail with a conflict when the policy changed since it was read.
+func policyDownsyncPatch(policy *unstructured.Unstructured) ([]byte, error) {
+	downsync, _, err := unstructured.NestedSlice(policy.Object, "spec", "downsync")
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"resourceVersion": policy.GetResourceVersion()},
+		"spec":     map[string]interface{}{"downsync": downsync},
+	})
+}
@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clienttesting "k8s.io/client-go/testing"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+	"kubectl-multi/pkg/util"
+)
+
+func TestResolveResourceTypes(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    []schema.GroupResource
+		wantErr string
+	}{
+		{
+			name: "group version kind",
+			args: []string{"apps/v1:Deployment", "v1:ConfigMap"},
+			want: []schema.GroupResource{{Group: "apps", Resource: "deployments"}, {Resource: "configmaps"}},
+		},
+		{
+			name: "resource names",
+			args: []string{"deployments", "cm"},
+			want: []schema.GroupResource{{Group: "apps", Resource: "deployments"}, {Resource: "configmaps"}},
+		},
+		{name: "invalid group version", args: []string{"apps/v1/x:Deployment"}, wantErr: "invalid resource type"},
+		{name: "missing kind", args: []string{"apps/v1:"}, wantErr: "invalid resource type"},
+		{name: "unknown kind", args: []string{"apps/v1:Widget"}, wantErr: "failed to resolve resource type apps/v1:Widget"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wds, _ := testClusterInfo()
+			got, err := resolveResourceTypes(tt.args, &wds)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("resolveResourceTypes() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveResourceTypes() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolveResourceTypes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// testDownsyncBindingPolicy returns a BindingPolicy downsyncing the core
+// resources of the objects labeled app=web
+func testDownsyncBindingPolicy(resources ...interface{}) *unstructured.Unstructured {
+	policy := testBindingPolicy("web", map[string]interface{}{"location": "edge"})
+	policy.SetResourceVersion("7")
+	unstructured.SetNestedSlice(policy.Object, []interface{}{map[string]interface{}{
+		"apiGroup":        "",
+		"resources":       resources,
+		"objectSelectors": []interface{}{map[string]interface{}{"matchLabels": map[string]interface{}{"app": "web"}}},
+	}}, "spec", "downsync")
+	return policy
+}
+
+func TestUpdatePolicyResources(t *testing.T) {
+	configmaps := schema.GroupResource{Resource: "configmaps"}
+	services := schema.GroupResource{Resource: "services"}
+
+	tests := []struct {
+		name          string
+		resources     []schema.GroupResource
+		remove        bool
+		dryRun        bool
+		missing       map[string]bool
+		conflicts     int
+		wantResources []interface{}
+		wantOut       []string
+		wantErr       string
+		wantResults   []audit.ClusterResult
+		wantManaged   bool
+	}{
+		{
+			name:          "add",
+			resources:     []schema.GroupResource{configmaps},
+			wantResources: []interface{}{"configmaps", "services"},
+			wantOut:       []string{"BindingPolicy web in WDS wds1: added configmaps", "BindingPolicy web in WDS wds2: added configmaps"},
+			wantResults:   []audit.ClusterResult{{Cluster: "wds1", Status: "ok"}, {Cluster: "wds2", Status: "ok"}},
+			wantManaged:   true,
+		},
+		{
+			name:          "add again",
+			resources:     []schema.GroupResource{services},
+			wantResources: []interface{}{"services"},
+			wantOut:       []string{"BindingPolicy web in WDS wds1 unchanged", "BindingPolicy web in WDS wds2 unchanged"},
+		},
+		{
+			name:          "remove",
+			resources:     []schema.GroupResource{configmaps},
+			remove:        true,
+			wantResources: []interface{}{"services"},
+			wantOut:       []string{"BindingPolicy web in WDS wds1 unchanged"},
+		},
+		{
+			name:          "remove last type",
+			resources:     []schema.GroupResource{services},
+			remove:        true,
+			wantResources: []interface{}{"services"},
+			wantOut:       []string{"wds1: error: removing services would leave BindingPolicy web without downsync clauses"},
+			wantErr:       "failed to update BindingPolicy web in 2 of 2 WDS(es)",
+			wantResults: []audit.ClusterResult{
+				{Cluster: "wds1", Status: "error", Error: "removing services would leave BindingPolicy web without downsync clauses; delete the policy instead"},
+				{Cluster: "wds2", Status: "error", Error: "removing services would leave BindingPolicy web without downsync clauses; delete the policy instead"},
+			},
+		},
+		{
+			name:          "dry run",
+			resources:     []schema.GroupResource{configmaps},
+			dryRun:        true,
+			wantResources: []interface{}{"services"},
+			wantOut:       []string{"- configmaps\n", "name: web"},
+		},
+		{
+			name:          "missing in one WDS",
+			resources:     []schema.GroupResource{configmaps},
+			missing:       map[string]bool{"wds2": true},
+			wantResources: []interface{}{"configmaps", "services"},
+			wantOut:       []string{"BindingPolicy web in WDS wds1: added configmaps", `wds2: error: bindingpolicies.control.kubestellar.io "web" not found`},
+			wantErr:       "failed to update BindingPolicy web in 1 of 2 WDS(es)",
+			wantResults: []audit.ClusterResult{
+				{Cluster: "wds1", Status: "ok"},
+				{Cluster: "wds2", Status: "error", Error: `bindingpolicies.control.kubestellar.io "web" not found`},
+			},
+			wantManaged: true,
+		},
+		{
+			name:          "retries on conflict",
+			resources:     []schema.GroupResource{configmaps},
+			conflicts:     1,
+			wantResources: []interface{}{"configmaps", "services"},
+			wantOut:       []string{"BindingPolicy web in WDS wds1: added configmaps"},
+			wantResults:   []audit.ClusterResult{{Cluster: "wds1", Status: "ok"}, {Cluster: "wds2", Status: "ok"}},
+			wantManaged:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var wdses []*cluster.ClusterInfo
+			for _, name := range []string{"wds1", "wds2"} {
+				var objects []runtime.Object
+				if !tt.missing[name] {
+					objects = append(objects, testDownsyncBindingPolicy("services"))
+				}
+				dyn := testWDSDynamic(objects...)
+				conflicts := tt.conflicts
+				dyn.PrependReactor("patch", "bindingpolicies", func(clienttesting.Action) (bool, runtime.Object, error) {
+					if conflicts == 0 {
+						return false, nil, nil
+					}
+					conflicts--
+					return true, nil, errors.NewConflict(kubestellar.BindingPolicyGVR.GroupResource(), "web", nil)
+				})
+				wdses = append(wdses, &cluster.ClusterInfo{Name: name, Context: name, DynamicClient: dyn})
+			}
+			rec := audit.Start("bindingpolicy add-resources", nil)
+			var out bytes.Buffer
+
+			err := updatePolicyResources("web", tt.resources, tt.remove, tt.dryRun, wdses, rec, &out)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("updatePolicyResources() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Fatalf("updatePolicyResources() error = %v, want %q", err, tt.wantErr)
+			}
+			for _, want := range tt.wantOut {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output %q does not contain %q", out.String(), want)
+				}
+			}
+			if got := resultsWithoutDurations(rec.Finish(nil)); !reflect.DeepEqual(got, tt.wantResults) {
+				t.Errorf("audit results = %+v, want %+v", got, tt.wantResults)
+			}
+			for _, wds := range wdses {
+				if tt.missing[wds.Context] {
+					continue
+				}
+				got, err := wds.DynamicClient.Resource(kubestellar.BindingPolicyGVR).Get(context.TODO(), "web", metav1.GetOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				downsync, _, _ := unstructured.NestedSlice(got.Object, "spec", "downsync")
+				if res := downsync[0].(map[string]interface{})["resources"]; !reflect.DeepEqual(res, tt.wantResources) {
+					t.Errorf("%s downsyncs %v, want %v", wds.Context, res, tt.wantResources)
+				}
+				if util.IsManaged(got) != tt.wantManaged {
+					t.Errorf("%s policy managed = %v, want %v", wds.Context, util.IsManaged(got), tt.wantManaged)
+				}
+			}
+		})
+	}
+}
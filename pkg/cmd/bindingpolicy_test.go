@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"kubectl-multi/pkg/kubestellar"
+)
+
+// testWDSDynamic returns a fake WDS client serving BindingPolicies and Bindings
+func testWDSDynamic(objects ...runtime.Object) *fakedynamic.FakeDynamicClient {
+	listKinds := map[schema.GroupVersionResource]string{
+		kubestellar.BindingPolicyGVR: "BindingPolicyList",
+		kubestellar.BindingGVR:       "BindingList",
+	}
+	return fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objects...)
+}
+
+func testBindingPolicy(name string, clusterLabels map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": kubestellar.BindingPolicyGVR.GroupVersion().String(),
+		"kind":       "BindingPolicy",
+		"metadata":   map[string]interface{}{"name": name},
+		"spec": map[string]interface{}{
+			"clusterSelectors": []interface{}{map[string]interface{}{"matchLabels": clusterLabels}},
+		},
+	}}
+}
+
+func testBinding(name string, clusters ...string) *unstructured.Unstructured {
+	var destinations []interface{}
+	for _, c := range clusters {
+		destinations = append(destinations, map[string]interface{}{"clusterId": c})
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": kubestellar.BindingGVR.GroupVersion().String(),
+		"kind":       "Binding",
+		"metadata":   map[string]interface{}{"name": name},
+		"spec":       map[string]interface{}{"destinations": destinations},
+	}}
+}
+
+func TestListPolicySummaries(t *testing.T) {
+	tests := []struct {
+		name         string
+		objects      []runtime.Object
+		failBindings bool
+		failPolicies bool
+		want         map[string][]string
+		wantErr      bool
+	}{
+		{
+			name: "policies with their bindings",
+			objects: []runtime.Object{
+				testBindingPolicy("web", map[string]interface{}{"location": "edge"}),
+				testBindingPolicy("db", map[string]interface{}{"tier": "data"}),
+				testBinding("web", "cluster1", "cluster2"),
+			},
+			want: map[string][]string{"db": {}, "web": {"cluster1", "cluster2"}},
+		},
+		{
+			name:         "bindings that cannot be listed only warn",
+			objects:      []runtime.Object{testBindingPolicy("web", map[string]interface{}{"location": "edge"})},
+			failBindings: true,
+			want:         map[string][]string{"web": {}},
+		},
+		{name: "no policies", want: map[string][]string{}},
+		{name: "policies that cannot be listed fail", failPolicies: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wds := testWDSDynamic(tt.objects...)
+			wds.PrependReactor("list", "*", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				resource := action.GetResource().Resource
+				if (tt.failBindings && resource == "bindings") || (tt.failPolicies && resource == "bindingpolicies") {
+					return true, nil, fmt.Errorf("forbidden")
+				}
+				return false, nil, nil
+			})
+
+			summaries, err := listPolicySummaries(wds, "wds1")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("listPolicySummaries() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got := map[string][]string{}
+			var names []string
+			for _, s := range summaries {
+				if s.WDS != "wds1" {
+					t.Errorf("summary %s has WDS %q, want wds1", s.Name, s.WDS)
+				}
+				got[s.Name] = s.Clusters
+				names = append(names, s.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("listPolicySummaries() clusters = %v, want %v", got, tt.want)
+			}
+			for i := 1; i < len(names); i++ {
+				if names[i-1] > names[i] {
+					t.Errorf("listPolicySummaries() not sorted: %v", names)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/dynamic"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+	"kubectl-multi/pkg/util"
+)
+
+// propagationResync re-reads the Binding and WorkStatuses even without watch
+// events, in case a watch was closed and missed one
+const propagationResync = 10 * time.Second
+
+// propagation is the state of a BindingPolicy's Binding and of its workload
+// in each destination cluster
+type propagation struct {
+	WDS      string                           `json:"wds"`
+	Policy   string                           `json:"policy"`
+	Binding  string                           `json:"binding"`
+	Clusters []kubestellar.ClusterPropagation `json:"clusters"`
+}
+
+// done reports whether the Binding is synced, has destinations and every
+// destination reports status for the whole workload
+func (p propagation) done() bool {
+	if p.Binding != "Synced" || len(p.Clusters) == 0 {
+		return false
+	}
+	for _, c := range p.Clusters {
+		if !c.Synced() {
+			return false
+		}
+	}
+	return true
+}
+
+// summary is the one-line progress report of the propagation
+func (p propagation) summary() string {
+	synced := 0
+	for _, c := range p.Clusters {
+		if c.Synced() {
+			synced++
+		}
+	}
+	return fmt.Sprintf("binding %s, %d/%d cluster(s) synced", p.Binding, synced, len(p.Clusters))
+}
+
+func newBindingPolicyWaitCommand() *cobra.Command {
+	var timeout time.Duration
+	var outputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "wait NAME",
+		Short: "Wait until a BindingPolicy's workload is synced to all its clusters",
+		Long: `Watch the Binding the WDS derives from a BindingPolicy and the WorkStatus
+objects the ITS receives from the clusters, and return once the Binding is
+synced and every destination cluster reports status for every object of its
+workload. On timeout, print a per-cluster breakdown and exit non-zero, so a
+CI pipeline can gate on placement.`,
+		Example: `# Gate a pipeline on nginx-bp reaching its clusters
+kubectl multi kubestellar bp wait nginx-bp --timeout 5m
+
+# Per-cluster result as JSON
+kubectl multi kubestellar bp wait nginx-bp -o json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch outputFormat {
+			case "", "json", "yaml":
+			default:
+				return fmt.Errorf("unsupported output format %q, must be one of json|yaml", outputFormat)
+			}
+			kubeconfig, remoteCtx, _, _, _ := GetGlobalFlags()
+			return handleBindingPolicyWait(args[0], timeout, outputFormat, kubeconfig, remoteCtx, GetWDSContexts())
+		},
+	}
+
+	cmd.Flags().DurationVar(&timeout, "timeout", 5*time.Minute, "how long to wait for the workload to be synced")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "output format of the final state (json|yaml)")
+
+	return cmd
+}
+
+func handleBindingPolicyWait(name string, timeout time.Duration, outputFormat, kubeconfig, remoteCtx string, wdsContexts []string) error {
+	itsContexts, err := selectedITSContexts(kubeconfig, remoteCtx)
+	if err != nil {
+		return err
+	}
+	var itses []dynamic.Interface
+	for _, its := range itsContexts {
+		c, err := cluster.ClientForContext(kubeconfig, its)
+		if err != nil {
+			return err
+		}
+		itses = append(itses, c.DynamicClient)
+	}
+
+	// Wait in every WDS holding the policy, sharing one deadline
+	ctx, cancel := context.WithTimeout(commandContext(), timeout)
+	defer cancel()
+	var results []propagation
+	for _, wdsContext := range wdsContexts {
+		wds, err := cluster.ClientForContext(kubeconfig, wdsContext)
+		if err != nil {
+			return err
+		}
+		_, err = wds.DynamicClient.Resource(kubestellar.BindingPolicyGVR).Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) && len(wdsContexts) > 1 {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get bindingpolicy %s in WDS %s: %v", name, wdsContext, err)
+		}
+		p, _ := waitForPropagation(ctx, wds.DynamicClient, itses, wdsContext, name)
+		results = append(results, p)
+	}
+	if len(results) == 0 {
+		return fmt.Errorf("bindingpolicy %q not found in WDS %s", name, strings.Join(wdsContexts, ", "))
+	}
+
+	if outputFormat != "" {
+		if err := util.PrintStructured(util.GetOutputStream(), outputFormat, results); err != nil {
+			return err
+		}
+	} else {
+		printPropagation(results)
+	}
+
+	pending := 0
+	for _, p := range results {
+		if !p.done() {
+			pending++
+		}
+	}
+	if pending > 0 {
+		return fmt.Errorf("bindingpolicy %s not synced within %s", name, timeout)
+	}
+	return nil
+}
+
+func printPropagation(results []propagation) {
+	tw := util.NewTableWriter(util.GetOutputStream(), "")
+	defer tw.Flush()
+	showWDS := len(results) > 1
+	header := "CLUSTER\tSTATUS\tOBJECTS"
+	if showWDS {
+		header = "WDS\t" + header
+	}
+	fmt.Fprintln(tw, header)
+	for _, p := range results {
+		if len(p.Clusters) == 0 {
+			if showWDS {
+				fmt.Fprintf(tw, "%s\t", p.WDS)
+			}
+			fmt.Fprintf(tw, "<none>\tbinding %s, no destination clusters\t-\n", p.Binding)
+			continue
+		}
+		for _, c := range p.Clusters {
+			status := "Synced"
+			if !c.Synced() {
+				status = "Pending"
+			}
+			if showWDS {
+				fmt.Fprintf(tw, "%s\t", p.WDS)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%d/%d reported\n", c.Cluster, status, c.Reported, c.Expected)
+		}
+	}
+}
+
+// waitForPropagation re-evaluates the propagation of a BindingPolicy on
+// every change to its Binding or to a WorkStatus until it is done or ctx
+// ends, and returns the last state seen
+func waitForPropagation(ctx context.Context, wds dynamic.Interface, itses []dynamic.Interface, wdsContext, name string) (propagation, error) {
+	changed := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+	watchCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	go watchChanges(watchCtx, wds.Resource(kubestellar.BindingGVR), metav1.ListOptions{FieldSelector: fields.OneTermEqualSelector("metadata.name", name).String()}, notify)
+	for _, its := range itses {
+		go watchChanges(watchCtx, its.Resource(kubestellar.WorkStatusGVR), metav1.ListOptions{}, notify)
+	}
+
+	resync := time.NewTicker(propagationResync)
+	defer resync.Stop()
+	last := ""
+	for {
+		p, err := readPropagation(ctx, wds, itses, wdsContext, name)
+		if err == nil && p.done() {
+			return p, nil
+		}
+		if summary := p.summary(); err == nil && summary != last {
+			fmt.Fprintf(os.Stderr, "Waiting for bindingpolicy %s: %s\n", name, summary)
+			last = summary
+		}
+		select {
+		case <-ctx.Done():
+			return p, ctx.Err()
+		case <-changed:
+		case <-resync.C:
+		}
+	}
+}
+
+// readPropagation reads the Binding of a BindingPolicy and the WorkStatuses
+// of the ITSes. A Binding not created yet is reported as Missing.
+func readPropagation(ctx context.Context, wds dynamic.Interface, itses []dynamic.Interface, wdsContext, name string) (propagation, error) {
+	p := propagation{WDS: wdsContext, Policy: name, Binding: "Missing"}
+	binding, err := wds.Resource(kubestellar.BindingGVR).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return p, nil
+	}
+	if err != nil {
+		return p, err
+	}
+	p.Binding = kubestellar.BindingState(binding)
+
+	var workStatuses []unstructured.Unstructured
+	for _, its := range itses {
+		list, err := its.Resource(kubestellar.WorkStatusGVR).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return p, err
+		}
+		workStatuses = append(workStatuses, list.Items...)
+	}
+	p.Clusters = kubestellar.BindingPropagation(binding, workStatuses)
+	return p, nil
+}
+
+// watchChanges calls notify on every event of a watch, reopening the watch
+// when the server closes it, until ctx ends
+func watchChanges(ctx context.Context, resource dynamic.ResourceInterface, opts metav1.ListOptions, notify func()) {
+	for ctx.Err() == nil {
+		w, err := resource.Watch(ctx, opts)
+		if err != nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+		for range w.ResultChan() {
+			notify()
+		}
+		w.Stop()
+	}
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+
+	"kubectl-multi/pkg/kubestellar"
+)
+
+func testWorkloadBinding(name string, generation, observed int64, clusters ...string) *unstructured.Unstructured {
+	b := testBinding(name, clusters...)
+	b.SetGeneration(generation)
+	unstructured.SetNestedSlice(b.Object, []interface{}{
+		map[string]interface{}{"group": "apps", "version": "v1", "resource": "deployments", "namespace": "web", "name": "nginx"},
+	}, "spec", "workload", "namespaceScope")
+	unstructured.SetNestedField(b.Object, observed, "status", "observedGeneration")
+	return b
+}
+
+func testWorkStatus(clusterName string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": kubestellar.WorkStatusGVR.GroupVersion().String(),
+		"kind":       "WorkStatus",
+		"metadata":   map[string]interface{}{"name": "deployments-nginx", "namespace": clusterName},
+		"spec": map[string]interface{}{"sourceRef": map[string]interface{}{
+			"group": "apps", "version": "v1", "resource": "deployments", "namespace": "web", "name": "nginx",
+		}},
+	}}
+}
+
+// testWorkStatusDynamic returns a fake ITS client serving WorkStatuses
+func testWorkStatusDynamic(objects ...runtime.Object) *fakedynamic.FakeDynamicClient {
+	listKinds := map[schema.GroupVersionResource]string{kubestellar.WorkStatusGVR: "WorkStatusList"}
+	return fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objects...)
+}
+
+func TestWaitForPropagation(t *testing.T) {
+	wds := testWDSDynamic(testWorkloadBinding("nginx-bp", 2, 1, "cluster1", "cluster2"))
+	its := testWorkStatusDynamic(testWorkStatus("cluster1"))
+
+	// The controller catches up and cluster2 reports while the command waits
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		ctx := context.Background()
+		wds.Resource(kubestellar.BindingGVR).Update(ctx, testWorkloadBinding("nginx-bp", 2, 2, "cluster1", "cluster2"), metav1.UpdateOptions{})
+		its.Resource(kubestellar.WorkStatusGVR).Namespace("cluster2").Create(ctx, testWorkStatus("cluster2"), metav1.CreateOptions{})
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	p, err := waitForPropagation(ctx, wds, []dynamic.Interface{its}, "wds1", "nginx-bp")
+	if err != nil || !p.done() {
+		t.Fatalf("waitForPropagation() = %+v, %v; want synced", p, err)
+	}
+}
+
+func TestWaitForPropagationTimeout(t *testing.T) {
+	wds := testWDSDynamic(testWorkloadBinding("nginx-bp", 1, 1, "cluster1", "cluster2"))
+	its := testWorkStatusDynamic(testWorkStatus("cluster1"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	p, err := waitForPropagation(ctx, wds, []dynamic.Interface{its}, "wds1", "nginx-bp")
+	if err == nil || p.done() {
+		t.Fatalf("waitForPropagation() = %+v, %v; want a timeout", p, err)
+	}
+	want := []kubestellar.ClusterPropagation{
+		{Cluster: "cluster1", Reported: 1, Expected: 1},
+		{Cluster: "cluster2", Reported: 0, Expected: 1},
+	}
+	if len(p.Clusters) != 2 || p.Clusters[0] != want[0] || p.Clusters[1] != want[1] {
+		t.Errorf("clusters = %+v, want %+v", p.Clusters, want)
+	}
+}
+
+func TestReadPropagationMissingBinding(t *testing.T) {
+	p, err := readPropagation(context.Background(), testWDSDynamic(), []dynamic.Interface{testWorkStatusDynamic()}, "wds1", "nginx-bp")
+	if err != nil || p.Binding != "Missing" || p.done() {
+		t.Errorf("readPropagation() = %+v, %v; want a missing binding", p, err)
+	}
+}
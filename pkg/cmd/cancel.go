@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// errInterrupted is the cancellation cause of Ctrl-C
+var errInterrupted = errors.New("interrupted")
+
+var (
+	commandTimeout    time.Duration
+	cmdCtx            = context.Background()
+	stopCmdCtx        = func() {}
+	interruptExpected bool
+)
+
+// commandContext returns the context of the running command. It is cancelled
+// by Ctrl-C and when --timeout expires, so the API calls of a fan-out return
+// promptly and the handler still prints what it collected.
+func commandContext() context.Context {
+	return cmdCtx
+}
+
+// startCommandContext derives the command context from parent
+func startCommandContext(parent context.Context, timeout time.Duration) context.Context {
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt, syscall.SIGTERM)
+	ctx, stop := newCommandContext(parent, timeout, interrupts)
+	cmdCtx = ctx
+	stopCmdCtx = func() {
+		signal.Stop(interrupts)
+		stop()
+	}
+	return ctx
+}
+
+// newCommandContext returns a context cancelled by the first signal on
+// interrupts or when timeout (if not zero) expires. The cause tells which.
+func newCommandContext(parent context.Context, timeout time.Duration, interrupts chan os.Signal) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(parent)
+	stop := func() { cancel(context.Canceled) }
+	if timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeoutCause(ctx, timeout, fmt.Errorf("--timeout of %s expired", timeout))
+		stop = func() {
+			cancelTimeout()
+			cancel(context.Canceled)
+		}
+	}
+	go func() {
+		select {
+		case <-interrupts:
+			// A second Ctrl-C kills the process as usual
+			signal.Stop(interrupts)
+			cancel(errInterrupted)
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, stop
+}
+
+// expectInterrupt marks the running command as one that runs until Ctrl-C,
+// such as get --poll or logs -f, so an interrupt ends it normally
+func expectInterrupt() {
+	interruptExpected = true
+}
+
+// finishCommandContext prints a footer when the command was cancelled, so
+// the output above is known to be partial, and makes the command fail
+func finishCommandContext(err error, w io.Writer) error {
+	defer stopCmdCtx()
+	if cmdCtx.Err() == nil {
+		return err
+	}
+	cause := context.Cause(cmdCtx)
+	if cause == errInterrupted && interruptExpected {
+		return err
+	}
+	fmt.Fprintf(w, "\nCancelled: %v; the results above may be incomplete\n", cause)
+	if err == nil {
+		err = fmt.Errorf("cancelled: %v", cause)
+	}
+	return err
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestNewCommandContext(t *testing.T) {
+	tests := []struct {
+		name      string
+		timeout   time.Duration
+		interrupt bool
+		wantCause string
+	}{
+		{name: "interrupted", interrupt: true, wantCause: "interrupted"},
+		{name: "timed out", timeout: time.Millisecond, wantCause: "--timeout of 1ms expired"},
+		{name: "interrupted before the timeout", timeout: time.Hour, interrupt: true, wantCause: "interrupted"},
+		{name: "still running", timeout: time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interrupts := make(chan os.Signal, 1)
+			ctx, stop := newCommandContext(context.Background(), tt.timeout, interrupts)
+			defer stop()
+			if tt.interrupt {
+				interrupts <- os.Interrupt
+			}
+			if tt.wantCause == "" {
+				select {
+				case <-ctx.Done():
+					t.Fatalf("context cancelled: %v", context.Cause(ctx))
+				case <-time.After(20 * time.Millisecond):
+				}
+				return
+			}
+			select {
+			case <-ctx.Done():
+			case <-time.After(5 * time.Second):
+				t.Fatal("context not cancelled")
+			}
+			if got := context.Cause(ctx).Error(); got != tt.wantCause {
+				t.Errorf("cause = %q, want %q", got, tt.wantCause)
+			}
+		})
+	}
+}
+
+func TestFinishCommandContext(t *testing.T) {
+	defer func(ctx context.Context, expected bool) {
+		cmdCtx, interruptExpected, stopCmdCtx = ctx, expected, func() {}
+	}(cmdCtx, interruptExpected)
+
+	failed := errors.New("no clusters discovered")
+	tests := []struct {
+		name              string
+		cause             error
+		interruptExpected bool
+		err               error
+		wantErr           string
+		wantFooter        string
+	}{
+		{name: "completed"},
+		{name: "completed with error", err: failed, wantErr: "no clusters discovered"},
+		{
+			name:       "timed out",
+			cause:      errors.New("--timeout of 30s expired"),
+			wantErr:    "cancelled: --timeout of 30s expired",
+			wantFooter: "\nCancelled: --timeout of 30s expired; the results above may be incomplete\n",
+		},
+		{
+			name:       "interrupted with error",
+			cause:      errInterrupted,
+			err:        failed,
+			wantErr:    "no clusters discovered",
+			wantFooter: "\nCancelled: interrupted; the results above may be incomplete\n",
+		},
+		{name: "interrupt ends a watch", cause: errInterrupted, interruptExpected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancelCause(context.Background())
+			if tt.cause != nil {
+				cancel(tt.cause)
+			}
+			stopped := false
+			cmdCtx, interruptExpected, stopCmdCtx = ctx, tt.interruptExpected, func() { stopped = true }
+
+			var footer bytes.Buffer
+			err := finishCommandContext(tt.err, &footer)
+			if (err == nil && tt.wantErr != "") || (err != nil && err.Error() != tt.wantErr) {
+				t.Errorf("finishCommandContext() error = %v, want %q", err, tt.wantErr)
+			}
+			if footer.String() != tt.wantFooter {
+				t.Errorf("footer = %q, want %q", footer.String(), tt.wantFooter)
+			}
+			if !stopped {
+				t.Error("command context not stopped")
+			}
+			cancel(nil)
+		})
+	}
+}
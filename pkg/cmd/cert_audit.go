@@ -0,0 +1,347 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+// Verdicts of cert-audit for one certificate or token
+const (
+	certOK       = "OK"
+	certExpiring = "Expiring"
+	certExpired  = "Expired"
+	certInvalid  = "Invalid"
+)
+
+// Where cert-audit found a certificate or token
+const (
+	// certSourceTLSSecret is a Secret of type kubernetes.io/tls
+	certSourceTLSSecret = "TLSSecret"
+	// certSourceClientCert, certSourceClusterCA and certSourceToken are the
+	// credentials of the kubeconfig context kubectl multi reaches a cluster
+	// with
+	certSourceClientCert = "ClientCert"
+	certSourceClusterCA  = "ClusterCA"
+	certSourceToken      = "Token"
+	// certSourceHubKubeconfig is the client certificate the klusterlet of a
+	// managed cluster reaches its ITS with
+	certSourceHubKubeconfig = "HubKubeconfig"
+)
+
+// ocmHubKubeconfigSecret is the Secret of the klusterlet holding its
+// registered client certificate for the ITS, which the klusterlet rotates
+const ocmHubKubeconfigSecret = "hub-kubeconfig-secret"
+
+// certExpiry is the expiry of one certificate or token of a cluster
+type certExpiry struct {
+	Cluster   string `json:"cluster"`
+	Source    string `json:"source"`
+	Namespace string `json:"namespace,omitempty"`
+	// Name is the Secret, or the kubeconfig context for kubeconfig
+	// credentials
+	Name    string     `json:"name"`
+	Subject string     `json:"subject,omitempty"`
+	Expires *time.Time `json:"expires,omitempty"`
+	Status  string     `json:"status"`
+	Detail  string     `json:"detail,omitempty"`
+}
+
+// ok reports whether the certificate or token is valid beyond --within
+func (c certExpiry) ok() bool {
+	return c.Status == certOK
+}
+
+func newCertAuditCommand() *cobra.Command {
+	var outputFormat string
+	var within string
+	var targets clusterTargets
+	var reach reachability
+
+	cmd := &cobra.Command{
+		Use:   "cert-audit",
+		Short: "Report when the TLS secrets and cluster access credentials of every cluster expire",
+		Long: `Report the expiry of the certificates and tokens of every cluster, and warn
+about those expiring within --within:
+
+  TLSSecret      a Secret of type kubernetes.io/tls (-n or -A)
+  ClientCert     the client certificate of the kubeconfig context
+  ClusterCA      the CA certificate the kubeconfig trusts for the cluster
+  Token          the bearer token of the kubeconfig context, when it is a JWT
+                 with an expiry
+  HubKubeconfig  the client certificate the klusterlet of a managed cluster
+                 uses to reach its ITS
+
+For a certificate chain the earliest expiry is reported. Kubeconfig
+credentials are those kubectl multi reaches the cluster with, so clusters
+discovered through the ITS are covered along with the ITS itself. The command
+fails when anything is Expired, Expiring or Invalid, so it can gate scripts.`,
+		Example: `# Everything expiring within 30 days, in every namespace
+kubectl multi cert-audit -A
+
+# A quarter ahead, as JSON
+kubectl multi cert-audit -A --within 90d -o json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch outputFormat {
+			case "", "json", util.TableFormatCSV, util.TableFormatMarkdown:
+			default:
+				return fmt.Errorf("unsupported output format %q, must be one of json|csv|markdown", outputFormat)
+			}
+			window, err := parseExpiryWindow(within)
+			if err != nil {
+				return err
+			}
+			kubeconfig, remoteCtx, _, namespace, allNamespaces := GetGlobalFlags()
+			return handleCertAuditCommand(outputFormat, window, targets, reach, kubeconfig, remoteCtx, namespace, allNamespaces)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "output format (json|csv|markdown)")
+	cmd.Flags().StringVar(&within, "within", "30d", "warn about what expires within this long, in days (e.g. 30d) or as a duration (e.g. 72h)")
+	targets.addFlags(cmd, "audit")
+	reach.addFlags(cmd)
+
+	return cmd
+}
+
+// parseExpiryWindow reads a number of days such as 30d, or a Go duration
+func parseExpiryWindow(s string) (time.Duration, error) {
+	var window time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid --within %q: want days such as 30d or a duration such as 72h", s)
+		}
+		window = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if window, err = time.ParseDuration(s); err != nil {
+			return 0, fmt.Errorf("invalid --within %q: want days such as 30d or a duration such as 72h", s)
+		}
+	}
+	if window < 0 {
+		return 0, fmt.Errorf("--within must not be negative")
+	}
+	return window, nil
+}
+
+func handleCertAuditCommand(outputFormat string, within time.Duration, targets clusterTargets, reach reachability, kubeconfig, remoteCtx, namespace string, allNamespaces bool) error {
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	clusters, err = targets.selectFrom(clusters, kubeconfig)
+	if err != nil {
+		return err
+	}
+	clusters, err = reach.filter(clusters)
+	if err != nil {
+		return err
+	}
+	defer printClusterIssuesToStderr()
+
+	targetNS := cluster.GetTargetNamespace(namespace)
+	if allNamespaces {
+		targetNS = ""
+	}
+	now := time.Now()
+	var results []certExpiry
+	for _, c := range clusters {
+		results = append(results, auditClusterCerts(c, targetNS, now, within)...)
+	}
+
+	if outputFormat == "json" {
+		if err := printJSONArray(results); err != nil {
+			return err
+		}
+	} else {
+		printCertExpiries(results, outputFormat, now)
+	}
+	failing := 0
+	for _, r := range results {
+		if !r.ok() {
+			failing++
+		}
+	}
+	if failing > 0 {
+		return fmt.Errorf("%d of %d certificate(s) and token(s) expired, invalid or expiring within %s", failing, len(results), duration.HumanDuration(within))
+	}
+	return nil
+}
+
+// auditClusterCerts returns the certificates and tokens of one cluster,
+// soonest expiring first
+func auditClusterCerts(c cluster.ClusterInfo, targetNS string, now time.Time, within time.Duration) []certExpiry {
+	results := kubeconfigCredentialExpiries(c)
+	if c.Client != nil {
+		results = append(results, tlsSecretExpiries(c, targetNS)...)
+		if hub, ok := hubKubeconfigExpiry(c); ok {
+			results = append(results, hub)
+		}
+	}
+	for i := range results {
+		judgeCertExpiry(&results[i], now, within)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i].Expires, results[j].Expires
+		if a == nil || b == nil {
+			return a == nil && b != nil
+		}
+		return a.Before(*b)
+	})
+	return results
+}
+
+// kubeconfigCredentialExpiries reads the client certificate, CA and bearer
+// token kubectl multi reaches the cluster with. Exec and auth-provider
+// credentials are issued on demand and not checked.
+func kubeconfigCredentialExpiries(c cluster.ClusterInfo) []certExpiry {
+	cfg := c.RestConfig
+	if cfg == nil {
+		return nil
+	}
+	var results []certExpiry
+	addCert := func(source string, data []byte, file string) {
+		if len(data) == 0 && file == "" {
+			return
+		}
+		r := certExpiry{Cluster: c.Name, Source: source, Name: c.Context}
+		if len(data) == 0 {
+			var err error
+			if data, err = os.ReadFile(file); err != nil {
+				r.Status, r.Detail = certInvalid, err.Error()
+				results = append(results, r)
+				return
+			}
+		}
+		r.setCertificate(data)
+		results = append(results, r)
+	}
+	addCert(certSourceClientCert, cfg.TLSClientConfig.CertData, cfg.TLSClientConfig.CertFile)
+	addCert(certSourceClusterCA, cfg.TLSClientConfig.CAData, cfg.TLSClientConfig.CAFile)
+
+	token := cfg.BearerToken
+	if token == "" && cfg.BearerTokenFile != "" {
+		if data, err := os.ReadFile(cfg.BearerTokenFile); err == nil {
+			token = string(data)
+		}
+	}
+	if exp, ok := util.TokenExpiry(token); ok {
+		results = append(results, certExpiry{Cluster: c.Name, Source: certSourceToken, Name: c.Context, Expires: &exp})
+	}
+	return results
+}
+
+// tlsSecretExpiries reads the certificates of the kubernetes.io/tls Secrets
+// of the cluster, noting a failure to list them as a cluster issue
+func tlsSecretExpiries(c cluster.ClusterInfo, targetNS string) []certExpiry {
+	secrets, err := listNamespaced(c, "", "secrets", targetNS, func(ns string) (*corev1.SecretList, error) {
+		return c.Client.CoreV1().Secrets(ns).List(commandContext(), metav1.ListOptions{
+			FieldSelector: "type=" + string(corev1.SecretTypeTLS),
+		})
+	})
+	if err != nil {
+		noteClusterIssue(c.Name, fmt.Sprintf("failed to list secrets: %v", err))
+		return nil
+	}
+	var results []certExpiry
+	for _, s := range secrets.Items {
+		if s.Type != corev1.SecretTypeTLS {
+			continue
+		}
+		r := certExpiry{Cluster: c.Name, Source: certSourceTLSSecret, Namespace: s.Namespace, Name: s.Name}
+		r.setCertificate(s.Data[corev1.TLSCertKey])
+		results = append(results, r)
+	}
+	return results
+}
+
+// hubKubeconfigExpiry reads the client certificate of the klusterlet, when
+// the cluster is managed through OCM
+func hubKubeconfigExpiry(c cluster.ClusterInfo) (certExpiry, bool) {
+	s, err := c.Client.CoreV1().Secrets(ocmAgentNamespace).Get(commandContext(), ocmHubKubeconfigSecret, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return certExpiry{}, false
+	}
+	if err != nil {
+		noteClusterIssue(c.Name, fmt.Sprintf("failed to get secret %s/%s: %v", ocmAgentNamespace, ocmHubKubeconfigSecret, err))
+		return certExpiry{}, false
+	}
+	if _, ok := s.Data[corev1.TLSCertKey]; !ok {
+		// Not registered yet: the klusterlet still uses the bootstrap kubeconfig
+		return certExpiry{}, false
+	}
+	r := certExpiry{Cluster: c.Name, Source: certSourceHubKubeconfig, Namespace: ocmAgentNamespace, Name: ocmHubKubeconfigSecret}
+	r.setCertificate(s.Data[corev1.TLSCertKey])
+	return r, true
+}
+
+// setCertificate fills the expiry and subject of the PEM certificates, or
+// marks them invalid
+func (c *certExpiry) setCertificate(data []byte) {
+	expires, subject, err := util.CertificateExpiry(data)
+	if err != nil {
+		c.Status, c.Detail = certInvalid, err.Error()
+		return
+	}
+	c.Expires, c.Subject = &expires, subject
+}
+
+// judgeCertExpiry sets the status of a certificate or token read without
+// error
+func judgeCertExpiry(c *certExpiry, now time.Time, within time.Duration) {
+	switch {
+	case c.Status != "":
+	case c.Expires.Before(now):
+		c.Status = certExpired
+	case c.Expires.Before(now.Add(within)):
+		c.Status = certExpiring
+	default:
+		c.Status = certOK
+	}
+}
+
+func printCertExpiries(results []certExpiry, outputFormat string, now time.Time) {
+	tw := util.NewTableWriter(util.GetOutputStream(), outputFormat)
+	defer tw.Flush()
+
+	if len(results) == 0 {
+		fmt.Fprintln(tw, "No certificates found.")
+		return
+	}
+	fmt.Fprintln(tw, "CLUSTER\tSOURCE\tNAMESPACE\tNAME\tSUBJECT\tEXPIRES\tSTATUS\tDETAIL")
+	for _, r := range results {
+		ns := r.Namespace
+		if ns == "" {
+			ns = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.Cluster, r.Source, ns, r.Name, orNone(r.Subject),
+			formatExpiry(r.Expires, now), r.Status, orNone(r.Detail))
+	}
+}
+
+// formatExpiry renders an expiry as its date and how far it is, e.g.
+// "2026-11-02 (in 16d)"
+func formatExpiry(expires *time.Time, now time.Time) string {
+	if expires == nil {
+		return "<none>"
+	}
+	date := expires.UTC().Format("2006-01-02")
+	if expires.Before(now) {
+		return date + " (" + duration.HumanDuration(now.Sub(*expires)) + " ago)"
+	}
+	return date + " (in " + duration.HumanDuration(expires.Sub(now)) + ")"
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+
+	"kubectl-multi/pkg/cluster"
+)
+
+// testCertPEM returns a self-signed PEM certificate
+func testCertPEM(t *testing.T, commonName string, notAfter time.Time) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    notAfter.AddDate(-1, 0, 0),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestAuditClusterCerts(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	secret := func(namespace, name string, secretType corev1.SecretType, data map[string][]byte) *corev1.Secret {
+		return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}, Type: secretType, Data: data}
+	}
+	client := fake.NewSimpleClientset(
+		secret("prod", "web-tls", corev1.SecretTypeTLS, map[string][]byte{corev1.TLSCertKey: testCertPEM(t, "web.example.com", now.AddDate(0, 0, 10))}),
+		secret("prod", "api-tls", corev1.SecretTypeTLS, map[string][]byte{corev1.TLSCertKey: testCertPEM(t, "api.example.com", now.AddDate(1, 0, 0))}),
+		secret("prod", "broken-tls", corev1.SecretTypeTLS, map[string][]byte{corev1.TLSCertKey: []byte("not a certificate")}),
+		// Not a TLS secret, though the fake client ignores the field selector
+		secret("prod", "db", corev1.SecretTypeOpaque, map[string][]byte{corev1.TLSCertKey: testCertPEM(t, "db", now)}),
+		secret(ocmAgentNamespace, ocmHubKubeconfigSecret, corev1.SecretTypeOpaque, map[string][]byte{corev1.TLSCertKey: testCertPEM(t, "system:open-cluster-management:cluster1", now.AddDate(0, 0, -1))}),
+	)
+	c := cluster.ClusterInfo{Name: "cluster1", Context: "cluster1", Client: client, RestConfig: &rest.Config{
+		TLSClientConfig: rest.TLSClientConfig{
+			CertData: testCertPEM(t, "admin", now.AddDate(0, 2, 0)),
+			CAData:   testCertPEM(t, "kubernetes", now.AddDate(9, 0, 0)),
+		},
+	}}
+
+	got := auditClusterCerts(c, "prod", now, 30*24*time.Hour)
+	want := []struct{ source, name, subject, status string }{
+		{certSourceTLSSecret, "broken-tls", "", certInvalid},
+		{certSourceHubKubeconfig, ocmHubKubeconfigSecret, "system:open-cluster-management:cluster1", certExpired},
+		{certSourceTLSSecret, "web-tls", "web.example.com", certExpiring},
+		{certSourceClientCert, "cluster1", "admin", certOK},
+		{certSourceTLSSecret, "api-tls", "api.example.com", certOK},
+		{certSourceClusterCA, "cluster1", "kubernetes", certOK},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("auditClusterCerts() = %+v, want %d entries", got, len(want))
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.Source != w.source || g.Name != w.name || g.Subject != w.subject || g.Status != w.status {
+			t.Errorf("entry %d = %s %s %q %s, want %s %s %q %s", i, g.Source, g.Name, g.Subject, g.Status, w.source, w.name, w.subject, w.status)
+		}
+	}
+}
+
+func TestParseExpiryWindow(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "30d", want: 30 * 24 * time.Hour},
+		{in: "72h", want: 72 * time.Hour},
+		{in: "0d", want: 0},
+		{in: "thirty", wantErr: true},
+		{in: "1.5d", wantErr: true},
+		{in: "-1d", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseExpiryWindow(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseExpiryWindow(%q) = %v, %v, want %v (error %v)", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestFormatExpiry(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	soon, past := now.AddDate(0, 0, 16), now.AddDate(0, 0, -3)
+	if got := formatExpiry(&soon, now); got != "2026-10-17 (in 16d)" {
+		t.Errorf("formatExpiry(soon) = %q", got)
+	}
+	if got := formatExpiry(&past, now); got != "2026-09-28 (3d ago)" {
+		t.Errorf("formatExpiry(past) = %q", got)
+	}
+	if got := formatExpiry(nil, now); got != "<none>" {
+		t.Errorf("formatExpiry(nil) = %q", got)
+	}
+}
@@ -0,0 +1,538 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/config"
+	"kubectl-multi/pkg/kubestellar"
+	"kubectl-multi/pkg/util"
+)
+
+// labelChange is the set of label edits to make on one ManagedCluster
+type labelChange struct {
+	Set    map[string]string
+	Remove []string
+}
+
+func newClustersCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "clusters",
+		Aliases: []string{"cluster"},
+		Short:   "Manage the ManagedClusters registered in the ITS",
+		Long: `Inspect and manage the ManagedCluster objects held by the ITS (the
+--remote-context). Cluster labels are what BindingPolicy clusterSelectors match
+against, so they need to be in place before label-based placement works.
+Taints, claims and ManagedClusterSet membership further steer OCM placement.`,
+	}
+	cmd.AddCommand(newClustersListCommand())
+	cmd.AddCommand(newClustersLabelCommand())
+	cmd.AddCommand(newClustersTaintCommand())
+	cmd.AddCommand(newClustersClusterSetCommand())
+	cmd.AddCommand(newClustersClaimsCommand())
+	cmd.AddCommand(newClustersPlacementsCommand())
+	cmd.AddCommand(newClustersAddCommand())
+	cmd.AddCommand(newClustersKubeconfigCommand())
+	cmd.AddCommand(newClustersExportContextsCommand())
+	return cmd
+}
+
+func newClustersListCommand() *cobra.Command {
+	var outputFormat string
+	var selector string
+	var reach reachability
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the ManagedClusters registered in the ITS",
+		Example: `# List clusters with their status
+kubectl multi clusters list
+
+# Include endpoints and labels
+kubectl multi clusters list -o wide
+
+# Machine-readable inventory of production clusters
+kubectl multi clusters list -l env=prod -o json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch outputFormat {
+			case "", "wide", "json", "yaml", util.TableFormatCSV, util.TableFormatMarkdown:
+			default:
+				return fmt.Errorf("unsupported output format %q, must be one of json|yaml|wide|csv|markdown", outputFormat)
+			}
+			kubeconfig, remoteCtx, _, _, _ := GetGlobalFlags()
+			return handleClustersList(outputFormat, selector, reach, kubeconfig, remoteCtx)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "output format (json|yaml|wide|csv|markdown)")
+	cmd.Flags().StringVarP(&selector, "selector", "l", "", "label selector to filter ManagedClusters")
+	reach.addFlags(cmd)
+
+	return cmd
+}
+
+func handleClustersList(outputFormat, selector string, reach reachability, kubeconfig, remoteCtx string) error {
+	sel, err := labels.Parse(selector)
+	if err != nil {
+		return fmt.Errorf("invalid selector %q: %v", selector, err)
+	}
+	itsContexts, err := selectedITSContexts(kubeconfig, remoteCtx)
+	if err != nil {
+		return err
+	}
+
+	var inventories []itsManagedClusters
+	for _, its := range itsContexts {
+		if _, err := reach.controlPlane("ITS", kubeconfig, its, true); err != nil {
+			return err
+		}
+		mcs, err := cluster.ListManagedClusterObjects(commandContext(), kubeconfig, its)
+		if err != nil {
+			return err
+		}
+		inventories = append(inventories, itsManagedClusters{ITS: its, Items: mcs})
+	}
+	summaries := summarizeInventories(inventories, sel)
+
+	if outputFormat == "json" || outputFormat == "yaml" {
+		return util.PrintStructured(util.GetOutputStream(), outputFormat, summaries)
+	}
+
+	tw := util.NewTableWriter(util.GetOutputStream(), outputFormat)
+	defer tw.Flush()
+
+	if len(summaries) == 0 {
+		fmt.Fprintf(tw, "No managed clusters found.\n")
+		return nil
+	}
+
+	showITS := len(itsContexts) > 1
+	header := "NAME\tACCEPTED\tJOINED\tAVAILABLE\tAGE"
+	if showITS {
+		header = "NAME\tITS\tACCEPTED\tJOINED\tAVAILABLE\tAGE"
+	}
+	if outputFormat == "wide" {
+		header += "\tENDPOINT\tLABELS"
+	}
+	fmt.Fprintln(tw, header)
+	for _, s := range summaries {
+		age := duration.HumanDuration(time.Since(s.Created))
+		fmt.Fprintf(tw, "%s\t", s.Name)
+		if showITS {
+			fmt.Fprintf(tw, "%s\t", s.ITS)
+		}
+		fmt.Fprintf(tw, "%t\t%t\t%s\t%s", s.Accepted, s.Joined, s.Available, age)
+		if outputFormat == "wide" {
+			fmt.Fprintf(tw, "\t%s\t%s", s.Endpoint, util.FormatLabels(s.Labels))
+		}
+		fmt.Fprintln(tw)
+	}
+	return nil
+}
+
+// itsManagedClusters is the ManagedCluster inventory of one ITS
+type itsManagedClusters struct {
+	ITS   string
+	Items []unstructured.Unstructured
+}
+
+// summarizeInventories merges the inventories of several ITSes into the
+// summaries of the clusters matching sel. Names registered in more than one
+// ITS are shown as ITS/NAME.
+func summarizeInventories(inventories []itsManagedClusters, sel labels.Selector) []kubestellar.ClusterSummary {
+	var names []cluster.ITSInventory
+	for _, inv := range inventories {
+		n := cluster.ITSInventory{ITS: inv.ITS}
+		for _, mc := range inv.Items {
+			n.Clusters = append(n.Clusters, mc.GetName())
+		}
+		names = append(names, n)
+	}
+	refs := cluster.MergeInventories(names)
+
+	summaries := []kubestellar.ClusterSummary{}
+	i := 0
+	for _, inv := range inventories {
+		for j := range inv.Items {
+			ref := refs[i]
+			i++
+			if !sel.Matches(labels.Set(inv.Items[j].GetLabels())) {
+				continue
+			}
+			s := kubestellar.SummarizeCluster(&inv.Items[j])
+			s.Name = ref.Name
+			s.ITS = ref.ITS
+			summaries = append(summaries, s)
+		}
+	}
+	return summaries
+}
+
+func newClustersLabelCommand() *cobra.Command {
+	var all bool
+	var selector string
+	var fromFile string
+	var dryRun bool
+	var overwrite bool
+
+	cmd := &cobra.Command{
+		Use:   "label [CLUSTER|@GROUP...] KEY=VALUE... [KEY-...]",
+		Short: "Add, update or remove labels on ManagedClusters in bulk",
+		Long: `Add, update or remove labels on ManagedClusters in the ITS.
+
+Clusters are chosen by name, with --all, or with a label selector (-l).
+Alternatively --from-file reads per-cluster labels from a YAML map
+(cluster: {key: value}, a null value removes the key) or a CSV file with
+lines of the form cluster,key=value,key2-.`,
+		Example: `# Label two clusters
+kubectl multi clusters label cluster1 cluster2 env=prod
+
+# Add a region label to every cluster, previewing the changes first
+kubectl multi clusters label --all region=eu --dry-run
+
+# Remove a label from every cluster labeled env=staging
+kubectl multi clusters label -l env=staging tier-
+
+# Apply labels from a map file
+kubectl multi clusters label --from-file cluster-labels.yaml`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var names, changeArgs []string
+			for _, arg := range args {
+				if strings.Contains(arg, "=") || (strings.HasSuffix(arg, "-") && !strings.HasPrefix(arg, config.GroupPrefix)) {
+					changeArgs = append(changeArgs, arg)
+				} else {
+					names = append(names, arg)
+				}
+			}
+
+			kubeconfig, remoteCtx, _, _, _ := GetGlobalFlags()
+
+			if fromFile != "" {
+				if len(args) > 0 || all || selector != "" {
+					return fmt.Errorf("--from-file cannot be combined with cluster names, label changes, --all or -l")
+				}
+				changes, err := readClusterLabelFile(fromFile)
+				if err != nil {
+					return err
+				}
+				return runClustersLabel(changes, dryRun, overwrite, kubeconfig, remoteCtx)
+			}
+
+			chosen := 0
+			for _, set := range []bool{len(names) > 0, all, selector != ""} {
+				if set {
+					chosen++
+				}
+			}
+			if chosen != 1 {
+				return fmt.Errorf("specify exactly one of cluster names, --all, -l or --from-file")
+			}
+			if len(changeArgs) == 0 {
+				return fmt.Errorf("at least one label change (KEY=VALUE or KEY-) is required")
+			}
+			change, err := parseLabelChanges(changeArgs)
+			if err != nil {
+				return err
+			}
+
+			mcs, err := cluster.ListManagedClusterObjects(commandContext(), kubeconfig, remoteCtx)
+			if err != nil {
+				return err
+			}
+			targets, err := selectManagedClusters(mcs, names, all, selector, remoteCtx)
+			if err != nil {
+				return err
+			}
+			changes := make(map[string]labelChange, len(targets))
+			for _, name := range targets {
+				changes[name] = change
+			}
+			return runClustersLabel(changes, dryRun, overwrite, kubeconfig, remoteCtx)
+		},
+	}
+
+	cmd.Flags().BoolVar(&all, "all", false, "label every ManagedCluster in the ITS")
+	cmd.Flags().StringVarP(&selector, "selector", "l", "", "label selector choosing the ManagedClusters to label")
+	cmd.Flags().StringVar(&fromFile, "from-file", "", "YAML or CSV file mapping cluster names to labels")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only print the label changes that would be made")
+	cmd.Flags().BoolVar(&overwrite, "overwrite", false, "allow existing label values to be replaced")
+
+	return cmd
+}
+
+// selectManagedClusters resolves cluster names, --all or a selector against
+// the ManagedClusters mcs of the ITS
+func selectManagedClusters(mcs []unstructured.Unstructured, names []string, all bool, selector, its string) ([]string, error) {
+	var err error
+	if len(names) > 0 {
+		names, err = expandClusterNames(names)
+		if err != nil {
+			return nil, err
+		}
+		known := make(map[string]bool, len(mcs))
+		for _, mc := range mcs {
+			known[mc.GetName()] = true
+		}
+		for _, name := range names {
+			if !known[name] {
+				return nil, fmt.Errorf("ManagedCluster %q not found in ITS %s", name, its)
+			}
+		}
+		return names, nil
+	}
+
+	sel := labels.Everything()
+	if !all {
+		sel, err = labels.Parse(selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector %q: %v", selector, err)
+		}
+	}
+
+	var selected []string
+	for _, mc := range mcs {
+		if sel.Matches(labels.Set(mc.GetLabels())) {
+			selected = append(selected, mc.GetName())
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no ManagedClusters match the selection")
+	}
+	return selected, nil
+}
+
+// runClustersLabel labels the clusters, auditing the change unless it is a dry run
+func runClustersLabel(changes map[string]labelChange, dryRun, overwrite bool, kubeconfig, remoteCtx string) error {
+	var rec *audit.Recorder
+	if !dryRun {
+		rec = startAudit("clusters label")
+	}
+	err := guardManagedClusters(rec, blastRadius{}, sortedKeys(changes), remoteCtx)
+	if err == nil {
+		var its *cluster.ClusterInfo
+		if its, err = cluster.ClientForContext(kubeconfig, remoteCtx); err == nil {
+			err = handleClustersLabel(its.DynamicClient, changes, dryRun, overwrite, rec)
+		}
+	}
+	finishAudit(rec, err)
+	return err
+}
+
+// handleClustersLabel applies the label changes to the ManagedClusters
+// served by the ITS client its
+func handleClustersLabel(its dynamic.Interface, changes map[string]labelChange, dryRun, overwrite bool, rec *audit.Recorder) error {
+	names := make([]string, 0, len(changes))
+	for name := range changes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var failed []string
+	for _, name := range names {
+		mc, err := its.Resource(cluster.ManagedClusterGVR).Get(commandContext(), name, metav1.GetOptions{})
+		if err != nil {
+			rec.Record(name, err)
+			fmt.Printf("%s: error: failed to get ManagedCluster: %v\n", name, err)
+			failed = append(failed, name)
+			continue
+		}
+
+		patch, diff, err := labelPatch(mc, changes[name], overwrite)
+		if err != nil {
+			rec.Record(name, err)
+			fmt.Printf("%s: error: %v\n", name, err)
+			failed = append(failed, name)
+			continue
+		}
+		if len(diff) == 0 {
+			fmt.Printf("%s: unchanged\n", name)
+			continue
+		}
+
+		if dryRun {
+			fmt.Printf("%s: %s (dry run)\n", name, strings.Join(diff, " "))
+			continue
+		}
+
+		_, err = its.Resource(cluster.ManagedClusterGVR).Patch(commandContext(), name, types.MergePatchType, patch, metav1.PatchOptions{FieldManager: util.FieldManager})
+		rec.Record(name, err)
+		if err == nil {
+			rec.AddUndo(audit.UndoStep{Cluster: name, Action: audit.UndoRestoreLabels, Name: name, Labels: previousLabels(mc.GetLabels(), changes[name])})
+		}
+		if err != nil {
+			fmt.Printf("%s: error: failed to patch labels: %v\n", name, err)
+			failed = append(failed, name)
+			continue
+		}
+		fmt.Printf("%s: %s\n", name, strings.Join(diff, " "))
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to label %d cluster(s): %s", len(failed), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// labelPatch builds a merge patch for the change and a human readable diff
+// (+key=value added, ~key=old->new updated, -key removed)
+func labelPatch(mc *unstructured.Unstructured, change labelChange, overwrite bool) ([]byte, []string, error) {
+	current := mc.GetLabels()
+	patchLabels := make(map[string]interface{})
+	var diff []string
+
+	keys := make([]string, 0, len(change.Set))
+	for k := range change.Set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := change.Set[k]
+		old, exists := current[k]
+		switch {
+		case !exists:
+			diff = append(diff, fmt.Sprintf("+%s=%s", k, v))
+		case old == v:
+			continue
+		case !overwrite:
+			return nil, nil, fmt.Errorf("label %q already has value %q, use --overwrite to change it", k, old)
+		default:
+			diff = append(diff, fmt.Sprintf("~%s=%s->%s", k, old, v))
+		}
+		patchLabels[k] = v
+	}
+	for _, k := range change.Remove {
+		if _, exists := current[k]; !exists {
+			continue
+		}
+		diff = append(diff, "-"+k)
+		patchLabels[k] = nil
+	}
+
+	if len(patchLabels) == 0 {
+		return nil, nil, nil
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": patchLabels},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return patch, diff, nil
+}
+
+// previousLabels captures the values the change is about to overwrite, with
+// nil for labels that did not exist
+func previousLabels(current map[string]string, change labelChange) map[string]*string {
+	prev := make(map[string]*string)
+	keys := append([]string{}, change.Remove...)
+	for k := range change.Set {
+		keys = append(keys, k)
+	}
+	for _, k := range keys {
+		if v, ok := current[k]; ok {
+			v := v
+			prev[k] = &v
+		} else {
+			prev[k] = nil
+		}
+	}
+	return prev
+}
+
+// parseLabelChanges splits KEY=VALUE and KEY- arguments into a labelChange
+func parseLabelChanges(args []string) (labelChange, error) {
+	change := labelChange{Set: map[string]string{}}
+	for _, arg := range args {
+		if strings.HasSuffix(arg, "-") && !strings.Contains(arg, "=") {
+			key := strings.TrimSuffix(arg, "-")
+			if key == "" {
+				return change, fmt.Errorf("invalid label removal %q", arg)
+			}
+			change.Remove = append(change.Remove, key)
+			continue
+		}
+		set, err := parseLabelPairs([]string{arg})
+		if err != nil {
+			return change, err
+		}
+		for k, v := range set {
+			change.Set[k] = v
+		}
+	}
+	return change, nil
+}
+
+// readClusterLabelFile loads per-cluster label changes from a YAML map or CSV file
+func readClusterLabelFile(path string) (map[string]labelChange, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	changes := make(map[string]labelChange)
+
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		reader := csv.NewReader(strings.NewReader(string(data)))
+		reader.FieldsPerRecord = -1
+		reader.Comment = '#'
+		records, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+		}
+		for _, record := range records {
+			name := strings.TrimSpace(record[0])
+			if name == "" {
+				continue
+			}
+			var fields []string
+			for _, f := range record[1:] {
+				if f = strings.TrimSpace(f); f != "" {
+					fields = append(fields, f)
+				}
+			}
+			change, err := parseLabelChanges(fields)
+			if err != nil {
+				return nil, fmt.Errorf("%s: cluster %s: %v", path, name, err)
+			}
+			changes[name] = change
+		}
+		return changes, nil
+	}
+
+	var entries map[string]map[string]*string
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	for name, set := range entries {
+		change := labelChange{Set: map[string]string{}}
+		for k, v := range set {
+			if v == nil {
+				change.Remove = append(change.Remove, k)
+				continue
+			}
+			change.Set[k] = *v
+		}
+		sort.Strings(change.Remove)
+		changes[name] = change
+	}
+	return changes, nil
+}
@@ -0,0 +1,371 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+	"kubectl-multi/pkg/util"
+)
+
+const (
+	// ocmBootstrapNamespace and ocmBootstrapServiceAccount are where the OCM
+	// cluster manager keeps the identity new clusters register with
+	ocmBootstrapNamespace      = "open-cluster-management"
+	ocmBootstrapServiceAccount = "cluster-bootstrap"
+
+	// ocmAgentNamespace is where the klusterlet of a WEC runs its agents and
+	// reads the bootstrap kubeconfig from
+	ocmAgentNamespace     = "open-cluster-management-agent"
+	ocmBootstrapSecret    = "bootstrap-hub-kubeconfig"
+	ocmClusterNameLabel   = "open-cluster-management.io/cluster-name"
+	csrApprovedByReason   = "ApprovedByKubectlMulti"
+	defaultBootstrapTTL   = 24 * time.Hour
+	defaultRegisterWait   = 5 * time.Minute
+	registrationPollDelay = 2 * time.Second
+)
+
+// wecManifestFiles are the files --emit-wec-manifests writes, in the order
+// the objects must be applied
+var wecManifestFiles = []string{"00-namespace.yaml", "01-bootstrap-hub-kubeconfig.yaml", "02-klusterlet.yaml"}
+
+// clustersAddOptions holds the flags of clusters add
+type clustersAddOptions struct {
+	HubAPIServer   string
+	TokenTTL       time.Duration
+	EmitDir        string
+	ApplyToContext string
+	Labels         []string
+	Wait           time.Duration
+}
+
+func newClustersAddCommand() *cobra.Command {
+	var o clustersAddOptions
+
+	cmd := &cobra.Command{
+		Use:   "add NAME",
+		Short: "Register a cluster with the ITS and produce the manifests its klusterlet needs",
+		Long: `Register a new cluster with the ITS: create its ManagedCluster, accepted,
+and issue a bootstrap token for the OCM agents of the cluster.
+
+The cluster side is a namespace, the bootstrap kubeconfig Secret and the
+Klusterlet object. --emit-wec-manifests writes them to a directory for the
+administrator of the WEC to apply. --apply-to-context applies them directly
+when the WEC is in the kubeconfig, then approves the certificate signing
+request of the registering agent and waits for the cluster to join.
+
+Both need the klusterlet operator installed in the WEC, which runs the
+agents the Klusterlet object describes.`,
+		Example: `# Hand the manifests to the administrator of the WEC
+kubectl multi clusters add cluster3 --emit-wec-manifests ./cluster3
+
+# Register a WEC the kubeconfig can reach, labeled for placement
+kubectl multi clusters add cluster3 --apply-to-context kind-cluster3 --label env=prod
+
+# The ITS address in the kubeconfig is not reachable from the WEC
+kubectl multi clusters add cluster3 --apply-to-context kind-cluster3 --hub-apiserver https://its1.example.com:6443`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if o.EmitDir == "" && o.ApplyToContext == "" {
+				return fmt.Errorf("specify --emit-wec-manifests, --apply-to-context or both")
+			}
+			labels, err := parseLabelChanges(o.Labels)
+			if err != nil {
+				return err
+			}
+			if len(labels.Remove) > 0 {
+				return fmt.Errorf("--label takes KEY=VALUE, not %s-", labels.Remove[0])
+			}
+			kubeconfig, remoteCtx, _, _, _ := GetGlobalFlags()
+			rec := startAudit("clusters add")
+			err = handleClustersAdd(args[0], labels.Set, o, rec, kubeconfig, remoteCtx)
+			finishAudit(rec, err)
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&o.HubAPIServer, "hub-apiserver", "", "URL of the ITS API server as the WEC reaches it (defaults to the server of --remote-context in the kubeconfig)")
+	cmd.Flags().DurationVar(&o.TokenTTL, "token-ttl", defaultBootstrapTTL, "lifetime of the bootstrap token")
+	cmd.Flags().StringVar(&o.EmitDir, "emit-wec-manifests", "", "write the manifests to apply to the WEC to this directory")
+	cmd.Flags().StringVar(&o.ApplyToContext, "apply-to-context", "", "apply the manifests to the WEC with this kubeconfig context and wait for it to join")
+	cmd.Flags().StringSliceVar(&o.Labels, "label", nil, "KEY=VALUE labels of the ManagedCluster, for BindingPolicy clusterSelectors")
+	cmd.Flags().DurationVar(&o.Wait, "wait", defaultRegisterWait, "with --apply-to-context, how long to wait for the cluster to join")
+
+	return cmd
+}
+
+func handleClustersAdd(name string, labels map[string]string, o clustersAddOptions, rec *audit.Recorder, kubeconfig, remoteCtx string) error {
+	its, err := cluster.ClientForContext(kubeconfig, remoteCtx)
+	if err != nil {
+		return err
+	}
+	var wec *cluster.ClusterInfo
+	if o.ApplyToContext != "" {
+		// Fail before registering anything when the WEC cannot take the manifests
+		if wec, err = cluster.ClientForContext(kubeconfig, o.ApplyToContext); err != nil {
+			return err
+		}
+		if _, err := wec.Mapper().RESTMapping(schema.GroupKind{Group: kubestellar.KlusterletGVR.Group, Kind: "Klusterlet"}, kubestellar.KlusterletGVR.Version); err != nil {
+			return fmt.Errorf("context %s has no Klusterlet API; install the klusterlet operator in the WEC first: %v", o.ApplyToContext, err)
+		}
+	}
+
+	hubURL, caData, err := hubEndpoint(its.RestConfig, o.HubAPIServer)
+	if err != nil {
+		return err
+	}
+	if err := guardManagedClusters(rec, blastRadius{}, []string{name}, remoteCtx); err != nil {
+		return err
+	}
+	token, err := bootstrapToken(its.Client, o.TokenTTL)
+	if err != nil {
+		return err
+	}
+	objs, err := wecManifests(name, hubURL, caData, token)
+	if err != nil {
+		return err
+	}
+
+	result, err := registerManagedCluster(its.DynamicClient, name, labels)
+	rec.Record(remoteCtx, err)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("managedcluster.cluster.open-cluster-management.io/%s %s in ITS %s\n", name, result, remoteCtx)
+
+	if o.EmitDir != "" {
+		if err := writeWECManifests(o.EmitDir, objs); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote the WEC manifests to %s; the bootstrap token expires in %s\n", o.EmitDir, o.TokenTTL)
+	}
+	if wec == nil {
+		fmt.Printf("\nApply them to the WEC in file name order, then approve the certificate signing request\n"+
+			"its agent creates in ITS %s (label %s=%s), for example with 'clusteradm accept --clusters %s'.\n",
+			remoteCtx, ocmClusterNameLabel, name, name)
+		return nil
+	}
+
+	wec.Name = o.ApplyToContext
+	out, err := applyObjects(*wec, objs, "", util.DryRunNone, false)
+	fmt.Print(out)
+	rec.Record(o.ApplyToContext, err)
+	if err != nil {
+		return err
+	}
+	return waitForRegistration(its.Client, its.DynamicClient, name, o.Wait)
+}
+
+// hubEndpoint returns the URL and CA bundle the WEC reaches the ITS with:
+// override when set, otherwise the server of the ITS rest config
+func hubEndpoint(config *rest.Config, override string) (string, []byte, error) {
+	if config == nil {
+		return "", nil, fmt.Errorf("no rest config for the ITS")
+	}
+	hubURL := config.Host
+	if override != "" {
+		hubURL = override
+	}
+	if !strings.HasPrefix(hubURL, "https://") {
+		return "", nil, fmt.Errorf("the ITS API server %q must be an https URL; set --hub-apiserver", hubURL)
+	}
+	if host := strings.TrimPrefix(hubURL, "https://"); strings.HasPrefix(host, "127.") || strings.HasPrefix(host, "localhost") {
+		fmt.Fprintf(os.Stderr, "Warning: the WEC reaches the ITS at %s, which is only reachable from this machine; set --hub-apiserver if the WEC runs elsewhere\n", hubURL)
+	}
+	caData, err := restConfigCA(config)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read the CA of the ITS: %v", err)
+	}
+	return hubURL, caData, nil
+}
+
+// restConfigCA returns the CA bundle of a rest config, inline or from its file
+func restConfigCA(config *rest.Config) ([]byte, error) {
+	if len(config.CAData) > 0 || config.CAFile == "" {
+		return config.CAData, nil
+	}
+	return os.ReadFile(config.CAFile)
+}
+
+// bootstrapToken issues a token of the OCM bootstrap ServiceAccount
+func bootstrapToken(client kubernetes.Interface, ttl time.Duration) (string, error) {
+	seconds := int64(ttl.Seconds())
+	req := &authenticationv1.TokenRequest{Spec: authenticationv1.TokenRequestSpec{ExpirationSeconds: &seconds}}
+	resp, err := client.CoreV1().ServiceAccounts(ocmBootstrapNamespace).CreateToken(commandContext(), ocmBootstrapServiceAccount, req, metav1.CreateOptions{})
+	if apierrors.IsNotFound(err) {
+		return "", fmt.Errorf("ServiceAccount %s/%s not found in the ITS; it is created when the OCM cluster manager is installed", ocmBootstrapNamespace, ocmBootstrapServiceAccount)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to issue a bootstrap token: %v", err)
+	}
+	return resp.Status.Token, nil
+}
+
+// wecManifests builds the objects the WEC needs to register as name: the
+// agent namespace, the bootstrap kubeconfig Secret and the Klusterlet
+func wecManifests(name, hubURL string, caData []byte, token string) ([]*unstructured.Unstructured, error) {
+	kubeconfig := clientcmdapi.NewConfig()
+	kubeconfig.Clusters["hub"] = &clientcmdapi.Cluster{Server: hubURL, CertificateAuthorityData: caData}
+	kubeconfig.AuthInfos["bootstrap"] = &clientcmdapi.AuthInfo{Token: token}
+	kubeconfig.Contexts["bootstrap"] = &clientcmdapi.Context{Cluster: "hub", AuthInfo: "bootstrap"}
+	kubeconfig.CurrentContext = "bootstrap"
+	data, err := clientcmd.Write(*kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the bootstrap kubeconfig: %v", err)
+	}
+
+	namespace := newManifestObject("v1", "Namespace", "", ocmAgentNamespace)
+	secret := newManifestObject("v1", "Secret", ocmAgentNamespace, ocmBootstrapSecret)
+	secret.Object["type"] = string(corev1.SecretTypeOpaque)
+	secret.Object["stringData"] = map[string]interface{}{"kubeconfig": string(data)}
+	klusterlet := newManifestObject(kubestellar.KlusterletGVR.GroupVersion().String(), "Klusterlet", "", "klusterlet")
+	klusterlet.Object["spec"] = map[string]interface{}{
+		"clusterName":  name,
+		"namespace":    ocmAgentNamespace,
+		"deployOption": map[string]interface{}{"mode": "Default"},
+	}
+	return []*unstructured.Unstructured{namespace, secret, klusterlet}, nil
+}
+
+// newManifestObject returns an object with only its type and name set
+func newManifestObject(apiVersion, kind, namespace, name string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+// writeWECManifests writes one file per object to dir, readable only by the
+// user because the Secret carries the bootstrap token
+func writeWECManifests(dir string, objs []*unstructured.Unstructured) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	for i, obj := range objs {
+		data, err := util.EncodeManifests([]*unstructured.Unstructured{obj})
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, wecManifestFiles[i]), data, 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// registerManagedCluster creates the ManagedCluster name, accepted and
+// labeled, or accepts and labels the one that exists. It returns "created"
+// or "configured".
+func registerManagedCluster(its dynamic.Interface, name string, labels map[string]string) (string, error) {
+	client := its.Resource(cluster.ManagedClusterGVR)
+	mc := newManifestObject(cluster.ManagedClusterGVR.GroupVersion().String(), "ManagedCluster", "", name)
+	if len(labels) > 0 {
+		mc.SetLabels(labels)
+	}
+	mc.Object["spec"] = map[string]interface{}{"hubAcceptsClient": true}
+	util.MarkManaged(mc)
+
+	_, err := client.Create(commandContext(), mc, metav1.CreateOptions{FieldManager: util.FieldManager})
+	if err == nil {
+		return "created", nil
+	}
+	if !apierrors.IsAlreadyExists(err) {
+		return "", fmt.Errorf("failed to create ManagedCluster %s: %v", name, err)
+	}
+
+	metadata := map[string]interface{}{"annotations": map[string]interface{}{util.AppliedByAnnotation: util.FieldManager}}
+	if len(labels) > 0 {
+		metadata["labels"] = labels
+	}
+	patch, err := json.Marshal(map[string]interface{}{"metadata": metadata, "spec": mc.Object["spec"]})
+	if err != nil {
+		return "", err
+	}
+	if _, err := client.Patch(commandContext(), name, types.MergePatchType, patch, metav1.PatchOptions{FieldManager: util.FieldManager}); err != nil {
+		return "", fmt.Errorf("failed to accept ManagedCluster %s: %v", name, err)
+	}
+	return "configured", nil
+}
+
+// approveRegistrationCSRs approves the pending certificate signing requests
+// the agents of cluster name created in the ITS and returns how many
+func approveRegistrationCSRs(client kubernetes.Interface, name string) (int, error) {
+	csrs, err := client.CertificatesV1().CertificateSigningRequests().List(commandContext(), metav1.ListOptions{
+		LabelSelector: ocmClusterNameLabel + "=" + name,
+	})
+	if err != nil {
+		return 0, err
+	}
+	approved := 0
+	for i := range csrs.Items {
+		csr := &csrs.Items[i]
+		if len(csr.Status.Conditions) > 0 {
+			// Already approved or denied
+			continue
+		}
+		csr.Status.Conditions = append(csr.Status.Conditions, certificatesv1.CertificateSigningRequestCondition{
+			Type:    certificatesv1.CertificateApproved,
+			Status:  corev1.ConditionTrue,
+			Reason:  csrApprovedByReason,
+			Message: "approved by kubectl multi clusters add",
+		})
+		if _, err := client.CertificatesV1().CertificateSigningRequests().UpdateApproval(commandContext(), csr.Name, csr, metav1.UpdateOptions{}); err != nil {
+			return approved, fmt.Errorf("failed to approve CertificateSigningRequest %s: %v", csr.Name, err)
+		}
+		fmt.Printf("certificatesigningrequest.certificates.k8s.io/%s approved\n", csr.Name)
+		approved++
+	}
+	return approved, nil
+}
+
+// waitForRegistration approves the certificate signing requests of the
+// cluster as its agent creates them, until the ManagedCluster has joined
+func waitForRegistration(client kubernetes.Interface, its dynamic.Interface, name string, wait time.Duration) error {
+	fmt.Printf("Waiting up to %s for cluster %s to join\n", wait, name)
+	deadline := time.Now().Add(wait)
+	for {
+		if _, err := approveRegistrationCSRs(client, name); err != nil {
+			return err
+		}
+		mc, err := its.Resource(cluster.ManagedClusterGVR).Get(commandContext(), name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		summary := kubestellar.SummarizeCluster(mc)
+		if summary.Joined {
+			fmt.Printf("Cluster %s joined (available: %s)\n", name, summary.Available)
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("cluster %s did not join within %s; check the klusterlet agents in namespace %s of the WEC", name, wait, ocmAgentNamespace)
+		}
+		select {
+		case <-commandContext().Done():
+			return commandContext().Err()
+		case <-time.After(registrationPollDelay):
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+func TestHubEndpoint(t *testing.T) {
+	config := &rest.Config{Host: "https://its1.example.com:6443"}
+	config.CAData = []byte("ca")
+	tests := []struct {
+		name     string
+		override string
+		wantURL  string
+		wantErr  string
+	}{
+		{name: "from the rest config", wantURL: "https://its1.example.com:6443"},
+		{name: "override", override: "https://10.0.0.1:6443", wantURL: "https://10.0.0.1:6443"},
+		{name: "not https", override: "http://10.0.0.1:8080", wantErr: "must be an https URL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, ca, err := hubEndpoint(config, tt.override)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("hubEndpoint() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("hubEndpoint() error = %v", err)
+			}
+			if url != tt.wantURL || string(ca) != "ca" {
+				t.Errorf("hubEndpoint() = %q, %q, want %q, \"ca\"", url, ca, tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestBootstrapToken(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Namespace: ocmBootstrapNamespace, Name: ocmBootstrapServiceAccount}})
+	client.PrependReactor("create", "serviceaccounts", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "token" {
+			return false, nil, nil
+		}
+		req := action.(clienttesting.CreateAction).GetObject().(*authenticationv1.TokenRequest)
+		if *req.Spec.ExpirationSeconds != 3600 {
+			t.Errorf("token requested for %ds, want 3600", *req.Spec.ExpirationSeconds)
+		}
+		return true, &authenticationv1.TokenRequest{Status: authenticationv1.TokenRequestStatus{Token: "secret-token"}}, nil
+	})
+	token, err := bootstrapToken(client, time.Hour)
+	if err != nil {
+		t.Fatalf("bootstrapToken() error = %v", err)
+	}
+	if token != "secret-token" {
+		t.Errorf("bootstrapToken() = %q, want secret-token", token)
+	}
+}
+
+func TestWECManifests(t *testing.T) {
+	objs, err := wecManifests("cluster3", "https://its1.example.com:6443", []byte("ca"), "secret-token")
+	if err != nil {
+		t.Fatalf("wecManifests() error = %v", err)
+	}
+	var kinds []string
+	for _, obj := range objs {
+		kinds = append(kinds, obj.GetKind())
+	}
+	if want := []string{"Namespace", "Secret", "Klusterlet"}; !reflect.DeepEqual(kinds, want) {
+		t.Fatalf("wecManifests() kinds = %v, want %v", kinds, want)
+	}
+
+	data, _, _ := unstructured.NestedString(objs[1].Object, "stringData", "kubeconfig")
+	kubeconfig, err := clientcmd.Load([]byte(data))
+	if err != nil {
+		t.Fatalf("bootstrap kubeconfig does not load: %v", err)
+	}
+	hub := kubeconfig.Clusters["hub"]
+	if hub == nil || hub.Server != "https://its1.example.com:6443" || string(hub.CertificateAuthorityData) != "ca" {
+		t.Errorf("bootstrap kubeconfig cluster = %+v", hub)
+	}
+	if auth := kubeconfig.AuthInfos["bootstrap"]; auth == nil || auth.Token != "secret-token" {
+		t.Errorf("bootstrap kubeconfig user = %+v", auth)
+	}
+
+	name, _, _ := unstructured.NestedString(objs[2].Object, "spec", "clusterName")
+	namespace, _, _ := unstructured.NestedString(objs[2].Object, "spec", "namespace")
+	if name != "cluster3" || namespace != ocmAgentNamespace {
+		t.Errorf("Klusterlet clusterName, namespace = %q, %q", name, namespace)
+	}
+}
+
+func TestWriteWECManifests(t *testing.T) {
+	objs, err := wecManifests("cluster3", "https://its1.example.com:6443", nil, "secret-token")
+	if err != nil {
+		t.Fatalf("wecManifests() error = %v", err)
+	}
+	dir := filepath.Join(t.TempDir(), "cluster3")
+	if err := writeWECManifests(dir, objs); err != nil {
+		t.Fatalf("writeWECManifests() error = %v", err)
+	}
+	for i, file := range wecManifestFiles {
+		path := filepath.Join(dir, file)
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("%s not written: %v", file, err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("%s mode = %v, want 0600", file, info.Mode().Perm())
+		}
+		read, err := util.ReadManifests(path, false)
+		if err != nil || len(read) != 1 || read[0].GetKind() != objs[i].GetKind() {
+			t.Errorf("%s does not hold the %s: %v", file, objs[i].GetKind(), err)
+		}
+	}
+}
+
+func TestRegisterManagedCluster(t *testing.T) {
+	pending := testManagedCluster("cluster2", map[string]string{"env": "dev"})
+	unstructured.SetNestedField(pending.Object, false, "spec", "hubAcceptsClient")
+	its := testITSDynamic(pending)
+
+	tests := []struct {
+		name       string
+		cluster    string
+		labels     map[string]string
+		wantResult string
+		wantLabels map[string]string
+	}{
+		{name: "new cluster", cluster: "cluster3", labels: map[string]string{"env": "prod"}, wantResult: "created", wantLabels: map[string]string{"env": "prod"}},
+		{name: "pending cluster", cluster: "cluster2", labels: map[string]string{"region": "eu"}, wantResult: "configured", wantLabels: map[string]string{"env": "dev", "region": "eu"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := registerManagedCluster(its, tt.cluster, tt.labels)
+			if err != nil {
+				t.Fatalf("registerManagedCluster() error = %v", err)
+			}
+			if result != tt.wantResult {
+				t.Errorf("registerManagedCluster() = %q, want %q", result, tt.wantResult)
+			}
+			mc, err := its.Resource(cluster.ManagedClusterGVR).Get(commandContext(), tt.cluster, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if accepted, _, _ := unstructured.NestedBool(mc.Object, "spec", "hubAcceptsClient"); !accepted {
+				t.Errorf("ManagedCluster %s is not accepted", tt.cluster)
+			}
+			if !reflect.DeepEqual(mc.GetLabels(), tt.wantLabels) {
+				t.Errorf("ManagedCluster %s labels = %v, want %v", tt.cluster, mc.GetLabels(), tt.wantLabels)
+			}
+			if !util.IsManaged(mc) {
+				t.Errorf("ManagedCluster %s lacks the %s annotation", tt.cluster, util.AppliedByAnnotation)
+			}
+		})
+	}
+}
+
+func TestApproveRegistrationCSRs(t *testing.T) {
+	csr := func(name, clusterName string, conditions ...certificatesv1.CertificateSigningRequestCondition) *certificatesv1.CertificateSigningRequest {
+		return &certificatesv1.CertificateSigningRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{ocmClusterNameLabel: clusterName}},
+			Status:     certificatesv1.CertificateSigningRequestStatus{Conditions: conditions},
+		}
+	}
+	denied := certificatesv1.CertificateSigningRequestCondition{Type: certificatesv1.CertificateDenied, Status: corev1.ConditionTrue}
+	client := fake.NewSimpleClientset(
+		csr("cluster3-abc", "cluster3"),
+		csr("cluster3-old", "cluster3", denied),
+		csr("cluster4-abc", "cluster4"),
+	)
+
+	approved, err := approveRegistrationCSRs(client, "cluster3")
+	if err != nil {
+		t.Fatalf("approveRegistrationCSRs() error = %v", err)
+	}
+	if approved != 1 {
+		t.Errorf("approveRegistrationCSRs() = %d, want 1", approved)
+	}
+	for name, want := range map[string]certificatesv1.RequestConditionType{"cluster3-abc": certificatesv1.CertificateApproved, "cluster3-old": certificatesv1.CertificateDenied, "cluster4-abc": ""} {
+		got, err := client.CertificatesV1().CertificateSigningRequests().Get(commandContext(), name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var last certificatesv1.RequestConditionType
+		if n := len(got.Status.Conditions); n > 0 {
+			last = got.Status.Conditions[n-1].Type
+		}
+		if last != want {
+			t.Errorf("CSR %s condition = %q, want %q", name, last, want)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/config"
+	"kubectl-multi/pkg/kubestellar"
+	"kubectl-multi/pkg/util"
+)
+
+// clusterClaims is the claims of one ManagedCluster, as printed by clusters
+// claims without changes
+type clusterClaims struct {
+	Cluster string                     `json:"cluster"`
+	Claims  []kubestellar.ClusterClaim `json:"claims"`
+}
+
+func newClustersClaimsCommand() *cobra.Command {
+	var all bool
+	var selector string
+	var dryRun bool
+	var outputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "claims [CLUSTER|@GROUP...] [NAME=VALUE...] [NAME-...]",
+		Short: "Show or set the cluster claims of ManagedClusters",
+		Long: `Show or set the claims clusters make about themselves, such as their
+platform or region, which placements can select clusters by.
+
+Claims are shown as the ITS received them, in the status of each
+ManagedCluster, all clusters unless some are named or selected. A claim is
+set or removed through the ClusterClaim object of that name in the cluster
+itself; the klusterlet reports the change to the ITS shortly after.`,
+		Example: `# Show the claims of every cluster
+kubectl multi clusters claims
+
+# Claim a region on two clusters
+kubectl multi clusters claims cluster1 cluster2 region.open-cluster-management.io=eu-west
+
+# Remove a claim from the clusters labeled env=dev
+kubectl multi clusters claims -l env=dev gpu-`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var names, changeArgs []string
+			for _, arg := range args {
+				if strings.Contains(arg, "=") || (strings.HasSuffix(arg, "-") && !strings.HasPrefix(arg, config.GroupPrefix)) {
+					changeArgs = append(changeArgs, arg)
+				} else {
+					names = append(names, arg)
+				}
+			}
+			chosen := 0
+			for _, set := range []bool{len(names) > 0, all, selector != ""} {
+				if set {
+					chosen++
+				}
+			}
+			if chosen > 1 {
+				return fmt.Errorf("specify only one of cluster names, --all or -l")
+			}
+
+			kubeconfig, remoteCtx, _, _, _ := GetGlobalFlags()
+			mcs, err := cluster.ListManagedClusterObjects(commandContext(), kubeconfig, remoteCtx)
+			if err != nil {
+				return err
+			}
+
+			if len(changeArgs) == 0 {
+				switch outputFormat {
+				case "", "json", "yaml":
+				default:
+					return fmt.Errorf("unsupported output format %q, must be one of json|yaml", outputFormat)
+				}
+				targets, err := selectManagedClusters(mcs, names, all || chosen == 0, selector, remoteCtx)
+				if err != nil {
+					return err
+				}
+				return printClusterClaims(mcs, targets, outputFormat)
+			}
+
+			if chosen == 0 {
+				return fmt.Errorf("specify the clusters to change by name, --all or -l")
+			}
+			change, err := parseLabelChanges(changeArgs)
+			if err != nil {
+				return err
+			}
+			targets, err := selectManagedClusters(mcs, names, all, selector, remoteCtx)
+			if err != nil {
+				return err
+			}
+			clusters, err := discoverClusters(kubeconfig, remoteCtx)
+			if err != nil {
+				return fmt.Errorf("failed to discover clusters: %v", err)
+			}
+			byName := make(map[string]cluster.ClusterInfo, len(clusters))
+			for _, c := range clusters {
+				byName[c.Name] = c
+			}
+
+			var rec *audit.Recorder
+			if !dryRun {
+				rec = startAudit("clusters claims")
+			}
+			err = guardManagedClusters(rec, blastRadius{}, targets, remoteCtx)
+			if err == nil {
+				err = handleClustersClaims(byName, targets, change, dryRun, rec)
+			}
+			finishAudit(rec, err)
+			return err
+		},
+	}
+
+	cmd.Flags().BoolVar(&all, "all", false, "change every ManagedCluster in the ITS")
+	cmd.Flags().StringVarP(&selector, "selector", "l", "", "label selector choosing the ManagedClusters")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only print the claim changes that would be made")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "output format when showing claims (json|yaml)")
+
+	return cmd
+}
+
+// handleClustersClaims sets and removes the ClusterClaims of the named
+// clusters, each through its own client in clusters
+func handleClustersClaims(clusters map[string]cluster.ClusterInfo, names []string, change labelChange, dryRun bool, rec *audit.Recorder) error {
+	names = append([]string{}, names...)
+	sort.Strings(names)
+
+	var failed []string
+	for _, name := range names {
+		c, ok := clusters[name]
+		if !ok || c.DynamicClient == nil {
+			err := fmt.Errorf("cluster %s is not reachable", name)
+			rec.Record(name, err)
+			fmt.Printf("%s: error: %v\n", name, err)
+			failed = append(failed, name)
+			continue
+		}
+
+		diff, undo, err := applyClaimChange(c.DynamicClient, change, dryRun)
+		for _, step := range undo {
+			step.Cluster = name
+			rec.AddUndo(step)
+		}
+		rec.Record(name, err)
+		switch {
+		case err != nil:
+			fmt.Printf("%s: error: %v\n", name, err)
+			failed = append(failed, name)
+		case len(diff) == 0:
+			fmt.Printf("%s: unchanged\n", name)
+		case dryRun:
+			fmt.Printf("%s: %s (dry run)\n", name, strings.Join(diff, " "))
+		default:
+			fmt.Printf("%s: %s\n", name, strings.Join(diff, " "))
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to change the claims of %d cluster(s): %s", len(failed), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// applyClaimChange makes the change to the ClusterClaims served by the
+// client of one cluster. It returns the diff made (+name=value created,
+// ~name=old->new updated, -name deleted) and how to undo it; on an error the
+// changes made before it are returned too.
+func applyClaimChange(c dynamic.Interface, change labelChange, dryRun bool) ([]string, []audit.UndoStep, error) {
+	client := c.Resource(kubestellar.ClusterClaimGVR)
+	gvr := kubestellar.ClusterClaimGVR
+	var diff []string
+	var undo []audit.UndoStep
+
+	keys := make([]string, 0, len(change.Set))
+	for k := range change.Set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := change.Set[k]
+		live, err := client.Get(commandContext(), k, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			diff = append(diff, fmt.Sprintf("+%s=%s", k, v))
+			if dryRun {
+				continue
+			}
+			claim := newManifestObject(gvr.GroupVersion().String(), "ClusterClaim", "", k)
+			unstructured.SetNestedField(claim.Object, v, "spec", "value")
+			util.MarkManaged(claim)
+			if _, err := client.Create(commandContext(), claim, metav1.CreateOptions{FieldManager: util.FieldManager}); err != nil {
+				return diff, undo, fmt.Errorf("failed to create ClusterClaim %s: %v", k, err)
+			}
+			undo = append(undo, audit.UndoStep{Action: audit.UndoDelete, Group: gvr.Group, Version: gvr.Version, Resource: gvr.Resource, Name: k})
+		case err != nil:
+			return diff, undo, fmt.Errorf("failed to get ClusterClaim %s: %v", k, err)
+		default:
+			old, _, _ := unstructured.NestedString(live.Object, "spec", "value")
+			if old == v {
+				continue
+			}
+			diff = append(diff, fmt.Sprintf("~%s=%s->%s", k, old, v))
+			if dryRun {
+				continue
+			}
+			snapshot := snapshotObject(live)
+			unstructured.SetNestedField(live.Object, v, "spec", "value")
+			if _, err := client.Update(commandContext(), live, metav1.UpdateOptions{FieldManager: util.FieldManager}); err != nil {
+				return diff, undo, fmt.Errorf("failed to update ClusterClaim %s: %v", k, err)
+			}
+			undo = append(undo, audit.UndoStep{Action: audit.UndoRestore, Group: gvr.Group, Version: gvr.Version, Resource: gvr.Resource, Name: k, Object: snapshot.Object})
+		}
+	}
+
+	for _, k := range change.Remove {
+		live, err := client.Get(commandContext(), k, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return diff, undo, fmt.Errorf("failed to get ClusterClaim %s: %v", k, err)
+		}
+		diff = append(diff, "-"+k)
+		if dryRun {
+			continue
+		}
+		if err := client.Delete(commandContext(), k, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return diff, undo, fmt.Errorf("failed to delete ClusterClaim %s: %v", k, err)
+		}
+		undo = append(undo, audit.UndoStep{Action: audit.UndoRestore, Group: gvr.Group, Version: gvr.Version, Resource: gvr.Resource, Name: k, Object: snapshotObject(live).Object})
+	}
+	return diff, undo, nil
+}
+
+// printClusterClaims prints the claims reported by the named ManagedClusters
+// of mcs
+func printClusterClaims(mcs []unstructured.Unstructured, names []string, outputFormat string) error {
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+	result := []clusterClaims{}
+	for i := range mcs {
+		if wanted[mcs[i].GetName()] {
+			result = append(result, clusterClaims{Cluster: mcs[i].GetName(), Claims: kubestellar.ManagedClusterClaims(&mcs[i])})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Cluster < result[j].Cluster })
+
+	if outputFormat != "" {
+		return util.PrintStructured(util.GetOutputStream(), outputFormat, result)
+	}
+
+	tw := util.NewTableWriter(util.GetOutputStream(), "")
+	defer tw.Flush()
+	fmt.Fprintln(tw, "CLUSTER\tCLAIM\tVALUE")
+	for _, c := range result {
+		if len(c.Claims) == 0 {
+			fmt.Fprintf(tw, "%s\t<none>\t\n", c.Cluster)
+			continue
+		}
+		for _, claim := range c.Claims {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", c.Cluster, claim.Name, claim.Value)
+		}
+	}
+	return nil
+}
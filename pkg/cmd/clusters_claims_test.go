@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+)
+
+func testClusterClaim(name, value string) *unstructured.Unstructured {
+	claim := newManifestObject(kubestellar.ClusterClaimGVR.GroupVersion().String(), "ClusterClaim", "", name)
+	unstructured.SetNestedField(claim.Object, value, "spec", "value")
+	return claim
+}
+
+func TestHandleClustersClaims(t *testing.T) {
+	wec := fakedynamic.NewSimpleDynamicClient(runtime.NewScheme(),
+		testClusterClaim("region.open-cluster-management.io", "eu-west"),
+		testClusterClaim("gpu", "a100"),
+	)
+	clusters := map[string]cluster.ClusterInfo{
+		"cluster1": {Name: "cluster1", DynamicClient: wec},
+		"cluster2": {Name: "cluster2"},
+	}
+	change := labelChange{
+		Set:    map[string]string{"region.open-cluster-management.io": "eu-central", "tier": "edge"},
+		Remove: []string{"gpu", "absent"},
+	}
+
+	// A dry run changes nothing
+	if err := handleClustersClaims(clusters, []string{"cluster1"}, change, true, nil); err != nil {
+		t.Fatalf("handleClustersClaims(dry run) error = %v", err)
+	}
+	if _, err := wec.Resource(kubestellar.ClusterClaimGVR).Get(context.TODO(), "gpu", metav1.GetOptions{}); err != nil {
+		t.Fatalf("dry run deleted the gpu claim: %v", err)
+	}
+
+	rec := audit.Start("clusters claims", nil)
+	err := handleClustersClaims(clusters, []string{"cluster2", "cluster1"}, change, false, rec)
+	if err == nil || err.Error() != "failed to change the claims of 1 cluster(s): cluster2" {
+		t.Fatalf("handleClustersClaims() error = %v", err)
+	}
+
+	list, err := wec.Resource(kubestellar.ClusterClaimGVR).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, claim := range list.Items {
+		got[claim.GetName()], _, _ = unstructured.NestedString(claim.Object, "spec", "value")
+	}
+	if want := map[string]string{"region.open-cluster-management.io": "eu-central", "tier": "edge"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("claims = %v, want %v", got, want)
+	}
+
+	var actions []string
+	for _, step := range rec.Finish(nil).Undo {
+		actions = append(actions, step.Cluster+" "+step.Action+" "+step.Name)
+	}
+	want := []string{
+		"cluster1 restore region.open-cluster-management.io",
+		"cluster1 delete tier",
+		"cluster1 restore gpu",
+	}
+	if !reflect.DeepEqual(actions, want) {
+		t.Errorf("undo steps = %v, want %v", actions, want)
+	}
+}
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+	"kubectl-multi/pkg/util"
+)
+
+// clusterSetMembership is the ManagedClusterSet of one ManagedCluster, as
+// printed by clusters clusterset without changes
+type clusterSetMembership struct {
+	Cluster    string `json:"cluster"`
+	ClusterSet string `json:"clusterSet,omitempty"`
+}
+
+func newClustersClusterSetCommand() *cobra.Command {
+	var all bool
+	var selector string
+	var set string
+	var unset bool
+	var dryRun bool
+	var outputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "clusterset [CLUSTER|@GROUP...] [--set SET | --unset]",
+		Short: "Show or change the ManagedClusterSet of ManagedClusters",
+		Long: `Show or change the ManagedClusterSet each ManagedCluster belongs to.
+
+Membership is the cluster.open-cluster-management.io/clusterset label of the
+ManagedCluster; a cluster is in at most one set, so --set moves clusters out
+of the set they were in. Placements only select clusters of the sets bound to
+their namespace. Without --set or --unset the command prints the set of the
+clusters, all of them unless some are named or selected.`,
+		Example: `# Show which set every cluster is in
+kubectl multi clusters clusterset
+
+# Move the clusters labeled env=prod into the prod set
+kubectl multi clusters clusterset -l env=prod --set prod
+
+# Take cluster3 out of its set, previewing first
+kubectl multi clusters clusterset cluster3 --unset --dry-run`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			chosen := 0
+			for _, s := range []bool{len(args) > 0, all, selector != ""} {
+				if s {
+					chosen++
+				}
+			}
+			if chosen > 1 {
+				return fmt.Errorf("specify only one of cluster names, --all or -l")
+			}
+			if set != "" && unset {
+				return fmt.Errorf("--set and --unset cannot be combined")
+			}
+
+			kubeconfig, remoteCtx, _, _, _ := GetGlobalFlags()
+			mcs, err := cluster.ListManagedClusterObjects(commandContext(), kubeconfig, remoteCtx)
+			if err != nil {
+				return err
+			}
+
+			if set == "" && !unset {
+				switch outputFormat {
+				case "", "json", "yaml":
+				default:
+					return fmt.Errorf("unsupported output format %q, must be one of json|yaml", outputFormat)
+				}
+				targets, err := selectManagedClusters(mcs, args, all || chosen == 0, selector, remoteCtx)
+				if err != nil {
+					return err
+				}
+				return printClusterSets(mcs, targets, outputFormat)
+			}
+
+			if chosen == 0 {
+				return fmt.Errorf("specify the clusters to change by name, --all or -l")
+			}
+			targets, err := selectManagedClusters(mcs, args, all, selector, remoteCtx)
+			if err != nil {
+				return err
+			}
+			change := labelChange{Set: map[string]string{}}
+			if unset {
+				change.Remove = []string{kubestellar.ClusterSetLabel}
+			} else {
+				change.Set[kubestellar.ClusterSetLabel] = set
+			}
+			changes := make(map[string]labelChange, len(targets))
+			for _, name := range targets {
+				changes[name] = change
+			}
+
+			var rec *audit.Recorder
+			if !dryRun {
+				rec = startAudit("clusters clusterset")
+			}
+			err = guardManagedClusters(rec, blastRadius{}, targets, remoteCtx)
+			var its *cluster.ClusterInfo
+			if err == nil {
+				its, err = cluster.ClientForContext(kubeconfig, remoteCtx)
+			}
+			if err == nil {
+				if set != "" {
+					warnMissingClusterSet(its.DynamicClient, set, remoteCtx)
+				}
+				// Moving a cluster to another set replaces the label
+				err = handleClustersLabel(its.DynamicClient, changes, dryRun, true, rec)
+			}
+			finishAudit(rec, err)
+			return err
+		},
+	}
+
+	cmd.Flags().BoolVar(&all, "all", false, "change every ManagedCluster in the ITS")
+	cmd.Flags().StringVarP(&selector, "selector", "l", "", "label selector choosing the ManagedClusters")
+	cmd.Flags().StringVar(&set, "set", "", "ManagedClusterSet to move the clusters into")
+	cmd.Flags().BoolVar(&unset, "unset", false, "take the clusters out of their ManagedClusterSet")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only print the changes that would be made")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "output format when showing sets (json|yaml)")
+
+	return cmd
+}
+
+// warnMissingClusterSet warns on stderr when the ManagedClusterSet does not
+// exist in the ITS, since no placement selects its clusters until it does
+func warnMissingClusterSet(its dynamic.Interface, set, remoteCtx string) {
+	_, err := its.Resource(kubestellar.ManagedClusterSetGVR).Get(commandContext(), set, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		fmt.Fprintf(os.Stderr, "Warning: ManagedClusterSet %q does not exist in ITS %s, its clusters are not placed until it is created\n", set, remoteCtx)
+	}
+}
+
+// printClusterSets prints the ManagedClusterSet of the named ManagedClusters
+// of mcs
+func printClusterSets(mcs []unstructured.Unstructured, names []string, outputFormat string) error {
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+	result := []clusterSetMembership{}
+	for i := range mcs {
+		if wanted[mcs[i].GetName()] {
+			result = append(result, clusterSetMembership{Cluster: mcs[i].GetName(), ClusterSet: kubestellar.ManagedClusterSet(&mcs[i])})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Cluster < result[j].Cluster })
+
+	if outputFormat != "" {
+		return util.PrintStructured(util.GetOutputStream(), outputFormat, result)
+	}
+
+	tw := util.NewTableWriter(util.GetOutputStream(), "")
+	defer tw.Flush()
+	fmt.Fprintln(tw, "CLUSTER\tCLUSTERSET")
+	for _, m := range result {
+		fmt.Fprintf(tw, "%s\t%s\n", m.Cluster, orNone(m.ClusterSet))
+	}
+	return nil
+}
@@ -0,0 +1,397 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+	"kubectl-multi/pkg/util"
+)
+
+const (
+	defaultExportServiceAccount = "kubectl-multi"
+	defaultExportTokenValidity  = 7 * 24 * time.Hour
+	defaultExportWait           = time.Minute
+)
+
+// exportPollDelay is how often export-contexts re-reads a
+// ManagedServiceAccount while waiting for its token
+var exportPollDelay = 2 * time.Second
+
+// clustersExportOptions holds the flags of clusters export-contexts
+type clustersExportOptions struct {
+	MergeInto      string
+	Selector       string
+	ServiceAccount string
+	Validity       time.Duration
+	Wait           time.Duration
+	Overwrite      bool
+}
+
+// exportedCluster is the endpoint of one managed cluster and the token the
+// ITS minted for it, written as a kubeconfig context
+type exportedCluster struct {
+	Context string
+	Server  string
+	CAData  []byte
+	Token   string
+	Expires time.Time
+}
+
+func newClustersExportContextsCommand() *cobra.Command {
+	var o clustersExportOptions
+
+	cmd := &cobra.Command{
+		Use:   "export-contexts [CLUSTER|@GROUP...]",
+		Short: "Write kubeconfig contexts for the managed clusters with credentials minted by the ITS",
+		Long: `Write a kubeconfig context for every managed cluster discovered through the
+ITS, or the ones named or selected with -l, so plain kubectl can reach a
+single cluster when needed.
+
+Each context reaches the cluster at the API server URL and with the CA its
+ManagedCluster registered with. The credentials are minted by the ITS: a
+ManagedServiceAccount of the OCM managed-serviceaccount addon is created in
+the namespace of the cluster, the addon creates the ServiceAccount in the
+cluster and reports a token for it back to the ITS. The addon must be
+enabled for the clusters, and the ServiceAccount (in namespace
+open-cluster-management-agent-addon of each cluster) only allows what RBAC
+bindings there grant it. The token rotates after --validity; run the command
+again to refresh the contexts.
+
+Contexts are named after the clusters, or ITS/NAME for a name registered in
+several ITSes, which is what cluster discovery looks for. Without
+--merge-into the kubeconfig is printed. With it, the contexts are added to
+that file; a context of the same name that the command did not write is
+left alone unless --overwrite is set, and the current context is kept.`,
+		Example: `# Add a context for every cluster to your kubeconfig
+kubectl multi clusters export-contexts --merge-into ~/.kube/config
+kubectl --context cluster1 get pods
+
+# Only the production clusters, into a separate file
+kubectl multi clusters export-contexts -l env=prod --merge-into prod.kubeconfig
+
+# Print a kubeconfig for two clusters
+kubectl multi clusters export-contexts cluster1 cluster2 > fleet.kubeconfig`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 && o.Selector != "" {
+				return fmt.Errorf("specify only one of cluster names or -l")
+			}
+			if o.Validity < minKubeconfigTokenTTL {
+				return fmt.Errorf("--validity must be at least %s", minKubeconfigTokenTTL)
+			}
+			kubeconfig, remoteCtx, _, _, _ := GetGlobalFlags()
+			rec := startAudit("clusters export-contexts")
+			err := acquireFleetLock(rec)
+			if err == nil {
+				err = handleClustersExportContexts(args, o, rec, kubeconfig, remoteCtx)
+			}
+			finishAudit(rec, err)
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&o.MergeInto, "merge-into", "", "kubeconfig file to add or update the contexts in, created if missing (prints the kubeconfig when empty)")
+	cmd.Flags().StringVarP(&o.Selector, "selector", "l", "", "label selector choosing the ManagedClusters")
+	cmd.Flags().StringVar(&o.ServiceAccount, "sa", defaultExportServiceAccount, "name of the ManagedServiceAccount, and of the ServiceAccount in each cluster, the tokens are issued for")
+	cmd.Flags().DurationVar(&o.Validity, "validity", defaultExportTokenValidity, "how long each token is valid before the addon rotates it")
+	cmd.Flags().DurationVar(&o.Wait, "wait", defaultExportWait, "how long to wait for the addon to report the token of each cluster")
+	cmd.Flags().BoolVar(&o.Overwrite, "overwrite", false, "replace contexts of the same name that were not written by this command")
+
+	return cmd
+}
+
+func handleClustersExportContexts(names []string, o clustersExportOptions, rec *audit.Recorder, kubeconfig, remoteCtx string) error {
+	sel, err := labels.Parse(o.Selector)
+	if err != nil {
+		return fmt.Errorf("invalid selector %q: %v", o.Selector, err)
+	}
+	names, err = expandClusterNames(names)
+	if err != nil {
+		return err
+	}
+	itsContexts, err := selectedITSContexts(kubeconfig, remoteCtx)
+	if err != nil {
+		return err
+	}
+
+	// Context names follow cluster discovery: ITS/NAME for a name
+	// registered in more than one ITS
+	type itsClients struct {
+		dynamic dynamic.Interface
+		typed   kubernetes.Interface
+		mcs     map[string]*unstructured.Unstructured
+	}
+	clients := map[string]itsClients{}
+	var inventories []cluster.ITSInventory
+	for _, its := range itsContexts {
+		c, err := cluster.ClientForContext(kubeconfig, its)
+		if err != nil {
+			return err
+		}
+		mcs, err := cluster.ListManagedClusters(commandContext(), c.DynamicClient)
+		if err != nil {
+			return fmt.Errorf("failed to list the ManagedClusters of ITS %s: %v", its, err)
+		}
+		inv := cluster.ITSInventory{ITS: its}
+		byName := map[string]*unstructured.Unstructured{}
+		for i := range mcs {
+			name := mcs[i].GetName()
+			if cluster.IsWDSCluster(name) || !sel.Matches(labels.Set(mcs[i].GetLabels())) {
+				continue
+			}
+			inv.Clusters = append(inv.Clusters, name)
+			byName[name] = &mcs[i]
+		}
+		inventories = append(inventories, inv)
+		clients[its] = itsClients{dynamic: c.DynamicClient, typed: c.Client, mcs: byName}
+	}
+
+	refs := cluster.MergeInventories(inventories)
+	if len(names) > 0 {
+		wanted := map[string]bool{}
+		for _, name := range names {
+			wanted[name] = true
+		}
+		var selected []cluster.ManagedClusterRef
+		found := map[string]bool{}
+		for _, ref := range refs {
+			if wanted[ref.Name] || wanted[ref.Cluster] {
+				selected = append(selected, ref)
+				found[ref.Name], found[ref.Cluster] = true, true
+			}
+		}
+		var missing []string
+		for _, name := range names {
+			if !found[name] {
+				missing = append(missing, name)
+			}
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("ManagedCluster(s) not found in the ITS: %s", strings.Join(missing, ", "))
+		}
+		refs = selected
+	}
+	if len(refs) == 0 {
+		return fmt.Errorf("no ManagedClusters match the selection")
+	}
+
+	// The progress goes to stderr when stdout carries the kubeconfig
+	progress := os.Stdout
+	if o.MergeInto == "" {
+		progress = os.Stderr
+	}
+
+	var exported []exportedCluster
+	var failed []string
+	for _, ref := range refs {
+		c := clients[ref.ITS]
+		e, err := exportClusterCredentials(c.dynamic, c.typed, c.mcs[ref.Cluster], o)
+		rec.Record(ref.Name, err)
+		if err != nil {
+			fmt.Fprintf(progress, "%s: error: %v\n", ref.Name, err)
+			failed = append(failed, ref.Name)
+			continue
+		}
+		e.Context = ref.Name
+		exported = append(exported, e)
+	}
+
+	if len(exported) > 0 {
+		if err := writeExportedContexts(exported, o, progress); err != nil {
+			return err
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to export the contexts of %d cluster(s): %s", len(failed), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// exportClusterCredentials returns the endpoint of the ManagedCluster mc and
+// a token for it minted through the ITS. The ManagedServiceAccount is
+// created in the namespace of the cluster when missing, then read until
+// the addon reports the token, within o.Wait.
+func exportClusterCredentials(its dynamic.Interface, itsClient kubernetes.Interface, mc *unstructured.Unstructured, o clustersExportOptions) (exportedCluster, error) {
+	name := mc.GetName()
+	server, caData, err := kubestellar.ManagedClusterClientConfig(mc)
+	if err != nil {
+		return exportedCluster{}, err
+	}
+	if server == "" {
+		return exportedCluster{}, fmt.Errorf("ManagedCluster %s reports no API server URL in spec.managedClusterClientConfigs", name)
+	}
+
+	client := its.Resource(kubestellar.ManagedServiceAccountGVR).Namespace(name)
+	_, err = client.Get(commandContext(), o.ServiceAccount, metav1.GetOptions{})
+	switch {
+	case meta.IsNoMatchError(err) || (apierrors.IsNotFound(err) && isManagedServiceAccountMissing(err)):
+		return exportedCluster{}, fmt.Errorf("the ITS does not serve ManagedServiceAccounts; install the managed-serviceaccount addon")
+	case apierrors.IsNotFound(err):
+		msa := newManifestObject(kubestellar.ManagedServiceAccountGVR.GroupVersion().String(), "ManagedServiceAccount", name, o.ServiceAccount)
+		unstructured.SetNestedMap(msa.Object, map[string]interface{}{
+			"enabled":  true,
+			"validity": o.Validity.String(),
+		}, "spec", "rotation")
+		util.MarkManaged(msa)
+		if _, err := client.Create(commandContext(), msa, metav1.CreateOptions{FieldManager: util.FieldManager}); err != nil {
+			return exportedCluster{}, fmt.Errorf("failed to create ManagedServiceAccount %s/%s: %v", name, o.ServiceAccount, err)
+		}
+	case err != nil:
+		return exportedCluster{}, fmt.Errorf("failed to get ManagedServiceAccount %s/%s: %v", name, o.ServiceAccount, err)
+	}
+
+	deadline := time.Now().Add(o.Wait)
+	for {
+		msa, err := client.Get(commandContext(), o.ServiceAccount, metav1.GetOptions{})
+		if err != nil {
+			return exportedCluster{}, fmt.Errorf("failed to get ManagedServiceAccount %s/%s: %v", name, o.ServiceAccount, err)
+		}
+		if secretName, expires := kubestellar.ManagedServiceAccountToken(msa); secretName != "" {
+			secret, err := itsClient.CoreV1().Secrets(name).Get(commandContext(), secretName, metav1.GetOptions{})
+			if err != nil {
+				return exportedCluster{}, fmt.Errorf("failed to read the token Secret %s/%s: %v", name, secretName, err)
+			}
+			token := string(secret.Data["token"])
+			if token == "" {
+				return exportedCluster{}, fmt.Errorf("the token Secret %s/%s holds no token", name, secretName)
+			}
+			if len(caData) == 0 {
+				caData = secret.Data["ca.crt"]
+			}
+			return exportedCluster{Server: server, CAData: caData, Token: token, Expires: expires}, nil
+		}
+		if time.Now().After(deadline) {
+			return exportedCluster{}, fmt.Errorf("no token reported for ManagedServiceAccount %s/%s within %s; check that the managed-serviceaccount addon is enabled for the cluster", name, o.ServiceAccount, o.Wait)
+		}
+		select {
+		case <-commandContext().Done():
+			return exportedCluster{}, commandContext().Err()
+		case <-time.After(exportPollDelay):
+		}
+	}
+}
+
+// isManagedServiceAccountMissing tells a 404 for the resource itself, when
+// the addon's CRD is not installed, from a missing object
+func isManagedServiceAccountMissing(err error) bool {
+	status, ok := err.(apierrors.APIStatus)
+	if !ok || status.Status().Details == nil {
+		return true
+	}
+	return status.Status().Details.Name == ""
+}
+
+// writeExportedContexts prints a kubeconfig of the exported clusters, or
+// merges them into the --merge-into file
+func writeExportedContexts(exported []exportedCluster, o clustersExportOptions, progress *os.File) error {
+	if o.MergeInto == "" {
+		config := clientcmdapi.NewConfig()
+		for _, change := range mergeExportedContexts(config, exported, o.ServiceAccount, false) {
+			fmt.Fprintln(progress, change)
+		}
+		data, err := clientcmd.Write(*config)
+		if err != nil {
+			return fmt.Errorf("failed to encode the kubeconfig: %v", err)
+		}
+		_, err = util.GetOutputStream().Write(data)
+		return err
+	}
+
+	path, err := expandHome(o.MergeInto)
+	if err != nil {
+		return err
+	}
+	config, err := clientcmd.LoadFromFile(path)
+	if os.IsNotExist(err) {
+		config, err = clientcmdapi.NewConfig(), nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig %s: %v", path, err)
+	}
+	for _, change := range mergeExportedContexts(config, exported, o.ServiceAccount, o.Overwrite) {
+		fmt.Fprintln(progress, change)
+	}
+	if err := clientcmd.WriteToFile(*config, path); err != nil {
+		return fmt.Errorf("failed to write kubeconfig %s: %v", path, err)
+	}
+	return nil
+}
+
+// mergeExportedContexts adds or updates a context, cluster and user entry in
+// config for each exported cluster and describes each change. The cluster
+// and user entries are named CONTEXT-SERVICEACCOUNT; a context of the same
+// name pointing at another user was not written by export-contexts and is
+// only replaced with overwrite.
+func mergeExportedContexts(config *clientcmdapi.Config, exported []exportedCluster, serviceAccount string, overwrite bool) []string {
+	var changes []string
+	for _, e := range exported {
+		entry := e.Context + "-" + serviceAccount
+		existing, found := config.Contexts[e.Context]
+		if found && existing.AuthInfo != entry && !overwrite {
+			changes = append(changes, fmt.Sprintf("%s: skipped, context %s uses user %s; use --overwrite to replace it", e.Context, e.Context, existing.AuthInfo))
+			continue
+		}
+
+		clusterEntry := &clientcmdapi.Cluster{Server: e.Server, CertificateAuthorityData: e.CAData}
+		authInfo := &clientcmdapi.AuthInfo{Token: e.Token}
+		context := &clientcmdapi.Context{Cluster: entry, AuthInfo: entry}
+		if found {
+			// Keep the namespace the user chose for the context
+			context.Namespace = existing.Namespace
+		}
+		unchanged := found && existing.Cluster == entry && existing.AuthInfo == entry &&
+			sameExportedCluster(config.Clusters[entry], clusterEntry) &&
+			config.AuthInfos[entry] != nil && config.AuthInfos[entry].Token == e.Token
+		config.Clusters[entry] = clusterEntry
+		config.AuthInfos[entry] = authInfo
+		config.Contexts[e.Context] = context
+
+		action := "created"
+		switch {
+		case unchanged:
+			action = "unchanged"
+		case found:
+			action = "updated"
+		}
+		change := fmt.Sprintf("%s: context %s %s", e.Context, e.Context, action)
+		if !e.Expires.IsZero() {
+			change += fmt.Sprintf(", token expires at %s", e.Expires.Local().Format(time.RFC3339))
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+func sameExportedCluster(a, b *clientcmdapi.Cluster) bool {
+	return a != nil && a.Server == b.Server && reflect.DeepEqual(a.CertificateAuthorityData, b.CertificateAuthorityData)
+}
+
+// expandHome resolves a leading ~/ in path, which the shell leaves alone in
+// --flag=~/path
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %v", path, err)
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"kubectl-multi/pkg/kubestellar"
+)
+
+func TestExportClusterCredentials(t *testing.T) {
+	mc := testManagedCluster("cluster1", nil)
+	unstructured.SetNestedSlice(mc.Object, []interface{}{
+		map[string]interface{}{"url": "https://cluster1:6443", "caBundle": base64.StdEncoding.EncodeToString([]byte("cluster1-ca"))},
+	}, "spec", "managedClusterClientConfigs")
+	its := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{kubestellar.ManagedServiceAccountGVR: "ManagedServiceAccountList"})
+	secrets := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "cluster1", Name: "kubectl-multi"},
+		Data:       map[string][]byte{"token": []byte("minted-token"), "ca.crt": []byte("secret-ca")},
+	})
+	o := clustersExportOptions{ServiceAccount: "kubectl-multi", Validity: 24 * time.Hour}
+
+	// The ManagedServiceAccount is created, but no token is reported yet
+	_, err := exportClusterCredentials(its, secrets, &mc, o)
+	if err == nil || !strings.Contains(err.Error(), "no token reported") {
+		t.Fatalf("exportClusterCredentials() before a token error = %v", err)
+	}
+	client := its.Resource(kubestellar.ManagedServiceAccountGVR).Namespace("cluster1")
+	msa, err := client.Get(commandContext(), "kubectl-multi", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("ManagedServiceAccount not created: %v", err)
+	}
+	if validity, _, _ := unstructured.NestedString(msa.Object, "spec", "rotation", "validity"); validity != "24h0m0s" {
+		t.Errorf("rotation validity = %q, want 24h0m0s", validity)
+	}
+
+	unstructured.SetNestedField(msa.Object, map[string]interface{}{
+		"tokenSecretRef":      map[string]interface{}{"name": "kubectl-multi"},
+		"expirationTimestamp": "2026-10-18T10:00:00Z",
+	}, "status")
+	if _, err := client.Update(commandContext(), msa, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := exportClusterCredentials(its, secrets, &mc, o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := exportedCluster{Server: "https://cluster1:6443", CAData: []byte("cluster1-ca"), Token: "minted-token", Expires: time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("exportClusterCredentials() = %+v, want %+v", got, want)
+	}
+
+	unstructured.RemoveNestedField(mc.Object, "spec", "managedClusterClientConfigs")
+	if _, err := exportClusterCredentials(its, secrets, &mc, o); err == nil || !strings.Contains(err.Error(), "no API server URL") {
+		t.Errorf("exportClusterCredentials() without an endpoint error = %v", err)
+	}
+}
+
+func TestMergeExportedContexts(t *testing.T) {
+	config := clientcmdapi.NewConfig()
+	config.CurrentContext = "kind-admin"
+	config.Contexts["kind-admin"] = &clientcmdapi.Context{Cluster: "kind", AuthInfo: "admin"}
+	config.Contexts["cluster2"] = &clientcmdapi.Context{Cluster: "kind-cluster2", AuthInfo: "kind-cluster2"}
+	config.Contexts["cluster3"] = &clientcmdapi.Context{Cluster: "cluster3-kubectl-multi", AuthInfo: "cluster3-kubectl-multi", Namespace: "apps"}
+	config.Clusters["cluster3-kubectl-multi"] = &clientcmdapi.Cluster{Server: "https://cluster3:6443"}
+	config.AuthInfos["cluster3-kubectl-multi"] = &clientcmdapi.AuthInfo{Token: "old-token"}
+
+	exported := []exportedCluster{
+		{Context: "cluster1", Server: "https://cluster1:6443", Token: "t1"},
+		{Context: "cluster2", Server: "https://cluster2:6443", Token: "t2"},
+		{Context: "cluster3", Server: "https://cluster3:6443", Token: "t3"},
+	}
+	changes := mergeExportedContexts(config, exported, "kubectl-multi", false)
+	want := []string{
+		"cluster1: context cluster1 created",
+		"cluster2: skipped, context cluster2 uses user kind-cluster2; use --overwrite to replace it",
+		"cluster3: context cluster3 updated",
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("mergeExportedContexts() = %q, want %q", changes, want)
+	}
+	if config.CurrentContext != "kind-admin" || config.Contexts["cluster2"].AuthInfo != "kind-cluster2" {
+		t.Errorf("merge changed the current context or a foreign context: %+v", config)
+	}
+	if ctx := config.Contexts["cluster3"]; ctx.Namespace != "apps" || config.AuthInfos[ctx.AuthInfo].Token != "t3" {
+		t.Errorf("cluster3 context = %+v, want namespace apps and the new token", ctx)
+	}
+	if ctx := config.Contexts["cluster1"]; config.Clusters[ctx.Cluster].Server != "https://cluster1:6443" || config.AuthInfos[ctx.AuthInfo].Token != "t1" {
+		t.Errorf("cluster1 context = %+v", ctx)
+	}
+
+	changes = mergeExportedContexts(config, exported, "kubectl-multi", true)
+	want = []string{
+		"cluster1: context cluster1 unchanged",
+		"cluster2: context cluster2 updated",
+		"cluster3: context cluster3 unchanged",
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("mergeExportedContexts(overwrite) = %q, want %q", changes, want)
+	}
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+const (
+	defaultKubeconfigServiceAccount = "default/default"
+	defaultKubeconfigTokenTTL       = time.Hour
+	// minKubeconfigTokenTTL is the shortest token the API server issues
+	minKubeconfigTokenTTL = 10 * time.Minute
+)
+
+// clustersKubeconfigOptions holds the flags of clusters kubeconfig
+type clustersKubeconfigOptions struct {
+	ServiceAccount string
+	Duration       time.Duration
+}
+
+func newClustersKubeconfigCommand() *cobra.Command {
+	var o clustersKubeconfigOptions
+
+	cmd := &cobra.Command{
+		Use:   "kubeconfig NAME",
+		Short: "Print a kubeconfig with a short-lived ServiceAccount token for one cluster",
+		Long: `Issue a token for a ServiceAccount of the managed cluster NAME, discovered
+through the ITS, with the TokenRequest API and print a kubeconfig using it.
+
+The kubeconfig reaches the cluster at the server and with the CA the local
+kubeconfig has for it, and allows what the RBAC bindings of the
+ServiceAccount allow, so it gives scoped access to that single cluster
+without sharing the local credentials. The token cannot be revoked before it
+expires other than by deleting the ServiceAccount, so keep --duration short.`,
+		Example: `# An hour of access to cluster1 as the deployer ServiceAccount of prod
+kubectl multi clusters kubeconfig cluster1 --sa prod/deployer > cluster1.kubeconfig
+kubectl --kubeconfig cluster1.kubeconfig get pods
+
+# A token valid for 15 minutes
+kubectl multi clusters kubeconfig cluster1 --sa prod/deployer --duration 15m`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, name, err := parseServiceAccountRef(o.ServiceAccount)
+			if err != nil {
+				return err
+			}
+			if o.Duration < minKubeconfigTokenTTL {
+				return fmt.Errorf("--duration must be at least %s", minKubeconfigTokenTTL)
+			}
+			kubeconfig, remoteCtx, _, _, _ := GetGlobalFlags()
+			rec := startAudit("clusters kubeconfig")
+			if err = acquireFleetLock(rec); err == nil {
+				err = handleClustersKubeconfig(args[0], namespace, name, o.Duration, rec, kubeconfig, remoteCtx)
+			}
+			finishAudit(rec, err)
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&o.ServiceAccount, "sa", defaultKubeconfigServiceAccount, "NAMESPACE/NAME of the ServiceAccount the token is issued for")
+	cmd.Flags().DurationVar(&o.Duration, "duration", defaultKubeconfigTokenTTL, "lifetime of the token")
+
+	return cmd
+}
+
+// parseServiceAccountRef splits NAMESPACE/NAME
+func parseServiceAccountRef(ref string) (string, string, error) {
+	namespace, name, found := strings.Cut(ref, "/")
+	if !found || len(validation.IsDNS1123Label(namespace)) > 0 || len(validation.IsDNS1123Subdomain(name)) > 0 {
+		return "", "", fmt.Errorf("invalid --sa %q, must be NAMESPACE/NAME", ref)
+	}
+	return namespace, name, nil
+}
+
+func handleClustersKubeconfig(name, namespace, serviceAccount string, ttl time.Duration, rec *audit.Recorder, kubeconfig, remoteCtx string) error {
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	selected, err := cluster.SelectClusters(clusters, []string{name})
+	if err != nil {
+		return err
+	}
+
+	data, expires, err := mintKubeconfig(selected[0], namespace, serviceAccount, ttl)
+	rec.Record(selected[0].Name, err)
+	if err != nil {
+		return err
+	}
+	if _, err := util.GetOutputStream().Write(data); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Token for ServiceAccount %s/%s in cluster %s expires at %s\n", namespace, serviceAccount, name, expires.Local().Format(time.RFC3339))
+	return nil
+}
+
+// mintKubeconfig issues a token for the ServiceAccount in the cluster and
+// returns a kubeconfig using it, with the token's expiry. The API server
+// may issue a shorter token than requested.
+func mintKubeconfig(c cluster.ClusterInfo, namespace, serviceAccount string, ttl time.Duration) ([]byte, time.Time, error) {
+	if c.Client == nil || c.RestConfig == nil {
+		return nil, time.Time{}, fmt.Errorf("no client for cluster %s", c.Name)
+	}
+	caData, err := restConfigCA(c.RestConfig)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to read the CA of cluster %s: %v", c.Name, err)
+	}
+
+	seconds := int64(ttl.Seconds())
+	req := &authenticationv1.TokenRequest{Spec: authenticationv1.TokenRequestSpec{ExpirationSeconds: &seconds}}
+	resp, err := c.Client.CoreV1().ServiceAccounts(namespace).CreateToken(commandContext(), serviceAccount, req, metav1.CreateOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, time.Time{}, fmt.Errorf("ServiceAccount %s/%s not found in cluster %s; create it or choose another with --sa", namespace, serviceAccount, c.Name)
+	}
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to issue a token in cluster %s: %v", c.Name, err)
+	}
+	expires := resp.Status.ExpirationTimestamp.Time
+	if expires.IsZero() {
+		expires = time.Now().Add(ttl)
+	}
+
+	user := c.Name + "-" + serviceAccount
+	config := clientcmdapi.NewConfig()
+	config.Clusters[c.Name] = &clientcmdapi.Cluster{
+		Server:                   c.RestConfig.Host,
+		CertificateAuthorityData: caData,
+		InsecureSkipTLSVerify:    c.RestConfig.Insecure,
+		TLSServerName:            c.RestConfig.ServerName,
+	}
+	config.AuthInfos[user] = &clientcmdapi.AuthInfo{Token: resp.Status.Token}
+	config.Contexts[user] = &clientcmdapi.Context{Cluster: c.Name, AuthInfo: user, Namespace: namespace}
+	config.CurrentContext = user
+	data, err := clientcmd.Write(*config)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to encode the kubeconfig: %v", err)
+	}
+	return data, expires, nil
+}
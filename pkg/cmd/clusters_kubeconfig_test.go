@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"kubectl-multi/pkg/cluster"
+)
+
+func TestParseServiceAccountRef(t *testing.T) {
+	if ns, name, err := parseServiceAccountRef("prod/deployer"); err != nil || ns != "prod" || name != "deployer" {
+		t.Errorf("parseServiceAccountRef() = %q, %q, %v", ns, name, err)
+	}
+	for _, ref := range []string{"deployer", "prod/", "/deployer", "Prod/deployer"} {
+		if _, _, err := parseServiceAccountRef(ref); err == nil {
+			t.Errorf("parseServiceAccountRef(%q) did not fail", ref)
+		}
+	}
+}
+
+func TestMintKubeconfig(t *testing.T) {
+	expires := time.Date(2026, 10, 17, 11, 0, 0, 0, time.UTC)
+	client := fake.NewSimpleClientset(&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "deployer"}})
+	client.PrependReactor("create", "serviceaccounts", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "token" {
+			return false, nil, nil
+		}
+		if action.GetNamespace() != "prod" {
+			return false, nil, nil
+		}
+		req := action.(clienttesting.CreateAction).GetObject().(*authenticationv1.TokenRequest)
+		if *req.Spec.ExpirationSeconds != 900 {
+			t.Errorf("token requested for %ds, want 900", *req.Spec.ExpirationSeconds)
+		}
+		return true, &authenticationv1.TokenRequest{Status: authenticationv1.TokenRequestStatus{
+			Token:               "secret-token",
+			ExpirationTimestamp: metav1.NewTime(expires),
+		}}, nil
+	})
+	c := cluster.ClusterInfo{
+		Name:       "cluster1",
+		Client:     client,
+		RestConfig: &rest.Config{Host: "https://cluster1.example.com:6443", TLSClientConfig: rest.TLSClientConfig{CAData: []byte("ca")}},
+	}
+
+	data, gotExpires, err := mintKubeconfig(c, "prod", "deployer", 15*time.Minute)
+	if err != nil {
+		t.Fatalf("mintKubeconfig() error = %v", err)
+	}
+	if !gotExpires.Equal(expires) {
+		t.Errorf("mintKubeconfig() expires %s, want %s", gotExpires, expires)
+	}
+	config, err := clientcmd.Load(data)
+	if err != nil {
+		t.Fatalf("kubeconfig does not load: %v", err)
+	}
+	ctx := config.Contexts[config.CurrentContext]
+	if ctx == nil || ctx.Namespace != "prod" {
+		t.Fatalf("current context = %+v, want one in namespace prod", ctx)
+	}
+	if server := config.Clusters[ctx.Cluster]; server.Server != "https://cluster1.example.com:6443" || string(server.CertificateAuthorityData) != "ca" {
+		t.Errorf("cluster = %+v", server)
+	}
+	if token := config.AuthInfos[ctx.AuthInfo].Token; token != "secret-token" {
+		t.Errorf("token = %q, want secret-token", token)
+	}
+
+	_, _, err = mintKubeconfig(c, "dev", "deployer", 15*time.Minute)
+	if err == nil || !strings.Contains(err.Error(), "ServiceAccount dev/deployer not found in cluster cluster1") {
+		t.Errorf("mintKubeconfig() of a missing ServiceAccount error = %v", err)
+	}
+}
@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+	"kubectl-multi/pkg/util"
+)
+
+// placementSatisfiedCondition is the condition the OCM scheduler sets on a
+// Placement once it found as many clusters as it asks for
+const placementSatisfiedCondition = "PlacementSatisfied"
+
+// placementSummary is one OCM Placement of an ITS and the clusters its
+// PlacementDecisions list
+type placementSummary struct {
+	ITS       string   `json:"its,omitempty"`
+	Namespace string   `json:"namespace"`
+	Name      string   `json:"name"`
+	Satisfied string   `json:"satisfied"`
+	Clusters  []string `json:"clusters"`
+}
+
+func newClustersPlacementsCommand() *cobra.Command {
+	var outputFormat string
+	var reach reachability
+
+	cmd := &cobra.Command{
+		Use:     "placements [[NAMESPACE/]NAME]",
+		Aliases: []string{"placement"},
+		Short:   "Show the OCM Placements of the ITS and the clusters they decided on",
+		Long: `Show the OCM Placement objects of the ITS, whether the scheduler
+satisfied them and the clusters their PlacementDecisions list.
+
+Those are the clusters that get, apply and the helm commands target with
+--placement [NAMESPACE/]NAME, so a team already selecting clusters through the
+OCM placement APIs does not need to repeat its predicates as labels.`,
+		Example: `# List every Placement with its decided clusters
+kubectl multi clusters placements
+
+# Show one Placement
+kubectl multi clusters placements team-a/frontend -o yaml
+
+# Deploy to the clusters that Placement decided on
+kubectl multi apply -f app.yaml --placement team-a/frontend`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch outputFormat {
+			case "", "json", "yaml":
+			default:
+				return fmt.Errorf("unsupported output format %q, must be one of json|yaml", outputFormat)
+			}
+			var ref string
+			if len(args) == 1 {
+				ref = args[0]
+			}
+			kubeconfig, remoteCtx, _, _, _ := GetGlobalFlags()
+			return handleClustersPlacements(ref, outputFormat, reach, kubeconfig, remoteCtx)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "output format (json|yaml)")
+	reach.addFlags(cmd)
+
+	return cmd
+}
+
+func handleClustersPlacements(ref, outputFormat string, reach reachability, kubeconfig, remoteCtx string) error {
+	itsContexts, err := selectedITSContexts(kubeconfig, remoteCtx)
+	if err != nil {
+		return err
+	}
+
+	summaries := []placementSummary{}
+	for _, its := range itsContexts {
+		if _, err := reach.controlPlane("ITS", kubeconfig, its, true); err != nil {
+			return err
+		}
+		c, err := cluster.ClientForContext(kubeconfig, its)
+		if err != nil {
+			return err
+		}
+		found, err := listPlacementSummaries(c.DynamicClient, ref)
+		if err != nil {
+			return fmt.Errorf("ITS %s: %v", its, err)
+		}
+		for i := range found {
+			if len(itsContexts) > 1 {
+				found[i].ITS = its
+			}
+		}
+		summaries = append(summaries, found...)
+	}
+	if ref != "" && len(summaries) == 0 {
+		return fmt.Errorf("placement %s not found in the ITS", ref)
+	}
+
+	if outputFormat != "" {
+		return util.PrintStructured(util.GetOutputStream(), outputFormat, summaries)
+	}
+
+	tw := util.NewTableWriter(util.GetOutputStream(), "")
+	defer tw.Flush()
+
+	if len(summaries) == 0 {
+		fmt.Fprintf(tw, "No placements found.\n")
+		return nil
+	}
+	showITS := len(itsContexts) > 1
+	header := "NAMESPACE\tNAME\tSATISFIED\tDECIDED\tCLUSTERS"
+	if showITS {
+		header = "ITS\t" + header
+	}
+	fmt.Fprintln(tw, header)
+	for _, s := range summaries {
+		if showITS {
+			fmt.Fprintf(tw, "%s\t", s.ITS)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", s.Namespace, s.Name, s.Satisfied, len(s.Clusters), orNone(strings.Join(s.Clusters, ",")))
+	}
+	return nil
+}
+
+// listPlacementSummaries reads the Placement ref, or every Placement when
+// ref is empty, from one ITS, sorted by namespace and name. An ITS without
+// the OCM placement APIs has none.
+func listPlacementSummaries(its dynamic.Interface, ref string) ([]placementSummary, error) {
+	var placements []unstructured.Unstructured
+	if ref != "" {
+		p, err := findPlacement(its, ref)
+		if err != nil {
+			return nil, err
+		}
+		if p != nil {
+			placements = append(placements, *p)
+		}
+	} else {
+		list, err := its.Resource(kubestellar.PlacementGVR).List(commandContext(), metav1.ListOptions{})
+		switch {
+		case apierrors.IsNotFound(err) || meta.IsNoMatchError(err):
+		case err != nil:
+			return nil, fmt.Errorf("failed to list placements: %v", err)
+		default:
+			placements = list.Items
+		}
+	}
+
+	var summaries []placementSummary
+	for i := range placements {
+		p := &placements[i]
+		clusters, err := placementClusters(its, p)
+		if err != nil {
+			return nil, err
+		}
+		if clusters == nil {
+			clusters = []string{}
+		}
+		summaries = append(summaries, placementSummary{
+			Namespace: p.GetNamespace(),
+			Name:      p.GetName(),
+			Satisfied: kubestellar.ConditionStatus(kubestellar.ObjectConditions(p), placementSatisfiedCondition),
+			Clusters:  clusters,
+		})
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Namespace != summaries[j].Namespace {
+			return summaries[i].Namespace < summaries[j].Namespace
+		}
+		return summaries[i].Name < summaries[j].Name
+	})
+	return summaries, nil
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+)
+
+func testPlacement(namespace, name, satisfied string) *unstructured.Unstructured {
+	p := newManifestObject(kubestellar.PlacementGVR.GroupVersion().String(), "Placement", namespace, name)
+	if satisfied != "" {
+		unstructured.SetNestedSlice(p.Object, []interface{}{
+			map[string]interface{}{"type": placementSatisfiedCondition, "status": satisfied},
+		}, "status", "conditions")
+	}
+	return p
+}
+
+func testPlacementDecision(namespace, placement string, index int, clusters ...string) *unstructured.Unstructured {
+	d := newManifestObject(kubestellar.PlacementDecisionGVR.GroupVersion().String(), "PlacementDecision", namespace, fmt.Sprintf("%s-decision-%d", placement, index))
+	d.SetLabels(map[string]string{kubestellar.PlacementLabel: placement})
+	var decisions []interface{}
+	for _, c := range clusters {
+		decisions = append(decisions, map[string]interface{}{"clusterName": c, "reason": ""})
+	}
+	unstructured.SetNestedSlice(d.Object, decisions, "status", "decisions")
+	return d
+}
+
+func testPlacementITS(objects ...runtime.Object) dynamic.Interface {
+	return fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		kubestellar.PlacementGVR:         "PlacementList",
+		kubestellar.PlacementDecisionGVR: "PlacementDecisionList",
+	}, objects...)
+}
+
+func TestListPlacementSummaries(t *testing.T) {
+	its := testPlacementITS(
+		testPlacement("team-b", "frontend", "True"),
+		testPlacement("team-a", "frontend", "False"),
+		testPlacement("team-a", "batch", ""),
+		testPlacementDecision("team-a", "frontend", 1, "cluster2", "cluster1"),
+		testPlacementDecision("team-a", "frontend", 2, "cluster3"),
+		testPlacementDecision("team-b", "frontend", 1, "cluster4"),
+	)
+
+	got, err := listPlacementSummaries(its, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []placementSummary{
+		{Namespace: "team-a", Name: "batch", Satisfied: "Unknown", Clusters: []string{}},
+		{Namespace: "team-a", Name: "frontend", Satisfied: "False", Clusters: []string{"cluster1", "cluster2", "cluster3"}},
+		{Namespace: "team-b", Name: "frontend", Satisfied: "True", Clusters: []string{"cluster4"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("listPlacementSummaries() = %+v, want %+v", got, want)
+	}
+
+	got, err = listPlacementSummaries(its, "team-b/frontend")
+	if err != nil || !reflect.DeepEqual(got, want[2:]) {
+		t.Errorf("listPlacementSummaries(team-b/frontend) = %+v, %v", got, err)
+	}
+	if _, err := listPlacementSummaries(its, "frontend"); err == nil || !strings.Contains(err.Error(), "name it as NAMESPACE/frontend") {
+		t.Errorf("listPlacementSummaries(frontend) error = %v, want an ambiguous name", err)
+	}
+	if got, err := listPlacementSummaries(its, "absent"); err != nil || got != nil {
+		t.Errorf("listPlacementSummaries(absent) = %+v, %v, want none", got, err)
+	}
+}
+
+func TestSelectClustersByPlacement(t *testing.T) {
+	itses := map[string]dynamic.Interface{
+		"its1": testPlacementITS(
+			testPlacement("team-a", "frontend", "True"),
+			testPlacementDecision("team-a", "frontend", 1, "cluster1", "cluster3"),
+		),
+		"its2": testPlacementITS(),
+	}
+	itsClient := func(its string) (dynamic.Interface, error) { return itses[its], nil }
+	clusters := []cluster.ClusterInfo{
+		{Name: "cluster1", ITS: "its1"},
+		{Name: "cluster2", ITS: "its1"},
+		{Name: "cluster3", ITS: "its1"},
+		{Name: "cluster1", ITS: "its2"},
+		{Name: "unregistered"},
+	}
+	names := func(clusters []cluster.ClusterInfo) []string {
+		var out []string
+		for _, c := range clusters {
+			out = append(out, c.ITS+"/"+c.Name)
+		}
+		return out
+	}
+
+	got, err := selectClustersByPlacement(clusters, "frontend", itsClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"its1/cluster1", "its1/cluster3"}; !reflect.DeepEqual(names(got), want) {
+		t.Errorf("selectClustersByPlacement() = %v, want %v", names(got), want)
+	}
+
+	if got, err := selectClustersByPlacement(clusters, "", itsClient); err != nil || len(got) != len(clusters) {
+		t.Errorf("selectClustersByPlacement() without a placement = %v, %v, want every cluster", names(got), err)
+	}
+	if _, err := selectClustersByPlacement(clusters, "team-b/frontend", itsClient); err == nil {
+		t.Error("selectClustersByPlacement() of a missing placement returned no error")
+	}
+	if _, err := selectClustersByPlacement(clusters[1:2], "frontend", itsClient); err == nil {
+		t.Error("selectClustersByPlacement() with no decided cluster targeted returned no error")
+	}
+}
@@ -0,0 +1,346 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/config"
+	"kubectl-multi/pkg/kubestellar"
+	"kubectl-multi/pkg/util"
+)
+
+// taintChange is the set of taint edits to make on ManagedClusters
+type taintChange struct {
+	Add []kubestellar.ClusterTaint
+	// Remove lists taints to remove by key, and by effect when it is set
+	Remove []kubestellar.ClusterTaint
+}
+
+// clusterTaints is the taints of one ManagedCluster, as printed by
+// clusters taint without changes
+type clusterTaints struct {
+	Cluster string                     `json:"cluster"`
+	Taints  []kubestellar.ClusterTaint `json:"taints"`
+}
+
+func newClustersTaintCommand() *cobra.Command {
+	var all bool
+	var selector string
+	var dryRun bool
+	var overwrite bool
+	var outputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "taint [CLUSTER|@GROUP...] [KEY[=VALUE]:EFFECT...] [KEY[:EFFECT]-...]",
+		Short: "Show, add or remove taints on ManagedClusters",
+		Long: `Show, add or remove the taints of ManagedClusters in the ITS.
+
+A taint keeps placements from selecting a cluster unless they tolerate it.
+Its effect is one of:
+
+  NoSelect        the cluster is not selected
+  PreferNoSelect  the cluster is selected only when no other one fits
+  NoSelectIfNew   the cluster is not newly selected, but stays selected
+                  where it already is
+
+Taints are given as KEY[=VALUE]:EFFECT to add one and KEY[:EFFECT]- to remove
+one; without an effect every taint with the key is removed. Without taints
+the command prints the taints of the clusters, all of them unless some are
+named or selected. The hub stamps the time each taint was added.`,
+		Example: `# Show the taints of every cluster
+kubectl multi clusters taint
+
+# Keep new placements off cluster2 during maintenance
+kubectl multi clusters taint cluster2 maintenance=true:NoSelectIfNew
+
+# Remove the maintenance taint wherever it is
+kubectl multi clusters taint --all maintenance-
+
+# Change the value of a taint on the clusters labeled tier=gpu
+kubectl multi clusters taint -l tier=gpu dedicated=ml:NoSelect --overwrite`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var names, changeArgs []string
+			for _, arg := range args {
+				if strings.Contains(arg, ":") || (strings.HasSuffix(arg, "-") && !strings.HasPrefix(arg, config.GroupPrefix)) {
+					changeArgs = append(changeArgs, arg)
+				} else {
+					names = append(names, arg)
+				}
+			}
+			chosen := 0
+			for _, set := range []bool{len(names) > 0, all, selector != ""} {
+				if set {
+					chosen++
+				}
+			}
+			if chosen > 1 {
+				return fmt.Errorf("specify only one of cluster names, --all or -l")
+			}
+
+			kubeconfig, remoteCtx, _, _, _ := GetGlobalFlags()
+			mcs, err := cluster.ListManagedClusterObjects(commandContext(), kubeconfig, remoteCtx)
+			if err != nil {
+				return err
+			}
+
+			if len(changeArgs) == 0 {
+				switch outputFormat {
+				case "", "json", "yaml":
+				default:
+					return fmt.Errorf("unsupported output format %q, must be one of json|yaml", outputFormat)
+				}
+				targets, err := selectManagedClusters(mcs, names, all || chosen == 0, selector, remoteCtx)
+				if err != nil {
+					return err
+				}
+				return printClusterTaints(mcs, targets, outputFormat)
+			}
+
+			if chosen == 0 {
+				return fmt.Errorf("specify the clusters to taint by name, --all or -l")
+			}
+			change, err := parseTaintChanges(changeArgs)
+			if err != nil {
+				return err
+			}
+			targets, err := selectManagedClusters(mcs, names, all, selector, remoteCtx)
+			if err != nil {
+				return err
+			}
+
+			var rec *audit.Recorder
+			if !dryRun {
+				rec = startAudit("clusters taint")
+			}
+			err = guardManagedClusters(rec, blastRadius{}, targets, remoteCtx)
+			var its *cluster.ClusterInfo
+			if err == nil {
+				its, err = cluster.ClientForContext(kubeconfig, remoteCtx)
+			}
+			if err == nil {
+				err = handleClustersTaint(its.DynamicClient, targets, change, dryRun, overwrite, rec)
+			}
+			finishAudit(rec, err)
+			return err
+		},
+	}
+
+	cmd.Flags().BoolVar(&all, "all", false, "taint every ManagedCluster in the ITS")
+	cmd.Flags().StringVarP(&selector, "selector", "l", "", "label selector choosing the ManagedClusters to taint")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only print the taint changes that would be made")
+	cmd.Flags().BoolVar(&overwrite, "overwrite", false, "allow the value of an existing taint to be replaced")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "output format when showing taints (json|yaml)")
+
+	return cmd
+}
+
+// parseTaintChanges splits KEY[=VALUE]:EFFECT and KEY[:EFFECT]- arguments
+// into a taintChange
+func parseTaintChanges(args []string) (taintChange, error) {
+	var change taintChange
+	for _, arg := range args {
+		if strings.HasSuffix(arg, "-") {
+			key, effect, _ := strings.Cut(strings.TrimSuffix(arg, "-"), ":")
+			if key == "" {
+				return change, fmt.Errorf("invalid taint removal %q, expected KEY[:EFFECT]-", arg)
+			}
+			if effect != "" && !validTaintEffect(effect) {
+				return change, fmt.Errorf("invalid taint effect %q in %q, must be one of %s", effect, arg, strings.Join(kubestellar.TaintEffects, "|"))
+			}
+			change.Remove = append(change.Remove, kubestellar.ClusterTaint{Key: key, Effect: effect})
+			continue
+		}
+
+		i := strings.LastIndex(arg, ":")
+		if i < 0 {
+			return change, fmt.Errorf("invalid taint %q, expected KEY[=VALUE]:EFFECT", arg)
+		}
+		key, value, _ := strings.Cut(arg[:i], "=")
+		effect := arg[i+1:]
+		if key == "" {
+			return change, fmt.Errorf("invalid taint %q, expected KEY[=VALUE]:EFFECT", arg)
+		}
+		if !validTaintEffect(effect) {
+			return change, fmt.Errorf("invalid taint effect %q in %q, must be one of %s", effect, arg, strings.Join(kubestellar.TaintEffects, "|"))
+		}
+		change.Add = append(change.Add, kubestellar.ClusterTaint{Key: key, Value: value, Effect: effect})
+	}
+	return change, nil
+}
+
+func validTaintEffect(effect string) bool {
+	for _, e := range kubestellar.TaintEffects {
+		if effect == e {
+			return true
+		}
+	}
+	return false
+}
+
+// handleClustersTaint applies the taint change to the named ManagedClusters
+// served by the ITS client its
+func handleClustersTaint(its dynamic.Interface, names []string, change taintChange, dryRun, overwrite bool, rec *audit.Recorder) error {
+	names = append([]string{}, names...)
+	sort.Strings(names)
+
+	var failed []string
+	for _, name := range names {
+		mc, err := its.Resource(cluster.ManagedClusterGVR).Get(commandContext(), name, metav1.GetOptions{})
+		if err != nil {
+			rec.Record(name, err)
+			fmt.Printf("%s: error: failed to get ManagedCluster: %v\n", name, err)
+			failed = append(failed, name)
+			continue
+		}
+
+		patch, diff, err := taintPatch(mc, change, overwrite)
+		if err != nil {
+			rec.Record(name, err)
+			fmt.Printf("%s: error: %v\n", name, err)
+			failed = append(failed, name)
+			continue
+		}
+		if len(diff) == 0 {
+			fmt.Printf("%s: unchanged\n", name)
+			continue
+		}
+
+		if dryRun {
+			fmt.Printf("%s: %s (dry run)\n", name, strings.Join(diff, " "))
+			continue
+		}
+
+		_, err = its.Resource(cluster.ManagedClusterGVR).Patch(commandContext(), name, types.MergePatchType, patch, metav1.PatchOptions{FieldManager: util.FieldManager})
+		rec.Record(name, err)
+		if err != nil {
+			fmt.Printf("%s: error: failed to patch taints: %v\n", name, err)
+			failed = append(failed, name)
+			continue
+		}
+		rec.AddUndo(audit.UndoStep{Cluster: name, Action: audit.UndoRestoreTaints, Name: name, Taints: taintList(kubestellar.ManagedClusterTaints(mc))})
+		fmt.Printf("%s: %s\n", name, strings.Join(diff, " "))
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to taint %d cluster(s): %s", len(failed), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// taintPatch builds a merge patch replacing the taints of mc with the result
+// of the change, and a human readable diff (+taint added, ~old->new updated,
+// -taint removed). The patch carries the resourceVersion of mc so that a
+// concurrent edit of the list fails instead of being lost.
+func taintPatch(mc *unstructured.Unstructured, change taintChange, overwrite bool) ([]byte, []string, error) {
+	taints := kubestellar.ManagedClusterTaints(mc)
+	var diff []string
+
+	for _, r := range change.Remove {
+		var kept []kubestellar.ClusterTaint
+		for _, t := range taints {
+			if t.Key == r.Key && (r.Effect == "" || t.Effect == r.Effect) {
+				diff = append(diff, "-"+t.String())
+				continue
+			}
+			kept = append(kept, t)
+		}
+		taints = kept
+	}
+
+	for _, a := range change.Add {
+		found := false
+		for i, t := range taints {
+			if t.Key != a.Key || t.Effect != a.Effect {
+				continue
+			}
+			found = true
+			if t.Value == a.Value {
+				break
+			}
+			if !overwrite {
+				return nil, nil, fmt.Errorf("taint %q already exists as %q, use --overwrite to change it", a.String(), t.String())
+			}
+			// The hub stamps the changed taint with a new time
+			diff = append(diff, fmt.Sprintf("~%s->%s", t.String(), a.String()))
+			taints[i] = a
+			break
+		}
+		if !found {
+			diff = append(diff, "+"+a.String())
+			taints = append(taints, a)
+		}
+	}
+
+	if len(diff) == 0 {
+		return nil, nil, nil
+	}
+	metadata := map[string]interface{}{}
+	if rv := mc.GetResourceVersion(); rv != "" {
+		metadata["resourceVersion"] = rv
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": metadata,
+		"spec":     map[string]interface{}{"taints": taintList(taints)},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return patch, diff, nil
+}
+
+// taintList converts taints to the list of the ManagedCluster spec
+func taintList(taints []kubestellar.ClusterTaint) []interface{} {
+	var list []interface{}
+	for _, t := range taints {
+		m := map[string]interface{}{"key": t.Key, "effect": t.Effect}
+		if t.Value != "" {
+			m["value"] = t.Value
+		}
+		if t.TimeAdded != "" {
+			m["timeAdded"] = t.TimeAdded
+		}
+		list = append(list, m)
+	}
+	return list
+}
+
+// printClusterTaints prints the taints of the named ManagedClusters of mcs
+func printClusterTaints(mcs []unstructured.Unstructured, names []string, outputFormat string) error {
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+	result := []clusterTaints{}
+	for i := range mcs {
+		if wanted[mcs[i].GetName()] {
+			result = append(result, clusterTaints{Cluster: mcs[i].GetName(), Taints: kubestellar.ManagedClusterTaints(&mcs[i])})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Cluster < result[j].Cluster })
+
+	if outputFormat != "" {
+		return util.PrintStructured(util.GetOutputStream(), outputFormat, result)
+	}
+
+	tw := util.NewTableWriter(util.GetOutputStream(), "")
+	defer tw.Flush()
+	fmt.Fprintln(tw, "CLUSTER\tTAINTS")
+	for _, c := range result {
+		taints := make([]string, 0, len(c.Taints))
+		for _, t := range c.Taints {
+			taints = append(taints, t.String())
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", c.Cluster, orNone(strings.Join(taints, ",")))
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+)
+
+func TestParseTaintChanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    taintChange
+		wantErr bool
+	}{
+		{
+			name: "add and remove",
+			args: []string{"dedicated=gpu:NoSelect", "maintenance:NoSelectIfNew", "old-", "spot:PreferNoSelect-"},
+			want: taintChange{
+				Add: []kubestellar.ClusterTaint{
+					{Key: "dedicated", Value: "gpu", Effect: kubestellar.TaintNoSelect},
+					{Key: "maintenance", Effect: kubestellar.TaintNoSelectIfNew},
+				},
+				Remove: []kubestellar.ClusterTaint{{Key: "old"}, {Key: "spot", Effect: kubestellar.TaintPreferNoSelect}},
+			},
+		},
+		{name: "value with a colon", args: []string{"zone=a:b:NoSelect"}, want: taintChange{
+			Add: []kubestellar.ClusterTaint{{Key: "zone", Value: "a:b", Effect: kubestellar.TaintNoSelect}},
+		}},
+		{name: "missing effect", args: []string{"dedicated=gpu"}, wantErr: true},
+		{name: "unknown effect", args: []string{"dedicated=gpu:NoSchedule"}, wantErr: true},
+		{name: "unknown effect in removal", args: []string{"dedicated:NoExecute-"}, wantErr: true},
+		{name: "missing key", args: []string{"=gpu:NoSelect"}, wantErr: true},
+		{name: "bare dash", args: []string{"-"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTaintChanges(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTaintChanges() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTaintChanges() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// testTaintedCluster returns a ManagedCluster with the taints
+func testTaintedCluster(name string, taints ...kubestellar.ClusterTaint) unstructured.Unstructured {
+	mc := testManagedCluster(name, nil)
+	if len(taints) > 0 {
+		unstructured.SetNestedSlice(mc.Object, taintList(taints), "spec", "taints")
+	}
+	return mc
+}
+
+func TestTaintPatch(t *testing.T) {
+	gpu := kubestellar.ClusterTaint{Key: "dedicated", Value: "gpu", Effect: kubestellar.TaintNoSelect, TimeAdded: "2024-05-01T10:00:00Z"}
+	spot := kubestellar.ClusterTaint{Key: "spot", Effect: kubestellar.TaintPreferNoSelect}
+	tests := []struct {
+		name      string
+		current   []kubestellar.ClusterTaint
+		change    taintChange
+		overwrite bool
+		wantPatch string
+		wantDiff  []string
+		wantErr   bool
+	}{
+		{
+			name:      "add",
+			current:   []kubestellar.ClusterTaint{gpu},
+			change:    taintChange{Add: []kubestellar.ClusterTaint{spot}},
+			wantPatch: `{"metadata":{},"spec":{"taints":[{"effect":"NoSelect","key":"dedicated","timeAdded":"2024-05-01T10:00:00Z","value":"gpu"},{"effect":"PreferNoSelect","key":"spot"}]}}`,
+			wantDiff:  []string{"+spot:PreferNoSelect"},
+		},
+		{
+			name:      "remove by key",
+			current:   []kubestellar.ClusterTaint{gpu, spot},
+			change:    taintChange{Remove: []kubestellar.ClusterTaint{{Key: "dedicated"}, {Key: "absent"}}},
+			wantPatch: `{"metadata":{},"spec":{"taints":[{"effect":"PreferNoSelect","key":"spot"}]}}`,
+			wantDiff:  []string{"-dedicated=gpu:NoSelect"},
+		},
+		{
+			name:      "remove the last taint",
+			current:   []kubestellar.ClusterTaint{spot},
+			change:    taintChange{Remove: []kubestellar.ClusterTaint{{Key: "spot", Effect: kubestellar.TaintPreferNoSelect}}},
+			wantPatch: `{"metadata":{},"spec":{"taints":null}}`,
+			wantDiff:  []string{"-spot:PreferNoSelect"},
+		},
+		{
+			name:    "remove with another effect",
+			current: []kubestellar.ClusterTaint{spot},
+			change:  taintChange{Remove: []kubestellar.ClusterTaint{{Key: "spot", Effect: kubestellar.TaintNoSelect}}},
+		},
+		{
+			name:    "already tainted",
+			current: []kubestellar.ClusterTaint{gpu},
+			change:  taintChange{Add: []kubestellar.ClusterTaint{{Key: "dedicated", Value: "gpu", Effect: kubestellar.TaintNoSelect}}},
+		},
+		{
+			name:    "update without overwrite",
+			current: []kubestellar.ClusterTaint{gpu},
+			change:  taintChange{Add: []kubestellar.ClusterTaint{{Key: "dedicated", Value: "ml", Effect: kubestellar.TaintNoSelect}}},
+			wantErr: true,
+		},
+		{
+			name:      "update with overwrite",
+			current:   []kubestellar.ClusterTaint{gpu},
+			change:    taintChange{Add: []kubestellar.ClusterTaint{{Key: "dedicated", Value: "ml", Effect: kubestellar.TaintNoSelect}}},
+			overwrite: true,
+			wantPatch: `{"metadata":{},"spec":{"taints":[{"effect":"NoSelect","key":"dedicated","value":"ml"}]}}`,
+			wantDiff:  []string{"~dedicated=gpu:NoSelect->dedicated=ml:NoSelect"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mc := testTaintedCluster("cluster1", tt.current...)
+			patch, diff, err := taintPatch(&mc, tt.change, tt.overwrite)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("taintPatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(patch) != tt.wantPatch {
+				t.Errorf("taintPatch() patch = %s, want %s", patch, tt.wantPatch)
+			}
+			if !reflect.DeepEqual(diff, tt.wantDiff) {
+				t.Errorf("taintPatch() diff = %v, want %v", diff, tt.wantDiff)
+			}
+		})
+	}
+
+	// The patch is conditional on the version the diff was computed from
+	mc := testTaintedCluster("cluster1")
+	mc.SetResourceVersion("42")
+	patch, _, _ := taintPatch(&mc, taintChange{Add: []kubestellar.ClusterTaint{spot}}, false)
+	if want := `{"metadata":{"resourceVersion":"42"},"spec":{"taints":[{"effect":"PreferNoSelect","key":"spot"}]}}`; string(patch) != want {
+		t.Errorf("taintPatch() patch = %s, want %s", patch, want)
+	}
+}
+
+func TestHandleClustersTaint(t *testing.T) {
+	spot := kubestellar.ClusterTaint{Key: "spot", Effect: kubestellar.TaintPreferNoSelect}
+	its := testITSDynamic(testTaintedCluster("cluster1", spot), testTaintedCluster("cluster2"))
+	rec := audit.Start("clusters taint", nil)
+
+	change := taintChange{Add: []kubestellar.ClusterTaint{{Key: "maintenance", Effect: kubestellar.TaintNoSelectIfNew}}}
+	if err := handleClustersTaint(its, []string{"cluster2", "cluster1", "cluster9"}, change, false, false, rec); err == nil || err.Error() != "failed to taint 1 cluster(s): cluster9" {
+		t.Fatalf("handleClustersTaint() error = %v", err)
+	}
+
+	for name, want := range map[string][]string{
+		"cluster1": {"spot:PreferNoSelect", "maintenance:NoSelectIfNew"},
+		"cluster2": {"maintenance:NoSelectIfNew"},
+	} {
+		mc, err := its.Resource(cluster.ManagedClusterGVR).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, taint := range kubestellar.ManagedClusterTaints(mc) {
+			got = append(got, taint.String())
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s taints = %v, want %v", name, got, want)
+		}
+	}
+
+	// Undo restores the taints each cluster had before
+	undo := rec.Finish(nil).Undo
+	if len(undo) != 2 || undo[0].Action != audit.UndoRestoreTaints || undo[0].Name != "cluster1" || len(undo[0].Taints) != 1 || undo[1].Taints != nil {
+		t.Errorf("undo steps = %+v", undo)
+	}
+}
@@ -0,0 +1,317 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/config"
+)
+
+func TestParseLabelChanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    labelChange
+		wantErr bool
+	}{
+		{name: "none", args: nil, want: labelChange{Set: map[string]string{}}},
+		{
+			name: "set and remove",
+			args: []string{"env=prod", "tier-", "region=us-east"},
+			want: labelChange{Set: map[string]string{"env": "prod", "region": "us-east"}, Remove: []string{"tier"}},
+		},
+		{name: "value ending in dash", args: []string{"suffix=a-"}, want: labelChange{Set: map[string]string{"suffix": "a-"}}},
+		{name: "bare dash", args: []string{"-"}, wantErr: true},
+		{name: "missing value", args: []string{"env"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLabelChanges(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseLabelChanges() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseLabelChanges() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadClusterLabelFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		data    string
+		want    map[string]labelChange
+		wantErr bool
+	}{
+		{
+			name: "csv",
+			file: "labels.csv",
+			data: "# cluster,labels...\ncluster1, env=prod ,tier-\ncluster2,env=dev,\n,ignored=1\n",
+			want: map[string]labelChange{
+				"cluster1": {Set: map[string]string{"env": "prod"}, Remove: []string{"tier"}},
+				"cluster2": {Set: map[string]string{"env": "dev"}},
+			},
+		},
+		{
+			name:    "csv with bad label",
+			file:    "labels.CSV",
+			data:    "cluster1,env\n",
+			wantErr: true,
+		},
+		{
+			name: "yaml",
+			file: "labels.yaml",
+			data: "cluster1:\n  env: prod\n  tier: null\n  old: ~\ncluster2:\n  env: \"\"\n",
+			want: map[string]labelChange{
+				"cluster1": {Set: map[string]string{"env": "prod"}, Remove: []string{"old", "tier"}},
+				"cluster2": {Set: map[string]string{"env": ""}},
+			},
+		},
+		{
+			name:    "invalid yaml",
+			file:    "labels.yaml",
+			data:    "cluster1: [env\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := readClusterLabelFile(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readClusterLabelFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readClusterLabelFile() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLabelPatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		current   map[string]string
+		change    labelChange
+		overwrite bool
+		wantPatch string
+		wantDiff  []string
+		wantErr   bool
+	}{
+		{
+			name:      "add and remove",
+			current:   map[string]string{"tier": "gold"},
+			change:    labelChange{Set: map[string]string{"env": "prod"}, Remove: []string{"tier", "absent"}},
+			wantPatch: `{"metadata":{"labels":{"env":"prod","tier":null}}}`,
+			wantDiff:  []string{"+env=prod", "-tier"},
+		},
+		{
+			name:    "no change",
+			current: map[string]string{"env": "prod"},
+			change:  labelChange{Set: map[string]string{"env": "prod"}, Remove: []string{"absent"}},
+		},
+		{
+			name:    "update without overwrite",
+			current: map[string]string{"env": "dev"},
+			change:  labelChange{Set: map[string]string{"env": "prod"}},
+			wantErr: true,
+		},
+		{
+			name:      "update with overwrite",
+			current:   map[string]string{"env": "dev"},
+			change:    labelChange{Set: map[string]string{"env": "prod"}},
+			overwrite: true,
+			wantPatch: `{"metadata":{"labels":{"env":"prod"}}}`,
+			wantDiff:  []string{"~env=dev->prod"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mc := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			mc.SetLabels(tt.current)
+			patch, diff, err := labelPatch(mc, tt.change, tt.overwrite)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("labelPatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(patch) != tt.wantPatch {
+				t.Errorf("labelPatch() patch = %s, want %s", patch, tt.wantPatch)
+			}
+			if !reflect.DeepEqual(diff, tt.wantDiff) {
+				t.Errorf("labelPatch() diff = %v, want %v", diff, tt.wantDiff)
+			}
+		})
+	}
+}
+
+func TestPreviousLabels(t *testing.T) {
+	prod := "prod"
+	got := previousLabels(map[string]string{"env": "prod", "other": "x"}, labelChange{
+		Set:    map[string]string{"env": "dev", "new": "1"},
+		Remove: []string{"gone"},
+	})
+	want := map[string]*string{"env": &prod, "new": nil, "gone": nil}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("previousLabels() = %v, want %v", got, want)
+	}
+}
+
+// testITSDynamic returns a fake ITS client serving the ManagedClusters
+func testITSDynamic(mcs ...unstructured.Unstructured) *fakedynamic.FakeDynamicClient {
+	objects := make([]runtime.Object, 0, len(mcs))
+	for i := range mcs {
+		objects = append(objects, &mcs[i])
+	}
+	listKinds := map[schema.GroupVersionResource]string{cluster.ManagedClusterGVR: "ManagedClusterList"}
+	return fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objects...)
+}
+
+func TestSelectManagedClusters(t *testing.T) {
+	t.Setenv(config.EnvConfigPath, filepath.Join(t.TempDir(), "config.yaml"))
+	mcs := []unstructured.Unstructured{
+		testManagedCluster("cluster1", map[string]string{"env": "prod"}),
+		testManagedCluster("cluster2", map[string]string{"env": "dev"}),
+		testManagedCluster("cluster3", map[string]string{"env": "prod"}),
+	}
+	tests := []struct {
+		name     string
+		names    []string
+		all      bool
+		selector string
+		want     []string
+		wantErr  string
+	}{
+		{name: "by name", names: []string{"cluster2"}, want: []string{"cluster2"}},
+		{name: "unknown name", names: []string{"cluster9"}, wantErr: `ManagedCluster "cluster9" not found in ITS its1`},
+		{name: "all", all: true, want: []string{"cluster1", "cluster2", "cluster3"}},
+		{name: "selector", selector: "env=prod", want: []string{"cluster1", "cluster3"}},
+		{name: "selector matching nothing", selector: "env=test", wantErr: "no ManagedClusters match the selection"},
+		{name: "invalid selector", selector: "env in", wantErr: "invalid selector"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := selectManagedClusters(mcs, tt.names, tt.all, tt.selector, "its1")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("selectManagedClusters() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("selectManagedClusters() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectManagedClusters() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleClustersLabel(t *testing.T) {
+	tests := []struct {
+		name       string
+		changes    map[string]labelChange
+		dryRun     bool
+		overwrite  bool
+		failPatch  bool
+		wantLabels map[string]map[string]string
+		wantUndo   int
+		wantErr    string
+	}{
+		{
+			name: "labels are patched",
+			changes: map[string]labelChange{
+				"cluster1": {Set: map[string]string{"region": "eu"}, Remove: []string{"env"}},
+				"cluster2": {Set: map[string]string{"region": "eu"}},
+			},
+			wantLabels: map[string]map[string]string{"cluster1": {"region": "eu"}, "cluster2": {"env": "dev", "region": "eu"}},
+			wantUndo:   2,
+		},
+		{
+			name:       "dry run changes nothing",
+			changes:    map[string]labelChange{"cluster1": {Set: map[string]string{"region": "eu"}}},
+			dryRun:     true,
+			wantLabels: map[string]map[string]string{"cluster1": {"env": "prod"}, "cluster2": {"env": "dev"}},
+		},
+		{
+			name:       "updates need overwrite",
+			changes:    map[string]labelChange{"cluster1": {Set: map[string]string{"env": "dev"}}, "cluster2": {Set: map[string]string{"tier": "gold"}}},
+			wantLabels: map[string]map[string]string{"cluster1": {"env": "prod"}, "cluster2": {"env": "dev", "tier": "gold"}},
+			wantUndo:   1,
+			wantErr:    "failed to label 1 cluster(s): cluster1",
+		},
+		{
+			name:       "overwrite updates",
+			changes:    map[string]labelChange{"cluster1": {Set: map[string]string{"env": "dev"}}},
+			overwrite:  true,
+			wantLabels: map[string]map[string]string{"cluster1": {"env": "dev"}, "cluster2": {"env": "dev"}},
+			wantUndo:   1,
+		},
+		{
+			name:       "missing cluster",
+			changes:    map[string]labelChange{"cluster9": {Set: map[string]string{"env": "dev"}}},
+			wantLabels: map[string]map[string]string{"cluster1": {"env": "prod"}, "cluster2": {"env": "dev"}},
+			wantErr:    "failed to label 1 cluster(s): cluster9",
+		},
+		{
+			name:       "patch failure",
+			changes:    map[string]labelChange{"cluster2": {Set: map[string]string{"tier": "gold"}}},
+			failPatch:  true,
+			wantLabels: map[string]map[string]string{"cluster1": {"env": "prod"}, "cluster2": {"env": "dev"}},
+			wantErr:    "failed to label 1 cluster(s): cluster2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			its := testITSDynamic(
+				testManagedCluster("cluster1", map[string]string{"env": "prod"}),
+				testManagedCluster("cluster2", map[string]string{"env": "dev"}),
+			)
+			if tt.failPatch {
+				its.PrependReactor("patch", "managedclusters", func(clienttesting.Action) (bool, runtime.Object, error) {
+					return true, nil, fmt.Errorf("forbidden")
+				})
+			}
+			rec := audit.Start("clusters label", nil)
+
+			err := handleClustersLabel(its, tt.changes, tt.dryRun, tt.overwrite, rec)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("handleClustersLabel() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Fatalf("handleClustersLabel() error = %v, want %q", err, tt.wantErr)
+			}
+
+			for name, want := range tt.wantLabels {
+				mc, err := its.Resource(cluster.ManagedClusterGVR).Get(context.TODO(), name, metav1.GetOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(mc.GetLabels(), want) {
+					t.Errorf("%s labels = %v, want %v", name, mc.GetLabels(), want)
+				}
+			}
+			if got := len(rec.Finish(nil).Undo); got != tt.wantUndo {
+				t.Errorf("undo steps = %d, want %d", got, tt.wantUndo)
+			}
+		})
+	}
+}
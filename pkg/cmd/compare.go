@@ -0,0 +1,354 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+// defaultCompareKinds are the resource types compare reads without --kinds
+var defaultCompareKinds = []string{"deployments", "statefulsets", "daemonsets", "services", "configmaps"}
+
+// compareIgnored are objects every cluster creates for itself and that
+// always differ between clusters, by resource and namespace/name
+var compareIgnored = map[string]map[string]bool{
+	"configmaps": {"*/kube-root-ca.crt": true},
+	"services":   {"default/kubernetes": true},
+}
+
+// Results of comparing one object between the two clusters
+const (
+	compareOnlySource = "OnlyInSource"
+	compareOnlyTarget = "OnlyInTarget"
+	compareDiffers    = "Differs"
+	compareIdentical  = "Identical"
+)
+
+// fieldDiff is one field whose value differs; an empty value means the
+// field is absent in that cluster
+type fieldDiff struct {
+	Path   string `json:"path"`
+	Source string `json:"source,omitempty"`
+	Target string `json:"target,omitempty"`
+}
+
+// objectComparison is the result of comparing one object
+type objectComparison struct {
+	Resource  string      `json:"resource"`
+	Namespace string      `json:"namespace,omitempty"`
+	Name      string      `json:"name"`
+	Result    string      `json:"result"`
+	Diffs     []fieldDiff `json:"diffs,omitempty"`
+}
+
+func newCompareCommand() *cobra.Command {
+	var source, target string
+	var kinds []string
+	var outputFormat string
+	var showIdentical bool
+
+	cmd := &cobra.Command{
+		Use:   "compare --source CLUSTER --target CLUSTER",
+		Short: "Compare the objects of two managed clusters",
+		Long: `List the objects of the given kinds that exist in only one of two managed
+clusters, and the fields that differ for the objects both have.
+
+Before comparing, the fields the API server fills in per cluster are
+dropped: status, uid, resourceVersion, creation timestamp, generation,
+managed fields, owner references, finalizers, last-applied and revision
+annotations, Service cluster IPs and generated Job selectors. Objects every
+cluster creates for itself (the kube-root-ca.crt ConfigMap, the kubernetes
+Service) are skipped.`,
+		Example: `# Why does cluster2 behave differently?
+kubectl multi compare --source cluster1 --target cluster2 -n web
+
+# Only Deployments and ConfigMaps, in every namespace
+kubectl multi compare --source cluster1 --target cluster2 -A --kinds deploy,cm
+
+# Machine-readable result
+kubectl multi compare --source cluster1 --target cluster2 -o json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if source == "" || target == "" {
+				return fmt.Errorf("--source and --target are required")
+			}
+			if source == target {
+				return fmt.Errorf("--source and --target must be different clusters")
+			}
+			switch outputFormat {
+			case "", "json", "yaml":
+			default:
+				return fmt.Errorf("unsupported output format %q, must be one of json|yaml", outputFormat)
+			}
+			kubeconfig, remoteCtx, _, namespace, allNamespaces := GetGlobalFlags()
+			return handleCompareCommand(source, target, kinds, outputFormat, showIdentical, kubeconfig, remoteCtx, namespace, allNamespaces)
+		},
+	}
+
+	cmd.Flags().StringVar(&source, "source", "", "first cluster to compare")
+	cmd.Flags().StringVar(&target, "target", "", "second cluster to compare")
+	cmd.Flags().StringSliceVar(&kinds, "kinds", defaultCompareKinds, "comma-separated resource types to compare")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "output format (json|yaml)")
+	cmd.Flags().BoolVar(&showIdentical, "show-identical", false, "also list the objects that are the same in both clusters")
+
+	return cmd
+}
+
+func handleCompareCommand(source, target string, kinds []string, outputFormat string, showIdentical bool, kubeconfig, remoteCtx, namespace string, allNamespaces bool) error {
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	selected, err := cluster.SelectClusters(clusters, []string{source, target})
+	if err != nil {
+		return err
+	}
+	src, dst := selected[0], selected[1]
+	defer printClusterIssuesToStderr()
+
+	targetNS := cluster.GetTargetNamespace(namespace)
+	if allNamespaces {
+		targetNS = ""
+	}
+
+	var results []objectComparison
+	for _, kind := range kinds {
+		gvr, namespaced, err := util.DiscoverGVR(src.DiscoveryClient, kind)
+		if err != nil {
+			return fmt.Errorf("cluster %s: %v", src.Name, err)
+		}
+		ns := targetNS
+		if !namespaced {
+			ns = ""
+		}
+		srcObjs, err := listForCompare(src, gvr, ns, namespaced)
+		if err != nil {
+			return fmt.Errorf("failed to list %s in cluster %s: %v", gvr.Resource, src.Name, err)
+		}
+		dstObjs, err := listForCompare(dst, gvr, ns, namespaced)
+		if err != nil {
+			return fmt.Errorf("failed to list %s in cluster %s: %v", gvr.Resource, dst.Name, err)
+		}
+		results = append(results, compareObjects(gvr.Resource, srcObjs, dstObjs)...)
+	}
+
+	if !showIdentical {
+		var shown []objectComparison
+		for _, r := range results {
+			if r.Result != compareIdentical {
+				shown = append(shown, r)
+			}
+		}
+		identical := len(results) - len(shown)
+		results = shown
+		defer func() {
+			if identical > 0 {
+				fmt.Fprintf(os.Stderr, "%d object(s) identical in %s and %s\n", identical, src.Name, dst.Name)
+			}
+		}()
+	}
+
+	if outputFormat != "" {
+		if results == nil {
+			results = []objectComparison{}
+		}
+		return util.PrintStructured(util.GetOutputStream(), outputFormat, results)
+	}
+	printComparison(results, src.Name, dst.Name)
+	return nil
+}
+
+// listForCompare lists a resource of a cluster, treating a resource the
+// cluster does not serve as empty
+func listForCompare(c cluster.ClusterInfo, gvr schema.GroupVersionResource, namespace string, namespaced bool) ([]unstructured.Unstructured, error) {
+	list := func(ns string) (*unstructured.UnstructuredList, error) {
+		if !namespaced {
+			return c.DynamicClient.Resource(gvr).List(commandContext(), metav1.ListOptions{})
+		}
+		return c.DynamicClient.Resource(gvr).Namespace(ns).List(commandContext(), metav1.ListOptions{})
+	}
+	var objs *unstructured.UnstructuredList
+	var err error
+	if namespaced {
+		objs, err = listNamespaced(c, gvr.Group, gvr.Resource, namespace, list)
+	} else {
+		objs, err = list("")
+	}
+	if apierrors.IsNotFound(err) {
+		noteClusterIssue(c.Name, fmt.Sprintf("does not serve %s", gvr.Resource))
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return objs.Items, nil
+}
+
+func printComparison(results []objectComparison, source, target string) {
+	out := util.GetOutputStream()
+	if len(results) == 0 {
+		fmt.Fprintf(os.Stderr, "No differences between %s and %s.\n", source, target)
+		return
+	}
+
+	tw := util.NewTableWriter(out, "")
+	fmt.Fprintf(tw, "RESOURCE\tNAMESPACE\tNAME\tRESULT\n")
+	for _, r := range results {
+		result := r.Result
+		switch r.Result {
+		case compareOnlySource:
+			result = "only in " + source
+		case compareOnlyTarget:
+			result = "only in " + target
+		case compareDiffers:
+			result = fmt.Sprintf("%d field(s) differ", len(r.Diffs))
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Resource, noneIfEmpty(r.Namespace), r.Name, result)
+	}
+	tw.Flush()
+
+	for _, r := range results {
+		if r.Result != compareDiffers {
+			continue
+		}
+		name := r.Name
+		if r.Namespace != "" {
+			name = r.Namespace + "/" + name
+		}
+		fmt.Fprintf(out, "\n%s %s:\n", r.Resource, name)
+		for _, d := range r.Diffs {
+			fmt.Fprintf(out, "  %s\n    %s: %s\n    %s: %s\n", d.Path, source, absentIfEmpty(d.Source), target, absentIfEmpty(d.Target))
+		}
+	}
+}
+
+func absentIfEmpty(s string) string {
+	if s == "" {
+		return "<absent>"
+	}
+	return s
+}
+
+// compareObjects pairs the objects of one resource by namespace and name
+// and compares their normalized content, sorted by namespace and name
+func compareObjects(resource string, source, target []unstructured.Unstructured) []objectComparison {
+	key := func(obj *unstructured.Unstructured) string { return obj.GetNamespace() + "/" + obj.GetName() }
+	ignored := func(obj *unstructured.Unstructured) bool {
+		skip := compareIgnored[resource]
+		return skip[key(obj)] || skip["*/"+obj.GetName()]
+	}
+
+	targetByKey := map[string]*unstructured.Unstructured{}
+	for i := range target {
+		if !ignored(&target[i]) {
+			targetByKey[key(&target[i])] = &target[i]
+		}
+	}
+
+	var results []objectComparison
+	for i := range source {
+		src := &source[i]
+		if ignored(src) {
+			continue
+		}
+		r := objectComparison{Resource: resource, Namespace: src.GetNamespace(), Name: src.GetName()}
+		dst, ok := targetByKey[key(src)]
+		delete(targetByKey, key(src))
+		switch {
+		case !ok:
+			r.Result = compareOnlySource
+		default:
+			r.Diffs = diffObjects(exportObject(src), exportObject(dst))
+			r.Result = compareIdentical
+			if len(r.Diffs) > 0 {
+				r.Result = compareDiffers
+			}
+		}
+		results = append(results, r)
+	}
+	for _, dst := range targetByKey {
+		results = append(results, objectComparison{Resource: resource, Namespace: dst.GetNamespace(), Name: dst.GetName(), Result: compareOnlyTarget})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Namespace != results[j].Namespace {
+			return results[i].Namespace < results[j].Namespace
+		}
+		return results[i].Name < results[j].Name
+	})
+	return results
+}
+
+// diffObjects returns the fields whose values differ between two objects,
+// sorted by path
+func diffObjects(source, target *unstructured.Unstructured) []fieldDiff {
+	srcFields, dstFields := map[string]string{}, map[string]string{}
+	flattenFields("", source.Object, srcFields)
+	flattenFields("", target.Object, dstFields)
+	return diffFields(srcFields, dstFields)
+}
+
+// diffFields returns the paths whose values differ between two sets of
+// flattened fields, sorted by path
+func diffFields(srcFields, dstFields map[string]string) []fieldDiff {
+	var diffs []fieldDiff
+	for path, value := range srcFields {
+		if dstFields[path] != value {
+			diffs = append(diffs, fieldDiff{Path: path, Source: value, Target: dstFields[path]})
+		}
+	}
+	for path, value := range dstFields {
+		if _, ok := srcFields[path]; !ok {
+			diffs = append(diffs, fieldDiff{Path: path, Target: value})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Path < diffs[j].Path })
+	return diffs
+}
+
+// flattenFields records every leaf value of v under its path, such as
+// spec.template.spec.containers[0].image, JSON-encoded
+func flattenFields(path string, v interface{}, fields map[string]string) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		if len(value) == 0 {
+			fields[path] = "{}"
+			return
+		}
+		for k, child := range value {
+			childPath := k
+			if path != "" {
+				childPath = path + "." + k
+			}
+			if strings.ContainsAny(k, ".[]") {
+				childPath = fmt.Sprintf("%s[%q]", path, k)
+			}
+			flattenFields(childPath, child, fields)
+		}
+	case []interface{}:
+		if len(value) == 0 {
+			fields[path] = "[]"
+			return
+		}
+		for i, child := range value {
+			flattenFields(fmt.Sprintf("%s[%d]", path, i), child, fields)
+		}
+	default:
+		data, err := json.Marshal(value)
+		if err != nil {
+			data = []byte(fmt.Sprint(value))
+		}
+		fields[path] = string(data)
+	}
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func compareDeployment(name string, replicas int64, image, uid string) unstructured.Unstructured {
+	return unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"namespace":       "web",
+			"name":            name,
+			"uid":             uid,
+			"resourceVersion": uid,
+			"annotations":     map[string]interface{}{"deployment.kubernetes.io/revision": uid},
+		},
+		"spec": map[string]interface{}{
+			"replicas": replicas,
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{map[string]interface{}{"name": "app", "image": image}},
+				},
+			},
+		},
+		"status": map[string]interface{}{"readyReplicas": replicas},
+	}}
+}
+
+func TestCompareObjects(t *testing.T) {
+	source := []unstructured.Unstructured{
+		compareDeployment("api", 3, "api:v2", "1"),
+		compareDeployment("cache", 1, "redis:7", "2"),
+		compareDeployment("frontend", 2, "web:v1", "3"),
+	}
+	target := []unstructured.Unstructured{
+		compareDeployment("worker", 1, "worker:v1", "4"),
+		compareDeployment("api", 2, "api:v1", "5"),
+		compareDeployment("cache", 1, "redis:7", "6"),
+	}
+
+	want := []objectComparison{
+		{Resource: "deployments", Namespace: "web", Name: "api", Result: compareDiffers, Diffs: []fieldDiff{
+			{Path: "spec.replicas", Source: "3", Target: "2"},
+			{Path: "spec.template.spec.containers[0].image", Source: `"api:v2"`, Target: `"api:v1"`},
+		}},
+		{Resource: "deployments", Namespace: "web", Name: "cache", Result: compareIdentical},
+		{Resource: "deployments", Namespace: "web", Name: "frontend", Result: compareOnlySource},
+		{Resource: "deployments", Namespace: "web", Name: "worker", Result: compareOnlyTarget},
+	}
+	if got := compareObjects("deployments", source, target); !reflect.DeepEqual(got, want) {
+		t.Errorf("compareObjects() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCompareObjectsSkipsPerClusterObjects(t *testing.T) {
+	rootCA := func(ns, cert string) unstructured.Unstructured {
+		return unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"namespace": ns, "name": "kube-root-ca.crt"},
+			"data":       map[string]interface{}{"ca.crt": cert},
+		}}
+	}
+	got := compareObjects("configmaps",
+		[]unstructured.Unstructured{rootCA("web", "a"), rootCA("default", "a")},
+		[]unstructured.Unstructured{rootCA("web", "b")})
+	if len(got) != 0 {
+		t.Errorf("compareObjects() = %+v, want kube-root-ca.crt skipped", got)
+	}
+}
+
+func TestDiffObjectsAbsentFields(t *testing.T) {
+	source := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": map[string]interface{}{"app.kubernetes.io/name": "api", "tier": "web"}},
+	}}
+	target := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": map[string]interface{}{"app.kubernetes.io/name": "api"}},
+		"data":     map[string]interface{}{},
+	}}
+	want := []fieldDiff{
+		{Path: "data", Target: "{}"},
+		{Path: "metadata.labels.tier", Source: `"web"`},
+	}
+	if got := diffObjects(source, target); !reflect.DeepEqual(got, want) {
+		t.Errorf("diffObjects() = %+v, want %+v", got, want)
+	}
+}
+
+func TestFlattenFieldsQuotesDottedKeys(t *testing.T) {
+	fields := map[string]string{}
+	flattenFields("", map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": map[string]interface{}{"example.com/owner": "team-a"}},
+	}, fields)
+	want := map[string]string{`metadata.annotations["example.com/owner"]`: `"team-a"`}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("flattenFields() = %v, want %v", fields, want)
+	}
+}
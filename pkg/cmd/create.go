@@ -0,0 +1,283 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+// createOptions holds the flags of create
+type createOptions struct {
+	Filename  string
+	Recursive bool
+	DryRun    string
+	Output    string
+	Targets   clusterTargets
+}
+
+// createResult is what create did in one cluster: the objects the API server
+// returned, a kubectl-style line per object, and the failures
+type createResult struct {
+	created []*unstructured.Unstructured
+	lines   []string
+	errs    []error
+}
+
+func newCreateCommand() *cobra.Command {
+	var o createOptions
+
+	cmd := &cobra.Command{
+		Use:   "create -f FILENAME",
+		Short: "Create a resource from a file or from stdin across managed clusters",
+		Long: `Create the objects of a manifest in every managed cluster.
+
+With --dry-run=server every cluster validates the objects, including
+admission webhooks and quota, without persisting them, and create prints one
+summary of the clusters that accepted them and the errors of those that did
+not. -o yaml|json additionally prints the validated objects once.`,
+		Example: `# Create a deployment in every managed cluster
+kubectl multi create -f deployment.yaml
+
+# Check which clusters would accept the manifests
+kubectl multi create -f app/ -R --dry-run=server
+
+# Print the objects as the API servers would store them
+kubectl multi create -f deployment.yaml --dry-run=server -o yaml`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if o.Filename == "" {
+				return fmt.Errorf("must specify -f")
+			}
+			if err := util.ValidateDryRun(o.DryRun); err != nil {
+				return err
+			}
+			switch o.Output {
+			case "":
+			case "json", "yaml":
+				if o.DryRun != util.DryRunServer {
+					return fmt.Errorf("-o %s requires --dry-run=server", o.Output)
+				}
+			default:
+				return fmt.Errorf("unsupported output format %q, must be one of json|yaml", o.Output)
+			}
+			objs, err := util.ReadManifests(o.Filename, o.Recursive)
+			if err != nil {
+				return err
+			}
+			if len(objs) == 0 {
+				return fmt.Errorf("no objects found in %s", o.Filename)
+			}
+			kubeconfig, remoteCtx, _, namespace, _ := GetGlobalFlags()
+			var rec *audit.Recorder
+			if o.DryRun == util.DryRunNone || o.DryRun == "" {
+				rec = startAudit("create")
+			}
+			err = handleCreateCommand(objs, o, rec, kubeconfig, remoteCtx, namespace)
+			finishAudit(rec, err)
+			return err
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.Filename, "filename", "f", "", "filename, directory, or URL to files to use to create the resource")
+	cmd.Flags().BoolVarP(&o.Recursive, "recursive", "R", false, "process the directory used in -f, --filename recursively")
+	cmd.Flags().StringVar(&o.DryRun, "dry-run", "none", "must be \"none\", \"server\", or \"client\"")
+	cmd.Flags().StringVarP(&o.Output, "output", "o", "", "with --dry-run=server, print the validated objects (json|yaml)")
+	o.Targets.addFlags(cmd, "create in")
+
+	return cmd
+}
+
+func handleCreateCommand(objs []*unstructured.Unstructured, o createOptions, rec *audit.Recorder, kubeconfig, remoteCtx, namespace string) error {
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	clusters, err = o.Targets.selectFrom(clusters, kubeconfig)
+	if err != nil {
+		return err
+	}
+	if len(clusters) == 0 {
+		return fmt.Errorf("no clusters discovered")
+	}
+	radius := blastRadius{Objects: len(objs) * fanOutTargets(clusters, remoteCtx), Namespaces: objectNamespaces(objs, namespace)}
+	if err := guardFanOut(rec, radius, clusters, remoteCtx); err != nil {
+		return err
+	}
+	return createClusters(clusters, remoteCtx, objs, o, rec, namespace, util.GetOutputStream(), os.Stderr)
+}
+
+// createClusters creates the objects in every cluster but the ITS. A server
+// dry run prints one summary for the fleet, to errOut when the validated
+// objects go to out; otherwise each cluster's result is printed.
+func createClusters(clusters []cluster.ClusterInfo, itsContext string, objs []*unstructured.Unstructured, o createOptions, rec *audit.Recorder, namespace string, out, errOut io.Writer) error {
+	if o.DryRun == util.DryRunServer {
+		var names []string
+		var results []createResult
+		skipped := ""
+		for _, c := range clusters {
+			if c.Context == itsContext {
+				skipped = c.Context
+				continue
+			}
+			names = append(names, c.Name)
+			results = append(results, createObjects(c, objs, namespace, o.DryRun, rec))
+		}
+		summaryOut := out
+		if o.Output != "" {
+			summaryOut = errOut
+			for _, r := range results {
+				if len(r.errs) == 0 {
+					items := make([]interface{}, 0, len(r.created))
+					for _, obj := range r.created {
+						items = append(items, obj.Object)
+					}
+					if err := util.PrintStructured(out, o.Output, objectList(items)); err != nil {
+						return err
+					}
+					break
+				}
+			}
+		}
+		return printDryRunSummary(summaryOut, names, results, len(objs), skipped)
+	}
+
+	trackFanOut(rec, fanOutTargets(clusters, itsContext))
+	failed, tried := 0, 0
+	for _, c := range clusters {
+		fmt.Fprintf(out, "=== Cluster: %s ===\n", c.Context)
+		if c.Context == itsContext {
+			fmt.Fprintf(out, "Cannot perform this operation on ITS (control) cluster: %s\n\n", c.Context)
+			continue
+		}
+		tried++
+		rec.Begin(c.Name)
+		r := createObjects(c, objs, namespace, o.DryRun, rec)
+		for _, line := range r.lines {
+			fmt.Fprintln(out, line)
+		}
+		for _, err := range r.errs {
+			fmt.Fprintf(out, "Error: %v\n", err)
+		}
+		if len(r.errs) > 0 {
+			failed++
+			rec.Record(c.Name, r.errs[0])
+		} else {
+			rec.Record(c.Name, nil)
+		}
+		fmt.Fprintln(out)
+	}
+	if failed > 0 {
+		return fmt.Errorf("create failed in %d of %d clusters", failed, tried)
+	}
+	return nil
+}
+
+// printDryRunSummary reports the clusters whose API servers accepted every
+// object and, grouped by reason, the errors of the others. skippedITS is the
+// ITS context left out of the run, if any.
+func printDryRunSummary(out io.Writer, names []string, results []createResult, objects int, skippedITS string) error {
+	var valid []string
+	var reasons []string
+	failing := map[string][]string{}
+	for i, r := range results {
+		if len(r.errs) == 0 {
+			valid = append(valid, names[i])
+			continue
+		}
+		msgs := make([]string, 0, len(r.errs))
+		for _, err := range r.errs {
+			msgs = append(msgs, err.Error())
+		}
+		reason := strings.Join(msgs, "; ")
+		if _, seen := failing[reason]; !seen {
+			reasons = append(reasons, reason)
+		}
+		failing[reason] = append(failing[reason], names[i])
+	}
+
+	fmt.Fprintf(out, "Server dry run of %d object(s) in %d cluster(s):\n", objects, len(results))
+	fmt.Fprintf(out, "  valid in %d cluster(s): %s\n", len(valid), orNone(strings.Join(valid, ", ")))
+	if skippedITS != "" {
+		fmt.Fprintf(out, "  skipped the ITS (control) cluster: %s\n", skippedITS)
+	}
+	failed := len(results) - len(valid)
+	if failed == 0 {
+		return nil
+	}
+	fmt.Fprintf(out, "  errors in %d cluster(s):\n", failed)
+	for _, reason := range reasons {
+		fmt.Fprintf(out, "    %s: %s\n", strings.Join(failing[reason], ", "), reason)
+	}
+	return fmt.Errorf("server dry run failed in %d of %d clusters", failed, len(results))
+}
+
+// createObjects creates objs in one cluster, recording how to delete them
+// again, and carries on past objects that fail
+func createObjects(c cluster.ClusterInfo, objs []*unstructured.Unstructured, namespace, dryRun string, rec *audit.Recorder) createResult {
+	var r createResult
+	if c.DynamicClient == nil || c.DiscoveryClient == nil {
+		r.errs = append(r.errs, fmt.Errorf("no clients available for cluster %s", c.Name))
+		return r
+	}
+	mapper := c.Mapper()
+	opts := metav1.CreateOptions{FieldManager: util.FieldManager}
+	suffix := ""
+	switch dryRun {
+	case util.DryRunServer:
+		opts.DryRun = []string{metav1.DryRunAll}
+		suffix = " (server dry run)"
+	case util.DryRunClient:
+		suffix = " (dry run)"
+	}
+
+	for _, obj := range objs {
+		gvk := obj.GroupVersionKind()
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			r.errs = append(r.errs, fmt.Errorf("%s %s: %v", gvk.Kind, obj.GetName(), err))
+			continue
+		}
+		obj = obj.DeepCopy()
+		util.MarkManaged(obj)
+		var client dynamic.ResourceInterface = c.DynamicClient.Resource(mapping.Resource)
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			if obj.GetNamespace() == "" {
+				obj.SetNamespace(cluster.GetTargetNamespace(namespace))
+			}
+			client = c.DynamicClient.Resource(mapping.Resource).Namespace(obj.GetNamespace())
+		}
+
+		created := obj
+		if dryRun != util.DryRunClient {
+			if created, err = client.Create(commandContext(), obj, opts); err != nil {
+				r.errs = append(r.errs, err)
+				continue
+			}
+		}
+		r.created = append(r.created, created)
+		r.lines = append(r.lines, qualifiedName(mapping.Resource, created)+" created"+suffix)
+		if dryRun == util.DryRunNone || dryRun == "" {
+			rec.AddUndo(audit.UndoStep{
+				Cluster:   c.Name,
+				Action:    audit.UndoDelete,
+				Group:     mapping.Resource.Group,
+				Version:   mapping.Resource.Version,
+				Resource:  mapping.Resource.Resource,
+				Namespace: created.GetNamespace(),
+				Name:      created.GetName(),
+			})
+		}
+	}
+	return r
+}
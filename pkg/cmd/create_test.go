@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+func TestCreateClusters(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       createOptions
+		existing   bool
+		rejectIn   []string
+		wantOut    []string
+		wantErrOut []string
+		wantLeft   int
+		wantUndo   int
+		wantErr    string
+	}{
+		{
+			name:     "create",
+			wantOut:  []string{"=== Cluster: cluster1 ===\nconfigmap/app created\n", "=== Cluster: cluster3 ===\nconfigmap/app created\n", "Cannot perform this operation on ITS (control) cluster: its1"},
+			wantLeft: 3,
+			wantUndo: 3,
+		},
+		{
+			name:     "already exists",
+			existing: true,
+			wantOut:  []string{"=== Cluster: cluster1 ===\nError: configmaps \"app\" already exists\n", "=== Cluster: cluster2 ===\nconfigmap/app created\n"},
+			wantLeft: 3,
+			wantUndo: 2,
+			wantErr:  "create failed in 1 of 3 clusters",
+		},
+		{
+			name:    "client dry run",
+			opts:    createOptions{DryRun: util.DryRunClient},
+			wantOut: []string{"configmap/app created (dry run)\n"},
+		},
+		{
+			name: "server dry run",
+			opts: createOptions{DryRun: util.DryRunServer},
+			wantOut: []string{"Server dry run of 1 object(s) in 3 cluster(s):\n" +
+				"  valid in 3 cluster(s): cluster1, cluster2, cluster3\n" +
+				"  skipped the ITS (control) cluster: its1\n"},
+		},
+		{
+			name:     "server dry run with errors",
+			opts:     createOptions{DryRun: util.DryRunServer},
+			rejectIn: []string{"cluster1", "cluster3"},
+			wantOut: []string{"  valid in 1 cluster(s): cluster2\n" +
+				"  skipped the ITS (control) cluster: its1\n" +
+				"  errors in 2 cluster(s):\n" +
+				"    cluster1, cluster3: exceeded quota\n"},
+			wantErr: "server dry run failed in 2 of 3 clusters",
+		},
+		{
+			name:       "server dry run objects",
+			opts:       createOptions{DryRun: util.DryRunServer, Output: "yaml"},
+			rejectIn:   []string{"cluster1"},
+			wantOut:    []string{"kind: List\n", "name: app\n"},
+			wantErrOut: []string{"    cluster1: exceeded quota\n"},
+			wantErr:    "server dry run failed in 1 of 3 clusters",
+		},
+	}
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.opts.DryRun == "" {
+				tt.opts.DryRun = util.DryRunNone
+			}
+			var clusters []cluster.ClusterInfo
+			var dyns []*fakedynamic.FakeDynamicClient
+			for i, name := range []string{"cluster1", "cluster2", "cluster3", "its1"} {
+				var objs []runtime.Object
+				if tt.existing && i == 0 {
+					objs = append(objs, testConfigMap("app", "old"))
+				}
+				c, dyn := testClusterInfo(objs...)
+				c.Name, c.Context = name, name
+				rejected := false
+				for _, r := range tt.rejectIn {
+					rejected = rejected || r == name
+				}
+				if tt.opts.DryRun == util.DryRunServer {
+					// Validate without persisting, as the API server does
+					dyn.PrependReactor("create", "configmaps", func(action clienttesting.Action) (bool, runtime.Object, error) {
+						if rejected {
+							return true, nil, fmt.Errorf("exceeded quota")
+						}
+						return true, action.(clienttesting.CreateAction).GetObject(), nil
+					})
+				}
+				clusters = append(clusters, c)
+				dyns = append(dyns, dyn)
+			}
+
+			var out, errOut bytes.Buffer
+			rec := audit.Start("create", nil)
+			err := createClusters(clusters, "its1", []*unstructured.Unstructured{testObject("v1", "ConfigMap", "", "app")}, tt.opts, rec, "default", &out, &errOut)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("createClusters() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("createClusters() error = %v", err)
+			}
+			for _, want := range tt.wantOut {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output %q does not contain %q", out.String(), want)
+				}
+			}
+			for _, want := range tt.wantErrOut {
+				if !strings.Contains(errOut.String(), want) {
+					t.Errorf("error output %q does not contain %q", errOut.String(), want)
+				}
+			}
+			if got := len(rec.Finish(err).Undo); got != tt.wantUndo {
+				t.Errorf("undo steps = %d, want %d", got, tt.wantUndo)
+			}
+
+			left := 0
+			for _, dyn := range dyns {
+				if obj, err := dyn.Resource(gvr).Namespace("default").Get(commandContext(), "app", metav1.GetOptions{}); err == nil {
+					left++
+					if !tt.existing && !util.IsManaged(obj) {
+						t.Errorf("created configmap lacks the %s annotation", util.AppliedByAnnotation)
+					}
+				}
+			}
+			if left != tt.wantLeft {
+				t.Errorf("clusters with configmap/app = %d, want %d", left, tt.wantLeft)
+			}
+		})
+	}
+}
@@ -0,0 +1,354 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/duration"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/schedule"
+	"kubectl-multi/pkg/util"
+)
+
+// Verdicts of cronjob-report for one cronjob in one cluster
+const (
+	cronJobOK        = "OK"
+	cronJobSuspended = "Suspended"
+	cronJobFailing   = "Failing"
+	cronJobMissed    = "Missed"
+	cronJobUnknown   = "Unknown"
+)
+
+// cronJobRuns are the last runs of one cronjob in one cluster
+type cronJobRuns struct {
+	Cluster   string     `json:"cluster"`
+	Namespace string     `json:"namespace"`
+	Name      string     `json:"name"`
+	Schedule  string     `json:"schedule"`
+	Status    string     `json:"status"`
+	Suspended bool       `json:"suspended,omitempty"`
+	LastRun   *time.Time `json:"lastSchedule,omitempty"`
+	// LastSuccessfulJob and LastFailedJob are empty when the job was
+	// deleted by the history limits, or never ran
+	LastSuccess       *time.Time `json:"lastSuccess,omitempty"`
+	LastSuccessfulJob string     `json:"lastSuccessfulJob,omitempty"`
+	LastFailure       *time.Time `json:"lastFailure,omitempty"`
+	LastFailedJob     string     `json:"lastFailedJob,omitempty"`
+	ActiveJobs        []string   `json:"activeJobs,omitempty"`
+	// MissedSchedules counts the runs due since the last success, up to
+	// --missed-schedules
+	MissedSchedules int    `json:"missedSchedules"`
+	Detail          string `json:"detail,omitempty"`
+}
+
+// ok reports whether the cronjob succeeds on schedule, or is not meant to run
+func (r cronJobRuns) ok() bool {
+	return r.Status == cronJobOK || r.Status == cronJobSuspended
+}
+
+func newCronJobReportCommand() *cobra.Command {
+	var outputFormat string
+	var maxMissed int
+	var targets clusterTargets
+	var reach reachability
+
+	cmd := &cobra.Command{
+		Use:   "cronjob-report [CRONJOB]",
+		Short: "List the last runs of the cronjobs of every managed cluster and flag those not succeeding",
+		Long: `List, for the cronjobs of every managed cluster, when they were last
+scheduled, their last successful and failed jobs and the jobs running now,
+and flag those that have not succeeded within --missed-schedules of their
+schedules, since a cronjob failing silently in one cluster is easy to miss:
+
+  OK         succeeded within the last schedules
+  Failing    no success within the last schedules, and its jobs fail
+  Missed     no success within the last schedules, and no job failed
+  Suspended  the cronjob is suspended
+  Unknown    the schedule cannot be read
+
+Schedules are read in the spec.timeZone of the cronjob, or a CRON_TZ= prefix,
+and in UTC otherwise. A running job counts as not yet due. The command fails
+when any cronjob is Failing, Missed or Unknown, so it can gate scripts.`,
+		Example: `# Cronjobs of every namespace of every cluster
+kubectl multi cronjob-report -A
+
+# The backup cronjob, flagged after a single missed schedule
+kubectl multi cronjob-report backup -n ops --missed-schedules 1
+
+# Two clusters, as JSON
+kubectl multi cronjob-report -n ops --clusters cluster1,cluster2 -o json`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch outputFormat {
+			case "", "json", util.TableFormatCSV, util.TableFormatMarkdown:
+			default:
+				return fmt.Errorf("unsupported output format %q, must be one of json|csv|markdown", outputFormat)
+			}
+			if maxMissed < 1 {
+				return fmt.Errorf("--missed-schedules must be at least 1")
+			}
+			name := ""
+			if len(args) == 1 {
+				name = args[0]
+			}
+			kubeconfig, remoteCtx, _, namespace, allNamespaces := GetGlobalFlags()
+			return handleCronJobReportCommand(name, outputFormat, maxMissed, targets, reach, kubeconfig, remoteCtx, namespace, allNamespaces)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "output format (json|csv|markdown)")
+	cmd.Flags().IntVar(&maxMissed, "missed-schedules", 3, "flag cronjobs that have not succeeded within this many schedules")
+	targets.addFlags(cmd, "report on")
+	reach.addFlags(cmd)
+
+	return cmd
+}
+
+func handleCronJobReportCommand(name, outputFormat string, maxMissed int, targets clusterTargets, reach reachability, kubeconfig, remoteCtx, namespace string, allNamespaces bool) error {
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	clusters, err = targets.selectFrom(clusters, kubeconfig)
+	if err != nil {
+		return err
+	}
+	clusters, err = reach.filter(workloadClusters(clusters, remoteCtx))
+	if err != nil {
+		return err
+	}
+	defer printClusterIssuesToStderr()
+
+	targetNS := cluster.GetTargetNamespace(namespace)
+	if allNamespaces {
+		targetNS = ""
+	}
+	now := time.Now()
+	var results []cronJobRuns
+	for _, c := range clusters {
+		if c.Client == nil {
+			continue
+		}
+		results = append(results, readCronJobRuns(c, targetNS, name, maxMissed, now)...)
+	}
+
+	if outputFormat == "json" {
+		if err := printJSONArray(results); err != nil {
+			return err
+		}
+	} else {
+		printCronJobRuns(results, outputFormat, now)
+	}
+	if len(results) == 0 && name != "" {
+		return fmt.Errorf("cronjob %s not found in any cluster", name)
+	}
+	var flagged []string
+	failing := 0
+	for _, r := range results {
+		if !r.ok() {
+			failing++
+			if !containsString(flagged, r.Cluster) {
+				flagged = append(flagged, r.Cluster)
+			}
+		}
+	}
+	if failing > 0 {
+		return fmt.Errorf("%d of %d cronjob(s) not succeeding within %s, in cluster(s) %s",
+			failing, len(results), plural(maxMissed, "schedule"), strings.Join(flagged, ", "))
+	}
+	return nil
+}
+
+// readCronJobRuns lists the cronjobs of one cluster and their jobs, noting a
+// failure as a cluster issue
+func readCronJobRuns(c cluster.ClusterInfo, targetNS, name string, maxMissed int, now time.Time) []cronJobRuns {
+	cronJobs, err := listNamespaced(c, "batch", "cronjobs", targetNS, func(ns string) (*batchv1.CronJobList, error) {
+		return c.Client.BatchV1().CronJobs(ns).List(commandContext(), metav1.ListOptions{})
+	})
+	if err != nil {
+		noteClusterIssue(c.Name, fmt.Sprintf("failed to list cronjobs: %v", err))
+		return nil
+	}
+	items := itemsNamed(cronJobs.Items, name, false)
+	if len(items) == 0 {
+		return nil
+	}
+	jobs, err := listNamespaced(c, "batch", "jobs", targetNS, func(ns string) (*batchv1.JobList, error) {
+		return c.Client.BatchV1().Jobs(ns).List(commandContext(), metav1.ListOptions{})
+	})
+	if err != nil {
+		noteClusterIssue(c.Name, fmt.Sprintf("failed to list jobs: %v", err))
+		return nil
+	}
+	owned := map[types.UID][]batchv1.Job{}
+	for _, job := range jobs.Items {
+		if owner := metav1.GetControllerOf(&job); owner != nil && owner.Kind == "CronJob" {
+			owned[owner.UID] = append(owned[owner.UID], job)
+		}
+	}
+
+	results := make([]cronJobRuns, 0, len(items))
+	for i := range items {
+		cj := &items[i]
+		results = append(results, judgeCronJob(c.Name, cj, owned[cj.UID], maxMissed, now))
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Namespace != results[j].Namespace {
+			return results[i].Namespace < results[j].Namespace
+		}
+		return results[i].Name < results[j].Name
+	})
+	return results
+}
+
+// judgeCronJob finds the last runs of a cronjob among its jobs and counts
+// the schedules due since it last succeeded
+func judgeCronJob(clusterName string, cj *batchv1.CronJob, jobs []batchv1.Job, maxMissed int, now time.Time) cronJobRuns {
+	r := cronJobRuns{
+		Cluster:   clusterName,
+		Namespace: cj.Namespace,
+		Name:      cj.Name,
+		Schedule:  cj.Spec.Schedule,
+		Suspended: cj.Spec.Suspend != nil && *cj.Spec.Suspend,
+	}
+	if cj.Status.LastScheduleTime != nil {
+		r.LastRun = &cj.Status.LastScheduleTime.Time
+	}
+	if cj.Status.LastSuccessfulTime != nil {
+		r.LastSuccess = &cj.Status.LastSuccessfulTime.Time
+	}
+	for _, ref := range cj.Status.Active {
+		r.ActiveJobs = append(r.ActiveJobs, ref.Name)
+	}
+	sort.Strings(r.ActiveJobs)
+
+	for i := range jobs {
+		job := &jobs[i]
+		for _, cond := range job.Status.Conditions {
+			if cond.Status != corev1.ConditionTrue {
+				continue
+			}
+			at := cond.LastTransitionTime.Time
+			switch cond.Type {
+			case batchv1.JobComplete:
+				if job.Status.CompletionTime != nil {
+					at = job.Status.CompletionTime.Time
+				}
+				// The status keeps the time after the job itself is deleted
+				if r.LastSuccess == nil || !at.Before(*r.LastSuccess) {
+					r.LastSuccess, r.LastSuccessfulJob = &at, job.Name
+				}
+			case batchv1.JobFailed:
+				if r.LastFailure == nil || at.After(*r.LastFailure) {
+					r.LastFailure, r.LastFailedJob = &at, job.Name
+				}
+			}
+		}
+	}
+
+	if r.Suspended {
+		r.Status = cronJobSuspended
+		return r
+	}
+	spec, loc, err := cronJobSchedule(cj)
+	if err != nil {
+		r.Status, r.Detail = cronJobUnknown, err.Error()
+		return r
+	}
+	since := cj.CreationTimestamp.Time
+	if r.LastSuccess != nil {
+		since = *r.LastSuccess
+	}
+	r.MissedSchedules = schedulesDue(spec, since.In(loc), now, maxMissed)
+	if len(r.ActiveJobs) > 0 && r.MissedSchedules > 0 {
+		r.MissedSchedules--
+	}
+
+	switch {
+	case r.MissedSchedules < maxMissed:
+		r.Status = cronJobOK
+	case r.LastFailure != nil && r.LastFailure.After(since):
+		r.Status, r.Detail = cronJobFailing, fmt.Sprintf("no success in the last %s, job %s failed", plural(maxMissed, "schedule"), orNone(r.LastFailedJob))
+	default:
+		r.Status, r.Detail = cronJobMissed, fmt.Sprintf("no success in the last %s", plural(maxMissed, "schedule"))
+	}
+	return r
+}
+
+// cronJobSchedule parses the schedule of a cronjob and returns the time zone
+// it is read in: spec.timeZone, a CRON_TZ= or TZ= prefix, or UTC, which
+// kube-controller-manager usually runs in
+func cronJobSchedule(cj *batchv1.CronJob) (*schedule.Spec, *time.Location, error) {
+	expr := strings.TrimSpace(cj.Spec.Schedule)
+	zone := ""
+	if cj.Spec.TimeZone != nil {
+		zone = *cj.Spec.TimeZone
+	}
+	for _, prefix := range []string{"CRON_TZ=", "TZ="} {
+		if strings.HasPrefix(expr, prefix) {
+			zone, expr, _ = strings.Cut(strings.TrimPrefix(expr, prefix), " ")
+		}
+	}
+	loc := time.UTC
+	if zone != "" {
+		var err error
+		if loc, err = time.LoadLocation(zone); err != nil {
+			return nil, nil, fmt.Errorf("unknown time zone %q", zone)
+		}
+	}
+	spec, err := schedule.Parse(expr)
+	if err != nil {
+		return nil, nil, err
+	}
+	return spec, loc, nil
+}
+
+// schedulesDue counts the runs due after since and up to now, stopping at
+// limit
+func schedulesDue(spec *schedule.Spec, since, now time.Time, limit int) int {
+	due := 0
+	for t := spec.Next(since); !t.IsZero() && !t.After(now) && due < limit; t = spec.Next(t) {
+		due++
+	}
+	return due
+}
+
+func printCronJobRuns(results []cronJobRuns, outputFormat string, now time.Time) {
+	tw := util.NewTableWriter(util.GetOutputStream(), outputFormat)
+	defer tw.Flush()
+
+	if len(results) == 0 {
+		fmt.Fprintln(tw, "No cronjobs found.")
+		return
+	}
+	fmt.Fprintln(tw, "CLUSTER\tNAMESPACE\tNAME\tSCHEDULE\tLAST SCHEDULE\tLAST SUCCESS\tLAST FAILURE\tACTIVE\tSTATUS\tDETAIL")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.Cluster, r.Namespace, r.Name, r.Schedule,
+			ageSince(r.LastRun, now), jobRunAge(r.LastSuccess, r.LastSuccessfulJob, now), jobRunAge(r.LastFailure, r.LastFailedJob, now),
+			orNone(strings.Join(r.ActiveJobs, ",")), r.Status, orNone(r.Detail))
+	}
+}
+
+// ageSince renders how long ago t was, <none> for no time
+func ageSince(t *time.Time, now time.Time) string {
+	if t == nil {
+		return "<none>"
+	}
+	return duration.HumanDuration(now.Sub(*t)) + " ago"
+}
+
+// jobRunAge renders a run as its age and job, e.g. "5m ago (backup-2901)"
+func jobRunAge(t *time.Time, job string, now time.Time) string {
+	if t == nil || job == "" {
+		return ageSince(t, now)
+	}
+	return ageSince(t, now) + " (" + job + ")"
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"kubectl-multi/pkg/cluster"
+)
+
+// cronJobNow is the time the cronjob tests report at, an hourly schedule
+// having last been due at 12:00
+var cronJobNow = time.Date(2026, 5, 4, 12, 30, 0, 0, time.UTC)
+
+func testCronJob(name, schedule string, lastSuccess time.Time) *batchv1.CronJob {
+	cj := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ops", Name: name, UID: types.UID(name + "-uid"), CreationTimestamp: metav1.NewTime(cronJobNow.AddDate(0, -1, 0))},
+		Spec:       batchv1.CronJobSpec{Schedule: schedule},
+	}
+	if !lastSuccess.IsZero() {
+		cj.Status.LastSuccessfulTime = &metav1.Time{Time: lastSuccess}
+		cj.Status.LastScheduleTime = &metav1.Time{Time: lastSuccess.Truncate(time.Hour)}
+	}
+	return cj
+}
+
+// testCronJobRun returns a job of the cronjob that finished at the given
+// time, Complete or Failed
+func testCronJobRun(cj *batchv1.CronJob, name string, finished time.Time, condition batchv1.JobConditionType) *batchv1.Job {
+	controller := true
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Namespace: cj.Namespace, Name: name, OwnerReferences: []metav1.OwnerReference{{
+			APIVersion: "batch/v1", Kind: "CronJob", Name: cj.Name, UID: cj.UID, Controller: &controller,
+		}}},
+		Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{{
+			Type: condition, Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(finished),
+		}}},
+	}
+	if condition == batchv1.JobComplete {
+		job.Status.CompletionTime = &metav1.Time{Time: finished}
+	}
+	return job
+}
+
+func TestJudgeCronJob(t *testing.T) {
+	hourly := func(lastSuccess time.Time) *batchv1.CronJob { return testCronJob("backup", "0 * * * *", lastSuccess) }
+	suspended := hourly(time.Time{})
+	suspended.Spec.Suspend = new(bool)
+	*suspended.Spec.Suspend = true
+	running := hourly(cronJobNow.Add(-150 * time.Minute))
+	running.Status.Active = []corev1.ObjectReference{{Name: "backup-1200"}}
+	berlin := "Europe/Berlin"
+	zoned := testCronJob("report", "30 14 * * *", cronJobNow.Add(-23*time.Hour))
+	zoned.Spec.TimeZone = &berlin
+
+	tests := []struct {
+		name       string
+		cj         *batchv1.CronJob
+		jobs       func(cj *batchv1.CronJob) []batchv1.Job
+		wantStatus string
+		wantMissed int
+		wantJobs   [2]string
+	}{
+		{name: "succeeded last hour", cj: hourly(cronJobNow.Add(-29 * time.Minute)), wantStatus: cronJobOK},
+		{name: "two missed runs tolerated", cj: hourly(cronJobNow.Add(-150 * time.Minute)), wantStatus: cronJobOK, wantMissed: 2},
+		{name: "three missed runs", cj: hourly(cronJobNow.Add(-210 * time.Minute)), wantStatus: cronJobMissed, wantMissed: 3},
+		{name: "running job not yet due", cj: running, wantStatus: cronJobOK, wantMissed: 1},
+		{name: "failing jobs", cj: hourly(cronJobNow.Add(-5 * time.Hour)), wantStatus: cronJobFailing, wantMissed: 3,
+			jobs: func(cj *batchv1.CronJob) []batchv1.Job {
+				return []batchv1.Job{
+					*testCronJobRun(cj, "backup-0700", cronJobNow.Add(-5*time.Hour), batchv1.JobComplete),
+					*testCronJobRun(cj, "backup-1100", cronJobNow.Add(-85*time.Minute), batchv1.JobFailed),
+					*testCronJobRun(cj, "backup-1200", cronJobNow.Add(-25*time.Minute), batchv1.JobFailed),
+				}
+			},
+			wantJobs: [2]string{"backup-0700", "backup-1200"}},
+		{name: "never succeeded", cj: hourly(time.Time{}), wantStatus: cronJobMissed, wantMissed: 3},
+		{name: "suspended", cj: suspended, wantStatus: cronJobSuspended},
+		{name: "bad schedule", cj: testCronJob("backup", "every hour", time.Time{}), wantStatus: cronJobUnknown},
+		{name: "time zone prefix", cj: testCronJob("backup", "CRON_TZ=Mars/Olympus 0 * * * *", time.Time{}), wantStatus: cronJobUnknown},
+		// 14:30 in Berlin is 12:30 UTC, due just now
+		{name: "spec time zone", cj: zoned, wantStatus: cronJobOK, wantMissed: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var jobs []batchv1.Job
+			if tt.jobs != nil {
+				jobs = tt.jobs(tt.cj)
+			}
+			got := judgeCronJob("cluster1", tt.cj, jobs, 3, cronJobNow)
+			if got.Status != tt.wantStatus || got.MissedSchedules != tt.wantMissed {
+				t.Errorf("judgeCronJob() = %s with %d missed (%s), want %s with %d", got.Status, got.MissedSchedules, got.Detail, tt.wantStatus, tt.wantMissed)
+			}
+			if jobs := [2]string{got.LastSuccessfulJob, got.LastFailedJob}; jobs != tt.wantJobs {
+				t.Errorf("last successful and failed jobs = %v, want %v", jobs, tt.wantJobs)
+			}
+		})
+	}
+}
+
+func TestReadCronJobRuns(t *testing.T) {
+	backup := testCronJob("backup", "0 * * * *", cronJobNow.Add(-10*time.Minute))
+	cleanup := testCronJob("cleanup", "@daily", time.Time{})
+	client := fake.NewSimpleClientset(backup, cleanup,
+		testCronJobRun(backup, "backup-1200", cronJobNow.Add(-10*time.Minute), batchv1.JobComplete),
+		// A job created by hand
+		&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "ops", Name: "manual"}},
+	)
+	c := cluster.ClusterInfo{Name: "cluster1", Client: client}
+
+	got := readCronJobRuns(c, "ops", "", 3, cronJobNow)
+	if len(got) != 2 || got[0].Name != "backup" || got[1].Name != "cleanup" {
+		t.Fatalf("readCronJobRuns() = %+v, want backup and cleanup", got)
+	}
+	if got[0].Status != cronJobOK || got[0].LastSuccessfulJob != "backup-1200" {
+		t.Errorf("backup = %+v, want OK with its last job", got[0])
+	}
+	// Created a month ago and never succeeded
+	if got[1].Status != cronJobMissed {
+		t.Errorf("cleanup = %+v, want Missed", got[1])
+	}
+
+	got = readCronJobRuns(c, "ops", "cleanup", 3, cronJobNow)
+	if len(got) != 1 || got[0].Name != "cleanup" {
+		t.Errorf("readCronJobRuns(cleanup) = %+v", got)
+	}
+}
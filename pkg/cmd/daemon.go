@@ -0,0 +1,297 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/duration"
+
+	"kubectl-multi/pkg/config"
+	"kubectl-multi/pkg/platform"
+	"kubectl-multi/pkg/util"
+)
+
+// EnvDaemonSocket overrides the socket the daemon listens on and commands
+// look for it on
+const EnvDaemonSocket = "KUBECTL_MULTI_DAEMON_SOCKET"
+
+// daemonURL is the base URL of requests to the daemon; the host is not
+// resolved, requests are dialed to the socket
+const daemonURL = "http://kubectl-multi-daemon"
+
+// defaultDaemonResources are the resource types cached when --resources is
+// not given
+var defaultDaemonResources = []string{"pods", "deployments", "replicasets", "statefulsets", "daemonsets", "services", "jobs", "nodes", "namespaces"}
+
+// defaultDaemonSocket is $KUBECTL_MULTI_DAEMON_SOCKET, or daemon.sock in the
+// kubectl-multi directory of the user cache directory
+func defaultDaemonSocket() string {
+	if socket := os.Getenv(EnvDaemonSocket); socket != "" {
+		return socket
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "kubectl-multi", "daemon.sock")
+}
+
+// dialSocket returns a dialer that connects every request to the socket
+func dialSocket(socket string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "unix", socket)
+	}
+}
+
+func newDaemonCommand() *cobra.Command {
+	var socket string
+
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Run a local daemon caching resources of every cluster for fast queries",
+		Long: `Run a background daemon that keeps informer caches of selected resource types
+for every cluster of the fleet and answers get from them over a local socket,
+instead of listing every cluster again for each query.
+
+While a daemon caching the same fleet (the same --kubeconfig, ITS and
+--context-pattern) is running, get uses it automatically; --no-daemon
+bypasses it. Requests the caches cannot answer, such as other resource types,
+field selectors and caches still syncing, are passed through to the clusters.
+The clusters are discovered once, when the daemon starts; restart it after
+clusters join or leave.
+
+The daemon also runs the tasks registered with 'kubectl multi schedule add'.`,
+		Example: `# Cache the default resource types of every cluster
+kubectl multi daemon start
+
+# Cache only pods and deployments
+kubectl multi daemon start --resources pods,deployments
+
+# See what is cached and whether the caches have synced
+kubectl multi daemon status
+
+# Stop the daemon
+kubectl multi daemon stop`,
+	}
+	cmd.PersistentFlags().StringVar(&socket, "socket", defaultDaemonSocket(), "unix socket the daemon listens on (defaults to $"+EnvDaemonSocket+")")
+
+	cmd.AddCommand(newDaemonStartCommand(&socket))
+	cmd.AddCommand(newDaemonRunCommand(&socket))
+	cmd.AddCommand(newDaemonStatusCommand(&socket))
+	cmd.AddCommand(newDaemonStopCommand(&socket))
+	return cmd
+}
+
+func newDaemonStartCommand(socket *string) *cobra.Command {
+	var resources []string
+	var wait time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "start",
+		Short: "Start the daemon in the background",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return startDaemon(*socket, resources, wait)
+		},
+	}
+	cmd.Flags().StringSliceVar(&resources, "resources", defaultDaemonResources, "comma-separated resource types to cache")
+	cmd.Flags().DurationVar(&wait, "wait", time.Minute, "how long to wait for the daemon to listen")
+	return cmd
+}
+
+func newDaemonRunCommand(socket *string) *cobra.Command {
+	var resources []string
+
+	cmd := &cobra.Command{
+		Use:   "run",
+		Short: "Run the daemon in the foreground, such as under a service manager",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDaemon(*socket, resources)
+		},
+	}
+	cmd.Flags().StringSliceVar(&resources, "resources", defaultDaemonResources, "comma-separated resource types to cache")
+	return cmd
+}
+
+func newDaemonStatusCommand(socket *string) *cobra.Command {
+	var outputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the cached clusters and resource types of the daemon",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch outputFormat {
+			case "", "json", "yaml":
+			default:
+				return fmt.Errorf("unsupported output format %q, must be one of json|yaml", outputFormat)
+			}
+			status, err := newDaemonClient(*socket).status(commandContext())
+			if err != nil {
+				return fmt.Errorf("no daemon is running on %s: %v", *socket, err)
+			}
+			if outputFormat != "" {
+				return util.PrintStructured(util.GetOutputStream(), outputFormat, status)
+			}
+			printDaemonStatus(*socket, status)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "output format (json|yaml)")
+	return cmd
+}
+
+func newDaemonStopCommand(socket *string) *cobra.Command {
+	return &cobra.Command{
+		Use:   "stop",
+		Short: "Stop the daemon",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := newDaemonClient(*socket)
+			status, err := client.status(commandContext())
+			if err != nil {
+				return fmt.Errorf("no daemon is running on %s: %v", *socket, err)
+			}
+			if err := client.stop(commandContext()); err != nil {
+				return err
+			}
+			for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+				if _, err := client.status(commandContext()); err != nil {
+					fmt.Fprintf(os.Stderr, "Daemon (pid %d) stopped\n", status.PID)
+					return nil
+				}
+			}
+			return fmt.Errorf("daemon (pid %d) is still running", status.PID)
+		},
+	}
+}
+
+// startDaemon runs 'daemon run' with the global flags of this command as a
+// detached process logging next to the socket, and waits until it listens
+func startDaemon(socket string, resources []string, wait time.Duration) error {
+	client := newDaemonClient(socket)
+	if status, err := client.status(commandContext()); err == nil {
+		return fmt.Errorf("a daemon (pid %d) is already running on %s; stop it first", status.PID, socket)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(socket), 0700); err != nil {
+		return err
+	}
+	logPath := strings.TrimSuffix(socket, filepath.Ext(socket)) + ".log"
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	config := currentDaemonConfig()
+	args := []string{"daemon", "run", "--socket", socket, "--resources", strings.Join(resources, ","),
+		"--kubeconfig", config.Kubeconfig, "--remote-context", config.RemoteContext, "--context-pattern", config.ContextPattern}
+	if len(config.ITS) > 0 {
+		args = append(args, "--its", strings.Join(config.ITS, ","))
+	}
+	if config.AllITS {
+		args = append(args, "--all-its")
+	}
+	daemon := exec.Command(executable, args...)
+	daemon.Stdout, daemon.Stderr = logFile, logFile
+	platform.Detach(daemon)
+	if err := daemon.Start(); err != nil {
+		return fmt.Errorf("failed to start the daemon: %v", err)
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- daemon.Wait() }()
+
+	deadline := time.After(wait)
+	for {
+		if status, err := client.status(commandContext()); err == nil {
+			fmt.Fprintf(os.Stderr, "Daemon (pid %d) caching %s in %d cluster(s) on %s; log: %s\n",
+				status.PID, strings.Join(resources, ","), len(status.Clusters), socket, logPath)
+			return nil
+		}
+		select {
+		case err := <-exited:
+			return fmt.Errorf("daemon exited (%v); see %s", err, logPath)
+		case <-deadline:
+			return fmt.Errorf("daemon did not listen on %s within %s; see %s", socket, wait, logPath)
+		case <-commandContext().Done():
+			return commandContext().Err()
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
+
+// runDaemon discovers the fleet, starts the caches and serves them on the
+// socket until interrupted or asked to stop
+func runDaemon(socket string, resources []string) error {
+	client := newDaemonClient(socket)
+	if status, err := client.status(commandContext()); err == nil {
+		return fmt.Errorf("a daemon (pid %d) is already running on %s", status.PID, socket)
+	}
+
+	kubeconfig, remoteCtx, _, _, _ := GetGlobalFlags()
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(socket), 0700); err != nil {
+		return err
+	}
+	// Nothing answered on the socket, so a file left there is stale
+	os.Remove(socket)
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(socket, 0600); err != nil {
+		listener.Close()
+		return err
+	}
+
+	ctx, stop := context.WithCancel(commandContext())
+	defer stop()
+	d := newDaemonCache(ctx, clusters, resources, currentDaemonConfig(), stop)
+	printClusterIssuesToStderr()
+	go newScheduler(config.SchedulesPath(), config.ScheduleLogDir(), os.Stderr).run(ctx)
+	fmt.Fprintf(os.Stderr, "Caching %s in %d cluster(s); serving on %s\n", strings.Join(resources, ","), len(d.clusters), socket)
+	return serveUntilInterrupted(ctx, &http.Server{Handler: d.handler()}, listener)
+}
+
+func printDaemonStatus(socket string, status daemonStatus) {
+	out := util.GetOutputStream()
+	fmt.Fprintf(out, "Socket:   %s\n", socket)
+	fmt.Fprintf(out, "PID:      %d\n", status.PID)
+	fmt.Fprintf(out, "Uptime:   %s\n", duration.HumanDuration(time.Since(status.Started)))
+	if len(status.Config.ITS) > 0 {
+		fmt.Fprintf(out, "ITS:      %s\n", strings.Join(status.Config.ITS, ","))
+	} else if status.Config.AllITS {
+		fmt.Fprintf(out, "ITS:      all\n")
+	} else {
+		fmt.Fprintf(out, "ITS:      %s\n", status.Config.RemoteContext)
+	}
+	fmt.Fprintln(out)
+
+	tw := util.NewTableWriter(out, "")
+	defer tw.Flush()
+	fmt.Fprintln(tw, "CLUSTER\tRESOURCE\tSYNCED\tOBJECTS")
+	for _, c := range status.Clusters {
+		for _, r := range c.Resources {
+			fmt.Fprintf(tw, "%s\t%s\t%t\t%d\n", c.Name, r.Resource, r.Synced, r.Objects)
+		}
+	}
+}
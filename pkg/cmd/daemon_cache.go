@@ -0,0 +1,405 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+// daemonConfig is the fleet a daemon caches, as chosen by the global flags
+// it was started with. Commands only use a daemon with the same fleet.
+type daemonConfig struct {
+	Kubeconfig     string   `json:"kubeconfig"`
+	RemoteContext  string   `json:"remoteContext"`
+	ITS            []string `json:"its,omitempty"`
+	AllITS         bool     `json:"allITS,omitempty"`
+	ContextPattern string   `json:"contextPattern"`
+}
+
+// currentDaemonConfig returns the fleet the global flags choose
+func currentDaemonConfig() daemonConfig {
+	kubeconfig, remoteCtx, _, _, _ := GetGlobalFlags()
+	return daemonConfig{Kubeconfig: kubeconfig, RemoteContext: remoteCtx, ITS: itsNames, AllITS: allITS, ContextPattern: contextPattern}
+}
+
+func (c daemonConfig) equal(other daemonConfig) bool {
+	return c.Kubeconfig == other.Kubeconfig && c.RemoteContext == other.RemoteContext &&
+		strings.Join(c.ITS, ",") == strings.Join(other.ITS, ",") && c.AllITS == other.AllITS &&
+		c.ContextPattern == other.ContextPattern
+}
+
+// daemonStatus is what /daemon/status reports
+type daemonStatus struct {
+	PID      int                  `json:"pid"`
+	Started  time.Time            `json:"started"`
+	Config   daemonConfig         `json:"config"`
+	Clusters []daemonClusterState `json:"clusters"`
+}
+
+// daemonClusterState is one cached cluster with the state of its caches
+type daemonClusterState struct {
+	Name      string                `json:"name"`
+	Context   string                `json:"context"`
+	ITS       string                `json:"its,omitempty"`
+	Resources []daemonResourceState `json:"resources"`
+}
+
+// daemonResourceState is the cache of one resource type in one cluster
+type daemonResourceState struct {
+	Resource string `json:"resource"`
+	Synced   bool   `json:"synced"`
+	Objects  int    `json:"objects"`
+}
+
+// cachedResource is an informer kept for one resource type
+type cachedResource struct {
+	gvr      schema.GroupVersionResource
+	listKind string
+	informer cache.SharedIndexInformer
+}
+
+// cachedCluster holds the informers of one cluster and the proxy that
+// answers the requests they cannot
+type cachedCluster struct {
+	info      cluster.ClusterInfo
+	resources map[schema.GroupVersionResource]*cachedResource
+	proxy     http.Handler
+}
+
+// daemonCache answers API requests for every cluster of the fleet under
+// /clusters/NAME/, from the informers where it can and from the cluster
+// otherwise. It is not changed after newDaemonCache returns, so requests are
+// served concurrently.
+type daemonCache struct {
+	status   daemonStatus
+	clusters map[string]*cachedCluster
+	shutdown func()
+}
+
+// newDaemonCache starts informers for resourceTypes in every cluster. Types
+// a cluster does not serve are left out of its cache and noted as cluster
+// issues. The informers stop when ctx is done.
+func newDaemonCache(ctx context.Context, clusters []cluster.ClusterInfo, resourceTypes []string, config daemonConfig, shutdown func()) *daemonCache {
+	d := &daemonCache{
+		status:   daemonStatus{PID: os.Getpid(), Started: time.Now(), Config: config},
+		clusters: map[string]*cachedCluster{},
+		shutdown: shutdown,
+	}
+	for _, c := range clusters {
+		if c.DynamicClient == nil || c.DiscoveryClient == nil {
+			continue
+		}
+		cc := &cachedCluster{info: c, resources: map[schema.GroupVersionResource]*cachedResource{}}
+		if c.RestConfig != nil {
+			proxy, err := clusterProxy(c.RestConfig)
+			if err != nil {
+				noteClusterIssue(c.Name, fmt.Sprintf("requests outside the cache will fail: %v", err))
+			}
+			cc.proxy = proxy
+		}
+
+		factory := dynamicinformer.NewDynamicSharedInformerFactory(c.DynamicClient, 0)
+		for _, rt := range resourceTypes {
+			gvr, _, err := util.DiscoverGVR(c.DiscoveryClient, rt)
+			if err != nil {
+				noteClusterIssue(c.Name, fmt.Sprintf("not caching %s: %v", rt, err))
+				continue
+			}
+			listKind := ""
+			if gvk, err := c.Mapper().KindFor(gvr); err == nil {
+				listKind = gvk.Kind + "List"
+			}
+			cc.resources[gvr] = &cachedResource{gvr: gvr, listKind: listKind, informer: factory.ForResource(gvr).Informer()}
+		}
+		factory.Start(ctx.Done())
+		d.clusters[c.Name] = cc
+	}
+	return d
+}
+
+// clusterProxy forwards requests to the API server of a cluster with the
+// credentials of its rest config
+func clusterProxy(config *rest.Config) (http.Handler, error) {
+	transport, err := rest.TransportFor(config)
+	if err != nil {
+		return nil, err
+	}
+	host := config.Host
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	target, err := url.Parse(host)
+	if err != nil {
+		return nil, err
+	}
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.Transport = transport
+	return proxy, nil
+}
+
+func (d *daemonCache) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/daemon/status", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, d.currentStatus())
+	})
+	mux.HandleFunc("/daemon/shutdown", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeJSONError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "stopping"})
+		if d.shutdown != nil {
+			go d.shutdown()
+		}
+	})
+	mux.HandleFunc("/clusters/", d.serveCluster)
+	return mux
+}
+
+// currentStatus reports the clusters and the state of their caches
+func (d *daemonCache) currentStatus() daemonStatus {
+	status := d.status
+	status.Clusters = []daemonClusterState{}
+	for _, name := range sortedKeys(d.clusters) {
+		cc := d.clusters[name]
+		state := daemonClusterState{Name: name, Context: cc.info.Context, ITS: cc.info.ITS, Resources: []daemonResourceState{}}
+		for _, res := range cc.resources {
+			state.Resources = append(state.Resources, daemonResourceState{
+				Resource: res.gvr.GroupResource().String(),
+				Synced:   res.informer.HasSynced(),
+				Objects:  len(res.informer.GetStore().ListKeys()),
+			})
+		}
+		sort.Slice(state.Resources, func(i, j int) bool { return state.Resources[i].Resource < state.Resources[j].Resource })
+		status.Clusters = append(status.Clusters, state)
+	}
+	return status
+}
+
+// serveCluster answers /clusters/NAME/API-PATH from the cache of the
+// cluster when it can, and forwards the request to the cluster otherwise
+func (d *daemonCache) serveCluster(w http.ResponseWriter, r *http.Request) {
+	escapedName, apiPath, _ := strings.Cut(strings.TrimPrefix(r.URL.EscapedPath(), "/clusters/"), "/")
+	name, err := url.PathUnescape(escapedName)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	cc, ok := d.clusters[name]
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, fmt.Errorf("cluster %s is not cached by this daemon", name))
+		return
+	}
+	apiPath = "/" + apiPath
+	if cc.serveFromCache(w, r, apiPath) {
+		return
+	}
+	if cc.proxy == nil {
+		writeJSONError(w, http.StatusBadGateway, fmt.Errorf("no connection to cluster %s", name))
+		return
+	}
+	forwarded := r.Clone(r.Context())
+	forwarded.URL.Path, forwarded.URL.RawPath = apiPath, ""
+	if unescaped, err := url.PathUnescape(apiPath); err == nil {
+		forwarded.URL.Path, forwarded.URL.RawPath = unescaped, apiPath
+	}
+	forwarded.RequestURI = ""
+	forwarded.Host = ""
+	cc.proxy.ServeHTTP(w, forwarded)
+}
+
+// serveFromCache answers a list or get of a cached resource type and
+// reports whether it did. Watches, field selectors, subresources, table and
+// protobuf requests, caches that have not synced and objects missing from
+// the cache are left to the cluster.
+func (cc *cachedCluster) serveFromCache(w http.ResponseWriter, r *http.Request, apiPath string) bool {
+	query := r.URL.Query()
+	if r.Method != http.MethodGet || query.Get("watch") == "true" || query.Get("fieldSelector") != "" {
+		return false
+	}
+	if accept := r.Header.Get("Accept"); accept != "" && (!strings.Contains(accept, "json") && !strings.Contains(accept, "*/*") || strings.Contains(accept, "as=Table")) {
+		return false
+	}
+	gvr, namespace, name, ok := parseAPIPath(apiPath)
+	if !ok {
+		return false
+	}
+	res, ok := cc.resources[gvr]
+	if !ok || !res.informer.HasSynced() {
+		return false
+	}
+
+	if name != "" {
+		key := name
+		if namespace != "" {
+			key = namespace + "/" + name
+		}
+		obj, exists, err := res.informer.GetStore().GetByKey(key)
+		if err != nil || !exists {
+			return false
+		}
+		writeJSON(w, http.StatusOK, obj)
+		return true
+	}
+
+	selector, err := labels.Parse(query.Get("labelSelector"))
+	if err != nil {
+		return false
+	}
+	items := []interface{}{}
+	for _, obj := range res.informer.GetStore().List() {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok || (namespace != "" && u.GetNamespace() != namespace) || !selector.Matches(labels.Set(u.GetLabels())) {
+			continue
+		}
+		items = append(items, u.Object)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		a, b := items[i].(map[string]interface{}), items[j].(map[string]interface{})
+		return objectKey(a) < objectKey(b)
+	})
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"apiVersion": gvr.GroupVersion().String(),
+		"kind":       res.listKind,
+		"metadata":   map[string]interface{}{"resourceVersion": res.informer.LastSyncResourceVersion()},
+		"items":      items,
+	})
+	return true
+}
+
+// objectKey orders objects by namespace and name, as the API server lists them
+func objectKey(obj map[string]interface{}) string {
+	u := unstructured.Unstructured{Object: obj}
+	return u.GetNamespace() + "/" + u.GetName()
+}
+
+// parseAPIPath splits the path of a list or get request, such as
+// /api/v1/namespaces/web/pods or /apis/apps/v1/deployments, into the
+// resource, the namespace and the object name. ok is false for other
+// requests, such as discovery and subresources.
+func parseAPIPath(apiPath string) (gvr schema.GroupVersionResource, namespace, name string, ok bool) {
+	parts := strings.Split(strings.Trim(apiPath, "/"), "/")
+	switch {
+	case len(parts) >= 3 && parts[0] == "api":
+		gvr.Version, parts = parts[1], parts[2:]
+	case len(parts) >= 4 && parts[0] == "apis":
+		gvr.Group, gvr.Version, parts = parts[1], parts[2], parts[3:]
+	default:
+		return gvr, "", "", false
+	}
+	if len(parts) >= 3 && parts[0] == "namespaces" {
+		namespace, parts = parts[1], parts[2:]
+	}
+	switch len(parts) {
+	case 1:
+		gvr.Resource = parts[0]
+	case 2:
+		gvr.Resource, name = parts[0], parts[1]
+	default:
+		return gvr, "", "", false
+	}
+	return gvr, namespace, name, true
+}
+
+// daemonClient talks to a running daemon over its socket
+type daemonClient struct {
+	socket string
+	http   *http.Client
+}
+
+func newDaemonClient(socket string) *daemonClient {
+	return &daemonClient{socket: socket, http: &http.Client{Transport: &http.Transport{DialContext: dialSocket(socket)}}}
+}
+
+// status asks the daemon for its status, failing when none is listening
+func (c *daemonClient) status(ctx context.Context) (daemonStatus, error) {
+	var status daemonStatus
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, daemonURL+"/daemon/status", nil)
+	if err != nil {
+		return status, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return status, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return status, fmt.Errorf("daemon answered %s", resp.Status)
+	}
+	return status, json.NewDecoder(resp.Body).Decode(&status)
+}
+
+// stop asks the daemon to shut down
+func (c *daemonClient) stop(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, daemonURL+"/daemon/shutdown", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("daemon answered %s", resp.Status)
+	}
+	return nil
+}
+
+// clusters returns the clusters the daemon caches, with clients whose
+// requests go through the daemon
+func (c *daemonClient) clusters(status daemonStatus) ([]cluster.ClusterInfo, error) {
+	var clusters []cluster.ClusterInfo
+	for _, state := range status.Clusters {
+		config := &rest.Config{
+			Host:  daemonURL + "/clusters/" + url.PathEscape(state.Name),
+			Dial:  dialSocket(c.socket),
+			QPS:   -1,
+			Burst: -1,
+		}
+		info, err := cluster.ClientForConfig(state.Name, state.Context, config)
+		if err != nil {
+			return nil, err
+		}
+		info.ITS = state.ITS
+		clusters = append(clusters, *info)
+	}
+	return clusters, nil
+}
+
+// daemonClusters returns the clusters of a running daemon caching the fleet
+// the global flags choose. ok is false when there is no such daemon, and
+// the clusters are then discovered as usual.
+func daemonClusters(socket string) (clusters []cluster.ClusterInfo, ok bool) {
+	client := newDaemonClient(socket)
+	ctx, cancel := context.WithTimeout(commandContext(), time.Second)
+	defer cancel()
+	status, err := client.status(ctx)
+	if err != nil || !status.Config.equal(currentDaemonConfig()) {
+		return nil, false
+	}
+	clusters, err = client.clusters(status)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: not using the daemon: %v\n", err)
+		return nil, false
+	}
+	return clusters, true
+}
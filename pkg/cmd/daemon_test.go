@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+
+	"kubectl-multi/pkg/cluster"
+)
+
+func TestParseAPIPath(t *testing.T) {
+	tests := []struct {
+		path          string
+		wantGVR       schema.GroupVersionResource
+		wantNamespace string
+		wantName      string
+		wantOK        bool
+	}{
+		{path: "/api/v1/pods", wantGVR: schema.GroupVersionResource{Version: "v1", Resource: "pods"}, wantOK: true},
+		{path: "/api/v1/namespaces/web/pods", wantGVR: schema.GroupVersionResource{Version: "v1", Resource: "pods"}, wantNamespace: "web", wantOK: true},
+		{path: "/api/v1/namespaces/web/pods/nginx", wantGVR: schema.GroupVersionResource{Version: "v1", Resource: "pods"}, wantNamespace: "web", wantName: "nginx", wantOK: true},
+		{path: "/api/v1/namespaces/web", wantGVR: schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}, wantName: "web", wantOK: true},
+		{path: "/apis/apps/v1/deployments", wantGVR: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, wantOK: true},
+		{path: "/api/v1/namespaces/web/pods/nginx/log"},
+		{path: "/apis/apps/v1"},
+		{path: "/version"},
+	}
+	for _, tt := range tests {
+		gvr, namespace, name, ok := parseAPIPath(tt.path)
+		if ok != tt.wantOK || (ok && (gvr != tt.wantGVR || namespace != tt.wantNamespace || name != tt.wantName)) {
+			t.Errorf("parseAPIPath(%q) = %v %q %q %t, want %v %q %q %t", tt.path, gvr, namespace, name, ok, tt.wantGVR, tt.wantNamespace, tt.wantName, tt.wantOK)
+		}
+	}
+}
+
+func TestDaemonServesFromCache(t *testing.T) {
+	t.Setenv("KUBECACHEDIR", t.TempDir())
+	web := testConfigMap("web", "v1")
+	web.SetLabels(map[string]string{"app": "web"})
+	c, dyn := testClusterInfo(web, testConfigMap("db", "v1"))
+	c.Context = "cluster1"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d := newDaemonCache(ctx, []cluster.ClusterInfo{c}, []string{"configmaps"}, currentDaemonConfig(), cancel)
+	for _, res := range d.clusters["cluster1"].resources {
+		if !cache.WaitForCacheSync(ctx.Done(), res.informer.HasSynced) {
+			t.Fatal("cache did not sync")
+		}
+	}
+
+	socket := filepath.Join(t.TempDir(), "d.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: d.handler()}
+	go server.Serve(listener)
+	defer server.Close()
+
+	clusters, ok := daemonClusters(socket)
+	if !ok || len(clusters) != 1 || clusters[0].Name != "cluster1" || clusters[0].Context != "cluster1" {
+		t.Fatalf("daemonClusters() = %v, %t", clusters, ok)
+	}
+	dyn.ClearActions()
+
+	list, err := clusters[0].Client.CoreV1().ConfigMaps("default").List(context.Background(), metav1.ListOptions{LabelSelector: "app=web"})
+	if err != nil {
+		t.Fatalf("list through the daemon: %v", err)
+	}
+	if len(list.Items) != 1 || list.Items[0].Name != "web" || list.Items[0].Data["key"] != "v1" {
+		t.Errorf("listed %v, want configmap web", list.Items)
+	}
+	cm, err := clusters[0].Client.CoreV1().ConfigMaps("default").Get(context.Background(), "db", metav1.GetOptions{})
+	if err != nil || cm.Name != "db" {
+		t.Errorf("get through the daemon = %v, %v", cm, err)
+	}
+	if actions := dyn.Actions(); len(actions) != 0 {
+		t.Errorf("the daemon called the cluster for cached objects: %v", actions)
+	}
+
+	// Deployments are not cached and the test cluster has no API server to forward to
+	if _, err := clusters[0].Client.AppsV1().Deployments("default").List(context.Background(), metav1.ListOptions{}); err == nil {
+		t.Errorf("list of an uncached type without a cluster connection succeeded")
+	}
+
+	status, err := newDaemonClient(socket).status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status.Clusters) != 1 || len(status.Clusters[0].Resources) != 1 || status.Clusters[0].Resources[0] != (daemonResourceState{Resource: "configmaps", Synced: true, Objects: 2}) {
+		t.Errorf("status = %+v", status)
+	}
+
+	if err := newDaemonClient(socket).stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Errorf("stop did not shut the daemon down")
+	}
+}
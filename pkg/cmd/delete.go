@@ -2,10 +2,22 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"strings"
 
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
 	"kubectl-multi/pkg/util"
 
 	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
 )
 
 // Custom help function for delete command
@@ -14,7 +26,7 @@ func deleteHelpFunc(cmd *cobra.Command, args []string) {
 	cmdInfo, err := util.GetKubectlCommandInfo("delete")
 	if err != nil {
 		// Fallback to default help if kubectl help is not available
-		cmd.Help()
+		defaultHelpFunc(cmd, args)
 		return
 	}
 
@@ -35,8 +47,8 @@ kubectl multi delete -f deployment.yaml
 # Delete all pods in all clusters
 kubectl multi delete pods --all
 
-# Delete with force flag across all clusters
-kubectl multi delete pod nginx --force`
+# Delete copies that a BindingPolicy propagated, although the next downsync recreates them
+kubectl multi delete deployment nginx --include-propagated`
 
 	// Multi-cluster usage
 	multiClusterUsage := `kubectl multi delete [TYPE[.VERSION][.GROUP] [NAME | -l label] | TYPE[.VERSION][.GROUP]/NAME ...] [flags]`
@@ -46,71 +58,331 @@ kubectl multi delete pod nginx --force`
 	fmt.Fprintln(cmd.OutOrStdout(), combinedHelp)
 }
 
+// deleteOptions holds the flags of delete
+type deleteOptions struct {
+	Filename          string
+	Recursive         bool
+	Selector          string
+	All               bool
+	DryRun            string
+	IgnoreNotFound    bool
+	IncludePropagated bool
+	Targets           clusterTargets
+}
+
+// deleteTarget is one live object delete removes from a cluster
+type deleteTarget struct {
+	gvr  schema.GroupVersionResource
+	live *unstructured.Unstructured
+}
+
+// ref names the object the way kubectl reports it
+func (t deleteTarget) ref() string {
+	return qualifiedName(t.gvr, t.live)
+}
+
+// deleteSelection is what the arguments of delete select in every cluster:
+// manifest objects, or a resource type with names, a selector or --all
+type deleteSelection struct {
+	objs         []*unstructured.Unstructured
+	resourceType string
+	names        []string
+}
+
 func newDeleteCommand() *cobra.Command {
+	var o deleteOptions
+
 	cmd := &cobra.Command{
-		Use:   "delete [TYPE[.VERSION][.GROUP] [NAME | -l label] | TYPE[.VERSION][.GROUP]/NAME ...]",
+		Use:   "delete ([-f FILENAME] | TYPE [(NAME | -l label | --all)] | TYPE/NAME ...)",
 		Short: "Delete resources across all managed clusters",
+		Long: `Delete resources across all managed clusters.
+
+Objects a BindingPolicy propagated to a cluster are recreated by the next
+downsync, so delete refuses to remove them and deletes nothing when any
+selected object is such a copy. Delete the object from the WDS or change the
+BindingPolicy instead, or pass --include-propagated to delete the copies
+anyway.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return fmt.Errorf("delete command not yet implemented")
+			sel, err := parseDeleteArgs(args, o)
+			if err != nil {
+				return err
+			}
+			if err := util.ValidateDryRun(o.DryRun); err != nil {
+				return err
+			}
+			kubeconfig, remoteCtx, _, namespace, allNamespaces := GetGlobalFlags()
+			var rec *audit.Recorder
+			if o.DryRun == util.DryRunNone || o.DryRun == "" {
+				rec = startAudit("delete")
+			}
+			err = handleDeleteCommand(sel, o, rec, kubeconfig, remoteCtx, namespace, allNamespaces)
+			finishAudit(rec, err)
+			return err
 		},
 	}
 
+	cmd.Flags().StringVarP(&o.Filename, "filename", "f", "", "filename, directory, or URL to files containing the resources to delete")
+	cmd.Flags().BoolVarP(&o.Recursive, "recursive", "R", false, "process the directory used in -f, --filename recursively")
+	cmd.Flags().StringVarP(&o.Selector, "selector", "l", "", "label selector of the objects to delete")
+	cmd.Flags().BoolVar(&o.All, "all", false, "delete all resources of the type in the namespace")
+	cmd.Flags().StringVar(&o.DryRun, "dry-run", "none", "must be \"none\", \"server\", or \"client\"")
+	cmd.Flags().BoolVar(&o.IgnoreNotFound, "ignore-not-found", false, "treat a missing object as successfully deleted")
+	cmd.Flags().BoolVar(&o.IncludePropagated, "include-propagated", false, "also delete objects a BindingPolicy propagated to the clusters, although the next downsync recreates them")
+	o.Targets.addFlags(cmd, "delete from")
+
 	// Set custom help function
 	cmd.SetHelpFunc(deleteHelpFunc)
 
 	return cmd
 }
 
-func newExecCommand() *cobra.Command {
-	cmd := &cobra.Command{
-		Use:   "exec POD [-c CONTAINER] -- COMMAND [args...]",
-		Short: "Execute a command in a container across managed clusters",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return fmt.Errorf("exec command not yet implemented")
-		},
+// parseDeleteArgs checks that the arguments and flags select objects in one
+// of the ways kubectl delete accepts
+func parseDeleteArgs(args []string, o deleteOptions) (deleteSelection, error) {
+	var sel deleteSelection
+	if o.Filename != "" {
+		if len(args) > 0 || o.Selector != "" || o.All {
+			return sel, fmt.Errorf("-f cannot be combined with resource arguments, -l or --all")
+		}
+		objs, err := util.ReadManifests(o.Filename, o.Recursive)
+		if err != nil {
+			return sel, err
+		}
+		if len(objs) == 0 {
+			return sel, fmt.Errorf("no objects found in %s", o.Filename)
+		}
+		sel.objs = objs
+		return sel, nil
 	}
-	return cmd
+	if len(args) == 0 {
+		return sel, fmt.Errorf("you must provide one or more resources by argument or filename")
+	}
+	if o.Selector != "" && o.All {
+		return sel, fmt.Errorf("--all and -l cannot be combined")
+	}
+
+	if strings.Contains(args[0], "/") {
+		for _, arg := range args {
+			resourceType, name, err := parseTypeName([]string{arg})
+			if err != nil {
+				return sel, err
+			}
+			if sel.resourceType != "" && resourceType != sel.resourceType {
+				return sel, fmt.Errorf("all TYPE/NAME arguments must be of the same type, got %s and %s", sel.resourceType, resourceType)
+			}
+			sel.resourceType = resourceType
+			sel.names = append(sel.names, name)
+		}
+	} else {
+		sel.resourceType, sel.names = args[0], args[1:]
+	}
+	switch {
+	case len(sel.names) > 0 && (o.Selector != "" || o.All):
+		return sel, fmt.Errorf("names cannot be combined with -l or --all")
+	case len(sel.names) == 0 && o.Selector == "" && !o.All:
+		return sel, fmt.Errorf("resource(s) were provided, but no name was specified; use -l or --all to select objects by label or all of them")
+	}
+	return sel, nil
 }
 
-func newCreateCommand() *cobra.Command {
-	cmd := &cobra.Command{
-		Use:   "create -f FILENAME",
-		Short: "Create a resource from a file or from stdin across managed clusters",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return fmt.Errorf("create command not yet implemented")
-		},
+func handleDeleteCommand(sel deleteSelection, o deleteOptions, rec *audit.Recorder, kubeconfig, remoteCtx, namespace string, allNamespaces bool) error {
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
 	}
-	return cmd
+	clusters, err = o.Targets.selectFrom(clusters, kubeconfig)
+	if err != nil {
+		return err
+	}
+	if len(clusters) == 0 {
+		return fmt.Errorf("no clusters discovered")
+	}
+	return deleteClusters(clusters, remoteCtx, sel, o, rec, namespace, allNamespaces, os.Stdout)
 }
 
-func newEditCommand() *cobra.Command {
-	cmd := &cobra.Command{
-		Use:   "edit [TYPE[.VERSION][.GROUP]/]NAME",
-		Short: "Edit a resource on the server across managed clusters",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return fmt.Errorf("edit command not yet implemented")
-		},
+// deleteClusters resolves the selection in every cluster but the ITS first,
+// and deletes nothing when it selects propagated objects without
+// --include-propagated. It then deletes the objects and prints each
+// cluster's result.
+func deleteClusters(clusters []cluster.ClusterInfo, itsContext string, sel deleteSelection, o deleteOptions, rec *audit.Recorder, namespace string, allNamespaces bool, out io.Writer) error {
+	targets := make([][]deleteTarget, len(clusters))
+	resolveErrs := make([][]error, len(clusters))
+	var propagated []string
+	for i, c := range clusters {
+		if c.Context == itsContext {
+			continue
+		}
+		targets[i], resolveErrs[i] = resolveDeleteTargets(c, sel, o, namespace, allNamespaces)
+		for _, t := range targets[i] {
+			if owner, managed := kubestellar.DownsyncOwnerOf(t.live); managed {
+				propagated = append(propagated, kubestellar.DownsyncDeleteWarning(t.ref(), c.Name, owner))
+			}
+		}
 	}
-	return cmd
+	if len(propagated) > 0 && !o.IncludePropagated {
+		return fmt.Errorf("nothing was deleted:\n  %s\nuse --include-propagated to delete the propagated copies anyway", strings.Join(propagated, "\n  "))
+	}
+	radius := blastRadius{}
+	namespaces := map[string]bool{}
+	for _, ts := range targets {
+		radius.Objects += len(ts)
+		for _, t := range ts {
+			if ns := t.live.GetNamespace(); ns != "" {
+				namespaces[ns] = true
+			}
+		}
+	}
+	radius.Namespaces = sortedKeys(namespaces)
+	if err := guardFanOut(rec, radius, clusters, itsContext); err != nil {
+		return err
+	}
+
+	trackFanOut(rec, fanOutTargets(clusters, itsContext))
+	failed, tried := 0, 0
+	for i, c := range clusters {
+		fmt.Fprintf(out, "=== Cluster: %s ===\n", c.Context)
+		if c.Context == itsContext {
+			fmt.Fprintf(out, "Cannot perform this operation on ITS (control) cluster: %s\n\n", c.Context)
+			continue
+		}
+		tried++
+		rec.Begin(c.Name)
+		errs := resolveErrs[i]
+		for _, err := range errs {
+			fmt.Fprintf(out, "Error: %v\n", err)
+		}
+		for _, t := range targets[i] {
+			if owner, managed := kubestellar.DownsyncOwnerOf(t.live); managed {
+				fmt.Fprintf(out, "Warning: %s\n", kubestellar.DownsyncDeleteWarning(t.ref(), c.Name, owner))
+			}
+			result, err := deleteTargetObject(c, t, o.DryRun, rec)
+			if err != nil {
+				errs = append(errs, err)
+				fmt.Fprintf(out, "Error: %v\n", err)
+				continue
+			}
+			fmt.Fprintln(out, result)
+		}
+		if len(targets[i]) == 0 && len(errs) == 0 {
+			fmt.Fprintln(out, "No resources found")
+		}
+		if len(errs) > 0 {
+			failed++
+			rec.Record(c.Name, errs[0])
+		} else {
+			rec.Record(c.Name, nil)
+		}
+		fmt.Fprintln(out)
+	}
+	if failed > 0 {
+		return fmt.Errorf("delete failed in %d of %d clusters", failed, tried)
+	}
+	return nil
 }
 
-func newPatchCommand() *cobra.Command {
-	cmd := &cobra.Command{
-		Use:   "patch [TYPE[.VERSION][.GROUP]/]NAME --patch PATCH",
-		Short: "Update field(s) of a resource across managed clusters",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return fmt.Errorf("patch command not yet implemented")
-		},
+// resolveDeleteTargets reads the objects the selection names in one cluster.
+// Objects that cannot be read are returned as errors, except missing objects
+// with --ignore-not-found.
+func resolveDeleteTargets(c cluster.ClusterInfo, sel deleteSelection, o deleteOptions, namespace string, allNamespaces bool) ([]deleteTarget, []error) {
+	if c.DynamicClient == nil || c.DiscoveryClient == nil {
+		return nil, []error{fmt.Errorf("no clients available for cluster %s", c.Name)}
 	}
-	return cmd
+	var targets []deleteTarget
+	var errs []error
+	get := func(gvr schema.GroupVersionResource, namespaced bool, ns, name string) {
+		var client dynamic.ResourceInterface = c.DynamicClient.Resource(gvr)
+		if namespaced {
+			client = c.DynamicClient.Resource(gvr).Namespace(ns)
+		}
+		live, err := client.Get(commandContext(), name, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err) && o.IgnoreNotFound:
+		case err != nil:
+			errs = append(errs, err)
+		default:
+			targets = append(targets, deleteTarget{gvr: gvr, live: live})
+		}
+	}
+
+	if sel.objs != nil {
+		mapper := c.Mapper()
+		for _, obj := range sel.objs {
+			gvk := obj.GroupVersionKind()
+			mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			namespaced := mapping.Scope.Name() == meta.RESTScopeNameNamespace
+			ns := obj.GetNamespace()
+			if ns == "" {
+				ns = cluster.GetTargetNamespace(namespace)
+			}
+			get(mapping.Resource, namespaced, ns, obj.GetName())
+		}
+		return targets, errs
+	}
+
+	gvr, namespaced, err := util.DiscoverGVR(c.DiscoveryClient, sel.resourceType)
+	if err != nil {
+		return nil, []error{err}
+	}
+	ns := cluster.GetTargetNamespace(namespace)
+	if len(sel.names) > 0 {
+		for _, name := range sel.names {
+			get(gvr, namespaced, ns, name)
+		}
+		return targets, errs
+	}
+
+	var client dynamic.ResourceInterface = c.DynamicClient.Resource(gvr)
+	if namespaced {
+		if allNamespaces {
+			ns = ""
+		}
+		client = c.DynamicClient.Resource(gvr).Namespace(ns)
+	}
+	list, err := client.List(commandContext(), metav1.ListOptions{LabelSelector: o.Selector})
+	if err != nil {
+		return nil, []error{err}
+	}
+	for i := range list.Items {
+		targets = append(targets, deleteTarget{gvr: gvr, live: &list.Items[i]})
+	}
+	return targets, nil
+}
+
+// deleteTargetObject deletes one object, recording how to restore it, and
+// returns a kubectl-style result line
+func deleteTargetObject(c cluster.ClusterInfo, t deleteTarget, dryRun string, rec *audit.Recorder) (string, error) {
+	if dryRun == util.DryRunClient {
+		return t.ref() + " deleted (dry run)", nil
+	}
+	var client dynamic.ResourceInterface = c.DynamicClient.Resource(t.gvr)
+	if ns := t.live.GetNamespace(); ns != "" {
+		client = c.DynamicClient.Resource(t.gvr).Namespace(ns)
+	}
+	policy := metav1.DeletePropagationBackground
+	opts := metav1.DeleteOptions{PropagationPolicy: &policy}
+	if dryRun == util.DryRunServer {
+		opts.DryRun = []string{metav1.DryRunAll}
+	}
+	if err := client.Delete(commandContext(), t.live.GetName(), opts); err != nil {
+		return "", err
+	}
+	if dryRun == util.DryRunServer {
+		return t.ref() + " deleted (server dry run)", nil
+	}
+	recordRestoreUndo(rec, c.Name, t.gvr, t.live.GetNamespace(), t.live, util.DryRunNone)
+	return t.ref() + " deleted", nil
 }
 
-func newScaleCommand() *cobra.Command {
+func newExecCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "scale [TYPE[.VERSION][.GROUP]/]NAME --replicas=COUNT",
-		Short: "Set a new size for a deployment, replica set, or stateful set across managed clusters",
+		Use:   "exec POD [-c CONTAINER] -- COMMAND [args...]",
+		Short: "Execute a command in a container across managed clusters",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return fmt.Errorf("scale command not yet implemented")
+			return fmt.Errorf("exec command not yet implemented")
 		},
 	}
 	return cmd
@@ -126,14 +398,3 @@ func newPortForwardCommand() *cobra.Command {
 	}
 	return cmd
 }
-
-func newTopCommand() *cobra.Command {
-	cmd := &cobra.Command{
-		Use:   "top [TYPE]",
-		Short: "Display resource (CPU/memory/storage) usage across managed clusters",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return fmt.Errorf("top command not yet implemented")
-		},
-	}
-	return cmd
-}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+	"kubectl-multi/pkg/util"
+)
+
+func TestParseDeleteArgs(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		opts      deleteOptions
+		wantType  string
+		wantNames []string
+		wantErr   bool
+	}{
+		{name: "type and names", args: []string{"configmap", "a", "b"}, wantType: "configmap", wantNames: []string{"a", "b"}},
+		{name: "type/name", args: []string{"configmap/a", "configmap/b"}, wantType: "configmap", wantNames: []string{"a", "b"}},
+		{name: "selector", args: []string{"configmap"}, opts: deleteOptions{Selector: "app=web"}, wantType: "configmap"},
+		{name: "all", args: []string{"configmap"}, opts: deleteOptions{All: true}, wantType: "configmap"},
+		{name: "mixed types", args: []string{"configmap/a", "secret/b"}, wantErr: true},
+		{name: "type without names", args: []string{"configmap"}, wantErr: true},
+		{name: "names and selector", args: []string{"configmap", "a"}, opts: deleteOptions{Selector: "app=web"}, wantErr: true},
+		{name: "selector and all", args: []string{"configmap"}, opts: deleteOptions{Selector: "app=web", All: true}, wantErr: true},
+		{name: "nothing", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sel, err := parseDeleteArgs(tt.args, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDeleteArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if sel.resourceType != tt.wantType || strings.Join(sel.names, ",") != strings.Join(tt.wantNames, ",") {
+				t.Errorf("parseDeleteArgs() = %s %v, want %s %v", sel.resourceType, sel.names, tt.wantType, tt.wantNames)
+			}
+		})
+	}
+}
+
+func TestDeleteClusters(t *testing.T) {
+	tests := []struct {
+		name      string
+		sel       deleteSelection
+		opts      deleteOptions
+		propagate bool
+		missing   bool
+		wantLeft  int
+		wantLines []string
+		wantUndo  int
+		wantErr   string
+	}{
+		{
+			name:      "by name",
+			sel:       deleteSelection{resourceType: "configmaps", names: []string{"app"}},
+			wantLines: []string{"=== Cluster: cluster1 ===\nconfigmap/app deleted\n", "=== Cluster: cluster2 ===\nconfigmap/app deleted\n"},
+			wantUndo:  2,
+		},
+		{
+			name:      "by selector",
+			sel:       deleteSelection{resourceType: "configmaps"},
+			opts:      deleteOptions{Selector: "app=web"},
+			wantLines: []string{"=== Cluster: cluster1 ===\nconfigmap/app deleted\n"},
+			wantUndo:  2,
+		},
+		{
+			name:      "all",
+			sel:       deleteSelection{resourceType: "configmaps"},
+			opts:      deleteOptions{All: true},
+			wantLines: []string{"configmap/app deleted\n", "configmap/other deleted\n"},
+			wantUndo:  4,
+		},
+		{
+			name:      "client dry run",
+			sel:       deleteSelection{resourceType: "configmaps", names: []string{"app"}},
+			opts:      deleteOptions{DryRun: util.DryRunClient},
+			wantLeft:  2,
+			wantLines: []string{"configmap/app deleted (dry run)\n"},
+		},
+		{
+			name:      "missing in one cluster",
+			sel:       deleteSelection{resourceType: "configmaps", names: []string{"app"}},
+			missing:   true,
+			wantLines: []string{"=== Cluster: cluster2 ===\nError: configmaps \"app\" not found\n"},
+			wantUndo:  1,
+			wantErr:   "delete failed in 1 of 2 clusters",
+		},
+		{
+			name:      "missing and ignored",
+			sel:       deleteSelection{resourceType: "configmaps", names: []string{"app"}},
+			opts:      deleteOptions{IgnoreNotFound: true},
+			missing:   true,
+			wantLines: []string{"=== Cluster: cluster2 ===\nNo resources found\n"},
+			wantUndo:  1,
+		},
+		{
+			name:      "propagated object",
+			sel:       deleteSelection{resourceType: "configmaps", names: []string{"app"}},
+			propagate: true,
+			wantLeft:  2,
+			wantErr:   "configmap/app in cluster cluster2 was propagated by KubeStellar (Binding nginx-bpolicy); the next downsync recreates it",
+		},
+		{
+			name:      "propagated object included",
+			sel:       deleteSelection{resourceType: "configmaps", names: []string{"app"}},
+			opts:      deleteOptions{IncludePropagated: true},
+			propagate: true,
+			wantLines: []string{"=== Cluster: cluster2 ===\nWarning: configmap/app in cluster cluster2 was propagated by KubeStellar (Binding nginx-bpolicy)", "configmap/app deleted\n"},
+			wantUndo:  2,
+		},
+	}
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	// Without a terminal the deletes need confirming up front
+	defer func(yes bool) { assumeYes = yes }(assumeYes)
+	assumeYes = true
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.opts.DryRun == "" {
+				tt.opts.DryRun = util.DryRunNone
+			}
+			app1, app2 := testConfigMap("app", "old"), testConfigMap("app", "old")
+			app1.SetLabels(map[string]string{"app": "web"})
+			labels := map[string]string{"app": "web"}
+			if tt.propagate {
+				labels[kubestellar.OriginBindingLabel] = "nginx-bpolicy"
+			}
+			app2.SetLabels(labels)
+			objs := []runtime.Object{app2, testConfigMap("other", "old")}
+			if tt.missing {
+				objs = objs[1:]
+			}
+			c1, dyn1 := testClusterInfo(app1, testConfigMap("other", "old"))
+			c2, dyn2 := testClusterInfo(objs...)
+			its, _ := testClusterInfo()
+			c1.Context = "cluster1"
+			c2.Name, c2.Context = "cluster2", "cluster2"
+			its.Name, its.Context = "its1", "its1"
+
+			var out bytes.Buffer
+			rec := audit.Start("delete", nil)
+			err := deleteClusters([]cluster.ClusterInfo{c1, c2, its}, "its1", tt.sel, tt.opts, rec, "default", false, &out)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("deleteClusters() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("deleteClusters() error = %v", err)
+			}
+			for _, line := range tt.wantLines {
+				if !strings.Contains(out.String(), line) {
+					t.Errorf("output %q does not contain %q", out.String(), line)
+				}
+			}
+			if got := len(rec.Finish(err).Undo); got != tt.wantUndo {
+				t.Errorf("undo steps = %d, want %d", got, tt.wantUndo)
+			}
+
+			left := 0
+			for _, dyn := range []*fakedynamic.FakeDynamicClient{dyn1, dyn2} {
+				if _, err := dyn.Resource(gvr).Namespace("default").Get(commandContext(), "app", metav1.GetOptions{}); err == nil {
+					left++
+				} else if !apierrors.IsNotFound(err) {
+					t.Fatal(err)
+				}
+			}
+			if left != tt.wantLeft {
+				t.Errorf("clusters still with configmap/app = %d, want %d", left, tt.wantLeft)
+			}
+		})
+	}
+}
@@ -8,6 +8,9 @@ import (
 
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/network"
 )
 
 type InstallOptions struct {
@@ -97,7 +100,36 @@ Examples:
 			if err := o.Validate(); err != nil {
 				return err
 			}
-			return o.Run(cmd.Context())
+			if o.DryRun {
+				return o.Run(cmd.Context())
+			}
+			rec := startAudit("install")
+			if err := acquireFleetLock(rec); err != nil {
+				finishAudit(rec, err)
+				return err
+			}
+			kubeconfig, _, _, _, _ := GetGlobalFlags()
+			hostContext := currentContextName(kubeconfig)
+			revision := helmReleaseRevision(o.ReleaseName, o.Namespace, hostContext, kubeconfig)
+			err := o.Run(cmd.Context())
+			rec.Record(hostContext, err)
+			if err == nil {
+				step := audit.UndoStep{
+					Cluster:    hostContext,
+					Action:     audit.UndoHelmUninstall,
+					Namespace:  o.Namespace,
+					Name:       o.ReleaseName,
+					Context:    hostContext,
+					Kubeconfig: kubeconfig,
+				}
+				if revision > 0 {
+					step.Action = audit.UndoHelmRollback
+					step.Revision = revision
+				}
+				rec.AddUndo(step)
+			}
+			finishAudit(rec, err)
+			return err
 		},
 	}
 
@@ -167,10 +199,14 @@ func (o *InstallOptions) Run(ctx context.Context) error {
 		return nil
 	}
 
-	if o.ChartPath != "" {
-		if err := o.updateHelmDependencies(ctx); err != nil {
-			return fmt.Errorf("failed to update helm dependencies: %w", err)
+	if o.ChartPath == "" {
+		if err := network.CheckOnline("pulling the KubeStellar chart from ghcr.io"); err != nil {
+			return fmt.Errorf("%v; install from a local copy of the chart with --chart-path", err)
 		}
+	} else if network.Offline() {
+		fmt.Fprintf(o.Out, "Offline mode: not updating helm dependencies, %s must include them in charts/\n", o.ChartPath)
+	} else if err := o.updateHelmDependencies(ctx); err != nil {
+		return fmt.Errorf("failed to update helm dependencies: %w", err)
 	}
 
 	cmd := exec.CommandContext(ctx, "helm", args...)
@@ -180,7 +216,8 @@ func (o *InstallOptions) Run(ctx context.Context) error {
 
 	fmt.Fprintf(o.Out, "Executing: helm %s\n", strings.Join(args, " "))
 
-	if err := cmd.Run(); err != nil {
+	// helm installs into the current context of the default kubeconfig
+	if err := runObserved(cmd, currentContextName("")); err != nil {
 		return fmt.Errorf("helm command failed: %w", err)
 	}
 
@@ -320,7 +357,7 @@ func (o *InstallOptions) updateHelmDependencies(ctx context.Context) error {
 	cmd.Stdout = o.Out
 	cmd.Stderr = o.ErrOut
 
-	return cmd.Run()
+	return runObserved(cmd, localSubprocess)
 }
 
 func (o *InstallOptions) printPostInstallInstructions() {
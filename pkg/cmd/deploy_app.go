@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+	"kubectl-multi/pkg/util"
+)
+
+// deployOptions holds the flags of deploy
+type deployOptions struct {
+	Filename       string
+	Recursive      bool
+	PolicyName     string
+	ClusterLabels  string
+	DryRun         string
+	ForceConflicts bool
+}
+
+func newDeployCommand() *cobra.Command {
+	var o deployOptions
+
+	cmd := &cobra.Command{
+		Use:   "deploy NAME -f FILENAME --cluster-labels SELECTOR",
+		Short: "Apply an application to the WDS and create the BindingPolicy placing it",
+		Long: `Deploy an application the KubeStellar way in one step: label every object of
+the manifests with ` + kubestellar.AppLabel + `=NAME, server-side apply
+them to the WDS (the --wds-context, or every WDS given with --wds) and apply a
+BindingPolicy that downsyncs the objects carrying that label to the
+ManagedClusters matching --cluster-labels.
+
+Running deploy again with changed manifests or labels updates the objects and
+the policy in place. The objects and the policy are recorded in the audit
+history, so 'kubectl multi undo' reverses the whole deployment.`,
+		Example: `# Place the app on the production clusters
+kubectl multi deploy app -f app.yaml --policy-name app-policy --cluster-labels env=prod
+
+# Every manifest of a directory, on the edge clusters outside the EU
+kubectl multi deploy shop -f shop/ -R --cluster-labels 'location=edge,region notin (eu)'
+
+# Print the labeled manifests and the policy without contacting the WDS
+kubectl multi deploy app -f app.yaml --cluster-labels env=prod --dry-run=client`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.validate(args[0]); err != nil {
+				return err
+			}
+			kubeconfig, remoteCtx, _, namespace, _ := GetGlobalFlags()
+			var rec *audit.Recorder
+			if o.DryRun == util.DryRunNone || o.DryRun == "" {
+				rec = startAudit("deploy")
+			}
+			err := handleDeployCommand(args[0], o, rec, kubeconfig, remoteCtx, namespace, GetWDSContexts())
+			finishAudit(rec, err)
+			return err
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.Filename, "filename", "f", "", "file, directory or URL of the application manifests")
+	cmd.Flags().BoolVarP(&o.Recursive, "recursive", "R", false, "process the directory used in -f recursively")
+	cmd.Flags().StringVar(&o.PolicyName, "policy-name", "", "name of the BindingPolicy (defaults to NAME)")
+	cmd.Flags().StringVar(&o.ClusterLabels, "cluster-labels", "", "label selector choosing the ManagedClusters to deploy to, e.g. env=prod")
+	cmd.Flags().StringVar(&o.DryRun, "dry-run", util.DryRunNone, "none, client (print the manifests and the policy) or server")
+	cmd.Flags().BoolVar(&o.ForceConflicts, "force-conflicts", false, "take ownership of fields another manager owns in the WDS")
+
+	return cmd
+}
+
+// validate checks the flags before any manifest is read
+func (o *deployOptions) validate(name string) error {
+	if errs := validation.IsValidLabelValue(name); len(errs) > 0 || name == "" {
+		return fmt.Errorf("invalid application name %q: it becomes the value of the %s label (%s)", name, kubestellar.AppLabel, strings.Join(errs, "; "))
+	}
+	if o.Filename == "" {
+		return fmt.Errorf("-f is required")
+	}
+	if o.ClusterLabels == "" {
+		return fmt.Errorf("--cluster-labels is required")
+	}
+	if o.PolicyName == "" {
+		o.PolicyName = name
+	}
+	if errs := validation.IsDNS1123Subdomain(o.PolicyName); len(errs) > 0 {
+		return fmt.Errorf("invalid --policy-name %q: %s", o.PolicyName, strings.Join(errs, "; "))
+	}
+	return util.ValidateDryRun(o.DryRun)
+}
+
+func handleDeployCommand(name string, o deployOptions, rec *audit.Recorder, kubeconfig, remoteCtx, namespace string, wdsContexts []string) error {
+	objs, err := util.ReadManifests(o.Filename, o.Recursive)
+	if err != nil {
+		return err
+	}
+	policy, err := deployBundle(name, o.PolicyName, o.ClusterLabels, objs)
+	if err != nil {
+		return err
+	}
+	if o.DryRun == util.DryRunClient {
+		data, err := util.EncodeManifests(append(objs, policy))
+		if err != nil {
+			return err
+		}
+		_, err = util.GetOutputStream().Write(data)
+		return err
+	}
+
+	wdses := make([]*cluster.ClusterInfo, 0, len(wdsContexts))
+	for _, wdsContext := range wdsContexts {
+		wds, err := cluster.ClientForContext(kubeconfig, wdsContext)
+		if err != nil {
+			return err
+		}
+		wdses = append(wdses, wds)
+	}
+	// A redeploy also takes the application off the clusters the policy no
+	// longer selects
+	policies := append(livePolicies(wdses, o.PolicyName), policy)
+	if err := guardPolicyChange(rec, len(wdses)*(len(objs)+1), policies, kubeconfig, remoteCtx); err != nil {
+		return err
+	}
+	return deployToWDSes(wdses, append(objs, policy), o, rec, namespace, os.Stdout)
+}
+
+// deployBundle labels the objects of the application and returns the
+// BindingPolicy placing the objects with that label on the clusters
+// matching clusterLabels
+func deployBundle(name, policyName, clusterLabels string, objs []*unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	if len(objs) == 0 {
+		return nil, fmt.Errorf("no objects found in the manifests")
+	}
+	clusterSelector, err := metav1.ParseToLabelSelector(clusterLabels)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --cluster-labels %q: %v", clusterLabels, err)
+	}
+	for _, obj := range objs {
+		if obj.GroupVersionKind() == kubestellar.BindingPolicyGVR.GroupVersion().WithKind("BindingPolicy") {
+			return nil, fmt.Errorf("the manifests already contain BindingPolicy %s; deploy creates the policy itself", obj.GetName())
+		}
+		labels := obj.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[kubestellar.AppLabel] = name
+		obj.SetLabels(labels)
+	}
+
+	policy, err := kubestellar.NewPolicy(policyName, kubestellar.BindingPolicySpec{
+		ClusterSelectors: []metav1.LabelSelector{*clusterSelector},
+		Downsync:         kubestellar.DownsyncClauses(nil, nil, &metav1.LabelSelector{MatchLabels: map[string]string{kubestellar.AppLabel: name}}),
+	})
+	if err != nil {
+		return nil, err
+	}
+	policy.SetLabels(map[string]string{kubestellar.AppLabel: name})
+	return policy, nil
+}
+
+// deployToWDSes applies the objects, the policy last, to every WDS, prints
+// each WDS's result and records the policy in its revision history
+func deployToWDSes(wdses []*cluster.ClusterInfo, objs []*unstructured.Unstructured, o deployOptions, rec *audit.Recorder, namespace string, out io.Writer) error {
+	policy := objs[len(objs)-1]
+	record := o.DryRun == util.DryRunNone || o.DryRun == ""
+	failed := 0
+	for _, wds := range wdses {
+		fmt.Fprintf(out, "=== WDS: %s ===\n", wds.Context)
+		var previous *unstructured.Unstructured
+		if record {
+			previous, _ = wds.DynamicClient.Resource(kubestellar.BindingPolicyGVR).Get(commandContext(), policy.GetName(), metav1.GetOptions{})
+		}
+		if err := captureApplyUndo(rec, *wds, objs, namespace); err != nil {
+			fmt.Fprintf(out, "Warning: undo information for WDS %s not recorded: %v\n", wds.Context, err)
+		}
+		output, err := applyObjects(*wds, objs, namespace, o.DryRun, o.ForceConflicts)
+		rec.Record(wds.Context, err)
+		fmt.Fprint(out, output)
+		if err != nil {
+			fmt.Fprintf(out, "Error: %v\n", err)
+			failed++
+		} else if record {
+			notePolicyRevision(out, wds, previous, policy, "deploy")
+		}
+		fmt.Fprintln(out)
+	}
+	if failed > 0 {
+		return fmt.Errorf("deploy failed in %d of %d WDS(es)", failed, len(wdses))
+	}
+	if record {
+		fmt.Fprintf(out, "Follow the propagation with: kubectl multi kubestellar bp wait %s\n", o.PolicyName)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+	"kubectl-multi/pkg/util"
+)
+
+func TestDeployBundle(t *testing.T) {
+	objs := []*unstructured.Unstructured{testObject("apps/v1", "Deployment", "prod", "web"), testObject("v1", "Service", "prod", "web")}
+	policy, err := deployBundle("app", "app-policy", "env=prod", objs)
+	if err != nil {
+		t.Fatalf("deployBundle() error = %v", err)
+	}
+	for _, obj := range objs {
+		if obj.GetLabels()[kubestellar.AppLabel] != "app" {
+			t.Errorf("%s/%s labels = %v, want %s=app", obj.GetKind(), obj.GetName(), obj.GetLabels(), kubestellar.AppLabel)
+		}
+	}
+	if policy.GetName() != "app-policy" {
+		t.Errorf("policy name = %q", policy.GetName())
+	}
+	spec, err := kubestellar.BindingPolicySpecFrom(policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantClusters := []metav1.LabelSelector{{MatchLabels: map[string]string{"env": "prod"}}}
+	if !reflect.DeepEqual(spec.ClusterSelectors, wantClusters) {
+		t.Errorf("clusterSelectors = %+v, want %+v", spec.ClusterSelectors, wantClusters)
+	}
+	wantObjects := []metav1.LabelSelector{{MatchLabels: map[string]string{kubestellar.AppLabel: "app"}}}
+	if len(spec.Downsync) != 1 || !reflect.DeepEqual(spec.Downsync[0].ObjectSelectors, wantObjects) {
+		t.Errorf("downsync = %+v, want the object selector %+v", spec.Downsync, wantObjects)
+	}
+
+	if _, err := deployBundle("app", "app-policy", "env in (prod", objs); err == nil {
+		t.Error("deployBundle() accepted an invalid cluster selector")
+	}
+	if _, err := deployBundle("app", "app-policy", "env=prod", []*unstructured.Unstructured{policy}); err == nil {
+		t.Error("deployBundle() accepted manifests with a BindingPolicy")
+	}
+}
+
+func TestDeployToWDSes(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(kubestellar.BindingPolicyGVR.GroupVersion().WithKind("BindingPolicy"), meta.RESTScopeRoot)
+	dyn := testWDSDynamic()
+	// The fake client does not implement server-side apply
+	dyn.PrependReactor("patch", "*", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		obj := &unstructured.Unstructured{}
+		if err := json.Unmarshal(action.(clienttesting.PatchAction).GetPatch(), &obj.Object); err != nil {
+			return true, nil, err
+		}
+		obj.SetResourceVersion("1")
+		return true, obj, nil
+	})
+	disc := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+	wds := &cluster.ClusterInfo{Name: "wds1", Context: "wds1", DynamicClient: dyn, DiscoveryClient: disc, RESTMapper: mapper}
+
+	objs := []*unstructured.Unstructured{testConfigMap("web", "v1")}
+	policy, err := deployBundle("app", "app-policy", "env=prod", objs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := audit.Start("deploy", nil)
+	var out bytes.Buffer
+	o := deployOptions{PolicyName: "app-policy", DryRun: util.DryRunNone}
+	if err := deployToWDSes([]*cluster.ClusterInfo{wds}, append(objs, policy), o, rec, "default", &out); err != nil {
+		t.Fatalf("deployToWDSes() error = %v\n%s", err, out.String())
+	}
+	for _, want := range []string{"=== WDS: wds1 ===\n", "configmap/web created\n", "bindingpolicy.control.kubestellar.io/app-policy created\n", "kubectl multi kubestellar bp wait app-policy"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output %q does not contain %q", out.String(), want)
+		}
+	}
+	entry := rec.Finish(nil)
+	if len(entry.Undo) != 2 || entry.Undo[0].Action != audit.UndoDelete || entry.Undo[1].Resource != "bindingpolicies" {
+		t.Errorf("undo steps = %+v, want deleting the configmap and the policy", entry.Undo)
+	}
+}
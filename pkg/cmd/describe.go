@@ -1,13 +1,13 @@
 package cmd
 
 import (
-	"bytes"
 	"fmt"
-	"os"
-	"os/exec"
 	"strings"
 
 	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/kubectl/pkg/describe"
 
 	"kubectl-multi/pkg/cluster"
 	"kubectl-multi/pkg/util"
@@ -19,7 +19,7 @@ func describeHelpFunc(cmd *cobra.Command, args []string) {
 	cmdInfo, err := util.GetKubectlCommandInfo("describe")
 	if err != nil {
 		// Fallback to default help if kubectl help is not available
-		cmd.Help()
+		defaultHelpFunc(cmd, args)
 		return
 	}
 
@@ -52,7 +52,8 @@ kubectl multi describe nodes`
 func newDescribeCommand() *cobra.Command {
 	var selector string
 	var showEvents bool
-	var chunkSize int
+	var chunkSize int64
+	var reach reachability
 
 	cmd := &cobra.Command{
 		Use:   "describe [TYPE[.VERSION][.GROUP] [NAME_PREFIX | -l label] | TYPE[.VERSION][.GROUP]/NAME]",
@@ -75,16 +76,21 @@ kubectl multi describe nodes`,
 			if len(args) == 0 {
 				return fmt.Errorf("resource type must be specified")
 			}
+			selector, err := parseSelector(selector)
+			if err != nil {
+				return err
+			}
 
 			kubeconfig, remoteCtx, _, namespace, allNamespaces := GetGlobalFlags()
-			return handleDescribeCommand(args, selector, showEvents, chunkSize, kubeconfig, remoteCtx, namespace, allNamespaces)
+			return handleDescribeCommand(args, selector, showEvents, chunkSize, reach, kubeconfig, remoteCtx, namespace, allNamespaces)
 		},
 	}
 
 	// Add describe-specific flags
 	cmd.Flags().StringVarP(&selector, "selector", "l", "", "selector (label query) to filter on, supports '=', '==', '!=', 'in', 'notin'")
 	cmd.Flags().BoolVar(&showEvents, "show-events", true, "if true, display events related to the described object")
-	cmd.Flags().IntVar(&chunkSize, "chunk-size", 500, "return large lists in chunks rather than all at once")
+	cmd.Flags().Int64Var(&chunkSize, "chunk-size", 500, "return large lists in chunks rather than all at once")
+	reach.addFlags(cmd)
 
 	// Set custom help function
 	cmd.SetHelpFunc(describeHelpFunc)
@@ -92,23 +98,24 @@ kubectl multi describe nodes`,
 	return cmd
 }
 
-func handleDescribeCommand(args []string, selector string, showEvents bool, chunkSize int, kubeconfig, remoteCtx, namespace string, allNamespaces bool) error {
-	clusters, err := cluster.DiscoverClusters(kubeconfig, remoteCtx)
+func handleDescribeCommand(args []string, selector string, showEvents bool, chunkSize int64, reach reachability, kubeconfig, remoteCtx, namespace string, allNamespaces bool) error {
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
 	if err != nil {
 		return fmt.Errorf("failed to discover clusters: %v", err)
 	}
+	clusters, err = reach.filter(clusters)
+	if err != nil {
+		return err
+	}
+	defer printClusterIssuesToStderr()
 
 	if len(clusters) == 0 {
 		return fmt.Errorf("no clusters discovered")
 	}
 
-	// Parse resource type and name from args
-	resourceType := args[0]
-	// Note: resourceName is not currently used but kept for future enhancement
-	// resourceName := ""
-	// if len(args) > 1 {
-	// 	resourceName = args[1]
-	// }
+	// Parse resource type and names from args
+	resourceType, names := parseDescribeArgs(args)
+	settings := describe.DescriberSettings{ShowEvents: showEvents, ChunkSize: chunkSize}
 
 	fmt.Printf("Describing %s across %d clusters...\n\n", resourceType, len(clusters))
 
@@ -116,20 +123,16 @@ func handleDescribeCommand(args []string, selector string, showEvents bool, chun
 	anyOutput := false
 
 	for _, clusterInfo := range clusters {
-		if clusterInfo.Client == nil {
-			fmt.Printf("Warning: skipping cluster %s (no client available)\n", clusterInfo.Name)
+		if clusterInfo.DynamicClient == nil || clusterInfo.DiscoveryClient == nil || clusterInfo.RestConfig == nil {
+			noteClusterIssue(clusterInfo.Name, "no client available")
 			continue
 		}
 
 		fmt.Printf("=== Cluster: %s (Context: %s) ===\n", clusterInfo.Name, clusterInfo.Context)
 
-		// Build kubectl describe command
-		kubectlArgs := buildDescribeArgs(args, selector, showEvents, chunkSize, namespace, allNamespaces, clusterInfo.Name)
-
-		// Execute kubectl describe for this cluster
-		output, err := executeKubectlDescribe(kubectlArgs, kubeconfig, clusterInfo.Name)
+		output, err := describeInCluster(clusterInfo, resourceType, names, selector, settings, namespace, allNamespaces)
 		if err != nil {
-			fmt.Printf("Error describing %s in cluster %s: %v\n", resourceType, clusterInfo.Name, err)
+			noteClusterIssue(clusterInfo.Name, fmt.Sprintf("failed to describe %s: %v", resourceType, err))
 			fmt.Printf("\n")
 			continue
 		}
@@ -152,80 +155,89 @@ func handleDescribeCommand(args []string, selector string, showEvents bool, chun
 	return nil
 }
 
-// buildDescribeArgs constructs the kubectl describe command arguments
-func buildDescribeArgs(args []string, selector string, showEvents bool, chunkSize int, namespace string, allNamespaces bool, clusterContext string) []string {
-	var kubectlArgs []string
-
-	// Add the describe command and resource type
-	kubectlArgs = append(kubectlArgs, "describe")
-	kubectlArgs = append(kubectlArgs, args...)
-
-	// Add selector if specified
-	if selector != "" {
-		kubectlArgs = append(kubectlArgs, "-l", selector)
+// parseDescribeArgs splits "TYPE [NAME_PREFIX...]" or "TYPE/NAME" into the
+// resource type and the names to describe
+func parseDescribeArgs(args []string) (string, []string) {
+	if resourceType, name, ok := strings.Cut(args[0], "/"); ok {
+		return resourceType, []string{name}
 	}
+	return args[0], args[1:]
+}
 
-	// Add namespace flags
-	if allNamespaces {
-		kubectlArgs = append(kubectlArgs, "-A")
-	} else if namespace != "" {
-		kubectlArgs = append(kubectlArgs, "-n", namespace)
+// describeInCluster describes the matching objects of one cluster with
+// kubectl's describers, falling back to the generic one for custom resources
+func describeInCluster(clusterInfo cluster.ClusterInfo, resourceType string, names []string, selector string, settings describe.DescriberSettings, namespace string, allNamespaces bool) (string, error) {
+	gvr, namespaced, err := util.DiscoverGVR(clusterInfo.DiscoveryClient, resourceType)
+	if err != nil {
+		return "", err
 	}
-
-	// Add show-events flag
-	if !showEvents {
-		kubectlArgs = append(kubectlArgs, "--show-events=false")
+	mapper := clusterInfo.Mapper()
+	gvk, err := mapper.KindFor(gvr)
+	if err != nil {
+		return "", err
 	}
-
-	// Add chunk-size flag
-	if chunkSize != 500 {
-		kubectlArgs = append(kubectlArgs, "--chunk-size", fmt.Sprintf("%d", chunkSize))
+	describer, ok := describe.DescriberFor(gvk.GroupKind(), clusterInfo.RestConfig)
+	if !ok {
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return "", err
+		}
+		if describer, ok = describe.GenericDescriberFor(mapping, clusterInfo.RestConfig); !ok {
+			return "", fmt.Errorf("no describer available for %s", gvk.Kind)
+		}
 	}
 
-	// Add context for this specific cluster
-	kubectlArgs = append(kubectlArgs, "--context", clusterContext)
+	ns := ""
+	if namespaced && !allNamespaces {
+		ns = cluster.GetTargetNamespace(namespace)
+	}
+	list, err := clusterInfo.DynamicClient.Resource(gvr).Namespace(ns).List(commandContext(), metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return "", err
+	}
 
-	return kubectlArgs
+	var out strings.Builder
+	for _, obj := range describeTargets(list.Items, names) {
+		text, err := describer.Describe(obj.GetNamespace(), obj.GetName(), settings)
+		if err != nil {
+			return out.String(), err
+		}
+		if out.Len() > 0 {
+			out.WriteString("\n\n")
+		}
+		out.WriteString(text)
+	}
+	return out.String(), nil
 }
 
-// executeKubectlDescribe executes kubectl describe command for a specific cluster
-func executeKubectlDescribe(args []string, kubeconfig, clusterName string) (string, error) {
-	// Create the command
-	cmd := exec.Command("kubectl", args...)
-
-	// Set environment variables
-	cmd.Env = os.Environ()
-	if kubeconfig != "" {
-		cmd.Env = append(cmd.Env, "KUBECONFIG="+kubeconfig)
+// describeTargets picks the objects to describe the way kubectl describe
+// does: all of them without names, otherwise for each name the exact match
+// or, failing that, every object whose name starts with it
+func describeTargets(items []unstructured.Unstructured, names []string) []unstructured.Unstructured {
+	if len(names) == 0 {
+		return items
 	}
-
-	// Capture stdout and stderr
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	// Execute the command
-	err := cmd.Run()
-
-	// Get the output
-	output := stdout.String()
-	stderrOutput := stderr.String()
-
-	// Handle different types of errors
-	if err != nil {
-		// Check if it's a "not found" error (which is expected for some resources)
-		if strings.Contains(stderrOutput, "not found") || strings.Contains(stderrOutput, "No resources found") {
-			return "", nil // Return empty string for not found, not an error
+	var targets []unstructured.Unstructured
+	seen := map[string]bool{}
+	add := func(obj unstructured.Unstructured) {
+		key := obj.GetNamespace() + "/" + obj.GetName()
+		if !seen[key] {
+			seen[key] = true
+			targets = append(targets, obj)
 		}
-
-		// For other errors, return the error with context
-		return "", fmt.Errorf("kubectl command failed: %v\nStderr: %s", err, stderrOutput)
 	}
-
-	// If we got stderr output but no error, it might be warnings
-	if stderrOutput != "" && !strings.Contains(stderrOutput, "not found") {
-		output = stderrOutput + "\n" + output
+	for _, name := range names {
+		if exact := itemsNamed(items, name, false); len(exact) > 0 {
+			for _, obj := range exact {
+				add(obj)
+			}
+			continue
+		}
+		for _, obj := range items {
+			if strings.HasPrefix(obj.GetName(), name) {
+				add(obj)
+			}
+		}
 	}
-
-	return output, nil
+	return targets
 }
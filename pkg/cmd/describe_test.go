@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestParseDescribeArgs(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantType  string
+		wantNames []string
+	}{
+		{name: "type only", args: []string{"pods"}, wantType: "pods", wantNames: []string{}},
+		{name: "type and names", args: []string{"pod", "nginx", "web"}, wantType: "pod", wantNames: []string{"nginx", "web"}},
+		{name: "type/name", args: []string{"service/my-service"}, wantType: "service", wantNames: []string{"my-service"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotType, gotNames := parseDescribeArgs(tt.args)
+			if gotType != tt.wantType || !reflect.DeepEqual(gotNames, tt.wantNames) {
+				t.Errorf("parseDescribeArgs() = %q, %q, want %q, %q", gotType, gotNames, tt.wantType, tt.wantNames)
+			}
+		})
+	}
+}
+
+func TestDescribeTargets(t *testing.T) {
+	items := []unstructured.Unstructured{
+		*testObject("v1", "Pod", "default", "nginx"),
+		*testObject("v1", "Pod", "default", "nginx-abc"),
+		*testObject("v1", "Pod", "default", "web-1"),
+		*testObject("v1", "Pod", "default", "web-2"),
+	}
+	tests := []struct {
+		name  string
+		names []string
+		want  []string
+	}{
+		{name: "all", want: []string{"nginx", "nginx-abc", "web-1", "web-2"}},
+		{name: "exact match wins over prefix", names: []string{"nginx"}, want: []string{"nginx"}},
+		{name: "prefix", names: []string{"web"}, want: []string{"web-1", "web-2"}},
+		{name: "duplicates once", names: []string{"web", "web-1"}, want: []string{"web-1", "web-2"}},
+		{name: "no match", names: []string{"db"}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, obj := range describeTargets(items, tt.names) {
+				got = append(got, obj.GetName())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("describeTargets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
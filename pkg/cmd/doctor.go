@@ -0,0 +1,448 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/config"
+	"kubectl-multi/pkg/gitops"
+	"kubectl-multi/pkg/hooks"
+	"kubectl-multi/pkg/kubestellar"
+	"kubectl-multi/pkg/lock"
+	"kubectl-multi/pkg/util"
+)
+
+// Doctor check outcomes
+const (
+	checkPass = "PASS"
+	checkWarn = "WARN"
+	checkFail = "FAIL"
+)
+
+// doctorCheck is one line of the doctor checklist
+type doctorCheck struct {
+	Status  string
+	Name    string
+	Details string
+}
+
+// doctorAccessChecks are the verbs a fleet operator typically needs in each WEC
+var doctorAccessChecks = []authorizationv1.ResourceAttributes{
+	{Verb: "list", Resource: "pods"},
+	{Verb: "get", Resource: "pods", Subresource: "log"},
+	{Verb: "list", Group: "apps", Resource: "deployments"},
+	{Verb: "create", Group: "apps", Resource: "deployments"},
+	{Verb: "patch", Group: "apps", Resource: "deployments"},
+	{Verb: "delete", Group: "apps", Resource: "deployments"},
+	{Verb: "create", Resource: "namespaces"},
+}
+
+func newDoctorCommand() *cobra.Command {
+	var timeout time.Duration
+	var forceRelease bool
+
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check kubeconfig, control planes and managed clusters for common setup problems",
+		Long: `Run pre-flight checks and print a pass/fail checklist: the kubeconfig and the
+ITS and WDS contexts resolve, the ITS is reachable and serves ManagedClusters,
+the KubeFlex ControlPlane and BindingPolicy CRDs are installed, every managed
+cluster has a context and answers within the timeout, the current user may
+perform common operations there, and the kubectl and helm binaries are on PATH.
+It also warns when Argo CD or Flux manages the namespace (-n) or its workloads
+in a managed cluster, since their syncs revert changes made with kubectl multi.
+When the fleet lock is enabled it also reports who holds it; --force-release
+frees a lock left behind by a command that crashed.`,
+		Example: `# Diagnose the default its1/wds1 setup
+kubectl multi doctor
+
+# Allow slow clusters more time to answer
+kubectl multi doctor --timeout 15s --remote-context its1 --wds-context wds2
+
+# Free the fleet lock of an operation that was killed
+kubectl multi doctor --force-release`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kubeconfig, remoteCtx, _, namespace, _ := GetGlobalFlags()
+			return handleDoctorCommand(timeout, forceRelease, kubeconfig, remoteCtx, cluster.GetTargetNamespace(namespace), GetWDSContexts())
+		},
+	}
+
+	cmd.Flags().DurationVar(&timeout, "timeout", 5*time.Second, "how long to wait for each cluster to answer")
+	cmd.Flags().BoolVar(&forceRelease, "force-release", false, "free the fleet lock, whoever holds it")
+
+	return cmd
+}
+
+func handleDoctorCommand(timeout time.Duration, forceRelease bool, kubeconfig, remoteCtx, namespace string, wdsContexts []string) error {
+	var checks []doctorCheck
+	add := func(status, name, details string) {
+		checks = append(checks, doctorCheck{Status: status, Name: name, Details: details})
+	}
+
+	loading := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfig != "" {
+		loading.ExplicitPath = kubeconfig
+	}
+	rawCfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loading, &clientcmd.ConfigOverrides{}).RawConfig()
+	if err != nil || len(rawCfg.Contexts) == 0 {
+		if err == nil {
+			err = fmt.Errorf("no contexts defined")
+		}
+		add(checkFail, "kubeconfig loads", err.Error())
+		return printDoctorChecks(checks)
+	}
+	add(checkPass, "kubeconfig loads", fmt.Sprintf("%d contexts, current %q", len(rawCfg.Contexts), rawCfg.CurrentContext))
+
+	// Hosting cluster: the KubeFlex ControlPlane CRD lives where the current context points
+	if hosting, err := doctorClient(kubeconfig, "", timeout); err != nil {
+		add(checkFail, "hosting cluster reachable", err.Error())
+	} else {
+		checks = append(checks, doctorResourceCheck(hosting.Discovery(), "ControlPlane CRD installed", "tenancy.kflex.kubestellar.org/v1alpha1", "controlplanes", "in context "+rawCfg.CurrentContext))
+	}
+
+	// ITS
+	itsReachable := false
+	if _, ok := rawCfg.Contexts[remoteCtx]; !ok {
+		add(checkFail, "ITS context resolves", fmt.Sprintf("context %q not found in kubeconfig (set --remote-context)", remoteCtx))
+	} else {
+		add(checkPass, "ITS context resolves", remoteCtx)
+		if its, err := doctorClient(kubeconfig, remoteCtx, timeout); err != nil {
+			add(checkFail, "ITS reachable", err.Error())
+		} else {
+			itsReachable = true
+			add(checkPass, "ITS reachable", doctorServerVersion(its))
+			checks = append(checks, doctorResourceCheck(its.Discovery(), "ManagedCluster CRD installed", cluster.ManagedClusterGVR.GroupVersion().String(), cluster.ManagedClusterGVR.Resource, "in ITS "+remoteCtx))
+		}
+	}
+
+	// WDSes
+	for _, wdsContext := range wdsContexts {
+		if _, ok := rawCfg.Contexts[wdsContext]; !ok {
+			add(checkFail, "WDS context resolves", fmt.Sprintf("context %q not found in kubeconfig (set --wds-context or --wds)", wdsContext))
+			continue
+		}
+		add(checkPass, "WDS context resolves", wdsContext)
+		if wds, err := doctorClient(kubeconfig, wdsContext, timeout); err != nil {
+			add(checkFail, "WDS reachable", fmt.Sprintf("%s: %v", wdsContext, err))
+		} else {
+			add(checkPass, "WDS reachable", doctorServerVersion(wds))
+			checks = append(checks, doctorResourceCheck(wds.Discovery(), "BindingPolicy CRD installed", kubestellar.BindingPolicyGVR.GroupVersion().String(), kubestellar.BindingPolicyGVR.Resource, "in WDS "+wdsContext))
+		}
+	}
+
+	// Managed clusters (WECs)
+	if itsReachable {
+		checks = append(checks, doctorManagedClusters(rawCfg, timeout, kubeconfig, remoteCtx, namespace)...)
+	}
+
+	// Fleet lock
+	if cfg, err := config.Load(); err != nil {
+		add(checkWarn, "fleet lock", err.Error())
+	} else if settings := cfg.LockSettings(); settings.Enabled || forceRelease {
+		contextName := settings.Context
+		if contextName == "" && len(wdsContexts) > 0 {
+			contextName = wdsContexts[0]
+		}
+		if client, err := doctorClient(kubeconfig, contextName, timeout); err != nil {
+			add(checkFail, "fleet lock", fmt.Sprintf("%s: %v", contextName, err))
+		} else {
+			checks = append(checks, doctorFleetLock(client, settings, contextName, forceRelease))
+		}
+	}
+
+	// Notification hooks
+	if cfg, err := config.Load(); err == nil {
+		for _, h := range cfg.Hooks {
+			checks = append(checks, doctorHook(h))
+		}
+	}
+
+	for _, binary := range []string{"kubectl", "helm"} {
+		if path, err := exec.LookPath(binary); err != nil {
+			add(checkWarn, binary+" binary on PATH", "not found; commands that shell out to "+binary+" will fail")
+		} else {
+			add(checkPass, binary+" binary on PATH", path)
+		}
+	}
+
+	return printDoctorChecks(checks)
+}
+
+// doctorFleetLock reports who holds the fleet lock, freeing it first with
+// forceRelease
+func doctorFleetLock(client kubernetes.Interface, settings config.LockConfig, contextName string, forceRelease bool) doctorCheck {
+	where := fmt.Sprintf("Lease %s/%s in %s", settings.Namespace, settings.Name, contextName)
+	if forceRelease {
+		holder, err := lock.ForceRelease(commandContext(), client, settings.Namespace, settings.Name)
+		switch {
+		case err != nil:
+			return doctorCheck{Status: checkFail, Name: "fleet lock", Details: err.Error()}
+		case holder != nil:
+			return doctorCheck{Status: checkPass, Name: "fleet lock", Details: fmt.Sprintf("released %s, held by %s", where, holder)}
+		}
+		return doctorCheck{Status: checkPass, Name: "fleet lock", Details: "free, " + where}
+	}
+	holder, err := lock.Status(commandContext(), client, settings.Namespace, settings.Name)
+	switch {
+	case err != nil:
+		return doctorCheck{Status: checkFail, Name: "fleet lock", Details: err.Error()}
+	case holder == nil:
+		return doctorCheck{Status: checkPass, Name: "fleet lock", Details: "free, " + where}
+	case holder.Expired(time.Now()):
+		return doctorCheck{Status: checkPass, Name: "fleet lock", Details: fmt.Sprintf("free, %s expired for %s", where, holder)}
+	}
+	return doctorCheck{Status: checkWarn, Name: "fleet lock", Details: fmt.Sprintf("%s held by %s; use --force-release if that command is gone", where, holder)}
+}
+
+// doctorManagedClusters checks every ManagedCluster concurrently for a
+// context, reachability, RBAC and GitOps management of namespace
+func doctorManagedClusters(rawCfg clientcmdapi.Config, timeout time.Duration, kubeconfig, remoteCtx, namespace string) []doctorCheck {
+	mcs, err := cluster.ListManagedClusterObjects(commandContext(), kubeconfig, remoteCtx)
+	if err != nil {
+		return []doctorCheck{{Status: checkFail, Name: "ManagedClusters listed", Details: err.Error()}}
+	}
+	var names []string
+	for _, mc := range mcs {
+		if !cluster.IsWDSCluster(mc.GetName()) {
+			names = append(names, mc.GetName())
+		}
+	}
+	if len(names) == 0 {
+		return []doctorCheck{{Status: checkWarn, Name: "ManagedClusters listed", Details: "no managed clusters registered in ITS " + remoteCtx}}
+	}
+
+	results := make([][]doctorCheck, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			results[i] = doctorManagedCluster(rawCfg, name, timeout, kubeconfig, namespace)
+		}(i, name)
+	}
+	wg.Wait()
+
+	checks := []doctorCheck{{Status: checkPass, Name: "ManagedClusters listed", Details: strings.Join(names, ", ")}}
+	for _, r := range results {
+		checks = append(checks, r...)
+	}
+	return checks
+}
+
+func doctorManagedCluster(rawCfg clientcmdapi.Config, name string, timeout time.Duration, kubeconfig, namespace string) []doctorCheck {
+	prefix := "cluster " + name + ": "
+	if _, ok := rawCfg.Contexts[name]; !ok {
+		return []doctorCheck{{Status: checkFail, Name: prefix + "context resolves", Details: "no kubeconfig context named " + name}}
+	}
+	client, err := doctorClient(kubeconfig, name, timeout)
+	if err != nil {
+		return []doctorCheck{{Status: checkFail, Name: prefix + "reachable", Details: err.Error()}}
+	}
+	checks := []doctorCheck{{Status: checkPass, Name: prefix + "reachable", Details: doctorServerVersion(client)}}
+
+	var denied []string
+	for _, attrs := range doctorAccessChecks {
+		attrs := attrs
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: &attrs},
+		}
+		result, err := client.AuthorizationV1().SelfSubjectAccessReviews().Create(commandContext(), review, metav1.CreateOptions{})
+		if err != nil {
+			checks = append(checks, doctorCheck{Status: checkWarn, Name: prefix + "RBAC", Details: "access review failed: " + err.Error()})
+			return checks
+		}
+		if !result.Status.Allowed {
+			denied = append(denied, doctorVerb(attrs))
+		}
+	}
+	if len(denied) > 0 {
+		checks = append(checks, doctorCheck{Status: checkWarn, Name: prefix + "RBAC", Details: "not allowed: " + strings.Join(denied, ", ")})
+	} else {
+		checks = append(checks, doctorCheck{Status: checkPass, Name: prefix + "RBAC", Details: "common verbs allowed"})
+	}
+	check := doctorGitOps(client, namespace)
+	check.Name = prefix + check.Name
+	return append(checks, check)
+}
+
+// doctorGitOps warns when Argo CD or Flux manages namespace or the workloads
+// in it, whose changes made with kubectl multi their syncs revert
+func doctorGitOps(client kubernetes.Interface, namespace string) doctorCheck {
+	check := doctorCheck{Status: checkPass, Name: "GitOps"}
+	tools, err := gitops.InstalledTools(client.Discovery())
+	if err != nil {
+		check.Status, check.Details = checkWarn, "failed to discover API groups: "+err.Error()
+		return check
+	}
+
+	var managed []string
+	if ns, err := client.CoreV1().Namespaces().Get(commandContext(), namespace, metav1.GetOptions{}); err == nil {
+		if m, ok := gitops.ManagerOf(ns); ok {
+			managed = append(managed, "namespace "+namespace+" by "+m.String())
+		}
+	}
+	// Workloads are what fleet operators most often change imperatively
+	byManager := map[string]int{}
+	var workloads []metav1.Object
+	if list, err := client.AppsV1().Deployments(namespace).List(commandContext(), metav1.ListOptions{}); err == nil {
+		for i := range list.Items {
+			workloads = append(workloads, &list.Items[i])
+		}
+	}
+	if list, err := client.AppsV1().StatefulSets(namespace).List(commandContext(), metav1.ListOptions{}); err == nil {
+		for i := range list.Items {
+			workloads = append(workloads, &list.Items[i])
+		}
+	}
+	if list, err := client.AppsV1().DaemonSets(namespace).List(commandContext(), metav1.ListOptions{}); err == nil {
+		for i := range list.Items {
+			workloads = append(workloads, &list.Items[i])
+		}
+	}
+	for _, w := range workloads {
+		if m, ok := gitops.ManagerOf(w); ok {
+			byManager[m.String()]++
+		}
+	}
+	for _, m := range sortedKeys(byManager) {
+		managed = append(managed, fmt.Sprintf("%s in %s by %s", plural(byManager[m], "workload"), namespace, m))
+	}
+
+	if len(managed) > 0 {
+		check.Status = checkWarn
+		check.Details = "managed: " + strings.Join(managed, "; ") + "; changes made with kubectl multi will be reverted on the next sync"
+		return check
+	}
+	if len(tools) == 0 {
+		check.Details = "no Argo CD or Flux APIs served"
+		return check
+	}
+	check.Details = strings.Join(tools, " and ") + " installed; namespace " + namespace + " and its workloads not managed"
+	return check
+}
+
+// doctorClient builds a clientset for a context that gives up after timeout,
+// and confirms the API server answers
+func doctorClient(kubeconfig, contextName string, timeout time.Duration) (*kubernetes.Clientset, error) {
+	loading := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfig != "" {
+		loading.ExplicitPath = kubeconfig
+	}
+	restCfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loading, &clientcmd.ConfigOverrides{CurrentContext: contextName}).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	restCfg = rest.CopyConfig(restCfg)
+	restCfg.Timeout = timeout
+	client, err := kubernetes.NewForConfig(restCfg)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := client.Discovery().ServerVersion(); err != nil {
+		return nil, fmt.Errorf("API server did not answer within %s: %v", timeout, err)
+	}
+	return client, nil
+}
+
+func doctorServerVersion(client *kubernetes.Clientset) string {
+	v, err := client.Discovery().ServerVersion()
+	if err != nil {
+		return ""
+	}
+	return "Kubernetes " + v.GitVersion
+}
+
+// doctorResourceCheck checks that the server serves a resource in a group
+// version; a failure carries the discovery error in its details
+func doctorResourceCheck(client discovery.DiscoveryInterface, name, groupVersion, resource, where string) doctorCheck {
+	details := groupVersion + " " + resource + " " + where
+	if err := doctorServesResource(client, groupVersion, resource); err != nil {
+		return doctorCheck{Status: checkFail, Name: name, Details: details + ": " + err.Error()}
+	}
+	return doctorCheck{Status: checkPass, Name: name, Details: details}
+}
+
+// doctorServesResource returns why the server does not serve a resource
+func doctorServesResource(client discovery.DiscoveryInterface, groupVersion, resource string) error {
+	list, err := client.ServerResourcesForGroupVersion(groupVersion)
+	if err != nil {
+		return fmt.Errorf("failed to discover %s: %v", groupVersion, err)
+	}
+	for _, r := range list.APIResources {
+		if r.Name == resource {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not served", resource)
+}
+
+func doctorVerb(attrs authorizationv1.ResourceAttributes) string {
+	resource := attrs.Resource
+	if attrs.Group != "" {
+		resource += "." + attrs.Group
+	}
+	if attrs.Subresource != "" {
+		resource += "/" + attrs.Subresource
+	}
+	return attrs.Verb + " " + resource
+}
+
+// printDoctorChecks prints the checklist and fails if any check failed
+func printDoctorChecks(checks []doctorCheck) error {
+	out := util.GetOutputStream()
+	failed, warned := 0, 0
+	for _, c := range checks {
+		switch c.Status {
+		case checkFail:
+			failed++
+		case checkWarn:
+			warned++
+		}
+		line := fmt.Sprintf("[%s] %s", c.Status, c.Name)
+		if c.Details != "" {
+			line += " - " + c.Details
+		}
+		fmt.Fprintln(out, line)
+	}
+	fmt.Fprintf(out, "\n%d checks: %d passed, %d warnings, %d failed\n", len(checks), len(checks)-failed-warned, warned, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d doctor check(s) failed", failed)
+	}
+	return nil
+}
+
+// doctorHook checks the settings of a notification hook. Webhook URLs often
+// embed a token, so only their host is shown.
+func doctorHook(h config.Hook) doctorCheck {
+	name := "hook " + h.Name
+	if err := hooks.Validate(h); err != nil {
+		return doctorCheck{Status: checkFail, Name: name, Details: err.Error()}
+	}
+	if h.Command != "" {
+		return doctorCheck{Status: checkPass, Name: name, Details: "runs " + h.Command}
+	}
+	u, err := url.Parse(h.URL)
+	if err != nil || u.Host == "" {
+		return doctorCheck{Status: checkFail, Name: name, Details: "invalid url"}
+	}
+	format := h.Format
+	if format == "" {
+		format = config.HookFormatJSON
+	}
+	return doctorCheck{Status: checkPass, Name: name, Details: fmt.Sprintf("posts %s to %s", format, u.Host)}
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"kubectl-multi/pkg/config"
+	"kubectl-multi/pkg/gitops"
+	"kubectl-multi/pkg/lock"
+)
+
+func TestDoctorResourceCheck(t *testing.T) {
+	fake := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: []*metav1.APIResourceList{{
+		GroupVersion: "cluster.open-cluster-management.io/v1",
+		APIResources: []metav1.APIResource{{Name: "managedclusters", Kind: "ManagedCluster"}},
+	}}}}
+
+	tests := []struct {
+		name         string
+		groupVersion string
+		resource     string
+		want         doctorCheck
+	}{
+		{
+			name:         "served",
+			groupVersion: "cluster.open-cluster-management.io/v1",
+			resource:     "managedclusters",
+			want:         doctorCheck{Status: checkPass, Name: "CRD installed", Details: "cluster.open-cluster-management.io/v1 managedclusters in ITS its1"},
+		},
+		{
+			name:         "resource missing from group version",
+			groupVersion: "cluster.open-cluster-management.io/v1",
+			resource:     "placements",
+			want:         doctorCheck{Status: checkFail, Name: "CRD installed", Details: "cluster.open-cluster-management.io/v1 placements in ITS its1: placements is not served"},
+		},
+		{
+			name:         "group version not served",
+			groupVersion: "control.kubestellar.io/v1alpha1",
+			resource:     "bindingpolicies",
+			want: doctorCheck{Status: checkFail, Name: "CRD installed", Details: "control.kubestellar.io/v1alpha1 bindingpolicies in ITS its1: " +
+				`failed to discover control.kubestellar.io/v1alpha1: the server could not find the requested resource, GroupVersion "control.kubestellar.io/v1alpha1" not found`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := doctorResourceCheck(fake, "CRD installed", tt.groupVersion, tt.resource, "in ITS its1"); got != tt.want {
+				t.Errorf("doctorResourceCheck() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDoctorVerb(t *testing.T) {
+	tests := []struct {
+		attrs authorizationv1.ResourceAttributes
+		want  string
+	}{
+		{authorizationv1.ResourceAttributes{Verb: "list", Resource: "pods"}, "list pods"},
+		{authorizationv1.ResourceAttributes{Verb: "get", Resource: "pods", Subresource: "log"}, "get pods/log"},
+		{authorizationv1.ResourceAttributes{Verb: "patch", Group: "apps", Resource: "deployments"}, "patch deployments.apps"},
+	}
+	for _, tt := range tests {
+		if got := doctorVerb(tt.attrs); got != tt.want {
+			t.Errorf("doctorVerb(%+v) = %q, want %q", tt.attrs, got, tt.want)
+		}
+	}
+}
+
+func TestDoctorFleetLock(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	settings := config.LockConfig{Namespace: "default", Name: "kubectl-multi"}
+	if c := doctorFleetLock(client, settings, "wds1", false); c.Status != checkPass || c.Details != "free, Lease default/kubectl-multi in wds1" {
+		t.Errorf("free lock check = %+v", c)
+	}
+
+	held, err := lock.Acquire(context.TODO(), client, "default", "kubectl-multi", "alice@laptop (pid 1)", "apply", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer held.Release()
+	if c := doctorFleetLock(client, settings, "wds1", false); c.Status != checkWarn || !strings.Contains(c.Details, "held by alice@laptop (pid 1) running apply") {
+		t.Errorf("held lock check = %+v", c)
+	}
+	if c := doctorFleetLock(client, settings, "wds1", true); c.Status != checkPass || !strings.Contains(c.Details, "released Lease default/kubectl-multi in wds1, held by alice@laptop (pid 1)") {
+		t.Errorf("force release check = %+v", c)
+	}
+	if holder, _ := lock.Status(context.TODO(), client, "default", "kubectl-multi"); holder != nil {
+		t.Errorf("lock still held by %s after --force-release", holder)
+	}
+}
+
+func TestDoctorHook(t *testing.T) {
+	tests := []struct {
+		hook   config.Hook
+		status string
+		want   string
+	}{
+		{hook: config.Hook{Name: "slack", URL: "https://hooks.slack.com/services/T0/B0/secret", Format: "slack"}, status: checkPass, want: "posts slack to hooks.slack.com"},
+		{hook: config.Hook{Name: "ci", URL: "https://ci.example.com/notify"}, status: checkPass, want: "posts json to ci.example.com"},
+		{hook: config.Hook{Name: "page", Command: "/usr/local/bin/page-oncall"}, status: checkPass, want: "runs /usr/local/bin/page-oncall"},
+		{hook: config.Hook{Name: "none"}, status: checkFail, want: `hook "none" must set exactly one of url and command`},
+		{hook: config.Hook{Name: "relative", URL: "hooks/notify"}, status: checkFail, want: "invalid url"},
+	}
+	for _, tt := range tests {
+		if c := doctorHook(tt.hook); c.Status != tt.status || c.Details != tt.want || c.Name != "hook "+tt.hook.Name {
+			t.Errorf("doctorHook(%s) = %+v, want %s %q", tt.hook.Name, c, tt.status, tt.want)
+		}
+	}
+}
+
+func TestDoctorGitOps(t *testing.T) {
+	argo := map[string]string{gitops.ArgoCDInstanceLabel: "web"}
+	client := fake.NewSimpleClientset(
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "web", Labels: argo}},
+		&appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "cache", Labels: argo}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "debug"}},
+	)
+	client.Fake.Resources = []*metav1.APIResourceList{{GroupVersion: "argoproj.io/v1alpha1", APIResources: []metav1.APIResource{{Name: "applications"}}}}
+
+	c := doctorGitOps(client, "prod")
+	if c.Status != checkWarn || c.Details != "managed: 2 workloads in prod by Argo CD Application web; changes made with kubectl multi will be reverted on the next sync" {
+		t.Errorf("doctorGitOps(prod) = %+v", c)
+	}
+	if c := doctorGitOps(client, "dev"); c.Status != checkPass || c.Details != "Argo CD installed; namespace dev and its workloads not managed" {
+		t.Errorf("doctorGitOps(dev) = %+v", c)
+	}
+	if c := doctorGitOps(fake.NewSimpleClientset(), "prod"); c.Status != checkPass || c.Details != "no Argo CD or Flux APIs served" {
+		t.Errorf("doctorGitOps() without GitOps = %+v", c)
+	}
+}
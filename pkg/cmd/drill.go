@@ -0,0 +1,510 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+	"kubectl-multi/pkg/util"
+)
+
+// drillPollInterval is how often a drill re-reads the Binding and the
+// workload copies while it waits
+var drillPollInterval = 2 * time.Second
+
+// Results of a drill
+const (
+	drillPassed = "Passed"
+	drillFailed = "Failed"
+)
+
+// drillEvent is one step of the timeline of a drill
+type drillEvent struct {
+	Time  time.Time `json:"time"`
+	Event string    `json:"event"`
+}
+
+// failoverReport is the outcome and timeline of a failover drill
+type failoverReport struct {
+	Policy   string   `json:"policy"`
+	WDS      string   `json:"wds"`
+	Removed  []string `json:"removed"`
+	Duration string   `json:"duration"`
+	Result   string   `json:"result"`
+	// FailoverTime is how long the workload took to be ready without the
+	// removed clusters, RecoveryTime how long it took to be back on them
+	FailoverTime string       `json:"failoverTime,omitempty"`
+	RecoveryTime string       `json:"recoveryTime,omitempty"`
+	Timeline     []drillEvent `json:"timeline"`
+
+	started time.Time
+	out     io.Writer
+}
+
+// note adds an event to the timeline and prints it as it happens
+func (r *failoverReport) note(format string, args ...interface{}) {
+	e := drillEvent{Time: time.Now(), Event: fmt.Sprintf(format, args...)}
+	r.Timeline = append(r.Timeline, e)
+	if r.out != nil {
+		fmt.Fprintf(r.out, "[%s] %s\n", drillOffset(r.started, e.Time), e.Event)
+	}
+}
+
+// drillOffset formats the time since the start of the drill, e.g. +1m05s
+func drillOffset(start, t time.Time) string {
+	return "+" + t.Sub(start).Round(time.Second).String()
+}
+
+// failoverDrill is a failover drill against one BindingPolicy
+type failoverDrill struct {
+	Policy       string
+	Remove       []string
+	Duration     time.Duration
+	ReadyTimeout time.Duration
+	WDSContext   string
+	WDS          *cluster.ClusterInfo
+	// Clusters are the reachable WECs by name, to check the workload copies
+	Clusters map[string]cluster.ClusterInfo
+}
+
+func newDrillCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "drill",
+		Short: "Rehearse cluster-loss scenarios against live placements",
+		Long: `Run drills that change placements for a limited time, check how the
+workloads react and put everything back, so teams can rehearse incidents
+before they happen.`,
+	}
+	cmd.AddCommand(newDrillFailoverCommand())
+	return cmd
+}
+
+func newDrillFailoverCommand() *cobra.Command {
+	var policy string
+	var remove []string
+	var duration, readyTimeout time.Duration
+	var dryRun bool
+	var outputFormat string
+	var reach reachability
+
+	cmd := &cobra.Command{
+		Use:   "failover --policy POLICY --remove CLUSTER[,CLUSTER...]",
+		Short: "Temporarily take clusters out of a BindingPolicy and check the workload survives",
+		Long: `Take clusters out of a BindingPolicy for a while, as if they were lost,
+and check that its workload is Ready on the remaining clusters, then restore
+the policy and check the workload comes back.
+
+The clusters are excluded by adding an expression on the
+open-cluster-management.io/cluster-name label to every cluster selector of
+the policy. After --duration, or on Ctrl-C, exactly that expression is
+removed again, so changes made to the policy meanwhile are kept. Both changes
+are recorded in the policy's revision history (bp history) and the drill in
+the audit history.
+
+The timeline is printed as the drill goes. The drill fails when the workload
+is not Ready elsewhere within --ready-timeout, in which case the policy is
+restored at once, or not back within it after the restore.`,
+		Example: `# Lose cluster1 for 30 minutes
+kubectl multi drill failover --policy app-policy --remove cluster1 --duration 30m
+
+# Lose two clusters for 5 minutes, with the timeline as JSON
+kubectl multi drill failover --policy app-policy --remove cluster1,cluster2 --duration 5m -o json
+
+# Show the policy as the drill would change it
+kubectl multi drill failover --policy app-policy --remove cluster1 --dry-run`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if policy == "" || len(remove) == 0 {
+				return fmt.Errorf("--policy and --remove are required")
+			}
+			if duration <= 0 || readyTimeout <= 0 {
+				return fmt.Errorf("--duration and --ready-timeout must be positive")
+			}
+			switch outputFormat {
+			case "", "json", "yaml":
+			default:
+				return fmt.Errorf("unsupported output format %q, must be one of json|yaml", outputFormat)
+			}
+			kubeconfig, remoteCtx, _, _, _ := GetGlobalFlags()
+			d := failoverDrill{Policy: policy, Remove: remove, Duration: duration, ReadyTimeout: readyTimeout, WDSContext: GetWDSContext()}
+			return handleDrillFailover(d, dryRun, outputFormat, reach, kubeconfig, remoteCtx)
+		},
+	}
+
+	cmd.Flags().StringVar(&policy, "policy", "", "BindingPolicy to drill")
+	cmd.Flags().StringSliceVar(&remove, "remove", nil, "clusters to take out of the policy")
+	cmd.Flags().DurationVar(&duration, "duration", 30*time.Minute, "how long the clusters stay out of the policy")
+	cmd.Flags().DurationVar(&readyTimeout, "ready-timeout", 5*time.Minute, "how long the workload may take to be Ready after each change")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only print the BindingPolicy as the drill would change it")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "output format of the report (json|yaml)")
+	reach.addFlags(cmd)
+
+	return cmd
+}
+
+func handleDrillFailover(d failoverDrill, dryRun bool, outputFormat string, reach reachability, kubeconfig, remoteCtx string) error {
+	if _, err := reach.controlPlane("WDS", kubeconfig, d.WDSContext, true); err != nil {
+		return err
+	}
+	if _, err := reach.controlPlane("ITS", kubeconfig, remoteCtx, true); err != nil {
+		return err
+	}
+	defer printClusterIssuesToStderr()
+
+	names, err := expandClusterNames(d.Remove)
+	if err != nil {
+		return err
+	}
+	d.Remove = names
+	sort.Strings(d.Remove)
+
+	wds, err := cluster.ClientForContext(kubeconfig, d.WDSContext)
+	if err != nil {
+		return err
+	}
+	d.WDS = wds
+
+	policy, err := d.WDS.DynamicClient.Resource(kubestellar.BindingPolicyGVR).Get(commandContext(), d.Policy, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get bindingpolicy %s in WDS %s: %v", d.Policy, d.WDSContext, err)
+	}
+	binding, err := d.WDS.DynamicClient.Resource(kubestellar.BindingGVR).Get(commandContext(), d.Policy, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get the binding of bindingpolicy %s in WDS %s: %v", d.Policy, d.WDSContext, err)
+	}
+	if err := checkFailoverTargets(kubestellar.BindingDestinations(binding), d.Remove); err != nil {
+		return fmt.Errorf("cannot drill bindingpolicy %s: %v", d.Policy, err)
+	}
+
+	mcs, err := cluster.ListManagedClusterObjects(commandContext(), kubeconfig, remoteCtx)
+	if err != nil {
+		return err
+	}
+	for _, name := range d.Remove {
+		if !managedClusterNamed(mcs, name) {
+			return fmt.Errorf("ManagedCluster %s has no %s=%s label for the policy to exclude it by", name, ocmClusterNameLabel, name)
+		}
+	}
+
+	excluded := policy.DeepCopy()
+	spec, err := excludeClusters(policySpec(policy), d.Remove)
+	if err != nil {
+		return fmt.Errorf("cannot drill bindingpolicy %s: %v", d.Policy, err)
+	}
+	excluded.Object["spec"] = spec
+	if dryRun {
+		return printPolicy(util.GetOutputStream(), excluded)
+	}
+
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	if clusters, err = reach.filter(clusters); err != nil {
+		return err
+	}
+	d.Clusters = map[string]cluster.ClusterInfo{}
+	for _, c := range clusters {
+		d.Clusters[c.Name] = c
+	}
+
+	// With -o the timeline still goes out as it happens, on stderr
+	progress := util.GetOutputStream()
+	if outputFormat != "" {
+		progress = os.Stderr
+	}
+	rec := startAudit("drill failover")
+	// The workload leaves the removed clusters for any other the policy selects
+	if err := guardManagedClusters(rec, blastRadius{Objects: 1}, policySelectedClusters([]*unstructured.Unstructured{policy}, mcs), remoteCtx); err != nil {
+		finishAudit(rec, err)
+		return err
+	}
+	report, err := runFailoverDrill(commandContext(), d, progress, rec)
+	finishAudit(rec, err)
+
+	if outputFormat != "" {
+		if perr := util.PrintStructured(util.GetOutputStream(), outputFormat, report); perr != nil {
+			return perr
+		}
+	} else {
+		fmt.Fprintf(util.GetOutputStream(), "Drill %s", report.Result)
+		if report.FailoverTime != "" {
+			fmt.Fprintf(util.GetOutputStream(), ": failover in %s", report.FailoverTime)
+		}
+		if report.RecoveryTime != "" {
+			fmt.Fprintf(util.GetOutputStream(), ", recovery in %s", report.RecoveryTime)
+		}
+		fmt.Fprintln(util.GetOutputStream())
+	}
+	return err
+}
+
+// runFailoverDrill excludes the clusters from the policy, waits for the
+// workload to be Ready elsewhere, holds for the duration of the drill and
+// restores the policy, whatever happened meanwhile. The policy is restored
+// even when ctx is cancelled; then the recovery is not waited for.
+func runFailoverDrill(ctx context.Context, d failoverDrill, out io.Writer, rec *audit.Recorder) (failoverReport, error) {
+	report := failoverReport{Policy: d.Policy, WDS: d.WDSContext, Removed: d.Remove, Duration: d.Duration.String(), Result: drillFailed, started: time.Now(), out: out}
+	removed := strings.Join(d.Remove, ", ")
+
+	if binding, err := d.WDS.DynamicClient.Resource(kubestellar.BindingGVR).Get(ctx, d.Policy, metav1.GetOptions{}); err == nil {
+		if ready, detail := workloadPlacementReady(binding, d.Clusters); !ready {
+			report.note("before the drill the workload is not ready: %s", detail)
+		}
+	}
+
+	if err := d.updatePolicy(ctx, out, excludeClusters, "drill failover: exclude "+removed); err != nil {
+		rec.Record(d.WDSContext, err)
+		report.note("failed to exclude %s: %v", removed, err)
+		return report, err
+	}
+	excludedAt := time.Now()
+	report.note("bindingpolicy %s excludes %s for %s", d.Policy, removed, d.Duration)
+
+	// Whatever happens from here on, the policy is restored
+	var failoverErr error
+	readyTimeout := d.ReadyTimeout
+	if readyTimeout > d.Duration {
+		readyTimeout = d.Duration
+	}
+	if err := d.waitPlacement(ctx, true, readyTimeout, &report); err != nil {
+		failoverErr = fmt.Errorf("workload of bindingpolicy %s not ready without %s: %v", d.Policy, removed, err)
+	} else {
+		report.FailoverTime = time.Since(excludedAt).Round(time.Second).String()
+	}
+
+	if failoverErr == nil {
+		hold := d.Duration - time.Since(excludedAt)
+		if hold > 0 {
+			report.note("holding %s out until %s", removed, time.Now().Add(hold).Format(time.Kitchen))
+			select {
+			case <-ctx.Done():
+				report.note("interrupted, restoring early")
+			case <-time.After(hold):
+			}
+		}
+	}
+
+	// Not ctx: the policy must be restored on Ctrl-C too
+	err := d.updatePolicy(context.TODO(), out, includeClusters, "drill failover: restore "+removed)
+	rec.Record(d.WDSContext, err)
+	if err != nil {
+		report.note("failed to restore bindingpolicy %s: %v", d.Policy, err)
+		fmt.Fprintf(os.Stderr, "Warning: bindingpolicy %s still excludes %s, revert it with: kubectl multi bp rollback %s\n", d.Policy, removed, d.Policy)
+		return report, err
+	}
+	restoredAt := time.Now()
+	report.note("bindingpolicy %s restored", d.Policy)
+
+	if failoverErr != nil {
+		return report, failoverErr
+	}
+	if ctx.Err() != nil {
+		report.note("recovery not verified: %v", context.Cause(ctx))
+		return report, context.Cause(ctx)
+	}
+	if err := d.waitPlacement(ctx, false, d.ReadyTimeout, &report); err != nil {
+		return report, fmt.Errorf("workload of bindingpolicy %s not back on %s: %v", d.Policy, removed, err)
+	}
+	report.RecoveryTime = time.Since(restoredAt).Round(time.Second).String()
+	report.Result = drillPassed
+	return report, nil
+}
+
+// updatePolicy rewrites the spec of the policy with change, retrying on
+// conflicts, and records the result as a revision
+func (d failoverDrill) updatePolicy(ctx context.Context, out io.Writer, change func(map[string]interface{}, []string) (map[string]interface{}, error), cause string) error {
+	policies := d.WDS.DynamicClient.Resource(kubestellar.BindingPolicyGVR)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		live, err := policies.Get(ctx, d.Policy, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		spec, err := change(policySpec(live), d.Remove)
+		if err != nil {
+			return err
+		}
+		updated := live.DeepCopy()
+		updated.Object["spec"] = spec
+		util.MarkManaged(updated)
+		if updated, err = policies.Update(ctx, updated, metav1.UpdateOptions{FieldManager: util.FieldManager}); err != nil {
+			return err
+		}
+		notePolicyRevision(out, d.WDS, live, updated, cause)
+		return nil
+	})
+}
+
+// waitPlacement polls until the Binding of the policy no longer lists the
+// removed clusters (excluded) or lists them again (!excluded), and the
+// workload is Ready in every destination
+func (d failoverDrill) waitPlacement(ctx context.Context, excluded bool, timeout time.Duration, report *failoverReport) error {
+	bindingSeen := false
+	var detail string
+	err := wait.PollUntilContextTimeout(ctx, drillPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		binding, err := d.WDS.DynamicClient.Resource(kubestellar.BindingGVR).Get(ctx, d.Policy, metav1.GetOptions{})
+		if err != nil {
+			detail = err.Error()
+			return false, nil
+		}
+		destinations := kubestellar.BindingDestinations(binding)
+		if !bindingSeen {
+			if listed := listsAny(destinations, d.Remove); listed == excluded {
+				detail = "the binding does not reflect the change yet"
+				return false, nil
+			}
+			bindingSeen = true
+			report.note("binding %s lists %s", d.Policy, orNone(strings.Join(destinations, ", ")))
+		}
+		var ready bool
+		ready, detail = workloadPlacementReady(binding, d.Clusters)
+		if ready {
+			report.note("workload ready on %s", strings.Join(destinations, ", "))
+		}
+		return ready, nil
+	})
+	if err != nil && detail != "" {
+		return fmt.Errorf("%s", detail)
+	}
+	return err
+}
+
+// workloadPlacementReady reports whether every object of the workload of
+// the Binding is Ready in every destination cluster, or else the first
+// copy that is not
+func workloadPlacementReady(binding *unstructured.Unstructured, clusters map[string]cluster.ClusterInfo) (bool, string) {
+	destinations := kubestellar.BindingDestinations(binding)
+	if len(destinations) == 0 {
+		return false, "the binding has no destination clusters"
+	}
+	for _, name := range destinations {
+		c, ok := clusters[name]
+		if !ok || c.DynamicClient == nil {
+			return false, fmt.Sprintf("cluster %s is not reachable", name)
+		}
+		for _, ref := range kubestellar.BindingWorkload(binding) {
+			gvr := schema.GroupVersionResource{Group: ref.Group, Version: ref.Version, Resource: ref.Resource}
+			if s := readClusterCopy(c, gvr, ref); s.Status != copyReady {
+				detail := fmt.Sprintf("%s is %s in cluster %s", ref, s.Status, name)
+				if s.Detail != "" {
+					detail += ": " + s.Detail
+				}
+				return false, detail
+			}
+		}
+	}
+	return true, ""
+}
+
+// checkFailoverTargets checks the removed clusters are destinations of the
+// policy and that others remain to fail over to
+func checkFailoverTargets(destinations, removed []string) error {
+	for _, name := range removed {
+		if !listsAny(destinations, []string{name}) {
+			return fmt.Errorf("cluster %s is not one of its destinations (%s)", name, orNone(strings.Join(destinations, ", ")))
+		}
+	}
+	if len(destinations) <= len(removed) {
+		return fmt.Errorf("removing %s leaves no cluster to fail over to", strings.Join(removed, ", "))
+	}
+	return nil
+}
+
+// listsAny reports whether any of names is in list
+func listsAny(list, names []string) bool {
+	for _, l := range list {
+		for _, n := range names {
+			if l == n {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// managedClusterNamed reports whether mcs holds the ManagedCluster name with
+// the OCM cluster name label the exclusion matches
+func managedClusterNamed(mcs []unstructured.Unstructured, name string) bool {
+	for _, mc := range mcs {
+		if mc.GetName() == name {
+			return mc.GetLabels()[ocmClusterNameLabel] == name
+		}
+	}
+	return false
+}
+
+// drillExclusion is the selector requirement a drill adds to exclude clusters
+func drillExclusion(clusters []string) map[string]interface{} {
+	values := make([]interface{}, 0, len(clusters))
+	for _, c := range clusters {
+		values = append(values, c)
+	}
+	return map[string]interface{}{"key": ocmClusterNameLabel, "operator": string(metav1.LabelSelectorOpNotIn), "values": values}
+}
+
+// excludeClusters changes every cluster selector of a BindingPolicy spec to
+// also require the cluster not to be one of clusters, and returns the spec
+func excludeClusters(spec map[string]interface{}, clusters []string) (map[string]interface{}, error) {
+	selectors, _, _ := unstructured.NestedSlice(spec, "clusterSelectors")
+	if len(selectors) == 0 {
+		return nil, fmt.Errorf("the policy has no cluster selectors")
+	}
+	for i := range selectors {
+		selector, ok := selectors[i].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid cluster selector %v", selectors[i])
+		}
+		exprs, _, _ := unstructured.NestedSlice(selector, "matchExpressions")
+		selector["matchExpressions"] = append(exprs, drillExclusion(clusters))
+	}
+	if err := unstructured.SetNestedSlice(spec, selectors, "clusterSelectors"); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// includeClusters undoes excludeClusters, removing exactly the requirement
+// it added from every cluster selector
+func includeClusters(spec map[string]interface{}, clusters []string) (map[string]interface{}, error) {
+	exclusion := drillExclusion(clusters)
+	selectors, _, _ := unstructured.NestedSlice(spec, "clusterSelectors")
+	for i := range selectors {
+		selector, ok := selectors[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		exprs, _, _ := unstructured.NestedSlice(selector, "matchExpressions")
+		var kept []interface{}
+		for _, e := range exprs {
+			if !reflect.DeepEqual(e, exclusion) {
+				kept = append(kept, e)
+			}
+		}
+		if len(kept) == 0 {
+			delete(selector, "matchExpressions")
+		} else {
+			selector["matchExpressions"] = kept
+		}
+	}
+	if len(selectors) > 0 {
+		if err := unstructured.SetNestedSlice(spec, selectors, "clusterSelectors"); err != nil {
+			return nil, err
+		}
+	}
+	return spec, nil
+}
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+)
+
+func TestExcludeClusters(t *testing.T) {
+	original := policySpec(testBindingPolicy("app", map[string]interface{}{"env": "prod"}))
+
+	excluded, err := excludeClusters(policySpec(testBindingPolicy("app", map[string]interface{}{"env": "prod"})), []string{"cluster1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{map[string]interface{}{
+		"matchLabels": map[string]interface{}{"env": "prod"},
+		"matchExpressions": []interface{}{map[string]interface{}{
+			"key": ocmClusterNameLabel, "operator": "NotIn", "values": []interface{}{"cluster1"},
+		}},
+	}}
+	if !reflect.DeepEqual(excluded["clusterSelectors"], want) {
+		t.Errorf("excludeClusters() = %v, want %v", excluded["clusterSelectors"], want)
+	}
+
+	restored, err := includeClusters(excluded, []string{"cluster1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(restored, original) {
+		t.Errorf("includeClusters() = %v, want the original %v", restored, original)
+	}
+
+	// Expressions added by others meanwhile are kept
+	excluded, _ = excludeClusters(policySpec(testBindingPolicy("app", nil)), []string{"cluster1"})
+	selector := excluded["clusterSelectors"].([]interface{})[0].(map[string]interface{})
+	other := map[string]interface{}{"key": "tier", "operator": "Exists"}
+	selector["matchExpressions"] = append(selector["matchExpressions"].([]interface{}), other)
+	restored, _ = includeClusters(excluded, []string{"cluster1"})
+	selector = restored["clusterSelectors"].([]interface{})[0].(map[string]interface{})
+	if !reflect.DeepEqual(selector["matchExpressions"], []interface{}{other}) {
+		t.Errorf("includeClusters() kept %v", selector["matchExpressions"])
+	}
+
+	if _, err := excludeClusters(map[string]interface{}{}, []string{"cluster1"}); err == nil {
+		t.Error("excludeClusters() of a policy without selectors succeeded")
+	}
+}
+
+func TestCheckFailoverTargets(t *testing.T) {
+	tests := []struct {
+		name    string
+		removed []string
+		wantErr string
+	}{
+		{name: "one of two", removed: []string{"cluster1"}},
+		{name: "not a destination", removed: []string{"cluster3"}, wantErr: "cluster cluster3 is not one of its destinations (cluster1, cluster2)"},
+		{name: "all of them", removed: []string{"cluster1", "cluster2"}, wantErr: "removing cluster1, cluster2 leaves no cluster to fail over to"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkFailoverTargets([]string{"cluster1", "cluster2"}, tt.removed)
+			if (err == nil) != (tt.wantErr == "") || (err != nil && err.Error() != tt.wantErr) {
+				t.Errorf("checkFailoverTargets() = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// testDrillCluster returns a WEC holding a ready copy of deployment web/nginx
+func testDrillCluster(name string) cluster.ClusterInfo {
+	deployment := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"namespace": "web", "name": "nginx"},
+		"spec":       map[string]interface{}{"replicas": int64(1)},
+		"status":     map[string]interface{}{"replicas": int64(1), "readyReplicas": int64(1), "updatedReplicas": int64(1), "availableReplicas": int64(1)},
+	}}
+	return cluster.ClusterInfo{Name: name, DynamicClient: fakedynamic.NewSimpleDynamicClient(runtime.NewScheme(), deployment)}
+}
+
+func TestRunFailoverDrill(t *testing.T) {
+	defer func(interval time.Duration) { drillPollInterval = interval }(drillPollInterval)
+	drillPollInterval = 10 * time.Millisecond
+
+	policy := testBindingPolicy("app", map[string]interface{}{"env": "prod"})
+	wds := testWDSDynamic(policy, testWorkloadBinding("app", 1, 1, "cluster1", "cluster2", "cluster3"))
+	// Stand in for the KubeStellar controller: the Binding follows the
+	// exclusion of the policy
+	wds.PrependReactor("update", "bindingpolicies", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		updated := action.(clienttesting.UpdateAction).GetObject().(*unstructured.Unstructured)
+		excluded := map[string]bool{}
+		selectors, _, _ := unstructured.NestedSlice(updated.Object, "spec", "clusterSelectors")
+		exprs, _, _ := unstructured.NestedSlice(selectors[0].(map[string]interface{}), "matchExpressions")
+		for _, e := range exprs {
+			for _, v := range e.(map[string]interface{})["values"].([]interface{}) {
+				excluded[v.(string)] = true
+			}
+		}
+		var destinations []string
+		for _, c := range []string{"cluster1", "cluster2", "cluster3"} {
+			if !excluded[c] {
+				destinations = append(destinations, c)
+			}
+		}
+		binding := testWorkloadBinding("app", 1, 1, destinations...)
+		if err := wds.Tracker().Update(kubestellar.BindingGVR, binding, ""); err != nil {
+			t.Fatal(err)
+		}
+		return false, nil, nil
+	})
+
+	d := failoverDrill{
+		Policy: "app", Remove: []string{"cluster1"}, Duration: 50 * time.Millisecond, ReadyTimeout: time.Second,
+		WDSContext: "wds1", WDS: &cluster.ClusterInfo{Name: "wds1", Context: "wds1", DynamicClient: wds},
+		Clusters: map[string]cluster.ClusterInfo{
+			"cluster1": testDrillCluster("cluster1"), "cluster2": testDrillCluster("cluster2"), "cluster3": testDrillCluster("cluster3"),
+		},
+	}
+	var out bytes.Buffer
+	report, err := runFailoverDrill(context.TODO(), d, &out, nil)
+	if err != nil {
+		t.Fatalf("runFailoverDrill() error = %v, output %q", err, out.String())
+	}
+	if report.Result != drillPassed || report.FailoverTime == "" || report.RecoveryTime == "" {
+		t.Errorf("report = %+v", report)
+	}
+	var events []string
+	for _, e := range report.Timeline {
+		events = append(events, e.Event)
+	}
+	want := []string{
+		"bindingpolicy app excludes cluster1 for 50ms",
+		"binding app lists cluster2, cluster3",
+		"workload ready on cluster2, cluster3",
+	}
+	if len(events) < 3 || !reflect.DeepEqual(events[:3], want) {
+		t.Errorf("timeline = %v, want it to start with %v", events, want)
+	}
+	if last := events[len(events)-1]; last != "workload ready on cluster1, cluster2, cluster3" {
+		t.Errorf("timeline ends with %q", last)
+	}
+	if !strings.Contains(out.String(), "] bindingpolicy app restored\n") {
+		t.Errorf("output %q does not show the restore", out.String())
+	}
+
+	live, err := wds.Resource(kubestellar.BindingPolicyGVR).Get(context.TODO(), "app", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(policySpec(live), policySpec(policy)) {
+		t.Errorf("policy spec after the drill = %v, want %v", policySpec(live), policySpec(policy))
+	}
+}
+
+func TestRunFailoverDrillNotReadyElsewhere(t *testing.T) {
+	defer func(interval time.Duration) { drillPollInterval = interval }(drillPollInterval)
+	drillPollInterval = 10 * time.Millisecond
+
+	policy := testBindingPolicy("app", map[string]interface{}{"env": "prod"})
+	// No controller updates the Binding, so the drill times out and restores
+	wds := testWDSDynamic(policy, testWorkloadBinding("app", 1, 1, "cluster1", "cluster2"))
+	d := failoverDrill{
+		Policy: "app", Remove: []string{"cluster1"}, Duration: time.Minute, ReadyTimeout: 50 * time.Millisecond,
+		WDSContext: "wds1", WDS: &cluster.ClusterInfo{Name: "wds1", Context: "wds1", DynamicClient: wds},
+		Clusters: map[string]cluster.ClusterInfo{"cluster1": testDrillCluster("cluster1"), "cluster2": testDrillCluster("cluster2")},
+	}
+	report, err := runFailoverDrill(context.TODO(), d, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "not ready without cluster1: the binding does not reflect the change yet") {
+		t.Fatalf("runFailoverDrill() error = %v", err)
+	}
+	if report.Result != drillFailed {
+		t.Errorf("result = %s, want %s", report.Result, drillFailed)
+	}
+	live, _ := wds.Resource(kubestellar.BindingPolicyGVR).Get(context.TODO(), "app", metav1.GetOptions{})
+	if !reflect.DeepEqual(policySpec(live), policySpec(policy)) {
+		t.Errorf("policy not restored: %v", policySpec(live))
+	}
+}
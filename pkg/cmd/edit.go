@@ -0,0 +1,374 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/platform"
+	"kubectl-multi/pkg/util"
+)
+
+// editOptions holds the flags of edit
+type editOptions struct {
+	Cluster   string
+	Propagate bool
+	Force     bool
+}
+
+// editFunc lets the user change a YAML document and returns the result
+type editFunc func(data []byte) ([]byte, error)
+
+// editCopy is one cluster's copy of the edited object
+type editCopy struct {
+	Cluster cluster.ClusterInfo
+	GVR     schema.GroupVersionResource
+	Client  dynamic.ResourceInterface
+	Live    *unstructured.Unstructured
+}
+
+func newEditCommand() *cobra.Command {
+	var o editOptions
+
+	cmd := &cobra.Command{
+		Use:   "edit (TYPE/NAME | TYPE NAME)",
+		Short: "Edit a resource in one managed cluster and optionally propagate the change",
+		Long: `Edit a resource in one managed cluster and optionally propagate the change.
+The object is fetched from the cluster given with --cluster, or from the only
+cluster that has it. When several clusters have it and no --cluster is given,
+a terminal prompt asks which one to edit.
+
+The object opens in $KUBE_EDITOR, $EDITOR or vi. Saving applies the difference
+as a patch (a strategic merge patch for built-in types, a JSON merge patch
+otherwise). With --propagate the same patch is applied to every other cluster
+that has the object, so only the edited fields change there.`,
+		Example: `# Edit a deployment in cluster1
+kubectl multi edit deployment/nginx -n prod --cluster cluster1
+
+# Edit it once and apply the same change wherever it exists
+kubectl multi edit deployment nginx -n prod --cluster cluster1 --propagate`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resourceType, name, err := parseTypeName(args)
+			if err != nil {
+				return err
+			}
+			kubeconfig, remoteCtx, _, namespace, _ := GetGlobalFlags()
+			rec := startAudit("edit")
+			err = handleEditCommand(resourceType, name, o, rec, kubeconfig, remoteCtx, namespace)
+			finishAudit(rec, err)
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&o.Cluster, "cluster", "", "managed cluster to fetch and edit the resource in")
+	cmd.Flags().BoolVar(&o.Propagate, "propagate", false, "apply the same change to every other cluster that has the resource")
+	cmd.Flags().BoolVar(&o.Force, "force", false, "edit objects that KubeStellar downsyncs to the cluster, although the next downsync reverts the change")
+
+	return cmd
+}
+
+func handleEditCommand(resourceType, name string, o editOptions, rec *audit.Recorder, kubeconfig, remoteCtx, namespace string) error {
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	var managed []cluster.ClusterInfo
+	for _, c := range clusters {
+		if c.Context != remoteCtx {
+			managed = append(managed, c)
+		}
+	}
+
+	var p *prompter
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		p = &prompter{in: bufio.NewReader(os.Stdin), out: os.Stdout}
+	}
+	defer printClusterIssuesToStderr()
+	return editResource(managed, resourceType, name, namespace, o, runEditor, p, rec, os.Stdout)
+}
+
+// editResource edits the object in the chosen cluster and, with --propagate,
+// applies the resulting patch to the other clusters that have it
+func editResource(clusters []cluster.ClusterInfo, resourceType, name, namespace string, o editOptions, edit editFunc, p *prompter, rec *audit.Recorder, out io.Writer) error {
+	if o.Cluster != "" {
+		if _, err := cluster.SelectClusters(clusters, []string{o.Cluster}); err != nil {
+			return err
+		}
+	}
+	copies := findEditCopies(clusters, resourceType, name, namespace)
+	chosen, err := chooseEditCopy(copies, resourceType+"/"+name, o.Cluster, p)
+	if err != nil {
+		return err
+	}
+	target := copies[chosen]
+	ref := qualifiedName(target.GVR, target.Live)
+	if err := guardDownsynced(ref, target.Cluster.Name, target.Live, o.Force, out); err != nil {
+		return err
+	}
+
+	original, modified, err := editObject(target.Live, ref, target.Cluster.Name, edit)
+	if err != nil {
+		return err
+	}
+	var patchType types.PatchType
+	var patch []byte
+	if modified != nil {
+		if patchType, patch, err = editPatch(target.Live.GroupVersionKind(), original, modified); err != nil {
+			return fmt.Errorf("failed to create patch for %s: %v", ref, err)
+		}
+	}
+	if modified == nil || string(patch) == "{}" {
+		fmt.Fprintln(out, "Edit cancelled, no changes made.")
+		return nil
+	}
+
+	if o.Propagate {
+		reached := make([]cluster.ClusterInfo, 0, len(copies))
+		for _, c := range copies {
+			reached = append(reached, c.Cluster)
+		}
+		radius := blastRadius{Objects: len(copies)}
+		if ns := target.Live.GetNamespace(); ns != "" {
+			radius.Namespaces = []string{ns}
+		}
+		if err := guardClusters(rec, radius, reached); err != nil {
+			return err
+		}
+	} else if err := acquireFleetLock(rec); err != nil {
+		return err
+	}
+	if err := patchEditCopy(target, patchType, patch, rec); err != nil {
+		return fmt.Errorf("failed to patch %s in cluster %s: %v", ref, target.Cluster.Name, err)
+	}
+	fmt.Fprintf(out, "%s edited in cluster %s\n", ref, target.Cluster.Name)
+	if !o.Propagate {
+		return nil
+	}
+
+	failed := 0
+	for i, c := range copies {
+		if i == chosen {
+			continue
+		}
+		err := guardDownsynced(ref, c.Cluster.Name, c.Live, o.Force, out)
+		if err == nil {
+			err = patchEditCopy(c, patchType, patch, rec)
+		}
+		switch {
+		case apierrors.IsNotFound(err):
+			fmt.Fprintf(out, "%s no longer exists in cluster %s, skipped\n", ref, c.Cluster.Name)
+		case err != nil:
+			failed++
+			noteClusterIssue(c.Cluster.Name, fmt.Sprintf("failed to patch %s: %v", ref, err))
+		default:
+			fmt.Fprintf(out, "%s edited in cluster %s\n", ref, c.Cluster.Name)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to propagate the edit to %d of %d clusters", failed, len(copies)-1)
+	}
+	return nil
+}
+
+// findEditCopies fetches the object from every cluster that serves the type;
+// clusters where it does not exist are left out
+func findEditCopies(clusters []cluster.ClusterInfo, resourceType, name, namespace string) []editCopy {
+	var copies []editCopy
+	for _, c := range clusters {
+		if c.DynamicClient == nil || c.DiscoveryClient == nil {
+			noteClusterIssue(c.Name, "no client available")
+			continue
+		}
+		gvr, namespaced, err := util.DiscoverGVR(c.DiscoveryClient, resourceType)
+		if err != nil {
+			continue
+		}
+		var client dynamic.ResourceInterface = c.DynamicClient.Resource(gvr)
+		if namespaced {
+			client = c.DynamicClient.Resource(gvr).Namespace(cluster.GetTargetNamespace(namespace))
+		}
+		live, err := client.Get(commandContext(), name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			noteClusterIssue(c.Name, fmt.Sprintf("failed to get %s/%s: %v", resourceType, name, err))
+			continue
+		}
+		copies = append(copies, editCopy{Cluster: c, GVR: gvr, Client: client, Live: live})
+	}
+	return copies
+}
+
+// chooseEditCopy returns the copy named by --cluster, the only copy, or the
+// one picked at the prompt
+func chooseEditCopy(copies []editCopy, ref, clusterName string, p *prompter) (int, error) {
+	if clusterName != "" {
+		for i, c := range copies {
+			if c.Cluster.Name == clusterName {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("%s not found in cluster %s", ref, clusterName)
+	}
+	switch len(copies) {
+	case 0:
+		return 0, fmt.Errorf("%s not found in any cluster", ref)
+	case 1:
+		return 0, nil
+	}
+
+	names := make([]string, len(copies))
+	for i, c := range copies {
+		names[i] = c.Cluster.Name
+	}
+	if p == nil {
+		return 0, fmt.Errorf("%s exists in clusters %s; choose one with --cluster", ref, strings.Join(names, ", "))
+	}
+	fmt.Fprintf(p.out, "%s exists in %d clusters:\n", ref, len(names))
+	for i, n := range names {
+		fmt.Fprintf(p.out, "  %d) %s\n", i+1, n)
+	}
+	for {
+		picked, err := p.pick("Cluster to edit: ", len(names), false)
+		if err != nil {
+			return 0, err
+		}
+		if len(picked) == 1 {
+			return picked[0], nil
+		}
+		fmt.Fprintln(p.out, "choose a single cluster")
+	}
+}
+
+// editObject hands the object to the editor as YAML and returns it before and
+// after the edit as JSON. modified is nil when the edit was cancelled.
+func editObject(live *unstructured.Unstructured, ref, clusterName string, edit editFunc) (original, modified []byte, err error) {
+	obj := live.DeepCopy()
+	unstructured.RemoveNestedField(obj.Object, "metadata", "managedFields")
+	if original, err = json.Marshal(obj.Object); err != nil {
+		return nil, nil, err
+	}
+	data, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header := fmt.Sprintf("# Editing %s in cluster %s.\n# Lines beginning with '#' are ignored, and an empty file cancels the edit.\n#\n", ref, clusterName)
+	edited, err := edit(append([]byte(header), data...))
+	if err != nil {
+		return nil, nil, err
+	}
+	edited = stripEditComments(edited)
+	if len(bytes.TrimSpace(edited)) == 0 {
+		return original, nil, nil
+	}
+
+	var changed map[string]interface{}
+	if err := yaml.Unmarshal(edited, &changed); err != nil {
+		return nil, nil, fmt.Errorf("edited %s is not valid YAML: %v", ref, err)
+	}
+	u := &unstructured.Unstructured{Object: changed}
+	if u.GetName() != obj.GetName() || u.GetNamespace() != obj.GetNamespace() ||
+		u.GetKind() != obj.GetKind() || u.GetAPIVersion() != obj.GetAPIVersion() {
+		return nil, nil, fmt.Errorf("the apiVersion, kind, name and namespace of %s cannot be edited", ref)
+	}
+	if modified, err = json.Marshal(changed); err != nil {
+		return nil, nil, err
+	}
+	return original, modified, nil
+}
+
+// stripEditComments drops the full-line comments the editor was given or the
+// user added
+func stripEditComments(data []byte) []byte {
+	var kept [][]byte
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if bytes.HasPrefix(bytes.TrimSpace(line), []byte("#")) {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return bytes.Join(kept, []byte("\n"))
+}
+
+// editPatch returns a strategic merge patch for types known to the client
+// scheme and a JSON merge patch for everything else, such as custom resources
+func editPatch(gvk schema.GroupVersionKind, original, modified []byte) (types.PatchType, []byte, error) {
+	if typed, err := scheme.Scheme.New(gvk); err == nil {
+		patch, err := strategicpatch.CreateTwoWayMergePatch(original, modified, typed)
+		return types.StrategicMergePatchType, patch, err
+	}
+	patch, err := jsonpatch.CreateMergePatch(original, modified)
+	return types.MergePatchType, patch, err
+}
+
+// patchEditCopy applies the patch to one copy and records how to restore it
+func patchEditCopy(c editCopy, patchType types.PatchType, patch []byte, rec *audit.Recorder) error {
+	patch, err := util.ManagedPatch(patchType, patch, c.Live)
+	if err != nil {
+		return err
+	}
+	_, err = c.Client.Patch(commandContext(), c.Live.GetName(), patchType, patch, metav1.PatchOptions{FieldManager: util.FieldManager})
+	if apierrors.IsNotFound(err) {
+		return err
+	}
+	rec.Record(c.Cluster.Name, err)
+	if err != nil {
+		return err
+	}
+	recordRestoreUndo(rec, c.Cluster.Name, c.GVR, c.Live.GetNamespace(), c.Live, util.DryRunNone)
+	return nil
+}
+
+// runEditor opens the document in $KUBE_EDITOR, $EDITOR or the platform's
+// default editor (vi, or notepad on Windows) and returns the saved file
+func runEditor(data []byte) ([]byte, error) {
+	editor := os.Getenv("KUBE_EDITOR")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = platform.DefaultEditor
+	}
+
+	f, err := platform.CreateTemp("kubectl-multi-edit-*.yaml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to write temporary file: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write temporary file: %v", err)
+	}
+
+	cmd := platform.ShellCommand(editor, f.Name())
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("editor %q failed: %v", editor, err)
+	}
+	return os.ReadFile(f.Name())
+}
@@ -0,0 +1,372 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	goruntime "runtime"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+	"kubectl-multi/pkg/util"
+)
+
+// testConfigMap returns a configmap with a single data entry
+func testConfigMap(name, value string) *unstructured.Unstructured {
+	obj := testObject("v1", "ConfigMap", "default", name)
+	_ = unstructured.SetNestedField(obj.Object, value, "data", "key")
+	return obj
+}
+
+// serveConfigMapPatches applies strategic merge patches to configmaps, which
+// the fake dynamic client cannot do for unstructured objects
+func serveConfigMapPatches(dyn *fakedynamic.FakeDynamicClient) {
+	dyn.PrependReactor("patch", "configmaps", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		patch := action.(clienttesting.PatchAction)
+		if patch.GetPatchType() != types.StrategicMergePatchType {
+			return false, nil, nil
+		}
+		gvr := patch.GetResource()
+		current, err := dyn.Tracker().Get(gvr, patch.GetNamespace(), patch.GetName())
+		if err != nil {
+			return true, nil, err
+		}
+		original, err := runtime.DefaultUnstructuredConverter.ToUnstructured(current)
+		if err != nil {
+			return true, nil, err
+		}
+		patched, err := strategicpatch.StrategicMergeMapPatch(original, mustUnmarshalPatch(patch.GetPatch()), &corev1.ConfigMap{})
+		if err != nil {
+			return true, nil, err
+		}
+		obj := &unstructured.Unstructured{Object: patched}
+		return true, obj, dyn.Tracker().Update(gvr, obj, patch.GetNamespace())
+	})
+}
+
+func mustUnmarshalPatch(data []byte) map[string]interface{} {
+	patch := map[string]interface{}{}
+	if err := json.Unmarshal(data, &patch); err != nil {
+		panic(err)
+	}
+	return patch
+}
+
+// replaceEditor returns an editor that replaces old with new in the document
+func replaceEditor(old, new string) editFunc {
+	return func(data []byte) ([]byte, error) {
+		return bytes.ReplaceAll(data, []byte(old), []byte(new)), nil
+	}
+}
+
+func TestStripEditComments(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "header", in: "# Editing\n#\nkind: ConfigMap\n", want: "kind: ConfigMap\n"},
+		{name: "indented comment", in: "data:\n  # note\n  key: v\n", want: "data:\n  key: v\n"},
+		{name: "hash inside value kept", in: "key: a#b\n", want: "key: a#b\n"},
+		{name: "only comments", in: "# a\n# b", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(stripEditComments([]byte(tt.in))); got != tt.want {
+				t.Errorf("stripEditComments() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEditPatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		gvk       schema.GroupVersionKind
+		original  string
+		modified  string
+		wantType  types.PatchType
+		wantPatch string
+	}{
+		{
+			name:      "built-in type",
+			gvk:       schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+			original:  `{"spec":{"replicas":1,"paused":false}}`,
+			modified:  `{"spec":{"replicas":3,"paused":false}}`,
+			wantType:  types.StrategicMergePatchType,
+			wantPatch: `{"spec":{"replicas":3}}`,
+		},
+		{
+			name:      "removed field",
+			gvk:       schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+			original:  `{"data":{"a":"1","b":"2"}}`,
+			modified:  `{"data":{"a":"1"}}`,
+			wantType:  types.StrategicMergePatchType,
+			wantPatch: `{"data":{"b":null}}`,
+		},
+		{
+			name:      "custom resource",
+			gvk:       schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"},
+			original:  `{"spec":{"size":1,"color":"red"}}`,
+			modified:  `{"spec":{"size":2,"color":"red"}}`,
+			wantType:  types.MergePatchType,
+			wantPatch: `{"spec":{"size":2}}`,
+		},
+		{
+			name:      "no change",
+			gvk:       schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+			original:  `{"data":{"a":"1"}}`,
+			modified:  `{"data":{"a":"1"}}`,
+			wantType:  types.StrategicMergePatchType,
+			wantPatch: `{}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotType, gotPatch, err := editPatch(tt.gvk, []byte(tt.original), []byte(tt.modified))
+			if err != nil {
+				t.Fatalf("editPatch() error = %v", err)
+			}
+			if gotType != tt.wantType || string(gotPatch) != tt.wantPatch {
+				t.Errorf("editPatch() = %s %s, want %s %s", gotType, gotPatch, tt.wantType, tt.wantPatch)
+			}
+		})
+	}
+}
+
+func TestChooseEditCopy(t *testing.T) {
+	copies := []editCopy{
+		{Cluster: cluster.ClusterInfo{Name: "cluster1"}},
+		{Cluster: cluster.ClusterInfo{Name: "cluster2"}},
+		{Cluster: cluster.ClusterInfo{Name: "cluster3"}},
+	}
+	tests := []struct {
+		name    string
+		copies  []editCopy
+		cluster string
+		input   *string
+		want    int
+		wantErr string
+	}{
+		{name: "named cluster", copies: copies, cluster: "cluster2", want: 1},
+		{name: "named cluster without the object", copies: copies[:1], cluster: "cluster2", wantErr: "not found in cluster cluster2"},
+		{name: "no copies", wantErr: "not found in any cluster"},
+		{name: "single copy", copies: copies[2:], want: 0},
+		{name: "several copies without a terminal", copies: copies, wantErr: "choose one with --cluster"},
+		{name: "picked at the prompt", copies: copies, input: stringPtr("9\n1,2\n3\n"), want: 2},
+		{name: "prompt input ends", copies: copies, input: stringPtr(""), wantErr: "no answer given"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p *prompter
+			if tt.input != nil {
+				p = &prompter{in: bufio.NewReader(strings.NewReader(*tt.input)), out: io.Discard}
+			}
+			got, err := chooseEditCopy(tt.copies, "configmaps/app", tt.cluster, p)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("chooseEditCopy() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("chooseEditCopy() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("chooseEditCopy() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func TestEditResource(t *testing.T) {
+	tests := []struct {
+		name       string
+		object     string
+		downsynced bool
+		opts       editOptions
+		edit       editFunc
+		wantData   map[string]string
+		wantOut    string
+		wantUndo   int
+		wantErr    string
+	}{
+		{
+			name:     "edit one cluster",
+			opts:     editOptions{Cluster: "cluster1"},
+			edit:     replaceEditor("key: old", "key: new"),
+			wantData: map[string]string{"cluster1": "new", "cluster2": "old"},
+			wantOut:  "configmap/app edited in cluster cluster1\n",
+			wantUndo: 1,
+		},
+		{
+			name:     "propagate",
+			opts:     editOptions{Cluster: "cluster2", Propagate: true},
+			edit:     replaceEditor("key: old", "key: new"),
+			wantData: map[string]string{"cluster1": "new", "cluster2": "new"},
+			wantOut:  "configmap/app edited in cluster cluster2\nconfigmap/app edited in cluster cluster1\n",
+			wantUndo: 2,
+		},
+		{
+			name:     "unchanged",
+			opts:     editOptions{Cluster: "cluster1", Propagate: true},
+			edit:     replaceEditor("key: old", "key: old"),
+			wantData: map[string]string{"cluster1": "old", "cluster2": "old"},
+			wantOut:  "Edit cancelled, no changes made.\n",
+		},
+		{
+			name: "emptied",
+			opts: editOptions{Cluster: "cluster1"},
+			edit: func(data []byte) ([]byte, error) {
+				return stripEditComments(data)[:0], nil
+			},
+			wantData: map[string]string{"cluster1": "old", "cluster2": "old"},
+			wantOut:  "Edit cancelled, no changes made.\n",
+		},
+		{
+			name:     "renamed",
+			opts:     editOptions{Cluster: "cluster1"},
+			edit:     replaceEditor("name: app", "name: other"),
+			wantData: map[string]string{"cluster1": "old", "cluster2": "old"},
+			wantErr:  "cannot be edited",
+		},
+		{
+			name:       "downsynced",
+			downsynced: true,
+			opts:       editOptions{Cluster: "cluster1"},
+			edit:       replaceEditor("key: old", "key: new"),
+			wantData:   map[string]string{"cluster1": "old", "cluster2": "old"},
+			wantErr:    "use --force",
+		},
+		{
+			name:       "downsynced with force",
+			downsynced: true,
+			opts:       editOptions{Cluster: "cluster2", Propagate: true, Force: true},
+			edit:       replaceEditor("key: old", "key: new"),
+			wantData:   map[string]string{"cluster1": "new", "cluster2": "new"},
+			wantOut:    "configmap/app edited in cluster cluster2\nWarning: configmap/app in cluster cluster1 is managed by KubeStellar (Binding app-bpolicy); direct changes are overwritten by the next downsync, change the object in the WDS instead\nconfigmap/app edited in cluster cluster1\n",
+			wantUndo:   2,
+		},
+		{
+			name:     "several clusters without --cluster",
+			edit:     replaceEditor("key: old", "key: new"),
+			wantData: map[string]string{"cluster1": "old", "cluster2": "old"},
+			wantErr:  "choose one with --cluster",
+		},
+		{
+			name:     "unknown cluster",
+			opts:     editOptions{Cluster: "cluster9"},
+			edit:     replaceEditor("key: old", "key: new"),
+			wantData: map[string]string{"cluster1": "old", "cluster2": "old"},
+			wantErr:  "not a discovered managed cluster",
+		},
+		{
+			name:     "missing everywhere",
+			object:   "absent",
+			edit:     replaceEditor("key: old", "key: new"),
+			wantData: map[string]string{"cluster1": "old", "cluster2": "old"},
+			wantErr:  "not found in any cluster",
+		},
+	}
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	// Without a terminal the propagated edits need confirming up front
+	defer func(yes bool) { assumeYes = yes }(assumeYes)
+	assumeYes = true
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer resetClusterIssues()
+			cm := testConfigMap("app", "old")
+			if tt.downsynced {
+				cm.SetLabels(map[string]string{kubestellar.OriginBindingLabel: "app-bpolicy"})
+			}
+			c1, dyn1 := testClusterInfo(cm)
+			c2, dyn2 := testClusterInfo(testConfigMap("app", "old"))
+			serveConfigMapPatches(dyn1)
+			serveConfigMapPatches(dyn2)
+			c3, _ := testClusterInfo()
+			c2.Name, c3.Name = "cluster2", "cluster3"
+			clusters := []cluster.ClusterInfo{c1, c2, c3}
+			name := "app"
+			if tt.object != "" {
+				name = tt.object
+			}
+
+			var out bytes.Buffer
+			rec := audit.Start("edit", nil)
+			err := editResource(clusters, "configmaps", name, "default", tt.opts, tt.edit, nil, rec, &out)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("editResource() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("editResource() error = %v", err)
+			}
+			if out.String() != tt.wantOut {
+				t.Errorf("output = %q, want %q", out.String(), tt.wantOut)
+			}
+			if got := len(rec.Finish(err).Undo); got != tt.wantUndo {
+				t.Errorf("undo steps = %d, want %d", got, tt.wantUndo)
+			}
+			for _, c := range clusters[:2] {
+				obj, err := c.DynamicClient.Resource(gvr).Namespace("default").Get(context.TODO(), "app", metav1.GetOptions{})
+				if err != nil {
+					t.Fatalf("get in %s: %v", c.Name, err)
+				}
+				got, _, _ := unstructured.NestedString(obj.Object, "data", "key")
+				if got != tt.wantData[c.Name] {
+					t.Errorf("data in %s = %q, want %q", c.Name, got, tt.wantData[c.Name])
+				}
+				if edited := got != "old"; util.IsManaged(obj) != edited {
+					t.Errorf("%s annotation in %s = %q after edit %v", util.AppliedByAnnotation, c.Name, obj.GetAnnotations()[util.AppliedByAnnotation], edited)
+				}
+			}
+		})
+	}
+}
+
+func TestRunEditor(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("the test editors are POSIX commands")
+	}
+	tests := []struct {
+		name    string
+		editor  string
+		want    string
+		wantErr bool
+	}{
+		{name: "editor with arguments", editor: "sed -i 's/replicas: 1/replicas: 3/'", want: "replicas: 3\n"},
+		{name: "editor leaves file alone", editor: "true", want: "replicas: 1\n"},
+		{name: "editor fails", editor: "false", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("KUBE_EDITOR", tt.editor)
+			got, err := runEditor([]byte("replicas: 1\n"))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runEditor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && string(got) != tt.want {
+				t.Errorf("runEditor() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
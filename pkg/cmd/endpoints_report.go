@@ -0,0 +1,257 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+// Verdicts of endpoints-report for the service in one cluster
+const (
+	endpointsOK           = "OK"
+	endpointsDegraded     = "Degraded"
+	endpointsNoReady      = "NoReadyEndpoints"
+	endpointsNoPods       = "NoPods"
+	endpointsMissing      = "Missing"
+	endpointsExternalName = "ExternalName"
+	endpointsUnknown      = "Unknown"
+)
+
+// serviceEndpoints is the readiness of a service and its backends in one cluster
+type serviceEndpoints struct {
+	Cluster string `json:"cluster"`
+	Status  string `json:"status"`
+	Type    string `json:"type,omitempty"`
+	// Endpoints counts the addresses of the service's EndpointSlices
+	Endpoints      int `json:"endpoints"`
+	ReadyEndpoints int `json:"readyEndpoints"`
+	// Pods counts the pods the service selector matches; services without
+	// a selector have their endpoints managed by hand and no pods counted
+	Pods      int      `json:"pods"`
+	ReadyPods int      `json:"readyPods"`
+	NotReady  []string `json:"notReady,omitempty"`
+	Detail    string   `json:"detail,omitempty"`
+}
+
+// ok reports whether the service is fully served in the cluster
+func (s serviceEndpoints) ok() bool {
+	return s.Status == endpointsOK || s.Status == endpointsExternalName
+}
+
+func newEndpointsReportCommand() *cobra.Command {
+	var outputFormat string
+	var targets clusterTargets
+	var reach reachability
+
+	cmd := &cobra.Command{
+		Use:   "endpoints-report SERVICE",
+		Short: "Check that a service has ready endpoints and pods in every managed cluster",
+		Long: `Check, in every managed cluster, whether the service exists, how many of its
+endpoints are ready, and whether the pods its selector matches are Ready. This
+catches a service that was downsynced to a cluster where no pods back it.
+
+  OK                every endpoint and every selected pod is ready
+  Degraded          some endpoints or selected pods are not ready
+  NoReadyEndpoints  the service has no ready endpoint
+  NoPods            the service selector matches no pod
+  Missing           the service does not exist
+  ExternalName      the service is a DNS alias without endpoints
+
+The command fails when the service is not OK in any cluster, so it can gate
+scripts.`,
+		Example: `# Check the nginx service in the prod namespace of every cluster
+kubectl multi endpoints-report nginx -n prod
+
+# Only two clusters, as JSON
+kubectl multi endpoints-report nginx -n prod --clusters cluster1,cluster2 -o json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch outputFormat {
+			case "", "json", util.TableFormatCSV, util.TableFormatMarkdown:
+			default:
+				return fmt.Errorf("unsupported output format %q, must be one of json|csv|markdown", outputFormat)
+			}
+			kubeconfig, remoteCtx, _, namespace, _ := GetGlobalFlags()
+			return handleEndpointsReportCommand(args[0], outputFormat, targets, reach, kubeconfig, remoteCtx, namespace)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "output format (json|csv|markdown)")
+	targets.addFlags(cmd, "check")
+	reach.addFlags(cmd)
+
+	return cmd
+}
+
+func handleEndpointsReportCommand(service, outputFormat string, targets clusterTargets, reach reachability, kubeconfig, remoteCtx, namespace string) error {
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	clusters, err = targets.selectFrom(clusters, kubeconfig)
+	if err != nil {
+		return err
+	}
+	clusters, err = reach.filter(workloadClusters(clusters, remoteCtx))
+	if err != nil {
+		return err
+	}
+	defer printClusterIssuesToStderr()
+
+	ns := cluster.GetTargetNamespace(namespace)
+	var results []serviceEndpoints
+	for _, c := range clusters {
+		if c.Client == nil {
+			continue
+		}
+		results = append(results, checkServiceEndpoints(c, ns, service))
+	}
+
+	failing := 0
+	for _, r := range results {
+		if !r.ok() {
+			failing++
+		}
+	}
+	if outputFormat == "json" {
+		if err := printJSONArray(results); err != nil {
+			return err
+		}
+	} else {
+		printServiceEndpoints(results, outputFormat)
+	}
+	if failing > 0 {
+		return fmt.Errorf("service %s/%s not ready in %d of %d cluster(s)", ns, service, failing, len(results))
+	}
+	return nil
+}
+
+// checkServiceEndpoints reads the service, its EndpointSlices and the pods
+// it selects in one cluster and judges whether it is served
+func checkServiceEndpoints(c cluster.ClusterInfo, namespace, name string) serviceEndpoints {
+	r := serviceEndpoints{Cluster: c.Name}
+	svc, err := c.Client.CoreV1().Services(namespace).Get(commandContext(), name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		r.Status = endpointsMissing
+		return r
+	}
+	if err != nil {
+		r.Status, r.Detail = endpointsUnknown, err.Error()
+		return r
+	}
+	r.Type = string(svc.Spec.Type)
+	if svc.Spec.Type == corev1.ServiceTypeExternalName {
+		r.Status, r.Detail = endpointsExternalName, svc.Spec.ExternalName
+		return r
+	}
+
+	slices, err := c.Client.DiscoveryV1().EndpointSlices(namespace).List(commandContext(), metav1.ListOptions{
+		LabelSelector: discoveryv1.LabelServiceName + "=" + name,
+	})
+	if err != nil {
+		r.Status, r.Detail = endpointsUnknown, fmt.Sprintf("failed to list endpointslices: %v", err)
+		return r
+	}
+	// Pods the endpoints point at, to tell which unready pods still have one
+	endpointPods := map[string]bool{}
+	for _, slice := range slices.Items {
+		for _, ep := range slice.Endpoints {
+			// A nil ready condition means ready
+			ready := ep.Conditions.Ready == nil || *ep.Conditions.Ready
+			r.Endpoints += len(ep.Addresses)
+			if ready {
+				r.ReadyEndpoints += len(ep.Addresses)
+			}
+			if ep.TargetRef != nil && ep.TargetRef.Kind == "Pod" {
+				endpointPods[ep.TargetRef.Name] = true
+			}
+		}
+	}
+
+	if len(svc.Spec.Selector) > 0 {
+		pods, err := c.Client.CoreV1().Pods(namespace).List(commandContext(), metav1.ListOptions{
+			LabelSelector: labels.SelectorFromSet(svc.Spec.Selector).String(),
+		})
+		if err != nil {
+			r.Status, r.Detail = endpointsUnknown, fmt.Sprintf("failed to list pods: %v", err)
+			return r
+		}
+		for i := range pods.Items {
+			pod := &pods.Items[i]
+			if pod.DeletionTimestamp != nil || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+				continue
+			}
+			r.Pods++
+			if podReady(*pod) {
+				r.ReadyPods++
+				continue
+			}
+			reason := podNotReadyReason(pod)
+			if endpointPods[pod.Name] {
+				reason += ", in endpoints"
+			}
+			r.NotReady = append(r.NotReady, pod.Name+" ("+reason+")")
+		}
+		sort.Strings(r.NotReady)
+	}
+
+	switch {
+	case len(svc.Spec.Selector) > 0 && r.Pods == 0:
+		r.Status, r.Detail = endpointsNoPods, "selector "+labels.SelectorFromSet(svc.Spec.Selector).String()+" matches no pod"
+	case r.ReadyEndpoints == 0:
+		r.Status = endpointsNoReady
+		if len(svc.Spec.Selector) == 0 {
+			r.Detail = "service without selector has no ready endpoint"
+		}
+	case r.ReadyEndpoints < r.Endpoints || r.ReadyPods < r.Pods:
+		r.Status = endpointsDegraded
+	default:
+		r.Status = endpointsOK
+	}
+	if r.Detail == "" && len(r.NotReady) > 0 {
+		r.Detail = "not ready: " + strings.Join(r.NotReady, ", ")
+	}
+	return r
+}
+
+// podNotReadyReason names why a pod is not ready: the waiting reason of a
+// container, such as CrashLoopBackOff, or the pod phase
+func podNotReadyReason(pod *corev1.Pod) string {
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.State.Waiting != nil && cs.State.Waiting.Reason != "" {
+			return cs.State.Waiting.Reason
+		}
+	}
+	return string(pod.Status.Phase)
+}
+
+func printServiceEndpoints(results []serviceEndpoints, outputFormat string) {
+	tw := util.NewTableWriter(util.GetOutputStream(), outputFormat)
+	defer tw.Flush()
+
+	if len(results) == 0 {
+		fmt.Fprintln(tw, "No clusters checked.")
+		return
+	}
+	fmt.Fprintln(tw, "CLUSTER\tSTATUS\tTYPE\tENDPOINTS\tPODS\tDETAIL")
+	for _, r := range results {
+		endpoints, pods := "-", "-"
+		if r.Status != endpointsMissing && r.Status != endpointsExternalName && r.Status != endpointsUnknown {
+			endpoints = fmt.Sprintf("%d/%d", r.ReadyEndpoints, r.Endpoints)
+			if r.Pods > 0 || r.Status == endpointsNoPods {
+				pods = fmt.Sprintf("%d/%d", r.ReadyPods, r.Pods)
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", r.Cluster, r.Status, orNone(r.Type), endpoints, pods, orNone(r.Detail))
+	}
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"kubectl-multi/pkg/cluster"
+)
+
+func testService(name string, selector map[string]string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: name},
+		Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP, Selector: selector},
+	}
+}
+
+func testEndpointPod(name string, ready bool, waiting string) *corev1.Pod {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: name, Labels: map[string]string{"app": "web"}},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}},
+		},
+	}
+	if waiting != "" {
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: waiting}}}}
+	}
+	return pod
+}
+
+// testEndpointSlice returns the EndpointSlice of the web service with one
+// endpoint per pod, ready as given
+func testEndpointSlice(ready map[string]bool) *discoveryv1.EndpointSlice {
+	slice := &discoveryv1.EndpointSlice{
+		ObjectMeta:  metav1.ObjectMeta{Namespace: "prod", Name: "web-abc", Labels: map[string]string{discoveryv1.LabelServiceName: "web"}},
+		AddressType: discoveryv1.AddressTypeIPv4,
+	}
+	for _, name := range sortedKeys(ready) {
+		r := ready[name]
+		slice.Endpoints = append(slice.Endpoints, discoveryv1.Endpoint{
+			Addresses:  []string{"10.0.0.1"},
+			Conditions: discoveryv1.EndpointConditions{Ready: &r},
+			TargetRef:  &corev1.ObjectReference{Kind: "Pod", Name: name},
+		})
+	}
+	return slice
+}
+
+func TestCheckServiceEndpoints(t *testing.T) {
+	selector := map[string]string{"app": "web"}
+	tests := []struct {
+		name    string
+		objects []runtime.Object
+		want    serviceEndpoints
+	}{
+		{
+			name: "ok",
+			objects: []runtime.Object{
+				testService("web", selector), testEndpointPod("web-1", true, ""), testEndpointPod("web-2", true, ""),
+				testEndpointSlice(map[string]bool{"web-1": true, "web-2": true}),
+			},
+			want: serviceEndpoints{Status: endpointsOK, Type: "ClusterIP", Endpoints: 2, ReadyEndpoints: 2, Pods: 2, ReadyPods: 2},
+		},
+		{
+			name: "degraded",
+			objects: []runtime.Object{
+				testService("web", selector), testEndpointPod("web-1", true, ""), testEndpointPod("web-2", false, "CrashLoopBackOff"),
+				testEndpointSlice(map[string]bool{"web-1": true, "web-2": false}),
+			},
+			want: serviceEndpoints{
+				Status: endpointsDegraded, Type: "ClusterIP", Endpoints: 2, ReadyEndpoints: 1, Pods: 2, ReadyPods: 1,
+				NotReady: []string{"web-2 (CrashLoopBackOff, in endpoints)"},
+				Detail:   "not ready: web-2 (CrashLoopBackOff, in endpoints)",
+			},
+		},
+		{
+			name: "no ready endpoints",
+			objects: []runtime.Object{
+				testService("web", selector), testEndpointPod("web-1", false, ""),
+				testEndpointSlice(map[string]bool{"web-1": false}),
+			},
+			want: serviceEndpoints{
+				Status: endpointsNoReady, Type: "ClusterIP", Endpoints: 1, Pods: 1,
+				NotReady: []string{"web-1 (Running, in endpoints)"},
+				Detail:   "not ready: web-1 (Running, in endpoints)",
+			},
+		},
+		{
+			name:    "no pods",
+			objects: []runtime.Object{testService("web", selector)},
+			want:    serviceEndpoints{Status: endpointsNoPods, Type: "ClusterIP", Detail: "selector app=web matches no pod"},
+		},
+		{
+			name:    "missing",
+			objects: []runtime.Object{testEndpointPod("web-1", true, "")},
+			want:    serviceEndpoints{Status: endpointsMissing},
+		},
+		{
+			name: "external name",
+			objects: []runtime.Object{&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "web"},
+				Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeExternalName, ExternalName: "web.example.com"},
+			}},
+			want: serviceEndpoints{Status: endpointsExternalName, Type: "ExternalName", Detail: "web.example.com"},
+		},
+		{
+			name:    "without selector",
+			objects: []runtime.Object{testService("web", nil), testEndpointSlice(map[string]bool{"db-1": true})},
+			want:    serviceEndpoints{Status: endpointsOK, Type: "ClusterIP", Endpoints: 1, ReadyEndpoints: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := cluster.ClusterInfo{Name: "cluster1", Client: fake.NewSimpleClientset(tt.objects...)}
+			got := checkServiceEndpoints(c, "prod", "web")
+			tt.want.Cluster = "cluster1"
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("checkServiceEndpoints() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,352 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+// explainDepth bounds how deep --recursive prints fields and how deep the
+// schemas of the clusters are compared
+const explainDepth = 12
+
+// explainWidth is the width descriptions are wrapped to
+const explainWidth = 80
+
+// clusterSchema is the schema one cluster serves for the explained kind or
+// field, or why it has none
+type clusterSchema struct {
+	Cluster string
+	GVK     schema.GroupVersionKind
+	Node    util.SchemaNode
+	Err     error
+}
+
+func newExplainCommand() *cobra.Command {
+	var apiVersion string
+	var recursive bool
+	var targets clusterTargets
+
+	cmd := &cobra.Command{
+		Use:   "explain TYPE[.FIELD...]",
+		Short: "Show the documentation of a resource or field and where its schema differs between clusters",
+		Long: `Show the documentation of a resource type or of one of its fields, like
+kubectl explain, from the OpenAPI schema the first cluster serves.
+
+The schemas of all clusters are compared below the explained field. Fields
+that are missing or typed differently in some clusters are listed with the
+clusters serving each variant, such as when WECs run different versions of a
+CRD or of Kubernetes, which is why a manifest may apply in one WEC and fail
+validation in another.`,
+		Example: `# Document the fields of a Deployment spec
+kubectl multi explain deployments.spec
+
+# Print every field of a custom resource, and where its CRD differs
+kubectl multi explain widgets --recursive
+
+# Explain a specific version of a group
+kubectl multi explain widgets.spec --api-version example.com/v1beta1`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if apiVersion != "" {
+				if _, err := schema.ParseGroupVersion(apiVersion); err != nil {
+					return fmt.Errorf("invalid --api-version %q: %v", apiVersion, err)
+				}
+			}
+			kubeconfig, remoteCtx, _, _, _ := GetGlobalFlags()
+			return handleExplainCommand(args[0], apiVersion, recursive, targets, kubeconfig, remoteCtx)
+		},
+	}
+
+	cmd.Flags().StringVar(&apiVersion, "api-version", "", "GROUP/VERSION of the resource, instead of the preferred version")
+	cmd.Flags().BoolVar(&recursive, "recursive", false, "print the fields of fields")
+	targets.addFlags(cmd, "explain in")
+
+	return cmd
+}
+
+func handleExplainCommand(arg, apiVersion string, recursive bool, targets clusterTargets, kubeconfig, remoteCtx string) error {
+	parts := strings.Split(arg, ".")
+	resourceType, path := parts[0], parts[1:]
+
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	clusters, err = targets.selectFrom(clusters, kubeconfig)
+	if err != nil {
+		return err
+	}
+	var results []clusterSchema
+	for _, c := range clusters {
+		if c.Context == remoteCtx {
+			continue
+		}
+		results = append(results, explainInCluster(c, resourceType, path, apiVersion))
+	}
+	if len(results) == 0 {
+		return fmt.Errorf("no clusters discovered")
+	}
+	return printExplain(util.GetOutputStream(), results, path, recursive)
+}
+
+// explainInCluster looks up the schema of the resource type, and of the
+// field path within it, in the OpenAPI v3 document the cluster serves
+func explainInCluster(c cluster.ClusterInfo, resourceType string, path []string, apiVersion string) clusterSchema {
+	r := clusterSchema{Cluster: c.Name}
+	if c.DiscoveryClient == nil {
+		r.Err = fmt.Errorf("no clients available for cluster %s", c.Name)
+		return r
+	}
+	gvr, _, err := util.DiscoverGVR(c.DiscoveryClient, resourceType)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	if apiVersion != "" {
+		gv, _ := schema.ParseGroupVersion(apiVersion)
+		gvr.Group, gvr.Version = gv.Group, gv.Version
+	}
+	gvk, err := c.Mapper().KindFor(gvr)
+	if err != nil {
+		r.Err = fmt.Errorf("the server doesn't have a resource type %q in %s", resourceType, gvr.GroupVersion())
+		return r
+	}
+	r.GVK = gvk
+
+	paths, err := c.DiscoveryClient.OpenAPIV3().Paths()
+	if err != nil {
+		r.Err = fmt.Errorf("failed to read the OpenAPI v3 index: %v", err)
+		return r
+	}
+	doc, ok := paths[util.OpenAPIPath(gvk.GroupVersion())]
+	if !ok {
+		r.Err = fmt.Errorf("no OpenAPI v3 schema published for %s", gvk.GroupVersion())
+		return r
+	}
+	data, err := doc.Schema(runtime.ContentTypeJSON)
+	if err != nil {
+		r.Err = fmt.Errorf("failed to read the OpenAPI v3 schema of %s: %v", gvk.GroupVersion(), err)
+		return r
+	}
+	schemas, err := util.ParseOpenAPISchemas(data)
+	if err != nil {
+		r.Err = err
+		return r
+	}
+	node, ok := schemas.ForKind(gvk)
+	if !ok {
+		r.Err = fmt.Errorf("no schema for %s in the OpenAPI v3 document of %s", gvk.Kind, gvk.GroupVersion())
+		return r
+	}
+	r.Node, r.Err = schemaField(node, gvk.Kind, path)
+	return r
+}
+
+// schemaField descends the field path from the schema of the kind
+func schemaField(node util.SchemaNode, kind string, path []string) (util.SchemaNode, error) {
+	for i, name := range path {
+		field, ok := node.Field(name)
+		if !ok {
+			return util.SchemaNode{}, fmt.Errorf("field %q does not exist in %s", strings.Join(path[:i+1], "."), kind)
+		}
+		node = field
+	}
+	return node, nil
+}
+
+// printExplain documents the kind or field as served by the first cluster
+// that has it, followed by how the other clusters differ
+func printExplain(out io.Writer, results []clusterSchema, path []string, recursive bool) error {
+	ref := -1
+	for i, r := range results {
+		if r.Err == nil {
+			ref = i
+			break
+		}
+	}
+	if ref < 0 {
+		if len(results) == 1 {
+			return results[0].Err
+		}
+		var reasons []string
+		for _, r := range results {
+			reasons = append(reasons, fmt.Sprintf("%s: %v", r.Cluster, r.Err))
+		}
+		return fmt.Errorf("no cluster serves the schema:\n  %s", strings.Join(reasons, "\n  "))
+	}
+
+	r := results[ref]
+	if r.GVK.Group != "" {
+		fmt.Fprintf(out, "GROUP:      %s\n", r.GVK.Group)
+	}
+	fmt.Fprintf(out, "KIND:       %s\n", r.GVK.Kind)
+	fmt.Fprintf(out, "VERSION:    %s\n", r.GVK.Version)
+	if len(results) > 1 {
+		fmt.Fprintf(out, "CLUSTER:    %s\n", r.Cluster)
+	}
+	fmt.Fprintln(out)
+	if len(path) > 0 {
+		fmt.Fprintf(out, "FIELD: %s <%s>\n\n", path[len(path)-1], r.Node.Type())
+	}
+	fmt.Fprintln(out, "DESCRIPTION:")
+	fmt.Fprint(out, wrapText(orNone(r.Node.Description()), "    ", explainWidth))
+
+	if fields := r.Node.Fields(); len(fields) > 0 {
+		fmt.Fprintln(out, "\nFIELDS:")
+		if recursive {
+			types := r.Node.FieldTypes(explainDepth)
+			for _, p := range sortedFieldPaths(types) {
+				segments := strings.Split(p, ".")
+				fmt.Fprintf(out, "%s%s\t<%s>\n", strings.Repeat("  ", len(segments)), segments[len(segments)-1], types[p])
+			}
+		} else {
+			for _, f := range fields {
+				required := ""
+				if f.Required {
+					required = " -required-"
+				}
+				fmt.Fprintf(out, "  %s\t<%s>%s\n", f.Name, f.Type, required)
+				fmt.Fprint(out, wrapText(f.Description, "    ", explainWidth))
+				fmt.Fprintln(out)
+			}
+		}
+	}
+
+	if len(results) > 1 {
+		printSchemaDifferences(out, results, path)
+	}
+	return nil
+}
+
+// printSchemaDifferences lists the clusters without the schema, and every
+// field below the explained one that is missing or typed differently in
+// some of the clusters, with the clusters serving each variant
+func printSchemaDifferences(out io.Writer, results []clusterSchema, path []string) {
+	var served []clusterSchema
+	var unavailable []string
+	for _, r := range results {
+		if r.Err != nil {
+			unavailable = append(unavailable, fmt.Sprintf("%s: %v", r.Cluster, r.Err))
+			continue
+		}
+		served = append(served, r)
+	}
+
+	prefix := strings.Join(path, ".")
+	types := make([]map[string]string, len(served))
+	all := map[string]string{}
+	for i, s := range served {
+		types[i] = map[string]string{}
+		for p, t := range s.Node.FieldTypes(explainDepth) {
+			if prefix != "" {
+				p = prefix + "." + p
+			}
+			types[i][p] = t
+			all[p] = t
+		}
+		if prefix != "" {
+			types[i][prefix] = s.Node.Type()
+			all[prefix] = s.Node.Type()
+		}
+	}
+
+	var diffs []string
+	if variants := schemaVariants(served, func(i int) string { return served[i].GVK.GroupVersion().String() }); variants != "" {
+		diffs = append(diffs, "version: "+variants)
+	}
+	for _, p := range sortedFieldPaths(all) {
+		variants := schemaVariants(served, func(i int) string {
+			if t, ok := types[i][p]; ok {
+				return "<" + t + ">"
+			}
+			return "absent"
+		})
+		if variants != "" {
+			diffs = append(diffs, p+": "+variants)
+		}
+	}
+
+	fmt.Fprintln(out)
+	if len(diffs) == 0 && len(unavailable) == 0 {
+		fmt.Fprintf(out, "The schema is the same in all %d clusters.\n", len(served))
+		return
+	}
+	fmt.Fprintf(out, "CLUSTER DIFFERENCES:\n")
+	for _, u := range unavailable {
+		fmt.Fprintf(out, "  %s\n", u)
+	}
+	for _, d := range diffs {
+		fmt.Fprintf(out, "  %s\n", d)
+	}
+}
+
+// schemaVariants groups the clusters by the variant of the schema each
+// serves, as "<string> in cluster1, cluster2; absent in cluster3", or
+// returns "" when all clusters agree
+func schemaVariants(served []clusterSchema, variantOf func(i int) string) string {
+	var order []string
+	clusters := map[string][]string{}
+	for i, s := range served {
+		variant := variantOf(i)
+		if _, seen := clusters[variant]; !seen {
+			order = append(order, variant)
+		}
+		clusters[variant] = append(clusters[variant], s.Cluster)
+	}
+	if len(order) < 2 {
+		return ""
+	}
+	parts := make([]string, 0, len(order))
+	for _, variant := range order {
+		parts = append(parts, variant+" in "+strings.Join(clusters[variant], ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// sortedFieldPaths orders dotted field paths as a tree, each field followed
+// by its own fields
+func sortedFieldPaths(types map[string]string) []string {
+	paths := make([]string, 0, len(types))
+	for p := range types {
+		paths = append(paths, p)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		a, b := strings.Split(paths[i], "."), strings.Split(paths[j], ".")
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	return paths
+}
+
+// wrapText wraps each paragraph of text to width, indenting every line
+func wrapText(text, indent string, width int) string {
+	var b strings.Builder
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := indent
+		for _, word := range strings.Fields(paragraph) {
+			if line != indent && len(line)+1+len(word) > width {
+				b.WriteString(line + "\n")
+				line = indent
+			}
+			if line != indent {
+				line += " "
+			}
+			line += word
+		}
+		b.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,278 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+	"kubectl-multi/pkg/util"
+)
+
+func newExplainPlacementCommand() *cobra.Command {
+	var reach reachability
+	cmd := &cobra.Command{
+		Use:   "explain-placement TYPE/NAME",
+		Short: "Explain which BindingPolicies place a workload object and where",
+		Long: `Walk the BindingPolicies and Bindings in the WDS and report which policy
+selected the given workload object, which managed clusters it resolved to, and
+why the remaining policies or clusters did not match.`,
+		Example: `# Explain the placement of a deployment
+kubectl multi kubestellar explain-placement deployment/nginx -n prod
+
+# Use a non-default WDS
+kubectl multi kubestellar explain-placement configmap app-config -n prod --wds-context wds2
+
+# Explain the placement in every WDS holding the object
+kubectl multi kubestellar explain-placement deployment/nginx -n prod --wds wds1,wds2`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resourceType, name, err := parseTypeName(args)
+			if err != nil {
+				return err
+			}
+			kubeconfig, remoteCtx, _, namespace, _ := GetGlobalFlags()
+			return handleExplainPlacementCommand(resourceType, name, reach, kubeconfig, remoteCtx, GetWDSContexts(), namespace)
+		},
+	}
+	reach.addFlags(cmd)
+	return cmd
+}
+
+// newLegacyExplainPlacementCommand keeps the former top-level
+// explain-placement working, hidden and deprecated in favour of
+// kubestellar explain-placement
+func newLegacyExplainPlacementCommand() *cobra.Command {
+	cmd := newExplainPlacementCommand()
+	cmd.Hidden = true
+	cmd.Deprecated = "use 'kubectl multi kubestellar explain-placement' instead"
+	return cmd
+}
+
+// parseTypeName accepts either TYPE/NAME or TYPE NAME
+func parseTypeName(args []string) (string, string, error) {
+	if len(args) == 2 {
+		return args[0], args[1], nil
+	}
+	parts := strings.SplitN(args[0], "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("expected TYPE/NAME or TYPE NAME, got %q", args[0])
+	}
+	return parts[0], parts[1], nil
+}
+
+func handleExplainPlacementCommand(resourceType, name string, reach reachability, kubeconfig, remoteCtx string, wdsContexts []string, namespace string) error {
+	for _, wdsContext := range wdsContexts {
+		if _, err := reach.controlPlane("WDS", kubeconfig, wdsContext, true); err != nil {
+			return err
+		}
+	}
+	// Without the ITS, placement is explained from the Bindings alone
+	itsReachable, err := reach.controlPlane("ITS", kubeconfig, remoteCtx, false)
+	if err != nil {
+		return err
+	}
+	defer printClusterIssuesToStderr()
+
+	var managedClusters []unstructured.Unstructured
+	if itsReachable {
+		managedClusters, err = cluster.ListManagedClusterObjects(commandContext(), kubeconfig, remoteCtx)
+		if err != nil {
+			noteClusterIssue(remoteCtx, err.Error())
+		}
+	}
+
+	if len(wdsContexts) == 1 {
+		return explainPlacementInWDS(resourceType, name, kubeconfig, wdsContexts[0], namespace, managedClusters)
+	}
+	// With several WDSes, an object missing from some of them is not an error
+	out := util.GetOutputStream()
+	for i, wdsContext := range wdsContexts {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintf(out, "=== WDS: %s ===\n", wdsContext)
+		if err := explainPlacementInWDS(resourceType, name, kubeconfig, wdsContext, namespace, managedClusters); err != nil {
+			noteClusterIssue(wdsContext, err.Error())
+		}
+	}
+	return nil
+}
+
+// explainPlacementInWDS explains the placement of the object by the
+// BindingPolicies of one WDS
+func explainPlacementInWDS(resourceType, name, kubeconfig, wdsContext, namespace string, managedClusters []unstructured.Unstructured) error {
+	wds, err := cluster.ClientForContext(kubeconfig, wdsContext)
+	if err != nil {
+		return err
+	}
+
+	gvr, namespaced, err := util.DiscoverGVR(wds.DiscoveryClient, resourceType)
+	if err != nil {
+		return fmt.Errorf("failed to resolve resource type %s: %v", resourceType, err)
+	}
+
+	workload := kubestellar.WorkloadObject{
+		Ref: kubestellar.ObjectRef{Group: gvr.Group, Version: gvr.Version, Resource: gvr.Resource, Name: name},
+	}
+
+	var obj *unstructured.Unstructured
+	if namespaced {
+		workload.Ref.Namespace = cluster.GetTargetNamespace(namespace)
+		obj, err = wds.DynamicClient.Resource(gvr).Namespace(workload.Ref.Namespace).Get(commandContext(), name, metav1.GetOptions{})
+	} else {
+		obj, err = wds.DynamicClient.Resource(gvr).Get(commandContext(), name, metav1.GetOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get %s in WDS %s: %v", workload.Ref, wdsContext, err)
+	}
+	workload.Labels = obj.GetLabels()
+
+	if namespaced {
+		ns, err := wds.Client.CoreV1().Namespaces().Get(commandContext(), workload.Ref.Namespace, metav1.GetOptions{})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to get namespace %s in WDS %s: %v\n", workload.Ref.Namespace, wdsContext, err)
+		} else {
+			workload.NamespaceLabels = ns.Labels
+		}
+	}
+
+	policies, err := wds.DynamicClient.Resource(kubestellar.BindingPolicyGVR).List(commandContext(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list bindingpolicies in WDS %s: %v", wdsContext, err)
+	}
+	sort.Slice(policies.Items, func(i, j int) bool { return policies.Items[i].GetName() < policies.Items[j].GetName() })
+
+	bindings := make(map[string]*unstructured.Unstructured)
+	bindingList, err := wds.DynamicClient.Resource(kubestellar.BindingGVR).List(commandContext(), metav1.ListOptions{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to list bindings in WDS %s: %v\n", wdsContext, err)
+	} else {
+		for i := range bindingList.Items {
+			bindings[bindingList.Items[i].GetName()] = &bindingList.Items[i]
+		}
+	}
+
+	out := util.GetOutputStream()
+	fmt.Fprintf(out, "Object:  %s\n", workload.Ref)
+	fmt.Fprintf(out, "Labels:  %s\n", kubestellar.FormatLabelSet(workload.Labels))
+	if namespaced {
+		fmt.Fprintf(out, "Namespace labels:  %s\n", kubestellar.FormatLabelSet(workload.NamespaceLabels))
+	}
+	fmt.Fprintln(out)
+
+	if len(policies.Items) == 0 {
+		fmt.Fprintf(out, "No BindingPolicies found in WDS %s.\n", wdsContext)
+		return nil
+	}
+
+	var selecting []string
+	notMatched := make(map[string][]string)
+
+	for i := range policies.Items {
+		policy := &policies.Items[i]
+		spec, err := kubestellar.BindingPolicySpecFrom(policy)
+		if err != nil {
+			notMatched[policy.GetName()] = []string{err.Error()}
+			continue
+		}
+
+		matched, reasons := kubestellar.MatchDownsync(spec, workload)
+		if !matched {
+			notMatched[policy.GetName()] = reasons
+			continue
+		}
+		selecting = append(selecting, policy.GetName())
+
+		fmt.Fprintf(out, "Selected by BindingPolicy %s\n", policy.GetName())
+		explainClusterSelection(out, spec, bindings[policy.GetName()], workload.Ref, managedClusters)
+		fmt.Fprintln(out)
+	}
+
+	if len(selecting) == 0 {
+		fmt.Fprintf(out, "Not selected by any BindingPolicy.\n\n")
+	}
+
+	if len(notMatched) > 0 {
+		fmt.Fprintf(out, "Policies that did not select the object:\n")
+		names := make([]string, 0, len(notMatched))
+		for n := range notMatched {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			fmt.Fprintf(out, "  %s\n", n)
+			for _, reason := range notMatched[n] {
+				fmt.Fprintf(out, "    - %s\n", reason)
+			}
+		}
+	}
+	return nil
+}
+
+// explainClusterSelection prints the clusters a selecting policy resolved to
+// and, for every other managed cluster, the selector requirements it failed.
+func explainClusterSelection(out io.Writer, spec kubestellar.BindingPolicySpec, binding *unstructured.Unstructured, ref kubestellar.ObjectRef, managedClusters []unstructured.Unstructured) {
+	if len(spec.ClusterSelectors) == 0 {
+		fmt.Fprintf(out, "  Policy has no clusterSelectors, so it selects no clusters\n")
+		return
+	}
+
+	resolved := make(map[string]bool)
+	if binding != nil {
+		if !bindingContains(binding, ref) {
+			fmt.Fprintf(out, "  Binding %s does not list this object yet\n", binding.GetName())
+		}
+		for _, c := range kubestellar.BindingDestinations(binding) {
+			resolved[c] = true
+		}
+	} else {
+		fmt.Fprintf(out, "  No Binding found; destinations are evaluated from clusterSelectors\n")
+		for _, mc := range managedClusters {
+			if ok, _ := kubestellar.MatchAnySelector(spec.ClusterSelectors, mc.GetLabels()); ok {
+				resolved[mc.GetName()] = true
+			}
+		}
+	}
+
+	destinations := make([]string, 0, len(resolved))
+	for c := range resolved {
+		destinations = append(destinations, c)
+	}
+	sort.Strings(destinations)
+	if len(destinations) == 0 {
+		fmt.Fprintf(out, "  Resolved clusters: <none>\n")
+	} else {
+		fmt.Fprintf(out, "  Resolved clusters: %s\n", strings.Join(destinations, ", "))
+	}
+
+	for _, mc := range managedClusters {
+		if resolved[mc.GetName()] {
+			continue
+		}
+		_, failed := kubestellar.MatchAnySelector(spec.ClusterSelectors, mc.GetLabels())
+		if len(failed) == 0 {
+			fmt.Fprintf(out, "  Cluster %s: matches clusterSelectors but is not in the Binding yet\n", mc.GetName())
+			continue
+		}
+		fmt.Fprintf(out, "  Cluster %s: labels %s do not satisfy %s\n",
+			mc.GetName(), kubestellar.FormatLabelSet(mc.GetLabels()), strings.Join(failed, " or "))
+	}
+}
+
+// bindingContains reports whether a Binding's workload lists the object
+func bindingContains(binding *unstructured.Unstructured, ref kubestellar.ObjectRef) bool {
+	for _, w := range kubestellar.BindingWorkload(binding) {
+		if w.Group == ref.Group && w.Resource == ref.Resource && w.Namespace == ref.Namespace && w.Name == ref.Name {
+			return true
+		}
+	}
+	return false
+}
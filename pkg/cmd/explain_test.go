@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"kubectl-multi/pkg/util"
+)
+
+// testWidgetDocument is an OpenAPI v3 document of a Widget kind whose spec
+// has the given properties
+func testWidgetDocument(version, specProperties string) string {
+	return fmt.Sprintf(`{"components": {"schemas": {
+  "com.example.%[1]s.Widget": {
+    "type": "object",
+    "description": "Widget is a test kind.",
+    "properties": {"spec": {"$ref": "#/components/schemas/com.example.%[1]s.WidgetSpec"}},
+    "x-kubernetes-group-version-kind": [{"group": "example.com", "kind": "Widget", "version": "%[1]s"}]
+  },
+  "com.example.%[1]s.WidgetSpec": {
+    "type": "object",
+    "description": "WidgetSpec describes a widget.",
+    "required": ["size"],
+    "properties": {%[2]s}
+  }
+}}}`, version, specProperties)
+}
+
+func testClusterSchema(t *testing.T, clusterName, version, specProperties string, path []string) clusterSchema {
+	t.Helper()
+	schemas, err := util.ParseOpenAPISchemas([]byte(testWidgetDocument(version, specProperties)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gvk := schema.GroupVersionKind{Group: "example.com", Version: version, Kind: "Widget"}
+	node, ok := schemas.ForKind(gvk)
+	if !ok {
+		t.Fatal("no Widget schema")
+	}
+	r := clusterSchema{Cluster: clusterName, GVK: gvk}
+	r.Node, r.Err = schemaField(node, gvk.Kind, path)
+	return r
+}
+
+func TestPrintExplain(t *testing.T) {
+	const v1Spec = `"size": {"type": "integer", "description": "Size of the widget."}, "color": {"type": "string"}`
+	tests := []struct {
+		name      string
+		path      []string
+		recursive bool
+		schemas   func(path []string) []clusterSchema
+		want      []string
+		wantNot   []string
+		wantErr   string
+	}{
+		{
+			name: "one cluster",
+			schemas: func(path []string) []clusterSchema {
+				return []clusterSchema{testClusterSchema(t, "cluster1", "v1", v1Spec, path)}
+			},
+			want:    []string{"GROUP:      example.com\nKIND:       Widget\nVERSION:    v1\n\nDESCRIPTION:\n    Widget is a test kind.\n", "  spec\t<WidgetSpec>\n"},
+			wantNot: []string{"CLUSTER", "same in all"},
+		},
+		{
+			name: "field",
+			path: []string{"spec"},
+			schemas: func(path []string) []clusterSchema {
+				return []clusterSchema{testClusterSchema(t, "cluster1", "v1", v1Spec, path), testClusterSchema(t, "cluster2", "v1", v1Spec, path)}
+			},
+			want: []string{"CLUSTER:    cluster1\n", "FIELD: spec <WidgetSpec>\n", "  color\t<string>\n", "  size\t<integer> -required-\n    Size of the widget.\n", "The schema is the same in all 2 clusters.\n"},
+		},
+		{
+			name:      "recursive",
+			recursive: true,
+			schemas: func(path []string) []clusterSchema {
+				return []clusterSchema{testClusterSchema(t, "cluster1", "v1", v1Spec, path)}
+			},
+			want: []string{"FIELDS:\n  spec\t<WidgetSpec>\n    color\t<string>\n    size\t<integer>\n"},
+		},
+		{
+			name: "differences",
+			path: []string{"spec"},
+			schemas: func(path []string) []clusterSchema {
+				return []clusterSchema{
+					testClusterSchema(t, "cluster1", "v1", v1Spec, path),
+					testClusterSchema(t, "cluster2", "v1", `"size": {"type": "string"}`, path),
+					testClusterSchema(t, "cluster3", "v1beta1", v1Spec, path),
+					{Cluster: "cluster4", Err: fmt.Errorf(`the server doesn't have a resource type "widgets"`)},
+				}
+			},
+			want: []string{"CLUSTER DIFFERENCES:\n" +
+				"  cluster4: the server doesn't have a resource type \"widgets\"\n" +
+				"  version: example.com/v1 in cluster1, cluster2; example.com/v1beta1 in cluster3\n" +
+				"  spec.color: <string> in cluster1, cluster3; absent in cluster2\n" +
+				"  spec.size: <integer> in cluster1, cluster3; <string> in cluster2\n"},
+		},
+		{
+			name: "reference cluster without the field",
+			path: []string{"spec", "color"},
+			schemas: func(path []string) []clusterSchema {
+				return []clusterSchema{testClusterSchema(t, "cluster1", "v1", `"size": {"type": "integer"}`, path), testClusterSchema(t, "cluster2", "v1", v1Spec, path)}
+			},
+			want: []string{"CLUSTER:    cluster2\n", "FIELD: color <string>\n", "  cluster1: field \"spec.color\" does not exist in Widget\n"},
+		},
+		{
+			name: "no cluster has it",
+			path: []string{"status"},
+			schemas: func(path []string) []clusterSchema {
+				return []clusterSchema{testClusterSchema(t, "cluster1", "v1", v1Spec, path), testClusterSchema(t, "cluster2", "v1", v1Spec, path)}
+			},
+			wantErr: "no cluster serves the schema:\n  cluster1: field \"status\" does not exist in Widget\n  cluster2: field \"status\" does not exist in Widget",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := printExplain(&out, tt.schemas(tt.path), tt.path, tt.recursive)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("printExplain() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("printExplain() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output %q does not contain %q", out.String(), want)
+				}
+			}
+			for _, unwanted := range tt.wantNot {
+				if strings.Contains(out.String(), unwanted) {
+					t.Errorf("output %q contains %q", out.String(), unwanted)
+				}
+			}
+		})
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	got := wrapText("one two three four\n\nfive", "  ", 12)
+	want := "  one two\n  three four\n\n  five\n"
+	if got != want {
+		t.Errorf("wrapText() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,266 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+	"kubectl-multi/pkg/util"
+)
+
+// defaultExportKinds are the resource types export reads without --kinds.
+// Secrets are left out so a bundle does not put credentials on disk unless
+// asked to.
+var defaultExportKinds = []string{"deployments", "statefulsets", "daemonsets", "cronjobs", "services", "configmaps", "serviceaccounts", "persistentvolumeclaims", "ingresses"}
+
+// exportOptions holds the flags of the export command
+type exportOptions struct {
+	Cluster           string
+	Kinds             []string
+	Selector          string
+	Output            string
+	IncludeNamespaces bool
+}
+
+// exportSkip is an object left out of a bundle and why
+type exportSkip struct {
+	Ref    string
+	Reason string
+}
+
+func newExportCommand() *cobra.Command {
+	var o exportOptions
+
+	cmd := &cobra.Command{
+		Use:   "export --cluster CLUSTER [-n NAMESPACE | -A] [-o DIR]",
+		Short: "Dump the workloads of a managed cluster as re-appliable YAML",
+		Long: `Dump the objects of the given kinds in a managed cluster as clean YAML that can
+be applied to another cluster or to a WDS, for example to bring workloads that
+were deployed by hand under KubeStellar placement.
+
+Cluster-specific fields are stripped: status, UIDs, resource versions, managed
+fields, owner references, allocated cluster IPs, node names and volume
+bindings. Objects created by a controller, objects KubeStellar already
+delivers and objects Kubernetes creates in every namespace are skipped and
+listed on stderr.
+
+With -o DIR each object is written to DIR/NAMESPACE/KIND-NAME.yaml, so the
+bundle can be handed to KubeStellar with 'kubectl multi deploy NAME -f DIR -R'
+or applied with 'kubectl multi apply -f DIR -R'. Without -o the objects are
+printed as a YAML stream.`,
+		Example: `# Bundle the workloads of namespace prod in cluster1 into ./bundle
+kubectl multi export --cluster cluster1 -n prod -o bundle/
+
+# Only the labeled Deployments and Services, including Secrets, to stdout
+kubectl multi export --cluster cluster1 -n prod -l app=web --kinds deploy,svc,secrets`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if o.Cluster == "" {
+				return fmt.Errorf("--cluster must be specified")
+			}
+			if len(o.Kinds) == 0 {
+				return fmt.Errorf("--kinds must name at least one resource type")
+			}
+			kubeconfig, remoteCtx, _, namespace, allNamespaces := GetGlobalFlags()
+			return handleExportCommand(o, kubeconfig, remoteCtx, namespace, allNamespaces)
+		},
+	}
+
+	cmd.Flags().StringVar(&o.Cluster, "cluster", "", "managed cluster to export from")
+	cmd.Flags().StringSliceVar(&o.Kinds, "kinds", defaultExportKinds, "comma-separated resource types to export")
+	cmd.Flags().StringVarP(&o.Selector, "selector", "l", "", "label selector filtering the exported objects")
+	cmd.Flags().StringVarP(&o.Output, "output", "o", "-", "directory to write the bundle to, - for stdout")
+	cmd.Flags().BoolVar(&o.IncludeNamespaces, "include-namespaces", true, "also export the Namespaces the objects live in")
+
+	return cmd
+}
+
+func handleExportCommand(o exportOptions, kubeconfig, remoteCtx, namespace string, allNamespaces bool) error {
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	selected, err := cluster.SelectClusters(clusters, []string{o.Cluster})
+	if err != nil {
+		return err
+	}
+	c := selected[0]
+	if c.DynamicClient == nil || c.DiscoveryClient == nil {
+		return fmt.Errorf("no clients available for cluster %s", c.Name)
+	}
+	defer printClusterIssuesToStderr()
+
+	ns := cluster.GetTargetNamespace(namespace)
+	if allNamespaces {
+		ns = ""
+	}
+	objs, skipped, err := exportBundle(c, o.Kinds, ns, o.Selector, o.IncludeNamespaces)
+	if err != nil {
+		return err
+	}
+	for _, s := range skipped {
+		fmt.Fprintf(os.Stderr, "Skipped %s: %s\n", s.Ref, s.Reason)
+	}
+	if len(objs) == 0 {
+		return fmt.Errorf("no objects to export from cluster %s", c.Name)
+	}
+
+	if o.Output == "-" {
+		data, err := util.EncodeManifests(objs)
+		if err != nil {
+			return err
+		}
+		_, err = util.GetOutputStream().Write(data)
+		return err
+	}
+	files, err := writeExportBundle(o.Output, objs)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Exported %s from cluster %s to %s\n", plural(len(files), "object"), c.Name, o.Output)
+	return nil
+}
+
+// exportBundle reads the objects of kinds in namespace ("" for all) of a
+// cluster and cleans them up for re-applying. The Namespaces of the objects
+// come first when includeNamespaces is set.
+func exportBundle(c cluster.ClusterInfo, kinds []string, namespace, selector string, includeNamespaces bool) ([]*unstructured.Unstructured, []exportSkip, error) {
+	var objs []*unstructured.Unstructured
+	var skipped []exportSkip
+	namespaces := map[string]bool{}
+	for _, kind := range kinds {
+		gvr, namespaced, err := util.DiscoverGVR(c.DiscoveryClient, kind)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cluster %s: %v", c.Name, err)
+		}
+		ns := namespace
+		if !namespaced {
+			ns = ""
+		}
+		items, err := listForExport(c, gvr, ns, namespaced, selector)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list %s in cluster %s: %v", gvr.Resource, c.Name, err)
+		}
+		sort.Slice(items, func(i, j int) bool {
+			if items[i].GetNamespace() != items[j].GetNamespace() {
+				return items[i].GetNamespace() < items[j].GetNamespace()
+			}
+			return items[i].GetName() < items[j].GetName()
+		})
+		for i := range items {
+			live := &items[i]
+			if reason := exportSkipReason(live); reason != "" {
+				skipped = append(skipped, exportSkip{Ref: qualifiedName(gvr, live), Reason: reason})
+				continue
+			}
+			objs = append(objs, exportObject(live))
+			if live.GetNamespace() != "" {
+				namespaces[live.GetNamespace()] = true
+			}
+		}
+	}
+
+	if !includeNamespaces || len(namespaces) == 0 {
+		return objs, skipped, nil
+	}
+	var nsObjs []*unstructured.Unstructured
+	nsGVR := schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+	for _, name := range sortedKeys(namespaces) {
+		live, err := c.DynamicClient.Resource(nsGVR).Get(commandContext(), name, metav1.GetOptions{})
+		if err != nil {
+			noteClusterIssue(c.Name, fmt.Sprintf("failed to get namespace %s: %v", name, err))
+			continue
+		}
+		nsObj := exportObject(live)
+		unstructured.RemoveNestedField(nsObj.Object, "spec")
+		nsObjs = append(nsObjs, nsObj)
+	}
+	return append(nsObjs, objs...), skipped, nil
+}
+
+// listForExport lists the objects of a resource matching selector, treating
+// a resource the cluster does not serve as empty
+func listForExport(c cluster.ClusterInfo, gvr schema.GroupVersionResource, namespace string, namespaced bool, selector string) ([]unstructured.Unstructured, error) {
+	opts := metav1.ListOptions{LabelSelector: selector}
+	list := func(ns string) (*unstructured.UnstructuredList, error) {
+		if !namespaced {
+			return c.DynamicClient.Resource(gvr).List(commandContext(), opts)
+		}
+		return c.DynamicClient.Resource(gvr).Namespace(ns).List(commandContext(), opts)
+	}
+	var objs *unstructured.UnstructuredList
+	var err error
+	if namespaced {
+		objs, err = listNamespaced(c, gvr.Group, gvr.Resource, namespace, list)
+	} else {
+		objs, err = list("")
+	}
+	if apierrors.IsNotFound(err) {
+		noteClusterIssue(c.Name, fmt.Sprintf("does not serve %s", gvr.Resource))
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return objs.Items, nil
+}
+
+// exportSkipReason explains why an object does not belong in a bundle, ""
+// when it does: its controller or KubeStellar recreates it, or Kubernetes
+// creates it by itself
+func exportSkipReason(obj *unstructured.Unstructured) string {
+	if owner, managed := kubestellar.DownsyncOwnerOf(obj); managed {
+		return "delivered by KubeStellar (" + owner.String() + ")"
+	}
+	if ref := metav1.GetControllerOf(obj); ref != nil {
+		return fmt.Sprintf("created by %s/%s", ref.Kind, ref.Name)
+	}
+	switch {
+	case obj.GetKind() == "ConfigMap" && obj.GetName() == "kube-root-ca.crt",
+		obj.GetKind() == "ServiceAccount" && obj.GetName() == "default",
+		obj.GetKind() == "Service" && obj.GetNamespace() == "default" && obj.GetName() == "kubernetes":
+		return "created by Kubernetes"
+	case obj.GetKind() == "Secret":
+		if secretType, _, _ := unstructured.NestedString(obj.Object, "type"); secretType == "kubernetes.io/service-account-token" {
+			return "service account token"
+		}
+	}
+	return ""
+}
+
+// writeExportBundle writes each object to dir/NAMESPACE/KIND-NAME.yaml, or
+// dir/KIND-NAME.yaml when cluster-scoped, and returns the files written,
+// relative to dir
+func writeExportBundle(dir string, objs []*unstructured.Unstructured) ([]string, error) {
+	var files []string
+	for _, obj := range objs {
+		file := strings.ToLower(obj.GetKind()) + "-" + obj.GetName() + ".yaml"
+		if obj.GetNamespace() != "" {
+			file = filepath.Join(obj.GetNamespace(), file)
+		}
+		path := filepath.Join(dir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return nil, fmt.Errorf("failed to create %s: %v", filepath.Dir(path), err)
+		}
+		data, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s %s: %v", obj.GetKind(), obj.GetName(), err)
+		}
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %v", path, err)
+		}
+		files = append(files, filepath.ToSlash(file))
+	}
+	return files, nil
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	goruntime "runtime"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"kubectl-multi/pkg/kubestellar"
+)
+
+func TestExportBundle(t *testing.T) {
+	ns := testObject("v1", "Namespace", "", "prod")
+	ns.SetLabels(map[string]string{"team": "web"})
+	unstructured.SetNestedStringSlice(ns.Object, []string{"kubernetes"}, "spec", "finalizers")
+	unstructured.SetNestedField(ns.Object, "Active", "status", "phase")
+
+	deploy := testObject("apps/v1", "Deployment", "prod", "nginx")
+	deploy.SetUID("1234")
+	deploy.SetResourceVersion("42")
+	deploy.SetAnnotations(map[string]string{"deployment.kubernetes.io/revision": "3"})
+	unstructured.SetNestedField(deploy.Object, int64(2), "spec", "replicas")
+	unstructured.SetNestedField(deploy.Object, int64(2), "status", "readyReplicas")
+
+	settings := testObject("v1", "ConfigMap", "prod", "settings")
+	unstructured.SetNestedStringMap(settings.Object, map[string]string{"mode": "fast"}, "data")
+	rootCA := testObject("v1", "ConfigMap", "prod", "kube-root-ca.crt")
+	delivered := testObject("v1", "ConfigMap", "prod", "shared")
+	delivered.SetLabels(map[string]string{kubestellar.OriginBindingLabel: "shared-policy"})
+
+	c, _ := testClusterInfo(ns, deploy, settings, rootCA, delivered)
+	objs, skipped, err := exportBundle(c, []string{"deployments", "configmaps"}, "prod", "", true)
+	if err != nil {
+		t.Fatalf("exportBundle() error = %v", err)
+	}
+
+	var names []string
+	for _, obj := range objs {
+		names = append(names, obj.GetKind()+"/"+obj.GetName())
+	}
+	if want := []string{"Namespace/prod", "Deployment/nginx", "ConfigMap/settings"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("exportBundle() objects = %v, want %v", names, want)
+	}
+	if _, found := objs[0].Object["spec"]; found {
+		t.Errorf("exported namespace kept its spec: %v", objs[0].Object)
+	}
+	if objs[0].GetLabels()["team"] != "web" {
+		t.Errorf("exported namespace lost its labels: %v", objs[0].GetLabels())
+	}
+	nginx := objs[1]
+	if nginx.GetUID() != "" || nginx.GetResourceVersion() != "" || nginx.GetAnnotations() != nil {
+		t.Errorf("exported deployment kept cluster-specific metadata: %v", nginx.Object["metadata"])
+	}
+	if _, found := nginx.Object["status"]; found {
+		t.Errorf("exported deployment kept its status")
+	}
+	if replicas, _, _ := unstructured.NestedInt64(nginx.Object, "spec", "replicas"); replicas != 2 {
+		t.Errorf("exported deployment replicas = %d, want 2", replicas)
+	}
+
+	wantSkipped := []exportSkip{
+		{Ref: "configmap/kube-root-ca.crt", Reason: "created by Kubernetes"},
+		{Ref: "configmap/shared", Reason: "delivered by KubeStellar (Binding shared-policy)"},
+	}
+	if !reflect.DeepEqual(skipped, wantSkipped) {
+		t.Errorf("exportBundle() skipped = %v, want %v", skipped, wantSkipped)
+	}
+
+	dir := t.TempDir()
+	files, err := writeExportBundle(dir, objs)
+	if err != nil {
+		t.Fatalf("writeExportBundle() error = %v", err)
+	}
+	if want := []string{"namespace-prod.yaml", "prod/deployment-nginx.yaml", "prod/configmap-settings.yaml"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("writeExportBundle() files = %v, want %v", files, want)
+	}
+	// The bundle may hold Secrets, so it is readable by its owner only
+	if goruntime.GOOS != "windows" {
+		for path, want := range map[string]os.FileMode{"prod": 0700, "prod/configmap-settings.yaml": 0600} {
+			info, err := os.Stat(filepath.Join(dir, path))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != want {
+				t.Errorf("mode of %s = %v, want %v", path, info.Mode().Perm(), want)
+			}
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "prod", "configmap-settings.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `apiVersion: v1
+data:
+  mode: fast
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: prod
+`
+	if string(data) != want {
+		t.Errorf("configmap-settings.yaml =\n%s\nwant\n%s", data, want)
+	}
+}
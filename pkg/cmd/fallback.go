@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// kubectlFallbackFlag routes a command through the kubectl binary
+// instead of the built-in client, for kubectl flags the plugin does not implement
+const kubectlFallbackFlag = "kubectl-fallback"
+
+func addKubectlFallbackFlag(cmd *cobra.Command, fallback *bool) {
+	cmd.Flags().BoolVar(fallback, kubectlFallbackFlag, false, "run kubectl for each cluster instead of the built-in client (requires kubectl on PATH)")
+}
+
+// takeKubectlFallback removes --kubectl-fallback from the raw arguments of
+// commands that do not parse their own flags
+func takeKubectlFallback(args []string) ([]string, bool) {
+	fallback := false
+	var rest []string
+	for i, arg := range args {
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		if arg == "--"+kubectlFallbackFlag || arg == "--"+kubectlFallbackFlag+"=true" {
+			fallback = true
+			continue
+		}
+		if arg == "--"+kubectlFallbackFlag+"=false" {
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return rest, fallback
+}
+
+// unsupportedFlagError points users of a niche kubectl flag at --kubectl-fallback
+func unsupportedFlagError(command, flag string) error {
+	return fmt.Errorf("%s: %s is not supported by the built-in client, add --%s to run it through kubectl", command, flag, kubectlFallbackFlag)
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTakeKubectlFallback(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		wantArgs     []string
+		wantFallback bool
+	}{
+		{name: "absent", args: []string{"nginx", "--image=nginx"}, wantArgs: []string{"nginx", "--image=nginx"}},
+		{name: "flag", args: []string{"nginx", "--kubectl-fallback", "--image=nginx"}, wantArgs: []string{"nginx", "--image=nginx"}, wantFallback: true},
+		{name: "explicit true", args: []string{"--kubectl-fallback=true", "nginx"}, wantArgs: []string{"nginx"}, wantFallback: true},
+		{name: "explicit false", args: []string{"--kubectl-fallback=false", "nginx"}, wantArgs: []string{"nginx"}},
+		{
+			name:     "after -- belongs to the container",
+			args:     []string{"nginx", "--", "sh", "--kubectl-fallback"},
+			wantArgs: []string{"nginx", "--", "sh", "--kubectl-fallback"},
+		},
+		{name: "empty", args: nil, wantArgs: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotArgs, gotFallback := takeKubectlFallback(tt.args)
+			if !reflect.DeepEqual(gotArgs, tt.wantArgs) {
+				t.Errorf("takeKubectlFallback() args = %q, want %q", gotArgs, tt.wantArgs)
+			}
+			if gotFallback != tt.wantFallback {
+				t.Errorf("takeKubectlFallback() fallback = %v, want %v", gotFallback, tt.wantFallback)
+			}
+		})
+	}
+}
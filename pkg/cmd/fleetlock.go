@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"k8s.io/client-go/kubernetes"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/config"
+	"kubectl-multi/pkg/lock"
+)
+
+// heldFleetLock is the fleet lock this command holds, if any
+var (
+	heldFleetLockMu sync.Mutex
+	heldFleetLock   *lock.Lease
+)
+
+// acquireFleetLock takes the fleet lock for a recorded mutation when the
+// lock is enabled in the plugin config. It is released by finishAudit.
+func acquireFleetLock(rec *audit.Recorder) error {
+	if rec == nil {
+		return nil
+	}
+	heldFleetLockMu.Lock()
+	defer heldFleetLockMu.Unlock()
+	if heldFleetLock != nil {
+		return nil
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	settings := cfg.LockSettings()
+	if !settings.Enabled {
+		return nil
+	}
+	client, _, err := fleetLockClient(settings)
+	if err != nil {
+		return err
+	}
+	l, err := lock.Acquire(commandContext(), client, settings.Namespace, settings.Name, fleetLockIdentity(rec), rec.Command(), settings.TTL.Duration)
+	var held *lock.HeldError
+	if errors.As(err, &held) {
+		return fmt.Errorf("%v; wait for it to finish, or clear a stale lock with 'kubectl multi doctor --force-release'", err)
+	}
+	if err != nil {
+		return err
+	}
+	heldFleetLock = l
+	return nil
+}
+
+// releaseFleetLock frees the fleet lock if this command holds it
+func releaseFleetLock() {
+	heldFleetLockMu.Lock()
+	defer heldFleetLockMu.Unlock()
+	if heldFleetLock == nil {
+		return
+	}
+	if err := heldFleetLock.Release(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	heldFleetLock = nil
+}
+
+// fleetLockIdentity names this process as the lock holder, e.g.
+// "alice@laptop (pid 4242)"
+func fleetLockIdentity(rec *audit.Recorder) string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s@%s (pid %d)", rec.User(), host, os.Getpid())
+}
+
+// fleetLockClient returns a client of the control plane holding the lock
+// Lease and that control plane's context
+func fleetLockClient(settings config.LockConfig) (kubernetes.Interface, string, error) {
+	kubeconfig, _, _, _, _ := GetGlobalFlags()
+	contextName := settings.Context
+	if contextName == "" {
+		contextName = GetWDSContext()
+	}
+	c, err := cluster.ClientForContext(kubeconfig, contextName)
+	if err != nil {
+		return nil, "", err
+	}
+	return c.Client, contextName, nil
+}
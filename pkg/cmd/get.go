@@ -1,18 +1,29 @@
 package cmd
 
 import (
-	"context"
+	"errors"
 	"fmt"
+	"io"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 	"time"
 
 	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/duration"
 
 	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/config"
+	"kubectl-multi/pkg/printers"
 	"kubectl-multi/pkg/util"
 )
 
@@ -22,7 +33,7 @@ func getHelpFunc(cmd *cobra.Command, args []string) {
 	cmdInfo, err := util.GetKubectlCommandInfo("get")
 	if err != nil {
 		// Fallback to default help if kubectl help is not available
-		cmd.Help()
+		defaultHelpFunc(cmd, args)
 		return
 	}
 
@@ -68,11 +79,14 @@ kubectl multi get jobs
 }
 
 func newGetCommand() *cobra.Command {
-	var outputFormat string
+	var o getOptions
 	var selector string
 	var showLabels bool
-	var watch bool
-	var watchOnly bool
+	var showAnnotations string
+	var targets clusterTargets
+	var reach reachability
+	var noDaemon bool
+	var columns string
 
 	cmd := &cobra.Command{
 		Use:   "get [TYPE[.VERSION][.GROUP] [NAME | -l label] | TYPE[.VERSION][.GROUP]/NAME ...]",
@@ -101,22 +115,125 @@ kubectl multi get pods -l app=nginx
 kubectl multi get pod nginx-pod
 
 # Get services with wide output
-kubectl multi get services -o wide`,
+kubectl multi get services -o wide
+
+# List secret keys and sizes without revealing values
+kubectl multi get secrets -n prod --show-data=redacted
+
+# Print one decoded secret value from one cluster
+kubectl multi get secret db-creds -n prod --clusters cluster1 --decode password
+
+# List the Bindings the WDS derived from its BindingPolicies
+kubectl multi get bindings
+
+# Get pods only from production clusters in the US regions
+kubectl multi get pods --cluster-selector 'env=prod,region in (us-east,us-west)'
+
+# Script on whether anything matched (exit status 3 when nothing did)
+kubectl multi get pods -l app=nginx -o name --exit-zero-on-empty=false
+
+# Export the node inventory of the fleet to a spreadsheet
+kubectl multi get nodes -o csv > nodes.csv
+
+# Allocatable CPU, memory and pods of every node, with cluster and fleet totals
+kubectl multi get nodes --capacity
+
+# List only the deployments kubectl multi created or changed
+kubectl multi get deployments -A --managed-only
+
+# Paste a deployment table into a runbook
+kubectl multi get deployments -n prod -o markdown
+
+# Only some of the columns, in this order
+kubectl multi get pods -A --columns CLUSTER,NAME,STATUS,AGE
+
+# kubectl's own columns behind CLUSTER, for scripts written for kubectl
+kubectl multi get pods -A --plain | awk '$5 != "Running"'
+
+# Refresh the table every 5 seconds, highlighting rows that changed
+kubectl multi get pods -A --poll 5s
+
+# Failed jobs and pods that are not running, without grep
+kubectl multi get jobs -A --failed
+kubectl multi get pods -A -l app=web --status Pending,Failed
+
+# Deployments that are not fully ready in any cluster
+kubectl multi get deployments -A --ready=false
+
+# Only the clusters where the nginx deployment is missing, unhealthy or different
+kubectl multi get deployment nginx -n prod --only-differences
+
+# Every hostname the fleet's ingresses serve, for a DNS audit
+kubectl multi get ingresses -A --hostnames-only
+
+# Which network policies select a pod, and what they allow, in every cluster
+kubectl multi get networkpolicies -n prod --analyze app=web
+
+# Which clusters lack which CRDs or versions
+kubectl multi get crds --compare
+
+# The CRDs of the cluster a workload was tested on that others are missing
+kubectl multi get crds --compare --required-from cluster1`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
 				return fmt.Errorf("resource type must be specified")
 			}
+			selector, err := parseSelector(selector)
+			if err != nil {
+				return err
+			}
+
+			if err := o.StateFilter.validate(strings.ToLower(args[0])); err != nil {
+				return err
+			}
+			o.UseDaemon = !noDaemon
+			if columns != "" {
+				if o.Columns, err = util.ParseColumns(columns); err != nil {
+					return err
+				}
+			}
+			annotations, err := parseShowAnnotations(showAnnotations)
+			if err != nil {
+				return err
+			}
 
 			kubeconfig, remoteCtx, _, namespace, allNamespaces := GetGlobalFlags()
-			return handleGetCommand(args, outputFormat, selector, showLabels, watch, watchOnly, kubeconfig, remoteCtx, namespace, allNamespaces)
+			q := tableQuery{Selector: selector, ShowLabels: showLabels, Annotations: annotations, Namespace: namespace, AllNamespaces: allNamespaces, Options: o}
+			err = handleGetCommand(args, q, targets, reach, kubeconfig, remoteCtx)
+			if errors.Is(err, errNoResources) {
+				// The notice is already on stderr; only the exit status is left to report
+				cmd.SilenceErrors = true
+				cmd.SilenceUsage = true
+			}
+			return err
 		},
 	}
 
-	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "output format (json|yaml|wide|name|custom-columns=...|custom-columns-file=...|go-template=...|go-template-file=...|jsonpath=...|jsonpath-file=...)")
-	cmd.Flags().StringVarP(&selector, "selector", "l", "", "selector (label query) to filter on")
+	cmd.Flags().StringVarP(&o.OutputFormat, "output", "o", "", "output format (json|yaml|wide|name|csv|markdown|custom-columns=...|custom-columns-file=...|go-template=...|go-template-file=...|jsonpath=...|jsonpath-file=...)")
+	cmd.Flags().StringVarP(&selector, "selector", "l", "", "selector (label query) to filter on, supports '=', '==', '!=', 'in', 'notin', 'key' and '!key'")
 	cmd.Flags().BoolVar(&showLabels, "show-labels", false, "show all labels as the last column")
-	cmd.Flags().BoolVarP(&watch, "watch", "w", false, "watch for changes to the requested object(s)")
-	cmd.Flags().BoolVar(&watchOnly, "watch-only", false, "watch for changes to the requested object(s), without listing/getting first")
+	cmd.Flags().StringVar(&showAnnotations, "show-annotations", "", "show annotations before the labels: all in one column, or =KEY[,KEY...] for a column per key")
+	cmd.Flags().Lookup("show-annotations").NoOptDefVal = allAnnotations
+	cmd.Flags().BoolVarP(&o.Watch, "watch", "w", false, "watch for changes to the requested object(s)")
+	cmd.Flags().BoolVar(&o.WatchOnly, "watch-only", false, "watch for changes to the requested object(s), without listing/getting first")
+	cmd.Flags().DurationVar(&o.Poll, "poll", 0, "refresh the table at this interval until interrupted, highlighting rows that changed (e.g. 5s)")
+	targets.addFlags(cmd, "query")
+	targets.addPlacementFlag(cmd, "query")
+	reach.addFlags(cmd)
+	cmd.Flags().StringVar(&o.SecretData.ShowData, "show-data", "false", "secret data to show: false, redacted (key names and sizes) or decoded (single secret on a single cluster)")
+	cmd.Flags().StringVar(&o.SecretData.DecodeKey, "decode", "", "print the decoded value of this key from a single secret on a single cluster")
+	cmd.Flags().BoolVar(&o.ExitZeroOnEmpty, "exit-zero-on-empty", true, fmt.Sprintf("exit 0 when no resources match; when false, exit %d instead", ExitCodeEmpty))
+	cmd.Flags().BoolVar(&o.Capacity, "capacity", false, "with nodes, add allocatable CPU, memory and pods columns and per-cluster and fleet totals")
+	cmd.Flags().BoolVar(&o.ManagedOnly, "managed-only", false, "only list objects written by kubectl multi (annotated "+util.AppliedByAnnotation+")")
+	o.StateFilter.addFlags(cmd)
+	cmd.Flags().StringVar(&o.NetworkPolicyTarget, "analyze", "", "with networkpolicies, show which policies select this pod (or KEY=VALUE labels) in each cluster and what traffic they allow")
+	cmd.Flags().BoolVar(&o.OnlyDifferences, "only-differences", false, "with a resource name or -l, only show the clusters where an object is missing, unhealthy or differs from the content most clusters have")
+	cmd.Flags().BoolVar(&o.CRDCompare, "compare", false, "with crds, show which clusters lack which CRDs and served versions")
+	cmd.Flags().StringVar(&o.CRDRequiredFrom, "required-from", "", "with --compare, the cluster whose CRDs and versions the others must have")
+	cmd.Flags().BoolVar(&o.HostnamesOnly, "hostnames-only", false, "with ingresses, print the deduplicated hostnames of the fleet, one per line")
+	cmd.Flags().StringVar(&columns, "columns", "", "comma-separated columns of the table to show, in this order (e.g. CLUSTER,NAME,STATUS,AGE)")
+	cmd.Flags().BoolVar(&o.Plain, "plain", false, "print the columns kubectl prints, as the API server of each cluster renders them, behind a CLUSTER column, for scripts written for kubectl output")
+	cmd.Flags().BoolVar(&noDaemon, "no-daemon", false, "query the clusters directly even when 'kubectl multi daemon' is running")
 
 	// Set custom help function
 	cmd.SetHelpFunc(getHelpFunc)
@@ -124,2268 +241,870 @@ kubectl multi get services -o wide`,
 	return cmd
 }
 
-func handleGetCommand(args []string, outputFormat, selector string, showLabels, watch, watchOnly bool, kubeconfig, remoteCtx, namespace string, allNamespaces bool) error {
+// getOptions are the get flags beyond the query of every cluster's table.
+// tableQuery carries them to the printers, so none of them lives in a
+// package variable between two runs.
+type getOptions struct {
+	OutputFormat     string
+	Watch, WatchOnly bool
+	// Poll refreshes the table at this interval (--poll)
+	Poll            time.Duration
+	SecretData      secretDataOptions
+	ExitZeroOnEmpty bool
+	// Columns are the table columns to show, in order (--columns); all
+	// when empty
+	Columns []string
+	// ManagedOnly limits get to the objects kubectl-multi wrote
+	// (--managed-only)
+	ManagedOnly bool
+	// Capacity adds the allocatable resources of nodes and their
+	// per-cluster and fleet totals to get nodes (--capacity)
+	Capacity bool
+	// StateFilter is set by --failed, --succeeded, --active, --status and
+	// --ready
+	StateFilter stateFilter
+	// NetworkPolicyTarget is the pod, or KEY=VALUE label set, whose
+	// effective policies get networkpolicies explains (--analyze)
+	NetworkPolicyTarget string
+	// OnlyDifferences lists only the clusters where an object is missing,
+	// unhealthy or differs (--only-differences)
+	OnlyDifferences bool
+	// CRDCompare prints which clusters lack which CRDs and versions
+	// (--compare); CRDRequiredFrom is the cluster whose CRDs every other
+	// cluster must have (--required-from)
+	CRDCompare      bool
+	CRDRequiredFrom string
+	// HostnamesOnly prints the deduplicated hostnames of the fleet's
+	// ingresses, one per line (--hostnames-only)
+	HostnamesOnly bool
+	// Plain prints the tables the API servers render for kubectl (--plain)
+	Plain bool
+	// UseDaemon lets get read through a running daemon (--no-daemon
+	// clears it)
+	UseDaemon bool
+}
+
+func handleGetCommand(args []string, q tableQuery, targets clusterTargets, reach reachability, kubeconfig, remoteCtx string) error {
+	o := q.Options
 	resourceType := args[0]
-	resourceName := ""
 	if len(args) > 1 {
-		resourceName = args[1]
+		q.Name = args[1]
 	}
 
 	// For watch operations, we don't support multi-cluster watch yet
-	if watch || watchOnly {
-		return fmt.Errorf("watch operations are not supported in multi-cluster mode")
+	if o.Watch || o.WatchOnly {
+		return fmt.Errorf("watch operations are not supported in multi-cluster mode; use --poll INTERVAL to refresh the table instead")
+	}
+	if o.Poll < 0 {
+		return fmt.Errorf("--poll must be a positive interval")
+	}
+	if o.Poll > 0 && (isStructuredGetFormat(o.OutputFormat) || util.IsTableExportFormat(o.OutputFormat) || o.SecretData.revealsData()) {
+		return fmt.Errorf("--poll only applies to the aligned table output")
+	}
+
+	resourceType = strings.ToLower(resourceType)
+	if o.Capacity {
+		if resourceType != "nodes" && resourceType != "node" && resourceType != "no" {
+			return fmt.Errorf("--capacity only applies to nodes")
+		}
+		if isStructuredGetFormat(o.OutputFormat) {
+			return fmt.Errorf("--capacity only applies to table output; -o %s already includes status.allocatable", o.OutputFormat)
+		}
+	}
+	if err := validateNetworkPolicyTarget(o.NetworkPolicyTarget, resourceType, q.Name, o.OutputFormat, q.AllNamespaces); err != nil {
+		return err
+	}
+	if err := validateOnlyDifferences(resourceType, q); err != nil {
+		return err
+	}
+	if o.OnlyDifferences && o.Poll > 0 {
+		return fmt.Errorf("--only-differences cannot be combined with --poll")
+	}
+	if err := validateCRDCompare(resourceType, o); err != nil {
+		return err
+	}
+	if o.CRDCompare && o.Poll > 0 {
+		return fmt.Errorf("--compare cannot be combined with --poll")
+	}
+	if err := validateHostnamesOnly(resourceType, o); err != nil {
+		return err
+	}
+	if len(o.Columns) > 0 && (isStructuredGetFormat(o.OutputFormat) || o.OnlyDifferences || o.CRDCompare || o.HostnamesOnly) {
+		return fmt.Errorf("--columns only applies to the resource table")
+	}
+	if o.Plain {
+		if err := validatePlainGet(o); err != nil {
+			return err
+		}
+	}
+	var clusters []cluster.ClusterInfo
+	var err error
+	if kind := controlObjectKind(resourceType); kind != "" {
+		// KubeStellar control objects live in the WDS or ITS, not the managed clusters
+		contexts := GetWDSContexts()
+		if kind == "ITS" {
+			if contexts, err = selectedITSContexts(kubeconfig, remoteCtx); err != nil {
+				return err
+			}
+		}
+		clusters, err = controlPlaneClusters(kind, targets, reach, kubeconfig, contexts)
+		if err != nil {
+			return err
+		}
+	} else {
+		clusters, err = discoverClustersForGet(kubeconfig, remoteCtx, o.UseDaemon)
+		if err != nil {
+			return fmt.Errorf("failed to discover clusters: %v", err)
+		}
+		clusters, err = targets.selectFrom(clusters, kubeconfig)
+		if err != nil {
+			return err
+		}
+		clusters, err = reach.filter(clusters)
+		if err != nil {
+			return err
+		}
+	}
+	defer printClusterIssuesToStderr()
+
+	if o.SecretData.revealsData() && resourceType != "secrets" && resourceType != "secret" {
+		return fmt.Errorf("--show-data and --decode only apply to secrets")
+	}
+
+	if o.OnlyDifferences {
+		skipContext := remoteCtx
+		if controlObjectKind(resourceType) != "" {
+			skipContext = ""
+		}
+		return handleOnlyDifferences(clusters, resourceType, q, skipContext)
 	}
 
-	clusters, err := cluster.DiscoverClusters(kubeconfig, remoteCtx)
+	if o.HostnamesOnly {
+		found := handleIngressHostnames(util.GetOutputStream(), clusters, q)
+		return checkEmptyResult(found > 0, o.ExitZeroOnEmpty)
+	}
+
+	if o.CRDCompare {
+		return handleCRDComparison(clusters, q, remoteCtx)
+	}
+
+	if isStructuredGetFormat(o.OutputFormat) && !o.SecretData.revealsData() {
+		found, err := handleStructuredGet(clusters, resourceType, q)
+		if err != nil {
+			return err
+		}
+		return checkEmptyResult(found > 0, o.ExitZeroOnEmpty)
+	}
+
+	if o.Poll > 0 {
+		title := "kubectl multi get " + strings.Join(args, " ")
+		return pollGetTable(o.Poll, title, func(w io.Writer) error {
+			tw := selectGetColumns(tabwriter.NewWriter(w, 0, 0, 2, ' ', 0), o.Columns)
+			rows, err := printGetTable(tw, clusters, resourceType, q)
+			if flushErr := tw.Flush(); err == nil {
+				err = flushErr
+			}
+			if err == nil && rows == 0 {
+				fmt.Fprintln(w, "No resources found.")
+			}
+			return err
+		})
+	}
+
+	if o.Plain {
+		tw := selectGetColumns(util.NewKubectlTableWriter(util.GetOutputStream()), o.Columns)
+		rows := printPlainGet(tw, clusters, resourceType, o.OutputFormat == "wide", q)
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		return checkEmptyResult(rows > 0, o.ExitZeroOnEmpty)
+	}
+
+	tw := selectGetColumns(util.NewTableWriter(util.GetOutputStream(), o.OutputFormat), o.Columns)
+	rows, err := printGetTable(tw, clusters, resourceType, q)
+	if flushErr := tw.Flush(); err == nil {
+		err = flushErr
+	}
 	if err != nil {
-		return fmt.Errorf("failed to discover clusters: %v", err)
+		return err
+	}
+	return checkEmptyResult(rows > 0, o.ExitZeroOnEmpty)
+}
+
+// selectGetColumns narrows the tables written to tw to columns (--columns)
+func selectGetColumns(tw util.TableWriter, columns []string) util.TableWriter {
+	if len(columns) == 0 {
+		return tw
 	}
+	return util.SelectColumns(tw, columns)
+}
 
-	tw := tabwriter.NewWriter(util.GetOutputStream(), 0, 0, 2, ' ', 0)
-	defer tw.Flush()
+// discoverClustersForGet returns the clusters of a running daemon caching
+// the fleet, whose clients are answered from its caches, when useDaemon is
+// set, or discovers them
+func discoverClustersForGet(kubeconfig, remoteCtx string, useDaemon bool) ([]cluster.ClusterInfo, error) {
+	if useDaemon {
+		if clusters, ok := daemonClusters(defaultDaemonSocket()); ok {
+			return clusters, nil
+		}
+	}
+	return discoverClusters(kubeconfig, remoteCtx)
+}
 
-	// Handle different resource types
-	switch strings.ToLower(resourceType) {
+// printGetTable prints resourceType from every cluster with its table and
+// returns the number of rows printed
+func printGetTable(tw util.TableWriter, clusters []cluster.ClusterInfo, resourceType string, q tableQuery) (int, error) {
+	switch resourceType {
 
 	case "ingresses", "ingress", "ing":
-		return handleIngressesGet(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
+		return printResourceTable(tw, clusters, ingressTable(), q)
 	case "jobs", "job":
-		return handleJobsGet(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
+		return printResourceTable(tw, clusters, jobTable, q)
 	case "cronjobs", "cronjob", "cj":
-		return handleCronJobsGet(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
+		return printResourceTable(tw, clusters, cronJobTable, q)
 	case "serviceaccounts", "serviceaccount", "sa":
-		return handleServiceAccountsGet(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
+		return printResourceTable(tw, clusters, serviceAccountTable, q)
 	case "endpoints", "endpoint", "ep":
-		return handleEndpointsGet(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
+		return printResourceTable(tw, clusters, endpointsTable, q)
 	case "resourcequotas", "resourcequota", "quota":
-		return handleResourceQuotasGet(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
+		return printResourceTable(tw, clusters, resourceQuotaTable, q)
 	case "limitranges", "limitrange", "limits":
-		return handleLimitRangesGet(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
+		return printResourceTable(tw, clusters, limitRangeTable, q)
 	case "networkpolicies", "networkpolicy", "np":
-		return handleNetworkPoliciesGet(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
+		if q.Options.NetworkPolicyTarget != "" {
+			return handleNetworkPolicyAnalysis(tw, clusters, q.Options.NetworkPolicyTarget, q.Namespace)
+		}
+		return printResourceTable(tw, clusters, networkPolicyTable, q)
 	case "all":
-		return handleAllGet(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
+		return handleAllGet(tw, clusters, q)
 	case "nodes", "node", "no":
-		return handleNodesGet(tw, clusters, resourceName, selector, showLabels, outputFormat)
+		return printResourceTable(tw, clusters, nodeTable(q.Options.Capacity), q)
 	case "pods", "pod", "po":
-		return handlePodsGet(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
+		return printResourceTable(tw, clusters, podTable, q)
 	case "services", "service", "svc":
-		return handleServicesGet(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
+		return printResourceTable(tw, clusters, serviceTable, q)
 	case "deployments", "deployment", "deploy":
-		return handleDeploymentsGet(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
+		return printResourceTable(tw, clusters, deploymentTable, q)
 	case "replicasets", "replicaset", "rs":
-		return handleReplicaSetsGet(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
+		return printResourceTable(tw, clusters, replicaSetTable, q)
 	case "daemonsets", "daemonset", "ds":
-		return handleDaemonSetsGet(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
+		return printResourceTable(tw, clusters, daemonSetTable, q)
 	case "namespaces", "namespace", "ns":
-		return handleNamespacesGet(tw, clusters, resourceName, selector, showLabels, outputFormat)
+		return printResourceTable(tw, clusters, namespaceTable, q)
 	case "configmaps", "configmap", "cm":
-		return handleConfigMapsGet(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
+		return printResourceTable(tw, clusters, configMapTable, q)
 	case "statefulsets", "statefulset", "sts":
-		return handleStatefulSetsGet(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
+		return printResourceTable(tw, clusters, statefulSetTable, q)
 	case "secrets", "secret":
-		return handleSecretsGet(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
+		if q.Options.SecretData.revealsData() {
+			return handleSecretDataGet(tw, clusters, q)
+		}
+		return printResourceTable(tw, clusters, secretTable, q)
 	case "persistentvolumes", "persistentvolume", "pv":
-		return handlePVGet(tw, clusters, resourceName, selector, showLabels, outputFormat)
+		return printResourceTable(tw, clusters, pvTable, q)
 	case "persistentvolumeclaims", "persistentvolumeclaim", "pvc":
-		return handlePVCGet(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
+		return printResourceTable(tw, clusters, pvcTable, q)
 	case "events", "event", "ev":
-		return handleEventsGet(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
+		return printResourceTable(tw, clusters, eventTable, q)
 	case "role", "roles":
-		return handleRolesGet(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
+		return printResourceTable(tw, clusters, roleTable, q)
 	case "storageclasses", "storageclass", "sc":
-		return handleStorageClassesGet(tw, clusters, resourceName, selector, showLabels, outputFormat)
+		return printResourceTable(tw, clusters, storageClassTable, q)
+	case "bindings", "binding", "bindings.control.kubestellar.io":
+		return printResourceTable(tw, clusters, bindingTable, q)
+	case "workstatuses", "workstatus", "workstatuses.control.kubestellar.io":
+		return printResourceTable(tw, clusters, workStatusTable, q)
+	case "combinedstatuses", "combinedstatus", "combinedstatuses.control.kubestellar.io":
+		return printResourceTable(tw, clusters, combinedStatusTable, q)
+	case "managedclusteraddons", "managedclusteraddon", "mca", "managedclusteraddons.addon.open-cluster-management.io":
+		return printResourceTable(tw, clusters, addOnTable, q)
+	case "klusterlets", "klusterlet", "klusterlets.operator.open-cluster-management.io":
+		return printResourceTable(tw, clusters, klusterletTable, q)
+	case "serviceexports", "serviceexport", "svcex", "serviceexports.multicluster.x-k8s.io":
+		return printResourceTable(tw, clusters, serviceExportTable, q)
+	case "serviceimports", "serviceimport", "svcim", "serviceimports.multicluster.x-k8s.io":
+		return printResourceTable(tw, clusters, serviceImportTable, q)
 	default:
-		return handleGenericGet(tw, clusters, resourceType, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
+		return handleGenericGet(tw, clusters, resourceType, q, q.Options.OutputFormat)
 	}
 }
 
-func handleServiceAccountsGet(tw *tabwriter.Writer, clusters []cluster.ClusterInfo, resourceName, selector string, showLabels bool, outputFormat, namespace string, allNamespaces bool) error {
-	isHeaderPrint := false
+// allSection is a table of get all and the title printed above it
+type allSection struct {
+	title string
+	print func(tw util.TableWriter, clusters []cluster.ClusterInfo, q tableQuery) (int, error)
+}
 
-	for _, clusterInfo := range clusters {
-		if clusterInfo.Client == nil {
-			continue
-		}
+// allSections are the tables of get all, in the order they are printed;
+// capacity adds the allocatable resources to the nodes
+func allSections(capacity bool) []allSection {
+	return []allSection{
+		{"Pods", tablePrinter(podTable)},
+		{"Services", tablePrinter(serviceTable)},
+		{"Deployments", tablePrinter(deploymentTable)},
+		{"Jobs", tablePrinter(jobTable)},
+		{"CronJobs", tablePrinter(cronJobTable)},
+		{"Nodes", tablePrinter(nodeTable(capacity))},
+		{"ReplicaSets", tablePrinter(replicaSetTable)},
+		{"DaemonSets", tablePrinter(daemonSetTable)},
+		{"Namespaces", tablePrinter(namespaceTable)},
+		{"ConfigMaps", tablePrinter(configMapTable)},
+		{"StatefulSets", tablePrinter(statefulSetTable)},
+		{"Secrets", tablePrinter(secretTable)},
+		{"PersistentVolumes", tablePrinter(pvTable)},
+		{"PersistentVolumeClaims", tablePrinter(pvcTable)},
+		{"Roles", tablePrinter(roleTable)},
+	}
+}
 
-		targetNS := cluster.GetTargetNamespace(namespace)
-		if allNamespaces {
-			targetNS = ""
+// handleAllGet prints the tables of get all one after the other, each under
+// a title
+func handleAllGet(tw util.TableWriter, clusters []cluster.ClusterInfo, q tableQuery) (int, error) {
+	rows := 0
+	for i, section := range allSections(q.Options.Capacity) {
+		if i > 0 {
+			fmt.Println()
 		}
-
-		serviceAccounts, err := clusterInfo.Client.CoreV1().ServiceAccounts(targetNS).List(context.TODO(), metav1.ListOptions{
-			LabelSelector: selector,
-		})
+		fmt.Println("==> " + section.title)
+		n, err := section.print(tw, clusters, q)
 		if err != nil {
-			fmt.Printf("Warning: failed to list serviceaccounts in cluster %s: %v\n", clusterInfo.Name, err)
-			continue
-		}
-
-		if len(serviceAccounts.Items) > 0 && !isHeaderPrint {
-			// Print header only once at top when any items is greater than 0.
-			if allNamespaces {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tSECRETS\tAGE\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tSECRETS\tAGE\n")
-				}
-			} else {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tSECRETS\tAGE\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tSECRETS\tAGE\n")
-				}
-			}
-			isHeaderPrint = true
+			return 0, err
 		}
+		rows += n
+		tw.Flush()
+	}
+	return rows, nil
+}
 
-		for _, sa := range serviceAccounts.Items {
-			if resourceName != "" && sa.Name != resourceName {
-				continue
-			}
+// The tables of the types get has typed clients for
+
+var podTable = resourceTable[*corev1.Pod]{
+	Resource: "pods", Namespaced: true,
+	List: func(c cluster.ClusterInfo, ns string, opts metav1.ListOptions) (runtime.Object, error) {
+		return c.Client.CoreV1().Pods(ns).List(commandContext(), opts)
+	},
+	Keep: stateFilter.keepPod,
+	Columns: []tableColumn[*corev1.Pod]{
+		nameColumn[*corev1.Pod](),
+		{Name: "READY", Value: func(pod *corev1.Pod) string {
+			return fmt.Sprintf("%d/%d", util.GetPodReadyContainers(pod), len(pod.Spec.Containers))
+		}},
+		{Name: "STATUS", Value: func(pod *corev1.Pod) string { return string(pod.Status.Phase) }},
+		{Name: "RESTARTS", Value: func(pod *corev1.Pod) string { return strconv.Itoa(int(util.GetPodRestarts(pod))) }},
+		ageColumn[*corev1.Pod](),
+	},
+}
 
-			secrets := len(sa.Secrets)
-			age := duration.HumanDuration(time.Since(sa.CreationTimestamp.Time))
+var serviceTable = resourceTable[*corev1.Service]{
+	Resource: "services", Namespaced: true,
+	List: func(c cluster.ClusterInfo, ns string, opts metav1.ListOptions) (runtime.Object, error) {
+		return c.Client.CoreV1().Services(ns).List(commandContext(), opts)
+	},
+	Columns: []tableColumn[*corev1.Service]{
+		nameColumn[*corev1.Service](),
+		{Name: "TYPE", Value: func(svc *corev1.Service) string { return string(svc.Spec.Type) }},
+		{Name: "CLUSTER-IP", Value: func(svc *corev1.Service) string { return svc.Spec.ClusterIP }},
+		{Name: "EXTERNAL-IP", Value: util.GetServiceExternalIP},
+		{Name: "PORT(S)", Value: util.GetServicePorts},
+		ageColumn[*corev1.Service](),
+	},
+}
 
-			if allNamespaces {
-				if showLabels {
-					labels := util.FormatLabels(sa.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\n",
-						clusterInfo.Name, sa.Namespace, sa.Name, secrets, age, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n",
-						clusterInfo.Name, sa.Namespace, sa.Name, secrets, age)
-				}
-			} else {
-				if showLabels {
-					labels := util.FormatLabels(sa.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n",
-						clusterInfo.Name, sa.Name, secrets, age, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n",
-						clusterInfo.Name, sa.Name, secrets, age)
-				}
-			}
-		}
-	}
+var deploymentTable = resourceTable[*appsv1.Deployment]{
+	Group: "apps", Resource: "deployments", Namespaced: true,
+	List: func(c cluster.ClusterInfo, ns string, opts metav1.ListOptions) (runtime.Object, error) {
+		return c.Client.AppsV1().Deployments(ns).List(commandContext(), opts)
+	},
+	Keep: stateFilter.keepDeployment,
+	Columns: []tableColumn[*appsv1.Deployment]{
+		nameColumn[*appsv1.Deployment](),
+		{Name: "READY", Value: func(deploy *appsv1.Deployment) string {
+			return fmt.Sprintf("%d/%d", deploy.Status.ReadyReplicas, replicasOrZero(deploy.Spec.Replicas))
+		}},
+		{Name: "UP-TO-DATE", Value: func(deploy *appsv1.Deployment) string { return strconv.Itoa(int(deploy.Status.UpdatedReplicas)) }},
+		{Name: "AVAILABLE", Value: func(deploy *appsv1.Deployment) string { return strconv.Itoa(int(deploy.Status.AvailableReplicas)) }},
+		ageColumn[*appsv1.Deployment](),
+	},
+}
 
-	if !isHeaderPrint {
-		// print no resource found if isHeaderPrint is still false at this point
-		if allNamespaces {
-			fmt.Fprintf(tw, "No resource found.\n")
-		} else {
-			if namespace == "" {
-				namespace = "default"
-			}
-			fmt.Fprintf(tw, "No resource found in %s namespace.\n", namespace)
-		}
-	}
+var replicaSetTable = resourceTable[*appsv1.ReplicaSet]{
+	Group: "apps", Resource: "replicasets", Namespaced: true,
+	List: func(c cluster.ClusterInfo, ns string, opts metav1.ListOptions) (runtime.Object, error) {
+		return c.Client.AppsV1().ReplicaSets(ns).List(commandContext(), opts)
+	},
+	Columns: []tableColumn[*appsv1.ReplicaSet]{
+		nameColumn[*appsv1.ReplicaSet](),
+		{Name: "DESIRED", Value: func(rs *appsv1.ReplicaSet) string { return strconv.Itoa(int(replicasOrZero(rs.Spec.Replicas))) }},
+		{Name: "CURRENT", Value: func(rs *appsv1.ReplicaSet) string { return strconv.Itoa(int(rs.Status.Replicas)) }},
+		{Name: "READY", Value: func(rs *appsv1.ReplicaSet) string { return strconv.Itoa(int(rs.Status.ReadyReplicas)) }},
+		ageColumn[*appsv1.ReplicaSet](),
+	},
+}
 
-	return nil
+var statefulSetTable = resourceTable[*appsv1.StatefulSet]{
+	Group: "apps", Resource: "statefulsets", Namespaced: true,
+	List: func(c cluster.ClusterInfo, ns string, opts metav1.ListOptions) (runtime.Object, error) {
+		return c.Client.AppsV1().StatefulSets(ns).List(commandContext(), opts)
+	},
+	Columns: []tableColumn[*appsv1.StatefulSet]{
+		nameColumn[*appsv1.StatefulSet](),
+		{Name: "READY", Value: func(sts *appsv1.StatefulSet) string {
+			return fmt.Sprintf("%d/%d", sts.Status.ReadyReplicas, replicasOrZero(sts.Spec.Replicas))
+		}},
+		ageColumn[*appsv1.StatefulSet](),
+	},
 }
 
-func handleEndpointsGet(tw *tabwriter.Writer, clusters []cluster.ClusterInfo, resourceName, selector string, showLabels bool, outputFormat, namespace string, allNamespaces bool) error {
-	isHeaderPrint := false
+var daemonSetTable = resourceTable[*appsv1.DaemonSet]{
+	Group: "apps", Resource: "daemonsets", Namespaced: true,
+	List: func(c cluster.ClusterInfo, ns string, opts metav1.ListOptions) (runtime.Object, error) {
+		return c.Client.AppsV1().DaemonSets(ns).List(commandContext(), opts)
+	},
+	Columns: []tableColumn[*appsv1.DaemonSet]{
+		nameColumn[*appsv1.DaemonSet](),
+		{Name: "DESIRED", Value: func(ds *appsv1.DaemonSet) string { return strconv.Itoa(int(ds.Status.DesiredNumberScheduled)) }},
+		{Name: "CURRENT", Value: func(ds *appsv1.DaemonSet) string { return strconv.Itoa(int(ds.Status.CurrentNumberScheduled)) }},
+		{Name: "READY", Value: func(ds *appsv1.DaemonSet) string { return strconv.Itoa(int(ds.Status.NumberReady)) }},
+		{Name: "UP-TO-DATE", Value: func(ds *appsv1.DaemonSet) string { return strconv.Itoa(int(ds.Status.UpdatedNumberScheduled)) }},
+		{Name: "AVAILABLE", Value: func(ds *appsv1.DaemonSet) string { return strconv.Itoa(int(ds.Status.NumberAvailable)) }},
+		{Name: "NODE SELECTOR", Value: func(ds *appsv1.DaemonSet) string { return util.FormatLabels(ds.Spec.Template.Spec.NodeSelector) }},
+		ageColumn[*appsv1.DaemonSet](),
+	},
+}
 
-	for _, clusterInfo := range clusters {
-		if clusterInfo.Client == nil {
-			continue
-		}
+var jobTable = resourceTable[*batchv1.Job]{
+	Group: "batch", Resource: "jobs", Namespaced: true,
+	List: func(c cluster.ClusterInfo, ns string, opts metav1.ListOptions) (runtime.Object, error) {
+		return c.Client.BatchV1().Jobs(ns).List(commandContext(), opts)
+	},
+	Keep: stateFilter.keepJob,
+	Columns: []tableColumn[*batchv1.Job]{
+		nameColumn[*batchv1.Job](),
+		{Name: "COMPLETIONS", Value: func(job *batchv1.Job) string {
+			completions := int32(1)
+			if job.Spec.Completions != nil {
+				completions = *job.Spec.Completions
+			}
+			return fmt.Sprintf("%d/%d", job.Status.Succeeded, completions)
+		}},
+		{Name: "DURATION", Value: func(job *batchv1.Job) string {
+			switch {
+			case job.Status.StartTime == nil:
+				return "<unknown>"
+			case job.Status.CompletionTime != nil:
+				return duration.HumanDuration(job.Status.CompletionTime.Sub(job.Status.StartTime.Time))
+			}
+			return objectAge(*job.Status.StartTime)
+		}},
+		ageColumn[*batchv1.Job](),
+	},
+}
 
-		targetNS := cluster.GetTargetNamespace(namespace)
-		if allNamespaces {
-			targetNS = ""
-		}
+var cronJobTable = resourceTable[*batchv1.CronJob]{
+	Group: "batch", Resource: "cronjobs", Namespaced: true,
+	List: func(c cluster.ClusterInfo, ns string, opts metav1.ListOptions) (runtime.Object, error) {
+		return c.Client.BatchV1().CronJobs(ns).List(commandContext(), opts)
+	},
+	Columns: []tableColumn[*batchv1.CronJob]{
+		nameColumn[*batchv1.CronJob](),
+		{Name: "SCHEDULE", Value: func(cj *batchv1.CronJob) string { return cj.Spec.Schedule }},
+		{Name: "SUSPEND", Value: func(cj *batchv1.CronJob) string {
+			if cj.Spec.Suspend != nil && *cj.Spec.Suspend {
+				return "True"
+			}
+			return "False"
+		}},
+		{Name: "ACTIVE", Value: func(cj *batchv1.CronJob) string { return strconv.Itoa(len(cj.Status.Active)) }},
+		{Name: "LAST SCHEDULE", Value: func(cj *batchv1.CronJob) string {
+			if cj.Status.LastScheduleTime == nil {
+				return "<none>"
+			}
+			return objectAge(*cj.Status.LastScheduleTime)
+		}},
+		ageColumn[*batchv1.CronJob](),
+	},
+}
 
-		endpoints, err := clusterInfo.Client.CoreV1().Endpoints(targetNS).List(context.TODO(), metav1.ListOptions{
-			LabelSelector: selector,
-		})
-		if err != nil {
-			fmt.Printf("Warning: failed to list endpoints in cluster %s: %v\n", clusterInfo.Name, err)
-			continue
-		}
+var configMapTable = resourceTable[*corev1.ConfigMap]{
+	Resource: "configmaps", Namespaced: true,
+	List: func(c cluster.ClusterInfo, ns string, opts metav1.ListOptions) (runtime.Object, error) {
+		return c.Client.CoreV1().ConfigMaps(ns).List(commandContext(), opts)
+	},
+	Columns: []tableColumn[*corev1.ConfigMap]{
+		nameColumn[*corev1.ConfigMap](),
+		{Name: "DATA", Value: func(cm *corev1.ConfigMap) string { return strconv.Itoa(len(cm.Data) + len(cm.BinaryData)) }},
+		ageColumn[*corev1.ConfigMap](),
+	},
+}
 
-		if len(endpoints.Items) > 0 && !isHeaderPrint {
-			// Print header only once at top when any items is greater than 0.
-			if allNamespaces {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tENDPOINTS\tAGE\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tENDPOINTS\tAGE\n")
-				}
-			} else {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tENDPOINTS\tAGE\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tENDPOINTS\tAGE\n")
-				}
-			}
-			isHeaderPrint = true
-		}
+var secretTable = resourceTable[*corev1.Secret]{
+	Resource: "secrets", Namespaced: true,
+	List: func(c cluster.ClusterInfo, ns string, opts metav1.ListOptions) (runtime.Object, error) {
+		return c.Client.CoreV1().Secrets(ns).List(commandContext(), opts)
+	},
+	Columns: []tableColumn[*corev1.Secret]{
+		nameColumn[*corev1.Secret](),
+		{Name: "TYPE", Value: func(secret *corev1.Secret) string { return string(secret.Type) }},
+		{Name: "DATA", Value: func(secret *corev1.Secret) string { return strconv.Itoa(len(secret.Data)) }},
+		ageColumn[*corev1.Secret](),
+	},
+}
 
-		for _, ep := range endpoints.Items {
-			if resourceName != "" && ep.Name != resourceName {
-				continue
-			}
+var serviceAccountTable = resourceTable[*corev1.ServiceAccount]{
+	Resource: "serviceaccounts", Namespaced: true,
+	List: func(c cluster.ClusterInfo, ns string, opts metav1.ListOptions) (runtime.Object, error) {
+		return c.Client.CoreV1().ServiceAccounts(ns).List(commandContext(), opts)
+	},
+	Columns: []tableColumn[*corev1.ServiceAccount]{
+		nameColumn[*corev1.ServiceAccount](),
+		{Name: "SECRETS", Value: func(sa *corev1.ServiceAccount) string { return strconv.Itoa(len(sa.Secrets)) }},
+		ageColumn[*corev1.ServiceAccount](),
+	},
+}
 
-			// Format endpoints
-			var endpointsList []string
+var endpointsTable = resourceTable[*corev1.Endpoints]{
+	Resource: "endpoints", Namespaced: true,
+	List: func(c cluster.ClusterInfo, ns string, opts metav1.ListOptions) (runtime.Object, error) {
+		return c.Client.CoreV1().Endpoints(ns).List(commandContext(), opts)
+	},
+	Columns: []tableColumn[*corev1.Endpoints]{
+		nameColumn[*corev1.Endpoints](),
+		{Name: "ENDPOINTS", Value: func(ep *corev1.Endpoints) string {
+			var addresses []string
 			for _, subset := range ep.Subsets {
 				for _, addr := range subset.Addresses {
 					for _, port := range subset.Ports {
-						endpointsList = append(endpointsList, fmt.Sprintf("%s:%d", addr.IP, port.Port))
+						addresses = append(addresses, fmt.Sprintf("%s:%d", addr.IP, port.Port))
 					}
 				}
 			}
+			return noneIfEmpty(strings.Join(addresses, ","))
+		}},
+		ageColumn[*corev1.Endpoints](),
+	},
+}
 
-			endpointsStr := "<none>"
-			if len(endpointsList) > 0 {
-				endpointsStr = strings.Join(endpointsList, ",")
-			}
-
-			age := duration.HumanDuration(time.Since(ep.CreationTimestamp.Time))
+var resourceQuotaTable = resourceTable[*corev1.ResourceQuota]{
+	Resource: "resourcequotas", Namespaced: true,
+	List: func(c cluster.ClusterInfo, ns string, opts metav1.ListOptions) (runtime.Object, error) {
+		return c.Client.CoreV1().ResourceQuotas(ns).List(commandContext(), opts)
+	},
+	Columns: []tableColumn[*corev1.ResourceQuota]{
+		nameColumn[*corev1.ResourceQuota](),
+		ageColumn[*corev1.ResourceQuota](),
+		{Name: "HARD", Value: func(rq *corev1.ResourceQuota) string { return quotaSummary(rq.Status.Hard) }},
+		{Name: "USED", Value: func(rq *corev1.ResourceQuota) string { return quotaSummary(rq.Status.Used) }},
+	},
+}
 
-			if allNamespaces {
-				if showLabels {
-					labels := util.FormatLabels(ep.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, ep.Namespace, ep.Name, endpointsStr, age, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, ep.Namespace, ep.Name, endpointsStr, age)
-				}
-			} else {
-				if showLabels {
-					labels := util.FormatLabels(ep.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, ep.Name, endpointsStr, age, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, ep.Name, endpointsStr, age)
-				}
+// quotaSummary formats the CPU, memory and pods of a quota, requests in
+// preference to limits, with - for those it does not set
+func quotaSummary(resources corev1.ResourceList) string {
+	value := func(names ...corev1.ResourceName) string {
+		for _, name := range names {
+			if q, ok := resources[name]; ok {
+				return q.String()
 			}
 		}
+		return "-"
 	}
+	return fmt.Sprintf("cpu:%s,mem:%s,pods:%s",
+		value(corev1.ResourceRequestsCPU, corev1.ResourceLimitsCPU),
+		value(corev1.ResourceRequestsMemory, corev1.ResourceLimitsMemory),
+		value(corev1.ResourcePods))
+}
 
-	if !isHeaderPrint {
-		// print no resource found if isHeaderPrint is still false at this point
-		if allNamespaces {
-			fmt.Fprintf(tw, "No resource found.\n")
-		} else {
-			if namespace == "" {
-				namespace = "default"
-			}
-			fmt.Fprintf(tw, "No resource found in %s namespace.\n", namespace)
-		}
-	}
+var limitRangeTable = resourceTable[*corev1.LimitRange]{
+	Resource: "limitranges", Namespaced: true,
+	List: func(c cluster.ClusterInfo, ns string, opts metav1.ListOptions) (runtime.Object, error) {
+		return c.Client.CoreV1().LimitRanges(ns).List(commandContext(), opts)
+	},
+	Columns: []tableColumn[*corev1.LimitRange]{
+		nameColumn[*corev1.LimitRange](),
+		{Name: "CREATED AT", Value: func(lr *corev1.LimitRange) string { return objectAge(lr.CreationTimestamp) }},
+	},
+}
 
-	return nil
+var pvcTable = resourceTable[*corev1.PersistentVolumeClaim]{
+	Resource: "persistentvolumeclaims", Namespaced: true,
+	List: func(c cluster.ClusterInfo, ns string, opts metav1.ListOptions) (runtime.Object, error) {
+		return c.Client.CoreV1().PersistentVolumeClaims(ns).List(commandContext(), opts)
+	},
+	Columns: []tableColumn[*corev1.PersistentVolumeClaim]{
+		nameColumn[*corev1.PersistentVolumeClaim](),
+		{Name: "STATUS", Value: func(pvc *corev1.PersistentVolumeClaim) string { return string(pvc.Status.Phase) }},
+		{Name: "VOLUME", Value: func(pvc *corev1.PersistentVolumeClaim) string { return pvc.Spec.VolumeName }},
+		{Name: "CAPACITY", Value: util.GetPVCCapacity},
+		{Name: "ACCESS MODES", Value: util.GetPVCAccessModes},
+		{Name: "STORAGE CLASS", Value: util.GetPVCStorageClass},
+		ageColumn[*corev1.PersistentVolumeClaim](),
+	},
 }
 
-func handleResourceQuotasGet(tw *tabwriter.Writer, clusters []cluster.ClusterInfo, resourceName, selector string, showLabels bool, outputFormat, namespace string, allNamespaces bool) error {
-	isHeaderPrint := false
+var eventTable = resourceTable[*corev1.Event]{
+	Resource: "events", Namespaced: true,
+	List: func(c cluster.ClusterInfo, ns string, opts metav1.ListOptions) (runtime.Object, error) {
+		return c.Client.CoreV1().Events(ns).List(commandContext(), opts)
+	},
+	Columns: []tableColumn[*corev1.Event]{
+		{Name: "LAST SEEN", Value: func(event *corev1.Event) string {
+			switch {
+			case !event.LastTimestamp.IsZero():
+				return objectAge(event.LastTimestamp) + " ago"
+			case !event.FirstTimestamp.IsZero():
+				return objectAge(event.FirstTimestamp) + " ago"
+			}
+			return "<unknown>"
+		}},
+		{Name: "TYPE", Value: func(event *corev1.Event) string { return event.Type }},
+		{Name: "REASON", Value: func(event *corev1.Event) string { return event.Reason }},
+		{Name: "OBJECT", Value: func(event *corev1.Event) string {
+			return event.InvolvedObject.Kind + "/" + event.InvolvedObject.Name
+		}},
+		{Name: "MESSAGE", Value: func(event *corev1.Event) string { return event.Message }},
+	},
+}
 
-	for _, clusterInfo := range clusters {
-		if clusterInfo.Client == nil {
-			continue
-		}
+var networkPolicyTable = resourceTable[*networkingv1.NetworkPolicy]{
+	Group: "networking.k8s.io", Resource: "networkpolicies", Namespaced: true,
+	List: func(c cluster.ClusterInfo, ns string, opts metav1.ListOptions) (runtime.Object, error) {
+		return c.Client.NetworkingV1().NetworkPolicies(ns).List(commandContext(), opts)
+	},
+	Columns: []tableColumn[*networkingv1.NetworkPolicy]{
+		nameColumn[*networkingv1.NetworkPolicy](),
+		{Name: "POD-SELECTOR", Value: func(np *networkingv1.NetworkPolicy) string {
+			if np.Spec.PodSelector.Size() == 0 {
+				return "<none>"
+			}
+			return metav1.FormatLabelSelector(&np.Spec.PodSelector)
+		}},
+		{Name: "POLICY-TYPES", Value: func(np *networkingv1.NetworkPolicy) string {
+			var types []string
+			for _, t := range np.Spec.PolicyTypes {
+				types = append(types, string(t))
+			}
+			return noneIfEmpty(strings.Join(types, ","))
+		}},
+		ageColumn[*networkingv1.NetworkPolicy](),
+	},
+}
 
-		targetNS := cluster.GetTargetNamespace(namespace)
-		if allNamespaces {
-			targetNS = ""
-		}
+var roleTable = resourceTable[*rbacv1.Role]{
+	Group: "rbac.authorization.k8s.io", Resource: "roles", Namespaced: true,
+	List: func(c cluster.ClusterInfo, ns string, opts metav1.ListOptions) (runtime.Object, error) {
+		return c.Client.RbacV1().Roles(ns).List(commandContext(), opts)
+	},
+	Columns: []tableColumn[*rbacv1.Role]{
+		nameColumn[*rbacv1.Role](),
+		{Name: "CREATED-AT", Value: func(role *rbacv1.Role) string { return role.CreationTimestamp.String() }},
+	},
+}
 
-		resourceQuotas, err := clusterInfo.Client.CoreV1().ResourceQuotas(targetNS).List(context.TODO(), metav1.ListOptions{
-			LabelSelector: selector,
-		})
-		if err != nil {
-			fmt.Printf("Warning: failed to list resourcequotas in cluster %s: %v\n", clusterInfo.Name, err)
-			continue
-		}
+// nodeTable lists nodes, with --capacity their allocatable CPU, memory and
+// pods and, below, the totals of every cluster and of the fleet
+func nodeTable(capacity bool) resourceTable[*corev1.Node] {
+	table := resourceTable[*corev1.Node]{
+		Resource: "nodes",
+		List: func(c cluster.ClusterInfo, _ string, opts metav1.ListOptions) (runtime.Object, error) {
+			return c.Client.CoreV1().Nodes().List(commandContext(), opts)
+		},
+		Columns: []tableColumn[*corev1.Node]{
+			nameColumn[*corev1.Node](),
+			{Name: "STATUS", Value: func(node *corev1.Node) string { return util.GetNodeStatus(*node) }},
+			{Name: "ROLES", Value: func(node *corev1.Node) string { return util.GetNodeRole(*node) }},
+			ageColumn[*corev1.Node](),
+			{Name: "VERSION", Value: func(node *corev1.Node) string { return node.Status.NodeInfo.KubeletVersion }},
+		},
+	}
+	if !capacity {
+		return table
+	}
 
-		if len(resourceQuotas.Items) > 0 && !isHeaderPrint {
-			// Print header only once at top when any items is greater than 0.
-			if allNamespaces {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tAGE\tHARD\tUSED\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tAGE\tHARD\tUSED\n")
-				}
-			} else {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tAGE\tHARD\tUSED\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tAGE\tHARD\tUSED\n")
-				}
-			}
-			isHeaderPrint = true
+	var totals []clusterCapacity
+	table.Columns = append(table.Columns,
+		tableColumn[*corev1.Node]{Name: "CPU", Value: func(node *corev1.Node) string { return formatCPU(node.Status.Allocatable[corev1.ResourceCPU]) }},
+		tableColumn[*corev1.Node]{Name: "MEMORY", Value: func(node *corev1.Node) string { return formatMemory(node.Status.Allocatable[corev1.ResourceMemory]) }},
+		tableColumn[*corev1.Node]{Name: "PODS", Value: func(node *corev1.Node) string {
+			pods := node.Status.Allocatable[corev1.ResourcePods]
+			return strconv.FormatInt(pods.Value(), 10)
+		}},
+	)
+	table.ForCluster = func(c cluster.ClusterInfo, nodes []*corev1.Node) {
+		if len(nodes) == 0 {
+			return
 		}
+		total := clusterCapacity{cluster: c.Name}
+		for _, node := range nodes {
+			var n nodeCapacity
+			n.add(node)
+			total.merge(n)
+		}
+		totals = append(totals, total)
+	}
+	table.Finish = func(tw util.TableWriter) {
+		printCapacityTotals(tw, totals)
+	}
+	return table
+}
 
-		for _, rq := range resourceQuotas.Items {
-			if resourceName != "" && rq.Name != resourceName {
-				continue
-			}
-
-			age := duration.HumanDuration(time.Since(rq.CreationTimestamp.Time))
-
-			// Format key quota metrics in a structured way
-			hardLimits := rq.Status.Hard
-			usedResources := rq.Status.Used
-
-			// Extract key metrics: CPU, Memory, Pods
-			var hardCPU, hardMemory, hardPods string = "-", "-", "-"
-			var usedCPU, usedMemory, usedPods string = "-", "-", "-"
-
-			if hardLimits != nil {
-				if val, ok := hardLimits["requests.cpu"]; ok {
-					hardCPU = val.String()
-				} else if val, ok := hardLimits["limits.cpu"]; ok {
-					hardCPU = val.String()
-				}
-				if val, ok := hardLimits["requests.memory"]; ok {
-					hardMemory = val.String()
-				} else if val, ok := hardLimits["limits.memory"]; ok {
-					hardMemory = val.String()
-				}
-				if val, ok := hardLimits["pods"]; ok {
-					hardPods = val.String()
-				}
-			}
-
-			if usedResources != nil {
-				if val, ok := usedResources["requests.cpu"]; ok {
-					usedCPU = val.String()
-				} else if val, ok := usedResources["limits.cpu"]; ok {
-					usedCPU = val.String()
-				}
-				if val, ok := usedResources["requests.memory"]; ok {
-					usedMemory = val.String()
-				} else if val, ok := usedResources["limits.memory"]; ok {
-					usedMemory = val.String()
-				}
-				if val, ok := usedResources["pods"]; ok {
-					usedPods = val.String()
-				}
-			}
+var namespaceTable = resourceTable[*corev1.Namespace]{
+	Resource: "namespaces",
+	List: func(c cluster.ClusterInfo, _ string, opts metav1.ListOptions) (runtime.Object, error) {
+		return c.Client.CoreV1().Namespaces().List(commandContext(), opts)
+	},
+	Columns: []tableColumn[*corev1.Namespace]{
+		nameColumn[*corev1.Namespace](),
+		{Name: "STATUS", Value: func(ns *corev1.Namespace) string { return string(ns.Status.Phase) }},
+		ageColumn[*corev1.Namespace](),
+	},
+}
 
-			hardStr := fmt.Sprintf("cpu:%s,mem:%s,pods:%s", hardCPU, hardMemory, hardPods)
-			usedStr := fmt.Sprintf("cpu:%s,mem:%s,pods:%s", usedCPU, usedMemory, usedPods)
+var pvTable = resourceTable[*corev1.PersistentVolume]{
+	Resource: "persistentvolumes",
+	List: func(c cluster.ClusterInfo, _ string, opts metav1.ListOptions) (runtime.Object, error) {
+		return c.Client.CoreV1().PersistentVolumes().List(commandContext(), opts)
+	},
+	Columns: []tableColumn[*corev1.PersistentVolume]{
+		nameColumn[*corev1.PersistentVolume](),
+		{Name: "CAPACITY", Value: util.GetPVCapacity},
+		{Name: "ACCESS MODES", Value: util.GetPVAccessModes},
+		{Name: "RECLAIM POLICY", Value: func(pv *corev1.PersistentVolume) string { return string(pv.Spec.PersistentVolumeReclaimPolicy) }},
+		{Name: "STATUS", Value: func(pv *corev1.PersistentVolume) string { return string(pv.Status.Phase) }},
+		{Name: "CLAIM", Value: util.GetPVClaim},
+		{Name: "STORAGE CLASS", Value: util.GetPVStorageClass},
+		{Name: "REASON", Value: func(pv *corev1.PersistentVolume) string { return pv.Status.Reason }},
+		ageColumn[*corev1.PersistentVolume](),
+	},
+}
 
-			if allNamespaces {
-				if showLabels {
-					labels := util.FormatLabels(rq.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, rq.Namespace, rq.Name, age, hardStr, usedStr, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, rq.Namespace, rq.Name, age, hardStr, usedStr)
-				}
-			} else {
-				if showLabels {
-					labels := util.FormatLabels(rq.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, rq.Name, age, hardStr, usedStr, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, rq.Name, age, hardStr, usedStr)
-				}
-			}
-		}
-	}
+var storageClassTable = resourceTable[*storagev1.StorageClass]{
+	Group: "storage.k8s.io", Resource: "storageclasses",
+	List: func(c cluster.ClusterInfo, _ string, opts metav1.ListOptions) (runtime.Object, error) {
+		return c.Client.StorageV1().StorageClasses().List(commandContext(), opts)
+	},
+	Columns: []tableColumn[*storagev1.StorageClass]{
+		nameColumn[*storagev1.StorageClass](),
+		{Name: "PROVISIONER", Value: func(sc *storagev1.StorageClass) string { return sc.Provisioner }},
+		{Name: "RECLAIMPOLICY", Value: func(sc *storagev1.StorageClass) string {
+			if sc.ReclaimPolicy == nil {
+				return "Delete"
+			}
+			return string(*sc.ReclaimPolicy)
+		}},
+		{Name: "VOLUMEBINDINGMODE", Value: func(sc *storagev1.StorageClass) string {
+			if sc.VolumeBindingMode == nil {
+				return "Immediate"
+			}
+			return string(*sc.VolumeBindingMode)
+		}},
+		{Name: "ALLOWVOLUMEEXPANSION", Value: func(sc *storagev1.StorageClass) string {
+			return strconv.FormatBool(sc.AllowVolumeExpansion != nil && *sc.AllowVolumeExpansion)
+		}},
+		ageColumn[*storagev1.StorageClass](),
+	},
+}
 
-	if !isHeaderPrint {
-		// print no resource found if isHeaderPrint is still false at this point
-		if allNamespaces {
-			fmt.Fprintf(tw, "No resource found.\n")
-		} else {
-			if namespace == "" {
-				namespace = "default"
-			}
-			fmt.Fprintf(tw, "No resource found in %s namespace.\n", namespace)
-		}
+func replicasOrZero(replicas *int32) int32 {
+	if replicas == nil {
+		return 0
 	}
-
-	return nil
+	return *replicas
 }
 
-func handleLimitRangesGet(tw *tabwriter.Writer, clusters []cluster.ClusterInfo, resourceName, selector string, showLabels bool, outputFormat, namespace string, allNamespaces bool) error {
-	isHeaderPrint := false
+func handleGenericGet(tw util.TableWriter, clusters []cluster.ClusterInfo, resourceType string, q tableQuery, outputFormat string) (int, error) {
+	selector, namespace, allNamespaces := q.Selector, q.Namespace, q.AllNamespaces
+	rows := 0
+	customPrinters, err := printers.Load(config.PrintersPath())
+	if err != nil {
+		return 0, err
+	}
+	var table *printers.Table
 
 	for _, clusterInfo := range clusters {
-		if clusterInfo.Client == nil {
+		if clusterInfo.DynamicClient == nil {
+			continue
+		}
+
+		// Try to discover the resource
+		gvr, isNamespaced, err := util.DiscoverGVR(clusterInfo.DiscoveryClient, resourceType)
+		if err != nil {
+			noteClusterIssue(clusterInfo.Name, fmt.Sprintf("failed to discover resource %s: %v", resourceType, err))
 			continue
 		}
 
 		targetNS := cluster.GetTargetNamespace(namespace)
-		if allNamespaces {
-			targetNS = ""
+		var list *unstructured.UnstructuredList
+
+		if isNamespaced && !allNamespaces && targetNS != "" {
+			list, err = clusterInfo.DynamicClient.Resource(gvr).Namespace(targetNS).List(commandContext(), metav1.ListOptions{
+				LabelSelector: selector,
+			})
+		} else if isNamespaced {
+			list, err = listNamespaced(clusterInfo, gvr.Group, gvr.Resource, "", func(ns string) (*unstructured.UnstructuredList, error) {
+				return clusterInfo.DynamicClient.Resource(gvr).Namespace(ns).List(commandContext(), metav1.ListOptions{
+					LabelSelector: selector,
+				})
+			})
+		} else {
+			list, err = clusterInfo.DynamicClient.Resource(gvr).List(commandContext(), metav1.ListOptions{
+				LabelSelector: selector,
+			})
 		}
 
-		limitRanges, err := clusterInfo.Client.CoreV1().LimitRanges(targetNS).List(context.TODO(), metav1.ListOptions{
-			LabelSelector: selector,
-		})
 		if err != nil {
-			fmt.Printf("Warning: failed to list limitranges in cluster %s: %v\n", clusterInfo.Name, err)
+			noteClusterIssue(clusterInfo.Name, fmt.Sprintf("failed to list %s: %v", resourceType, err))
 			continue
 		}
 
-		if len(limitRanges.Items) > 0 && !isHeaderPrint {
-			// Print header only once at top when any items is greater than 0.
-			if allNamespaces {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tCREATED AT\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tCREATED AT\n")
-				}
-			} else {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tCREATED AT\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tCREATED AT\n")
+		list.Items = itemsNamed(list.Items, q.Name, q.Options.ManagedOnly)
+		if len(list.Items) > 0 && rows == 0 {
+			// The columns a team defined for the type, if any, are chosen
+			// along with the header
+			if p, ok := printers.Lookup(gvr, customPrinters); ok {
+				if table, err = p.Table(outputFormat == "wide"); err != nil {
+					return 0, err
 				}
 			}
-			isHeaderPrint = true
-		}
-
-		for _, lr := range limitRanges.Items {
-			if resourceName != "" && lr.Name != resourceName {
-				continue
-			}
-
-			age := duration.HumanDuration(time.Since(lr.CreationTimestamp.Time))
-
+			// Print the header once, before the first matching row
+			header := []string{"CLUSTER"}
 			if allNamespaces {
-				if showLabels {
-					labels := util.FormatLabels(lr.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, lr.Namespace, lr.Name, age, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, lr.Namespace, lr.Name, age)
-				}
-			} else {
-				if showLabels {
-					labels := util.FormatLabels(lr.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, lr.Name, age, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\n",
-						clusterInfo.Name, lr.Name, age)
-				}
+				header = append(header, "NAMESPACE")
 			}
-		}
-	}
-
-	if !isHeaderPrint {
-		// print no resource found if isHeaderPrint is still false at this point
-		if allNamespaces {
-			fmt.Fprintf(tw, "No resource found.\n")
-		} else {
-			if namespace == "" {
-				namespace = "default"
+			header = append(header, "NAME")
+			if table != nil {
+				header = append(header, table.Columns()...)
 			}
-			fmt.Fprintf(tw, "No resource found in %s namespace.\n", namespace)
-		}
-	}
-
-	return nil
-}
-
-func handleIngressesGet(tw *tabwriter.Writer, clusters []cluster.ClusterInfo, resourceName, selector string, showLabels bool, outputFormat, namespace string, allNamespaces bool) error {
-	isHeaderPrint := false
-
-	for _, clusterInfo := range clusters {
-		if clusterInfo.Client == nil {
-			continue
-		}
-
-		targetNS := cluster.GetTargetNamespace(namespace)
-		if allNamespaces {
-			targetNS = ""
+			header = append(header, "AGE")
+			header = append(header, q.Annotations.header()...)
+			if q.ShowLabels {
+				header = append(header, "LABELS")
+			}
+			fmt.Fprintln(tw, strings.Join(header, "\t"))
 		}
+		rows += len(list.Items)
 
-		ingresses, err := clusterInfo.Client.NetworkingV1().Ingresses(targetNS).List(context.TODO(), metav1.ListOptions{
-			LabelSelector: selector,
-		})
-		if err != nil {
-			fmt.Printf("Warning: failed to list ingresses in cluster %s: %v\n", clusterInfo.Name, err)
-			continue
-		}
-
-		if len(ingresses.Items) > 0 && !isHeaderPrint {
-			// Print header only once at top when any items is greater than 0.
-			if allNamespaces {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tHOSTS\tADDRESS\tPORTS\tAGE\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tHOSTS\tADDRESS\tPORTS\tAGE\n")
-				}
-			} else {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tHOSTS\tADDRESS\tPORTS\tAGE\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tHOSTS\tADDRESS\tPORTS\tAGE\n")
-				}
-			}
-			isHeaderPrint = true
-		}
-
-		for _, ing := range ingresses.Items {
-			if resourceName != "" && ing.Name != resourceName {
-				continue
-			}
-
-			// Gather hosts
-			var hosts []string
-			for _, rule := range ing.Spec.Rules {
-				if rule.Host != "" {
-					hosts = append(hosts, rule.Host)
-				}
-			}
-			hostsStr := strings.Join(hosts, ",")
-			if hostsStr == "" {
-				hostsStr = "<none>"
-			}
-
-			// Gather address
-			address := "<none>"
-			if len(ing.Status.LoadBalancer.Ingress) > 0 {
-				var addrs []string
-				for _, lb := range ing.Status.LoadBalancer.Ingress {
-					if lb.IP != "" {
-						addrs = append(addrs, lb.IP)
-					} else if lb.Hostname != "" {
-						addrs = append(addrs, lb.Hostname)
-					}
-				}
-				if len(addrs) > 0 {
-					address = strings.Join(addrs, ",")
-				}
-			}
-
-			// Gather ports
-			var ports []string
-			for _, rule := range ing.Spec.Rules {
-				if rule.HTTP != nil {
-					for range rule.HTTP.Paths {
-						// Ingress does not specify port directly; default is 80
-						ports = append(ports, "80")
-					}
-				}
-			}
-			// If TLS is specified, port 443 is implied
-			if len(ing.Spec.TLS) > 0 {
-				ports = append(ports, "443")
-			}
-			// Deduplicate ports
-			portMap := make(map[string]struct{})
-			for _, p := range ports {
-				portMap[p] = struct{}{}
-			}
-			var portList []string
-			for p := range portMap {
-				portList = append(portList, p)
-			}
-			portsStr := strings.Join(portList, ",")
-			if portsStr == "" {
-				portsStr = "<none>"
-			}
-
-			age := duration.HumanDuration(time.Since(ing.CreationTimestamp.Time))
-
-			if allNamespaces {
-				if showLabels {
-					labels := util.FormatLabels(ing.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, ing.Namespace, ing.Name, hostsStr, address, portsStr, age, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, ing.Namespace, ing.Name, hostsStr, address, portsStr, age)
-				}
-			} else {
-				if showLabels {
-					labels := util.FormatLabels(ing.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, ing.Name, hostsStr, address, portsStr, age, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, ing.Name, hostsStr, address, portsStr, age)
-				}
-			}
-		}
-	}
-
-	if !isHeaderPrint {
-		// print no resource found if isHeaderPrint is still false at this point
-		if allNamespaces {
-			fmt.Fprintf(tw, "No resource found.\n")
-		} else {
-			if namespace == "" {
-				namespace = "default"
-			}
-			fmt.Fprintf(tw, "No resource found in %s namespace.\n", namespace)
-		}
-	}
-
-	return nil
-}
-
-func handleJobsGet(tw *tabwriter.Writer, clusters []cluster.ClusterInfo, resourceName, selector string, showLabels bool, outputFormat, namespace string, allNamespaces bool) error {
-	isHeaderPrint := false
-
-	for _, clusterInfo := range clusters {
-		if clusterInfo.Client == nil {
-			continue
-		}
-
-		targetNS := cluster.GetTargetNamespace(namespace)
-		if allNamespaces {
-			targetNS = ""
-		}
-
-		jobs, err := clusterInfo.Client.BatchV1().Jobs(targetNS).List(context.TODO(), metav1.ListOptions{
-			LabelSelector: selector,
-		})
-		if err != nil {
-			fmt.Printf("Warning: failed to list jobs in cluster %s: %v\n", clusterInfo.Name, err)
-			continue
-		}
-
-		if len(jobs.Items) > 0 && !isHeaderPrint {
-			// Print header only once at top when items len is greater than 0.
-			if allNamespaces {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tCOMPLETIONS\tDURATION\tAGE\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tCOMPLETIONS\tDURATION\tAGE\n")
-				}
-			} else {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tCOMPLETIONS\tDURATION\tAGE\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tCOMPLETIONS\tDURATION\tAGE\n")
-				}
-			}
-			isHeaderPrint = true
-		}
-
-		for _, job := range jobs.Items {
-			if resourceName != "" && job.Name != resourceName {
-				continue
-			}
-
-			// Calculate completions
-			var completions string
-			if job.Spec.Completions != nil {
-				completions = fmt.Sprintf("%d/%d", job.Status.Succeeded, *job.Spec.Completions)
-			} else {
-				completions = fmt.Sprintf("%d/1", job.Status.Succeeded)
-			}
-
-			// Calculate duration
-			var jobDuration string
-			if job.Status.StartTime != nil {
-				if job.Status.CompletionTime != nil {
-					jobDuration = duration.HumanDuration(job.Status.CompletionTime.Sub(job.Status.StartTime.Time))
-				} else {
-					jobDuration = duration.HumanDuration(time.Since(job.Status.StartTime.Time))
-				}
-			} else {
-				jobDuration = "<unknown>"
-			}
-
-			// Calculate age
-			age := duration.HumanDuration(time.Since(job.CreationTimestamp.Time))
-
-			if allNamespaces {
-				if showLabels {
-					labels := util.FormatLabels(job.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, job.Namespace, job.Name, completions, jobDuration, age, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, job.Namespace, job.Name, completions, jobDuration, age)
-				}
-			} else {
-				if showLabels {
-					labels := util.FormatLabels(job.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, job.Name, completions, jobDuration, age, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, job.Name, completions, jobDuration, age)
-				}
-			}
-		}
-	}
-
-	if !isHeaderPrint {
-		// print no resource found if isHeaderPrint is still false at this point
-		if allNamespaces {
-			fmt.Fprintf(tw, "No resource found.\n")
-		} else {
-			if namespace == "" {
-				namespace = "default"
-			}
-			fmt.Fprintf(tw, "No resource found in %s namespace.\n", namespace)
-		}
-	}
-
-	return nil
-}
-
-func handleAllGet(tw *tabwriter.Writer, clusters []cluster.ClusterInfo, resourceName, selector string, showLabels bool, outputFormat, namespace string, allNamespaces bool) error {
-	fmt.Println("==> Pods")
-	if err := handlePodsGet(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces); err != nil {
-		return err
-	}
-	tw.Flush()
-
-	fmt.Println("\n==> Services")
-	tw = tabwriter.NewWriter(util.GetOutputStream(), 0, 0, 2, ' ', 0)
-	if err := handleServicesGet(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces); err != nil {
-		return err
-	}
-	tw.Flush()
-
-	fmt.Println("\n==> Deployments")
-	tw = tabwriter.NewWriter(util.GetOutputStream(), 0, 0, 2, ' ', 0)
-	if err := handleDeploymentsGet(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces); err != nil {
-		return err
-	}
-	tw.Flush()
-
-	fmt.Println("\n==> Jobs")
-	tw = tabwriter.NewWriter(util.GetOutputStream(), 0, 0, 2, ' ', 0)
-	if err := handleJobsGet(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces); err != nil {
-		return err
-	}
-	tw.Flush()
-
-	fmt.Println("\n==> CronJobs")
-	tw = tabwriter.NewWriter(util.GetOutputStream(), 0, 0, 2, ' ', 0)
-	if err := handleCronJobsGet(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces); err != nil {
-		return err
-	}
-	tw.Flush()
-
-	fmt.Println("\n==> Nodes")
-	tw = tabwriter.NewWriter(util.GetOutputStream(), 0, 0, 2, ' ', 0)
-	if err := handleNodesGet(tw, clusters, resourceName, selector, showLabels, outputFormat); err != nil {
-		return err
-	}
-	tw.Flush()
-
-	fmt.Println("\n==> ReplicaSets")
-	tw = tabwriter.NewWriter(util.GetOutputStream(), 0, 0, 2, ' ', 0)
-	if err := handleReplicaSetsGet(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces); err != nil {
-		return err
-	}
-	tw.Flush()
-
-	fmt.Println("\n==> DaemonSets")
-	tw = tabwriter.NewWriter(util.GetOutputStream(), 0, 0, 2, ' ', 0)
-	if err := handleDaemonSetsGet(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces); err != nil {
-		return err
-	}
-	tw.Flush()
-
-	fmt.Println("\n==> Namespaces")
-	tw = tabwriter.NewWriter(util.GetOutputStream(), 0, 0, 2, ' ', 0)
-	if err := handleNamespacesGet(tw, clusters, resourceName, selector, showLabels, outputFormat); err != nil {
-		return err
-	}
-	tw.Flush()
-
-	fmt.Println("\n==> ConfigMaps")
-	tw = tabwriter.NewWriter(util.GetOutputStream(), 0, 0, 2, ' ', 0)
-	if err := handleConfigMapsGet(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces); err != nil {
-		return err
-	}
-	tw.Flush()
-
-	fmt.Println("\n==> StatefulSets")
-	tw = tabwriter.NewWriter(util.GetOutputStream(), 0, 0, 2, ' ', 0)
-	if err := handleStatefulSetsGet(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces); err != nil {
-		return err
-	}
-	tw.Flush()
-
-	fmt.Println("\n==> Secrets")
-	tw = tabwriter.NewWriter(util.GetOutputStream(), 0, 0, 2, ' ', 0)
-	if err := handleSecretsGet(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces); err != nil {
-		return err
-	}
-	tw.Flush()
-
-	fmt.Println("\n==> PersistentVolumes")
-	tw = tabwriter.NewWriter(util.GetOutputStream(), 0, 0, 2, ' ', 0)
-	if err := handlePVGet(tw, clusters, resourceName, selector, showLabels, outputFormat); err != nil {
-		return err
-	}
-	tw.Flush()
-
-	fmt.Println("\n==> PersistentVolumeClaims")
-	tw = tabwriter.NewWriter(util.GetOutputStream(), 0, 0, 2, ' ', 0)
-	if err := handlePVCGet(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces); err != nil {
-		return err
-	}
-	tw.Flush()
-
-	fmt.Println("\n==> Roles")
-	tw = tabwriter.NewWriter(util.GetOutputStream(), 0, 0, 2, ' ', 0)
-	if err := handleRolesGet(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces); err != nil {
-		return err
-	}
-	tw.Flush()
-
-	return nil
-}
-func handleNodesGet(tw *tabwriter.Writer, clusters []cluster.ClusterInfo, resourceName, selector string, showLabels bool, outputFormat string) error {
-	// Print header only once at the top
-	if showLabels {
-		fmt.Fprintf(tw, "CLUSTER\tNAME\tSTATUS\tROLES\tAGE\tVERSION\tLABELS\n")
-	} else {
-		fmt.Fprintf(tw, "CLUSTER\tNAME\tSTATUS\tROLES\tAGE\tVERSION\n")
-	}
-
-	for _, clusterInfo := range clusters {
-		if clusterInfo.Client == nil {
-			continue
-		}
-
-		nodes, err := clusterInfo.Client.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{
-			LabelSelector: selector,
-		})
-		if err != nil {
-			fmt.Printf("Warning: failed to list nodes in cluster %s: %v\n", clusterInfo.Name, err)
-			continue
-		}
-
-		for _, node := range nodes.Items {
-			if resourceName != "" && node.Name != resourceName {
-				continue
-			}
-
-			status := util.GetNodeStatus(node)
-			role := util.GetNodeRole(node)
-			age := duration.HumanDuration(time.Since(node.CreationTimestamp.Time))
-			version := node.Status.NodeInfo.KubeletVersion
-
-			if showLabels {
-				labels := util.FormatLabels(node.Labels)
-				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-					clusterInfo.Name, node.Name, status, role, age, version, labels)
-			} else {
-				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
-					clusterInfo.Name, node.Name, status, role, age, version)
-			}
-		}
-	}
-	return nil
-}
-
-func handlePodsGet(tw *tabwriter.Writer, clusters []cluster.ClusterInfo, resourceName, selector string, showLabels bool, outputFormat, namespace string, allNamespaces bool) error {
-	isHeaderPrint := false
-
-	for _, clusterInfo := range clusters {
-		if clusterInfo.Client == nil {
-			continue
-		}
-
-		targetNS := cluster.GetTargetNamespace(namespace)
-		if allNamespaces {
-			targetNS = ""
-		}
-
-		pods, err := clusterInfo.Client.CoreV1().Pods(targetNS).List(context.TODO(), metav1.ListOptions{
-			LabelSelector: selector,
-		})
-		if err != nil {
-			fmt.Printf("Warning: failed to list pods in cluster %s: %v\n", clusterInfo.Name, err)
-			continue
-		}
-
-		if len(pods.Items) > 0 && !isHeaderPrint {
-			// Print header only once at top when any items is greater than 0.
-			if allNamespaces {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tREADY\tSTATUS\tRESTARTS\tAGE\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tREADY\tSTATUS\tRESTARTS\tAGE\n")
-				}
-			} else {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tREADY\tSTATUS\tRESTARTS\tAGE\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tREADY\tSTATUS\tRESTARTS\tAGE\n")
-				}
-			}
-			isHeaderPrint = true
-		}
-
-		for _, pod := range pods.Items {
-			if resourceName != "" && pod.Name != resourceName {
-				continue
-			}
-
-			ready := fmt.Sprintf("%d/%d", util.GetPodReadyContainers(&pod), len(pod.Spec.Containers))
-			status := string(pod.Status.Phase)
-			restarts := util.GetPodRestarts(&pod)
-			age := duration.HumanDuration(time.Since(pod.CreationTimestamp.Time))
-
-			if allNamespaces {
-				if showLabels {
-					labels := util.FormatLabels(pod.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
-						clusterInfo.Name, pod.Namespace, pod.Name, ready, status, restarts, age, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\t%s\n",
-						clusterInfo.Name, pod.Namespace, pod.Name, ready, status, restarts, age)
-				}
-			} else {
-				if showLabels {
-					labels := util.FormatLabels(pod.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
-						clusterInfo.Name, pod.Name, ready, status, restarts, age, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\n",
-						clusterInfo.Name, pod.Name, ready, status, restarts, age)
-				}
-			}
-		}
-	}
-
-	if !isHeaderPrint {
-		// print no resource found if isHeaderPrint is still false at this point
-		if allNamespaces {
-			fmt.Fprintf(tw, "No resource found.\n")
-		} else {
-			if namespace == "" {
-				namespace = "default"
-			}
-			fmt.Fprintf(tw, "No resource found in %s namespace.\n", namespace)
-		}
-	}
-	return nil
-}
-
-func handleServicesGet(tw *tabwriter.Writer, clusters []cluster.ClusterInfo, resourceName, selector string, showLabels bool, outputFormat, namespace string, allNamespaces bool) error {
-	isHeaderPrint := false
-
-	for _, clusterInfo := range clusters {
-		if clusterInfo.Client == nil {
-			continue
-		}
-
-		targetNS := cluster.GetTargetNamespace(namespace)
-		if allNamespaces {
-			targetNS = ""
-		}
-
-		services, err := clusterInfo.Client.CoreV1().Services(targetNS).List(context.TODO(), metav1.ListOptions{
-			LabelSelector: selector,
-		})
-		if err != nil {
-			fmt.Printf("Warning: failed to list services in cluster %s: %v\n", clusterInfo.Name, err)
-			continue
-		}
-
-		if len(services.Items) > 0 && !isHeaderPrint {
-			// Print header only once at top when any items is greater than 0.
-			if allNamespaces {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tTYPE\tCLUSTER-IP\tEXTERNAL-IP\tPORT(S)\tAGE\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tTYPE\tCLUSTER-IP\tEXTERNAL-IP\tPORT(S)\tAGE\n")
-				}
-			} else {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tTYPE\tCLUSTER-IP\tEXTERNAL-IP\tPORT(S)\tAGE\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tTYPE\tCLUSTER-IP\tEXTERNAL-IP\tPORT(S)\tAGE\n")
-				}
-
-			}
-			isHeaderPrint = true
-		}
-
-		for _, svc := range services.Items {
-			if resourceName != "" && svc.Name != resourceName {
-				continue
-			}
-
-			svcType := string(svc.Spec.Type)
-			clusterIP := svc.Spec.ClusterIP
-			externalIP := util.GetServiceExternalIP(&svc)
-			ports := util.GetServicePorts(&svc)
-			age := duration.HumanDuration(time.Since(svc.CreationTimestamp.Time))
-
-			if allNamespaces {
-				if showLabels {
-					labels := util.FormatLabels(svc.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, svc.Namespace, svc.Name, svcType, clusterIP, externalIP, ports, age, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, svc.Namespace, svc.Name, svcType, clusterIP, externalIP, ports, age)
-				}
-			} else {
-				if showLabels {
-					labels := util.FormatLabels(svc.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, svc.Name, svcType, clusterIP, externalIP, ports, age, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, svc.Name, svcType, clusterIP, externalIP, ports, age)
-				}
-			}
-		}
-	}
-
-	if !isHeaderPrint {
-		// print no resource found if isHeaderPrint is still false at this point
-		if allNamespaces {
-			fmt.Fprintf(tw, "No resource found.\n")
-		} else {
-			if namespace == "" {
-				namespace = "default"
-			}
-			fmt.Fprintf(tw, "No resource found in %s namespace.\n", namespace)
-		}
-	}
-
-	return nil
-}
-
-func handleDeploymentsGet(tw *tabwriter.Writer, clusters []cluster.ClusterInfo, resourceName, selector string, showLabels bool, outputFormat, namespace string, allNamespaces bool) error {
-	isHeaderPrint := false
-
-	for _, clusterInfo := range clusters {
-		if clusterInfo.Client == nil {
-			continue
-		}
-
-		targetNS := cluster.GetTargetNamespace(namespace)
-		if allNamespaces {
-			targetNS = ""
-		}
-
-		deployments, err := clusterInfo.Client.AppsV1().Deployments(targetNS).List(context.TODO(), metav1.ListOptions{
-			LabelSelector: selector,
-		})
-		if err != nil {
-			fmt.Printf("Warning: failed to list deployments in cluster %s: %v\n", clusterInfo.Name, err)
-			continue
-		}
-
-		if len(deployments.Items) > 0 && !isHeaderPrint {
-			// Print header only once at top when any items is greater than 0.
-			if allNamespaces {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tREADY\tUP-TO-DATE\tAVAILABLE\tAGE\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tREADY\tUP-TO-DATE\tAVAILABLE\tAGE\n")
-				}
-			} else {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tREADY\tUP-TO-DATE\tAVAILABLE\tAGE\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tREADY\tUP-TO-DATE\tAVAILABLE\tAGE\n")
-				}
-			}
-			isHeaderPrint = true
-		}
-
-		for _, deploy := range deployments.Items {
-			if resourceName != "" && deploy.Name != resourceName {
-				continue
-			}
-
-			var replicas int32 = 0
-			if deploy.Spec.Replicas != nil {
-				replicas = *deploy.Spec.Replicas
-			}
-			ready := fmt.Sprintf("%d/%d", deploy.Status.ReadyReplicas, replicas)
-			upToDate := fmt.Sprintf("%d", deploy.Status.UpdatedReplicas)
-			available := fmt.Sprintf("%d", deploy.Status.AvailableReplicas)
-			age := duration.HumanDuration(time.Since(deploy.CreationTimestamp.Time))
-
-			if allNamespaces {
-				if showLabels {
-					labels := util.FormatLabels(deploy.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, deploy.Namespace, deploy.Name, ready, upToDate, available, age, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, deploy.Namespace, deploy.Name, ready, upToDate, available, age)
-				}
-			} else {
-				if showLabels {
-					labels := util.FormatLabels(deploy.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, deploy.Name, ready, upToDate, available, age, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, deploy.Name, ready, upToDate, available, age)
-				}
-			}
-		}
-	}
-
-	if !isHeaderPrint {
-		// print no resource found if isHeaderPrint is still false at this point
-		if allNamespaces {
-			fmt.Fprintf(tw, "No resource found.\n")
-		} else {
-			if namespace == "" {
-				namespace = "default"
-			}
-			fmt.Fprintf(tw, "No resource found in %s namespace.\n", namespace)
-		}
-	}
-
-	return nil
-}
-
-func handleNamespacesGet(tw *tabwriter.Writer, clusters []cluster.ClusterInfo, resourceName, selector string, showLabels bool, outputFormat string) error {
-	// Print header only once at the top
-	if showLabels {
-		fmt.Fprintf(tw, "CLUSTER\tNAME\tSTATUS\tAGE\tLABELS\n")
-	} else {
-		fmt.Fprintf(tw, "CLUSTER\tNAME\tSTATUS\tAGE\n")
-	}
-
-	for _, clusterInfo := range clusters {
-		if clusterInfo.Client == nil {
-			continue
-		}
-
-		namespaces, err := clusterInfo.Client.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{
-			LabelSelector: selector,
-		})
-		if err != nil {
-			fmt.Printf("Warning: failed to list namespaces in cluster %s: %v\n", clusterInfo.Name, err)
-			continue
-		}
-
-		for _, ns := range namespaces.Items {
-			if resourceName != "" && ns.Name != resourceName {
-				continue
-			}
-
-			status := string(ns.Status.Phase)
-			age := duration.HumanDuration(time.Since(ns.CreationTimestamp.Time))
-
-			if showLabels {
-				labels := util.FormatLabels(ns.Labels)
-				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
-					clusterInfo.Name, ns.Name, status, age, labels)
-			} else {
-				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n",
-					clusterInfo.Name, ns.Name, status, age)
-			}
-		}
-	}
-	return nil
-}
-
-func handleConfigMapsGet(tw *tabwriter.Writer, clusters []cluster.ClusterInfo, resourceName, selector string, showLabels bool, outputFormat, namespace string, allNamespaces bool) error {
-	isHeaderPrint := false
-
-	for _, clusterInfo := range clusters {
-		if clusterInfo.Client == nil {
-			continue
-		}
-
-		targetNS := cluster.GetTargetNamespace(namespace)
-		if allNamespaces {
-			targetNS = ""
-		}
-
-		configMaps, err := clusterInfo.Client.CoreV1().ConfigMaps(targetNS).List(context.TODO(), metav1.ListOptions{
-			LabelSelector: selector,
-		})
-		if err != nil {
-			fmt.Printf("Warning: failed to list configmaps in cluster %s: %v\n", clusterInfo.Name, err)
-			continue
-		}
-
-		if len(configMaps.Items) > 0 && !isHeaderPrint {
-			// Print header only once at top when any items is greater than 0.
-			if allNamespaces {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tDATA\tAGE\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tDATA\tAGE\n")
-				}
-			} else {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tDATA\tAGE\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tDATA\tAGE\n")
-				}
-			}
-			isHeaderPrint = true
-		}
-
-		for _, cm := range configMaps.Items {
-			if resourceName != "" && cm.Name != resourceName {
-				continue
-			}
-
-			dataCount := len(cm.Data) + len(cm.BinaryData)
-			age := duration.HumanDuration(time.Since(cm.CreationTimestamp.Time))
-
-			if allNamespaces {
-				if showLabels {
-					labels := util.FormatLabels(cm.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\n",
-						clusterInfo.Name, cm.Namespace, cm.Name, dataCount, age, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n",
-						clusterInfo.Name, cm.Namespace, cm.Name, dataCount, age)
-				}
-			} else {
-				if showLabels {
-					labels := util.FormatLabels(cm.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n",
-						clusterInfo.Name, cm.Name, dataCount, age, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n",
-						clusterInfo.Name, cm.Name, dataCount, age)
-				}
-			}
-		}
-	}
-
-	if !isHeaderPrint {
-		// print no resource found if isHeaderPrint is still false at this point
-		if allNamespaces {
-			fmt.Fprintf(tw, "No resource found.\n")
-		} else {
-			if namespace == "" {
-				namespace = "default"
-			}
-			fmt.Fprintf(tw, "No resource found in %s namespace.\n", namespace)
-		}
-	}
-	return nil
-}
-
-func handleSecretsGet(tw *tabwriter.Writer, clusters []cluster.ClusterInfo, resourceName, selector string, showLabels bool, outputFormat, namespace string, allNamespaces bool) error {
-	isHeaderPrint := false
-
-	for _, clusterInfo := range clusters {
-		if clusterInfo.Client == nil {
-			continue
-		}
-
-		targetNS := cluster.GetTargetNamespace(namespace)
-		if allNamespaces {
-			targetNS = ""
-		}
-
-		secrets, err := clusterInfo.Client.CoreV1().Secrets(targetNS).List(context.TODO(), metav1.ListOptions{
-			LabelSelector: selector,
-		})
-		if err != nil {
-			fmt.Printf("Warning: failed to list secrets in cluster %s: %v\n", clusterInfo.Name, err)
-			continue
-		}
-
-		if len(secrets.Items) > 0 && !isHeaderPrint {
-			// Print header only once at top when any items is greater than 0.
-			if allNamespaces {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tTYPE\tDATA\tAGE\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tTYPE\tDATA\tAGE\n")
-				}
-			} else {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tTYPE\tDATA\tAGE\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tTYPE\tDATA\tAGE\n")
-				}
-			}
-			isHeaderPrint = true
-		}
-
-		for _, secret := range secrets.Items {
-			if resourceName != "" && secret.Name != resourceName {
-				continue
-			}
-
-			secretType := string(secret.Type)
-			dataCount := len(secret.Data)
-			age := duration.HumanDuration(time.Since(secret.CreationTimestamp.Time))
-
-			if allNamespaces {
-				if showLabels {
-					labels := util.FormatLabels(secret.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
-						clusterInfo.Name, secret.Namespace, secret.Name, secretType, dataCount, age, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\n",
-						clusterInfo.Name, secret.Namespace, secret.Name, secretType, dataCount, age)
-				}
-			} else {
-				if showLabels {
-					labels := util.FormatLabels(secret.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\n",
-						clusterInfo.Name, secret.Name, secretType, dataCount, age, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n",
-						clusterInfo.Name, secret.Name, secretType, dataCount, age)
-				}
-			}
-		}
-	}
-
-	if !isHeaderPrint {
-		// print no resource found if isHeaderPrint is still false at this point
-		if allNamespaces {
-			fmt.Fprintf(tw, "No resource found.\n")
-		} else {
-			if namespace == "" {
-				namespace = "default"
-			}
-			fmt.Fprintf(tw, "No resource found in %s namespace.\n", namespace)
-		}
-	}
-
-	return nil
-}
-
-func handlePVGet(tw *tabwriter.Writer, clusters []cluster.ClusterInfo, resourceName, selector string, showLabels bool, outputFormat string) error {
-	isHeaderPrint := false
-
-	for _, clusterInfo := range clusters {
-		if clusterInfo.Client == nil {
-			continue
-		}
-
-		pvs, err := clusterInfo.Client.CoreV1().PersistentVolumes().List(context.TODO(), metav1.ListOptions{
-			LabelSelector: selector,
-		})
-		if err != nil {
-			fmt.Printf("Warning: failed to list persistent volumes in cluster %s: %v\n", clusterInfo.Name, err)
-			continue
-		}
-
-		if len(pvs.Items) > 0 && !isHeaderPrint {
-			if showLabels {
-				fmt.Fprintf(tw, "CLUSTER\tNAME\tCAPACITY\tACCESS MODES\tRECLAIM POLICY\tSTATUS\tCLAIM\tSTORAGE CLASS\tREASON\tAGE\tLABELS\n")
-			} else {
-				fmt.Fprintf(tw, "CLUSTER\tNAME\tCAPACITY\tACCESS MODES\tRECLAIM POLICY\tSTATUS\tCLAIM\tSTORAGE CLASS\tREASON\tAGE\n")
-			}
-			isHeaderPrint = true
-		}
-
-		for _, pv := range pvs.Items {
-			if resourceName != "" && pv.Name != resourceName {
-				continue
-			}
-
-			capacity := util.GetPVCapacity(&pv)
-			accessModes := util.GetPVAccessModes(&pv)
-			reclaimPolicy := string(pv.Spec.PersistentVolumeReclaimPolicy)
-			status := string(pv.Status.Phase)
-			claim := util.GetPVClaim(&pv)
-			storageClass := util.GetPVStorageClass(&pv)
-			reason := pv.Status.Reason
-			age := duration.HumanDuration(time.Since(pv.CreationTimestamp.Time))
-
-			if showLabels {
-				labels := util.FormatLabels(pv.Labels)
-				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-					clusterInfo.Name, pv.Name, capacity, accessModes, reclaimPolicy, status, claim, storageClass, reason, age, labels)
-			} else {
-				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-					clusterInfo.Name, pv.Name, capacity, accessModes, reclaimPolicy, status, claim, storageClass, reason, age)
-			}
-		}
-	}
-
-	if !isHeaderPrint {
-		fmt.Fprintf(tw, "No resources found\n")
-	}
-
-	return nil
-}
-
-func handlePVCGet(tw *tabwriter.Writer, clusters []cluster.ClusterInfo, resourceName, selector string, showLabels bool, outputFormat, namespace string, allNamespaces bool) error {
-	isHeaderPrint := false
-
-	for _, clusterInfo := range clusters {
-		if clusterInfo.Client == nil {
-			continue
-		}
-
-		targetNS := cluster.GetTargetNamespace(namespace)
-		if allNamespaces {
-			targetNS = ""
-		}
-
-		pvcs, err := clusterInfo.Client.CoreV1().PersistentVolumeClaims(targetNS).List(context.TODO(), metav1.ListOptions{
-			LabelSelector: selector,
-		})
-		if err != nil {
-			fmt.Printf("Warning: failed to list persistent volume claims in cluster %s: %v\n", clusterInfo.Name, err)
-			continue
-		}
-
-		if len(pvcs.Items) > 0 && !isHeaderPrint {
-			// Print header only once at top when any items is greater than 0.
-			if allNamespaces {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tSTATUS\tVOLUME\tCAPACITY\tACCESS MODES\tSTORAGE CLASS\tAGE\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tSTATUS\tVOLUME\tCAPACITY\tACCESS MODES\tSTORAGE CLASS\tAGE\n")
-				}
-			} else {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tSTATUS\tVOLUME\tCAPACITY\tACCESS MODES\tSTORAGE CLASS\tAGE\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tSTATUS\tVOLUME\tCAPACITY\tACCESS MODES\tSTORAGE CLASS\tAGE\n")
-				}
-			}
-			isHeaderPrint = true
-		}
-
-		for _, pvc := range pvcs.Items {
-			if resourceName != "" && pvc.Name != resourceName {
-				continue
-			}
-
-			status := string(pvc.Status.Phase)
-			volume := pvc.Spec.VolumeName
-			capacity := util.GetPVCCapacity(&pvc)
-			accessModes := util.GetPVCAccessModes(&pvc)
-			storageClass := util.GetPVCStorageClass(&pvc)
-			age := duration.HumanDuration(time.Since(pvc.CreationTimestamp.Time))
-
-			if allNamespaces {
-				if showLabels {
-					labels := util.FormatLabels(pvc.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, pvc.Namespace, pvc.Name, status, volume, capacity, accessModes, storageClass, age, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, pvc.Namespace, pvc.Name, status, volume, capacity, accessModes, storageClass, age)
-				}
-			} else {
-				if showLabels {
-					labels := util.FormatLabels(pvc.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, pvc.Name, status, volume, capacity, accessModes, storageClass, age, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, pvc.Name, status, volume, capacity, accessModes, storageClass, age)
-				}
-			}
-		}
-	}
-
-	if !isHeaderPrint {
-		// print no resource found if isHeaderPrint is still false at this point
-		if allNamespaces {
-			fmt.Fprintf(tw, "No resource found.\n")
-		} else {
-			if namespace == "" {
-				namespace = "default"
-			}
-			fmt.Fprintf(tw, "No resource found in %s namespace.\n", namespace)
-		}
-	}
-	return nil
-}
-
-func handleGenericGet(tw *tabwriter.Writer, clusters []cluster.ClusterInfo, resourceType, resourceName, selector string, showLabels bool, outputFormat, namespace string, allNamespaces bool) error {
-	isHeaderPrint := false
-
-	for _, clusterInfo := range clusters {
-		if clusterInfo.DynamicClient == nil {
-			continue
-		}
-
-		// Try to discover the resource
-		gvr, isNamespaced, err := util.DiscoverGVR(clusterInfo.DiscoveryClient, resourceType)
-		if err != nil {
-			fmt.Printf("Warning: failed to discover resource %s in cluster %s: %v\n", resourceType, clusterInfo.Name, err)
-			continue
-		}
-
-		targetNS := cluster.GetTargetNamespace(namespace)
-		var list *unstructured.UnstructuredList
-
-		if isNamespaced && !allNamespaces && targetNS != "" {
-			list, err = clusterInfo.DynamicClient.Resource(gvr).Namespace(targetNS).List(context.TODO(), metav1.ListOptions{
-				LabelSelector: selector,
-			})
-		} else {
-			list, err = clusterInfo.DynamicClient.Resource(gvr).List(context.TODO(), metav1.ListOptions{
-				LabelSelector: selector,
-			})
-		}
-
-		if err != nil {
-			fmt.Printf("Warning: failed to list %s in cluster %s: %v\n", resourceType, clusterInfo.Name, err)
-			continue
-		}
-
-		if len(list.Items) > 0 && !isHeaderPrint {
-			// Print header only once at top when any items is greater than 0.
-			if allNamespaces {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tAGE\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tAGE\n")
-				}
-			} else {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tAGE\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tAGE\n")
-				}
-			}
-			isHeaderPrint = true
-		}
-
-		for _, item := range list.Items {
-			if resourceName != "" && item.GetName() != resourceName {
-				continue
-			}
-
-			age := duration.HumanDuration(time.Since(item.GetCreationTimestamp().Time))
-
-			if isNamespaced && allNamespaces {
-				if showLabels {
-					labels := util.FormatLabels(item.GetLabels())
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, item.GetNamespace(), item.GetName(), age, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, item.GetNamespace(), item.GetName(), age)
-				}
-			} else {
-				if showLabels {
-					labels := util.FormatLabels(item.GetLabels())
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, item.GetName(), age, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\n",
-						clusterInfo.Name, item.GetName(), age)
-				}
-			}
-		}
-	}
-
-	if !isHeaderPrint {
-		// print no resource found if isHeaderPrint is still false at this point
-		if allNamespaces {
-			fmt.Fprintf(tw, "No resource found.\n")
-		} else {
-
-			if namespace == "" {
-				namespace = "default"
-			}
-			fmt.Fprintf(tw, "No resource found in %s namespace.\n", namespace)
-		}
-	}
-
-	return nil
-}
-
-func handleReplicaSetsGet(tw *tabwriter.Writer, clusters []cluster.ClusterInfo, resourceName, selector string, showLabels bool, outputFormat, namespace string, allNamespaces bool) error {
-	isHeaderPrint := false
-
-	for _, clusterInfo := range clusters {
-		if clusterInfo.Client == nil {
-			continue
-		}
-
-		targetNS := cluster.GetTargetNamespace(namespace)
-		if allNamespaces {
-			targetNS = ""
-		}
-
-		replicaSets, err := clusterInfo.Client.AppsV1().ReplicaSets(targetNS).List(context.TODO(), metav1.ListOptions{
-			LabelSelector: selector,
-		})
-		if err != nil {
-			fmt.Printf("Warning: failed to list replicasets in cluster %s: %v\n", clusterInfo.Name, err)
-			continue
-		}
-
-		if len(replicaSets.Items) > 0 && !isHeaderPrint {
-			// Print header only once at top when any items is greater than 0.
-			if allNamespaces {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tDESIRED\tCURRENT\tREADY\tAGE\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tDESIRED\tCURRENT\tREADY\tAGE\n")
-				}
-			} else {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tDESIRED\tCURRENT\tREADY\tAGE\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tDESIRED\tCURRENT\tREADY\tAGE\n")
-				}
-			}
-			isHeaderPrint = true
-		}
-
-		for _, rs := range replicaSets.Items {
-			if resourceName != "" && rs.Name != resourceName {
-				continue
-			}
-
-			var desired int32 = 0
-			if rs.Spec.Replicas != nil {
-				desired = *rs.Spec.Replicas
-			}
-			current := rs.Status.Replicas
-			ready := rs.Status.ReadyReplicas
-			age := duration.HumanDuration(time.Since(rs.CreationTimestamp.Time))
-
-			if allNamespaces {
-				if showLabels {
-					labels := util.FormatLabels(rs.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%d\t%s\t%s\n",
-						clusterInfo.Name, rs.Namespace, rs.Name, desired, current, ready, age, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%d\t%s\n",
-						clusterInfo.Name, rs.Namespace, rs.Name, desired, current, ready, age)
-				}
-			} else {
-				if showLabels {
-					labels := util.FormatLabels(rs.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%s\t%s\n",
-						clusterInfo.Name, rs.Name, desired, current, ready, age, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%s\n",
-						clusterInfo.Name, rs.Name, desired, current, ready, age)
-				}
-			}
-		}
-	}
-
-	if !isHeaderPrint {
-		// print no resource found if isHeaderPrint is still false at this point
-		if allNamespaces {
-			fmt.Fprintf(tw, "No resource found.\n")
-		} else {
-			if namespace == "" {
-				namespace = "default"
-			}
-			fmt.Fprintf(tw, "No resource found in %s namespace.\n", namespace)
-		}
-	}
-	return nil
-}
-
-func handleStatefulSetsGet(tw *tabwriter.Writer, clusters []cluster.ClusterInfo, resourceName, selector string, showLabels bool, outputFormat, namespace string, allNamespaces bool) error {
-	isHeaderPrint := false
-
-	for _, clusterInfo := range clusters {
-		if clusterInfo.Client == nil {
-			continue
-		}
-
-		targetNS := cluster.GetTargetNamespace(namespace)
-		if allNamespaces {
-			targetNS = ""
-		}
-
-		statefulSets, err := clusterInfo.Client.AppsV1().StatefulSets(targetNS).List(context.TODO(), metav1.ListOptions{
-			LabelSelector: selector,
-		})
-		if err != nil {
-			fmt.Printf("Warning: failed to list statefulsets in cluster %s: %v\n", clusterInfo.Name, err)
-			continue
-		}
-
-		if len(statefulSets.Items) > 0 && !isHeaderPrint {
-			// Print header only once at top when any items is greater than 0.
-			if allNamespaces {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tREADY\tAGE\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tREADY\tAGE\n")
-				}
-			} else {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tREADY\tAGE\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tREADY\tAGE\n")
-				}
-			}
-			isHeaderPrint = true
-		}
-
-		for _, sts := range statefulSets.Items {
-			if resourceName != "" && sts.Name != resourceName {
-				continue
-			}
-
-			var replicas int32 = 0
-			if sts.Spec.Replicas != nil {
-				replicas = *sts.Spec.Replicas
-			}
-			ready := fmt.Sprintf("%d/%d", sts.Status.ReadyReplicas, replicas)
-			age := duration.HumanDuration(time.Since(sts.CreationTimestamp.Time))
-
-			if allNamespaces {
-				if showLabels {
-					labels := util.FormatLabels(sts.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, sts.Namespace, sts.Name, ready, age, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, sts.Namespace, sts.Name, ready, age)
-				}
-			} else {
-				if showLabels {
-					labels := util.FormatLabels(sts.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, sts.Name, ready, age, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, sts.Name, ready, age)
-				}
-			}
-		}
-	}
-
-	if !isHeaderPrint {
-		// print no resource found if isHeaderPrint is still false at this point
-		if allNamespaces {
-			fmt.Fprintf(tw, "No resource found.\n")
-		} else {
-			if namespace == "" {
-				namespace = "default"
-			}
-			fmt.Fprintf(tw, "No resource found in %s namespace.\n", namespace)
-		}
-	}
-	return nil
-}
-
-func handleDaemonSetsGet(tw *tabwriter.Writer, clusters []cluster.ClusterInfo, resourceName, selector string, showLabels bool, outputFormat, namespace string, allNamespaces bool) error {
-	isHeaderPrint := false
-
-	for _, clusterInfo := range clusters {
-		if clusterInfo.Client == nil {
-			continue
-		}
-
-		targetNS := cluster.GetTargetNamespace(namespace)
-		if allNamespaces {
-			targetNS = ""
-		}
-
-		daemonSets, err := clusterInfo.Client.AppsV1().DaemonSets(targetNS).List(context.TODO(), metav1.ListOptions{
-			LabelSelector: selector,
-		})
-		if err != nil {
-			fmt.Printf("Warning: failed to list daemonsets in cluster %s: %v\n", clusterInfo.Name, err)
-			continue
-		}
-
-		if len(daemonSets.Items) > 0 && !isHeaderPrint {
-			if allNamespaces {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tDESIRED\tCURRENT\tREADY\tUP-TO-DATE\tAVAILABLE\tNODE SELECTOR\tAGE\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tDESIRED\tCURRENT\tREADY\tUP-TO-DATE\tAVAILABLE\tNODE SELECTOR\tAGE\n")
-				}
-			} else {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tDESIRED\tCURRENT\tREADY\tUP-TO-DATE\tAVAILABLE\tNODE SELECTOR\tAGE\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tDESIRED\tCURRENT\tREADY\tUP-TO-DATE\tAVAILABLE\tNODE SELECTOR\tAGE\n")
-				}
-			}
-			isHeaderPrint = true
-		}
-
-		for _, ds := range daemonSets.Items {
-			if resourceName != "" && ds.Name != resourceName {
-				continue
-			}
-
-			desired := ds.Status.DesiredNumberScheduled
-			current := ds.Status.CurrentNumberScheduled
-			ready := ds.Status.NumberReady
-			upToDate := ds.Status.UpdatedNumberScheduled
-			available := ds.Status.NumberAvailable
-
-			// Format node selector
-			nodeSelector := "<none>"
-			if len(ds.Spec.Template.Spec.NodeSelector) > 0 {
-				var selectors []string
-				for k, v := range ds.Spec.Template.Spec.NodeSelector {
-					selectors = append(selectors, fmt.Sprintf("%s=%s", k, v))
-				}
-				nodeSelector = strings.Join(selectors, ",")
-			}
-
-			age := duration.HumanDuration(time.Since(ds.CreationTimestamp.Time))
-
-			if allNamespaces {
-				if showLabels {
-					labels := util.FormatLabels(ds.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%d\t%d\t%d\t%s\t%s\t%s\n",
-						clusterInfo.Name, ds.Namespace, ds.Name, desired, current, ready, upToDate, available, nodeSelector, age, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t%d\t%d\t%d\t%s\t%s\n",
-						clusterInfo.Name, ds.Namespace, ds.Name, desired, current, ready, upToDate, available, nodeSelector, age)
-				}
-			} else {
-				if showLabels {
-					labels := util.FormatLabels(ds.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%d\t%d\t%s\t%s\t%s\n",
-						clusterInfo.Name, ds.Name, desired, current, ready, upToDate, available, nodeSelector, age, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%d\t%d\t%s\t%s\n",
-						clusterInfo.Name, ds.Name, desired, current, ready, upToDate, available, nodeSelector, age)
-				}
-			}
-		}
-	}
-
-	if !isHeaderPrint {
-		// print no resource found if isHeaderPrint is still false at this point
-		if allNamespaces {
-			fmt.Fprintf(tw, "No resource found.\n")
-		} else {
-			if namespace == "" {
-				namespace = "default"
-			}
-			fmt.Fprintf(tw, "No resource found in %s namespace.\n", namespace)
-		}
-	}
-	return nil
-}
-
-func handleCronJobsGet(tw *tabwriter.Writer, clusters []cluster.ClusterInfo, resourceName, selector string, showLabels bool, outputFormat, namespace string, allNamespaces bool) error {
-	isHeaderPrint := false
-
-	for _, clusterInfo := range clusters {
-		if clusterInfo.Client == nil {
-			continue
-		}
-
-		targetNS := cluster.GetTargetNamespace(namespace)
-		if allNamespaces {
-			targetNS = ""
-		}
-
-		cronJobs, err := clusterInfo.Client.BatchV1().CronJobs(targetNS).List(context.TODO(), metav1.ListOptions{
-			LabelSelector: selector,
-		})
-		if err != nil {
-			fmt.Printf("Warning: failed to list cronjobs in cluster %s: %v\n", clusterInfo.Name, err)
-			continue
-		}
-
-		if len(cronJobs.Items) > 0 && !isHeaderPrint {
-			if allNamespaces {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tSCHEDULE\tSUSPEND\tACTIVE\tLAST SCHEDULE\tAGE\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tSCHEDULE\tSUSPEND\tACTIVE\tLAST SCHEDULE\tAGE\n")
-				}
-			} else {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tSCHEDULE\tSUSPEND\tACTIVE\tLAST SCHEDULE\tAGE\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tSCHEDULE\tSUSPEND\tACTIVE\tLAST SCHEDULE\tAGE\n")
-				}
-			}
-			isHeaderPrint = true
-		}
-
-		for _, cj := range cronJobs.Items {
-			if resourceName != "" && cj.Name != resourceName {
-				continue
-			}
-
-			schedule := cj.Spec.Schedule
-
-			suspend := "False"
-			if cj.Spec.Suspend != nil && *cj.Spec.Suspend {
-				suspend = "True"
-			}
-
-			active := len(cj.Status.Active)
-
-			lastSchedule := "<none>"
-			if cj.Status.LastScheduleTime != nil {
-				lastSchedule = duration.HumanDuration(time.Since(cj.Status.LastScheduleTime.Time))
-			}
-
-			age := duration.HumanDuration(time.Since(cj.CreationTimestamp.Time))
-
-			if allNamespaces {
-				if showLabels {
-					labels := util.FormatLabels(cj.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
-						clusterInfo.Name, cj.Namespace, cj.Name, schedule, suspend, active, lastSchedule, age, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
-						clusterInfo.Name, cj.Namespace, cj.Name, schedule, suspend, active, lastSchedule, age)
-				}
-			} else {
-				if showLabels {
-					labels := util.FormatLabels(cj.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
-						clusterInfo.Name, cj.Name, schedule, suspend, active, lastSchedule, age, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
-						clusterInfo.Name, cj.Name, schedule, suspend, active, lastSchedule, age)
-				}
-			}
-		}
-	}
-
-	if !isHeaderPrint {
-		// print no resource found if isHeaderPrint is still false at this point
-		if allNamespaces {
-			fmt.Fprintf(tw, "No resource found.\n")
-		} else {
-			if namespace == "" {
-				namespace = "default"
-			}
-			fmt.Fprintf(tw, "No resource found in %s namespace.\n", namespace)
-		}
-	}
-	return nil
-}
-
-func handleEventsGet(tw *tabwriter.Writer, clusters []cluster.ClusterInfo, resourceName, selector string, showLabels bool, outputFormat, namespace string, allNamespaces bool) error {
-	if allNamespaces {
-		if showLabels {
-			fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tLAST SEEN\tTYPE\tREASON\tOBJECT\tMESSAGE\tLABELS\n")
-		} else {
-			fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tLAST SEEN\tTYPE\tREASON\tOBJECT\tMESSAGE\n")
-		}
-	} else {
-		if showLabels {
-			fmt.Fprintf(tw, "CLUSTER\tLAST SEEN\tTYPE\tREASON\tOBJECT\tMESSAGE\tLABELS\n")
-		} else {
-			fmt.Fprintf(tw, "CLUSTER\tLAST SEEN\tTYPE\tREASON\tOBJECT\tMESSAGE\n")
-		}
-	}
-
-	for _, clusterInfo := range clusters {
-		if clusterInfo.Client == nil {
-			continue
-		}
-
-		targetNS := cluster.GetTargetNamespace(namespace)
-		if allNamespaces {
-			targetNS = ""
-		}
-
-		events, err := clusterInfo.Client.CoreV1().Events(targetNS).List(context.TODO(), metav1.ListOptions{
-			LabelSelector: selector,
-		})
-		if err != nil {
-			fmt.Printf("Warning: failed to list events in cluster %s: %v\n", clusterInfo.Name, err)
-			continue
-		}
-
-		for _, event := range events.Items {
-			if resourceName != "" && event.Name != resourceName {
-				continue
-			}
-
-			lastSeen := "<unknown>"
-			if !event.LastTimestamp.IsZero() {
-				lastSeen = duration.HumanDuration(time.Since(event.LastTimestamp.Time)) + " ago"
-			} else if !event.FirstTimestamp.IsZero() {
-				lastSeen = duration.HumanDuration(time.Since(event.FirstTimestamp.Time)) + " ago"
-			}
-
-			eventType := event.Type
-			reason := event.Reason
-			object := fmt.Sprintf("%s/%s", event.InvolvedObject.Kind, event.InvolvedObject.Name)
-			message := event.Message
-
-			if allNamespaces {
-				if showLabels {
-					labels := util.FormatLabels(event.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, event.Namespace, lastSeen, eventType, reason, object, message, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, event.Namespace, lastSeen, eventType, reason, object, message)
-				}
-			} else {
-				if showLabels {
-					labels := util.FormatLabels(event.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, lastSeen, eventType, reason, object, message, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, lastSeen, eventType, reason, object, message)
-				}
-			}
-		}
-	}
-	return nil
-}
-
-func handleNetworkPoliciesGet(tw *tabwriter.Writer, clusters []cluster.ClusterInfo, resourceName, selector string, showLabels bool, outputFormat, namespace string, allNamespaces bool) error {
-	isHeaderPrint := false
-
-	for _, clusterInfo := range clusters {
-		if clusterInfo.Client == nil {
-			continue
-		}
-
-		targetNS := cluster.GetTargetNamespace(namespace)
-		if allNamespaces {
-			targetNS = ""
-		}
-
-		networkPolicies, err := clusterInfo.Client.NetworkingV1().NetworkPolicies(targetNS).List(context.TODO(), metav1.ListOptions{
-			LabelSelector: selector,
-		})
-		if err != nil {
-			fmt.Printf("Warning: failed to list networkpolicies in cluster %s: %v\n", clusterInfo.Name, err)
-			continue
-		}
-
-		if len(networkPolicies.Items) > 0 && !isHeaderPrint {
-			// Print header only once at top when any items is greater than 0.
-			if allNamespaces {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tPOD-SELECTOR\tPOLICY-TYPES\tAGE\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tPOD-SELECTOR\tPOLICY-TYPES\tAGE\n")
-				}
-			} else {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tPOD-SELECTOR\tPOLICY-TYPES\tAGE\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tPOD-SELECTOR\tPOLICY-TYPES\tAGE\n")
-				}
-			}
-			isHeaderPrint = true
-		}
-
-		for _, np := range networkPolicies.Items {
-			if resourceName != "" && np.Name != resourceName {
-				continue
-			}
-
-			// Format pod selector
-			podSelector := "<none>"
-			if np.Spec.PodSelector.Size() > 0 {
-				podSelector = metav1.FormatLabelSelector(&np.Spec.PodSelector)
-			}
-
-			// Format policy types
-			policyTypes := "<none>"
-			if len(np.Spec.PolicyTypes) > 0 {
-				var types []string
-				for _, t := range np.Spec.PolicyTypes {
-					types = append(types, string(t))
-				}
-				policyTypes = strings.Join(types, ",")
-			}
-
-			age := duration.HumanDuration(time.Since(np.CreationTimestamp.Time))
-
-			if allNamespaces {
-				if showLabels {
-					labels := util.FormatLabels(np.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, np.Namespace, np.Name, podSelector, policyTypes, age, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, np.Namespace, np.Name, podSelector, policyTypes, age)
-				}
-			} else {
-				if showLabels {
-					labels := util.FormatLabels(np.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, np.Name, podSelector, policyTypes, age, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, np.Name, podSelector, policyTypes, age)
-				}
-			}
-		}
-	}
-
-	if !isHeaderPrint {
-		// print no resource found if isHeaderPrint is still false at this point
-		if allNamespaces {
-			fmt.Fprintf(tw, "No resource found.\n")
-		} else {
-			if namespace == "" {
-				namespace = "default"
-			}
-			fmt.Fprintf(tw, "No resource found in %s namespace.\n", namespace)
-		}
-	}
-
-	return nil
-}
-
-func handleRolesGet(tw *tabwriter.Writer, clusters []cluster.ClusterInfo, resourceName, selector string, showLabels bool, outputFormat, namespace string, allNamespaces bool) error {
-	isHeaderPrint := false
-
-	for _, clusterInfo := range clusters {
-		if clusterInfo.Client == nil {
-			continue
-		}
-
-		targetNS := cluster.GetTargetNamespace(namespace)
-		if allNamespaces {
-			targetNS = ""
-		}
-
-		roles, err := clusterInfo.Client.RbacV1().Roles(targetNS).List(context.TODO(), metav1.ListOptions{
-			LabelSelector: selector,
-		})
-		if err != nil {
-			fmt.Printf("Warning: failed to list roles in cluster %s: %v\n", clusterInfo.Name, err)
-			continue
-		}
-
-		if len(roles.Items) > 0 && !isHeaderPrint {
-			// Print header only once at top when any items is greater than 0.
-			if allNamespaces {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tCREATED-AT\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tCREATED-AT\n")
-				}
-			} else {
-				if showLabels {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tCREATED-AT\tLABELS\n")
-				} else {
-					fmt.Fprintf(tw, "CLUSTER\tNAME\tCREATED-AT\n")
-				}
-			}
-			isHeaderPrint = true
-		}
-
-		for _, role := range roles.Items {
-			if resourceName != "" && role.Name != resourceName {
-				continue
-			}
-
-			if allNamespaces {
-				if showLabels {
-					labels := util.FormatLabels(role.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, role.Namespace, role.Name, role.CreationTimestamp, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, role.Namespace, role.Name, role.CreationTimestamp)
-				}
-			} else {
-				if showLabels {
-					labels := util.FormatLabels(role.Labels)
-					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n",
-						clusterInfo.Name, role.Name, role.CreationTimestamp, labels)
-				} else {
-					fmt.Fprintf(tw, "%s\t%s\t%s\n",
-						clusterInfo.Name, role.Name, role.CreationTimestamp)
-				}
-			}
-		}
-	}
-
-	if !isHeaderPrint {
-		// print no resource found if isHeaderPrint is still false at this point
-		if allNamespaces {
-			fmt.Fprintf(tw, "No resource found.\n")
-		} else {
-			if namespace == "" {
-				namespace = "default"
-			}
-			fmt.Fprintf(tw, "No resource found in %s namespace.\n", namespace)
-		}
-	}
-
-	return nil
-}
-
-func handleStorageClassesGet(tw *tabwriter.Writer, clusters []cluster.ClusterInfo, resourceName, selector string, showLabels bool, outputFormat string) error {
-	isHeaderPrint := false
-
-	for _, clusterInfo := range clusters {
-		if clusterInfo.Client == nil {
-			continue
-		}
-
-		storageClasses, err := clusterInfo.Client.StorageV1().StorageClasses().List(context.TODO(), metav1.ListOptions{
-			LabelSelector: selector,
-		})
-		if err != nil {
-			fmt.Printf("Warning: failed to list storageclasses in cluster %s: %v\n", clusterInfo.Name, err)
-			continue
-		}
-
-		if len(storageClasses.Items) > 0 && !isHeaderPrint {
-			// Print header only once at top when items len is greater than 0.
-			if showLabels {
-				fmt.Fprintf(tw, "CLUSTER\tNAME\tPROVISIONER\tRECLAIMPOLICY\tVOLUMEBINDINGMODE\tALLOWVOLUMEEXPANSION\tAGE\tLABELS\n")
-			} else {
-				fmt.Fprintf(tw, "CLUSTER\tNAME\tPROVISIONER\tRECLAIMPOLICY\tVOLUMEBINDINGMODE\tALLOWVOLUMEEXPANSION\tAGE\n")
-			}
-			isHeaderPrint = true
-		}
-
-		for _, sc := range storageClasses.Items {
-			if resourceName != "" && sc.Name != resourceName {
-				continue
-			}
-
-			// Get reclaim policy (default to Delete if not set)
-			reclaimPolicy := "Delete"
-			if sc.ReclaimPolicy != nil {
-				reclaimPolicy = string(*sc.ReclaimPolicy)
-			}
-
-			// Get volume binding mode (default to Immediate if not set)
-			volumeBindingMode := "Immediate"
-			if sc.VolumeBindingMode != nil {
-				volumeBindingMode = string(*sc.VolumeBindingMode)
+		for i := range list.Items {
+			item := &list.Items[i]
+			row := []string{clusterInfo.Name}
+			if isNamespaced && allNamespaces {
+				row = append(row, item.GetNamespace())
 			}
-
-			// Get allow volume expansion
-			allowVolumeExpansion := "false"
-			if sc.AllowVolumeExpansion != nil && *sc.AllowVolumeExpansion {
-				allowVolumeExpansion = "true"
+			row = append(row, item.GetName())
+			if table != nil {
+				row = append(row, table.Row(item)...)
 			}
-
-			// Calculate age
-			age := duration.HumanDuration(time.Since(sc.CreationTimestamp.Time))
-
-			if showLabels {
-				labels := util.FormatLabels(sc.Labels)
-				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-					clusterInfo.Name, sc.Name, sc.Provisioner, reclaimPolicy, volumeBindingMode, allowVolumeExpansion, age, labels)
-			} else {
-				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
-					clusterInfo.Name, sc.Name, sc.Provisioner, reclaimPolicy, volumeBindingMode, allowVolumeExpansion, age)
+			row = append(row, duration.HumanDuration(time.Since(item.GetCreationTimestamp().Time)))
+			row = append(row, q.Annotations.cells(item.GetAnnotations())...)
+			if q.ShowLabels {
+				row = append(row, util.FormatLabels(item.GetLabels()))
 			}
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
 		}
 	}
 
-	if !isHeaderPrint {
-		// print no resource found if isHeaderPrint is still false at this point
-		fmt.Fprintf(tw, "No resource found.\n")
+	if rows == 0 {
+		reportNoResources(namespace, allNamespaces)
 	}
 
-	return nil
+	return rows, nil
 }
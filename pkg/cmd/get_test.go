@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+// testTypedCluster returns a cluster whose typed client serves objects
+func testTypedCluster(name string, objects ...runtime.Object) cluster.ClusterInfo {
+	return cluster.ClusterInfo{Name: name, Context: name, Client: kubefake.NewSimpleClientset(objects...)}
+}
+
+// testBrokenTypedCluster returns a cluster whose typed client fails every list
+func testBrokenTypedCluster(name string) cluster.ClusterInfo {
+	client := kubefake.NewSimpleClientset()
+	client.PrependReactor("list", "*", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, fmt.Errorf("connection refused")
+	})
+	return cluster.ClusterInfo{Name: name, Context: name, Client: client}
+}
+
+func testPod(namespace, name string, labels map[string]string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+// tableCells returns the first n cells of every printed table line
+func tableCells(out string, n int) [][]string {
+	var cells [][]string
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > n {
+			fields = fields[:n]
+		}
+		if len(fields) > 0 {
+			cells = append(cells, fields)
+		}
+	}
+	return cells
+}
+
+func TestTypedGetHandlers(t *testing.T) {
+	replicas := int32(2)
+	clusters := []cluster.ClusterInfo{
+		testTypedCluster("cluster1",
+			testPod("default", "web-1", map[string]string{"app": "web"}),
+			testPod("default", "db-1", map[string]string{"app": "db"}),
+			testPod("prod", "web-2", map[string]string{"app": "web"}),
+			&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"}, Spec: appsv1.DeploymentSpec{Replicas: &replicas}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "prod", Labels: map[string]string{"env": "prod"}}},
+		),
+		testBrokenTypedCluster("cluster2"),
+		testTypedCluster("cluster3",
+			testPod("default", "web-3", map[string]string{"app": "web"}),
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		),
+	}
+
+	tests := []struct {
+		name      string
+		get       func(tw util.TableWriter) (int, error)
+		columns   int
+		wantRows  int
+		wantTable [][]string
+	}{
+		{
+			name: "pods in the default namespace of every cluster",
+			get: func(tw util.TableWriter) (int, error) {
+				return printResourceTable(tw, clusters, podTable, tableQuery{})
+			},
+			columns:   2,
+			wantRows:  3,
+			wantTable: [][]string{{"CLUSTER", "NAME"}, {"cluster1", "db-1"}, {"cluster1", "web-1"}, {"cluster3", "web-3"}},
+		},
+		{
+			name: "pods by selector in all namespaces",
+			get: func(tw util.TableWriter) (int, error) {
+				return printResourceTable(tw, clusters, podTable, tableQuery{Selector: "app=web", AllNamespaces: true})
+			},
+			columns:   3,
+			wantRows:  3,
+			wantTable: [][]string{{"CLUSTER", "NAMESPACE", "NAME"}, {"cluster1", "default", "web-1"}, {"cluster1", "prod", "web-2"}, {"cluster3", "default", "web-3"}},
+		},
+		{
+			name: "pods by set-based selector",
+			get: func(tw util.TableWriter) (int, error) {
+				return printResourceTable(tw, clusters, podTable, tableQuery{Selector: "app in (db,cache),!tier", AllNamespaces: true})
+			},
+			columns:   3,
+			wantRows:  1,
+			wantTable: [][]string{{"CLUSTER", "NAMESPACE", "NAME"}, {"cluster1", "default", "db-1"}},
+		},
+		{
+			name: "pods by notin selector",
+			get: func(tw util.TableWriter) (int, error) {
+				return printResourceTable(tw, clusters, podTable, tableQuery{Selector: "app notin (db)", Namespace: "prod"})
+			},
+			columns:   2,
+			wantRows:  1,
+			wantTable: [][]string{{"CLUSTER", "NAME"}, {"cluster1", "web-2"}},
+		},
+		{
+			name: "pod by name",
+			get: func(tw util.TableWriter) (int, error) {
+				return printResourceTable(tw, clusters, podTable, tableQuery{Name: "web-2", Namespace: "prod"})
+			},
+			columns:   2,
+			wantRows:  1,
+			wantTable: [][]string{{"CLUSTER", "NAME"}, {"cluster1", "web-2"}},
+		},
+		{
+			name: "deployments",
+			get: func(tw util.TableWriter) (int, error) {
+				return printResourceTable(tw, clusters, deploymentTable, tableQuery{})
+			},
+			columns:   3,
+			wantRows:  1,
+			wantTable: [][]string{{"CLUSTER", "NAME", "READY"}, {"cluster1", "web", "0/2"}},
+		},
+		{
+			name: "namespaces by label",
+			get: func(tw util.TableWriter) (int, error) {
+				return printResourceTable(tw, clusters, namespaceTable, tableQuery{Selector: "env=prod"})
+			},
+			columns:   2,
+			wantRows:  1,
+			wantTable: [][]string{{"CLUSTER", "NAME"}, {"cluster1", "prod"}},
+		},
+		{
+			name: "nothing matches",
+			get: func(tw util.TableWriter) (int, error) {
+				return printResourceTable(tw, clusters, podTable, tableQuery{Selector: "app=none", AllNamespaces: true})
+			},
+			columns:  1,
+			wantRows: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			takeClusterIssues()
+			var out bytes.Buffer
+			tw := util.NewTableWriter(&out, "")
+			rows, err := tt.get(tw)
+			tw.Flush()
+			if err != nil {
+				t.Fatalf("get error = %v", err)
+			}
+			if rows != tt.wantRows {
+				t.Errorf("rows = %d, want %d", rows, tt.wantRows)
+			}
+			if got := tableCells(out.String(), tt.columns); !reflect.DeepEqual(got, tt.wantTable) {
+				t.Errorf("printed %q, want cells %q", out.String(), tt.wantTable)
+			}
+			issues := takeClusterIssues()
+			if len(issues) != 1 || issues[0].Cluster != "cluster2" {
+				t.Errorf("cluster issues = %+v, want one for cluster2", issues)
+			}
+		})
+	}
+}
+
+func TestGetColumns(t *testing.T) {
+	clusters := []cluster.ClusterInfo{testTypedCluster("cluster1", testPod("prod", "web-1", nil))}
+	var out bytes.Buffer
+	tw := selectGetColumns(util.NewTableWriter(&out, util.TableFormatCSV), []string{"STATUS", "NAME", "CLUSTER"})
+	if _, err := printResourceTable(tw, clusters, podTable, tableQuery{AllNamespaces: true}); err != nil {
+		t.Fatalf("printResourceTable() error = %v", err)
+	}
+	if err := tw.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if want := "STATUS,NAME,CLUSTER\nRunning,web-1,cluster1\n"; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+
+	// Without -A the table has no NAMESPACE column
+	out.Reset()
+	tw = selectGetColumns(util.NewTableWriter(&out, ""), []string{"NAMESPACE", "NAME"})
+	printResourceTable(tw, clusters, podTable, tableQuery{Namespace: "prod"})
+	if err := tw.Flush(); err == nil || !strings.Contains(err.Error(), "column NAMESPACE is not in the table") {
+		t.Errorf("Flush() error = %v, want the missing column", err)
+	}
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"kubectl-multi/pkg/util"
+)
+
+// nodeCapacity sums the allocatable resources of a set of nodes
+type nodeCapacity struct {
+	nodes  int
+	cpu    resource.Quantity
+	memory resource.Quantity
+	pods   resource.Quantity
+}
+
+func (c *nodeCapacity) add(node *corev1.Node) {
+	c.nodes++
+	alloc := node.Status.Allocatable
+	c.cpu.Add(alloc[corev1.ResourceCPU])
+	c.memory.Add(alloc[corev1.ResourceMemory])
+	c.pods.Add(alloc[corev1.ResourcePods])
+}
+
+func (c *nodeCapacity) merge(other nodeCapacity) {
+	c.nodes += other.nodes
+	c.cpu.Add(other.cpu)
+	c.memory.Add(other.memory)
+	c.pods.Add(other.pods)
+}
+
+// columns returns the CPU, MEMORY and PODS cells, each starting with a tab
+func (c nodeCapacity) columns() string {
+	return fmt.Sprintf("\t%s\t%s\t%d", formatCPU(c.cpu), formatMemory(c.memory), c.pods.Value())
+}
+
+// clusterCapacity is the allocatable total of the listed nodes of a cluster
+type clusterCapacity struct {
+	cluster string
+	nodeCapacity
+}
+
+// printCapacityTotals prints the allocatable totals of every cluster and of
+// the fleet as a table of its own below the node list
+func printCapacityTotals(tw util.TableWriter, totals []clusterCapacity) {
+	var fleet nodeCapacity
+	fmt.Fprintf(tw, "\nCLUSTER\tNODES\tCPU\tMEMORY\tPODS\n")
+	for _, t := range totals {
+		fmt.Fprintf(tw, "%s\t%d%s\n", t.cluster, t.nodes, t.columns())
+		fleet.merge(t.nodeCapacity)
+	}
+	fmt.Fprintf(tw, "TOTAL\t%d%s\n", fleet.nodes, fleet.columns())
+}
+
+// formatCPU prints cores, with a fraction for millicores, e.g. 7.5
+func formatCPU(q resource.Quantity) string {
+	return strconv.FormatFloat(float64(q.MilliValue())/1000, 'f', -1, 64)
+}
+
+// formatMemory prints bytes in the largest binary unit that keeps the value
+// at least 1, rounded to one decimal, e.g. 15.5Gi
+func formatMemory(q resource.Quantity) string {
+	value := float64(q.Value())
+	units := []string{"", "Ki", "Mi", "Gi", "Ti", "Pi"}
+	i := 0
+	for value >= 1024 && i < len(units)-1 {
+		value /= 1024
+		i++
+	}
+	return strconv.FormatFloat(math.Round(value*10)/10, 'f', -1, 64) + units[i]
+}
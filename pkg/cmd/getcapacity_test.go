@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+func testNode(name, cpu, memory, pods string, labels map[string]string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		Status: corev1.NodeStatus{
+			Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(memory),
+				corev1.ResourcePods:   resource.MustParse(pods),
+			},
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+			NodeInfo:   corev1.NodeSystemInfo{KubeletVersion: "v1.29.2"},
+		},
+	}
+}
+
+func TestHandleNodesGetCapacity(t *testing.T) {
+	clusters := []cluster.ClusterInfo{
+		testTypedCluster("cluster1",
+			testNode("node-a", "4", "16Gi", "110", map[string]string{"pool": "web"}),
+			testNode("node-b", "3500m", "8032Mi", "110", nil),
+		),
+		testBrokenTypedCluster("cluster2"),
+		testTypedCluster("cluster3", testNode("node-c", "2", "4Gi", "58", map[string]string{"pool": "web"})),
+	}
+
+	tests := []struct {
+		name       string
+		capacity   bool
+		showLabels bool
+		selector   string
+		want       []string
+	}{
+		{
+			name: "without capacity",
+			want: []string{
+				"CLUSTER,NAME,STATUS,ROLES,VERSION",
+				"cluster1,node-a,Ready,<none>,v1.29.2",
+				"cluster1,node-b,Ready,<none>,v1.29.2",
+				"cluster3,node-c,Ready,<none>,v1.29.2",
+			},
+		},
+		{
+			name:     "capacity with totals",
+			capacity: true,
+			want: []string{
+				"CLUSTER,NAME,STATUS,ROLES,VERSION,CPU,MEMORY,PODS",
+				"cluster1,node-a,Ready,<none>,v1.29.2,4,16Gi,110",
+				"cluster1,node-b,Ready,<none>,v1.29.2,3.5,7.8Gi,110",
+				"cluster3,node-c,Ready,<none>,v1.29.2,2,4Gi,58",
+				"CLUSTER,NODES,CPU,MEMORY,PODS",
+				"cluster1,2,7.5,23.8Gi,220",
+				"cluster3,1,2,4Gi,58",
+				"TOTAL,3,9.5,27.8Gi,278",
+			},
+		},
+		{
+			name:       "capacity with labels and a selector",
+			capacity:   true,
+			showLabels: true,
+			selector:   "pool=web",
+			want: []string{
+				"CLUSTER,NAME,STATUS,ROLES,VERSION,CPU,MEMORY,PODS,LABELS",
+				"cluster1,node-a,Ready,<none>,v1.29.2,4,16Gi,110,pool=web",
+				"cluster3,node-c,Ready,<none>,v1.29.2,2,4Gi,58,pool=web",
+				"CLUSTER,NODES,CPU,MEMORY,PODS",
+				"cluster1,1,4,16Gi,110",
+				"cluster3,1,2,4Gi,58",
+				"TOTAL,2,6,20Gi,168",
+			},
+		},
+		{
+			name:     "nothing matches",
+			capacity: true,
+			selector: "pool=none",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			takeClusterIssues()
+
+			var out bytes.Buffer
+			tw := util.NewTableWriter(&out, util.TableFormatCSV)
+			if _, err := printResourceTable(tw, clusters, nodeTable(tt.capacity), tableQuery{Selector: tt.selector, ShowLabels: tt.showLabels}); err != nil {
+				t.Fatalf("printResourceTable() error = %v", err)
+			}
+			tw.Flush()
+
+			var got []string
+			totals := false
+			for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+				if line == "" {
+					continue
+				}
+				cells := strings.Split(line, ",")
+				totals = totals || strings.HasPrefix(line, "CLUSTER,NODES,")
+				if !totals {
+					// Drop the AGE column of the node rows, which depends on the clock
+					cells = append(cells[:4], cells[5:]...)
+				}
+				got = append(got, strings.Join(cells, ","))
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("printed\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestFormatCapacity(t *testing.T) {
+	tests := []struct {
+		quantity   string
+		wantCPU    string
+		wantMemory string
+	}{
+		{quantity: "0", wantCPU: "0", wantMemory: "0"},
+		{quantity: "250m", wantCPU: "0.25", wantMemory: "1"},
+		{quantity: "4", wantCPU: "4", wantMemory: "4"},
+		{quantity: "1536", wantCPU: "1536", wantMemory: "1.5Ki"},
+		{quantity: "16283676Ki", wantCPU: "16674484224", wantMemory: "15.5Gi"},
+		{quantity: "2Ti", wantCPU: "2199023255552", wantMemory: "2Ti"},
+		{quantity: "1G", wantCPU: "1000000000", wantMemory: "953.7Mi"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.quantity, func(t *testing.T) {
+			q := resource.MustParse(tt.quantity)
+			if got := formatCPU(q); got != tt.wantCPU {
+				t.Errorf("formatCPU(%s) = %s, want %s", tt.quantity, got, tt.wantCPU)
+			}
+			if got := formatMemory(q); got != tt.wantMemory {
+				t.Errorf("formatMemory(%s) = %s, want %s", tt.quantity, got, tt.wantMemory)
+			}
+		})
+	}
+}
+
+func TestGetCapacityFlagValidation(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		outputFormat string
+		wantErr      string
+	}{
+		{name: "other resource type", args: []string{"pods"}, wantErr: "--capacity only applies to nodes"},
+		{name: "structured output", args: []string{"no"}, outputFormat: "yaml", wantErr: "-o yaml already includes status.allocatable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := tableQuery{Options: getOptions{OutputFormat: tt.outputFormat, ExitZeroOnEmpty: true, Capacity: true}}
+			err := handleGetCommand(tt.args, q, clusterTargets{}, reachability{}, "", "")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("handleGetCommand() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
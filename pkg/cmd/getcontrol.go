@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+)
+
+// controlColumn is a column of a KubeStellar control object table
+type controlColumn = tableColumn[*unstructured.Unstructured]
+
+// controlObjectTable is the table get prints for one type of KubeStellar
+// control object: NAME, the columns of the type, then AGE
+func controlObjectTable(gvr schema.GroupVersionResource, namespaced bool, columns ...controlColumn) resourceTable[*unstructured.Unstructured] {
+	return resourceTable[*unstructured.Unstructured]{
+		Group:      gvr.Group,
+		Resource:   gvr.Resource,
+		Namespaced: namespaced,
+		Dynamic:    true,
+		List: func(c cluster.ClusterInfo, ns string, opts metav1.ListOptions) (runtime.Object, error) {
+			return c.DynamicClient.Resource(gvr).Namespace(ns).List(commandContext(), opts)
+		},
+		Columns: append(append([]controlColumn{nameColumn[*unstructured.Unstructured]()}, columns...), ageColumn[*unstructured.Unstructured]()),
+	}
+}
+
+var (
+	bindingTable = controlObjectTable(kubestellar.BindingGVR, false,
+		controlColumn{Name: "CLUSTERS", Value: func(obj *unstructured.Unstructured) string {
+			return noneIfEmpty(strings.Join(kubestellar.BindingDestinations(obj), ","))
+		}},
+		controlColumn{Name: "WORKLOADS", Value: func(obj *unstructured.Unstructured) string {
+			return strconv.Itoa(len(kubestellar.BindingWorkload(obj)))
+		}},
+		controlColumn{Name: "STATUS", Value: kubestellar.BindingState},
+	)
+
+	workStatusTable = controlObjectTable(kubestellar.WorkStatusGVR, true,
+		controlColumn{Name: "SOURCE", Value: func(obj *unstructured.Unstructured) string {
+			return kubestellar.WorkStatusSource(obj).String()
+		}},
+		controlColumn{Name: "STATUS", Value: kubestellar.WorkStatusState},
+	)
+
+	combinedStatusTable = controlObjectTable(kubestellar.CombinedStatusGVR, true,
+		controlColumn{Name: "WORKLOAD", Value: func(obj *unstructured.Unstructured) string {
+			return kubestellar.CombinedStatusWorkload(obj).String()
+		}},
+		controlColumn{Name: "POLICY", Value: func(obj *unstructured.Unstructured) string {
+			return noneIfEmpty(obj.GetLabels()[kubestellar.StatusLabelBindingPolicy])
+		}},
+		controlColumn{Name: "RESULTS", Value: kubestellar.CombinedStatusResults},
+	)
+
+	addOnTable = controlObjectTable(kubestellar.ManagedClusterAddOnGVR, true,
+		conditionColumn("AVAILABLE", "Available"),
+		conditionColumn("DEGRADED", "Degraded"),
+		conditionColumn("PROGRESSING", "Progressing"),
+	)
+
+	// klusterletTable is read from the managed clusters themselves, where
+	// the OCM operator keeps the Klusterlet of their agents
+	klusterletTable = controlObjectTable(kubestellar.KlusterletGVR, false,
+		controlColumn{Name: "MODE", Value: kubestellar.KlusterletMode},
+		conditionColumn("AVAILABLE", "Available"),
+		controlColumn{Name: "DEGRADED", Value: func(obj *unstructured.Unstructured) string {
+			if d := kubestellar.DegradedConditions(kubestellar.ObjectConditions(obj)); len(d) > 0 {
+				return strings.Join(d, ",")
+			}
+			return "False"
+		}},
+	)
+
+	// The Multi-Cluster Services API objects are read from the managed
+	// clusters, where services are exported and imported
+	serviceExportTable = controlObjectTable(kubestellar.ServiceExportGVR, true,
+		controlColumn{Name: "STATUS", Value: kubestellar.ServiceExportState},
+		controlColumn{Name: "MESSAGE", Value: func(obj *unstructured.Unstructured) string {
+			return noneIfEmpty(kubestellar.ServiceExportMessage(obj))
+		}},
+	)
+
+	serviceImportTable = controlObjectTable(kubestellar.ServiceImportGVR, true,
+		controlColumn{Name: "TYPE", Value: func(obj *unstructured.Unstructured) string {
+			return noneIfEmpty(kubestellar.ServiceImportType(obj))
+		}},
+		controlColumn{Name: "IPS", Value: func(obj *unstructured.Unstructured) string {
+			return noneIfEmpty(strings.Join(kubestellar.ServiceImportIPs(obj), ","))
+		}},
+		controlColumn{Name: "PORTS", Value: func(obj *unstructured.Unstructured) string {
+			return noneIfEmpty(strings.Join(kubestellar.ServiceImportPorts(obj), ","))
+		}},
+		controlColumn{Name: "CLUSTERS", Value: func(obj *unstructured.Unstructured) string {
+			return noneIfEmpty(strings.Join(kubestellar.ServiceImportClusters(obj), ","))
+		}},
+	)
+)
+
+// conditionColumn shows the status of a condition of the object
+func conditionColumn(name, condition string) controlColumn {
+	return controlColumn{Name: name, Value: func(obj *unstructured.Unstructured) string {
+		return kubestellar.ConditionStatus(kubestellar.ObjectConditions(obj), condition)
+	}}
+}
+
+// controlObjectKind returns the control plane ("WDS" or "ITS") holding a
+// KubeStellar control object type, or "" for workload types
+func controlObjectKind(resourceType string) string {
+	switch resourceType {
+	case "bindings", "binding", "bindings.control.kubestellar.io",
+		"combinedstatuses", "combinedstatus", "combinedstatuses.control.kubestellar.io":
+		return "WDS"
+	case "workstatuses", "workstatus", "workstatuses.control.kubestellar.io",
+		"managedclusteraddons", "managedclusteraddon", "mca", "managedclusteraddons.addon.open-cluster-management.io":
+		return "ITS"
+	}
+	return ""
+}
+
+// controlPlaneClusters returns the selected WDSes or ITSes as the clusters to
+// read control objects from; --clusters, --cluster-selector and --placement
+// choose managed clusters and do not apply
+func controlPlaneClusters(kind string, targets clusterTargets, reach reachability, kubeconfig string, contexts []string) ([]cluster.ClusterInfo, error) {
+	if len(targets.Names) > 0 || targets.Selector != "" || targets.Placement != "" {
+		return nil, fmt.Errorf("--clusters, --cluster-selector and --placement do not apply to objects read from the %s", kind)
+	}
+	var clusters []cluster.ClusterInfo
+	for _, contextName := range contexts {
+		if _, err := reach.controlPlane(kind, kubeconfig, contextName, true); err != nil {
+			return nil, err
+		}
+		c, err := cluster.ClientForContext(kubeconfig, contextName)
+		if err != nil {
+			return nil, err
+		}
+		c.Name = contextName
+		clusters = append(clusters, *c)
+	}
+	return clusters, nil
+}
+
+func noneIfEmpty(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
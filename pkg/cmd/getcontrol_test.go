@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"text/tabwriter"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+)
+
+func TestControlObjectKind(t *testing.T) {
+	tests := []struct {
+		resourceType string
+		want         string
+	}{
+		{resourceType: "bindings", want: "WDS"},
+		{resourceType: "binding", want: "WDS"},
+		{resourceType: "combinedstatuses.control.kubestellar.io", want: "WDS"},
+		{resourceType: "workstatuses", want: "ITS"},
+		{resourceType: "mca", want: "ITS"},
+		{resourceType: "klusterlets", want: ""},
+		{resourceType: "pods", want: ""},
+		{resourceType: "bindingpolicies", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.resourceType, func(t *testing.T) {
+			if got := controlObjectKind(tt.resourceType); got != tt.want {
+				t.Errorf("controlObjectKind(%q) = %q, want %q", tt.resourceType, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestControlPlaneClustersRejectsTargets(t *testing.T) {
+	tests := []struct {
+		name    string
+		targets clusterTargets
+	}{
+		{name: "names", targets: clusterTargets{Names: []string{"cluster1"}}},
+		{name: "selector", targets: clusterTargets{Selector: "env=prod"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := controlPlaneClusters("WDS", tt.targets, reachability{}, "", []string{"wds1"})
+			if err == nil || !strings.Contains(err.Error(), "do not apply") {
+				t.Errorf("controlPlaneClusters() error = %v, want a targeting error", err)
+			}
+		})
+	}
+}
+
+func testControlObject(gvr schema.GroupVersionResource, kind, namespace, name string, fields map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: fields}
+	obj.SetAPIVersion(gvr.GroupVersion().String())
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+func TestHandleControlObjectsGet(t *testing.T) {
+	binding := testControlObject(kubestellar.BindingGVR, "Binding", "", "nginx-bp", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"destinations": []interface{}{map[string]interface{}{"clusterId": "cluster1"}, map[string]interface{}{"clusterId": "cluster2"}},
+			"workload": map[string]interface{}{"namespaceScope": []interface{}{
+				map[string]interface{}{"group": "apps", "version": "v1", "resource": "deployments", "namespace": "web", "name": "nginx"},
+			}},
+		},
+		"status": map[string]interface{}{"observedGeneration": int64(0)},
+	})
+	workStatus := testControlObject(kubestellar.WorkStatusGVR, "WorkStatus", "cluster1", "deployment-nginx", map[string]interface{}{
+		"spec":   map[string]interface{}{"sourceRef": map[string]interface{}{"group": "apps", "resource": "deployments", "namespace": "web", "name": "nginx"}},
+		"status": map[string]interface{}{"replicas": int64(2), "readyReplicas": int64(2)},
+	})
+	combined := testControlObject(kubestellar.CombinedStatusGVR, "CombinedStatus", "web", "abc.nginx-bp", map[string]interface{}{
+		"results": []interface{}{map[string]interface{}{"name": "ready", "rows": []interface{}{map[string]interface{}{}}}},
+	})
+	combined.SetLabels(map[string]string{
+		kubestellar.StatusLabelAPIGroup:      "apps",
+		kubestellar.StatusLabelResource:      "deployments",
+		kubestellar.StatusLabelNamespace:     "web",
+		kubestellar.StatusLabelName:          "nginx",
+		kubestellar.StatusLabelBindingPolicy: "nginx-bp",
+	})
+
+	addOn := testControlObject(kubestellar.ManagedClusterAddOnGVR, "ManagedClusterAddOn", "cluster1", "status-addon", map[string]interface{}{
+		"status": map[string]interface{}{"conditions": []interface{}{
+			map[string]interface{}{"type": "Available", "status": "False"},
+			map[string]interface{}{"type": "Degraded", "status": "True"},
+		}},
+	})
+	klusterlet := testControlObject(kubestellar.KlusterletGVR, "Klusterlet", "", "klusterlet", map[string]interface{}{
+		"status": map[string]interface{}{"conditions": []interface{}{
+			map[string]interface{}{"type": "Available", "status": "True"},
+			map[string]interface{}{"type": "HubConnectionDegraded", "status": "True"},
+		}},
+	})
+
+	tests := []struct {
+		name          string
+		table         resourceTable[*unstructured.Unstructured]
+		resourceName  string
+		namespace     string
+		allNamespaces bool
+		wantRows      int
+		wantOutput    []string
+	}{
+		{
+			name:       "bindings",
+			table:      bindingTable,
+			wantRows:   1,
+			wantOutput: []string{"CLUSTER NAME CLUSTERS WORKLOADS STATUS AGE", "wds1 nginx-bp cluster1,cluster2 1 Synced"},
+		},
+		{name: "binding by missing name", table: bindingTable, resourceName: "other", wantRows: 0},
+		{
+			name:          "workstatuses in all namespaces",
+			table:         workStatusTable,
+			allNamespaces: true,
+			wantRows:      1,
+			wantOutput:    []string{"CLUSTER NAMESPACE NAME SOURCE STATUS AGE", "wds1 cluster1 deployment-nginx deployments.apps web/nginx 2/2 ready"},
+		},
+		{name: "workstatuses in another namespace", table: workStatusTable, namespace: "cluster2", wantRows: 0},
+		{
+			name:       "combinedstatuses",
+			table:      combinedStatusTable,
+			namespace:  "web",
+			wantRows:   1,
+			wantOutput: []string{"CLUSTER NAME WORKLOAD POLICY RESULTS AGE", "wds1 abc.nginx-bp deployments.apps web/nginx nginx-bp ready(1)"},
+		},
+		{
+			name:          "managedclusteraddons",
+			table:         addOnTable,
+			allNamespaces: true,
+			wantRows:      1,
+			wantOutput:    []string{"CLUSTER NAMESPACE NAME AVAILABLE DEGRADED PROGRESSING AGE", "wds1 cluster1 status-addon False True Unknown"},
+		},
+		{
+			name:       "klusterlets",
+			table:      klusterletTable,
+			wantRows:   1,
+			wantOutput: []string{"CLUSTER NAME MODE AVAILABLE DEGRADED AGE", "wds1 klusterlet Default True HubConnectionDegraded"},
+		},
+	}
+	listKinds := map[schema.GroupVersionResource]string{
+		kubestellar.BindingGVR:             "BindingList",
+		kubestellar.WorkStatusGVR:          "WorkStatusList",
+		kubestellar.CombinedStatusGVR:      "CombinedStatusList",
+		kubestellar.ManagedClusterAddOnGVR: "ManagedClusterAddOnList",
+		kubestellar.KlusterletGVR:          "KlusterletList",
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, binding, workStatus, combined, addOn, klusterlet)
+			clusters := []cluster.ClusterInfo{{Name: "wds1", DynamicClient: client}}
+			var out bytes.Buffer
+			tw := tabwriter.NewWriter(&out, 0, 0, 2, ' ', 0)
+			rows, err := printResourceTable(tw, clusters, tt.table, tableQuery{Name: tt.resourceName, Namespace: tt.namespace, AllNamespaces: tt.allNamespaces})
+			tw.Flush()
+			if err != nil {
+				t.Fatalf("printResourceTable() error = %v", err)
+			}
+			if rows != tt.wantRows {
+				t.Errorf("printResourceTable() rows = %d, want %d", rows, tt.wantRows)
+			}
+			var lines []string
+			for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+				if line != "" {
+					lines = append(lines, strings.Join(strings.Fields(line), " "))
+				}
+			}
+			if len(lines) != len(tt.wantOutput) {
+				t.Fatalf("printResourceTable() printed %q, want %q", lines, tt.wantOutput)
+			}
+			for i, want := range tt.wantOutput {
+				if !strings.HasPrefix(lines[i], want) {
+					t.Errorf("line %d = %q, want prefix %q", i, lines[i], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+var crdGVR = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+// How the CRDs of the clusters compare
+const (
+	crdStatusOK          = "OK"
+	crdStatusMissing     = "Missing"
+	crdStatusVersionSkew = "VersionSkew"
+)
+
+// crdInfo is what the comparison looks at in one CRD of one cluster
+type crdInfo struct {
+	Versions    []string
+	Established bool
+}
+
+// crdPresence is a CRD as one cluster serves it
+type crdPresence struct {
+	Cluster         string   `json:"cluster"`
+	Present         bool     `json:"present"`
+	Versions        []string `json:"versions,omitempty"`
+	MissingVersions []string `json:"missingVersions,omitempty"`
+	Established     bool     `json:"established"`
+}
+
+// crdComparison is a CRD across the compared clusters. Versions are those
+// every cluster is expected to serve: the baseline's, or all served anywhere.
+type crdComparison struct {
+	Name     string        `json:"name"`
+	Versions []string      `json:"versions"`
+	Status   string        `json:"status"`
+	Clusters []crdPresence `json:"clusters"`
+}
+
+// isCRDResource reports whether get was asked for CRDs
+func isCRDResource(resourceType string) bool {
+	switch resourceType {
+	case "crds", "crd", "customresourcedefinitions", "customresourcedefinition":
+		return true
+	}
+	return false
+}
+
+// validateCRDCompare checks that --compare and --required-from are used
+// with crds and an output the comparison can print
+func validateCRDCompare(resourceType string, o getOptions) error {
+	if o.CRDRequiredFrom != "" && !o.CRDCompare {
+		return fmt.Errorf("--required-from only applies with --compare")
+	}
+	if !o.CRDCompare {
+		return nil
+	}
+	if !isCRDResource(resourceType) {
+		return fmt.Errorf("--compare only applies to crds")
+	}
+	if o.OnlyDifferences {
+		return fmt.Errorf("--compare cannot be combined with --only-differences")
+	}
+	switch o.OutputFormat {
+	case "", "json", "yaml", util.TableFormatCSV, util.TableFormatMarkdown:
+		return nil
+	}
+	return fmt.Errorf("--compare supports -o json|yaml|csv|markdown")
+}
+
+// handleCRDComparison lists the CRDs of every cluster, leaving out the
+// cluster of skipContext, and prints a row per CRD with the versions each
+// cluster serves
+func handleCRDComparison(clusters []cluster.ClusterInfo, q tableQuery, skipContext string) error {
+	requiredFrom, outputFormat := q.Options.CRDRequiredFrom, q.Options.OutputFormat
+	var compared []string
+	crds := map[string]map[string]crdInfo{}
+	for _, clusterInfo := range clusters {
+		if clusterInfo.Context == skipContext || clusterInfo.DynamicClient == nil {
+			continue
+		}
+		list, err := clusterInfo.DynamicClient.Resource(crdGVR).List(commandContext(), metav1.ListOptions{LabelSelector: q.Selector})
+		if err != nil {
+			noteClusterIssue(clusterInfo.Name, fmt.Sprintf("failed to list crds: %v", err))
+			continue
+		}
+		compared = append(compared, clusterInfo.Name)
+		crds[clusterInfo.Name] = map[string]crdInfo{}
+		for i := range list.Items {
+			if q.Name != "" && list.Items[i].GetName() != q.Name {
+				continue
+			}
+			crds[clusterInfo.Name][list.Items[i].GetName()] = crdInfoFrom(&list.Items[i])
+		}
+	}
+	if requiredFrom != "" && crds[requiredFrom] == nil {
+		return fmt.Errorf("--required-from cluster %s is not among the compared clusters", requiredFrom)
+	}
+
+	comparisons := compareCRDs(compared, crds, requiredFrom)
+	if outputFormat == "json" || outputFormat == "yaml" {
+		return util.PrintStructured(util.GetOutputStream(), outputFormat, comparisons)
+	}
+	if len(comparisons) == 0 {
+		fmt.Fprintln(os.Stderr, "No resources found.")
+		return nil
+	}
+	printCRDComparisons(comparisons, compared, outputFormat)
+	if summary := crdComparisonSummary(comparisons, requiredFrom); summary != "" {
+		fmt.Fprintln(os.Stderr, summary)
+	}
+	return nil
+}
+
+// crdInfoFrom reads the served versions and the Established condition of a CRD
+func crdInfoFrom(crd *unstructured.Unstructured) crdInfo {
+	var info crdInfo
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	for _, v := range versions {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if served, _ := m["served"].(bool); served {
+			name, _ := m["name"].(string)
+			info.Versions = append(info.Versions, name)
+		}
+	}
+	sort.Strings(info.Versions)
+	conditions, _, _ := unstructured.NestedSlice(crd.Object, "status", "conditions")
+	for _, c := range conditions {
+		m, ok := c.(map[string]interface{})
+		if ok && m["type"] == "Established" && m["status"] == "True" {
+			info.Established = true
+		}
+	}
+	return info
+}
+
+// compareCRDs compares the CRDs of the clusters, keyed by cluster and CRD
+// name. With a baseline cluster only its CRDs and versions are required;
+// otherwise every CRD and version served by any cluster is.
+func compareCRDs(compared []string, crds map[string]map[string]crdInfo, baseline string) []crdComparison {
+	required := map[string]map[string]bool{}
+	for _, c := range compared {
+		if baseline != "" && c != baseline {
+			continue
+		}
+		for name, info := range crds[c] {
+			if required[name] == nil {
+				required[name] = map[string]bool{}
+			}
+			for _, v := range info.Versions {
+				required[name][v] = true
+			}
+		}
+	}
+
+	comparisons := []crdComparison{}
+	for _, name := range sortedKeys(required) {
+		comparison := crdComparison{Name: name, Versions: sortedKeys(required[name]), Status: crdStatusOK}
+		for _, c := range compared {
+			p := crdPresence{Cluster: c}
+			info, ok := crds[c][name]
+			if !ok {
+				comparison.Status = crdStatusMissing
+				comparison.Clusters = append(comparison.Clusters, p)
+				continue
+			}
+			p.Present, p.Versions, p.Established = true, info.Versions, info.Established
+			for _, v := range comparison.Versions {
+				if !containsString(info.Versions, v) {
+					p.MissingVersions = append(p.MissingVersions, v)
+				}
+			}
+			if len(p.MissingVersions) > 0 && comparison.Status == crdStatusOK {
+				comparison.Status = crdStatusVersionSkew
+			}
+			comparison.Clusters = append(comparison.Clusters, p)
+		}
+		comparisons = append(comparisons, comparison)
+	}
+	return comparisons
+}
+
+// printCRDComparisons prints a row per CRD and a column per cluster
+func printCRDComparisons(comparisons []crdComparison, compared []string, outputFormat string) {
+	tw := util.NewTableWriter(util.GetOutputStream(), outputFormat)
+	defer tw.Flush()
+
+	header := []string{"CRD", "VERSIONS", "STATUS"}
+	for _, c := range compared {
+		header = append(header, strings.ToUpper(c))
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, comparison := range comparisons {
+		row := []string{comparison.Name, strings.Join(comparison.Versions, ","), comparison.Status}
+		for _, p := range comparison.Clusters {
+			row = append(row, describeCRDPresence(p))
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+}
+
+// describeCRDPresence is the cell of a cluster: the versions it serves, what
+// it lacks, and whether the CRD is established
+func describeCRDPresence(p crdPresence) string {
+	if !p.Present {
+		return "<missing>"
+	}
+	cell := orNone(strings.Join(p.Versions, ","))
+	var notes []string
+	if len(p.MissingVersions) > 0 {
+		notes = append(notes, "no "+strings.Join(p.MissingVersions, ","))
+	}
+	if !p.Established {
+		notes = append(notes, "not established")
+	}
+	if len(notes) > 0 {
+		cell += " (" + strings.Join(notes, "; ") + ")"
+	}
+	return cell
+}
+
+// crdComparisonSummary counts the CRDs or versions each cluster lacks
+func crdComparisonSummary(comparisons []crdComparison, baseline string) string {
+	missing := map[string]int{}
+	var order []string
+	for _, comparison := range comparisons {
+		for _, p := range comparison.Clusters {
+			if p.Present && len(p.MissingVersions) == 0 {
+				continue
+			}
+			if _, seen := missing[p.Cluster]; !seen {
+				order = append(order, p.Cluster)
+			}
+			missing[p.Cluster]++
+		}
+	}
+	if len(order) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(order))
+	for _, c := range order {
+		parts = append(parts, fmt.Sprintf("%s lacks %d", c, missing[c]))
+	}
+	of := "the other clusters"
+	if baseline != "" {
+		of = baseline
+	}
+	return fmt.Sprintf("Warning: CRDs or versions of %s are missing: %s", of, strings.Join(parts, ", "))
+}
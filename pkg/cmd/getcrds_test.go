@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestCRDInfoFrom(t *testing.T) {
+	crd := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"versions": []interface{}{
+			map[string]interface{}{"name": "v1beta1", "served": true},
+			map[string]interface{}{"name": "v1alpha1", "served": false},
+			map[string]interface{}{"name": "v1", "served": true},
+		}},
+		"status": map[string]interface{}{"conditions": []interface{}{
+			map[string]interface{}{"type": "NamesAccepted", "status": "True"},
+			map[string]interface{}{"type": "Established", "status": "True"},
+		}},
+	}}
+	want := crdInfo{Versions: []string{"v1", "v1beta1"}, Established: true}
+	if got := crdInfoFrom(crd); !reflect.DeepEqual(got, want) {
+		t.Errorf("crdInfoFrom() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCompareCRDs(t *testing.T) {
+	compared := []string{"cluster1", "cluster2", "cluster3"}
+	crds := map[string]map[string]crdInfo{
+		"cluster1": {
+			"certificates.cert-manager.io": {Versions: []string{"v1"}, Established: true},
+			"widgets.example.com":          {Versions: []string{"v1", "v2"}, Established: true},
+		},
+		"cluster2": {
+			"certificates.cert-manager.io": {Versions: []string{"v1"}, Established: true},
+			"widgets.example.com":          {Versions: []string{"v1"}, Established: true},
+			"gadgets.example.com":          {Versions: []string{"v1"}},
+		},
+		"cluster3": {
+			"certificates.cert-manager.io": {Versions: []string{"v1"}, Established: true},
+		},
+	}
+
+	got := compareCRDs(compared, crds, "")
+	want := []crdComparison{
+		{Name: "certificates.cert-manager.io", Versions: []string{"v1"}, Status: crdStatusOK, Clusters: []crdPresence{
+			{Cluster: "cluster1", Present: true, Versions: []string{"v1"}, Established: true},
+			{Cluster: "cluster2", Present: true, Versions: []string{"v1"}, Established: true},
+			{Cluster: "cluster3", Present: true, Versions: []string{"v1"}, Established: true},
+		}},
+		{Name: "gadgets.example.com", Versions: []string{"v1"}, Status: crdStatusMissing, Clusters: []crdPresence{
+			{Cluster: "cluster1"},
+			{Cluster: "cluster2", Present: true, Versions: []string{"v1"}},
+			{Cluster: "cluster3"},
+		}},
+		{Name: "widgets.example.com", Versions: []string{"v1", "v2"}, Status: crdStatusMissing, Clusters: []crdPresence{
+			{Cluster: "cluster1", Present: true, Versions: []string{"v1", "v2"}, Established: true},
+			{Cluster: "cluster2", Present: true, Versions: []string{"v1"}, MissingVersions: []string{"v2"}, Established: true},
+			{Cluster: "cluster3"},
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("compareCRDs() = %+v, want %+v", got, want)
+	}
+	if cell := describeCRDPresence(got[2].Clusters[1]); cell != "v1 (no v2)" {
+		t.Errorf("describeCRDPresence() = %q, want %q", cell, "v1 (no v2)")
+	}
+	if cell := describeCRDPresence(got[1].Clusters[1]); cell != "v1 (not established)" {
+		t.Errorf("describeCRDPresence() = %q, want %q", cell, "v1 (not established)")
+	}
+
+	// With cluster3 as the baseline the CRDs only other clusters have do not count
+	got = compareCRDs(compared, crds, "cluster3")
+	if len(got) != 1 || got[0].Name != "certificates.cert-manager.io" || got[0].Status != crdStatusOK {
+		t.Errorf("compareCRDs() from cluster3 = %+v, want only the consistent certificates CRD", got)
+	}
+	got = compareCRDs(compared, crds, "cluster1")
+	if len(got) != 2 || got[1].Status != crdStatusMissing {
+		t.Errorf("compareCRDs() from cluster1 = %+v, want widgets missing", got)
+	}
+	wantSummary := "Warning: CRDs or versions of cluster1 are missing: cluster2 lacks 1, cluster3 lacks 1"
+	if summary := crdComparisonSummary(got, "cluster1"); summary != wantSummary {
+		t.Errorf("crdComparisonSummary() = %q, want %q", summary, wantSummary)
+	}
+}
+
+func TestValidateCRDCompare(t *testing.T) {
+	if err := validateCRDCompare("crds", getOptions{CRDRequiredFrom: "cluster1"}); err == nil {
+		t.Error("validateCRDCompare() of --required-from without --compare did not fail")
+	}
+	if err := validateCRDCompare("pods", getOptions{CRDCompare: true}); err == nil {
+		t.Error("validateCRDCompare() of pods did not fail")
+	}
+	if err := validateCRDCompare("crd", getOptions{CRDCompare: true, OutputFormat: "wide"}); err == nil {
+		t.Error("validateCRDCompare(-o wide) did not fail")
+	}
+	if err := validateCRDCompare("crds", getOptions{CRDCompare: true, OnlyDifferences: true}); err == nil {
+		t.Error("validateCRDCompare() with --only-differences did not fail")
+	}
+	if err := validateCRDCompare("customresourcedefinitions", getOptions{CRDCompare: true, CRDRequiredFrom: "cluster1", OutputFormat: "yaml"}); err != nil {
+		t.Errorf("validateCRDCompare() error = %v", err)
+	}
+}
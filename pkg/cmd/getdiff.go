@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+// How an object in one cluster departs from the rest of the fleet
+const (
+	differenceMissing   = "Missing"
+	differenceDiffers   = "Differs"
+	differenceUnhealthy = "Unhealthy"
+)
+
+// maxDifferencePaths is how many differing fields the table names per row
+const maxDifferencePaths = 3
+
+// objectDifference is an object that is missing, unhealthy or divergent in
+// one cluster. Diffs compare the cluster (target) with the baseline (source).
+type objectDifference struct {
+	Cluster    string      `json:"cluster"`
+	Resource   string      `json:"resource"`
+	Namespace  string      `json:"namespace,omitempty"`
+	Name       string      `json:"name"`
+	Difference string      `json:"difference"`
+	Baseline   string      `json:"baseline,omitempty"`
+	Detail     string      `json:"detail,omitempty"`
+	Diffs      []fieldDiff `json:"diffs,omitempty"`
+}
+
+// validateOnlyDifferences checks that --only-differences has one resource
+// type narrowed by a name or selector, and an output it can print
+func validateOnlyDifferences(resourceType string, q tableQuery) error {
+	if !q.Options.OnlyDifferences {
+		return nil
+	}
+	if resourceType == "all" {
+		return fmt.Errorf("--only-differences compares one resource type at a time")
+	}
+	if q.Name == "" && q.Selector == "" {
+		return fmt.Errorf("--only-differences needs a resource name or a -l selector")
+	}
+	switch q.Options.OutputFormat {
+	case "", "wide", "json", "yaml", util.TableFormatCSV, util.TableFormatMarkdown:
+		return nil
+	}
+	return fmt.Errorf("--only-differences supports -o json|yaml|wide|csv|markdown")
+}
+
+// handleOnlyDifferences lists the objects from every cluster and prints only
+// the clusters where an object departs from the baseline: the content most
+// clusters share. The cluster of skipContext, the ITS for workloads, is
+// left out.
+func handleOnlyDifferences(clusters []cluster.ClusterInfo, resourceType string, q tableQuery, skipContext string) error {
+	var compared []string
+	objects := map[string]map[string]*unstructured.Unstructured{}
+	resources := map[string]string{}
+	for _, clusterInfo := range clusters {
+		if clusterInfo.Context == skipContext || clusterInfo.DynamicClient == nil || clusterInfo.DiscoveryClient == nil {
+			continue
+		}
+		gvr, isNamespaced, err := util.DiscoverGVR(clusterInfo.DiscoveryClient, resourceType)
+		if err != nil {
+			noteClusterIssue(clusterInfo.Name, fmt.Sprintf("failed to discover resource %s: %v", resourceType, err))
+			continue
+		}
+		opts := metav1.ListOptions{LabelSelector: q.Selector}
+		list := func(ns string) (*unstructured.UnstructuredList, error) {
+			if !isNamespaced {
+				return clusterInfo.DynamicClient.Resource(gvr).List(commandContext(), opts)
+			}
+			return clusterInfo.DynamicClient.Resource(gvr).Namespace(ns).List(commandContext(), opts)
+		}
+		var items *unstructured.UnstructuredList
+		switch {
+		case !isNamespaced:
+			items, err = list("")
+		case q.AllNamespaces:
+			items, err = listNamespaced(clusterInfo, gvr.Group, gvr.Resource, "", list)
+		default:
+			items, err = list(cluster.GetTargetNamespace(q.Namespace))
+		}
+		if err != nil {
+			noteClusterIssue(clusterInfo.Name, fmt.Sprintf("failed to list %s: %v", resourceType, err))
+			continue
+		}
+
+		compared = append(compared, clusterInfo.Name)
+		for i := range items.Items {
+			item := &items.Items[i]
+			if q.Name != "" && item.GetName() != q.Name {
+				continue
+			}
+			if q.Options.ManagedOnly && !util.IsManaged(item) {
+				continue
+			}
+			key := item.GetNamespace() + "/" + item.GetName()
+			if objects[key] == nil {
+				objects[key] = map[string]*unstructured.Unstructured{}
+			}
+			objects[key][clusterInfo.Name] = item
+			resources[key] = gvr.Resource
+		}
+	}
+
+	var differences []objectDifference
+	consistent := 0
+	for _, key := range sortedKeys(objects) {
+		ns, name, _ := strings.Cut(key, "/")
+		found := findDifferences(resources[key], ns, name, compared, objects[key])
+		if len(found) == 0 {
+			consistent++
+		}
+		differences = append(differences, found...)
+	}
+
+	if consistent > 0 {
+		defer fmt.Fprintf(os.Stderr, "%d object(s) consistent across %d cluster(s)\n", consistent, len(compared))
+	}
+	switch q.Options.OutputFormat {
+	case "json", "yaml":
+		if differences == nil {
+			differences = []objectDifference{}
+		}
+		return util.PrintStructured(util.GetOutputStream(), q.Options.OutputFormat, differences)
+	}
+	if len(differences) == 0 {
+		if len(objects) == 0 {
+			fmt.Fprintln(os.Stderr, "No resources found.")
+		} else {
+			fmt.Fprintf(os.Stderr, "No differences across %d cluster(s).\n", len(compared))
+		}
+		return nil
+	}
+	printObjectDifferences(differences, q.Options.OutputFormat)
+	return nil
+}
+
+// findDifferences compares the copies of one object, keyed by cluster, across
+// the compared clusters. The baseline is the content most clusters share,
+// the first of them on a tie; its cluster is reported as the reference.
+func findDifferences(resource, namespace, name string, compared []string, copies map[string]*unstructured.Unstructured) []objectDifference {
+	fields := map[string]map[string]string{}
+	fingerprints := map[string]string{}
+	counts := map[string]int{}
+	for _, c := range compared {
+		obj, ok := copies[c]
+		if !ok {
+			continue
+		}
+		flat := map[string]string{}
+		flattenFields("", exportObject(obj).Object, flat)
+		// Maps marshal with sorted keys, so equal content has equal JSON
+		data, _ := json.Marshal(flat)
+		fields[c], fingerprints[c] = flat, string(data)
+		counts[string(data)]++
+	}
+	baseline := ""
+	for _, c := range compared {
+		if fp, ok := fingerprints[c]; ok && (baseline == "" || counts[fp] > counts[fingerprints[baseline]]) {
+			baseline = c
+		}
+	}
+
+	var differences []objectDifference
+	for _, c := range compared {
+		obj, ok := copies[c]
+		d := objectDifference{Cluster: c, Resource: resource, Namespace: namespace, Name: name}
+		if !ok {
+			d.Difference = differenceMissing
+			d.Detail = fmt.Sprintf("present in %d of %d cluster(s)", len(fingerprints), len(compared))
+			differences = append(differences, d)
+			continue
+		}
+
+		var details []string
+		if fingerprints[c] != fingerprints[baseline] {
+			d.Difference, d.Baseline = differenceDiffers, baseline
+			d.Diffs = diffFields(fields[baseline], fields[c])
+			if obj.GetKind() == "Secret" {
+				redactSecretDiffs(d.Diffs)
+			}
+			details = append(details, describeDiffPaths(d.Diffs, baseline))
+		}
+		if ready, reason := workloadReady(obj); !ready {
+			if d.Difference == "" {
+				d.Difference = differenceUnhealthy
+			}
+			details = append(details, reason)
+		}
+		if d.Difference == "" {
+			continue
+		}
+		d.Detail = strings.Join(details, "; ")
+		differences = append(differences, d)
+	}
+	return differences
+}
+
+// redactSecretDiffs hides the values of differing secret data, keeping
+// whether a key is present
+func redactSecretDiffs(diffs []fieldDiff) {
+	for i := range diffs {
+		if !strings.HasPrefix(diffs[i].Path, "data") && !strings.HasPrefix(diffs[i].Path, "stringData") {
+			continue
+		}
+		if diffs[i].Source != "" {
+			diffs[i].Source = "<redacted>"
+		}
+		if diffs[i].Target != "" {
+			diffs[i].Target = "<redacted>"
+		}
+	}
+}
+
+// describeDiffPaths names the first differing fields, e.g.
+// "spec.replicas, metadata.labels.tier differ from cluster1"
+func describeDiffPaths(diffs []fieldDiff, baseline string) string {
+	var paths []string
+	for i, d := range diffs {
+		if i == maxDifferencePaths {
+			paths = append(paths, fmt.Sprintf("%d more", len(diffs)-maxDifferencePaths))
+			break
+		}
+		paths = append(paths, d.Path)
+	}
+	return strings.Join(paths, ", ") + " differ from " + baseline
+}
+
+func printObjectDifferences(differences []objectDifference, outputFormat string) {
+	tw := util.NewTableWriter(util.GetOutputStream(), outputFormat)
+	defer tw.Flush()
+
+	fmt.Fprintln(tw, "CLUSTER\tRESOURCE\tNAMESPACE\tNAME\tDIFFERENCE\tDETAIL")
+	for _, d := range differences {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", d.Cluster, d.Resource, noneIfEmpty(d.Namespace), d.Name, d.Difference, orNone(d.Detail))
+	}
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func testDiffDeployment(replicas, ready int64, image string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"namespace": "prod", "name": "web", "uid": "uid-" + image},
+		"spec": map[string]interface{}{
+			"replicas": replicas,
+			"template": map[string]interface{}{"spec": map[string]interface{}{"containers": []interface{}{
+				map[string]interface{}{"name": "web", "image": image},
+			}}},
+		},
+		"status": map[string]interface{}{"updatedReplicas": replicas, "availableReplicas": ready},
+	}}
+}
+
+func TestFindDifferences(t *testing.T) {
+	compared := []string{"cluster1", "cluster2", "cluster3", "cluster4"}
+	copies := map[string]*unstructured.Unstructured{
+		"cluster1": testDiffDeployment(2, 2, "web:1"),
+		"cluster2": testDiffDeployment(2, 2, "web:2"),
+		"cluster3": testDiffDeployment(2, 1, "web:1"),
+	}
+	want := []objectDifference{
+		{
+			Cluster: "cluster2", Resource: "deployments", Namespace: "prod", Name: "web",
+			Difference: differenceDiffers, Baseline: "cluster1",
+			Detail: "spec.template.spec.containers[0].image differ from cluster1",
+			Diffs:  []fieldDiff{{Path: "spec.template.spec.containers[0].image", Source: `"web:1"`, Target: `"web:2"`}},
+		},
+		{Cluster: "cluster3", Resource: "deployments", Namespace: "prod", Name: "web", Difference: differenceUnhealthy, Detail: "1 of 2 replicas ready"},
+		{Cluster: "cluster4", Resource: "deployments", Namespace: "prod", Name: "web", Difference: differenceMissing, Detail: "present in 3 of 4 cluster(s)"},
+	}
+	got := findDifferences("deployments", "prod", "web", compared, copies)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findDifferences() = %+v, want %+v", got, want)
+	}
+
+	copies["cluster4"] = testDiffDeployment(2, 2, "web:1")
+	copies["cluster2"] = testDiffDeployment(2, 2, "web:1")
+	copies["cluster3"] = testDiffDeployment(2, 2, "web:1")
+	if got := findDifferences("deployments", "prod", "web", compared, copies); len(got) != 0 {
+		t.Errorf("findDifferences() of identical copies = %+v, want none", got)
+	}
+}
+
+func TestFindDifferencesRedactsSecrets(t *testing.T) {
+	secret := func(value string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1", "kind": "Secret",
+			"metadata": map[string]interface{}{"namespace": "prod", "name": "db"},
+			"data":     map[string]interface{}{"password": value},
+		}}
+	}
+	copies := map[string]*unstructured.Unstructured{"cluster1": secret("czNjcjN0"), "cluster2": secret("b3RoZXI=")}
+	got := findDifferences("secrets", "prod", "db", []string{"cluster1", "cluster2"}, copies)
+	want := []fieldDiff{{Path: "data.password", Source: "<redacted>", Target: "<redacted>"}}
+	if len(got) != 1 || !reflect.DeepEqual(got[0].Diffs, want) {
+		t.Errorf("findDifferences() = %+v, want the diffs %+v", got, want)
+	}
+}
+
+func TestValidateOnlyDifferences(t *testing.T) {
+	o := getOptions{OnlyDifferences: true}
+	if err := validateOnlyDifferences("deployments", tableQuery{Options: o}); err == nil {
+		t.Error("validateOnlyDifferences() without a name or selector did not fail")
+	}
+	o.OutputFormat = "name"
+	if err := validateOnlyDifferences("deployments", tableQuery{Selector: "app=web", Options: o}); err == nil {
+		t.Error("validateOnlyDifferences(-o name) did not fail")
+	}
+	o.OutputFormat = "json"
+	if err := validateOnlyDifferences("deployments", tableQuery{Name: "web", Options: o}); err != nil {
+		t.Errorf("validateOnlyDifferences() error = %v", err)
+	}
+}
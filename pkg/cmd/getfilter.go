@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// stateFilter holds the filters of get on the state of jobs, pods and
+// deployments. They are applied to the listed objects of every cluster,
+// after the label selector.
+type stateFilter struct {
+	// Failed, Succeeded and Active keep the jobs in any of the chosen states
+	Failed    bool
+	Succeeded bool
+	Active    bool
+	// PodStatus keeps the pods in one of these comma-separated phases
+	PodStatus string
+	// Ready is "true" or "false" to keep the deployments that are or are not
+	// fully ready, or empty for all of them
+	Ready string
+}
+
+func (f *stateFilter) addFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&f.Failed, "failed", false, "with jobs, only list failed jobs")
+	cmd.Flags().BoolVar(&f.Succeeded, "succeeded", false, "with jobs, only list jobs that completed")
+	cmd.Flags().BoolVar(&f.Active, "active", false, "with jobs, only list jobs that have not finished")
+	cmd.Flags().StringVar(&f.PodStatus, "status", "", "with pods, only list pods in these comma-separated phases (Running, Pending, Succeeded, Failed, Unknown)")
+	cmd.Flags().StringVar(&f.Ready, "ready", "", "with deployments, only list deployments whose replicas are all ready (true) or not (false)")
+	cmd.Flags().Lookup("ready").NoOptDefVal = "true"
+}
+
+// any reports whether any of the filters is set
+func (f stateFilter) any() bool {
+	return f.jobStates() || f.PodStatus != "" || f.Ready != ""
+}
+
+func (f stateFilter) jobStates() bool {
+	return f.Failed || f.Succeeded || f.Active
+}
+
+// validate checks that the filters apply to the resource type and
+// normalizes their values
+func (f *stateFilter) validate(resourceType string) error {
+	if f.jobStates() && !isResourceType(resourceType, "jobs", "job") {
+		return fmt.Errorf("--failed, --succeeded and --active only apply to jobs")
+	}
+	if f.PodStatus != "" {
+		if !isResourceType(resourceType, "pods", "pod", "po") {
+			return fmt.Errorf("--status only applies to pods")
+		}
+		var phases []string
+		for _, phase := range strings.Split(f.PodStatus, ",") {
+			canonical, ok := podPhase(strings.TrimSpace(phase))
+			if !ok {
+				return fmt.Errorf("unknown pod status %q, must be one of Running|Pending|Succeeded|Failed|Unknown", phase)
+			}
+			phases = append(phases, canonical)
+		}
+		f.PodStatus = strings.Join(phases, ",")
+	}
+	if f.Ready != "" {
+		if !isResourceType(resourceType, "deployments", "deployment", "deploy") {
+			return fmt.Errorf("--ready only applies to deployments")
+		}
+		switch strings.ToLower(f.Ready) {
+		case "true", "false":
+			f.Ready = strings.ToLower(f.Ready)
+		default:
+			return fmt.Errorf("--ready must be true or false, got %q", f.Ready)
+		}
+	}
+	return nil
+}
+
+func isResourceType(resourceType string, names ...string) bool {
+	for _, name := range names {
+		if resourceType == name {
+			return true
+		}
+	}
+	return false
+}
+
+// podPhase matches a phase name regardless of case
+func podPhase(name string) (string, bool) {
+	for _, phase := range []corev1.PodPhase{corev1.PodRunning, corev1.PodPending, corev1.PodSucceeded, corev1.PodFailed, corev1.PodUnknown} {
+		if strings.EqualFold(name, string(phase)) {
+			return string(phase), true
+		}
+	}
+	return "", false
+}
+
+// keepJob reports whether a job is in one of the chosen states
+func (f stateFilter) keepJob(job *batchv1.Job) bool {
+	if !f.jobStates() {
+		return true
+	}
+	failed, complete := jobFinished(job)
+	return (f.Failed && failed) || (f.Succeeded && complete) || (f.Active && !failed && !complete)
+}
+
+// jobFinished reports whether the Failed or Complete condition of a job is true
+func jobFinished(job *batchv1.Job) (failed, complete bool) {
+	for _, c := range job.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobFailed:
+			failed = true
+		case batchv1.JobComplete:
+			complete = true
+		}
+	}
+	return failed, complete
+}
+
+// keepPod reports whether a pod is in one of the chosen phases
+func (f stateFilter) keepPod(pod *corev1.Pod) bool {
+	if f.PodStatus == "" {
+		return true
+	}
+	for _, phase := range strings.Split(f.PodStatus, ",") {
+		if string(pod.Status.Phase) == phase {
+			return true
+		}
+	}
+	return false
+}
+
+// keepDeployment reports whether a deployment is as ready as chosen. A
+// deployment is ready when its ready, up-to-date and available replicas all
+// reach the desired count.
+func (f stateFilter) keepDeployment(deploy *appsv1.Deployment) bool {
+	if f.Ready == "" {
+		return true
+	}
+	var replicas int32
+	if deploy.Spec.Replicas != nil {
+		replicas = *deploy.Spec.Replicas
+	}
+	ready := deploy.Status.ReadyReplicas >= replicas && deploy.Status.UpdatedReplicas >= replicas && deploy.Status.AvailableReplicas >= replicas
+	return ready == (f.Ready == "true")
+}
+
+// keepObject applies the filter to an object listed with the dynamic
+// client, for the structured output formats
+func (f stateFilter) keepObject(gvr schema.GroupVersionResource, item *unstructured.Unstructured) bool {
+	switch {
+	case gvr.Group == "batch" && gvr.Resource == "jobs" && f.jobStates():
+		var job batchv1.Job
+		return runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &job) == nil && f.keepJob(&job)
+	case gvr.Group == "" && gvr.Resource == "pods" && f.PodStatus != "":
+		var pod corev1.Pod
+		return runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &pod) == nil && f.keepPod(&pod)
+	case gvr.Group == "apps" && gvr.Resource == "deployments" && f.Ready != "":
+		var deploy appsv1.Deployment
+		return runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &deploy) == nil && f.keepDeployment(&deploy)
+	}
+	return true
+}
+
+// keepItems returns the items keep accepts
+func keepItems[T any](items []T, keep func(*T) bool) []T {
+	var kept []T
+	for i := range items {
+		if keep(&items[i]) {
+			kept = append(kept, items[i])
+		}
+	}
+	return kept
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestStateFilterValidate(t *testing.T) {
+	tests := []struct {
+		name         string
+		filter       stateFilter
+		resourceType string
+		want         stateFilter
+		wantErr      bool
+	}{
+		{name: "job states", filter: stateFilter{Failed: true, Active: true}, resourceType: "jobs", want: stateFilter{Failed: true, Active: true}},
+		{name: "job states on pods", filter: stateFilter{Failed: true}, resourceType: "pods", wantErr: true},
+		{name: "pod phases", filter: stateFilter{PodStatus: "running, pending"}, resourceType: "po", want: stateFilter{PodStatus: "Running,Pending"}},
+		{name: "unknown pod phase", filter: stateFilter{PodStatus: "CrashLoopBackOff"}, resourceType: "pods", wantErr: true},
+		{name: "status on jobs", filter: stateFilter{PodStatus: "Running"}, resourceType: "jobs", wantErr: true},
+		{name: "ready", filter: stateFilter{Ready: "False"}, resourceType: "deploy", want: stateFilter{Ready: "false"}},
+		{name: "ready on statefulsets", filter: stateFilter{Ready: "true"}, resourceType: "statefulsets", wantErr: true},
+		{name: "invalid ready", filter: stateFilter{Ready: "maybe"}, resourceType: "deployments", wantErr: true},
+		{name: "no filters", resourceType: "all"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := tt.filter
+			err := f.validate(tt.resourceType)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && f != tt.want {
+				t.Errorf("validate() normalized to %+v, want %+v", f, tt.want)
+			}
+		})
+	}
+}
+
+func testJob(name string, conditions ...batchv1.JobConditionType) batchv1.Job {
+	job := batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	for _, c := range conditions {
+		job.Status.Conditions = append(job.Status.Conditions, batchv1.JobCondition{Type: c, Status: corev1.ConditionTrue})
+	}
+	return job
+}
+
+func TestStateFilterKeep(t *testing.T) {
+	jobs := []batchv1.Job{
+		testJob("done", batchv1.JobComplete),
+		testJob("broken", batchv1.JobFailed),
+		testJob("running"),
+	}
+	jobNames := func(f stateFilter) []string {
+		var names []string
+		for _, job := range keepItems(jobs, f.keepJob) {
+			names = append(names, job.Name)
+		}
+		return names
+	}
+	if got := jobNames(stateFilter{Failed: true}); len(got) != 1 || got[0] != "broken" {
+		t.Errorf("--failed kept %v, want [broken]", got)
+	}
+	if got := jobNames(stateFilter{Succeeded: true, Active: true}); len(got) != 2 || got[0] != "done" || got[1] != "running" {
+		t.Errorf("--succeeded --active kept %v, want [done running]", got)
+	}
+	if got := jobNames(stateFilter{}); len(got) != 3 {
+		t.Errorf("no filter kept %v, want all jobs", got)
+	}
+
+	pod := corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodPending}}
+	if !(stateFilter{PodStatus: "Running,Pending"}).keepPod(&pod) || (stateFilter{PodStatus: "Running"}).keepPod(&pod) {
+		t.Errorf("keepPod() did not match the pod phase")
+	}
+
+	replicas := int32(3)
+	deploy := appsv1.Deployment{
+		Spec:   appsv1.DeploymentSpec{Replicas: &replicas},
+		Status: appsv1.DeploymentStatus{ReadyReplicas: 3, UpdatedReplicas: 2, AvailableReplicas: 3},
+	}
+	if (stateFilter{Ready: "true"}).keepDeployment(&deploy) || !(stateFilter{Ready: "false"}).keepDeployment(&deploy) {
+		t.Errorf("deployment with an outdated replica counted as ready")
+	}
+
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&deploy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gvr := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	if (stateFilter{Ready: "true"}).keepObject(gvr, &unstructured.Unstructured{Object: obj}) {
+		t.Errorf("keepObject() kept a deployment that is not ready")
+	}
+}
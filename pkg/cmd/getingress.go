@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"kubectl-multi/pkg/cluster"
+)
+
+// ingressClassAnnotation is the class of ingresses predating
+// spec.ingressClassName
+const ingressClassAnnotation = "kubernetes.io/ingress.class"
+
+func isIngressResource(resourceType string) bool {
+	switch resourceType {
+	case "ingresses", "ingress", "ing":
+		return true
+	}
+	return false
+}
+
+// validateHostnamesOnly checks that --hostnames-only is used with ingresses
+// and no other output
+func validateHostnamesOnly(resourceType string, o getOptions) error {
+	if !o.HostnamesOnly {
+		return nil
+	}
+	if !isIngressResource(resourceType) {
+		return fmt.Errorf("--hostnames-only only applies to ingresses")
+	}
+	if o.OutputFormat != "" {
+		return fmt.Errorf("--hostnames-only cannot be combined with -o")
+	}
+	if o.Poll > 0 || o.OnlyDifferences {
+		return fmt.Errorf("--hostnames-only cannot be combined with --poll or --only-differences")
+	}
+	return nil
+}
+
+// ingressTable lists ingresses with the ports their backends route to,
+// resolving named service ports against the services of each cluster
+func ingressTable() resourceTable[*networkingv1.Ingress] {
+	var ports func(namespace, service, port string) string
+	return resourceTable[*networkingv1.Ingress]{
+		Group: "networking.k8s.io", Resource: "ingresses", Namespaced: true,
+		List: func(c cluster.ClusterInfo, ns string, opts metav1.ListOptions) (runtime.Object, error) {
+			return c.Client.NetworkingV1().Ingresses(ns).List(commandContext(), opts)
+		},
+		ForCluster: func(c cluster.ClusterInfo, _ []*networkingv1.Ingress) {
+			ports = newServicePortResolver(c)
+		},
+		Columns: []tableColumn[*networkingv1.Ingress]{
+			nameColumn[*networkingv1.Ingress](),
+			{Name: "INGRESSCLASS", Value: ingressClass},
+			{Name: "HOSTS", Value: func(ing *networkingv1.Ingress) string {
+				return noneIfEmpty(strings.Join(ingressRuleHosts(ing), ","))
+			}},
+			{Name: "ADDRESS", Value: ingressAddress},
+			{Name: "PORTS", Value: func(ing *networkingv1.Ingress) string {
+				return noneIfEmpty(strings.Join(ingressBackendPorts(ing, ports), ","))
+			}},
+			{Name: "TLS", Value: func(ing *networkingv1.Ingress) string {
+				return noneIfEmpty(strings.Join(ingressTLSSecrets(ing), ","))
+			}},
+			ageColumn[*networkingv1.Ingress](),
+		},
+	}
+}
+
+// handleIngressHostnames prints every hostname the ingresses of the
+// clusters route or terminate TLS for, once, in sorted order
+func handleIngressHostnames(out io.Writer, clusters []cluster.ClusterInfo, q tableQuery) int {
+	seen := map[string]bool{}
+	for _, clusterInfo := range clusters {
+		if clusterInfo.Client == nil {
+			continue
+		}
+		ingresses, _ := listIngresses(clusterInfo, q)
+		for i := range ingresses {
+			hosts := ingressRuleHosts(&ingresses[i])
+			for _, tls := range ingresses[i].Spec.TLS {
+				hosts = append(hosts, tls.Hosts...)
+			}
+			for _, host := range hosts {
+				seen[strings.ToLower(host)] = true
+			}
+		}
+	}
+	hosts := sortedKeys(seen)
+	for _, host := range hosts {
+		fmt.Fprintln(out, host)
+	}
+	if len(hosts) == 0 {
+		reportNoResources(q.Namespace, q.AllNamespaces)
+	}
+	return len(hosts)
+}
+
+// listIngresses lists the ingresses of one cluster, noting a failure as a
+// cluster issue
+func listIngresses(clusterInfo cluster.ClusterInfo, q tableQuery) ([]networkingv1.Ingress, bool) {
+	targetNS := cluster.GetTargetNamespace(q.Namespace)
+	if q.AllNamespaces {
+		targetNS = ""
+	}
+	ingresses, err := listNamespaced(clusterInfo, "networking.k8s.io", "ingresses", targetNS, func(ns string) (*networkingv1.IngressList, error) {
+		return clusterInfo.Client.NetworkingV1().Ingresses(ns).List(commandContext(), metav1.ListOptions{
+			LabelSelector: q.Selector,
+		})
+	})
+	if err != nil {
+		noteClusterIssue(clusterInfo.Name, fmt.Sprintf("failed to list ingresses: %v", err))
+		return nil, false
+	}
+	return itemsNamed(ingresses.Items, q.Name, q.Options.ManagedOnly), true
+}
+
+// ingressClass returns spec.ingressClassName, or the legacy class
+// annotation of older ingresses
+func ingressClass(ing *networkingv1.Ingress) string {
+	if ing.Spec.IngressClassName != nil && *ing.Spec.IngressClassName != "" {
+		return *ing.Spec.IngressClassName
+	}
+	return noneIfEmpty(ing.Annotations[ingressClassAnnotation])
+}
+
+func ingressRuleHosts(ing *networkingv1.Ingress) []string {
+	var hosts []string
+	for _, rule := range ing.Spec.Rules {
+		if rule.Host != "" {
+			hosts = append(hosts, rule.Host)
+		}
+	}
+	return hosts
+}
+
+func ingressAddress(ing *networkingv1.Ingress) string {
+	var addrs []string
+	for _, lb := range ing.Status.LoadBalancer.Ingress {
+		if lb.IP != "" {
+			addrs = append(addrs, lb.IP)
+		} else if lb.Hostname != "" {
+			addrs = append(addrs, lb.Hostname)
+		}
+	}
+	return noneIfEmpty(strings.Join(addrs, ","))
+}
+
+// ingressTLSSecrets returns the distinct secrets holding the certificates
+// of the ingress
+func ingressTLSSecrets(ing *networkingv1.Ingress) []string {
+	var secrets []string
+	for _, tls := range ing.Spec.TLS {
+		if tls.SecretName != "" && !containsString(secrets, tls.SecretName) {
+			secrets = append(secrets, tls.SecretName)
+		}
+	}
+	return secrets
+}
+
+// ingressBackendPorts returns the distinct service ports the default backend
+// and the paths of the ingress send traffic to, numerically sorted. Named
+// ports are resolved through resolve.
+func ingressBackendPorts(ing *networkingv1.Ingress, resolve func(namespace, service, port string) string) []string {
+	backends := []*networkingv1.IngressBackend{ing.Spec.DefaultBackend}
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for i := range rule.HTTP.Paths {
+			backends = append(backends, &rule.HTTP.Paths[i].Backend)
+		}
+	}
+	seen := map[string]bool{}
+	for _, b := range backends {
+		if b == nil || b.Service == nil {
+			continue
+		}
+		port := b.Service.Port.Name
+		if b.Service.Port.Number != 0 {
+			port = strconv.Itoa(int(b.Service.Port.Number))
+		} else if port != "" {
+			port = resolve(ing.Namespace, b.Service.Name, port)
+		}
+		if port != "" {
+			seen[port] = true
+		}
+	}
+	ports := sortedKeys(seen)
+	sort.SliceStable(ports, func(i, j int) bool {
+		pi, errI := strconv.Atoi(ports[i])
+		pj, errJ := strconv.Atoi(ports[j])
+		if errI != nil || errJ != nil {
+			// Unresolved names follow the numbers
+			return errI == nil && errJ != nil
+		}
+		return pi < pj
+	})
+	return ports
+}
+
+// newServicePortResolver resolves a named service port to its number by
+// reading the Service once, falling back to the name when it cannot be read
+// or has no such port
+func newServicePortResolver(clusterInfo cluster.ClusterInfo) func(namespace, service, port string) string {
+	type key struct{ namespace, service string }
+	ports := map[key]map[string]int32{}
+	return func(namespace, service, port string) string {
+		k := key{namespace, service}
+		named, ok := ports[k]
+		if !ok {
+			named = map[string]int32{}
+			if svc, err := clusterInfo.Client.CoreV1().Services(namespace).Get(commandContext(), service, metav1.GetOptions{}); err == nil {
+				for _, p := range svc.Spec.Ports {
+					named[p.Name] = p.Port
+				}
+			}
+			ports[k] = named
+		}
+		if number, ok := named[port]; ok {
+			return strconv.Itoa(int(number))
+		}
+		return port
+	}
+}
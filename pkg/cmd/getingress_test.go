@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"text/tabwriter"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"kubectl-multi/pkg/cluster"
+)
+
+func testIngressBackend(service string, port networkingv1.ServiceBackendPort) networkingv1.IngressBackend {
+	return networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{Name: service, Port: port}}
+}
+
+func testIngress(name, host string, backends ...networkingv1.IngressBackend) *networkingv1.Ingress {
+	var paths []networkingv1.HTTPIngressPath
+	for _, b := range backends {
+		paths = append(paths, networkingv1.HTTPIngressPath{Path: "/", Backend: b})
+	}
+	return &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		Spec: networkingv1.IngressSpec{Rules: []networkingv1.IngressRule{{
+			Host:             host,
+			IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{Paths: paths}},
+		}}},
+	}
+}
+
+func TestHandleIngressesGet(t *testing.T) {
+	nginx := "nginx"
+	web := testIngress("web", "web.example.com",
+		testIngressBackend("web", networkingv1.ServiceBackendPort{Number: 8080}),
+		testIngressBackend("api", networkingv1.ServiceBackendPort{Name: "grpc"}),
+		testIngressBackend("admin", networkingv1.ServiceBackendPort{Name: "http"}),
+	)
+	web.Spec.IngressClassName = &nginx
+	web.Spec.DefaultBackend = &networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{Name: "web", Port: networkingv1.ServiceBackendPort{Number: 80}}}
+	web.Spec.TLS = []networkingv1.IngressTLS{{Hosts: []string{"web.example.com"}, SecretName: "web-tls"}, {SecretName: "web-tls"}}
+	legacy := testIngress("legacy", "")
+	legacy.Annotations = map[string]string{ingressClassAnnotation: "traefik"}
+	api := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "api"},
+		Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "grpc", Port: 9090}}},
+	}
+	clusterInfo := cluster.ClusterInfo{Name: "cluster1", Client: fake.NewSimpleClientset(web, legacy, api)}
+
+	var out bytes.Buffer
+	tw := tabwriter.NewWriter(&out, 0, 0, 2, ' ', 0)
+	rows, err := printResourceTable(tw, []cluster.ClusterInfo{clusterInfo}, ingressTable(), tableQuery{Namespace: "default"})
+	tw.Flush()
+	if err != nil || rows != 2 {
+		t.Fatalf("printResourceTable() = %d, %v", rows, err)
+	}
+	var got [][]string
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		fields := strings.Fields(line)
+		got = append(got, fields[:len(fields)-1])
+	}
+	want := [][]string{
+		{"CLUSTER", "NAME", "INGRESSCLASS", "HOSTS", "ADDRESS", "PORTS", "TLS"},
+		{"cluster1", "legacy", "traefik", "<none>", "<none>", "<none>", "<none>"},
+		// The admin Service does not exist, so its port stays named
+		{"cluster1", "web", "nginx", "web.example.com", "<none>", "80,8080,9090,http", "web-tls"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("printResourceTable() printed\n%s\nwant %q", out.String(), want)
+	}
+}
+
+func TestHandleIngressHostnames(t *testing.T) {
+	withTLS := testIngress("shop", "shop.example.com")
+	withTLS.Spec.TLS = []networkingv1.IngressTLS{{Hosts: []string{"www.example.com"}}}
+	cluster1 := cluster.ClusterInfo{Name: "cluster1", Client: fake.NewSimpleClientset(testIngress("web", "web.example.com"), withTLS)}
+	cluster2 := cluster.ClusterInfo{Name: "cluster2", Client: fake.NewSimpleClientset(testIngress("web", "Web.example.com"))}
+
+	var out bytes.Buffer
+	if found := handleIngressHostnames(&out, []cluster.ClusterInfo{cluster1, cluster2}, tableQuery{AllNamespaces: true}); found != 3 {
+		t.Errorf("handleIngressHostnames() = %d hostnames, want 3", found)
+	}
+	if want := "shop.example.com\nweb.example.com\nwww.example.com\n"; out.String() != want {
+		t.Errorf("handleIngressHostnames() printed %q, want %q", out.String(), want)
+	}
+}
+
+func TestValidateHostnamesOnly(t *testing.T) {
+	o := getOptions{HostnamesOnly: true}
+	if err := validateHostnamesOnly("ing", o); err != nil {
+		t.Errorf("validateHostnamesOnly(ing) error = %v", err)
+	}
+	if err := validateHostnamesOnly("services", o); err == nil {
+		t.Error("validateHostnamesOnly(services) succeeded")
+	}
+	if err := validateHostnamesOnly("ingresses", getOptions{HostnamesOnly: true, OutputFormat: "json"}); err == nil {
+		t.Error("validateHostnamesOnly() with -o json succeeded")
+	}
+}
@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+// ExitCodeEmpty is the exit status of get with --exit-zero-on-empty=false
+// when no resource matched in any cluster
+const ExitCodeEmpty = 3
+
+// clusterAnnotation records the source cluster on items of a structured get
+const clusterAnnotation = "kubectl-multi.kubestellar.io/cluster"
+
+// allGetResources are the resource types "get all" covers, in display order
+var allGetResources = []string{
+	"pods", "services", "deployments", "jobs", "cronjobs", "nodes", "replicasets", "daemonsets",
+	"namespaces", "configmaps", "statefulsets", "secrets", "persistentvolumes", "persistentvolumeclaims", "roles",
+}
+
+var errNoResources = errors.New("no resources found")
+
+// exitError carries a specific process exit status out of a command
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// ExitCode returns the process exit status for an error returned by Execute
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	return 1
+}
+
+// itemsNamed keeps the items called name, or all of them when name is
+// empty, so a table prints its header only when a matching row follows.
+// With managedOnly (--managed-only) it also drops the items kubectl-multi
+// did not write.
+func itemsNamed[T any, PT interface {
+	*T
+	GetName() string
+	GetAnnotations() map[string]string
+}](items []T, name string, managedOnly bool) []T {
+	if name == "" && !managedOnly {
+		return items
+	}
+	var named []T
+	for i := range items {
+		item := PT(&items[i])
+		if (name == "" || item.GetName() == name) && (!managedOnly || util.IsManaged(item)) {
+			named = append(named, items[i])
+		}
+	}
+	return named
+}
+
+// reportNoResources tells the user on stderr that nothing matched, leaving
+// stdout empty for scripts
+func reportNoResources(namespace string, allNamespaces bool) {
+	if allNamespaces {
+		fmt.Fprintln(os.Stderr, "No resources found.")
+		return
+	}
+	fmt.Fprintf(os.Stderr, "No resources found in %s namespace.\n", cluster.GetTargetNamespace(namespace))
+}
+
+// checkEmptyResult fails with ExitCodeEmpty when nothing was found and
+// --exit-zero-on-empty=false
+func checkEmptyResult(found, exitZeroOnEmpty bool) error {
+	if found || exitZeroOnEmpty {
+		return nil
+	}
+	return &exitError{code: ExitCodeEmpty, err: errNoResources}
+}
+
+// isStructuredGetFormat reports whether -o is served from the raw objects
+// instead of the per-resource tables
+func isStructuredGetFormat(format string) bool {
+	switch format {
+	case "json", "yaml", "name":
+		return true
+	}
+	return false
+}
+
+// handleStructuredGet prints the matching objects of every cluster as one
+// v1 List (json/yaml) or as "CLUSTER TYPE/NAME" lines (name). An empty
+// result is an empty List or no lines. It returns the number of objects.
+func handleStructuredGet(clusters []cluster.ClusterInfo, resourceType string, q tableQuery) (int, error) {
+	items, names := collectObjects(clusters, resourceType, q)
+
+	out := util.GetOutputStream()
+	outputFormat := q.Options.OutputFormat
+	if outputFormat == "name" {
+		for _, name := range names {
+			fmt.Fprintln(out, name)
+		}
+		return len(names), nil
+	}
+	return len(items), util.PrintStructured(out, outputFormat, objectList(items))
+}
+
+// objectList wraps the items in a v1 List
+func objectList(items []interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "List",
+		"metadata":   map[string]interface{}{"resourceVersion": ""},
+		"items":      items,
+	}
+}
+
+// collectObjects lists the matching objects of every cluster, each annotated
+// with its source cluster, together with their "CLUSTER TYPE/NAME" names.
+// Clusters that fail are noted as cluster issues.
+func collectObjects(clusters []cluster.ClusterInfo, resourceType string, q tableQuery) ([]interface{}, []string) {
+	resourceTypes := []string{resourceType}
+	if resourceType == "all" {
+		resourceTypes = allGetResources
+	}
+
+	items := []interface{}{}
+	var names []string
+	for _, clusterInfo := range clusters {
+		if clusterInfo.DynamicClient == nil || clusterInfo.DiscoveryClient == nil {
+			continue
+		}
+		for _, rt := range resourceTypes {
+			gvr, isNamespaced, err := util.DiscoverGVR(clusterInfo.DiscoveryClient, rt)
+			if err != nil {
+				noteClusterIssue(clusterInfo.Name, fmt.Sprintf("failed to discover resource %s: %v", rt, err))
+				continue
+			}
+
+			var list *unstructured.UnstructuredList
+			opts := metav1.ListOptions{LabelSelector: q.Selector}
+			if isNamespaced && !q.AllNamespaces {
+				list, err = clusterInfo.DynamicClient.Resource(gvr).Namespace(cluster.GetTargetNamespace(q.Namespace)).List(commandContext(), opts)
+			} else if isNamespaced {
+				list, err = listNamespaced(clusterInfo, gvr.Group, gvr.Resource, "", func(ns string) (*unstructured.UnstructuredList, error) {
+					return clusterInfo.DynamicClient.Resource(gvr).Namespace(ns).List(commandContext(), opts)
+				})
+			} else {
+				list, err = clusterInfo.DynamicClient.Resource(gvr).List(commandContext(), opts)
+			}
+			if err != nil {
+				noteClusterIssue(clusterInfo.Name, fmt.Sprintf("failed to list %s: %v", rt, err))
+				continue
+			}
+
+			for i := range list.Items {
+				item := &list.Items[i]
+				if q.Name != "" && item.GetName() != q.Name {
+					continue
+				}
+				if q.Options.ManagedOnly && !util.IsManaged(item) {
+					continue
+				}
+				if !q.Options.StateFilter.keepObject(gvr, item) {
+					continue
+				}
+				annotations := item.GetAnnotations()
+				if annotations == nil {
+					annotations = map[string]string{}
+				}
+				annotations[clusterAnnotation] = clusterInfo.Name
+				item.SetAnnotations(annotations)
+				items = append(items, item.Object)
+				names = append(names, clusterInfo.Name+" "+qualifiedName(gvr, item))
+			}
+		}
+	}
+	return items, names
+}
+
+// qualifiedName formats an object the way kubectl -o name does, e.g. deployment.apps/nginx
+func qualifiedName(gvr schema.GroupVersionResource, obj *unstructured.Unstructured) string {
+	kind := strings.ToLower(obj.GetKind())
+	if kind == "" {
+		kind = gvr.Resource
+	}
+	if gvr.Group != "" {
+		kind += "." + gvr.Group
+	}
+	return kind + "/" + obj.GetName()
+}
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"text/tabwriter"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/config"
+	"kubectl-multi/pkg/util"
+)
+
+func TestItemsNamed(t *testing.T) {
+	applied := map[string]string{util.AppliedByAnnotation: util.FieldManager}
+	pods := []corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Annotations: applied}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "web-2"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "other"}},
+	}
+	tests := []struct {
+		name        string
+		managedOnly bool
+		want        []string
+	}{
+		{name: "", want: []string{"web-1", "web-2", "web-1"}},
+		{name: "web-1", want: []string{"web-1", "web-1"}},
+		{name: "db", want: nil},
+		{name: "", managedOnly: true, want: []string{"web-1"}},
+		{name: "web-1", managedOnly: true, want: []string{"web-1"}},
+		{name: "web-2", managedOnly: true, want: nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, pod := range itemsNamed(pods, tt.name, tt.managedOnly) {
+			got = append(got, pod.Name)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("itemsNamed(%q) with managedOnly %v = %q, want %q", tt.name, tt.managedOnly, got, tt.want)
+		}
+	}
+}
+
+func TestCheckEmptyResult(t *testing.T) {
+	tests := []struct {
+		name            string
+		found           bool
+		exitZeroOnEmpty bool
+		wantCode        int
+	}{
+		{name: "found", found: true, wantCode: 0},
+		{name: "empty with default", exitZeroOnEmpty: true, wantCode: 0},
+		{name: "empty for scripts", wantCode: ExitCodeEmpty},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(checkEmptyResult(tt.found, tt.exitZeroOnEmpty)); got != tt.wantCode {
+				t.Errorf("exit code = %d, want %d", got, tt.wantCode)
+			}
+		})
+	}
+	if got := ExitCode(fmt.Errorf("failed")); got != 1 {
+		t.Errorf("ExitCode(other error) = %d, want 1", got)
+	}
+}
+
+func TestHandleGenericGetRows(t *testing.T) {
+	tests := []struct {
+		name         string
+		resourceName string
+		managedOnly  bool
+		wantRows     int
+		wantOutput   []string
+	}{
+		{name: "all", wantRows: 2, wantOutput: []string{"NAME", "app-config", "db-config"}},
+		{name: "name matches", resourceName: "db-config", wantRows: 1, wantOutput: []string{"NAME", "db-config"}},
+		{name: "name does not match", resourceName: "missing", wantRows: 0},
+		{name: "managed only", managedOnly: true, wantRows: 1, wantOutput: []string{"NAME", "app-config"}},
+		{name: "managed only and name", resourceName: "db-config", managedOnly: true, wantRows: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := tableQuery{Name: tt.resourceName, Namespace: "default", Options: getOptions{ManagedOnly: tt.managedOnly}}
+			applied := testObject("v1", "ConfigMap", "default", "app-config")
+			util.MarkManaged(applied)
+			clusterInfo, _ := testClusterInfo(
+				applied,
+				testObject("v1", "ConfigMap", "default", "db-config"),
+			)
+			if items, _ := collectObjects([]cluster.ClusterInfo{clusterInfo}, "configmaps", q); len(items) != tt.wantRows {
+				t.Errorf("collectObjects() = %d objects, want %d", len(items), tt.wantRows)
+			}
+			var out bytes.Buffer
+			tw := tabwriter.NewWriter(&out, 0, 0, 2, ' ', 0)
+			rows, err := handleGenericGet(tw, []cluster.ClusterInfo{clusterInfo}, "configmaps", q, "")
+			tw.Flush()
+			if err != nil {
+				t.Fatalf("handleGenericGet() error = %v", err)
+			}
+			if rows != tt.wantRows {
+				t.Errorf("handleGenericGet() rows = %d, want %d", rows, tt.wantRows)
+			}
+			var got []string
+			for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+				if fields := strings.Fields(line); len(fields) > 1 {
+					got = append(got, fields[1])
+				}
+			}
+			if !reflect.DeepEqual(got, tt.wantOutput) {
+				t.Errorf("handleGenericGet() printed %q, want %q", out.String(), tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestHandleGenericGetCustomPrinter(t *testing.T) {
+	t.Setenv(config.EnvConfigPath, filepath.Join(t.TempDir(), "config.yaml"))
+	defs := `printers:
+- resource: configmaps
+  columns:
+  - name: tier
+    jsonPath: .data.tier
+  - name: owner
+    jsonPath: '{.metadata.annotations.team}'
+    wide: true
+`
+	if err := os.WriteFile(config.PrintersPath(), []byte(defs), 0600); err != nil {
+		t.Fatal(err)
+	}
+	app := testObject("v1", "ConfigMap", "default", "app-config")
+	unstructured.SetNestedField(app.Object, "frontend", "data", "tier")
+	clusterInfo, _ := testClusterInfo(app, testObject("v1", "ConfigMap", "default", "db-config"))
+
+	tests := []struct {
+		outputFormat string
+		want         [][]string
+	}{
+		{want: [][]string{{"CLUSTER", "NAME", "TIER", "AGE"}, {"cluster1", "app-config", "frontend"}, {"cluster1", "db-config", "<none>"}}},
+		{outputFormat: "wide", want: [][]string{{"CLUSTER", "NAME", "TIER", "OWNER", "AGE"}, {"cluster1", "app-config", "frontend", "<none>"}, {"cluster1", "db-config", "<none>", "<none>"}}},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		tw := tabwriter.NewWriter(&out, 0, 0, 2, ' ', 0)
+		if _, err := handleGenericGet(tw, []cluster.ClusterInfo{clusterInfo}, "configmaps", tableQuery{Namespace: "default"}, tt.outputFormat); err != nil {
+			t.Fatalf("handleGenericGet() error = %v", err)
+		}
+		tw.Flush()
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		var got [][]string
+		for i, line := range lines {
+			fields := strings.Fields(line)
+			if i > 0 {
+				// Drop the age
+				fields = fields[:len(fields)-1]
+			}
+			got = append(got, fields)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("handleGenericGet(-o %q) printed\n%s\nwant %q", tt.outputFormat, out.String(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/json"
+	kubectlprinters "k8s.io/cli-runtime/pkg/printers"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+// serverTableAccept asks the API server for the table kubectl prints
+const serverTableAccept = "application/json;as=Table;v=v1;g=meta.k8s.io,application/json"
+
+// plainAllResources are the types kubectl get all lists, in its order, with
+// the kind it prefixes to their names
+var plainAllResources = []struct{ resource, kind string }{
+	{"pods", "pod"},
+	{"services", "service"},
+	{"daemonsets", "daemonset.apps"},
+	{"deployments", "deployment.apps"},
+	{"replicasets", "replicaset.apps"},
+	{"statefulsets", "statefulset.apps"},
+	{"horizontalpodautoscalers", "horizontalpodautoscaler.autoscaling"},
+	{"cronjobs", "cronjob.batch"},
+	{"jobs", "job.batch"},
+}
+
+// validatePlainGet checks that the other get flags leave the output to
+// the kubectl tables of --plain
+func validatePlainGet(o getOptions) error {
+	switch {
+	case o.OutputFormat != "" && o.OutputFormat != "wide":
+		return fmt.Errorf("--plain only applies to the table output, with or without -o wide")
+	case o.Poll > 0:
+		return fmt.Errorf("--plain cannot be combined with --poll")
+	case o.Capacity:
+		return fmt.Errorf("--plain cannot be combined with --capacity, whose totals kubectl does not print")
+	case o.SecretData.revealsData():
+		return fmt.Errorf("--plain cannot be combined with --show-data or --decode")
+	case o.OnlyDifferences || o.CRDCompare || o.HostnamesOnly || o.NetworkPolicyTarget != "":
+		return fmt.Errorf("--plain cannot be combined with --only-differences, --compare, --hostnames-only or --analyze")
+	case o.StateFilter.any():
+		return fmt.Errorf("--plain cannot be combined with the state filters (--failed, --succeeded, --active, --status, --ready)")
+	}
+	return nil
+}
+
+// printPlainGet prints resourceType with --plain: a table per type, get
+// all being several tables separated by a blank line as kubectl prints
+// them. It returns the number of rows printed.
+func printPlainGet(tw util.TableWriter, clusters []cluster.ClusterInfo, resourceType string, wide bool, q tableQuery) int {
+	if resourceType != "all" {
+		header, rows := collectPlainTable(clusters, resourceType, "", wide, q)
+		writePlainTable(tw, header, rows)
+		if len(rows) == 0 {
+			reportNoResources(q.Namespace, q.AllNamespaces)
+		}
+		return len(rows)
+	}
+
+	printed := 0
+	for _, r := range plainAllResources {
+		header, rows := collectPlainTable(clusters, r.resource, r.kind, wide, q)
+		if len(rows) == 0 {
+			continue
+		}
+		if printed > 0 {
+			fmt.Fprintln(tw)
+		}
+		writePlainTable(tw, header, rows)
+		printed += len(rows)
+	}
+	if printed == 0 {
+		reportNoResources(q.Namespace, q.AllNamespaces)
+	}
+	return printed
+}
+
+func writePlainTable(tw util.TableWriter, header []string, rows [][]string) {
+	if len(rows) == 0 {
+		return
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+}
+
+// collectPlainTable reads resourceType from every cluster as the table its
+// API server renders for kubectl and returns the header and rows to print:
+// CLUSTER, NAMESPACE for a namespaced type with -A, the columns kubectl
+// shows (all of them when wide), then the --show-annotations columns and
+// LABELS. The columns are those of the first cluster with rows; the cells
+// of a cluster whose server renders other columns are matched to them by
+// name. With kind, names are prefixed with it as in kubectl get all.
+func collectPlainTable(clusters []cluster.ClusterInfo, resourceType, kind string, wide bool, q tableQuery) ([]string, [][]string) {
+	var columns []metav1.TableColumnDefinition
+	var header []string
+	var rows [][]string
+
+	for _, c := range clusters {
+		if c.Client == nil || c.DiscoveryClient == nil {
+			continue
+		}
+		gvr, namespaced, err := util.DiscoverGVR(c.DiscoveryClient, resourceType)
+		if err != nil {
+			noteClusterIssue(c.Name, fmt.Sprintf("failed to discover resource %s: %v", resourceType, err))
+			continue
+		}
+		namespace := ""
+		if namespaced && !q.AllNamespaces {
+			namespace = cluster.GetTargetNamespace(q.Namespace)
+		}
+		table, err := fetchServerTable(c, gvr, namespace, q)
+		if err != nil {
+			noteClusterIssue(c.Name, fmt.Sprintf("failed to list %s: %v", gvr.Resource, err))
+			continue
+		}
+
+		withNamespace := namespaced && q.AllNamespaces
+		if len(table.Rows) > 0 && columns == nil {
+			for _, col := range table.ColumnDefinitions {
+				if wide || col.Priority == 0 {
+					columns = append(columns, col)
+				}
+			}
+			header = []string{"CLUSTER"}
+			if withNamespace {
+				header = append(header, "NAMESPACE")
+			}
+			for _, col := range columns {
+				header = append(header, strings.ToUpper(col.Name))
+			}
+			header = append(header, q.Annotations.header()...)
+			if q.ShowLabels {
+				header = append(header, "LABELS")
+			}
+		}
+
+		index := map[string]int{}
+		for i, col := range table.ColumnDefinitions {
+			index[col.Name] = i
+		}
+		for _, row := range table.Rows {
+			var object metav1.PartialObjectMetadata
+			if len(row.Object.Raw) > 0 {
+				if err := json.Unmarshal(row.Object.Raw, &object); err != nil {
+					noteClusterIssue(c.Name, fmt.Sprintf("failed to decode the metadata of a %s row: %v", gvr.Resource, err))
+					continue
+				}
+			}
+			if q.Options.ManagedOnly && !util.IsManaged(&object) {
+				continue
+			}
+
+			cells := []string{c.Name}
+			if withNamespace {
+				cells = append(cells, object.Namespace)
+			}
+			for _, col := range columns {
+				var cell interface{}
+				if i, ok := index[col.Name]; ok && i < len(row.Cells) {
+					cell = row.Cells[i]
+				}
+				value := plainCell(cell)
+				if kind != "" && col.Format == "name" {
+					value = kind + "/" + value
+				}
+				cells = append(cells, value)
+			}
+			cells = append(cells, q.Annotations.cells(object.Annotations)...)
+			if q.ShowLabels {
+				cells = append(cells, util.FormatLabels(object.Labels))
+			}
+			rows = append(rows, cells)
+		}
+	}
+	return header, rows
+}
+
+// fetchServerTable lists gvr in namespace, or in every namespace when it is
+// empty, as the table the API server of the cluster renders for kubectl,
+// with the metadata of the object of every row
+func fetchServerTable(c cluster.ClusterInfo, gvr schema.GroupVersionResource, namespace string, q tableQuery) (*metav1.Table, error) {
+	segments := []string{"/apis", gvr.Group, gvr.Version}
+	if gvr.Group == "" {
+		segments = []string{"/api", gvr.Version}
+	}
+	if namespace != "" {
+		segments = append(segments, "namespaces", namespace)
+	}
+	segments = append(segments, gvr.Resource)
+
+	req := c.Client.CoreV1().RESTClient().Get().
+		AbsPath(path.Join(segments...)).
+		SetHeader("Accept", serverTableAccept).
+		Param("includeObject", string(metav1.IncludeMetadata))
+	if q.Selector != "" {
+		req = req.Param("labelSelector", q.Selector)
+	}
+	if q.Name != "" {
+		req = req.Param("fieldSelector", fields.OneTermEqualSelector("metadata.name", q.Name).String())
+	}
+	data, err := req.Do(commandContext()).Raw()
+	if err != nil {
+		return nil, err
+	}
+
+	var table metav1.Table
+	if err := json.Unmarshal(data, &table); err != nil {
+		return nil, fmt.Errorf("failed to decode the table: %v", err)
+	}
+	if table.Kind != "Table" {
+		return nil, fmt.Errorf("the API server does not render tables")
+	}
+	return &table, nil
+}
+
+// plainCell formats a cell of a server-rendered table the way kubectl
+// prints it: strings cut at the first line break, and characters that
+// would break the table escaped
+func plainCell(cell interface{}) string {
+	var value string
+	switch v := cell.(type) {
+	case nil:
+		return ""
+	case string:
+		value = v
+		if i := strings.IndexAny(value, "\f\n\r"); i >= 0 {
+			value = value[:i] + "..."
+		}
+	default:
+		value = fmt.Sprint(v)
+	}
+	var b strings.Builder
+	kubectlprinters.WriteEscaped(&b, value)
+	return b.String()
+}
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	clienttesting "k8s.io/client-go/testing"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+// testPlainCluster serves tables as the API server of a cluster serving
+// pods and deployments renders them for kubectl, by request path
+func testPlainCluster(t *testing.T, name string, tables map[string]metav1.Table) cluster.ClusterInfo {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept"), "as=Table") {
+			t.Errorf("%s requested without asking for a table", r.URL.Path)
+		}
+		table, ok := tables[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		table.Kind, table.APIVersion = "Table", "meta.k8s.io/v1"
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(table)
+	}))
+	t.Cleanup(server.Close)
+
+	return cluster.ClusterInfo{
+		Name:   name,
+		Client: kubernetes.NewForConfigOrDie(&rest.Config{Host: server.URL}),
+		DiscoveryClient: &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: []*metav1.APIResourceList{
+			{GroupVersion: "v1", APIResources: []metav1.APIResource{{Name: "pods", Namespaced: true, Kind: "Pod"}}},
+			{GroupVersion: "apps/v1", APIResources: []metav1.APIResource{{Name: "deployments", Namespaced: true, Kind: "Deployment"}}},
+		}}},
+	}
+}
+
+func testPlainRow(namespace, name string, labels map[string]string, cells ...interface{}) metav1.TableRow {
+	object, _ := json.Marshal(metav1.PartialObjectMetadata{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "meta.k8s.io/v1", Kind: "PartialObjectMetadata"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels},
+	})
+	return metav1.TableRow{Cells: cells, Object: runtime.RawExtension{Raw: object}}
+}
+
+var testPodColumns = []metav1.TableColumnDefinition{
+	{Name: "Name", Type: "string", Format: "name"},
+	{Name: "Ready", Type: "string"},
+	{Name: "Status", Type: "string"},
+	{Name: "Restarts", Type: "string"},
+	{Name: "IP", Type: "string", Priority: 1},
+}
+
+func TestCollectPlainTable(t *testing.T) {
+	clusters := []cluster.ClusterInfo{
+		testPlainCluster(t, "cluster1", map[string]metav1.Table{
+			"/api/v1/pods": {ColumnDefinitions: testPodColumns, Rows: []metav1.TableRow{
+				testPlainRow("default", "web-1", map[string]string{"app": "web"}, "web-1", "1/1", "Running", "0", "10.0.0.1"),
+			}},
+		}),
+		// An older server rendering the columns in another order, without IP
+		testPlainCluster(t, "cluster2", map[string]metav1.Table{
+			"/api/v1/pods": {ColumnDefinitions: []metav1.TableColumnDefinition{
+				{Name: "Name", Type: "string", Format: "name"},
+				{Name: "Status", Type: "string"},
+				{Name: "Ready", Type: "string"},
+				{Name: "Restarts", Type: "integer"},
+			}, Rows: []metav1.TableRow{
+				testPlainRow("prod", "web-2", nil, "web-2", "CrashLoopBackOff", "0/1", int64(7)),
+			}},
+		}),
+		{Name: "unreachable"},
+	}
+
+	header, rows := collectPlainTable(clusters, "pods", "", false, tableQuery{AllNamespaces: true, ShowLabels: true})
+	if want := []string{"CLUSTER", "NAMESPACE", "NAME", "READY", "STATUS", "RESTARTS", "LABELS"}; !reflect.DeepEqual(header, want) {
+		t.Errorf("header = %v, want %v", header, want)
+	}
+	want := [][]string{
+		{"cluster1", "default", "web-1", "1/1", "Running", "0", "app=web"},
+		{"cluster2", "prod", "web-2", "0/1", "CrashLoopBackOff", "7", "<none>"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %v, want %v", rows, want)
+	}
+
+	header, rows = collectPlainTable(clusters[:1], "pods", "", true, tableQuery{AllNamespaces: true})
+	if want := []string{"CLUSTER", "NAMESPACE", "NAME", "READY", "STATUS", "RESTARTS", "IP"}; !reflect.DeepEqual(header, want) {
+		t.Errorf("wide header = %v, want %v", header, want)
+	}
+	if len(rows) != 1 || rows[0][6] != "10.0.0.1" {
+		t.Errorf("wide rows = %v", rows)
+	}
+}
+
+func TestPrintPlainGetAll(t *testing.T) {
+	clusters := []cluster.ClusterInfo{
+		testPlainCluster(t, "cluster1", map[string]metav1.Table{
+			"/api/v1/namespaces/default/pods": {ColumnDefinitions: testPodColumns, Rows: []metav1.TableRow{
+				testPlainRow("default", "web-1", nil, "web-1", "1/1", "Running", "0", "10.0.0.1"),
+			}},
+			"/apis/apps/v1/namespaces/default/deployments": {ColumnDefinitions: []metav1.TableColumnDefinition{
+				{Name: "Name", Type: "string", Format: "name"},
+				{Name: "Ready", Type: "string"},
+			}, Rows: []metav1.TableRow{
+				testPlainRow("default", "web", nil, "web", "1/1"),
+			}},
+		}),
+	}
+
+	var out bytes.Buffer
+	tw := util.NewKubectlTableWriter(&out)
+	rows := printPlainGet(tw, clusters, "all", false, tableQuery{Namespace: "default"})
+	tw.Flush()
+	if rows != 2 {
+		t.Errorf("printPlainGet() = %d rows, want 2", rows)
+	}
+	want := "CLUSTER    NAME        READY   STATUS    RESTARTS\n" +
+		"cluster1   pod/web-1   1/1     Running   0\n" +
+		"\n" +
+		"CLUSTER    NAME                  READY\n" +
+		"cluster1   deployment.apps/web   1/1\n"
+	if out.String() != want {
+		t.Errorf("printed\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestPlainCell(t *testing.T) {
+	tests := []struct {
+		cell interface{}
+		want string
+	}{
+		{nil, ""},
+		{"Running", "Running"},
+		{"first line\nsecond line", "first line..."},
+		{int64(3), "3"},
+		{float64(2), "2"},
+		{true, "true"},
+	}
+	for _, tt := range tests {
+		if got := plainCell(tt.cell); got != tt.want {
+			t.Errorf("plainCell(%#v) = %q, want %q", tt.cell, got, tt.want)
+		}
+	}
+}
+
+func TestValidatePlainGet(t *testing.T) {
+	hidden := secretDataOptions{ShowData: "false"}
+	if err := validatePlainGet(getOptions{OutputFormat: "wide", SecretData: hidden}); err != nil {
+		t.Errorf("validatePlainGet(wide) = %v", err)
+	}
+	for name, err := range map[string]error{
+		"json":     validatePlainGet(getOptions{OutputFormat: "json", SecretData: hidden}),
+		"poll":     validatePlainGet(getOptions{Poll: time.Second, SecretData: hidden}),
+		"capacity": validatePlainGet(getOptions{Capacity: true, SecretData: hidden}),
+		"decode":   validatePlainGet(getOptions{SecretData: secretDataOptions{ShowData: "false", DecodeKey: "password"}}),
+		"analyze":  validatePlainGet(getOptions{NetworkPolicyTarget: "app=web", SecretData: hidden}),
+		"failed":   validatePlainGet(getOptions{StateFilter: stateFilter{Failed: true}, SecretData: hidden}),
+	} {
+		if err == nil {
+			t.Errorf("validatePlainGet() with %s returned no error", name)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+const (
+	ansiClearScreen = "\x1b[H\x1b[2J"
+	ansiHighlight   = "\x1b[7m"
+	ansiReset       = "\x1b[0m"
+)
+
+// pollGetTable re-renders the get table every interval until interrupted,
+// marking the rows that changed since the previous refresh. On a terminal
+// the table is redrawn in place with changed rows highlighted; otherwise each
+// refresh is appended with changed rows prefixed by "* ".
+func pollGetTable(interval time.Duration, title string, render func(w io.Writer) error) error {
+	expectInterrupt()
+	ctx := commandContext()
+
+	out := os.Stdout
+	tty := term.IsTerminal(int(out.Fd()))
+	previous := ""
+	for {
+		var frame bytes.Buffer
+		if err := render(&frame); err != nil {
+			return err
+		}
+		printClusterIssues(&frame)
+
+		if tty {
+			fmt.Fprint(out, ansiClearScreen)
+		} else if previous != "" {
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintf(out, "Every %s: %s    %s\n\n", interval, title, time.Now().Format(time.RFC1123))
+		writePollFrame(out, frame.String(), changedRows(previous, frame.String()), tty)
+		previous = frame.String()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// writePollFrame writes the frame lines, marking the changed ones
+func writePollFrame(w io.Writer, frame string, changed []bool, tty bool) {
+	for i, line := range strings.Split(strings.TrimSuffix(frame, "\n"), "\n") {
+		switch {
+		case tty && changed[i]:
+			fmt.Fprintf(w, "%s%s%s\n", ansiHighlight, line, ansiReset)
+		case tty:
+			fmt.Fprintln(w, line)
+		case changed[i]:
+			fmt.Fprintf(w, "* %s\n", line)
+		default:
+			fmt.Fprintf(w, "  %s\n", line)
+		}
+	}
+}
+
+// changedRows reports for every line of the current frame whether it is a
+// table row that was not in the previous frame. Header and blank lines never
+// count, nor does a row whose only difference is its AGE. The first frame has
+// nothing to compare against, so nothing in it is marked.
+func changedRows(previous, current string) []bool {
+	lines := strings.Split(strings.TrimSuffix(current, "\n"), "\n")
+	changed := make([]bool, len(lines))
+	if previous == "" {
+		return changed
+	}
+
+	before := map[string]bool{}
+	ageColumn := -1
+	for _, line := range strings.Split(previous, "\n") {
+		if isHeaderLine(line) {
+			ageColumn = columnIndex(line, "AGE")
+			continue
+		}
+		before[rowKey(line, ageColumn)] = true
+	}
+
+	ageColumn = -1
+	for i, line := range lines {
+		if isHeaderLine(line) {
+			ageColumn = columnIndex(line, "AGE")
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		changed[i] = !before[rowKey(line, ageColumn)]
+	}
+	return changed
+}
+
+// isHeaderLine reports whether line is a table header; every table starts
+// with the CLUSTER column
+func isHeaderLine(line string) bool {
+	return strings.HasPrefix(line, "CLUSTER ")
+}
+
+// columnIndex returns the field index of column in a header line, or -1
+func columnIndex(header, column string) int {
+	for i, field := range strings.Fields(header) {
+		if field == column {
+			return i
+		}
+	}
+	return -1
+}
+
+// rowKey is the row with the AGE field dropped, so rows are compared by
+// their content rather than their growing age
+func rowKey(line string, ageColumn int) string {
+	fields := strings.Fields(line)
+	if ageColumn >= 0 && ageColumn < len(fields) {
+		fields = append(fields[:ageColumn:ageColumn], fields[ageColumn+1:]...)
+	}
+	return strings.Join(fields, " ")
+}
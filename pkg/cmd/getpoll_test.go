@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestChangedRows(t *testing.T) {
+	const first = "CLUSTER   NAME   READY  STATUS   AGE\n" +
+		"cluster1  web-1  1/1    Running  5m\n" +
+		"cluster2  web-1  1/1    Running  5m\n"
+
+	tests := []struct {
+		name     string
+		previous string
+		current  string
+		want     []bool
+	}{
+		{
+			name:    "first frame marks nothing",
+			current: first,
+			want:    []bool{false, false, false},
+		},
+		{
+			name:     "unchanged",
+			previous: first,
+			current:  first,
+			want:     []bool{false, false, false},
+		},
+		{
+			name:     "age alone is not a change",
+			previous: first,
+			current: "CLUSTER   NAME   READY  STATUS   AGE\n" +
+				"cluster1  web-1  1/1    Running  6m\n" +
+				"cluster2  web-1  1/1    Running  6m\n",
+			want: []bool{false, false, false},
+		},
+		{
+			name:     "status change and new row",
+			previous: first,
+			current: "CLUSTER   NAME   READY  STATUS             AGE\n" +
+				"cluster1  web-1  0/1    CrashLoopBackOff   5m\n" +
+				"cluster2  web-1  1/1    Running            5m\n" +
+				"cluster2  web-2  0/1    Pending            1s\n",
+			want: []bool{false, true, false, true},
+		},
+		{
+			name:     "blank lines and notices",
+			previous: first,
+			current:  "No resources found.\n\n",
+			want:     []bool{true, false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := changedRows(tt.previous, tt.current); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("changedRows() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWritePollFrame(t *testing.T) {
+	const frame = "CLUSTER   NAME\ncluster1  web-1\n"
+	tests := []struct {
+		name string
+		tty  bool
+		want string
+	}{
+		{name: "terminal highlights", tty: true, want: "CLUSTER   NAME\n\x1b[7mcluster1  web-1\x1b[0m\n"},
+		{name: "pipe marks", want: "  CLUSTER   NAME\n* cluster1  web-1\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			writePollFrame(&out, frame, []bool{false, true}, tt.tty)
+			if out.String() != tt.want {
+				t.Errorf("writePollFrame() = %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
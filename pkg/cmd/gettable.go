@@ -0,0 +1,262 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/duration"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+// tableObject is an object get prints as a table row: a typed object such
+// as *corev1.Pod, or *unstructured.Unstructured
+type tableObject interface {
+	runtime.Object
+	metav1.Object
+}
+
+// tableColumn is a column of a get table and how the cell of an object is
+// derived
+type tableColumn[T tableObject] struct {
+	Name  string
+	Value func(obj T) string
+}
+
+// resourceTable describes how get lists a resource type and the columns it
+// prints. Every table starts with CLUSTER, then NAMESPACE for namespaced
+// types with -A, and ends with the annotation columns of --show-annotations
+// and LABELS with --show-labels.
+type resourceTable[T tableObject] struct {
+	// Group and Resource name the type in messages and in the RBAC check of
+	// the per-namespace fallback of namespaced types
+	Group, Resource string
+	Namespaced      bool
+	// Dynamic tables are listed with the dynamic client, others with the
+	// typed client
+	Dynamic bool
+	// List lists the objects of the cluster in namespace, or in every
+	// namespace when it is empty
+	List    func(c cluster.ClusterInfo, namespace string, opts metav1.ListOptions) (runtime.Object, error)
+	Columns []tableColumn[T]
+	// Keep, when set, applies the state filters (--failed, --status, ...)
+	Keep func(f stateFilter, obj T) bool
+	// ForCluster, when set, is given the objects of each cluster before
+	// their rows are printed
+	ForCluster func(c cluster.ClusterInfo, objs []T)
+	// Finish, when set, prints below a table that has rows
+	Finish func(tw util.TableWriter)
+}
+
+// tableQuery is what get asks of every cluster's table
+type tableQuery struct {
+	Name, Selector string
+	ShowLabels     bool
+	Annotations    annotationColumns
+	Namespace      string
+	AllNamespaces  bool
+	Options        getOptions
+}
+
+// allAnnotations is the value of a bare --show-annotations
+const allAnnotations = "*"
+
+// lastAppliedAnnotation is left out of the ANNOTATIONS column: it holds a
+// copy of the whole object
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// annotationColumns are the annotations get shows (--show-annotations):
+// all of them in one ANNOTATIONS column, or a column for each of Keys
+type annotationColumns struct {
+	All  bool
+	Keys []string
+}
+
+// parseShowAnnotations parses the value of --show-annotations, "*" for all
+// annotations or a comma-separated list of keys
+func parseShowAnnotations(value string) (annotationColumns, error) {
+	if value == "" {
+		return annotationColumns{}, nil
+	}
+	if value == allAnnotations {
+		return annotationColumns{All: true}, nil
+	}
+	var a annotationColumns
+	for _, key := range strings.Split(value, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return a, fmt.Errorf("invalid --show-annotations %q: empty annotation key", value)
+		}
+		a.Keys = append(a.Keys, key)
+	}
+	return a, nil
+}
+
+// header returns the names of the annotation columns, each key in upper case
+func (a annotationColumns) header() []string {
+	if a.All {
+		return []string{"ANNOTATIONS"}
+	}
+	header := make([]string, 0, len(a.Keys))
+	for _, key := range a.Keys {
+		header = append(header, strings.ToUpper(key))
+	}
+	return header
+}
+
+// cells returns the annotation cells of an object's row. A key the object
+// lacks is an empty cell.
+func (a annotationColumns) cells(annotations map[string]string) []string {
+	if a.All {
+		shown := make(map[string]string, len(annotations))
+		for k, v := range annotations {
+			if k != lastAppliedAnnotation {
+				shown[k] = annotationCell(v)
+			}
+		}
+		return []string{util.FormatLabels(shown)}
+	}
+	cells := make([]string, 0, len(a.Keys))
+	for _, key := range a.Keys {
+		cells = append(cells, annotationCell(annotations[key]))
+	}
+	return cells
+}
+
+// annotationCell puts an annotation value on one line, since tabs and
+// newlines, common in JSON values, would break the table
+func annotationCell(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
+
+// printResourceTable lists the objects matching q in every cluster and
+// prints them with the columns of table, the header once before the first
+// row. It returns the number of rows printed.
+func printResourceTable[T tableObject](tw util.TableWriter, clusters []cluster.ClusterInfo, table resourceTable[T], q tableQuery) (int, error) {
+	rows := 0
+	withNamespace := table.Namespaced && q.AllNamespaces
+
+	for _, clusterInfo := range clusters {
+		if (table.Dynamic && clusterInfo.DynamicClient == nil) || (!table.Dynamic && clusterInfo.Client == nil) {
+			continue
+		}
+		objs, err := listTableObjects(clusterInfo, table, q)
+		if err != nil {
+			noteClusterIssue(clusterInfo.Name, fmt.Sprintf("failed to list %s: %v", table.Resource, err))
+			continue
+		}
+
+		if len(objs) > 0 && rows == 0 {
+			// Print the header once, before the first matching row
+			header := []string{"CLUSTER"}
+			if withNamespace {
+				header = append(header, "NAMESPACE")
+			}
+			for _, col := range table.Columns {
+				header = append(header, col.Name)
+			}
+			header = append(header, q.Annotations.header()...)
+			if q.ShowLabels {
+				header = append(header, "LABELS")
+			}
+			fmt.Fprintln(tw, strings.Join(header, "\t"))
+		}
+		rows += len(objs)
+
+		if table.ForCluster != nil {
+			table.ForCluster(clusterInfo, objs)
+		}
+		for _, obj := range objs {
+			row := []string{clusterInfo.Name}
+			if withNamespace {
+				row = append(row, obj.GetNamespace())
+			}
+			for _, col := range table.Columns {
+				row = append(row, col.Value(obj))
+			}
+			row = append(row, q.Annotations.cells(obj.GetAnnotations())...)
+			if q.ShowLabels {
+				row = append(row, util.FormatLabels(obj.GetLabels()))
+			}
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+	}
+
+	if rows == 0 {
+		reportNoResources(q.Namespace, q.AllNamespaces || !table.Namespaced)
+	} else if table.Finish != nil {
+		table.Finish(tw)
+	}
+	return rows, nil
+}
+
+// listTableObjects lists the objects of a table in one cluster that match
+// the name of q, --managed-only and the state filters
+func listTableObjects[T tableObject](c cluster.ClusterInfo, table resourceTable[T], q tableQuery) ([]T, error) {
+	opts := metav1.ListOptions{LabelSelector: q.Selector}
+	list := func(ns string) (runtime.Object, error) {
+		return table.List(c, ns, opts)
+	}
+	var result runtime.Object
+	var err error
+	if table.Namespaced {
+		targetNS := ""
+		if !q.AllNamespaces {
+			targetNS = cluster.GetTargetNamespace(q.Namespace)
+		}
+		result, err = listNamespaced(c, table.Group, table.Resource, targetNS, list)
+	} else {
+		result, err = list("")
+	}
+	if err != nil {
+		return nil, err
+	}
+	items, err := meta.ExtractList(result)
+	if err != nil {
+		return nil, err
+	}
+
+	var objs []T
+	for _, item := range items {
+		obj, ok := item.(T)
+		if !ok {
+			return nil, fmt.Errorf("unexpected %T in the list of %s", item, table.Resource)
+		}
+		if (q.Name != "" && obj.GetName() != q.Name) || (q.Options.ManagedOnly && !util.IsManaged(obj)) {
+			continue
+		}
+		if table.Keep != nil && !table.Keep(q.Options.StateFilter, obj) {
+			continue
+		}
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
+
+// Columns most tables share
+
+func nameColumn[T tableObject]() tableColumn[T] {
+	return tableColumn[T]{Name: "NAME", Value: func(obj T) string { return obj.GetName() }}
+}
+
+func ageColumn[T tableObject]() tableColumn[T] {
+	return tableColumn[T]{Name: "AGE", Value: func(obj T) string { return objectAge(obj.GetCreationTimestamp()) }}
+}
+
+// objectAge formats the time since t the way kubectl does
+func objectAge(t metav1.Time) string {
+	return duration.HumanDuration(time.Since(t.Time))
+}
+
+// tablePrinter binds table to printResourceTable, for lists of tables of
+// different types
+func tablePrinter[T tableObject](table resourceTable[T]) func(util.TableWriter, []cluster.ClusterInfo, tableQuery) (int, error) {
+	return func(tw util.TableWriter, clusters []cluster.ClusterInfo, q tableQuery) (int, error) {
+		return printResourceTable(tw, clusters, table, q)
+	}
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+func TestPrintResourceTable(t *testing.T) {
+	clusters := []cluster.ClusterInfo{
+		testTypedCluster("cluster1",
+			testPod("default", "web-1", map[string]string{"app": "web"}),
+			testPod("prod", "web-2", nil),
+		),
+		testTypedCluster("cluster2", testPod("prod", "web-3", map[string]string{"tier": "front", "app": "web"})),
+	}
+	var seen []string
+	table := resourceTable[*corev1.Pod]{
+		Resource:   "pods",
+		Namespaced: true,
+		List: func(c cluster.ClusterInfo, ns string, opts metav1.ListOptions) (runtime.Object, error) {
+			return c.Client.CoreV1().Pods(ns).List(commandContext(), opts)
+		},
+		Columns: []tableColumn[*corev1.Pod]{
+			nameColumn[*corev1.Pod](),
+			{Name: "PHASE", Value: func(pod *corev1.Pod) string { return string(pod.Status.Phase) }},
+		},
+		Keep: func(f stateFilter, pod *corev1.Pod) bool { return pod.Name != "web-2" },
+		ForCluster: func(c cluster.ClusterInfo, pods []*corev1.Pod) {
+			seen = append(seen, fmt.Sprintf("%s:%d", c.Name, len(pods)))
+		},
+		Finish: func(tw util.TableWriter) { fmt.Fprintln(tw, "END") },
+	}
+
+	var out bytes.Buffer
+	tw := util.NewTableWriter(&out, util.TableFormatCSV)
+	rows, err := printResourceTable(tw, clusters, table, tableQuery{ShowLabels: true, AllNamespaces: true})
+	tw.Flush()
+	if err != nil || rows != 2 {
+		t.Fatalf("printResourceTable() = %d, %v, want 2 rows", rows, err)
+	}
+	want := "CLUSTER,NAMESPACE,NAME,PHASE,LABELS\n" +
+		"cluster1,default,web-1,Running,app=web\n" +
+		"cluster2,prod,web-3,Running,\"app=web,tier=front\"\n" +
+		"END\n"
+	if out.String() != want {
+		t.Errorf("printed %q, want %q", out.String(), want)
+	}
+	if fmt.Sprint(seen) != "[cluster1:1 cluster2:1]" {
+		t.Errorf("ForCluster saw %v", seen)
+	}
+
+	// Nothing matches: no header and no Finish
+	out.Reset()
+	tw = util.NewTableWriter(&out, util.TableFormatCSV)
+	rows, _ = printResourceTable(tw, clusters, table, tableQuery{Name: "web-2", Namespace: "prod"})
+	tw.Flush()
+	if rows != 0 || out.Len() != 0 {
+		t.Errorf("printResourceTable(web-2) = %d rows, printed %q", rows, out.String())
+	}
+}
+
+func TestPrintResourceTableAnnotations(t *testing.T) {
+	annotated := testPod("default", "web-1", map[string]string{"app": "web"})
+	annotated.Annotations = map[string]string{
+		"owner":               "team-a",
+		"note":                "line one\nline\ttwo",
+		lastAppliedAnnotation: `{"kind":"Pod"}`,
+	}
+	clusters := []cluster.ClusterInfo{testTypedCluster("cluster1", annotated, testPod("default", "web-2", nil))}
+	table := resourceTable[*corev1.Pod]{
+		Resource:   "pods",
+		Namespaced: true,
+		List: func(c cluster.ClusterInfo, ns string, opts metav1.ListOptions) (runtime.Object, error) {
+			return c.Client.CoreV1().Pods(ns).List(commandContext(), opts)
+		},
+		Columns: []tableColumn[*corev1.Pod]{nameColumn[*corev1.Pod]()},
+	}
+
+	tests := []struct {
+		name        string
+		annotations string
+		want        string
+	}{
+		{
+			name:        "all annotations without last-applied",
+			annotations: allAnnotations,
+			want: "CLUSTER,NAME,ANNOTATIONS,LABELS\n" +
+				"cluster1,web-1,\"note=line one line two,owner=team-a\",app=web\n" +
+				"cluster1,web-2,<none>,<none>\n",
+		},
+		{
+			name:        "a column per key",
+			annotations: "owner, missing",
+			want: "CLUSTER,NAME,OWNER,MISSING,LABELS\n" +
+				"cluster1,web-1,team-a,,app=web\n" +
+				"cluster1,web-2,,,<none>\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			annotations, err := parseShowAnnotations(tt.annotations)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var out bytes.Buffer
+			tw := util.NewTableWriter(&out, util.TableFormatCSV)
+			if _, err := printResourceTable(tw, clusters, table, tableQuery{ShowLabels: true, Annotations: annotations, Namespace: "default"}); err != nil {
+				t.Fatal(err)
+			}
+			tw.Flush()
+			if out.String() != tt.want {
+				t.Errorf("printed %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+
+	if _, err := parseShowAnnotations("owner,,note"); err == nil {
+		t.Error("parseShowAnnotations accepted an empty key")
+	}
+}
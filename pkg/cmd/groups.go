@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/config"
+	"kubectl-multi/pkg/util"
+)
+
+func newGroupsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "groups",
+		Aliases: []string{"group"},
+		Short:   "Manage named cluster groups used for targeting",
+		Long: `Manage named groups of managed clusters stored in the plugin config
+($KUBECTL_MULTI_CONFIG or ~/.kube/kubectl-multi.yaml).
+
+A group can be used anywhere a cluster list is accepted by prefixing its name
+with @, for example --clusters=@edge.`,
+	}
+	cmd.AddCommand(newGroupsCreateCommand())
+	cmd.AddCommand(newGroupsListCommand())
+	cmd.AddCommand(newGroupsDeleteCommand())
+	return cmd
+}
+
+func newGroupsCreateCommand() *cobra.Command {
+	var clusterNames []string
+	var overwrite bool
+
+	cmd := &cobra.Command{
+		Use:   "create NAME --clusters CLUSTER[,CLUSTER...]",
+		Short: "Create or replace a cluster group",
+		Example: `# Define an edge group
+kubectl multi groups create edge --clusters cluster3,cluster4,cluster7
+
+# Build a group from other groups
+kubectl multi groups create all-edge --clusters @edge,cluster9`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return handleGroupsCreate(args[0], clusterNames, overwrite)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&clusterNames, "clusters", nil, "comma-separated list of clusters (or @groups) in the group")
+	cmd.Flags().BoolVar(&overwrite, "overwrite", false, "replace the group if it already exists")
+	cmd.MarkFlagRequired("clusters")
+
+	return cmd
+}
+
+func newGroupsListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List cluster groups",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return handleGroupsList()
+		},
+	}
+}
+
+func newGroupsDeleteCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete NAME",
+		Short: "Delete a cluster group",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return handleGroupsDelete(args[0])
+		},
+	}
+}
+
+func handleGroupsCreate(name string, clusterNames []string, overwrite bool) error {
+	if name == "" || strings.ContainsAny(name, config.GroupPrefix+", ") {
+		return fmt.Errorf("invalid group name %q", name)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if _, exists := cfg.Groups[name]; exists && !overwrite {
+		return fmt.Errorf("group %q already exists, use --overwrite to replace it", name)
+	}
+
+	members, err := cfg.ExpandClusters(clusterNames)
+	if err != nil {
+		return err
+	}
+	if len(members) == 0 {
+		return fmt.Errorf("group %q must contain at least one cluster", name)
+	}
+
+	if cfg.Groups == nil {
+		cfg.Groups = make(map[string][]string)
+	}
+	cfg.Groups[name] = members
+	if err := cfg.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("group %q set to %s\n", name, strings.Join(members, ","))
+	return nil
+}
+
+func handleGroupsList() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(util.GetOutputStream(), 0, 0, 2, ' ', 0)
+	defer tw.Flush()
+
+	if len(cfg.Groups) == 0 {
+		fmt.Fprintf(tw, "No cluster groups defined.\n")
+		return nil
+	}
+	fmt.Fprintf(tw, "GROUP\tCLUSTERS\n")
+	for _, name := range cfg.GroupNames() {
+		fmt.Fprintf(tw, "%s\t%s\n", name, strings.Join(cfg.Groups[name], ","))
+	}
+	return nil
+}
+
+func handleGroupsDelete(name string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if _, exists := cfg.Groups[name]; !exists {
+		return fmt.Errorf("group %q is not defined", name)
+	}
+	delete(cfg.Groups, name)
+	if err := cfg.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("group %q deleted\n", name)
+	return nil
+}
+
+// expandClusterNames resolves @group references in a --clusters list
+func expandClusterNames(names []string) ([]string, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	if profileGroups != nil {
+		cfg.Groups = profileGroups
+	}
+	return cfg.ExpandClusters(names)
+}
+
+// selectTargetClusters narrows discovered clusters to a --clusters list,
+// which may reference cluster groups. An empty list keeps every cluster.
+func selectTargetClusters(clusters []cluster.ClusterInfo, names []string) ([]cluster.ClusterInfo, error) {
+	expanded, err := expandClusterNames(names)
+	if err != nil {
+		return nil, err
+	}
+	return cluster.SelectClusters(clusters, expanded)
+}
@@ -0,0 +1,276 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+// Outcomes of an API server health endpoint probe
+const (
+	healthOK          = "ok"
+	healthFailed      = "failed"
+	healthUnreachable = "unreachable"
+)
+
+// controlPlaneSelector matches the static control plane pods of kubeadm and kind clusters
+const controlPlaneSelector = "tier=control-plane"
+
+// endpointHealth is the answer of one /readyz or /livez endpoint
+type endpointHealth struct {
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latencyMs"`
+	// Failed are the checks the endpoint reported as failing, or the error
+	// when it did not answer with a health report
+	Failed []string `json:"failed,omitempty"`
+}
+
+// controlPlaneHealth is the readiness of the control plane pods a cluster shows
+type controlPlaneHealth struct {
+	Pods     int      `json:"pods"`
+	Ready    int      `json:"ready"`
+	NotReady []string `json:"notReady,omitempty"`
+}
+
+// clusterHealth is the API server health of one cluster
+type clusterHealth struct {
+	Cluster string         `json:"cluster"`
+	Healthy bool           `json:"healthy"`
+	Readyz  endpointHealth `json:"readyz"`
+	Livez   endpointHealth `json:"livez"`
+	// ControlPlane is nil when the control plane pods are not visible, as on
+	// managed Kubernetes services or without access to kube-system
+	ControlPlane *controlPlaneHealth `json:"controlPlane,omitempty"`
+}
+
+func newHealthzCommand() *cobra.Command {
+	var outputFormat string
+	var timeout time.Duration
+	var targets clusterTargets
+
+	cmd := &cobra.Command{
+		Use:   "healthz",
+		Short: "Check the API server health of every managed cluster",
+		Long: `Call the /readyz and /livez endpoints of the API server of every managed
+cluster and report their status and latency, the checks that fail, and the
+readiness of the control plane pods (tier=control-plane in kube-system) where
+they are visible.
+
+The command fails when any cluster is not healthy, so it can gate scripts.`,
+		Example: `# Health of the whole fleet
+kubectl multi healthz
+
+# Give slow clusters more time, as JSON
+kubectl multi healthz --timeout 15s -o json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch outputFormat {
+			case "", "json", util.TableFormatCSV, util.TableFormatMarkdown:
+			default:
+				return fmt.Errorf("unsupported output format %q, must be one of json|csv|markdown", outputFormat)
+			}
+			kubeconfig, remoteCtx, _, _, _ := GetGlobalFlags()
+			return handleHealthzCommand(outputFormat, timeout, targets, kubeconfig, remoteCtx)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "output format (json|csv|markdown)")
+	cmd.Flags().DurationVar(&timeout, "timeout", 5*time.Second, "how long to wait for each endpoint to answer")
+	targets.addFlags(cmd, "check")
+
+	return cmd
+}
+
+func handleHealthzCommand(outputFormat string, timeout time.Duration, targets clusterTargets, kubeconfig, remoteCtx string) error {
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	clusters, err = targets.selectFrom(clusters, kubeconfig)
+	if err != nil {
+		return err
+	}
+
+	results := checkClustersHealth(commandContext(), clusters, timeout)
+	unhealthy := 0
+	for _, h := range results {
+		if !h.Healthy {
+			unhealthy++
+		}
+	}
+
+	if outputFormat == "json" {
+		if err := printJSONArray(results); err != nil {
+			return err
+		}
+	} else {
+		printClusterHealth(results, outputFormat)
+	}
+	if unhealthy > 0 {
+		return fmt.Errorf("%d cluster(s) not healthy", unhealthy)
+	}
+	return nil
+}
+
+func printClusterHealth(results []clusterHealth, outputFormat string) {
+	tw := util.NewTableWriter(util.GetOutputStream(), outputFormat)
+	defer tw.Flush()
+	fmt.Fprintf(tw, "CLUSTER\tREADYZ\tREADYZ LATENCY\tLIVEZ\tLIVEZ LATENCY\tCONTROL PLANE\tFAILED CHECKS\n")
+	for _, h := range results {
+		controlPlane := "<not visible>"
+		if cp := h.ControlPlane; cp != nil {
+			controlPlane = fmt.Sprintf("%d/%d ready", cp.Ready, cp.Pods)
+		}
+		var failed []string
+		failed = append(failed, h.Readyz.Failed...)
+		for _, check := range h.Livez.Failed {
+			if !slices.Contains(failed, check) {
+				failed = append(failed, check)
+			}
+		}
+		if h.ControlPlane != nil {
+			for _, pod := range h.ControlPlane.NotReady {
+				failed = append(failed, "pod "+pod+" not ready")
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			h.Cluster, h.Readyz.Status, formatLatency(h.Readyz), h.Livez.Status, formatLatency(h.Livez), controlPlane, orNone(strings.Join(failed, "; ")))
+	}
+}
+
+func formatLatency(e endpointHealth) string {
+	if e.Status == healthUnreachable {
+		return "-"
+	}
+	return fmt.Sprintf("%dms", e.LatencyMS)
+}
+
+// checkClustersHealth probes every cluster concurrently, keeping their order
+func checkClustersHealth(ctx context.Context, clusters []cluster.ClusterInfo, timeout time.Duration) []clusterHealth {
+	results := make([]clusterHealth, len(clusters))
+	var wg sync.WaitGroup
+	for i, c := range clusters {
+		wg.Add(1)
+		go func(i int, c cluster.ClusterInfo) {
+			defer wg.Done()
+			results[i] = checkClusterHealth(ctx, c, timeout)
+		}(i, c)
+	}
+	wg.Wait()
+	return results
+}
+
+// checkClusterHealth probes the health endpoints and control plane pods of one cluster
+func checkClusterHealth(ctx context.Context, c cluster.ClusterInfo, timeout time.Duration) clusterHealth {
+	h := clusterHealth{Cluster: c.Name}
+	if c.RestConfig == nil {
+		unreachable := endpointHealth{Status: healthUnreachable, Failed: []string{"no client available"}}
+		h.Readyz, h.Livez = unreachable, unreachable
+		return h
+	}
+	cfg := rest.CopyConfig(c.RestConfig)
+	cfg.Timeout = timeout
+	disc, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		unreachable := endpointHealth{Status: healthUnreachable, Failed: []string{err.Error()}}
+		h.Readyz, h.Livez = unreachable, unreachable
+		return h
+	}
+	h.Readyz = probeHealthEndpoint(ctx, disc.RESTClient(), "/readyz")
+	h.Livez = probeHealthEndpoint(ctx, disc.RESTClient(), "/livez")
+	h.Healthy = h.Readyz.Status == healthOK && h.Livez.Status == healthOK
+
+	if h.Livez.Status != healthUnreachable && c.Client != nil {
+		h.ControlPlane = controlPlanePods(ctx, c, timeout)
+		if h.ControlPlane != nil && len(h.ControlPlane.NotReady) > 0 {
+			h.Healthy = false
+		}
+	}
+	return h
+}
+
+// probeHealthEndpoint calls a verbose health endpoint and collects the
+// checks it reports as failing
+func probeHealthEndpoint(ctx context.Context, client rest.Interface, path string) endpointHealth {
+	start := time.Now()
+	var code int
+	body, err := client.Get().AbsPath(path).Param("verbose", "").Do(ctx).StatusCode(&code).Raw()
+	e := endpointHealth{LatencyMS: time.Since(start).Milliseconds()}
+	switch {
+	case code == 0:
+		e.Status = healthUnreachable
+		if err != nil {
+			e.Failed = []string{err.Error()}
+		}
+	case code == http.StatusOK:
+		e.Status = healthOK
+	case code == http.StatusInternalServerError:
+		e.Status = healthFailed
+		e.Failed = failedHealthChecks(string(body))
+	default:
+		e.Status = fmt.Sprintf("HTTP %d", code)
+		if err != nil {
+			e.Failed = []string{err.Error()}
+		}
+	}
+	return e
+}
+
+// failedHealthChecks returns the names of the checks marked [-] in a
+// verbose health report, such as "etcd" for "[-]etcd failed: reason withheld"
+func failedHealthChecks(report string) []string {
+	var failed []string
+	for _, line := range strings.Split(report, "\n") {
+		check, found := strings.CutPrefix(strings.TrimSpace(line), "[-]")
+		if !found {
+			continue
+		}
+		if name, _, ok := strings.Cut(check, " "); ok {
+			check = name
+		}
+		failed = append(failed, check)
+	}
+	return failed
+}
+
+// controlPlanePods returns the readiness of the control plane pods in
+// kube-system, or nil when the cluster shows none or may not list them
+func controlPlanePods(ctx context.Context, c cluster.ClusterInfo, timeout time.Duration) *controlPlaneHealth {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	pods, err := c.Client.CoreV1().Pods("kube-system").List(ctx, metav1.ListOptions{LabelSelector: controlPlaneSelector})
+	if err != nil || len(pods.Items) == 0 {
+		return nil
+	}
+	cp := &controlPlaneHealth{Pods: len(pods.Items)}
+	for _, pod := range pods.Items {
+		if podReady(pod) {
+			cp.Ready++
+		} else {
+			cp.NotReady = append(cp.NotReady, pod.Name)
+		}
+	}
+	return cp
+}
+
+func podReady(pod corev1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+
+	"kubectl-multi/pkg/cluster"
+)
+
+func TestFailedHealthChecks(t *testing.T) {
+	report := `[+]ping ok
+[+]log ok
+[-]etcd failed: reason withheld
+[+]poststarthook/start-apiextensions-informers ok
+[-]poststarthook/crd-informer-synced failed: reason withheld
+readyz check failed`
+	want := []string{"etcd", "poststarthook/crd-informer-synced"}
+	if got := failedHealthChecks(report); !reflect.DeepEqual(got, want) {
+		t.Errorf("failedHealthChecks() = %v, want %v", got, want)
+	}
+}
+
+func controlPlanePod(name string, ready bool) *corev1.Pod {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: name, Labels: map[string]string{"tier": "control-plane"}},
+		Status:     corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}}},
+	}
+}
+
+func TestCheckClusterHealth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, verbose := r.URL.Query()["verbose"]; !verbose {
+			t.Errorf("%s called without verbose", r.URL.Path)
+		}
+		switch r.URL.Path {
+		case "/livez":
+			w.Write([]byte("[+]ping ok\nlivez check passed\n"))
+		case "/readyz":
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("[+]ping ok\n[-]etcd failed: reason withheld\nreadyz check failed\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	c := cluster.ClusterInfo{
+		Name:       "cluster1",
+		RestConfig: &rest.Config{Host: server.URL},
+		Client:     fake.NewSimpleClientset(controlPlanePod("etcd-cp", false), controlPlanePod("kube-apiserver-cp", true)),
+	}
+	h := checkClusterHealth(context.Background(), c, time.Second)
+
+	if h.Healthy {
+		t.Errorf("cluster with failing readyz reported healthy")
+	}
+	if h.Livez.Status != healthOK || h.Livez.Failed != nil {
+		t.Errorf("livez = %+v, want ok", h.Livez)
+	}
+	if h.Readyz.Status != healthFailed || !reflect.DeepEqual(h.Readyz.Failed, []string{"etcd"}) {
+		t.Errorf("readyz = %+v, want failed etcd", h.Readyz)
+	}
+	want := &controlPlaneHealth{Pods: 2, Ready: 1, NotReady: []string{"etcd-cp"}}
+	if !reflect.DeepEqual(h.ControlPlane, want) {
+		t.Errorf("control plane = %+v, want %+v", h.ControlPlane, want)
+	}
+}
+
+func TestCheckClusterHealthUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	c := cluster.ClusterInfo{Name: "gone", RestConfig: &rest.Config{Host: server.URL}, Client: fake.NewSimpleClientset()}
+	h := checkClusterHealth(context.Background(), c, time.Second)
+	if h.Healthy || h.Readyz.Status != healthUnreachable || h.Livez.Status != healthUnreachable {
+		t.Errorf("checkClusterHealth() = %+v, want unreachable", h)
+	}
+	if h.ControlPlane != nil {
+		t.Errorf("control plane of an unreachable cluster = %+v, want nil", h.ControlPlane)
+	}
+}
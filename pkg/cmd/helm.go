@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+)
+
+// mutatingHelmCommands are the helm subcommands recorded in the audit log
+var mutatingHelmCommands = map[string]bool{
+	"install":   true,
+	"upgrade":   true,
+	"uninstall": true,
+	"delete":    true,
+	"rollback":  true,
+}
+
+func newHelmCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "helm",
+		Short: "Run helm against managed clusters",
+		Long: `Run helm release operations against every targeted managed cluster, one
+cluster after another, using the kubeconfig context of each cluster.`,
+	}
+	cmd.AddCommand(newHelmRunCommand())
+	cmd.AddCommand(newHelmDiffValuesCommand())
+	cmd.AddCommand(newHelmTestCommand())
+	return cmd
+}
+
+func newHelmRunCommand() *cobra.Command {
+	var targets clusterTargets
+	var strategy fanOutStrategy
+
+	cmd := &cobra.Command{
+		Use:   "run [flags] -- HELM-ARGS...",
+		Short: "Run a helm command in each targeted managed cluster",
+		Example: `# Upgrade a release in the production clusters
+kubectl multi helm run --cluster-selector env=prod -- upgrade --install web ./chart -n web
+
+# List releases in two clusters
+kubectl multi helm run --clusters cluster1,cluster2 -- list -A
+
+# Upgrade one cluster at a time, halting at the first failed upgrade
+kubectl multi helm run --strategy waves --wave-size 1 -- upgrade web ./chart -n web`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateHelmArgs(args); err != nil {
+				return err
+			}
+			if err := strategy.validate(); err != nil {
+				return err
+			}
+			kubeconfig, remoteCtx, _, _, _ := GetGlobalFlags()
+			var rec *audit.Recorder
+			if mutatingHelmCommands[args[0]] {
+				rec = startAudit("helm " + args[0])
+			}
+			err := handleHelmRun(args, targets, strategy, rec, kubeconfig, remoteCtx)
+			finishAudit(rec, err)
+			return err
+		},
+	}
+
+	targets.addFlags(cmd, "run helm in")
+	targets.addPlacementFlag(cmd, "run helm in")
+	strategy.addFlags(cmd)
+
+	return cmd
+}
+
+// validateHelmArgs rejects flags that would point every run at one cluster,
+// and downloads in offline mode
+func validateHelmArgs(args []string) error {
+	for _, arg := range args {
+		name, _, _ := strings.Cut(arg, "=")
+		if name == "--kube-context" || name == "--kubeconfig" {
+			return fmt.Errorf("%s is set per cluster by kubectl multi and cannot be passed to helm", name)
+		}
+	}
+	return checkHelmOffline(args)
+}
+
+// helmRunArgs builds the helm command line for one cluster
+func helmRunArgs(args []string, clusterContext, kubeconfig string) []string {
+	return append(append([]string{}, args...), helmClusterArgs(clusterContext, kubeconfig)...)
+}
+
+func handleHelmRun(args []string, targets clusterTargets, strategy fanOutStrategy, rec *audit.Recorder, kubeconfig, remoteCtx string) error {
+	if _, err := exec.LookPath("helm"); err != nil {
+		return fmt.Errorf("helm is not installed or not in PATH: %v", err)
+	}
+
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	clusters, err = targets.selectFrom(clusters, kubeconfig)
+	if err != nil {
+		return err
+	}
+	if len(clusters) == 0 {
+		return fmt.Errorf("no clusters discovered")
+	}
+
+	trackFanOut(rec, fanOutTargets(clusters, remoteCtx))
+	var workload []cluster.ClusterInfo
+	for _, c := range clusters {
+		if c.Context == remoteCtx {
+			fmt.Printf("=== Cluster: %s ===\n", c.Name)
+			fmt.Printf("Cannot perform this operation on ITS (control) cluster: %s\n\n", c.Context)
+			continue
+		}
+		workload = append(workload, c)
+	}
+	if err := guardFanOut(rec, blastRadius{}, workload, remoteCtx); err != nil {
+		return err
+	}
+	failed := 0
+	haltErr := strategy.run(workload, func(c cluster.ClusterInfo) error {
+		fmt.Printf("=== Cluster: %s ===\n", c.Name)
+		rec.Begin(c.Name)
+
+		var stdout, stderr bytes.Buffer
+		helm := exec.CommandContext(commandContext(), "helm", helmRunArgs(args, c.Context, kubeconfig)...)
+		helm.Stdout = &stdout
+		helm.Stderr = &stderr
+		err := runObserved(helm, c.Name)
+		rec.Record(c.Name, err)
+		fmt.Print(stdout.String())
+		if err != nil {
+			failed++
+			fmt.Printf("Error: %v: %s\n", err, stderr.String())
+		}
+		fmt.Println()
+		return err
+	})
+	if haltErr != nil {
+		return haltErr
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("helm %s failed in %d cluster(s)", args[0], failed)
+	}
+	return nil
+}
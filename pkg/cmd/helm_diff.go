@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+// helmReleaseValues is a release as installed in one cluster
+type helmReleaseValues struct {
+	Cluster    string `json:"cluster"`
+	Chart      string `json:"chart"`
+	AppVersion string `json:"appVersion,omitempty"`
+	Revision   string `json:"revision"`
+
+	values map[string]interface{}
+}
+
+// helmValueDiff is a value that is not the same in every cluster with the
+// release, by cluster; a cluster without the value is absent
+type helmValueDiff struct {
+	Path   string            `json:"path"`
+	Values map[string]string `json:"values"`
+}
+
+// helmValuesReport is the result of helm diff-values
+type helmValuesReport struct {
+	Release   string              `json:"release"`
+	Namespace string              `json:"namespace"`
+	Clusters  []helmReleaseValues `json:"clusters"`
+	// NotInstalled are the targeted clusters without the release
+	NotInstalled []string        `json:"notInstalled,omitempty"`
+	Diffs        []helmValueDiff `json:"diffs"`
+}
+
+func newHelmDiffValuesCommand() *cobra.Command {
+	var outputFormat string
+	var allValues bool
+	var targets clusterTargets
+
+	cmd := &cobra.Command{
+		Use:   "diff-values RELEASE",
+		Short: "Compare the values and chart version of a release across clusters",
+		Long: `Read the values of a helm release with 'helm get values' in every targeted
+cluster that has it and print the chart versions and the values that are not
+the same everywhere, one column per cluster.
+
+By default only the values supplied at install or upgrade are compared; with
+--all the computed values, including the chart defaults, are compared too.`,
+		Example: `# Why does web behave differently in cluster2?
+kubectl multi helm diff-values web -n web
+
+# Include chart defaults, for the production clusters only
+kubectl multi helm diff-values web -n web --all --cluster-selector env=prod
+
+# Machine-readable result
+kubectl multi helm diff-values web -n web -o json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch outputFormat {
+			case "", "json", "yaml":
+			default:
+				return fmt.Errorf("unsupported output format %q, must be one of json|yaml", outputFormat)
+			}
+			kubeconfig, remoteCtx, _, namespace, _ := GetGlobalFlags()
+			return handleHelmDiffValues(args[0], cluster.GetTargetNamespace(namespace), allValues, outputFormat, targets, kubeconfig, remoteCtx)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "output format (json|yaml)")
+	cmd.Flags().BoolVar(&allValues, "all", false, "compare all computed values, not only the user-supplied ones")
+	targets.addFlags(cmd, "read the release from")
+	targets.addPlacementFlag(cmd, "read the release from")
+
+	return cmd
+}
+
+func handleHelmDiffValues(release, namespace string, allValues bool, outputFormat string, targets clusterTargets, kubeconfig, remoteCtx string) error {
+	if _, err := exec.LookPath("helm"); err != nil {
+		return fmt.Errorf("helm is not installed or not in PATH: %v", err)
+	}
+
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	clusters, err = targets.selectFrom(clusters, kubeconfig)
+	if err != nil {
+		return err
+	}
+	defer printClusterIssuesToStderr()
+
+	report := helmValuesReport{Release: release, Namespace: namespace}
+	for _, c := range clusters {
+		if c.Context == remoteCtx {
+			continue
+		}
+		rv, found, err := readHelmRelease(release, namespace, allValues, c.Name, c.Context, kubeconfig)
+		if err != nil {
+			noteClusterIssue(c.Name, err.Error())
+			continue
+		}
+		if !found {
+			report.NotInstalled = append(report.NotInstalled, c.Name)
+			continue
+		}
+		report.Clusters = append(report.Clusters, rv)
+	}
+	if len(report.Clusters) == 0 {
+		return fmt.Errorf("release %s not found in namespace %s of any cluster", release, namespace)
+	}
+	report.Diffs = diffHelmValues(report.Clusters)
+
+	if outputFormat != "" {
+		if report.Diffs == nil {
+			report.Diffs = []helmValueDiff{}
+		}
+		return util.PrintStructured(util.GetOutputStream(), outputFormat, report)
+	}
+	printHelmValuesReport(report)
+	return nil
+}
+
+func printHelmValuesReport(report helmValuesReport) {
+	if len(report.NotInstalled) > 0 {
+		fmt.Fprintf(os.Stderr, "Release %s is not installed in: %s\n", report.Release, strings.Join(report.NotInstalled, ", "))
+	}
+
+	tw := util.NewTableWriter(util.GetOutputStream(), "")
+	defer tw.Flush()
+	header := []string{"VALUE"}
+	charts := []string{"(chart)"}
+	for _, rv := range report.Clusters {
+		header = append(header, strings.ToUpper(rv.Cluster))
+		charts = append(charts, rv.Chart)
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	fmt.Fprintln(tw, strings.Join(charts, "\t"))
+	for _, d := range report.Diffs {
+		row := []string{d.Path}
+		for _, rv := range report.Clusters {
+			row = append(row, absentIfEmpty(d.Values[rv.Cluster]))
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	if len(report.Diffs) == 0 {
+		fmt.Fprintf(os.Stderr, "Values of release %s are the same in %d cluster(s).\n", report.Release, len(report.Clusters))
+	}
+}
+
+// readHelmRelease reads the chart and values of a release in one cluster.
+// found is false when the cluster does not have the release.
+func readHelmRelease(release, namespace string, allValues bool, clusterName, context, kubeconfig string) (helmReleaseValues, bool, error) {
+	rv := helmReleaseValues{Cluster: clusterName}
+
+	var listed []struct {
+		Name       string `json:"name"`
+		Revision   string `json:"revision"`
+		Chart      string `json:"chart"`
+		AppVersion string `json:"app_version"`
+	}
+	listArgs := []string{"list", "--namespace", namespace, "--filter", "^" + regexp.QuoteMeta(release) + "$", "--all", "-o", "json"}
+	if err := runHelmJSON(listArgs, clusterName, context, kubeconfig, &listed); err != nil {
+		return rv, false, err
+	}
+	if len(listed) == 0 {
+		return rv, false, nil
+	}
+	rv.Chart, rv.AppVersion, rv.Revision = listed[0].Chart, listed[0].AppVersion, listed[0].Revision
+
+	valuesArgs := []string{"get", "values", release, "--namespace", namespace, "-o", "json"}
+	if allValues {
+		valuesArgs = append(valuesArgs, "--all")
+	}
+	if err := runHelmJSON(valuesArgs, clusterName, context, kubeconfig, &rv.values); err != nil {
+		return rv, false, err
+	}
+	return rv, true, nil
+}
+
+// runHelmJSON runs a helm command against one cluster and decodes its JSON output
+func runHelmJSON(args []string, clusterName, context, kubeconfig string, v interface{}) error {
+	var command []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			break
+		}
+		command = append(command, arg)
+	}
+	var stdout, stderr bytes.Buffer
+	helm := exec.CommandContext(commandContext(), "helm", helmRunArgs(args, context, kubeconfig)...)
+	helm.Stdout = &stdout
+	helm.Stderr = &stderr
+	if err := runObserved(helm, clusterName); err != nil {
+		return fmt.Errorf("helm %s failed: %v: %s", strings.Join(command, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	if err := json.Unmarshal(stdout.Bytes(), v); err != nil {
+		return fmt.Errorf("failed to decode helm %s output: %v", strings.Join(command, " "), err)
+	}
+	return nil
+}
+
+// diffHelmValues returns the values that are not the same in every release,
+// sorted by path. Nested values are compared field by field, so one changed
+// key of a map shows as one row.
+func diffHelmValues(releases []helmReleaseValues) []helmValueDiff {
+	flattened := make([]map[string]string, len(releases))
+	paths := map[string]bool{}
+	for i, rv := range releases {
+		flattened[i] = map[string]string{}
+		if len(rv.values) > 0 {
+			flattenFields("", rv.values, flattened[i])
+		}
+		for path := range flattened[i] {
+			paths[path] = true
+		}
+	}
+
+	var diffs []helmValueDiff
+	for path := range paths {
+		d := helmValueDiff{Path: path, Values: map[string]string{}}
+		same := true
+		for i, rv := range releases {
+			value, ok := flattened[i][path]
+			if ok {
+				d.Values[rv.Cluster] = value
+			}
+			if !ok || value != flattened[0][path] {
+				same = false
+			}
+		}
+		if !same {
+			diffs = append(diffs, d)
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Path < diffs[j].Path })
+	return diffs
+}
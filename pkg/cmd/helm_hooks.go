@@ -0,0 +1,294 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+// Results of running the tests of a release in one cluster
+const (
+	helmTestPassed = "Passed"
+	helmTestFailed = "Failed"
+	helmTestError  = "Error"
+)
+
+// helmFailedPodPattern matches the test pods helm reports as failed on stderr
+var helmFailedPodPattern = regexp.MustCompile(`pod (\S+) failed`)
+
+// helmTestSuite is one test hook of a release and the phase of its pod
+type helmTestSuite struct {
+	Name  string `json:"name"`
+	Phase string `json:"phase"`
+}
+
+// helmTestResult is the outcome of helm test in one cluster
+type helmTestResult struct {
+	Cluster    string          `json:"cluster"`
+	Result     string          `json:"result"`
+	DurationMS int64           `json:"durationMs"`
+	Suites     []helmTestSuite `json:"suites,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	// Logs are the logs of the failed test pods, when --logs is set
+	Logs map[string]string `json:"logs,omitempty"`
+}
+
+func newHelmTestCommand() *cobra.Command {
+	var outputFormat string
+	var timeout time.Duration
+	var fetchLogs bool
+	var targets clusterTargets
+
+	cmd := &cobra.Command{
+		Use:   "test RELEASE",
+		Short: "Run the tests of a release in every targeted cluster",
+		Long: `Run 'helm test' for a release in every targeted cluster concurrently and
+report which clusters pass. The command fails when the tests fail in any
+cluster.
+
+With --logs, the logs of the test hook pods of the failing clusters are read
+and printed after the summary.`,
+		Example: `# Run the tests of web everywhere
+kubectl multi helm test web -n web
+
+# Show why the tests failed where they did
+kubectl multi helm test web -n web --logs
+
+# Per-cluster result as JSON, for CI
+kubectl multi helm test web -n web --timeout 10m -o json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch outputFormat {
+			case "", "json", util.TableFormatCSV, util.TableFormatMarkdown:
+			default:
+				return fmt.Errorf("unsupported output format %q, must be one of json|csv|markdown", outputFormat)
+			}
+			kubeconfig, remoteCtx, _, namespace, _ := GetGlobalFlags()
+			return handleHelmTest(args[0], cluster.GetTargetNamespace(namespace), timeout, fetchLogs, outputFormat, targets, kubeconfig, remoteCtx)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "output format (json|csv|markdown)")
+	cmd.Flags().DurationVar(&timeout, "timeout", 5*time.Minute, "time helm waits for the tests of one cluster")
+	cmd.Flags().BoolVar(&fetchLogs, "logs", false, "print the logs of the test pods of the failing clusters")
+	targets.addFlags(cmd, "test the release in")
+	targets.addPlacementFlag(cmd, "test the release in")
+
+	return cmd
+}
+
+func handleHelmTest(release, namespace string, timeout time.Duration, fetchLogs bool, outputFormat string, targets clusterTargets, kubeconfig, remoteCtx string) error {
+	if _, err := exec.LookPath("helm"); err != nil {
+		return fmt.Errorf("helm is not installed or not in PATH: %v", err)
+	}
+
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	clusters, err = targets.selectFrom(clusters, kubeconfig)
+	if err != nil {
+		return err
+	}
+	var workload []cluster.ClusterInfo
+	for _, c := range clusters {
+		if c.Context != remoteCtx {
+			workload = append(workload, c)
+		}
+	}
+	if len(workload) == 0 {
+		return fmt.Errorf("no clusters discovered")
+	}
+
+	results := make([]helmTestResult, len(workload))
+	var wg sync.WaitGroup
+	for i, c := range workload {
+		wg.Add(1)
+		go func(i int, c cluster.ClusterInfo) {
+			defer wg.Done()
+			results[i] = runHelmTest(release, namespace, timeout, c, kubeconfig)
+			if fetchLogs && results[i].Result != helmTestPassed {
+				results[i].Logs = helmTestLogs(c, namespace, failedTestPods(results[i]))
+			}
+		}(i, c)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, r := range results {
+		if r.Result != helmTestPassed {
+			failed++
+		}
+	}
+
+	if outputFormat == "json" {
+		if err := printJSONArray(results); err != nil {
+			return err
+		}
+	} else {
+		printHelmTestResults(results, outputFormat)
+	}
+	if failed > 0 {
+		return fmt.Errorf("tests of release %s failed in %d cluster(s)", release, failed)
+	}
+	return nil
+}
+
+func printHelmTestResults(results []helmTestResult, outputFormat string) {
+	out := util.GetOutputStream()
+	tw := util.NewTableWriter(out, outputFormat)
+	fmt.Fprintf(tw, "CLUSTER\tRESULT\tTESTS\tDURATION\tERROR\n")
+	for _, r := range results {
+		passed := 0
+		for _, s := range r.Suites {
+			if s.Phase == string(corev1.PodSucceeded) {
+				passed++
+			}
+		}
+		tests := fmt.Sprintf("%d/%d passed", passed, len(r.Suites))
+		if len(r.Suites) == 0 {
+			tests = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.Cluster, r.Result, tests,
+			(time.Duration(r.DurationMS) * time.Millisecond).String(), orNone(r.Error))
+	}
+	tw.Flush()
+
+	for _, r := range results {
+		for _, pod := range sortedKeys(r.Logs) {
+			fmt.Fprintf(out, "\n=== Cluster: %s, pod: %s ===\n%s", r.Cluster, pod, r.Logs[pod])
+		}
+	}
+}
+
+// runHelmTest runs helm test for a release in one cluster
+func runHelmTest(release, namespace string, timeout time.Duration, c cluster.ClusterInfo, kubeconfig string) helmTestResult {
+	r := helmTestResult{Cluster: c.Name}
+	args := []string{"test", release, "--namespace", namespace, "--timeout", timeout.String()}
+	var stdout, stderr bytes.Buffer
+	helm := exec.CommandContext(commandContext(), "helm", helmRunArgs(args, c.Context, kubeconfig)...)
+	helm.Stdout = &stdout
+	helm.Stderr = &stderr
+	start := time.Now()
+	err := runObserved(helm, c.Name)
+	r.DurationMS = time.Since(start).Milliseconds()
+	r.Suites = parseHelmTestSuites(stdout.String())
+
+	for _, m := range helmFailedPodPattern.FindAllStringSubmatch(stderr.String(), -1) {
+		if !hasHelmTestSuite(r.Suites, m[1]) {
+			r.Suites = append(r.Suites, helmTestSuite{Name: m[1], Phase: string(corev1.PodFailed)})
+		}
+	}
+
+	switch {
+	case err == nil:
+		r.Result = helmTestPassed
+	case len(r.Suites) > 0:
+		r.Result = helmTestFailed
+		r.Error = helmErrorMessage(stderr.String(), err)
+	default:
+		// helm could not run the tests, such as for a release that is not installed
+		r.Result = helmTestError
+		r.Error = helmErrorMessage(stderr.String(), err)
+	}
+	return r
+}
+
+// helmErrorMessage joins helm's error output into one line, or returns err
+// when helm printed nothing
+func helmErrorMessage(stderr string, err error) string {
+	var parts []string
+	for _, line := range strings.Split(stderr, "\n") {
+		line = strings.TrimPrefix(strings.TrimSpace(line), "Error: ")
+		line = strings.TrimPrefix(line, "* ")
+		if line != "" {
+			parts = append(parts, line)
+		}
+	}
+	if len(parts) == 0 {
+		return err.Error()
+	}
+	return strings.Join(parts, " ")
+}
+
+// parseHelmTestSuites reads the TEST SUITE and Phase lines helm test prints
+// for every test hook of the release
+func parseHelmTestSuites(output string) []helmTestSuite {
+	var suites []helmTestSuite
+	for _, line := range strings.Split(output, "\n") {
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "TEST SUITE":
+			if value != "None" {
+				suites = append(suites, helmTestSuite{Name: value})
+			}
+		case "Phase":
+			if len(suites) > 0 {
+				suites[len(suites)-1].Phase = value
+			}
+		}
+	}
+	return suites
+}
+
+func hasHelmTestSuite(suites []helmTestSuite, name string) bool {
+	for _, s := range suites {
+		if s.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// failedTestPods returns the test pods of a result that did not succeed
+func failedTestPods(r helmTestResult) []string {
+	var pods []string
+	for _, s := range r.Suites {
+		if s.Phase != string(corev1.PodSucceeded) {
+			pods = append(pods, s.Name)
+		}
+	}
+	return pods
+}
+
+// helmTestLogs reads the logs of every container of the given test pods,
+// keyed by pod name. A pod helm already deleted is reported in place of its log.
+func helmTestLogs(c cluster.ClusterInfo, namespace string, podNames []string) map[string]string {
+	if c.Client == nil || len(podNames) == 0 {
+		return nil
+	}
+	logs := map[string]string{}
+	for _, name := range podNames {
+		pod, err := c.Client.CoreV1().Pods(namespace).Get(commandContext(), name, metav1.GetOptions{})
+		if err != nil {
+			logs[name] = fmt.Sprintf("failed to get pod: %v\n", err)
+			continue
+		}
+		var buf bytes.Buffer
+		for _, t := range logTargets(c, []corev1.Pod{*pod}, "", true) {
+			if len(pod.Spec.InitContainers)+len(pod.Spec.Containers) > 1 {
+				fmt.Fprintf(&buf, "--- container %s ---\n", t.container)
+			}
+			if err := t.stream(&corev1.PodLogOptions{}, &buf); err != nil {
+				fmt.Fprintf(&buf, "failed to read logs of %s: %v\n", t.describe(), err)
+			}
+		}
+		logs[name] = buf.String()
+	}
+	return logs
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestValidateHelmArgs(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr bool
+	}{
+		{[]string{"upgrade", "--install", "web", "./chart", "-n", "web"}, false},
+		{[]string{"list", "-A"}, false},
+		{[]string{"list", "--kube-context", "cluster1"}, true},
+		{[]string{"list", "--kube-context=cluster1"}, true},
+		{[]string{"status", "web", "--kubeconfig=/tmp/kc"}, true},
+	}
+	for _, tt := range tests {
+		if err := validateHelmArgs(tt.args); (err != nil) != tt.wantErr {
+			t.Errorf("validateHelmArgs(%q) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+		}
+	}
+}
+
+func TestHelmRunArgs(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		context    string
+		kubeconfig string
+		want       []string
+	}{
+		{
+			name:    "context only",
+			args:    []string{"list", "-A"},
+			context: "cluster1",
+			want:    []string{"list", "-A", "--kube-context", "cluster1"},
+		},
+		{
+			name:       "explicit kubeconfig",
+			args:       []string{"upgrade", "--install", "web", "./chart"},
+			context:    "cluster2",
+			kubeconfig: "/tmp/kc",
+			want:       []string{"upgrade", "--install", "web", "./chart", "--kubeconfig", "/tmp/kc", "--kube-context", "cluster2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orig := append([]string{}, tt.args...)
+			if got := helmRunArgs(tt.args, tt.context, tt.kubeconfig); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("helmRunArgs() = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(tt.args, orig) {
+				t.Errorf("helmRunArgs() modified its input")
+			}
+		})
+	}
+}
+
+func TestDiffHelmValues(t *testing.T) {
+	releases := []helmReleaseValues{
+		{Cluster: "cluster1", Chart: "web-1.2.0", values: map[string]interface{}{
+			"replicaCount": float64(3),
+			"image":        map[string]interface{}{"repository": "nginx", "tag": "1.27"},
+			"ingress":      map[string]interface{}{"enabled": true},
+		}},
+		{Cluster: "cluster2", Chart: "web-1.1.0", values: map[string]interface{}{
+			"replicaCount": float64(2),
+			"image":        map[string]interface{}{"repository": "nginx", "tag": "1.27"},
+		}},
+		{Cluster: "cluster3", Chart: "web-1.2.0", values: map[string]interface{}{
+			"replicaCount": float64(3),
+			"image":        map[string]interface{}{"repository": "nginx", "tag": "1.27"},
+			"ingress":      map[string]interface{}{"enabled": true},
+		}},
+	}
+
+	want := []helmValueDiff{
+		{Path: "ingress.enabled", Values: map[string]string{"cluster1": "true", "cluster3": "true"}},
+		{Path: "replicaCount", Values: map[string]string{"cluster1": "3", "cluster2": "2", "cluster3": "3"}},
+	}
+	if got := diffHelmValues(releases); !reflect.DeepEqual(got, want) {
+		t.Errorf("diffHelmValues() = %+v, want %+v", got, want)
+	}
+
+	releases[1].values = nil
+	if got := diffHelmValues(releases[1:2]); got != nil {
+		t.Errorf("diffHelmValues() of one release without values = %+v, want none", got)
+	}
+}
+
+func TestParseHelmTestSuites(t *testing.T) {
+	output := `NAME: web
+LAST DEPLOYED: Mon Jun  2 10:00:00 2025
+NAMESPACE: web
+STATUS: deployed
+REVISION: 3
+TEST SUITE:     web-test-connection
+Last Started:   Mon Jun  2 10:05:00 2025
+Last Completed: Mon Jun  2 10:05:04 2025
+Phase:          Succeeded
+TEST SUITE:     web-test-db
+Last Started:   Mon Jun  2 10:05:04 2025
+Last Completed: Mon Jun  2 10:05:09 2025
+Phase:          Failed
+NOTES:
+Visit http://web.example.com
+`
+	want := []helmTestSuite{{Name: "web-test-connection", Phase: "Succeeded"}, {Name: "web-test-db", Phase: "Failed"}}
+	if got := parseHelmTestSuites(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseHelmTestSuites() = %+v, want %+v", got, want)
+	}
+	if got := parseHelmTestSuites("TEST SUITE: None\n"); got != nil {
+		t.Errorf("parseHelmTestSuites() of a release without tests = %+v, want none", got)
+	}
+}
+
+func TestFailedTestPods(t *testing.T) {
+	r := helmTestResult{Suites: []helmTestSuite{
+		{Name: "web-test-connection", Phase: "Succeeded"},
+		{Name: "web-test-db", Phase: "Failed"},
+		{Name: "web-test-cache", Phase: "Running"},
+	}}
+	want := []string{"web-test-db", "web-test-cache"}
+	if got := failedTestPods(r); !reflect.DeepEqual(got, want) {
+		t.Errorf("failedTestPods() = %v, want %v", got, want)
+	}
+}
+
+func TestHelmErrorMessage(t *testing.T) {
+	stderr := "Error: 1 error occurred:\n\t* pod web-test-db failed\n"
+	if got := helmErrorMessage(stderr, errors.New("exit status 1")); got != "1 error occurred: pod web-test-db failed" {
+		t.Errorf("helmErrorMessage() = %q", got)
+	}
+	if got := helmErrorMessage("", errors.New("exit status 1")); got != "exit status 1" {
+		t.Errorf("helmErrorMessage() without stderr = %q, want the exit error", got)
+	}
+}
@@ -0,0 +1,368 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/config"
+	"kubectl-multi/pkg/hooks"
+	"kubectl-multi/pkg/util"
+)
+
+func newHistoryCommand() *cobra.Command {
+	var limit int
+	var clusterName string
+	var command string
+	var outputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show the audit log of mutating fleet operations",
+		Long: `Show the mutating operations (apply, namespace, label, install, rollout,
+run, ...) recorded in the local audit log ($KUBECTL_MULTI_AUDIT_LOG or
+~/.kube/kubectl-multi-audit.jsonl), newest first, with who ran them, the
+target clusters and the per-cluster result.`,
+		Example: `# Last 20 fleet mutations
+kubectl multi history
+
+# Everything done to cluster2 with apply
+kubectl multi history --cluster cluster2 --command apply --limit 0
+
+# Full entries including per-cluster errors
+kubectl multi history -o json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch outputFormat {
+			case "", "json", "yaml":
+			default:
+				return fmt.Errorf("unsupported output format %q, must be one of json|yaml", outputFormat)
+			}
+			return handleHistoryCommand(limit, clusterName, command, outputFormat)
+		},
+	}
+
+	cmd.Flags().IntVar(&limit, "limit", 20, "maximum number of entries to show (0 for all)")
+	cmd.Flags().StringVar(&clusterName, "cluster", "", "only show operations that targeted this cluster")
+	cmd.Flags().StringVar(&command, "command", "", "only show operations of this command (e.g. apply)")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "output format (json|yaml)")
+
+	return cmd
+}
+
+func handleHistoryCommand(limit int, clusterName, command, outputFormat string) error {
+	entries, err := audit.Read()
+	if err != nil {
+		return err
+	}
+
+	// Newest first, filtered
+	var selected []audit.Entry
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if command != "" && e.Command != command && !strings.HasPrefix(e.Command, command+" ") {
+			continue
+		}
+		if clusterName != "" && !containsString(e.Clusters, clusterName) {
+			continue
+		}
+		selected = append(selected, e)
+		if limit > 0 && len(selected) == limit {
+			break
+		}
+	}
+
+	if outputFormat != "" {
+		if selected == nil {
+			selected = []audit.Entry{}
+		}
+		return util.PrintStructured(util.GetOutputStream(), outputFormat, selected)
+	}
+
+	tw := tabwriter.NewWriter(util.GetOutputStream(), 0, 0, 2, ' ', 0)
+	defer tw.Flush()
+
+	if len(selected) == 0 {
+		fmt.Fprintf(tw, "No recorded operations.\n")
+		return nil
+	}
+
+	fmt.Fprintf(tw, "ID\tTIME\tUSER\tCOMMAND\tCLUSTERS\tRESULT\tDURATION\tARGS\n")
+	for _, e := range selected {
+		clusters := strings.Join(e.Clusters, ",")
+		if clusters == "" {
+			clusters = "<none>"
+		}
+		duration := "-"
+		if e.DurationMs > 0 {
+			duration = roundDuration(time.Duration(e.DurationMs) * time.Millisecond)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.ID, e.Time.Local().Format("2006-01-02 15:04:05"), e.User, e.Command, clusters, historyResult(e), duration, strings.Join(e.Args, " "))
+	}
+	return nil
+}
+
+// historyResult summarizes an entry as ok, or the number of failed clusters
+func historyResult(e audit.Entry) string {
+	if e.Succeeded() {
+		return "ok"
+	}
+	failed := 0
+	for _, r := range e.Results {
+		if r.Status != "ok" {
+			failed++
+		}
+	}
+	if failed == 0 {
+		return "failed"
+	}
+	return fmt.Sprintf("failed (%d/%d)", failed, len(e.Results))
+}
+
+// startAudit begins recording a mutating command invocation
+func startAudit(command string) *audit.Recorder {
+	return audit.Start(command, os.Args[1:])
+}
+
+// fanOutProgressMin is the number of clusters from which a mutating command
+// reports its progress and a timing summary on stderr
+const fanOutProgressMin = 5
+
+// trackFanOut announces that the recorded operation changes total clusters.
+// From fanOutProgressMin clusters on, and when stderr is a terminal, a line
+// with the running ETA is printed as each cluster completes.
+func trackFanOut(rec *audit.Recorder, total int) {
+	if rec == nil {
+		return
+	}
+	if total < fanOutProgressMin || !term.IsTerminal(int(os.Stderr.Fd())) {
+		rec.Expect(total, nil)
+		return
+	}
+	completed := 0
+	rec.Expect(total, func(p audit.Progress) {
+		// Only completions move the estimate
+		if p.Completed == completed {
+			return
+		}
+		completed = p.Completed
+		fmt.Fprintln(os.Stderr, progressLine(p))
+	})
+}
+
+// fanOutTargets counts the clusters a command changes: all but the ITS
+func fanOutTargets(clusters []cluster.ClusterInfo, itsContext string) int {
+	n := 0
+	for _, c := range clusters {
+		if c.Context != itsContext {
+			n++
+		}
+	}
+	return n
+}
+
+// progressLine reports a running operation, e.g.
+// "[4/10] 1 failed, 1 in flight, 8s elapsed, ~12s remaining"
+func progressLine(p audit.Progress) string {
+	line := fmt.Sprintf("[%d/%d] %d failed, %d in flight, %s elapsed", p.Completed, p.Total, p.Failed, p.InFlight, roundDuration(p.Elapsed))
+	if p.Completed < p.Total {
+		line += ", ~" + roundDuration(p.Remaining) + " remaining"
+	}
+	return line
+}
+
+// fanOutSummary reports how long a finished operation took, how many
+// clusters succeeded and which one was slowest
+func fanOutSummary(e audit.Entry) string {
+	durations := map[string]time.Duration{}
+	failed := map[string]bool{}
+	for _, r := range e.Results {
+		durations[r.Cluster] += time.Duration(r.DurationMs) * time.Millisecond
+		if r.Status != "ok" {
+			failed[r.Cluster] = true
+		}
+	}
+	slowest := ""
+	for _, name := range sortedKeys(durations) {
+		if slowest == "" || durations[name] > durations[slowest] {
+			slowest = name
+		}
+	}
+	summary := fmt.Sprintf("%d cluster(s) in %s: %d succeeded, %d failed", len(durations), roundDuration(time.Duration(e.DurationMs)*time.Millisecond), len(durations)-len(failed), len(failed))
+	if slowest != "" {
+		summary += fmt.Sprintf("; slowest %s (%s)", slowest, roundDuration(durations[slowest]))
+	}
+	return summary
+}
+
+// roundDuration keeps a tenth of a second below ten seconds and whole
+// seconds above
+func roundDuration(d time.Duration) string {
+	if d < 10*time.Second {
+		return d.Round(100 * time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
+
+// finishAudit releases the fleet lock, then notifies the configured hooks
+// and writes the recorded operation to the local audit log and, when
+// configured, to the audit ConfigMap in the WDS. Hook and audit failures
+// only warn.
+func finishAudit(rec *audit.Recorder, err error) {
+	releaseFleetLock()
+	if rec == nil {
+		return
+	}
+	entry := rec.Finish(err)
+	if len(entry.Clusters) >= fanOutProgressMin {
+		fmt.Fprintln(os.Stderr, fanOutSummary(entry))
+	}
+
+	cfg, cfgErr := config.Load()
+	if cfgErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: audit: %v\n", cfgErr)
+		cfg = &config.Config{}
+	}
+	notifyHooks(cfg.Hooks, entry)
+	settings := cfg.AuditSettings()
+	if settings.Disabled {
+		return
+	}
+
+	if err := audit.Append(entry); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: audit: %v\n", err)
+	}
+	if settings.ConfigMap != "" {
+		if err := recordAuditConfigMap(entry, settings); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: audit: %v\n", err)
+		}
+	}
+}
+
+// hooksTimeout bounds the notification of all the hooks together, whatever
+// their own timeouts, so slow hooks cannot hold up the command for long
+var hooksTimeout = 30 * time.Second
+
+// notifyHooks runs the hooks interested in the operation at the same time,
+// then warns of the failed ones in the configured order. Not the command
+// context: a cancelled command is still reported.
+func notifyHooks(configured []config.Hook, entry audit.Entry) {
+	summary := hooks.NewSummary(entry)
+	ctx, cancel := context.WithTimeout(context.Background(), hooksTimeout)
+	defer cancel()
+
+	errs := make([]error, len(configured))
+	var wg sync.WaitGroup
+	for i, h := range configured {
+		if !hooks.Matches(h, summary) {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, h config.Hook) {
+			defer wg.Done()
+			errs[i] = hooks.Run(ctx, h, summary)
+		}(i, h)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+}
+
+// auditRequestTimeout bounds the requests writing the audit ConfigMap, so an
+// unreachable WDS does not hold up the end of the command
+const auditRequestTimeout = 10 * time.Second
+
+// recordAuditConfigMap stores the entry under its operation ID in the audit ConfigMap
+func recordAuditConfigMap(entry audit.Entry, settings config.AuditConfig) error {
+	kubeconfig, _, _, _, _ := GetGlobalFlags()
+	wds, err := cluster.ClientForContext(kubeconfig, GetWDSContext())
+	if err != nil {
+		return err
+	}
+	return writeAuditConfigMap(wds.Client, entry, settings)
+}
+
+// writeAuditConfigMap adds the entry to the audit ConfigMap, which whoever
+// reads ConfigMaps in its namespace can read, so Secret contents captured
+// for undo are left out
+func writeAuditConfigMap(client kubernetes.Interface, entry audit.Entry, settings config.AuditConfig) error {
+	ns := settings.Namespace
+	if ns == "" {
+		ns = "default"
+	}
+	data, err := json.Marshal(entry.Shared())
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %v", err)
+	}
+	key := entry.ID
+	maxEntries := settings.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = config.DefaultAuditMaxEntries
+	}
+
+	// Not the command context: a cancelled command is still recorded
+	ctx, cancel := context.WithTimeout(context.Background(), auditRequestTimeout)
+	defer cancel()
+	cms := client.CoreV1().ConfigMaps(ns)
+	// Concurrent commands race to create or update the ConfigMap; the loser
+	// adds its entry again to what the winner wrote
+	err = retry.OnError(retry.DefaultRetry, func(err error) bool {
+		return apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err)
+	}, func() error {
+		cm, err := cms.Get(ctx, settings.ConfigMap, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: settings.ConfigMap, Namespace: ns},
+				Data:       map[string]string{key: string(data)},
+			}
+			util.MarkManaged(cm)
+			_, err = cms.Create(ctx, cm, metav1.CreateOptions{FieldManager: util.FieldManager})
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[key] = string(data)
+		audit.PruneEntries(cm.Data, maxEntries, config.AuditConfigMapMaxBytes)
+		util.MarkManaged(cm)
+		_, err = cms.Update(ctx, cm, metav1.UpdateOptions{FieldManager: util.FieldManager})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write audit configmap %s/%s: %v", ns, settings.ConfigMap, err)
+	}
+	return nil
+}
+
+// containsString reports whether list holds value
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
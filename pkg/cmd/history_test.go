@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/config"
+)
+
+func TestProgressLine(t *testing.T) {
+	p := audit.Progress{Total: 10, Completed: 4, Failed: 1, InFlight: 1, Elapsed: 8 * time.Second, Remaining: 12 * time.Second}
+	if got, want := progressLine(p), "[4/10] 1 failed, 1 in flight, 8s elapsed, ~12s remaining"; got != want {
+		t.Errorf("progressLine() = %q, want %q", got, want)
+	}
+	p = audit.Progress{Total: 10, Completed: 10, Elapsed: 1500 * time.Millisecond}
+	if got, want := progressLine(p), "[10/10] 0 failed, 0 in flight, 1.5s elapsed"; got != want {
+		t.Errorf("progressLine() = %q, want %q", got, want)
+	}
+}
+
+func TestFanOutSummary(t *testing.T) {
+	entry := audit.Entry{
+		DurationMs: 42300,
+		Results: []audit.ClusterResult{
+			{Cluster: "cluster1", Status: "ok", DurationMs: 3000},
+			{Cluster: "cluster2", Status: "error", DurationMs: 12400},
+			{Cluster: "cluster3", Status: "ok", DurationMs: 5000},
+			{Cluster: "cluster3", Status: "ok", DurationMs: 9000},
+		},
+	}
+	want := "3 cluster(s) in 42s: 2 succeeded, 1 failed; slowest cluster3 (14s)"
+	if got := fanOutSummary(entry); got != want {
+		t.Errorf("fanOutSummary() = %q, want %q", got, want)
+	}
+}
+
+// resultsWithoutDurations returns the per-cluster results of an entry with
+// their timings cleared, for comparing them in tests
+func resultsWithoutDurations(e audit.Entry) []audit.ClusterResult {
+	for i := range e.Results {
+		e.Results[i].DurationMs = 0
+	}
+	return e.Results
+}
+
+func TestNotifyHooksSharesDeadline(t *testing.T) {
+	var calls int32
+	// The webhook does not answer before the test is over
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	defer func(timeout time.Duration) { hooksTimeout = timeout }(hooksTimeout)
+	hooksTimeout = 200 * time.Millisecond
+	configured := []config.Hook{
+		{Name: "first", URL: server.URL},
+		{Name: "second", URL: server.URL},
+		{Name: "failures only", URL: server.URL, On: []string{config.HookOnFailure}},
+	}
+	start := time.Now()
+	notifyHooks(configured, audit.Entry{Command: "apply"})
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("notifyHooks() took %v, want the hooks cut off together at %v", elapsed, hooksTimeout)
+	}
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Errorf("webhooks called %d times, want the 2 matching hooks", n)
+	}
+}
+
+func TestWriteAuditConfigMapRedactsSecrets(t *testing.T) {
+	const password = "aHVudGVyMg=="
+	secret := testObject("v1", "Secret", "prod", "db")
+	secret.Object["data"] = map[string]interface{}{"password": password}
+	client := kubefake.NewSimpleClientset()
+	settings := config.AuditConfig{ConfigMap: "kubectl-multi-audit", Namespace: "kube-system"}
+
+	// The first entry creates the ConfigMap, the second updates it
+	for _, id := range []string{"lq3x9k2a1b", "lq3x9k2a1c"} {
+		entry := audit.Entry{ID: id, Command: "apply", Undo: []audit.UndoStep{
+			{Cluster: "cluster1", Action: audit.UndoRestore, Version: "v1", Resource: "secrets", Namespace: "prod", Name: "db", Object: secret.Object},
+		}}
+		if err := writeAuditConfigMap(client, entry, settings); err != nil {
+			t.Fatalf("writeAuditConfigMap(%s) error = %v", id, err)
+		}
+	}
+
+	cm, err := client.CoreV1().ConfigMaps("kube-system").Get(context.Background(), "kubectl-multi-audit", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cm.Data) != 2 {
+		t.Errorf("audit configmap holds %d entries, want 2", len(cm.Data))
+	}
+	for id, data := range cm.Data {
+		if strings.Contains(data, password) || !strings.Contains(data, `"password":"REDACTED"`) {
+			t.Errorf("audit configmap entry %s = %s, want the secret data redacted", id, data)
+		}
+	}
+	if secret.Object["data"].(map[string]interface{})["password"] != password {
+		t.Error("writeAuditConfigMap() redacted the entry kept for the local log")
+	}
+}
+
+func TestWriteAuditConfigMapRetriesRaces(t *testing.T) {
+	settings := config.AuditConfig{ConfigMap: "kubectl-multi-audit", Namespace: "kube-system"}
+	gr := schema.GroupResource{Resource: "configmaps"}
+	for _, tt := range []struct {
+		name  string
+		verb  string
+		exist bool
+		lose  error
+	}{
+		{name: "create", verb: "create", lose: apierrors.NewAlreadyExists(gr, settings.ConfigMap)},
+		{name: "update", verb: "update", exist: true, lose: apierrors.NewConflict(gr, settings.ConfigMap, nil)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			client := kubefake.NewSimpleClientset()
+			cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: settings.ConfigMap, Namespace: settings.Namespace}, Data: map[string]string{}}
+			if tt.exist {
+				if _, err := client.CoreV1().ConfigMaps(settings.Namespace).Create(context.Background(), cm, metav1.CreateOptions{}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// Another command writes its entry in between, once
+			raced := false
+			client.PrependReactor(tt.verb, "configmaps", func(clienttesting.Action) (bool, runtime.Object, error) {
+				if raced {
+					return false, nil, nil
+				}
+				raced = true
+				other := cm.DeepCopy()
+				other.Data["lq3x9k2a1a"] = `{"id":"lq3x9k2a1a"}`
+				write := client.Tracker().Create
+				if tt.exist {
+					write = client.Tracker().Update
+				}
+				if err := write(gr.WithVersion("v1"), other, settings.Namespace); err != nil {
+					t.Error(err)
+				}
+				return true, nil, tt.lose
+			})
+
+			if err := writeAuditConfigMap(client, audit.Entry{ID: "lq3x9k2a1b", Command: "apply"}, settings); err != nil {
+				t.Fatalf("writeAuditConfigMap() error = %v", err)
+			}
+			got, err := client.CoreV1().ConfigMaps(settings.Namespace).Get(context.Background(), settings.ConfigMap, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := got.Data["lq3x9k2a1a"]; !ok {
+				t.Error("writeAuditConfigMap() dropped the entry of the concurrent command")
+			}
+			if _, ok := got.Data["lq3x9k2a1b"]; !ok {
+				t.Error("writeAuditConfigMap() did not add its entry after the race")
+			}
+		})
+	}
+}
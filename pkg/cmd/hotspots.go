@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+
+	"kubectl-multi/pkg/util"
+)
+
+// Reasons a container is reported by hotspots, most severe first
+const (
+	hotspotCrashLoop = "CrashLoopBackOff"
+	hotspotOOMKilled = "OOMKilled"
+	hotspotImagePull = "ImagePullBackOff"
+	hotspotRestarts  = "HighRestarts"
+)
+
+var hotspotSeverity = map[string]int{
+	hotspotCrashLoop: 0,
+	hotspotOOMKilled: 1,
+	hotspotImagePull: 2,
+	hotspotRestarts:  3,
+}
+
+// hotspot is one unhealthy container
+type hotspot struct {
+	Cluster   string     `json:"cluster"`
+	Namespace string     `json:"namespace"`
+	Pod       string     `json:"pod"`
+	Container string     `json:"container"`
+	Reasons   []string   `json:"reasons"`
+	Restarts  int32      `json:"restarts"`
+	LastExit  *time.Time `json:"lastExit,omitempty"`
+	Message   string     `json:"message,omitempty"`
+}
+
+func newHotspotsCommand() *cobra.Command {
+	var outputFormat string
+	var selector string
+	var since time.Duration
+	var minRestarts int32
+	var targets clusterTargets
+	var reach reachability
+
+	cmd := &cobra.Command{
+		Use:   "hotspots",
+		Short: "List crash-looping, OOM-killed and image-pull-failing containers across managed clusters",
+		Long: `Scan the pods of every managed cluster for containers that are in
+CrashLoopBackOff, cannot pull their image, were OOM-killed within --since, or
+have restarted at least --min-restarts times with the last restart within
+--since, and print them as one triage list, most severe first:
+
+  CrashLoopBackOff  the container keeps crashing
+  OOMKilled         the last exit was for running out of memory
+  ImagePullBackOff  the image cannot be pulled (also ErrImagePull)
+  HighRestarts      many restarts, the last one recent`,
+		Example: `# Triage list for the whole fleet over the last hour
+kubectl multi hotspots -A
+
+# OOM kills and restarts of the last day in prod, as JSON
+kubectl multi hotspots -n prod --since 24h -o json
+
+# Be stricter about restart counts
+kubectl multi hotspots -A --min-restarts 20`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch outputFormat {
+			case "", "json", util.TableFormatCSV, util.TableFormatMarkdown:
+			default:
+				return fmt.Errorf("unsupported output format %q, must be one of json|csv|markdown", outputFormat)
+			}
+			if since <= 0 {
+				return fmt.Errorf("--since must be a positive duration")
+			}
+			selector, err := parseSelector(selector)
+			if err != nil {
+				return err
+			}
+			kubeconfig, remoteCtx, _, namespace, allNamespaces := GetGlobalFlags()
+			return handleHotspotsCommand(outputFormat, selector, since, minRestarts, targets, reach, kubeconfig, remoteCtx, namespace, allNamespaces)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "output format (json|csv|markdown)")
+	cmd.Flags().StringVarP(&selector, "selector", "l", "", "label selector on the pods")
+	cmd.Flags().DurationVar(&since, "since", time.Hour, "only count OOM kills and restarts that happened this recently")
+	cmd.Flags().Int32Var(&minRestarts, "min-restarts", 5, "restart count from which a container with a recent restart is reported")
+	targets.addFlags(cmd, "scan")
+	reach.addFlags(cmd)
+
+	return cmd
+}
+
+func handleHotspotsCommand(outputFormat, selector string, since time.Duration, minRestarts int32, targets clusterTargets, reach reachability, kubeconfig, remoteCtx, namespace string, allNamespaces bool) error {
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	clusters, err = targets.selectFrom(clusters, kubeconfig)
+	if err != nil {
+		return err
+	}
+	clusters, err = reach.filter(clusters)
+	if err != nil {
+		return err
+	}
+	defer printClusterIssuesToStderr()
+
+	listed := listClusterPods(clusters, selector, namespace, allNamespaces)
+	spots := findHotspots(listed, time.Now().Add(-since), minRestarts)
+
+	if outputFormat == "json" {
+		return printJSONArray(spots)
+	}
+
+	tw := util.NewTableWriter(util.GetOutputStream(), outputFormat)
+	defer tw.Flush()
+	if len(spots) == 0 {
+		fmt.Fprintln(os.Stderr, "No hotspots found.")
+		return nil
+	}
+	fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tPOD\tCONTAINER\tREASONS\tRESTARTS\tLAST EXIT\tMESSAGE\n")
+	for _, s := range spots {
+		lastExit := "<none>"
+		if s.LastExit != nil {
+			lastExit = duration.HumanDuration(time.Since(*s.LastExit)) + " ago"
+		}
+		message := s.Message
+		if len(message) > 80 {
+			message = message[:77] + "..."
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			s.Cluster, s.Namespace, s.Pod, s.Container, strings.Join(s.Reasons, ","), s.Restarts, lastExit, message)
+	}
+	return nil
+}
+
+// findHotspots returns the unhealthy containers of the listed pods, most
+// severe reason first, then most restarts, then by cluster, namespace and pod
+func findHotspots(listed []clusterPods, cutoff time.Time, minRestarts int32) []hotspot {
+	var spots []hotspot
+	for _, cp := range listed {
+		for _, pod := range cp.pods {
+			statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+			for _, status := range statuses {
+				if s, ok := containerHotspot(status, cutoff, minRestarts); ok {
+					s.Cluster = cp.cluster
+					s.Namespace = pod.Namespace
+					s.Pod = pod.Name
+					spots = append(spots, s)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(spots, func(i, j int) bool {
+		a, b := spots[i], spots[j]
+		if sa, sb := hotspotSeverity[a.Reasons[0]], hotspotSeverity[b.Reasons[0]]; sa != sb {
+			return sa < sb
+		}
+		if a.Restarts != b.Restarts {
+			return a.Restarts > b.Restarts
+		}
+		if a.Cluster != b.Cluster {
+			return a.Cluster < b.Cluster
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Pod < b.Pod
+	})
+	return spots
+}
+
+// containerHotspot reports whether a container is unhealthy and why, with
+// its reasons ordered by severity
+func containerHotspot(status corev1.ContainerStatus, cutoff time.Time, minRestarts int32) (hotspot, bool) {
+	s := hotspot{Container: status.Name, Restarts: status.RestartCount}
+
+	// A container that just exited has not been restarted yet
+	last := status.State.Terminated
+	if last == nil {
+		last = status.LastTerminationState.Terminated
+	}
+	var recentExit bool
+	if last != nil {
+		finished := last.FinishedAt.Time
+		s.LastExit = &finished
+		recentExit = !last.FinishedAt.Time.Before(cutoff)
+		if recentExit && last.Reason == hotspotOOMKilled {
+			s.Reasons = append(s.Reasons, hotspotOOMKilled)
+		}
+	}
+	if waiting := status.State.Waiting; waiting != nil {
+		switch waiting.Reason {
+		case hotspotCrashLoop:
+			s.Reasons = append([]string{hotspotCrashLoop}, s.Reasons...)
+			s.Message = waiting.Message
+		case hotspotImagePull, "ErrImagePull":
+			s.Reasons = append(s.Reasons, hotspotImagePull)
+			s.Message = waiting.Message
+		}
+	}
+	if recentExit && minRestarts > 0 && status.RestartCount >= minRestarts {
+		s.Reasons = append(s.Reasons, hotspotRestarts)
+	}
+	if len(s.Reasons) == 0 {
+		return hotspot{}, false
+	}
+	return s, true
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testStatusPod(namespace, name string, statuses ...corev1.ContainerStatus) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Status:     corev1.PodStatus{ContainerStatuses: statuses},
+	}
+}
+
+func exitedAt(reason string, at time.Time) corev1.ContainerState {
+	return corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: reason, FinishedAt: metav1.NewTime(at)}}
+}
+
+func waitingFor(reason string) corev1.ContainerState {
+	return corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason}}
+}
+
+func TestFindHotspots(t *testing.T) {
+	now := time.Now()
+	recent := now.Add(-10 * time.Minute)
+	old := now.Add(-3 * time.Hour)
+
+	listed := []clusterPods{
+		{cluster: "cluster1", pods: []corev1.Pod{
+			testStatusPod("prod", "healthy", corev1.ContainerStatus{Name: "app", RestartCount: 1, LastTerminationState: exitedAt("Error", recent)}),
+			testStatusPod("prod", "flaky", corev1.ContainerStatus{Name: "app", RestartCount: 12, LastTerminationState: exitedAt("Error", recent)}),
+			testStatusPod("prod", "settled", corev1.ContainerStatus{Name: "app", RestartCount: 40, LastTerminationState: exitedAt("Error", old)}),
+			testStatusPod("prod", "old-oom", corev1.ContainerStatus{Name: "app", RestartCount: 1, LastTerminationState: exitedAt("OOMKilled", old)}),
+		}},
+		{cluster: "cluster2", pods: []corev1.Pod{
+			testStatusPod("prod", "pull", corev1.ContainerStatus{Name: "app", State: waitingFor("ErrImagePull")}),
+			testStatusPod("prod", "oom", corev1.ContainerStatus{Name: "app", RestartCount: 2, LastTerminationState: exitedAt("OOMKilled", recent)}),
+			testStatusPod("prod", "crash", corev1.ContainerStatus{
+				Name: "app", RestartCount: 7, State: waitingFor("CrashLoopBackOff"), LastTerminationState: exitedAt("OOMKilled", recent),
+			}),
+			testStatusPod("dev", "crash", corev1.ContainerStatus{Name: "app", RestartCount: 3, State: waitingFor("CrashLoopBackOff")}),
+		}},
+	}
+
+	type row struct {
+		cluster, namespace, pod string
+		reasons                 []string
+	}
+	want := []row{
+		{"cluster2", "prod", "crash", []string{hotspotCrashLoop, hotspotOOMKilled, hotspotRestarts}},
+		{"cluster2", "dev", "crash", []string{hotspotCrashLoop}},
+		{"cluster2", "prod", "oom", []string{hotspotOOMKilled}},
+		{"cluster2", "prod", "pull", []string{hotspotImagePull}},
+		{"cluster1", "prod", "flaky", []string{hotspotRestarts}},
+	}
+
+	var got []row
+	for _, s := range findHotspots(listed, now.Add(-time.Hour), 5) {
+		got = append(got, row{s.Cluster, s.Namespace, s.Pod, s.Reasons})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findHotspots() =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestContainerHotspotJustExited(t *testing.T) {
+	now := time.Now()
+	status := corev1.ContainerStatus{Name: "app", State: exitedAt("OOMKilled", now)}
+	s, ok := containerHotspot(status, now.Add(-time.Hour), 5)
+	if !ok || !reflect.DeepEqual(s.Reasons, []string{hotspotOOMKilled}) {
+		t.Errorf("containerHotspot() = %v, %v, want OOMKilled", s.Reasons, ok)
+	}
+}
@@ -0,0 +1,310 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+// imageUsage counts the pods running one image in one namespace of a cluster
+type imageUsage struct {
+	Cluster   string `json:"cluster"`
+	Namespace string `json:"namespace"`
+	Image     string `json:"image"`
+	Pods      int    `json:"pods"`
+}
+
+// workloadImages are the images one container of a workload runs in each
+// cluster, for --diff
+type workloadImages struct {
+	Namespace string `json:"namespace"`
+	Workload  string `json:"workload"`
+	Container string `json:"container"`
+	// Images maps cluster name to the images its pods run, sorted
+	Images map[string][]string `json:"images"`
+}
+
+// clusterPods are the pods listed from one cluster
+type clusterPods struct {
+	cluster string
+	pods    []corev1.Pod
+}
+
+func newImagesCommand() *cobra.Command {
+	var outputFormat string
+	var selector string
+	var diff bool
+	var targets clusterTargets
+	var reach reachability
+
+	cmd := &cobra.Command{
+		Use:   "images",
+		Short: "List the container images running across managed clusters",
+		Long: `List every unique container image the pods of the managed clusters run,
+grouped by cluster and namespace, with the number of pods using it.
+
+With --diff, compare the images of the same workload (Deployment,
+StatefulSet, DaemonSet, Job or bare pod, by namespace and name) across
+clusters and list only the containers whose image differs, which shows the
+clusters still running an older release.`,
+		Example: `# Image inventory of the whole fleet
+kubectl multi images -A
+
+# Workloads of prod whose image is not the same in every cluster
+kubectl multi images -n prod --diff
+
+# Inventory as CSV
+kubectl multi images -A -o csv > images.csv`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch outputFormat {
+			case "", "json", util.TableFormatCSV, util.TableFormatMarkdown:
+			default:
+				return fmt.Errorf("unsupported output format %q, must be one of json|csv|markdown", outputFormat)
+			}
+			selector, err := parseSelector(selector)
+			if err != nil {
+				return err
+			}
+			kubeconfig, remoteCtx, _, namespace, allNamespaces := GetGlobalFlags()
+			return handleImagesCommand(outputFormat, selector, diff, targets, reach, kubeconfig, remoteCtx, namespace, allNamespaces)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "output format (json|csv|markdown)")
+	cmd.Flags().StringVarP(&selector, "selector", "l", "", "label selector on the pods")
+	cmd.Flags().BoolVar(&diff, "diff", false, "only show workload containers whose image differs between clusters")
+	targets.addFlags(cmd, "inventory")
+	reach.addFlags(cmd)
+
+	return cmd
+}
+
+func handleImagesCommand(outputFormat, selector string, diff bool, targets clusterTargets, reach reachability, kubeconfig, remoteCtx, namespace string, allNamespaces bool) error {
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	clusters, err = targets.selectFrom(clusters, kubeconfig)
+	if err != nil {
+		return err
+	}
+	clusters, err = reach.filter(clusters)
+	if err != nil {
+		return err
+	}
+	defer printClusterIssuesToStderr()
+
+	listed := listClusterPods(clusters, selector, namespace, allNamespaces)
+	out := util.GetOutputStream()
+
+	if diff {
+		diffs := diffWorkloadImages(listed)
+		if outputFormat == "json" {
+			return printJSONArray(diffs)
+		}
+		tw := util.NewTableWriter(out, outputFormat)
+		defer tw.Flush()
+		if len(diffs) == 0 {
+			fmt.Fprintln(os.Stderr, "Every workload runs the same images in all clusters.")
+			return nil
+		}
+		fmt.Fprintf(tw, "NAMESPACE\tWORKLOAD\tCONTAINER\tCLUSTER\tIMAGE\n")
+		for _, d := range diffs {
+			for _, clusterName := range sortedKeys(d.Images) {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+					d.Namespace, d.Workload, d.Container, clusterName, strings.Join(d.Images[clusterName], ","))
+			}
+		}
+		return nil
+	}
+
+	usages := inventoryImages(listed)
+	if outputFormat == "json" {
+		return printJSONArray(usages)
+	}
+	tw := util.NewTableWriter(out, outputFormat)
+	defer tw.Flush()
+	if len(usages) == 0 {
+		reportNoResources(namespace, allNamespaces)
+		return nil
+	}
+	fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tIMAGE\tPODS\n")
+	for _, u := range usages {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\n", u.Cluster, u.Namespace, u.Image, u.Pods)
+	}
+	return nil
+}
+
+// printJSONArray writes rows as an indented JSON array, [] when empty
+func printJSONArray[T any](rows []T) error {
+	if rows == nil {
+		rows = []T{}
+	}
+	data, err := json.MarshalIndent(rows, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %v", err)
+	}
+	fmt.Fprintln(util.GetOutputStream(), string(data))
+	return nil
+}
+
+// listClusterPods lists the pods of every cluster, noting the clusters that fail
+func listClusterPods(clusters []cluster.ClusterInfo, selector, namespace string, allNamespaces bool) []clusterPods {
+	targetNS := cluster.GetTargetNamespace(namespace)
+	if allNamespaces {
+		targetNS = ""
+	}
+
+	var listed []clusterPods
+	for _, clusterInfo := range clusters {
+		if clusterInfo.Client == nil {
+			continue
+		}
+		pods, err := listNamespaced(clusterInfo, "", "pods", targetNS, func(ns string) (*corev1.PodList, error) {
+			return clusterInfo.Client.CoreV1().Pods(ns).List(commandContext(), metav1.ListOptions{
+				LabelSelector: selector,
+			})
+		})
+		if err != nil {
+			noteClusterIssue(clusterInfo.Name, fmt.Sprintf("failed to list pods: %v", err))
+			continue
+		}
+		listed = append(listed, clusterPods{cluster: clusterInfo.Name, pods: pods.Items})
+	}
+	return listed
+}
+
+// inventoryImages counts the pods running each image per cluster and
+// namespace, sorted by cluster, namespace and image
+func inventoryImages(listed []clusterPods) []imageUsage {
+	byKey := make(map[string]*imageUsage)
+	for _, cp := range listed {
+		for _, pod := range cp.pods {
+			seen := make(map[string]bool)
+			for _, c := range podContainers(pod) {
+				if seen[c.Image] {
+					continue
+				}
+				seen[c.Image] = true
+				key := cp.cluster + "/" + pod.Namespace + "/" + c.Image
+				u, ok := byKey[key]
+				if !ok {
+					u = &imageUsage{Cluster: cp.cluster, Namespace: pod.Namespace, Image: c.Image}
+					byKey[key] = u
+				}
+				u.Pods++
+			}
+		}
+	}
+
+	usages := make([]imageUsage, 0, len(byKey))
+	for _, u := range byKey {
+		usages = append(usages, *u)
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		a, b := usages[i], usages[j]
+		if a.Cluster != b.Cluster {
+			return a.Cluster < b.Cluster
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Image < b.Image
+	})
+	return usages
+}
+
+// diffWorkloadImages returns the workload containers that run in more than
+// one cluster without the same images everywhere, sorted by namespace,
+// workload and container
+func diffWorkloadImages(listed []clusterPods) []workloadImages {
+	byKey := make(map[string]*workloadImages)
+	for _, cp := range listed {
+		for _, pod := range cp.pods {
+			workload := podWorkload(pod)
+			for _, c := range podContainers(pod) {
+				key := pod.Namespace + "/" + workload + "/" + c.Name
+				w, ok := byKey[key]
+				if !ok {
+					w = &workloadImages{Namespace: pod.Namespace, Workload: workload, Container: c.Name, Images: map[string][]string{}}
+					byKey[key] = w
+				}
+				w.Images[cp.cluster] = appendUnique(w.Images[cp.cluster], c.Image)
+			}
+		}
+	}
+
+	var diffs []workloadImages
+	for _, w := range byKey {
+		if len(w.Images) < 2 {
+			continue
+		}
+		var first string
+		same := true
+		for i, clusterName := range sortedKeys(w.Images) {
+			sort.Strings(w.Images[clusterName])
+			images := strings.Join(w.Images[clusterName], ",")
+			if i == 0 {
+				first = images
+			} else if images != first {
+				same = false
+			}
+		}
+		if !same {
+			diffs = append(diffs, *w)
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		a, b := diffs[i], diffs[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Workload != b.Workload {
+			return a.Workload < b.Workload
+		}
+		return a.Container < b.Container
+	})
+	return diffs
+}
+
+// podContainers returns the init and regular containers of a pod
+func podContainers(pod corev1.Pod) []corev1.Container {
+	return append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+}
+
+// podWorkload names the workload a pod belongs to as kind/name, following a
+// ReplicaSet to its Deployment through the pod-template-hash suffix. Pods
+// without a controller are their own workload.
+func podWorkload(pod corev1.Pod) string {
+	owner := metav1.GetControllerOf(&pod)
+	if owner == nil {
+		return "pod/" + pod.Name
+	}
+	if owner.Kind == "ReplicaSet" {
+		if hash := pod.Labels[appsv1.DefaultDeploymentUniqueLabelKey]; hash != "" && strings.HasSuffix(owner.Name, "-"+hash) {
+			return "deployment/" + strings.TrimSuffix(owner.Name, "-"+hash)
+		}
+	}
+	return strings.ToLower(owner.Kind) + "/" + owner.Name
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// testImagePod returns a pod owned by a controller of kind/owner running images
+func testImagePod(namespace, name, kind, owner, hash string, images ...string) corev1.Pod {
+	pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+	if kind != "" {
+		controller := true
+		pod.OwnerReferences = []metav1.OwnerReference{{Kind: kind, Name: owner, Controller: &controller}}
+	}
+	if hash != "" {
+		pod.Labels = map[string]string{"pod-template-hash": hash}
+	}
+	for i, image := range images {
+		pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: []string{"app", "sidecar"}[i], Image: image})
+	}
+	return pod
+}
+
+func TestPodWorkload(t *testing.T) {
+	tests := []struct {
+		name string
+		pod  corev1.Pod
+		want string
+	}{
+		{"deployment", testImagePod("prod", "web-7d9f-abcde", "ReplicaSet", "web-7d9f", "7d9f"), "deployment/web"},
+		{"bare replicaset", testImagePod("prod", "rs-xyz", "ReplicaSet", "rs", ""), "replicaset/rs"},
+		{"statefulset", testImagePod("prod", "db-0", "StatefulSet", "db", ""), "statefulset/db"},
+		{"bare pod", testImagePod("prod", "debug", "", "", ""), "pod/debug"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := podWorkload(tt.pod); got != tt.want {
+				t.Errorf("podWorkload() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInventoryImages(t *testing.T) {
+	listed := []clusterPods{
+		{cluster: "cluster2", pods: []corev1.Pod{
+			testImagePod("prod", "web-1", "ReplicaSet", "web-aa", "aa", "nginx:1.25", "nginx:1.25"),
+		}},
+		{cluster: "cluster1", pods: []corev1.Pod{
+			testImagePod("prod", "web-1", "ReplicaSet", "web-bb", "bb", "nginx:1.24", "envoy:1.30"),
+			testImagePod("prod", "web-2", "ReplicaSet", "web-bb", "bb", "nginx:1.24"),
+			testImagePod("dev", "web-1", "ReplicaSet", "web-bb", "bb", "nginx:1.24"),
+		}},
+	}
+	want := []imageUsage{
+		{Cluster: "cluster1", Namespace: "dev", Image: "nginx:1.24", Pods: 1},
+		{Cluster: "cluster1", Namespace: "prod", Image: "envoy:1.30", Pods: 1},
+		{Cluster: "cluster1", Namespace: "prod", Image: "nginx:1.24", Pods: 2},
+		{Cluster: "cluster2", Namespace: "prod", Image: "nginx:1.25", Pods: 1},
+	}
+	if got := inventoryImages(listed); !reflect.DeepEqual(got, want) {
+		t.Errorf("inventoryImages() = %v, want %v", got, want)
+	}
+}
+
+func TestDiffWorkloadImages(t *testing.T) {
+	listed := []clusterPods{
+		{cluster: "cluster1", pods: []corev1.Pod{
+			testImagePod("prod", "web-bb-1", "ReplicaSet", "web-bb", "bb", "nginx:1.24", "envoy:1.30"),
+			testImagePod("prod", "db-0", "StatefulSet", "db", "", "postgres:16"),
+			testImagePod("prod", "only-here", "", "", "", "busybox"),
+		}},
+		{cluster: "cluster2", pods: []corev1.Pod{
+			testImagePod("prod", "web-aa-1", "ReplicaSet", "web-aa", "aa", "nginx:1.25", "envoy:1.30"),
+			testImagePod("prod", "web-bb-2", "ReplicaSet", "web-bb", "bb", "nginx:1.24", "envoy:1.30"),
+			testImagePod("prod", "db-0", "StatefulSet", "db", "", "postgres:16"),
+		}},
+	}
+	want := []workloadImages{{
+		Namespace: "prod",
+		Workload:  "deployment/web",
+		Container: "app",
+		Images: map[string][]string{
+			"cluster1": {"nginx:1.24"},
+			"cluster2": {"nginx:1.24", "nginx:1.25"},
+		},
+	}}
+	if got := diffWorkloadImages(listed); !reflect.DeepEqual(got, want) {
+		t.Errorf("diffWorkloadImages() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"kubectl-multi/pkg/cluster"
+)
+
+// controlPlaneGVR is the KubeFlex ControlPlane resource on the hosting cluster
+var controlPlaneGVR = schema.GroupVersionResource{
+	Group:    "tenancy.kflex.kubestellar.org",
+	Version:  "v1alpha1",
+	Resource: "controlplanes",
+}
+
+// discoverClusters finds the managed clusters of the ITSes chosen by --its,
+// --all-its or --remote-context, plus the local cluster. Without OCM on the
+// ITS the fleet is the kubeconfig contexts matching --context-pattern.
+func discoverClusters(kubeconfig, remoteCtx string) ([]cluster.ClusterInfo, error) {
+	contexts, err := selectedITSContexts(kubeconfig, remoteCtx)
+	if err != nil {
+		return nil, err
+	}
+	return cluster.DiscoverClustersFromITSes(commandContext(), kubeconfig, contexts, contextPattern)
+}
+
+// selectedITSContexts returns the ITS contexts chosen by the global flags
+func selectedITSContexts(kubeconfig, remoteCtx string) ([]string, error) {
+	return resolveITSContexts(itsNames, allITS, remoteCtx, func() ([]string, error) {
+		hostCtx, err := discoverKubeFlexHostingCluster(kubeconfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover KubeFlex hosting cluster: %v", err)
+		}
+		host, err := cluster.ClientForContext(kubeconfig, hostCtx)
+		if err != nil {
+			return nil, err
+		}
+		return listITSControlPlanes(host.DynamicClient)
+	})
+}
+
+// resolveITSContexts picks the ITS contexts from --its, --all-its (listAll)
+// or, when neither is set, the --remote-context
+func resolveITSContexts(names []string, all bool, remoteCtx string, listAll func() ([]string, error)) ([]string, error) {
+	if all && len(names) > 0 {
+		return nil, fmt.Errorf("--its and --all-its cannot be combined")
+	}
+	if all {
+		contexts, err := listAll()
+		if err != nil {
+			return nil, err
+		}
+		if len(contexts) == 0 {
+			return nil, fmt.Errorf("no ITS ControlPlanes found on the KubeFlex hosting cluster")
+		}
+		return contexts, nil
+	}
+	if len(names) > 0 {
+		return uniqueContexts(names), nil
+	}
+	if remoteCtx == "" {
+		return nil, nil
+	}
+	return []string{remoteCtx}, nil
+}
+
+// uniqueContexts drops empty and repeated context names, keeping the order
+func uniqueContexts(names []string) []string {
+	seen := map[string]bool{}
+	var contexts []string
+	for _, name := range names {
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		contexts = append(contexts, name)
+	}
+	return contexts
+}
+
+// listITSControlPlanes returns the names of the ITS ControlPlanes on the
+// KubeFlex hosting cluster, which double as their kubeconfig context names.
+// A ControlPlane is an ITS when it runs the "its" post-create hook or, like
+// the vcluster ITSes created by older releases, has type vcluster.
+func listITSControlPlanes(dyn dynamic.Interface) ([]string, error) {
+	cps, err := dyn.Resource(controlPlaneGVR).List(commandContext(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ControlPlanes: %v", err)
+	}
+
+	var names []string
+	for _, cp := range cps.Items {
+		hook, _, _ := unstructured.NestedString(cp.Object, "spec", "postCreateHook")
+		cpType, _, _ := unstructured.NestedString(cp.Object, "spec", "type")
+		if hook == "its" || cpType == "vcluster" {
+			names = append(names, cp.GetName())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
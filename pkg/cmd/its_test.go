@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+)
+
+func TestResolveITSContexts(t *testing.T) {
+	listAll := func() ([]string, error) { return []string{"its1", "its2"}, nil }
+	tests := []struct {
+		name      string
+		names     []string
+		all       bool
+		remoteCtx string
+		listAll   func() ([]string, error)
+		want      []string
+		wantErr   string
+	}{
+		{name: "remote context by default", remoteCtx: "its1", want: []string{"its1"}},
+		{name: "no ITS at all", want: nil},
+		{name: "named ITSes override the remote context", names: []string{"its2", "its3"}, remoteCtx: "its1", want: []string{"its2", "its3"}},
+		{name: "duplicates dropped", names: []string{"its2", "its2", ""}, want: []string{"its2"}},
+		{name: "all ITSes", all: true, remoteCtx: "its1", listAll: listAll, want: []string{"its1", "its2"}},
+		{name: "all with names", names: []string{"its1"}, all: true, wantErr: "cannot be combined"},
+		{
+			name:    "all finds none",
+			all:     true,
+			listAll: func() ([]string, error) { return nil, nil },
+			wantErr: "no ITS ControlPlanes",
+		},
+		{
+			name:    "all fails",
+			all:     true,
+			listAll: func() ([]string, error) { return nil, errors.New("no KubeFlex hosting cluster found") },
+			wantErr: "no KubeFlex",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveITSContexts(tt.names, tt.all, tt.remoteCtx, tt.listAll)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("resolveITSContexts() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveITSContexts() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolveITSContexts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func testControlPlane(name string, spec map[string]interface{}) *unstructured.Unstructured {
+	cp := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	cp.SetAPIVersion(controlPlaneGVR.GroupVersion().String())
+	cp.SetKind("ControlPlane")
+	cp.SetName(name)
+	return cp
+}
+
+func TestListITSControlPlanes(t *testing.T) {
+	tests := []struct {
+		name    string
+		objects []runtime.Object
+		want    []string
+	}{
+		{
+			name: "ITS by hook or vcluster type",
+			objects: []runtime.Object{
+				testControlPlane("its2", map[string]interface{}{"type": "host", "postCreateHook": "its"}),
+				testControlPlane("its1", map[string]interface{}{"type": "vcluster"}),
+				testControlPlane("wds1", map[string]interface{}{"type": "k8s", "postCreateHook": "wds"}),
+			},
+			want: []string{"its1", "its2"},
+		},
+		{
+			name:    "no ITS",
+			objects: []runtime.Object{testControlPlane("wds1", map[string]interface{}{"type": "k8s"})},
+		},
+	}
+	listKinds := map[schema.GroupVersionResource]string{controlPlaneGVR: "ControlPlaneList"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dyn := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, tt.objects...)
+			got, err := listITSControlPlanes(dyn)
+			if err != nil {
+				t.Fatalf("listITSControlPlanes() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("listITSControlPlanes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func testManagedCluster(name string, labels map[string]string) unstructured.Unstructured {
+	mc := unstructured.Unstructured{Object: map[string]interface{}{}}
+	mc.SetAPIVersion("cluster.open-cluster-management.io/v1")
+	mc.SetKind("ManagedCluster")
+	mc.SetName(name)
+	mc.SetLabels(labels)
+	return mc
+}
+
+func TestSummarizeInventories(t *testing.T) {
+	inventories := []itsManagedClusters{
+		{ITS: "its1", Items: []unstructured.Unstructured{
+			testManagedCluster("cluster1", map[string]string{"env": "prod"}),
+			testManagedCluster("edge", map[string]string{"env": "dev"}),
+		}},
+		{ITS: "its2", Items: []unstructured.Unstructured{
+			testManagedCluster("cluster1", map[string]string{"env": "dev"}),
+		}},
+	}
+	tests := []struct {
+		name     string
+		selector string
+		want     []string
+	}{
+		{name: "everything", want: []string{"its1/cluster1@its1", "edge@its1", "its2/cluster1@its2"}},
+		{name: "names stay qualified when filtered", selector: "env=dev", want: []string{"edge@its1", "its2/cluster1@its2"}},
+		{name: "no match", selector: "env=qa"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sel, err := labels.Parse(tt.selector)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, s := range summarizeInventories(inventories, sel) {
+				got = append(got, s.Name+"@"+s.ITS)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("summarizeInventories() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
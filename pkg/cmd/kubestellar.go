@@ -0,0 +1,444 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/retry"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+	"kubectl-multi/pkg/util"
+)
+
+func newKubeStellarCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "kubestellar",
+		Short: "Manage and verify the configuration of the KubeStellar control plane",
+	}
+	cmd.AddCommand(newKubeStellarBackupCommand())
+	cmd.AddCommand(newKubeStellarRestoreCommand())
+	cmd.AddCommand(newKubeStellarVerifyPlacementCommand())
+	cmd.AddCommand(newExplainPlacementCommand())
+	cmd.AddCommand(newBindingPolicyCommand())
+	return cmd
+}
+
+func newKubeStellarBackupCommand() *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "backup --output FILE",
+		Short: "Save the BindingPolicies, CustomTransforms, StatusCollectors and cluster labels to an archive",
+		Long: `Save the configuration of the KubeStellar control plane to a gzipped tar
+archive: the BindingPolicies, CustomTransforms and StatusCollectors of the WDS
+(the --wds-context, or every WDS given with --wds), and the labels of the
+ManagedClusters of the ITS (--remote-context, --its or --all-its), which the
+cluster selectors of the policies match.
+
+Objects are saved without their status and server-set fields, so the archive
+can be restored into the same control plane after a disaster or into another
+one to clone it. A WDS without one of the resources is backed up without it.`,
+		Example: `# Back up wds1 and the ITS
+kubectl multi kubestellar backup --output backup.tar.gz
+
+# Back up two WDSes
+kubectl multi kubestellar backup --output backup.tar.gz --wds wds1,wds2`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output == "" {
+				return fmt.Errorf("--output is required")
+			}
+			kubeconfig, remoteCtx, _, _, _ := GetGlobalFlags()
+			wdses, err := wdsClients(kubeconfig, GetWDSContexts())
+			if err != nil {
+				return err
+			}
+			itses, err := itsClients(kubeconfig, remoteCtx)
+			if err != nil {
+				return err
+			}
+			b, err := backupControlPlane(wdses, itses, os.Stderr)
+			if err != nil {
+				return err
+			}
+			if err := writeBackupFile(output, b); err != nil {
+				return err
+			}
+			fmt.Fprintf(util.GetOutputStream(), "Backup written to %s\n", output)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&output, "output", "", "archive to write (.tar.gz)")
+
+	return cmd
+}
+
+func newKubeStellarRestoreCommand() *cobra.Command {
+	var filename string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "restore -f FILE",
+		Short: "Recreate the control objects and cluster labels saved by kubestellar backup",
+		Long: `Restore an archive written by kubestellar backup. StatusCollectors and
+CustomTransforms are created or updated first, then the BindingPolicies naming
+them, whose restored spec is recorded in their revision history. The labels
+of the ManagedClusters are added or updated; labels missing from the backup
+are kept, and clusters missing from the ITS are reported and skipped.
+
+Each WDS and ITS of the backup is restored into the context of the same name
+among the --wds-context (or --wds) and the selected ITSes. A backup of a
+single WDS or ITS is restored into a single target of another name, which
+clones a control plane.`,
+		Example: `# Restore a backup into the same control plane
+kubectl multi kubestellar restore -f backup.tar.gz
+
+# Clone a control plane: restore the backup of wds1 into wds2 and its2
+kubectl multi kubestellar restore -f backup.tar.gz --wds-context wds2 --remote-context its2
+
+# Show what would change
+kubectl multi kubestellar restore -f backup.tar.gz --dry-run`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if filename == "" {
+				return fmt.Errorf("-f is required")
+			}
+			f, err := os.Open(filename)
+			if err != nil {
+				return fmt.Errorf("failed to open backup: %v", err)
+			}
+			b, err := kubestellar.ReadBackup(f)
+			f.Close()
+			if err != nil {
+				return fmt.Errorf("failed to read backup %s: %v", filename, err)
+			}
+			kubeconfig, remoteCtx, _, _, _ := GetGlobalFlags()
+			wdses, err := wdsClients(kubeconfig, GetWDSContexts())
+			if err != nil {
+				return err
+			}
+			itses, err := itsClients(kubeconfig, remoteCtx)
+			if err != nil {
+				return err
+			}
+			var rec *audit.Recorder
+			if !dryRun {
+				rec = startAudit("kubestellar restore")
+			}
+			if err = guardManagedClusters(rec, restoreRadius(b), restoredClusters(b), remoteCtx); err == nil {
+				err = restoreControlPlane(b, wdses, itses, dryRun, rec, util.GetOutputStream())
+			}
+			finishAudit(rec, err)
+			return err
+		},
+	}
+
+	cmd.Flags().StringVarP(&filename, "filename", "f", "", "archive written by kubestellar backup")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only print what would be restored")
+
+	return cmd
+}
+
+// itsClients connects to every selected ITS
+func itsClients(kubeconfig, remoteCtx string) ([]*cluster.ClusterInfo, error) {
+	contexts, err := selectedITSContexts(kubeconfig, remoteCtx)
+	if err != nil {
+		return nil, err
+	}
+	itses := make([]*cluster.ClusterInfo, 0, len(contexts))
+	for _, ctx := range contexts {
+		its, err := cluster.ClientForContext(kubeconfig, ctx)
+		if err != nil {
+			return nil, err
+		}
+		itses = append(itses, its)
+	}
+	return itses, nil
+}
+
+// backupControlPlane reads the control objects of the WDSes and the
+// ManagedCluster labels of the ITSes. Resources a WDS does not serve are
+// skipped with a warning.
+func backupControlPlane(wdses, itses []*cluster.ClusterInfo, progress io.Writer) (*kubestellar.Backup, error) {
+	b := kubestellar.NewBackup(time.Now())
+	for _, wds := range wdses {
+		for _, gvr := range kubestellar.BackupResources {
+			list, err := wds.DynamicClient.Resource(gvr).List(commandContext(), metav1.ListOptions{})
+			if apierrors.IsNotFound(err) {
+				fmt.Fprintf(progress, "Warning: WDS %s does not serve %s, skipping them\n", wds.Context, gvr.GroupResource())
+				b.AddObjects(wds.Context, gvr, nil)
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to list %s in WDS %s: %v", gvr.GroupResource(), wds.Context, err)
+			}
+			objs := make([]*unstructured.Unstructured, 0, len(list.Items))
+			for i := range list.Items {
+				objs = append(objs, exportObject(&list.Items[i]))
+			}
+			b.AddObjects(wds.Context, gvr, objs)
+			fmt.Fprintf(progress, "Backed up %d %s from WDS %s\n", len(objs), gvr.GroupResource(), wds.Context)
+		}
+	}
+	for _, its := range itses {
+		list, err := its.DynamicClient.Resource(cluster.ManagedClusterGVR).List(commandContext(), metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list ManagedClusters in ITS %s: %v", its.Context, err)
+		}
+		labels := map[string]map[string]string{}
+		for _, mc := range list.Items {
+			labels[mc.GetName()] = mc.GetLabels()
+		}
+		b.AddClusterLabels(its.Context, labels)
+		fmt.Fprintf(progress, "Backed up the labels of %s from ITS %s\n", plural(len(labels), "ManagedCluster"), its.Context)
+	}
+	return b, nil
+}
+
+// writeBackupFile writes the archive next to path first, so a failed backup
+// does not replace an earlier one
+func writeBackupFile(path string, b *kubestellar.Backup) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %v", path, err)
+	}
+	err = kubestellar.WriteBackup(f, b)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0o600)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("failed to write backup %s: %v", path, err)
+	}
+	return nil
+}
+
+// restoreTargets maps each context of the backup to the client of the
+// target of the same name, or to the only target when the backup holds a
+// single context of that kind
+func restoreTargets(kind string, backedUp []string, targets []*cluster.ClusterInfo) (map[string]*cluster.ClusterInfo, error) {
+	byName := map[string]*cluster.ClusterInfo{}
+	for _, t := range targets {
+		byName[t.Context] = t
+	}
+	mapped := map[string]*cluster.ClusterInfo{}
+	for _, ctx := range backedUp {
+		switch t, ok := byName[ctx]; {
+		case ok:
+			mapped[ctx] = t
+		case len(backedUp) == 1 && len(targets) == 1:
+			mapped[ctx] = targets[0]
+		case len(targets) == 0:
+			return nil, fmt.Errorf("the backup holds %s %s, but no %s is selected", kind, ctx, kind)
+		default:
+			return nil, fmt.Errorf("the backup holds %s %s, which is not among the selected %ses", kind, ctx, kind)
+		}
+	}
+	return mapped, nil
+}
+
+// restoreRadius counts the control objects of the backup, whose policies
+// place workloads again, and the ManagedClusters whose labels are restored
+func restoreRadius(b *kubestellar.Backup) blastRadius {
+	var radius blastRadius
+	for _, objs := range b.Objects {
+		for _, list := range objs {
+			radius.Objects += len(list)
+		}
+	}
+	for _, labels := range b.ClusterLabels {
+		radius.Objects += len(labels)
+	}
+	return radius
+}
+
+// restoredClusters lists the ManagedClusters of the backup, which the
+// restored policies and labels select again
+func restoredClusters(b *kubestellar.Backup) []string {
+	names := map[string]bool{}
+	for _, labels := range b.ClusterLabels {
+		for name := range labels {
+			names[name] = true
+		}
+	}
+	return sortedKeys(names)
+}
+
+// restoreControlPlane restores the control objects of each WDS and the
+// cluster labels of each ITS of the backup
+func restoreControlPlane(b *kubestellar.Backup, wdses, itses []*cluster.ClusterInfo, dryRun bool, rec *audit.Recorder, out io.Writer) error {
+	wdsTargets, err := restoreTargets("WDS", b.WDS, wdses)
+	if err != nil {
+		return err
+	}
+	itsTargets, err := restoreTargets("ITS", b.ITS, itses)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, ctx := range b.WDS {
+		wds := wdsTargets[ctx]
+		var wdsErr error
+		for _, gvr := range kubestellar.BackupResources {
+			for _, obj := range b.Objects[ctx][gvr.Resource] {
+				action, err := restoreControlObject(wds, gvr, obj, dryRun, out)
+				if err != nil {
+					fmt.Fprintf(out, "%s: error: %v\n", wds.Context, err)
+					wdsErr = err
+					failed++
+					continue
+				}
+				fmt.Fprintf(out, "%s: %s %s %s%s\n", wds.Context, obj.GetKind(), obj.GetName(), action, dryRunSuffix(dryRun))
+			}
+		}
+		if !dryRun {
+			rec.Record(wds.Context, wdsErr)
+		}
+	}
+	for _, ctx := range b.ITS {
+		its := itsTargets[ctx]
+		n, err := restoreClusterLabels(its, b.ClusterLabels[ctx], dryRun, out)
+		failed += n
+		if !dryRun {
+			rec.Record(its.Context, err)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to restore %s", plural(failed, "object"))
+	}
+	return nil
+}
+
+func dryRunSuffix(dryRun bool) string {
+	if dryRun {
+		return " (dry run)"
+	}
+	return ""
+}
+
+// restoreControlObject creates obj in the WDS, or updates the live object
+// when its spec or labels differ, and returns what was done. BindingPolicies
+// get a revision recorded.
+func restoreControlObject(wds *cluster.ClusterInfo, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, dryRun bool, out io.Writer) (string, error) {
+	client := wds.DynamicClient.Resource(gvr)
+	var live, restored *unstructured.Unstructured
+	action := ""
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var err error
+		live, err = client.Get(commandContext(), obj.GetName(), metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			live, action = nil, "created"
+			restored = obj.DeepCopy()
+			if dryRun {
+				return nil
+			}
+			util.MarkManaged(restored)
+			restored, err = client.Create(commandContext(), restored, metav1.CreateOptions{FieldManager: util.FieldManager})
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		if sameControlObject(live, obj) {
+			action = "unchanged"
+			return nil
+		}
+		action = "configured"
+		if dryRun {
+			return nil
+		}
+		restored = obj.DeepCopy()
+		restored.SetResourceVersion(live.GetResourceVersion())
+		util.MarkManaged(restored)
+		restored, err = client.Update(commandContext(), restored, metav1.UpdateOptions{FieldManager: util.FieldManager})
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to restore %s %s: %v", obj.GetKind(), obj.GetName(), err)
+	}
+	if gvr == kubestellar.BindingPolicyGVR && !dryRun && action != "unchanged" {
+		notePolicyRevision(out, wds, live, restored, "kubestellar restore")
+	}
+	return action, nil
+}
+
+// sameControlObject reports whether the live object already has the spec
+// and labels of the backed up one
+func sameControlObject(live, backedUp *unstructured.Unstructured) bool {
+	liveSpec, _, _ := unstructured.NestedFieldNoCopy(live.Object, "spec")
+	spec, _, _ := unstructured.NestedFieldNoCopy(backedUp.Object, "spec")
+	return reflect.DeepEqual(liveSpec, spec) && reflect.DeepEqual(live.GetLabels(), backedUp.GetLabels())
+}
+
+// restoreClusterLabels adds and updates the labels of the ManagedClusters of
+// the ITS from the backup, and returns the number of clusters that failed.
+// Clusters that no longer exist are reported but not counted.
+func restoreClusterLabels(its *cluster.ClusterInfo, labels map[string]map[string]string, dryRun bool, out io.Writer) (int, error) {
+	client := its.DynamicClient.Resource(cluster.ManagedClusterGVR)
+	failed := 0
+	var lastErr error
+	for _, name := range sortedKeys(labels) {
+		changed, err := restoreManagedClusterLabels(client, name, labels[name], dryRun)
+		switch {
+		case apierrors.IsNotFound(err):
+			fmt.Fprintf(out, "%s: Warning: ManagedCluster %s not found, labels not restored\n", its.Context, name)
+		case err != nil:
+			fmt.Fprintf(out, "%s: error: failed to restore the labels of ManagedCluster %s: %v\n", its.Context, name, err)
+			failed++
+			lastErr = err
+		case changed == 0:
+			fmt.Fprintf(out, "%s: ManagedCluster %s labels unchanged\n", its.Context, name)
+		default:
+			fmt.Fprintf(out, "%s: ManagedCluster %s %s restored%s\n", its.Context, name, plural(changed, "label"), dryRunSuffix(dryRun))
+		}
+	}
+	return failed, lastErr
+}
+
+// restoreManagedClusterLabels merges the backed up labels into one
+// ManagedCluster and returns how many were added or changed
+func restoreManagedClusterLabels(client dynamic.NamespaceableResourceInterface, name string, labels map[string]string, dryRun bool) (int, error) {
+	mc, err := client.Get(commandContext(), name, metav1.GetOptions{})
+	if err != nil {
+		return 0, err
+	}
+	current := mc.GetLabels()
+	changes := map[string]interface{}{}
+	for k, v := range labels {
+		if old, ok := current[k]; !ok || old != v {
+			changes[k] = v
+		}
+	}
+	if len(changes) == 0 || dryRun {
+		return len(changes), nil
+	}
+	patch, err := json.Marshal(map[string]interface{}{"metadata": map[string]interface{}{"labels": changes}})
+	if err != nil {
+		return 0, err
+	}
+	_, err = client.Patch(commandContext(), name, types.MergePatchType, patch, metav1.PatchOptions{FieldManager: util.FieldManager})
+	return len(changes), err
+}
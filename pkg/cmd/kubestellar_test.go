@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+)
+
+// testControlPlaneDynamic returns a fake WDS client serving the control
+// objects a backup holds
+func testControlPlaneDynamic(objects ...runtime.Object) *fakedynamic.FakeDynamicClient {
+	listKinds := map[schema.GroupVersionResource]string{
+		kubestellar.BindingPolicyGVR:   "BindingPolicyList",
+		kubestellar.CustomTransformGVR: "CustomTransformList",
+		kubestellar.StatusCollectorGVR: "StatusCollectorList",
+	}
+	return fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objects...)
+}
+
+func testStatusCollector(name string) *unstructured.Unstructured {
+	sc := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"limit": int64(10)},
+	}}
+	sc.SetAPIVersion(kubestellar.StatusCollectorGVR.GroupVersion().String())
+	sc.SetKind("StatusCollector")
+	sc.SetName(name)
+	sc.SetResourceVersion("3")
+	return sc
+}
+
+func TestKubeStellarBackupAndRestore(t *testing.T) {
+	policy := testDownsyncBindingPolicy("services")
+	unstructured.SetNestedField(policy.Object, "True", "status", "ready")
+	sourceDyn := testControlPlaneDynamic(policy, testStatusCollector("replicas"))
+	// A WDS predating CustomTransforms
+	sourceDyn.PrependReactor("list", "customtransforms", func(clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(kubestellar.CustomTransformGVR.GroupResource(), "")
+	})
+	source := &cluster.ClusterInfo{Name: "wds1", Context: "wds1", DynamicClient: sourceDyn}
+	sourceITS := &cluster.ClusterInfo{Name: "its1", Context: "its1", DynamicClient: testITSDynamic(
+		testManagedCluster("cluster1", map[string]string{"location": "edge", "tier": "gold"}),
+		testManagedCluster("cluster2", map[string]string{"location": "edge"}),
+	)}
+
+	var progress bytes.Buffer
+	b, err := backupControlPlane([]*cluster.ClusterInfo{source}, []*cluster.ClusterInfo{sourceITS}, &progress)
+	if err != nil {
+		t.Fatalf("backupControlPlane() error = %v", err)
+	}
+	if !strings.Contains(progress.String(), "Warning: WDS wds1 does not serve customtransforms.control.kubestellar.io") {
+		t.Errorf("progress %q does not warn about the missing CustomTransforms", progress.String())
+	}
+	var archive bytes.Buffer
+	if err := kubestellar.WriteBackup(&archive, b); err != nil {
+		t.Fatal(err)
+	}
+	b, err = kubestellar.ReadBackup(&archive)
+	if err != nil {
+		t.Fatalf("ReadBackup() error = %v", err)
+	}
+	saved := b.Objects["wds1"]["bindingpolicies"]
+	if len(saved) != 1 || saved[0].GetResourceVersion() != "" || saved[0].Object["status"] != nil {
+		t.Fatalf("backed up policies = %v, want web without status and server fields", saved)
+	}
+
+	// Clone into a WDS and ITS of other names
+	target := &cluster.ClusterInfo{Name: "wds2", Context: "wds2", DynamicClient: testControlPlaneDynamic()}
+	targetITS := &cluster.ClusterInfo{Name: "its2", Context: "its2", DynamicClient: testITSDynamic(
+		testManagedCluster("cluster1", map[string]string{"location": "edge", "owner": "ops"}),
+	)}
+	var out bytes.Buffer
+	rec := audit.Start("kubestellar restore", nil)
+	if err := restoreControlPlane(b, []*cluster.ClusterInfo{target}, []*cluster.ClusterInfo{targetITS}, false, rec, &out); err != nil {
+		t.Fatalf("restoreControlPlane() error = %v\n%s", err, out.String())
+	}
+	for _, want := range []string{
+		"wds2: StatusCollector replicas created",
+		"wds2: BindingPolicy web created",
+		"its2: ManagedCluster cluster1 1 label restored",
+		"its2: Warning: ManagedCluster cluster2 not found",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output %q does not contain %q", out.String(), want)
+		}
+	}
+	if got := policyResources(t, target); !reflect.DeepEqual(got, []interface{}{"services"}) {
+		t.Errorf("restored policy downsyncs %v, want [services]", got)
+	}
+	revisions, err := readPolicyRevisions(target, "web")
+	if err != nil || len(revisions) != 1 || revisions[0].Cause != "kubestellar restore" {
+		t.Errorf("revisions of the restored policy = %+v, %v, want one recorded by the restore", revisions, err)
+	}
+	mc, err := targetITS.DynamicClient.Resource(cluster.ManagedClusterGVR).Get(context.TODO(), "cluster1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"location": "edge", "owner": "ops", "tier": "gold"}; !reflect.DeepEqual(mc.GetLabels(), want) {
+		t.Errorf("cluster1 labels = %v, want %v", mc.GetLabels(), want)
+	}
+	if got := resultsWithoutDurations(rec.Finish(nil)); len(got) != 2 || got[0].Status != "ok" || got[1].Status != "ok" {
+		t.Errorf("audit results = %+v", got)
+	}
+
+	// Restoring again changes nothing
+	out.Reset()
+	if err := restoreControlPlane(b, []*cluster.ClusterInfo{target}, []*cluster.ClusterInfo{targetITS}, false, nil, &out); err != nil {
+		t.Fatalf("second restoreControlPlane() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "wds2: BindingPolicy web unchanged") || !strings.Contains(out.String(), "its2: ManagedCluster cluster1 labels unchanged") {
+		t.Errorf("second restore output %q, want everything unchanged", out.String())
+	}
+}
+
+func TestRestoreControlObjectDryRun(t *testing.T) {
+	live := testDownsyncBindingPolicy("configmaps")
+	wds := &cluster.ClusterInfo{Name: "wds1", Context: "wds1", DynamicClient: testControlPlaneDynamic(live)}
+	backedUp := exportObject(testDownsyncBindingPolicy("services"))
+
+	var out bytes.Buffer
+	action, err := restoreControlObject(wds, kubestellar.BindingPolicyGVR, backedUp, true, &out)
+	if err != nil || action != "configured" {
+		t.Fatalf("restoreControlObject() = %q, %v, want configured", action, err)
+	}
+	if got := policyResources(t, wds); !reflect.DeepEqual(got, []interface{}{"configmaps"}) {
+		t.Errorf("dry run changed the policy to downsync %v", got)
+	}
+
+	action, err = restoreControlObject(wds, kubestellar.BindingPolicyGVR, backedUp, false, &out)
+	if err != nil || action != "configured" {
+		t.Fatalf("restoreControlObject() = %q, %v, want configured", action, err)
+	}
+	if got := policyResources(t, wds); !reflect.DeepEqual(got, []interface{}{"services"}) {
+		t.Errorf("restored policy downsyncs %v, want [services]", got)
+	}
+	revisions, _ := readPolicyRevisions(wds, "web")
+	if len(revisions) != 2 {
+		t.Errorf("revisions = %+v, want the spec before and after the restore", revisions)
+	}
+}
+
+func TestRestoreTargets(t *testing.T) {
+	wds1 := &cluster.ClusterInfo{Context: "wds1"}
+	wds2 := &cluster.ClusterInfo{Context: "wds2"}
+	tests := []struct {
+		name     string
+		backedUp []string
+		targets  []*cluster.ClusterInfo
+		want     map[string]*cluster.ClusterInfo
+		wantErr  string
+	}{
+		{name: "same names", backedUp: []string{"wds1", "wds2"}, targets: []*cluster.ClusterInfo{wds2, wds1},
+			want: map[string]*cluster.ClusterInfo{"wds1": wds1, "wds2": wds2}},
+		{name: "clone a single WDS", backedUp: []string{"wds1"}, targets: []*cluster.ClusterInfo{wds2},
+			want: map[string]*cluster.ClusterInfo{"wds1": wds2}},
+		{name: "nothing backed up", targets: []*cluster.ClusterInfo{wds1}, want: map[string]*cluster.ClusterInfo{}},
+		{name: "no target", backedUp: []string{"wds1"}, wantErr: "the backup holds WDS wds1, but no WDS is selected"},
+		{name: "ambiguous", backedUp: []string{"wds1", "wds3"}, targets: []*cluster.ClusterInfo{wds1, wds2},
+			wantErr: "the backup holds WDS wds3, which is not among the selected WDSes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := restoreTargets("WDS", tt.backedUp, tt.targets)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("restoreTargets() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("restoreTargets() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
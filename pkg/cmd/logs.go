@@ -2,15 +2,17 @@ package cmd
 
 import (
 	"bytes"
-	"context"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 
 	"kubectl-multi/pkg/cluster"
 	"kubectl-multi/pkg/util"
@@ -22,7 +24,7 @@ func logsHelpFunc(cmd *cobra.Command, args []string) {
 	cmdInfo, err := util.GetKubectlCommandInfo("logs")
 	if err != nil {
 		// Fallback to default help if kubectl help is not available
-		cmd.Help()
+		defaultHelpFunc(cmd, args)
 		return
 	}
 
@@ -48,10 +50,19 @@ kubectl multi logs app-* -f
 kubectl multi logs nginx-* --timestamps
 
 # Print last 50 lines of logs from matching pods across all clusters
-kubectl multi logs transport-* --tail=50`
+kubectl multi logs transport-* --tail=50
+
+# Every container of the previous instance of matching pods
+kubectl multi logs app-* --all-containers --previous
+
+# Follow up to 20 streams, printing bare log lines
+kubectl multi logs -l app=nginx -f --max-log-requests 20 --prefix=false
+
+# Save the logs of every pod labeled app=nginx to one file per container
+kubectl multi logs -l app=nginx --output-dir ./logs`
 
 	// Multi-cluster usage
-	multiClusterUsage := `kubectl multi logs [-f] [-p] POD [-c CONTAINER] [flags]`
+	multiClusterUsage := `kubectl multi logs [-f] [-p] (POD | -l selector) [-c CONTAINER] [flags]`
 
 	// Format combined help using the new CommandInfo structure
 	combinedHelp := util.FormatMultiClusterHelp(cmdInfo, multiClusterInfo, multiClusterExamples, multiClusterUsage)
@@ -67,9 +78,16 @@ func newLogsCommand() *cobra.Command {
 	var timestamps bool
 	var tail int64
 	var limitBytes int64
+	var selector string
+	var outputDir string
+	var allContainers bool
+	var maxLogRequests int
+	var prefix bool
+	var targets clusterTargets
+	var reach reachability
 
 	cmd := &cobra.Command{
-		Use:   "logs [-f] [-p] POD [-c CONTAINER]",
+		Use:   "logs [-f] [-p] (POD | -l selector) [-c CONTAINER]",
 		Short: "Print the logs for a container in a pod across managed clusters",
 		Long: `Print the logs for a container in a pod across all managed clusters.
 This command retrieves and displays logs from pods across all KubeStellar managed clusters,
@@ -87,14 +105,52 @@ kubectl multi logs nginx-pod* -c nginx-container
 kubectl multi logs app-* -f
 
 # Print logs with timestamps across all clusters
-kubectl multi logs nginx-pod --timestamps`,
+kubectl multi logs nginx-pod --timestamps
+
+# Every container of the previous instance of matching pods
+kubectl multi logs app-* --all-containers --previous
+
+# Follow up to 20 streams, printing bare log lines
+kubectl multi logs -l app=nginx -f --max-log-requests 20 --prefix=false
+
+# Save logs of pods labeled app=nginx, one file per cluster/pod/container
+kubectl multi logs -l app=nginx --output-dir ./logs`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if len(args) == 0 {
+			if len(args) == 0 && selector == "" {
 				return fmt.Errorf("pod name or pattern must be specified")
 			}
+			selector, err := parseSelector(selector)
+			if err != nil {
+				return err
+			}
+			podPattern := ""
+			if len(args) > 0 {
+				podPattern = args[0]
+			}
 
 			kubeconfig, remoteCtx, _, namespace, allNamespaces := GetGlobalFlags()
-			return handleLogsCommand(args[0], follow, previous, container, since, sinceTime, timestamps, tail, limitBytes, kubeconfig, remoteCtx, namespace, allNamespaces)
+			if outputDir != "" && follow {
+				return fmt.Errorf("--follow cannot be combined with --output-dir")
+			}
+			if allContainers && container != "" {
+				return fmt.Errorf("--all-containers=true should not be specified with container name %s", container)
+			}
+			if maxLogRequests < 1 {
+				return fmt.Errorf("--max-log-requests must be greater than 0")
+			}
+			if outputDir == "" && selector != "" && tail == -1 {
+				tail = 10
+			}
+			opts, err := buildPodLogOptions(previous, container, since, sinceTime, timestamps, tail, limitBytes)
+			if err != nil {
+				return err
+			}
+			if outputDir != "" {
+				return handleLogsToDir(podPattern, selector, opts, outputDir, targets, reach, kubeconfig, remoteCtx, namespace, allNamespaces)
+			}
+			opts.Follow = follow
+			out := logsOutput{AllContainers: allContainers, MaxLogRequests: maxLogRequests, Prefix: prefix}
+			return handleLogsCommand(podPattern, selector, opts, out, targets, reach, kubeconfig, remoteCtx, namespace, allNamespaces)
 		},
 	}
 
@@ -102,166 +158,328 @@ kubectl multi logs nginx-pod --timestamps`,
 	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "specify if the logs should be streamed")
 	cmd.Flags().BoolVarP(&previous, "previous", "p", false, "if true, print the logs for the previous instance of the container in a pod if it exists")
 	cmd.Flags().StringVarP(&container, "container", "c", "", "print the logs of this container")
+	cmd.Flags().BoolVar(&allContainers, "all-containers", false, "get all containers' logs in the pod(s), init containers included")
+	cmd.Flags().IntVar(&maxLogRequests, "max-log-requests", 5, "maximum number of concurrent log streams; with --follow, the most streams that may be opened")
+	cmd.Flags().BoolVar(&prefix, "prefix", true, "label the output with its cluster, pod and container; --prefix=false prints only the log lines")
 	cmd.Flags().StringVar(&since, "since", "", "only return logs newer than a relative duration like 5s, 2m, or 3h")
 	cmd.Flags().StringVar(&sinceTime, "since-time", "", "only return logs after a specific date (RFC3339)")
 	cmd.Flags().BoolVar(&timestamps, "timestamps", false, "include timestamps on each line in the log output")
-	cmd.Flags().Int64Var(&tail, "tail", -1, "lines of recent log file to display. Defaults to -1 with no selector, showing all log lines otherwise 10, if a selector is provided")
+	cmd.Flags().Int64Var(&tail, "tail", -1, "lines of recent log file to display. Defaults to -1 with no selector, showing all log lines otherwise 10, if a selector is provided; with --output-dir, -1 exports all lines")
 	cmd.Flags().Int64Var(&limitBytes, "limit-bytes", 0, "maximum bytes of logs to return. Defaults to no limit")
+	cmd.Flags().StringVarP(&selector, "selector", "l", "", "selector (label query) to filter pods on")
+	cmd.Flags().StringVar(&outputDir, "output-dir", "", "write each cluster/pod/container log to a separate file under this directory, with an index.json manifest")
+
+	targets.addFlags(cmd, "read logs from")
+	reach.addFlags(cmd)
 
 	cmd.SetHelpFunc(logsHelpFunc)
 
 	return cmd
 }
 
-func handleLogsCommand(podPattern string, follow, previous bool, container, since, sinceTime string, timestamps bool, tail, limitBytes int64, kubeconfig, remoteCtx, namespace string, allNamespaces bool) error {
-	clusters, err := cluster.DiscoverClusters(kubeconfig, remoteCtx)
+func handleLogsCommand(podPattern, selector string, opts *corev1.PodLogOptions, out logsOutput, targets clusterTargets, reach reachability, kubeconfig, remoteCtx, namespace string, allNamespaces bool) error {
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
 	if err != nil {
 		return fmt.Errorf("failed to discover clusters: %v", err)
 	}
+	clusters, err = targets.selectFrom(clusters, kubeconfig)
+	if err != nil {
+		return err
+	}
+	clusters, err = reach.filter(clusters)
+	if err != nil {
+		return err
+	}
+	defer printClusterIssuesToStderr()
 
 	if len(clusters) == 0 {
 		return fmt.Errorf("no clusters discovered")
 	}
 
-	if follow {
-		fmt.Println("Warning: Follow mode (-f) across multiple clusters can be overwhelming.")
-		fmt.Println("Consider using this command on a specific cluster for follow mode.")
-		fmt.Println("Example: kubectl logs pod-name -f --context=specific-cluster")
-		fmt.Println()
+	if podPattern == "" {
+		podPattern = "*"
+	}
+	if opts.Follow {
+		return followLogs(clusters, podPattern, selector, opts, out, namespace, allNamespaces)
+	}
+	if out.Prefix {
+		fmt.Printf("Getting logs for pod pattern '%s' across %d clusters...\n\n", podPattern, len(clusters))
 	}
 
-	fmt.Printf("Getting logs for pod pattern '%s' across %d clusters...\n\n", podPattern, len(clusters))
-
-	foundAnyPod := false
-
-	for _, clusterInfo := range clusters {
+	// List the pods of every cluster first, then read their logs in parallel
+	perCluster := make([][]logTarget, len(clusters))
+	listed := make([]bool, len(clusters))
+	var all []logTarget
+	for i, clusterInfo := range clusters {
 		if clusterInfo.Client == nil {
-			fmt.Printf("Warning: skipping cluster %s (no client available)\n", clusterInfo.Name)
+			noteClusterIssue(clusterInfo.Name, "no client available")
 			continue
 		}
-
-		fmt.Printf("=== Cluster: %s (Context: %s) ===\n", clusterInfo.Name, clusterInfo.Context)
-
-		// Get matching pods from this cluster
-		matchingPods, err := getMatchingPods(clusterInfo, podPattern, namespace, allNamespaces)
+		matchingPods, err := getMatchingPodObjects(clusterInfo, podPattern, selector, namespace, allNamespaces)
 		if err != nil {
-			fmt.Printf("Error listing pods in cluster %s: %v\n", clusterInfo.Name, err)
-			fmt.Printf("\n")
+			noteClusterIssue(clusterInfo.Name, fmt.Sprintf("failed to list pods: %v", err))
 			continue
 		}
+		listed[i] = true
+		perCluster[i] = logTargets(clusterInfo, matchingPods, opts.Container, out.AllContainers)
+		all = append(all, perCluster[i]...)
+	}
+	logs, errs := readContainerLogs(all, opts, out.MaxLogRequests)
 
-		if len(matchingPods) == 0 {
-			fmt.Printf("No pods matching pattern '%s' found in cluster %s\n", podPattern, clusterInfo.Name)
-			fmt.Printf("\n")
+	n := 0
+	for i, clusterInfo := range clusters {
+		if !listed[i] {
 			continue
 		}
-
-		for _, podName := range matchingPods {
-			fmt.Printf("--- Pod: %s ---\n", podName)
-
-			kubectlArgs := buildLogsArgs(podName, follow, previous, container, since, sinceTime, timestamps, tail, limitBytes, namespace, allNamespaces, clusterInfo.Context)
-
-			output, err := executeKubectlLogs(kubectlArgs, kubeconfig, clusterInfo.Name)
-			if err != nil {
-				fmt.Printf("Error getting logs for pod '%s' in cluster %s: %v\n", podName, clusterInfo.Name, err)
-			} else if strings.TrimSpace(output) != "" {
+		if out.Prefix {
+			fmt.Printf("=== Cluster: %s (Context: %s) ===\n", clusterInfo.Name, clusterInfo.Context)
+			if len(perCluster[i]) == 0 {
+				fmt.Printf("No pods matching pattern '%s' found in cluster %s\n\n", podPattern, clusterInfo.Name)
+			}
+		}
+		for _, t := range perCluster[i] {
+			output, err := logs[n], errs[n]
+			n++
+			if out.Prefix {
+				if out.AllContainers {
+					fmt.Printf("--- Pod: %s [%s] ---\n", t.pod.Name, t.container)
+				} else {
+					fmt.Printf("--- Pod: %s ---\n", t.pod.Name)
+				}
+			}
+			switch {
+			case err != nil:
+				noteClusterIssue(clusterInfo.Name, fmt.Sprintf("failed to get logs for %s: %v", t.describe(), err))
+			case strings.TrimSpace(output) != "":
 				fmt.Print(output)
-				foundAnyPod = true
-			} else {
-				fmt.Printf("No logs available for pod '%s'\n", podName)
+			case out.Prefix:
+				fmt.Printf("No logs available for %s\n", t.describe())
+			}
+			if out.Prefix {
+				fmt.Printf("\n")
 			}
-			fmt.Printf("\n")
 		}
 	}
 
-	if !foundAnyPod {
-		fmt.Printf("No pods matching pattern '%s' found in any cluster\n", podPattern)
+	if len(all) == 0 {
+		fmt.Fprintf(logsInfoStream(out), "No pods matching pattern '%s' found in any cluster\n", podPattern)
 	}
 
 	return nil
 }
 
-func buildLogsArgs(podName string, follow, previous bool, container, since, sinceTime string, timestamps bool, tail, limitBytes int64, namespace string, allNamespaces bool, clusterContext string) []string {
-	var kubectlArgs []string
-
-	kubectlArgs = append(kubectlArgs, "logs", podName)
+// logsOutput holds the logs flags choosing which containers are read and
+// how their output is laid out
+type logsOutput struct {
+	// AllContainers reads every init and regular container of each pod
+	AllContainers bool
+	// MaxLogRequests bounds the log streams read at once; with --follow it
+	// is the most streams a command may open
+	MaxLogRequests int
+	// Prefix labels the output with its cluster, pod and container; without
+	// it only the log lines are printed
+	Prefix bool
+}
 
-	if container != "" {
-		kubectlArgs = append(kubectlArgs, "-c", container)
+// logsInfoStream is where the logs command reports that nothing matched:
+// stdout next to the headers, or stderr when stdout carries only log lines
+func logsInfoStream(out logsOutput) io.Writer {
+	if out.Prefix {
+		return os.Stdout
 	}
+	return os.Stderr
+}
 
-	if follow {
-		kubectlArgs = append(kubectlArgs, "-f")
-	}
+// logTarget is one container log to read
+type logTarget struct {
+	cluster cluster.ClusterInfo
+	pod     corev1.Pod
+	// container is empty for the pod's default container
+	container string
+}
 
-	if previous {
-		kubectlArgs = append(kubectlArgs, "-p")
+// describe names the pod, and the container when one is set, for messages
+func (t logTarget) describe() string {
+	if t.container == "" {
+		return "pod " + t.pod.Name
 	}
+	return fmt.Sprintf("pod %s container %s", t.pod.Name, t.container)
+}
 
-	if since != "" {
-		kubectlArgs = append(kubectlArgs, "--since", since)
+// stream copies the target's log to w
+func (t logTarget) stream(opts *corev1.PodLogOptions, w io.Writer) error {
+	podOpts := opts.DeepCopy()
+	if t.container != "" {
+		podOpts.Container = t.container
 	}
+	return streamPodLogs(t.cluster.Client, t.pod, podOpts, w)
+}
 
-	if sinceTime != "" {
-		kubectlArgs = append(kubectlArgs, "--since-time", sinceTime)
+// logTargets returns the container logs to read from the pods: every init and
+// regular container with allContainers, else the named or default container
+func logTargets(clusterInfo cluster.ClusterInfo, pods []corev1.Pod, container string, allContainers bool) []logTarget {
+	var targets []logTarget
+	for _, pod := range pods {
+		if !allContainers {
+			targets = append(targets, logTarget{cluster: clusterInfo, pod: pod, container: container})
+			continue
+		}
+		for _, c := range append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...) {
+			targets = append(targets, logTarget{cluster: clusterInfo, pod: pod, container: c.Name})
+		}
 	}
+	return targets
+}
 
-	if timestamps {
-		kubectlArgs = append(kubectlArgs, "--timestamps")
+// readContainerLogs reads the logs of the targets, at most maxRequests at a
+// time, returning each target's output and error in order
+func readContainerLogs(targets []logTarget, opts *corev1.PodLogOptions, maxRequests int) ([]string, []error) {
+	logs := make([]string, len(targets))
+	errs := make([]error, len(targets))
+	if maxRequests < 1 {
+		maxRequests = 1
 	}
-
-	if tail >= 0 {
-		kubectlArgs = append(kubectlArgs, "--tail", fmt.Sprintf("%d", tail))
+	sem := make(chan struct{}, maxRequests)
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		wg.Add(1)
+		go func(i int, t logTarget) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			var output bytes.Buffer
+			errs[i] = t.stream(opts, &output)
+			logs[i] = output.String()
+		}(i, t)
 	}
+	wg.Wait()
+	return logs, errs
+}
 
-	if limitBytes > 0 {
-		kubectlArgs = append(kubectlArgs, "--limit-bytes", fmt.Sprintf("%d", limitBytes))
+// followLogs streams the logs of every matching container in every cluster
+// at the same time, prefixing each line with [CLUSTER/POD] (and /CONTAINER
+// with --all-containers) unless --prefix=false, until all streams end
+func followLogs(clusters []cluster.ClusterInfo, podPattern, selector string, opts *corev1.PodLogOptions, out logsOutput, namespace string, allNamespaces bool) error {
+	var targets []logTarget
+	for _, clusterInfo := range clusters {
+		if clusterInfo.Client == nil {
+			noteClusterIssue(clusterInfo.Name, "no client available")
+			continue
+		}
+		pods, err := getMatchingPodObjects(clusterInfo, podPattern, selector, namespace, allNamespaces)
+		if err != nil {
+			noteClusterIssue(clusterInfo.Name, fmt.Sprintf("failed to list pods: %v", err))
+			continue
+		}
+		targets = append(targets, logTargets(clusterInfo, pods, opts.Container, out.AllContainers)...)
 	}
-
-	if !allNamespaces && namespace != "" {
-		kubectlArgs = append(kubectlArgs, "-n", namespace)
+	if len(targets) == 0 {
+		fmt.Fprintf(logsInfoStream(out), "No pods matching pattern '%s' found in any cluster\n", podPattern)
+		return nil
+	}
+	if len(targets) > out.MaxLogRequests {
+		return fmt.Errorf("you are attempting to follow %d log streams, but maximum allowed concurrency is %d, use --max-log-requests to increase the limit", len(targets), out.MaxLogRequests)
 	}
 
-	kubectlArgs = append(kubectlArgs, "--context", clusterContext)
-
-	return kubectlArgs
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, t := range targets {
+		wg.Add(1)
+		go func(t logTarget) {
+			defer wg.Done()
+			w := &prefixWriter{mu: &mu, out: os.Stdout}
+			if out.Prefix {
+				w.prefix = "[" + t.cluster.Name + "/" + t.pod.Name + "] "
+				if out.AllContainers {
+					w.prefix = "[" + t.cluster.Name + "/" + t.pod.Name + "/" + t.container + "] "
+				}
+			}
+			if err := t.stream(opts, w); err != nil {
+				noteClusterIssue(t.cluster.Name, fmt.Sprintf("failed to get logs for %s: %v", t.describe(), err))
+			}
+			w.flush()
+		}(t)
+	}
+	expectInterrupt()
+	fmt.Fprintf(os.Stderr, "Following logs of %d stream(s), press Ctrl+C to stop\n", len(targets))
+	wg.Wait()
+	return nil
 }
 
-func executeKubectlLogs(args []string, kubeconfig, clusterName string) (string, error) {
-
-	cmd := exec.Command("kubectl", args...)
-
-	cmd.Env = os.Environ()
-	if kubeconfig != "" {
-		cmd.Env = append(cmd.Env, "KUBECONFIG="+kubeconfig)
+// streamPodLogs copies the log of one pod container to w. Without
+// opts.Container it reads the pod's default container, as kubectl logs does.
+func streamPodLogs(client kubernetes.Interface, pod corev1.Pod, opts *corev1.PodLogOptions, w io.Writer) error {
+	podOpts := opts.DeepCopy()
+	if podOpts.Container == "" {
+		podOpts.Container = defaultLogContainer(pod)
 	}
+	stream, err := client.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, podOpts).Stream(commandContext())
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	_, err = io.Copy(w, stream)
+	return err
+}
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	err := cmd.Run()
+// defaultContainerAnnotation names the container kubectl logs and exec use
+// when none is given
+const defaultContainerAnnotation = "kubectl.kubernetes.io/default-container"
+
+// defaultLogContainer returns the container named by the
+// kubectl.kubernetes.io/default-container annotation, or the first one
+func defaultLogContainer(pod corev1.Pod) string {
+	if name := pod.Annotations[defaultContainerAnnotation]; name != "" {
+		for _, c := range pod.Spec.Containers {
+			if c.Name == name {
+				return name
+			}
+		}
+	}
+	if len(pod.Spec.Containers) > 0 {
+		return pod.Spec.Containers[0].Name
+	}
+	return ""
+}
 
-	output := stdout.String()
-	stderrOutput := stderr.String()
+// prefixWriter writes whole lines, each with a prefix, to a shared output
+type prefixWriter struct {
+	mu      *sync.Mutex
+	out     io.Writer
+	prefix  string
+	partial []byte
+}
 
-	if err != nil {
-		if strings.Contains(stderrOutput, "not found") || strings.Contains(stderrOutput, "NotFound") {
-			return "", fmt.Errorf("not found")
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			return len(p), nil
 		}
-
-		return "", fmt.Errorf("kubectl logs failed: %v\nStderr: %s", err, stderrOutput)
+		w.writeLine(w.partial[:i+1])
+		w.partial = w.partial[i+1:]
 	}
+}
 
-	if stderrOutput != "" && !strings.Contains(stderrOutput, "not found") {
-		output = "# Warning: " + strings.TrimSpace(stderrOutput) + "\n" + output
+// flush writes a last line that did not end in a newline
+func (w *prefixWriter) flush() {
+	if len(w.partial) > 0 {
+		w.writeLine(append(w.partial, '\n'))
+		w.partial = nil
 	}
+}
 
-	return output, nil
+func (w *prefixWriter) writeLine(line []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fmt.Fprintf(w.out, "%s%s", w.prefix, line)
 }
 
-func getMatchingPods(clusterInfo cluster.ClusterInfo, pattern, namespace string, allNamespaces bool) ([]string, error) {
-	var matchingPods []string
+// getMatchingPodObjects lists the pods matching the name pattern (which may
+// contain wildcards, empty matches everything) and label selector
+func getMatchingPodObjects(clusterInfo cluster.ClusterInfo, pattern, selector, namespace string, allNamespaces bool) ([]corev1.Pod, error) {
+	var matchingPods []corev1.Pod
 
 	targetNS := ""
 	if allNamespaces {
@@ -272,7 +490,9 @@ func getMatchingPods(clusterInfo cluster.ClusterInfo, pattern, namespace string,
 		targetNS = "default"
 	}
 
-	pods, err := clusterInfo.Client.CoreV1().Pods(targetNS).List(context.TODO(), metav1.ListOptions{})
+	pods, err := clusterInfo.Client.CoreV1().Pods(targetNS).List(commandContext(), metav1.ListOptions{
+		LabelSelector: selector,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -280,19 +500,21 @@ func getMatchingPods(clusterInfo cluster.ClusterInfo, pattern, namespace string,
 	hasWildcard := strings.Contains(pattern, "*")
 
 	for _, pod := range pods.Items {
-		if hasWildcard {
+		if pattern == "" {
+			matchingPods = append(matchingPods, pod)
+		} else if hasWildcard {
 
 			matched, err := filepath.Match(pattern, pod.Name)
 			if err != nil {
 				continue
 			}
 			if matched {
-				matchingPods = append(matchingPods, pod.Name)
+				matchingPods = append(matchingPods, pod)
 			}
 		} else {
 
 			if pod.Name == pattern {
-				matchingPods = append(matchingPods, pod.Name)
+				matchingPods = append(matchingPods, pod)
 			}
 		}
 	}
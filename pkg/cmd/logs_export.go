@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kubectl-multi/pkg/cluster"
+)
+
+// logExportConcurrency bounds the number of log streams read at once
+const logExportConcurrency = 8
+
+// logIndexEntry describes one log file written by --output-dir
+type logIndexEntry struct {
+	Cluster   string `json:"cluster"`
+	Namespace string `json:"namespace"`
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+	File      string `json:"file"`
+	Bytes     int64  `json:"bytes"`
+	Error     string `json:"error,omitempty"`
+}
+
+// logIndex is the manifest written next to the exported log files
+type logIndex struct {
+	Collected time.Time       `json:"collected"`
+	Selector  string          `json:"selector,omitempty"`
+	Pattern   string          `json:"pattern,omitempty"`
+	Files     []logIndexEntry `json:"files"`
+}
+
+// logStream identifies one container log to export
+type logStream struct {
+	cluster   cluster.ClusterInfo
+	namespace string
+	pod       string
+	container string
+}
+
+// buildPodLogOptions converts the logs flags into PodLogOptions
+func buildPodLogOptions(previous bool, container, since, sinceTime string, timestamps bool, tail, limitBytes int64) (*corev1.PodLogOptions, error) {
+	opts := &corev1.PodLogOptions{
+		Container:  container,
+		Previous:   previous,
+		Timestamps: timestamps,
+	}
+	if since != "" && sinceTime != "" {
+		return nil, fmt.Errorf("at most one of --since or --since-time may be specified")
+	}
+	if since != "" {
+		d, err := time.ParseDuration(since)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --since %q: %v", since, err)
+		}
+		seconds := int64(d.Round(time.Second).Seconds())
+		opts.SinceSeconds = &seconds
+	}
+	if sinceTime != "" {
+		t, err := time.Parse(time.RFC3339, sinceTime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --since-time %q: %v", sinceTime, err)
+		}
+		mt := metav1.NewTime(t)
+		opts.SinceTime = &mt
+	}
+	if tail >= 0 {
+		opts.TailLines = &tail
+	}
+	if limitBytes > 0 {
+		opts.LimitBytes = &limitBytes
+	}
+	return opts, nil
+}
+
+// handleLogsToDir streams the logs of every matching container in every
+// cluster, in parallel, into DIR/CLUSTER/NAMESPACE/POD/CONTAINER.log and
+// writes DIR/index.json describing the files
+func handleLogsToDir(podPattern, selector string, opts *corev1.PodLogOptions, outputDir string, targets clusterTargets, reach reachability, kubeconfig, remoteCtx, namespace string, allNamespaces bool) error {
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	clusters, err = targets.selectFrom(clusters, kubeconfig)
+	if err != nil {
+		return err
+	}
+	clusters, err = reach.filter(clusters)
+	if err != nil {
+		return err
+	}
+	defer printClusterIssuesToStderr()
+	if len(clusters) == 0 {
+		return fmt.Errorf("no clusters discovered")
+	}
+
+	var streams []logStream
+	for _, clusterInfo := range clusters {
+		if clusterInfo.Client == nil {
+			noteClusterIssue(clusterInfo.Name, "no client available")
+			continue
+		}
+		pods, err := getMatchingPodObjects(clusterInfo, podPattern, selector, namespace, allNamespaces)
+		if err != nil {
+			noteClusterIssue(clusterInfo.Name, fmt.Sprintf("failed to list pods: %v", err))
+			continue
+		}
+		streams = append(streams, podLogStreams(clusterInfo, pods, opts.Container)...)
+	}
+
+	if len(streams) == 0 {
+		return fmt.Errorf("no matching pods found in any cluster")
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory %s: %v", outputDir, err)
+	}
+
+	entries := make([]logIndexEntry, len(streams))
+	sem := make(chan struct{}, logExportConcurrency)
+	var wg sync.WaitGroup
+	for i, s := range streams {
+		wg.Add(1)
+		go func(i int, s logStream) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			entries[i] = exportContainerLog(s, opts, outputDir)
+		}(i, s)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, e := range entries {
+		if e.Error != "" {
+			failed++
+			noteClusterIssue(e.Cluster, fmt.Sprintf("failed to export %s/%s [%s]: %s", e.Namespace, e.Pod, e.Container, e.Error))
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].File < entries[j].File })
+	index := logIndex{Collected: time.Now().UTC(), Selector: selector, Pattern: podPattern, Files: entries}
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode log index: %v", err)
+	}
+	indexPath := filepath.Join(outputDir, "index.json")
+	if err := os.WriteFile(indexPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", indexPath, err)
+	}
+
+	fmt.Printf("Wrote %d log file(s) to %s (index: %s)\n", len(entries)-failed, outputDir, indexPath)
+	if failed > 0 {
+		return fmt.Errorf("failed to collect %d of %d log stream(s)", failed, len(entries))
+	}
+	return nil
+}
+
+// podLogStreams lists the init and regular containers of the pods, limited to
+// the named container when one is given
+func podLogStreams(clusterInfo cluster.ClusterInfo, pods []corev1.Pod, container string) []logStream {
+	var streams []logStream
+	for _, pod := range pods {
+		containers := append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+		for _, c := range containers {
+			if container != "" && c.Name != container {
+				continue
+			}
+			streams = append(streams, logStream{cluster: clusterInfo, namespace: pod.Namespace, pod: pod.Name, container: c.Name})
+		}
+	}
+	return streams
+}
+
+// exportContainerLog copies one container's log stream into its file
+func exportContainerLog(s logStream, base *corev1.PodLogOptions, outputDir string) logIndexEntry {
+	rel := filepath.Join(s.cluster.Name, s.namespace, s.pod, s.container+".log")
+	entry := logIndexEntry{Cluster: s.cluster.Name, Namespace: s.namespace, Pod: s.pod, Container: s.container, File: rel}
+
+	opts := base.DeepCopy()
+	opts.Container = s.container
+
+	path := filepath.Join(outputDir, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+
+	stream, err := s.cluster.Client.CoreV1().Pods(s.namespace).GetLogs(s.pod, opts).Stream(commandContext())
+	if err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+	defer stream.Close()
+
+	f, err := os.Create(path)
+	if err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+	defer f.Close()
+
+	entry.Bytes, err = io.Copy(f, stream)
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	return entry
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kubectl-multi/pkg/cluster"
+)
+
+func TestBuildPodLogOptions(t *testing.T) {
+	int64p := func(v int64) *int64 { return &v }
+	sinceTime := metav1.NewTime(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name       string
+		previous   bool
+		container  string
+		since      string
+		sinceTime  string
+		timestamps bool
+		tail       int64
+		limitBytes int64
+		want       *corev1.PodLogOptions
+		wantErr    bool
+	}{
+		{name: "defaults", tail: -1, want: &corev1.PodLogOptions{}},
+		{
+			name: "all options", previous: true, container: "app", since: "90m", timestamps: true, tail: 20, limitBytes: 1024,
+			want: &corev1.PodLogOptions{Container: "app", Previous: true, Timestamps: true, SinceSeconds: int64p(5400), TailLines: int64p(20), LimitBytes: int64p(1024)},
+		},
+		{name: "zero tail", tail: 0, want: &corev1.PodLogOptions{TailLines: int64p(0)}},
+		{name: "since time", sinceTime: "2024-05-01T12:00:00Z", tail: -1, want: &corev1.PodLogOptions{SinceTime: &sinceTime}},
+		{name: "since and since time", since: "1h", sinceTime: "2024-05-01T12:00:00Z", tail: -1, wantErr: true},
+		{name: "invalid since", since: "yesterday", tail: -1, wantErr: true},
+		{name: "invalid since time", sinceTime: "2024-05-01", tail: -1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildPodLogOptions(tt.previous, tt.container, tt.since, tt.sinceTime, tt.timestamps, tt.tail, tt.limitBytes)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("buildPodLogOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildPodLogOptions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPodLogStreams(t *testing.T) {
+	clusterInfo := cluster.ClusterInfo{Name: "cluster1"}
+	pods := []corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "web-1"},
+			Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "migrate"}},
+				Containers:     []corev1.Container{{Name: "app"}, {Name: "sidecar"}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "web-2"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		container string
+		want      []string
+	}{
+		{name: "all containers", want: []string{"web-1/migrate", "web-1/app", "web-1/sidecar", "web-2/app"}},
+		{name: "one container", container: "app", want: []string{"web-1/app", "web-2/app"}},
+		{name: "init container", container: "migrate", want: []string{"web-1/migrate"}},
+		{name: "no match", container: "missing", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, s := range podLogStreams(clusterInfo, pods, tt.container) {
+				if s.cluster.Name != "cluster1" || s.namespace != "prod" {
+					t.Errorf("stream %+v has the wrong cluster or namespace", s)
+				}
+				got = append(got, s.pod+"/"+s.container)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("podLogStreams() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
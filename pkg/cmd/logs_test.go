@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"kubectl-multi/pkg/cluster"
+)
+
+func testLogPod(annotations map[string]string, containers ...string) corev1.Pod {
+	pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "apps", Annotations: annotations}}
+	for _, name := range containers {
+		pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: name})
+	}
+	return pod
+}
+
+func TestStreamPodLogs(t *testing.T) {
+	tests := []struct {
+		name          string
+		pod           corev1.Pod
+		container     string
+		wantContainer string
+	}{
+		{name: "first container", pod: testLogPod(nil, "app", "sidecar"), wantContainer: "app"},
+		{name: "requested container", pod: testLogPod(nil, "app", "sidecar"), container: "sidecar", wantContainer: "sidecar"},
+		{
+			name:          "default-container annotation",
+			pod:           testLogPod(map[string]string{defaultContainerAnnotation: "sidecar"}, "app", "sidecar"),
+			wantContainer: "sidecar",
+		},
+		{
+			name:          "annotation naming a missing container",
+			pod:           testLogPod(map[string]string{defaultContainerAnnotation: "gone"}, "app"),
+			wantContainer: "app",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			opts := &corev1.PodLogOptions{Container: tt.container}
+			var out bytes.Buffer
+			if err := streamPodLogs(client, tt.pod, opts, &out); err != nil {
+				t.Fatalf("streamPodLogs() error = %v", err)
+			}
+			if out.String() != "fake logs" {
+				t.Errorf("streamPodLogs() wrote %q", out.String())
+			}
+			actions := client.Actions()
+			if len(actions) != 1 || actions[0].GetSubresource() != "log" || actions[0].GetNamespace() != "apps" {
+				t.Fatalf("actions = %v, want one pods/log get in apps", actions)
+			}
+			sent := actions[0].(clienttesting.GenericAction).GetValue().(*corev1.PodLogOptions)
+			if sent.Container != tt.wantContainer {
+				t.Errorf("container = %q, want %q", sent.Container, tt.wantContainer)
+			}
+			if opts.Container != tt.container {
+				t.Errorf("streamPodLogs() changed the shared options to %q", opts.Container)
+			}
+		})
+	}
+}
+
+func TestPrefixWriter(t *testing.T) {
+	tests := []struct {
+		name   string
+		writes []string
+		want   string
+	}{
+		{name: "whole lines", writes: []string{"a\nb\n"}, want: "[c1/web] a\n[c1/web] b\n"},
+		{name: "split line", writes: []string{"hel", "lo\nwor", "ld\n"}, want: "[c1/web] hello\n[c1/web] world\n"},
+		{name: "unterminated last line", writes: []string{"a\nb"}, want: "[c1/web] a\n[c1/web] b\n"},
+		{name: "nothing", writes: nil, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			w := &prefixWriter{mu: &sync.Mutex{}, out: &out, prefix: "[c1/web] "}
+			for _, s := range tt.writes {
+				if n, err := w.Write([]byte(s)); err != nil || n != len(s) {
+					t.Fatalf("Write(%q) = %d, %v", s, n, err)
+				}
+			}
+			w.flush()
+			if out.String() != tt.want {
+				t.Errorf("output = %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestLogTargets(t *testing.T) {
+	pod := testLogPod(nil, "app", "sidecar")
+	pod.Spec.InitContainers = []corev1.Container{{Name: "migrate"}}
+	c1 := cluster.ClusterInfo{Name: "c1"}
+
+	tests := []struct {
+		name          string
+		container     string
+		allContainers bool
+		want          []string
+	}{
+		{name: "default container", want: []string{""}},
+		{name: "named container", container: "sidecar", want: []string{"sidecar"}},
+		{name: "all containers, init first", allContainers: true, want: []string{"migrate", "app", "sidecar"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, target := range logTargets(c1, []corev1.Pod{pod}, tt.container, tt.allContainers) {
+				got = append(got, target.container)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("logTargets() containers = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadContainerLogs(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	c1 := cluster.ClusterInfo{Name: "c1", Client: client}
+	pod := testLogPod(nil, "app", "sidecar")
+	targets := logTargets(c1, []corev1.Pod{pod, pod, pod}, "", true)
+
+	opts := &corev1.PodLogOptions{Previous: true}
+	logs, errs := readContainerLogs(targets, opts, 2)
+	if len(logs) != 6 || len(errs) != 6 {
+		t.Fatalf("readContainerLogs() returned %d logs and %d errors, want 6", len(logs), len(errs))
+	}
+	for i := range logs {
+		if errs[i] != nil || logs[i] != "fake logs" {
+			t.Errorf("log %d = %q, %v", i, logs[i], errs[i])
+		}
+	}
+
+	containers := map[string]int{}
+	for _, action := range client.Actions() {
+		sent := action.(clienttesting.GenericAction).GetValue().(*corev1.PodLogOptions)
+		if !sent.Previous {
+			t.Errorf("log request of %s lost --previous", sent.Container)
+		}
+		containers[sent.Container]++
+	}
+	if want := map[string]int{"app": 3, "sidecar": 3}; !reflect.DeepEqual(containers, want) {
+		t.Errorf("requested containers = %v, want %v", containers, want)
+	}
+	if opts.Container != "" {
+		t.Errorf("readContainerLogs() changed the shared options to %q", opts.Container)
+	}
+}
+
+func TestFollowLogsMaxLogRequests(t *testing.T) {
+	defer resetClusterIssues()
+	pods := []runtime.Object{
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default"}, Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-2", Namespace: "default"}, Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}}},
+	}
+	clusters := []cluster.ClusterInfo{
+		{Name: "c1", Client: fake.NewSimpleClientset(pods...)},
+		{Name: "c2", Client: fake.NewSimpleClientset(pods...)},
+	}
+	out := logsOutput{MaxLogRequests: 3, Prefix: true}
+	err := followLogs(clusters, "web-*", "", &corev1.PodLogOptions{Follow: true}, out, "", false)
+	if err == nil || !strings.Contains(err.Error(), "follow 4 log streams") {
+		t.Errorf("followLogs() error = %v, want the --max-log-requests limit", err)
+	}
+}
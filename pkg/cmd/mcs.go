@@ -0,0 +1,257 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+	"kubectl-multi/pkg/util"
+)
+
+// Verdicts of mcs status for the service in one cluster
+const (
+	mcsExported    = "Exported"
+	mcsImporting   = "Importing"
+	mcsPending     = "Pending"
+	mcsConflict    = "Conflict"
+	mcsInvalid     = "Invalid"
+	mcsNoService   = "NoService"
+	mcsNotImported = "NotImported"
+	mcsStale       = "StaleImport"
+	mcsAbsent      = "Absent"
+	mcsUnknown     = "Unknown"
+)
+
+// mcsServiceStatus is how one cluster exports and imports a service through
+// the Multi-Cluster Services API
+type mcsServiceStatus struct {
+	Cluster string `json:"cluster"`
+	Status  string `json:"status"`
+	// ServiceType is the type of the local Service, empty when there is none
+	ServiceType string `json:"serviceType,omitempty"`
+	// Export is the state of the local ServiceExport, empty when there is none
+	Export string `json:"export,omitempty"`
+	// Import is the type and IPs of the local ServiceImport, empty when
+	// there is none
+	Import string `json:"import,omitempty"`
+	// ImportClusters are the exporting clusters the ServiceImport lists
+	ImportClusters []string `json:"importClusters,omitempty"`
+	Detail         string   `json:"detail,omitempty"`
+
+	hasImport bool
+}
+
+// ok reports whether the cluster exports or consumes the service as intended
+func (s mcsServiceStatus) ok() bool {
+	return s.Status == mcsExported || s.Status == mcsImporting
+}
+
+func newMCSCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mcs",
+		Short: "Inspect Multi-Cluster Services API exports and imports across managed clusters",
+	}
+	cmd.AddCommand(newMCSStatusCommand())
+	return cmd
+}
+
+func newMCSStatusCommand() *cobra.Command {
+	var outputFormat string
+	var targets clusterTargets
+	var reach reachability
+
+	cmd := &cobra.Command{
+		Use:   "status SERVICE",
+		Short: "Correlate the ServiceExports and ServiceImports of a service across managed clusters",
+		Long: `Read the Service, ServiceExport and ServiceImport (multicluster.x-k8s.io) of
+a service in every managed cluster and check that they agree, for fleets that
+combine KubeStellar with an MCS implementation such as Submariner or Cilium
+ClusterMesh:
+
+  Exported     the service is exported and the cluster imports it
+  Importing    the cluster consumes the service without exporting it
+  Pending      the ServiceExport is not accepted yet
+  Conflict     the export conflicts with the exports of other clusters
+  Invalid      the ServiceExport cannot be exported
+  NoService    a ServiceExport exists without its Service
+  NotImported  other clusters export the service but this one has no ServiceImport
+  StaleImport  the ServiceImport lists other clusters than those exporting
+  Absent       the cluster neither exports nor imports the service
+
+StaleImport compares the cluster names the MCS implementation reports with
+the managed cluster names, so it assumes both name clusters alike. The
+command fails when the service is not Exported or Importing in any cluster,
+so it can gate scripts.`,
+		Example: `# How the web service of the prod namespace is exported and imported
+kubectl multi mcs status web -n prod
+
+# Two clusters, as JSON
+kubectl multi mcs status web -n prod --clusters cluster1,cluster2 -o json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch outputFormat {
+			case "", "json", util.TableFormatCSV, util.TableFormatMarkdown:
+			default:
+				return fmt.Errorf("unsupported output format %q, must be one of json|csv|markdown", outputFormat)
+			}
+			kubeconfig, remoteCtx, _, namespace, _ := GetGlobalFlags()
+			return handleMCSStatusCommand(args[0], outputFormat, targets, reach, kubeconfig, remoteCtx, namespace)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "output format (json|csv|markdown)")
+	targets.addFlags(cmd, "check")
+	reach.addFlags(cmd)
+
+	return cmd
+}
+
+func handleMCSStatusCommand(service, outputFormat string, targets clusterTargets, reach reachability, kubeconfig, remoteCtx, namespace string) error {
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	clusters, err = targets.selectFrom(clusters, kubeconfig)
+	if err != nil {
+		return err
+	}
+	clusters, err = reach.filter(clusters)
+	if err != nil {
+		return err
+	}
+	defer printClusterIssuesToStderr()
+
+	ns := cluster.GetTargetNamespace(namespace)
+	var results []mcsServiceStatus
+	for _, c := range clusters {
+		// Services are not delivered to the ITS
+		if c.Context == remoteCtx || c.Client == nil || c.DynamicClient == nil {
+			continue
+		}
+		results = append(results, readMCSService(c, ns, service))
+	}
+	exporters := correlateMCSService(results)
+
+	if outputFormat == "json" {
+		if err := printJSONArray(results); err != nil {
+			return err
+		}
+	} else {
+		printMCSServiceStatus(results, outputFormat)
+	}
+	if len(exporters) == 0 && len(results) > 0 {
+		return fmt.Errorf("service %s/%s is not exported from any cluster", ns, service)
+	}
+	failing := 0
+	for _, r := range results {
+		if !r.ok() {
+			failing++
+		}
+	}
+	if failing > 0 {
+		return fmt.Errorf("service %s/%s is not exported or imported as expected in %d of %d cluster(s)", ns, service, failing, len(results))
+	}
+	return nil
+}
+
+// readMCSService reads the Service, ServiceExport and ServiceImport of one
+// cluster and judges what it can without the other clusters
+func readMCSService(c cluster.ClusterInfo, namespace, name string) mcsServiceStatus {
+	r := mcsServiceStatus{Cluster: c.Name}
+	svc, err := c.Client.CoreV1().Services(namespace).Get(commandContext(), name, metav1.GetOptions{})
+	switch {
+	case err == nil:
+		r.ServiceType = string(svc.Spec.Type)
+	case !apierrors.IsNotFound(err):
+		r.Status, r.Detail = mcsUnknown, fmt.Sprintf("failed to get service: %v", err)
+		return r
+	}
+
+	// A cluster without the MCS CRDs answers NotFound as for a missing object
+	export, err := c.DynamicClient.Resource(kubestellar.ServiceExportGVR).Namespace(namespace).Get(commandContext(), name, metav1.GetOptions{})
+	switch {
+	case err == nil:
+		r.Export = kubestellar.ServiceExportState(export)
+		r.Detail = kubestellar.ServiceExportMessage(export)
+	case !apierrors.IsNotFound(err):
+		r.Status, r.Detail = mcsUnknown, fmt.Sprintf("failed to get serviceexport: %v", err)
+		return r
+	}
+
+	imp, err := c.DynamicClient.Resource(kubestellar.ServiceImportGVR).Namespace(namespace).Get(commandContext(), name, metav1.GetOptions{})
+	switch {
+	case err == nil:
+		r.hasImport = true
+		r.Import = kubestellar.ServiceImportSummary(imp)
+		r.ImportClusters = kubestellar.ServiceImportClusters(imp)
+	case !apierrors.IsNotFound(err):
+		r.Status, r.Detail = mcsUnknown, fmt.Sprintf("failed to get serviceimport: %v", err)
+		return r
+	}
+
+	switch {
+	case r.Export != "" && r.ServiceType == "":
+		r.Status, r.Detail = mcsNoService, "the ServiceExport has no Service to export"
+	case r.Export == kubestellar.ServiceExportConflict:
+		r.Status = mcsConflict
+	case r.Export == kubestellar.ServiceExportInvalid:
+		r.Status = mcsInvalid
+	case r.Export == kubestellar.ServiceExportPending:
+		r.Status = mcsPending
+	}
+	return r
+}
+
+// correlateMCSService judges the clusters against each other once all are
+// read, and returns the clusters whose export is ready
+func correlateMCSService(results []mcsServiceStatus) []string {
+	var exporters []string
+	for _, r := range results {
+		if r.Status == "" && r.Export == kubestellar.ServiceExportReady {
+			exporters = append(exporters, r.Cluster)
+		}
+	}
+	slices.Sort(exporters)
+
+	for i := range results {
+		r := &results[i]
+		if r.Status != "" {
+			continue
+		}
+		switch {
+		case !r.hasImport && len(exporters) > 0:
+			r.Status, r.Detail = mcsNotImported, "exported by "+strings.Join(exporters, ",")
+		case !r.hasImport:
+			r.Status = mcsAbsent
+		case len(r.ImportClusters) > 0 && !slices.Equal(r.ImportClusters, exporters):
+			r.Status = mcsStale
+			r.Detail = fmt.Sprintf("imports from %s, exported by %s", strings.Join(r.ImportClusters, ","), noneIfEmpty(strings.Join(exporters, ",")))
+		case r.Export == kubestellar.ServiceExportReady:
+			r.Status = mcsExported
+		default:
+			r.Status = mcsImporting
+		}
+	}
+	return exporters
+}
+
+func printMCSServiceStatus(results []mcsServiceStatus, outputFormat string) {
+	tw := util.NewTableWriter(util.GetOutputStream(), outputFormat)
+	defer tw.Flush()
+
+	if len(results) == 0 {
+		fmt.Fprintln(tw, "No clusters checked.")
+		return
+	}
+	fmt.Fprintln(tw, "CLUSTER\tSTATUS\tSERVICE\tEXPORT\tIMPORT\tIMPORT CLUSTERS\tDETAIL")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.Cluster, r.Status, orNone(r.ServiceType), orNone(r.Export),
+			orNone(r.Import), orNone(strings.Join(r.ImportClusters, ",")), orNone(r.Detail))
+	}
+}
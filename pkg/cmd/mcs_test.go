@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+)
+
+// testMCSCluster returns a cluster holding the web Service when withService,
+// a ServiceExport in the given state unless empty, and a ServiceImport
+// listing importFrom unless nil
+func testMCSCluster(name string, withService bool, exportCondition string, importFrom []string) cluster.ClusterInfo {
+	var typed []runtime.Object
+	if withService {
+		typed = append(typed, testService("web", map[string]string{"app": "web"}))
+	}
+	var objects []runtime.Object
+	if exportCondition != "" {
+		objects = append(objects, testControlObject(kubestellar.ServiceExportGVR, "ServiceExport", "prod", "web", map[string]interface{}{
+			"status": map[string]interface{}{"conditions": []interface{}{
+				map[string]interface{}{"type": exportCondition, "status": "True", "message": exportCondition + " reported"},
+			}},
+		}))
+	}
+	if importFrom != nil {
+		var clusters []interface{}
+		for _, c := range importFrom {
+			clusters = append(clusters, map[string]interface{}{"cluster": c})
+		}
+		objects = append(objects, testControlObject(kubestellar.ServiceImportGVR, "ServiceImport", "prod", "web", map[string]interface{}{
+			"spec":   map[string]interface{}{"type": "ClusterSetIP", "ips": []interface{}{"10.96.0.20"}},
+			"status": map[string]interface{}{"clusters": clusters},
+		}))
+	}
+	listKinds := map[schema.GroupVersionResource]string{
+		kubestellar.ServiceExportGVR: "ServiceExportList",
+		kubestellar.ServiceImportGVR: "ServiceImportList",
+	}
+	return cluster.ClusterInfo{
+		Name:          name,
+		Client:        fake.NewSimpleClientset(typed...),
+		DynamicClient: fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objects...),
+	}
+}
+
+func TestMCSServiceStatus(t *testing.T) {
+	clusters := []cluster.ClusterInfo{
+		testMCSCluster("cluster1", true, "Valid", []string{"cluster1", "cluster2"}),
+		testMCSCluster("cluster2", true, "Ready", []string{"cluster1", "cluster2"}),
+		testMCSCluster("cluster3", false, "", []string{"cluster1", "cluster2"}),
+		testMCSCluster("cluster4", true, "", nil),
+		testMCSCluster("cluster5", false, "Valid", nil),
+		testMCSCluster("cluster6", true, "Conflict", []string{"cluster1"}),
+		testMCSCluster("cluster7", false, "", []string{"cluster1"}),
+	}
+	var results []mcsServiceStatus
+	for _, c := range clusters {
+		results = append(results, readMCSService(c, "prod", "web"))
+	}
+	exporters := correlateMCSService(results)
+	if want := []string{"cluster1", "cluster2"}; !reflect.DeepEqual(exporters, want) {
+		t.Errorf("exporters = %q, want %q", exporters, want)
+	}
+
+	want := []struct{ status, detail string }{
+		{status: mcsExported},
+		{status: mcsExported},
+		{status: mcsImporting},
+		{status: mcsNotImported, detail: "exported by cluster1,cluster2"},
+		{status: mcsNoService, detail: "the ServiceExport has no Service to export"},
+		{status: mcsConflict, detail: "Conflict reported"},
+		{status: mcsStale, detail: "imports from cluster1, exported by cluster1,cluster2"},
+	}
+	for i, r := range results {
+		if r.Status != want[i].status || r.Detail != want[i].detail {
+			t.Errorf("%s = %s (%q), want %s (%q)", r.Cluster, r.Status, r.Detail, want[i].status, want[i].detail)
+		}
+	}
+	if r := results[0]; r.ServiceType != "ClusterIP" || r.Export != kubestellar.ServiceExportReady || r.Import != "ClusterSetIP 10.96.0.20" {
+		t.Errorf("cluster1 = %+v", r)
+	}
+}
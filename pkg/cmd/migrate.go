@@ -0,0 +1,363 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+// migrateOptions holds the flags of the migrate command
+type migrateOptions struct {
+	From         string
+	To           string
+	DeleteSource bool
+	DryRun       bool
+	Overwrite    bool
+	Wait         bool
+	Timeout      time.Duration
+}
+
+func newMigrateCommand() *cobra.Command {
+	var o migrateOptions
+
+	cmd := &cobra.Command{
+		Use:   "migrate (TYPE/NAME | TYPE NAME) --from CLUSTER --to CLUSTER",
+		Short: "Move a workload from one managed cluster to another",
+		Long: `Move a workload from one managed cluster to another.
+The object is read from the source cluster, stripped of cluster-specific
+fields (status, UIDs, resource versions, owner references, allocated IPs and
+volume bindings), created in the target cluster and, by default, waited on
+until it is ready. With --delete-source it is then removed from the source.
+
+Only the object itself is copied: data held in PersistentVolumes is not
+migrated, and a warning is printed when the workload uses volume claims.`,
+		Example: `# Preview the object that would be created in cluster2
+kubectl multi migrate deployment/nginx -n prod --from cluster1 --to cluster2 --dry-run
+
+# Copy to cluster2, wait until it is ready, then remove it from cluster1
+kubectl multi migrate deployment/nginx -n prod --from cluster1 --to cluster2 --delete-source`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resourceType, name, err := parseTypeName(args)
+			if err != nil {
+				return err
+			}
+			if o.From == "" || o.To == "" {
+				return fmt.Errorf("--from and --to must both be specified")
+			}
+			if o.From == o.To {
+				return fmt.Errorf("--from and --to must name different clusters")
+			}
+			if o.DeleteSource && !o.Wait {
+				return fmt.Errorf("--delete-source requires waiting for the target to become ready")
+			}
+
+			kubeconfig, remoteCtx, _, namespace, _ := GetGlobalFlags()
+			var rec *audit.Recorder
+			if !o.DryRun {
+				rec = startAudit("migrate")
+			}
+			err = handleMigrateCommand(resourceType, name, o, rec, kubeconfig, remoteCtx, namespace)
+			finishAudit(rec, err)
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&o.From, "from", "", "managed cluster to move the workload from")
+	cmd.Flags().StringVar(&o.To, "to", "", "managed cluster to move the workload to")
+	cmd.Flags().BoolVar(&o.DeleteSource, "delete-source", false, "delete the workload from the source cluster once it is ready in the target")
+	cmd.Flags().BoolVar(&o.DryRun, "dry-run", false, "print the object that would be created and validate it against the target cluster")
+	cmd.Flags().BoolVar(&o.Overwrite, "overwrite", false, "replace the object if it already exists in the target cluster")
+	cmd.Flags().BoolVar(&o.Wait, "wait", true, "wait for the workload to become ready in the target cluster")
+	cmd.Flags().DurationVar(&o.Timeout, "timeout", 5*time.Minute, "how long to wait for the workload to become ready")
+
+	return cmd
+}
+
+func handleMigrateCommand(resourceType, name string, o migrateOptions, rec *audit.Recorder, kubeconfig, remoteCtx, namespace string) error {
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	selected, err := cluster.SelectClusters(clusters, []string{o.From, o.To})
+	if err != nil {
+		return err
+	}
+	source, target := selected[0], selected[1]
+	for _, c := range selected {
+		if c.DynamicClient == nil || c.DiscoveryClient == nil {
+			return fmt.Errorf("no clients available for cluster %s", c.Name)
+		}
+	}
+
+	gvr, namespaced, err := util.DiscoverGVR(source.DiscoveryClient, resourceType)
+	if err != nil {
+		return fmt.Errorf("failed to resolve resource type %s: %v", resourceType, err)
+	}
+	ns := ""
+	if namespaced {
+		ns = cluster.GetTargetNamespace(namespace)
+	}
+	resourceClient := func(c cluster.ClusterInfo) dynamic.ResourceInterface {
+		if namespaced {
+			return c.DynamicClient.Resource(gvr).Namespace(ns)
+		}
+		return c.DynamicClient.Resource(gvr)
+	}
+	ref := fmt.Sprintf("%s/%s", gvr.Resource, name)
+
+	ctx := commandContext()
+	live, err := resourceClient(source).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get %s in cluster %s: %v", ref, source.Name, err)
+	}
+	obj := exportObject(live)
+	util.MarkManaged(obj)
+
+	if claims := volumeClaims(obj); len(claims) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %s uses persistent volume claims (%s); data in PersistentVolumes is not migrated\n", ref, strings.Join(claims, ", "))
+	}
+
+	existing, err := resourceClient(target).Get(ctx, name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		existing = nil
+	case err != nil:
+		return fmt.Errorf("failed to check %s in cluster %s: %v", ref, target.Name, err)
+	case !o.Overwrite:
+		return fmt.Errorf("%s already exists in cluster %s (use --overwrite to replace it)", ref, target.Name)
+	}
+	if namespaced {
+		if _, err := target.Client.CoreV1().Namespaces().Get(ctx, ns, metav1.GetOptions{}); err != nil {
+			return fmt.Errorf("namespace %s is not available in cluster %s: %v", ns, target.Name, err)
+		}
+	}
+
+	if o.DryRun {
+		if existing == nil {
+			_, err = resourceClient(target).Create(ctx, obj.DeepCopy(), metav1.CreateOptions{FieldManager: util.FieldManager, DryRun: []string{metav1.DryRunAll}})
+		} else {
+			update := obj.DeepCopy()
+			update.SetResourceVersion(existing.GetResourceVersion())
+			_, err = resourceClient(target).Update(ctx, update, metav1.UpdateOptions{FieldManager: util.FieldManager, DryRun: []string{metav1.DryRunAll}})
+		}
+		if err != nil {
+			return fmt.Errorf("target cluster %s rejected %s: %v", target.Name, ref, err)
+		}
+		data, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %v", ref, err)
+		}
+		fmt.Printf("# %s would be migrated from %s to %s", ref, source.Name, target.Name)
+		if o.DeleteSource {
+			fmt.Printf(" and deleted from %s", source.Name)
+		}
+		fmt.Printf(" (dry run)\n---\n%s", data)
+		return nil
+	}
+
+	changed := []cluster.ClusterInfo{target}
+	if o.DeleteSource {
+		changed = append(changed, source)
+	}
+	radius := blastRadius{Objects: len(changed)}
+	if namespaced {
+		radius.Namespaces = []string{ns}
+	}
+	if err := guardFanOut(rec, radius, changed, remoteCtx); err != nil {
+		return err
+	}
+
+	undo := audit.UndoStep{Cluster: target.Name, Group: gvr.Group, Version: gvr.Version, Resource: gvr.Resource, Namespace: ns, Name: name}
+	if existing == nil {
+		undo.Action = audit.UndoDelete
+	} else {
+		undo.Action = audit.UndoRestore
+		undo.Object = snapshotObject(existing).Object
+	}
+	err = restoreObject(ctx, resourceClient(target), obj.DeepCopy())
+	rec.Record(target.Name, err)
+	if err != nil {
+		return fmt.Errorf("failed to create %s in cluster %s: %v", ref, target.Name, err)
+	}
+	rec.AddUndo(undo)
+	fmt.Printf("%s created in cluster %s\n", ref, target.Name)
+
+	if o.Wait {
+		fmt.Printf("Waiting up to %s for %s to become ready in cluster %s...\n", o.Timeout, ref, target.Name)
+		if err := waitForReady(ctx, resourceClient(target), name, o.Timeout); err != nil {
+			return fmt.Errorf("%s is not ready in cluster %s, source left in place: %v", ref, target.Name, err)
+		}
+		fmt.Printf("%s is ready in cluster %s\n", ref, target.Name)
+	}
+
+	if o.DeleteSource {
+		err := resourceClient(source).Delete(ctx, name, metav1.DeleteOptions{})
+		rec.Record(source.Name, err)
+		if err != nil {
+			return fmt.Errorf("failed to delete %s from cluster %s: %v", ref, source.Name, err)
+		}
+		rec.AddUndo(audit.UndoStep{Cluster: source.Name, Action: audit.UndoRestore, Group: gvr.Group, Version: gvr.Version,
+			Resource: gvr.Resource, Namespace: ns, Name: name, Object: snapshotObject(live).Object})
+		fmt.Printf("%s deleted from cluster %s\n", ref, source.Name)
+	}
+	return nil
+}
+
+// exportObject strips the fields that only make sense in the cluster the
+// object was read from
+func exportObject(live *unstructured.Unstructured) *unstructured.Unstructured {
+	obj := snapshotObject(live)
+	unstructured.RemoveNestedField(obj.Object, "metadata", "ownerReferences")
+	unstructured.RemoveNestedField(obj.Object, "metadata", "selfLink")
+	unstructured.RemoveNestedField(obj.Object, "metadata", "finalizers")
+
+	annotations := obj.GetAnnotations()
+	for key := range annotations {
+		if key == "kubectl.kubernetes.io/last-applied-configuration" ||
+			key == "deployment.kubernetes.io/revision" ||
+			strings.HasPrefix(key, "pv.kubernetes.io/") ||
+			strings.HasPrefix(key, "volume.kubernetes.io/") ||
+			strings.HasPrefix(key, "volume.beta.kubernetes.io/") {
+			delete(annotations, key)
+		}
+	}
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+	obj.SetAnnotations(annotations)
+
+	switch obj.GetKind() {
+	case "Service":
+		unstructured.RemoveNestedField(obj.Object, "spec", "clusterIP")
+		unstructured.RemoveNestedField(obj.Object, "spec", "clusterIPs")
+	case "PersistentVolumeClaim":
+		unstructured.RemoveNestedField(obj.Object, "spec", "volumeName")
+	case "Pod":
+		unstructured.RemoveNestedField(obj.Object, "spec", "nodeName")
+	case "Job":
+		// The selector and its controller-uid label are generated per cluster
+		unstructured.RemoveNestedField(obj.Object, "spec", "selector")
+		for _, path := range [][]string{{"spec", "template", "metadata", "labels"}, {"metadata", "labels"}} {
+			labels, found, _ := unstructured.NestedStringMap(obj.Object, path...)
+			if !found {
+				continue
+			}
+			for key := range labels {
+				if key == "controller-uid" || key == "batch.kubernetes.io/controller-uid" {
+					delete(labels, key)
+				}
+			}
+			_ = unstructured.SetNestedStringMap(obj.Object, labels, path...)
+		}
+	}
+	return obj
+}
+
+// volumeClaims lists the persistent volume claims an object is or mounts
+func volumeClaims(obj *unstructured.Unstructured) []string {
+	if obj.GetKind() == "PersistentVolumeClaim" {
+		return []string{obj.GetName()}
+	}
+
+	var claims []string
+	for _, path := range [][]string{{"spec", "volumes"}, {"spec", "template", "spec", "volumes"}} {
+		volumes, _, _ := unstructured.NestedSlice(obj.Object, path...)
+		for _, v := range volumes {
+			volume, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if claim, found, _ := unstructured.NestedString(volume, "persistentVolumeClaim", "claimName"); found {
+				claims = append(claims, claim)
+			}
+		}
+	}
+	templates, _, _ := unstructured.NestedSlice(obj.Object, "spec", "volumeClaimTemplates")
+	for _, t := range templates {
+		if template, ok := t.(map[string]interface{}); ok {
+			if name, found, _ := unstructured.NestedString(template, "metadata", "name"); found {
+				claims = append(claims, name+" (template)")
+			}
+		}
+	}
+	return claims
+}
+
+// waitForReady polls the object until workloadReady reports it ready
+func waitForReady(ctx context.Context, client dynamic.ResourceInterface, name string, timeout time.Duration) error {
+	var reason string
+	err := wait.PollUntilContextTimeout(ctx, 2*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		obj, err := client.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			reason = err.Error()
+			return false, nil
+		}
+		var ready bool
+		ready, reason = workloadReady(obj)
+		return ready, nil
+	})
+	if err != nil && reason != "" {
+		return fmt.Errorf("%s", reason)
+	}
+	return err
+}
+
+// workloadReady reports whether a workload has converged, with the reason
+// when it has not. Kinds without a readiness notion are ready once they exist.
+func workloadReady(obj *unstructured.Unstructured) (bool, string) {
+	status := func(field string) int64 {
+		v, _, _ := unstructured.NestedInt64(obj.Object, "status", field)
+		return v
+	}
+	if observed, found, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration"); found && observed < obj.GetGeneration() {
+		return false, "waiting for the controller to observe the latest generation"
+	}
+
+	switch obj.GetKind() {
+	case "Deployment", "StatefulSet", "ReplicaSet":
+		replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+		if !found {
+			replicas = 1
+		}
+		ready := status("readyReplicas")
+		if obj.GetKind() == "Deployment" {
+			ready = status("availableReplicas")
+			if updated := status("updatedReplicas"); updated < replicas {
+				return false, fmt.Sprintf("%d of %d replicas updated", updated, replicas)
+			}
+		}
+		if ready < replicas {
+			return false, fmt.Sprintf("%d of %d replicas ready", ready, replicas)
+		}
+	case "DaemonSet":
+		desired := status("desiredNumberScheduled")
+		if ready := status("numberReady"); ready < desired {
+			return false, fmt.Sprintf("%d of %d pods ready", ready, desired)
+		}
+	case "Pod":
+		conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+		for _, c := range conditions {
+			cond, ok := c.(map[string]interface{})
+			if ok && cond["type"] == "Ready" && cond["status"] == "True" {
+				return true, ""
+			}
+		}
+		return false, "pod is not ready"
+	}
+	return true, ""
+}
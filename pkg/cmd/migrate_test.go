@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestWorkloadReady(t *testing.T) {
+	tests := []struct {
+		name       string
+		obj        map[string]interface{}
+		wantReady  bool
+		wantReason string
+	}{
+		{
+			name: "generation not observed",
+			obj: map[string]interface{}{
+				"kind":     "Deployment",
+				"metadata": map[string]interface{}{"generation": int64(3)},
+				"status":   map[string]interface{}{"observedGeneration": int64(2)},
+			},
+			wantReason: "waiting for the controller to observe the latest generation",
+		},
+		{
+			name: "deployment rollout in progress",
+			obj: map[string]interface{}{
+				"kind":   "Deployment",
+				"spec":   map[string]interface{}{"replicas": int64(3)},
+				"status": map[string]interface{}{"updatedReplicas": int64(1), "availableReplicas": int64(3)},
+			},
+			wantReason: "1 of 3 replicas updated",
+		},
+		{
+			name: "deployment not available",
+			obj: map[string]interface{}{
+				"kind":   "Deployment",
+				"spec":   map[string]interface{}{"replicas": int64(3)},
+				"status": map[string]interface{}{"updatedReplicas": int64(3), "readyReplicas": int64(3), "availableReplicas": int64(2)},
+			},
+			wantReason: "2 of 3 replicas ready",
+		},
+		{
+			name: "deployment ready",
+			obj: map[string]interface{}{
+				"kind":     "Deployment",
+				"metadata": map[string]interface{}{"generation": int64(2)},
+				"spec":     map[string]interface{}{"replicas": int64(2)},
+				"status":   map[string]interface{}{"observedGeneration": int64(2), "updatedReplicas": int64(2), "availableReplicas": int64(2)},
+			},
+			wantReady: true,
+		},
+		{
+			name:       "replicas default to one",
+			obj:        map[string]interface{}{"kind": "StatefulSet"},
+			wantReason: "0 of 1 replicas ready",
+		},
+		{
+			name: "statefulset scaled to zero",
+			obj: map[string]interface{}{
+				"kind": "StatefulSet",
+				"spec": map[string]interface{}{"replicas": int64(0)},
+			},
+			wantReady: true,
+		},
+		{
+			name: "replicaset ready",
+			obj: map[string]interface{}{
+				"kind":   "ReplicaSet",
+				"spec":   map[string]interface{}{"replicas": int64(2)},
+				"status": map[string]interface{}{"readyReplicas": int64(2)},
+			},
+			wantReady: true,
+		},
+		{
+			name: "daemonset not ready",
+			obj: map[string]interface{}{
+				"kind":   "DaemonSet",
+				"status": map[string]interface{}{"desiredNumberScheduled": int64(4), "numberReady": int64(3)},
+			},
+			wantReason: "3 of 4 pods ready",
+		},
+		{
+			name: "pod ready",
+			obj: map[string]interface{}{
+				"kind": "Pod",
+				"status": map[string]interface{}{"conditions": []interface{}{
+					map[string]interface{}{"type": "Initialized", "status": "True"},
+					map[string]interface{}{"type": "Ready", "status": "True"},
+				}},
+			},
+			wantReady: true,
+		},
+		{
+			name: "pod not ready",
+			obj: map[string]interface{}{
+				"kind": "Pod",
+				"status": map[string]interface{}{"conditions": []interface{}{
+					map[string]interface{}{"type": "Ready", "status": "False"},
+				}},
+			},
+			wantReason: "pod is not ready",
+		},
+		{
+			name:      "kind without readiness",
+			obj:       map[string]interface{}{"kind": "ConfigMap"},
+			wantReady: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ready, reason := workloadReady(&unstructured.Unstructured{Object: tt.obj})
+			if ready != tt.wantReady || reason != tt.wantReason {
+				t.Errorf("workloadReady() = %v, %q, want %v, %q", ready, reason, tt.wantReady, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestVolumeClaims(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  map[string]interface{}
+		want []string
+	}{
+		{
+			name: "claim itself",
+			obj:  map[string]interface{}{"kind": "PersistentVolumeClaim", "metadata": map[string]interface{}{"name": "data"}},
+			want: []string{"data"},
+		},
+		{
+			name: "pod volumes",
+			obj: map[string]interface{}{
+				"kind": "Pod",
+				"spec": map[string]interface{}{"volumes": []interface{}{
+					map[string]interface{}{"name": "cfg", "configMap": map[string]interface{}{"name": "cfg"}},
+					map[string]interface{}{"name": "data", "persistentVolumeClaim": map[string]interface{}{"claimName": "data"}},
+				}},
+			},
+			want: []string{"data"},
+		},
+		{
+			name: "template volumes and claim templates",
+			obj: map[string]interface{}{
+				"kind": "StatefulSet",
+				"spec": map[string]interface{}{
+					"template": map[string]interface{}{"spec": map[string]interface{}{"volumes": []interface{}{
+						map[string]interface{}{"name": "shared", "persistentVolumeClaim": map[string]interface{}{"claimName": "shared"}},
+					}}},
+					"volumeClaimTemplates": []interface{}{
+						map[string]interface{}{"metadata": map[string]interface{}{"name": "www"}},
+					},
+				},
+			},
+			want: []string{"shared", "www (template)"},
+		},
+		{
+			name: "no claims",
+			obj:  map[string]interface{}{"kind": "Deployment"},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := volumeClaims(&unstructured.Unstructured{Object: tt.obj}); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("volumeClaims() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -17,16 +17,17 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 
 	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/platform"
 	"kubectl-multi/pkg/util"
 )
 
 // ClusterInfo for multiget (may include extra fields for ITS)
 type MultiGetClusterInfo struct {
-	Name           string
-	KubeconfigPath string
-	Client         *kubernetes.Clientset
-	DynamicClient  dynamic.Interface
-	RestConfig     *rest.Config
+	Name          string
+	Kubeconfig    *platform.Kubeconfig
+	Client        kubernetes.Interface
+	DynamicClient dynamic.Interface
+	RestConfig    *rest.Config
 }
 
 func toClusterInfo(m MultiGetClusterInfo) cluster.ClusterInfo {
@@ -43,6 +44,7 @@ func newMultiGetCommand() *cobra.Command {
 	var outputFormat string
 	var selector string
 	var showLabels bool
+	var showAnnotations string
 	var watch bool
 	var watchOnly bool
 
@@ -54,6 +56,14 @@ func newMultiGetCommand() *cobra.Command {
 			if len(args) == 0 {
 				return fmt.Errorf("resource type must be specified")
 			}
+			selector, err := parseSelector(selector)
+			if err != nil {
+				return err
+			}
+			annotations, err := parseShowAnnotations(showAnnotations)
+			if err != nil {
+				return err
+			}
 
 			kubeconfig, _, _, namespace, allNamespaces := GetGlobalFlags()
 			// Auto-discover the KubeFlex hosting cluster
@@ -65,13 +75,16 @@ func newMultiGetCommand() *cobra.Command {
 			if err != nil {
 				return fmt.Errorf("failed to discover ITS clusters: %v", err)
 			}
-			return handleMultiGetCommand(args, outputFormat, selector, showLabels, watch, watchOnly, clusters, namespace, allNamespaces)
+			q := tableQuery{Selector: selector, ShowLabels: showLabels, Annotations: annotations, Namespace: namespace, AllNamespaces: allNamespaces}
+			return handleMultiGetCommand(args, outputFormat, q, watch, watchOnly, clusters)
 		},
 	}
 
 	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "output format (json|yaml|wide|name|custom-columns=...|custom-columns-file=...|go-template=...|go-template-file=...|jsonpath=...|jsonpath-file=...)")
-	cmd.Flags().StringVarP(&selector, "selector", "l", "", "selector (label query) to filter on")
+	cmd.Flags().StringVarP(&selector, "selector", "l", "", "selector (label query) to filter on, supports '=', '==', '!=', 'in', 'notin', 'key' and '!key'")
 	cmd.Flags().BoolVar(&showLabels, "show-labels", false, "show all labels as the last column")
+	cmd.Flags().StringVar(&showAnnotations, "show-annotations", "", "show annotations before the labels: all in one column, or =KEY[,KEY...] for a column per key")
+	cmd.Flags().Lookup("show-annotations").NoOptDefVal = allAnnotations
 	cmd.Flags().BoolVarP(&watch, "watch", "w", false, "watch for changes to the requested object(s)")
 	cmd.Flags().BoolVar(&watchOnly, "watch-only", false, "watch for changes to the requested object(s), without listing/getting first")
 
@@ -106,12 +119,7 @@ func discoverITSClustersFromCore(coreKubeconfig, coreContext string) ([]MultiGet
 	}
 
 	// List ControlPlane CRDs
-	gvr := schema.GroupVersionResource{
-		Group:    "tenancy.kflex.kubestellar.org",
-		Version:  "v1alpha1",
-		Resource: "controlplanes",
-	}
-	cps, err := dyn.Resource(gvr).List(context.TODO(), metav1.ListOptions{})
+	cps, err := dyn.Resource(controlPlaneGVR).List(commandContext(), metav1.ListOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list ControlPlane CRDs: %v", err)
 	}
@@ -131,7 +139,7 @@ func discoverITSClustersFromCore(coreKubeconfig, coreContext string) ([]MultiGet
 			continue
 		}
 		// Fetch kubeconfig from secret
-		secret, err := coreClient.CoreV1().Secrets(secretNamespace).Get(context.TODO(), secretName, metav1.GetOptions{})
+		secret, err := coreClient.CoreV1().Secrets(secretNamespace).Get(commandContext(), secretName, metav1.GetOptions{})
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: failed to get secret %s/%s: %v\n", secretNamespace, secretName, err)
 			continue
@@ -141,21 +149,9 @@ func discoverITSClustersFromCore(coreKubeconfig, coreContext string) ([]MultiGet
 			fmt.Fprintf(os.Stderr, "Warning: secret %s/%s missing key %s\n", secretNamespace, secretName, key)
 			continue
 		}
-		// Write kubeconfig to temp file
-		tmpFile, err := os.CreateTemp("", fmt.Sprintf("%s-kubeconfig-*.yaml", name))
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to create temp kubeconfig for %s: %v\n", name, err)
-			continue
-		}
-		if _, err := tmpFile.Write(kubeconfigBytes); err != nil {
-			tmpFile.Close()
-			fmt.Fprintf(os.Stderr, "Warning: failed to write kubeconfig for %s: %v\n", name, err)
-			continue
-		}
-		tmpFile.Close()
-
-		// Build client for ITS vcluster
-		itsCfg, err := clientcmd.BuildConfigFromFlags("", tmpFile.Name())
+		// Build client for ITS vcluster from the secret, without a temp file
+		itsKubeconfig := platform.NewKubeconfig(name, kubeconfigBytes)
+		itsCfg, err := itsKubeconfig.RESTConfig()
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: failed to build rest config for ITS %s: %v\n", name, err)
 			continue
@@ -173,11 +169,11 @@ func discoverITSClustersFromCore(coreKubeconfig, coreContext string) ([]MultiGet
 
 		// Add the ITS cluster itself to the results
 		clusters = append(clusters, MultiGetClusterInfo{
-			Name:           name,
-			KubeconfigPath: tmpFile.Name(),
-			Client:         itsClient,
-			DynamicClient:  itsDyn,
-			RestConfig:     itsCfg,
+			Name:          name,
+			Kubeconfig:    itsKubeconfig,
+			Client:        itsClient,
+			DynamicClient: itsDyn,
+			RestConfig:    itsCfg,
 		})
 
 		// Discover ManagedClusters from the ITS vcluster
@@ -186,7 +182,7 @@ func discoverITSClustersFromCore(coreKubeconfig, coreContext string) ([]MultiGet
 			Version:  "v1",
 			Resource: "managedclusters",
 		}
-		mcs, err := itsDyn.Resource(mcGVR).List(context.TODO(), metav1.ListOptions{})
+		mcs, err := itsDyn.Resource(mcGVR).List(commandContext(), metav1.ListOptions{})
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: failed to list managed clusters from ITS %s: %v\n", name, err)
 			continue
@@ -237,18 +233,9 @@ users:
     token: ""  # We'll use in-cluster config or need to get token from somewhere
 `, caBundle, url, mcName, mcName, mcName, mcName, mcName, mcName)
 
-			// Write managed cluster kubeconfig to temp file
-			mcTmpFile, err := os.CreateTemp("", fmt.Sprintf("%s-kubeconfig-*.yaml", mcName))
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: failed to create temp kubeconfig for managed cluster %s: %v\n", mcName, err)
-				continue
-			}
-			if _, err := mcTmpFile.Write([]byte(kubeconfig)); err != nil {
-				mcTmpFile.Close()
-				fmt.Fprintf(os.Stderr, "Warning: failed to write kubeconfig for managed cluster %s: %v\n", mcName, err)
-				continue
-			}
-			mcTmpFile.Close()
+			// Keep the managed cluster kubeconfig in memory; a file is only
+			// written if a child process asks for its path
+			mcKubeconfig := platform.NewKubeconfig(mcName, []byte(kubeconfig))
 
 			// Use the existing context-based approach since we have the contexts
 			loading := clientcmd.NewDefaultClientConfigLoadingRules()
@@ -274,11 +261,11 @@ users:
 			}
 
 			clusters = append(clusters, MultiGetClusterInfo{
-				Name:           mcName,
-				KubeconfigPath: mcTmpFile.Name(),
-				Client:         mcClient,
-				DynamicClient:  mcDyn,
-				RestConfig:     mcCfg,
+				Name:          mcName,
+				Kubeconfig:    mcKubeconfig,
+				Client:        mcClient,
+				DynamicClient: mcDyn,
+				RestConfig:    mcCfg,
 			})
 		}
 	}
@@ -338,21 +325,15 @@ func hasKubeFlexResources(kubeconfig, contextName string) bool {
 		return false
 	}
 
-	gvr := schema.GroupVersionResource{
-		Group:    "tenancy.kflex.kubestellar.org",
-		Version:  "v1alpha1",
-		Resource: "controlplanes",
-	}
-	_, err = dyn.Resource(gvr).List(context.Background(), metav1.ListOptions{})
+	_, err = dyn.Resource(controlPlaneGVR).List(context.Background(), metav1.ListOptions{})
 	return err == nil
 }
 
 // handleMultiGetCommand runs the get logic across all discovered ITS clusters
-func handleMultiGetCommand(args []string, outputFormat, selector string, showLabels, watch, watchOnly bool, clusters []MultiGetClusterInfo, namespace string, allNamespaces bool) error {
+func handleMultiGetCommand(args []string, outputFormat string, q tableQuery, watch, watchOnly bool, clusters []MultiGetClusterInfo) error {
 	resourceType := args[0]
-	resourceName := ""
 	if len(args) > 1 {
-		resourceName = args[1]
+		q.Name = args[1]
 	}
 
 	if watch || watchOnly {
@@ -362,116 +343,34 @@ func handleMultiGetCommand(args []string, outputFormat, selector string, showLab
 	tw := tabwriter.NewWriter(util.GetOutputStream(), 0, 0, 2, ' ', 0)
 	defer tw.Flush()
 
+	var infos []cluster.ClusterInfo
+	for _, c := range clusters {
+		infos = append(infos, toClusterInfo(c))
+	}
+	var err error
 	switch strings.ToLower(resourceType) {
 	case "nodes", "node", "no":
-		return handleNodesGetMulti(tw, clusters, resourceName, selector, showLabels, outputFormat)
+		_, err = printResourceTable(tw, infos, nodeTable(q.Options.Capacity), q)
 	case "pods", "pod", "po":
-		return handlePodsGetMulti(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
+		_, err = printResourceTable(tw, infos, podTable, q)
 	case "services", "service", "svc":
-		return handleServicesGetMulti(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
+		_, err = printResourceTable(tw, infos, serviceTable, q)
 	case "deployments", "deployment", "deploy":
-		return handleDeploymentsGetMulti(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
+		_, err = printResourceTable(tw, infos, deploymentTable, q)
 	case "namespaces", "namespace", "ns":
-		return handleNamespacesGetMulti(tw, clusters, resourceName, selector, showLabels, outputFormat)
+		_, err = printResourceTable(tw, infos, namespaceTable, q)
 	case "configmaps", "configmap", "cm":
-		return handleConfigMapsGetMulti(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
+		_, err = printResourceTable(tw, infos, configMapTable, q)
 	case "secrets", "secret":
-		return handleSecretsGetMulti(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
+		_, err = printResourceTable(tw, infos, secretTable, q)
 	case "serviceaccounts", "serviceaccount", "sa":
-		return handleServiceAccountsGetMulti(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
+		_, err = printResourceTable(tw, infos, serviceAccountTable, q)
 	case "persistentvolumes", "persistentvolume", "pv":
-		return handlePVGetMulti(tw, clusters, resourceName, selector, showLabels, outputFormat)
+		_, err = printResourceTable(tw, infos, pvTable, q)
 	case "persistentvolumeclaims", "persistentvolumeclaim", "pvc":
-		return handlePVCGetMulti(tw, clusters, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
+		_, err = printResourceTable(tw, infos, pvcTable, q)
 	default:
-		return handleGenericGetMulti(tw, clusters, resourceType, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
-	}
-}
-
-func handleNodesGetMulti(tw *tabwriter.Writer, clusters []MultiGetClusterInfo, resourceName, selector string, showLabels bool, outputFormat string) error {
-	var infos []cluster.ClusterInfo
-	for _, c := range clusters {
-		infos = append(infos, toClusterInfo(c))
-	}
-	return handleNodesGet(tw, infos, resourceName, selector, showLabels, outputFormat)
-}
-
-func handlePodsGetMulti(tw *tabwriter.Writer, clusters []MultiGetClusterInfo, resourceName, selector string, showLabels bool, outputFormat, namespace string, allNamespaces bool) error {
-	var infos []cluster.ClusterInfo
-	for _, c := range clusters {
-		infos = append(infos, toClusterInfo(c))
-	}
-	return handlePodsGet(tw, infos, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
-}
-
-func handleServicesGetMulti(tw *tabwriter.Writer, clusters []MultiGetClusterInfo, resourceName, selector string, showLabels bool, outputFormat, namespace string, allNamespaces bool) error {
-	var infos []cluster.ClusterInfo
-	for _, c := range clusters {
-		infos = append(infos, toClusterInfo(c))
-	}
-	return handleServicesGet(tw, infos, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
-}
-
-func handleDeploymentsGetMulti(tw *tabwriter.Writer, clusters []MultiGetClusterInfo, resourceName, selector string, showLabels bool, outputFormat, namespace string, allNamespaces bool) error {
-	var infos []cluster.ClusterInfo
-	for _, c := range clusters {
-		infos = append(infos, toClusterInfo(c))
-	}
-	return handleDeploymentsGet(tw, infos, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
-}
-
-func handleNamespacesGetMulti(tw *tabwriter.Writer, clusters []MultiGetClusterInfo, resourceName, selector string, showLabels bool, outputFormat string) error {
-	var infos []cluster.ClusterInfo
-	for _, c := range clusters {
-		infos = append(infos, toClusterInfo(c))
-	}
-	return handleNamespacesGet(tw, infos, resourceName, selector, showLabels, outputFormat)
-}
-
-func handleConfigMapsGetMulti(tw *tabwriter.Writer, clusters []MultiGetClusterInfo, resourceName, selector string, showLabels bool, outputFormat, namespace string, allNamespaces bool) error {
-	var infos []cluster.ClusterInfo
-	for _, c := range clusters {
-		infos = append(infos, toClusterInfo(c))
-	}
-	return handleConfigMapsGet(tw, infos, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
-}
-
-func handleSecretsGetMulti(tw *tabwriter.Writer, clusters []MultiGetClusterInfo, resourceName, selector string, showLabels bool, outputFormat, namespace string, allNamespaces bool) error {
-	var infos []cluster.ClusterInfo
-	for _, c := range clusters {
-		infos = append(infos, toClusterInfo(c))
-	}
-	return handleSecretsGet(tw, infos, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
-}
-
-func handleServiceAccountsGetMulti(tw *tabwriter.Writer, clusters []MultiGetClusterInfo, resourceName, selector string, showLabels bool, outputFormat, namespace string, allNamespaces bool) error {
-	var infos []cluster.ClusterInfo
-	for _, c := range clusters {
-		infos = append(infos, toClusterInfo(c))
-	}
-	return handleServiceAccountsGet(tw, infos, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
-}
-
-func handlePVGetMulti(tw *tabwriter.Writer, clusters []MultiGetClusterInfo, resourceName, selector string, showLabels bool, outputFormat string) error {
-	var infos []cluster.ClusterInfo
-	for _, c := range clusters {
-		infos = append(infos, toClusterInfo(c))
-	}
-	return handlePVGet(tw, infos, resourceName, selector, showLabels, outputFormat)
-}
-
-func handlePVCGetMulti(tw *tabwriter.Writer, clusters []MultiGetClusterInfo, resourceName, selector string, showLabels bool, outputFormat, namespace string, allNamespaces bool) error {
-	var infos []cluster.ClusterInfo
-	for _, c := range clusters {
-		infos = append(infos, toClusterInfo(c))
-	}
-	return handlePVCGet(tw, infos, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
-}
-
-func handleGenericGetMulti(tw *tabwriter.Writer, clusters []MultiGetClusterInfo, resourceType, resourceName, selector string, showLabels bool, outputFormat, namespace string, allNamespaces bool) error {
-	var infos []cluster.ClusterInfo
-	for _, c := range clusters {
-		infos = append(infos, toClusterInfo(c))
+		_, err = handleGenericGet(tw, infos, resourceType, q, outputFormat)
 	}
-	return handleGenericGet(tw, infos, resourceType, resourceName, selector, showLabels, outputFormat, namespace, allNamespaces)
+	return err
 }
@@ -0,0 +1,449 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+// namespaceNameLabel is maintained by the API server and must never be touched by label sync
+const namespaceNameLabel = "kubernetes.io/metadata.name"
+
+func newNamespaceCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "namespace",
+		Aliases: []string{"ns"},
+		Short:   "Manage namespaces consistently across managed clusters",
+		Long: `Create and delete namespaces across KubeStellar managed clusters.
+
+Creation is idempotent, and deletion refuses to remove namespaces that still
+hold resources unless --force is given.`,
+	}
+	cmd.AddCommand(newNamespaceCreateCommand())
+	cmd.AddCommand(newNamespaceDeleteCommand())
+	return cmd
+}
+
+func newNamespaceCreateCommand() *cobra.Command {
+	var targets clusterTargets
+	var labelPairs []string
+	var syncLabels bool
+
+	cmd := &cobra.Command{
+		Use:   "create NAME",
+		Short: "Create a namespace in all selected managed clusters",
+		Example: `# Create a namespace in every managed cluster
+kubectl multi namespace create prod
+
+# Create a labeled namespace in two clusters only
+kubectl multi namespace create prod --clusters cluster1,cluster2 --label team=web
+
+# Make the namespace labels identical in every cluster
+kubectl multi namespace create prod --label team=web --sync-labels`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			labels, err := parseLabelPairs(labelPairs)
+			if err != nil {
+				return err
+			}
+			kubeconfig, remoteCtx, _, _, _ := GetGlobalFlags()
+			rec := startAudit("namespace create")
+			err = handleNamespaceCreate(args[0], targets, labels, syncLabels, rec, kubeconfig, remoteCtx)
+			finishAudit(rec, err)
+			return err
+		},
+	}
+
+	targets.addFlags(cmd, "target")
+	cmd.Flags().StringArrayVar(&labelPairs, "label", nil, "label to set on the namespace as key=value (can be repeated)")
+	cmd.Flags().BoolVar(&syncLabels, "sync-labels", false, "reconcile namespace labels so they are identical in every selected cluster")
+
+	return cmd
+}
+
+func newNamespaceDeleteCommand() *cobra.Command {
+	var targets clusterTargets
+	var force bool
+	var skipUnreachable bool
+
+	cmd := &cobra.Command{
+		Use:   "delete NAME",
+		Short: "Delete a namespace from all selected managed clusters",
+		Example: `# Delete an empty namespace from every managed cluster
+kubectl multi namespace delete staging
+
+# Delete a namespace even though it still contains resources
+kubectl multi namespace delete staging --force
+
+# Delete it from the clusters that answer while one cluster is down
+kubectl multi namespace delete staging --skip-unreachable`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kubeconfig, remoteCtx, _, _, _ := GetGlobalFlags()
+			rec := startAudit("namespace delete")
+			err := handleNamespaceDelete(args[0], targets, force, skipUnreachable, rec, kubeconfig, remoteCtx)
+			finishAudit(rec, err)
+			return err
+		},
+	}
+
+	targets.addFlags(cmd, "target")
+	cmd.Flags().BoolVar(&force, "force", false, "delete the namespace even if it still contains resources")
+	cmd.Flags().BoolVar(&skipUnreachable, "skip-unreachable", false, "delete the namespace from the clusters where it can be read, leaving out those where it cannot")
+
+	return cmd
+}
+
+// namespaceTargets discovers the clusters a namespace operation should touch,
+// leaving out the ITS (control) cluster.
+func namespaceTargets(targets clusterTargets, kubeconfig, remoteCtx string) ([]cluster.ClusterInfo, error) {
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	clusters, err = targets.selectFrom(clusters, kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []cluster.ClusterInfo
+	for _, c := range clusters {
+		if c.Client == nil {
+			continue
+		}
+		if c.Context == remoteCtx {
+			fmt.Printf("Skipping ITS (control) cluster: %s\n", c.Context)
+			continue
+		}
+		selected = append(selected, c)
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no clusters discovered")
+	}
+	return selected, nil
+}
+
+func handleNamespaceCreate(name string, targets clusterTargets, labels map[string]string, syncLabels bool, rec *audit.Recorder, kubeconfig, remoteCtx string) error {
+	clusters, err := namespaceTargets(targets, kubeconfig, remoteCtx)
+	if err != nil {
+		return err
+	}
+
+	desired := labels
+	if syncLabels && len(desired) == 0 {
+		// Without explicit labels the first cluster that already has the
+		// namespace acts as the reference for every other cluster.
+		desired = referenceNamespaceLabels(clusters, name)
+	}
+
+	if err := guardFanOut(rec, blastRadius{Namespaces: []string{name}, Objects: len(clusters)}, clusters, remoteCtx); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(util.GetOutputStream(), 0, 0, 2, ' ', 0)
+	defer tw.Flush()
+	fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tRESULT\n")
+
+	trackFanOut(rec, len(clusters))
+	failed := 0
+	for _, clusterInfo := range clusters {
+		rec.Begin(clusterInfo.Name)
+		result, err := ensureNamespace(clusterInfo, name, desired, syncLabels)
+		rec.Record(clusterInfo.Name, err)
+		if result == "created" {
+			rec.AddUndo(audit.UndoStep{Cluster: clusterInfo.Name, Action: audit.UndoDelete, Version: "v1", Resource: "namespaces", Name: name})
+		}
+		if err != nil {
+			result = fmt.Sprintf("error: %v", err)
+			failed++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", clusterInfo.Name, name, result)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to create namespace %s in %d cluster(s)", name, failed)
+	}
+	return nil
+}
+
+// ensureNamespace creates the namespace if it is missing and, when syncLabels
+// is set, rewrites its labels to exactly the desired set.
+func ensureNamespace(clusterInfo cluster.ClusterInfo, name string, desired map[string]string, syncLabels bool) (string, error) {
+	nsClient := clusterInfo.Client.CoreV1().Namespaces()
+
+	existing, err := nsClient.Get(commandContext(), name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: desired}}
+		util.MarkManaged(ns)
+		if _, err := nsClient.Create(commandContext(), ns, metav1.CreateOptions{FieldManager: util.FieldManager}); err != nil {
+			if apierrors.IsAlreadyExists(err) {
+				return "unchanged", nil
+			}
+			return "", err
+		}
+		return "created", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if !syncLabels {
+		return "unchanged", nil
+	}
+
+	synced := make(map[string]string, len(desired)+1)
+	for k, v := range desired {
+		synced[k] = v
+	}
+	if v, ok := existing.Labels[namespaceNameLabel]; ok {
+		synced[namespaceNameLabel] = v
+	}
+	if labelsEqual(existing.Labels, synced) {
+		return "unchanged", nil
+	}
+
+	existing.Labels = synced
+	util.MarkManaged(existing)
+	if _, err := nsClient.Update(commandContext(), existing, metav1.UpdateOptions{FieldManager: util.FieldManager}); err != nil {
+		return "", err
+	}
+	return "labels synced", nil
+}
+
+// referenceNamespaceLabels returns the labels of the namespace in the first
+// cluster where it exists, excluding labels managed by the API server.
+func referenceNamespaceLabels(clusters []cluster.ClusterInfo, name string) map[string]string {
+	for _, clusterInfo := range clusters {
+		ns, err := clusterInfo.Client.CoreV1().Namespaces().Get(commandContext(), name, metav1.GetOptions{})
+		if err != nil {
+			continue
+		}
+		labels := make(map[string]string)
+		for k, v := range ns.Labels {
+			if k != namespaceNameLabel {
+				labels[k] = v
+			}
+		}
+		fmt.Printf("Using labels of namespace %s in cluster %s as reference\n", name, clusterInfo.Name)
+		return labels
+	}
+	return map[string]string{}
+}
+
+// namespaceDeleteCheck is what the first pass of namespace delete found in
+// the selected clusters
+type namespaceDeleteCheck struct {
+	// Present are the clusters that have the namespace
+	Present []cluster.ClusterInfo
+	// NonEmpty and Unknown count the present namespaces that still hold
+	// resources, or whose contents could not all be listed
+	NonEmpty, Unknown int
+	// Unchecked are the clusters where the namespace itself could not be read
+	Unchecked []string
+}
+
+// checkNamespaceForDelete reports, cluster by cluster, what still lives in
+// the namespace before it is deleted
+func checkNamespaceForDelete(clusters []cluster.ClusterInfo, name string) namespaceDeleteCheck {
+	var check namespaceDeleteCheck
+	for _, clusterInfo := range clusters {
+		_, err := clusterInfo.Client.CoreV1().Namespaces().Get(commandContext(), name, metav1.GetOptions{})
+		fmt.Printf("=== Cluster: %s ===\n", clusterInfo.Name)
+		if apierrors.IsNotFound(err) {
+			fmt.Printf("Namespace %s not found\n\n", name)
+			continue
+		}
+		if err != nil {
+			// The namespace may well exist there
+			check.Unchecked = append(check.Unchecked, clusterInfo.Name)
+			fmt.Printf("Namespace %s unknown: %v\n\n", name, err)
+			noteClusterIssue(clusterInfo.Name, fmt.Sprintf("failed to get namespace %s: %v", name, err))
+			continue
+		}
+		check.Present = append(check.Present, clusterInfo)
+
+		residual, err := listResidualResources(clusterInfo, name)
+		if err != nil {
+			// Contents that cannot be listed may not be empty
+			check.Unknown++
+			fmt.Printf("Contents of namespace %s unknown: %v\n", name, err)
+		}
+		if len(residual) == 0 {
+			if err == nil {
+				fmt.Printf("Namespace %s is empty\n", name)
+			}
+			fmt.Println()
+			continue
+		}
+		check.NonEmpty++
+		fmt.Printf("Namespace %s still contains:\n", name)
+		for _, line := range residual {
+			fmt.Printf("  %s\n", line)
+		}
+		fmt.Println()
+	}
+	return check
+}
+
+// refusal returns why the namespace must not be deleted, if it must not:
+// clusters where it could not be read need --skip-unreachable, and
+// contents that are or may be left need --force
+func (c namespaceDeleteCheck) refusal(name string, force, skipUnreachable bool) error {
+	switch {
+	case len(c.Unchecked) > 0 && !skipUnreachable:
+		return fmt.Errorf("could not check namespace %s in %d cluster(s) (%s); re-run with --skip-unreachable to delete it from the others", name, len(c.Unchecked), strings.Join(c.Unchecked, ", "))
+	case c.NonEmpty > 0 && !force:
+		return fmt.Errorf("namespace %s is not empty in %d cluster(s); re-run with --force to delete it anyway", name, c.NonEmpty)
+	case c.Unknown > 0 && !force:
+		return fmt.Errorf("could not check that namespace %s is empty in %d cluster(s); re-run with --force to delete it anyway", name, c.Unknown)
+	}
+	return nil
+}
+
+func handleNamespaceDelete(name string, targets clusterTargets, force, skipUnreachable bool, rec *audit.Recorder, kubeconfig, remoteCtx string) error {
+	clusters, err := namespaceTargets(targets, kubeconfig, remoteCtx)
+	if err != nil {
+		return err
+	}
+	defer printClusterIssuesToStderr()
+
+	// First pass: report what is still living in the namespace everywhere.
+	check := checkNamespaceForDelete(clusters, name)
+	if err := check.refusal(name, force, skipUnreachable); err != nil {
+		return err
+	}
+	present := check.Present
+
+	if err := guardFanOut(rec, blastRadius{Namespaces: []string{name}, Objects: len(present)}, present, remoteCtx); err != nil {
+		return err
+	}
+	trackFanOut(rec, len(present))
+	failed := 0
+	for _, clusterInfo := range present {
+		rec.Begin(clusterInfo.Name)
+		err := clusterInfo.Client.CoreV1().Namespaces().Delete(commandContext(), name, metav1.DeleteOptions{})
+		if apierrors.IsNotFound(err) {
+			err = nil
+		}
+		rec.Record(clusterInfo.Name, err)
+		if err != nil {
+			fmt.Printf("Error: failed to delete namespace %s in cluster %s: %v\n", name, clusterInfo.Name, err)
+			failed++
+			continue
+		}
+		fmt.Printf("namespace/%s deleted from cluster %s\n", name, clusterInfo.Name)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to delete namespace %s in %d cluster(s)", name, failed)
+	}
+	return nil
+}
+
+// listResidualResources counts the objects of every listable namespaced
+// resource type in the namespace, ignoring objects Kubernetes creates itself.
+// Any discovery or list failure is returned, since the namespace may then
+// hold resources that were not counted.
+func listResidualResources(clusterInfo cluster.ClusterInfo, namespace string) ([]string, error) {
+	if clusterInfo.DiscoveryClient == nil || clusterInfo.DynamicClient == nil {
+		return nil, fmt.Errorf("no discovery client available")
+	}
+
+	resourceLists, discoveryErr := clusterInfo.DiscoveryClient.ServerPreferredNamespacedResources()
+	if discoveryErr != nil && len(resourceLists) == 0 {
+		return nil, fmt.Errorf("failed to discover resource types: %v", discoveryErr)
+	}
+	resourceLists = discovery.FilteredBy(discovery.SupportsAllVerbs{Verbs: []string{"list", "delete"}}, resourceLists)
+
+	var residual, unlisted []string
+	var listErr error
+	for _, resourceList := range resourceLists {
+		gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			unlisted = append(unlisted, resourceList.GroupVersion)
+			listErr = err
+			continue
+		}
+		for _, apiResource := range resourceList.APIResources {
+			if apiResource.Name == "events" {
+				continue
+			}
+			gvr := gv.WithResource(apiResource.Name)
+			list, err := clusterInfo.DynamicClient.Resource(gvr).Namespace(namespace).List(commandContext(), metav1.ListOptions{})
+			if err != nil {
+				unlisted = append(unlisted, apiResource.Name)
+				listErr = err
+				continue
+			}
+			count := 0
+			for _, item := range list.Items {
+				if !isDefaultNamespaceObject(apiResource.Name, item.GetName()) {
+					count++
+				}
+			}
+			if count > 0 {
+				residual = append(residual, fmt.Sprintf("%d %s", count, apiResource.Name))
+			}
+		}
+	}
+	sort.Strings(residual)
+
+	if discoveryErr != nil {
+		return residual, fmt.Errorf("failed to discover resource types: %v", discoveryErr)
+	}
+	if len(unlisted) > 0 {
+		return residual, fmt.Errorf("failed to list %s: %v", strings.Join(unlisted, ", "), listErr)
+	}
+	return residual, nil
+}
+
+// isDefaultNamespaceObject reports objects that Kubernetes places into every namespace
+func isDefaultNamespaceObject(resource, name string) bool {
+	switch resource {
+	case "configmaps":
+		return name == "kube-root-ca.crt"
+	case "serviceaccounts":
+		return name == "default"
+	case "secrets":
+		return strings.HasPrefix(name, "default-token-")
+	}
+	return false
+}
+
+// parseLabelPairs converts key=value strings into a label map
+func parseLabelPairs(pairs []string) (map[string]string, error) {
+	labels := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid label %q, expected key=value", pair)
+		}
+		labels[parts[0]] = parts[1]
+	}
+	return labels, nil
+}
+
+// labelsEqual reports whether two label maps hold the same pairs
+func labelsEqual(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
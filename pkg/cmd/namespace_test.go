@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"kubectl-multi/pkg/cluster"
+)
+
+// namespacedDiscovery serves a fixed ServerPreferredNamespacedResources,
+// which the client-go fake leaves unimplemented
+type namespacedDiscovery struct {
+	*fakediscovery.FakeDiscovery
+	resources []*metav1.APIResourceList
+	err       error
+}
+
+func (d *namespacedDiscovery) ServerPreferredNamespacedResources() ([]*metav1.APIResourceList, error) {
+	return d.resources, d.err
+}
+
+func TestIsDefaultNamespaceObject(t *testing.T) {
+	tests := []struct {
+		resource, name string
+		want           bool
+	}{
+		{"configmaps", "kube-root-ca.crt", true},
+		{"configmaps", "app-config", false},
+		{"serviceaccounts", "default", true},
+		{"serviceaccounts", "builder", false},
+		{"secrets", "default-token-abcde", true},
+		{"secrets", "db-password", false},
+		{"pods", "default", false},
+	}
+	for _, tt := range tests {
+		if got := isDefaultNamespaceObject(tt.resource, tt.name); got != tt.want {
+			t.Errorf("isDefaultNamespaceObject(%q, %q) = %v, want %v", tt.resource, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseLabelPairs(t *testing.T) {
+	tests := []struct {
+		name    string
+		pairs   []string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "empty", pairs: nil, want: map[string]string{}},
+		{name: "pairs", pairs: []string{"team=web", "env=prod"}, want: map[string]string{"team": "web", "env": "prod"}},
+		{name: "empty value", pairs: []string{"team="}, want: map[string]string{"team": ""}},
+		{name: "value with equals", pairs: []string{"expr=a=b"}, want: map[string]string{"expr": "a=b"}},
+		{name: "missing equals", pairs: []string{"team"}, wantErr: true},
+		{name: "missing key", pairs: []string{"=web"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLabelPairs(tt.pairs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseLabelPairs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseLabelPairs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLabelsEqual(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b map[string]string
+		want bool
+	}{
+		{name: "both empty", a: nil, b: map[string]string{}, want: true},
+		{name: "same", a: map[string]string{"a": "1"}, b: map[string]string{"a": "1"}, want: true},
+		{name: "different value", a: map[string]string{"a": "1"}, b: map[string]string{"a": "2"}, want: false},
+		{name: "different key", a: map[string]string{"a": "1"}, b: map[string]string{"b": "1"}, want: false},
+		{name: "different size", a: map[string]string{"a": "1"}, b: map[string]string{"a": "1", "b": "2"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := labelsEqual(tt.a, tt.b); got != tt.want {
+				t.Errorf("labelsEqual() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListResidualResources(t *testing.T) {
+	resources := []*metav1.APIResourceList{{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{
+			{Name: "configmaps", Namespaced: true, Kind: "ConfigMap", Verbs: []string{"list", "delete"}},
+			{Name: "secrets", Namespaced: true, Kind: "Secret", Verbs: []string{"list", "delete"}},
+			{Name: "events", Namespaced: true, Kind: "Event", Verbs: []string{"list", "delete"}},
+		},
+	}}
+	listKinds := map[schema.GroupVersionResource]string{
+		{Version: "v1", Resource: "configmaps"}: "ConfigMapList",
+		{Version: "v1", Resource: "secrets"}:    "SecretList",
+		{Version: "v1", Resource: "events"}:     "EventList",
+	}
+	object := func(kind, name string) runtime.Object {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("v1")
+		obj.SetKind(kind)
+		obj.SetNamespace("demo")
+		obj.SetName(name)
+		return obj
+	}
+
+	tests := []struct {
+		name         string
+		objects      []runtime.Object
+		discoveryErr error
+		forbidden    string
+		want         []string
+		wantErr      bool
+	}{
+		{
+			name:    "only default objects",
+			objects: []runtime.Object{object("ConfigMap", "kube-root-ca.crt"), object("Event", "e1")},
+			want:    nil,
+		},
+		{
+			name:    "user objects",
+			objects: []runtime.Object{object("ConfigMap", "kube-root-ca.crt"), object("ConfigMap", "app"), object("Secret", "db")},
+			want:    []string{"1 configmaps", "1 secrets"},
+		},
+		{
+			name:      "forbidden list",
+			objects:   []runtime.Object{object("ConfigMap", "app")},
+			forbidden: "secrets",
+			want:      []string{"1 configmaps"},
+			wantErr:   true,
+		},
+		{
+			name:         "discovery failure",
+			discoveryErr: apierrors.NewServiceUnavailable("discovery down"),
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, tt.objects...)
+			if tt.forbidden != "" {
+				dynamicClient.PrependReactor("list", tt.forbidden, func(clienttesting.Action) (bool, runtime.Object, error) {
+					return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: tt.forbidden}, "", nil)
+				})
+			}
+			disc := &namespacedDiscovery{FakeDiscovery: &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}, resources: resources}
+			if tt.discoveryErr != nil {
+				disc.resources, disc.err = nil, tt.discoveryErr
+			}
+
+			got, err := listResidualResources(cluster.ClusterInfo{Name: "c1", DynamicClient: dynamicClient, DiscoveryClient: disc}, "demo")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("listResidualResources() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("listResidualResources() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckNamespaceForDelete(t *testing.T) {
+	resetClusterIssues()
+	defer resetClusterIssues()
+
+	emptyNamespaceCluster := func(name string, objects ...runtime.Object) cluster.ClusterInfo {
+		return cluster.ClusterInfo{
+			Name:            name,
+			Client:          kubefake.NewSimpleClientset(objects...),
+			DynamicClient:   fakedynamic.NewSimpleDynamicClient(runtime.NewScheme()),
+			DiscoveryClient: &namespacedDiscovery{FakeDiscovery: &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}},
+		}
+	}
+	unreadable := emptyNamespaceCluster("cluster2")
+	unreadable.Client.(*kubefake.Clientset).PrependReactor("get", "namespaces", func(clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "namespaces"}, "demo", nil)
+	})
+	clusters := []cluster.ClusterInfo{
+		emptyNamespaceCluster("cluster1", &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "demo"}}),
+		unreadable,
+		emptyNamespaceCluster("cluster3"),
+	}
+
+	check := checkNamespaceForDelete(clusters, "demo")
+	if len(check.Present) != 1 || check.Present[0].Name != "cluster1" {
+		t.Errorf("Present = %v, want cluster1 only", check.Present)
+	}
+	if !reflect.DeepEqual(check.Unchecked, []string{"cluster2"}) || check.NonEmpty != 0 || check.Unknown != 0 {
+		t.Errorf("check = %+v, want cluster2 unchecked", check)
+	}
+	if issues := takeClusterIssues(); len(issues) != 1 || issues[0].Cluster != "cluster2" {
+		t.Errorf("issues = %v, want one for cluster2", issues)
+	}
+
+	if err := check.refusal("demo", true, false); err == nil {
+		t.Error("refusal() with an unchecked cluster and no --skip-unreachable returned no error")
+	}
+	if err := check.refusal("demo", false, true); err != nil {
+		t.Errorf("refusal() with --skip-unreachable = %v", err)
+	}
+	if err := (namespaceDeleteCheck{NonEmpty: 1}).refusal("demo", false, true); err == nil {
+		t.Error("refusal() of a non-empty namespace without --force returned no error")
+	}
+}
@@ -0,0 +1,295 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+// Network postures of a pod, from the NetworkPolicies that select it
+const (
+	postureDefaultAllow    = "default-allow"
+	postureIngressIsolated = "ingress-isolated"
+	postureEgressIsolated  = "egress-isolated"
+	postureLockedDown      = "locked-down"
+	posturePodMissing      = "pod-missing"
+)
+
+// netpolAnalysis is the effect of the NetworkPolicies of one cluster on a pod
+type netpolAnalysis struct {
+	Cluster  string
+	Pod      string
+	Policies []string
+	Ingress  string
+	Egress   string
+	Posture  string
+}
+
+// netpolRule is an ingress or egress rule: the peers and ports it allows
+type netpolRule struct {
+	peers []networkingv1.NetworkPolicyPeer
+	ports []networkingv1.NetworkPolicyPort
+}
+
+// validateNetworkPolicyTarget checks that --analyze is used with
+// networkpolicies in one namespace and a table output
+func validateNetworkPolicyTarget(target, resourceType, resourceName, outputFormat string, allNamespaces bool) error {
+	if target == "" {
+		return nil
+	}
+	switch resourceType {
+	case "networkpolicies", "networkpolicy", "np":
+	default:
+		return fmt.Errorf("--analyze only applies to networkpolicies")
+	}
+	if resourceName != "" {
+		return fmt.Errorf("--analyze takes the pod instead of a networkpolicy name")
+	}
+	if allNamespaces {
+		return fmt.Errorf("--analyze works in one namespace; use -n instead of -A")
+	}
+	if isStructuredGetFormat(outputFormat) {
+		return fmt.Errorf("--analyze only applies to table output")
+	}
+	if strings.Contains(target, "=") {
+		if _, err := labels.ConvertSelectorToLabelsMap(target); err != nil {
+			return fmt.Errorf("invalid --analyze labels %q: %v", target, err)
+		}
+	}
+	return nil
+}
+
+// handleNetworkPolicyAnalysis prints which NetworkPolicies select the target
+// in every cluster and what they allow, and warns when the posture of the
+// target differs between clusters
+func handleNetworkPolicyAnalysis(tw util.TableWriter, clusters []cluster.ClusterInfo, target, namespace string) (int, error) {
+	ns := cluster.GetTargetNamespace(namespace)
+	var results []netpolAnalysis
+	for _, clusterInfo := range clusters {
+		if clusterInfo.Client == nil {
+			continue
+		}
+		podLabels, podName, found, err := networkPolicyTargetLabels(clusterInfo, ns, target)
+		if err != nil {
+			noteClusterIssue(clusterInfo.Name, fmt.Sprintf("failed to get pod %s: %v", target, err))
+			continue
+		}
+		if !found {
+			results = append(results, netpolAnalysis{Cluster: clusterInfo.Name, Pod: podName, Posture: posturePodMissing})
+			continue
+		}
+		policies, err := clusterInfo.Client.NetworkingV1().NetworkPolicies(ns).List(commandContext(), metav1.ListOptions{})
+		if err != nil {
+			noteClusterIssue(clusterInfo.Name, fmt.Sprintf("failed to list networkpolicies: %v", err))
+			continue
+		}
+		a := analyzeNetworkPolicies(podLabels, policies.Items)
+		a.Cluster, a.Pod = clusterInfo.Name, podName
+		results = append(results, a)
+	}
+	if len(results) == 0 {
+		return 0, nil
+	}
+
+	fmt.Fprintf(tw, "CLUSTER\tPOD\tPOLICIES\tINGRESS\tEGRESS\tPOSTURE\n")
+	for _, a := range results {
+		policies, ingress, egress := orNone(strings.Join(a.Policies, ",")), a.Ingress, a.Egress
+		if a.Posture == posturePodMissing {
+			ingress, egress = "-", "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", a.Cluster, a.Pod, policies, ingress, egress, a.Posture)
+	}
+	if warning := postureWarning(target, results); warning != "" {
+		fmt.Fprintln(os.Stderr, warning)
+	}
+	return len(results), nil
+}
+
+// networkPolicyTargetLabels returns the labels NetworkPolicies are matched
+// against: those of the named pod, or the KEY=VALUE labels given instead
+func networkPolicyTargetLabels(c cluster.ClusterInfo, namespace, target string) (map[string]string, string, bool, error) {
+	if strings.Contains(target, "=") {
+		set, _ := labels.ConvertSelectorToLabelsMap(target)
+		return set, "<" + target + ">", true, nil
+	}
+	pod, err := c.Client.CoreV1().Pods(namespace).Get(commandContext(), target, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, target, false, nil
+	}
+	if err != nil {
+		return nil, target, false, err
+	}
+	return pod.Labels, target, true, nil
+}
+
+// analyzeNetworkPolicies finds the policies whose podSelector matches the
+// pod labels and summarizes the traffic they allow. A direction is isolated
+// once any selecting policy covers it, and then allows only the union of the
+// rules of those policies.
+func analyzeNetworkPolicies(podLabels map[string]string, policies []networkingv1.NetworkPolicy) netpolAnalysis {
+	var a netpolAnalysis
+	var ingress, egress []netpolRule
+	ingressIsolated, egressIsolated := false, false
+	for _, np := range policies {
+		selector, err := metav1.LabelSelectorAsSelector(&np.Spec.PodSelector)
+		if err != nil || !selector.Matches(labels.Set(podLabels)) {
+			continue
+		}
+		a.Policies = append(a.Policies, np.Name)
+		coversIngress, coversEgress := policyTypes(np)
+		if coversIngress {
+			ingressIsolated = true
+			for _, rule := range np.Spec.Ingress {
+				ingress = append(ingress, netpolRule{peers: rule.From, ports: rule.Ports})
+			}
+		}
+		if coversEgress {
+			egressIsolated = true
+			for _, rule := range np.Spec.Egress {
+				egress = append(egress, netpolRule{peers: rule.To, ports: rule.Ports})
+			}
+		}
+	}
+	sort.Strings(a.Policies)
+
+	a.Ingress = summarizeRules(ingressIsolated, ingress)
+	a.Egress = summarizeRules(egressIsolated, egress)
+	switch {
+	case ingressIsolated && egressIsolated:
+		a.Posture = postureLockedDown
+	case ingressIsolated:
+		a.Posture = postureIngressIsolated
+	case egressIsolated:
+		a.Posture = postureEgressIsolated
+	default:
+		a.Posture = postureDefaultAllow
+	}
+	return a
+}
+
+// policyTypes reports the directions a policy isolates: its policyTypes, or
+// by default ingress, and egress when it has egress rules
+func policyTypes(np networkingv1.NetworkPolicy) (bool, bool) {
+	if len(np.Spec.PolicyTypes) == 0 {
+		return true, len(np.Spec.Egress) > 0
+	}
+	ingress, egress := false, false
+	for _, t := range np.Spec.PolicyTypes {
+		switch t {
+		case networkingv1.PolicyTypeIngress:
+			ingress = true
+		case networkingv1.PolicyTypeEgress:
+			egress = true
+		}
+	}
+	return ingress, egress
+}
+
+// summarizeRules describes the traffic a direction allows: "all" when it is
+// not isolated, "none" when no rule allows anything, otherwise each rule
+func summarizeRules(isolated bool, rules []netpolRule) string {
+	if !isolated {
+		return "all"
+	}
+	var parts []string
+	seen := map[string]bool{}
+	for _, rule := range rules {
+		part := describeRule(rule)
+		if !seen[part] {
+			seen[part] = true
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, "; ")
+}
+
+func describeRule(rule netpolRule) string {
+	peers := "any"
+	if len(rule.peers) > 0 {
+		var described []string
+		for _, peer := range rule.peers {
+			described = append(described, describePeer(peer))
+		}
+		peers = strings.Join(described, ", ")
+	}
+	if len(rule.ports) == 0 {
+		return peers
+	}
+	var ports []string
+	for _, p := range rule.ports {
+		ports = append(ports, describePort(p))
+	}
+	return peers + " on " + strings.Join(ports, ",")
+}
+
+func describePeer(peer networkingv1.NetworkPolicyPeer) string {
+	if peer.IPBlock != nil {
+		if len(peer.IPBlock.Except) > 0 {
+			return peer.IPBlock.CIDR + " except " + strings.Join(peer.IPBlock.Except, ",")
+		}
+		return peer.IPBlock.CIDR
+	}
+	pods := "pods"
+	if peer.PodSelector != nil && (len(peer.PodSelector.MatchLabels) > 0 || len(peer.PodSelector.MatchExpressions) > 0) {
+		pods = "pods " + metav1.FormatLabelSelector(peer.PodSelector)
+	}
+	switch {
+	case peer.NamespaceSelector == nil:
+		return pods
+	case len(peer.NamespaceSelector.MatchLabels) == 0 && len(peer.NamespaceSelector.MatchExpressions) == 0:
+		return pods + " in all namespaces"
+	default:
+		return pods + " in namespaces " + metav1.FormatLabelSelector(peer.NamespaceSelector)
+	}
+}
+
+func describePort(p networkingv1.NetworkPolicyPort) string {
+	protocol := "TCP"
+	if p.Protocol != nil {
+		protocol = string(*p.Protocol)
+	}
+	if p.Port == nil {
+		return protocol
+	}
+	port := protocol + "/" + p.Port.String()
+	if p.EndPort != nil {
+		port += fmt.Sprintf("-%d", *p.EndPort)
+	}
+	return port
+}
+
+// postureWarning reports the clusters of each posture when the target does
+// not have the same posture everywhere it exists
+func postureWarning(target string, results []netpolAnalysis) string {
+	var order []string
+	clusters := map[string][]string{}
+	for _, a := range results {
+		if a.Posture == posturePodMissing {
+			continue
+		}
+		if _, seen := clusters[a.Posture]; !seen {
+			order = append(order, a.Posture)
+		}
+		clusters[a.Posture] = append(clusters[a.Posture], a.Cluster)
+	}
+	if len(order) < 2 {
+		return ""
+	}
+	parts := make([]string, 0, len(order))
+	for _, posture := range order {
+		parts = append(parts, posture+" in "+strings.Join(clusters[posture], ", "))
+	}
+	return fmt.Sprintf("Warning: %s has an inconsistent network posture: %s", target, strings.Join(parts, "; "))
+}
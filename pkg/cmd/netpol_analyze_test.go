@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func testNetworkPolicy(name string, podLabels map[string]string, types []networkingv1.PolicyType, ingress []networkingv1.NetworkPolicyIngressRule, egress []networkingv1.NetworkPolicyEgressRule) networkingv1.NetworkPolicy {
+	return networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: name},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: podLabels},
+			PolicyTypes: types,
+			Ingress:     ingress,
+			Egress:      egress,
+		},
+	}
+}
+
+func TestAnalyzeNetworkPolicies(t *testing.T) {
+	web := map[string]string{"app": "web"}
+	udp := corev1.ProtocolUDP
+	port80, port53 := intstr.FromInt(80), intstr.FromInt(53)
+	endPort := int32(9000)
+	port8000 := intstr.FromInt(8000)
+	fromFrontend := networkingv1.NetworkPolicyIngressRule{
+		From: []networkingv1.NetworkPolicyPeer{{
+			PodSelector:       &metav1.LabelSelector{MatchLabels: map[string]string{"app": "frontend"}},
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+		}},
+		Ports: []networkingv1.NetworkPolicyPort{{Port: &port80}, {Port: &port8000, EndPort: &endPort}},
+	}
+	dns := networkingv1.NetworkPolicyEgressRule{
+		To:    []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "10.0.0.0/8", Except: []string{"10.1.0.0/16"}}}},
+		Ports: []networkingv1.NetworkPolicyPort{{Protocol: &udp, Port: &port53}},
+	}
+	both := []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress}
+
+	tests := []struct {
+		name     string
+		policies []networkingv1.NetworkPolicy
+		want     netpolAnalysis
+	}{
+		{
+			name:     "unselected",
+			policies: []networkingv1.NetworkPolicy{testNetworkPolicy("db", map[string]string{"app": "db"}, nil, nil, nil)},
+			want:     netpolAnalysis{Ingress: "all", Egress: "all", Posture: postureDefaultAllow},
+		},
+		{
+			name:     "default deny ingress",
+			policies: []networkingv1.NetworkPolicy{testNetworkPolicy("deny", nil, nil, nil, nil)},
+			want:     netpolAnalysis{Policies: []string{"deny"}, Ingress: "none", Egress: "all", Posture: postureIngressIsolated},
+		},
+		{
+			name: "egress rules imply egress",
+			policies: []networkingv1.NetworkPolicy{
+				testNetworkPolicy("dns", web, nil, nil, []networkingv1.NetworkPolicyEgressRule{dns}),
+			},
+			want: netpolAnalysis{Policies: []string{"dns"}, Ingress: "none", Egress: "10.0.0.0/8 except 10.1.0.0/16 on UDP/53", Posture: postureLockedDown},
+		},
+		{
+			name: "union of selecting policies",
+			policies: []networkingv1.NetworkPolicy{
+				testNetworkPolicy("web", web, both, []networkingv1.NetworkPolicyIngressRule{fromFrontend, {}}, nil),
+				testNetworkPolicy("egress", nil, []networkingv1.PolicyType{networkingv1.PolicyTypeEgress}, nil, []networkingv1.NetworkPolicyEgressRule{dns, dns}),
+			},
+			want: netpolAnalysis{
+				Policies: []string{"egress", "web"},
+				Ingress:  "pods app=frontend in namespaces team=a on TCP/80,TCP/8000-9000; any",
+				Egress:   "10.0.0.0/8 except 10.1.0.0/16 on UDP/53",
+				Posture:  postureLockedDown,
+			},
+		},
+		{
+			name: "egress only",
+			policies: []networkingv1.NetworkPolicy{
+				testNetworkPolicy("egress", web, []networkingv1.PolicyType{networkingv1.PolicyTypeEgress}, nil, []networkingv1.NetworkPolicyEgressRule{{
+					To: []networkingv1.NetworkPolicyPeer{{NamespaceSelector: &metav1.LabelSelector{}}},
+				}}),
+			},
+			want: netpolAnalysis{Policies: []string{"egress"}, Ingress: "all", Egress: "pods in all namespaces", Posture: postureEgressIsolated},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := analyzeNetworkPolicies(web, tt.policies); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("analyzeNetworkPolicies() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPostureWarning(t *testing.T) {
+	results := []netpolAnalysis{
+		{Cluster: "cluster1", Posture: postureLockedDown},
+		{Cluster: "cluster2", Posture: postureDefaultAllow},
+		{Cluster: "cluster3", Posture: postureLockedDown},
+		{Cluster: "cluster4", Posture: posturePodMissing},
+	}
+	want := "Warning: web has an inconsistent network posture: locked-down in cluster1, cluster3; default-allow in cluster2"
+	if got := postureWarning("web", results); got != want {
+		t.Errorf("postureWarning() = %q, want %q", got, want)
+	}
+	if got := postureWarning("web", results[:1]); got != "" {
+		t.Errorf("postureWarning() of one posture = %q, want none", got)
+	}
+}
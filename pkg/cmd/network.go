@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"kubectl-multi/pkg/config"
+	"kubectl-multi/pkg/network"
+)
+
+// offline forbids network access outside the configured clusters
+var offline bool
+
+// configureNetwork applies the proxies and offline mode of the plugin config
+// and --offline. It runs before every command.
+func configureNetwork() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if err := network.Configure(cfg.Network, offline); err != nil {
+		return fmt.Errorf("invalid plugin config: %v", err)
+	}
+	return nil
+}
+
+// proxySubprocess points a kubectl or helm subprocess at the proxy
+// configured for the context it targets, or else for the cluster it is
+// recorded against
+func proxySubprocess(cmd *exec.Cmd, cluster string) {
+	context := subprocessContext(cmd.Args)
+	if context == "" && cluster != localSubprocess {
+		context = cluster
+	}
+	proxy, ok := network.ProxyFor(context)
+	if !ok {
+		return
+	}
+	env := cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	cmd.Env = append(env, network.ProxyEnv(proxy)...)
+}
+
+// subprocessContext returns the context a kubectl (--context) or helm
+// (--kube-context) command line targets
+func subprocessContext(args []string) string {
+	if context := kubectlContext(args); context != "" {
+		return context
+	}
+	for i, arg := range args {
+		if arg == "--kube-context" && i+1 < len(args) {
+			return args[i+1]
+		}
+		if value, ok := strings.CutPrefix(arg, "--kube-context="); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// offlineHelmCommands are the helm commands that reach chart repositories,
+// registries or plugin sources
+var offlineHelmCommands = map[string]string{
+	"repo":       "helm repo",
+	"pull":       "helm pull",
+	"push":       "helm push",
+	"registry":   "helm registry",
+	"dependency": "helm dependency",
+	"plugin":     "helm plugin",
+	"search":     "helm search",
+}
+
+// checkHelmOffline rejects, in offline mode, helm command lines that would
+// download charts or talk to a registry: remote chart references, --repo and
+// dependency updates
+func checkHelmOffline(args []string) error {
+	if !network.Offline() || len(args) == 0 {
+		return nil
+	}
+	if what, ok := offlineHelmCommands[args[0]]; ok {
+		return network.CheckOnline(what)
+	}
+	for i, arg := range args {
+		name, _, _ := strings.Cut(arg, "=")
+		switch {
+		case name == "--repo":
+			return network.CheckOnline("a chart from --repo")
+		case name == "--dependency-update" || name == "--dep-up":
+			return network.CheckOnline(name)
+		case strings.HasPrefix(arg, "oci://") || strings.HasPrefix(arg, "http://") || strings.HasPrefix(arg, "https://"):
+			return network.CheckOnline("chart " + arg)
+		case i > 0 && isRepoChart(arg):
+			return network.CheckOnline("chart " + arg + " from its repository")
+		}
+	}
+	return nil
+}
+
+// isRepoChart reports whether a helm argument is a REPO/CHART reference,
+// which helm downloads, rather than a local chart directory or archive
+func isRepoChart(arg string) bool {
+	repo, chart, ok := strings.Cut(arg, "/")
+	if !ok || repo == "" || chart == "" || strings.ContainsAny(arg, "=:,") ||
+		strings.HasPrefix(arg, "-") || strings.HasPrefix(arg, ".") || strings.Contains(chart, "/") {
+		return false
+	}
+	_, err := os.Stat(arg)
+	return os.IsNotExist(err)
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"kubectl-multi/pkg/config"
+	"kubectl-multi/pkg/network"
+)
+
+func TestCheckHelmOffline(t *testing.T) {
+	chart := filepath.Join(t.TempDir(), "web")
+	if err := os.Mkdir(chart, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "local chart", args: []string{"upgrade", "--install", "web", chart, "--set", "image=registry/web:1.2"}},
+		{name: "status", args: []string{"status", "web", "-n", "web"}},
+		{name: "repo update", args: []string{"repo", "update"}, wantErr: "helm repo needs network access"},
+		{name: "dependency update", args: []string{"dependency", "update", chart}, wantErr: "helm dependency"},
+		{name: "OCI chart", args: []string{"install", "web", "oci://ghcr.io/acme/web"}, wantErr: "chart oci://ghcr.io/acme/web"},
+		{name: "repo chart", args: []string{"upgrade", "--install", "web", "bitnami/nginx"}, wantErr: "chart bitnami/nginx from its repository"},
+		{name: "--repo", args: []string{"install", "web", "nginx", "--repo=https://charts.example.com"}, wantErr: "--repo"},
+		{name: "--dependency-update", args: []string{"install", "web", chart, "--dependency-update"}, wantErr: "--dependency-update"},
+	}
+	t.Cleanup(func() { network.Configure(nil, false) })
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			network.Configure(nil, false)
+			if err := checkHelmOffline(tt.args); err != nil {
+				t.Errorf("checkHelmOffline() online error = %v", err)
+			}
+			network.Configure(nil, true)
+			err := checkHelmOffline(tt.args)
+			if (err == nil) != (tt.wantErr == "") || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("checkHelmOffline() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProxySubprocess(t *testing.T) {
+	t.Cleanup(func() { network.Configure(nil, false) })
+	network.Configure(&config.NetworkConfig{Proxies: map[string]config.Proxy{
+		"edge-*": {HTTPSProxy: "http://edge-proxy:3128"},
+	}}, false)
+
+	tests := []struct {
+		name    string
+		args    []string
+		cluster string
+		want    bool
+	}{
+		{name: "kubectl --context", args: []string{"kubectl", "apply", "--context", "edge-1", "-f", "-"}, cluster: "cluster1", want: true},
+		{name: "helm --kube-context", args: []string{"helm", "status", "web", "--kube-context=edge-1"}, cluster: "cluster1", want: true},
+		{name: "recorded cluster", args: []string{"helm", "upgrade", "--install", "ks", "./chart"}, cluster: "edge-1", want: true},
+		{name: "other context", args: []string{"kubectl", "get", "pods", "--context", "cluster1"}, cluster: "edge-1"},
+		{name: "local", args: []string{"helm", "dependency", "update", "./chart"}, cluster: localSubprocess},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := exec.Command(tt.args[0], tt.args[1:]...)
+			proxySubprocess(cmd, tt.cluster)
+			got := slices.Contains(cmd.Env, "HTTPS_PROXY=http://edge-proxy:3128")
+			if got != tt.want {
+				t.Errorf("proxySubprocess() env has proxy = %v, want %v", got, tt.want)
+			}
+			if !tt.want && cmd.Env != nil {
+				t.Errorf("proxySubprocess() set env %v, want inherited", cmd.Env)
+			}
+		})
+	}
+}
@@ -0,0 +1,333 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kubectl/pkg/drain"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+// nodeOptions holds the node selection flags shared by cordon, uncordon and drain
+type nodeOptions struct {
+	Cluster  string
+	Selector string
+	DryRun   bool
+	Targets  clusterTargets
+}
+
+// drainOptions holds the eviction flags of drain
+type drainOptions struct {
+	Force               bool
+	IgnoreDaemonSets    bool
+	DeleteEmptyDirData  bool
+	DisableEviction     bool
+	GracePeriodSeconds  int
+	Timeout             time.Duration
+	PodSelector         string
+	SkipWaitForDeleteTS int
+}
+
+// addFlags registers the node selection flags on cmd
+func (o *nodeOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.Cluster, "cluster", "", "managed cluster (or @group) holding NODE")
+	cmd.Flags().StringVarP(&o.Selector, "selector", "l", "", "node label selector; selects matching nodes in every target cluster")
+	cmd.Flags().BoolVar(&o.DryRun, "dry-run", false, "only print the nodes (and, for drain, the pods) that would be affected")
+	o.Targets.addFlags(cmd, "target with -l")
+}
+
+// validate checks that exactly one of NODE or -l selects the nodes, and
+// that --cluster is not mixed with the fleet targeting flags
+func (o nodeOptions) validate(args []string) error {
+	switch {
+	case o.Cluster != "" && (len(o.Targets.Names) > 0 || o.Targets.Selector != ""):
+		return fmt.Errorf("--cluster cannot be combined with --clusters or --cluster-selector")
+	case len(args) == 1 && o.Selector != "":
+		return fmt.Errorf("specify either NODE or --selector, not both")
+	case len(args) == 1 && o.Cluster == "":
+		return fmt.Errorf("NODE requires --cluster; use --selector to pick nodes across clusters")
+	case len(args) == 0 && o.Selector == "":
+		return fmt.Errorf("a NODE (with --cluster) or a node --selector must be specified")
+	}
+	return nil
+}
+
+// targets returns the clusters to act in: the --cluster holding NODE, or
+// the fleet targeting flags
+func (o nodeOptions) targets() clusterTargets {
+	if o.Cluster != "" {
+		return clusterTargets{Names: []string{o.Cluster}}
+	}
+	return o.Targets
+}
+
+func newCordonCommand() *cobra.Command {
+	var o nodeOptions
+	cmd := &cobra.Command{
+		Use:   "cordon (NODE --cluster NAME | -l SELECTOR)",
+		Short: "Mark nodes as unschedulable across managed clusters",
+		Long: `Mark nodes as unschedulable. Name one NODE in one --cluster, or select
+nodes by label in every managed cluster (optionally narrowed with --clusters
+or --cluster-selector).`,
+		Example: `# Cordon one node
+kubectl multi cordon worker-1 --cluster cluster1
+
+# Cordon every node of the patch wave across the fleet
+kubectl multi cordon -l patch-wave=1`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runNodeCommand("cordon", args, o, nil)
+		},
+	}
+	o.addFlags(cmd)
+	return cmd
+}
+
+func newUncordonCommand() *cobra.Command {
+	var o nodeOptions
+	cmd := &cobra.Command{
+		Use:   "uncordon (NODE --cluster NAME | -l SELECTOR)",
+		Short: "Mark nodes as schedulable across managed clusters",
+		Long: `Mark nodes as schedulable again. Name one NODE in one --cluster, or select
+nodes by label in every managed cluster.`,
+		Example: `# Uncordon one node
+kubectl multi uncordon worker-1 --cluster cluster1
+
+# Return the patch wave to service everywhere
+kubectl multi uncordon -l patch-wave=1`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runNodeCommand("uncordon", args, o, nil)
+		},
+	}
+	o.addFlags(cmd)
+	return cmd
+}
+
+func newDrainCommand() *cobra.Command {
+	var o nodeOptions
+	var d drainOptions
+	cmd := &cobra.Command{
+		Use:   "drain (NODE --cluster NAME | -l SELECTOR)",
+		Short: "Cordon nodes and evict their pods across managed clusters",
+		Long: `Cordon nodes and evict (or delete) their pods, honoring PodDisruptionBudgets,
+using the same rules as kubectl drain. Nodes are drained one at a time with
+per-cluster progress; a failure on one node does not stop the others.`,
+		Example: `# Drain one node
+kubectl multi drain worker-1 --cluster cluster1 --ignore-daemonsets
+
+# Drain the patch wave across the fleet, showing the plan first
+kubectl multi drain -l patch-wave=1 --ignore-daemonsets --dry-run
+kubectl multi drain -l patch-wave=1 --ignore-daemonsets --delete-emptydir-data`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runNodeCommand("drain", args, o, &d)
+		},
+	}
+	o.addFlags(cmd)
+	cmd.Flags().BoolVar(&d.Force, "force", false, "continue even if there are pods not managed by a controller")
+	cmd.Flags().BoolVar(&d.IgnoreDaemonSets, "ignore-daemonsets", false, "ignore DaemonSet-managed pods")
+	cmd.Flags().BoolVar(&d.DeleteEmptyDirData, "delete-emptydir-data", false, "continue even if there are pods using emptyDir (local data that will be deleted)")
+	cmd.Flags().BoolVar(&d.DisableEviction, "disable-eviction", false, "delete pods instead of evicting them, bypassing PodDisruptionBudgets")
+	cmd.Flags().IntVar(&d.GracePeriodSeconds, "grace-period", -1, "seconds given to each pod to terminate gracefully; negative uses the pod's own value")
+	cmd.Flags().DurationVar(&d.Timeout, "timeout", 0, "how long to wait per node before giving up, zero means infinite")
+	cmd.Flags().StringVar(&d.PodSelector, "pod-selector", "", "label selector to filter pods on the node")
+	cmd.Flags().IntVar(&d.SkipWaitForDeleteTS, "skip-wait-for-delete-timeout", 0, "skip waiting for pods whose deletion timestamp is older than this many seconds")
+	return cmd
+}
+
+// runNodeCommand wraps handleNodeCommand with auditing
+func runNodeCommand(action string, args []string, o nodeOptions, d *drainOptions) error {
+	if err := o.validate(args); err != nil {
+		return err
+	}
+	var err error
+	if o.Selector, err = parseSelector(o.Selector); err != nil {
+		return err
+	}
+	if d != nil {
+		if d.PodSelector, err = parseSelector(d.PodSelector); err != nil {
+			return fmt.Errorf("--pod-selector: %v", err)
+		}
+	}
+	nodeName := ""
+	if len(args) == 1 {
+		nodeName = args[0]
+	}
+
+	kubeconfig, remoteCtx, _, _, _ := GetGlobalFlags()
+	var rec *audit.Recorder
+	if !o.DryRun {
+		rec = startAudit(action)
+	}
+	err = handleNodeCommand(action, nodeName, o, d, rec, kubeconfig, remoteCtx)
+	finishAudit(rec, err)
+	return err
+}
+
+func handleNodeCommand(action, nodeName string, o nodeOptions, d *drainOptions, rec *audit.Recorder, kubeconfig, remoteCtx string) error {
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	clusters, err = o.targets().selectFrom(clusters, kubeconfig)
+	if err != nil {
+		return err
+	}
+	if len(clusters) == 0 {
+		return fmt.Errorf("no clusters discovered")
+	}
+	if err := guardFanOut(rec, blastRadius{}, clusters, remoteCtx); err != nil {
+		return err
+	}
+
+	total, failed := 0, 0
+	for _, clusterInfo := range clusters {
+		if clusterInfo.Client == nil {
+			fmt.Printf("Warning: skipping cluster %s (no client available)\n", clusterInfo.Name)
+			continue
+		}
+		if clusterInfo.Context == remoteCtx {
+			fmt.Printf("Cannot perform this operation on ITS (control) cluster: %s\n", clusterInfo.Context)
+			continue
+		}
+
+		nodes, err := selectNodes(clusterInfo, nodeName, o.Selector)
+		if err != nil {
+			fmt.Printf("=== Cluster: %s ===\nError: %v\n\n", clusterInfo.Name, err)
+			rec.Record(clusterInfo.Name, err)
+			failed++
+			continue
+		}
+		if len(nodes) == 0 {
+			continue
+		}
+
+		fmt.Printf("=== Cluster: %s ===\n", clusterInfo.Name)
+		for i := range nodes {
+			node := &nodes[i]
+			total++
+			err := runNodeAction(action, clusterInfo, node, o.DryRun, d, rec)
+			if err != nil {
+				fmt.Printf("node/%s %s failed: %v\n", node.Name, action, err)
+				failed++
+			}
+			rec.Record(clusterInfo.Name, err)
+		}
+		fmt.Println()
+	}
+
+	if total == 0 && failed == 0 {
+		return fmt.Errorf("no matching nodes found in any cluster")
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to %s %d node(s)", action, failed)
+	}
+	return nil
+}
+
+// selectNodes returns the named node or the nodes matching the selector
+func selectNodes(clusterInfo cluster.ClusterInfo, nodeName, selector string) ([]corev1.Node, error) {
+	if nodeName != "" {
+		node, err := clusterInfo.Client.CoreV1().Nodes().Get(commandContext(), nodeName, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return []corev1.Node{*node}, nil
+	}
+	list, err := clusterInfo.Client.CoreV1().Nodes().List(commandContext(), metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %v", err)
+	}
+	return list.Items, nil
+}
+
+// runNodeAction cordons, uncordons or drains one node and records how to
+// revert the scheduling change
+func runNodeAction(action string, clusterInfo cluster.ClusterInfo, node *corev1.Node, dryRun bool, d *drainOptions, rec *audit.Recorder) error {
+	out := util.GetOutputStream()
+	helper := &drain.Helper{
+		Ctx:    commandContext(),
+		Client: clusterInfo.Client,
+		Out:    out,
+		ErrOut: os.Stderr,
+	}
+	if d != nil {
+		helper.Force = d.Force
+		helper.IgnoreAllDaemonSets = d.IgnoreDaemonSets
+		helper.DeleteEmptyDirData = d.DeleteEmptyDirData
+		helper.DisableEviction = d.DisableEviction
+		helper.GracePeriodSeconds = d.GracePeriodSeconds
+		helper.Timeout = d.Timeout
+		helper.PodSelector = d.PodSelector
+		helper.SkipWaitForDeleteTimeoutSeconds = d.SkipWaitForDeleteTS
+		helper.OnPodDeletionOrEvictionFinished = func(pod *corev1.Pod, usingEviction bool, err error) {
+			verb := "deleted"
+			if usingEviction {
+				verb = "evicted"
+			}
+			if err != nil {
+				fmt.Fprintf(out, "  pod/%s -n %s not %s: %v\n", pod.Name, pod.Namespace, verb, err)
+				return
+			}
+			fmt.Fprintf(out, "  pod/%s -n %s %s\n", pod.Name, pod.Namespace, verb)
+		}
+	}
+
+	wasUnschedulable := node.Spec.Unschedulable
+	desired := action != "uncordon"
+
+	if dryRun {
+		suffix := ""
+		if desired == wasUnschedulable {
+			suffix = " (no change)"
+		}
+		fmt.Fprintf(out, "node/%s would be %sed%s (dry run)\n", node.Name, action, suffix)
+		if action == "drain" {
+			list, errs := helper.GetPodsForDeletion(node.Name)
+			if len(errs) > 0 {
+				return fmt.Errorf("cannot drain: %v", errs)
+			}
+			for _, pod := range list.Pods() {
+				fmt.Fprintf(out, "  pod/%s -n %s would be evicted\n", pod.Name, pod.Namespace)
+			}
+			if warnings := list.Warnings(); warnings != "" {
+				fmt.Fprintf(os.Stderr, "WARNING: %s\n", warnings)
+			}
+		}
+		return nil
+	}
+
+	if err := drain.RunCordonOrUncordon(helper, node, desired); err != nil {
+		return err
+	}
+	if desired != wasUnschedulable {
+		undo := audit.UndoUncordon
+		if !desired {
+			undo = audit.UndoCordon
+		}
+		rec.AddUndo(audit.UndoStep{Cluster: clusterInfo.Name, Action: undo, Version: "v1", Resource: "nodes", Name: node.Name})
+		if desired {
+			fmt.Fprintf(out, "node/%s cordoned\n", node.Name)
+		} else {
+			fmt.Fprintf(out, "node/%s uncordoned\n", node.Name)
+		}
+	} else if action != "drain" {
+		fmt.Fprintf(out, "node/%s already %sed\n", node.Name, action)
+	}
+
+	if action == "drain" {
+		if err := drain.RunNodeDrain(helper, node.Name); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "node/%s drained\n", node.Name)
+	}
+	return nil
+}
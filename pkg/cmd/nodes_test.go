@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/config"
+)
+
+func TestNodeOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    nodeOptions
+		args    []string
+		wantErr bool
+	}{
+		{name: "node in cluster", opts: nodeOptions{Cluster: "cluster1"}, args: []string{"worker-1"}},
+		{name: "selector across fleet", opts: nodeOptions{Selector: "patch-wave=1"}},
+		{name: "selector in targeted clusters", opts: nodeOptions{Selector: "patch-wave=1", Targets: clusterTargets{Selector: "env=prod"}}},
+		{name: "selector in one cluster", opts: nodeOptions{Selector: "patch-wave=1", Cluster: "cluster1"}},
+		{name: "node without cluster", args: []string{"worker-1"}, wantErr: true},
+		{name: "node and selector", opts: nodeOptions{Cluster: "cluster1", Selector: "a=b"}, args: []string{"worker-1"}, wantErr: true},
+		{name: "nothing selected", wantErr: true},
+		{
+			name:    "cluster with --clusters",
+			opts:    nodeOptions{Cluster: "cluster1", Targets: clusterTargets{Names: []string{"cluster2"}}},
+			args:    []string{"worker-1"},
+			wantErr: true,
+		},
+		{
+			name:    "cluster with --cluster-selector",
+			opts:    nodeOptions{Cluster: "cluster1", Selector: "a=b", Targets: clusterTargets{Selector: "env=prod"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opts.validate(tt.args); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNodeOptionsTargets(t *testing.T) {
+	t.Setenv(config.EnvConfigPath, filepath.Join(t.TempDir(), "config.yaml"))
+	cfg := &config.Config{Groups: map[string][]string{"edge": {"cluster2", "cluster3"}}}
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	discovered := []cluster.ClusterInfo{{Name: "cluster1"}, {Name: "cluster2"}, {Name: "cluster3"}}
+
+	tests := []struct {
+		name    string
+		opts    nodeOptions
+		want    []string
+		wantErr bool
+	}{
+		{name: "whole fleet", opts: nodeOptions{Selector: "a=b"}, want: []string{"cluster1", "cluster2", "cluster3"}},
+		{name: "named cluster", opts: nodeOptions{Cluster: "cluster2"}, want: []string{"cluster2"}},
+		{name: "group cluster", opts: nodeOptions{Cluster: "@edge"}, want: []string{"cluster2", "cluster3"}},
+		{name: "fleet narrowed by group", opts: nodeOptions{Targets: clusterTargets{Names: []string{"@edge"}}}, want: []string{"cluster2", "cluster3"}},
+		{name: "undefined group", opts: nodeOptions{Cluster: "@core"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected, err := tt.opts.targets().selectFrom(discovered, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("selectFrom() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, c := range selected {
+				got = append(got, c.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectFrom() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"kubectl-multi/pkg/cluster"
+)
+
+// listNamespaced lists a namespaced resource in targetNS, or in every
+// namespace when targetNS is empty. When the cluster-wide list is forbidden
+// it lists the namespaces the user may read instead, found with
+// SelfSubjectRulesReview, merges their items into one list and notes the
+// partial result as a cluster issue.
+func listNamespaced[L runtime.Object](c cluster.ClusterInfo, group, resource, targetNS string, list func(namespace string) (L, error)) (L, error) {
+	result, err := list(targetNS)
+	if targetNS != "" || !apierrors.IsForbidden(err) || c.Client == nil {
+		return result, err
+	}
+
+	namespaces := accessibleNamespaces(c, group, resource)
+	if len(namespaces) == 0 {
+		return result, err
+	}
+
+	var merged L
+	var items []runtime.Object
+	listed := 0
+	for _, ns := range namespaces {
+		nsList, nsErr := list(ns)
+		if nsErr != nil {
+			if apierrors.IsForbidden(nsErr) {
+				continue
+			}
+			return nsList, nsErr
+		}
+		nsItems, nsErr := meta.ExtractList(nsList)
+		if nsErr != nil {
+			return nsList, nsErr
+		}
+		if listed == 0 {
+			merged = nsList
+		}
+		items = append(items, nsItems...)
+		listed++
+	}
+	if listed == 0 {
+		return result, err
+	}
+	if err := meta.SetList(merged, items); err != nil {
+		return merged, err
+	}
+	noteClusterIssue(c.Name, fmt.Sprintf("not allowed to list %s cluster-wide, showing %d accessible namespace(s)", resource, listed))
+	return merged, nil
+}
+
+// accessibleNamespaces returns the namespaces whose rules let the user list
+// group/resource. The candidates are all namespaces when the user may list
+// them, otherwise only the default namespace.
+func accessibleNamespaces(c cluster.ClusterInfo, group, resource string) []string {
+	candidates := []string{cluster.GetTargetNamespace("")}
+	if nsList, err := c.Client.CoreV1().Namespaces().List(commandContext(), metav1.ListOptions{}); err == nil {
+		candidates = candidates[:0]
+		for _, ns := range nsList.Items {
+			candidates = append(candidates, ns.Name)
+		}
+	}
+
+	var accessible []string
+	for _, ns := range candidates {
+		review := &authorizationv1.SelfSubjectRulesReview{
+			Spec: authorizationv1.SelfSubjectRulesReviewSpec{Namespace: ns},
+		}
+		review, err := c.Client.AuthorizationV1().SelfSubjectRulesReviews().Create(commandContext(), review, metav1.CreateOptions{})
+		if err != nil {
+			continue
+		}
+		if rulesAllowList(review.Status.ResourceRules, group, resource) {
+			accessible = append(accessible, ns)
+		}
+	}
+	return accessible
+}
+
+// rulesAllowList reports whether any rule grants list on every object of
+// group/resource. Rules limited to resource names do not allow a list.
+func rulesAllowList(rules []authorizationv1.ResourceRule, group, resource string) bool {
+	for _, rule := range rules {
+		if len(rule.ResourceNames) > 0 {
+			continue
+		}
+		if matchesRule(rule.Verbs, "list") && matchesRule(rule.APIGroups, group) && matchesRule(rule.Resources, resource) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesRule(values []string, want string) bool {
+	for _, v := range values {
+		if v == "*" || v == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"kubectl-multi/pkg/cluster"
+)
+
+// testLimitedCluster returns a cluster whose user may list pods only in the
+// namespaces of allowed, and never cluster-wide
+func testLimitedCluster(name string, allowed []string, objects ...runtime.Object) cluster.ClusterInfo {
+	client := kubefake.NewSimpleClientset(objects...)
+	forbidden := apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "", nil)
+	client.PrependReactor("list", "pods", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		ns := action.GetNamespace()
+		for _, a := range allowed {
+			if a == ns {
+				return false, nil, nil
+			}
+		}
+		return true, nil, forbidden
+	})
+	client.PrependReactor("create", "selfsubjectrulesreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		review := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectRulesReview).DeepCopy()
+		for _, a := range allowed {
+			if a == review.Spec.Namespace {
+				review.Status.ResourceRules = []authorizationv1.ResourceRule{{Verbs: []string{"get", "list"}, APIGroups: []string{""}, Resources: []string{"pods"}}}
+			}
+		}
+		return true, review, nil
+	})
+	return cluster.ClusterInfo{Name: name, Context: name, Client: client}
+}
+
+func TestListNamespacedFallback(t *testing.T) {
+	resetClusterIssues()
+	defer resetClusterIssues()
+
+	c := testLimitedCluster("cluster1", []string{"team-a", "team-c"},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-b"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-c"}},
+		testPod("team-a", "web-1", nil),
+		testPod("team-b", "web-2", nil),
+		testPod("team-c", "web-3", nil),
+	)
+	pods, err := listNamespaced(c, "", "pods", "", func(ns string) (*corev1.PodList, error) {
+		return c.Client.CoreV1().Pods(ns).List(commandContext(), metav1.ListOptions{})
+	})
+	if err != nil {
+		t.Fatalf("listNamespaced() error = %v", err)
+	}
+	var names []string
+	for _, pod := range pods.Items {
+		names = append(names, pod.Namespace+"/"+pod.Name)
+	}
+	sort.Strings(names)
+	if want := []string{"team-a/web-1", "team-c/web-3"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("listed %v, want %v", names, want)
+	}
+	want := []clusterIssue{{Cluster: "cluster1", Reason: "not allowed to list pods cluster-wide, showing 2 accessible namespace(s)"}}
+	if issues := takeClusterIssues(); !reflect.DeepEqual(issues, want) {
+		t.Errorf("issues = %v, want %v", issues, want)
+	}
+
+	// Without an accessible namespace the original error is returned
+	c = testLimitedCluster("cluster2", nil, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}})
+	_, err = listNamespaced(c, "", "pods", "", func(ns string) (*corev1.PodList, error) {
+		return c.Client.CoreV1().Pods(ns).List(commandContext(), metav1.ListOptions{})
+	})
+	if !apierrors.IsForbidden(err) {
+		t.Errorf("error = %v, want forbidden", err)
+	}
+}
+
+func TestRulesAllowList(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []authorizationv1.ResourceRule
+		want  bool
+	}{
+		{"exact", []authorizationv1.ResourceRule{{Verbs: []string{"list"}, APIGroups: []string{"apps"}, Resources: []string{"deployments"}}}, true},
+		{"wildcards", []authorizationv1.ResourceRule{{Verbs: []string{"*"}, APIGroups: []string{"*"}, Resources: []string{"*"}}}, true},
+		{"get only", []authorizationv1.ResourceRule{{Verbs: []string{"get"}, APIGroups: []string{"apps"}, Resources: []string{"deployments"}}}, false},
+		{"other group", []authorizationv1.ResourceRule{{Verbs: []string{"list"}, APIGroups: []string{""}, Resources: []string{"deployments"}}}, false},
+		{"named objects", []authorizationv1.ResourceRule{{Verbs: []string{"list"}, APIGroups: []string{"apps"}, Resources: []string{"deployments"}, ResourceNames: []string{"web"}}}, false},
+		{"no rules", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rulesAllowList(tt.rules, "apps", "deployments"); got != tt.want {
+				t.Errorf("rulesAllowList() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// outputFile is the --output-file flag sending the standard output of the
+// command to a file
+var outputFile string
+
+// redirectedOutput is the file standing in for stdout while the command
+// runs, and the stdout it replaced
+var (
+	redirectedOutput *os.File
+	originalStdout   *os.File
+)
+
+// redirectOutput points stdout at a temporary file next to path. It is
+// renamed to path by finishOutputFile when the command succeeds, so a
+// failed run, such as a scheduled report, leaves the previous file intact.
+func redirectOutput(path string) error {
+	if path == "" {
+		return nil
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create --output-file %s: %v", path, err)
+	}
+	redirectedOutput, originalStdout = f, os.Stdout
+	os.Stdout = f
+	return nil
+}
+
+// finishOutputFile restores stdout and moves the output into place, or
+// drops it when the command failed
+func finishOutputFile(path string, err error) error {
+	if redirectedOutput == nil {
+		return err
+	}
+	f := redirectedOutput
+	os.Stdout, redirectedOutput = originalStdout, nil
+	closeErr := f.Close()
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if closeErr != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("failed to write --output-file %s: %v", path, closeErr)
+	}
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("failed to write --output-file %s: %v", path, err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("failed to write --output-file %s: %v", path, err)
+	}
+	return nil
+}
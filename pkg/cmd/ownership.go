@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/gitops"
+	"kubectl-multi/pkg/kubestellar"
+)
+
+// guardDownsynced refuses to write an object that KubeStellar downsyncs to
+// the cluster, since the next downsync reverts the change. With force it
+// only writes a warning. An object Argo CD or Flux manages is only warned
+// about, since the GitOps controller may be set not to revert changes.
+func guardDownsynced(ref, clusterName string, live *unstructured.Unstructured, force bool, warn io.Writer) error {
+	if m, ok := gitops.ManagerOf(live); ok {
+		fmt.Fprintf(warn, "Warning: %s\n", gitops.Warning(ref, clusterName, m))
+	}
+	owner, managed := kubestellar.DownsyncOwnerOf(live)
+	if !managed {
+		return nil
+	}
+	msg := kubestellar.DownsyncWarning(ref, clusterName, owner)
+	if force {
+		fmt.Fprintf(warn, "Warning: %s\n", msg)
+		return nil
+	}
+	return fmt.Errorf("%s (use --force to write it anyway)", msg)
+}
+
+// guardDownsyncedObjects runs guardDownsynced for each manifest object that
+// already exists in the cluster. Objects that cannot be mapped or read are
+// left for the write itself to report.
+func guardDownsyncedObjects(c cluster.ClusterInfo, objs []*unstructured.Unstructured, namespace string, force bool, warn io.Writer) error {
+	if c.DynamicClient == nil || c.DiscoveryClient == nil {
+		return nil
+	}
+	mapper := c.Mapper()
+
+	var blocked []string
+	for _, obj := range objs {
+		gvk := obj.GroupVersionKind()
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			continue
+		}
+		resource := c.DynamicClient.Resource(mapping.Resource)
+		var live *unstructured.Unstructured
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			ns := obj.GetNamespace()
+			if ns == "" {
+				ns = cluster.GetTargetNamespace(namespace)
+			}
+			live, err = resource.Namespace(ns).Get(commandContext(), obj.GetName(), metav1.GetOptions{})
+		} else {
+			live, err = resource.Get(commandContext(), obj.GetName(), metav1.GetOptions{})
+		}
+		if err != nil {
+			continue
+		}
+		if err := guardDownsynced(qualifiedName(mapping.Resource, live), c.Name, live, force, warn); err != nil {
+			blocked = append(blocked, err.Error())
+		}
+	}
+	if len(blocked) > 0 {
+		return fmt.Errorf("%s", strings.Join(blocked, "\n"))
+	}
+	return nil
+}
+
+// warnGitOpsNamespaces warns about each namespace objs are written to that
+// Argo CD or Flux manages in the cluster, since what is applied there outside
+// Git is likely to clash with its syncs. Namespaces that are themselves in
+// objs are left to guardDownsyncedObjects.
+func warnGitOpsNamespaces(c cluster.ClusterInfo, objs []*unstructured.Unstructured, namespace string, warn io.Writer) {
+	if c.Client == nil {
+		return
+	}
+	inManifests := map[string]bool{}
+	for _, obj := range objs {
+		if obj.GetKind() == "Namespace" && obj.GroupVersionKind().Group == "" {
+			inManifests[obj.GetName()] = true
+		}
+	}
+	for _, ns := range objectNamespaces(objs, namespace) {
+		if inManifests[ns] {
+			continue
+		}
+		live, err := c.Client.CoreV1().Namespaces().Get(commandContext(), ns, metav1.GetOptions{})
+		if err != nil {
+			continue
+		}
+		if m, ok := gitops.ManagerOf(live); ok {
+			fmt.Fprintf(warn, "Warning: %s\n", gitops.Warning("namespace/"+ns, c.Name, m))
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/gitops"
+	"kubectl-multi/pkg/kubestellar"
+)
+
+func TestGuardDownsyncedObjects(t *testing.T) {
+	downsynced := testConfigMap("app", "old")
+	downsynced.SetLabels(map[string]string{kubestellar.OriginBindingLabel: "app-bpolicy"})
+	fluxManaged := testConfigMap("app", "old")
+	fluxManaged.SetLabels(map[string]string{gitops.FluxKustomizeNameLabel: "apps", gitops.FluxKustomizeNamespaceLabel: "flux-system"})
+	tests := []struct {
+		name     string
+		live     []runtime.Object
+		objs     []*unstructured.Unstructured
+		force    bool
+		wantErr  string
+		wantWarn string
+	}{
+		{
+			name: "not yet in the cluster",
+			objs: []*unstructured.Unstructured{testConfigMap("app", "new")},
+		},
+		{
+			name: "created imperatively",
+			live: []runtime.Object{testConfigMap("app", "old")},
+			objs: []*unstructured.Unstructured{testConfigMap("app", "new")},
+		},
+		{
+			name:    "downsynced",
+			live:    []runtime.Object{downsynced.DeepCopy()},
+			objs:    []*unstructured.Unstructured{testConfigMap("app", "new")},
+			wantErr: "configmap/app in cluster cluster1 is managed by KubeStellar (Binding app-bpolicy); direct changes are overwritten by the next downsync, change the object in the WDS instead (use --force to write it anyway)",
+		},
+		{
+			name:     "downsynced with force",
+			live:     []runtime.Object{downsynced.DeepCopy()},
+			objs:     []*unstructured.Unstructured{testConfigMap("app", "new")},
+			force:    true,
+			wantWarn: "Warning: configmap/app in cluster cluster1 is managed by KubeStellar (Binding app-bpolicy)",
+		},
+		{
+			name:     "managed by Flux",
+			live:     []runtime.Object{fluxManaged},
+			objs:     []*unstructured.Unstructured{testConfigMap("app", "new")},
+			wantWarn: "Warning: configmap/app in cluster cluster1 is managed by Flux Kustomization flux-system/apps; Flux reverts imperative changes on its next sync",
+		},
+		{
+			name: "unknown kind left to the write",
+			objs: []*unstructured.Unstructured{testObject("example.com/v1", "Widget", "default", "w")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := testClusterInfo(tt.live...)
+			var warn bytes.Buffer
+			err := guardDownsyncedObjects(c, tt.objs, "default", tt.force, &warn)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("guardDownsyncedObjects() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("guardDownsyncedObjects() error = %v", err)
+			}
+			if (tt.wantWarn == "" && warn.Len() > 0) || !strings.Contains(warn.String(), tt.wantWarn) {
+				t.Errorf("warnings = %q, want %q", warn.String(), tt.wantWarn)
+			}
+		})
+	}
+}
+
+func TestWarnGitOpsNamespaces(t *testing.T) {
+	argoNamespace := func(name string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Annotations: map[string]string{
+			gitops.ArgoCDTrackingAnnotation: "platform:/Namespace:/" + name,
+		}}}
+	}
+	c := cluster.ClusterInfo{Name: "cluster1", Client: fake.NewSimpleClientset(argoNamespace("prod"), argoNamespace("infra"), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev"}})}
+	infra := testObject("v1", "Namespace", "", "infra")
+	objs := []*unstructured.Unstructured{
+		testObject("v1", "ConfigMap", "prod", "a"),
+		testObject("v1", "ConfigMap", "dev", "b"),
+		testObject("v1", "ConfigMap", "infra", "c"),
+		infra,
+	}
+
+	var warn bytes.Buffer
+	warnGitOpsNamespaces(c, objs, "default", &warn)
+	want := "Warning: namespace/prod in cluster cluster1 is managed by Argo CD Application platform; Argo CD reverts imperative changes on its next sync, so change it in Git instead\n"
+	if warn.String() != want {
+		t.Errorf("warnings = %q, want %q", warn.String(), want)
+	}
+}
@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+// patchOptions holds the flags of patch
+type patchOptions struct {
+	Type    string
+	Patch   string
+	DryRun  string
+	Force   bool
+	Targets clusterTargets
+}
+
+// patchTypes maps the --type values to patch types
+var patchTypes = map[string]types.PatchType{
+	"strategic": types.StrategicMergePatchType,
+	"merge":     types.MergePatchType,
+	"json":      types.JSONPatchType,
+}
+
+func newPatchCommand() *cobra.Command {
+	var o patchOptions
+
+	cmd := &cobra.Command{
+		Use:   "patch (TYPE/NAME | TYPE NAME) -p PATCH [--type strategic|merge|json]",
+		Short: "Update field(s) of a resource across managed clusters",
+		Long: `Update field(s) of a resource across managed clusters.
+The patch, given as JSON or YAML, is sent to every selected cluster. The
+result of each cluster is printed separately: a cluster where the object is
+missing or the patch conflicts does not stop the others, but makes the command
+fail once all clusters were tried.
+
+Strategic merge patches only work for built-in types; use --type merge or
+--type json for custom resources.`,
+		Example: `# Scale nginx in every managed cluster
+kubectl multi patch deployment/nginx -n prod -p '{"spec":{"replicas":3}}'
+
+# Check a JSON patch against the API servers of the prod clusters only
+kubectl multi patch deployment nginx -n prod --cluster-selector env=prod --type json \
+  -p '[{"op":"replace","path":"/spec/template/spec/containers/0/image","value":"nginx:1.27"}]' --dry-run=server`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resourceType, name, err := parseTypeName(args)
+			if err != nil {
+				return err
+			}
+			patchType, patch, err := parsePatch(o.Type, o.Patch)
+			if err != nil {
+				return err
+			}
+			if err := util.ValidateDryRun(o.DryRun); err != nil {
+				return err
+			}
+			if o.DryRun == util.DryRunClient {
+				return fmt.Errorf("--dry-run=client is not supported by patch, use --dry-run=server")
+			}
+
+			kubeconfig, remoteCtx, _, namespace, _ := GetGlobalFlags()
+			var rec *audit.Recorder
+			if o.DryRun != util.DryRunServer {
+				rec = startAudit("patch")
+			}
+			err = handlePatchCommand(resourceType, name, patchType, patch, o, rec, kubeconfig, remoteCtx, namespace)
+			finishAudit(rec, err)
+			return err
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.Patch, "patch", "p", "", "the patch to be applied to the resource JSON file")
+	cmd.Flags().StringVar(&o.Type, "type", "strategic", "the type of patch being provided; one of [json merge strategic]")
+	cmd.Flags().StringVar(&o.DryRun, "dry-run", "none", "must be \"none\" or \"server\"")
+	cmd.Flags().BoolVar(&o.Force, "force", false, "patch objects that KubeStellar downsyncs to the cluster, although the next downsync reverts the change")
+	o.Targets.addFlags(cmd, "patch")
+
+	return cmd
+}
+
+// parsePatch checks the --type and converts the patch to JSON of the shape
+// the type expects
+func parsePatch(patchType, patch string) (types.PatchType, []byte, error) {
+	pt, ok := patchTypes[patchType]
+	if !ok {
+		return "", nil, fmt.Errorf("invalid --type %q, must be one of json, merge, strategic", patchType)
+	}
+	if patch == "" {
+		return "", nil, fmt.Errorf("must specify -p to patch")
+	}
+
+	var parsed interface{}
+	if err := yaml.Unmarshal([]byte(patch), &parsed); err != nil {
+		return "", nil, fmt.Errorf("unable to parse %q: %v", patch, err)
+	}
+	switch parsed.(type) {
+	case []interface{}:
+		if pt != types.JSONPatchType {
+			return "", nil, fmt.Errorf("a %s patch must be an object, a list of operations needs --type json", patchType)
+		}
+	case map[string]interface{}:
+		if pt == types.JSONPatchType {
+			return "", nil, fmt.Errorf("a json patch must be a list of operations")
+		}
+	default:
+		return "", nil, fmt.Errorf("unable to parse %q: not a JSON object or list", patch)
+	}
+	data, err := yaml.YAMLToJSON([]byte(patch))
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to parse %q: %v", patch, err)
+	}
+	return pt, data, nil
+}
+
+func handlePatchCommand(resourceType, name string, patchType types.PatchType, patch []byte, o patchOptions, rec *audit.Recorder, kubeconfig, remoteCtx, namespace string) error {
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	clusters, err = o.Targets.selectFrom(clusters, kubeconfig)
+	if err != nil {
+		return err
+	}
+	if len(clusters) == 0 {
+		return fmt.Errorf("no clusters discovered")
+	}
+	radius := blastRadius{Objects: fanOutTargets(clusters, remoteCtx), Namespaces: []string{cluster.GetTargetNamespace(namespace)}}
+	if err := guardFanOut(rec, radius, clusters, remoteCtx); err != nil {
+		return err
+	}
+	return patchClusters(clusters, remoteCtx, resourceType, name, namespace, patchType, patch, o.DryRun, o.Force, rec, os.Stdout)
+}
+
+// patchClusters sends the patch to every cluster but the ITS and prints each
+// cluster's result
+func patchClusters(clusters []cluster.ClusterInfo, itsContext, resourceType, name, namespace string, patchType types.PatchType, patch []byte, dryRun string, force bool, rec *audit.Recorder, out io.Writer) error {
+	trackFanOut(rec, fanOutTargets(clusters, itsContext))
+	failed, tried := 0, 0
+	for _, c := range clusters {
+		fmt.Fprintf(out, "=== Cluster: %s ===\n", c.Context)
+		if c.Context == itsContext {
+			fmt.Fprintf(out, "Cannot perform this operation on ITS (control) cluster: %s\n\n", c.Context)
+			continue
+		}
+		tried++
+		rec.Begin(c.Name)
+		result, err := patchInCluster(c, resourceType, name, namespace, patchType, patch, dryRun, force, rec)
+		rec.Record(c.Name, err)
+		switch {
+		case apierrors.IsConflict(err):
+			failed++
+			fmt.Fprintf(out, "Conflict: %v\n", err)
+		case err != nil:
+			failed++
+			fmt.Fprintf(out, "Error: %v\n", err)
+		default:
+			fmt.Fprintln(out, result)
+		}
+		fmt.Fprintln(out)
+	}
+	if failed > 0 {
+		return fmt.Errorf("patch failed in %d of %d clusters", failed, tried)
+	}
+	return nil
+}
+
+// patchInCluster patches one resource and returns a kubectl-style result line
+func patchInCluster(c cluster.ClusterInfo, resourceType, name, namespace string, patchType types.PatchType, patch []byte, dryRun string, force bool, rec *audit.Recorder) (string, error) {
+	if c.DynamicClient == nil || c.DiscoveryClient == nil {
+		return "", fmt.Errorf("no clients available for cluster %s", c.Name)
+	}
+	gvr, namespaced, err := util.DiscoverGVR(c.DiscoveryClient, resourceType)
+	if err != nil {
+		return "", err
+	}
+	var client dynamic.ResourceInterface = c.DynamicClient.Resource(gvr)
+	ns := ""
+	if namespaced {
+		ns = cluster.GetTargetNamespace(namespace)
+		client = c.DynamicClient.Resource(gvr).Namespace(ns)
+	}
+
+	live, err := client.Get(commandContext(), name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	ref := qualifiedName(gvr, live)
+	var warnings strings.Builder
+	if err := guardDownsynced(ref, c.Name, live, force, &warnings); err != nil {
+		return "", err
+	}
+
+	patch, err = util.ManagedPatch(patchType, patch, live)
+	if err != nil {
+		return "", err
+	}
+	opts := metav1.PatchOptions{FieldManager: util.FieldManager}
+	if dryRun == util.DryRunServer {
+		opts.DryRun = []string{metav1.DryRunAll}
+	}
+	patched, err := client.Patch(commandContext(), name, patchType, patch, opts)
+	if apierrors.IsUnsupportedMediaType(err) && patchType == types.StrategicMergePatchType {
+		return "", fmt.Errorf("strategic merge patch is not supported for %s, use --type merge or --type json", ref)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case dryRun == util.DryRunServer:
+		return warnings.String() + ref + " patched (server dry run)", nil
+	case reflect.DeepEqual(patched.Object, live.Object):
+		return warnings.String() + ref + " patched (no change)", nil
+	}
+	recordRestoreUndo(rec, c.Name, gvr, ns, live, dryRun)
+	return warnings.String() + ref + " patched", nil
+}
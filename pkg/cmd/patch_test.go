@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	clienttesting "k8s.io/client-go/testing"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+	"kubectl-multi/pkg/util"
+)
+
+func TestParsePatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		patchType string
+		patch     string
+		wantType  types.PatchType
+		want      string
+		wantErr   bool
+	}{
+		{name: "strategic json", patchType: "strategic", patch: `{"spec":{"replicas":3}}`, wantType: types.StrategicMergePatchType, want: `{"spec":{"replicas":3}}`},
+		{name: "merge yaml", patchType: "merge", patch: "data:\n  key: new\n", wantType: types.MergePatchType, want: `{"data":{"key":"new"}}`},
+		{name: "json operations", patchType: "json", patch: `[{"op":"remove","path":"/data/key"}]`, wantType: types.JSONPatchType, want: `[{"op":"remove","path":"/data/key"}]`},
+		{name: "operations without json type", patchType: "merge", patch: `[{"op":"remove","path":"/data/key"}]`, wantErr: true},
+		{name: "object with json type", patchType: "json", patch: `{"data":{}}`, wantErr: true},
+		{name: "scalar", patchType: "merge", patch: `3`, wantErr: true},
+		{name: "invalid", patchType: "merge", patch: `{"data":`, wantErr: true},
+		{name: "empty", patchType: "merge", wantErr: true},
+		{name: "unknown type", patchType: "apply", patch: `{}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotType, got, err := parsePatch(tt.patchType, tt.patch)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (gotType != tt.wantType || string(got) != tt.want) {
+				t.Errorf("parsePatch() = %s %s, want %s %s", gotType, got, tt.wantType, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatchClusters(t *testing.T) {
+	conflict := func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, "app", nil)
+	}
+	tests := []struct {
+		name      string
+		patchType types.PatchType
+		patch     string
+		dryRun    string
+		missing   bool
+		conflict  bool
+		managed   bool
+		applied   bool
+		force     bool
+		wantData  string
+		wantLines []string
+		wantUndo  int
+		wantErr   string
+	}{
+		{
+			name:      "merge patch",
+			patchType: types.MergePatchType,
+			patch:     `{"data":{"key":"new"}}`,
+			wantData:  "new",
+			wantLines: []string{"=== Cluster: cluster1 ===\nconfigmap/app patched\n", "=== Cluster: cluster2 ===\nconfigmap/app patched\n"},
+			wantUndo:  2,
+		},
+		{
+			name:      "json patch",
+			patchType: types.JSONPatchType,
+			patch:     `[{"op":"replace","path":"/data/key","value":"new"}]`,
+			wantData:  "new",
+			wantLines: []string{"=== Cluster: cluster2 ===\nconfigmap/app patched\n"},
+			wantUndo:  2,
+		},
+		{
+			name:      "strategic merge patch",
+			patchType: types.StrategicMergePatchType,
+			patch:     `{"data":{"key":"new"}}`,
+			wantData:  "new",
+			wantLines: []string{"=== Cluster: cluster1 ===\nconfigmap/app patched\n"},
+			wantUndo:  2,
+		},
+		{
+			name:      "no change",
+			patchType: types.MergePatchType,
+			patch:     `{"data":{"key":"old"}}`,
+			applied:   true,
+			wantData:  "old",
+			wantLines: []string{"configmap/app patched (no change)\n"},
+		},
+		{
+			name:      "server dry run",
+			patchType: types.MergePatchType,
+			patch:     `{"data":{"key":"new"}}`,
+			dryRun:    util.DryRunServer,
+			wantLines: []string{"=== Cluster: cluster1 ===\nconfigmap/app patched (server dry run)\n"},
+		},
+		{
+			name:      "missing in one cluster",
+			patchType: types.MergePatchType,
+			patch:     `{"data":{"key":"new"}}`,
+			missing:   true,
+			wantData:  "new",
+			wantLines: []string{"=== Cluster: cluster2 ===\nError: configmaps \"app\" not found\n", "=== Cluster: cluster1 ===\nconfigmap/app patched\n"},
+			wantUndo:  1,
+			wantErr:   "patch failed in 1 of 2 clusters",
+		},
+		{
+			name:      "conflict in one cluster",
+			patchType: types.MergePatchType,
+			patch:     `{"data":{"key":"new"}}`,
+			conflict:  true,
+			wantData:  "new",
+			wantLines: []string{"=== Cluster: cluster2 ===\nConflict: ", "=== Cluster: cluster1 ===\nconfigmap/app patched\n"},
+			wantUndo:  1,
+			wantErr:   "patch failed in 1 of 2 clusters",
+		},
+		{
+			name:      "downsynced object",
+			patchType: types.MergePatchType,
+			patch:     `{"data":{"key":"new"}}`,
+			managed:   true,
+			wantData:  "new",
+			wantLines: []string{"=== Cluster: cluster2 ===\nError: configmap/app in cluster cluster2 is managed by KubeStellar (Binding nginx-bpolicy)", "use --force"},
+			wantUndo:  1,
+			wantErr:   "patch failed in 1 of 2 clusters",
+		},
+		{
+			name:      "downsynced object with force",
+			patchType: types.MergePatchType,
+			patch:     `{"data":{"key":"new"}}`,
+			managed:   true,
+			force:     true,
+			wantData:  "new",
+			wantLines: []string{"=== Cluster: cluster2 ===\nWarning: configmap/app in cluster cluster2 is managed by KubeStellar (Binding nginx-bpolicy); direct changes are overwritten by the next downsync, change the object in the WDS instead\nconfigmap/app patched\n"},
+			wantUndo:  2,
+		},
+	}
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dryRun := tt.dryRun
+			if dryRun == "" {
+				dryRun = util.DryRunNone
+			}
+			cm1, cm := testConfigMap("app", "old"), testConfigMap("app", "old")
+			if tt.applied {
+				util.MarkManaged(cm1)
+				util.MarkManaged(cm)
+			}
+			c1, dyn1 := testClusterInfo(cm1)
+			if tt.managed {
+				cm.SetLabels(map[string]string{kubestellar.OriginBindingLabel: "nginx-bpolicy"})
+			}
+			objs := []runtime.Object{cm}
+			if tt.missing {
+				objs = nil
+			}
+			c2, dyn2 := testClusterInfo(objs...)
+			serveConfigMapPatches(dyn1)
+			serveConfigMapPatches(dyn2)
+			if tt.conflict {
+				dyn2.PrependReactor("patch", "configmaps", conflict)
+			}
+			its, _ := testClusterInfo()
+			c1.Context = "cluster1"
+			c2.Name, c2.Context = "cluster2", "cluster2"
+			its.Name, its.Context = "its1", "its1"
+
+			var out bytes.Buffer
+			rec := audit.Start("patch", nil)
+			err := patchClusters([]cluster.ClusterInfo{c1, c2, its}, "its1", "configmaps", "app", "default", tt.patchType, []byte(tt.patch), dryRun, tt.force, rec, &out)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("patchClusters() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("patchClusters() error = %v", err)
+			}
+			for _, line := range append(tt.wantLines, "Cannot perform this operation on ITS (control) cluster: its1") {
+				if !strings.Contains(out.String(), line) {
+					t.Errorf("output %q does not contain %q", out.String(), line)
+				}
+			}
+			if got := len(rec.Finish(err).Undo); got != tt.wantUndo {
+				t.Errorf("undo steps = %d, want %d", got, tt.wantUndo)
+			}
+			if tt.wantData == "" {
+				return
+			}
+			obj, err := c1.DynamicClient.Resource(gvr).Namespace("default").Get(context.TODO(), "app", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, _, _ := unstructured.NestedString(obj.Object, "data", "key"); got != tt.wantData {
+				t.Errorf("data in cluster1 = %q, want %q", got, tt.wantData)
+			}
+			if !util.IsManaged(obj) {
+				t.Errorf("annotations in cluster1 = %v, want %s", obj.GetAnnotations(), util.AppliedByAnnotation)
+			}
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"kubectl-multi/pkg/config"
+	"kubectl-multi/pkg/util"
+)
+
+// profileName is the --profile flag choosing a saved profile for one command
+var profileName string
+
+// profileGroups are the cluster groups of a profile chosen with --profile;
+// nil when the current profile, whose groups are the config's, applies
+var profileGroups map[string][]string
+
+func newProfileCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profile",
+		Short: "Save and switch between fleet profiles",
+		Long: `Save the flags that choose a KubeStellar fleet - kubeconfig, ITS and WDS
+contexts, cluster filters - together with the cluster groups as a named
+profile in the plugin config ($KUBECTL_MULTI_CONFIG or
+~/.kube/kubectl-multi.yaml), and switch between installations with one
+command.
+
+The current profile supplies every one of these flags that is not given on
+the command line. --profile NAME uses another profile for a single command.`,
+	}
+	cmd.AddCommand(newProfileSaveCommand())
+	cmd.AddCommand(newProfileListCommand())
+	cmd.AddCommand(newProfileUseCommand())
+	cmd.AddCommand(newProfileDeleteCommand())
+	return cmd
+}
+
+func newProfileSaveCommand() *cobra.Command {
+	var targets clusterTargets
+
+	cmd := &cobra.Command{
+		Use:   "save NAME",
+		Short: "Save the current fleet flags and cluster groups as a profile",
+		Example: `# Production fleet behind its own kubeconfig, ITS and WDS
+kubectl multi profile save prod --kubeconfig ~/.kube/prod.yaml --its its-prod --wds wds-prod
+
+# Staging, limited to the clusters labeled env=stage
+kubectl multi profile save stage --its its1 --cluster-selector env=stage`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return handleProfileSave(args[0], targets)
+		},
+	}
+	targets.addFlags(cmd, "target by default")
+	return cmd
+}
+
+func newProfileListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List saved profiles, marking the current one",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return handleProfileList()
+		},
+	}
+}
+
+func newProfileUseCommand() *cobra.Command {
+	var none bool
+
+	cmd := &cobra.Command{
+		Use:   "use NAME",
+		Short: "Make a profile current",
+		Example: `# Switch to the production fleet
+kubectl multi profile use prod
+
+# Go back to plain flags and defaults
+kubectl multi profile use --none`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if none {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := ""
+			if !none {
+				name = args[0]
+			}
+			return handleProfileUse(name)
+		},
+	}
+	cmd.Flags().BoolVar(&none, "none", false, "stop using a profile; the cluster groups stay as they are")
+	return cmd
+}
+
+func newProfileDeleteCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete NAME",
+		Short: "Delete a profile",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return handleProfileDelete(args[0])
+		},
+	}
+}
+
+func handleProfileSave(name string, targets clusterTargets) error {
+	if name == "" || strings.ContainsAny(name, ", /") {
+		return fmt.Errorf("invalid profile name %q", name)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	cfg.SaveProfile(name, config.Profile{
+		Kubeconfig:      kubeconfig,
+		RemoteContext:   remoteCtx,
+		ITS:             itsNames,
+		AllITS:          allITS,
+		WDSContext:      wdsCtx,
+		WDS:             wdsNames,
+		Clusters:        targets.Names,
+		ClusterSelector: targets.Selector,
+	})
+	if profileGroups != nil {
+		// Saving under --profile keeps that profile's groups, not the current ones
+		p := cfg.Profiles[name]
+		p.Groups = profileGroups
+		cfg.Profiles[name] = p
+	}
+	if err := cfg.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("profile %q saved\n", name)
+	return nil
+}
+
+func handleProfileList() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(util.GetOutputStream(), 0, 0, 2, ' ', 0)
+	defer tw.Flush()
+
+	if len(cfg.Profiles) == 0 {
+		fmt.Fprintf(tw, "No profiles saved.\n")
+		return nil
+	}
+	fmt.Fprintf(tw, "CURRENT\tNAME\tKUBECONFIG\tITS\tWDS\tCLUSTERS\tGROUPS\n")
+	for _, name := range cfg.ProfileNames() {
+		p := cfg.Profiles[name]
+		current := ""
+		if name == cfg.CurrentProfile {
+			current = "*"
+		}
+		its := strings.Join(p.ITS, ",")
+		if p.AllITS {
+			its = "<all>"
+		} else if its == "" {
+			its = p.RemoteContext
+		}
+		wds := strings.Join(p.WDS, ",")
+		if wds == "" {
+			wds = p.WDSContext
+		}
+		clusters := strings.Join(p.Clusters, ",")
+		if p.ClusterSelector != "" {
+			clusters = strings.TrimPrefix(clusters+" "+p.ClusterSelector, " ")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%d\n",
+			current, name, orNone(p.Kubeconfig), orNone(its), orNone(wds), orNone(clusters), len(p.Groups))
+	}
+	return nil
+}
+
+func handleProfileUse(name string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		cfg.CurrentProfile = ""
+	} else if err := cfg.UseProfile(name); err != nil {
+		return err
+	}
+	if err := cfg.Save(); err != nil {
+		return err
+	}
+	if name == "" {
+		fmt.Println("no profile in use")
+		return nil
+	}
+	fmt.Printf("switched to profile %q\n", name)
+	return nil
+}
+
+func handleProfileDelete(name string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if _, exists := cfg.Profiles[name]; !exists {
+		return fmt.Errorf("profile %q is not defined", name)
+	}
+	delete(cfg.Profiles, name)
+	if cfg.CurrentProfile == name {
+		cfg.CurrentProfile = ""
+	}
+	if err := cfg.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("profile %q deleted\n", name)
+	return nil
+}
+
+// applyProfile fills the fleet flags the user did not give from the
+// --profile or current profile. It runs before every command.
+func applyProfile(cmd *cobra.Command) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	name := profileName
+	if name == "" {
+		name = cfg.CurrentProfile
+	}
+	if name == "" {
+		return nil
+	}
+	p, ok := cfg.Profiles[name]
+	if !ok {
+		return fmt.Errorf("profile %q is not defined (see 'kubectl multi profile list')", name)
+	}
+	if name != cfg.CurrentProfile {
+		profileGroups = p.Groups
+		if profileGroups == nil {
+			profileGroups = map[string][]string{}
+		}
+	}
+
+	global := rootCmd.PersistentFlags()
+	values := []struct {
+		flags *pflag.FlagSet
+		name  string
+		value string
+	}{
+		{global, "kubeconfig", p.Kubeconfig},
+		{global, "remote-context", p.RemoteContext},
+		{global, "its", strings.Join(p.ITS, ",")},
+		{global, "all-its", boolFlagValue(p.AllITS)},
+		{global, "wds-context", p.WDSContext},
+		{global, "wds", strings.Join(p.WDS, ",")},
+		{cmd.Flags(), "clusters", strings.Join(p.Clusters, ",")},
+		{cmd.Flags(), "cluster-selector", p.ClusterSelector},
+	}
+	for _, v := range values {
+		f := v.flags.Lookup(v.name)
+		if f == nil || f.Changed || v.value == "" {
+			continue
+		}
+		if err := v.flags.Set(v.name, v.value); err != nil {
+			return fmt.Errorf("profile %q: invalid %s: %v", name, v.name, err)
+		}
+	}
+	return nil
+}
+
+// boolFlagValue returns "true" for true and "" (leave the flag alone) for false
+func boolFlagValue(b bool) string {
+	if b {
+		return strconv.FormatBool(b)
+	}
+	return ""
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"kubectl-multi/pkg/config"
+)
+
+// resetFleetFlags restores the global fleet flags a profile may have set
+func resetFleetFlags() {
+	kubeconfig, remoteCtx, itsNames, allITS, wdsCtx, wdsNames = "", "its1", nil, false, "wds1", nil
+	profileName, profileGroups = "", nil
+	for _, name := range []string{"kubeconfig", "remote-context", "its", "all-its", "wds-context", "wds"} {
+		rootCmd.PersistentFlags().Lookup(name).Changed = false
+	}
+}
+
+func TestApplyProfile(t *testing.T) {
+	t.Setenv(config.EnvConfigPath, filepath.Join(t.TempDir(), "config.yaml"))
+	resetFleetFlags()
+	defer resetFleetFlags()
+
+	cfg := &config.Config{Groups: map[string][]string{"edge": {"edge1"}}}
+	cfg.SaveProfile("prod", config.Profile{
+		Kubeconfig:    "/tmp/prod.yaml",
+		RemoteContext: "its-prod",
+		ITS:           []string{"its-a", "its-b"},
+		WDSContext:    "wds-prod",
+		Clusters:      []string{"@edge"},
+	})
+	cfg.SaveProfile("stage", config.Profile{RemoteContext: "its-stage", ClusterSelector: "env=stage"})
+	cfg.Profiles["stage"] = config.Profile{RemoteContext: "its-stage", ClusterSelector: "env=stage", Groups: map[string][]string{"canary": {"stage1"}}}
+	if err := cfg.UseProfile("prod"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The current profile fills every flag the user did not give
+	var targets clusterTargets
+	cmd := &cobra.Command{Use: "test"}
+	targets.addFlags(cmd, "target")
+	rootCmd.PersistentFlags().Set("wds-context", "wds-cli")
+	if err := applyProfile(cmd); err != nil {
+		t.Fatalf("applyProfile() error = %v", err)
+	}
+	if kubeconfig != "/tmp/prod.yaml" || remoteCtx != "its-prod" || !reflect.DeepEqual(itsNames, []string{"its-a", "its-b"}) {
+		t.Errorf("globals = %q %q %v, want the prod profile", kubeconfig, remoteCtx, itsNames)
+	}
+	if wdsCtx != "wds-cli" {
+		t.Errorf("wds-context = %q, want the command line value", wdsCtx)
+	}
+	if !reflect.DeepEqual(targets.Names, []string{"@edge"}) {
+		t.Errorf("--clusters = %v, want [@edge]", targets.Names)
+	}
+	if profileGroups != nil {
+		t.Errorf("profile groups = %v, want the config's groups", profileGroups)
+	}
+
+	// --profile picks another profile, with its own groups, for one command
+	resetFleetFlags()
+	profileName = "stage"
+	targets = clusterTargets{}
+	cmd = &cobra.Command{Use: "test"}
+	targets.addFlags(cmd, "target")
+	if err := applyProfile(cmd); err != nil {
+		t.Fatalf("applyProfile() error = %v", err)
+	}
+	if remoteCtx != "its-stage" || targets.Selector != "env=stage" {
+		t.Errorf("remote-context %q, cluster-selector %q, want the stage profile", remoteCtx, targets.Selector)
+	}
+	names, err := expandClusterNames([]string{"@canary"})
+	if err != nil || !reflect.DeepEqual(names, []string{"stage1"}) {
+		t.Errorf("expandClusterNames(@canary) = %v, %v, want [stage1]", names, err)
+	}
+
+	profileName = "missing"
+	if err := applyProfile(&cobra.Command{Use: "test"}); err == nil {
+		t.Error("applyProfile() succeeded with an undefined profile")
+	}
+}
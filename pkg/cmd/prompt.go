@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// prompter asks questions on a terminal for the interactive commands
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// ask prints the question and returns the trimmed answer
+func (p *prompter) ask(question string) (string, error) {
+	fmt.Fprint(p.out, question)
+	line, err := p.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", fmt.Errorf("no answer given")
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// pick asks for items out of n until the answer parses
+func (p *prompter) pick(question string, n int, allowNone bool) ([]int, error) {
+	for {
+		answer, err := p.ask(question)
+		if err != nil {
+			return nil, err
+		}
+		picked, err := parseSelection(answer, n)
+		if err == nil && len(picked) == 0 && !allowNone {
+			err = fmt.Errorf("choose at least one")
+		}
+		if err == nil {
+			return picked, nil
+		}
+		fmt.Fprintf(p.out, "%v\n", err)
+	}
+}
+
+// confirm asks a yes/no question, defaulting to no
+func (p *prompter) confirm(question string) (bool, error) {
+	answer, err := p.ask(question + " [y/N]: ")
+	if err != nil {
+		return false, err
+	}
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes", nil
+}
+
+// parseSelection parses a selection such as "1,3-5" or "all" among n items
+// into sorted zero-based indexes
+func parseSelection(input string, n int) ([]int, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return nil, nil
+	}
+	if input == "all" || input == "*" {
+		picked := make([]int, n)
+		for i := range picked {
+			picked[i] = i
+		}
+		return picked, nil
+	}
+
+	seen := map[int]bool{}
+	for _, part := range strings.Split(input, ",") {
+		part = strings.TrimSpace(part)
+		from, to, isRange := strings.Cut(part, "-")
+		first, err := strconv.Atoi(strings.TrimSpace(from))
+		last := first
+		if err == nil && isRange {
+			last, err = strconv.Atoi(strings.TrimSpace(to))
+		}
+		if err != nil || first < 1 || last > n || first > last {
+			return nil, fmt.Errorf("invalid choice %q, expected numbers between 1 and %d", part, n)
+		}
+		for i := first; i <= last; i++ {
+			seen[i-1] = true
+		}
+	}
+	picked := make([]int, 0, len(seen))
+	for i := range seen {
+		picked = append(picked, i)
+	}
+	sort.Ints(picked)
+	return picked, nil
+}
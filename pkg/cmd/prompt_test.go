@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"bufio"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSelection(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []int
+		wantErr bool
+	}{
+		{name: "empty", input: " "},
+		{name: "all", input: "all", want: []int{0, 1, 2, 3}},
+		{name: "list and range", input: "4, 1-2,2", want: []int{0, 1, 3}},
+		{name: "out of range", input: "5", wantErr: true},
+		{name: "zero", input: "0", wantErr: true},
+		{name: "reversed range", input: "3-1", wantErr: true},
+		{name: "not a number", input: "one", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSelection(tt.input, 4)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSelection() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && len(got)+len(tt.want) > 0 && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSelection() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrompterPick(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		allowNone bool
+		want      []int
+		wantErr   bool
+	}{
+		{name: "first answer", input: "2\n", want: []int{1}},
+		{name: "retries invalid answer", input: "9\n1-2\n", want: []int{0, 1}},
+		{name: "retries empty answer", input: "\n3\n", want: []int{2}},
+		{name: "empty allowed", input: "\n", allowNone: true},
+		{name: "input ends", input: "9\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &prompter{in: bufio.NewReader(strings.NewReader(tt.input)), out: io.Discard}
+			got, err := p.pick("? ", 3, tt.allowNone)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("pick() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got)+len(tt.want) > 0 && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pick() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+// quotaUsage is the fleet-wide usage of one quota resource in one namespace
+type quotaUsage struct {
+	Namespace     string   `json:"namespace"`
+	Resource      string   `json:"resource"`
+	Hard          string   `json:"hard"`
+	Used          string   `json:"used"`
+	Remaining     string   `json:"remaining"`
+	Utilization   float64  `json:"utilization"`
+	OverThreshold bool     `json:"overThreshold"`
+	Clusters      []string `json:"clusters"`
+
+	hard resource.Quantity
+	used resource.Quantity
+}
+
+func newQuotaReportCommand() *cobra.Command {
+	var outputFormat string
+	var threshold float64
+	var targets clusterTargets
+	var reach reachability
+
+	cmd := &cobra.Command{
+		Use:   "quota-report",
+		Short: "Summarize ResourceQuota usage per namespace across managed clusters",
+		Long: `Sum the hard limits and used amounts of every ResourceQuota across all
+managed clusters, grouped by namespace and resource, and report the remaining
+headroom. Namespaces whose utilization is at or above --threshold are flagged.
+The quotas of the ITS are left out, since workloads do not run there.`,
+		Example: `# Fleet quota report for all namespaces
+kubectl multi quota-report -A
+
+# Flag namespaces above 90% utilization and print JSON
+kubectl multi quota-report -A --threshold 90 -o json
+
+# Only the production clusters
+kubectl multi quota-report -A --cluster-selector env=prod
+
+# Quota usage as CSV for a spreadsheet
+kubectl multi quota-report -A -o csv > quota.csv`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch outputFormat {
+			case "", "json", util.TableFormatCSV, util.TableFormatMarkdown:
+			default:
+				return fmt.Errorf("unsupported output format %q, must be one of json|csv|markdown", outputFormat)
+			}
+			kubeconfig, remoteCtx, _, namespace, allNamespaces := GetGlobalFlags()
+			return handleQuotaReportCommand(outputFormat, threshold, targets, reach, kubeconfig, remoteCtx, namespace, allNamespaces)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "output format (json|csv|markdown)")
+	cmd.Flags().Float64Var(&threshold, "threshold", 80, "utilization percentage at which a namespace is flagged")
+	targets.addFlags(cmd, "report on")
+	targets.addPlacementFlag(cmd, "report on")
+	reach.addFlags(cmd)
+
+	return cmd
+}
+
+func handleQuotaReportCommand(outputFormat string, threshold float64, targets clusterTargets, reach reachability, kubeconfig, remoteCtx, namespace string, allNamespaces bool) error {
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	clusters, err = targets.selectFrom(clusters, kubeconfig)
+	if err != nil {
+		return err
+	}
+	clusters, err = reach.filter(workloadClusters(clusters, remoteCtx))
+	if err != nil {
+		return err
+	}
+	defer printClusterIssuesToStderr()
+
+	usages := aggregateQuotas(clusters, namespace, allNamespaces, threshold)
+
+	if outputFormat == "json" {
+		if usages == nil {
+			usages = []*quotaUsage{}
+		}
+		data, err := json.MarshalIndent(usages, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode quota report: %v", err)
+		}
+		fmt.Fprintln(util.GetOutputStream(), string(data))
+		return nil
+	}
+
+	tw := util.NewTableWriter(util.GetOutputStream(), outputFormat)
+	defer tw.Flush()
+
+	if len(usages) == 0 {
+		reportNoResources(namespace, allNamespaces)
+		return nil
+	}
+
+	fmt.Fprintf(tw, "NAMESPACE\tRESOURCE\tHARD\tUSED\tREMAINING\tUTILIZATION\tCLUSTERS\tSTATUS\n")
+	for _, u := range usages {
+		status := "OK"
+		if u.OverThreshold {
+			status = "OVER THRESHOLD"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%.1f%%\t%d\t%s\n",
+			u.Namespace, u.Resource, u.Hard, u.Used, u.Remaining, u.Utilization, len(u.Clusters), status)
+	}
+	return nil
+}
+
+// clusterQuotas are the ResourceQuotas listed from one cluster
+type clusterQuotas struct {
+	cluster string
+	quotas  []corev1.ResourceQuota
+}
+
+// aggregateQuotas sums the status of every ResourceQuota per namespace and resource name
+func aggregateQuotas(clusters []cluster.ClusterInfo, namespace string, allNamespaces bool, threshold float64) []*quotaUsage {
+	var listed []clusterQuotas
+
+	for _, clusterInfo := range clusters {
+		if clusterInfo.Client == nil {
+			continue
+		}
+
+		targetNS := cluster.GetTargetNamespace(namespace)
+		if allNamespaces {
+			targetNS = ""
+		}
+
+		quotas, err := clusterInfo.Client.CoreV1().ResourceQuotas(targetNS).List(commandContext(), metav1.ListOptions{})
+		if err != nil {
+			noteClusterIssue(clusterInfo.Name, fmt.Sprintf("failed to list resourcequotas: %v", err))
+			continue
+		}
+		listed = append(listed, clusterQuotas{cluster: clusterInfo.Name, quotas: quotas.Items})
+	}
+
+	return summarizeQuotas(listed, threshold)
+}
+
+// summarizeQuotas adds up quota status across clusters, sorted by namespace and resource
+func summarizeQuotas(listed []clusterQuotas, threshold float64) []*quotaUsage {
+	byKey := make(map[string]*quotaUsage)
+
+	for _, cq := range listed {
+		for _, rq := range cq.quotas {
+			for name, hard := range rq.Status.Hard {
+				key := rq.Namespace + "/" + string(name)
+				u, ok := byKey[key]
+				if !ok {
+					u = &quotaUsage{Namespace: rq.Namespace, Resource: string(name)}
+					byKey[key] = u
+				}
+				u.hard.Add(hard)
+				if used, ok := rq.Status.Used[name]; ok {
+					u.used.Add(used)
+				}
+				u.Clusters = appendUnique(u.Clusters, cq.cluster)
+			}
+		}
+	}
+
+	var usages []*quotaUsage
+	for _, u := range byKey {
+		remaining := u.hard.DeepCopy()
+		remaining.Sub(u.used)
+
+		u.Hard = u.hard.String()
+		u.Used = u.used.String()
+		u.Remaining = remaining.String()
+		u.Utilization = quotaUtilization(u.hard, u.used)
+		u.OverThreshold = u.Utilization >= threshold
+		usages = append(usages, u)
+	}
+
+	sort.Slice(usages, func(i, j int) bool {
+		if usages[i].Namespace != usages[j].Namespace {
+			return usages[i].Namespace < usages[j].Namespace
+		}
+		return usages[i].Resource < usages[j].Resource
+	})
+	return usages
+}
+
+// quotaUtilization returns used as a percentage of hard
+func quotaUtilization(hard, used resource.Quantity) float64 {
+	if hard.IsZero() {
+		if used.IsZero() {
+			return 0
+		}
+		return 100
+	}
+	return float64(used.MilliValue()) / float64(hard.MilliValue()) * 100
+}
+
+// appendUnique appends value to list unless it is already present
+func appendUnique(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
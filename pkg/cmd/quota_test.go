@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func resourceQuota(namespace string, hard, used corev1.ResourceList) corev1.ResourceQuota {
+	return corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "quota", Namespace: namespace},
+		Status:     corev1.ResourceQuotaStatus{Hard: hard, Used: used},
+	}
+}
+
+func TestSummarizeQuotas(t *testing.T) {
+	type row struct {
+		namespace, resource, hard, used, remaining string
+		utilization                                float64
+		over                                       bool
+		clusters                                   []string
+	}
+	tests := []struct {
+		name      string
+		listed    []clusterQuotas
+		threshold float64
+		want      []row
+	}{
+		{
+			name:      "nothing listed",
+			threshold: 80,
+			want:      nil,
+		},
+		{
+			name: "summed across clusters",
+			listed: []clusterQuotas{
+				{cluster: "cluster1", quotas: []corev1.ResourceQuota{
+					resourceQuota("team-a", corev1.ResourceList{"pods": resource.MustParse("10")}, corev1.ResourceList{"pods": resource.MustParse("4")}),
+				}},
+				{cluster: "cluster2", quotas: []corev1.ResourceQuota{
+					resourceQuota("team-a", corev1.ResourceList{"pods": resource.MustParse("10")}, corev1.ResourceList{"pods": resource.MustParse("12")}),
+				}},
+			},
+			threshold: 80,
+			want: []row{
+				{"team-a", "pods", "20", "16", "4", 80, true, []string{"cluster1", "cluster2"}},
+			},
+		},
+		{
+			name: "sorted by namespace then resource",
+			listed: []clusterQuotas{
+				{cluster: "cluster1", quotas: []corev1.ResourceQuota{
+					resourceQuota("team-b", corev1.ResourceList{"requests.cpu": resource.MustParse("2")}, corev1.ResourceList{"requests.cpu": resource.MustParse("500m")}),
+					resourceQuota("team-a", corev1.ResourceList{
+						"requests.memory": resource.MustParse("1Gi"),
+						"pods":            resource.MustParse("5"),
+					}, nil),
+				}},
+			},
+			threshold: 80,
+			want: []row{
+				{"team-a", "pods", "5", "0", "5", 0, false, []string{"cluster1"}},
+				{"team-a", "requests.memory", "1Gi", "0", "1Gi", 0, false, []string{"cluster1"}},
+				{"team-b", "requests.cpu", "2", "500m", "1500m", 25, false, []string{"cluster1"}},
+			},
+		},
+		{
+			name: "zero hard limit in use",
+			listed: []clusterQuotas{
+				{cluster: "cluster1", quotas: []corev1.ResourceQuota{
+					resourceQuota("team-a", corev1.ResourceList{"services": resource.MustParse("0")}, corev1.ResourceList{"services": resource.MustParse("1")}),
+				}},
+			},
+			threshold: 100,
+			want: []row{
+				{"team-a", "services", "0", "1", "-1", 100, true, []string{"cluster1"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []row
+			for _, u := range summarizeQuotas(tt.listed, tt.threshold) {
+				got = append(got, row{u.Namespace, u.Resource, u.Hard, u.Used, u.Remaining, u.Utilization, u.OverThreshold, u.Clusters})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("summarizeQuotas() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuotaUtilization(t *testing.T) {
+	tests := []struct {
+		hard, used string
+		want       float64
+	}{
+		{"10", "5", 50},
+		{"1", "250m", 25},
+		{"2Gi", "2Gi", 100},
+		{"0", "0", 0},
+		{"0", "1", 100},
+	}
+	for _, tt := range tests {
+		if got := quotaUtilization(resource.MustParse(tt.hard), resource.MustParse(tt.used)); got != tt.want {
+			t.Errorf("quotaUtilization(%s, %s) = %v, want %v", tt.hard, tt.used, got, tt.want)
+		}
+	}
+}
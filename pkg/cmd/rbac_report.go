@@ -0,0 +1,338 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+// rbacGrantRow is what one subject may do to one resource in a namespace,
+// per cluster
+type rbacGrantRow struct {
+	Subject  string `json:"subject"`
+	Resource string `json:"resource"`
+	// Verbs and Bindings are keyed by cluster; a cluster granting nothing is absent
+	Verbs    map[string][]string `json:"verbs"`
+	Bindings map[string][]string `json:"bindings"`
+	// Differs is set when the clusters do not all grant the same verbs
+	Differs bool `json:"differs"`
+}
+
+// clusterRBAC are the RBAC objects of one cluster that affect a namespace
+type clusterRBAC struct {
+	roles               []rbacv1.Role
+	clusterRoles        []rbacv1.ClusterRole
+	roleBindings        []rbacv1.RoleBinding
+	clusterRoleBindings []rbacv1.ClusterRoleBinding
+}
+
+// rbacGrant are the verbs one cluster grants a subject on a resource and the
+// bindings granting them
+type rbacGrant struct {
+	verbs    []string
+	bindings []string
+}
+
+// rbacGrants are the grants of one cluster, by subject and resource
+type rbacGrants map[[2]string]*rbacGrant
+
+func newRBACReportCommand() *cobra.Command {
+	var outputFormat string
+	var diffOnly, includeSystem bool
+	var targets clusterTargets
+	var reach reachability
+
+	cmd := &cobra.Command{
+		Use:   "rbac-report",
+		Short: "Show who may do what in a namespace across managed clusters",
+		Long: `Resolve the RoleBindings of a namespace and the ClusterRoleBindings of
+every managed cluster to the rules of the Roles and ClusterRoles they
+reference, and print the verbs each subject is granted on each resource of
+the namespace, side by side per cluster. Rows whose verbs are not the same in
+every cluster are marked DIFFERS.
+
+Subjects named system:* and the service accounts of kube-system are left out
+unless --include-system is set.`,
+		Example: `# Effective grants in the prod namespace
+kubectl multi rbac-report -n prod
+
+# Only what differs between the clusters
+kubectl multi rbac-report -n prod --diff-only
+
+# Including the grants of the system components, as JSON
+kubectl multi rbac-report -n prod --include-system -o json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch outputFormat {
+			case "", "json", util.TableFormatCSV, util.TableFormatMarkdown:
+			default:
+				return fmt.Errorf("unsupported output format %q, must be one of json|csv|markdown", outputFormat)
+			}
+			kubeconfig, remoteCtx, _, namespace, allNamespaces := GetGlobalFlags()
+			if allNamespaces {
+				return fmt.Errorf("rbac-report covers one namespace, use -n instead of --all-namespaces")
+			}
+			return handleRBACReportCommand(outputFormat, diffOnly, includeSystem, targets, reach, kubeconfig, remoteCtx, cluster.GetTargetNamespace(namespace))
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "output format (json|csv|markdown)")
+	cmd.Flags().BoolVar(&diffOnly, "diff-only", false, "only show grants that differ between clusters")
+	cmd.Flags().BoolVar(&includeSystem, "include-system", false, "include system:* subjects and kube-system service accounts")
+	targets.addFlags(cmd, "report on")
+	reach.addFlags(cmd)
+
+	return cmd
+}
+
+func handleRBACReportCommand(outputFormat string, diffOnly, includeSystem bool, targets clusterTargets, reach reachability, kubeconfig, remoteCtx, namespace string) error {
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	clusters, err = targets.selectFrom(clusters, kubeconfig)
+	if err != nil {
+		return err
+	}
+	clusters, err = reach.filter(clusters)
+	if err != nil {
+		return err
+	}
+	defer printClusterIssuesToStderr()
+
+	var names []string
+	perCluster := map[string]rbacGrants{}
+	for _, c := range clusters {
+		if c.Client == nil {
+			continue
+		}
+		objs, err := listClusterRBAC(commandContext(), c, namespace)
+		if err != nil {
+			noteClusterIssue(c.Name, err.Error())
+			continue
+		}
+		grants, missing := namespaceGrants(namespace, objs, includeSystem)
+		for _, m := range missing {
+			noteClusterIssue(c.Name, m)
+		}
+		names = append(names, c.Name)
+		perCluster[c.Name] = grants
+	}
+
+	rows := rbacReportRows(names, perCluster)
+	if diffOnly {
+		rows = slices.DeleteFunc(rows, func(r rbacGrantRow) bool { return !r.Differs })
+	}
+
+	if outputFormat == "json" {
+		return printJSONArray(rows)
+	}
+	if len(rows) == 0 {
+		if diffOnly {
+			fmt.Fprintf(os.Stderr, "No RBAC differences in namespace %s.\n", namespace)
+		} else {
+			fmt.Fprintf(os.Stderr, "No RBAC grants found in namespace %s.\n", namespace)
+		}
+		return nil
+	}
+
+	tw := util.NewTableWriter(util.GetOutputStream(), outputFormat)
+	defer tw.Flush()
+	fmt.Fprintf(tw, "SUBJECT\tRESOURCE\t%s\tSTATUS\n", strings.ToUpper(strings.Join(names, "\t")))
+	for _, r := range rows {
+		cells := make([]string, len(names))
+		for i, name := range names {
+			cells[i] = "-"
+			if verbs := r.Verbs[name]; len(verbs) > 0 {
+				cells[i] = strings.Join(verbs, ",")
+			}
+		}
+		status := "same"
+		if r.Differs {
+			status = "DIFFERS"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Subject, r.Resource, strings.Join(cells, "\t"), status)
+	}
+	return nil
+}
+
+// listClusterRBAC reads the Roles and RoleBindings of a namespace and the
+// cluster-wide ClusterRoles and ClusterRoleBindings of a cluster
+func listClusterRBAC(ctx context.Context, c cluster.ClusterInfo, namespace string) (clusterRBAC, error) {
+	var objs clusterRBAC
+	rbac := c.Client.RbacV1()
+	roles, err := rbac.Roles(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return objs, fmt.Errorf("failed to list roles: %v", err)
+	}
+	roleBindings, err := rbac.RoleBindings(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return objs, fmt.Errorf("failed to list rolebindings: %v", err)
+	}
+	clusterRoles, err := rbac.ClusterRoles().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return objs, fmt.Errorf("failed to list clusterroles: %v", err)
+	}
+	clusterRoleBindings, err := rbac.ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return objs, fmt.Errorf("failed to list clusterrolebindings: %v", err)
+	}
+	objs.roles = roles.Items
+	objs.roleBindings = roleBindings.Items
+	objs.clusterRoles = clusterRoles.Items
+	objs.clusterRoleBindings = clusterRoleBindings.Items
+	return objs, nil
+}
+
+// namespaceGrants resolves the bindings of a cluster to the verbs they grant
+// each subject on the resources of namespace. It also returns a message for
+// every binding whose role does not exist.
+func namespaceGrants(namespace string, objs clusterRBAC, includeSystem bool) (rbacGrants, []string) {
+	roles := map[string][]rbacv1.PolicyRule{}
+	for _, r := range objs.roles {
+		roles[r.Name] = r.Rules
+	}
+	clusterRoles := map[string][]rbacv1.PolicyRule{}
+	for _, r := range objs.clusterRoles {
+		clusterRoles[r.Name] = r.Rules
+	}
+
+	grants := rbacGrants{}
+	var missing []string
+	bind := func(binding string, bindingNS string, ref rbacv1.RoleRef, subjects []rbacv1.Subject) {
+		rules, ok := clusterRoles[ref.Name]
+		if ref.Kind == "Role" {
+			rules, ok = roles[ref.Name]
+		}
+		if !ok {
+			missing = append(missing, fmt.Sprintf("%s references missing %s %s", binding, strings.ToLower(ref.Kind), ref.Name))
+			return
+		}
+		for _, s := range subjects {
+			if !includeSystem && systemSubject(s, namespace) {
+				continue
+			}
+			subject := subjectName(s, bindingNS)
+			for _, rule := range rules {
+				for _, resource := range ruleResources(rule) {
+					g := grants[[2]string{subject, resource}]
+					if g == nil {
+						g = &rbacGrant{}
+						grants[[2]string{subject, resource}] = g
+					}
+					for _, verb := range rule.Verbs {
+						if !slices.Contains(g.verbs, verb) {
+							g.verbs = append(g.verbs, verb)
+						}
+					}
+					if !slices.Contains(g.bindings, binding) {
+						g.bindings = append(g.bindings, binding)
+					}
+				}
+			}
+		}
+	}
+
+	for _, b := range objs.roleBindings {
+		bind("rolebinding/"+b.Name, b.Namespace, b.RoleRef, b.Subjects)
+	}
+	for _, b := range objs.clusterRoleBindings {
+		bind("clusterrolebinding/"+b.Name, "", b.RoleRef, b.Subjects)
+	}
+	for _, g := range grants {
+		sort.Strings(g.verbs)
+		sort.Strings(g.bindings)
+	}
+	return grants, missing
+}
+
+// ruleResources names the resources a rule applies to, as resource.group
+// with /name appended for rules restricted to resource names. Rules for
+// non-resource URLs do not apply to a namespace and yield nothing.
+func ruleResources(rule rbacv1.PolicyRule) []string {
+	var resources []string
+	for _, group := range rule.APIGroups {
+		for _, resource := range rule.Resources {
+			name := resource
+			if group != "" {
+				name += "." + group
+			}
+			if len(rule.ResourceNames) == 0 {
+				resources = append(resources, name)
+				continue
+			}
+			for _, rn := range rule.ResourceNames {
+				resources = append(resources, name+"/"+rn)
+			}
+		}
+	}
+	return resources
+}
+
+// subjectName formats a subject as Kind:name, with the namespace of service
+// accounts, which defaults to the namespace of the binding
+func subjectName(s rbacv1.Subject, bindingNS string) string {
+	if s.Kind != rbacv1.ServiceAccountKind {
+		return s.Kind + ":" + s.Name
+	}
+	ns := s.Namespace
+	if ns == "" {
+		ns = bindingNS
+	}
+	return s.Kind + ":" + ns + "/" + s.Name
+}
+
+// systemSubject reports whether a subject belongs to the Kubernetes system
+// components rather than to users of the namespace
+func systemSubject(s rbacv1.Subject, namespace string) bool {
+	if s.Kind == rbacv1.ServiceAccountKind {
+		return s.Namespace == "kube-system" && namespace != "kube-system"
+	}
+	return strings.HasPrefix(s.Name, "system:")
+}
+
+// rbacReportRows merges the grants of every cluster into one row per subject
+// and resource, sorted by subject and resource
+func rbacReportRows(clusters []string, perCluster map[string]rbacGrants) []rbacGrantRow {
+	byKey := map[[2]string]*rbacGrantRow{}
+	for _, name := range clusters {
+		for key, g := range perCluster[name] {
+			row := byKey[key]
+			if row == nil {
+				row = &rbacGrantRow{Subject: key[0], Resource: key[1], Verbs: map[string][]string{}, Bindings: map[string][]string{}}
+				byKey[key] = row
+			}
+			row.Verbs[name] = g.verbs
+			row.Bindings[name] = g.bindings
+		}
+	}
+
+	rows := make([]rbacGrantRow, 0, len(byKey))
+	for _, row := range byKey {
+		first := strings.Join(row.Verbs[clusters[0]], ",")
+		for _, name := range clusters[1:] {
+			if strings.Join(row.Verbs[name], ",") != first {
+				row.Differs = true
+				break
+			}
+		}
+		rows = append(rows, *row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Subject != rows[j].Subject {
+			return rows[i].Subject < rows[j].Subject
+		}
+		return rows[i].Resource < rows[j].Resource
+	})
+	return rows
+}
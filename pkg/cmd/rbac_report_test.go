@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testClusterRBAC(deployVerbs []string) clusterRBAC {
+	return clusterRBAC{
+		roles: []rbacv1.Role{{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "deployer"},
+			Rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: deployVerbs},
+				{APIGroups: []string{""}, Resources: []string{"configmaps"}, ResourceNames: []string{"app-config"}, Verbs: []string{"get"}},
+			},
+		}},
+		clusterRoles: []rbacv1.ClusterRole{
+			{ObjectMeta: metav1.ObjectMeta{Name: "view"}, Rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"list", "get"}},
+			}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "system:controller:foo"}, Rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"*"}},
+				{NonResourceURLs: []string{"/healthz"}, Verbs: []string{"get"}},
+			}},
+		},
+		roleBindings: []rbacv1.RoleBinding{
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "ci"},
+				RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "deployer"},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "ci"}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "stale"},
+				RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "gone"},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "bob"}},
+			},
+		},
+		clusterRoleBindings: []rbacv1.ClusterRoleBinding{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "viewers"},
+				RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "view"},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.GroupKind, Name: "devs"}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "system:controller:foo"},
+				RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "system:controller:foo"},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Namespace: "kube-system", Name: "foo"}},
+			},
+		},
+	}
+}
+
+func TestNamespaceGrants(t *testing.T) {
+	grants, missing := namespaceGrants("prod", testClusterRBAC([]string{"update", "get"}), false)
+
+	want := rbacGrants{
+		{"ServiceAccount:prod/ci", "deployments.apps"}:      {verbs: []string{"get", "update"}, bindings: []string{"rolebinding/ci"}},
+		{"ServiceAccount:prod/ci", "configmaps/app-config"}: {verbs: []string{"get"}, bindings: []string{"rolebinding/ci"}},
+		{"Group:devs", "pods"}:                              {verbs: []string{"get", "list"}, bindings: []string{"clusterrolebinding/viewers"}},
+	}
+	if !reflect.DeepEqual(grants, want) {
+		t.Errorf("namespaceGrants() grants:")
+		for key, g := range grants {
+			t.Errorf("  %v: %+v", key, *g)
+		}
+	}
+	if want := []string{"rolebinding/stale references missing role gone"}; !reflect.DeepEqual(missing, want) {
+		t.Errorf("namespaceGrants() missing = %v, want %v", missing, want)
+	}
+
+	grants, _ = namespaceGrants("prod", testClusterRBAC([]string{"get"}), true)
+	if g := grants[[2]string{"ServiceAccount:kube-system/foo", "pods"}]; g == nil || !reflect.DeepEqual(g.verbs, []string{"*"}) {
+		t.Errorf("--include-system grant of kube-system/foo = %+v, want *", g)
+	}
+}
+
+func TestRBACReportRows(t *testing.T) {
+	cluster1, _ := namespaceGrants("prod", testClusterRBAC([]string{"get", "update"}), false)
+	cluster2, _ := namespaceGrants("prod", testClusterRBAC([]string{"get"}), false)
+	delete(cluster2, [2]string{"Group:devs", "pods"})
+
+	rows := rbacReportRows([]string{"cluster1", "cluster2"}, map[string]rbacGrants{"cluster1": cluster1, "cluster2": cluster2})
+
+	type summary struct {
+		subject, resource string
+		differs           bool
+		cluster2          []string
+	}
+	var got []summary
+	for _, r := range rows {
+		got = append(got, summary{r.Subject, r.Resource, r.Differs, r.Verbs["cluster2"]})
+	}
+	want := []summary{
+		{"Group:devs", "pods", true, nil},
+		{"ServiceAccount:prod/ci", "configmaps/app-config", false, []string{"get"}},
+		{"ServiceAccount:prod/ci", "deployments.apps", true, []string{"get"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rbacReportRows() = %+v, want %+v", got, want)
+	}
+}
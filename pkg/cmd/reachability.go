@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+
+	"kubectl-multi/pkg/cluster"
+)
+
+// reachability holds the --skip-unreachable/--require-all flags of read commands
+type reachability struct {
+	SkipUnreachable bool
+	RequireAll      bool
+	Timeout         time.Duration
+}
+
+// clusterIssue records why a cluster is missing from, or incomplete in, a result
+type clusterIssue struct {
+	Cluster string `json:"cluster"`
+	Reason  string `json:"reason"`
+}
+
+var (
+	clusterIssuesMu sync.Mutex
+	clusterIssues   []clusterIssue
+)
+
+// addFlags registers the reachability flags on cmd
+func (r *reachability) addFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&r.SkipUnreachable, "skip-unreachable", true, "leave out clusters that cannot be contacted and list them after the output")
+	cmd.Flags().BoolVar(&r.RequireAll, "require-all", false, "fail before printing anything if any cluster cannot be contacted")
+	cmd.Flags().DurationVar(&r.Timeout, "reachability-timeout", 5*time.Second, "how long to wait for each cluster to answer the reachability probe")
+}
+
+// filter probes every cluster concurrently and drops the ones that do not
+// answer. With --require-all (or --skip-unreachable=false) any unreachable
+// cluster is an error instead.
+func (r reachability) filter(clusters []cluster.ClusterInfo) ([]cluster.ClusterInfo, error) {
+	reasons := make([]string, len(clusters))
+	var wg sync.WaitGroup
+	for i, c := range clusters {
+		wg.Add(1)
+		go func(i int, c cluster.ClusterInfo) {
+			defer wg.Done()
+			reasons[i] = probeCluster(c, r.Timeout)
+		}(i, c)
+	}
+	wg.Wait()
+
+	var reachable []cluster.ClusterInfo
+	var unreachable []string
+	for i, c := range clusters {
+		if reasons[i] == "" {
+			reachable = append(reachable, c)
+			continue
+		}
+		unreachable = append(unreachable, fmt.Sprintf("%s (%s)", c.Name, reasons[i]))
+		noteClusterIssue(c.Name, "unreachable: "+reasons[i])
+	}
+
+	if len(unreachable) > 0 && r.strict() {
+		resetClusterIssues()
+		return nil, fmt.Errorf("%d cluster(s) unreachable: %s", len(unreachable), strings.Join(unreachable, "; "))
+	}
+	return reachable, nil
+}
+
+// controlPlane probes the ITS or WDS a command reads from. An unreachable
+// required control plane is an error; an optional one is listed in the
+// footer and reported as not reachable, unless --require-all is set.
+func (r reachability) controlPlane(kind, kubeconfig, contextName string, required bool) (bool, error) {
+	var reason string
+	c, err := cluster.ClientForContext(kubeconfig, contextName)
+	if err != nil {
+		reason = err.Error()
+	} else {
+		reason = probeCluster(*c, r.Timeout)
+	}
+	if reason == "" {
+		return true, nil
+	}
+	if required || r.strict() {
+		return false, fmt.Errorf("%s %s unreachable: %s", kind, contextName, reason)
+	}
+	noteClusterIssue(contextName, kind+" unreachable: "+reason)
+	return false, nil
+}
+
+// strict reports whether an unreachable cluster fails the command
+func (r reachability) strict() bool {
+	return r.RequireAll || !r.SkipUnreachable
+}
+
+// probeCluster returns why a cluster cannot be contacted, or "" if it answers
+func probeCluster(c cluster.ClusterInfo, timeout time.Duration) string {
+	if c.Client == nil || c.RestConfig == nil {
+		return "no client available"
+	}
+	cfg := rest.CopyConfig(c.RestConfig)
+	cfg.Timeout = timeout
+	disc, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return err.Error()
+	}
+	// Any API answer, even an error status, means the cluster is reachable
+	if _, err := disc.ServerVersion(); err != nil && !isAPIStatus(err) {
+		return err.Error()
+	}
+	return ""
+}
+
+func isAPIStatus(err error) bool {
+	_, ok := err.(apierrors.APIStatus)
+	return ok
+}
+
+// noteClusterIssue records a per-cluster problem to be reported after the
+// output instead of interleaving it with table rows
+func noteClusterIssue(clusterName, reason string) {
+	clusterIssuesMu.Lock()
+	defer clusterIssuesMu.Unlock()
+	clusterIssues = append(clusterIssues, clusterIssue{Cluster: clusterName, Reason: reason})
+}
+
+func resetClusterIssues() {
+	clusterIssuesMu.Lock()
+	defer clusterIssuesMu.Unlock()
+	clusterIssues = nil
+}
+
+// takeClusterIssues returns the recorded problems and clears them
+func takeClusterIssues() []clusterIssue {
+	clusterIssuesMu.Lock()
+	defer clusterIssuesMu.Unlock()
+	issues := clusterIssues
+	clusterIssues = nil
+	return issues
+}
+
+// printClusterIssues writes the recorded problems as a footer and clears them
+func printClusterIssues(w io.Writer) {
+	issues := takeClusterIssues()
+	if len(issues) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\nIncomplete results, %d cluster issue(s):\n", len(issues))
+	for _, issue := range issues {
+		fmt.Fprintf(w, "  %s: %s\n", issue.Cluster, issue.Reason)
+	}
+}
+
+// printClusterIssuesToStderr is deferred by read commands so the footer
+// follows the flushed table without mixing into it
+func printClusterIssuesToStderr() {
+	printClusterIssues(os.Stderr)
+}
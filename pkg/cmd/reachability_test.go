@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"kubectl-multi/pkg/cluster"
+)
+
+// testAPIServer serves just enough of the API for the reachability probe
+func testAPIServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"major": "1", "minor": "29", "gitVersion": "v1.29.0"}`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// unreachableHost returns the address of a server that is no longer listening
+func unreachableHost() string {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	return srv.URL
+}
+
+func testReachCluster(t *testing.T, name, host string) cluster.ClusterInfo {
+	cfg := &rest.Config{Host: host}
+	client, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		t.Fatalf("failed to build client: %v", err)
+	}
+	return cluster.ClusterInfo{Name: name, Context: name, Client: client, RestConfig: cfg}
+}
+
+func clusterNames(clusters []cluster.ClusterInfo) []string {
+	var names []string
+	for _, c := range clusters {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func TestReachabilityFilter(t *testing.T) {
+	up := testAPIServer(t).URL
+	down := unreachableHost()
+
+	tests := []struct {
+		name       string
+		reach      reachability
+		hosts      map[string]string
+		want       []string
+		wantErr    bool
+		wantIssues string
+	}{
+		{
+			name:  "all reachable",
+			reach: reachability{SkipUnreachable: true},
+			hosts: map[string]string{"cluster1": up, "cluster2": up},
+			want:  []string{"cluster1", "cluster2"},
+		},
+		{
+			name:       "unreachable skipped",
+			reach:      reachability{SkipUnreachable: true},
+			hosts:      map[string]string{"cluster1": up, "cluster2": down},
+			want:       []string{"cluster1"},
+			wantIssues: "cluster2: unreachable:",
+		},
+		{
+			name:    "require all",
+			reach:   reachability{SkipUnreachable: true, RequireAll: true},
+			hosts:   map[string]string{"cluster1": up, "cluster2": down},
+			wantErr: true,
+		},
+		{
+			name:    "skipping disabled",
+			reach:   reachability{},
+			hosts:   map[string]string{"cluster1": up, "cluster2": down},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetClusterIssues()
+			tt.reach.Timeout = 2 * time.Second
+			var clusters []cluster.ClusterInfo
+			for _, name := range []string{"cluster1", "cluster2"} {
+				clusters = append(clusters, testReachCluster(t, name, tt.hosts[name]))
+			}
+
+			got, err := tt.reach.filter(clusters)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("filter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if names := clusterNames(got); strings.Join(names, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("filter() = %q, want %q", names, tt.want)
+			}
+
+			var footer bytes.Buffer
+			printClusterIssues(&footer)
+			if tt.wantIssues == "" && footer.Len() > 0 {
+				t.Errorf("unexpected footer %q", footer.String())
+			}
+			if tt.wantIssues != "" && !strings.Contains(footer.String(), tt.wantIssues) {
+				t.Errorf("footer %q does not contain %q", footer.String(), tt.wantIssues)
+			}
+		})
+	}
+}
+
+func TestReachabilityControlPlane(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	data := fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: up
+  cluster: {server: %s}
+- name: down
+  cluster: {server: %s}
+contexts:
+- name: wds1
+  context: {cluster: up, user: user}
+- name: its1
+  context: {cluster: down, user: user}
+users:
+- name: user
+  user: {token: test}
+current-context: wds1
+`, testAPIServer(t).URL, unreachableHost())
+	if err := os.WriteFile(kubeconfig, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		reach         reachability
+		context       string
+		required      bool
+		wantReachable bool
+		wantErr       bool
+		wantIssue     bool
+	}{
+		{name: "reachable", reach: reachability{SkipUnreachable: true}, context: "wds1", required: true, wantReachable: true},
+		{name: "required and unreachable", reach: reachability{SkipUnreachable: true}, context: "its1", required: true, wantErr: true},
+		{name: "optional and unreachable", reach: reachability{SkipUnreachable: true}, context: "its1", wantIssue: true},
+		{name: "optional with require all", reach: reachability{SkipUnreachable: true, RequireAll: true}, context: "its1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetClusterIssues()
+			tt.reach.Timeout = 2 * time.Second
+			reachable, err := tt.reach.controlPlane("ITS", kubeconfig, tt.context, tt.required)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("controlPlane() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if reachable != tt.wantReachable {
+				t.Errorf("controlPlane() reachable = %v, want %v", reachable, tt.wantReachable)
+			}
+			var footer bytes.Buffer
+			printClusterIssues(&footer)
+			if got := strings.Contains(footer.String(), "its1: ITS unreachable:"); got != tt.wantIssue {
+				t.Errorf("footer %q, want issue %v", footer.String(), tt.wantIssue)
+			}
+		})
+	}
+}
+
+func TestPrintClusterIssues(t *testing.T) {
+	resetClusterIssues()
+	var empty bytes.Buffer
+	printClusterIssues(&empty)
+	if empty.Len() != 0 {
+		t.Errorf("printClusterIssues() with no issues = %q, want nothing", empty.String())
+	}
+
+	noteClusterIssue("cluster1", "failed to list pods: forbidden")
+	noteClusterIssue("cluster2", "no client available")
+	var footer bytes.Buffer
+	printClusterIssues(&footer)
+	want := "\nIncomplete results, 2 cluster issue(s):\n  cluster1: failed to list pods: forbidden\n  cluster2: no client available\n"
+	if footer.String() != want {
+		t.Errorf("printClusterIssues() = %q, want %q", footer.String(), want)
+	}
+
+	var again bytes.Buffer
+	printClusterIssues(&again)
+	if again.Len() != 0 {
+		t.Errorf("issues were not cleared, got %q", again.String())
+	}
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/platform"
+	"kubectl-multi/pkg/util"
+)
+
+// manifestRender holds the --render and --var flags of apply
+type manifestRender struct {
+	Format string
+	Vars   []string
+}
+
+// renderContext is the data a manifest template is evaluated against
+type renderContext struct {
+	ClusterName   string
+	ClusterLabels map[string]string
+	Vars          map[string]string
+}
+
+// addFlags registers the rendering flags on cmd
+func (r *manifestRender) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&r.Format, "render", "", "render manifests per cluster before applying; only \"go-template\" is supported")
+	cmd.Flags().StringArrayVar(&r.Vars, "var", nil, "template variable as key=value, available as .Vars.key (repeatable)")
+}
+
+func (r manifestRender) enabled() bool {
+	return r.Format != ""
+}
+
+func (r manifestRender) validate() error {
+	if r.Format != "" && r.Format != util.RenderFormatGoTemplate {
+		return fmt.Errorf("unsupported --render %q, must be %s", r.Format, util.RenderFormatGoTemplate)
+	}
+	if r.Format == "" && len(r.Vars) > 0 {
+		return fmt.Errorf("--var requires --render")
+	}
+	_, err := r.vars()
+	return err
+}
+
+// vars parses the --var flags
+func (r manifestRender) vars() (map[string]string, error) {
+	vars := map[string]string{}
+	for _, v := range r.Vars {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --var %q, must be key=value", v)
+		}
+		vars[key] = value
+	}
+	return vars, nil
+}
+
+// renderFor renders the manifests for one cluster into a temporary file and
+// returns its path; the caller removes it
+func (r manifestRender) renderFor(sources []util.ManifestSource, clusterName string, labels map[string]string) (string, error) {
+	vars, err := r.vars()
+	if err != nil {
+		return "", err
+	}
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	data, err := util.RenderManifests(sources, renderContext{ClusterName: clusterName, ClusterLabels: labels, Vars: vars})
+	if err != nil {
+		return "", err
+	}
+
+	f, err := platform.CreateTemp("kubectl-multi-" + clusterName + "-*.yaml")
+	if err != nil {
+		return "", fmt.Errorf("failed to create rendered manifest: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write rendered manifest: %v", err)
+	}
+	return f.Name(), nil
+}
+
+// managedClusterLabels maps each ManagedCluster name to its labels. An
+// unreachable ITS only warns, leaving the labels empty.
+func managedClusterLabels(kubeconfig, remoteCtx string) map[string]map[string]string {
+	labels := map[string]map[string]string{}
+	mcs, err := cluster.ListManagedClusterObjects(commandContext(), kubeconfig, remoteCtx)
+	if err != nil {
+		fmt.Printf("Warning: cluster labels unavailable for rendering: %v\n", err)
+		return labels
+	}
+	for _, mc := range mcs {
+		labels[mc.GetName()] = mc.GetLabels()
+	}
+	return labels
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"kubectl-multi/pkg/util"
+)
+
+func TestManifestRenderVars(t *testing.T) {
+	tests := []struct {
+		name    string
+		vars    []string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "none", want: map[string]string{}},
+		{name: "pairs", vars: []string{"image=nginx:1.25", "replicas=3"}, want: map[string]string{"image": "nginx:1.25", "replicas": "3"}},
+		{name: "value with equals", vars: []string{"args=--level=debug"}, want: map[string]string{"args": "--level=debug"}},
+		{name: "empty value", vars: []string{"suffix="}, want: map[string]string{"suffix": ""}},
+		{name: "last wins", vars: []string{"a=1", "a=2"}, want: map[string]string{"a": "2"}},
+		{name: "missing equals", vars: []string{"image"}, wantErr: true},
+		{name: "missing key", vars: []string{"=nginx"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := manifestRender{Format: util.RenderFormatGoTemplate, Vars: tt.vars}.vars()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("vars() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("vars() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManifestRenderValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		render  manifestRender
+		wantErr bool
+	}{
+		{name: "disabled", render: manifestRender{}},
+		{name: "go template", render: manifestRender{Format: "go-template", Vars: []string{"a=1"}}},
+		{name: "unsupported format", render: manifestRender{Format: "helm"}, wantErr: true},
+		{name: "vars without render", render: manifestRender{Vars: []string{"a=1"}}, wantErr: true},
+		{name: "invalid var", render: manifestRender{Format: "go-template", Vars: []string{"a"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.render.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestManifestRenderFor(t *testing.T) {
+	render := manifestRender{Format: "go-template", Vars: []string{"image=nginx"}}
+	sources := []util.ManifestSource{{Path: "cm.yaml", Data: []byte(`{{ .ClusterName }} {{ .Vars.image }} {{ index .ClusterLabels "region" | default "none" }}`)}}
+
+	path, err := render.renderFor(sources, "cluster1", nil)
+	if err != nil {
+		t.Fatalf("renderFor() error = %v", err)
+	}
+	defer os.Remove(path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read rendered manifest: %v", err)
+	}
+	if want := "cluster1 nginx none"; string(data) != want {
+		t.Errorf("renderFor() wrote %q, want %q", data, want)
+	}
+}
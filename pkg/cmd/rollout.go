@@ -1,12 +1,27 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
+	"kubectl-multi/pkg/audit"
 	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
 
 	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/clientcmd"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/polymorphichelpers"
 )
 
 func newRolloutCommand() *cobra.Command {
@@ -24,82 +39,384 @@ func newRolloutCommand() *cobra.Command {
 }
 
 func newRolloutHistoryCommand() *cobra.Command {
+	var o rolloutReadOptions
+	var reach reachability
 	cmd := &cobra.Command{
-		Use:   "history",
+		Use:   "history (TYPE/NAME | TYPE NAME)",
 		Short: "View the rollout history of a resource across all managed clusters",
+		Example: `# The revisions of a deployment in every cluster
+kubectl multi rollout history deployment/nginx -n prod
+
+# The pod template of revision 3
+kubectl multi rollout history deployment/nginx -n prod --revision 3`,
+		Args: cobra.RangeArgs(1, 2),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			kubeconfig, remoteCtx, _, _, _ := GetGlobalFlags()
-			return handleRolloutSubcommand("history", args, kubeconfig, remoteCtx)
+			kubeconfig, remoteCtx, _, namespace, _ := GetGlobalFlags()
+			if o.Fallback {
+				return handleRolloutSubcommand("history", o.kubectlArgs("history", args, namespace), &reach, nil, kubeconfig, remoteCtx)
+			}
+			return handleRolloutRead("history", args, o, reach, kubeconfig, remoteCtx, namespace)
 		},
 	}
+	cmd.Flags().Int64Var(&o.Revision, "revision", 0, "show the pod template of this revision")
+	addKubectlFallbackFlag(cmd, &o.Fallback)
+	reach.addFlags(cmd)
 	return cmd
 }
 
 func newRolloutPauseCommand() *cobra.Command {
-	cmd := &cobra.Command{
-		Use:   "pause",
-		Short: "Pause a resource across all managed clusters",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			kubeconfig, remoteCtx, _, _, _ := GetGlobalFlags()
-			return handleRolloutSubcommand("pause", args, kubeconfig, remoteCtx)
-		},
-	}
-	return cmd
+	return newRolloutWriteCommand("pause", "Pause a resource across all managed clusters")
 }
 
 func newRolloutRestartCommand() *cobra.Command {
-	cmd := &cobra.Command{
-		Use:   "restart",
-		Short: "Restart a resource across all managed clusters",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			kubeconfig, remoteCtx, _, _, _ := GetGlobalFlags()
-			return handleRolloutSubcommand("restart", args, kubeconfig, remoteCtx)
-		},
-	}
+	cmd := newRolloutWriteCommand("restart", "Restart a resource across all managed clusters")
+	cmd.Use = "restart (TYPE/NAME | TYPE NAME | -l SELECTOR [TYPE])"
+	cmd.Long = `Restart a workload in every managed cluster, or with -l every deployment
+and statefulset matching the selector (only those of TYPE when given) in the
+targeted clusters.
+
+With -l at most --concurrency clusters are restarted at a time, and a cluster
+is done once its restarted workloads are ready again, within --timeout. With
+--canary the first targeted cluster is restarted alone, and the others only
+follow once its workloads are healthy.`
+	cmd.Example = `# Restart one deployment everywhere
+kubectl multi rollout restart deployment/nginx -n prod
+
+# Restart everything labeled app=nginx on the edge clusters, two at a time
+kubectl multi rollout restart -l app=nginx --clusters @edge --concurrency 2
+
+# Try cluster1 first, then the rest once it is healthy again
+kubectl multi rollout restart deployments -l app=nginx --clusters cluster1,cluster2,cluster3 --canary`
 	return cmd
 }
 
 func newRolloutResumeCommand() *cobra.Command {
-	cmd := &cobra.Command{
-		Use:   "resume",
-		Short: "Resume a resource across all managed clusters",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			kubeconfig, remoteCtx, _, _, _ := GetGlobalFlags()
-			return handleRolloutSubcommand("resume", args, kubeconfig, remoteCtx)
-		},
-	}
-	return cmd
+	return newRolloutWriteCommand("resume", "Resume a resource across all managed clusters")
 }
 
 func newRolloutStatusCommand() *cobra.Command {
+	var o rolloutReadOptions
+	var reach reachability
 	cmd := &cobra.Command{
-		Use:   "status",
+		Use:   "status (TYPE/NAME | TYPE NAME)",
 		Short: "Show the status of the rollout across all managed clusters",
+		Long: `Show the status of the rollout of a deployment, daemonset or statefulset in
+every managed cluster, one cluster after the other. By default it waits in
+each cluster until the rollout is done; --watch=false shows the current status
+only.`,
+		Example: `# Wait for the rollout of a deployment to finish everywhere
+kubectl multi rollout status deployment/nginx -n prod
+
+# Show where it stands without waiting
+kubectl multi rollout status deployment/nginx -n prod --watch=false`,
+		Args: cobra.RangeArgs(1, 2),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			kubeconfig, remoteCtx, _, _, _ := GetGlobalFlags()
-			return handleRolloutSubcommand("status", args, kubeconfig, remoteCtx)
+			kubeconfig, remoteCtx, _, namespace, _ := GetGlobalFlags()
+			if o.Fallback {
+				return handleRolloutSubcommand("status", o.kubectlArgs("status", args, namespace), &reach, nil, kubeconfig, remoteCtx)
+			}
+			return handleRolloutRead("status", args, o, reach, kubeconfig, remoteCtx, namespace)
 		},
 	}
+	cmd.Flags().Int64Var(&o.Revision, "revision", 0, "wait for this revision to be rolled out (statefulsets only); 0 for the latest")
+	cmd.Flags().BoolVarP(&o.Watch, "watch", "w", true, "wait in each cluster until the rollout is done")
+	cmd.Flags().DurationVar(&o.Timeout, "timeout", 0, "how long to wait in each cluster before giving up, zero means no limit")
+	addKubectlFallbackFlag(cmd, &o.Fallback)
+	reach.addFlags(cmd)
 	return cmd
 }
 
 func newRolloutUndoCommand() *cobra.Command {
+	return newRolloutWriteCommand("undo", "Roll back to a previous rollout across all managed clusters")
+}
+
+// rolloutOptions are the flags of the mutating rollout subcommands
+type rolloutOptions struct {
+	ToRevision int64
+	DryRun     string
+	Fallback   bool
+
+	// Selector, Targets, Concurrency, Canary and Timeout are the flags of
+	// rollout restart -l
+	Selector    string
+	Targets     clusterTargets
+	Concurrency int
+	Canary      bool
+	Timeout     time.Duration
+}
+
+// newRolloutWriteCommand builds pause, restart, resume and undo, which patch
+// the resource in every cluster with the built-in client
+func newRolloutWriteCommand(subcommand, short string) *cobra.Command {
+	var o rolloutOptions
+
 	cmd := &cobra.Command{
-		Use:   "undo",
-		Short: "Roll back to a previous rollout across all managed clusters",
+		Use:   subcommand + " (TYPE/NAME | TYPE NAME)",
+		Short: short,
+		Args:  cobra.RangeArgs(0, 2),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			kubeconfig, remoteCtx, _, _, _ := GetGlobalFlags()
-			return handleRolloutSubcommand("undo", args, kubeconfig, remoteCtx)
+			if err := util.ValidateDryRun(o.DryRun); err != nil {
+				return err
+			}
+			var types []string
+			if o.Selector != "" {
+				var err error
+				if o.Selector, err = parseSelector(o.Selector); err != nil {
+					return err
+				}
+				if types, err = restartTypes(args); err != nil {
+					return err
+				}
+				if err := o.validateRestartSelector(); err != nil {
+					return err
+				}
+			} else if len(args) == 0 {
+				return fmt.Errorf("a resource is required: TYPE/NAME or TYPE NAME")
+			} else if cmd.Flags().Changed("canary") || cmd.Flags().Changed("concurrency") {
+				return fmt.Errorf("--canary and --concurrency only apply with -l")
+			}
+			kubeconfig, remoteCtx, _, namespace, allNamespaces := GetGlobalFlags()
+			var rec *audit.Recorder
+			if o.DryRun == util.DryRunNone {
+				rec = startAudit("rollout " + subcommand)
+			}
+			var err error
+			if o.Selector != "" {
+				err = handleRolloutRestartSelector(types, o, rec, kubeconfig, remoteCtx, namespace, allNamespaces)
+			} else if o.Fallback {
+				err = handleRolloutSubcommand(subcommand, o.kubectlArgs(args, namespace), nil, rec, kubeconfig, remoteCtx)
+			} else {
+				err = handleRolloutWrite(subcommand, args, o, rec, kubeconfig, remoteCtx, namespace)
+			}
+			finishAudit(rec, err)
+			return err
 		},
 	}
+
+	if subcommand == "undo" {
+		cmd.Flags().Int64Var(&o.ToRevision, "to-revision", 0, "the revision to roll back to, 0 for the previous one")
+	}
+	if subcommand == "restart" {
+		cmd.Flags().StringVarP(&o.Selector, "selector", "l", "", "restart the deployments and statefulsets matching this label selector")
+		o.Targets.addFlags(cmd, "restart in")
+		cmd.Flags().IntVar(&o.Concurrency, "concurrency", defaultRestartConcurrency, "with -l, how many clusters to restart at a time")
+		cmd.Flags().BoolVar(&o.Canary, "canary", false, "with -l, restart the first targeted cluster alone and continue once it is healthy")
+		cmd.Flags().DurationVar(&o.Timeout, "timeout", defaultRestartTimeout, "with -l, how long a cluster's workloads may take to become ready again")
+	}
+	cmd.Flags().StringVar(&o.DryRun, "dry-run", util.DryRunNone, "must be \"none\", \"server\", or \"client\"")
+	addKubectlFallbackFlag(cmd, &o.Fallback)
+
 	return cmd
 }
 
-func handleRolloutSubcommand(subcommand string, extraArgs []string, kubeconfig, remoteCtx string) error {
-	clusters, err := cluster.DiscoverClusters(kubeconfig, remoteCtx)
+// kubectlArgs turns the parsed flags back into kubectl rollout arguments
+func (o rolloutOptions) kubectlArgs(args []string, namespace string) []string {
+	if namespace != "" {
+		args = append(args, "-n", namespace)
+	}
+	if o.ToRevision != 0 {
+		args = append(args, fmt.Sprintf("--to-revision=%d", o.ToRevision))
+	}
+	if o.DryRun != util.DryRunNone {
+		args = append(args, "--dry-run="+o.DryRun)
+	}
+	return args
+}
+
+func handleRolloutWrite(subcommand string, args []string, o rolloutOptions, rec *audit.Recorder, kubeconfig, remoteCtx, namespace string) error {
+	resourceType, name, err := parseTypeName(args)
+	if err != nil {
+		return err
+	}
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
 	if err != nil {
 		return fmt.Errorf("failed to discover clusters: %v", err)
 	}
+	clusters, err = o.Targets.selectFrom(clusters, kubeconfig)
+	if err != nil {
+		return err
+	}
+	if len(clusters) == 0 {
+		return fmt.Errorf("no clusters discovered")
+	}
+
+	currentContext := currentContextName(kubeconfig)
+	itsContext := remoteCtx
+
+	radius := blastRadius{Objects: fanOutTargets(clusters, itsContext), Namespaces: []string{cluster.GetTargetNamespace(namespace)}}
+	if err := guardFanOut(rec, radius, clusters, itsContext); err != nil {
+		return err
+	}
+	trackFanOut(rec, fanOutTargets(clusters, itsContext))
+	run := func(c cluster.ClusterInfo) {
+		rec.Begin(c.Name)
+		output, err := rolloutInCluster(c, subcommand, resourceType, name, o, rec, namespace)
+		rec.Record(c.Name, err)
+		fmt.Printf("=== Cluster: %s ===\n", c.Context)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+		} else {
+			fmt.Println(output)
+		}
+		fmt.Println()
+	}
+
+	// 1. Run for current context (if present), 2. then the other managed clusters
+	for _, c := range clusters {
+		if c.Context == currentContext && c.Context != itsContext {
+			run(c)
+		}
+	}
+	for _, c := range clusters {
+		if c.Context == currentContext || c.Context == itsContext {
+			continue
+		}
+		run(c)
+	}
+
+	// 3. Print warning for ITS (control) cluster
+	for _, c := range clusters {
+		if c.Context == itsContext {
+			fmt.Printf("=== Cluster: %s ===\n", c.Context)
+			fmt.Printf("Cannot perform this operation on ITS (control) cluster: %s\n", c.Context)
+			fmt.Println()
+		}
+	}
+
+	return nil
+}
+
+// rolloutInCluster pauses, restarts, resumes or rolls back one resource with
+// kubectl's own rollout helpers and returns a kubectl-style result line
+func rolloutInCluster(c cluster.ClusterInfo, subcommand, resourceType, name string, o rolloutOptions, rec *audit.Recorder, namespace string) (string, error) {
+	if c.Client == nil || c.DynamicClient == nil || c.DiscoveryClient == nil {
+		return "", fmt.Errorf("no clients available for cluster %s", c.Name)
+	}
+	gvr, namespaced, err := util.DiscoverGVR(c.DiscoveryClient, resourceType)
+	if err != nil {
+		return "", err
+	}
+	var client dynamic.ResourceInterface = c.DynamicClient.Resource(gvr)
+	ns := ""
+	if namespaced {
+		ns = cluster.GetTargetNamespace(namespace)
+		client = c.DynamicClient.Resource(gvr).Namespace(ns)
+	}
+
+	live, err := client.Get(commandContext(), name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	obj, err := scheme.Scheme.New(live.GroupVersionKind())
+	if err != nil {
+		return "", fmt.Errorf("rollout %s is not supported for %s", subcommand, live.GetKind())
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(live.Object, obj); err != nil {
+		return "", err
+	}
+	ref := qualifiedName(gvr, live)
+	suffix := ""
+	if o.DryRun != util.DryRunNone {
+		suffix = " (" + o.DryRun + " dry run)"
+	}
+
+	if subcommand == "undo" {
+		rollbacker, err := polymorphichelpers.RollbackerFor(live.GroupVersionKind().GroupKind(), c.Client)
+		if err != nil {
+			return "", err
+		}
+		strategy := cmdutil.DryRunNone
+		switch o.DryRun {
+		case util.DryRunClient:
+			strategy = cmdutil.DryRunClient
+		case util.DryRunServer:
+			strategy = cmdutil.DryRunServer
+		}
+		result, err := rollbacker.Rollback(obj, nil, o.ToRevision, strategy)
+		if err != nil {
+			return "", err
+		}
+		recordRestoreUndo(rec, c.Name, gvr, ns, live, o.DryRun)
+		return ref + " " + result, nil
+	}
+
+	var modify func(runtime.Object) ([]byte, error)
+	var done, noop string
+	switch subcommand {
+	case "pause":
+		modify, done, noop = polymorphichelpers.ObjectPauserFn, "paused", "already paused"
+	case "resume":
+		modify, done, noop = polymorphichelpers.ObjectResumerFn, "resumed", "already resumed"
+	case "restart":
+		modify, done = polymorphichelpers.ObjectRestarterFn, "restarted"
+	default:
+		return "", fmt.Errorf("unknown rollout subcommand %q", subcommand)
+	}
+
+	original, err := json.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+	modified, err := modify(obj)
+	if err != nil {
+		// The helpers refuse to pause a paused or resume a running rollout
+		if noop != "" && (strings.Contains(err.Error(), "already paused") || strings.Contains(err.Error(), "not paused")) {
+			return ref + " " + noop, nil
+		}
+		return "", err
+	}
+	patch, err := strategicpatch.CreateTwoWayMergePatch(original, modified, obj)
+	if err != nil {
+		return "", fmt.Errorf("failed to create patch for %s: %v", ref, err)
+	}
+	if patch, err = util.ManagedPatch(types.StrategicMergePatchType, patch, live); err != nil {
+		return "", err
+	}
+	if o.DryRun != util.DryRunClient {
+		opts := metav1.PatchOptions{FieldManager: util.FieldManager}
+		if o.DryRun == util.DryRunServer {
+			opts.DryRun = []string{metav1.DryRunAll}
+		}
+		if _, err := client.Patch(commandContext(), name, types.StrategicMergePatchType, patch, opts); err != nil {
+			return "", err
+		}
+	}
+	recordRestoreUndo(rec, c.Name, gvr, ns, live, o.DryRun)
+	return ref + " " + done + suffix, nil
+}
+
+// recordRestoreUndo records restoring the object as it was before a change
+func recordRestoreUndo(rec *audit.Recorder, clusterName string, gvr schema.GroupVersionResource, namespace string, live *unstructured.Unstructured, dryRun string) {
+	if dryRun != util.DryRunNone {
+		return
+	}
+	rec.AddUndo(audit.UndoStep{
+		Cluster:   clusterName,
+		Action:    audit.UndoRestore,
+		Group:     gvr.Group,
+		Version:   gvr.Version,
+		Resource:  gvr.Resource,
+		Namespace: namespace,
+		Name:      live.GetName(),
+		Object:    snapshotObject(live).Object,
+	})
+}
+
+// handleRolloutSubcommand runs kubectl rollout in every cluster, for
+// --kubectl-fallback. Read-only subcommands pass reach to leave out or fail
+// on unreachable clusters.
+func handleRolloutSubcommand(subcommand string, extraArgs []string, reach *reachability, rec *audit.Recorder, kubeconfig, remoteCtx string) error {
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	if reach != nil {
+		clusters, err = reach.filter(clusters)
+		if err != nil {
+			return err
+		}
+		defer printClusterIssuesToStderr()
+	}
 	if len(clusters) == 0 {
 		return fmt.Errorf("no clusters discovered")
 	}
@@ -135,6 +452,7 @@ func handleRolloutSubcommand(subcommand string, extraArgs []string, kubeconfig,
 		}
 		args = append(args, "--context", cinfo.Context)
 		cmdOutput, err := runKubectl(args, kubeconfig)
+		rec.Record(cinfo.Name, err)
 		fmt.Printf("=== Cluster: %s ===\n", cinfo.Context)
 		if err != nil {
 			fmt.Printf("Error: %v\n", err)
@@ -155,6 +473,7 @@ func handleRolloutSubcommand(subcommand string, extraArgs []string, kubeconfig,
 		}
 		args = append(args, "--context", c.Context)
 		cmdOutput, err := runKubectl(args, kubeconfig)
+		rec.Record(c.Name, err)
 		fmt.Printf("=== Cluster: %s ===\n", c.Context)
 		if err != nil {
 			fmt.Printf("Error: %v\n", err)
@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+// rolloutRestartTypes are the workloads rollout restart -l restarts when no
+// type is given
+var rolloutRestartTypes = []string{"deployments", "statefulsets"}
+
+const (
+	defaultRestartConcurrency = 3
+	defaultRestartTimeout     = 5 * time.Minute
+)
+
+// restartTypes returns the workload types rollout restart -l covers: the
+// given one, or deployments and statefulsets
+func restartTypes(args []string) ([]string, error) {
+	if len(args) == 0 {
+		return rolloutRestartTypes, nil
+	}
+	if len(args) > 1 || strings.Contains(args[0], "/") {
+		return nil, fmt.Errorf("with -l, give at most a resource type, such as deployments, instead of names")
+	}
+	switch strings.ToLower(args[0]) {
+	case "deployments", "deployment", "deploy":
+		return []string{"deployments"}, nil
+	case "statefulsets", "statefulset", "sts":
+		return []string{"statefulsets"}, nil
+	case "daemonsets", "daemonset", "ds":
+		return []string{"daemonsets"}, nil
+	}
+	return nil, fmt.Errorf("rollout restart -l supports deployments, statefulsets and daemonsets, not %s", args[0])
+}
+
+// handleRolloutRestartSelector restarts the workloads matching the selector
+// in the targeted clusters, at most o.Concurrency clusters at a time, and
+// waits for each cluster's workloads to become ready again before its slot
+// goes to the next cluster. With o.Canary the first cluster goes alone and
+// the others only follow once it is healthy.
+func handleRolloutRestartSelector(types []string, o rolloutOptions, rec *audit.Recorder, kubeconfig, remoteCtx, namespace string, allNamespaces bool) error {
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	clusters, err = o.Targets.selectFrom(clusters, kubeconfig)
+	if err != nil {
+		return err
+	}
+	var workload []cluster.ClusterInfo
+	for _, c := range clusters {
+		if c.Context != remoteCtx {
+			workload = append(workload, c)
+		}
+	}
+	if len(workload) == 0 {
+		return fmt.Errorf("no clusters discovered")
+	}
+
+	radius := blastRadius{Namespaces: []string{cluster.GetTargetNamespace(namespace)}}
+	if allNamespaces {
+		radius.Namespaces = nil
+	}
+	if err := guardFanOut(rec, radius, workload, remoteCtx); err != nil {
+		return err
+	}
+
+	trackFanOut(rec, len(workload))
+	var mu sync.Mutex
+	failed, skipped := runCanaryWaves(workload, o.Concurrency, o.Canary, func(c cluster.ClusterInfo) error {
+		rec.Begin(c.Name)
+		var buf bytes.Buffer
+		err := restartInCluster(c, types, o, rec, namespace, allNamespaces, &buf)
+		rec.Record(c.Name, err)
+		if err != nil {
+			fmt.Fprintf(&buf, "Error: %v\n", err)
+		}
+		// Whole sections, so concurrent clusters do not interleave
+		mu.Lock()
+		fmt.Printf("=== Cluster: %s ===\n%s\n", c.Context, buf.String())
+		mu.Unlock()
+		return err
+	})
+
+	switch {
+	case len(skipped) > 0:
+		return fmt.Errorf("canary cluster %s did not restart cleanly; not restarted: %s", workload[0].Name, strings.Join(skipped, ", "))
+	case len(failed) > 0:
+		return fmt.Errorf("restart failed in %d of %d cluster(s): %s", len(failed), len(workload), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// runCanaryWaves calls run for every cluster, at most concurrency at a time.
+// With canary the first cluster runs alone first; when it fails the other
+// clusters are skipped. It returns the clusters that failed and those
+// skipped.
+func runCanaryWaves(clusters []cluster.ClusterInfo, concurrency int, canary bool, run func(cluster.ClusterInfo) error) ([]string, []string) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	rest := clusters
+	if canary && len(clusters) > 1 {
+		if err := run(clusters[0]); err != nil {
+			var skipped []string
+			for _, c := range clusters[1:] {
+				skipped = append(skipped, c.Name)
+			}
+			return []string{clusters[0].Name}, skipped
+		}
+		rest = clusters[1:]
+	}
+
+	errs := make([]error, len(rest))
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, c := range rest {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, c cluster.ClusterInfo) {
+			defer func() { <-slots; wg.Done() }()
+			errs[i] = run(c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	var failed []string
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, rest[i].Name)
+		}
+	}
+	return failed, nil
+}
+
+// restartInCluster restarts the workloads of the types matching the selector
+// in one cluster and, unless this is a dry run, waits until they are ready
+// again
+func restartInCluster(c cluster.ClusterInfo, types []string, o rolloutOptions, rec *audit.Recorder, namespace string, allNamespaces bool, out io.Writer) error {
+	if c.DynamicClient == nil || c.DiscoveryClient == nil {
+		return fmt.Errorf("no clients available for cluster %s", c.Name)
+	}
+	type restarted struct {
+		client dynamic.ResourceInterface
+		name   string
+		ref    string
+	}
+	var waitFor []restarted
+	var errs []string
+	for _, resourceType := range types {
+		gvr, _, err := util.DiscoverGVR(c.DiscoveryClient, resourceType)
+		if err != nil {
+			return err
+		}
+		ns := cluster.GetTargetNamespace(namespace)
+		if allNamespaces {
+			ns = metav1.NamespaceAll
+		}
+		list, err := c.DynamicClient.Resource(gvr).Namespace(ns).List(commandContext(), metav1.ListOptions{LabelSelector: o.Selector})
+		if err != nil {
+			return fmt.Errorf("failed to list %s: %v", resourceType, err)
+		}
+		for i := range list.Items {
+			item := &list.Items[i]
+			line, err := rolloutInCluster(c, "restart", gvr.Resource, item.GetName(), o, rec, item.GetNamespace())
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", qualifiedName(gvr, item), err))
+				continue
+			}
+			fmt.Fprintln(out, line)
+			waitFor = append(waitFor, restarted{c.DynamicClient.Resource(gvr).Namespace(item.GetNamespace()), item.GetName(), qualifiedName(gvr, item)})
+		}
+	}
+	if len(waitFor) == 0 && len(errs) == 0 {
+		fmt.Fprintf(out, "No %s match %s\n", strings.Join(types, " or "), o.Selector)
+		return nil
+	}
+
+	if o.DryRun == util.DryRunNone {
+		ctx, cancel := context.WithTimeout(commandContext(), o.Timeout)
+		defer cancel()
+		for _, w := range waitFor {
+			if err := waitForReady(ctx, w.client, w.name, o.Timeout); err != nil {
+				errs = append(errs, fmt.Sprintf("%s not ready after %s: %v", w.ref, o.Timeout, err))
+				continue
+			}
+			fmt.Fprintf(out, "%s ready\n", w.ref)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// validateRestartSelector checks the flags of rollout restart -l
+func (o rolloutOptions) validateRestartSelector() error {
+	if o.Concurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1")
+	}
+	if o.Timeout <= 0 {
+		return fmt.Errorf("--timeout must be a positive duration")
+	}
+	if o.Fallback {
+		return fmt.Errorf("-l cannot be combined with --%s", kubectlFallbackFlag)
+	}
+	return nil
+}
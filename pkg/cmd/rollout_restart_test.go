@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+func TestRestartTypes(t *testing.T) {
+	if got, err := restartTypes(nil); err != nil || !reflect.DeepEqual(got, []string{"deployments", "statefulsets"}) {
+		t.Errorf("restartTypes() = %v, %v", got, err)
+	}
+	if got, err := restartTypes([]string{"sts"}); err != nil || !reflect.DeepEqual(got, []string{"statefulsets"}) {
+		t.Errorf("restartTypes(sts) = %v, %v", got, err)
+	}
+	for _, args := range [][]string{{"deployment/web"}, {"deployment", "web"}, {"pods"}} {
+		if _, err := restartTypes(args); err == nil {
+			t.Errorf("restartTypes(%v) did not fail", args)
+		}
+	}
+}
+
+func testRestartClusters(names ...string) []cluster.ClusterInfo {
+	var clusters []cluster.ClusterInfo
+	for _, name := range names {
+		clusters = append(clusters, cluster.ClusterInfo{Name: name, Context: name})
+	}
+	return clusters
+}
+
+func TestRunCanaryWavesConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	var ran []string
+	failed, skipped := runCanaryWaves(testRestartClusters("c1", "c2", "c3", "c4", "c5"), 2, false, func(c cluster.ClusterInfo) error {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		ran = append(ran, c.Name)
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		if c.Name == "c3" {
+			return errors.New("not ready")
+		}
+		return nil
+	})
+	if maxRunning != 2 {
+		t.Errorf("%d clusters ran at once, want 2", maxRunning)
+	}
+	if len(ran) != 5 || !reflect.DeepEqual(failed, []string{"c3"}) || skipped != nil {
+		t.Errorf("ran %v, failed %v, skipped %v; want all run and c3 failed", ran, failed, skipped)
+	}
+}
+
+func TestRunCanaryWavesCanary(t *testing.T) {
+	var order []string
+	var mu sync.Mutex
+	run := func(fail string) func(cluster.ClusterInfo) error {
+		return func(c cluster.ClusterInfo) error {
+			mu.Lock()
+			order = append(order, c.Name)
+			mu.Unlock()
+			if c.Name == fail {
+				return errors.New("not ready")
+			}
+			return nil
+		}
+	}
+
+	failed, skipped := runCanaryWaves(testRestartClusters("c1", "c2", "c3"), 3, true, run(""))
+	if len(order) != 3 || order[0] != "c1" || failed != nil || skipped != nil {
+		t.Errorf("order %v, failed %v, skipped %v; want c1 first and no failures", order, failed, skipped)
+	}
+
+	order = nil
+	failed, skipped = runCanaryWaves(testRestartClusters("c1", "c2", "c3"), 3, true, run("c1"))
+	if !reflect.DeepEqual(order, []string{"c1"}) || !reflect.DeepEqual(failed, []string{"c1"}) || !reflect.DeepEqual(skipped, []string{"c2", "c3"}) {
+		t.Errorf("order %v, failed %v, skipped %v; want only the failed canary c1", order, failed, skipped)
+	}
+}
+
+func TestRestartInClusterDryRun(t *testing.T) {
+	deployment := func(name, app string) *unstructured.Unstructured {
+		obj := testObject("apps/v1", "Deployment", "prod", name)
+		obj.SetLabels(map[string]string{"app": app})
+		unstructured.SetNestedField(obj.Object, int64(1), "spec", "replicas")
+		return obj
+	}
+	c, _ := testClusterInfo(deployment("web", "nginx"), deployment("api", "api"))
+	c.Client = fake.NewSimpleClientset()
+	o := rolloutOptions{Selector: "app=nginx", DryRun: util.DryRunClient, Timeout: time.Minute}
+	rec := audit.Start("rollout restart", nil)
+	var out bytes.Buffer
+
+	if err := restartInCluster(c, []string{"deployments"}, o, rec, "prod", false, &out); err != nil {
+		t.Fatalf("restartInCluster() error = %v", err)
+	}
+	if got := out.String(); got != "deployment.apps/web restarted (client dry run)\n" {
+		t.Errorf("output = %q, want only web restarted", got)
+	}
+
+	out.Reset()
+	o.Selector = "app=none"
+	if err := restartInCluster(c, []string{"deployments"}, o, rec, "prod", false, &out); err != nil {
+		t.Fatalf("restartInCluster() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "No deployments match app=none") {
+		t.Errorf("output = %q, want no match", out.String())
+	}
+}
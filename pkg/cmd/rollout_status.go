@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/kubectl/pkg/polymorphichelpers"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+// rolloutStatusInterval is how often rollout status reads the resource
+// again while it waits for the rollout to finish
+var rolloutStatusInterval = 2 * time.Second
+
+// rolloutReadOptions are the flags of rollout status and history
+type rolloutReadOptions struct {
+	Revision int64
+	Watch    bool
+	Timeout  time.Duration
+	Fallback bool
+}
+
+// kubectlArgs turns the parsed flags back into kubectl rollout arguments
+func (o rolloutReadOptions) kubectlArgs(subcommand string, args []string, namespace string) []string {
+	if namespace != "" {
+		args = append(args, "-n", namespace)
+	}
+	if o.Revision != 0 {
+		args = append(args, fmt.Sprintf("--revision=%d", o.Revision))
+	}
+	if subcommand == "status" {
+		args = append(args, fmt.Sprintf("--watch=%t", o.Watch))
+		if o.Timeout > 0 {
+			args = append(args, "--timeout="+o.Timeout.String())
+		}
+	}
+	return args
+}
+
+// handleRolloutRead shows the rollout status or history of one resource in
+// every reachable cluster with kubectl's own status and history viewers
+func handleRolloutRead(subcommand string, args []string, o rolloutReadOptions, reach reachability, kubeconfig, remoteCtx, namespace string) error {
+	resourceType, name, err := parseTypeName(args)
+	if err != nil {
+		return err
+	}
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	clusters, err = reach.filter(clusters)
+	if err != nil {
+		return err
+	}
+	defer printClusterIssuesToStderr()
+	if len(clusters) == 0 {
+		return fmt.Errorf("no clusters discovered")
+	}
+
+	currentContext := currentContextName(kubeconfig)
+	itsContext := remoteCtx
+	failed := 0
+	run := func(c cluster.ClusterInfo) {
+		fmt.Printf("=== Cluster: %s ===\n", c.Context)
+		var err error
+		if subcommand == "status" {
+			err = rolloutStatusInCluster(os.Stdout, c, resourceType, name, o, namespace)
+		} else {
+			err = rolloutHistoryInCluster(os.Stdout, c, resourceType, name, o, namespace)
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			failed++
+		}
+		fmt.Println()
+	}
+
+	// 1. Run for current context (if present), 2. then the other managed clusters
+	for _, c := range clusters {
+		if c.Context == currentContext && c.Context != itsContext {
+			run(c)
+		}
+	}
+	for _, c := range clusters {
+		if c.Context == currentContext || c.Context == itsContext {
+			continue
+		}
+		run(c)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("rollout %s failed in %d cluster(s)", subcommand, failed)
+	}
+	return nil
+}
+
+// rolloutResource returns the client, resource and live object of
+// resourceType/name in one cluster
+func rolloutResource(c cluster.ClusterInfo, resourceType, name, namespace string) (dynamic.ResourceInterface, schema.GroupVersionResource, *unstructured.Unstructured, error) {
+	if c.DynamicClient == nil || c.DiscoveryClient == nil {
+		return nil, schema.GroupVersionResource{}, nil, fmt.Errorf("no clients available for cluster %s", c.Name)
+	}
+	gvr, namespaced, err := util.DiscoverGVR(c.DiscoveryClient, resourceType)
+	if err != nil {
+		return nil, gvr, nil, err
+	}
+	var client dynamic.ResourceInterface = c.DynamicClient.Resource(gvr)
+	if namespaced {
+		client = c.DynamicClient.Resource(gvr).Namespace(cluster.GetTargetNamespace(namespace))
+	}
+	live, err := client.Get(commandContext(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, gvr, nil, err
+	}
+	return client, gvr, live, nil
+}
+
+// rolloutStatusInCluster writes the rollout status of one resource to w as
+// kubectl rollout status does. With Watch it reads the resource again every
+// rolloutStatusInterval, writing each new status, until the rollout is done
+// or Timeout passes.
+func rolloutStatusInCluster(w io.Writer, c cluster.ClusterInfo, resourceType, name string, o rolloutReadOptions, namespace string) error {
+	client, _, live, err := rolloutResource(c, resourceType, name, namespace)
+	if err != nil {
+		return err
+	}
+	viewer, err := polymorphichelpers.StatusViewerFor(live.GroupVersionKind().GroupKind())
+	if err != nil {
+		return err
+	}
+
+	var deadline <-chan time.Time
+	if o.Timeout > 0 {
+		deadline = time.After(o.Timeout)
+	}
+	last := ""
+	for {
+		status, done, err := viewer.Status(live, o.Revision)
+		if err != nil {
+			return err
+		}
+		if status != last {
+			fmt.Fprint(w, status)
+			last = status
+		}
+		if done || !o.Watch {
+			return nil
+		}
+
+		select {
+		case <-commandContext().Done():
+			return commandContext().Err()
+		case <-deadline:
+			return fmt.Errorf("timed out waiting for the rollout of %s to finish", name)
+		case <-time.After(rolloutStatusInterval):
+		}
+		if live, err = client.Get(commandContext(), name, metav1.GetOptions{}); err != nil {
+			return err
+		}
+	}
+}
+
+// rolloutHistoryInCluster writes the revisions of one resource, or the pod
+// template of Revision, to w as kubectl rollout history does
+func rolloutHistoryInCluster(w io.Writer, c cluster.ClusterInfo, resourceType, name string, o rolloutReadOptions, namespace string) error {
+	if c.Client == nil {
+		return fmt.Errorf("no clients available for cluster %s", c.Name)
+	}
+	_, gvr, live, err := rolloutResource(c, resourceType, name, namespace)
+	if err != nil {
+		return err
+	}
+	viewer, err := polymorphichelpers.HistoryViewerFor(live.GroupVersionKind().GroupKind(), c.Client)
+	if err != nil {
+		return err
+	}
+	history, err := viewer.ViewHistory(live.GetNamespace(), live.GetName(), o.Revision)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%s\n%s", qualifiedName(gvr, live), history)
+	return nil
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"kubectl-multi/pkg/cluster"
+)
+
+// testRolloutDeployment returns a Deployment of 2 replicas, updated of
+// which are on the current template
+func testRolloutDeployment(updated int64) *unstructured.Unstructured {
+	obj := testObject("apps/v1", "Deployment", "prod", "web")
+	obj.SetGeneration(2)
+	unstructured.SetNestedField(obj.Object, int64(2), "spec", "replicas")
+	unstructured.SetNestedField(obj.Object, int64(2), "status", "observedGeneration")
+	unstructured.SetNestedField(obj.Object, int64(2), "status", "replicas")
+	unstructured.SetNestedField(obj.Object, updated, "status", "updatedReplicas")
+	unstructured.SetNestedField(obj.Object, updated, "status", "availableReplicas")
+	return obj
+}
+
+func testRolloutCluster(typed []runtime.Object, objects ...runtime.Object) cluster.ClusterInfo {
+	c := testTreeCluster(objects...)
+	c.Client = kubefake.NewSimpleClientset(typed...)
+	return c
+}
+
+func TestRolloutStatusInCluster(t *testing.T) {
+	var out bytes.Buffer
+	c := testRolloutCluster(nil, testRolloutDeployment(2))
+	if err := rolloutStatusInCluster(&out, c, "deployments", "web", rolloutReadOptions{Watch: true}, "prod"); err != nil {
+		t.Fatalf("rolloutStatusInCluster() = %v", err)
+	}
+	if want := "deployment \"web\" successfully rolled out\n"; out.String() != want {
+		t.Errorf("printed %q, want %q", out.String(), want)
+	}
+
+	out.Reset()
+	c = testRolloutCluster(nil, testRolloutDeployment(1))
+	if err := rolloutStatusInCluster(&out, c, "deployments", "web", rolloutReadOptions{}, "prod"); err != nil {
+		t.Fatalf("rolloutStatusInCluster() without watch = %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "Waiting for deployment \"web\" rollout to finish: 1 out of 2") {
+		t.Errorf("printed %q, want the waiting message", out.String())
+	}
+
+	defer func(interval time.Duration) { rolloutStatusInterval = interval }(rolloutStatusInterval)
+	rolloutStatusInterval = time.Millisecond
+	out.Reset()
+	err := rolloutStatusInCluster(&out, c, "deployments", "web", rolloutReadOptions{Watch: true, Timeout: 20 * time.Millisecond}, "prod")
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("rolloutStatusInCluster() with a timeout = %v, want timed out", err)
+	}
+	if strings.Count(out.String(), "Waiting") != 1 {
+		t.Errorf("printed %q, want the unchanged status once", out.String())
+	}
+}
+
+func TestRolloutHistoryInCluster(t *testing.T) {
+	labels := map[string]string{"app": "web"}
+	deploy := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "web", UID: types.UID("deploy-web")},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: labels}},
+		},
+	}
+	controller := true
+	replicas := int32(0)
+	replicaSet := func(name, revision, cause string) runtime.Object {
+		return &appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:       "prod",
+				Name:            name,
+				UID:             types.UID("rs-" + name),
+				Labels:          labels,
+				Annotations:     map[string]string{"deployment.kubernetes.io/revision": revision, "kubernetes.io/change-cause": cause},
+				OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", UID: deploy.UID, Controller: &controller}},
+			},
+			Spec: appsv1.ReplicaSetSpec{
+				Replicas: &replicas,
+				Selector: &metav1.LabelSelector{MatchLabels: labels},
+				Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: labels}},
+			},
+		}
+	}
+	c := testRolloutCluster([]runtime.Object{deploy, replicaSet("web-1", "1", "first"), replicaSet("web-2", "2", "second")}, testRolloutDeployment(2))
+
+	var out bytes.Buffer
+	if err := rolloutHistoryInCluster(&out, c, "deployments", "web", rolloutReadOptions{}, "prod"); err != nil {
+		t.Fatalf("rolloutHistoryInCluster() = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 || lines[0] != "deployment.apps/web" || !strings.HasPrefix(lines[1], "REVISION") ||
+		!strings.Contains(lines[2], "first") || !strings.Contains(lines[3], "second") {
+		t.Errorf("printed\n%s\nwant the ref and both revisions", out.String())
+	}
+}
+
+func TestRolloutReadKubectlArgs(t *testing.T) {
+	o := rolloutReadOptions{Revision: 3, Watch: false, Timeout: time.Minute}
+	got := o.kubectlArgs("status", []string{"deployment/web"}, "prod")
+	want := []string{"deployment/web", "-n", "prod", "--revision=3", "--watch=false", "--timeout=1m0s"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("kubectlArgs(status) = %v, want %v", got, want)
+	}
+	got = o.kubectlArgs("history", []string{"deployment", "web"}, "")
+	if want := []string{"deployment", "web", "--revision=3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("kubectlArgs(history) = %v, want %v", got, want)
+	}
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"kubectl-multi/pkg/telemetry"
 	"kubectl-multi/pkg/util"
 	"os"
 
@@ -15,15 +16,35 @@ var (
 	allClusters   bool
 	namespace     string
 	allNamespaces bool
+	wdsCtx        string
+	itsNames      []string
+	allITS        bool
+	wdsNames      []string
+	metricsJSON   string
+	otlpEndpoint  string
+	// contextPattern is the glob of kubeconfig contexts forming the fleet
+	// when the ITS does not serve ManagedClusters; empty, the default, keeps
+	// the fallback off
+	contextPattern string
 )
 
-// Custom help function for root command
+// defaultHelpFunc is cobra's help, rendering the help template of a command.
+// A command without a parent or help func of its own returns it.
+var defaultHelpFunc = (&cobra.Command{}).HelpFunc()
+
+// rootHelpFunc prints the root help in the layout of kubectl's. Every
+// subcommand inherits it, so the others get cobra's help of their own.
 func rootHelpFunc(cmd *cobra.Command, args []string) {
+	if cmd != cmd.Root() {
+		defaultHelpFunc(cmd, args)
+		return
+	}
+
 	// Get original kubectl help using the new implementation
 	cmdInfo, err := util.GetKubectlRootInfo()
 	if err != nil {
 		// Fallback to default help if kubectl help is not available
-		cmd.Help()
+		defaultHelpFunc(cmd, args)
 		return
 	}
 
@@ -73,6 +94,9 @@ const helpTemplate = `{{with (or .Long .Short)}}{{. | trimTrailingWhitespaces}}
 {{end}}{{if .Example}}Examples:
 {{.Example}}
 
+{{end}}{{if .HasAvailableSubCommands}}Available Commands:{{range .Commands}}{{if .IsAvailableCommand}}
+  {{rpad .Name .NamePadding}} {{.Short}}{{end}}{{end}}
+
 {{end}}{{if .HasAvailableFlags}}Options:
 {{.LocalFlags.FlagUsages | trimTrailingWhitespaces}}
 
@@ -123,7 +147,26 @@ func Execute() error {
 	// Set custom help function for root command
 	rootCmd.SetHelpFunc(rootHelpFunc)
 
-	return rootCmd.Execute()
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := configureNetwork(); err != nil {
+			return err
+		}
+		if err := applyProfile(cmd); err != nil {
+			return err
+		}
+		if err := redirectOutput(outputFile); err != nil {
+			return err
+		}
+		cmd.SetContext(startCommandContext(cmd.Context(), commandTimeout))
+		startTelemetry(cmd)
+		return nil
+	}
+
+	err := rootCmd.Execute()
+	err = finishCommandContext(err, os.Stderr)
+	err = finishOutputFile(outputFile, err)
+	finishTelemetry(err)
+	return err
 }
 
 func init() {
@@ -133,11 +176,25 @@ func init() {
 	rootCmd.PersistentFlags().BoolVar(&allClusters, "all-clusters", true, "operate on all managed clusters")
 	rootCmd.PersistentFlags().StringVarP(&namespace, "namespace", "n", "", "target namespace")
 	rootCmd.PersistentFlags().BoolVarP(&allNamespaces, "all-namespaces", "A", false, "list resources across all namespaces")
+	rootCmd.PersistentFlags().StringSliceVar(&itsNames, "its", nil, "comma-separated ITS contexts whose managed clusters to operate on (overrides --remote-context)")
+	rootCmd.PersistentFlags().BoolVar(&allITS, "all-its", false, "operate on the managed clusters of every ITS ControlPlane on the KubeFlex hosting cluster")
+	rootCmd.PersistentFlags().StringVar(&wdsCtx, "wds-context", "wds1", "context of the WDS holding BindingPolicy resources")
+	rootCmd.PersistentFlags().StringSliceVar(&wdsNames, "wds", nil, "comma-separated WDS contexts to apply BindingPolicy operations to (overrides --wds-context)")
+	rootCmd.PersistentFlags().StringVar(&contextPattern, "context-pattern", "", "when the ITS has no ManagedCluster resource (OCM not installed), operate on the kubeconfig contexts matching this glob (e.g. 'kind-*', or '*' for all)")
+	rootCmd.PersistentFlags().StringVar(&metricsJSON, "metrics-json", "", "write per-cluster API call counts, errors and durations as JSON to this file (- for stderr)")
+	rootCmd.PersistentFlags().DurationVar(&commandTimeout, "timeout", 0, "give up on the command after this long and print the results collected so far (e.g. 30s, zero means no limit); commands with their own --timeout keep its meaning")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "use this saved fleet profile instead of the current one (see 'kubectl multi profile')")
+	rootCmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "confirm changes to the fleet without asking, including changes to protected clusters")
+	rootCmd.PersistentFlags().StringVar(&outputFile, "output-file", "", "write the output of the command to this file, replacing it only when the command succeeds")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "forbid network access outside the configured clusters: no manifests from URLs, OCI pulls, helm chart downloads or repo updates, webhooks or trace export")
+	rootCmd.PersistentFlags().StringVar(&otlpEndpoint, "otlp-endpoint", os.Getenv(telemetry.EnvOTLPEndpoint), "OTLP/HTTP collector to send a trace of the API calls to (defaults to $OTEL_EXPORTER_OTLP_ENDPOINT)")
 
 	// Add subcommands
 	rootCmd.AddCommand(newGetCommand())
 	rootCmd.AddCommand(newDescribeCommand())
+	rootCmd.AddCommand(newTreeCommand())
 	rootCmd.AddCommand(newApplyCommand())
+	rootCmd.AddCommand(newDeployCommand())
 	rootCmd.AddCommand(newDeleteCommand())
 	rootCmd.AddCommand(newLogsCommand())
 	rootCmd.AddCommand(newExecCommand())
@@ -150,6 +207,42 @@ func init() {
 	rootCmd.AddCommand(newTopCommand())
 	rootCmd.AddCommand(newRunCommand())
 	rootCmd.AddCommand(newMultiGetCommand()) // Register multiget
+	rootCmd.AddCommand(newNamespaceCommand())
+	rootCmd.AddCommand(newQuotaReportCommand())
+	rootCmd.AddCommand(newRBACReportCommand())
+	rootCmd.AddCommand(newStorageCommand())
+	rootCmd.AddCommand(newImagesCommand())
+	rootCmd.AddCommand(newHotspotsCommand())
+	rootCmd.AddCommand(newExplainCommand())
+	rootCmd.AddCommand(newLegacyExplainPlacementCommand())
+	rootCmd.AddCommand(newStatusCommand())
+	rootCmd.AddCommand(newClustersCommand())
+	rootCmd.AddCommand(newGroupsCommand())
+	rootCmd.AddCommand(newProfileCommand())
+	rootCmd.AddCommand(newBindingPolicyCommand())
+	rootCmd.AddCommand(newWDSCommand())
+	rootCmd.AddCommand(newHistoryCommand())
+	rootCmd.AddCommand(newUndoCommand())
+	rootCmd.AddCommand(newKubeStellarCommand())
+	rootCmd.AddCommand(newMigrateCommand())
+	rootCmd.AddCommand(newDrillCommand())
+	rootCmd.AddCommand(newCompareCommand())
+	rootCmd.AddCommand(newExportCommand())
+	rootCmd.AddCommand(newCordonCommand())
+	rootCmd.AddCommand(newUncordonCommand())
+	rootCmd.AddCommand(newDrainCommand())
+	rootCmd.AddCommand(newWaitCommand())
+	rootCmd.AddCommand(newHealthzCommand())
+	rootCmd.AddCommand(newEndpointsReportCommand())
+	rootCmd.AddCommand(newMCSCommand())
+	rootCmd.AddCommand(newCronJobReportCommand())
+	rootCmd.AddCommand(newCertAuditCommand())
+	rootCmd.AddCommand(newBenchCommand())
+	rootCmd.AddCommand(newDoctorCommand())
+	rootCmd.AddCommand(newHelmCommand())
+	rootCmd.AddCommand(newServeCommand())
+	rootCmd.AddCommand(newDaemonCommand())
+	rootCmd.AddCommand(newScheduleCommand())
 
 	// Add the install command - NEW LINE
 	streams := genericclioptions.IOStreams{
@@ -164,3 +257,15 @@ func init() {
 func GetGlobalFlags() (string, string, bool, string, bool) {
 	return kubeconfig, remoteCtx, allClusters, namespace, allNamespaces
 }
+
+// GetWDSContext returns the kubeconfig context of the first selected
+// workload description space
+func GetWDSContext() string {
+	return GetWDSContexts()[0]
+}
+
+// GetWDSContexts returns the WDS contexts chosen by --wds, or the
+// --wds-context when --wds is not set
+func GetWDSContexts() []string {
+	return resolveWDSContexts(wdsNames, wdsCtx)
+}
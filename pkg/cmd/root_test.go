@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRootHelpFuncSubcommand(t *testing.T) {
+	rootCmd.SetHelpTemplate(helpTemplate)
+	rootCmd.SetHelpFunc(rootHelpFunc)
+
+	sub, _, err := rootCmd.Find([]string{"kubestellar"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	sub.SetOut(&out)
+	defer sub.SetOut(nil)
+
+	sub.HelpFunc()(sub, nil)
+	help := out.String()
+	if !strings.HasPrefix(help, sub.Short) {
+		t.Errorf("help of kubestellar does not start with its Short:\n%s", help)
+	}
+	if !strings.Contains(help, "verify-placement") {
+		t.Errorf("help of kubestellar does not list its subcommands:\n%s", help)
+	}
+	if strings.Contains(help, "kubectl-multi provides multi-cluster operations") {
+		t.Errorf("help of kubestellar is the root help:\n%s", help)
+	}
+}
+
+func TestContextPatternIsOptIn(t *testing.T) {
+	flag := rootCmd.PersistentFlags().Lookup("context-pattern")
+	if flag == nil {
+		t.Fatal("root command has no --context-pattern flag")
+	}
+	if flag.DefValue != "" {
+		t.Errorf("--context-pattern defaults to %q, want the fallback off", flag.DefValue)
+	}
+}
+
+func TestBindingPolicyUnderKubeStellar(t *testing.T) {
+	for _, path := range [][]string{{"kubestellar", "bp", "wait"}, {"bp", "wait"}} {
+		cmd, _, err := rootCmd.Find(path)
+		if err != nil {
+			t.Errorf("Find(%v) = %v", path, err)
+			continue
+		}
+		if cmd.Name() != "wait" || cmd.Parent().Name() != "bindingpolicy" {
+			t.Errorf("Find(%v) = %s, want bindingpolicy wait", path, cmd.CommandPath())
+		}
+	}
+}
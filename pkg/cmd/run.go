@@ -2,10 +2,16 @@ package cmd
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
+	"kubectl-multi/pkg/audit"
 	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
 
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
@@ -21,16 +27,197 @@ func newRunCommand() *cobra.Command {
 					return nil
 				}
 			}
+			args, fallback := takeKubectlFallback(args)
+			var spec *runSpec
+			if !fallback {
+				var err error
+				if spec, err = parseRunArgs(args); err != nil {
+					return err
+				}
+			}
 			kubeconfig, remoteCtx, _, _, _ := GetGlobalFlags()
-			return handleRunMulti(args, kubeconfig, remoteCtx)
+			var rec *audit.Recorder
+			if spec == nil || spec.DryRun == util.DryRunNone {
+				rec = startAudit("run")
+			}
+			err := handleRunMulti(args, spec, rec, kubeconfig, remoteCtx)
+			finishAudit(rec, err)
+			return err
 		},
 	}
 	cmd.DisableFlagParsing = true
 	return cmd
 }
 
-func handleRunMulti(args []string, kubeconfig, remoteCtx string) error {
-	clusters, err := cluster.DiscoverClusters(kubeconfig, remoteCtx)
+// runSpec is the pod described by kubectl run arguments
+type runSpec struct {
+	Pod    *corev1.Pod
+	DryRun string
+}
+
+// parseRunArgs builds the pod for "run NAME --image=IMAGE [flags] [-- ARGS]".
+// Flags outside the common set need --kubectl-fallback.
+func parseRunArgs(args []string) (*runSpec, error) {
+	spec := &runSpec{DryRun: util.DryRunNone}
+	container := corev1.Container{}
+	pod := &corev1.Pod{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		Spec:     corev1.PodSpec{RestartPolicy: corev1.RestartPolicyAlways},
+	}
+	labels := map[string]string{}
+	command := false
+	var trailing []string
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			trailing = args[i+1:]
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			if pod.Name != "" {
+				return nil, fmt.Errorf("run: unexpected argument %q, put container arguments after --", arg)
+			}
+			pod.Name = arg
+			continue
+		}
+
+		flag, value, hasValue := strings.Cut(arg, "=")
+		next := func() (string, error) {
+			if hasValue {
+				return value, nil
+			}
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("run: flag %s needs a value", flag)
+			}
+			i++
+			return args[i], nil
+		}
+
+		var err error
+		switch flag {
+		case "--image":
+			container.Image, err = next()
+		case "-n", "--namespace":
+			pod.Namespace, err = next()
+		case "-l", "--labels":
+			var v string
+			if v, err = next(); err == nil {
+				err = parseKeyValues(v, labels)
+			}
+		case "--annotations":
+			var v string
+			if v, err = next(); err == nil {
+				if pod.Annotations == nil {
+					pod.Annotations = map[string]string{}
+				}
+				err = parseKeyValues(v, pod.Annotations)
+			}
+		case "--env":
+			var v string
+			if v, err = next(); err == nil {
+				k, val, ok := strings.Cut(v, "=")
+				if !ok {
+					return nil, fmt.Errorf("run: --env must be NAME=VALUE, got %q", v)
+				}
+				container.Env = append(container.Env, corev1.EnvVar{Name: k, Value: val})
+			}
+		case "--port":
+			var v string
+			if v, err = next(); err == nil {
+				port, perr := strconv.Atoi(v)
+				if perr != nil {
+					return nil, fmt.Errorf("run: invalid --port %q", v)
+				}
+				container.Ports = append(container.Ports, corev1.ContainerPort{ContainerPort: int32(port)})
+			}
+		case "--restart":
+			var v string
+			if v, err = next(); err == nil {
+				switch corev1.RestartPolicy(v) {
+				case corev1.RestartPolicyAlways, corev1.RestartPolicyOnFailure, corev1.RestartPolicyNever:
+					pod.Spec.RestartPolicy = corev1.RestartPolicy(v)
+				default:
+					return nil, fmt.Errorf("run: invalid --restart %q, must be Always, OnFailure or Never", v)
+				}
+			}
+		case "--image-pull-policy":
+			var v string
+			if v, err = next(); err == nil {
+				container.ImagePullPolicy = corev1.PullPolicy(v)
+			}
+		case "--command":
+			command = !hasValue || value == "true"
+		case "--dry-run":
+			spec.DryRun = util.DryRunClient
+			if hasValue {
+				spec.DryRun = value
+			}
+			err = util.ValidateDryRun(spec.DryRun)
+		default:
+			return nil, unsupportedFlagError("run", flag)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if pod.Name == "" {
+		return nil, fmt.Errorf("run: NAME is required")
+	}
+	if container.Image == "" {
+		return nil, fmt.Errorf("run: --image is required")
+	}
+	container.Name = pod.Name
+	if command {
+		container.Command = trailing
+	} else {
+		container.Args = trailing
+	}
+	if len(labels) == 0 {
+		labels["run"] = pod.Name
+	}
+	pod.Labels = labels
+	pod.Spec.Containers = []corev1.Container{container}
+	spec.Pod = pod
+	return spec, nil
+}
+
+// parseKeyValues adds comma-separated key=value pairs to m
+func parseKeyValues(value string, m map[string]string) error {
+	for _, pair := range strings.Split(value, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || k == "" {
+			return fmt.Errorf("run: invalid key=value pair %q", pair)
+		}
+		m[k] = v
+	}
+	return nil
+}
+
+// createRunPod creates the pod of a parsed run in one cluster
+func createRunPod(c cluster.ClusterInfo, spec *runSpec) (string, error) {
+	if c.Client == nil {
+		return "", fmt.Errorf("no client available for cluster %s", c.Name)
+	}
+	pod := spec.Pod.DeepCopy()
+	pod.Namespace = cluster.GetTargetNamespace(pod.Namespace)
+	util.MarkManaged(pod)
+	switch spec.DryRun {
+	case util.DryRunClient:
+		return "pod/" + pod.Name + " created (dry run)", nil
+	case util.DryRunServer:
+		_, err := c.Client.CoreV1().Pods(pod.Namespace).Create(commandContext(), pod, metav1.CreateOptions{FieldManager: util.FieldManager, DryRun: []string{metav1.DryRunAll}})
+		return "pod/" + pod.Name + " created (server dry run)", err
+	}
+	_, err := c.Client.CoreV1().Pods(pod.Namespace).Create(commandContext(), pod, metav1.CreateOptions{FieldManager: util.FieldManager})
+	return "pod/" + pod.Name + " created", err
+}
+
+// handleRunMulti creates the pod with the built-in client, or runs kubectl
+// run with the raw arguments when spec is nil (--kubectl-fallback)
+func handleRunMulti(args []string, spec *runSpec, rec *audit.Recorder, kubeconfig, remoteCtx string) error {
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
 	if err != nil {
 		return fmt.Errorf("failed to discover clusters: %v", err)
 	}
@@ -61,10 +248,23 @@ func handleRunMulti(args []string, kubeconfig, remoteCtx string) error {
 		contextToCluster[c.Context] = c
 	}
 
-	// 1. Run for current context (if present)
-	if cinfo, ok := contextToCluster[currentContext]; ok {
-		output, err := runKubectl(append([]string{"run"}, append(args, "--context", cinfo.Context)...), kubeconfig)
-		fmt.Printf("=== Cluster: %s ===\n", cinfo.Context)
+	if err := guardFanOut(rec, blastRadius{Objects: fanOutTargets(clusters, itsContext)}, clusters, itsContext); err != nil {
+		return err
+	}
+	trackFanOut(rec, fanOutTargets(clusters, itsContext))
+	runInCluster := func(c cluster.ClusterInfo) {
+		rec.Begin(c.Name)
+		var output string
+		var err error
+		if spec == nil {
+			output, err = runKubectl(append([]string{"run"}, append(args, "--context", c.Context)...), kubeconfig)
+		} else {
+			output, err = createRunPod(c, spec)
+			output += "\n"
+		}
+		rec.Record(c.Name, err)
+		recordRunUndo(rec, c.Name, args, err)
+		fmt.Printf("=== Cluster: %s ===\n", c.Context)
 		if err != nil {
 			fmt.Printf("Error: %v\n", err)
 		} else {
@@ -73,19 +273,17 @@ func handleRunMulti(args []string, kubeconfig, remoteCtx string) error {
 		fmt.Println()
 	}
 
+	// 1. Run for current context (if present)
+	if cinfo, ok := contextToCluster[currentContext]; ok {
+		runInCluster(cinfo)
+	}
+
 	// 2. Run for KubeStellar clusters (excluding ITS and current)
 	for _, c := range clusters {
 		if c.Context == currentContext || c.Context == itsContext {
 			continue
 		}
-		output, err := runKubectl(append([]string{"run"}, append(args, "--context", c.Context)...), kubeconfig)
-		fmt.Printf("=== Cluster: %s ===\n", c.Context)
-		if err != nil {
-			fmt.Printf("Error: %v\n", err)
-		} else {
-			fmt.Print(output)
-		}
-		fmt.Println()
+		runInCluster(c)
 	}
 
 	// 3. Print warning for ITS (control) cluster
@@ -97,3 +295,36 @@ func handleRunMulti(args []string, kubeconfig, remoteCtx string) error {
 
 	return nil
 }
+
+// recordRunUndo records deleting the pod started by a successful run
+func recordRunUndo(rec *audit.Recorder, clusterName string, args []string, err error) {
+	if err != nil {
+		return
+	}
+	name, ns := runPodTarget(args)
+	if name == "" {
+		return
+	}
+	rec.AddUndo(audit.UndoStep{Cluster: clusterName, Action: audit.UndoDelete, Version: "v1", Resource: "pods", Namespace: ns, Name: name})
+}
+
+// runPodTarget extracts the pod name and namespace from kubectl run
+// arguments. The name is expected first, as in "kubectl run NAME --image=...".
+func runPodTarget(args []string) (string, string) {
+	name := ""
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name = args[0]
+	}
+	ns := ""
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if (arg == "-n" || arg == "--namespace") && i+1 < len(args) {
+			ns = args[i+1]
+		} else if strings.HasPrefix(arg, "--namespace=") {
+			ns = strings.TrimPrefix(arg, "--namespace=")
+		}
+	}
+	return name, cluster.GetTargetNamespace(ns)
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"kubectl-multi/pkg/util"
+)
+
+func TestParseRunArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		check   func(t *testing.T, spec *runSpec)
+		wantErr string
+	}{
+		{
+			name: "minimal",
+			args: []string{"nginx", "--image=nginx"},
+			check: func(t *testing.T, spec *runSpec) {
+				pod := spec.Pod
+				if pod.Name != "nginx" || pod.Spec.Containers[0].Name != "nginx" || pod.Spec.Containers[0].Image != "nginx" {
+					t.Errorf("pod = %s with container %+v", pod.Name, pod.Spec.Containers[0])
+				}
+				if !reflect.DeepEqual(pod.Labels, map[string]string{"run": "nginx"}) {
+					t.Errorf("labels = %v, want run=nginx", pod.Labels)
+				}
+				if pod.Spec.RestartPolicy != corev1.RestartPolicyAlways || spec.DryRun != util.DryRunNone {
+					t.Errorf("restart = %s, dry run = %s", pod.Spec.RestartPolicy, spec.DryRun)
+				}
+			},
+		},
+		{
+			name: "separate values and flags",
+			args: []string{"web", "--image", "nginx:1.25", "-n", "apps", "-l", "app=web,tier=front", "--env", "A=1", "--port=8080", "--restart", "Never", "--annotations=team=x"},
+			check: func(t *testing.T, spec *runSpec) {
+				pod := spec.Pod
+				c := pod.Spec.Containers[0]
+				if pod.Namespace != "apps" || c.Image != "nginx:1.25" || pod.Spec.RestartPolicy != corev1.RestartPolicyNever {
+					t.Errorf("pod = %+v", pod)
+				}
+				if !reflect.DeepEqual(pod.Labels, map[string]string{"app": "web", "tier": "front"}) {
+					t.Errorf("labels = %v", pod.Labels)
+				}
+				if !reflect.DeepEqual(pod.Annotations, map[string]string{"team": "x"}) {
+					t.Errorf("annotations = %v", pod.Annotations)
+				}
+				if len(c.Env) != 1 || c.Env[0].Name != "A" || c.Env[0].Value != "1" {
+					t.Errorf("env = %v", c.Env)
+				}
+				if len(c.Ports) != 1 || c.Ports[0].ContainerPort != 8080 {
+					t.Errorf("ports = %v", c.Ports)
+				}
+			},
+		},
+		{
+			name: "trailing args",
+			args: []string{"bb", "--image=busybox", "--", "sleep", "3600"},
+			check: func(t *testing.T, spec *runSpec) {
+				c := spec.Pod.Spec.Containers[0]
+				if !reflect.DeepEqual(c.Args, []string{"sleep", "3600"}) || c.Command != nil {
+					t.Errorf("command = %v, args = %v", c.Command, c.Args)
+				}
+			},
+		},
+		{
+			name: "trailing command",
+			args: []string{"bb", "--image=busybox", "--command", "--", "sleep", "3600"},
+			check: func(t *testing.T, spec *runSpec) {
+				c := spec.Pod.Spec.Containers[0]
+				if !reflect.DeepEqual(c.Command, []string{"sleep", "3600"}) || c.Args != nil {
+					t.Errorf("command = %v, args = %v", c.Command, c.Args)
+				}
+			},
+		},
+		{
+			name: "bare dry run is client",
+			args: []string{"nginx", "--image=nginx", "--dry-run"},
+			check: func(t *testing.T, spec *runSpec) {
+				if spec.DryRun != util.DryRunClient {
+					t.Errorf("dry run = %s, want client", spec.DryRun)
+				}
+			},
+		},
+		{name: "missing name", args: []string{"--image=nginx"}, wantErr: "NAME is required"},
+		{name: "missing image", args: []string{"nginx"}, wantErr: "--image is required"},
+		{name: "missing value", args: []string{"nginx", "--image"}, wantErr: "needs a value"},
+		{name: "second name", args: []string{"nginx", "extra", "--image=nginx"}, wantErr: "unexpected argument"},
+		{name: "bad restart", args: []string{"nginx", "--image=nginx", "--restart=Sometimes"}, wantErr: "invalid --restart"},
+		{name: "bad port", args: []string{"nginx", "--image=nginx", "--port=http"}, wantErr: "invalid --port"},
+		{name: "bad env", args: []string{"nginx", "--image=nginx", "--env=A"}, wantErr: "NAME=VALUE"},
+		{name: "bad labels", args: []string{"nginx", "--image=nginx", "-l", "app"}, wantErr: "invalid key=value"},
+		{name: "bad dry run", args: []string{"nginx", "--image=nginx", "--dry-run=true"}, wantErr: "invalid --dry-run"},
+		{name: "niche flag", args: []string{"nginx", "--image=nginx", "--overrides={}"}, wantErr: "--kubectl-fallback"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := parseRunArgs(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseRunArgs() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseRunArgs() error = %v", err)
+			}
+			tt.check(t, spec)
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/config"
+)
+
+// assumeYes is the --yes flag, confirming every fleet mutation up front
+var assumeYes bool
+
+// blastRadius summarizes what a mutating command is about to change
+type blastRadius struct {
+	Command    string
+	Clusters   []string
+	Namespaces []string
+	// Objects is the number of objects changed across the clusters, 0 when
+	// not known up front
+	Objects int
+}
+
+// summary reports the blast radius on one line, e.g. "apply changes 3
+// clusters (c1, c2, c3), 1 namespace (prod), 12 objects"
+func (b blastRadius) summary() string {
+	parts := []string{countedList(len(b.Clusters), "cluster", b.Clusters)}
+	if len(b.Namespaces) > 0 {
+		parts = append(parts, countedList(len(b.Namespaces), "namespace", b.Namespaces))
+	}
+	if b.Objects > 0 {
+		parts = append(parts, plural(b.Objects, "object"))
+	}
+	return fmt.Sprintf("%s changes %s", b.Command, strings.Join(parts, ", "))
+}
+
+// countedList renders "2 clusters (a, b)", abbreviating long lists
+func countedList(n int, noun string, names []string) string {
+	const shown = 5
+	listed := names
+	if len(listed) > shown {
+		listed = append(append([]string{}, names[:shown]...), fmt.Sprintf("%d more", len(names)-shown))
+	}
+	return fmt.Sprintf("%s (%s)", plural(n, noun), strings.Join(listed, ", "))
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// confirmation decides whether a fleet mutation may go ahead
+type confirmation struct {
+	yes         bool
+	interactive bool
+	// skip is the skipConfirmation setting
+	skip bool
+	in   io.Reader
+	out  io.Writer
+}
+
+// check lets the change through when --yes was given, or when confirmation
+// is skipped and no protected cluster is reached. Otherwise, at a terminal,
+// it shows the blast radius and asks; without a terminal there is no one to
+// ask, so the change is refused.
+func (c confirmation) check(b blastRadius, protected []string) error {
+	if c.yes {
+		return nil
+	}
+	if c.skip && len(protected) == 0 {
+		return nil
+	}
+	if !c.interactive {
+		if len(protected) > 0 {
+			return fmt.Errorf("%s reaches protected cluster(s) %s; confirm with --yes", b.Command, strings.Join(protected, ", "))
+		}
+		return fmt.Errorf("%s needs confirming and stdin is not a terminal; confirm with --yes or set safety.skipConfirmation", b.Command)
+	}
+	fmt.Fprintln(c.out, b.summary())
+	if len(protected) > 0 {
+		fmt.Fprintf(c.out, "Protected clusters: %s\n", strings.Join(protected, ", "))
+	}
+	p := &prompter{in: bufio.NewReader(c.in), out: c.out}
+	ok, err := p.confirm("Proceed?")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%s cancelled", b.Command)
+	}
+	return nil
+}
+
+// guardFanOut runs the guardrails of a fleet mutation before it changes
+// anything: the confirmation, then the fleet lock. The clusters are the
+// targeted ones; the ITS among them is left out.
+func guardFanOut(rec *audit.Recorder, b blastRadius, clusters []cluster.ClusterInfo, itsContext string) error {
+	return guardClusters(rec, b, workloadClusters(clusters, itsContext))
+}
+
+// guardManagedClusters runs the guardrails of a fleet mutation made through
+// the ITS, to the named ManagedClusters or to what they run, such as the
+// BindingPolicies selecting them
+func guardManagedClusters(rec *audit.Recorder, b blastRadius, names []string, itsContext string) error {
+	return guardClusters(rec, b, managedClusterTargets(names, itsContext))
+}
+
+// guardClusters runs the guardrails of a fleet mutation of the workload
+// clusters
+func guardClusters(rec *audit.Recorder, b blastRadius, workload []cluster.ClusterInfo) error {
+	if err := confirmFanOut(rec, b, workload); err != nil {
+		return err
+	}
+	return acquireFleetLock(rec)
+}
+
+// managedClusterTargets stands for the named ManagedClusters of the ITS
+// itsContext in a blast radius, without clients
+func managedClusterTargets(names []string, itsContext string) []cluster.ClusterInfo {
+	clusters := make([]cluster.ClusterInfo, 0, len(names))
+	for _, name := range names {
+		clusters = append(clusters, cluster.ClusterInfo{Name: name, ITS: itsContext})
+	}
+	return clusters
+}
+
+// confirmFanOut shows the blast radius of a fleet mutation of the workload
+// clusters on stderr and asks for confirmation as the safety settings say.
+// Dry runs, which are not recorded, are never confirmed.
+func confirmFanOut(rec *audit.Recorder, b blastRadius, workload []cluster.ClusterInfo) error {
+	if rec == nil || len(workload) == 0 {
+		return nil
+	}
+	for _, c := range workload {
+		b.Clusters = append(b.Clusters, c.Name)
+	}
+	b.Command = rec.Command()
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	settings := cfg.SafetySettings()
+	var protected []string
+	if settings.ProtectedClusters != "" && !assumeYes {
+		kubeconfig, _, _, _, _ := GetGlobalFlags()
+		matched, err := cluster.SelectClustersByLabels(commandContext(), workload, kubeconfig, settings.ProtectedClusters)
+		if err != nil {
+			return fmt.Errorf("failed to check for protected clusters: %v", err)
+		}
+		for _, c := range matched {
+			protected = append(protected, c.Name)
+		}
+	}
+	return confirmation{
+		yes:         assumeYes,
+		interactive: term.IsTerminal(int(os.Stdin.Fd())),
+		skip:        settings.SkipConfirmation,
+		in:          os.Stdin,
+		out:         os.Stderr,
+	}.check(b, protected)
+}
+
+// objectNamespaces lists the namespaces the objects go to, sorted; objects
+// without a namespace go to the target namespace
+func objectNamespaces(objs []*unstructured.Unstructured, namespace string) []string {
+	seen := map[string]bool{}
+	for _, obj := range objs {
+		ns := obj.GetNamespace()
+		if ns == "" {
+			ns = cluster.GetTargetNamespace(namespace)
+		}
+		seen[ns] = true
+	}
+	return sortedKeys(seen)
+}
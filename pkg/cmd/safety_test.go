@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/config"
+)
+
+func TestBlastRadiusSummary(t *testing.T) {
+	b := blastRadius{Command: "apply", Clusters: []string{"c1", "c2", "c3"}, Namespaces: []string{"prod"}, Objects: 12}
+	if got, want := b.summary(), "apply changes 3 clusters (c1, c2, c3), 1 namespace (prod), 12 objects"; got != want {
+		t.Errorf("summary() = %q, want %q", got, want)
+	}
+	b = blastRadius{Command: "drain", Clusters: []string{"c1", "c2", "c3", "c4", "c5", "c6", "c7"}}
+	if got, want := b.summary(), "drain changes 7 clusters (c1, c2, c3, c4, c5, 2 more)"; got != want {
+		t.Errorf("summary() = %q, want %q", got, want)
+	}
+}
+
+func TestConfirmationCheck(t *testing.T) {
+	b := blastRadius{Command: "apply", Clusters: []string{"dev1", "prod1"}}
+	tests := []struct {
+		name       string
+		c          confirmation
+		protected  []string
+		answer     string
+		wantErr    string
+		wantPrompt bool
+	}{
+		{name: "yes", c: confirmation{yes: true, interactive: true}, protected: []string{"prod1"}},
+		{name: "script", c: confirmation{}, wantErr: "apply needs confirming and stdin is not a terminal; confirm with --yes or set safety.skipConfirmation"},
+		{name: "script with confirmation skipped", c: confirmation{skip: true}},
+		{name: "script with confirmation skipped reaching protected cluster", c: confirmation{skip: true}, protected: []string{"prod1"}, wantErr: "apply reaches protected cluster(s) prod1; confirm with --yes"},
+		{name: "script reaching protected cluster", c: confirmation{}, protected: []string{"prod1"}, wantErr: "apply reaches protected cluster(s) prod1; confirm with --yes"},
+		{name: "confirmed", c: confirmation{interactive: true}, answer: "y\n", wantPrompt: true},
+		{name: "declined", c: confirmation{interactive: true}, answer: "\n", wantErr: "apply cancelled", wantPrompt: true},
+		{name: "skipped", c: confirmation{interactive: true, skip: true}},
+		{name: "skipped but protected", c: confirmation{interactive: true, skip: true}, protected: []string{"prod1"}, answer: "yes\n", wantPrompt: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			tt.c.in, tt.c.out = strings.NewReader(tt.answer), &out
+			err := tt.c.check(b, tt.protected)
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("check() error = %v, want %q", err, tt.wantErr)
+			}
+			if prompted := strings.Contains(out.String(), "Proceed? [y/N]"); prompted != tt.wantPrompt {
+				t.Errorf("prompted = %v, want %v; output %q", prompted, tt.wantPrompt, out.String())
+			}
+			if tt.wantPrompt && !strings.Contains(out.String(), "apply changes 2 clusters (dev1, prod1)") {
+				t.Errorf("output %q does not show the blast radius", out.String())
+			}
+			if tt.wantPrompt && len(tt.protected) > 0 && !strings.Contains(out.String(), "Protected clusters: prod1") {
+				t.Errorf("output %q does not name the protected clusters", out.String())
+			}
+		})
+	}
+}
+
+func TestGuardManagedClusters(t *testing.T) {
+	t.Setenv(config.EnvConfigPath, filepath.Join(t.TempDir(), "config.yaml"))
+	defer func(yes bool) { assumeYes = yes }(assumeYes)
+	assumeYes = false
+	rec := audit.Start("clusters taint", nil)
+
+	// Tests have no terminal to confirm at
+	err := guardManagedClusters(rec, blastRadius{}, []string{"cluster1"}, "its1")
+	if err == nil || !strings.Contains(err.Error(), "not a terminal") {
+		t.Errorf("guardManagedClusters() without a terminal = %v, want a refusal", err)
+	}
+	if err := guardManagedClusters(nil, blastRadius{}, []string{"cluster1"}, "its1"); err != nil {
+		t.Errorf("guardManagedClusters() of a dry run = %v, want nil", err)
+	}
+	if err := guardManagedClusters(rec, blastRadius{}, nil, "its1"); err != nil {
+		t.Errorf("guardManagedClusters() of no cluster = %v, want nil", err)
+	}
+	assumeYes = true
+	if err := guardManagedClusters(rec, blastRadius{}, []string{"cluster1"}, "its1"); err != nil {
+		t.Errorf("guardManagedClusters() with --yes = %v, want nil", err)
+	}
+}
+
+func TestObjectNamespaces(t *testing.T) {
+	objs := []*unstructured.Unstructured{
+		testObject("v1", "ConfigMap", "prod", "a"),
+		testObject("v1", "ConfigMap", "", "b"),
+		testObject("apps/v1", "Deployment", "prod", "c"),
+	}
+	if got := objectNamespaces(objs, "web"); !reflect.DeepEqual(got, []string{"prod", "web"}) {
+		t.Errorf("objectNamespaces() = %v, want [prod web]", got)
+	}
+}
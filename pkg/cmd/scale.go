@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+// scaleOptions holds the flags of scale
+type scaleOptions struct {
+	Replicas        int
+	CurrentReplicas int
+	DryRun          string
+	Force           bool
+	Targets         clusterTargets
+	Strategy        fanOutStrategy
+}
+
+func newScaleCommand() *cobra.Command {
+	var o scaleOptions
+
+	cmd := &cobra.Command{
+		Use:   "scale (TYPE/NAME | TYPE NAME) --replicas=COUNT",
+		Short: "Set a new size for a deployment, replica set, or stateful set across managed clusters",
+		Long: `Set a new size for a deployment, replica set, or stateful set across managed clusters.
+The replica count is set in every selected cluster and each cluster's result is
+printed separately. A cluster where the workload is missing, or where
+--current-replicas does not match, fails without stopping the others, unless
+a --strategy halts the rollout after the failed wave.`,
+		Example: `# Scale nginx to 3 replicas in every managed cluster
+kubectl multi scale deployment/nginx -n prod --replicas=3
+
+# Only scale clusters where nginx currently runs 2 replicas
+kubectl multi scale deployment nginx -n prod --replicas=3 --current-replicas=2
+
+# Scale cluster1 first, then the others two at a time, a minute apart
+kubectl multi scale deployment/nginx -n prod --replicas=5 --strategy canary --canary cluster1 --wave-size 2 --wave-pause 1m`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resourceType, name, err := parseTypeName(args)
+			if err != nil {
+				return err
+			}
+			if o.Replicas < 0 {
+				return fmt.Errorf("must specify --replicas of 0 or more")
+			}
+			if err := util.ValidateDryRun(o.DryRun); err != nil {
+				return err
+			}
+			if o.DryRun == util.DryRunClient {
+				return fmt.Errorf("--dry-run=client is not supported by scale, use --dry-run=server")
+			}
+			if err := o.Strategy.validate(); err != nil {
+				return err
+			}
+
+			kubeconfig, remoteCtx, _, namespace, _ := GetGlobalFlags()
+			var rec *audit.Recorder
+			if o.DryRun != util.DryRunServer {
+				rec = startAudit("scale")
+			}
+			err = handleScaleCommand(resourceType, name, o, rec, kubeconfig, remoteCtx, namespace)
+			finishAudit(rec, err)
+			return err
+		},
+	}
+
+	cmd.Flags().IntVar(&o.Replicas, "replicas", -1, "the new desired number of replicas")
+	cmd.Flags().IntVar(&o.CurrentReplicas, "current-replicas", -1, "precondition for the current size: clusters where it differs are not scaled")
+	cmd.Flags().StringVar(&o.DryRun, "dry-run", "none", "must be \"none\" or \"server\"")
+	cmd.Flags().BoolVar(&o.Force, "force", false, "scale workloads that KubeStellar downsyncs to the cluster, although the next downsync reverts the change")
+	o.Targets.addFlags(cmd, "scale")
+	o.Strategy.addFlags(cmd)
+
+	return cmd
+}
+
+func handleScaleCommand(resourceType, name string, o scaleOptions, rec *audit.Recorder, kubeconfig, remoteCtx, namespace string) error {
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	clusters, err = o.Targets.selectFrom(clusters, kubeconfig)
+	if err != nil {
+		return err
+	}
+	if len(clusters) == 0 {
+		return fmt.Errorf("no clusters discovered")
+	}
+	radius := blastRadius{Objects: fanOutTargets(clusters, remoteCtx), Namespaces: []string{cluster.GetTargetNamespace(namespace)}}
+	if err := guardFanOut(rec, radius, clusters, remoteCtx); err != nil {
+		return err
+	}
+	return scaleClusters(clusters, remoteCtx, resourceType, name, namespace, o, rec, os.Stdout)
+}
+
+// scaleClusters sets the replica count in every cluster but the ITS, wave by
+// wave as the strategy says, and prints each cluster's result
+func scaleClusters(clusters []cluster.ClusterInfo, itsContext, resourceType, name, namespace string, o scaleOptions, rec *audit.Recorder, out io.Writer) error {
+	trackFanOut(rec, fanOutTargets(clusters, itsContext))
+	var workload []cluster.ClusterInfo
+	for _, c := range clusters {
+		if c.Context == itsContext {
+			fmt.Fprintf(out, "=== Cluster: %s ===\n", c.Context)
+			fmt.Fprintf(out, "Cannot perform this operation on ITS (control) cluster: %s\n\n", c.Context)
+			continue
+		}
+		workload = append(workload, c)
+	}
+
+	var failed []string
+	haltErr := o.Strategy.run(workload, func(c cluster.ClusterInfo) error {
+		fmt.Fprintf(out, "=== Cluster: %s ===\n", c.Context)
+		rec.Begin(c.Name)
+		result, err := scaleInCluster(c, resourceType, name, namespace, o, rec)
+		rec.Record(c.Name, err)
+		if err != nil {
+			failed = append(failed, c.Name)
+			fmt.Fprintf(out, "Error: %v\n\n", err)
+			return err
+		}
+		fmt.Fprintf(out, "%s\n\n", result)
+		return nil
+	})
+	if haltErr != nil {
+		return haltErr
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("scale failed in %d of %d clusters: %s", len(failed), len(workload), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// scaleInCluster sets spec.replicas of one workload and returns a
+// kubectl-style result line
+func scaleInCluster(c cluster.ClusterInfo, resourceType, name, namespace string, o scaleOptions, rec *audit.Recorder) (string, error) {
+	if c.DynamicClient == nil || c.DiscoveryClient == nil {
+		return "", fmt.Errorf("no clients available for cluster %s", c.Name)
+	}
+	gvr, namespaced, err := util.DiscoverGVR(c.DiscoveryClient, resourceType)
+	if err != nil {
+		return "", err
+	}
+	if !namespaced {
+		return "", fmt.Errorf("%s cannot be scaled", gvr.Resource)
+	}
+	ns := cluster.GetTargetNamespace(namespace)
+	client := c.DynamicClient.Resource(gvr).Namespace(ns)
+
+	live, err := client.Get(commandContext(), name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	ref := qualifiedName(gvr, live)
+	current, found, err := unstructured.NestedInt64(live.Object, "spec", "replicas")
+	if err != nil || !found {
+		return "", fmt.Errorf("%s has no spec.replicas and cannot be scaled", ref)
+	}
+	if o.CurrentReplicas >= 0 && current != int64(o.CurrentReplicas) {
+		return "", fmt.Errorf("%s has %d replicas, not the expected --current-replicas=%d", ref, current, o.CurrentReplicas)
+	}
+	var warnings strings.Builder
+	if err := guardDownsynced(ref, c.Name, live, o.Force, &warnings); err != nil {
+		return "", err
+	}
+
+	patch, err := util.ManagedPatch(types.MergePatchType, []byte(fmt.Sprintf(`{"spec":{"replicas":%d}}`, o.Replicas)), live)
+	if err != nil {
+		return "", err
+	}
+	opts := metav1.PatchOptions{FieldManager: util.FieldManager}
+	if o.DryRun == util.DryRunServer {
+		opts.DryRun = []string{metav1.DryRunAll}
+	}
+	if _, err := client.Patch(commandContext(), name, types.MergePatchType, patch, opts); err != nil {
+		return "", err
+	}
+
+	switch {
+	case o.DryRun == util.DryRunServer:
+		return warnings.String() + ref + " scaled (server dry run)", nil
+	case current == int64(o.Replicas):
+		return warnings.String() + ref + " scaled (no change)", nil
+	}
+	recordRestoreUndo(rec, c.Name, gvr, ns, live, o.DryRun)
+	return warnings.String() + ref + " scaled", nil
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+func testScaledDeployment(replicas int64) *unstructured.Unstructured {
+	obj := testObject("apps/v1", "Deployment", "default", "nginx")
+	unstructured.SetNestedField(obj.Object, replicas, "spec", "replicas")
+	return obj
+}
+
+func TestScaleClusters(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	tests := []struct {
+		name      string
+		opts      scaleOptions
+		missing   bool
+		wantLines []string
+		wantErr   string
+		want1     int64
+		wantUndo  int
+	}{
+		{
+			name:      "scale",
+			opts:      scaleOptions{Replicas: 3, CurrentReplicas: -1},
+			wantLines: []string{"=== Cluster: cluster1 ===\ndeployment.apps/nginx scaled\n", "=== Cluster: cluster2 ===\ndeployment.apps/nginx scaled\n"},
+			want1:     3,
+			wantUndo:  2,
+		},
+		{
+			name:      "no change",
+			opts:      scaleOptions{Replicas: 1, CurrentReplicas: -1},
+			wantLines: []string{"deployment.apps/nginx scaled (no change)"},
+			want1:     1,
+		},
+		{
+			name:      "current replicas mismatch",
+			opts:      scaleOptions{Replicas: 3, CurrentReplicas: 2},
+			wantLines: []string{"Error: deployment.apps/nginx has 1 replicas, not the expected --current-replicas=2"},
+			wantErr:   "scale failed in 2 of 2 clusters: cluster1, cluster2",
+			want1:     1,
+		},
+		{
+			name:      "missing in one cluster",
+			opts:      scaleOptions{Replicas: 3, CurrentReplicas: -1},
+			missing:   true,
+			wantLines: []string{"=== Cluster: cluster2 ===\nError: "},
+			wantErr:   "scale failed in 1 of 2 clusters: cluster2",
+			want1:     3,
+			wantUndo:  1,
+		},
+		{
+			name:      "canary halts",
+			opts:      scaleOptions{Replicas: 3, CurrentReplicas: -1, Strategy: fanOutStrategy{Name: strategyCanary, Canary: "cluster2"}},
+			missing:   true,
+			wantLines: []string{"=== Cluster: cluster2 ===\nError: "},
+			wantErr:   "halted after wave 1/2, failed in cluster2; not changed: cluster1",
+			want1:     1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.opts.Strategy.Name == "" {
+				tt.opts.Strategy.Name = strategyAll
+			}
+			tt.opts.DryRun = util.DryRunNone
+			c1, _ := testClusterInfo(testScaledDeployment(1))
+			c2, _ := testClusterInfo(testScaledDeployment(1))
+			if tt.missing {
+				c2, _ = testClusterInfo()
+			}
+			its, _ := testClusterInfo()
+			c1.Context = "cluster1"
+			c2.Name, c2.Context = "cluster2", "cluster2"
+			its.Name, its.Context = "its1", "its1"
+
+			var out bytes.Buffer
+			rec := audit.Start("scale", nil)
+			err := scaleClusters([]cluster.ClusterInfo{c1, c2, its}, "its1", "deployment", "nginx", "default", tt.opts, rec, &out)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("scaleClusters() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("scaleClusters() error = %v", err)
+			}
+			for _, line := range append(tt.wantLines, "Cannot perform this operation on ITS (control) cluster: its1") {
+				if !strings.Contains(out.String(), line) {
+					t.Errorf("output %q does not contain %q", out.String(), line)
+				}
+			}
+			if got := len(rec.Finish(err).Undo); got != tt.wantUndo {
+				t.Errorf("undo steps = %d, want %d", got, tt.wantUndo)
+			}
+			obj, err := c1.DynamicClient.Resource(gvr).Namespace("default").Get(context.TODO(), "nginx", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, _, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas"); got != tt.want1 {
+				t.Errorf("replicas in cluster1 = %d, want %d", got, tt.want1)
+			}
+		})
+	}
+}
@@ -0,0 +1,442 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"kubectl-multi/pkg/config"
+	"kubectl-multi/pkg/schedule"
+	"kubectl-multi/pkg/util"
+)
+
+// unschedulableCommands run until stopped or need a terminal, so they make
+// no sense as scheduled tasks
+var unschedulableCommands = map[string]bool{"daemon": true, "schedule": true, "serve": true, "edit": true}
+
+// scheduleLogMaxBytes is the size at which the log of a task is rotated to
+// NAME.log.1
+const scheduleLogMaxBytes = 10 << 20
+
+// scheduleExecutable returns the program running scheduled tasks, this
+// kubectl-multi binary; replaced in tests
+var scheduleExecutable = os.Executable
+
+func newScheduleCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "Run kubectl multi commands on a cron schedule",
+		Long: `Register kubectl multi commands to run on a cron schedule, such as a nightly
+report of the fleet. The tasks are stored next to the plugin config
+(kubectl-multi-schedules.yaml) and run by the local daemon ('kubectl multi
+daemon start'), which checks them every minute; tasks due while no daemon runs
+are not caught up.
+
+Each run appends the output of the command to a log per task in the
+kubectl-multi-schedules directory next to the plugin config. Use the global
+--output-file flag in the command to keep its result in a file of its own.
+
+Tasks run with --yes, since no one is there to confirm: scheduling a command
+that changes the fleet confirms its changes, to protected clusters too.`,
+	}
+	cmd.AddCommand(newScheduleAddCommand())
+	cmd.AddCommand(newScheduleListCommand())
+	cmd.AddCommand(newScheduleRemoveCommand())
+	cmd.AddCommand(newScheduleRunCommand())
+	return cmd
+}
+
+func newScheduleAddCommand() *cobra.Command {
+	var name string
+	var overwrite bool
+
+	cmd := &cobra.Command{
+		Use:   "add SCHEDULE -- COMMAND [ARGS...]",
+		Short: "Register a command to run on a cron schedule",
+		Long: `Register a kubectl multi command to run on a cron schedule. SCHEDULE is a cron
+expression of five fields (minute, hour, day of month, month, day of week) in
+local time, or one of @hourly, @daily, @weekly, @monthly and @yearly. The
+command runs in the current directory, so relative paths in it resolve as
+they do now. It runs with --yes: check what a command changes with --dry-run
+before scheduling it.`,
+		Example: `# Every night at 2:00, save the pods of the fleet as JSON
+kubectl multi schedule add "0 2 * * *" -- get pods -A -o json --output-file nightly.json
+
+# Every 15 minutes on weekdays, named
+kubectl multi schedule add "*/15 * * * mon-fri" --name health -- doctor`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if cmd.ArgsLenAtDash() != 1 || len(args) < 2 {
+				return fmt.Errorf("usage: kubectl multi schedule add SCHEDULE -- COMMAND [ARGS...]")
+			}
+			return handleScheduleAdd(args[0], args[1:], name, overwrite)
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", "", "name of the task (defaults to the command and a number, e.g. get-1)")
+	cmd.Flags().BoolVar(&overwrite, "overwrite", false, "replace the task if one with this name exists")
+	return cmd
+}
+
+func newScheduleListCommand() *cobra.Command {
+	var outputFormat string
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the scheduled tasks with their next and latest runs",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch outputFormat {
+			case "", "json", "yaml":
+			default:
+				return fmt.Errorf("unsupported output format %q, must be one of json|yaml", outputFormat)
+			}
+			tasks, err := schedule.Load(config.SchedulesPath())
+			if err != nil {
+				return err
+			}
+			if outputFormat != "" {
+				if tasks == nil {
+					tasks = []schedule.Task{}
+				}
+				return util.PrintStructured(util.GetOutputStream(), outputFormat, tasks)
+			}
+			printScheduledTasks(util.GetOutputStream(), tasks, time.Now())
+			if len(tasks) > 0 {
+				warnIfNoDaemon()
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "output format (json|yaml)")
+	return cmd
+}
+
+func newScheduleRemoveCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "remove NAME...",
+		Aliases: []string{"rm"},
+		Short:   "Remove scheduled tasks",
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := schedule.Update(config.SchedulesPath(), func(tasks []schedule.Task) ([]schedule.Task, error) {
+				for _, name := range args {
+					if schedule.Find(tasks, name) == nil {
+						return nil, fmt.Errorf("task %q is not scheduled", name)
+					}
+				}
+				var kept []schedule.Task
+				for _, t := range tasks {
+					if !slices.Contains(args, t.Name) {
+						kept = append(kept, t)
+					}
+				}
+				return kept, nil
+			})
+			if err != nil {
+				return err
+			}
+			for _, name := range args {
+				fmt.Printf("task %q removed\n", name)
+			}
+			return nil
+		},
+	}
+}
+
+func newScheduleRunCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "run NAME",
+		Short: "Run a scheduled task now, as the daemon would",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tasks, err := schedule.Load(config.SchedulesPath())
+			if err != nil {
+				return err
+			}
+			task := schedule.Find(tasks, args[0])
+			if task == nil {
+				return fmt.Errorf("task %q is not scheduled", args[0])
+			}
+			run := runScheduledTask(commandContext(), *task, config.ScheduleLogDir())
+			if err := recordScheduledRun(config.SchedulesPath(), task.Name, run); err != nil {
+				return err
+			}
+			logPath := scheduleLogPath(config.ScheduleLogDir(), task.Name)
+			if run.Error != "" {
+				return fmt.Errorf("task %q failed: %s; see %s", task.Name, run.Error, logPath)
+			}
+			fmt.Printf("task %q finished in %s; log: %s\n", task.Name, time.Duration(run.DurationMs)*time.Millisecond, logPath)
+			return nil
+		},
+	}
+}
+
+func handleScheduleAdd(expr string, args []string, name string, overwrite bool) error {
+	spec, err := schedule.Parse(expr)
+	if err != nil {
+		return err
+	}
+	command, err := scheduledCommandName(args)
+	if err != nil {
+		return err
+	}
+	if name != "" {
+		if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+			return fmt.Errorf("invalid task name %q: %s", name, strings.Join(errs, "; "))
+		}
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	task := schedule.Task{Name: name, Schedule: expr, Args: args, Dir: dir, Created: time.Now().UTC()}
+	err = schedule.Update(config.SchedulesPath(), func(tasks []schedule.Task) ([]schedule.Task, error) {
+		if task.Name == "" {
+			task.Name = nextTaskName(tasks, command)
+		}
+		existing := schedule.Find(tasks, task.Name)
+		if existing == nil {
+			return append(tasks, task), nil
+		}
+		if !overwrite {
+			return nil, fmt.Errorf("task %q already exists, use --overwrite to replace it", task.Name)
+		}
+		*existing = task
+		return tasks, nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("task %q scheduled at %q, next run %s\n", task.Name, expr, formatNextRun(spec.Next(time.Now())))
+	warnIfNoDaemon()
+	return nil
+}
+
+// scheduledCommandName checks that args run a kubectl multi command that
+// can run unattended and returns the name of its top-level command
+func scheduledCommandName(args []string) (string, error) {
+	sub, _, err := rootCmd.Find(args)
+	if err != nil || sub == rootCmd {
+		return "", fmt.Errorf("unknown command %q for \"kubectl multi\"", args[0])
+	}
+	top := sub
+	for top.HasParent() && top.Parent() != rootCmd {
+		top = top.Parent()
+	}
+	if unschedulableCommands[top.Name()] {
+		return "", fmt.Errorf("%q cannot be scheduled", top.Name())
+	}
+	return top.Name(), nil
+}
+
+// nextTaskName returns COMMAND-N with the lowest N not taken
+func nextTaskName(tasks []schedule.Task, command string) string {
+	for n := 1; ; n++ {
+		name := fmt.Sprintf("%s-%d", command, n)
+		if schedule.Find(tasks, name) == nil {
+			return name
+		}
+	}
+}
+
+// warnIfNoDaemon reminds that tasks only run while the daemon does
+func warnIfNoDaemon() {
+	socket := defaultDaemonSocket()
+	if _, err := newDaemonClient(socket).status(commandContext()); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: no daemon is running on %s; scheduled tasks only run while it does (start it with 'kubectl multi daemon start')\n", socket)
+	}
+}
+
+func printScheduledTasks(out io.Writer, tasks []schedule.Task, now time.Time) {
+	if len(tasks) == 0 {
+		fmt.Fprintln(out, "No tasks scheduled.")
+		return
+	}
+	tw := util.NewTableWriter(out, "")
+	defer tw.Flush()
+	fmt.Fprintln(tw, "NAME\tSCHEDULE\tNEXT RUN\tLAST RUN\tRESULT\tCOMMAND")
+	for _, t := range tasks {
+		next := "invalid schedule"
+		if spec, err := schedule.Parse(t.Schedule); err == nil {
+			next = formatNextRun(spec.Next(now))
+		}
+		last, result := "<never>", "<none>"
+		if t.LastRun != nil {
+			last = duration.HumanDuration(now.Sub(t.LastRun.Started)) + " ago"
+			result = "OK"
+			if t.LastRun.Error != "" {
+				result = "Failed: " + t.LastRun.Error
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", t.Name, t.Schedule, next, last, result, "kubectl multi "+commandLine(t.Args))
+	}
+}
+
+// formatNextRun shows a next run time, or that the schedule never fires
+func formatNextRun(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+// commandLine joins args for display, quoting the ones a shell would split
+func commandLine(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		if a == "" || strings.ContainsAny(a, " \t\"'$*?") {
+			a = fmt.Sprintf("%q", a)
+		}
+		quoted[i] = a
+	}
+	return strings.Join(quoted, " ")
+}
+
+// scheduleLogPath is the log of the task in dir
+func scheduleLogPath(dir, name string) string {
+	return filepath.Join(dir, name+".log")
+}
+
+// runScheduledTask runs a task to completion, appending its output to its
+// log in logDir between a header and a footer line
+func runScheduledTask(ctx context.Context, task schedule.Task, logDir string) schedule.Run {
+	run := schedule.Run{Started: time.Now().UTC()}
+	fail := func(err error) schedule.Run {
+		run.Error = err.Error()
+		run.DurationMs = time.Since(run.Started).Milliseconds()
+		return run
+	}
+
+	executable, err := scheduleExecutable()
+	if err != nil {
+		return fail(err)
+	}
+	if err := os.MkdirAll(logDir, 0700); err != nil {
+		return fail(err)
+	}
+	logPath := scheduleLogPath(logDir, task.Name)
+	if info, err := os.Stat(logPath); err == nil && info.Size() > scheduleLogMaxBytes {
+		os.Rename(logPath, logPath+".1")
+	}
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fail(err)
+	}
+	defer logFile.Close()
+
+	fmt.Fprintf(logFile, "=== %s kubectl multi %s ===\n", run.Started.Format(time.RFC3339), commandLine(task.Args))
+	// No one is at a terminal to confirm a fleet change: scheduling the
+	// command confirmed it
+	c := exec.CommandContext(ctx, executable, append([]string{"--yes"}, task.Args...)...)
+	c.Dir = task.Dir
+	c.Stdout, c.Stderr = logFile, logFile
+	err = c.Run()
+	run.DurationMs = time.Since(run.Started).Milliseconds()
+	if err != nil {
+		run.Error = err.Error()
+	}
+	result := "succeeded"
+	if run.Error != "" {
+		result = "failed: " + run.Error
+	}
+	fmt.Fprintf(logFile, "=== %s after %s ===\n", result, time.Duration(run.DurationMs)*time.Millisecond)
+	return run
+}
+
+// recordScheduledRun stores the outcome of a run in the task, unless the
+// task was removed meanwhile
+func recordScheduledRun(path, name string, run schedule.Run) error {
+	return schedule.Update(path, func(tasks []schedule.Task) ([]schedule.Task, error) {
+		if t := schedule.Find(tasks, name); t != nil {
+			t.LastRun = &run
+		}
+		return tasks, nil
+	})
+}
+
+// scheduler runs the due tasks of the schedules file once a minute
+type scheduler struct {
+	path, logDir string
+	log          io.Writer
+
+	mu      sync.Mutex
+	running map[string]bool
+	wg      sync.WaitGroup
+}
+
+func newScheduler(path, logDir string, log io.Writer) *scheduler {
+	return &scheduler{path: path, logDir: logDir, log: log, running: map[string]bool{}}
+}
+
+// run checks the tasks at the start of every minute until ctx is done, then
+// waits for the runs in progress, which ctx cancels
+func (s *scheduler) run(ctx context.Context) {
+	defer s.wg.Wait()
+	for {
+		now := time.Now()
+		minute := now.Truncate(time.Minute).Add(time.Minute)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(minute.Sub(now)):
+		}
+		s.runDue(ctx, minute)
+	}
+}
+
+// runDue starts the tasks due at minute. The schedules file is read each
+// time, so tasks added or removed take effect without a restart. A task
+// still running from an earlier minute is not started again.
+func (s *scheduler) runDue(ctx context.Context, minute time.Time) {
+	tasks, err := schedule.Load(s.path)
+	if err != nil {
+		fmt.Fprintf(s.log, "Scheduler: %v\n", err)
+		return
+	}
+	for _, task := range tasks {
+		spec, err := schedule.Parse(task.Schedule)
+		if err != nil {
+			fmt.Fprintf(s.log, "Scheduler: skipping task %q: %v\n", task.Name, err)
+			continue
+		}
+		if !spec.Matches(minute) {
+			continue
+		}
+		s.mu.Lock()
+		busy := s.running[task.Name]
+		s.running[task.Name] = true
+		s.mu.Unlock()
+		if busy {
+			fmt.Fprintf(s.log, "Scheduler: task %q is still running, skipping its %s run\n", task.Name, minute.Format("15:04"))
+			continue
+		}
+
+		s.wg.Add(1)
+		go func(task schedule.Task) {
+			defer s.wg.Done()
+			run := runScheduledTask(ctx, task, s.logDir)
+			if run.Error != "" {
+				fmt.Fprintf(s.log, "Scheduler: task %q failed: %s\n", task.Name, run.Error)
+			}
+			if err := recordScheduledRun(s.path, task.Name, run); err != nil {
+				fmt.Fprintf(s.log, "Scheduler: %v\n", err)
+			}
+			s.mu.Lock()
+			delete(s.running, task.Name)
+			s.mu.Unlock()
+		}(task)
+	}
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"kubectl-multi/pkg/schedule"
+)
+
+func TestSchedulerRunsDueTasks(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("the test tasks are POSIX shell commands")
+	}
+	dir := t.TempDir()
+	fakeScheduleExecutable(t, dir)
+	path := filepath.Join(dir, "schedules.yaml")
+	logDir := filepath.Join(dir, "logs")
+	err := schedule.Update(path, func([]schedule.Task) ([]schedule.Task, error) {
+		return []schedule.Task{
+			{Name: "report", Schedule: "0 2 * * *", Args: []string{"-c", "echo nightly > report.txt; echo done"}, Dir: dir},
+			{Name: "broken", Schedule: "0 2 * * *", Args: []string{"-c", "echo oops >&2; exit 3"}, Dir: dir},
+			{Name: "later", Schedule: "30 2 * * *", Args: []string{"-c", "echo later"}, Dir: dir},
+		}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var log bytes.Buffer
+	s := newScheduler(path, logDir, &log)
+	s.runDue(context.Background(), time.Date(2026, 10, 18, 2, 0, 0, 0, time.Local))
+	s.wg.Wait()
+
+	tasks, err := schedule.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	broken, later, report := tasks[0], tasks[1], tasks[2]
+	if report.LastRun == nil || report.LastRun.Error != "" {
+		t.Errorf("report run = %+v, want a successful run", report.LastRun)
+	}
+	if broken.LastRun == nil || broken.LastRun.Error != "exit status 3" {
+		t.Errorf("broken run = %+v, want exit status 3", broken.LastRun)
+	}
+	if later.LastRun != nil {
+		t.Errorf("later ran at 2:00 although not due then: %+v", later.LastRun)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "report.txt")); string(data) != "nightly\n" {
+		t.Errorf("report.txt = %q, want the task to run in its directory", data)
+	}
+
+	data, err := os.ReadFile(filepath.Join(logDir, "broken.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 || !strings.HasSuffix(lines[0], `kubectl multi -c "echo oops >&2; exit 3" ===`) || lines[1] != "oops" || !strings.HasPrefix(lines[2], "=== failed: exit status 3 after ") {
+		t.Errorf("broken.log =\n%s", data)
+	}
+	if !strings.Contains(log.String(), `task "broken" failed: exit status 3`) {
+		t.Errorf("scheduler log = %q, want the failure reported", log.String())
+	}
+}
+
+// fakePluginScript stands for the plugin: like it, apply refuses to change the
+// fleet unconfirmed without a terminal; anything else runs as sh arguments
+const fakePluginScript = `#!/bin/sh
+yes=false
+if [ "$1" = --yes ]; then yes=true; shift; fi
+if [ "$1" != apply ]; then exec /bin/sh "$@"; fi
+if [ "$yes" != true ]; then echo "apply needs confirming and stdin is not a terminal; confirm with --yes" >&2; exit 1; fi
+echo "applied $3" > applied.txt
+`
+
+// fakeScheduleExecutable makes the scheduler run fakePluginScript, written in
+// dir, instead of the plugin
+func fakeScheduleExecutable(t *testing.T, dir string) {
+	t.Helper()
+	path := filepath.Join(dir, "kubectl-multi")
+	if err := os.WriteFile(path, []byte(fakePluginScript), 0700); err != nil {
+		t.Fatal(err)
+	}
+	scheduleExecutable = func() (string, error) { return path, nil }
+	t.Cleanup(func() { scheduleExecutable = os.Executable })
+}
+
+func TestSchedulerRunsApply(t *testing.T) {
+	if goruntime.GOOS == "windows" {
+		t.Skip("the test plugin is a POSIX shell script")
+	}
+	dir := t.TempDir()
+	fakeScheduleExecutable(t, dir)
+	path := filepath.Join(dir, "schedules.yaml")
+	err := schedule.Update(path, func([]schedule.Task) ([]schedule.Task, error) {
+		return []schedule.Task{{Name: "apply-1", Schedule: "0 2 * * *", Args: []string{"apply", "-f", "app.yaml"}, Dir: dir}}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := newScheduler(path, filepath.Join(dir, "logs"), io.Discard)
+	s.runDue(context.Background(), time.Date(2026, 10, 18, 2, 0, 0, 0, time.Local))
+	s.wg.Wait()
+
+	tasks, err := schedule.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run := tasks[0].LastRun; run == nil || run.Error != "" {
+		log, _ := os.ReadFile(scheduleLogPath(filepath.Join(dir, "logs"), "apply-1"))
+		t.Fatalf("apply run = %+v, want a successful run; log:\n%s", run, log)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "applied.txt")); string(data) != "applied app.yaml\n" {
+		t.Errorf("applied.txt = %q, want the scheduled apply to run", data)
+	}
+}
+
+func TestPrintScheduledTasks(t *testing.T) {
+	now := time.Date(2026, 10, 17, 10, 0, 0, 0, time.Local)
+	tasks := []schedule.Task{
+		{Name: "get-1", Schedule: "0 2 * * *", Args: []string{"get", "pods", "-A", "-o", "json", "--output-file", "nightly.json"},
+			LastRun: &schedule.Run{Started: now.Add(-8 * time.Hour)}},
+		{Name: "health", Schedule: "*/15 * * * *", Args: []string{"doctor"},
+			LastRun: &schedule.Run{Started: now.Add(-15 * time.Minute), Error: "exit status 1"}},
+		{Name: "new", Schedule: "@weekly", Args: []string{"get", "nodes", "-l", "tier in (edge)"}},
+	}
+	var out bytes.Buffer
+	printScheduledTasks(&out, tasks, now)
+	want := `NAME    SCHEDULE      NEXT RUN          LAST RUN  RESULT                 COMMAND
+get-1   0 2 * * *     2026-10-18 02:00  8h ago    OK                     kubectl multi get pods -A -o json --output-file nightly.json
+health  */15 * * * *  2026-10-17 10:15  15m ago   Failed: exit status 1  kubectl multi doctor
+new     @weekly       2026-10-18 00:00  <never>   <none>                 kubectl multi get nodes -l "tier in (edge)"
+`
+	if out.String() != want {
+		t.Errorf("printScheduledTasks() =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestScheduledCommandName(t *testing.T) {
+	if name, err := scheduledCommandName([]string{"get", "pods", "-A"}); err != nil || name != "get" {
+		t.Errorf("scheduledCommandName(get) = %q, %v", name, err)
+	}
+	for _, args := range [][]string{{"frobnicate"}, {"daemon", "run"}, {"schedule", "list"}} {
+		if _, err := scheduledCommandName(args); err == nil {
+			t.Errorf("scheduledCommandName(%v) succeeded, want an error", args)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+// secretDataOptions controls how much of a secret's data get may reveal
+type secretDataOptions struct {
+	// ShowData is one of false, redacted or decoded
+	ShowData string
+	// DecodeKey prints the decoded value of a single key
+	DecodeKey string
+}
+
+// revealsData reports whether the options ask for more than the default table
+func (o secretDataOptions) revealsData() bool {
+	return o.ShowData != "false" || o.DecodeKey != ""
+}
+
+// validate rejects unknown modes and fleet-wide requests for decoded values
+func (o secretDataOptions) validate(resourceName string, clusters []cluster.ClusterInfo) error {
+	switch o.ShowData {
+	case "false", "redacted", "decoded":
+	default:
+		return fmt.Errorf("invalid --show-data %q, must be one of false|redacted|decoded", o.ShowData)
+	}
+	if o.ShowData != "decoded" && o.DecodeKey == "" {
+		return nil
+	}
+	if resourceName == "" {
+		return fmt.Errorf("decoding secret data requires a secret name")
+	}
+	if len(clusters) != 1 {
+		return fmt.Errorf("decoding secret data requires exactly one cluster, select it with --clusters")
+	}
+	return nil
+}
+
+// handleSecretDataGet lists secret keys with their sizes, or decoded values
+// when a single secret on a single cluster is selected. It returns the number
+// of secrets printed.
+func handleSecretDataGet(tw util.TableWriter, clusters []cluster.ClusterInfo, q tableQuery) (int, error) {
+	opts := q.Options.SecretData
+	if err := opts.validate(q.Name, clusters); err != nil {
+		return 0, err
+	}
+
+	rows := 0
+	for _, clusterInfo := range clusters {
+		if clusterInfo.Client == nil {
+			continue
+		}
+
+		targetNS := cluster.GetTargetNamespace(q.Namespace)
+		if q.AllNamespaces {
+			targetNS = ""
+		}
+
+		secrets, err := listNamespaced(clusterInfo, "", "secrets", targetNS, func(ns string) (*corev1.SecretList, error) {
+			return clusterInfo.Client.CoreV1().Secrets(ns).List(commandContext(), metav1.ListOptions{
+				LabelSelector: q.Selector,
+			})
+		})
+		if err != nil {
+			noteClusterIssue(clusterInfo.Name, fmt.Sprintf("failed to list secrets: %v", err))
+			continue
+		}
+
+		for _, secret := range itemsNamed(secrets.Items, q.Name, q.Options.ManagedOnly) {
+			if opts.DecodeKey != "" {
+				value, ok := secret.Data[opts.DecodeKey]
+				if !ok {
+					return 0, fmt.Errorf("key %q not found in secret %s/%s on cluster %s", opts.DecodeKey, secret.Namespace, secret.Name, clusterInfo.Name)
+				}
+				util.GetOutputStream().Write(value)
+				return 1, nil
+			}
+
+			keys := make([]string, 0, len(secret.Data))
+			for k := range secret.Data {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+
+			if rows == 0 {
+				if opts.ShowData == "decoded" {
+					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tKEY\tVALUE\n")
+				} else {
+					fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tNAME\tKEY\tSIZE\n")
+				}
+			}
+			rows++
+			for _, k := range keys {
+				if opts.ShowData == "decoded" {
+					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", clusterInfo.Name, secret.Namespace, secret.Name, k, string(secret.Data[k]))
+				} else {
+					fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d bytes\n", clusterInfo.Name, secret.Namespace, secret.Name, k, len(secret.Data[k]))
+				}
+			}
+		}
+	}
+
+	if rows == 0 {
+		reportNoResources(namespace, allNamespaces)
+	}
+	return rows, nil
+}
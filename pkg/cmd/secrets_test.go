@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"testing"
+
+	"kubectl-multi/pkg/cluster"
+)
+
+func TestSecretDataOptionsValidate(t *testing.T) {
+	one := []cluster.ClusterInfo{{Name: "cluster1"}}
+	two := []cluster.ClusterInfo{{Name: "cluster1"}, {Name: "cluster2"}}
+
+	tests := []struct {
+		name         string
+		opts         secretDataOptions
+		resourceName string
+		clusters     []cluster.ClusterInfo
+		wantErr      bool
+	}{
+		{name: "default", opts: secretDataOptions{ShowData: "false"}, clusters: two},
+		{name: "redacted fleet wide", opts: secretDataOptions{ShowData: "redacted"}, clusters: two},
+		{name: "unknown mode", opts: secretDataOptions{ShowData: "plain"}, clusters: one, wantErr: true},
+		{name: "decoded one secret one cluster", opts: secretDataOptions{ShowData: "decoded"}, resourceName: "db", clusters: one},
+		{name: "decoded without name", opts: secretDataOptions{ShowData: "decoded"}, clusters: one, wantErr: true},
+		{name: "decoded across clusters", opts: secretDataOptions{ShowData: "decoded"}, resourceName: "db", clusters: two, wantErr: true},
+		{name: "decode key one cluster", opts: secretDataOptions{ShowData: "false", DecodeKey: "password"}, resourceName: "db", clusters: one},
+		{name: "decode key across clusters", opts: secretDataOptions{ShowData: "false", DecodeKey: "password"}, resourceName: "db", clusters: two, wantErr: true},
+		{name: "decode key without name", opts: secretDataOptions{ShowData: "false", DecodeKey: "password"}, clusters: one, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.validate(tt.resourceName, tt.clusters)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSecretDataOptionsRevealsData(t *testing.T) {
+	tests := []struct {
+		opts secretDataOptions
+		want bool
+	}{
+		{secretDataOptions{ShowData: "false"}, false},
+		{secretDataOptions{ShowData: "redacted"}, true},
+		{secretDataOptions{ShowData: "decoded"}, true},
+		{secretDataOptions{ShowData: "false", DecodeKey: "password"}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.opts.revealsData(); got != tt.want {
+			t.Errorf("%+v.revealsData() = %v, want %v", tt.opts, got, tt.want)
+		}
+	}
+}
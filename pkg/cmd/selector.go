@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// parseSelector validates a -l label selector, including the set-based
+// forms (in, notin, key, !key), before any cluster is contacted, so a typo
+// fails once instead of once per cluster. It returns the selector in the
+// canonical form every cluster receives; an empty selector stays empty.
+func parseSelector(selector string) (string, error) {
+	if strings.TrimSpace(selector) == "" {
+		return "", nil
+	}
+	sel, err := labels.Parse(selector)
+	if err != nil {
+		return "", fmt.Errorf("invalid selector %q: %v", selector, err)
+	}
+	return sel.String(), nil
+}
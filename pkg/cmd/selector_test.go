@@ -0,0 +1,33 @@
+package cmd
+
+import "testing"
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     string
+		wantErr  bool
+	}{
+		{selector: "", want: ""},
+		{selector: "  ", want: ""},
+		{selector: "app=web", want: "app=web"},
+		{selector: "app==web,tier!=db", want: "app==web,tier!=db"},
+		{selector: "env in (prod, stage)", want: "env in (prod,stage)"},
+		{selector: "env notin (dev),canary", want: "canary,env notin (dev)"},
+		{selector: "!legacy", want: "!legacy"},
+		{selector: "app in (web", wantErr: true},
+		{selector: "app=web,,", wantErr: true},
+		{selector: "app=we b", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			got, err := parseSelector(tt.selector)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSelector(%q) error = %v, wantErr %v", tt.selector, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseSelector(%q) = %q, want %q", tt.selector, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,342 @@
+package cmd
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"kubectl-multi/pkg/cluster"
+)
+
+// EnvServeToken is the bearer token serve requires when --token-file is not set
+const EnvServeToken = "KUBECTL_MULTI_SERVE_TOKEN"
+
+// serveOptions are the flags of serve that guard what it exposes
+type serveOptions struct {
+	Listen       string
+	TokenFile    string
+	TLSCertFile  string
+	TLSKeyFile   string
+	AllowSecrets bool
+}
+
+// token returns the bearer token from --token-file or $KUBECTL_MULTI_SERVE_TOKEN,
+// empty when neither is set
+func (o serveOptions) token() (string, error) {
+	if o.TokenFile == "" {
+		return strings.TrimSpace(os.Getenv(EnvServeToken)), nil
+	}
+	data, err := os.ReadFile(o.TokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read --token-file: %v", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("--token-file %s is empty", o.TokenFile)
+	}
+	return token, nil
+}
+
+// validate refuses to serve on an address other hosts can reach without a
+// bearer token, and TLS flags given by halves
+func (o serveOptions) validate(token string) error {
+	if (o.TLSCertFile == "") != (o.TLSKeyFile == "") {
+		return fmt.Errorf("--tls-cert-file and --tls-key-file must be given together")
+	}
+	if token == "" && !isLoopbackAddress(o.Listen) {
+		return fmt.Errorf("--listen %s is not a loopback address; set --token-file or $%s so clients must send a bearer token", o.Listen, EnvServeToken)
+	}
+	return nil
+}
+
+// isLoopbackAddress reports whether listen only accepts connections from
+// this host. An empty host listens on every interface.
+func isLoopbackAddress(listen string) bool {
+	host, _, err := net.SplitHostPort(listen)
+	if err != nil || host == "" {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func newServeCommand() *cobra.Command {
+	var o serveOptions
+	var rediscover time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve cluster discovery and aggregated get as a REST API",
+		Long: `Expose the managed cluster discovery and the aggregated get of kubectl-multi
+over HTTP, so dashboards and other tools can reuse it without running the CLI
+for every query. All endpoints answer GET with JSON:
+
+  /clusters                 the discovered clusters
+  /resources/TYPE           the matching objects of every cluster as a v1 List,
+                            each annotated with its cluster; query parameters
+                            name, namespace, allNamespaces=true, selector,
+                            clusters (comma-separated) and clusterSelector
+  /healthz                  "ok"
+
+Clusters that fail to answer are listed under clusterIssues in the List.
+
+It listens on 127.0.0.1:8080. Any other address needs a bearer token, read
+from --token-file or $KUBECTL_MULTI_SERVE_TOKEN; once a token is set, every
+endpoint but /healthz requires "Authorization: Bearer TOKEN". Add
+--tls-cert-file and --tls-key-file so the token does not cross the network in
+clear text. Secrets are refused unless --allow-secrets is given.`,
+		Example: `# Serve on 127.0.0.1:8080
+kubectl multi serve
+
+# Query it
+curl 'localhost:8080/resources/pods?allNamespaces=true&selector=app=nginx'
+
+# Serve to other hosts over TLS, with a token
+kubectl multi serve --listen :8443 --token-file token --tls-cert-file tls.crt --tls-key-file tls.key
+curl -H "Authorization: Bearer $(cat token)" 'https://dashboard-host:8443/clusters'`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			token, err := o.token()
+			if err != nil {
+				return err
+			}
+			if err := o.validate(token); err != nil {
+				return err
+			}
+			kubeconfig, remoteCtx, _, _, _ := GetGlobalFlags()
+			srv := newAggregatorServer(kubeconfig, rediscover, func() ([]cluster.ClusterInfo, error) {
+				return discoverClusters(kubeconfig, remoteCtx)
+			})
+			srv.token, srv.allowSecrets = token, o.AllowSecrets
+			listener, err := net.Listen("tcp", o.Listen)
+			if err != nil {
+				return err
+			}
+			scheme := "http"
+			if o.TLSCertFile != "" {
+				cert, err := tls.LoadX509KeyPair(o.TLSCertFile, o.TLSKeyFile)
+				if err != nil {
+					listener.Close()
+					return fmt.Errorf("failed to load the TLS key pair: %v", err)
+				}
+				listener = tls.NewListener(listener, &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12})
+				scheme = "https"
+			} else if !isLoopbackAddress(o.Listen) {
+				fmt.Fprintf(os.Stderr, "Warning: the bearer token is sent in clear text without --tls-cert-file and --tls-key-file\n")
+			}
+			fmt.Fprintf(os.Stderr, "Serving on %s://%s\n", scheme, o.Listen)
+			return serveUntilInterrupted(commandContext(), &http.Server{Handler: srv.handler()}, listener)
+		},
+	}
+
+	cmd.Flags().StringVar(&o.Listen, "listen", "127.0.0.1:8080", "address to listen on; addresses other than loopback need a bearer token")
+	cmd.Flags().StringVar(&o.TokenFile, "token-file", "", "file holding the bearer token clients must send (defaults to $"+EnvServeToken+")")
+	cmd.Flags().StringVar(&o.TLSCertFile, "tls-cert-file", "", "certificate to serve HTTPS with")
+	cmd.Flags().StringVar(&o.TLSKeyFile, "tls-key-file", "", "private key of --tls-cert-file")
+	cmd.Flags().BoolVar(&o.AllowSecrets, "allow-secrets", false, "serve Secrets, which are refused by default")
+	cmd.Flags().DurationVar(&rediscover, "rediscover", time.Minute, "how long discovered clusters are reused before discovering them again")
+
+	return cmd
+}
+
+// serveUntilInterrupted runs server on listener until ctx is cancelled, then
+// lets the requests in flight finish
+func serveUntilInterrupted(ctx context.Context, server *http.Server, listener net.Listener) error {
+	expectInterrupt()
+	done := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		done <- server.Shutdown(shutdownCtx)
+	}()
+	if err := server.Serve(listener); err != http.ErrServerClosed {
+		return err
+	}
+	return <-done
+}
+
+// aggregatorServer answers the REST API of serve. Requests are handled one
+// at a time because cluster issues are collected process-wide.
+type aggregatorServer struct {
+	mu           sync.Mutex
+	kubeconfig   string
+	rediscover   time.Duration
+	discover     func() ([]cluster.ClusterInfo, error)
+	clusters     []cluster.ClusterInfo
+	discovered   time.Time
+	token        string
+	allowSecrets bool
+}
+
+func newAggregatorServer(kubeconfig string, rediscover time.Duration, discover func() ([]cluster.ClusterInfo, error)) *aggregatorServer {
+	return &aggregatorServer{kubeconfig: kubeconfig, rediscover: rediscover, discover: discover}
+}
+
+func (s *aggregatorServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.get(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	}))
+	mux.HandleFunc("/clusters", s.get(s.authorized(s.serveClusters)))
+	mux.HandleFunc("/resources/", s.get(s.authorized(s.serveResources)))
+	return mux
+}
+
+// authorized rejects requests without the bearer token, when there is one
+func (s *aggregatorServer) authorized(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.token != "" {
+			sent, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(sent), []byte(s.token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="kubectl-multi"`)
+				writeJSONError(w, http.StatusUnauthorized, fmt.Errorf("missing or wrong bearer token"))
+				return
+			}
+		}
+		h(w, r)
+	}
+}
+
+// get rejects methods other than GET and serializes the requests
+func (s *aggregatorServer) get(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			writeJSONError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		h(w, r)
+	}
+}
+
+// currentClusters returns the discovered clusters, discovering them again
+// once they are older than the rediscover interval
+func (s *aggregatorServer) currentClusters() ([]cluster.ClusterInfo, error) {
+	if !s.discovered.IsZero() && time.Since(s.discovered) < s.rediscover {
+		return s.clusters, nil
+	}
+	clusters, err := s.discover()
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	s.clusters, s.discovered = clusters, time.Now()
+	return clusters, nil
+}
+
+// clusterEntry is a discovered cluster as served by /clusters
+type clusterEntry struct {
+	Name    string `json:"name"`
+	Context string `json:"context"`
+	ITS     string `json:"its,omitempty"`
+}
+
+func (s *aggregatorServer) serveClusters(w http.ResponseWriter, r *http.Request) {
+	clusters, err := s.currentClusters()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	entries := []clusterEntry{}
+	for _, c := range clusters {
+		entries = append(entries, clusterEntry{Name: c.Name, Context: c.Context, ITS: c.ITS})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"items": entries})
+}
+
+func (s *aggregatorServer) serveResources(w http.ResponseWriter, r *http.Request) {
+	resourceType := strings.ToLower(strings.Trim(strings.TrimPrefix(r.URL.Path, "/resources/"), "/"))
+	if resourceType == "" || strings.Contains(resourceType, "/") {
+		writeJSONError(w, http.StatusNotFound, fmt.Errorf("expected /resources/TYPE, got %s", r.URL.Path))
+		return
+	}
+	if !s.allowSecrets && isSecretResource(resourceType) {
+		writeJSONError(w, http.StatusForbidden, errSecretsNotServed)
+		return
+	}
+	query := r.URL.Query()
+	selector, err := parseSelector(query.Get("selector"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	targets := clusterTargets{Selector: query.Get("clusterSelector")}
+	if names := query.Get("clusters"); names != "" {
+		targets.Names = strings.Split(names, ",")
+	}
+
+	clusters, err := s.currentClusters()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	clusters, err = targets.selectFrom(clusters, s.kubeconfig)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	takeClusterIssues()
+	q := tableQuery{Name: query.Get("name"), Selector: selector, Namespace: query.Get("namespace"), AllNamespaces: query.Get("allNamespaces") == "true"}
+	items, _ := collectObjects(clusters, resourceType, q)
+	// Secrets reached through an alias or category are refused as well
+	if !s.allowSecrets && containsSecrets(items) {
+		takeClusterIssues()
+		writeJSONError(w, http.StatusForbidden, errSecretsNotServed)
+		return
+	}
+	list := objectList(items)
+	if issues := takeClusterIssues(); len(issues) > 0 {
+		list["clusterIssues"] = issues
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+var errSecretsNotServed = fmt.Errorf("secrets are not served without --allow-secrets")
+
+// isSecretResource reports whether resourceType names core Secrets
+func isSecretResource(resourceType string) bool {
+	switch resourceType {
+	case "secret", "secrets", "secrets.v1", "secret.v1":
+		return true
+	}
+	return false
+}
+
+// containsSecrets reports whether any of the collected items is a core Secret
+func containsSecrets(items []interface{}) bool {
+	for _, item := range items {
+		obj, ok := item.(map[string]interface{})
+		if ok && obj["apiVersion"] == "v1" && obj["kind"] == "Secret" {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to write response: %v\n", err)
+	}
+}
+
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
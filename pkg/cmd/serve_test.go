@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+
+	"kubectl-multi/pkg/cluster"
+)
+
+func TestAggregatorServer(t *testing.T) {
+	c1, _ := testClusterInfo(
+		testObject("v1", "ConfigMap", "default", "app-config"),
+		testObject("v1", "ConfigMap", "prod", "app-config"),
+	)
+	c1.Context = "cluster1"
+	c1.ITS = "its1"
+	c2, _ := testClusterInfo(testObject("v1", "ConfigMap", "default", "other"))
+	c2.Name, c2.Context = "cluster2", "cluster2"
+	broken, brokenDynamic := testClusterInfo()
+	broken.Name, broken.Context = "broken", "broken"
+	brokenDynamic.PrependReactor("list", "*", func(clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("connection refused")
+	})
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		discoverErr  error
+		wantStatus   int
+		wantContains []string
+		wantItems    int
+	}{
+		{name: "health", path: "/healthz", wantStatus: http.StatusOK, wantContains: []string{"ok"}},
+		{
+			name:         "clusters",
+			path:         "/clusters",
+			wantStatus:   http.StatusOK,
+			wantContains: []string{`"name":"cluster1"`, `"its":"its1"`, `"name":"cluster2"`, `"name":"broken"`},
+		},
+		{
+			name:         "resources in the default namespace",
+			path:         "/resources/configmaps",
+			wantStatus:   http.StatusOK,
+			wantContains: []string{`"kind":"List"`, `"kubectl-multi.kubestellar.io/cluster":"cluster2"`},
+			wantItems:    2,
+		},
+		{name: "all namespaces", path: "/resources/configmaps?allNamespaces=true", wantStatus: http.StatusOK, wantItems: 3},
+		{name: "by name and cluster", path: "/resources/configmaps?name=app-config&clusters=cluster1&namespace=prod", wantStatus: http.StatusOK, wantItems: 1},
+		{name: "unknown cluster", path: "/resources/configmaps?clusters=nope", wantStatus: http.StatusBadRequest, wantContains: []string{`"error"`}},
+		{
+			name:         "failing cluster is a cluster issue",
+			path:         "/resources/configmaps?clusters=cluster1,broken",
+			wantStatus:   http.StatusOK,
+			wantContains: []string{`"clusterIssues"`, `"cluster":"broken"`, "connection refused"},
+			wantItems:    1,
+		},
+		{name: "missing type", path: "/resources/", wantStatus: http.StatusNotFound},
+		{name: "only GET", method: http.MethodPost, path: "/clusters", wantStatus: http.StatusMethodNotAllowed},
+		{name: "discovery fails", path: "/clusters", discoverErr: errors.New("no ITS"), wantStatus: http.StatusInternalServerError, wantContains: []string{"no ITS"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newAggregatorServer("", time.Minute, func() ([]cluster.ClusterInfo, error) {
+				if tt.discoverErr != nil {
+					return nil, tt.discoverErr
+				}
+				return []cluster.ClusterInfo{c1, c2, broken}, nil
+			})
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			rec := httptest.NewRecorder()
+			srv.handler().ServeHTTP(rec, httptest.NewRequest(method, tt.path, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			for _, want := range tt.wantContains {
+				if !strings.Contains(rec.Body.String(), want) {
+					t.Errorf("body %s does not contain %s", rec.Body.String(), want)
+				}
+			}
+			if tt.wantItems > 0 {
+				var list struct {
+					Items []interface{} `json:"items"`
+				}
+				if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+					t.Fatalf("body is not JSON: %v", err)
+				}
+				if len(list.Items) != tt.wantItems {
+					t.Errorf("items = %d, want %d", len(list.Items), tt.wantItems)
+				}
+			}
+		})
+	}
+}
+
+func TestAggregatorServerToken(t *testing.T) {
+	c1, _ := testClusterInfo(testObject("v1", "ConfigMap", "default", "app-config"))
+	srv := newAggregatorServer("", time.Minute, func() ([]cluster.ClusterInfo, error) {
+		return []cluster.ClusterInfo{c1}, nil
+	})
+	srv.token = "s3cret"
+
+	tests := []struct {
+		name          string
+		path          string
+		authorization string
+		wantStatus    int
+	}{
+		{name: "no token", path: "/resources/configmaps", wantStatus: http.StatusUnauthorized},
+		{name: "wrong token", path: "/clusters", authorization: "Bearer guess", wantStatus: http.StatusUnauthorized},
+		{name: "not a bearer token", path: "/clusters", authorization: "Basic s3cret", wantStatus: http.StatusUnauthorized},
+		{name: "right token", path: "/resources/configmaps", authorization: "Bearer s3cret", wantStatus: http.StatusOK},
+		{name: "health needs no token", path: "/healthz", wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			srv.handler().ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if rec.Code == http.StatusUnauthorized && strings.Contains(rec.Body.String(), "app-config") {
+				t.Errorf("rejected request got objects: %s", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestAggregatorServerSecrets(t *testing.T) {
+	srv := newAggregatorServer("", time.Minute, func() ([]cluster.ClusterInfo, error) {
+		return nil, nil
+	})
+	for _, path := range []string{"/resources/secrets", "/resources/Secret"} {
+		rec := httptest.NewRecorder()
+		srv.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("%s: status = %d, want %d", path, rec.Code, http.StatusForbidden)
+		}
+	}
+
+	srv.allowSecrets = true
+	rec := httptest.NewRecorder()
+	srv.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/resources/secrets", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("with allowSecrets: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	secret := testObject("v1", "Secret", "default", "db").Object
+	if !containsSecrets([]interface{}{testObject("v1", "ConfigMap", "default", "app").Object, secret}) {
+		t.Error("containsSecrets() missed a Secret")
+	}
+	if containsSecrets([]interface{}{testObject("example.com/v1", "Secret", "default", "db").Object}) {
+		t.Error("containsSecrets() matched a Secret of another group")
+	}
+}
+
+func TestServeOptionsValidate(t *testing.T) {
+	tests := []struct {
+		options serveOptions
+		token   string
+		wantErr bool
+	}{
+		{options: serveOptions{Listen: "127.0.0.1:8080"}},
+		{options: serveOptions{Listen: "localhost:8080"}},
+		{options: serveOptions{Listen: "[::1]:8080"}},
+		{options: serveOptions{Listen: ":8080"}, wantErr: true},
+		{options: serveOptions{Listen: "0.0.0.0:8080"}, wantErr: true},
+		{options: serveOptions{Listen: "10.0.0.5:8080"}, wantErr: true},
+		{options: serveOptions{Listen: ":8080"}, token: "s3cret"},
+		{options: serveOptions{Listen: ":8443", TLSCertFile: "tls.crt"}, token: "s3cret", wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := tt.options.validate(tt.token); (err != nil) != tt.wantErr {
+			t.Errorf("validate(%+v, token %q) = %v, want error %t", tt.options, tt.token, err, tt.wantErr)
+		}
+	}
+}
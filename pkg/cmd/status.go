@@ -0,0 +1,310 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+	"kubectl-multi/pkg/util"
+)
+
+// Verdicts of status for the copy of a workload object in one cluster
+const (
+	copyReady    = "Ready"
+	copyNotReady = "NotReady"
+	copyMissing  = "Missing"
+	copyUnknown  = "Unknown"
+)
+
+// workloadStatus is one view of a workload object across the spaces: its
+// desired state in the WDS, the Bindings placing it and its copy in each
+// destination cluster
+type workloadStatus struct {
+	Object     string `json:"object"`
+	WDS        string `json:"wds"`
+	Generation int64  `json:"generation"`
+	// Desired summarizes the spec of the WDS copy, e.g. "3 replicas"
+	Desired  string                  `json:"desired,omitempty"`
+	Bindings []workloadBinding       `json:"bindings"`
+	Clusters []workloadClusterStatus `json:"clusters"`
+}
+
+// workloadBinding is a Binding of the WDS that lists the object
+type workloadBinding struct {
+	Policy   string   `json:"policy"`
+	State    string   `json:"state"`
+	Clusters []string `json:"clusters"`
+}
+
+// workloadClusterStatus is the copy of the object in one destination cluster
+type workloadClusterStatus struct {
+	Cluster string `json:"cluster"`
+	Status  string `json:"status"`
+	// Reported is the status the ITS received for the copy in a WorkStatus,
+	// empty when none arrived
+	Reported string `json:"reported,omitempty"`
+	// Live is the status of the copy read from the cluster itself
+	Live       string   `json:"live,omitempty"`
+	Conditions []string `json:"conditions,omitempty"`
+	Detail     string   `json:"detail,omitempty"`
+}
+
+func newStatusCommand() *cobra.Command {
+	var outputFormat string
+	var reach reachability
+
+	cmd := &cobra.Command{
+		Use:   "status (TYPE/NAME | TYPE NAME)",
+		Short: "Show a workload object's desired state, placement and live status in one view",
+		Long: `Show, for one workload object, what each space knows about it:
+
+  WDS       the object as defined, its generation and desired replicas
+  Bindings  the BindingPolicies whose Binding lists the object, and their clusters
+  ITS       the status each destination cluster reported in a WorkStatus
+  WECs      the copy read from each destination cluster: its status and conditions
+
+Each destination cluster is then judged:
+
+  Ready     the copy exists and has converged
+  NotReady  the copy exists but has not converged, with the reason
+  Missing   the cluster has no copy of the object
+  Unknown   the cluster could not be read
+
+The command fails when the object is not Ready in every destination cluster,
+so it can gate scripts.`,
+		Example: `# Where nginx is placed and how it is doing there
+kubectl multi status deployment/nginx -n prod
+
+# As JSON, from another WDS
+kubectl multi status deployment nginx -n prod --wds-context wds2 -o json`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resourceType, name, err := parseTypeName(args)
+			if err != nil {
+				return err
+			}
+			switch outputFormat {
+			case "", "json", "yaml":
+			default:
+				return fmt.Errorf("unsupported output format %q, must be one of json|yaml", outputFormat)
+			}
+			kubeconfig, remoteCtx, _, namespace, _ := GetGlobalFlags()
+			return handleStatusCommand(resourceType, name, outputFormat, reach, kubeconfig, remoteCtx, GetWDSContext(), namespace)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "output format (json|yaml)")
+	reach.addFlags(cmd)
+
+	return cmd
+}
+
+func handleStatusCommand(resourceType, name, outputFormat string, reach reachability, kubeconfig, remoteCtx, wdsContext, namespace string) error {
+	if _, err := reach.controlPlane("WDS", kubeconfig, wdsContext, true); err != nil {
+		return err
+	}
+	// Without the ITS the placement is still shown, the clusters as Unknown
+	itsReachable, err := reach.controlPlane("ITS", kubeconfig, remoteCtx, false)
+	if err != nil {
+		return err
+	}
+	defer printClusterIssuesToStderr()
+
+	wds, err := cluster.ClientForContext(kubeconfig, wdsContext)
+	if err != nil {
+		return err
+	}
+	gvr, namespaced, err := util.DiscoverGVR(wds.DiscoveryClient, resourceType)
+	if err != nil {
+		return fmt.Errorf("failed to resolve resource type %s: %v", resourceType, err)
+	}
+	ref := kubestellar.ObjectRef{Group: gvr.Group, Version: gvr.Version, Resource: gvr.Resource, Name: name}
+	if namespaced {
+		ref.Namespace = cluster.GetTargetNamespace(namespace)
+	}
+
+	obj, err := wds.DynamicClient.Resource(gvr).Namespace(ref.Namespace).Get(commandContext(), name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get %s in WDS %s: %v", ref, wdsContext, err)
+	}
+	status := workloadStatus{Object: ref.String(), WDS: wdsContext, Generation: obj.GetGeneration(), Desired: desiredSummary(obj)}
+
+	bindingList, err := wds.DynamicClient.Resource(kubestellar.BindingGVR).List(commandContext(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list bindings in WDS %s: %v", wdsContext, err)
+	}
+	status.Bindings = workloadBindings(bindingList.Items, ref)
+
+	var reported map[string]string
+	clients := map[string]cluster.ClusterInfo{}
+	if itsReachable {
+		if its, err := cluster.ClientForContext(kubeconfig, remoteCtx); err != nil {
+			noteClusterIssue(remoteCtx, err.Error())
+		} else if list, err := its.DynamicClient.Resource(kubestellar.WorkStatusGVR).List(commandContext(), metav1.ListOptions{}); err != nil {
+			noteClusterIssue(remoteCtx, fmt.Sprintf("failed to list workstatuses: %v", err))
+		} else {
+			reported = reportedStates(list.Items, ref)
+		}
+
+		clusters, err := discoverClusters(kubeconfig, remoteCtx)
+		if err != nil {
+			return fmt.Errorf("failed to discover clusters: %v", err)
+		}
+		if clusters, err = reach.filter(clusters); err != nil {
+			return err
+		}
+		for _, c := range clusters {
+			clients[c.Name] = c
+		}
+	}
+
+	for _, clusterName := range placedClusters(status.Bindings) {
+		var s workloadClusterStatus
+		if c, ok := clients[clusterName]; ok && c.DynamicClient != nil {
+			s = readClusterCopy(c, gvr, ref)
+		} else {
+			s = workloadClusterStatus{Cluster: clusterName, Status: copyUnknown, Detail: "cluster not reachable"}
+		}
+		s.Reported = reported[clusterName]
+		status.Clusters = append(status.Clusters, s)
+	}
+
+	if outputFormat != "" {
+		if err := util.PrintStructured(util.GetOutputStream(), outputFormat, status); err != nil {
+			return err
+		}
+	} else {
+		printWorkloadStatus(status)
+	}
+
+	notReady := 0
+	for _, c := range status.Clusters {
+		if c.Status != copyReady {
+			notReady++
+		}
+	}
+	if notReady > 0 {
+		return fmt.Errorf("%s is not ready in %d of %d cluster(s)", ref, notReady, len(status.Clusters))
+	}
+	return nil
+}
+
+// desiredSummary returns the replicas the spec of a workload asks for, or ""
+// for kinds without replicas
+func desiredSummary(obj *unstructured.Unstructured) string {
+	if replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas"); found {
+		return fmt.Sprintf("%d replicas", replicas)
+	}
+	return ""
+}
+
+// workloadBindings returns the Bindings whose workload lists the object, in
+// name order
+func workloadBindings(bindings []unstructured.Unstructured, ref kubestellar.ObjectRef) []workloadBinding {
+	var result []workloadBinding
+	for i := range bindings {
+		if !bindingContains(&bindings[i], ref) {
+			continue
+		}
+		result = append(result, workloadBinding{
+			Policy:   bindings[i].GetName(),
+			State:    kubestellar.BindingState(&bindings[i]),
+			Clusters: kubestellar.BindingDestinations(&bindings[i]),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Policy < result[j].Policy })
+	return result
+}
+
+// placedClusters returns the destinations of all the Bindings, once each
+func placedClusters(bindings []workloadBinding) []string {
+	seen := map[string]bool{}
+	for _, b := range bindings {
+		for _, c := range b.Clusters {
+			seen[c] = true
+		}
+	}
+	return sortedKeys(seen)
+}
+
+// reportedStates returns the status each cluster reported for the object,
+// from the WorkStatuses of the ITS, which live in the cluster's namespace
+func reportedStates(workStatuses []unstructured.Unstructured, ref kubestellar.ObjectRef) map[string]string {
+	states := map[string]string{}
+	for i := range workStatuses {
+		source := kubestellar.WorkStatusSource(&workStatuses[i])
+		if source.Group == ref.Group && source.Resource == ref.Resource && source.Namespace == ref.Namespace && source.Name == ref.Name {
+			states[workStatuses[i].GetNamespace()] = kubestellar.WorkStatusState(&workStatuses[i])
+		}
+	}
+	return states
+}
+
+// readClusterCopy reads the copy of the object in one cluster and judges it
+func readClusterCopy(c cluster.ClusterInfo, gvr schema.GroupVersionResource, ref kubestellar.ObjectRef) workloadClusterStatus {
+	s, _ := getClusterCopy(c, gvr, ref)
+	return s
+}
+
+// getClusterCopy reads the copy of the object in one cluster and judges it,
+// returning the copy too when the cluster has one
+func getClusterCopy(c cluster.ClusterInfo, gvr schema.GroupVersionResource, ref kubestellar.ObjectRef) (workloadClusterStatus, *unstructured.Unstructured) {
+	s := workloadClusterStatus{Cluster: c.Name}
+	obj, err := c.DynamicClient.Resource(gvr).Namespace(ref.Namespace).Get(commandContext(), ref.Name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		s.Status = copyMissing
+		return s, nil
+	case err != nil:
+		s.Status, s.Detail = copyUnknown, fmt.Sprintf("failed to get %s: %v", ref.Resource, err)
+		return s, nil
+	}
+
+	objStatus, _, _ := unstructured.NestedMap(obj.Object, "status")
+	s.Live = kubestellar.ObjectStatusState(objStatus)
+	for _, cond := range kubestellar.ObjectConditions(obj) {
+		s.Conditions = append(s.Conditions, cond.Type+"="+cond.Status)
+	}
+	s.Status = copyReady
+	if ready, reason := workloadReady(obj); !ready {
+		s.Status, s.Detail = copyNotReady, reason
+	}
+	return s, obj
+}
+
+func printWorkloadStatus(status workloadStatus) {
+	out := util.GetOutputStream()
+	fmt.Fprintf(out, "Object:      %s\n", status.Object)
+	fmt.Fprintf(out, "WDS:         %s (generation %d)\n", status.WDS, status.Generation)
+	if status.Desired != "" {
+		fmt.Fprintf(out, "Desired:     %s\n", status.Desired)
+	}
+	if len(status.Bindings) == 0 {
+		fmt.Fprintf(out, "Bindings:    <none>, the object is not placed on any cluster\n")
+		return
+	}
+	for i, b := range status.Bindings {
+		label := "Bindings:"
+		if i > 0 {
+			label = ""
+		}
+		fmt.Fprintf(out, "%-12s %s (%s) -> %s\n", label, b.Policy, b.State, orNone(strings.Join(b.Clusters, ", ")))
+	}
+	fmt.Fprintln(out)
+
+	tw := util.NewTableWriter(out, "")
+	defer tw.Flush()
+	fmt.Fprintln(tw, "CLUSTER\tSTATUS\tREPORTED\tLIVE\tCONDITIONS\tDETAIL")
+	for _, c := range status.Clusters {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", c.Cluster, c.Status, orNone(c.Reported), orNone(c.Live),
+			orNone(strings.Join(c.Conditions, ",")), orNone(c.Detail))
+	}
+}
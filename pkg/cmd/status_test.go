@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+)
+
+func TestWorkloadBindings(t *testing.T) {
+	ref := kubestellar.ObjectRef{Group: "apps", Version: "v1", Resource: "deployments", Namespace: "web", Name: "nginx"}
+	bindings := []unstructured.Unstructured{
+		*testWorkloadBinding("web-edge", 2, 2, "edge1", "cluster1"),
+		*testBinding("other", "cluster3"),
+		*testWorkloadBinding("web-core", 3, 2, "cluster1", "cluster2"),
+	}
+
+	got := workloadBindings(bindings, ref)
+	want := []workloadBinding{
+		{Policy: "web-core", State: "Pending", Clusters: []string{"cluster1", "cluster2"}},
+		{Policy: "web-edge", State: "Synced", Clusters: []string{"edge1", "cluster1"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("workloadBindings() = %+v, want %+v", got, want)
+	}
+	if clusters := placedClusters(got); !reflect.DeepEqual(clusters, []string{"cluster1", "cluster2", "edge1"}) {
+		t.Errorf("placedClusters() = %v", clusters)
+	}
+}
+
+func TestReportedStates(t *testing.T) {
+	ref := kubestellar.ObjectRef{Group: "apps", Version: "v1", Resource: "deployments", Namespace: "web", Name: "nginx"}
+	reporting := testWorkStatus("cluster1")
+	unstructured.SetNestedField(reporting.Object, map[string]interface{}{"replicas": int64(2), "readyReplicas": int64(1)}, "status")
+	other := testWorkStatus("cluster2")
+	unstructured.SetNestedField(other.Object, "redis", "spec", "sourceRef", "name")
+
+	got := reportedStates([]unstructured.Unstructured{*reporting, *other}, ref)
+	if want := map[string]string{"cluster1": "1/2 ready"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("reportedStates() = %v, want %v", got, want)
+	}
+}
+
+func TestReadClusterCopy(t *testing.T) {
+	deployment := func(ready int64) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"namespace": "web", "name": "nginx"},
+			"spec":       map[string]interface{}{"replicas": int64(2)},
+			"status": map[string]interface{}{
+				"replicas": int64(2), "readyReplicas": ready, "updatedReplicas": int64(2), "availableReplicas": ready,
+				"conditions": []interface{}{map[string]interface{}{"type": "Available", "status": "True"}},
+			},
+		}}
+	}
+	ref := kubestellar.ObjectRef{Group: "apps", Version: "v1", Resource: "deployments", Namespace: "web", Name: "nginx"}
+	gvr := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	copyIn := func(name string, objects ...runtime.Object) cluster.ClusterInfo {
+		return cluster.ClusterInfo{Name: name, DynamicClient: fakedynamic.NewSimpleDynamicClient(runtime.NewScheme(), objects...)}
+	}
+
+	tests := []struct {
+		name string
+		c    cluster.ClusterInfo
+		want workloadClusterStatus
+	}{
+		{name: "ready", c: copyIn("cluster1", deployment(2)), want: workloadClusterStatus{
+			Cluster: "cluster1", Status: copyReady, Live: "2/2 ready", Conditions: []string{"Available=True"},
+		}},
+		{name: "not ready", c: copyIn("cluster2", deployment(1)), want: workloadClusterStatus{
+			Cluster: "cluster2", Status: copyNotReady, Live: "1/2 ready", Conditions: []string{"Available=True"}, Detail: "1 of 2 replicas ready",
+		}},
+		{name: "missing", c: copyIn("cluster3"), want: workloadClusterStatus{Cluster: "cluster3", Status: copyMissing}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := readClusterCopy(tt.c, gvr, ref); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readClusterCopy() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
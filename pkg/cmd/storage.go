@@ -0,0 +1,303 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+// Issues reported by storage audit
+const (
+	storageIssueReleased  = "Released"
+	storageIssueAvailable = "Unbound"
+	storageIssueFailed    = "Failed"
+	storageIssuePending   = "Pending"
+	storageIssueLost      = "Lost"
+	storageIssueUnused    = "NotMounted"
+)
+
+// storageFinding is one volume or claim that wastes or waits for storage
+type storageFinding struct {
+	Kind         string    `json:"kind"`
+	Namespace    string    `json:"namespace,omitempty"`
+	Name         string    `json:"name"`
+	Issue        string    `json:"issue"`
+	Detail       string    `json:"detail"`
+	Capacity     string    `json:"capacity"`
+	StorageClass string    `json:"storageClass"`
+	Created      time.Time `json:"created"`
+}
+
+// storageReport is the audit of one cluster
+type storageReport struct {
+	Cluster  string           `json:"cluster"`
+	Volumes  int              `json:"volumes"`
+	Claims   int              `json:"claims"`
+	Findings []storageFinding `json:"findings"`
+}
+
+func newStorageCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "storage",
+		Short: "Inspect persistent storage across managed clusters",
+	}
+	cmd.AddCommand(newStorageAuditCommand())
+	return cmd
+}
+
+func newStorageAuditCommand() *cobra.Command {
+	var outputFormat string
+	var targets clusterTargets
+	var reach reachability
+
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Find released, unbound, pending and unused volumes in every managed cluster",
+		Long: `Correlate the PersistentVolumes, PersistentVolumeClaims and pods of every
+managed cluster and report storage that is wasted or stuck:
+
+  Released    volume whose claim was deleted but which was not reclaimed
+  Unbound     volume available but not bound to any claim
+  Failed      volume whose automatic reclamation failed
+  Pending     claim waiting for a volume
+  Lost        claim whose volume disappeared
+  NotMounted  bound claim that no running or pending pod mounts
+
+Volumes are cluster-scoped; with -n only the volumes claimed from that
+namespace are audited, with -A all of them are.`,
+		Example: `# Storage waste in every namespace of every cluster
+kubectl multi storage audit -A
+
+# One namespace in two clusters, as JSON
+kubectl multi storage audit -n prod --clusters cluster1,cluster2 -o json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch outputFormat {
+			case "", "json", util.TableFormatCSV, util.TableFormatMarkdown:
+			default:
+				return fmt.Errorf("unsupported output format %q, must be one of json|csv|markdown", outputFormat)
+			}
+			kubeconfig, remoteCtx, _, namespace, allNamespaces := GetGlobalFlags()
+			return handleStorageAuditCommand(outputFormat, targets, reach, kubeconfig, remoteCtx, namespace, allNamespaces)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "output format (json|csv|markdown)")
+	targets.addFlags(cmd, "audit")
+	reach.addFlags(cmd)
+
+	return cmd
+}
+
+func handleStorageAuditCommand(outputFormat string, targets clusterTargets, reach reachability, kubeconfig, remoteCtx, namespace string, allNamespaces bool) error {
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	clusters, err = targets.selectFrom(clusters, kubeconfig)
+	if err != nil {
+		return err
+	}
+	clusters, err = reach.filter(clusters)
+	if err != nil {
+		return err
+	}
+	defer printClusterIssuesToStderr()
+
+	targetNS := cluster.GetTargetNamespace(namespace)
+	if allNamespaces {
+		targetNS = ""
+	}
+	var reports []storageReport
+	for _, clusterInfo := range clusters {
+		if clusterInfo.Client == nil {
+			continue
+		}
+		if report, ok := auditClusterStorage(clusterInfo, targetNS); ok {
+			reports = append(reports, report)
+		}
+	}
+
+	if outputFormat == "json" {
+		if reports == nil {
+			reports = []storageReport{}
+		}
+		data, err := json.MarshalIndent(reports, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode storage report: %v", err)
+		}
+		fmt.Fprintln(util.GetOutputStream(), string(data))
+		return nil
+	}
+
+	tw := util.NewTableWriter(util.GetOutputStream(), outputFormat)
+	defer tw.Flush()
+
+	rows := 0
+	for _, report := range reports {
+		for _, f := range report.Findings {
+			if rows == 0 {
+				fmt.Fprintf(tw, "CLUSTER\tKIND\tNAMESPACE\tNAME\tISSUE\tCAPACITY\tSTORAGECLASS\tDETAIL\tAGE\n")
+			}
+			rows++
+			ns := f.Namespace
+			if ns == "" {
+				ns = "-"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				report.Cluster, f.Kind, ns, f.Name, f.Issue, f.Capacity, f.StorageClass, f.Detail,
+				duration.HumanDuration(time.Since(f.Created)))
+		}
+	}
+	if rows == 0 {
+		fmt.Fprintln(os.Stderr, "No storage issues found.")
+	}
+	return nil
+}
+
+// auditClusterStorage lists the volumes, claims and pods of one cluster and
+// audits them. It notes a cluster issue and returns false if a list fails.
+func auditClusterStorage(clusterInfo cluster.ClusterInfo, targetNS string) (storageReport, bool) {
+	pvs, err := clusterInfo.Client.CoreV1().PersistentVolumes().List(commandContext(), metav1.ListOptions{})
+	if err != nil {
+		noteClusterIssue(clusterInfo.Name, fmt.Sprintf("failed to list persistentvolumes: %v", err))
+		return storageReport{}, false
+	}
+	pvcs, err := listNamespaced(clusterInfo, "", "persistentvolumeclaims", targetNS, func(ns string) (*corev1.PersistentVolumeClaimList, error) {
+		return clusterInfo.Client.CoreV1().PersistentVolumeClaims(ns).List(commandContext(), metav1.ListOptions{})
+	})
+	if err != nil {
+		noteClusterIssue(clusterInfo.Name, fmt.Sprintf("failed to list persistentvolumeclaims: %v", err))
+		return storageReport{}, false
+	}
+	pods, err := listNamespaced(clusterInfo, "", "pods", targetNS, func(ns string) (*corev1.PodList, error) {
+		return clusterInfo.Client.CoreV1().Pods(ns).List(commandContext(), metav1.ListOptions{})
+	})
+	if err != nil {
+		noteClusterIssue(clusterInfo.Name, fmt.Sprintf("failed to list pods: %v", err))
+		return storageReport{}, false
+	}
+
+	volumes := pvs.Items
+	if targetNS != "" {
+		volumes = nil
+		for _, pv := range pvs.Items {
+			if pv.Spec.ClaimRef != nil && pv.Spec.ClaimRef.Namespace == targetNS {
+				volumes = append(volumes, pv)
+			}
+		}
+	}
+	return storageReport{
+		Cluster:  clusterInfo.Name,
+		Volumes:  len(volumes),
+		Claims:   len(pvcs.Items),
+		Findings: auditStorage(volumes, pvcs.Items, pods.Items),
+	}, true
+}
+
+// auditStorage returns the problem volumes and claims, volumes first, each
+// sorted by namespace and name
+func auditStorage(pvs []corev1.PersistentVolume, pvcs []corev1.PersistentVolumeClaim, pods []corev1.Pod) []storageFinding {
+	findings := []storageFinding{}
+
+	sort.Slice(pvs, func(i, j int) bool { return pvs[i].Name < pvs[j].Name })
+	for i := range pvs {
+		pv := &pvs[i]
+		var issue, detail string
+		switch pv.Status.Phase {
+		case corev1.VolumeReleased:
+			issue = storageIssueReleased
+			detail = fmt.Sprintf("was %s, reclaim policy %s", util.GetPVClaim(pv), pv.Spec.PersistentVolumeReclaimPolicy)
+		case corev1.VolumeAvailable:
+			issue = storageIssueAvailable
+			detail = "no claim"
+		case corev1.VolumeFailed:
+			issue = storageIssueFailed
+			detail = pv.Status.Message
+		default:
+			continue
+		}
+		findings = append(findings, storageFinding{
+			Kind:         "PersistentVolume",
+			Name:         pv.Name,
+			Issue:        issue,
+			Detail:       detail,
+			Capacity:     util.GetPVCapacity(pv),
+			StorageClass: util.GetPVStorageClass(pv),
+			Created:      pv.CreationTimestamp.Time,
+		})
+	}
+
+	mounted := mountedClaims(pods)
+	sort.Slice(pvcs, func(i, j int) bool {
+		if pvcs[i].Namespace != pvcs[j].Namespace {
+			return pvcs[i].Namespace < pvcs[j].Namespace
+		}
+		return pvcs[i].Name < pvcs[j].Name
+	})
+	for i := range pvcs {
+		pvc := &pvcs[i]
+		var issue, detail string
+		switch pvc.Status.Phase {
+		case corev1.ClaimPending:
+			issue = storageIssuePending
+			detail = "waiting for a volume"
+		case corev1.ClaimLost:
+			issue = storageIssueLost
+			detail = "volume " + pvc.Spec.VolumeName + " is gone"
+		case corev1.ClaimBound:
+			if mounted[pvc.Namespace+"/"+pvc.Name] {
+				continue
+			}
+			issue = storageIssueUnused
+			detail = "bound to " + pvc.Spec.VolumeName
+		default:
+			continue
+		}
+		capacity := util.GetPVCCapacity(pvc)
+		if request, ok := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; ok && pvc.Status.Phase == corev1.ClaimPending {
+			capacity = request.String()
+		}
+		findings = append(findings, storageFinding{
+			Kind:         "PersistentVolumeClaim",
+			Namespace:    pvc.Namespace,
+			Name:         pvc.Name,
+			Issue:        issue,
+			Detail:       detail,
+			Capacity:     capacity,
+			StorageClass: util.GetPVCStorageClass(pvc),
+			Created:      pvc.CreationTimestamp.Time,
+		})
+	}
+	return findings
+}
+
+// mountedClaims returns the namespace/name of every claim a pod that has not
+// finished mounts, including the claims of generic ephemeral volumes
+func mountedClaims(pods []corev1.Pod) map[string]bool {
+	mounted := make(map[string]bool)
+	for _, pod := range pods {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		for _, volume := range pod.Spec.Volumes {
+			switch {
+			case volume.PersistentVolumeClaim != nil:
+				mounted[pod.Namespace+"/"+volume.PersistentVolumeClaim.ClaimName] = true
+			case volume.Ephemeral != nil:
+				mounted[pod.Namespace+"/"+pod.Name+"-"+volume.Name] = true
+			}
+		}
+	}
+	return mounted
+}
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testPV(name string, phase corev1.PersistentVolumePhase, claim *corev1.ObjectReference) *corev1.PersistentVolume {
+	return &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: corev1.PersistentVolumeSpec{
+			Capacity:                      corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")},
+			ClaimRef:                      claim,
+			PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimRetain,
+		},
+		Status: corev1.PersistentVolumeStatus{Phase: phase},
+	}
+}
+
+func testPVC(namespace, name string, phase corev1.PersistentVolumeClaimPhase) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			VolumeName: "pv-" + name,
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+			},
+		},
+		Status: corev1.PersistentVolumeClaimStatus{Phase: phase},
+	}
+}
+
+func testClaimPod(namespace, name string, phase corev1.PodPhase, volumes ...corev1.Volume) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       corev1.PodSpec{Volumes: volumes},
+		Status:     corev1.PodStatus{Phase: phase},
+	}
+}
+
+func claimVolume(claim string) corev1.Volume {
+	return corev1.Volume{Name: "data", VolumeSource: corev1.VolumeSource{
+		PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claim},
+	}}
+}
+
+func TestAuditClusterStorage(t *testing.T) {
+	c := testTypedCluster("cluster1",
+		testPV("pv-bound", corev1.VolumeBound, &corev1.ObjectReference{Namespace: "prod", Name: "db"}),
+		testPV("pv-released", corev1.VolumeReleased, &corev1.ObjectReference{Namespace: "prod", Name: "old"}),
+		testPV("pv-free", corev1.VolumeAvailable, nil),
+		testPV("pv-other", corev1.VolumeReleased, &corev1.ObjectReference{Namespace: "dev", Name: "tmp"}),
+		testPVC("prod", "db", corev1.ClaimBound),
+		testPVC("prod", "cache", corev1.ClaimBound),
+		testPVC("prod", "logs", corev1.ClaimBound),
+		testPVC("prod", "new", corev1.ClaimPending),
+		testPVC("prod", "web-scratch", corev1.ClaimBound),
+		testClaimPod("prod", "db-0", corev1.PodRunning, claimVolume("db")),
+		testClaimPod("prod", "backup", corev1.PodSucceeded, claimVolume("logs")),
+		testClaimPod("prod", "web", corev1.PodRunning, corev1.Volume{Name: "scratch", VolumeSource: corev1.VolumeSource{
+			Ephemeral: &corev1.EphemeralVolumeSource{},
+		}}),
+	)
+
+	type row struct{ kind, namespace, name, issue string }
+	tests := []struct {
+		name     string
+		targetNS string
+		want     []row
+		volumes  int
+	}{
+		{
+			name:     "one namespace",
+			targetNS: "prod",
+			volumes:  2,
+			want: []row{
+				{"PersistentVolume", "", "pv-released", storageIssueReleased},
+				{"PersistentVolumeClaim", "prod", "cache", storageIssueUnused},
+				{"PersistentVolumeClaim", "prod", "logs", storageIssueUnused},
+				{"PersistentVolumeClaim", "prod", "new", storageIssuePending},
+			},
+		},
+		{
+			name:    "all namespaces",
+			volumes: 4,
+			want: []row{
+				{"PersistentVolume", "", "pv-free", storageIssueAvailable},
+				{"PersistentVolume", "", "pv-other", storageIssueReleased},
+				{"PersistentVolume", "", "pv-released", storageIssueReleased},
+				{"PersistentVolumeClaim", "prod", "cache", storageIssueUnused},
+				{"PersistentVolumeClaim", "prod", "logs", storageIssueUnused},
+				{"PersistentVolumeClaim", "prod", "new", storageIssuePending},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, ok := auditClusterStorage(c, tt.targetNS)
+			if !ok {
+				t.Fatal("auditClusterStorage() failed")
+			}
+			if report.Volumes != tt.volumes || report.Claims != 5 {
+				t.Errorf("audited %d volumes and %d claims, want %d and 5", report.Volumes, report.Claims, tt.volumes)
+			}
+			var got []row
+			for _, f := range report.Findings {
+				got = append(got, row{f.Kind, f.Namespace, f.Name, f.Issue})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findings = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	report, _ := auditClusterStorage(c, "prod")
+	if got := report.Findings[0].Detail; got != "was prod/old, reclaim policy Retain" {
+		t.Errorf("released detail = %q", got)
+	}
+	if got := report.Findings[3].Capacity; got != "1Gi" {
+		t.Errorf("pending capacity = %q, want the requested 1Gi", got)
+	}
+}
+
+func TestAuditClusterStorageListFailure(t *testing.T) {
+	resetClusterIssues()
+	defer resetClusterIssues()
+
+	if _, ok := auditClusterStorage(testBrokenTypedCluster("cluster2"), ""); ok {
+		t.Error("auditClusterStorage() succeeded on a broken cluster")
+	}
+	if issues := takeClusterIssues(); len(issues) != 1 || issues[0].Cluster != "cluster2" {
+		t.Errorf("issues = %v, want one for cluster2", issues)
+	}
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"kubectl-multi/pkg/cluster"
+)
+
+// Execution strategies of mutating fan-out commands
+const (
+	strategyAll    = "all"
+	strategyWaves  = "waves"
+	strategyCanary = "canary"
+)
+
+// fanOutStrategy holds the --strategy, --wave-size, --wave-pause and --canary
+// flags that make a mutating command roll through the fleet gradually
+// instead of changing every targeted cluster in one go
+type fanOutStrategy struct {
+	Name      string
+	WaveSize  int
+	WavePause time.Duration
+	Canary    string
+}
+
+// addFlags registers the strategy flags on cmd
+func (s *fanOutStrategy) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&s.Name, "strategy", strategyAll, "how to roll through the clusters: all, waves (--wave-size clusters at a time) or canary (--canary first, then the rest)")
+	cmd.Flags().IntVar(&s.WaveSize, "wave-size", 0, "clusters per wave; with --strategy canary, the clusters after the canary are split into waves of this size")
+	cmd.Flags().DurationVar(&s.WavePause, "wave-pause", 0, "how long to wait between waves, e.g. 5m, to watch the changed clusters")
+	cmd.Flags().StringVar(&s.Canary, "canary", "", "with --strategy canary, the cluster changed first (defaults to the first targeted cluster)")
+}
+
+// validate checks the strategy flags
+func (s fanOutStrategy) validate() error {
+	switch s.Name {
+	case strategyAll:
+		if s.WaveSize != 0 || s.WavePause != 0 || s.Canary != "" {
+			return fmt.Errorf("--wave-size, --wave-pause and --canary need --strategy waves or canary")
+		}
+	case strategyWaves:
+		if s.WaveSize < 1 {
+			return fmt.Errorf("--strategy waves requires --wave-size of at least 1")
+		}
+		if s.Canary != "" {
+			return fmt.Errorf("--canary needs --strategy canary")
+		}
+	case strategyCanary:
+		if s.WaveSize < 0 {
+			return fmt.Errorf("--wave-size must not be negative")
+		}
+	default:
+		return fmt.Errorf("invalid --strategy %q, must be one of all, waves, canary", s.Name)
+	}
+	if s.WavePause < 0 {
+		return fmt.Errorf("--wave-pause must not be negative")
+	}
+	return nil
+}
+
+// waves splits the clusters into the waves the strategy changes one after
+// another
+func (s fanOutStrategy) waves(clusters []cluster.ClusterInfo) ([][]cluster.ClusterInfo, error) {
+	if len(clusters) == 0 {
+		return nil, nil
+	}
+	switch s.Name {
+	case strategyWaves:
+		return chunkClusters(clusters, s.WaveSize), nil
+	case strategyCanary:
+		canary := 0
+		if s.Canary != "" {
+			canary = -1
+			for i, c := range clusters {
+				if c.Name == s.Canary {
+					canary = i
+					break
+				}
+			}
+			if canary < 0 {
+				return nil, fmt.Errorf("canary cluster %s is not among the targeted clusters", s.Canary)
+			}
+		}
+		var rest []cluster.ClusterInfo
+		rest = append(rest, clusters[:canary]...)
+		rest = append(rest, clusters[canary+1:]...)
+		size := s.WaveSize
+		if size == 0 {
+			size = len(rest)
+		}
+		return append([][]cluster.ClusterInfo{{clusters[canary]}}, chunkClusters(rest, size)...), nil
+	}
+	return [][]cluster.ClusterInfo{clusters}, nil
+}
+
+// chunkClusters splits clusters into groups of at most size
+func chunkClusters(clusters []cluster.ClusterInfo, size int) [][]cluster.ClusterInfo {
+	var chunks [][]cluster.ClusterInfo
+	for len(clusters) > 0 {
+		n := size
+		if n > len(clusters) {
+			n = len(clusters)
+		}
+		chunks = append(chunks, clusters[:n])
+		clusters = clusters[n:]
+	}
+	return chunks
+}
+
+// run calls change for every cluster, wave by wave. With the all strategy
+// every cluster is tried regardless of failures, as before. Otherwise a wave
+// in which a cluster failed halts the rollout: the later waves are not
+// touched and the returned error names them. Between waves it announces the
+// next wave on stderr and waits --wave-pause.
+func (s fanOutStrategy) run(clusters []cluster.ClusterInfo, change func(cluster.ClusterInfo) error) error {
+	waves, err := s.waves(clusters)
+	if err != nil {
+		return err
+	}
+	if s.Name == strategyAll {
+		for _, c := range clusters {
+			change(c)
+		}
+		return nil
+	}
+
+	for i, wave := range waves {
+		if i > 0 && s.WavePause > 0 {
+			fmt.Fprintf(os.Stderr, "Waiting %s before wave %d/%d\n", s.WavePause, i+1, len(waves))
+			select {
+			case <-time.After(s.WavePause):
+			case <-commandContext().Done():
+				return fmt.Errorf("interrupted before wave %d/%d; not changed: %s", i+1, len(waves), strings.Join(waveNames(waves[i:]), ", "))
+			}
+		}
+		fmt.Fprintf(os.Stderr, "Wave %d/%d: %s\n", i+1, len(waves), strings.Join(waveNames(waves[i:i+1]), ", "))
+		var failed []string
+		for _, c := range wave {
+			if err := change(c); err != nil {
+				failed = append(failed, c.Name)
+			}
+		}
+		if len(failed) > 0 && i < len(waves)-1 {
+			return fmt.Errorf("halted after wave %d/%d, failed in %s; not changed: %s", i+1, len(waves), strings.Join(failed, ", "), strings.Join(waveNames(waves[i+1:]), ", "))
+		}
+	}
+	return nil
+}
+
+// waveNames lists the cluster names of the waves in order
+func waveNames(waves [][]cluster.ClusterInfo) []string {
+	var names []string
+	for _, wave := range waves {
+		for _, c := range wave {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
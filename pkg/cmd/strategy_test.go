@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"kubectl-multi/pkg/cluster"
+)
+
+func TestFanOutStrategyValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy fanOutStrategy
+		wantErr  bool
+	}{
+		{name: "all", strategy: fanOutStrategy{Name: strategyAll}},
+		{name: "waves", strategy: fanOutStrategy{Name: strategyWaves, WaveSize: 2, WavePause: 5 * time.Minute}},
+		{name: "canary", strategy: fanOutStrategy{Name: strategyCanary, Canary: "cluster1"}},
+		{name: "canary without cluster", strategy: fanOutStrategy{Name: strategyCanary}},
+		{name: "waves without size", strategy: fanOutStrategy{Name: strategyWaves}, wantErr: true},
+		{name: "waves with canary", strategy: fanOutStrategy{Name: strategyWaves, WaveSize: 1, Canary: "cluster1"}, wantErr: true},
+		{name: "wave size without strategy", strategy: fanOutStrategy{Name: strategyAll, WaveSize: 2}, wantErr: true},
+		{name: "negative pause", strategy: fanOutStrategy{Name: strategyWaves, WaveSize: 1, WavePause: -time.Second}, wantErr: true},
+		{name: "unknown", strategy: fanOutStrategy{Name: "blue-green"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.strategy.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFanOutStrategyWaves(t *testing.T) {
+	clusters := testRestartClusters("c1", "c2", "c3", "c4", "c5")
+	tests := []struct {
+		name     string
+		strategy fanOutStrategy
+		want     [][]string
+		wantErr  bool
+	}{
+		{name: "all", strategy: fanOutStrategy{Name: strategyAll}, want: [][]string{{"c1", "c2", "c3", "c4", "c5"}}},
+		{name: "waves", strategy: fanOutStrategy{Name: strategyWaves, WaveSize: 2}, want: [][]string{{"c1", "c2"}, {"c3", "c4"}, {"c5"}}},
+		{name: "canary first cluster", strategy: fanOutStrategy{Name: strategyCanary}, want: [][]string{{"c1"}, {"c2", "c3", "c4", "c5"}}},
+		{name: "canary named", strategy: fanOutStrategy{Name: strategyCanary, Canary: "c3", WaveSize: 3}, want: [][]string{{"c3"}, {"c1", "c2", "c4"}, {"c5"}}},
+		{name: "canary not targeted", strategy: fanOutStrategy{Name: strategyCanary, Canary: "c9"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			waves, err := tt.strategy.waves(clusters)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("waves() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got [][]string
+			for _, wave := range waves {
+				got = append(got, waveNames([][]cluster.ClusterInfo{wave}))
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("waves() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFanOutStrategyRun(t *testing.T) {
+	clusters := testRestartClusters("c1", "c2", "c3", "c4", "c5")
+	change := func(ran *[]string, fail string) func(cluster.ClusterInfo) error {
+		return func(c cluster.ClusterInfo) error {
+			*ran = append(*ran, c.Name)
+			if c.Name == fail {
+				return errors.New("failed")
+			}
+			return nil
+		}
+	}
+
+	// The all strategy keeps going after failures
+	var ran []string
+	if err := (fanOutStrategy{Name: strategyAll}).run(clusters, change(&ran, "c2")); err != nil || len(ran) != 5 {
+		t.Errorf("all: ran %v, error %v; want all clusters and no error", ran, err)
+	}
+
+	// Waves halt after the wave with the failure
+	ran = nil
+	err := fanOutStrategy{Name: strategyWaves, WaveSize: 2}.run(clusters, change(&ran, "c2"))
+	if !reflect.DeepEqual(ran, []string{"c1", "c2"}) {
+		t.Errorf("waves: ran %v, want c1 and c2", ran)
+	}
+	if err == nil || !strings.Contains(err.Error(), "halted after wave 1/3, failed in c2; not changed: c3, c4, c5") {
+		t.Errorf("waves: error = %v", err)
+	}
+
+	// A failed canary leaves every other cluster alone
+	ran = nil
+	err = fanOutStrategy{Name: strategyCanary, Canary: "c4"}.run(clusters, change(&ran, "c4"))
+	if !reflect.DeepEqual(ran, []string{"c4"}) || err == nil || !strings.Contains(err.Error(), "not changed: c1, c2, c3, c5") {
+		t.Errorf("canary: ran %v, error %v", ran, err)
+	}
+
+	// A failure in the last wave has nothing left to halt
+	ran = nil
+	if err := (fanOutStrategy{Name: strategyWaves, WaveSize: 2}).run(clusters, change(&ran, "c5")); err != nil || len(ran) != 5 {
+		t.Errorf("last wave: ran %v, error %v", ran, err)
+	}
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+)
+
+// clusterTargets holds the --clusters and --cluster-selector flags that
+// narrow a fleet command to a subset of the managed clusters, and
+// --placement on the commands that register it
+type clusterTargets struct {
+	Names     []string
+	Selector  string
+	Placement string
+}
+
+// addFlags registers the targeting flags on cmd; verb completes the help text
+func (t *clusterTargets) addFlags(cmd *cobra.Command, verb string) {
+	cmd.Flags().StringSliceVar(&t.Names, "clusters", nil, "comma-separated list of managed clusters or @groups to "+verb+" (defaults to all)")
+	cmd.Flags().StringVar(&t.Selector, "cluster-selector", "", "label selector on the ManagedCluster objects in the ITS choosing the clusters to "+verb+" (e.g. 'env=prod,region in (us-east,us-west)')")
+}
+
+// addPlacementFlag registers --placement, which targets the clusters an OCM
+// Placement of the ITS decided on
+func (t *clusterTargets) addPlacementFlag(cmd *cobra.Command, verb string) {
+	cmd.Flags().StringVar(&t.Placement, "placement", "", "OCM Placement in the ITS, as [NAMESPACE/]NAME, whose decided clusters to "+verb)
+}
+
+// selectFrom narrows the discovered clusters by name or group, then by the
+// ManagedCluster label selector, then by the decisions of the Placement
+func (t clusterTargets) selectFrom(clusters []cluster.ClusterInfo, kubeconfig string) ([]cluster.ClusterInfo, error) {
+	clusters, err := selectTargetClusters(clusters, t.Names)
+	if err != nil {
+		return nil, err
+	}
+	clusters, err = cluster.SelectClustersByLabels(commandContext(), clusters, kubeconfig, t.Selector)
+	if err != nil {
+		return nil, err
+	}
+	return selectClustersByPlacement(clusters, t.Placement, func(its string) (dynamic.Interface, error) {
+		c, err := cluster.ClientForContext(kubeconfig, its)
+		if err != nil {
+			return nil, err
+		}
+		return c.DynamicClient, nil
+	})
+}
+
+// selectClustersByPlacement keeps the clusters the OCM Placement in their
+// ITS decided on, reading each ITS through itsClient. The Placement must
+// exist in one of the ITSes. An empty placement keeps every cluster.
+func selectClustersByPlacement(clusters []cluster.ClusterInfo, placement string, itsClient func(its string) (dynamic.Interface, error)) ([]cluster.ClusterInfo, error) {
+	if placement == "" {
+		return clusters, nil
+	}
+
+	decided := map[string]map[string]bool{}
+	found := false
+	for _, c := range clusters {
+		if c.ITS == "" || decided[c.ITS] != nil {
+			continue
+		}
+		its, err := itsClient(c.ITS)
+		if err != nil {
+			return nil, err
+		}
+		decided[c.ITS] = map[string]bool{}
+		p, err := findPlacement(its, placement)
+		if err != nil {
+			return nil, fmt.Errorf("ITS %s: %v", c.ITS, err)
+		}
+		if p == nil {
+			continue
+		}
+		found = true
+		names, err := placementClusters(its, p)
+		if err != nil {
+			return nil, fmt.Errorf("ITS %s: %v", c.ITS, err)
+		}
+		for _, name := range names {
+			decided[c.ITS][name] = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("placement %s not found in the ITS", placement)
+	}
+
+	var selected []cluster.ClusterInfo
+	for _, c := range clusters {
+		if c.ITS != "" && decided[c.ITS][c.ManagedClusterName()] {
+			selected = append(selected, c)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("placement %s has decided on none of the targeted clusters", placement)
+	}
+	return selected, nil
+}
+
+// findPlacement returns the OCM Placement named [NAMESPACE/]NAME in the
+// ITS, or nil when there is none. Without a namespace the name must be
+// unique across namespaces.
+func findPlacement(its dynamic.Interface, ref string) (*unstructured.Unstructured, error) {
+	namespace, name, qualified := strings.Cut(ref, "/")
+	if !qualified {
+		namespace, name = "", ref
+	}
+	if name == "" || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("invalid placement %q, expected [NAMESPACE/]NAME", ref)
+	}
+
+	list, err := its.Resource(kubestellar.PlacementGVR).Namespace(namespace).List(commandContext(), metav1.ListOptions{})
+	if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+		// The ITS does not serve the OCM placement APIs
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list placements: %v", err)
+	}
+	var found []*unstructured.Unstructured
+	var namespaces []string
+	for i := range list.Items {
+		if list.Items[i].GetName() == name {
+			found = append(found, &list.Items[i])
+			namespaces = append(namespaces, list.Items[i].GetNamespace())
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, nil
+	case 1:
+		return found[0], nil
+	default:
+		return nil, fmt.Errorf("placement %s exists in namespaces %s, name it as NAMESPACE/%s", name, strings.Join(namespaces, ", "), name)
+	}
+}
+
+// placementClusters returns the clusters the PlacementDecisions of an OCM
+// Placement decided on, sorted
+func placementClusters(its dynamic.Interface, placement *unstructured.Unstructured) ([]string, error) {
+	list, err := its.Resource(kubestellar.PlacementDecisionGVR).Namespace(placement.GetNamespace()).List(commandContext(), metav1.ListOptions{
+		LabelSelector: kubestellar.PlacementLabel + "=" + placement.GetName(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the placementdecisions of placement %s/%s: %v", placement.GetNamespace(), placement.GetName(), err)
+	}
+	return kubestellar.PlacementDecisionClusters(list.Items), nil
+}
+
+// workloadClusters leaves the ITS, whose context is itsContext, out of
+// clusters. Workloads are not delivered to the ITS, so the reports about
+// them would only pick up its own control plane objects there.
+func workloadClusters(clusters []cluster.ClusterInfo, itsContext string) []cluster.ClusterInfo {
+	var wecs []cluster.ClusterInfo
+	for _, c := range clusters {
+		if c.Context != itsContext {
+			wecs = append(wecs, c)
+		}
+	}
+	return wecs
+}
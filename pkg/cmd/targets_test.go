@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	"testing"
+
+	"kubectl-multi/pkg/cluster"
+)
+
+func TestWorkloadClusters(t *testing.T) {
+	clusters := []cluster.ClusterInfo{
+		{Name: "its1", Context: "its1"},
+		{Name: "cluster1", Context: "cluster1"},
+		{Name: "cluster2", Context: "cluster2"},
+	}
+	got := workloadClusters(clusters, "its1")
+	if len(got) != 2 || got[0].Name != "cluster1" || got[1].Name != "cluster2" {
+		t.Errorf("workloadClusters() = %v, want cluster1 and cluster2", got)
+	}
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"kubectl-multi/pkg/network"
+	"kubectl-multi/pkg/telemetry"
+)
+
+// startTelemetry instruments the cluster clients when --metrics-json or an
+// OTLP endpoint is set
+func startTelemetry(cmd *cobra.Command) {
+	if metricsJSON == "" && otlpEndpoint == "" {
+		return
+	}
+	command := strings.TrimPrefix(cmd.CommandPath(), rootCmd.Name()+" ")
+	telemetry.Enable(command, otlpEndpoint != "")
+}
+
+// finishTelemetry writes the metrics summary and exports the trace. Failures
+// only warn so they never change the command's result.
+func finishTelemetry(cmdErr error) {
+	c := telemetry.Active()
+	if c == nil {
+		return
+	}
+	end := time.Now()
+
+	if metricsJSON != "" {
+		if err := writeMetricsJSON(c.Summary(end, cmdErr)); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: metrics: %v\n", err)
+		}
+	}
+	if otlpEndpoint != "" {
+		if err := network.CheckOnline("exporting the trace to " + otlpEndpoint); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: tracing: %v\n", err)
+		} else if err := c.ExportOTLP(otlpEndpoint, end, cmdErr); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: tracing: %v\n", err)
+		}
+	}
+}
+
+func writeMetricsJSON(summary telemetry.Summary) error {
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode metrics: %v", err)
+	}
+	data = append(data, '\n')
+	if metricsJSON == "-" {
+		_, err = os.Stderr.Write(data)
+		return err
+	}
+	if err := os.WriteFile(metricsJSON, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", metricsJSON, err)
+	}
+	return nil
+}
+
+// localSubprocess attributes subprocesses that do not talk to a cluster
+const localSubprocess = "<local>"
+
+// runObserved runs a kubectl or helm subprocess, through the proxy of its
+// cluster, recording it against the cluster in the metrics summary and trace
+func runObserved(cmd *exec.Cmd, cluster string) error {
+	proxySubprocess(cmd, cluster)
+	start := time.Now()
+	err := cmd.Run()
+	telemetry.ObserveCommand(cluster, cmd.Args, start, err)
+	return err
+}
+
+// kubectlContext returns the --context a kubectl command line targets
+func kubectlContext(args []string) string {
+	for i, arg := range args {
+		if arg == "--context" && i+1 < len(args) {
+			return args[i+1]
+		}
+		if value, ok := strings.CutPrefix(arg, "--context="); ok {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,20 @@
+package cmd
+
+import "testing"
+
+func TestKubectlContext(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"apply", "-f", "app.yaml", "--context", "cluster1"}, "cluster1"},
+		{[]string{"logs", "web", "--context=cluster2"}, "cluster2"},
+		{[]string{"describe", "pod", "web"}, ""},
+		{[]string{"get", "--context"}, ""},
+	}
+	for _, tt := range tests {
+		if got := kubectlContext(tt.args); got != tt.want {
+			t.Errorf("kubectlContext(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
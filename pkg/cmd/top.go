@@ -0,0 +1,325 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	resourcehelper "k8s.io/kubectl/pkg/util/resource"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+// podMetricsGVR identifies the pod usage served by metrics-server
+var podMetricsGVR = schema.GroupVersionResource{Group: "metrics.k8s.io", Version: "v1beta1", Resource: "pods"}
+
+// unknownUsage is printed for usage a cluster has no metrics for
+const unknownUsage = "<unknown>"
+
+// namespaceUsage is what the pods of one namespace request, are limited to
+// and use, in one cluster or, for the fleet rollup, over the Clusters that
+// run pods in it
+type namespaceUsage struct {
+	Cluster        string   `json:"cluster,omitempty"`
+	Clusters       []string `json:"clusters,omitempty"`
+	Namespace      string   `json:"namespace"`
+	Pods           int      `json:"pods"`
+	CPURequests    string   `json:"cpuRequests"`
+	CPULimits      string   `json:"cpuLimits"`
+	CPUUsage       string   `json:"cpuUsage,omitempty"`
+	MemoryRequests string   `json:"memoryRequests"`
+	MemoryLimits   string   `json:"memoryLimits"`
+	MemoryUsage    string   `json:"memoryUsage,omitempty"`
+
+	requests corev1.ResourceList
+	limits   corev1.ResourceList
+	usage    corev1.ResourceList
+	// metered is set when every cluster counted reported usage
+	metered bool
+}
+
+// topNamespacesReport is the JSON output of top namespaces
+type topNamespacesReport struct {
+	Clusters []*namespaceUsage `json:"clusters"`
+	Fleet    []*namespaceUsage `json:"fleet"`
+}
+
+// clusterPodUsage is the pods listed from one cluster with the usage of its
+// namespaces; usage is nil when the cluster serves no pod metrics
+type clusterPodUsage struct {
+	clusterPods
+	usage map[string]corev1.ResourceList
+}
+
+func newTopCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "top",
+		Short: "Display resource (CPU/memory) usage across managed clusters",
+	}
+	cmd.AddCommand(newTopNamespacesCommand())
+	return cmd
+}
+
+func newTopNamespacesCommand() *cobra.Command {
+	var outputFormat string
+	var selector string
+	var targets clusterTargets
+	var reach reachability
+
+	cmd := &cobra.Command{
+		Use:     "namespaces",
+		Aliases: []string{"namespace", "ns"},
+		Short:   "Show the CPU and memory requests, limits and usage of namespaces across managed clusters",
+		Long: `Sum the CPU and memory requests and limits of the pods of every namespace
+in every managed cluster, with their actual usage where the cluster serves the
+metrics API (metrics-server), then roll the namespaces up over the fleet.
+Comparing usage with requests shows what to right-size; the rollup is what
+each namespace costs the fleet.
+
+Pods that have finished are not counted. Requests and limits are the
+effective ones of each pod, as the scheduler sees them, so a container
+without a limit adds nothing to LIMITS. Usage is <unknown> for clusters
+without metrics, and in the rollup for namespaces any of them runs pods in.`,
+		Example: `# Requests, limits and usage of every namespace of the fleet
+kubectl multi top namespaces -A
+
+# One namespace, as JSON for a chargeback report
+kubectl multi top ns -n team-a -o json
+
+# The rollup as CSV for a spreadsheet
+kubectl multi top namespaces -A -o csv > namespaces.csv`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch outputFormat {
+			case "", "json", util.TableFormatCSV, util.TableFormatMarkdown:
+			default:
+				return fmt.Errorf("unsupported output format %q, must be one of json|csv|markdown", outputFormat)
+			}
+			selector, err := parseSelector(selector)
+			if err != nil {
+				return err
+			}
+			kubeconfig, remoteCtx, _, namespace, allNamespaces := GetGlobalFlags()
+			return handleTopNamespacesCommand(outputFormat, selector, targets, reach, kubeconfig, remoteCtx, namespace, allNamespaces)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "output format (json|csv|markdown)")
+	cmd.Flags().StringVarP(&selector, "selector", "l", "", "label selector on the pods")
+	targets.addFlags(cmd, "report on")
+	reach.addFlags(cmd)
+
+	return cmd
+}
+
+func handleTopNamespacesCommand(outputFormat, selector string, targets clusterTargets, reach reachability, kubeconfig, remoteCtx, namespace string, allNamespaces bool) error {
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	clusters, err = targets.selectFrom(clusters, kubeconfig)
+	if err != nil {
+		return err
+	}
+	clusters, err = reach.filter(clusters)
+	if err != nil {
+		return err
+	}
+	defer printClusterIssuesToStderr()
+
+	targetNS := cluster.GetTargetNamespace(namespace)
+	if allNamespaces {
+		targetNS = ""
+	}
+	byName := make(map[string]cluster.ClusterInfo, len(clusters))
+	for _, c := range clusters {
+		byName[c.Name] = c
+	}
+	var listed []clusterPodUsage
+	for _, cp := range listClusterPods(clusters, selector, namespace, allNamespaces) {
+		usage, err := listNamespaceUsage(byName[cp.cluster], selector, targetNS)
+		if err != nil {
+			noteClusterIssue(cp.cluster, err.Error())
+		}
+		listed = append(listed, clusterPodUsage{clusterPods: cp, usage: usage})
+	}
+	report := summarizeNamespaceUsage(listed)
+
+	if outputFormat == "json" {
+		if report.Clusters == nil {
+			report.Clusters = []*namespaceUsage{}
+			report.Fleet = []*namespaceUsage{}
+		}
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode report: %v", err)
+		}
+		fmt.Fprintln(util.GetOutputStream(), string(data))
+		return nil
+	}
+
+	tw := util.NewTableWriter(util.GetOutputStream(), outputFormat)
+	defer tw.Flush()
+	if len(report.Clusters) == 0 {
+		fmt.Fprintln(os.Stderr, "No pods found.")
+		return nil
+	}
+	fmt.Fprintf(tw, "CLUSTER\tNAMESPACE\tPODS\tCPU REQUESTS\tCPU LIMITS\tCPU USAGE\tMEMORY REQUESTS\tMEMORY LIMITS\tMEMORY USAGE\n")
+	for _, u := range report.Clusters {
+		fmt.Fprintf(tw, "%s\t%s\t%d%s\n", u.Cluster, u.Namespace, u.Pods, u.columns())
+	}
+	fmt.Fprintf(tw, "\nNAMESPACE\tCLUSTERS\tPODS\tCPU REQUESTS\tCPU LIMITS\tCPU USAGE\tMEMORY REQUESTS\tMEMORY LIMITS\tMEMORY USAGE\n")
+	for _, u := range report.Fleet {
+		fmt.Fprintf(tw, "%s\t%d\t%d%s\n", u.Namespace, len(u.Clusters), u.Pods, u.columns())
+	}
+	return nil
+}
+
+// columns returns the CPU and memory cells, each starting with a tab
+func (u *namespaceUsage) columns() string {
+	cpuUsage, memoryUsage := u.CPUUsage, u.MemoryUsage
+	if !u.metered {
+		cpuUsage, memoryUsage = unknownUsage, unknownUsage
+	}
+	return fmt.Sprintf("\t%s\t%s\t%s\t%s\t%s\t%s", u.CPURequests, u.CPULimits, cpuUsage, u.MemoryRequests, u.MemoryLimits, memoryUsage)
+}
+
+// listNamespaceUsage sums the CPU and memory usage metrics-server reports for
+// the pods of each namespace of a cluster. It returns nil, with an error to
+// note, when the cluster serves no pod metrics.
+func listNamespaceUsage(c cluster.ClusterInfo, selector, targetNS string) (map[string]corev1.ResourceList, error) {
+	if c.DynamicClient == nil {
+		return nil, fmt.Errorf("metrics API not available, usage not shown")
+	}
+	list, err := c.DynamicClient.Resource(podMetricsGVR).Namespace(targetNS).List(commandContext(), metav1.ListOptions{LabelSelector: selector})
+	if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+		return nil, fmt.Errorf("metrics API not available, usage not shown")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pod metrics, usage not shown: %v", err)
+	}
+
+	usage := make(map[string]corev1.ResourceList)
+	for _, item := range list.Items {
+		ns := usage[item.GetNamespace()]
+		if ns == nil {
+			ns = corev1.ResourceList{}
+			usage[item.GetNamespace()] = ns
+		}
+		containers, _, _ := unstructured.NestedSlice(item.Object, "containers")
+		for _, container := range containers {
+			m, ok := container.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			used, _, _ := unstructured.NestedStringMap(m, "usage")
+			for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+				q, err := resource.ParseQuantity(used[string(name)])
+				if err != nil {
+					continue
+				}
+				total := ns[name]
+				total.Add(q)
+				ns[name] = total
+			}
+		}
+	}
+	return usage, nil
+}
+
+// summarizeNamespaceUsage adds up the requests and limits of the pods that
+// have not finished, and the usage, per cluster and namespace and per
+// namespace over the fleet, each sorted by namespace, the clusters' rows by
+// cluster within it
+func summarizeNamespaceUsage(listed []clusterPodUsage) topNamespacesReport {
+	var report topNamespacesReport
+	fleet := make(map[string]*namespaceUsage)
+
+	for _, cp := range listed {
+		byNS := make(map[string]*namespaceUsage)
+		for i := range cp.pods {
+			pod := &cp.pods[i]
+			if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+				continue
+			}
+			u, ok := byNS[pod.Namespace]
+			if !ok {
+				u = &namespaceUsage{Cluster: cp.cluster, Namespace: pod.Namespace, requests: corev1.ResourceList{}, limits: corev1.ResourceList{}}
+				if cp.usage != nil {
+					u.metered = true
+					u.usage = cp.usage[pod.Namespace]
+				}
+				byNS[pod.Namespace] = u
+				report.Clusters = append(report.Clusters, u)
+			}
+			requests, limits := resourcehelper.PodRequestsAndLimits(pod)
+			u.Pods++
+			addResources(u.requests, requests)
+			addResources(u.limits, limits)
+		}
+
+		for _, u := range byNS {
+			f, ok := fleet[u.Namespace]
+			if !ok {
+				f = &namespaceUsage{Namespace: u.Namespace, requests: corev1.ResourceList{}, limits: corev1.ResourceList{}, usage: corev1.ResourceList{}, metered: true}
+				fleet[u.Namespace] = f
+				report.Fleet = append(report.Fleet, f)
+			}
+			f.Clusters = appendUnique(f.Clusters, u.Cluster)
+			f.Pods += u.Pods
+			addResources(f.requests, u.requests)
+			addResources(f.limits, u.limits)
+			addResources(f.usage, u.usage)
+			f.metered = f.metered && u.metered
+		}
+	}
+
+	for _, rows := range [][]*namespaceUsage{report.Clusters, report.Fleet} {
+		for _, u := range rows {
+			u.fill()
+		}
+	}
+	sort.Slice(report.Clusters, func(i, j int) bool {
+		a, b := report.Clusters[i], report.Clusters[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Cluster < b.Cluster
+	})
+	sort.Slice(report.Fleet, func(i, j int) bool { return report.Fleet[i].Namespace < report.Fleet[j].Namespace })
+	for _, f := range report.Fleet {
+		sort.Strings(f.Clusters)
+	}
+	return report
+}
+
+// fill formats the summed quantities for printing
+func (u *namespaceUsage) fill() {
+	u.CPURequests = formatCPU(u.requests[corev1.ResourceCPU])
+	u.CPULimits = formatCPU(u.limits[corev1.ResourceCPU])
+	u.MemoryRequests = formatMemory(u.requests[corev1.ResourceMemory])
+	u.MemoryLimits = formatMemory(u.limits[corev1.ResourceMemory])
+	if u.metered {
+		u.CPUUsage = formatCPU(u.usage[corev1.ResourceCPU])
+		u.MemoryUsage = formatMemory(u.usage[corev1.ResourceMemory])
+	}
+}
+
+// addResources adds the quantities of add to list
+func addResources(list, add corev1.ResourceList) {
+	for name, q := range add {
+		total := list[name]
+		total.Add(q)
+		list[name] = total
+	}
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+
+	"kubectl-multi/pkg/cluster"
+)
+
+func testSizedPod(namespace, name string, phase corev1.PodPhase, cpu, memory, cpuLimit string) corev1.Pod {
+	resources := corev1.ResourceRequirements{Requests: corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse(cpu),
+		corev1.ResourceMemory: resource.MustParse(memory),
+	}}
+	if cpuLimit != "" {
+		resources.Limits = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpuLimit)}
+	}
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Resources: resources}}},
+		Status:     corev1.PodStatus{Phase: phase},
+	}
+}
+
+func testPodMetrics(namespace, name string, usage ...map[string]interface{}) *unstructured.Unstructured {
+	var containers []interface{}
+	for _, u := range usage {
+		containers = append(containers, map[string]interface{}{"name": "app", "usage": u})
+	}
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "metrics.k8s.io/v1beta1",
+		"kind":       "PodMetrics",
+		"containers": containers,
+	}}
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+func TestListNamespaceUsage(t *testing.T) {
+	client := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{podMetricsGVR: "PodMetricsList"})
+	// The tracker would guess the wrong resource for PodMetrics passed in
+	// as objects, so they are created through the client
+	for _, m := range []*unstructured.Unstructured{
+		testPodMetrics("team-a", "web-1", map[string]interface{}{"cpu": "150m", "memory": "100Mi"}, map[string]interface{}{"cpu": "50m", "memory": "28Mi"}),
+		testPodMetrics("team-a", "web-2", map[string]interface{}{"cpu": "1", "memory": "1Gi"}),
+		testPodMetrics("team-b", "db-1", map[string]interface{}{"cpu": "250m"}),
+	} {
+		if _, err := client.Resource(podMetricsGVR).Namespace(m.GetNamespace()).Create(commandContext(), m, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	usage, err := listNamespaceUsage(cluster.ClusterInfo{Name: "cluster1", DynamicClient: client}, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cpu, memory := usage["team-a"][corev1.ResourceCPU], usage["team-a"][corev1.ResourceMemory]
+	if formatCPU(cpu) != "1.2" || formatMemory(memory) != "1.1Gi" {
+		t.Errorf("team-a usage = %s cpu, %s memory, want 1.2 and 1.1Gi", formatCPU(cpu), formatMemory(memory))
+	}
+	if cpu := usage["team-b"][corev1.ResourceCPU]; formatCPU(cpu) != "0.25" {
+		t.Errorf("team-b cpu usage = %s, want 0.25", formatCPU(cpu))
+	}
+
+	if _, err := listNamespaceUsage(cluster.ClusterInfo{Name: "cluster2"}, "", ""); err == nil {
+		t.Error("listNamespaceUsage() of a cluster without metrics returned no error")
+	}
+}
+
+func TestSummarizeNamespaceUsage(t *testing.T) {
+	listed := []clusterPodUsage{
+		{
+			clusterPods: clusterPods{cluster: "cluster1", pods: []corev1.Pod{
+				testSizedPod("team-a", "web-1", corev1.PodRunning, "500m", "256Mi", "1"),
+				testSizedPod("team-a", "web-2", corev1.PodPending, "250m", "256Mi", ""),
+				testSizedPod("team-a", "job-1", corev1.PodSucceeded, "4", "8Gi", ""),
+				testSizedPod("team-b", "db-1", corev1.PodRunning, "1", "2Gi", "2"),
+			}},
+			usage: map[string]corev1.ResourceList{
+				"team-a": {corev1.ResourceCPU: resource.MustParse("300m"), corev1.ResourceMemory: resource.MustParse("200Mi")},
+			},
+		},
+		{
+			clusterPods: clusterPods{cluster: "cluster2", pods: []corev1.Pod{
+				testSizedPod("team-a", "web-1", corev1.PodRunning, "500m", "512Mi", "1"),
+			}},
+			usage: map[string]corev1.ResourceList{
+				"team-a": {corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("312Mi")},
+			},
+		},
+		{
+			clusterPods: clusterPods{cluster: "cluster3", pods: []corev1.Pod{
+				testSizedPod("team-b", "db-1", corev1.PodRunning, "1", "2Gi", "2"),
+			}},
+		},
+	}
+
+	report := summarizeNamespaceUsage(listed)
+
+	type row struct {
+		cluster, namespace                     string
+		pods                                   int
+		cpuReq, cpuLim, cpuUse, memReq, memUse string
+	}
+	got := func(rows []*namespaceUsage) []row {
+		var out []row
+		for _, u := range rows {
+			cluster := u.Cluster
+			if cluster == "" {
+				cluster = "fleet"
+			}
+			cpuUse, memUse := u.CPUUsage, u.MemoryUsage
+			if !u.metered {
+				cpuUse, memUse = unknownUsage, unknownUsage
+			}
+			out = append(out, row{cluster, u.Namespace, u.Pods, u.CPURequests, u.CPULimits, cpuUse, u.MemoryRequests, memUse})
+		}
+		return out
+	}
+
+	wantClusters := []row{
+		{"cluster1", "team-a", 2, "0.75", "1", "0.3", "512Mi", "200Mi"},
+		{"cluster2", "team-a", 1, "0.5", "1", "0.1", "512Mi", "312Mi"},
+		{"cluster1", "team-b", 1, "1", "2", "0", "2Gi", "0"},
+		{"cluster3", "team-b", 1, "1", "2", unknownUsage, "2Gi", unknownUsage},
+	}
+	if g := got(report.Clusters); !reflect.DeepEqual(g, wantClusters) {
+		t.Errorf("cluster rows = %+v, want %+v", g, wantClusters)
+	}
+
+	wantFleet := []row{
+		{"fleet", "team-a", 3, "1.25", "2", "0.4", "1Gi", "512Mi"},
+		{"fleet", "team-b", 2, "2", "4", unknownUsage, "4Gi", unknownUsage},
+	}
+	if g := got(report.Fleet); !reflect.DeepEqual(g, wantFleet) {
+		t.Fatalf("fleet rows = %+v, want %+v", g, wantFleet)
+	}
+	if clusters := report.Fleet[1].Clusters; !reflect.DeepEqual(clusters, []string{"cluster1", "cluster3"}) {
+		t.Errorf("team-b clusters = %v, want [cluster1 cluster3]", clusters)
+	}
+}
@@ -0,0 +1,362 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/duration"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+// treeChildResources are the resources listed for the descendants of the
+// object tree shows: what Deployments, StatefulSets, DaemonSets and
+// CronJobs create
+var treeChildResources = []schema.GroupVersionResource{
+	{Group: "apps", Version: "v1", Resource: "replicasets"},
+	{Group: "apps", Version: "v1", Resource: "controllerrevisions"},
+	{Group: "batch", Version: "v1", Resource: "jobs"},
+	{Version: "v1", Resource: "pods"},
+}
+
+// deploymentRevisionAnnotation numbers the revisions of a Deployment and of
+// its ReplicaSets
+const deploymentRevisionAnnotation = "deployment.kubernetes.io/revision"
+
+// treeNode is one object of an ownership tree
+type treeNode struct {
+	Kind     string      `json:"kind"`
+	Name     string      `json:"name"`
+	Ready    string      `json:"ready,omitempty"`
+	Status   string      `json:"status,omitempty"`
+	Created  time.Time   `json:"created"`
+	Children []*treeNode `json:"children,omitempty"`
+}
+
+// clusterTree is the ownership tree of the object in one cluster
+type clusterTree struct {
+	Cluster string    `json:"cluster"`
+	Root    *treeNode `json:"root,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+func newTreeCommand() *cobra.Command {
+	var outputFormat string
+	var targets clusterTargets
+	var reach reachability
+
+	cmd := &cobra.Command{
+		Use:   "tree (TYPE/NAME | TYPE NAME)",
+		Short: "Show the objects a workload owns in every managed cluster as a tree",
+		Long: `Show, per managed cluster, the ownership tree of a workload: a Deployment with
+its ReplicaSets and their Pods, a StatefulSet or DaemonSet with its Pods and
+ControllerRevisions, a CronJob with its Jobs and their Pods.
+
+Each object shows its ready count and a status. ReplicaSets of an earlier
+Deployment revision are marked Old, and controllers that should have pods
+but have none are marked NoPods, so stuck rollouts stand out.`,
+		Example: `# Deployment -> ReplicaSets -> Pods in every cluster
+kubectl multi tree deployment/nginx -n prod
+
+# Only the edge clusters, as JSON
+kubectl multi tree statefulset web --clusters @edge -o json`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resourceType, name, err := parseTypeName(args)
+			if err != nil {
+				return err
+			}
+			if outputFormat != "" && outputFormat != "json" {
+				return fmt.Errorf("unsupported output format %q, must be json", outputFormat)
+			}
+			kubeconfig, remoteCtx, _, namespace, _ := GetGlobalFlags()
+			return handleTreeCommand(resourceType, name, outputFormat, targets, reach, kubeconfig, remoteCtx, namespace)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "output format (json)")
+	targets.addFlags(cmd, "show")
+	reach.addFlags(cmd)
+
+	return cmd
+}
+
+func handleTreeCommand(resourceType, name, outputFormat string, targets clusterTargets, reach reachability, kubeconfig, remoteCtx, namespace string) error {
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	clusters, err = targets.selectFrom(clusters, kubeconfig)
+	if err != nil {
+		return err
+	}
+	clusters, err = reach.filter(workloadClusters(clusters, remoteCtx))
+	if err != nil {
+		return err
+	}
+	defer printClusterIssuesToStderr()
+
+	ns := cluster.GetTargetNamespace(namespace)
+	var trees []clusterTree
+	found := 0
+	for _, c := range clusters {
+		tree := clusterTree{Cluster: c.Name}
+		root, err := ownershipTree(c, resourceType, ns, name)
+		if err != nil {
+			tree.Error = err.Error()
+		} else {
+			tree.Root = root
+			found++
+		}
+		trees = append(trees, tree)
+	}
+
+	if outputFormat == "json" {
+		if err := printJSONArray(trees); err != nil {
+			return err
+		}
+	} else {
+		printClusterTrees(util.GetOutputStream(), trees, time.Now())
+	}
+	if found == 0 && len(trees) > 0 {
+		return fmt.Errorf("%s %s/%s not found in any cluster", resourceType, ns, name)
+	}
+	return nil
+}
+
+// ownershipTree reads the object and every object it owns, directly or
+// through its children, in one cluster
+func ownershipTree(c cluster.ClusterInfo, resourceType, namespace, name string) (*treeNode, error) {
+	if c.DynamicClient == nil || c.DiscoveryClient == nil {
+		return nil, fmt.Errorf("no clients available for cluster %s", c.Name)
+	}
+	gvr, namespaced, err := util.DiscoverGVR(c.DiscoveryClient, resourceType)
+	if err != nil {
+		return nil, err
+	}
+	client := c.DynamicClient.Resource(gvr)
+	var root *unstructured.Unstructured
+	if namespaced {
+		root, err = client.Namespace(namespace).Get(commandContext(), name, metav1.GetOptions{})
+	} else {
+		root, err = client.Get(commandContext(), name, metav1.GetOptions{})
+	}
+	if apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("%s/%s not found", gvr.Resource, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	owned := map[types.UID][]*unstructured.Unstructured{}
+	for _, child := range treeChildResources {
+		list, err := c.DynamicClient.Resource(child).Namespace(namespace).List(commandContext(), metav1.ListOptions{})
+		if err != nil {
+			// Some children unreadable, e.g. forbidden: show the rest
+			noteClusterIssue(c.Name, fmt.Sprintf("cannot list %s: %v", child.Resource, err))
+			continue
+		}
+		for i := range list.Items {
+			item := &list.Items[i]
+			for _, ref := range item.GetOwnerReferences() {
+				owned[ref.UID] = append(owned[ref.UID], item)
+			}
+		}
+	}
+	return buildTreeNode(root, nil, owned, map[types.UID]bool{}), nil
+}
+
+// buildTreeNode describes obj and, recursively, the objects it owns
+func buildTreeNode(obj, parent *unstructured.Unstructured, owned map[types.UID][]*unstructured.Unstructured, seen map[types.UID]bool) *treeNode {
+	seen[obj.GetUID()] = true
+	node := &treeNode{Kind: obj.GetKind(), Name: obj.GetName(), Created: obj.GetCreationTimestamp().Time}
+	children := owned[obj.GetUID()]
+	sort.Slice(children, func(i, j int) bool {
+		if children[i].GetKind() != children[j].GetKind() {
+			return children[i].GetKind() < children[j].GetKind()
+		}
+		return children[i].GetName() < children[j].GetName()
+	})
+	pods := 0
+	for _, child := range children {
+		if seen[child.GetUID()] {
+			continue
+		}
+		if child.GetKind() == "Pod" {
+			pods++
+		}
+		node.Children = append(node.Children, buildTreeNode(child, obj, owned, seen))
+	}
+	node.Ready, node.Status = treeNodeState(obj, parent, pods)
+	return node
+}
+
+// treeNodeState returns the ready count and status of an object; pods is
+// the number of pods it owns
+func treeNodeState(obj, parent *unstructured.Unstructured, pods int) (string, string) {
+	nested := func(fields ...string) int64 {
+		v, _, _ := unstructured.NestedInt64(obj.Object, fields...)
+		return v
+	}
+	if obj.GetDeletionTimestamp() != nil {
+		return "", "Terminating"
+	}
+	switch obj.GetKind() {
+	case "Deployment":
+		desired, ready, updated := nested("spec", "replicas"), nested("status", "readyReplicas"), nested("status", "updatedReplicas")
+		status := "Available"
+		if reason := falseConditionReason(obj, "Progressing"); reason != "" {
+			status = reason
+		} else if ready < desired || updated < desired {
+			status = "Progressing"
+		}
+		return fmt.Sprintf("%d/%d", ready, desired), status
+	case "ReplicaSet":
+		desired, ready := nested("spec", "replicas"), nested("status", "readyReplicas")
+		var status []string
+		if parent != nil && parent.GetKind() == "Deployment" && obj.GetAnnotations()[deploymentRevisionAnnotation] != parent.GetAnnotations()[deploymentRevisionAnnotation] {
+			status = append(status, "Old")
+		}
+		if desired > 0 && pods == 0 {
+			status = append(status, "NoPods")
+		}
+		return fmt.Sprintf("%d/%d", ready, desired), strings.Join(status, ",")
+	case "StatefulSet":
+		desired, ready := nested("spec", "replicas"), nested("status", "readyReplicas")
+		status := ""
+		if desired > 0 && pods == 0 {
+			status = "NoPods"
+		}
+		return fmt.Sprintf("%d/%d", ready, desired), status
+	case "DaemonSet":
+		desired, ready := nested("status", "desiredNumberScheduled"), nested("status", "numberReady")
+		status := ""
+		if desired > 0 && pods == 0 {
+			status = "NoPods"
+		}
+		return fmt.Sprintf("%d/%d", ready, desired), status
+	case "Job":
+		completions := nested("spec", "completions")
+		if completions == 0 {
+			completions = 1
+		}
+		status := "Running"
+		switch {
+		case trueCondition(obj, "Complete"):
+			status = "Complete"
+		case trueCondition(obj, "Failed"):
+			status = "Failed"
+		}
+		return fmt.Sprintf("%d/%d", nested("status", "succeeded"), completions), status
+	case "Pod":
+		return podTreeState(obj)
+	}
+	return "", ""
+}
+
+// podTreeState returns the ready containers of a pod and its phase, or the
+// reason a container is waiting, such as CrashLoopBackOff
+func podTreeState(pod *unstructured.Unstructured) (string, string) {
+	statuses, _, _ := unstructured.NestedSlice(pod.Object, "status", "containerStatuses")
+	containers, _, _ := unstructured.NestedSlice(pod.Object, "spec", "containers")
+	status, _, _ := unstructured.NestedString(pod.Object, "status", "phase")
+	ready := 0
+	for _, s := range statuses {
+		cs, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if r, _, _ := unstructured.NestedBool(cs, "ready"); r {
+			ready++
+		}
+		if reason, _, _ := unstructured.NestedString(cs, "state", "waiting", "reason"); reason != "" {
+			status = reason
+		}
+	}
+	return fmt.Sprintf("%d/%d", ready, len(containers)), status
+}
+
+// trueCondition reports whether the object has the condition with status True
+func trueCondition(obj *unstructured.Unstructured, conditionType string) bool {
+	return conditionStatus(obj, conditionType) == "True"
+}
+
+// falseConditionReason returns the reason of the condition when its status
+// is False
+func falseConditionReason(obj *unstructured.Unstructured, conditionType string) string {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if ok && cond["type"] == conditionType && cond["status"] == "False" {
+			reason, _ := cond["reason"].(string)
+			return reason
+		}
+	}
+	return ""
+}
+
+func conditionStatus(obj *unstructured.Unstructured, conditionType string) string {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if ok && cond["type"] == conditionType {
+			status, _ := cond["status"].(string)
+			return status
+		}
+	}
+	return ""
+}
+
+// printClusterTrees prints one indented tree per cluster
+func printClusterTrees(out io.Writer, trees []clusterTree, now time.Time) {
+	if len(trees) == 0 {
+		fmt.Fprintln(out, "No clusters checked.")
+		return
+	}
+	for _, tree := range trees {
+		fmt.Fprintf(out, "=== Cluster: %s ===\n", tree.Cluster)
+		if tree.Root == nil {
+			fmt.Fprintf(out, "Error: %s\n\n", tree.Error)
+			continue
+		}
+		tw := util.NewTableWriter(out, "")
+		fmt.Fprintln(tw, "NAME\tREADY\tSTATUS\tAGE")
+		printTreeNode(tw, tree.Root, "", "", now)
+		tw.Flush()
+		fmt.Fprintln(out)
+	}
+}
+
+// printTreeNode prints a node after its branch prefix, then its children
+// indented under it
+func printTreeNode(w io.Writer, node *treeNode, branch, indent string, now time.Time) {
+	age := "<unknown>"
+	if !node.Created.IsZero() {
+		age = duration.HumanDuration(now.Sub(node.Created))
+	}
+	ready, status := node.Ready, node.Status
+	if ready == "" {
+		ready = "-"
+	}
+	if status == "" {
+		status = "-"
+	}
+	fmt.Fprintf(w, "%s%s/%s\t%s\t%s\t%s\n", branch, node.Kind, node.Name, ready, status, age)
+	for i, child := range node.Children {
+		if i == len(node.Children)-1 {
+			printTreeNode(w, child, indent+"└─", indent+"  ", now)
+		} else {
+			printTreeNode(w, child, indent+"├─", indent+"│ ", now)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"kubectl-multi/pkg/cluster"
+)
+
+// testOwned returns an object with a UID, owned by owner when given
+func testOwned(apiVersion, kind, name string, owner *unstructured.Unstructured, created time.Time) *unstructured.Unstructured {
+	obj := testObject(apiVersion, kind, "prod", name)
+	obj.SetUID(types.UID(kind + "-" + name))
+	obj.SetCreationTimestamp(metav1.NewTime(created))
+	if owner != nil {
+		controller := true
+		obj.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: owner.GetAPIVersion(), Kind: owner.GetKind(), Name: owner.GetName(), UID: owner.GetUID(), Controller: &controller}})
+	}
+	return obj
+}
+
+func testTreeCluster(objects ...runtime.Object) cluster.ClusterInfo {
+	listKinds := map[schema.GroupVersionResource]string{
+		{Group: "apps", Version: "v1", Resource: "deployments"}:         "DeploymentList",
+		{Group: "apps", Version: "v1", Resource: "replicasets"}:         "ReplicaSetList",
+		{Group: "apps", Version: "v1", Resource: "controllerrevisions"}: "ControllerRevisionList",
+		{Group: "batch", Version: "v1", Resource: "jobs"}:               "JobList",
+		{Version: "v1", Resource: "pods"}:                               "PodList",
+	}
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objects...)
+	disc := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: testResources}}
+	return cluster.ClusterInfo{Name: "cluster1", Context: "cluster1", DynamicClient: dynamicClient, DiscoveryClient: disc}
+}
+
+func TestOwnershipTree(t *testing.T) {
+	created := time.Date(2026, 10, 10, 12, 0, 0, 0, time.UTC)
+	deploy := testOwned("apps/v1", "Deployment", "nginx", nil, created)
+	deploy.SetAnnotations(map[string]string{deploymentRevisionAnnotation: "2"})
+	unstructured.SetNestedField(deploy.Object, int64(2), "spec", "replicas")
+	unstructured.SetNestedField(deploy.Object, int64(1), "status", "readyReplicas")
+	unstructured.SetNestedField(deploy.Object, int64(2), "status", "updatedReplicas")
+
+	current := testOwned("apps/v1", "ReplicaSet", "nginx-new", deploy, created)
+	current.SetAnnotations(map[string]string{deploymentRevisionAnnotation: "2"})
+	unstructured.SetNestedField(current.Object, int64(2), "spec", "replicas")
+	unstructured.SetNestedField(current.Object, int64(1), "status", "readyReplicas")
+	stuck := testOwned("apps/v1", "ReplicaSet", "nginx-old", deploy, created)
+	stuck.SetAnnotations(map[string]string{deploymentRevisionAnnotation: "1"})
+	unstructured.SetNestedField(stuck.Object, int64(1), "spec", "replicas")
+
+	running := testOwned("v1", "Pod", "nginx-new-a", current, created)
+	unstructured.SetNestedField(running.Object, "Running", "status", "phase")
+	unstructured.SetNestedSlice(running.Object, []interface{}{map[string]interface{}{"name": "nginx"}}, "spec", "containers")
+	unstructured.SetNestedSlice(running.Object, []interface{}{map[string]interface{}{"ready": true}}, "status", "containerStatuses")
+	crashing := testOwned("v1", "Pod", "nginx-new-b", current, created)
+	unstructured.SetNestedField(crashing.Object, "Running", "status", "phase")
+	unstructured.SetNestedSlice(crashing.Object, []interface{}{map[string]interface{}{"name": "nginx"}}, "spec", "containers")
+	unstructured.SetNestedSlice(crashing.Object, []interface{}{map[string]interface{}{"ready": false, "state": map[string]interface{}{"waiting": map[string]interface{}{"reason": "CrashLoopBackOff"}}}}, "status", "containerStatuses")
+	unrelated := testOwned("v1", "Pod", "other", nil, created)
+
+	c := testTreeCluster(deploy, current, stuck, running, crashing, unrelated)
+	root, err := ownershipTree(c, "deployment", "prod", "nginx")
+	if err != nil {
+		t.Fatalf("ownershipTree() error = %v", err)
+	}
+	var out bytes.Buffer
+	printClusterTrees(&out, []clusterTree{{Cluster: "cluster1", Root: root}, {Cluster: "cluster2", Error: "deployments/nginx not found"}}, created.Add(48*time.Hour))
+	want := `=== Cluster: cluster1 ===
+NAME                    READY  STATUS            AGE
+Deployment/nginx        1/2    Progressing       2d
+├─ReplicaSet/nginx-new  1/2    -                 2d
+│ ├─Pod/nginx-new-a     1/1    Running           2d
+│ └─Pod/nginx-new-b     0/1    CrashLoopBackOff  2d
+└─ReplicaSet/nginx-old  0/1    Old,NoPods        2d
+
+=== Cluster: cluster2 ===
+Error: deployments/nginx not found
+
+`
+	if out.String() != want {
+		t.Errorf("printClusterTrees() =\n%s\nwant\n%s", out.String(), want)
+	}
+
+	if _, err := ownershipTree(c, "deployment", "prod", "missing"); err == nil || err.Error() != "deployments/missing not found" {
+		t.Errorf("ownershipTree() of a missing deployment error = %v", err)
+	}
+}
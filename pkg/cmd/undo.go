@@ -0,0 +1,414 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/kubectl/pkg/drain"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+func newUndoCommand() *cobra.Command {
+	var dryRun bool
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "undo OPERATION-ID",
+		Short: "Reverse a recorded fleet operation",
+		Long: `Reverse a mutating operation recorded in the audit log, cluster by cluster.
+
+Objects created by apply are deleted and objects changed by apply are restored
+to the version captured before the apply. Namespaces created by
+'namespace create' are deleted unless something was created in them since
+(see --force), ManagedCluster labels changed by 'clusters label' are restored,
+pods started by run are deleted and installs are rolled back (or uninstalled)
+with helm. Operation IDs are shown by 'kubectl multi history'.`,
+		Example: `# Find the operation to reverse
+kubectl multi history
+
+# Preview what undo would do
+kubectl multi undo lq3x9k2a1b --dry-run
+
+# Reverse it
+kubectl multi undo lq3x9k2a1b`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kubeconfig, remoteCtx, _, _, _ := GetGlobalFlags()
+			var rec *audit.Recorder
+			if !dryRun {
+				rec = startAudit("undo")
+			}
+			err := handleUndoCommand(args[0], dryRun, force, rec, kubeconfig, remoteCtx)
+			finishAudit(rec, err)
+			return err
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only print the steps that would be taken")
+	cmd.Flags().BoolVar(&force, "force", false, "undo the operation even if it was already undone, and delete namespaces that are not empty")
+
+	return cmd
+}
+
+func handleUndoCommand(id string, dryRun, force bool, rec *audit.Recorder, kubeconfig, remoteCtx string) error {
+	entry, err := audit.Find(id)
+	if err != nil {
+		return err
+	}
+
+	if !force {
+		entries, err := audit.Read()
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if e.Command == "undo" && e.Error == "" && containsString(e.Args, id) {
+				return fmt.Errorf("operation %s was already undone by %s, use --force to undo it again", id, e.ID)
+			}
+		}
+	}
+
+	// Clusters whose changes cannot be reversed
+	covered := make(map[string]bool)
+	for _, step := range entry.Undo {
+		covered[step.Cluster] = true
+	}
+	var unsupported []string
+	for _, r := range entry.Results {
+		if r.Status == "ok" && !covered[r.Cluster] {
+			unsupported = append(unsupported, r.Cluster)
+		}
+	}
+
+	if len(entry.Undo) == 0 {
+		return fmt.Errorf("operation %s (%s) recorded no undo information; it cannot be undone", id, entry.Command)
+	}
+
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	byName := make(map[string]cluster.ClusterInfo, len(clusters))
+	for _, c := range clusters {
+		byName[c.Name] = c
+	}
+	var undone []string
+	for _, step := range entry.Undo {
+		if !containsString(undone, step.Cluster) {
+			undone = append(undone, step.Cluster)
+		}
+	}
+	if err := guardManagedClusters(rec, blastRadius{Objects: len(entry.Undo)}, undone, remoteCtx); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(util.GetOutputStream(), 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "CLUSTER\tACTION\tOBJECT\tRESULT\n")
+
+	failed := 0
+	// Reverse in the opposite order the changes were made
+	for i := len(entry.Undo) - 1; i >= 0; i-- {
+		step := entry.Undo[i]
+		result := "done"
+		if dryRun {
+			result = "would run (dry run)"
+		} else {
+			err := runUndoStep(step, byName, force, kubeconfig, remoteCtx)
+			rec.Record(step.Cluster, err)
+			if err != nil {
+				result = fmt.Sprintf("error: %v", err)
+				failed++
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", step.Cluster, step.Action, undoStepObject(step), result)
+	}
+	tw.Flush()
+
+	for _, c := range unsupported {
+		fmt.Printf("Cannot undo changes on cluster %s: %s recorded no undo information for it\n", c, entry.Command)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to undo %d step(s) of operation %s", failed, id)
+	}
+	return nil
+}
+
+// undoStepObject names the object an undo step acts on
+func undoStepObject(step audit.UndoStep) string {
+	switch step.Action {
+	case audit.UndoHelmRollback:
+		return fmt.Sprintf("release %s (revision %d)", step.Name, step.Revision)
+	case audit.UndoHelmUninstall:
+		return "release " + step.Name
+	case audit.UndoRestoreLabels, audit.UndoRestoreTaints:
+		return "managedcluster/" + step.Name
+	}
+	ref := step.Resource
+	if step.Group != "" {
+		ref += "." + step.Group
+	}
+	ref += "/" + step.Name
+	if step.Namespace != "" {
+		ref = step.Namespace + "/" + ref
+	}
+	return ref
+}
+
+func runUndoStep(step audit.UndoStep, clusters map[string]cluster.ClusterInfo, force bool, kubeconfig, remoteCtx string) error {
+	switch step.Action {
+	case audit.UndoHelmRollback, audit.UndoHelmUninstall:
+		return runHelmUndo(step)
+	case audit.UndoRestoreLabels:
+		its, err := cluster.ClientForContext(kubeconfig, remoteCtx)
+		if err != nil {
+			return err
+		}
+		patch, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{"labels": step.Labels},
+		})
+		if err != nil {
+			return err
+		}
+		_, err = its.DynamicClient.Resource(cluster.ManagedClusterGVR).Patch(commandContext(), step.Name, types.MergePatchType, patch, metav1.PatchOptions{FieldManager: util.FieldManager})
+		return err
+	case audit.UndoRestoreTaints:
+		its, err := cluster.ClientForContext(kubeconfig, remoteCtx)
+		if err != nil {
+			return err
+		}
+		patch, err := json.Marshal(map[string]interface{}{
+			"spec": map[string]interface{}{"taints": step.Taints},
+		})
+		if err != nil {
+			return err
+		}
+		_, err = its.DynamicClient.Resource(cluster.ManagedClusterGVR).Patch(commandContext(), step.Name, types.MergePatchType, patch, metav1.PatchOptions{FieldManager: util.FieldManager})
+		return err
+	}
+
+	clusterInfo, ok := clusters[step.Cluster]
+	if !ok || clusterInfo.DynamicClient == nil {
+		return fmt.Errorf("cluster %s is not reachable", step.Cluster)
+	}
+	if step.Action == audit.UndoCordon || step.Action == audit.UndoUncordon {
+		if clusterInfo.Client == nil {
+			return fmt.Errorf("cluster %s is not reachable", step.Cluster)
+		}
+		node, err := clusterInfo.Client.CoreV1().Nodes().Get(commandContext(), step.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		helper := &drain.Helper{Ctx: commandContext(), Client: clusterInfo.Client}
+		return drain.RunCordonOrUncordon(helper, node, step.Action == audit.UndoCordon)
+	}
+
+	gvr := schema.GroupVersionResource{Group: step.Group, Version: step.Version, Resource: step.Resource}
+	var client dynamic.ResourceInterface = clusterInfo.DynamicClient.Resource(gvr)
+	if step.Namespace != "" {
+		client = clusterInfo.DynamicClient.Resource(gvr).Namespace(step.Namespace)
+	}
+
+	switch step.Action {
+	case audit.UndoDelete:
+		if step.Group == "" && step.Resource == "namespaces" && !force {
+			// Deleting a namespace takes everything created in it since
+			residual, err := listResidualResources(clusterInfo, step.Name)
+			if err != nil {
+				return fmt.Errorf("could not check that namespace %s is empty: %v; use --force to delete it anyway", step.Name, err)
+			}
+			if len(residual) > 0 {
+				return fmt.Errorf("namespace %s still contains %s; use --force to delete it anyway", step.Name, strings.Join(residual, ", "))
+			}
+		}
+		policy := metav1.DeletePropagationBackground
+		err := client.Delete(commandContext(), step.Name, metav1.DeleteOptions{PropagationPolicy: &policy})
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	case audit.UndoRestore:
+		return restoreObject(commandContext(), client, &unstructured.Unstructured{Object: step.Object})
+	}
+	return fmt.Errorf("unknown undo action %q", step.Action)
+}
+
+// restoreObject replaces the live object with the captured version, or
+// recreates it if it has been deleted since
+func restoreObject(ctx context.Context, client dynamic.ResourceInterface, obj *unstructured.Unstructured) error {
+	util.MarkManaged(obj)
+	live, err := client.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = client.Create(ctx, obj, metav1.CreateOptions{FieldManager: util.FieldManager})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	obj.SetResourceVersion(live.GetResourceVersion())
+	_, err = client.Update(ctx, obj, metav1.UpdateOptions{FieldManager: util.FieldManager})
+	return err
+}
+
+func runHelmUndo(step audit.UndoStep) error {
+	args := helmUndoArgs(step)
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(commandContext(), "helm", args...)
+	cmd.Stderr = &stderr
+	if err := runObserved(cmd, step.Cluster); err != nil {
+		return fmt.Errorf("helm %s failed: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// helmUndoArgs builds the helm command line reversing a helm step, pinned to
+// the cluster the release was installed in
+func helmUndoArgs(step audit.UndoStep) []string {
+	var args []string
+	if step.Action == audit.UndoHelmRollback {
+		args = []string{"rollback", step.Name, strconv.Itoa(step.Revision)}
+	} else {
+		args = []string{"uninstall", step.Name}
+	}
+	if step.Namespace != "" {
+		args = append(args, "--namespace", step.Namespace)
+	}
+	return append(args, helmClusterArgs(step.Context, step.Kubeconfig)...)
+}
+
+// helmClusterArgs selects the kubeconfig and context helm talks to
+func helmClusterArgs(context, kubeconfig string) []string {
+	var args []string
+	if kubeconfig != "" {
+		args = append(args, "--kubeconfig", kubeconfig)
+	}
+	if context != "" {
+		args = append(args, "--kube-context", context)
+	}
+	return args
+}
+
+// captureApplyUndo records, for every object in the manifests, how to reverse
+// applying it to the cluster: delete it if it does not exist yet, otherwise
+// restore the current version. Nothing is recorded unless every object could
+// be captured, so undo never reverses only part of the apply.
+func captureApplyUndo(rec *audit.Recorder, clusterInfo cluster.ClusterInfo, objs []*unstructured.Unstructured, namespace string) error {
+	if rec == nil {
+		return nil
+	}
+	if clusterInfo.DiscoveryClient == nil || clusterInfo.DynamicClient == nil {
+		return fmt.Errorf("no clients available for cluster %s", clusterInfo.Name)
+	}
+	mapper := clusterInfo.Mapper()
+
+	var steps []audit.UndoStep
+	for _, obj := range objs {
+		gvk := obj.GroupVersionKind()
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return fmt.Errorf("failed to map %s: %v", gvk, err)
+		}
+
+		step := audit.UndoStep{
+			Cluster:  clusterInfo.Name,
+			Group:    mapping.Resource.Group,
+			Version:  mapping.Resource.Version,
+			Resource: mapping.Resource.Resource,
+			Name:     obj.GetName(),
+		}
+
+		var live *unstructured.Unstructured
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			step.Namespace = obj.GetNamespace()
+			if step.Namespace == "" {
+				step.Namespace = cluster.GetTargetNamespace(namespace)
+			}
+			live, err = clusterInfo.DynamicClient.Resource(mapping.Resource).Namespace(step.Namespace).Get(commandContext(), step.Name, metav1.GetOptions{})
+		} else {
+			live, err = clusterInfo.DynamicClient.Resource(mapping.Resource).Get(commandContext(), step.Name, metav1.GetOptions{})
+		}
+
+		switch {
+		case apierrors.IsNotFound(err):
+			step.Action = audit.UndoDelete
+		case err != nil:
+			return fmt.Errorf("failed to read %s %s: %v", mapping.Resource.Resource, step.Name, err)
+		default:
+			step.Action = audit.UndoRestore
+			step.Object = snapshotObject(live).Object
+		}
+		steps = append(steps, step)
+	}
+
+	for _, step := range steps {
+		rec.AddUndo(step)
+	}
+	return nil
+}
+
+// snapshotObject strips server-populated fields so the object can be re-applied
+func snapshotObject(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	snapshot := obj.DeepCopy()
+	unstructured.RemoveNestedField(snapshot.Object, "status")
+	unstructured.RemoveNestedField(snapshot.Object, "metadata", "uid")
+	unstructured.RemoveNestedField(snapshot.Object, "metadata", "resourceVersion")
+	unstructured.RemoveNestedField(snapshot.Object, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(snapshot.Object, "metadata", "generation")
+	unstructured.RemoveNestedField(snapshot.Object, "metadata", "managedFields")
+	return snapshot
+}
+
+// currentContextName returns the current context of the kubeconfig
+func currentContextName(kubeconfig string) string {
+	loading := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfig != "" {
+		loading.ExplicitPath = kubeconfig
+	}
+	cfg := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loading, &clientcmd.ConfigOverrides{})
+	rawCfg, err := cfg.RawConfig()
+	if err != nil {
+		return ""
+	}
+	return rawCfg.CurrentContext
+}
+
+// helmReleaseRevision returns the current revision of a helm release, or 0
+// when the release is not installed
+func helmReleaseRevision(release, namespace, context, kubeconfig string) int {
+	args := []string{"status", release, "-o", "json"}
+	if namespace != "" {
+		args = append(args, "--namespace", namespace)
+	}
+	args = append(args, helmClusterArgs(context, kubeconfig)...)
+	var out bytes.Buffer
+	cmd := exec.CommandContext(commandContext(), "helm", args...)
+	cmd.Stdout = &out
+	if err := runObserved(cmd, context); err != nil {
+		return 0
+	}
+	var status struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &status); err != nil {
+		return 0
+	}
+	return status.Version
+}
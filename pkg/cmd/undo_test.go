@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/cluster"
+)
+
+// testObject builds an unstructured object for the fake clients
+func testObject(apiVersion, kind, namespace, name string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+// testResources are the API resources the fake test clusters serve
+var testResources = []*metav1.APIResourceList{
+	{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{
+			{Name: "namespaces", Kind: "Namespace", Verbs: []string{"get", "list", "delete"}},
+			{Name: "configmaps", Namespaced: true, Kind: "ConfigMap", Verbs: []string{"get", "list", "delete"}},
+		},
+	},
+	{
+		GroupVersion: "apps/v1",
+		APIResources: []metav1.APIResource{
+			{Name: "deployments", Namespaced: true, Kind: "Deployment", Verbs: []string{"get", "list", "delete"}},
+		},
+	},
+}
+
+// testClusterInfo returns a cluster backed by fake dynamic and discovery clients
+func testClusterInfo(objects ...runtime.Object) (cluster.ClusterInfo, *fakedynamic.FakeDynamicClient) {
+	listKinds := map[schema.GroupVersionResource]string{
+		{Version: "v1", Resource: "namespaces"}:                 "NamespaceList",
+		{Version: "v1", Resource: "configmaps"}:                 "ConfigMapList",
+		{Group: "apps", Version: "v1", Resource: "deployments"}: "DeploymentList",
+	}
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objects...)
+	fake := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: testResources}}
+	disc := &namespacedDiscovery{FakeDiscovery: fake, resources: []*metav1.APIResourceList{{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{testResources[0].APIResources[1]},
+	}, testResources[1]}}
+	return cluster.ClusterInfo{Name: "cluster1", DynamicClient: dynamicClient, DiscoveryClient: disc}, dynamicClient
+}
+
+func TestHelmUndoArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		step audit.UndoStep
+		want []string
+	}{
+		{
+			name: "rollback",
+			step: audit.UndoStep{Action: audit.UndoHelmRollback, Name: "ks-core", Revision: 3, Namespace: "kubestellar", Context: "kind-host", Kubeconfig: "/tmp/kc"},
+			want: []string{"rollback", "ks-core", "3", "--namespace", "kubestellar", "--kubeconfig", "/tmp/kc", "--kube-context", "kind-host"},
+		},
+		{
+			name: "uninstall",
+			step: audit.UndoStep{Action: audit.UndoHelmUninstall, Name: "ks-core", Context: "kind-host"},
+			want: []string{"uninstall", "ks-core", "--kube-context", "kind-host"},
+		},
+		{
+			name: "recorded before contexts were kept",
+			step: audit.UndoStep{Action: audit.UndoHelmUninstall, Name: "ks-core"},
+			want: []string{"uninstall", "ks-core"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := helmUndoArgs(tt.step); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("helmUndoArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUndoStepObject(t *testing.T) {
+	tests := []struct {
+		step audit.UndoStep
+		want string
+	}{
+		{audit.UndoStep{Action: audit.UndoHelmRollback, Name: "ks-core", Revision: 2}, "release ks-core (revision 2)"},
+		{audit.UndoStep{Action: audit.UndoHelmUninstall, Name: "ks-core"}, "release ks-core"},
+		{audit.UndoStep{Action: audit.UndoRestoreLabels, Name: "cluster1"}, "managedcluster/cluster1"},
+		{audit.UndoStep{Action: audit.UndoRestoreTaints, Name: "cluster1"}, "managedcluster/cluster1"},
+		{audit.UndoStep{Action: audit.UndoDelete, Resource: "namespaces", Name: "team-a"}, "namespaces/team-a"},
+		{audit.UndoStep{Action: audit.UndoRestore, Group: "apps", Resource: "deployments", Namespace: "prod", Name: "nginx"}, "prod/deployments.apps/nginx"},
+	}
+	for _, tt := range tests {
+		if got := undoStepObject(tt.step); got != tt.want {
+			t.Errorf("undoStepObject(%+v) = %q, want %q", tt.step, got, tt.want)
+		}
+	}
+}
+
+func TestCaptureApplyUndo(t *testing.T) {
+	live := testObject("apps/v1", "Deployment", "prod", "nginx")
+	live.SetResourceVersion("42")
+	live.SetUID("abc")
+	unstructured.SetNestedField(live.Object, int64(1), "status", "replicas")
+
+	tests := []struct {
+		name    string
+		objs    []*unstructured.Unstructured
+		want    []audit.UndoStep
+		wantErr bool
+	}{
+		{
+			name: "new and existing objects",
+			objs: []*unstructured.Unstructured{
+				testObject("v1", "ConfigMap", "", "cfg"),
+				testObject("apps/v1", "Deployment", "prod", "nginx"),
+			},
+			want: []audit.UndoStep{
+				{Cluster: "cluster1", Action: audit.UndoDelete, Version: "v1", Resource: "configmaps", Namespace: "team-a", Name: "cfg"},
+				{Cluster: "cluster1", Action: audit.UndoRestore, Group: "apps", Version: "v1", Resource: "deployments", Namespace: "prod", Name: "nginx",
+					Object: map[string]interface{}{
+						"apiVersion": "apps/v1",
+						"kind":       "Deployment",
+						"metadata":   map[string]interface{}{"namespace": "prod", "name": "nginx"},
+					}},
+			},
+		},
+		{
+			name: "one unmappable object records nothing",
+			objs: []*unstructured.Unstructured{
+				testObject("v1", "ConfigMap", "", "cfg"),
+				testObject("example.com/v1", "Widget", "prod", "w"),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusterInfo, _ := testClusterInfo(live.DeepCopy())
+			rec := audit.Start("apply", nil)
+			err := captureApplyUndo(rec, clusterInfo, tt.objs, "team-a")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("captureApplyUndo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := rec.Finish(nil).Undo; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("captureApplyUndo() recorded %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunUndoStepNamespaceGuard(t *testing.T) {
+	step := audit.UndoStep{Cluster: "cluster1", Action: audit.UndoDelete, Version: "v1", Resource: "namespaces", Name: "team-a"}
+
+	tests := []struct {
+		name        string
+		objects     []runtime.Object
+		force       bool
+		wantErr     bool
+		wantDeleted bool
+	}{
+		{
+			name:        "empty namespace",
+			objects:     []runtime.Object{testObject("v1", "ConfigMap", "team-a", "kube-root-ca.crt")},
+			wantDeleted: true,
+		},
+		{
+			name:    "namespace in use",
+			objects: []runtime.Object{testObject("apps/v1", "Deployment", "team-a", "nginx")},
+			wantErr: true,
+		},
+		{
+			name:        "namespace in use with force",
+			objects:     []runtime.Object{testObject("apps/v1", "Deployment", "team-a", "nginx")},
+			force:       true,
+			wantDeleted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := append([]runtime.Object{testObject("v1", "Namespace", "", "team-a")}, tt.objects...)
+			clusterInfo, dynamicClient := testClusterInfo(objects...)
+			err := runUndoStep(step, map[string]cluster.ClusterInfo{"cluster1": clusterInfo}, tt.force, "", "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runUndoStep() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			deleted := false
+			for _, action := range dynamicClient.Actions() {
+				if action.GetVerb() == "delete" && action.GetResource().Resource == "namespaces" {
+					deleted = true
+				}
+			}
+			if deleted != tt.wantDeleted {
+				t.Errorf("namespace deleted = %v, want %v", deleted, tt.wantDeleted)
+			}
+		})
+	}
+}
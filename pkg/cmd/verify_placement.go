@@ -0,0 +1,461 @@
+package cmd
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+	"kubectl-multi/pkg/util"
+)
+
+// Verdicts of verify-placement beyond those of status: a copy that misses
+// the desired state, and clusters the policy and its Binding disagree on
+const (
+	copyStale           = "Stale"
+	placementUnbound    = "Unbound"
+	placementUnselected = "Unselected"
+)
+
+// copySeverity orders the verdicts of the copies of a cluster, the worst
+// first, to pick the one the cluster is reported with
+var copySeverity = map[string]int{
+	copyMissing:  0,
+	copyStale:    1,
+	copyNotReady: 2,
+	copyUnknown:  3,
+	copyReady:    4,
+}
+
+// clusterSpecificFields are the fields of an object that the WDS and each
+// cluster set on their own, such as the cluster IP of a Service, and so are
+// not compared with the WDS; list indexes are not part of the paths
+var clusterSpecificFields = map[schema.GroupResource][]string{
+	{Resource: "services"}:               {"spec.clusterIP", "spec.clusterIPs", "spec.ports.nodePort", "spec.healthCheckNodePort"},
+	{Resource: "persistentvolumeclaims"}: {"spec.volumeName"},
+	{Resource: "serviceaccounts"}:        {"secrets"},
+	{Group: "batch", Resource: "jobs"}:   {"spec.selector", "spec.template.metadata.labels"},
+}
+
+// placementVerification is the conformance of a BindingPolicy's placement:
+// whether every cluster it selects holds its workload, as intended
+type placementVerification struct {
+	Policy  string `json:"policy"`
+	WDS     string `json:"wds"`
+	Binding string `json:"binding"`
+	// Objects are the workload objects of the Binding, with their WDS
+	// generation
+	Objects    []string             `json:"objects"`
+	Clusters   []clusterConformance `json:"clusters"`
+	Conformant bool                 `json:"conformant"`
+	Problems   []string             `json:"problems,omitempty"`
+
+	objects    []kubestellar.ObjectRef
+	desired    map[kubestellar.ObjectRef]*unstructured.Unstructured
+	removed    map[schema.GroupResource][]string
+	createOnly map[kubestellar.ObjectRef]bool
+}
+
+// clusterConformance is the verdict for one cluster and each of its copies
+type clusterConformance struct {
+	Cluster string              `json:"cluster"`
+	Status  string              `json:"status"`
+	Ready   int                 `json:"ready"`
+	Objects []objectConformance `json:"objects,omitempty"`
+	Detail  string              `json:"detail,omitempty"`
+}
+
+// objectConformance is the verdict for the copy of one object in a cluster
+type objectConformance struct {
+	Object string `json:"object"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+func newKubeStellarVerifyPlacementCommand() *cobra.Command {
+	var policy string
+	var outputFormat string
+	var reach reachability
+
+	cmd := &cobra.Command{
+		Use:   "verify-placement --policy POLICY",
+		Short: "Check every cluster a BindingPolicy selects holds its workload, current and Ready",
+		Long: `Check that a BindingPolicy's placement is what it intends: every cluster
+its cluster selectors match in the ITS is a destination of its Binding, and
+holds every workload object of the Binding, current and Ready. Each copy is
+judged:
+
+  Ready     the copy carries the desired state and has converged
+  NotReady  the copy is current but has not converged, with the reason
+  Stale     the copy lacks a field as the WDS sets it, with the first one
+  Missing   the cluster has no copy of the object
+  Unknown   the cluster could not be read
+
+Fields added in the cluster, such as defaults, do not make a copy stale, nor
+do the fields CustomTransforms remove, fields the WDS and each cluster set on
+their own (such as the cluster IP of a Service), and objects downsynced
+createOnly. A cluster is Unbound when the policy selects it but the Binding
+does not list it, and Unselected the other way round.
+
+The command fails unless the Binding is Synced and every cluster is Ready,
+so it can gate a release pipeline.`,
+		Example: `# Check the placement of app-policy after a release
+kubectl multi kubestellar verify-placement --policy app-policy
+
+# As JSON for a CI report, against another WDS
+kubectl multi kubestellar verify-placement --policy app-policy --wds-context wds2 -o json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if policy == "" {
+				return fmt.Errorf("--policy is required")
+			}
+			switch outputFormat {
+			case "", "json", "yaml":
+			default:
+				return fmt.Errorf("unsupported output format %q, must be one of json|yaml", outputFormat)
+			}
+			kubeconfig, remoteCtx, _, _, _ := GetGlobalFlags()
+			return handleVerifyPlacement(policy, outputFormat, reach, kubeconfig, remoteCtx, GetWDSContext())
+		},
+	}
+
+	cmd.Flags().StringVar(&policy, "policy", "", "BindingPolicy to verify")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "output format (json|yaml)")
+	reach.addFlags(cmd)
+
+	return cmd
+}
+
+func handleVerifyPlacement(name, outputFormat string, reach reachability, kubeconfig, remoteCtx, wdsContext string) error {
+	if _, err := reach.controlPlane("WDS", kubeconfig, wdsContext, true); err != nil {
+		return err
+	}
+	if _, err := reach.controlPlane("ITS", kubeconfig, remoteCtx, true); err != nil {
+		return err
+	}
+	defer printClusterIssuesToStderr()
+
+	wds, err := cluster.ClientForContext(kubeconfig, wdsContext)
+	if err != nil {
+		return err
+	}
+	policy, err := wds.DynamicClient.Resource(kubestellar.BindingPolicyGVR).Get(commandContext(), name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get bindingpolicy %s in WDS %s: %v", name, wdsContext, err)
+	}
+	binding, err := wds.DynamicClient.Resource(kubestellar.BindingGVR).Get(commandContext(), name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get the binding of bindingpolicy %s in WDS %s: %v", name, wdsContext, err)
+	}
+	mcs, err := cluster.ListManagedClusterObjects(commandContext(), kubeconfig, remoteCtx)
+	if err != nil {
+		return err
+	}
+
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	if clusters, err = reach.filter(clusters); err != nil {
+		return err
+	}
+	clients := make(map[string]cluster.ClusterInfo, len(clusters))
+	for _, c := range clusters {
+		clients[c.Name] = c
+	}
+
+	v, err := verifyPlacement(policy, binding, mcs, wds.DynamicClient, clients)
+	if err != nil {
+		return err
+	}
+	v.WDS = wdsContext
+
+	if outputFormat != "" {
+		if err := util.PrintStructured(util.GetOutputStream(), outputFormat, v); err != nil {
+			return err
+		}
+	} else {
+		printPlacementVerification(v)
+	}
+	if !v.Conformant {
+		return fmt.Errorf("bindingpolicy %s does not conform: %s", name, strings.Join(v.Problems, "; "))
+	}
+	return nil
+}
+
+// verifyPlacement judges every cluster the policy selects among the
+// ManagedClusters mcs, or its Binding lists, reading the desired objects and
+// CustomTransforms from the WDS and the copies through clients
+func verifyPlacement(policy, binding *unstructured.Unstructured, mcs []unstructured.Unstructured, wds dynamic.Interface, clients map[string]cluster.ClusterInfo) (placementVerification, error) {
+	v := placementVerification{
+		Policy:     policy.GetName(),
+		Binding:    kubestellar.BindingState(binding),
+		Clusters:   []clusterConformance{},
+		objects:    kubestellar.BindingWorkload(binding),
+		desired:    map[kubestellar.ObjectRef]*unstructured.Unstructured{},
+		removed:    map[schema.GroupResource][]string{},
+		createOnly: kubestellar.BindingCreateOnly(binding),
+	}
+	spec, err := kubestellar.BindingPolicySpecFrom(policy)
+	if err != nil {
+		return v, err
+	}
+
+	// Without CustomTransforms in the WDS nothing is removed
+	var transforms []unstructured.Unstructured
+	if list, err := wds.Resource(kubestellar.CustomTransformGVR).List(commandContext(), metav1.ListOptions{}); err == nil {
+		transforms = list.Items
+	}
+	for _, ref := range v.objects {
+		gr := schema.GroupResource{Group: ref.Group, Resource: ref.Resource}
+		if _, ok := v.removed[gr]; !ok {
+			v.removed[gr] = append(append([]string{}, clusterSpecificFields[gr]...), transformFieldPaths(kubestellar.TransformRemovals(transforms, gr))...)
+		}
+		gvr := schema.GroupVersionResource{Group: ref.Group, Version: ref.Version, Resource: ref.Resource}
+		obj, err := wds.Resource(gvr).Namespace(ref.Namespace).Get(commandContext(), ref.Name, metav1.GetOptions{})
+		if err != nil {
+			v.Objects = append(v.Objects, ref.String())
+			v.Problems = append(v.Problems, fmt.Sprintf("%s of the binding cannot be read from the WDS: %v", ref, err))
+			continue
+		}
+		v.desired[ref] = obj
+		v.Objects = append(v.Objects, fmt.Sprintf("%s (generation %d)", ref, obj.GetGeneration()))
+	}
+	if v.Binding != "Synced" {
+		v.Problems = append(v.Problems, fmt.Sprintf("the binding is %s", v.Binding))
+	}
+
+	selected := map[string]bool{}
+	for i := range mcs {
+		if ok, _ := kubestellar.MatchAnySelector(spec.ClusterSelectors, mcs[i].GetLabels()); ok {
+			selected[mcs[i].GetName()] = true
+		}
+	}
+	bound := map[string]bool{}
+	for _, name := range kubestellar.BindingDestinations(binding) {
+		bound[name] = true
+	}
+	all := map[string]bool{}
+	for name := range selected {
+		all[name] = true
+	}
+	for name := range bound {
+		all[name] = true
+	}
+
+	failing := 0
+	for _, name := range sortedKeys(all) {
+		c := v.verifyCluster(name, clients[name])
+		switch {
+		case !bound[name]:
+			c.Status, c.Detail = placementUnbound, "selected by the policy but not a destination of its binding"
+		case !selected[name]:
+			c.Status, c.Detail = placementUnselected, "a destination of the binding but no longer selected by the policy"
+		}
+		if c.Status != copyReady {
+			failing++
+		}
+		v.Clusters = append(v.Clusters, c)
+	}
+	if failing > 0 {
+		v.Problems = append(v.Problems, fmt.Sprintf("%d of %d cluster(s) are not Ready", failing, len(v.Clusters)))
+	}
+	v.Conformant = len(v.Problems) == 0
+	return v, nil
+}
+
+// verifyCluster judges the copy of every workload object in one cluster
+func (v placementVerification) verifyCluster(name string, c cluster.ClusterInfo) clusterConformance {
+	result := clusterConformance{Cluster: name, Status: copyReady}
+	var problems []string
+	for _, ref := range v.objects {
+		o := objectConformance{Object: ref.String()}
+		if c.DynamicClient == nil {
+			o.Status, o.Detail = copyUnknown, "cluster not reachable"
+		} else {
+			gvr := schema.GroupVersionResource{Group: ref.Group, Version: ref.Version, Resource: ref.Resource}
+			s, live := getClusterCopy(c, gvr, ref)
+			o.Status, o.Detail = s.Status, s.Detail
+			desired := v.desired[ref]
+			if live != nil && desired != nil && !v.createOnly[ref] {
+				if path := workloadDrift(desired, live, v.removed[schema.GroupResource{Group: ref.Group, Resource: ref.Resource}]); path != "" {
+					o.Status, o.Detail = copyStale, path+" differs from the WDS"
+				}
+			}
+		}
+
+		if o.Status == copyReady {
+			result.Ready++
+		} else {
+			problem := o.Object + " is " + o.Status
+			if o.Detail != "" {
+				problem += ": " + o.Detail
+			}
+			problems = append(problems, problem)
+		}
+		if copySeverity[o.Status] < copySeverity[result.Status] {
+			result.Status = o.Status
+		}
+		result.Objects = append(result.Objects, o)
+	}
+	if len(problems) > 0 {
+		result.Detail = problems[0]
+		if len(problems) > 1 {
+			result.Detail += fmt.Sprintf(" (+%d more)", len(problems)-1)
+		}
+	}
+	return result
+}
+
+// transformFieldPaths converts CustomTransform JSONPaths such as
+// $.spec.template.spec.containers[0].env to field paths, cut at the first
+// list index or wildcard so that the whole list is left out of the
+// comparison
+func transformFieldPaths(jsonPaths []string) []string {
+	var paths []string
+	for _, p := range jsonPaths {
+		p = strings.TrimPrefix(strings.TrimPrefix(p, "$"), ".")
+		if i := strings.IndexAny(p, "[*"); i >= 0 {
+			p = strings.TrimSuffix(p[:i], ".")
+		}
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// workloadDrift returns the first field, as a path, that the desired object
+// of the WDS sets and its copy does not match, or "" when the copy carries
+// all of the desired state. Fields the copy has in addition, such as
+// defaults, are ignored, as are the removed paths and the metadata and
+// status of the objects.
+func workloadDrift(desired, copy *unstructured.Unstructured, removed []string) string {
+	for _, key := range sortedKeys(desired.Object) {
+		switch key {
+		case "apiVersion", "kind", "metadata", "status":
+			continue
+		}
+		if path := fieldDrift(desired.Object[key], copy.Object[key], []string{key}, removed); path != "" {
+			return path
+		}
+	}
+	return ""
+}
+
+// fieldDrift compares a desired value with the copy's at path
+func fieldDrift(want, got interface{}, path []string, removed []string) string {
+	if fieldRemoved(path, removed) {
+		return ""
+	}
+	switch w := want.(type) {
+	case nil:
+		return ""
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			if len(w) == 0 && got == nil {
+				return ""
+			}
+			return formatFieldPath(path)
+		}
+		for _, key := range sortedKeys(w) {
+			if p := fieldDrift(w[key], g[key], append(path[:len(path):len(path)], key), removed); p != "" {
+				return p
+			}
+		}
+		return ""
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok && len(w) == 0 && got == nil {
+			return ""
+		}
+		if !ok || len(g) != len(w) {
+			return formatFieldPath(path)
+		}
+		for i := range w {
+			if p := fieldDrift(w[i], g[i], append(path[:len(path):len(path)], fmt.Sprintf("[%d]", i)), removed); p != "" {
+				return p
+			}
+		}
+		return ""
+	default:
+		if !reflect.DeepEqual(want, got) {
+			return formatFieldPath(path)
+		}
+		return ""
+	}
+}
+
+// fieldRemoved reports whether path, without its list indexes, is one of
+// the removed paths or inside one
+func fieldRemoved(path []string, removed []string) bool {
+	if len(removed) == 0 {
+		return false
+	}
+	var fields []string
+	for _, p := range path {
+		if !strings.HasPrefix(p, "[") {
+			fields = append(fields, p)
+		}
+	}
+	dotted := strings.Join(fields, ".")
+	for _, r := range removed {
+		if dotted == r || strings.HasPrefix(dotted, r+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// formatFieldPath joins a path the way kubectl explain names fields, with
+// list indexes in brackets, e.g. spec.template.spec.containers[0].image
+func formatFieldPath(path []string) string {
+	var b strings.Builder
+	for i, p := range path {
+		if i > 0 && !strings.HasPrefix(p, "[") {
+			b.WriteString(".")
+		}
+		b.WriteString(p)
+	}
+	return b.String()
+}
+
+func printPlacementVerification(v placementVerification) {
+	out := util.GetOutputStream()
+	fmt.Fprintf(out, "Policy:      %s (WDS %s)\n", v.Policy, v.WDS)
+	fmt.Fprintf(out, "Binding:     %s\n", v.Binding)
+	for i, o := range v.Objects {
+		label := "Objects:"
+		if i > 0 {
+			label = ""
+		}
+		fmt.Fprintf(out, "%-12s %s\n", label, o)
+	}
+	if len(v.Objects) == 0 {
+		fmt.Fprintf(out, "Objects:     <none>, the binding lists no workload\n")
+	}
+	fmt.Fprintln(out)
+
+	if len(v.Clusters) == 0 {
+		fmt.Fprintln(out, "No cluster is selected by the policy.")
+	} else {
+		tw := util.NewTableWriter(out, "")
+		fmt.Fprintln(tw, "CLUSTER\tSTATUS\tREADY\tDETAIL")
+		for _, c := range v.Clusters {
+			fmt.Fprintf(tw, "%s\t%s\t%d/%d\t%s\n", c.Cluster, c.Status, c.Ready, len(v.Objects), orNone(c.Detail))
+		}
+		tw.Flush()
+	}
+
+	if v.Conformant {
+		fmt.Fprintf(out, "\nConformant: %d cluster(s) hold the workload, current and Ready\n", len(v.Clusters))
+	}
+}
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/kubestellar"
+)
+
+// testNginx returns deployment web/nginx running image, with the status of a
+// ready copy when ready is set
+func testNginx(image string, ready bool) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"namespace": "web", "name": "nginx", "generation": int64(3)},
+		"spec": map[string]interface{}{
+			"replicas": int64(1),
+			"template": map[string]interface{}{"spec": map[string]interface{}{
+				"containers": []interface{}{map[string]interface{}{"name": "nginx", "image": image}},
+			}},
+		},
+	}}
+	if ready {
+		// Defaults the WEC adds do not make the copy stale
+		unstructured.SetNestedField(obj.Object, "RollingUpdate", "spec", "strategy", "type")
+		unstructured.SetNestedField(obj.Object, map[string]interface{}{
+			"observedGeneration": int64(3), "replicas": int64(1), "readyReplicas": int64(1), "updatedReplicas": int64(1), "availableReplicas": int64(1),
+		}, "status")
+	}
+	return obj
+}
+
+func TestVerifyPlacement(t *testing.T) {
+	policy := testBindingPolicy("app", map[string]interface{}{"env": "prod"})
+	binding := testWorkloadBinding("app", 2, 2, "cluster1", "cluster2", "cluster4", "cluster5")
+	wds := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{kubestellar.CustomTransformGVR: "CustomTransformList"},
+		testNginx("nginx:1.25", false))
+	mcs := []unstructured.Unstructured{
+		testManagedCluster("cluster1", map[string]string{"env": "prod"}),
+		testManagedCluster("cluster2", map[string]string{"env": "prod"}),
+		testManagedCluster("cluster3", map[string]string{"env": "prod"}),
+		testManagedCluster("cluster4", map[string]string{"env": "prod"}),
+		testManagedCluster("cluster5", map[string]string{"env": "dev"}),
+	}
+	wec := func(name string, objects ...runtime.Object) cluster.ClusterInfo {
+		return cluster.ClusterInfo{Name: name, DynamicClient: fakedynamic.NewSimpleDynamicClient(runtime.NewScheme(), objects...)}
+	}
+	clients := map[string]cluster.ClusterInfo{
+		"cluster1": wec("cluster1", testNginx("nginx:1.25", true)),
+		"cluster2": wec("cluster2", testNginx("nginx:1.24", true)),
+		"cluster3": wec("cluster3"),
+		"cluster4": wec("cluster4"),
+		"cluster5": wec("cluster5", testNginx("nginx:1.25", true)),
+	}
+
+	v, err := verifyPlacement(policy, binding, mcs, wds, clients)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type verdict struct{ cluster, status, detail string }
+	var got []verdict
+	for _, c := range v.Clusters {
+		got = append(got, verdict{c.Cluster, c.Status, c.Detail})
+	}
+	want := []verdict{
+		{"cluster1", copyReady, ""},
+		{"cluster2", copyStale, "deployments.apps web/nginx is Stale: spec.template.spec.containers[0].image differs from the WDS"},
+		{"cluster3", placementUnbound, "selected by the policy but not a destination of its binding"},
+		{"cluster4", copyMissing, "deployments.apps web/nginx is Missing"},
+		{"cluster5", placementUnselected, "a destination of the binding but no longer selected by the policy"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("verdicts = %+v, want %+v", got, want)
+	}
+	if v.Conformant || !reflect.DeepEqual(v.Problems, []string{"4 of 5 cluster(s) are not Ready"}) {
+		t.Errorf("conformant = %v, problems = %v", v.Conformant, v.Problems)
+	}
+	if !reflect.DeepEqual(v.Objects, []string{"deployments.apps web/nginx (generation 3)"}) {
+		t.Errorf("objects = %v", v.Objects)
+	}
+
+	// A CustomTransform removing the image, and a pending Binding
+	transform := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": kubestellar.CustomTransformGVR.GroupVersion().String(),
+		"kind":       "CustomTransform",
+		"metadata":   map[string]interface{}{"name": "no-image"},
+		"spec": map[string]interface{}{
+			"apiGroup": "apps", "resource": "deployments",
+			"remove": []interface{}{"$.spec.template.spec.containers[0].image"},
+		},
+	}}
+	wds = fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{kubestellar.CustomTransformGVR: "CustomTransformList"},
+		testNginx("nginx:1.25", false), transform)
+	v, err = verifyPlacement(policy, testWorkloadBinding("app", 3, 2, "cluster1", "cluster2"), mcs[:2], wds, clients)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Clusters[1].Status != copyReady {
+		t.Errorf("cluster2 = %+v, want Ready with the image removed", v.Clusters[1])
+	}
+	if v.Conformant || !reflect.DeepEqual(v.Problems, []string{"the binding is Pending"}) {
+		t.Errorf("conformant = %v, problems = %v", v.Conformant, v.Problems)
+	}
+}
+
+func TestWorkloadDrift(t *testing.T) {
+	desired := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata":   map[string]interface{}{"name": "web"},
+		"spec": map[string]interface{}{
+			"clusterIP": "10.0.0.1",
+			"ports":     []interface{}{map[string]interface{}{"port": int64(80), "nodePort": int64(30080)}},
+			"selector":  map[string]interface{}{"app": "web"},
+		},
+	}}
+	copyWith := func(change func(spec map[string]interface{})) *unstructured.Unstructured {
+		c := desired.DeepCopy()
+		c.SetLabels(map[string]string{"added": "in-cluster"})
+		change(c.Object["spec"].(map[string]interface{}))
+		return c
+	}
+	removed := clusterSpecificFields[schema.GroupResource{Resource: "services"}]
+
+	tests := []struct {
+		name   string
+		change func(spec map[string]interface{})
+		want   string
+	}{
+		{name: "same", change: func(spec map[string]interface{}) {}},
+		{name: "defaults added", change: func(spec map[string]interface{}) { spec["type"] = "ClusterIP" }},
+		{name: "cluster specific fields", change: func(spec map[string]interface{}) {
+			spec["clusterIP"] = "10.96.0.7"
+			spec["ports"].([]interface{})[0].(map[string]interface{})["nodePort"] = int64(31000)
+		}},
+		{name: "changed value", change: func(spec map[string]interface{}) { spec["selector"] = map[string]interface{}{"app": "api"} }, want: "spec.selector.app"},
+		{name: "missing field", change: func(spec map[string]interface{}) { delete(spec, "selector") }, want: "spec.selector"},
+		{name: "list length", change: func(spec map[string]interface{}) {
+			spec["ports"] = append(spec["ports"].([]interface{}), map[string]interface{}{"port": int64(443)})
+		}, want: "spec.ports"},
+		{name: "list element", change: func(spec map[string]interface{}) {
+			spec["ports"].([]interface{})[0].(map[string]interface{})["port"] = int64(8080)
+		}, want: "spec.ports[0].port"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := workloadDrift(desired, copyWith(tt.change), removed); got != tt.want {
+				t.Errorf("workloadDrift() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransformFieldPaths(t *testing.T) {
+	got := transformFieldPaths([]string{"$.spec.suspend", "$.spec.template.spec.containers[0].env", "$.metadata.annotations.*", "$"})
+	want := []string{"spec.suspend", "spec.template.spec.containers", "metadata.annotations"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("transformFieldPaths() = %v, want %v", got, want)
+	}
+}
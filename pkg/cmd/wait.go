@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/jsonpath"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+// waitCondition is a parsed --for value
+type waitCondition struct {
+	Delete    bool
+	Condition string
+	Status    string
+	JSONPath  string
+	Value     string
+	Raw       string
+}
+
+// waitResult is the outcome of waiting in one cluster
+type waitResult struct {
+	Cluster string
+	Met     bool
+	Elapsed time.Duration
+	Message string
+}
+
+func newWaitCommand() *cobra.Command {
+	var forCondition string
+	var timeout time.Duration
+	var targets clusterTargets
+
+	cmd := &cobra.Command{
+		Use:   "wait (TYPE/NAME | TYPE NAME) --for=delete|condition=NAME[=VALUE]|jsonpath='{PATH}'=VALUE",
+		Short: "Wait for a condition on a resource in every managed cluster",
+		Long: `Wait for a condition on a resource in every selected managed cluster at the
+same time. A table shows which clusters met the condition and which timed out
+or failed; the command exits non-zero unless every cluster met it.`,
+		Example: `# Gate a pipeline on the deployment being available everywhere
+kubectl multi wait --for=condition=Available deployment/nginx --timeout=120s
+
+# Wait for a pod to go away in the production clusters
+kubectl multi wait --for=delete pod/busybox --cluster-selector env=prod
+
+# Wait for a field value
+kubectl multi wait --for=jsonpath='{.status.phase}'=Running pod/busybox`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resourceType, name, err := parseTypeName(args)
+			if err != nil {
+				return err
+			}
+			cond, err := parseWaitCondition(forCondition)
+			if err != nil {
+				return err
+			}
+			kubeconfig, remoteCtx, _, namespace, _ := GetGlobalFlags()
+			return handleWaitCommand(resourceType, name, cond, timeout, targets, kubeconfig, remoteCtx, namespace)
+		},
+	}
+
+	cmd.Flags().StringVar(&forCondition, "for", "", "the condition to wait on: delete, condition=NAME[=VALUE] or jsonpath='{PATH}'=VALUE")
+	cmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "how long to wait in each cluster")
+	targets.addFlags(cmd, "wait in")
+	cmd.MarkFlagRequired("for")
+
+	return cmd
+}
+
+// parseWaitCondition parses the --for flag the way kubectl wait does
+func parseWaitCondition(value string) (waitCondition, error) {
+	cond := waitCondition{Raw: value}
+	switch {
+	case strings.ToLower(value) == "delete":
+		cond.Delete = true
+	case strings.HasPrefix(value, "condition="):
+		spec := strings.TrimPrefix(value, "condition=")
+		cond.Condition, cond.Status = spec, "True"
+		if i := strings.Index(spec, "="); i >= 0 {
+			cond.Condition, cond.Status = spec[:i], spec[i+1:]
+		}
+		if cond.Condition == "" {
+			return cond, fmt.Errorf("--for=condition= requires a condition name")
+		}
+	case strings.HasPrefix(value, "jsonpath="):
+		spec := strings.TrimPrefix(value, "jsonpath=")
+		i := strings.LastIndex(spec, "=")
+		if i <= 0 || !strings.HasSuffix(spec[:i], "}") {
+			return cond, fmt.Errorf("--for=jsonpath must be of the form jsonpath='{PATH}'=VALUE")
+		}
+		cond.JSONPath = spec[:i]
+		cond.Value = spec[i+1:]
+		if _, err := cond.parseJSONPath(); err != nil {
+			return cond, err
+		}
+	default:
+		return cond, fmt.Errorf("unrecognized --for %q, must be delete, condition=NAME[=VALUE] or jsonpath='{PATH}'=VALUE", value)
+	}
+	return cond, nil
+}
+
+// parseJSONPath parses the jsonpath of the condition, or returns nil when it
+// has none. JSONPath evaluation is stateful, so each concurrent wait parses
+// its own.
+func (c waitCondition) parseJSONPath() (*jsonpath.JSONPath, error) {
+	if c.JSONPath == "" {
+		return nil, nil
+	}
+	jp := jsonpath.New("wait")
+	if err := jp.Parse(c.JSONPath); err != nil {
+		return nil, fmt.Errorf("invalid jsonpath %s: %v", c.JSONPath, err)
+	}
+	return jp, nil
+}
+
+// check reports whether obj satisfies a condition or jsonpath wait; jp is
+// the parsed jsonpath of a jsonpath wait
+func (c waitCondition) check(obj *unstructured.Unstructured, jp *jsonpath.JSONPath) (bool, string) {
+	if jp != nil {
+		results, err := jp.FindResults(obj.Object)
+		if err != nil || len(results) == 0 || len(results[0]) == 0 {
+			return false, "jsonpath not found"
+		}
+		got := fmt.Sprint(results[0][0].Interface())
+		return got == c.Value, fmt.Sprintf("value is %q", got)
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, raw := range conditions {
+		condition, ok := raw.(map[string]interface{})
+		if !ok || !strings.EqualFold(fmt.Sprint(condition["type"]), c.Condition) {
+			continue
+		}
+		status := fmt.Sprint(condition["status"])
+		if observed, found, _ := unstructured.NestedInt64(condition, "observedGeneration"); found && observed < obj.GetGeneration() {
+			return false, "condition is from an older generation"
+		}
+		return strings.EqualFold(status, c.Status), fmt.Sprintf("%s=%s", c.Condition, status)
+	}
+	return false, fmt.Sprintf("no %s condition", c.Condition)
+}
+
+func handleWaitCommand(resourceType, name string, cond waitCondition, timeout time.Duration, targets clusterTargets, kubeconfig, remoteCtx, namespace string) error {
+	clusters, err := discoverClusters(kubeconfig, remoteCtx)
+	if err != nil {
+		return fmt.Errorf("failed to discover clusters: %v", err)
+	}
+	clusters, err = targets.selectFrom(clusters, kubeconfig)
+	if err != nil {
+		return err
+	}
+	if len(clusters) == 0 {
+		return fmt.Errorf("no clusters discovered")
+	}
+
+	results := make([]waitResult, len(clusters))
+	var wg sync.WaitGroup
+	for i, clusterInfo := range clusters {
+		wg.Add(1)
+		go func(i int, clusterInfo cluster.ClusterInfo) {
+			defer wg.Done()
+			results[i] = waitInCluster(clusterInfo, resourceType, name, cond, timeout, namespace)
+		}(i, clusterInfo)
+	}
+	wg.Wait()
+
+	tw := tabwriter.NewWriter(util.GetOutputStream(), 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "CLUSTER\tRESULT\tELAPSED\tMESSAGE\n")
+	failed := 0
+	for _, r := range results {
+		result := "met"
+		if !r.Met {
+			result = "not met"
+			failed++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Cluster, result, r.Elapsed.Round(100*time.Millisecond), r.Message)
+	}
+	tw.Flush()
+
+	if failed > 0 {
+		return fmt.Errorf("condition %s not met in %d of %d cluster(s)", cond.Raw, failed, len(results))
+	}
+	return nil
+}
+
+// waitInCluster polls one cluster until the condition is met or the timeout expires
+func waitInCluster(clusterInfo cluster.ClusterInfo, resourceType, name string, cond waitCondition, timeout time.Duration, namespace string) waitResult {
+	start := time.Now()
+	result := waitResult{Cluster: clusterInfo.Name}
+	if clusterInfo.DynamicClient == nil || clusterInfo.DiscoveryClient == nil {
+		result.Message = "no client available"
+		return result
+	}
+
+	jp, err := cond.parseJSONPath()
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	gvr, namespaced, err := util.DiscoverGVR(clusterInfo.DiscoveryClient, resourceType)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	client := clusterInfo.DynamicClient.Resource(gvr)
+	get := client.Get
+	if namespaced {
+		get = client.Namespace(cluster.GetTargetNamespace(namespace)).Get
+	}
+
+	message := ""
+	err = wait.PollUntilContextTimeout(commandContext(), time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		obj, err := get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			message = "not found"
+			if cond.Delete {
+				message = "deleted"
+			}
+			return cond.Delete, nil
+		}
+		if err != nil {
+			message = err.Error()
+			return false, nil
+		}
+		if cond.Delete {
+			message = "still exists"
+			return false, nil
+		}
+		var met bool
+		met, message = cond.check(obj, jp)
+		return met, nil
+	})
+
+	result.Elapsed = time.Since(start)
+	result.Met = err == nil
+	result.Message = message
+	if !result.Met && wait.Interrupted(err) {
+		result.Message = fmt.Sprintf("timed out (%s)", message)
+	}
+	return result
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestParseWaitCondition(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    waitCondition
+		wantErr bool
+	}{
+		{value: "delete", want: waitCondition{Delete: true, Raw: "delete"}},
+		{value: "Delete", want: waitCondition{Delete: true, Raw: "Delete"}},
+		{value: "condition=Available", want: waitCondition{Condition: "Available", Status: "True", Raw: "condition=Available"}},
+		{value: "condition=Ready=False", want: waitCondition{Condition: "Ready", Status: "False", Raw: "condition=Ready=False"}},
+		{value: "condition=", wantErr: true},
+		{
+			value: "jsonpath={.status.phase}=Running",
+			want:  waitCondition{JSONPath: "{.status.phase}", Value: "Running", Raw: "jsonpath={.status.phase}=Running"},
+		},
+		{
+			value: "jsonpath={.metadata.labels.a=b}=c",
+			want:  waitCondition{JSONPath: "{.metadata.labels.a=b}", Value: "c", Raw: "jsonpath={.metadata.labels.a=b}=c"},
+		},
+		{value: "jsonpath={.status.phase}", wantErr: true},
+		{value: "jsonpath=.status.phase=Running", wantErr: true},
+		{value: "jsonpath={.status[}=Running", wantErr: true},
+		{value: "ready", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseWaitCondition(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseWaitCondition() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseWaitCondition() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWaitConditionCheck(t *testing.T) {
+	pod := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind":     "Pod",
+		"metadata": map[string]interface{}{"name": "busybox", "generation": int64(2)},
+		"status": map[string]interface{}{
+			"phase": "Running",
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Ready", "status": "True"},
+				map[string]interface{}{"type": "Initialized", "status": "True", "observedGeneration": int64(1)},
+			},
+		},
+	}}
+
+	tests := []struct {
+		name        string
+		forValue    string
+		wantMet     bool
+		wantMessage string
+	}{
+		{name: "condition met", forValue: "condition=Ready", wantMet: true, wantMessage: "Ready=True"},
+		{name: "condition type is case insensitive", forValue: "condition=ready", wantMet: true, wantMessage: "ready=True"},
+		{name: "condition status differs", forValue: "condition=Ready=False", wantMessage: "Ready=True"},
+		{name: "condition missing", forValue: "condition=Available", wantMessage: "no Available condition"},
+		{name: "stale condition", forValue: "condition=Initialized", wantMessage: "condition is from an older generation"},
+		{name: "jsonpath met", forValue: "jsonpath={.status.phase}=Running", wantMet: true, wantMessage: `value is "Running"`},
+		{name: "jsonpath differs", forValue: "jsonpath={.status.phase}=Succeeded", wantMessage: `value is "Running"`},
+		{name: "jsonpath missing", forValue: "jsonpath={.status.podIP}=10.0.0.1", wantMessage: "jsonpath not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cond, err := parseWaitCondition(tt.forValue)
+			if err != nil {
+				t.Fatalf("parseWaitCondition() error = %v", err)
+			}
+			jp, err := cond.parseJSONPath()
+			if err != nil {
+				t.Fatalf("parseJSONPath() error = %v", err)
+			}
+			met, message := cond.check(pod, jp)
+			if met != tt.wantMet || message != tt.wantMessage {
+				t.Errorf("check() = %v, %q, want %v, %q", met, message, tt.wantMet, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestWaitInCluster(t *testing.T) {
+	deployment := testObject("apps/v1", "Deployment", "default", "nginx")
+	_ = unstructured.SetNestedSlice(deployment.Object, []interface{}{
+		map[string]interface{}{"type": "Available", "status": "True"},
+	}, "status", "conditions")
+
+	tests := []struct {
+		name        string
+		forValue    string
+		objName     string
+		wantMet     bool
+		wantMessage string
+	}{
+		{name: "condition met", forValue: "condition=Available", objName: "nginx", wantMet: true, wantMessage: "Available=True"},
+		{name: "jsonpath met", forValue: "jsonpath={.status.conditions[0].type}=Available", objName: "nginx", wantMet: true, wantMessage: `value is "Available"`},
+		{name: "deleted", forValue: "delete", objName: "redis", wantMet: true, wantMessage: "deleted"},
+		{name: "timed out", forValue: "delete", objName: "nginx", wantMessage: "timed out (still exists)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusterInfo, _ := testClusterInfo(deployment.DeepCopy())
+			cond, err := parseWaitCondition(tt.forValue)
+			if err != nil {
+				t.Fatalf("parseWaitCondition() error = %v", err)
+			}
+			got := waitInCluster(clusterInfo, "deployment", tt.objName, cond, 10*time.Millisecond, "default")
+			if got.Met != tt.wantMet || got.Message != tt.wantMessage {
+				t.Errorf("waitInCluster() = %v, %q, want %v, %q", got.Met, got.Message, tt.wantMet, tt.wantMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/client-go/dynamic"
+
+	"kubectl-multi/pkg/cluster"
+	"kubectl-multi/pkg/util"
+)
+
+// wdsSummary is the machine-readable view of a WDS ControlPlane
+type wdsSummary struct {
+	Name    string    `json:"name"`
+	Type    string    `json:"type,omitempty"`
+	ITS     string    `json:"its,omitempty"`
+	Ready   string    `json:"ready"`
+	Context bool      `json:"context"`
+	Created time.Time `json:"created"`
+}
+
+func newWDSCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "wds",
+		Short: "Inspect the WDS ControlPlanes on the KubeFlex hosting cluster",
+		Long: `Inspect the workload description spaces (WDSes) defined as KubeFlex
+ControlPlanes. Their names are the contexts to pass to --wds-context or --wds.`,
+	}
+	cmd.AddCommand(newWDSListCommand())
+	return cmd
+}
+
+func newWDSListCommand() *cobra.Command {
+	var outputFormat string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the WDS ControlPlanes and whether the kubeconfig can reach them",
+		Example: `# List WDSes with their ITS and readiness
+kubectl multi wds list
+
+# Machine-readable list
+kubectl multi wds list -o json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch outputFormat {
+			case "", "json", "yaml":
+			default:
+				return fmt.Errorf("unsupported output format %q, must be one of json|yaml", outputFormat)
+			}
+			kubeconfig, _, _, _, _ := GetGlobalFlags()
+			return handleWDSList(outputFormat, kubeconfig)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "", "output format (json|yaml)")
+
+	return cmd
+}
+
+func handleWDSList(outputFormat, kubeconfig string) error {
+	hostCtx, err := discoverKubeFlexHostingCluster(kubeconfig)
+	if err != nil {
+		return fmt.Errorf("failed to discover KubeFlex hosting cluster: %v", err)
+	}
+	host, err := cluster.ClientForContext(kubeconfig, hostCtx)
+	if err != nil {
+		return err
+	}
+	summaries, err := listWDSControlPlanes(host.DynamicClient, cluster.KubeconfigContexts(kubeconfig))
+	if err != nil {
+		return err
+	}
+
+	if outputFormat == "json" || outputFormat == "yaml" {
+		return util.PrintStructured(util.GetOutputStream(), outputFormat, summaries)
+	}
+
+	tw := tabwriter.NewWriter(util.GetOutputStream(), 0, 0, 2, ' ', 0)
+	defer tw.Flush()
+
+	if len(summaries) == 0 {
+		fmt.Fprintf(tw, "No WDS ControlPlanes found in context %s.\n", hostCtx)
+		return nil
+	}
+
+	fmt.Fprintf(tw, "NAME\tTYPE\tITS\tREADY\tCONTEXT\tAGE\n")
+	for _, s := range summaries {
+		contextName := "<missing>"
+		if s.Context {
+			contextName = s.Name
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", s.Name, noneIfEmpty(s.Type), noneIfEmpty(s.ITS), s.Ready, contextName, duration.HumanDuration(time.Since(s.Created)))
+	}
+	return nil
+}
+
+// listWDSControlPlanes summarizes the WDS ControlPlanes on the KubeFlex
+// hosting cluster, sorted by name. A ControlPlane is a WDS when it runs the
+// "wds" post-create hook or, lacking a hook, is named like one.
+func listWDSControlPlanes(dyn dynamic.Interface, contexts map[string]bool) ([]wdsSummary, error) {
+	cps, err := dyn.Resource(controlPlaneGVR).List(commandContext(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ControlPlanes: %v", err)
+	}
+
+	summaries := []wdsSummary{}
+	for _, cp := range cps.Items {
+		hook, _, _ := unstructured.NestedString(cp.Object, "spec", "postCreateHook")
+		if hook != "wds" && (hook != "" || !cluster.IsWDSCluster(cp.GetName())) {
+			continue
+		}
+		s := wdsSummary{
+			Name:    cp.GetName(),
+			Ready:   "Unknown",
+			Context: contexts[cp.GetName()],
+			Created: cp.GetCreationTimestamp().Time,
+		}
+		s.Type, _, _ = unstructured.NestedString(cp.Object, "spec", "type")
+		s.ITS, _, _ = unstructured.NestedString(cp.Object, "spec", "postCreateHookVars", "ITSName")
+		conditions, _, _ := unstructured.NestedSlice(cp.Object, "status", "conditions")
+		for _, c := range conditions {
+			cond, ok := c.(map[string]interface{})
+			if !ok || cond["type"] != "Ready" {
+				continue
+			}
+			if status, ok := cond["status"].(string); ok {
+				s.Ready = status
+			}
+		}
+		summaries = append(summaries, s)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Name < summaries[j].Name })
+	return summaries, nil
+}
+
+// resolveWDSContexts picks the WDS contexts from --wds or, when it is not
+// set, the --wds-context
+func resolveWDSContexts(names []string, wdsContext string) []string {
+	if contexts := uniqueContexts(names); len(contexts) > 0 {
+		return contexts
+	}
+	return []string{wdsContext}
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+)
+
+func TestResolveWDSContexts(t *testing.T) {
+	tests := []struct {
+		name       string
+		names      []string
+		wdsContext string
+		want       []string
+	}{
+		{name: "wds-context by default", wdsContext: "wds1", want: []string{"wds1"}},
+		{name: "--wds overrides", names: []string{"wds2", "wds3"}, wdsContext: "wds1", want: []string{"wds2", "wds3"}},
+		{name: "duplicates dropped", names: []string{"wds2", "", "wds2"}, wdsContext: "wds1", want: []string{"wds2"}},
+		{name: "only empty names", names: []string{""}, wdsContext: "wds1", want: []string{"wds1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveWDSContexts(tt.names, tt.wdsContext); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("resolveWDSContexts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListWDSControlPlanes(t *testing.T) {
+	ready := testControlPlane("wds1", map[string]interface{}{
+		"type":               "k8s",
+		"postCreateHook":     "wds",
+		"postCreateHookVars": map[string]interface{}{"ITSName": "its1"},
+	})
+	ready.Object["status"] = map[string]interface{}{
+		"conditions": []interface{}{map[string]interface{}{"type": "Ready", "status": "True"}},
+	}
+
+	tests := []struct {
+		name     string
+		objects  []runtime.Object
+		contexts map[string]bool
+		want     []wdsSummary
+	}{
+		{
+			name: "WDS by hook or name",
+			objects: []runtime.Object{
+				testControlPlane("wds2", map[string]interface{}{"type": "host"}),
+				ready,
+				testControlPlane("its1", map[string]interface{}{"type": "vcluster", "postCreateHook": "its"}),
+				testControlPlane("edge-wds", map[string]interface{}{"type": "k8s", "postCreateHook": "its"}),
+			},
+			contexts: map[string]bool{"wds1": true},
+			want: []wdsSummary{
+				{Name: "wds1", Type: "k8s", ITS: "its1", Ready: "True", Context: true},
+				{Name: "wds2", Type: "host", Ready: "Unknown"},
+			},
+		},
+		{
+			name:    "none",
+			objects: []runtime.Object{testControlPlane("its1", map[string]interface{}{"postCreateHook": "its"})},
+			want:    []wdsSummary{},
+		},
+	}
+	listKinds := map[schema.GroupVersionResource]string{controlPlaneGVR: "ControlPlaneList"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dyn := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, tt.objects...)
+			got, err := listWDSControlPlanes(dyn, tt.contexts)
+			if err != nil {
+				t.Fatalf("listWDSControlPlanes() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("listWDSControlPlanes() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
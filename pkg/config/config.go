@@ -0,0 +1,380 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"kubectl-multi/pkg/platform"
+)
+
+// EnvConfigPath overrides the location of the plugin config file
+const EnvConfigPath = "KUBECTL_MULTI_CONFIG"
+
+// GroupPrefix marks a cluster group reference in a cluster list, e.g. @edge
+const GroupPrefix = "@"
+
+// Config is the persisted kubectl-multi plugin configuration
+type Config struct {
+	// Groups maps a group name to the managed clusters it stands for
+	Groups map[string][]string `json:"groups,omitempty"`
+
+	// Audit controls where fleet mutations are recorded
+	Audit *AuditConfig `json:"audit,omitempty"`
+
+	// Profiles maps a profile name to a saved fleet selection
+	Profiles map[string]Profile `json:"profiles,omitempty"`
+	// CurrentProfile is the profile applied to every command, if any
+	CurrentProfile string `json:"currentProfile,omitempty"`
+
+	// Safety controls the confirmation of fleet mutations
+	Safety *SafetyConfig `json:"safety,omitempty"`
+
+	// Lock makes fleet mutations hold a coordination Lease while they run
+	Lock *LockConfig `json:"lock,omitempty"`
+
+	// Hooks are notified when a mutating fleet operation completes
+	Hooks []Hook `json:"hooks,omitempty"`
+
+	// Network sets the proxies clusters are reached through and whether
+	// anything else may be reached
+	Network *NetworkConfig `json:"network,omitempty"`
+}
+
+// Profile is a named snapshot of the flags that choose a KubeStellar fleet,
+// so one installation (dev, stage, prod...) can be switched to at once.
+// Flags given on the command line take precedence over the profile.
+type Profile struct {
+	Kubeconfig      string   `json:"kubeconfig,omitempty"`
+	RemoteContext   string   `json:"remoteContext,omitempty"`
+	ITS             []string `json:"its,omitempty"`
+	AllITS          bool     `json:"allITS,omitempty"`
+	WDSContext      string   `json:"wdsContext,omitempty"`
+	WDS             []string `json:"wds,omitempty"`
+	Clusters        []string `json:"clusters,omitempty"`
+	ClusterSelector string   `json:"clusterSelector,omitempty"`
+	// Groups are the cluster groups in effect while the profile is used
+	Groups map[string][]string `json:"groups,omitempty"`
+}
+
+// AuditConfig controls the audit trail of mutating commands
+type AuditConfig struct {
+	// Disabled turns off auditing, both the local log and the ConfigMap
+	Disabled bool `json:"disabled,omitempty"`
+	// ConfigMap, when set, also records each entry in this ConfigMap in the WDS
+	ConfigMap string `json:"configMap,omitempty"`
+	// Namespace holds the audit ConfigMap; defaults to "default"
+	Namespace string `json:"namespace,omitempty"`
+	// MaxEntries caps the entries kept in the ConfigMap, oldest dropped
+	// first; defaults to DefaultAuditMaxEntries
+	MaxEntries int `json:"maxEntries,omitempty"`
+}
+
+// SafetyConfig controls when mutating commands ask for confirmation
+type SafetyConfig struct {
+	// ProtectedClusters is a label selector on the ManagedClusters, e.g.
+	// env=prod. Changes reaching a matching cluster always need confirming,
+	// at the prompt or with --yes, even when stdin is not a terminal.
+	ProtectedClusters string `json:"protectedClusters,omitempty"`
+	// SkipConfirmation turns off the prompt for changes that reach no
+	// protected cluster, and lets them run without a terminal and --yes
+	SkipConfirmation bool `json:"skipConfirmation,omitempty"`
+}
+
+// LockConfig controls the fleet lock that keeps two operators from
+// changing the fleet at the same time
+type LockConfig struct {
+	// Enabled makes mutating commands acquire the lock first
+	Enabled bool `json:"enabled,omitempty"`
+	// Context is the control plane holding the Lease; defaults to the WDS
+	Context string `json:"context,omitempty"`
+	// Namespace holds the Lease; defaults to "default"
+	Namespace string `json:"namespace,omitempty"`
+	// Name of the Lease; defaults to DefaultLockName
+	Name string `json:"name,omitempty"`
+	// TTL is how long the lock outlives a command that stopped renewing
+	// it, e.g. after a crash; defaults to DefaultLockTTL
+	TTL metav1.Duration `json:"ttl,omitempty"`
+}
+
+// NetworkConfig controls the network access of the plugin
+type NetworkConfig struct {
+	// Offline forbids network access outside the configured clusters, as
+	// --offline does: no manifests read from URLs, OCI pulls, helm chart
+	// downloads or repo updates, webhooks or trace export
+	Offline bool `json:"offline,omitempty"`
+	// Proxies maps a kubeconfig context, or a glob such as edge-*, to the
+	// proxy the cluster is reached through. A context named exactly takes
+	// precedence over the globs matching it.
+	Proxies map[string]Proxy `json:"proxies,omitempty"`
+}
+
+// Proxy is an HTTP proxy setting with the meaning of the HTTP_PROXY,
+// HTTPS_PROXY and NO_PROXY environment variables
+type Proxy struct {
+	HTTPProxy  string `json:"httpProxy,omitempty"`
+	HTTPSProxy string `json:"httpsProxy,omitempty"`
+	// NoProxy lists the hosts, domains and CIDRs reached directly
+	NoProxy string `json:"noProxy,omitempty"`
+}
+
+// Outcomes a Hook can be limited to
+const (
+	HookOnSuccess = "success"
+	HookOnFailure = "failure"
+)
+
+// Hook formats of the webhook body
+const (
+	HookFormatJSON  = "json"
+	HookFormatSlack = "slack"
+	HookFormatTeams = "teams"
+)
+
+// Hook is told the result of mutating fleet operations, either by a POST to
+// a webhook or by running a local command
+type Hook struct {
+	// Name identifies the hook in warnings
+	Name string `json:"name"`
+	// URL receives a POST of the result
+	URL string `json:"url,omitempty"`
+	// Format of the POST body: json (the structured summary, the default),
+	// slack or teams (a message for an incoming webhook)
+	Format string `json:"format,omitempty"`
+	// Headers are added to the POST, e.g. Authorization
+	Headers map[string]string `json:"headers,omitempty"`
+	// Command is run by the shell with the path of a file holding the JSON
+	// summary as its argument
+	Command string `json:"command,omitempty"`
+	// On limits the hook to success or failure; both when empty
+	On []string `json:"on,omitempty"`
+	// Commands limits the hook to these commands, e.g. apply, delete; all
+	// mutating commands when empty
+	Commands []string `json:"commands,omitempty"`
+	// Timeout bounds the POST or command; defaults to DefaultHookTimeout
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+}
+
+// DefaultHookTimeout bounds a hook when Hook.Timeout is unset
+const DefaultHookTimeout = 10 * time.Second
+
+// DefaultLockName is the name of the fleet lock Lease when unset
+const DefaultLockName = "kubectl-multi"
+
+// DefaultLockTTL is the fleet lock duration when unset
+const DefaultLockTTL = 2 * time.Minute
+
+// DefaultAuditMaxEntries is the number of entries kept in the audit ConfigMap
+// when AuditConfig.MaxEntries is unset
+const DefaultAuditMaxEntries = 500
+
+// AuditConfigMapMaxBytes keeps the audit ConfigMap well below the 1 MiB
+// object size limit of the API server
+const AuditConfigMapMaxBytes = 900 * 1024
+
+// Path returns the location of the plugin config file
+func Path() string {
+	if p := os.Getenv(EnvConfigPath); p != "" {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".kube", "kubectl-multi.yaml")
+	}
+	return filepath.Join(home, ".kube", "kubectl-multi.yaml")
+}
+
+// PresetsDir returns the directory next to the config file holding the
+// user's BindingPolicy presets
+func PresetsDir() string {
+	return filepath.Join(filepath.Dir(Path()), "kubectl-multi-presets")
+}
+
+// HelpCacheDir returns the directory next to the config file caching the
+// kubectl help text, one subdirectory per kubectl version
+func HelpCacheDir() string {
+	return filepath.Join(filepath.Dir(Path()), "kubectl-multi-help")
+}
+
+// SchedulesPath returns the file next to the config file holding the tasks
+// registered with 'kubectl multi schedule'
+func SchedulesPath() string {
+	return filepath.Join(filepath.Dir(Path()), "kubectl-multi-schedules.yaml")
+}
+
+// ScheduleLogDir returns the directory next to the config file holding the
+// output of the scheduled tasks, one log file per task
+func ScheduleLogDir() string {
+	return filepath.Join(filepath.Dir(Path()), "kubectl-multi-schedules")
+}
+
+// PrintersPath returns the file next to the config file defining the table
+// columns of get for resource types without a built-in table
+func PrintersPath() string {
+	return filepath.Join(filepath.Dir(Path()), "kubectl-multi-printers.yaml")
+}
+
+// Load reads the plugin config, returning an empty config if none exists yet
+func Load() (*Config, error) {
+	cfg := &Config{}
+	data, err := os.ReadFile(Path())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, fmt.Errorf("failed to read config %s: %v", Path(), err)
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %v", Path(), err)
+	}
+	return cfg, nil
+}
+
+// Save writes the plugin config, creating its directory when needed. A file
+// lock keeps concurrent kubectl-multi processes from interleaving writes.
+func (c *Config) Save() error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %v", err)
+	}
+	path := Path()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %v", err)
+	}
+	unlock, err := platform.LockFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write config %s: %v", path, err)
+	}
+	return nil
+}
+
+// GroupNames returns the defined group names in sorted order
+func (c *Config) GroupNames() []string {
+	names := make([]string, 0, len(c.Groups))
+	for name := range c.Groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ProfileNames returns the saved profile names in sorted order
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SaveProfile stores p under name together with a copy of the current
+// cluster groups, replacing any profile of that name
+func (c *Config) SaveProfile(name string, p Profile) {
+	p.Groups = copyGroups(c.Groups)
+	if c.Profiles == nil {
+		c.Profiles = make(map[string]Profile)
+	}
+	c.Profiles[name] = p
+}
+
+// UseProfile makes name the current profile. The groups of the profile that
+// was current are kept in it, and the groups of name take their place.
+func (c *Config) UseProfile(name string) error {
+	p, ok := c.Profiles[name]
+	if !ok {
+		return fmt.Errorf("profile %q is not defined (see 'kubectl multi profile list')", name)
+	}
+	if current, ok := c.Profiles[c.CurrentProfile]; ok && c.CurrentProfile != name {
+		current.Groups = copyGroups(c.Groups)
+		c.Profiles[c.CurrentProfile] = current
+	}
+	c.CurrentProfile = name
+	c.Groups = copyGroups(p.Groups)
+	return nil
+}
+
+func copyGroups(groups map[string][]string) map[string][]string {
+	if len(groups) == 0 {
+		return nil
+	}
+	copied := make(map[string][]string, len(groups))
+	for name, members := range groups {
+		copied[name] = append([]string(nil), members...)
+	}
+	return copied
+}
+
+// ExpandClusters replaces every @group entry with the group's clusters,
+// dropping duplicates while keeping the first-seen order
+func (c *Config) ExpandClusters(names []string) ([]string, error) {
+	var expanded []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			expanded = append(expanded, name)
+		}
+	}
+
+	for _, name := range names {
+		if !strings.HasPrefix(name, GroupPrefix) {
+			add(name)
+			continue
+		}
+		group := strings.TrimPrefix(name, GroupPrefix)
+		members, ok := c.Groups[group]
+		if !ok {
+			return nil, fmt.Errorf("cluster group %q is not defined (see 'kubectl multi groups list')", group)
+		}
+		for _, m := range members {
+			add(m)
+		}
+	}
+	return expanded, nil
+}
+
+// LockSettings returns the lock configuration with the defaults filled in;
+// the context stays empty for the caller to default
+func (c *Config) LockSettings() LockConfig {
+	var l LockConfig
+	if c.Lock != nil {
+		l = *c.Lock
+	}
+	if l.Namespace == "" {
+		l.Namespace = "default"
+	}
+	if l.Name == "" {
+		l.Name = DefaultLockName
+	}
+	if l.TTL.Duration <= 0 {
+		l.TTL.Duration = DefaultLockTTL
+	}
+	return l
+}
+
+// SafetySettings returns the safety configuration, or the defaults when unset
+func (c *Config) SafetySettings() SafetyConfig {
+	if c.Safety == nil {
+		return SafetyConfig{}
+	}
+	return *c.Safety
+}
+
+// AuditSettings returns the audit configuration, or the defaults when unset
+func (c *Config) AuditSettings() AuditConfig {
+	if c.Audit == nil {
+		return AuditConfig{}
+	}
+	return *c.Audit
+}
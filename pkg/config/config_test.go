@@ -0,0 +1,113 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExpandClusters(t *testing.T) {
+	cfg := &Config{Groups: map[string][]string{
+		"edge":  {"edge1", "edge2"},
+		"east":  {"edge2", "core1"},
+		"empty": {},
+	}}
+
+	tests := []struct {
+		name    string
+		names   []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "none", names: nil, want: nil},
+		{name: "plain names", names: []string{"a", "b"}, want: []string{"a", "b"}},
+		{name: "group", names: []string{"@edge"}, want: []string{"edge1", "edge2"}},
+		{name: "duplicates keep first order", names: []string{"core1", "@edge", "@east", "edge1"}, want: []string{"core1", "edge1", "edge2"}},
+		{name: "empty group", names: []string{"@empty", "a"}, want: []string{"a"}},
+		{name: "unknown group", names: []string{"a", "@west"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cfg.ExpandClusters(tt.names)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExpandClusters() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExpandClusters() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupNames(t *testing.T) {
+	tests := []struct {
+		groups map[string][]string
+		want   []string
+	}{
+		{groups: nil, want: []string{}},
+		{groups: map[string][]string{"west": nil, "east": nil, "edge": nil}, want: []string{"east", "edge", "west"}},
+	}
+	for _, tt := range tests {
+		cfg := &Config{Groups: tt.groups}
+		if got := cfg.GroupNames(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GroupNames() = %v, want %v", got, tt.want)
+		}
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	t.Setenv(EnvConfigPath, filepath.Join(t.TempDir(), "sub", "kubectl-multi.yaml"))
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() of a missing file error = %v", err)
+	}
+	if len(cfg.Groups) != 0 {
+		t.Fatalf("Load() of a missing file = %+v, want empty config", cfg)
+	}
+
+	cfg.Groups = map[string][]string{"edge": {"edge1", "edge2"}}
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Groups, cfg.Groups) {
+		t.Errorf("Load() groups = %v, want %v", loaded.Groups, cfg.Groups)
+	}
+}
+
+func TestUseProfile(t *testing.T) {
+	cfg := &Config{Groups: map[string][]string{"edge": {"edge1"}}}
+	cfg.SaveProfile("dev", Profile{RemoteContext: "its-dev"})
+	cfg.SaveProfile("prod", Profile{RemoteContext: "its-prod"})
+	cfg.Profiles["prod"] = Profile{RemoteContext: "its-prod", Groups: map[string][]string{"core": {"prod1", "prod2"}}}
+
+	if err := cfg.UseProfile("dev"); err != nil {
+		t.Fatalf("UseProfile(dev) error = %v", err)
+	}
+	// Groups edited while dev is current belong to dev
+	cfg.Groups["west"] = []string{"west1"}
+
+	if err := cfg.UseProfile("prod"); err != nil {
+		t.Fatalf("UseProfile(prod) error = %v", err)
+	}
+	if cfg.CurrentProfile != "prod" {
+		t.Errorf("CurrentProfile = %q, want prod", cfg.CurrentProfile)
+	}
+	if want := map[string][]string{"core": {"prod1", "prod2"}}; !reflect.DeepEqual(cfg.Groups, want) {
+		t.Errorf("groups after switching to prod = %v, want %v", cfg.Groups, want)
+	}
+	if want := map[string][]string{"edge": {"edge1"}, "west": {"west1"}}; !reflect.DeepEqual(cfg.Profiles["dev"].Groups, want) {
+		t.Errorf("dev groups = %v, want %v", cfg.Profiles["dev"].Groups, want)
+	}
+
+	if err := cfg.UseProfile("stage"); err == nil {
+		t.Error("UseProfile(stage) succeeded for an undefined profile")
+	}
+	if want := []string{"dev", "prod"}; !reflect.DeepEqual(cfg.ProfileNames(), want) {
+		t.Errorf("ProfileNames() = %v, want %v", cfg.ProfileNames(), want)
+	}
+}
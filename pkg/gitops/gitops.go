@@ -0,0 +1,104 @@
+// Package gitops recognizes objects that Argo CD or Flux manage, from the
+// labels and annotations those controllers put on what they apply. Their
+// next sync reverts imperative changes made to such objects.
+package gitops
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+)
+
+// GitOps tools
+const (
+	ArgoCD = "Argo CD"
+	Flux   = "Flux"
+)
+
+// Marks Argo CD and Flux leave on the objects they apply
+const (
+	// ArgoCDInstanceLabel names the Application with label tracking set to
+	// this key, a common replacement for app.kubernetes.io/instance, which
+	// Helm charts set as well and so is not taken as a sign of Argo CD
+	ArgoCDInstanceLabel = "argocd.argoproj.io/instance"
+	// ArgoCDTrackingAnnotation is set by annotation tracking, as
+	// APP:GROUP/KIND:NAMESPACE/NAME
+	ArgoCDTrackingAnnotation = "argocd.argoproj.io/tracking-id"
+
+	FluxKustomizeNameLabel      = "kustomize.toolkit.fluxcd.io/name"
+	FluxKustomizeNamespaceLabel = "kustomize.toolkit.fluxcd.io/namespace"
+	FluxHelmNameLabel           = "helm.toolkit.fluxcd.io/name"
+	FluxHelmNamespaceLabel      = "helm.toolkit.fluxcd.io/namespace"
+)
+
+// Manager is the GitOps object an object is applied from
+type Manager struct {
+	// Tool is ArgoCD or Flux
+	Tool string
+	// Kind is Application, Kustomization or HelmRelease
+	Kind string
+	// Name of the Application, or NAMESPACE/NAME of a Flux object
+	Name string
+}
+
+// String names the manager, e.g. "Argo CD Application web"
+func (m Manager) String() string {
+	return m.Tool + " " + m.Kind + " " + m.Name
+}
+
+// ManagerOf reports whether Argo CD or Flux manages obj
+func ManagerOf(obj metav1.Object) (Manager, bool) {
+	labels, annotations := obj.GetLabels(), obj.GetAnnotations()
+	if id := annotations[ArgoCDTrackingAnnotation]; id != "" {
+		app, _, _ := strings.Cut(id, ":")
+		return Manager{Tool: ArgoCD, Kind: "Application", Name: app}, true
+	}
+	if app := labels[ArgoCDInstanceLabel]; app != "" {
+		return Manager{Tool: ArgoCD, Kind: "Application", Name: app}, true
+	}
+	if name := labels[FluxKustomizeNameLabel]; name != "" {
+		return Manager{Tool: Flux, Kind: "Kustomization", Name: qualified(labels[FluxKustomizeNamespaceLabel], name)}, true
+	}
+	if name := labels[FluxHelmNameLabel]; name != "" {
+		return Manager{Tool: Flux, Kind: "HelmRelease", Name: qualified(labels[FluxHelmNamespaceLabel], name)}, true
+	}
+	return Manager{}, false
+}
+
+func qualified(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
+
+// Warning explains that a change to ref in a cluster will not last
+func Warning(ref, clusterName string, m Manager) string {
+	return fmt.Sprintf("%s in cluster %s is managed by %s; %s reverts imperative changes on its next sync, so change it in Git instead", ref, clusterName, m, m.Tool)
+}
+
+// toolGroups are the API groups whose presence shows a GitOps tool runs in
+// a cluster
+var toolGroups = map[string]string{
+	"argoproj.io":                 ArgoCD,
+	"kustomize.toolkit.fluxcd.io": Flux,
+	"helm.toolkit.fluxcd.io":      Flux,
+}
+
+// InstalledTools returns the GitOps tools whose APIs the cluster serves
+func InstalledTools(client discovery.DiscoveryInterface) ([]string, error) {
+	groups, err := client.ServerGroups()
+	if err != nil {
+		return nil, err
+	}
+	var tools []string
+	for _, g := range groups.Groups {
+		if tool, ok := toolGroups[g.Name]; ok && !slices.Contains(tools, tool) {
+			tools = append(tools, tool)
+		}
+	}
+	return tools, nil
+}
@@ -0,0 +1,47 @@
+package gitops
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestManagerOf(t *testing.T) {
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		annotations map[string]string
+		want        string
+	}{
+		{name: "annotation tracking", annotations: map[string]string{ArgoCDTrackingAnnotation: "web:apps/Deployment:prod/web"}, want: "Argo CD Application web"},
+		{name: "label tracking", labels: map[string]string{ArgoCDInstanceLabel: "web"}, want: "Argo CD Application web"},
+		{name: "flux kustomization", labels: map[string]string{FluxKustomizeNameLabel: "apps", FluxKustomizeNamespaceLabel: "flux-system"}, want: "Flux Kustomization flux-system/apps"},
+		{name: "flux helmrelease", labels: map[string]string{FluxHelmNameLabel: "redis", FluxHelmNamespaceLabel: "cache"}, want: "Flux HelmRelease cache/redis"},
+		{name: "helm alone", labels: map[string]string{"app.kubernetes.io/instance": "web", "app.kubernetes.io/managed-by": "Helm"}},
+		{name: "unmarked"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &metav1.ObjectMeta{Labels: tt.labels, Annotations: tt.annotations}
+			m, ok := ManagerOf(obj)
+			if ok != (tt.want != "") || (ok && m.String() != tt.want) {
+				t.Errorf("ManagerOf() = %q, %t, want %q", m, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstalledTools(t *testing.T) {
+	client := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: []*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{{Name: "pods"}}},
+		{GroupVersion: "kustomize.toolkit.fluxcd.io/v1", APIResources: []metav1.APIResource{{Name: "kustomizations"}}},
+		{GroupVersion: "helm.toolkit.fluxcd.io/v2", APIResources: []metav1.APIResource{{Name: "helmreleases"}}},
+	}}}
+	tools, err := InstalledTools(client)
+	if err != nil || !reflect.DeepEqual(tools, []string{Flux}) {
+		t.Errorf("InstalledTools() = %q, %v, want Flux once", tools, err)
+	}
+}
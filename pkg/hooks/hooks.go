@@ -0,0 +1,245 @@
+// Package hooks tells webhooks and local commands about the outcome of
+// mutating fleet operations, as configured in the plugin config.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/config"
+	"kubectl-multi/pkg/network"
+	"kubectl-multi/pkg/platform"
+)
+
+// Summary is the structured result of an operation handed to hooks
+type Summary struct {
+	ID         string    `json:"id"`
+	Time       time.Time `json:"time"`
+	User       string    `json:"user"`
+	Command    string    `json:"command"`
+	Args       []string  `json:"args"`
+	Outcome    string    `json:"outcome"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"durationMs"`
+	Clusters   []string  `json:"clusters"`
+	Succeeded  []string  `json:"succeeded,omitempty"`
+	// Failed maps each failed cluster to its error
+	Failed map[string]string `json:"failed,omitempty"`
+}
+
+// NewSummary summarizes an audit entry. The outcome is failure when the
+// command or any cluster failed.
+func NewSummary(e audit.Entry) Summary {
+	s := Summary{
+		ID: e.ID, Time: e.Time, User: e.User, Command: e.Command, Args: e.Args,
+		Outcome: config.HookOnSuccess, Error: e.Error, DurationMs: e.DurationMs, Clusters: e.Clusters,
+	}
+	if s.Clusters == nil {
+		s.Clusters = []string{}
+	}
+	for _, r := range e.Results {
+		if r.Status == "ok" {
+			if !slices.Contains(s.Succeeded, r.Cluster) {
+				s.Succeeded = append(s.Succeeded, r.Cluster)
+			}
+			continue
+		}
+		if s.Failed == nil {
+			s.Failed = map[string]string{}
+		}
+		s.Failed[r.Cluster] = r.Error
+	}
+	// A cluster that failed one step did not succeed
+	s.Succeeded = slices.DeleteFunc(s.Succeeded, func(c string) bool { _, failed := s.Failed[c]; return failed })
+	if !e.Succeeded() {
+		s.Outcome = config.HookOnFailure
+	}
+	return s
+}
+
+// Text renders the summary as a short message, e.g.
+// "kubectl multi apply by alice failed on 1 of 3 clusters in 4.2s"
+// followed by a line per failed cluster
+func (s Summary) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "kubectl multi %s by %s ", s.Command, s.User)
+	took := (time.Duration(s.DurationMs) * time.Millisecond).Round(100 * time.Millisecond)
+	switch {
+	case len(s.Failed) > 0:
+		fmt.Fprintf(&b, "failed on %d of %d clusters in %s", len(s.Failed), len(s.Clusters), took)
+	case s.Outcome == config.HookOnFailure:
+		fmt.Fprintf(&b, "failed in %s", took)
+	default:
+		fmt.Fprintf(&b, "succeeded on %d clusters in %s", len(s.Clusters), took)
+	}
+	if s.Error != "" {
+		fmt.Fprintf(&b, ": %s", s.Error)
+	}
+	clusters := make([]string, 0, len(s.Failed))
+	for c := range s.Failed {
+		clusters = append(clusters, c)
+	}
+	slices.Sort(clusters)
+	for _, c := range clusters {
+		fmt.Fprintf(&b, "\n%s: %s", c, s.Failed[c])
+	}
+	return b.String()
+}
+
+// Matches reports whether the hook wants to hear about the summary
+func Matches(h config.Hook, s Summary) bool {
+	if len(h.On) > 0 && !slices.Contains(h.On, s.Outcome) {
+		return false
+	}
+	return len(h.Commands) == 0 || slices.Contains(h.Commands, s.Command)
+}
+
+// Validate checks that the hook has exactly one target and known settings
+func Validate(h config.Hook) error {
+	if (h.URL == "") == (h.Command == "") {
+		return fmt.Errorf("hook %q must set exactly one of url and command", h.Name)
+	}
+	switch h.Format {
+	case "", config.HookFormatJSON, config.HookFormatSlack, config.HookFormatTeams:
+	default:
+		return fmt.Errorf("hook %q has unknown format %q, must be one of json|slack|teams", h.Name, h.Format)
+	}
+	for _, on := range h.On {
+		if on != config.HookOnSuccess && on != config.HookOnFailure {
+			return fmt.Errorf("hook %q has unknown outcome %q in on, must be success or failure", h.Name, on)
+		}
+	}
+	return nil
+}
+
+// Run notifies one hook of the summary
+func Run(ctx context.Context, h config.Hook, s Summary) error {
+	if err := Validate(h); err != nil {
+		return err
+	}
+	timeout := h.Timeout.Duration
+	if timeout <= 0 {
+		timeout = config.DefaultHookTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if h.URL != "" {
+		if err := network.CheckOnline("posting to a webhook"); err != nil {
+			return fmt.Errorf("hook %q: %v", h.Name, err)
+		}
+		return post(ctx, h, s)
+	}
+	return runCommand(ctx, h, s)
+}
+
+// post sends the summary, or a chat message made of it, to the webhook
+func post(ctx context.Context, h config.Hook, s Summary) error {
+	var payload interface{} = s
+	switch h.Format {
+	case config.HookFormatSlack:
+		payload = map[string]string{"text": s.Text()}
+	case config.HookFormatTeams:
+		payload = map[string]string{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"summary":    strings.SplitN(s.Text(), "\n", 2)[0],
+			"themeColor": map[string]string{config.HookOnSuccess: "2EB886", config.HookOnFailure: "D00000"}[s.Outcome],
+			// Teams renders the text as Markdown, where a line break needs two spaces
+			"text": strings.ReplaceAll(s.Text(), "\n", "  \n"),
+		}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode hook %q payload: %v", h.Name, err)
+	}
+	// Webhook URLs often embed a token, so errors only show the host
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("hook %q: invalid url: %v", h.Name, withoutURL(err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range h.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("hook %q: %s: %v", h.Name, req.URL.Host, withoutURL(err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("hook %q: %s returned %s", h.Name, req.URL.Host, resp.Status)
+	}
+	return nil
+}
+
+// withoutURL returns the cause of a *url.Error, whose message repeats the
+// full URL
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
+
+// runCommand runs the hook command with the path of a file holding the JSON
+// summary as its argument, and the outcome in its environment
+func runCommand(ctx context.Context, h config.Hook, s Summary) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode hook %q summary: %v", h.Name, err)
+	}
+	f, err := platform.CreateTemp("kubectl-multi-hook-*.json")
+	if err != nil {
+		return fmt.Errorf("hook %q: %v", h.Name, err)
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("hook %q: %v", h.Name, err)
+	}
+
+	cmd := platform.ShellCommand(h.Command, f.Name())
+	cmd.Env = append(os.Environ(),
+		"KUBECTL_MULTI_OPERATION_ID="+s.ID,
+		"KUBECTL_MULTI_COMMAND="+s.Command,
+		"KUBECTL_MULTI_OUTCOME="+s.Outcome,
+	)
+	done := make(chan error, 1)
+	var out bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &out
+	// Children of a killed shell may hold its output open
+	cmd.WaitDelay = time.Second
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("hook %q: %v", h.Name, err)
+	}
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		cmd.Process.Kill()
+		<-done
+		err = ctx.Err()
+	}
+	if err != nil {
+		msg := strings.TrimSpace(out.String())
+		if msg != "" {
+			return fmt.Errorf("hook %q: %v: %s", h.Name, err, msg)
+		}
+		return fmt.Errorf("hook %q: %v", h.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kubectl-multi/pkg/audit"
+	"kubectl-multi/pkg/config"
+	"kubectl-multi/pkg/network"
+)
+
+func testEntry() audit.Entry {
+	return audit.Entry{
+		ID: "20261017-100000-1", Time: time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC), User: "alice",
+		Command: "apply", Args: []string{"apply", "-f", "app.yaml"}, Clusters: []string{"cluster1", "cluster2", "cluster3"},
+		Results: []audit.ClusterResult{
+			{Cluster: "cluster1", Status: "ok"},
+			{Cluster: "cluster2", Status: "error", Error: "forbidden"},
+			{Cluster: "cluster3", Status: "ok"},
+		},
+		DurationMs: 4230,
+	}
+}
+
+func TestNewSummary(t *testing.T) {
+	s := NewSummary(testEntry())
+	if s.Outcome != config.HookOnFailure || !reflect.DeepEqual(s.Succeeded, []string{"cluster1", "cluster3"}) || !reflect.DeepEqual(s.Failed, map[string]string{"cluster2": "forbidden"}) {
+		t.Errorf("NewSummary() = %+v", s)
+	}
+	want := "kubectl multi apply by alice failed on 1 of 3 clusters in 4.2s\ncluster2: forbidden"
+	if got := s.Text(); got != want {
+		t.Errorf("Text() = %q, want %q", got, want)
+	}
+
+	ok := testEntry()
+	ok.Results[1] = audit.ClusterResult{Cluster: "cluster2", Status: "ok"}
+	if s := NewSummary(ok); s.Outcome != config.HookOnSuccess || s.Text() != "kubectl multi apply by alice succeeded on 3 clusters in 4.2s" {
+		t.Errorf("NewSummary() of a success = %+v, %q", s, s.Text())
+	}
+}
+
+func TestMatches(t *testing.T) {
+	s := NewSummary(testEntry())
+	tests := []struct {
+		hook config.Hook
+		want bool
+	}{
+		{hook: config.Hook{}, want: true},
+		{hook: config.Hook{On: []string{config.HookOnFailure}}, want: true},
+		{hook: config.Hook{On: []string{config.HookOnSuccess}}, want: false},
+		{hook: config.Hook{Commands: []string{"apply", "delete"}}, want: true},
+		{hook: config.Hook{Commands: []string{"delete"}}, want: false},
+	}
+	for _, tt := range tests {
+		if got := Matches(tt.hook, s); got != tt.want {
+			t.Errorf("Matches(%+v) = %t, want %t", tt.hook, got, tt.want)
+		}
+	}
+}
+
+func TestRunWebhook(t *testing.T) {
+	var body map[string]interface{}
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &body)
+	}))
+	defer server.Close()
+	s := NewSummary(testEntry())
+
+	if err := Run(context.Background(), config.Hook{Name: "slack", URL: server.URL, Format: config.HookFormatSlack}, s); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if body["text"] != s.Text() {
+		t.Errorf("slack body = %v, want the summary text", body)
+	}
+
+	hook := config.Hook{Name: "generic", URL: server.URL, Headers: map[string]string{"Authorization": "Bearer t0ken"}}
+	if err := Run(context.Background(), hook, s); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if body["id"] != "20261017-100000-1" || body["outcome"] != "failure" || auth != "Bearer t0ken" {
+		t.Errorf("json body = %v, Authorization %q", body, auth)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer failing.Close()
+	if err := Run(context.Background(), config.Hook{Name: "teams", URL: failing.URL, Format: config.HookFormatTeams}, s); err == nil {
+		t.Error("Run() against a failing webhook succeeded")
+	}
+
+	body = nil
+	network.Configure(nil, true)
+	defer network.Configure(nil, false)
+	if err := Run(context.Background(), hook, s); err == nil || !strings.Contains(err.Error(), "offline mode") {
+		t.Errorf("Run() offline error = %v, want offline mode", err)
+	}
+	if body != nil {
+		t.Errorf("offline webhook received %v", body)
+	}
+}
+
+func TestRunWebhookHidesToken(t *testing.T) {
+	// Nothing listens on the address any more, so the POST is refused
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	const token = "s3cr3tT0ken"
+	s := NewSummary(testEntry())
+	for name, hook := range map[string]config.Hook{
+		"refused": {Name: "slack", URL: "http://" + addr + "/services/T000/B000/" + token},
+		"invalid": {Name: "slack", URL: "http://" + addr + "/services/" + token + "\x7f"},
+	} {
+		err := Run(context.Background(), hook, s)
+		if err == nil || strings.Contains(err.Error(), token) {
+			t.Errorf("Run() %s error = %v, want an error without the token", name, err)
+		}
+	}
+	err = Run(context.Background(), config.Hook{Name: "slack", URL: "http://" + addr + "/" + token}, s)
+	if err == nil || !strings.Contains(err.Error(), addr) {
+		t.Errorf("Run() error = %v, want the host %s", err, addr)
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test hook is a POSIX shell command")
+	}
+	out := filepath.Join(t.TempDir(), "out")
+	hook := config.Hook{Name: "script", Command: `f() { echo "$KUBECTL_MULTI_OUTCOME" > ` + out + `; cat "$1" >> ` + out + `; }; f`}
+	if err := Run(context.Background(), hook, NewSummary(testEntry())); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outcome, summary, _ := strings.Cut(string(data), "\n")
+	var got Summary
+	if outcome != "failure" || json.Unmarshal([]byte(summary), &got) != nil || got.Command != "apply" {
+		t.Errorf("hook saw %q", data)
+	}
+
+	slow := config.Hook{Name: "slow", Command: "sleep 5; true", Timeout: metav1.Duration{Duration: 100 * time.Millisecond}}
+	if err := Run(context.Background(), slow, NewSummary(testEntry())); err == nil {
+		t.Error("Run() of a hook exceeding its timeout succeeded")
+	}
+
+	bad := config.Hook{Name: "both", URL: "http://example.com", Command: "true"}
+	if err := Run(context.Background(), bad, NewSummary(testEntry())); err == nil {
+		t.Error("Run() of a hook with both url and command succeeded")
+	}
+}
@@ -0,0 +1,73 @@
+package kubestellar
+
+import (
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	// ManagedClusterAddOnGVR identifies the OCM add-on objects an ITS keeps,
+	// in each cluster's namespace, with the health the add-on agent reports
+	ManagedClusterAddOnGVR = schema.GroupVersionResource{Group: "addon.open-cluster-management.io", Version: "v1alpha1", Resource: "managedclusteraddons"}
+
+	// KlusterletGVR identifies the Klusterlet object the OCM operator keeps in
+	// each managed cluster for the agents that connect it to the ITS
+	KlusterletGVR = schema.GroupVersionResource{Group: "operator.open-cluster-management.io", Version: "v1", Resource: "klusterlets"}
+)
+
+// ObjectConditions returns the status conditions of an object
+func ObjectConditions(obj *unstructured.Unstructured) []Condition {
+	var conds []Condition
+	items, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		cond := Condition{}
+		cond.Type, _ = m["type"].(string)
+		cond.Status, _ = m["status"].(string)
+		cond.Reason, _ = m["reason"].(string)
+		cond.Message, _ = m["message"].(string)
+		conds = append(conds, cond)
+	}
+	return conds
+}
+
+// ConditionStatus returns the status of the condition of the given type:
+// "True", "False", or "Unknown" when the object does not report it
+func ConditionStatus(conds []Condition, condType string) string {
+	for _, c := range conds {
+		if c.Type == condType && c.Status != "" {
+			return c.Status
+		}
+	}
+	return "Unknown"
+}
+
+// DegradedConditions lists, sorted, the true conditions of an object whose
+// type ends in Degraded, as reported by the agents of a Klusterlet such as
+// HubConnectionDegraded or KlusterletWorkDegraded
+func DegradedConditions(conds []Condition) []string {
+	var degraded []string
+	for _, c := range conds {
+		if strings.HasSuffix(c.Type, "Degraded") && c.Status == "True" {
+			degraded = append(degraded, c.Type)
+		}
+	}
+	sort.Strings(degraded)
+	return degraded
+}
+
+// KlusterletMode returns how the agents of a Klusterlet are deployed,
+// "Default" when the spec does not say
+func KlusterletMode(klusterlet *unstructured.Unstructured) string {
+	mode, _, _ := unstructured.NestedString(klusterlet.Object, "spec", "deployOption", "mode")
+	if mode == "" {
+		return "Default"
+	}
+	return mode
+}
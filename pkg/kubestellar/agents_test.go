@@ -0,0 +1,36 @@
+package kubestellar
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestAgentConditions(t *testing.T) {
+	klusterlet := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"deployOption": map[string]interface{}{"mode": "Hosted"}},
+		"status": map[string]interface{}{"conditions": []interface{}{
+			map[string]interface{}{"type": "Available", "status": "True"},
+			map[string]interface{}{"type": "KlusterletWorkDegraded", "status": "True", "reason": "WorkFunctional"},
+			map[string]interface{}{"type": "HubConnectionDegraded", "status": "True"},
+			map[string]interface{}{"type": "KlusterletRegistrationDegraded", "status": "False"},
+		}},
+	}}
+	conds := ObjectConditions(klusterlet)
+	if got := ConditionStatus(conds, "Available"); got != "True" {
+		t.Errorf("ConditionStatus(Available) = %s, want True", got)
+	}
+	if got := ConditionStatus(conds, "Progressing"); got != "Unknown" {
+		t.Errorf("ConditionStatus(Progressing) = %s, want Unknown", got)
+	}
+	if got, want := strings.Join(DegradedConditions(conds), ","), "HubConnectionDegraded,KlusterletWorkDegraded"; got != want {
+		t.Errorf("DegradedConditions() = %s, want %s", got, want)
+	}
+	if got := KlusterletMode(klusterlet); got != "Hosted" {
+		t.Errorf("KlusterletMode() = %s, want Hosted", got)
+	}
+	if got := KlusterletMode(&unstructured.Unstructured{Object: map[string]interface{}{}}); got != "Default" {
+		t.Errorf("KlusterletMode() of an empty spec = %s, want Default", got)
+	}
+}
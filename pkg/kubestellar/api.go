@@ -0,0 +1,53 @@
+package kubestellar
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ControlGroup is the API group of the KubeStellar control objects
+const ControlGroup = "control.kubestellar.io"
+
+var (
+	// BindingPolicyGVR identifies BindingPolicy objects in a WDS
+	BindingPolicyGVR = schema.GroupVersionResource{Group: ControlGroup, Version: "v1alpha1", Resource: "bindingpolicies"}
+
+	// BindingGVR identifies the Binding objects a WDS derives from each BindingPolicy
+	BindingGVR = schema.GroupVersionResource{Group: ControlGroup, Version: "v1alpha1", Resource: "bindings"}
+
+	// WorkStatusGVR identifies the WorkStatus objects an ITS keeps, in each
+	// cluster's namespace, with the status reported for one workload object
+	WorkStatusGVR = schema.GroupVersionResource{Group: ControlGroup, Version: "v1alpha1", Resource: "workstatuses"}
+
+	// CombinedStatusGVR identifies the CombinedStatus objects a WDS derives
+	// from the StatusCollectors of a BindingPolicy
+	CombinedStatusGVR = schema.GroupVersionResource{Group: ControlGroup, Version: "v1alpha1", Resource: "combinedstatuses"}
+
+	// CustomTransformGVR identifies the CustomTransform objects of a WDS,
+	// which remove fields of a resource before it is propagated
+	CustomTransformGVR = schema.GroupVersionResource{Group: ControlGroup, Version: "v1alpha1", Resource: "customtransforms"}
+
+	// StatusCollectorGVR identifies the StatusCollector objects of a WDS that
+	// BindingPolicies name to combine the status of their workload objects
+	StatusCollectorGVR = schema.GroupVersionResource{Group: ControlGroup, Version: "v1alpha1", Resource: "statuscollectors"}
+)
+
+// ObjectRef identifies a single workload object by resource and name
+type ObjectRef struct {
+	Group     string
+	Version   string
+	Resource  string
+	Namespace string
+	Name      string
+}
+
+// String renders the reference the way kubectl names objects
+func (r ObjectRef) String() string {
+	resource := r.Resource
+	if r.Group != "" {
+		resource += "." + r.Group
+	}
+	if r.Namespace != "" {
+		return resource + " " + r.Namespace + "/" + r.Name
+	}
+	return resource + " " + r.Name
+}
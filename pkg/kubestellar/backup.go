@@ -0,0 +1,220 @@
+package kubestellar
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+
+	"kubectl-multi/pkg/util"
+)
+
+// BackupVersion is the layout version of the backup archives written by
+// WriteBackup
+const BackupVersion = 1
+
+// maxBackupFileSize bounds each file read from a backup archive
+const maxBackupFileSize = 64 << 20
+
+// BackupResources are the control objects of a WDS a backup holds, in the
+// order they are restored: StatusCollectors and CustomTransforms before the
+// BindingPolicies naming them
+var BackupResources = []schema.GroupVersionResource{StatusCollectorGVR, CustomTransformGVR, BindingPolicyGVR}
+
+// BackupManifest describes a backup archive
+type BackupManifest struct {
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+	// WDS are the contexts of the WDSes backed up
+	WDS []string `json:"wds,omitempty"`
+	// ITS are the contexts of the ITSes whose ManagedCluster labels are
+	// backed up
+	ITS []string `json:"its,omitempty"`
+}
+
+// Backup is the configuration of a KubeStellar control plane
+type Backup struct {
+	BackupManifest
+	// Objects are the control objects of each WDS, by resource
+	Objects map[string]map[string][]*unstructured.Unstructured
+	// ClusterLabels are the labels of each ManagedCluster of each ITS
+	ClusterLabels map[string]map[string]map[string]string
+}
+
+// NewBackup returns an empty backup created at now
+func NewBackup(now time.Time) *Backup {
+	return &Backup{
+		BackupManifest: BackupManifest{Version: BackupVersion, Created: now.UTC()},
+		Objects:        map[string]map[string][]*unstructured.Unstructured{},
+		ClusterLabels:  map[string]map[string]map[string]string{},
+	}
+}
+
+// AddObjects adds the objects of one resource of a WDS
+func (b *Backup) AddObjects(wds string, gvr schema.GroupVersionResource, objs []*unstructured.Unstructured) {
+	if b.Objects[wds] == nil {
+		b.Objects[wds] = map[string][]*unstructured.Unstructured{}
+		b.WDS = append(b.WDS, wds)
+	}
+	b.Objects[wds][gvr.Resource] = append(b.Objects[wds][gvr.Resource], objs...)
+}
+
+// AddClusterLabels adds the labels of the ManagedClusters of an ITS
+func (b *Backup) AddClusterLabels(its string, labels map[string]map[string]string) {
+	if b.ClusterLabels[its] == nil {
+		b.ClusterLabels[its] = map[string]map[string]string{}
+		b.ITS = append(b.ITS, its)
+	}
+	for name, l := range labels {
+		b.ClusterLabels[its][name] = l
+	}
+}
+
+// Archive layout: manifest.yaml, then wds/CONTEXT/RESOURCE.yaml holding the
+// objects of one resource as a multi-document stream, and
+// its/CONTEXT/managedcluster-labels.yaml mapping each cluster to its labels.
+// Context names may contain slashes, so files are found by their prefix and
+// base name.
+const (
+	backupManifestFile     = "manifest.yaml"
+	backupWDSDir           = "wds/"
+	backupITSDir           = "its/"
+	backupClusterLabelFile = "managedcluster-labels.yaml"
+)
+
+// WriteBackup writes the backup as a gzipped tar archive
+func WriteBackup(w io.Writer, b *Backup) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	add := func(name string, data []byte) error {
+		hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: b.Created, Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return fmt.Errorf("failed to write %s: %v", name, err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			return fmt.Errorf("failed to write %s: %v", name, err)
+		}
+		return nil
+	}
+
+	manifest, err := yaml.Marshal(b.BackupManifest)
+	if err != nil {
+		return err
+	}
+	if err := add(backupManifestFile, manifest); err != nil {
+		return err
+	}
+	for _, wds := range b.WDS {
+		resources := make([]string, 0, len(b.Objects[wds]))
+		for resource := range b.Objects[wds] {
+			resources = append(resources, resource)
+		}
+		sort.Strings(resources)
+		for _, resource := range resources {
+			data, err := util.EncodeManifests(b.Objects[wds][resource])
+			if err != nil {
+				return err
+			}
+			if err := add(backupWDSDir+wds+"/"+resource+".yaml", data); err != nil {
+				return err
+			}
+		}
+	}
+	for _, its := range b.ITS {
+		data, err := yaml.Marshal(b.ClusterLabels[its])
+		if err != nil {
+			return err
+		}
+		if err := add(backupITSDir+its+"/"+backupClusterLabelFile, data); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %v", err)
+	}
+	return gz.Close()
+}
+
+// ReadBackup reads a backup archive written by WriteBackup
+func ReadBackup(r io.Reader) (*Backup, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a backup archive: %v", err)
+	}
+	defer gz.Close()
+
+	b := NewBackup(time.Time{})
+	b.Version = 0
+	objects := map[string]map[string][]*unstructured.Unstructured{}
+	labels := map[string]map[string]map[string]string{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %v", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, maxBackupFileSize))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", hdr.Name, err)
+		}
+		name := path.Clean(hdr.Name)
+		dir, file := path.Split(name)
+		dir = strings.TrimSuffix(dir, "/")
+		switch {
+		case name == backupManifestFile:
+			if err := yaml.UnmarshalStrict(data, &b.BackupManifest); err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %v", name, err)
+			}
+		case strings.HasPrefix(dir, backupWDSDir) && path.Ext(file) == ".yaml":
+			wds, resource := strings.TrimPrefix(dir, backupWDSDir), strings.TrimSuffix(file, ".yaml")
+			objs, err := util.DecodeManifests(data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode %s: %v", name, err)
+			}
+			if objects[wds] == nil {
+				objects[wds] = map[string][]*unstructured.Unstructured{}
+			}
+			objects[wds][resource] = objs
+		case strings.HasPrefix(dir, backupITSDir) && file == backupClusterLabelFile:
+			var l map[string]map[string]string
+			if err := yaml.UnmarshalStrict(data, &l); err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %v", name, err)
+			}
+			labels[strings.TrimPrefix(dir, backupITSDir)] = l
+		}
+	}
+
+	if b.Version == 0 {
+		return nil, fmt.Errorf("not a backup archive: no %s", backupManifestFile)
+	}
+	if b.Version > BackupVersion {
+		return nil, fmt.Errorf("backup version %d is newer than the supported version %d", b.Version, BackupVersion)
+	}
+	for _, wds := range b.WDS {
+		if objects[wds] == nil {
+			objects[wds] = map[string][]*unstructured.Unstructured{}
+		}
+	}
+	for _, its := range b.ITS {
+		if labels[its] == nil {
+			labels[its] = map[string]map[string]string{}
+		}
+	}
+	b.Objects, b.ClusterLabels = objects, labels
+	return b, nil
+}
@@ -0,0 +1,86 @@
+package kubestellar
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestBackupRoundTrip(t *testing.T) {
+	policy := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "control.kubestellar.io/v1alpha1",
+		"kind":       "BindingPolicy",
+		"metadata":   map[string]interface{}{"name": "web"},
+		"spec":       map[string]interface{}{"wantSingletonReportedState": true},
+	}}
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	b := NewBackup(created)
+	// Context names of cloud providers contain slashes
+	b.AddObjects("arn:aws:eks:eu-west-1:1:cluster/wds1", BindingPolicyGVR, []*unstructured.Unstructured{policy})
+	b.AddObjects("arn:aws:eks:eu-west-1:1:cluster/wds1", CustomTransformGVR, nil)
+	b.AddClusterLabels("its1", map[string]map[string]string{"cluster1": {"location": "edge"}, "cluster2": nil})
+
+	var buf bytes.Buffer
+	if err := WriteBackup(&buf, b); err != nil {
+		t.Fatalf("WriteBackup() error = %v", err)
+	}
+	got, err := ReadBackup(&buf)
+	if err != nil {
+		t.Fatalf("ReadBackup() error = %v", err)
+	}
+	if got.Version != BackupVersion || !got.Created.Equal(created) {
+		t.Errorf("manifest = %+v", got.BackupManifest)
+	}
+	if !reflect.DeepEqual(got.WDS, []string{"arn:aws:eks:eu-west-1:1:cluster/wds1"}) || !reflect.DeepEqual(got.ITS, []string{"its1"}) {
+		t.Errorf("contexts = %v, %v", got.WDS, got.ITS)
+	}
+	policies := got.Objects["arn:aws:eks:eu-west-1:1:cluster/wds1"]["bindingpolicies"]
+	if len(policies) != 1 || !reflect.DeepEqual(policies[0].Object, policy.Object) {
+		t.Errorf("policies = %v, want %v", policies, policy)
+	}
+	if transforms := got.Objects["arn:aws:eks:eu-west-1:1:cluster/wds1"]["customtransforms"]; len(transforms) != 0 {
+		t.Errorf("customtransforms = %v, want none", transforms)
+	}
+	if want := map[string]map[string]string{"cluster1": {"location": "edge"}, "cluster2": nil}; !reflect.DeepEqual(got.ClusterLabels["its1"], want) {
+		t.Errorf("cluster labels = %v, want %v", got.ClusterLabels["its1"], want)
+	}
+}
+
+func TestReadBackupErrors(t *testing.T) {
+	archive := func(files map[string]string) *bytes.Buffer {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gz)
+		for name, data := range files {
+			tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), Typeflag: tar.TypeReg})
+			tw.Write([]byte(data))
+		}
+		tw.Close()
+		gz.Close()
+		return &buf
+	}
+	tests := []struct {
+		name    string
+		data    *bytes.Buffer
+		wantErr string
+	}{
+		{name: "not gzip", data: bytes.NewBufferString("apiVersion: v1"), wantErr: "not a backup archive"},
+		{name: "no manifest", data: archive(map[string]string{"wds/wds1/bindingpolicies.yaml": ""}), wantErr: "no manifest.yaml"},
+		{name: "newer version", data: archive(map[string]string{"manifest.yaml": "version: 2\n"}), wantErr: "backup version 2 is newer"},
+		{name: "unknown manifest field", data: archive(map[string]string{"manifest.yaml": "version: 1\nclusters: []\n"}), wantErr: "failed to parse manifest.yaml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadBackup(tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ReadBackup() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
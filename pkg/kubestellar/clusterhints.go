@@ -0,0 +1,101 @@
+package kubestellar
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	// ManagedClusterSetGVR identifies the OCM ManagedClusterSets of an ITS,
+	// which group ManagedClusters for placement
+	ManagedClusterSetGVR = schema.GroupVersionResource{Group: "cluster.open-cluster-management.io", Version: "v1beta2", Resource: "managedclustersets"}
+
+	// ClusterClaimGVR identifies the ClusterClaims kept in a managed cluster,
+	// which the klusterlet reports in the status of its ManagedCluster
+	ClusterClaimGVR = schema.GroupVersionResource{Group: "cluster.open-cluster-management.io", Version: "v1alpha1", Resource: "clusterclaims"}
+)
+
+// ClusterSetLabel is the label of a ManagedCluster naming the
+// ManagedClusterSet it belongs to
+const ClusterSetLabel = "cluster.open-cluster-management.io/clusterset"
+
+// Effects of a ManagedCluster taint on placement
+const (
+	TaintNoSelect       = "NoSelect"
+	TaintPreferNoSelect = "PreferNoSelect"
+	TaintNoSelectIfNew  = "NoSelectIfNew"
+)
+
+// TaintEffects lists the valid taint effects
+var TaintEffects = []string{TaintNoSelect, TaintPreferNoSelect, TaintNoSelectIfNew}
+
+// ClusterTaint is a taint of a ManagedCluster
+type ClusterTaint struct {
+	Key    string `json:"key"`
+	Value  string `json:"value,omitempty"`
+	Effect string `json:"effect"`
+	// TimeAdded is set by the hub when the taint is added
+	TimeAdded string `json:"timeAdded,omitempty"`
+}
+
+// String formats the taint the way kubectl does, key=value:Effect
+func (t ClusterTaint) String() string {
+	s := t.Key
+	if t.Value != "" {
+		s += "=" + t.Value
+	}
+	return s + ":" + t.Effect
+}
+
+// ClusterClaim is a claim a managed cluster reports about itself, such as
+// its platform or region
+type ClusterClaim struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// ManagedClusterTaints returns the taints in the spec of a ManagedCluster
+func ManagedClusterTaints(mc *unstructured.Unstructured) []ClusterTaint {
+	var taints []ClusterTaint
+	items, _, _ := unstructured.NestedSlice(mc.Object, "spec", "taints")
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		t := ClusterTaint{}
+		t.Key, _ = m["key"].(string)
+		t.Value, _ = m["value"].(string)
+		t.Effect, _ = m["effect"].(string)
+		t.TimeAdded, _ = m["timeAdded"].(string)
+		taints = append(taints, t)
+	}
+	return taints
+}
+
+// ManagedClusterClaims returns the claims reported in the status of a
+// ManagedCluster, sorted by name
+func ManagedClusterClaims(mc *unstructured.Unstructured) []ClusterClaim {
+	var claims []ClusterClaim
+	items, _, _ := unstructured.NestedSlice(mc.Object, "status", "clusterClaims")
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		c := ClusterClaim{}
+		c.Name, _ = m["name"].(string)
+		c.Value, _ = m["value"].(string)
+		claims = append(claims, c)
+	}
+	sort.Slice(claims, func(i, j int) bool { return claims[i].Name < claims[j].Name })
+	return claims
+}
+
+// ManagedClusterSet returns the ManagedClusterSet a ManagedCluster belongs
+// to, or "" when it is in none
+func ManagedClusterSet(mc *unstructured.Unstructured) string {
+	return mc.GetLabels()[ClusterSetLabel]
+}
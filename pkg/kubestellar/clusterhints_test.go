@@ -0,0 +1,53 @@
+package kubestellar
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestManagedClusterHints(t *testing.T) {
+	mc := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":   "cluster1",
+			"labels": map[string]interface{}{ClusterSetLabel: "prod"},
+		},
+		"spec": map[string]interface{}{"taints": []interface{}{
+			map[string]interface{}{"key": "maintenance", "effect": "NoSelectIfNew", "timeAdded": "2024-05-01T10:00:00Z"},
+			map[string]interface{}{"key": "dedicated", "value": "gpu", "effect": "NoSelect"},
+		}},
+		"status": map[string]interface{}{"clusterClaims": []interface{}{
+			map[string]interface{}{"name": "region.open-cluster-management.io", "value": "eu-west"},
+			map[string]interface{}{"name": "platform.open-cluster-management.io", "value": "AWS"},
+		}},
+	}}
+
+	taints := ManagedClusterTaints(mc)
+	want := []ClusterTaint{
+		{Key: "maintenance", Effect: TaintNoSelectIfNew, TimeAdded: "2024-05-01T10:00:00Z"},
+		{Key: "dedicated", Value: "gpu", Effect: TaintNoSelect},
+	}
+	if !reflect.DeepEqual(taints, want) {
+		t.Errorf("ManagedClusterTaints() = %+v, want %+v", taints, want)
+	}
+	if got := taints[0].String() + " " + taints[1].String(); got != "maintenance:NoSelectIfNew dedicated=gpu:NoSelect" {
+		t.Errorf("taint strings = %q", got)
+	}
+
+	claims := ManagedClusterClaims(mc)
+	wantClaims := []ClusterClaim{
+		{Name: "platform.open-cluster-management.io", Value: "AWS"},
+		{Name: "region.open-cluster-management.io", Value: "eu-west"},
+	}
+	if !reflect.DeepEqual(claims, wantClaims) {
+		t.Errorf("ManagedClusterClaims() = %+v, want %+v", claims, wantClaims)
+	}
+
+	if got := ManagedClusterSet(mc); got != "prod" {
+		t.Errorf("ManagedClusterSet() = %q, want prod", got)
+	}
+	if got := ManagedClusterSet(&unstructured.Unstructured{Object: map[string]interface{}{}}); got != "" {
+		t.Errorf("ManagedClusterSet() of an unlabeled cluster = %q", got)
+	}
+}
@@ -0,0 +1,219 @@
+package kubestellar
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// The downsync clauses are edited as unstructured maps rather than through
+// DownsyncClause, so fields the plugin does not model, such as
+// statusCollectors, survive the round trip.
+
+// AddDownsyncResources makes the downsync clauses of policy cover resources.
+// Each distinct set of namespace and object selectors among the clauses gets
+// the resource types it lacks, either in its clause for the same API group
+// or in a new clause copying those selectors. Types already covered are left
+// alone, so adding twice changes nothing. It returns the types added.
+func AddDownsyncResources(policy *unstructured.Unstructured, resources []schema.GroupResource) ([]schema.GroupResource, error) {
+	clauses, err := downsyncClauses(policy)
+	if err != nil {
+		return nil, err
+	}
+	if len(clauses) == 0 {
+		return nil, fmt.Errorf("BindingPolicy %s has no downsync clauses to take the object selectors from", policy.GetName())
+	}
+
+	var added []schema.GroupResource
+	for _, gr := range resources {
+		changed := false
+		for _, scope := range clauseScopes(clauses) {
+			if scopeCovers(clauses, scope, gr) {
+				continue
+			}
+			changed = true
+			if i := groupClause(clauses, scope, gr.Group); i >= 0 {
+				res := append(clauseResources(clauses[i]), gr.Resource)
+				sort.Strings(res)
+				clauses[i]["resources"] = toInterfaces(res)
+				continue
+			}
+			clause := runtime.DeepCopyJSON(clauses[scope[0]])
+			clause["apiGroup"] = gr.Group
+			clause["resources"] = []interface{}{gr.Resource}
+			clauses = append(clauses, clause)
+		}
+		if changed {
+			added = append(added, gr)
+		}
+	}
+	return added, setDownsyncClauses(policy, clauses)
+}
+
+// RemoveDownsyncResources takes resources out of the resource lists of the
+// downsync clauses of policy, dropping clauses left without any type. A type
+// matched by a clause without a resource list, or through "*", cannot be
+// removed that way and is an error, as is removing the last type. It returns
+// the types removed.
+func RemoveDownsyncResources(policy *unstructured.Unstructured, resources []schema.GroupResource) ([]schema.GroupResource, error) {
+	clauses, err := downsyncClauses(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []schema.GroupResource
+	for _, gr := range resources {
+		changed := false
+		kept := clauses[:0]
+		for _, clause := range clauses {
+			if !clauseGroupMatches(clause, gr.Group) {
+				kept = append(kept, clause)
+				continue
+			}
+			res := clauseResources(clause)
+			if len(res) == 0 || containsOrWildcard(res, "*") {
+				return nil, fmt.Errorf("a downsync clause of BindingPolicy %s matches every resource type%s, so %s cannot be removed; list the types explicitly first", policy.GetName(), groupSuffix(clause), gr)
+			}
+			var rest []string
+			for _, r := range res {
+				if r != gr.Resource {
+					rest = append(rest, r)
+				}
+			}
+			if len(rest) == len(res) {
+				kept = append(kept, clause)
+				continue
+			}
+			changed = true
+			if len(rest) > 0 {
+				clause["resources"] = toInterfaces(rest)
+				kept = append(kept, clause)
+			}
+		}
+		clauses = kept
+		if changed {
+			removed = append(removed, gr)
+		}
+	}
+	if len(removed) > 0 && len(clauses) == 0 {
+		return nil, fmt.Errorf("removing %s would leave BindingPolicy %s without downsync clauses; delete the policy instead", FormatGroupResources(removed), policy.GetName())
+	}
+	return removed, setDownsyncClauses(policy, clauses)
+}
+
+// downsyncClauses returns deep copies of the downsync clauses of policy
+func downsyncClauses(policy *unstructured.Unstructured) ([]map[string]interface{}, error) {
+	raw, _, err := unstructured.NestedSlice(policy.Object, "spec", "downsync")
+	if err != nil {
+		return nil, fmt.Errorf("invalid downsync clauses in BindingPolicy %s: %v", policy.GetName(), err)
+	}
+	clauses := make([]map[string]interface{}, 0, len(raw))
+	for _, c := range raw {
+		clause, ok := c.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid downsync clause in BindingPolicy %s: %v", policy.GetName(), c)
+		}
+		clauses = append(clauses, clause)
+	}
+	return clauses, nil
+}
+
+func setDownsyncClauses(policy *unstructured.Unstructured, clauses []map[string]interface{}) error {
+	raw := make([]interface{}, 0, len(clauses))
+	for _, clause := range clauses {
+		raw = append(raw, clause)
+	}
+	return unstructured.SetNestedSlice(policy.Object, raw, "spec", "downsync")
+}
+
+// clauseScopes groups the clauses by everything but their API group and
+// resources, returning the clause indexes of each group in order
+func clauseScopes(clauses []map[string]interface{}) [][]int {
+	var keys []string
+	byKey := map[string][]int{}
+	for i, clause := range clauses {
+		scope := map[string]interface{}{}
+		for k, v := range clause {
+			if k != "apiGroup" && k != "resources" {
+				scope[k] = v
+			}
+		}
+		data, _ := json.Marshal(scope)
+		key := string(data)
+		if _, seen := byKey[key]; !seen {
+			keys = append(keys, key)
+		}
+		byKey[key] = append(byKey[key], i)
+	}
+	scopes := make([][]int, 0, len(keys))
+	for _, key := range keys {
+		scopes = append(scopes, byKey[key])
+	}
+	return scopes
+}
+
+// scopeCovers reports whether a clause of scope already matches gr
+func scopeCovers(clauses []map[string]interface{}, scope []int, gr schema.GroupResource) bool {
+	for _, i := range scope {
+		res := clauseResources(clauses[i])
+		if clauseGroupMatches(clauses[i], gr.Group) && (len(res) == 0 || containsOrWildcard(res, gr.Resource)) {
+			return true
+		}
+	}
+	return false
+}
+
+// groupClause returns the clause of scope listing resources of group, or -1
+func groupClause(clauses []map[string]interface{}, scope []int, group string) int {
+	for _, i := range scope {
+		if g, ok := clauses[i]["apiGroup"].(string); ok && g == group && len(clauseResources(clauses[i])) > 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// clauseGroupMatches reports whether the clause selects objects of group; a
+// clause without apiGroup selects every group
+func clauseGroupMatches(clause map[string]interface{}, group string) bool {
+	g, ok := clause["apiGroup"].(string)
+	return !ok || g == group
+}
+
+func clauseResources(clause map[string]interface{}) []string {
+	res, _, _ := unstructured.NestedStringSlice(clause, "resources")
+	return res
+}
+
+func groupSuffix(clause map[string]interface{}) string {
+	if g, ok := clause["apiGroup"].(string); ok {
+		if g == "" {
+			g = "core"
+		}
+		return " of API group " + g
+	}
+	return ""
+}
+
+func toInterfaces(values []string) []interface{} {
+	out := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		out = append(out, v)
+	}
+	return out
+}
+
+// FormatGroupResources joins resource types the way kubectl names them,
+// e.g. "deployments.apps, services"
+func FormatGroupResources(resources []schema.GroupResource) string {
+	names := make([]string, 0, len(resources))
+	for _, gr := range resources {
+		names = append(names, gr.String())
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,224 @@
+package kubestellar
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func testDownsyncPolicy(clauses ...map[string]interface{}) *unstructured.Unstructured {
+	raw := make([]interface{}, 0, len(clauses))
+	for _, c := range clauses {
+		raw = append(raw, c)
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "web"},
+		"spec":     map[string]interface{}{"downsync": raw},
+	}}
+}
+
+func testClause(group interface{}, resources []interface{}, app string) map[string]interface{} {
+	clause := map[string]interface{}{
+		"objectSelectors": []interface{}{map[string]interface{}{"matchLabels": map[string]interface{}{"app": app}}},
+	}
+	if group != nil {
+		clause["apiGroup"] = group
+	}
+	if resources != nil {
+		clause["resources"] = resources
+	}
+	return clause
+}
+
+var (
+	deployments = schema.GroupResource{Group: "apps", Resource: "deployments"}
+	services    = schema.GroupResource{Resource: "services"}
+	configmaps  = schema.GroupResource{Resource: "configmaps"}
+)
+
+func TestAddDownsyncResources(t *testing.T) {
+	collecting := testClause("", []interface{}{"services"}, "web")
+	collecting["statusCollectors"] = []interface{}{"replicas"}
+
+	tests := []struct {
+		name      string
+		clauses   []map[string]interface{}
+		resources []schema.GroupResource
+		want      []map[string]interface{}
+		wantAdded []schema.GroupResource
+		wantErr   string
+	}{
+		{
+			name:      "joins the clause of the same group",
+			clauses:   []map[string]interface{}{testClause("", []interface{}{"services"}, "web")},
+			resources: []schema.GroupResource{configmaps},
+			want:      []map[string]interface{}{testClause("", []interface{}{"configmaps", "services"}, "web")},
+			wantAdded: []schema.GroupResource{configmaps},
+		},
+		{
+			name:      "new group copies the selectors",
+			clauses:   []map[string]interface{}{testClause("", []interface{}{"services"}, "web")},
+			resources: []schema.GroupResource{deployments},
+			want: []map[string]interface{}{
+				testClause("", []interface{}{"services"}, "web"),
+				testClause("apps", []interface{}{"deployments"}, "web"),
+			},
+			wantAdded: []schema.GroupResource{deployments},
+		},
+		{
+			name:      "already listed",
+			clauses:   []map[string]interface{}{testClause("", []interface{}{"services"}, "web")},
+			resources: []schema.GroupResource{services},
+			want:      []map[string]interface{}{testClause("", []interface{}{"services"}, "web")},
+		},
+		{
+			name:      "clause without resources covers every type",
+			clauses:   []map[string]interface{}{testClause(nil, nil, "web")},
+			resources: []schema.GroupResource{deployments, services},
+			want:      []map[string]interface{}{testClause(nil, nil, "web")},
+		},
+		{
+			name:      "wildcard covers the group",
+			clauses:   []map[string]interface{}{testClause("apps", []interface{}{"*"}, "web")},
+			resources: []schema.GroupResource{deployments},
+			want:      []map[string]interface{}{testClause("apps", []interface{}{"*"}, "web")},
+		},
+		{
+			name: "every selector set gets the type",
+			clauses: []map[string]interface{}{
+				testClause("", []interface{}{"services"}, "web"),
+				testClause("", []interface{}{"services", "configmaps"}, "db"),
+				testClause("apps", []interface{}{"statefulsets"}, "db"),
+			},
+			resources: []schema.GroupResource{configmaps, deployments},
+			want: []map[string]interface{}{
+				testClause("", []interface{}{"configmaps", "services"}, "web"),
+				testClause("", []interface{}{"services", "configmaps"}, "db"),
+				testClause("apps", []interface{}{"deployments", "statefulsets"}, "db"),
+				testClause("apps", []interface{}{"deployments"}, "web"),
+			},
+			wantAdded: []schema.GroupResource{configmaps, deployments},
+		},
+		{
+			name:      "keeps fields it does not model",
+			clauses:   []map[string]interface{}{collecting},
+			resources: []schema.GroupResource{configmaps},
+			want: []map[string]interface{}{func() map[string]interface{} {
+				c := testClause("", []interface{}{"configmaps", "services"}, "web")
+				c["statusCollectors"] = []interface{}{"replicas"}
+				return c
+			}()},
+			wantAdded: []schema.GroupResource{configmaps},
+		},
+		{
+			name:      "no clauses",
+			resources: []schema.GroupResource{services},
+			wantErr:   "has no downsync clauses",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := testDownsyncPolicy(tt.clauses...)
+			added, err := AddDownsyncResources(policy, tt.resources)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("AddDownsyncResources() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AddDownsyncResources() error = %v", err)
+			}
+			if !reflect.DeepEqual(added, tt.wantAdded) {
+				t.Errorf("added = %v, want %v", added, tt.wantAdded)
+			}
+			if got := testDownsyncPolicy(tt.want...); !reflect.DeepEqual(policy.Object["spec"], got.Object["spec"]) {
+				t.Errorf("downsync = %v, want %v", policy.Object["spec"], got.Object["spec"])
+			}
+		})
+	}
+}
+
+func TestRemoveDownsyncResources(t *testing.T) {
+	tests := []struct {
+		name        string
+		clauses     []map[string]interface{}
+		resources   []schema.GroupResource
+		want        []map[string]interface{}
+		wantRemoved []schema.GroupResource
+		wantErr     string
+	}{
+		{
+			name:        "from a resource list",
+			clauses:     []map[string]interface{}{testClause("", []interface{}{"configmaps", "services"}, "web")},
+			resources:   []schema.GroupResource{services},
+			want:        []map[string]interface{}{testClause("", []interface{}{"configmaps"}, "web")},
+			wantRemoved: []schema.GroupResource{services},
+		},
+		{
+			name: "drops emptied clauses",
+			clauses: []map[string]interface{}{
+				testClause("", []interface{}{"services"}, "web"),
+				testClause("apps", []interface{}{"deployments"}, "web"),
+				testClause("", []interface{}{"services"}, "db"),
+			},
+			resources:   []schema.GroupResource{services},
+			want:        []map[string]interface{}{testClause("apps", []interface{}{"deployments"}, "web")},
+			wantRemoved: []schema.GroupResource{services},
+		},
+		{
+			name:      "not listed",
+			clauses:   []map[string]interface{}{testClause("", []interface{}{"services"}, "web")},
+			resources: []schema.GroupResource{configmaps, deployments},
+			want:      []map[string]interface{}{testClause("", []interface{}{"services"}, "web")},
+		},
+		{
+			name:      "same name in another group",
+			clauses:   []map[string]interface{}{testClause("extensions", []interface{}{"deployments"}, "web"), testClause("", []interface{}{"services"}, "web")},
+			resources: []schema.GroupResource{deployments},
+			want:      []map[string]interface{}{testClause("extensions", []interface{}{"deployments"}, "web"), testClause("", []interface{}{"services"}, "web")},
+		},
+		{
+			name:      "clause without resources",
+			clauses:   []map[string]interface{}{testClause(nil, nil, "web")},
+			resources: []schema.GroupResource{services},
+			wantErr:   "matches every resource type, so services cannot be removed",
+		},
+		{
+			name:      "wildcard",
+			clauses:   []map[string]interface{}{testClause("apps", []interface{}{"*"}, "web")},
+			resources: []schema.GroupResource{deployments},
+			wantErr:   "matches every resource type of API group apps",
+		},
+		{
+			name:      "last type",
+			clauses:   []map[string]interface{}{testClause("", []interface{}{"services"}, "web")},
+			resources: []schema.GroupResource{services},
+			wantErr:   "would leave BindingPolicy web without downsync clauses",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := testDownsyncPolicy(tt.clauses...)
+			removed, err := RemoveDownsyncResources(policy, tt.resources)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("RemoveDownsyncResources() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RemoveDownsyncResources() error = %v", err)
+			}
+			if !reflect.DeepEqual(removed, tt.wantRemoved) {
+				t.Errorf("removed = %v, want %v", removed, tt.wantRemoved)
+			}
+			if got := testDownsyncPolicy(tt.want...); !reflect.DeepEqual(policy.Object["spec"], got.Object["spec"]) {
+				t.Errorf("downsync = %v, want %v", policy.Object["spec"], got.Object["spec"])
+			}
+		})
+	}
+}
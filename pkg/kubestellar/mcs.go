@@ -0,0 +1,121 @@
+package kubestellar
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	// ServiceExportGVR identifies the Multi-Cluster Services API object that
+	// exports the Service of the same name and namespace to the ClusterSet
+	ServiceExportGVR = schema.GroupVersionResource{Group: "multicluster.x-k8s.io", Version: "v1alpha1", Resource: "serviceexports"}
+
+	// ServiceImportGVR identifies the object an MCS implementation creates in
+	// the clusters of a ClusterSet for a Service exported by any of them
+	ServiceImportGVR = schema.GroupVersionResource{Group: "multicluster.x-k8s.io", Version: "v1alpha1", Resource: "serviceimports"}
+)
+
+// States of a ServiceExport, from its conditions
+const (
+	ServiceExportReady    = "Ready"
+	ServiceExportPending  = "Pending"
+	ServiceExportInvalid  = "Invalid"
+	ServiceExportConflict = "Conflict"
+)
+
+// ServiceExportState summarizes the conditions of a ServiceExport: Conflict
+// when its Service conflicts with the exports of other clusters, Invalid
+// when it cannot be exported (e.g. no such Service), Ready once the MCS
+// implementation accepted it, and Pending before. Conditions are those of
+// the KEP: Valid and Conflict, and Ready in newer implementations.
+func ServiceExportState(export *unstructured.Unstructured) string {
+	conds := ObjectConditions(export)
+	switch {
+	case ConditionStatus(conds, "Conflict") == "True":
+		return ServiceExportConflict
+	case ConditionStatus(conds, "Valid") == "False":
+		return ServiceExportInvalid
+	case ConditionStatus(conds, "Ready") == "True",
+		ConditionStatus(conds, "Ready") == "Unknown" && ConditionStatus(conds, "Valid") == "True":
+		return ServiceExportReady
+	}
+	return ServiceExportPending
+}
+
+// ServiceExportMessage returns the message of the condition that makes a
+// ServiceExport Conflict or Invalid, if any
+func ServiceExportMessage(export *unstructured.Unstructured) string {
+	want := map[string]string{ServiceExportConflict: "Conflict", ServiceExportInvalid: "Valid"}[ServiceExportState(export)]
+	for _, c := range ObjectConditions(export) {
+		if c.Type == want {
+			if c.Message != "" {
+				return c.Message
+			}
+			return c.Reason
+		}
+	}
+	return ""
+}
+
+// ServiceImportType returns the type of a ServiceImport, ClusterSetIP or
+// Headless
+func ServiceImportType(imp *unstructured.Unstructured) string {
+	t, _, _ := unstructured.NestedString(imp.Object, "spec", "type")
+	return t
+}
+
+// ServiceImportIPs returns the ClusterSet IPs of a ServiceImport
+func ServiceImportIPs(imp *unstructured.Unstructured) []string {
+	ips, _, _ := unstructured.NestedStringSlice(imp.Object, "spec", "ips")
+	return ips
+}
+
+// ServiceImportPorts returns the ports of a ServiceImport as PORT/PROTOCOL,
+// e.g. 80/TCP
+func ServiceImportPorts(imp *unstructured.Unstructured) []string {
+	items, _, _ := unstructured.NestedSlice(imp.Object, "spec", "ports")
+	var ports []string
+	for _, item := range items {
+		p, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		number, _, _ := unstructured.NestedInt64(p, "port")
+		protocol, _, _ := unstructured.NestedString(p, "protocol")
+		if protocol == "" {
+			protocol = "TCP"
+		}
+		ports = append(ports, fmt.Sprintf("%d/%s", number, protocol))
+	}
+	return ports
+}
+
+// ServiceImportClusters returns the clusters exporting the Service, as the
+// MCS implementation lists them in the status of a ServiceImport
+func ServiceImportClusters(imp *unstructured.Unstructured) []string {
+	items, _, _ := unstructured.NestedSlice(imp.Object, "status", "clusters")
+	var clusters []string
+	for _, item := range items {
+		if c, ok := item.(map[string]interface{}); ok {
+			if name, _ := c["cluster"].(string); name != "" {
+				clusters = append(clusters, name)
+			}
+		}
+	}
+	sort.Strings(clusters)
+	return clusters
+}
+
+// ServiceImportSummary renders the type and IPs of a ServiceImport, e.g.
+// "ClusterSetIP 10.96.0.20"
+func ServiceImportSummary(imp *unstructured.Unstructured) string {
+	parts := []string{ServiceImportType(imp)}
+	if ips := ServiceImportIPs(imp); len(ips) > 0 {
+		parts = append(parts, strings.Join(ips, ","))
+	}
+	return strings.TrimSpace(strings.Join(parts, " "))
+}
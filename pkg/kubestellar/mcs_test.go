@@ -0,0 +1,71 @@
+package kubestellar
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestServiceExportState(t *testing.T) {
+	cond := func(condType, status, message string) interface{} {
+		return map[string]interface{}{"type": condType, "status": status, "message": message}
+	}
+	tests := []struct {
+		name        string
+		conditions  []interface{}
+		want        string
+		wantMessage string
+	}{
+		{name: "no status", want: ServiceExportPending},
+		{name: "valid", conditions: []interface{}{cond("Valid", "True", "")}, want: ServiceExportReady},
+		{name: "valid but not ready", conditions: []interface{}{cond("Valid", "True", ""), cond("Ready", "False", "")}, want: ServiceExportPending},
+		{name: "ready", conditions: []interface{}{cond("Ready", "True", "")}, want: ServiceExportReady},
+		{name: "invalid", conditions: []interface{}{cond("Valid", "False", "service not found")}, want: ServiceExportInvalid, wantMessage: "service not found"},
+		{name: "conflict", conditions: []interface{}{cond("Valid", "True", ""), cond("Conflict", "True", "port 80 conflicts")}, want: ServiceExportConflict, wantMessage: "port 80 conflicts"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			export := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			if tt.conditions != nil {
+				unstructured.SetNestedSlice(export.Object, tt.conditions, "status", "conditions")
+			}
+			if got := ServiceExportState(export); got != tt.want {
+				t.Errorf("ServiceExportState() = %s, want %s", got, tt.want)
+			}
+			if got := ServiceExportMessage(export); got != tt.wantMessage {
+				t.Errorf("ServiceExportMessage() = %q, want %q", got, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestServiceImport(t *testing.T) {
+	imp := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"type": "ClusterSetIP",
+			"ips":  []interface{}{"10.96.0.20"},
+			"ports": []interface{}{
+				map[string]interface{}{"port": int64(80), "protocol": "TCP"},
+				map[string]interface{}{"port": int64(53)},
+			},
+		},
+		"status": map[string]interface{}{"clusters": []interface{}{
+			map[string]interface{}{"cluster": "cluster2"},
+			map[string]interface{}{"cluster": "cluster1"},
+		}},
+	}}
+	if got := ServiceImportSummary(imp); got != "ClusterSetIP 10.96.0.20" {
+		t.Errorf("ServiceImportSummary() = %q", got)
+	}
+	if got, want := ServiceImportPorts(imp), []string{"80/TCP", "53/TCP"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ServiceImportPorts() = %q, want %q", got, want)
+	}
+	if got, want := ServiceImportClusters(imp), []string{"cluster1", "cluster2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ServiceImportClusters() = %q, want %q", got, want)
+	}
+	headless := &unstructured.Unstructured{Object: map[string]interface{}{"spec": map[string]interface{}{"type": "Headless"}}}
+	if got := ServiceImportSummary(headless); got != "Headless" {
+		t.Errorf("ServiceImportSummary() of a headless import = %q", got)
+	}
+}
@@ -0,0 +1,46 @@
+package kubestellar
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	// PlacementGVR identifies the OCM Placements of an ITS, which select
+	// ManagedClusters for the teams using the OCM placement APIs directly
+	PlacementGVR = schema.GroupVersionResource{Group: "cluster.open-cluster-management.io", Version: "v1beta1", Resource: "placements"}
+
+	// PlacementDecisionGVR identifies the PlacementDecisions in which the OCM
+	// scheduler lists the clusters a Placement decided on
+	PlacementDecisionGVR = schema.GroupVersionResource{Group: "cluster.open-cluster-management.io", Version: "v1beta1", Resource: "placementdecisions"}
+)
+
+// PlacementLabel is the label of a PlacementDecision naming its Placement,
+// which is in the same namespace
+const PlacementLabel = "cluster.open-cluster-management.io/placement"
+
+// PlacementDecisionClusters returns the clusters the PlacementDecisions of a
+// Placement decided on, sorted and once each; the scheduler splits large
+// decisions over several objects
+func PlacementDecisionClusters(decisions []unstructured.Unstructured) []string {
+	seen := map[string]bool{}
+	var clusters []string
+	for i := range decisions {
+		items, _, _ := unstructured.NestedSlice(decisions[i].Object, "status", "decisions")
+		for _, item := range items {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _ := m["clusterName"].(string)
+			if name != "" && !seen[name] {
+				seen[name] = true
+				clusters = append(clusters, name)
+			}
+		}
+	}
+	sort.Strings(clusters)
+	return clusters
+}
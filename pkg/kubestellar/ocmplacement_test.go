@@ -0,0 +1,25 @@
+package kubestellar
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestPlacementDecisionClusters(t *testing.T) {
+	decision := func(clusters ...string) unstructured.Unstructured {
+		var items []interface{}
+		for _, c := range clusters {
+			items = append(items, map[string]interface{}{"clusterName": c, "reason": ""})
+		}
+		return unstructured.Unstructured{Object: map[string]interface{}{"status": map[string]interface{}{"decisions": items}}}
+	}
+	got := PlacementDecisionClusters([]unstructured.Unstructured{decision("cluster3", "cluster1"), decision("cluster2", "cluster1"), decision()})
+	if want := []string{"cluster1", "cluster2", "cluster3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("PlacementDecisionClusters() = %v, want %v", got, want)
+	}
+	if got := PlacementDecisionClusters(nil); got != nil {
+		t.Errorf("PlacementDecisionClusters(nil) = %v, want none", got)
+	}
+}
@@ -0,0 +1,90 @@
+package kubestellar
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Marks KubeStellar leaves on the workload objects it downsyncs to a WEC
+const (
+	// TransportPrefix starts the labels and annotations of the transport
+	TransportPrefix = "transport.kubestellar.io/"
+
+	// OriginBindingLabel names the Binding an object was delivered for
+	OriginBindingLabel = TransportPrefix + "originOwnerReferenceBindingKey"
+
+	// WorkGroup is the API group of the OCM ManifestWorks that carry
+	// downsynced objects; the work agent makes each delivered object owned by
+	// an AppliedManifestWork of this group
+	WorkGroup = "work.open-cluster-management.io"
+)
+
+// DownsyncOwner describes how an object in a WEC was delivered by KubeStellar
+type DownsyncOwner struct {
+	// Binding is the Binding the object was delivered for, if known
+	Binding string
+	// AppliedManifestWork is the work agent's owner of the object, if any
+	AppliedManifestWork string
+	// Markers are the transport labels and annotations found on the object
+	Markers []string
+}
+
+// String explains the ownership in a single clause
+func (o DownsyncOwner) String() string {
+	var parts []string
+	if o.Binding != "" {
+		parts = append(parts, "Binding "+o.Binding)
+	}
+	if o.AppliedManifestWork != "" {
+		parts = append(parts, "AppliedManifestWork "+o.AppliedManifestWork)
+	}
+	if len(parts) == 0 {
+		parts = append(parts, strings.Join(o.Markers, ", "))
+	}
+	return strings.Join(parts, " via ")
+}
+
+// DownsyncOwnerOf reports whether obj, read from a WEC, is managed by
+// KubeStellar downsync, judged by the transport labels and annotations and by
+// an AppliedManifestWork owner reference
+func DownsyncOwnerOf(obj *unstructured.Unstructured) (DownsyncOwner, bool) {
+	var owner DownsyncOwner
+	for _, marks := range []map[string]string{obj.GetLabels(), obj.GetAnnotations()} {
+		for key, value := range marks {
+			if !strings.HasPrefix(key, TransportPrefix) {
+				continue
+			}
+			owner.Markers = append(owner.Markers, key)
+			if key == OriginBindingLabel && value != "" {
+				owner.Binding = value
+			}
+		}
+	}
+	sort.Strings(owner.Markers)
+
+	for _, ref := range obj.GetOwnerReferences() {
+		gv, err := schema.ParseGroupVersion(ref.APIVersion)
+		if err == nil && gv.Group == WorkGroup && ref.Kind == "AppliedManifestWork" {
+			owner.AppliedManifestWork = ref.Name
+			break
+		}
+	}
+
+	managed := len(owner.Markers) > 0 || owner.AppliedManifestWork != ""
+	return owner, managed
+}
+
+// DownsyncWarning explains that direct changes to a downsynced object revert
+func DownsyncWarning(ref, clusterName string, owner DownsyncOwner) string {
+	return fmt.Sprintf("%s in cluster %s is managed by KubeStellar (%s); direct changes are overwritten by the next downsync, change the object in the WDS instead", ref, clusterName, owner)
+}
+
+// DownsyncDeleteWarning explains that deleting a downsynced object from a WEC
+// does not last
+func DownsyncDeleteWarning(ref, clusterName string, owner DownsyncOwner) string {
+	return fmt.Sprintf("%s in cluster %s was propagated by KubeStellar (%s); the next downsync recreates it, delete it from the WDS or change the BindingPolicy so it no longer selects the object or this cluster", ref, clusterName, owner)
+}
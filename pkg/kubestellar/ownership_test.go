@@ -0,0 +1,75 @@
+package kubestellar
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestDownsyncOwnerOf(t *testing.T) {
+	appliedWork := metav1.OwnerReference{APIVersion: "work.open-cluster-management.io/v1", Kind: "AppliedManifestWork", Name: "a1b2-nginx-bpolicy"}
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		annotations map[string]string
+		owners      []metav1.OwnerReference
+		want        DownsyncOwner
+		wantManaged bool
+		wantString  string
+	}{
+		{
+			name:   "not managed",
+			labels: map[string]string{"app": "nginx"},
+			owners: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "nginx-5d"}},
+		},
+		{
+			name:        "binding label and applied manifest work",
+			labels:      map[string]string{OriginBindingLabel: "nginx-bpolicy"},
+			owners:      []metav1.OwnerReference{appliedWork},
+			want:        DownsyncOwner{Binding: "nginx-bpolicy", AppliedManifestWork: "a1b2-nginx-bpolicy", Markers: []string{OriginBindingLabel}},
+			wantManaged: true,
+			wantString:  "Binding nginx-bpolicy via AppliedManifestWork a1b2-nginx-bpolicy",
+		},
+		{
+			name:        "applied manifest work only",
+			owners:      []metav1.OwnerReference{appliedWork},
+			want:        DownsyncOwner{AppliedManifestWork: "a1b2-nginx-bpolicy"},
+			wantManaged: true,
+			wantString:  "AppliedManifestWork a1b2-nginx-bpolicy",
+		},
+		{
+			name:        "other transport annotation",
+			annotations: map[string]string{TransportPrefix + "originWdsName": "wds1"},
+			want:        DownsyncOwner{Markers: []string{TransportPrefix + "originWdsName"}},
+			wantManaged: true,
+			wantString:  TransportPrefix + "originWdsName",
+		},
+		{
+			name:   "manifest work of another group",
+			owners: []metav1.OwnerReference{{APIVersion: "example.com/v1", Kind: "AppliedManifestWork", Name: "x"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			obj.SetLabels(tt.labels)
+			obj.SetAnnotations(tt.annotations)
+			obj.SetOwnerReferences(tt.owners)
+			got, managed := DownsyncOwnerOf(obj)
+			if managed != tt.wantManaged {
+				t.Fatalf("DownsyncOwnerOf() managed = %v, want %v", managed, tt.wantManaged)
+			}
+			if !managed {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DownsyncOwnerOf() = %+v, want %+v", got, tt.want)
+			}
+			if got.String() != tt.wantString {
+				t.Errorf("String() = %q, want %q", got.String(), tt.wantString)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package kubestellar
+
+import (
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// DownsyncClause mirrors one entry of a BindingPolicy's spec.downsync list
+type DownsyncClause struct {
+	APIGroup           *string                `json:"apiGroup,omitempty"`
+	Resources          []string               `json:"resources,omitempty"`
+	Namespaces         []string               `json:"namespaces,omitempty"`
+	NamespaceSelectors []metav1.LabelSelector `json:"namespaceSelectors,omitempty"`
+	ObjectNames        []string               `json:"objectNames,omitempty"`
+	ObjectSelectors    []metav1.LabelSelector `json:"objectSelectors,omitempty"`
+	CreateOnly         bool                   `json:"createOnly,omitempty"`
+}
+
+// BindingPolicySpec mirrors the parts of a BindingPolicy spec the plugin works with
+type BindingPolicySpec struct {
+	ClusterSelectors           []metav1.LabelSelector `json:"clusterSelectors,omitempty"`
+	Downsync                   []DownsyncClause       `json:"downsync,omitempty"`
+	WantSingletonReportedState bool                   `json:"wantSingletonReportedState,omitempty"`
+}
+
+// BindingPolicySpecFrom decodes the spec of an unstructured BindingPolicy
+func BindingPolicySpecFrom(obj *unstructured.Unstructured) (BindingPolicySpec, error) {
+	var spec BindingPolicySpec
+	raw, found, err := unstructured.NestedMap(obj.Object, "spec")
+	if err != nil || !found {
+		return spec, err
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &spec); err != nil {
+		return spec, fmt.Errorf("failed to decode spec of BindingPolicy %s: %v", obj.GetName(), err)
+	}
+	return spec, nil
+}
+
+// BindingDestinations returns the cluster names listed in a Binding's spec.destinations
+func BindingDestinations(binding *unstructured.Unstructured) []string {
+	destinations, _, _ := unstructured.NestedSlice(binding.Object, "spec", "destinations")
+	var clusters []string
+	for _, d := range destinations {
+		if m, ok := d.(map[string]interface{}); ok {
+			if id, ok := m["clusterId"].(string); ok {
+				clusters = append(clusters, id)
+			}
+		}
+	}
+	return clusters
+}
+
+// BindingWorkload returns the objects listed in a Binding's spec.workload
+func BindingWorkload(binding *unstructured.Unstructured) []ObjectRef {
+	var refs []ObjectRef
+	for _, scope := range []string{"clusterScope", "namespaceScope"} {
+		entries, _, _ := unstructured.NestedSlice(binding.Object, "spec", "workload", scope)
+		for _, e := range entries {
+			m, ok := e.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			ref := ObjectRef{}
+			ref.Group, _ = m["group"].(string)
+			ref.Version, _ = m["version"].(string)
+			ref.Resource, _ = m["resource"].(string)
+			ref.Namespace, _ = m["namespace"].(string)
+			ref.Name, _ = m["name"].(string)
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// BindingCreateOnly returns the objects of a Binding's workload marked
+// createOnly, which are created in the destination clusters but not updated
+// afterwards
+func BindingCreateOnly(binding *unstructured.Unstructured) map[ObjectRef]bool {
+	createOnly := map[ObjectRef]bool{}
+	for _, scope := range []string{"clusterScope", "namespaceScope"} {
+		entries, _, _ := unstructured.NestedSlice(binding.Object, "spec", "workload", scope)
+		for _, e := range entries {
+			m, ok := e.(map[string]interface{})
+			if !ok || m["createOnly"] != true {
+				continue
+			}
+			ref := ObjectRef{}
+			ref.Group, _ = m["group"].(string)
+			ref.Version, _ = m["version"].(string)
+			ref.Resource, _ = m["resource"].(string)
+			ref.Namespace, _ = m["namespace"].(string)
+			ref.Name, _ = m["name"].(string)
+			createOnly[ref] = true
+		}
+	}
+	return createOnly
+}
+
+// WorkloadObject describes the object whose placement is being evaluated
+type WorkloadObject struct {
+	Ref             ObjectRef
+	Labels          map[string]string
+	NamespaceLabels map[string]string
+}
+
+// MatchDownsync reports whether any downsync clause selects the object. When
+// nothing matches, the returned reasons explain why each clause was rejected.
+func MatchDownsync(spec BindingPolicySpec, obj WorkloadObject) (bool, []string) {
+	var reasons []string
+	for i, clause := range spec.Downsync {
+		reason := clauseMismatch(clause, obj)
+		if reason == "" {
+			return true, nil
+		}
+		reasons = append(reasons, fmt.Sprintf("downsync[%d]: %s", i, reason))
+	}
+	if len(spec.Downsync) == 0 {
+		reasons = append(reasons, "policy has no downsync clauses")
+	}
+	return false, reasons
+}
+
+// clauseMismatch returns an empty string when the clause selects the object,
+// otherwise a description of the first failing criterion.
+func clauseMismatch(clause DownsyncClause, obj WorkloadObject) string {
+	if clause.APIGroup != nil && *clause.APIGroup != obj.Ref.Group {
+		return fmt.Sprintf("apiGroup %q does not match %q", *clause.APIGroup, obj.Ref.Group)
+	}
+	if len(clause.Resources) > 0 && !containsOrWildcard(clause.Resources, obj.Ref.Resource) {
+		return fmt.Sprintf("resource %s not in [%s]", obj.Ref.Resource, strings.Join(clause.Resources, ","))
+	}
+	if len(clause.Namespaces) > 0 && !containsOrWildcard(clause.Namespaces, obj.Ref.Namespace) {
+		return fmt.Sprintf("namespace %s not in [%s]", obj.Ref.Namespace, strings.Join(clause.Namespaces, ","))
+	}
+	if len(clause.NamespaceSelectors) > 0 {
+		if ok, failed := MatchAnySelector(clause.NamespaceSelectors, obj.NamespaceLabels); !ok {
+			return fmt.Sprintf("namespace labels %s do not satisfy %s", FormatLabelSet(obj.NamespaceLabels), strings.Join(failed, " or "))
+		}
+	}
+	if len(clause.ObjectNames) > 0 && !containsOrWildcard(clause.ObjectNames, obj.Ref.Name) {
+		return fmt.Sprintf("name %s not in [%s]", obj.Ref.Name, strings.Join(clause.ObjectNames, ","))
+	}
+	if len(clause.ObjectSelectors) > 0 {
+		if ok, failed := MatchAnySelector(clause.ObjectSelectors, obj.Labels); !ok {
+			return fmt.Sprintf("object labels %s do not satisfy %s", FormatLabelSet(obj.Labels), strings.Join(failed, " or "))
+		}
+	}
+	return ""
+}
+
+// MatchAnySelector reports whether the label set satisfies at least one of the
+// selectors. When none match, it returns the unsatisfied requirements of each.
+func MatchAnySelector(selectors []metav1.LabelSelector, set map[string]string) (bool, []string) {
+	var failed []string
+	for i := range selectors {
+		missing, err := UnsatisfiedRequirements(&selectors[i], set)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("<invalid selector: %v>", err))
+			continue
+		}
+		if len(missing) == 0 {
+			return true, nil
+		}
+		failed = append(failed, strings.Join(missing, ","))
+	}
+	return false, failed
+}
+
+// UnsatisfiedRequirements lists the requirements of the selector that the label set does not meet
+func UnsatisfiedRequirements(selector *metav1.LabelSelector, set map[string]string) ([]string, error) {
+	sel, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	requirements, _ := sel.Requirements()
+	var missing []string
+	for _, req := range requirements {
+		if !req.Matches(labels.Set(set)) {
+			missing = append(missing, req.String())
+		}
+	}
+	return missing, nil
+}
+
+// FormatLabelSet renders labels in selector syntax, or <none> when empty
+func FormatLabelSet(set map[string]string) string {
+	if len(set) == 0 {
+		return "<none>"
+	}
+	return labels.Set(set).String()
+}
+
+func containsOrWildcard(list []string, value string) bool {
+	for _, v := range list {
+		if v == "*" || v == value {
+			return true
+		}
+	}
+	return false
+}
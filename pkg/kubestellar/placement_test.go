@@ -0,0 +1,181 @@
+package kubestellar
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestMatchDownsync(t *testing.T) {
+	apps := "apps"
+	core := ""
+	deployment := WorkloadObject{
+		Ref:             ObjectRef{Group: "apps", Version: "v1", Resource: "deployments", Namespace: "prod", Name: "nginx"},
+		Labels:          map[string]string{"app": "nginx"},
+		NamespaceLabels: map[string]string{"env": "prod"},
+	}
+
+	tests := []struct {
+		name        string
+		spec        BindingPolicySpec
+		wantMatched bool
+		wantReasons []string
+	}{
+		{
+			name:        "no clauses",
+			spec:        BindingPolicySpec{},
+			wantReasons: []string{"policy has no downsync clauses"},
+		},
+		{
+			name:        "empty clause selects everything",
+			spec:        BindingPolicySpec{Downsync: []DownsyncClause{{}}},
+			wantMatched: true,
+		},
+		{
+			name: "all criteria match",
+			spec: BindingPolicySpec{Downsync: []DownsyncClause{{
+				APIGroup:           &apps,
+				Resources:          []string{"deployments"},
+				Namespaces:         []string{"prod"},
+				NamespaceSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"env": "prod"}}},
+				ObjectNames:        []string{"nginx"},
+				ObjectSelectors:    []metav1.LabelSelector{{MatchLabels: map[string]string{"app": "nginx"}}},
+			}}},
+			wantMatched: true,
+		},
+		{
+			name:        "wildcards",
+			spec:        BindingPolicySpec{Downsync: []DownsyncClause{{Resources: []string{"*"}, Namespaces: []string{"*"}, ObjectNames: []string{"*"}}}},
+			wantMatched: true,
+		},
+		{
+			name: "second clause matches",
+			spec: BindingPolicySpec{Downsync: []DownsyncClause{
+				{Resources: []string{"services"}},
+				{Resources: []string{"deployments"}},
+			}},
+			wantMatched: true,
+		},
+		{
+			name:        "api group",
+			spec:        BindingPolicySpec{Downsync: []DownsyncClause{{APIGroup: &core}}},
+			wantReasons: []string{`downsync[0]: apiGroup "" does not match "apps"`},
+		},
+		{
+			name: "every clause rejected",
+			spec: BindingPolicySpec{Downsync: []DownsyncClause{
+				{Resources: []string{"services", "configmaps"}},
+				{Namespaces: []string{"dev"}},
+				{ObjectNames: []string{"redis"}},
+			}},
+			wantReasons: []string{
+				"downsync[0]: resource deployments not in [services,configmaps]",
+				"downsync[1]: namespace prod not in [dev]",
+				"downsync[2]: name nginx not in [redis]",
+			},
+		},
+		{
+			name: "namespace selector",
+			spec: BindingPolicySpec{Downsync: []DownsyncClause{{
+				NamespaceSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"env": "dev"}}},
+			}}},
+			wantReasons: []string{"downsync[0]: namespace labels env=prod do not satisfy env=dev"},
+		},
+		{
+			name: "object selector",
+			spec: BindingPolicySpec{Downsync: []DownsyncClause{{
+				ObjectSelectors: []metav1.LabelSelector{
+					{MatchLabels: map[string]string{"app": "redis"}},
+					{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "tier", Operator: metav1.LabelSelectorOpExists}}},
+				},
+			}}},
+			wantReasons: []string{"downsync[0]: object labels app=nginx do not satisfy app=redis or tier"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched, reasons := MatchDownsync(tt.spec, deployment)
+			if matched != tt.wantMatched {
+				t.Errorf("MatchDownsync() matched = %v, want %v", matched, tt.wantMatched)
+			}
+			if !reflect.DeepEqual(reasons, tt.wantReasons) {
+				t.Errorf("MatchDownsync() reasons = %q, want %q", reasons, tt.wantReasons)
+			}
+		})
+	}
+}
+
+func TestUnsatisfiedRequirements(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector metav1.LabelSelector
+		set      map[string]string
+		want     []string
+		wantErr  bool
+	}{
+		{name: "empty selector", selector: metav1.LabelSelector{}, set: nil, want: nil},
+		{name: "satisfied", selector: metav1.LabelSelector{MatchLabels: map[string]string{"a": "1"}}, set: map[string]string{"a": "1", "b": "2"}, want: nil},
+		{
+			name:     "partly satisfied",
+			selector: metav1.LabelSelector{MatchLabels: map[string]string{"a": "1", "b": "2"}},
+			set:      map[string]string{"a": "1"},
+			want:     []string{"b=2"},
+		},
+		{
+			name: "invalid operator",
+			selector: metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "a", Operator: "Bogus"},
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UnsatisfiedRequirements(&tt.selector, tt.set)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnsatisfiedRequirements() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnsatisfiedRequirements() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatLabelSet(t *testing.T) {
+	tests := []struct {
+		set  map[string]string
+		want string
+	}{
+		{nil, "<none>"},
+		{map[string]string{"b": "2", "a": "1"}, "a=1,b=2"},
+	}
+	for _, tt := range tests {
+		if got := FormatLabelSet(tt.set); got != tt.want {
+			t.Errorf("FormatLabelSet(%v) = %q, want %q", tt.set, got, tt.want)
+		}
+	}
+}
+
+func TestBindingCreateOnly(t *testing.T) {
+	binding := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"workload": map[string]interface{}{
+			"clusterScope": []interface{}{
+				map[string]interface{}{"version": "v1", "resource": "namespaces", "name": "web", "createOnly": true},
+			},
+			"namespaceScope": []interface{}{
+				map[string]interface{}{"group": "apps", "version": "v1", "resource": "deployments", "namespace": "web", "name": "nginx"},
+				map[string]interface{}{"version": "v1", "resource": "configmaps", "namespace": "web", "name": "seed", "createOnly": true},
+			},
+		}},
+	}}
+	want := map[ObjectRef]bool{
+		{Version: "v1", Resource: "namespaces", Name: "web"}:                    true,
+		{Version: "v1", Resource: "configmaps", Namespace: "web", Name: "seed"}: true,
+	}
+	if got := BindingCreateOnly(binding); !reflect.DeepEqual(got, want) {
+		t.Errorf("BindingCreateOnly() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,258 @@
+package kubestellar
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// PolicyLabel is put on workload objects by a generated BindingPolicy so the
+// policy's downsync clause can select exactly those objects
+const PolicyLabel = "kubectl-multi.kubestellar.io/policy"
+
+// AppLabel is put on the workload objects of an application deployed with
+// deploy so that the application's BindingPolicy selects exactly those objects
+const AppLabel = "kubectl-multi.kubestellar.io/app"
+
+// ClusterNameLabel is the ManagedCluster label carrying the cluster's name
+const ClusterNameLabel = "name"
+
+// GeneratePolicy builds a BindingPolicy that places every object labeled
+// PolicyLabel=name onto the given clusters, selected by their name label.
+// The objects are labeled in place so they match the generated policy.
+func GeneratePolicy(name string, clusters []string, objs []*unstructured.Unstructured) *unstructured.Unstructured {
+	for _, obj := range objs {
+		labels := obj.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[PolicyLabel] = name
+		obj.SetLabels(labels)
+	}
+
+	values := make([]interface{}, 0, len(clusters))
+	for _, c := range clusters {
+		values = append(values, c)
+	}
+
+	policy := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": BindingPolicyGVR.GroupVersion().String(),
+		"kind":       "BindingPolicy",
+		"metadata": map[string]interface{}{
+			"name": name,
+		},
+		"spec": map[string]interface{}{
+			"clusterSelectors": []interface{}{
+				map[string]interface{}{
+					"matchExpressions": []interface{}{
+						map[string]interface{}{
+							"key":      ClusterNameLabel,
+							"operator": "In",
+							"values":   values,
+						},
+					},
+				},
+			},
+			"downsync": []interface{}{
+				map[string]interface{}{
+					"objectSelectors": []interface{}{
+						map[string]interface{}{
+							"matchLabels": map[string]interface{}{PolicyLabel: name},
+						},
+					},
+				},
+			},
+		},
+	}}
+	return policy
+}
+
+// NewPolicy builds a BindingPolicy object with the given spec, after
+// validating and normalizing the match expressions of its selectors
+func NewPolicy(name string, spec BindingPolicySpec) (*unstructured.Unstructured, error) {
+	if err := NormalizePolicySpec(&spec); err != nil {
+		return nil, fmt.Errorf("invalid BindingPolicy %s: %v", name, err)
+	}
+	raw, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode spec of BindingPolicy %s: %v", name, err)
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": BindingPolicyGVR.GroupVersion().String(),
+		"kind":       "BindingPolicy",
+		"metadata": map[string]interface{}{
+			"name": name,
+		},
+		"spec": raw,
+	}}, nil
+}
+
+// DownsyncClauses builds the clauses downsyncing the objects that match
+// objectSelector in namespaces (all when empty), one clause per API group of
+// resources. Without resources a single clause matches objects of any type.
+func DownsyncClauses(resources []schema.GroupResource, namespaces []string, objectSelector *metav1.LabelSelector) []DownsyncClause {
+	newClause := func() DownsyncClause {
+		clause := DownsyncClause{Namespaces: namespaces}
+		if objectSelector != nil {
+			clause.ObjectSelectors = []metav1.LabelSelector{*objectSelector}
+		}
+		return clause
+	}
+	if len(resources) == 0 {
+		return []DownsyncClause{newClause()}
+	}
+
+	byGroup := map[string][]string{}
+	for _, r := range resources {
+		if !containsOrWildcard(byGroup[r.Group], r.Resource) {
+			byGroup[r.Group] = append(byGroup[r.Group], r.Resource)
+		}
+	}
+	groups := make([]string, 0, len(byGroup))
+	for g := range byGroup {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+
+	clauses := make([]DownsyncClause, 0, len(groups))
+	for _, g := range groups {
+		group := g
+		clause := newClause()
+		clause.APIGroup = &group
+		clause.Resources = byGroup[g]
+		sort.Strings(clause.Resources)
+		clauses = append(clauses, clause)
+	}
+	return clauses
+}
+
+// SelectorForClusters returns a cluster selector matching exactly the chosen
+// clusters out of all (name to labels). The labels the chosen clusters share
+// are used when they select nothing else, so clusters labeled alike later are
+// picked up too; otherwise the clusters are selected by their name label.
+// Labels set by Open Cluster Management itself are never used.
+func SelectorForClusters(chosen []string, all map[string]map[string]string) metav1.LabelSelector {
+	names := append([]string(nil), chosen...)
+	sort.Strings(names)
+
+	if len(names) > 1 {
+		common := map[string]string{}
+		for k, v := range all[names[0]] {
+			if k != ClusterNameLabel && !strings.Contains(k, "open-cluster-management.io") {
+				common[k] = v
+			}
+		}
+		for _, name := range names[1:] {
+			for k, v := range common {
+				if all[name][k] != v {
+					delete(common, k)
+				}
+			}
+		}
+		if len(common) > 0 && matchCount(common, all) == len(names) {
+			return metav1.LabelSelector{MatchLabels: common}
+		}
+	}
+
+	return metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+		Key:      ClusterNameLabel,
+		Operator: metav1.LabelSelectorOpIn,
+		Values:   names,
+	}}}
+}
+
+// matchCount counts the clusters whose labels include set
+func matchCount(set map[string]string, all map[string]map[string]string) int {
+	sel := labels.SelectorFromSet(set)
+	n := 0
+	for _, l := range all {
+		if sel.Matches(labels.Set(l)) {
+			n++
+		}
+	}
+	return n
+}
+
+// NormalizePolicySpec validates and normalizes the match expressions of every
+// cluster, namespace and object selector in spec
+func NormalizePolicySpec(spec *BindingPolicySpec) error {
+	if err := NormalizeSelectors(spec.ClusterSelectors); err != nil {
+		return fmt.Errorf("cluster selector: %v", err)
+	}
+	for i := range spec.Downsync {
+		if err := NormalizeSelectors(spec.Downsync[i].NamespaceSelectors); err != nil {
+			return fmt.Errorf("downsync namespace selector: %v", err)
+		}
+		if err := NormalizeSelectors(spec.Downsync[i].ObjectSelectors); err != nil {
+			return fmt.Errorf("downsync object selector: %v", err)
+		}
+	}
+	return nil
+}
+
+// NormalizeSelectors checks the match expressions of the selectors in place.
+// Operators are matched case-insensitively and rewritten to their canonical
+// form, so "in" becomes In; In and NotIn need at least one value, Exists and
+// DoesNotExist none, and every expression needs a key.
+func NormalizeSelectors(selectors []metav1.LabelSelector) error {
+	for i := range selectors {
+		exprs := selectors[i].MatchExpressions
+		for j := range exprs {
+			op, err := normalizeRequirement(exprs[j])
+			if err != nil {
+				return fmt.Errorf("invalid match expression %q: %v", formatRequirement(exprs[j]), err)
+			}
+			exprs[j].Operator = op
+		}
+	}
+	return nil
+}
+
+// selectorOperators are the valid match expression operators
+var selectorOperators = []metav1.LabelSelectorOperator{
+	metav1.LabelSelectorOpIn,
+	metav1.LabelSelectorOpNotIn,
+	metav1.LabelSelectorOpExists,
+	metav1.LabelSelectorOpDoesNotExist,
+}
+
+// normalizeRequirement returns the canonical operator of req, or why req is invalid
+func normalizeRequirement(req metav1.LabelSelectorRequirement) (metav1.LabelSelectorOperator, error) {
+	if req.Key == "" {
+		return "", fmt.Errorf("key is required")
+	}
+	var op metav1.LabelSelectorOperator
+	for _, known := range selectorOperators {
+		if strings.EqualFold(string(req.Operator), string(known)) {
+			op = known
+		}
+	}
+	switch op {
+	case metav1.LabelSelectorOpIn, metav1.LabelSelectorOpNotIn:
+		if len(req.Values) == 0 {
+			return "", fmt.Errorf("operator %s needs at least one value", op)
+		}
+	case metav1.LabelSelectorOpExists, metav1.LabelSelectorOpDoesNotExist:
+		if len(req.Values) > 0 {
+			return "", fmt.Errorf("operator %s takes no values", op)
+		}
+	default:
+		return "", fmt.Errorf("unknown operator %q, must be one of In, NotIn, Exists, DoesNotExist", req.Operator)
+	}
+	return op, nil
+}
+
+// formatRequirement renders a match expression as "key operator [values]"
+func formatRequirement(req metav1.LabelSelectorRequirement) string {
+	s := strings.TrimSpace(req.Key + " " + string(req.Operator))
+	if len(req.Values) > 0 {
+		s += " [" + strings.Join(req.Values, ",") + "]"
+	}
+	return s
+}
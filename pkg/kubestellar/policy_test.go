@@ -0,0 +1,164 @@
+package kubestellar
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestDownsyncClauses(t *testing.T) {
+	apps := "apps"
+	core := ""
+	sel := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "nginx"}}
+
+	tests := []struct {
+		name       string
+		resources  []schema.GroupResource
+		namespaces []string
+		want       []DownsyncClause
+	}{
+		{
+			name: "any type",
+			want: []DownsyncClause{{ObjectSelectors: []metav1.LabelSelector{*sel}}},
+		},
+		{
+			name:       "one clause per group",
+			resources:  []schema.GroupResource{{Resource: "services"}, {Group: "apps", Resource: "statefulsets"}, {Group: "apps", Resource: "deployments"}, {Resource: "services"}},
+			namespaces: []string{"prod"},
+			want: []DownsyncClause{
+				{APIGroup: &core, Resources: []string{"services"}, Namespaces: []string{"prod"}, ObjectSelectors: []metav1.LabelSelector{*sel}},
+				{APIGroup: &apps, Resources: []string{"deployments", "statefulsets"}, Namespaces: []string{"prod"}, ObjectSelectors: []metav1.LabelSelector{*sel}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DownsyncClauses(tt.resources, tt.namespaces, sel)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DownsyncClauses() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectorForClusters(t *testing.T) {
+	all := map[string]map[string]string{
+		"edge1":  {"name": "edge1", "location": "edge", "cluster.open-cluster-management.io/clusterset": "default"},
+		"edge2":  {"name": "edge2", "location": "edge", "cluster.open-cluster-management.io/clusterset": "default"},
+		"cloud1": {"name": "cloud1", "location": "cloud", "cluster.open-cluster-management.io/clusterset": "default"},
+	}
+	byName := func(names ...string) metav1.LabelSelector {
+		return metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "name", Operator: metav1.LabelSelectorOpIn, Values: names}}}
+	}
+
+	tests := []struct {
+		name   string
+		chosen []string
+		want   metav1.LabelSelector
+	}{
+		{name: "shared label selects exactly", chosen: []string{"edge2", "edge1"}, want: metav1.LabelSelector{MatchLabels: map[string]string{"location": "edge"}}},
+		{name: "single cluster by name", chosen: []string{"cloud1"}, want: byName("cloud1")},
+		{name: "no shared label", chosen: []string{"edge1", "cloud1"}, want: byName("cloud1", "edge1")},
+		{name: "OCM labels are ignored", chosen: []string{"edge1", "edge2", "cloud1"}, want: byName("cloud1", "edge1", "edge2")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SelectorForClusters(tt.chosen, all); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SelectorForClusters() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewPolicy(t *testing.T) {
+	apps := "apps"
+	edge := []metav1.LabelSelector{{MatchLabels: map[string]string{"location": "edge"}}}
+	tests := []struct {
+		name    string
+		spec    BindingPolicySpec
+		want    BindingPolicySpec
+		wantErr string
+	}{
+		{
+			name: "valid",
+			spec: BindingPolicySpec{ClusterSelectors: edge, Downsync: []DownsyncClause{{APIGroup: &apps, Resources: []string{"deployments"}}}},
+			want: BindingPolicySpec{ClusterSelectors: edge, Downsync: []DownsyncClause{{APIGroup: &apps, Resources: []string{"deployments"}}}},
+		},
+		{
+			name: "operators normalized",
+			spec: BindingPolicySpec{ClusterSelectors: []metav1.LabelSelector{{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "location", Operator: "in", Values: []string{"edge"}}}}}},
+			want: BindingPolicySpec{ClusterSelectors: []metav1.LabelSelector{{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "location", Operator: metav1.LabelSelectorOpIn, Values: []string{"edge"}}}}}},
+		},
+		{
+			name:    "invalid object selector",
+			spec:    BindingPolicySpec{ClusterSelectors: edge, Downsync: []DownsyncClause{{ObjectSelectors: []metav1.LabelSelector{{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "app", Operator: "Exists", Values: []string{"x"}}}}}}}},
+			wantErr: `invalid BindingPolicy nginx: downsync object selector: invalid match expression "app Exists [x]": operator Exists takes no values`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := NewPolicy("nginx", tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("NewPolicy() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewPolicy() error = %v", err)
+			}
+			if policy.GetName() != "nginx" || policy.GetKind() != "BindingPolicy" {
+				t.Errorf("NewPolicy() = %s %s, want BindingPolicy nginx", policy.GetKind(), policy.GetName())
+			}
+			got, err := BindingPolicySpecFrom(policy)
+			if err != nil {
+				t.Fatalf("BindingPolicySpecFrom() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("spec round trip = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeSelectors(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    metav1.LabelSelectorRequirement
+		wantOp  metav1.LabelSelectorOperator
+		wantErr string
+	}{
+		{name: "canonical", expr: metav1.LabelSelectorRequirement{Key: "env", Operator: "NotIn", Values: []string{"dev"}}, wantOp: metav1.LabelSelectorOpNotIn},
+		{name: "lower case in", expr: metav1.LabelSelectorRequirement{Key: "env", Operator: "in", Values: []string{"prod", "stage"}}, wantOp: metav1.LabelSelectorOpIn},
+		{name: "upper case exists", expr: metav1.LabelSelectorRequirement{Key: "gpu", Operator: "EXISTS"}, wantOp: metav1.LabelSelectorOpExists},
+		{name: "doesnotexist", expr: metav1.LabelSelectorRequirement{Key: "gpu", Operator: "doesnotexist"}, wantOp: metav1.LabelSelectorOpDoesNotExist},
+		{name: "unknown operator", expr: metav1.LabelSelectorRequirement{Key: "env", Operator: "Equals", Values: []string{"prod"}},
+			wantErr: `invalid match expression "env Equals [prod]": unknown operator "Equals", must be one of In, NotIn, Exists, DoesNotExist`},
+		{name: "in without values", expr: metav1.LabelSelectorRequirement{Key: "env", Operator: "In"},
+			wantErr: `invalid match expression "env In": operator In needs at least one value`},
+		{name: "exists with values", expr: metav1.LabelSelectorRequirement{Key: "gpu", Operator: "DoesNotExist", Values: []string{"a", "b"}},
+			wantErr: `invalid match expression "gpu DoesNotExist [a,b]": operator DoesNotExist takes no values`},
+		{name: "missing key", expr: metav1.LabelSelectorRequirement{Operator: "Exists"},
+			wantErr: `invalid match expression "Exists": key is required`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selectors := []metav1.LabelSelector{{MatchExpressions: []metav1.LabelSelectorRequirement{tt.expr}}}
+			err := NormalizeSelectors(selectors)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("NormalizeSelectors() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NormalizeSelectors() error = %v", err)
+			}
+			if got := selectors[0].MatchExpressions[0].Operator; got != tt.wantOp {
+				t.Errorf("operator = %s, want %s", got, tt.wantOp)
+			}
+		})
+	}
+}
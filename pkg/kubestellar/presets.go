@@ -0,0 +1,149 @@
+package kubestellar
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+// builtinPresets are the curated presets shipped with the plugin
+//
+//go:embed presets/*.yaml
+var builtinPresets embed.FS
+
+// PresetSourceBuiltin is the Source of the presets shipped with the plugin
+const PresetSourceBuiltin = "built-in"
+
+// Preset is a named downsync template for bp create, expanding into a set of
+// resource types and an object selector for common application shapes
+type Preset struct {
+	// Name is the file name of the preset without its extension
+	Name string `json:"-"`
+	// Source is PresetSourceBuiltin or the file the preset was read from
+	Source string `json:"-"`
+
+	Description string `json:"description,omitempty"`
+	// ObjectSelector is a label selector in which {{.Name}} stands for the
+	// name of the policy being created
+	ObjectSelector string `json:"objectSelector,omitempty"`
+	// Resources are the downsynced types as RESOURCE[.GROUP], e.g.
+	// deployments.apps or services
+	Resources []string `json:"resources,omitempty"`
+	// Namespaces limits the downsync to these namespaces; empty means all
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// LoadPresets returns the built-in presets, overridden and extended by the
+// *.yaml files in dir. A missing dir is not an error.
+func LoadPresets(dir string) (map[string]Preset, error) {
+	presets := map[string]Preset{}
+	if err := readPresets(builtinPresets, "presets", PresetSourceBuiltin, presets); err != nil {
+		return nil, err
+	}
+	if dir == "" {
+		return presets, nil
+	}
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return presets, nil
+	}
+	if err := readPresets(os.DirFS(dir), ".", dir, presets); err != nil {
+		return nil, err
+	}
+	return presets, nil
+}
+
+// LookupPreset returns the preset called name from LoadPresets(dir)
+func LookupPreset(dir, name string) (Preset, error) {
+	presets, err := LoadPresets(dir)
+	if err != nil {
+		return Preset{}, err
+	}
+	preset, ok := presets[name]
+	if !ok {
+		return Preset{}, fmt.Errorf("unknown preset %q, must be one of %s", name, strings.Join(PresetNames(presets), "|"))
+	}
+	return preset, nil
+}
+
+// PresetNames returns the names of presets in sorted order
+func PresetNames(presets map[string]Preset) []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func readPresets(fsys fs.FS, dir, source string, presets map[string]Preset) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return fmt.Errorf("failed to read presets in %s: %v", source, err)
+	}
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		file := entry.Name()
+		if source != PresetSourceBuiltin {
+			file = filepath.Join(source, entry.Name())
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to read preset %s: %v", file, err)
+		}
+		preset := Preset{Name: strings.TrimSuffix(entry.Name(), ext), Source: file}
+		if source == PresetSourceBuiltin {
+			preset.Source = PresetSourceBuiltin
+		}
+		if err := yaml.UnmarshalStrict(data, &preset); err != nil {
+			return fmt.Errorf("failed to parse preset %s: %v", file, err)
+		}
+		if _, _, err := preset.Expand("test"); err != nil {
+			return fmt.Errorf("invalid preset %s: %v", file, err)
+		}
+		presets[preset.Name] = preset
+	}
+	return nil
+}
+
+// Expand returns the object selector and resource types of the preset for
+// the policy called name
+func (p Preset) Expand(name string) (string, []schema.GroupResource, error) {
+	if p.ObjectSelector == "" && len(p.Resources) == 0 {
+		return "", nil, fmt.Errorf("preset %s sets neither objectSelector nor resources", p.Name)
+	}
+	tmpl, err := template.New(p.Name).Option("missingkey=error").Parse(p.ObjectSelector)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid objectSelector: %v", err)
+	}
+	var selector bytes.Buffer
+	if err := tmpl.Execute(&selector, struct{ Name string }{name}); err != nil {
+		return "", nil, fmt.Errorf("invalid objectSelector: %v", err)
+	}
+	if _, err := metav1.ParseToLabelSelector(selector.String()); err != nil {
+		return "", nil, fmt.Errorf("invalid objectSelector %q: %v", selector.String(), err)
+	}
+
+	resources := make([]schema.GroupResource, 0, len(p.Resources))
+	for _, r := range p.Resources {
+		gr := schema.ParseGroupResource(r)
+		if gr.Resource == "" || strings.ContainsAny(r, "/: ") {
+			return "", nil, fmt.Errorf("invalid resource %q, must be RESOURCE[.GROUP] such as deployments.apps", r)
+		}
+		resources = append(resources, gr)
+	}
+	return selector.String(), resources, nil
+}
@@ -0,0 +1,162 @@
+package kubestellar
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestLoadPresets(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       map[string]string
+		noDir       bool
+		wantSources map[string]string
+		wantErr     string
+	}{
+		{
+			name:        "built-in only",
+			noDir:       true,
+			wantSources: map[string]string{"web-app": PresetSourceBuiltin, "batch": PresetSourceBuiltin, "config-only": PresetSourceBuiltin},
+		},
+		{
+			name: "user presets override and extend",
+			files: map[string]string{
+				"web-app.yaml": "objectSelector: tier={{.Name}}\nresources: [deployments.apps]\n",
+				"cache.yml":    "resources: [statefulsets.apps, services]\n",
+				"README.md":    "not a preset",
+			},
+			wantSources: map[string]string{"web-app": "web-app.yaml", "batch": PresetSourceBuiltin, "config-only": PresetSourceBuiltin, "cache": "cache.yml"},
+		},
+		{
+			name:    "unknown field",
+			files:   map[string]string{"web.yaml": "resource: [services]\n"},
+			wantErr: `unknown field "resource"`,
+		},
+		{
+			name:    "invalid resource",
+			files:   map[string]string{"web.yaml": "resources: [apps/v1/deployments]\n"},
+			wantErr: "invalid preset",
+		},
+		{
+			name:    "invalid selector",
+			files:   map[string]string{"web.yaml": "objectSelector: app in\n"},
+			wantErr: "invalid objectSelector",
+		},
+		{
+			name:    "empty preset",
+			files:   map[string]string{"web.yaml": "description: nothing\n"},
+			wantErr: "sets neither objectSelector nor resources",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "presets")
+			if !tt.noDir {
+				if err := os.Mkdir(dir, 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for name, data := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			presets, err := LoadPresets(dir)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadPresets() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadPresets() error = %v", err)
+			}
+			got := map[string]string{}
+			for name, p := range presets {
+				if p.Name != name {
+					t.Errorf("preset %s has Name %q", name, p.Name)
+				}
+				got[name] = strings.TrimPrefix(p.Source, dir+string(filepath.Separator))
+			}
+			if !reflect.DeepEqual(got, tt.wantSources) {
+				t.Errorf("preset sources = %v, want %v", got, tt.wantSources)
+			}
+		})
+	}
+}
+
+func TestLookupPreset(t *testing.T) {
+	tests := []struct {
+		name    string
+		preset  string
+		wantErr string
+	}{
+		{name: "built-in", preset: "batch"},
+		{name: "unknown", preset: "web", wantErr: `unknown preset "web", must be one of batch|config-only|web-app`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LookupPreset("", tt.preset)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("LookupPreset() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LookupPreset() error = %v", err)
+			}
+			if got.Name != tt.preset {
+				t.Errorf("LookupPreset() = %s, want %s", got.Name, tt.preset)
+			}
+		})
+	}
+}
+
+func TestPresetExpand(t *testing.T) {
+	tests := []struct {
+		name          string
+		preset        Preset
+		wantSelector  string
+		wantResources []schema.GroupResource
+		wantErr       bool
+	}{
+		{
+			name:          "selector template and grouped resources",
+			preset:        Preset{ObjectSelector: "app={{.Name}},tier in (web)", Resources: []string{"deployments.apps", "services", "ingresses.networking.k8s.io"}},
+			wantSelector:  "app=shop,tier in (web)",
+			wantResources: []schema.GroupResource{{Group: "apps", Resource: "deployments"}, {Resource: "services"}, {Group: "networking.k8s.io", Resource: "ingresses"}},
+		},
+		{
+			name:          "resources only",
+			preset:        Preset{Resources: []string{"configmaps"}},
+			wantResources: []schema.GroupResource{{Resource: "configmaps"}},
+		},
+		{name: "unknown template field", preset: Preset{ObjectSelector: "app={{.Namespace}}"}, wantErr: true},
+		{name: "unparsable template", preset: Preset{ObjectSelector: "app={{.Name"}, wantErr: true},
+		{name: "kind instead of resource", preset: Preset{Resources: []string{"apps/v1:Deployment"}}, wantErr: true},
+		{name: "empty", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selector, resources, err := tt.preset.Expand("shop")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if selector != tt.wantSelector {
+				t.Errorf("Expand() selector = %q, want %q", selector, tt.wantSelector)
+			}
+			if !reflect.DeepEqual(resources, tt.wantResources) {
+				t.Errorf("Expand() resources = %v, want %v", resources, tt.wantResources)
+			}
+		})
+	}
+}
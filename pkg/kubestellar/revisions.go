@@ -0,0 +1,129 @@
+package kubestellar
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// PolicyHistoryNamespace is the namespace of the WDS holding the revision
+// histories of the cluster-scoped BindingPolicies
+const PolicyHistoryNamespace = "default"
+
+// MaxPolicyRevisions is how many revisions the history of a policy keeps
+const MaxPolicyRevisions = 10
+
+// policyHistoryPrefix starts the name of every history ConfigMap
+const policyHistoryPrefix = "bindingpolicy-history-"
+
+// PolicyRevision is the spec a BindingPolicy had after a change made through
+// kubectl multi
+type PolicyRevision struct {
+	Revision int                    `json:"revision"`
+	Time     time.Time              `json:"time"`
+	Cause    string                 `json:"cause,omitempty"`
+	Spec     map[string]interface{} `json:"spec"`
+}
+
+// PolicyHistoryConfigMap names the ConfigMap holding the revisions of a
+// policy. Names too long for a ConfigMap are shortened and made unique with
+// a hash.
+func PolicyHistoryConfigMap(policy string) string {
+	name := policyHistoryPrefix + policy
+	if len(name) <= 253 {
+		return name
+	}
+	h := fnv.New32a()
+	h.Write([]byte(policy))
+	return fmt.Sprintf("%s-%08x", name[:253-9], h.Sum32())
+}
+
+// PolicyRevisions decodes the revisions of a history ConfigMap, oldest first
+func PolicyRevisions(data map[string]string) ([]PolicyRevision, error) {
+	revisions := make([]PolicyRevision, 0, len(data))
+	for key, value := range data {
+		var rev PolicyRevision
+		if err := json.Unmarshal([]byte(value), &rev); err != nil {
+			return nil, fmt.Errorf("invalid revision %s: %v", key, err)
+		}
+		revisions = append(revisions, rev)
+	}
+	sort.Slice(revisions, func(i, j int) bool { return revisions[i].Revision < revisions[j].Revision })
+	return revisions, nil
+}
+
+// AddPolicyRevision records spec as the newest revision of the history data
+// and drops the oldest revisions beyond MaxPolicyRevisions. A spec equal to
+// the newest revision is not recorded again. It returns the revision of the
+// spec and whether it was added.
+func AddPolicyRevision(data map[string]string, spec map[string]interface{}, cause string, now time.Time) (int, bool, error) {
+	revisions, err := PolicyRevisions(data)
+	if err != nil {
+		return 0, false, err
+	}
+	next := 1
+	if n := len(revisions); n > 0 {
+		if SameSpec(revisions[n-1].Spec, spec) {
+			return revisions[n-1].Revision, false, nil
+		}
+		next = revisions[n-1].Revision + 1
+	}
+	encoded, err := json.Marshal(PolicyRevision{Revision: next, Time: now.UTC(), Cause: cause, Spec: spec})
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to encode revision: %v", err)
+	}
+	data[strconv.Itoa(next)] = string(encoded)
+	for i := 0; i < len(revisions)+1-MaxPolicyRevisions; i++ {
+		delete(data, strconv.Itoa(revisions[i].Revision))
+	}
+	return next, true, nil
+}
+
+// SameSpec reports whether two policy specs have the same content, whether
+// they were read from the API server or decoded from a revision
+func SameSpec(a, b map[string]interface{}) bool {
+	// Maps marshal with sorted keys, and numbers the same either way
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
+
+// CurrentRevision returns the newest revision whose spec is the live spec,
+// or 0 when the policy was changed outside kubectl multi since
+func CurrentRevision(revisions []PolicyRevision, spec map[string]interface{}) int {
+	for i := len(revisions) - 1; i >= 0; i-- {
+		if SameSpec(revisions[i].Spec, spec) {
+			return revisions[i].Revision
+		}
+	}
+	return 0
+}
+
+// RollbackTarget returns the revision to roll back to: the given one, or for
+// 0 the one before the current revision, the newest when the live spec
+// matches none
+func RollbackTarget(revisions []PolicyRevision, current, toRevision int) (*PolicyRevision, error) {
+	if len(revisions) == 0 {
+		return nil, fmt.Errorf("no revisions recorded")
+	}
+	if toRevision > 0 {
+		for i := range revisions {
+			if revisions[i].Revision == toRevision {
+				return &revisions[i], nil
+			}
+		}
+		return nil, fmt.Errorf("revision %d not found, the history holds revisions %d to %d", toRevision, revisions[0].Revision, revisions[len(revisions)-1].Revision)
+	}
+	if current == 0 {
+		return &revisions[len(revisions)-1], nil
+	}
+	for i := len(revisions) - 1; i > 0; i-- {
+		if revisions[i].Revision == current {
+			return &revisions[i-1], nil
+		}
+	}
+	return nil, fmt.Errorf("no revision before revision %d", current)
+}
@@ -0,0 +1,98 @@
+package kubestellar
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func testSpec(location string) map[string]interface{} {
+	return map[string]interface{}{
+		"clusterSelectors": []interface{}{map[string]interface{}{"matchLabels": map[string]interface{}{"location": location}}},
+	}
+}
+
+func TestAddPolicyRevision(t *testing.T) {
+	data := map[string]string{}
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+
+	if rev, added, err := AddPolicyRevision(data, testSpec("edge"), "bindingpolicy create", now); err != nil || rev != 1 || !added {
+		t.Fatalf("AddPolicyRevision() = %d, %v, %v, want revision 1 added", rev, added, err)
+	}
+	if rev, added, err := AddPolicyRevision(data, testSpec("edge"), "deploy", now); err != nil || rev != 1 || added {
+		t.Fatalf("AddPolicyRevision() of the same spec = %d, %v, %v, want revision 1 not added", rev, added, err)
+	}
+	for i := 0; i < MaxPolicyRevisions+2; i++ {
+		if _, _, err := AddPolicyRevision(data, testSpec(strings.Repeat("x", i+1)), "deploy", now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	revisions, err := PolicyRevisions(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != MaxPolicyRevisions {
+		t.Fatalf("history holds %d revisions, want %d", len(revisions), MaxPolicyRevisions)
+	}
+	if first, last := revisions[0].Revision, revisions[len(revisions)-1].Revision; first != 4 || last != 13 {
+		t.Errorf("history holds revisions %d to %d, want 4 to 13", first, last)
+	}
+	if revisions[0].Cause != "deploy" || !revisions[0].Time.Equal(now) {
+		t.Errorf("revision 4 = %+v", revisions[0])
+	}
+}
+
+func TestPolicyRevisionsInvalid(t *testing.T) {
+	if _, err := PolicyRevisions(map[string]string{"1": "{"}); err == nil || !strings.Contains(err.Error(), "invalid revision 1") {
+		t.Errorf("PolicyRevisions() error = %v, want invalid revision 1", err)
+	}
+}
+
+func TestRollbackTarget(t *testing.T) {
+	revisions := []PolicyRevision{
+		{Revision: 2, Spec: testSpec("edge")},
+		{Revision: 3, Spec: testSpec("core")},
+		{Revision: 4, Spec: testSpec("nowhere")},
+	}
+	tests := []struct {
+		name       string
+		live       map[string]interface{}
+		toRevision int
+		want       int
+		wantErr    string
+	}{
+		{name: "previous", live: testSpec("nowhere"), want: 3},
+		{name: "given revision", live: testSpec("nowhere"), toRevision: 2, want: 2},
+		{name: "changed outside", live: testSpec("other"), want: 4},
+		{name: "oldest is current", live: testSpec("edge"), wantErr: "no revision before revision 2"},
+		{name: "pruned revision", live: testSpec("nowhere"), toRevision: 1, wantErr: "revision 1 not found, the history holds revisions 2 to 4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RollbackTarget(revisions, CurrentRevision(revisions, tt.live), tt.toRevision)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("RollbackTarget() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RollbackTarget() error = %v", err)
+			}
+			if got.Revision != tt.want {
+				t.Errorf("RollbackTarget() = revision %d, want %d", got.Revision, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyHistoryConfigMap(t *testing.T) {
+	if got := PolicyHistoryConfigMap("web"); got != "bindingpolicy-history-web" {
+		t.Errorf("PolicyHistoryConfigMap() = %q", got)
+	}
+	long := strings.Repeat("a", 253)
+	if got := PolicyHistoryConfigMap(long); len(got) != 253 || got == PolicyHistoryConfigMap(long[:252]+"b") {
+		t.Errorf("PolicyHistoryConfigMap() of a long name = %q, want a unique 253 character name", got)
+	}
+}
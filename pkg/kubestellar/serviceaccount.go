@@ -0,0 +1,50 @@
+package kubestellar
+
+import (
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ManagedServiceAccountGVR identifies the ManagedServiceAccounts of the OCM
+// managed-serviceaccount addon. Each one, in the namespace of a cluster in
+// the ITS, has the addon create a ServiceAccount in that cluster and report
+// a token for it as a Secret next to it in the ITS.
+var ManagedServiceAccountGVR = schema.GroupVersionResource{Group: "authentication.open-cluster-management.io", Version: "v1beta1", Resource: "managedserviceaccounts"}
+
+// ManagedClusterClientConfig returns the API server URL and CA bundle a
+// ManagedCluster registered with, from the first of its client configs. The
+// URL is empty when the klusterlet reported none.
+func ManagedClusterClientConfig(mc *unstructured.Unstructured) (string, []byte, error) {
+	configs, _, _ := unstructured.NestedSlice(mc.Object, "spec", "managedClusterClientConfigs")
+	if len(configs) == 0 {
+		return "", nil, nil
+	}
+	m, ok := configs[0].(map[string]interface{})
+	if !ok {
+		return "", nil, nil
+	}
+	url, _ := m["url"].(string)
+	encoded, _ := m["caBundle"].(string)
+	if encoded == "" {
+		return url, nil, nil
+	}
+	ca, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid caBundle of ManagedCluster %s: %v", mc.GetName(), err)
+	}
+	return url, ca, nil
+}
+
+// ManagedServiceAccountToken returns the name of the Secret holding the
+// token the addon reported for a ManagedServiceAccount and when the token
+// expires; the name is empty until a token is reported
+func ManagedServiceAccountToken(msa *unstructured.Unstructured) (string, time.Time) {
+	name, _, _ := unstructured.NestedString(msa.Object, "status", "tokenSecretRef", "name")
+	expiration, _, _ := unstructured.NestedString(msa.Object, "status", "expirationTimestamp")
+	expires, _ := time.Parse(time.RFC3339, expiration)
+	return name, expires
+}
@@ -0,0 +1,46 @@
+package kubestellar
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestManagedClusterClientConfig(t *testing.T) {
+	mc := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "cluster1"},
+		"spec": map[string]interface{}{"managedClusterClientConfigs": []interface{}{
+			map[string]interface{}{"url": "https://cluster1:6443", "caBundle": base64.StdEncoding.EncodeToString([]byte("ca"))},
+		}},
+	}}
+	url, ca, err := ManagedClusterClientConfig(mc)
+	if err != nil || url != "https://cluster1:6443" || string(ca) != "ca" {
+		t.Errorf("ManagedClusterClientConfig() = %q, %q, %v", url, ca, err)
+	}
+
+	unstructured.SetNestedSlice(mc.Object, []interface{}{map[string]interface{}{"url": "https://cluster1:6443", "caBundle": "not base64!"}}, "spec", "managedClusterClientConfigs")
+	if _, _, err := ManagedClusterClientConfig(mc); err == nil {
+		t.Error("ManagedClusterClientConfig() of an invalid caBundle returned no error")
+	}
+	unstructured.RemoveNestedField(mc.Object, "spec")
+	if url, _, err := ManagedClusterClientConfig(mc); url != "" || err != nil {
+		t.Errorf("ManagedClusterClientConfig() without client configs = %q, %v", url, err)
+	}
+}
+
+func TestManagedServiceAccountToken(t *testing.T) {
+	msa := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	if name, expires := ManagedServiceAccountToken(msa); name != "" || !expires.IsZero() {
+		t.Errorf("ManagedServiceAccountToken() before a token = %q, %s", name, expires)
+	}
+	unstructured.SetNestedField(msa.Object, map[string]interface{}{
+		"tokenSecretRef":      map[string]interface{}{"name": "kubectl-multi", "lastRefreshTimestamp": "2026-10-17T10:00:00Z"},
+		"expirationTimestamp": "2026-10-24T10:00:00Z",
+	}, "status")
+	name, expires := ManagedServiceAccountToken(msa)
+	if want := time.Date(2026, 10, 24, 10, 0, 0, 0, time.UTC); name != "kubectl-multi" || !expires.Equal(want) {
+		t.Errorf("ManagedServiceAccountToken() = %q, %s", name, expires)
+	}
+}
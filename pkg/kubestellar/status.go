@@ -0,0 +1,161 @@
+package kubestellar
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Labels a WDS puts on each CombinedStatus to name the workload object and
+// BindingPolicy it combines the status of
+const (
+	StatusLabelAPIGroup      = "status.kubestellar.io/api-group"
+	StatusLabelResource      = "status.kubestellar.io/resource"
+	StatusLabelNamespace     = "status.kubestellar.io/namespace"
+	StatusLabelName          = "status.kubestellar.io/name"
+	StatusLabelBindingPolicy = "status.kubestellar.io/binding-policy"
+)
+
+// BindingState summarizes whether the controller has processed the latest
+// spec of a Binding: "Synced", "Pending" or "Errors(N)"
+func BindingState(binding *unstructured.Unstructured) string {
+	errs, _, _ := unstructured.NestedStringSlice(binding.Object, "status", "errors")
+	if len(errs) > 0 {
+		return fmt.Sprintf("Errors(%d)", len(errs))
+	}
+	observed, found, _ := unstructured.NestedInt64(binding.Object, "status", "observedGeneration")
+	if !found || observed < binding.GetGeneration() {
+		return "Pending"
+	}
+	return "Synced"
+}
+
+// WorkStatusSource returns the workload object a WorkStatus reports on
+func WorkStatusSource(ws *unstructured.Unstructured) ObjectRef {
+	ref := ObjectRef{}
+	ref.Group, _, _ = unstructured.NestedString(ws.Object, "spec", "sourceRef", "group")
+	ref.Version, _, _ = unstructured.NestedString(ws.Object, "spec", "sourceRef", "version")
+	ref.Resource, _, _ = unstructured.NestedString(ws.Object, "spec", "sourceRef", "resource")
+	ref.Namespace, _, _ = unstructured.NestedString(ws.Object, "spec", "sourceRef", "namespace")
+	ref.Name, _, _ = unstructured.NestedString(ws.Object, "spec", "sourceRef", "name")
+	return ref
+}
+
+// WorkStatusState summarizes the status a WorkStatus carries for its object
+func WorkStatusState(ws *unstructured.Unstructured) string {
+	status, _, _ := unstructured.NestedMap(ws.Object, "status")
+	return ObjectStatusState(status)
+}
+
+// ObjectStatusState renders the status of a workload object in one word or
+// two: its phase, its ready/desired replicas, or its true conditions
+func ObjectStatusState(status map[string]interface{}) string {
+	if len(status) == 0 {
+		return "<none>"
+	}
+	if phase, ok := status["phase"].(string); ok && phase != "" {
+		return phase
+	}
+	if replicas, found, _ := unstructured.NestedInt64(status, "replicas"); found {
+		ready, _, _ := unstructured.NestedInt64(status, "readyReplicas")
+		return fmt.Sprintf("%d/%d ready", ready, replicas)
+	}
+	conditions, _, _ := unstructured.NestedSlice(status, "conditions")
+	var trueTypes []string
+	for _, c := range conditions {
+		m, ok := c.(map[string]interface{})
+		if !ok || m["status"] != "True" {
+			continue
+		}
+		if t, ok := m["type"].(string); ok {
+			trueTypes = append(trueTypes, t)
+		}
+	}
+	if len(trueTypes) > 0 {
+		return strings.Join(trueTypes, ",")
+	}
+	return "<unknown>"
+}
+
+// CombinedStatusWorkload returns the workload object a CombinedStatus
+// combines the status of, from its labels
+func CombinedStatusWorkload(cs *unstructured.Unstructured) ObjectRef {
+	labels := cs.GetLabels()
+	return ObjectRef{
+		Group:     labels[StatusLabelAPIGroup],
+		Resource:  labels[StatusLabelResource],
+		Namespace: labels[StatusLabelNamespace],
+		Name:      labels[StatusLabelName],
+	}
+}
+
+// CombinedStatusResults lists the named results of a CombinedStatus with
+// their row counts, such as "replicas(3),phases(2)"
+func CombinedStatusResults(cs *unstructured.Unstructured) string {
+	results, _, _ := unstructured.NestedSlice(cs.Object, "results")
+	var parts []string
+	for _, r := range results {
+		m, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := m["name"].(string)
+		rows, _ := m["rows"].([]interface{})
+		parts = append(parts, fmt.Sprintf("%s(%d)", name, len(rows)))
+	}
+	sort.Strings(parts)
+	if len(parts) == 0 {
+		return "<none>"
+	}
+	return strings.Join(parts, ",")
+}
+
+// ClusterPropagation is how far the workload of a Binding has reached one
+// destination cluster: the number of its objects a WorkStatus reports on
+type ClusterPropagation struct {
+	Cluster  string `json:"cluster"`
+	Reported int    `json:"reported"`
+	Expected int    `json:"expected"`
+}
+
+// Synced reports whether every workload object has reported status
+func (p ClusterPropagation) Synced() bool {
+	return p.Reported >= p.Expected
+}
+
+// BindingPropagation matches the workload of a Binding against the
+// WorkStatus objects of the ITS, which live in a namespace named after the
+// cluster they come from, and returns one entry per destination cluster
+func BindingPropagation(binding *unstructured.Unstructured, workStatuses []unstructured.Unstructured) []ClusterPropagation {
+	reported := map[string]map[ObjectRef]bool{}
+	for i := range workStatuses {
+		ns := workStatuses[i].GetNamespace()
+		if reported[ns] == nil {
+			reported[ns] = map[ObjectRef]bool{}
+		}
+		reported[ns][workloadKey(WorkStatusSource(&workStatuses[i]))] = true
+	}
+
+	workload := BindingWorkload(binding)
+	var result []ClusterPropagation
+	for _, c := range BindingDestinations(binding) {
+		p := ClusterPropagation{Cluster: c, Expected: len(workload)}
+		for _, ref := range workload {
+			if reported[c][workloadKey(ref)] {
+				p.Reported++
+			}
+		}
+		result = append(result, p)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Cluster < result[j].Cluster })
+	return result
+}
+
+// workloadKey drops the version, which a WorkStatus may report differently
+// from the Binding, so that the same object compares equal
+func workloadKey(ref ObjectRef) ObjectRef {
+	ref.Version = ""
+	return ref
+}
@@ -0,0 +1,184 @@
+package kubestellar
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestBindingState(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  map[string]interface{}
+		want string
+	}{
+		{
+			name: "no status",
+			obj:  map[string]interface{}{"metadata": map[string]interface{}{"name": "b", "generation": int64(1)}},
+			want: "Pending",
+		},
+		{
+			name: "older generation",
+			obj: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "b", "generation": int64(3)},
+				"status":   map[string]interface{}{"observedGeneration": int64(2)},
+			},
+			want: "Pending",
+		},
+		{
+			name: "synced",
+			obj: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "b", "generation": int64(3)},
+				"status":   map[string]interface{}{"observedGeneration": int64(3)},
+			},
+			want: "Synced",
+		},
+		{
+			name: "errors",
+			obj: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "b", "generation": int64(3)},
+				"status":   map[string]interface{}{"observedGeneration": int64(3), "errors": []interface{}{"a", "b"}},
+			},
+			want: "Errors(2)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BindingState(&unstructured.Unstructured{Object: tt.obj}); got != tt.want {
+				t.Errorf("BindingState() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestObjectStatusState(t *testing.T) {
+	tests := []struct {
+		name   string
+		status map[string]interface{}
+		want   string
+	}{
+		{name: "empty", status: nil, want: "<none>"},
+		{name: "phase", status: map[string]interface{}{"phase": "Running"}, want: "Running"},
+		{name: "replicas", status: map[string]interface{}{"replicas": int64(3), "readyReplicas": int64(2)}, want: "2/3 ready"},
+		{name: "no ready replicas", status: map[string]interface{}{"replicas": int64(1)}, want: "0/1 ready"},
+		{
+			name: "true conditions",
+			status: map[string]interface{}{"conditions": []interface{}{
+				map[string]interface{}{"type": "Complete", "status": "True"},
+				map[string]interface{}{"type": "Failed", "status": "False"},
+			}},
+			want: "Complete",
+		},
+		{name: "nothing recognizable", status: map[string]interface{}{"loadBalancer": map[string]interface{}{}}, want: "<unknown>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ObjectStatusState(tt.status); got != tt.want {
+				t.Errorf("ObjectStatusState() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkStatusSource(t *testing.T) {
+	tests := []struct {
+		name string
+		spec map[string]interface{}
+		want string
+	}{
+		{
+			name: "namespaced",
+			spec: map[string]interface{}{"sourceRef": map[string]interface{}{"group": "apps", "version": "v1", "resource": "deployments", "namespace": "web", "name": "nginx"}},
+			want: "deployments.apps web/nginx",
+		},
+		{
+			name: "cluster-scoped core",
+			spec: map[string]interface{}{"sourceRef": map[string]interface{}{"version": "v1", "resource": "namespaces", "name": "web"}},
+			want: "namespaces web",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws := &unstructured.Unstructured{Object: map[string]interface{}{"spec": tt.spec}}
+			if got := WorkStatusSource(ws).String(); got != tt.want {
+				t.Errorf("WorkStatusSource() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCombinedStatusResults(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []interface{}
+		want    string
+	}{
+		{name: "none", want: "<none>"},
+		{
+			name: "sorted with row counts",
+			results: []interface{}{
+				map[string]interface{}{"name": "replicas", "rows": []interface{}{map[string]interface{}{}, map[string]interface{}{}}},
+				map[string]interface{}{"name": "count"},
+			},
+			want: "count(0),replicas(2)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			if tt.results != nil {
+				cs.Object["results"] = tt.results
+			}
+			if got := CombinedStatusResults(cs); got != tt.want {
+				t.Errorf("CombinedStatusResults() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBindingPropagation(t *testing.T) {
+	binding := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "nginx-bp"},
+		"spec": map[string]interface{}{
+			"destinations": []interface{}{
+				map[string]interface{}{"clusterId": "cluster2"},
+				map[string]interface{}{"clusterId": "cluster1"},
+			},
+			"workload": map[string]interface{}{
+				"clusterScope": []interface{}{
+					map[string]interface{}{"group": "", "version": "v1", "resource": "namespaces", "name": "web"},
+				},
+				"namespaceScope": []interface{}{
+					map[string]interface{}{"group": "apps", "version": "v1", "resource": "deployments", "namespace": "web", "name": "nginx"},
+				},
+			},
+		},
+	}}
+	workStatus := func(cluster, group, version, resource, namespace, name string) unstructured.Unstructured {
+		return unstructured.Unstructured{Object: map[string]interface{}{
+			"metadata": map[string]interface{}{"name": resource + "-" + name, "namespace": cluster},
+			"spec": map[string]interface{}{"sourceRef": map[string]interface{}{
+				"group": group, "version": version, "resource": resource, "namespace": namespace, "name": name,
+			}},
+		}}
+	}
+	statuses := []unstructured.Unstructured{
+		workStatus("cluster1", "", "v1", "namespaces", "", "web"),
+		workStatus("cluster1", "apps", "v1beta1", "deployments", "web", "nginx"),
+		workStatus("cluster2", "apps", "v1", "deployments", "web", "nginx"),
+		workStatus("cluster3", "", "v1", "namespaces", "", "web"),
+	}
+
+	got := BindingPropagation(binding, statuses)
+	want := []ClusterPropagation{
+		{Cluster: "cluster1", Reported: 2, Expected: 2},
+		{Cluster: "cluster2", Reported: 1, Expected: 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("BindingPropagation() = %+v, want %+v", got, want)
+	}
+	if !got[0].Synced() || got[1].Synced() {
+		t.Errorf("Synced() = %v, %v, want true, false", got[0].Synced(), got[1].Synced())
+	}
+}
@@ -0,0 +1,157 @@
+package kubestellar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Condition is a status condition reported on a ManagedCluster
+type Condition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// ClusterSummary is the machine-readable view of a ManagedCluster
+type ClusterSummary struct {
+	Name       string            `json:"name"`
+	ITS        string            `json:"its,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	Accepted   bool              `json:"accepted"`
+	Joined     bool              `json:"joined"`
+	Available  string            `json:"available"`
+	Endpoint   string            `json:"endpoint,omitempty"`
+	Conditions []Condition       `json:"conditions,omitempty"`
+	Created    time.Time         `json:"created"`
+}
+
+// PolicySummary is the machine-readable view of a BindingPolicy
+type PolicySummary struct {
+	Name             string                 `json:"name"`
+	WDS              string                 `json:"wds,omitempty"`
+	ClusterSelectors []metav1.LabelSelector `json:"clusterSelectors,omitempty"`
+	Downsync         []string               `json:"downsync,omitempty"`
+	Clusters         []string               `json:"clusters"`
+	Created          time.Time              `json:"created"`
+}
+
+// SummarizeCluster extracts the status and endpoint of a ManagedCluster
+func SummarizeCluster(mc *unstructured.Unstructured) ClusterSummary {
+	s := ClusterSummary{
+		Name:      mc.GetName(),
+		Labels:    mc.GetLabels(),
+		Available: "Unknown",
+		Created:   mc.GetCreationTimestamp().Time,
+	}
+	s.Accepted, _, _ = unstructured.NestedBool(mc.Object, "spec", "hubAcceptsClient")
+
+	configs, _, _ := unstructured.NestedSlice(mc.Object, "spec", "managedClusterClientConfigs")
+	if len(configs) > 0 {
+		if m, ok := configs[0].(map[string]interface{}); ok {
+			s.Endpoint, _ = m["url"].(string)
+		}
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(mc.Object, "status", "conditions")
+	for _, c := range conditions {
+		m, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		cond := Condition{}
+		cond.Type, _ = m["type"].(string)
+		cond.Status, _ = m["status"].(string)
+		cond.Reason, _ = m["reason"].(string)
+		cond.Message, _ = m["message"].(string)
+		s.Conditions = append(s.Conditions, cond)
+
+		switch cond.Type {
+		case "ManagedClusterConditionAvailable":
+			s.Available = cond.Status
+		case "ManagedClusterJoined":
+			s.Joined = cond.Status == "True"
+		}
+	}
+	return s
+}
+
+// SummarizePolicy extracts the selectors and downsync clauses of a
+// BindingPolicy, along with the clusters its Binding resolved to (if any)
+func SummarizePolicy(policy, binding *unstructured.Unstructured) (PolicySummary, error) {
+	s := PolicySummary{
+		Name:     policy.GetName(),
+		Clusters: []string{},
+		Created:  policy.GetCreationTimestamp().Time,
+	}
+	spec, err := BindingPolicySpecFrom(policy)
+	if err != nil {
+		return s, err
+	}
+	s.ClusterSelectors = spec.ClusterSelectors
+	for _, clause := range spec.Downsync {
+		s.Downsync = append(s.Downsync, DescribeClause(clause))
+	}
+	if binding != nil {
+		if destinations := BindingDestinations(binding); destinations != nil {
+			s.Clusters = destinations
+		}
+	}
+	return s, nil
+}
+
+// DescribeClause renders a downsync clause as a compact one-line summary
+func DescribeClause(clause DownsyncClause) string {
+	var parts []string
+	if clause.APIGroup != nil {
+		group := *clause.APIGroup
+		if group == "" {
+			group = "core"
+		}
+		parts = append(parts, "group="+group)
+	}
+	if len(clause.Resources) > 0 {
+		parts = append(parts, "resources="+strings.Join(clause.Resources, ","))
+	}
+	if len(clause.Namespaces) > 0 {
+		parts = append(parts, "namespaces="+strings.Join(clause.Namespaces, ","))
+	}
+	if len(clause.NamespaceSelectors) > 0 {
+		parts = append(parts, "namespaceSelector="+FormatSelectors(clause.NamespaceSelectors))
+	}
+	if len(clause.ObjectNames) > 0 {
+		parts = append(parts, "names="+strings.Join(clause.ObjectNames, ","))
+	}
+	if len(clause.ObjectSelectors) > 0 {
+		parts = append(parts, "objectSelector="+FormatSelectors(clause.ObjectSelectors))
+	}
+	if clause.CreateOnly {
+		parts = append(parts, "createOnly")
+	}
+	if len(parts) == 0 {
+		return "<all objects>"
+	}
+	return strings.Join(parts, " ")
+}
+
+// FormatSelectors renders ORed label selectors in selector syntax
+func FormatSelectors(selectors []metav1.LabelSelector) string {
+	var rendered []string
+	for i := range selectors {
+		sel, err := metav1.LabelSelectorAsSelector(&selectors[i])
+		if err != nil {
+			rendered = append(rendered, fmt.Sprintf("<invalid: %v>", err))
+			continue
+		}
+		if sel.Empty() {
+			rendered = append(rendered, "<all>")
+			continue
+		}
+		rendered = append(rendered, sel.String())
+	}
+	return strings.Join(rendered, " | ")
+}
@@ -0,0 +1,169 @@
+package kubestellar
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestSummarizeCluster(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  map[string]interface{}
+		want ClusterSummary
+	}{
+		{
+			name: "no status",
+			obj:  map[string]interface{}{"metadata": map[string]interface{}{"name": "cluster1"}},
+			want: ClusterSummary{Name: "cluster1", Available: "Unknown"},
+		},
+		{
+			name: "joined and available",
+			obj: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "cluster2", "labels": map[string]interface{}{"env": "prod"}},
+				"spec": map[string]interface{}{
+					"hubAcceptsClient":            true,
+					"managedClusterClientConfigs": []interface{}{map[string]interface{}{"url": "https://10.0.0.1:6443"}},
+				},
+				"status": map[string]interface{}{"conditions": []interface{}{
+					map[string]interface{}{"type": "ManagedClusterJoined", "status": "True"},
+					map[string]interface{}{"type": "ManagedClusterConditionAvailable", "status": "False", "reason": "Lease", "message": "lease expired"},
+				}},
+			},
+			want: ClusterSummary{
+				Name:      "cluster2",
+				Labels:    map[string]string{"env": "prod"},
+				Accepted:  true,
+				Joined:    true,
+				Available: "False",
+				Endpoint:  "https://10.0.0.1:6443",
+				Conditions: []Condition{
+					{Type: "ManagedClusterJoined", Status: "True"},
+					{Type: "ManagedClusterConditionAvailable", Status: "False", Reason: "Lease", Message: "lease expired"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SummarizeCluster(&unstructured.Unstructured{Object: tt.obj})
+			got.Created = tt.want.Created
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SummarizeCluster() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarizePolicy(t *testing.T) {
+	policy := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "web"},
+		"spec": map[string]interface{}{
+			"clusterSelectors": []interface{}{map[string]interface{}{"matchLabels": map[string]interface{}{"env": "prod"}}},
+			"downsync": []interface{}{
+				map[string]interface{}{"resources": []interface{}{"deployments"}},
+			},
+		},
+	}}
+	binding := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"destinations": []interface{}{
+			map[string]interface{}{"clusterId": "cluster1"},
+			map[string]interface{}{"clusterId": "cluster2"},
+		}},
+	}}
+	invalid := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "broken"},
+		"spec":     map[string]interface{}{"downsync": "not-a-list"},
+	}}
+
+	tests := []struct {
+		name     string
+		policy   *unstructured.Unstructured
+		binding  *unstructured.Unstructured
+		clusters []string
+		downsync []string
+		wantErr  bool
+	}{
+		{name: "without binding", policy: policy, clusters: []string{}, downsync: []string{"resources=deployments"}},
+		{name: "with binding", policy: policy, binding: binding, clusters: []string{"cluster1", "cluster2"}, downsync: []string{"resources=deployments"}},
+		{name: "invalid spec", policy: invalid, clusters: []string{}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SummarizePolicy(tt.policy, tt.binding)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SummarizePolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.Name != tt.policy.GetName() {
+				t.Errorf("SummarizePolicy() name = %q, want %q", got.Name, tt.policy.GetName())
+			}
+			if !reflect.DeepEqual(got.Clusters, tt.clusters) {
+				t.Errorf("SummarizePolicy() clusters = %v, want %v", got.Clusters, tt.clusters)
+			}
+			if !reflect.DeepEqual(got.Downsync, tt.downsync) {
+				t.Errorf("SummarizePolicy() downsync = %v, want %v", got.Downsync, tt.downsync)
+			}
+		})
+	}
+}
+
+func TestDescribeClause(t *testing.T) {
+	core := ""
+	apps := "apps"
+	tests := []struct {
+		name   string
+		clause DownsyncClause
+		want   string
+	}{
+		{name: "empty", clause: DownsyncClause{}, want: "<all objects>"},
+		{name: "core group", clause: DownsyncClause{APIGroup: &core, Resources: []string{"configmaps"}}, want: "group=core resources=configmaps"},
+		{
+			name: "everything",
+			clause: DownsyncClause{
+				APIGroup:           &apps,
+				Resources:          []string{"deployments", "statefulsets"},
+				Namespaces:         []string{"prod"},
+				NamespaceSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"team": "web"}}},
+				ObjectNames:        []string{"nginx"},
+				ObjectSelectors:    []metav1.LabelSelector{{}},
+				CreateOnly:         true,
+			},
+			want: "group=apps resources=deployments,statefulsets namespaces=prod namespaceSelector=team=web names=nginx objectSelector=<all> createOnly",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DescribeClause(tt.clause); got != tt.want {
+				t.Errorf("DescribeClause() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatSelectors(t *testing.T) {
+	tests := []struct {
+		name      string
+		selectors []metav1.LabelSelector
+		want      string
+	}{
+		{name: "none", selectors: nil, want: ""},
+		{
+			name: "ored selectors",
+			selectors: []metav1.LabelSelector{
+				{MatchLabels: map[string]string{"env": "prod"}},
+				{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "region", Operator: metav1.LabelSelectorOpIn, Values: []string{"us-east", "us-west"}}}},
+			},
+			want: "env=prod | region in (us-east,us-west)",
+		},
+		{name: "empty selector", selectors: []metav1.LabelSelector{{}}, want: "<all>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatSelectors(tt.selectors); got != tt.want {
+				t.Errorf("FormatSelectors() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package kubestellar
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// TransformRemovals returns the JSONPaths, such as $.spec.suspend, that the
+// CustomTransforms for a resource remove from its objects before they are
+// propagated, sorted
+func TransformRemovals(transforms []unstructured.Unstructured, gr schema.GroupResource) []string {
+	var paths []string
+	for i := range transforms {
+		group, _, _ := unstructured.NestedString(transforms[i].Object, "spec", "apiGroup")
+		resource, _, _ := unstructured.NestedString(transforms[i].Object, "spec", "resource")
+		if group != gr.Group || resource != gr.Resource {
+			continue
+		}
+		remove, _, _ := unstructured.NestedStringSlice(transforms[i].Object, "spec", "remove")
+		paths = append(paths, remove...)
+	}
+	sort.Strings(paths)
+	return paths
+}
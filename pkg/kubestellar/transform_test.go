@@ -0,0 +1,30 @@
+package kubestellar
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestTransformRemovals(t *testing.T) {
+	transform := func(group, resource string, remove ...interface{}) unstructured.Unstructured {
+		return unstructured.Unstructured{Object: map[string]interface{}{
+			"spec": map[string]interface{}{"apiGroup": group, "resource": resource, "remove": remove},
+		}}
+	}
+	transforms := []unstructured.Unstructured{
+		transform("batch", "jobs", "$.spec.suspend"),
+		transform("apps", "deployments", "$.spec.replicas"),
+		transform("batch", "jobs", "$.spec.completions", "$.metadata.annotations"),
+	}
+	got := TransformRemovals(transforms, schema.GroupResource{Group: "batch", Resource: "jobs"})
+	want := []string{"$.metadata.annotations", "$.spec.completions", "$.spec.suspend"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TransformRemovals() = %v, want %v", got, want)
+	}
+	if got := TransformRemovals(transforms, schema.GroupResource{Resource: "services"}); got != nil {
+		t.Errorf("TransformRemovals(services) = %v, want none", got)
+	}
+}
@@ -0,0 +1,242 @@
+// Package lock coordinates fleet operations between operators with a
+// coordination.k8s.io Lease on a control plane: a mutating command holds
+// the lease while it runs, so a second one started meanwhile is refused.
+package lock
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"kubectl-multi/pkg/util"
+)
+
+// CommandAnnotation records the command holding the lease
+const CommandAnnotation = "kubestellar.io/lock-command"
+
+// requestTimeout bounds the requests renewing and releasing the lease, so
+// an unresponsive control plane cannot hang the command holding it
+const requestTimeout = 10 * time.Second
+
+// Holder describes who holds a lease and since when
+type Holder struct {
+	Identity string
+	Command  string
+	Acquired time.Time
+	Renewed  time.Time
+	Duration time.Duration
+}
+
+// Expired reports whether the holder stopped renewing the lease long enough
+// ago for another one to take it over
+func (h Holder) Expired(now time.Time) bool {
+	return !h.Renewed.Add(h.Duration).After(now)
+}
+
+// String describes the holder, e.g. "alice@laptop (pid 42) running apply
+// since 10:04:05"
+func (h Holder) String() string {
+	s := h.Identity
+	if h.Command != "" {
+		s += " running " + h.Command
+	}
+	return s + " since " + h.Acquired.Local().Format(time.TimeOnly)
+}
+
+// HeldError is returned when another holder has the lease
+type HeldError struct {
+	Namespace, Name string
+	Holder          Holder
+}
+
+func (e *HeldError) Error() string {
+	return fmt.Sprintf("fleet lock %s/%s is held by %s", e.Namespace, e.Name, e.Holder)
+}
+
+// Lease is a lease held by this process. It is renewed in the background
+// until Release is called.
+type Lease struct {
+	client          kubernetes.Interface
+	namespace, name string
+	identity        string
+	ttl             time.Duration
+
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+// now is the clock of the lease, replaced in tests
+var now = time.Now
+
+// Acquire takes the lease namespace/name for identity, creating it when
+// missing. A lease held by someone else that has not expired fails with a
+// *HeldError; an expired one is taken over.
+func Acquire(ctx context.Context, client kubernetes.Interface, namespace, name, identity, command string, ttl time.Duration) (*Lease, error) {
+	leases := client.CoordinationV1().Leases(namespace)
+	t := metav1.NewMicroTime(now())
+	seconds := int32(ttl.Seconds())
+	spec := coordinationv1.LeaseSpec{
+		HolderIdentity:       &identity,
+		LeaseDurationSeconds: &seconds,
+		AcquireTime:          &t,
+		RenewTime:            &t,
+	}
+
+	current, err := leases.Get(ctx, name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		lease := &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Annotations: map[string]string{CommandAnnotation: command}},
+			Spec:       spec,
+		}
+		util.MarkManaged(lease)
+		_, err = leases.Create(ctx, lease, metav1.CreateOptions{FieldManager: util.FieldManager})
+		if apierrors.IsAlreadyExists(err) {
+			// Someone else created it first
+			return nil, heldError(ctx, client, namespace, name)
+		}
+	case err == nil:
+		if h := holderOf(current); h != nil && !h.Expired(now()) {
+			return nil, &HeldError{Namespace: namespace, Name: name, Holder: *h}
+		}
+		current.Spec = spec
+		if current.Annotations == nil {
+			current.Annotations = map[string]string{}
+		}
+		current.Annotations[CommandAnnotation] = command
+		util.MarkManaged(current)
+		_, err = leases.Update(ctx, current, metav1.UpdateOptions{FieldManager: util.FieldManager})
+		if apierrors.IsConflict(err) {
+			// Someone else took it over first
+			return nil, heldError(ctx, client, namespace, name)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire fleet lock %s/%s: %v", namespace, name, err)
+	}
+
+	l := &Lease{client: client, namespace: namespace, name: name, identity: identity, ttl: ttl, stop: make(chan struct{})}
+	l.done.Add(1)
+	go l.keepAlive()
+	return l, nil
+}
+
+// heldError reports the holder that won a race for the lease
+func heldError(ctx context.Context, client kubernetes.Interface, namespace, name string) error {
+	h, err := Status(ctx, client, namespace, name)
+	if err != nil || h == nil {
+		return fmt.Errorf("fleet lock %s/%s was taken concurrently", namespace, name)
+	}
+	return &HeldError{Namespace: namespace, Name: name, Holder: *h}
+}
+
+// keepAlive renews the lease at a third of its duration until stopped
+func (l *Lease) keepAlive() {
+	defer l.done.Done()
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			l.renew()
+		}
+	}
+}
+
+// renew moves the renew time of the lease forward while it is still ours
+func (l *Lease) renew() {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	leases := l.client.CoordinationV1().Leases(l.namespace)
+	current, err := leases.Get(ctx, l.name, metav1.GetOptions{})
+	if err != nil || current.Spec.HolderIdentity == nil || *current.Spec.HolderIdentity != l.identity {
+		return
+	}
+	t := metav1.NewMicroTime(now())
+	current.Spec.RenewTime = &t
+	leases.Update(ctx, current, metav1.UpdateOptions{FieldManager: util.FieldManager})
+}
+
+// Release stops renewing the lease and frees it, unless it was taken over
+// or force-released in the meantime
+func (l *Lease) Release() error {
+	close(l.stop)
+	l.done.Wait()
+
+	// Not the command's context: the lease is released also after the
+	// command was interrupted
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	leases := l.client.CoordinationV1().Leases(l.namespace)
+	current, err := leases.Get(ctx, l.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to release fleet lock %s/%s: %v", l.namespace, l.name, err)
+	}
+	if current.Spec.HolderIdentity == nil || *current.Spec.HolderIdentity != l.identity {
+		return nil
+	}
+	current.Spec.HolderIdentity = nil
+	current.Spec.AcquireTime = nil
+	current.Spec.RenewTime = nil
+	delete(current.Annotations, CommandAnnotation)
+	if _, err := leases.Update(ctx, current, metav1.UpdateOptions{FieldManager: util.FieldManager}); err != nil {
+		return fmt.Errorf("failed to release fleet lock %s/%s: %v", l.namespace, l.name, err)
+	}
+	return nil
+}
+
+// Status returns the holder of the lease, or nil when it is free
+func Status(ctx context.Context, client kubernetes.Interface, namespace, name string) (*Holder, error) {
+	current, err := client.CoordinationV1().Leases(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fleet lock %s/%s: %v", namespace, name, err)
+	}
+	return holderOf(current), nil
+}
+
+// ForceRelease frees the lease whoever holds it and returns the previous
+// holder, nil when it was free
+func ForceRelease(ctx context.Context, client kubernetes.Interface, namespace, name string) (*Holder, error) {
+	holder, err := Status(ctx, client, namespace, name)
+	if err != nil || holder == nil {
+		return holder, err
+	}
+	err = client.CoordinationV1().Leases(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to release fleet lock %s/%s: %v", namespace, name, err)
+	}
+	return holder, nil
+}
+
+// holderOf returns the holder recorded in the lease, nil when it is free
+func holderOf(lease *coordinationv1.Lease) *Holder {
+	spec := lease.Spec
+	if spec.HolderIdentity == nil || *spec.HolderIdentity == "" {
+		return nil
+	}
+	h := &Holder{Identity: *spec.HolderIdentity, Command: lease.Annotations[CommandAnnotation]}
+	if spec.AcquireTime != nil {
+		h.Acquired = spec.AcquireTime.Time
+	}
+	if spec.RenewTime != nil {
+		h.Renewed = spec.RenewTime.Time
+	}
+	if spec.LeaseDurationSeconds != nil {
+		h.Duration = time.Duration(*spec.LeaseDurationSeconds) * time.Second
+	}
+	return h
+}
@@ -0,0 +1,94 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestAcquireRelease(t *testing.T) {
+	clock := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+	client := fake.NewSimpleClientset()
+	ctx := context.TODO()
+
+	alice, err := Acquire(ctx, client, "default", "kubectl-multi", "alice@laptop (pid 1)", "apply", time.Minute)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	holder, err := Status(ctx, client, "default", "kubectl-multi")
+	if err != nil || holder == nil || holder.Identity != "alice@laptop (pid 1)" || holder.Command != "apply" {
+		t.Fatalf("Status() = %+v, %v; want alice running apply", holder, err)
+	}
+
+	// A second operator is refused while the lease is live
+	_, err = Acquire(ctx, client, "default", "kubectl-multi", "bob@desk (pid 2)", "delete", time.Minute)
+	var held *HeldError
+	if !errors.As(err, &held) || held.Holder.Identity != "alice@laptop (pid 1)" {
+		t.Fatalf("Acquire() while held error = %v, want HeldError by alice", err)
+	}
+
+	if err := alice.Release(); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if holder, err := Status(ctx, client, "default", "kubectl-multi"); err != nil || holder != nil {
+		t.Fatalf("Status() after release = %+v, %v; want free", holder, err)
+	}
+	bob, err := Acquire(ctx, client, "default", "kubectl-multi", "bob@desk (pid 2)", "delete", time.Minute)
+	if err != nil {
+		t.Fatalf("Acquire() after release error = %v", err)
+	}
+	bob.Release()
+}
+
+func TestAcquireExpired(t *testing.T) {
+	clock := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+	client := fake.NewSimpleClientset()
+	ctx := context.TODO()
+
+	crashed, err := Acquire(ctx, client, "default", "kubectl-multi", "alice@laptop (pid 1)", "apply", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Stop renewing without releasing, as a killed process would
+	close(crashed.stop)
+	crashed.done.Wait()
+
+	clock = clock.Add(2 * time.Minute)
+	holder, _ := Status(ctx, client, "default", "kubectl-multi")
+	if holder == nil || !holder.Expired(clock) {
+		t.Fatalf("Status() = %+v, want an expired holder", holder)
+	}
+	bob, err := Acquire(ctx, client, "default", "kubectl-multi", "bob@desk (pid 2)", "delete", time.Minute)
+	if err != nil {
+		t.Fatalf("Acquire() of an expired lease error = %v", err)
+	}
+	bob.Release()
+}
+
+func TestForceRelease(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	ctx := context.TODO()
+	if holder, err := ForceRelease(ctx, client, "default", "kubectl-multi"); err != nil || holder != nil {
+		t.Fatalf("ForceRelease() of a missing lease = %+v, %v", holder, err)
+	}
+
+	alice, err := Acquire(ctx, client, "default", "kubectl-multi", "alice@laptop (pid 1)", "apply", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	holder, err := ForceRelease(ctx, client, "default", "kubectl-multi")
+	if err != nil || holder == nil || holder.Identity != "alice@laptop (pid 1)" {
+		t.Fatalf("ForceRelease() = %+v, %v; want alice", holder, err)
+	}
+	// The original holder's release finds nothing left to free
+	if err := alice.Release(); err != nil {
+		t.Errorf("Release() after force release error = %v", err)
+	}
+}
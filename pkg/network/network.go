@@ -0,0 +1,122 @@
+// Package network holds the network policy of a command: the proxies the
+// clusters are reached through and whether offline mode forbids reaching
+// anything other than the clusters.
+package network
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/http/httpproxy"
+
+	"kubectl-multi/pkg/config"
+)
+
+// settings is the policy of the running command
+var settings config.NetworkConfig
+
+// Configure sets the policy of the running command from the plugin
+// configuration; offline is the --offline flag, which can only turn offline
+// mode on
+func Configure(cfg *config.NetworkConfig, offline bool) error {
+	settings = config.NetworkConfig{}
+	if cfg != nil {
+		if err := Validate(*cfg); err != nil {
+			return err
+		}
+		settings = *cfg
+	}
+	settings.Offline = settings.Offline || offline
+	return nil
+}
+
+// Validate checks the proxy URLs and context globs of the configuration
+func Validate(cfg config.NetworkConfig) error {
+	for pattern, p := range cfg.Proxies {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("network proxy context %q is not a valid glob: %v", pattern, err)
+		}
+		for _, proxy := range []string{p.HTTPProxy, p.HTTPSProxy} {
+			if proxy == "" {
+				continue
+			}
+			u, err := url.Parse(proxy)
+			if err != nil || u.Host == "" {
+				return fmt.Errorf("network proxy for %q: invalid proxy URL %q", pattern, proxy)
+			}
+			switch u.Scheme {
+			case "http", "https", "socks5":
+			default:
+				return fmt.Errorf("network proxy for %q: unsupported proxy scheme %q, must be one of http|https|socks5", pattern, u.Scheme)
+			}
+		}
+	}
+	return nil
+}
+
+// Offline reports whether network access outside the clusters is forbidden
+func Offline() bool {
+	return settings.Offline
+}
+
+// CheckOnline returns an error when offline mode forbids what, an access to
+// something other than the configured clusters
+func CheckOnline(what string) error {
+	if !settings.Offline {
+		return nil
+	}
+	return fmt.Errorf("%s needs network access outside the configured clusters, which offline mode forbids", what)
+}
+
+// ProxyFor returns the proxy the cluster of a kubeconfig context is reached
+// through. A context configured by name takes precedence over the globs
+// matching it, of which the first in lexical order is used.
+func ProxyFor(context string) (config.Proxy, bool) {
+	if context == "" {
+		return config.Proxy{}, false
+	}
+	if p, ok := settings.Proxies[context]; ok {
+		return p, true
+	}
+	patterns := make([]string, 0, len(settings.Proxies))
+	for pattern := range settings.Proxies {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, context); ok {
+			return settings.Proxies[pattern], true
+		}
+	}
+	return config.Proxy{}, false
+}
+
+// ProxyFunc returns the proxy selection of an HTTP transport for p, with
+// the semantics of the proxy environment variables
+func ProxyFunc(p config.Proxy) func(*http.Request) (*url.URL, error) {
+	proxy := (&httpproxy.Config{HTTPProxy: p.HTTPProxy, HTTPSProxy: p.HTTPSProxy, NoProxy: p.NoProxy}).ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxy(req.URL)
+	}
+}
+
+// ProxyEnv returns the environment variables setting p for a kubectl or helm
+// subprocess, in both the upper and lower case spellings tools look for
+func ProxyEnv(p config.Proxy) []string {
+	var env []string
+	for _, v := range []struct{ name, value string }{
+		{"HTTP_PROXY", p.HTTPProxy},
+		{"HTTPS_PROXY", p.HTTPSProxy},
+		{"NO_PROXY", p.NoProxy},
+	} {
+		if v.value == "" {
+			continue
+		}
+		env = append(env, v.name+"="+v.value, strings.ToLower(v.name)+"="+v.value)
+	}
+	return env
+}
@@ -0,0 +1,120 @@
+package network
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"kubectl-multi/pkg/config"
+)
+
+func TestProxyFor(t *testing.T) {
+	t.Cleanup(func() { Configure(nil, false) })
+	err := Configure(&config.NetworkConfig{Proxies: map[string]config.Proxy{
+		"edge-*":       {HTTPSProxy: "http://edge-proxy:3128"},
+		"edge-*-lab":   {HTTPSProxy: "http://lab-proxy:3128"},
+		"edge-berlin":  {HTTPSProxy: "http://berlin-proxy:3128", NoProxy: "10.0.0.0/8"},
+		"cluster-[ab]": {HTTPProxy: "socks5://jump:1080"},
+	}}, false)
+	if err != nil {
+		t.Fatalf("Configure() error = %v", err)
+	}
+	tests := []struct {
+		context string
+		want    string
+	}{
+		{context: "edge-berlin", want: "http://berlin-proxy:3128"},
+		// edge-* sorts before edge-*-lab
+		{context: "edge-paris-lab", want: "http://edge-proxy:3128"},
+		{context: "edge-paris", want: "http://edge-proxy:3128"},
+		{context: "cluster-a", want: "socks5://jump:1080"},
+		{context: "cluster-c"},
+		{context: ""},
+	}
+	for _, tt := range tests {
+		proxy, ok := ProxyFor(tt.context)
+		got := proxy.HTTPSProxy + proxy.HTTPProxy
+		if ok != (tt.want != "") || got != tt.want {
+			t.Errorf("ProxyFor(%q) = %+v, %v, want %q", tt.context, proxy, ok, tt.want)
+		}
+	}
+}
+
+func TestProxyFunc(t *testing.T) {
+	proxy := ProxyFunc(config.Proxy{HTTPSProxy: "http://proxy:3128", NoProxy: "10.0.0.0/8,.internal"})
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "https://api.edge.example.com:6443", want: "http://proxy:3128"},
+		{url: "https://10.1.2.3:6443"},
+		{url: "https://api.cluster.internal:6443"},
+		// Only the HTTPS proxy is set
+		{url: "http://api.edge.example.com"},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+		got, err := proxy(req)
+		if err != nil {
+			t.Fatalf("proxy(%s) error = %v", tt.url, err)
+		}
+		if (got == nil && tt.want != "") || (got != nil && got.String() != tt.want) {
+			t.Errorf("proxy(%s) = %v, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestProxyEnv(t *testing.T) {
+	got := ProxyEnv(config.Proxy{HTTPSProxy: "http://proxy:3128", NoProxy: "localhost"})
+	want := []string{"HTTPS_PROXY=http://proxy:3128", "https_proxy=http://proxy:3128", "NO_PROXY=localhost", "no_proxy=localhost"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ProxyEnv() = %v, want %v", got, want)
+	}
+}
+
+func TestConfigureOffline(t *testing.T) {
+	t.Cleanup(func() { Configure(nil, false) })
+	for _, tt := range []struct {
+		cfg     *config.NetworkConfig
+		flag    bool
+		offline bool
+	}{
+		{cfg: nil, flag: false, offline: false},
+		{cfg: nil, flag: true, offline: true},
+		{cfg: &config.NetworkConfig{Offline: true}, flag: false, offline: true},
+	} {
+		if err := Configure(tt.cfg, tt.flag); err != nil {
+			t.Fatalf("Configure() error = %v", err)
+		}
+		if Offline() != tt.offline {
+			t.Errorf("Configure(%+v, %v): Offline() = %v, want %v", tt.cfg, tt.flag, Offline(), tt.offline)
+		}
+		if err := CheckOnline("reading https://example.com/app.yaml"); (err != nil) != tt.offline {
+			t.Errorf("CheckOnline() error = %v, want error %v", err, tt.offline)
+		} else if err != nil && !strings.Contains(err.Error(), "offline mode forbids") {
+			t.Errorf("CheckOnline() error = %v", err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxies map[string]config.Proxy
+		wantErr string
+	}{
+		{name: "valid", proxies: map[string]config.Proxy{"edge-*": {HTTPProxy: "http://proxy:3128", HTTPSProxy: "https://proxy:3129"}}},
+		{name: "bad glob", proxies: map[string]config.Proxy{"edge-[": {HTTPSProxy: "http://proxy:3128"}}, wantErr: "not a valid glob"},
+		{name: "no host", proxies: map[string]config.Proxy{"edge": {HTTPSProxy: "proxy:3128"}}, wantErr: "invalid proxy URL"},
+		{name: "bad scheme", proxies: map[string]config.Proxy{"edge": {HTTPSProxy: "ftp://proxy:21"}}, wantErr: "unsupported proxy scheme"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(config.NetworkConfig{Proxies: tt.proxies})
+			if (err == nil) != (tt.wantErr == "") || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package platform
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// Detach makes cmd run in a session of its own, so that it outlives the
+// terminal it was started from
+func Detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
@@ -0,0 +1,16 @@
+//go:build windows
+
+package platform
+
+import (
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// Detach makes cmd run without a console of its own, so that it outlives
+// the terminal it was started from
+func Detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: windows.CREATE_NEW_PROCESS_GROUP | windows.DETACHED_PROCESS}
+}
@@ -0,0 +1,58 @@
+package platform
+
+import (
+	"os"
+	"sync"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// Kubeconfig is a kubeconfig held in memory, such as one read from a
+// KubeFlex secret. Clients are built from the bytes; a file is written only
+// when a child process such as kubectl or helm needs a path, so read-only
+// containers work for everything else.
+type Kubeconfig struct {
+	Name string
+	Data []byte
+
+	mu   sync.Mutex
+	path string
+}
+
+// NewKubeconfig returns an in-memory kubeconfig called name
+func NewKubeconfig(name string, data []byte) *Kubeconfig {
+	return &Kubeconfig{Name: name, Data: data}
+}
+
+// RESTConfig builds a client configuration from the current context
+func (k *Kubeconfig) RESTConfig() (*rest.Config, error) {
+	return clientcmd.RESTConfigFromKubeConfig(k.Data)
+}
+
+// Path writes the kubeconfig to a temporary file, once, and returns its path
+func (k *Kubeconfig) Path() (string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.path != "" {
+		return k.path, nil
+	}
+	path, err := WriteTemp(k.Name+"-kubeconfig-*.yaml", k.Data)
+	if err != nil {
+		return "", err
+	}
+	k.path = path
+	return path, nil
+}
+
+// Remove deletes the file written by Path, if any
+func (k *Kubeconfig) Remove() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.path == "" {
+		return nil
+	}
+	err := os.Remove(k.path)
+	k.path = ""
+	return err
+}
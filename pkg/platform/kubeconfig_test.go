@@ -0,0 +1,83 @@
+package platform
+
+import (
+	"os"
+	"testing"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: its1
+  cluster:
+    server: https://its1.example:6443
+contexts:
+- name: its1
+  context:
+    cluster: its1
+    user: admin
+current-context: its1
+users:
+- name: admin
+  user:
+    token: secret
+`
+
+func TestKubeconfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		wantHost   string
+		wantErr    bool
+		writeTwice bool
+	}{
+		{name: "valid", data: testKubeconfig, wantHost: "https://its1.example:6443"},
+		{name: "path written once", data: testKubeconfig, wantHost: "https://its1.example:6443", writeTwice: true},
+		{name: "invalid", data: "clusters: [", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EnvTempDir, t.TempDir())
+			k := NewKubeconfig("its1", []byte(tt.data))
+
+			cfg, err := k.RESTConfig()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RESTConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if cfg.Host != tt.wantHost {
+				t.Errorf("Host = %q, want %q", cfg.Host, tt.wantHost)
+			}
+			if k.path != "" {
+				t.Errorf("RESTConfig() wrote %s, want no file", k.path)
+			}
+
+			path, err := k.Path()
+			if err != nil {
+				t.Fatalf("Path() error = %v", err)
+			}
+			if tt.writeTwice {
+				again, err := k.Path()
+				if err != nil || again != path {
+					t.Errorf("second Path() = %q, %v, want %q", again, err, path)
+				}
+			}
+			got, err := os.ReadFile(path)
+			if err != nil || string(got) != tt.data {
+				t.Errorf("file holds %q, %v, want the kubeconfig", got, err)
+			}
+
+			if err := k.Remove(); err != nil {
+				t.Fatalf("Remove() error = %v", err)
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("%s still exists after Remove()", path)
+			}
+			if err := k.Remove(); err != nil {
+				t.Errorf("second Remove() error = %v", err)
+			}
+		})
+	}
+}
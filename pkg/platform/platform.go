@@ -0,0 +1,82 @@
+// Package platform hides the differences between the systems the plugin runs
+// on: Linux and macOS shells, Windows workstations, and containers with a
+// read-only file system.
+package platform
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// EnvTempDir overrides the directory temporary files are written to
+const EnvTempDir = "KUBECTL_MULTI_TMPDIR"
+
+// tempDirs lists the directories tried for temporary files, in order
+func tempDirs() []string {
+	var dirs []string
+	if dir := os.Getenv(EnvTempDir); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	dirs = append(dirs, os.TempDir())
+	if cache, err := os.UserCacheDir(); err == nil {
+		dirs = append(dirs, filepath.Join(cache, "kubectl-multi", "tmp"))
+	}
+	return dirs
+}
+
+// CreateTemp creates a temporary file, readable only by the user, in the
+// first writable directory of $KUBECTL_MULTI_TMPDIR, the system temporary
+// directory and the user cache directory. The caller removes the file.
+func CreateTemp(pattern string) (*os.File, error) {
+	var errs []error
+	for _, dir := range tempDirs() {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		f, err := os.CreateTemp(dir, pattern)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		return f, nil
+	}
+	return nil, fmt.Errorf("no writable directory for temporary files (set %s): %v", EnvTempDir, errs)
+}
+
+// WriteTemp writes data to a new temporary file and returns its path
+func WriteTemp(pattern string, data []byte) (string, error) {
+	f, err := CreateTemp(pattern)
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// LockFile takes an exclusive lock on path+".lock", waiting for other
+// kubectl-multi processes to release it, and returns the function that
+// releases it
+func LockFile(path string) (func(), error) {
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %v", err)
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock %s: %v", path, err)
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
@@ -0,0 +1,194 @@
+package platform
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// blockTempDir points every temporary directory variable at a regular file,
+// so creating files below it fails the way a read-only /tmp does, even when
+// the tests run as root
+func blockTempDir(t *testing.T) {
+	blocked := filepath.Join(t.TempDir(), "not-a-dir")
+	if err := os.WriteFile(blocked, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, env := range []string{"TMPDIR", "TMP", "TEMP"} {
+		t.Setenv(env, blocked)
+	}
+}
+
+// setCacheDir points the user cache directory at dir on every platform
+func setCacheDir(t *testing.T, dir string) string {
+	t.Setenv("XDG_CACHE_HOME", dir)
+	t.Setenv("LocalAppData", dir)
+	t.Setenv("HOME", dir)
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(cache, "kubectl-multi", "tmp")
+}
+
+func TestCreateTemp(t *testing.T) {
+	tests := []struct {
+		name          string
+		override      bool
+		blockOverride bool
+		blockSystem   bool
+		blockCache    bool
+		wantDir       string // override, system or cache
+		wantErr       bool
+	}{
+		{name: "system temp dir", wantDir: "system"},
+		{name: "override", override: true, wantDir: "override"},
+		{name: "unusable override", override: true, blockOverride: true, wantDir: "system"},
+		{name: "read-only temp dir", blockSystem: true, wantDir: "cache"},
+		{name: "nothing writable", blockSystem: true, blockCache: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			dirs := map[string]string{"override": filepath.Join(root, "override")}
+			t.Setenv(EnvTempDir, "")
+			if tt.override {
+				t.Setenv(EnvTempDir, dirs["override"])
+				if tt.blockOverride {
+					if err := os.WriteFile(dirs["override"], nil, 0600); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			if tt.blockSystem {
+				blockTempDir(t)
+			}
+			dirs["system"] = os.TempDir()
+			cacheRoot := filepath.Join(root, "cache")
+			if tt.blockCache {
+				if err := os.WriteFile(cacheRoot, nil, 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			dirs["cache"] = setCacheDir(t, cacheRoot)
+
+			f, err := CreateTemp("test-*.yaml")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CreateTemp() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !strings.Contains(err.Error(), EnvTempDir) {
+					t.Errorf("error %q does not mention %s", err, EnvTempDir)
+				}
+				return
+			}
+			f.Close()
+			defer os.Remove(f.Name())
+			if got := filepath.Dir(f.Name()); got != filepath.Clean(dirs[tt.wantDir]) {
+				t.Errorf("CreateTemp() in %s, want %s (%s)", got, dirs[tt.wantDir], tt.wantDir)
+			}
+		})
+	}
+}
+
+func TestWriteTemp(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		block   bool
+		wantErr bool
+	}{
+		{name: "writes data", data: []byte("kind: Config\n")},
+		{name: "empty", data: nil},
+		{name: "no writable directory", block: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EnvTempDir, t.TempDir())
+			if tt.block {
+				blocked := filepath.Join(t.TempDir(), "blocked")
+				if err := os.WriteFile(blocked, nil, 0600); err != nil {
+					t.Fatal(err)
+				}
+				t.Setenv(EnvTempDir, blocked)
+				blockTempDir(t)
+				setCacheDir(t, blocked)
+			}
+			path, err := WriteTemp("test-*.yaml", tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WriteTemp() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			defer os.Remove(path)
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(tt.data) {
+				t.Errorf("file holds %q, want %q", got, tt.data)
+			}
+		})
+	}
+}
+
+func TestLockFile(t *testing.T) {
+	tests := []struct {
+		name      string
+		holders   int
+		wantError bool
+	}{
+		{name: "uncontended", holders: 1},
+		{name: "second holder waits", holders: 2},
+		{name: "missing directory", wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "audit.jsonl")
+			if tt.wantError {
+				path = filepath.Join(t.TempDir(), "missing", "audit.jsonl")
+				if _, err := LockFile(path); err == nil {
+					t.Fatal("LockFile() succeeded without a directory")
+				}
+				return
+			}
+
+			unlock, err := LockFile(path)
+			if err != nil {
+				t.Fatalf("LockFile() error = %v", err)
+			}
+			if tt.holders == 1 {
+				unlock()
+				return
+			}
+
+			acquired := make(chan func())
+			go func() {
+				next, err := LockFile(path)
+				if err != nil {
+					t.Errorf("second LockFile() error = %v", err)
+					close(acquired)
+					return
+				}
+				acquired <- next
+			}()
+			select {
+			case <-acquired:
+				t.Fatal("second holder took the lock while the first held it")
+			case <-time.After(50 * time.Millisecond):
+			}
+			unlock()
+			select {
+			case next := <-acquired:
+				if next != nil {
+					next()
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("second holder never took the released lock")
+			}
+		})
+	}
+}
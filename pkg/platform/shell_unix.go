@@ -0,0 +1,14 @@
+//go:build !windows
+
+package platform
+
+import "os/exec"
+
+// DefaultEditor is used when neither $KUBE_EDITOR nor $EDITOR is set
+const DefaultEditor = "vi"
+
+// ShellCommand runs command, which may carry its own arguments as in
+// EDITOR="code --wait", with path as its last argument
+func ShellCommand(command, path string) *exec.Cmd {
+	return exec.Command("sh", "-c", command+` "$1"`, "sh", path)
+}
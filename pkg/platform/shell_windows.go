@@ -0,0 +1,20 @@
+//go:build windows
+
+package platform
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// DefaultEditor is used when neither $KUBE_EDITOR nor $EDITOR is set
+const DefaultEditor = "notepad"
+
+// ShellCommand runs command, which may carry its own arguments as in
+// EDITOR="code --wait", with path as its last argument. cmd.exe parses its
+// own command line, so it is passed verbatim instead of quoted per argument.
+func ShellCommand(command, path string) *exec.Cmd {
+	cmd := exec.Command("cmd")
+	cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: `/S /C "` + command + ` "` + path + `""`}
+	return cmd
+}
@@ -0,0 +1,206 @@
+// Package printers holds the table columns 'kubectl multi get' prints for
+// resource types it has no built-in table for, such as a team's own CRDs.
+// Columns come from the printers file next to the plugin config, or are
+// registered from Go by builds embedding the plugin.
+package printers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/jsonpath"
+	kubectlget "k8s.io/kubectl/pkg/cmd/get"
+	"sigs.k8s.io/yaml"
+)
+
+// Column is one table column, filled from a JSONPath of the object such as
+// .spec.replicas or {.status.conditions[?(@.type=="Ready")].status}
+type Column struct {
+	Name     string `json:"name"`
+	JSONPath string `json:"jsonPath"`
+	// Wide columns are only printed with -o wide
+	Wide bool `json:"wide,omitempty"`
+}
+
+// Printer defines the columns of one resource type. They are printed
+// between the NAME and AGE columns of get.
+type Printer struct {
+	// Resource is the plural resource with its group, e.g.
+	// widgets.example.com, or the bare plural for the core group
+	Resource string   `json:"resource"`
+	Columns  []Column `json:"columns"`
+}
+
+// File is the content of the printers file
+type File struct {
+	Printers []Printer `json:"printers"`
+}
+
+var (
+	mu         sync.RWMutex
+	registered = map[string]Printer{}
+)
+
+// Register adds the columns of a resource type, replacing any registered
+// before for it. Definitions of the printers file take precedence.
+func Register(p Printer) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	registered[p.Resource] = p
+	return nil
+}
+
+// Validate checks that the printer names its resource and has columns with
+// a name and a valid JSONPath
+func (p Printer) Validate() error {
+	if p.Resource == "" {
+		return fmt.Errorf("printer has no resource")
+	}
+	if len(p.Columns) == 0 {
+		return fmt.Errorf("printer for %s has no columns", p.Resource)
+	}
+	seen := map[string]bool{}
+	for _, c := range p.Columns {
+		if c.Name == "" || c.JSONPath == "" {
+			return fmt.Errorf("printer for %s has a column without a name or jsonPath", p.Resource)
+		}
+		name := strings.ToUpper(c.Name)
+		if seen[name] {
+			return fmt.Errorf("printer for %s has column %s twice", p.Resource, name)
+		}
+		seen[name] = true
+		if _, err := parseJSONPath(c.JSONPath); err != nil {
+			return fmt.Errorf("printer for %s column %s: %v", p.Resource, c.Name, err)
+		}
+	}
+	return nil
+}
+
+// Load reads the printers file, returning no printers if it does not exist
+func Load(path string) ([]Printer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read printers %s: %v", path, err)
+	}
+	var f File
+	if err := yaml.UnmarshalStrict(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse printers %s: %v", path, err)
+	}
+	for _, p := range f.Printers {
+		if err := p.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid printers %s: %v", path, err)
+		}
+	}
+	return f.Printers, nil
+}
+
+// Lookup returns the printer of gvr among fromFile, then among the
+// registered printers
+func Lookup(gvr schema.GroupVersionResource, fromFile []Printer) (Printer, bool) {
+	resource := gvr.GroupResource().String()
+	for _, p := range fromFile {
+		if p.Resource == resource {
+			return p, true
+		}
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	p, ok := registered[resource]
+	return p, ok
+}
+
+// Table is a printer ready to print rows
+type Table struct {
+	names []string
+	paths []*jsonpath.JSONPath
+}
+
+// Table parses the JSONPaths of the columns printed at the given width.
+// JSONPath evaluation is stateful, so a Table must not be shared between
+// goroutines.
+func (p Printer) Table(wide bool) (*Table, error) {
+	t := &Table{}
+	for _, c := range p.Columns {
+		if c.Wide && !wide {
+			continue
+		}
+		jp, err := parseJSONPath(c.JSONPath)
+		if err != nil {
+			return nil, fmt.Errorf("printer for %s column %s: %v", p.Resource, c.Name, err)
+		}
+		t.names = append(t.names, strings.ToUpper(c.Name))
+		t.paths = append(t.paths, jp)
+	}
+	return t, nil
+}
+
+// Columns returns the column headers
+func (t *Table) Columns() []string {
+	return t.names
+}
+
+// Row returns the column values of obj. A path matching nothing prints as
+// <none>, and several matches are separated by commas as kubectl's
+// custom-columns do.
+func (t *Table) Row(obj *unstructured.Unstructured) []string {
+	row := make([]string, len(t.paths))
+	for i, jp := range t.paths {
+		row[i] = "<none>"
+		results, err := jp.FindResults(obj.Object)
+		if err != nil {
+			continue
+		}
+		var values []string
+		for _, result := range results {
+			for _, v := range result {
+				values = append(values, formatValue(v))
+			}
+		}
+		if len(values) > 0 {
+			row[i] = strings.Join(values, ",")
+		}
+	}
+	return row
+}
+
+func parseJSONPath(path string) (*jsonpath.JSONPath, error) {
+	expr, err := kubectlget.RelaxedJSONPathExpression(path)
+	if err != nil {
+		return nil, err
+	}
+	jp := jsonpath.New("column").AllowMissingKeys(true)
+	if err := jp.Parse(expr); err != nil {
+		return nil, fmt.Errorf("invalid jsonPath %s: %v", path, err)
+	}
+	return jp, nil
+}
+
+// formatValue prints scalars as they are and maps and lists as JSON
+func formatValue(v reflect.Value) string {
+	if v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	if !v.IsValid() || (v.Kind() == reflect.Interface && v.IsNil()) {
+		return "<none>"
+	}
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice:
+		data, err := json.Marshal(v.Interface())
+		if err == nil {
+			return string(data)
+		}
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,111 @@
+package printers
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var widgetGVR = schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+
+func TestTableRow(t *testing.T) {
+	p := Printer{Resource: "widgets.example.com", Columns: []Column{
+		{Name: "size", JSONPath: ".spec.size"},
+		{Name: "Ready", JSONPath: `{.status.conditions[?(@.type=="Ready")].status}`},
+		{Name: "zones", JSONPath: ".spec.zones[*]"},
+		{Name: "selector", JSONPath: ".spec.selector", Wide: true},
+		{Name: "owner", JSONPath: ".spec.owner"},
+	}}
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"size":     int64(3),
+			"zones":    []interface{}{"a", "b"},
+			"selector": map[string]interface{}{"app": "web"},
+		},
+		"status": map[string]interface{}{"conditions": []interface{}{
+			map[string]interface{}{"type": "Synced", "status": "False"},
+			map[string]interface{}{"type": "Ready", "status": "True"},
+		}},
+	}}
+
+	table, err := p.Table(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"SIZE", "READY", "ZONES", "OWNER"}; !reflect.DeepEqual(table.Columns(), want) {
+		t.Errorf("Columns() = %q, want %q", table.Columns(), want)
+	}
+	if got, want := table.Row(obj), []string{"3", "True", "a,b", "<none>"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Row() = %q, want %q", got, want)
+	}
+
+	wide, err := p.Table(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := wide.Row(obj)[3]; got != `{"app":"web"}` {
+		t.Errorf("wide Row() selector = %q, want it as JSON", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		printer Printer
+		wantErr string
+	}{
+		{name: "no resource", printer: Printer{Columns: []Column{{Name: "a", JSONPath: ".a"}}}, wantErr: "no resource"},
+		{name: "no columns", printer: Printer{Resource: "widgets.example.com"}, wantErr: "no columns"},
+		{name: "unnamed", printer: Printer{Resource: "widgets.example.com", Columns: []Column{{JSONPath: ".a"}}}, wantErr: "without a name"},
+		{name: "twice", printer: Printer{Resource: "widgets.example.com", Columns: []Column{{Name: "a", JSONPath: ".a"}, {Name: "A", JSONPath: ".b"}}}, wantErr: "column A twice"},
+		{name: "bad path", printer: Printer{Resource: "widgets.example.com", Columns: []Column{{Name: "a", JSONPath: "{.a[}"}}}, wantErr: "column a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.printer.Validate(); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	defer func() { registered = map[string]Printer{} }()
+	if err := Register(Printer{Resource: "widgets.example.com", Columns: []Column{{Name: "size", JSONPath: ".spec.size"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if p, ok := Lookup(widgetGVR, nil); !ok || p.Columns[0].Name != "size" {
+		t.Errorf("Lookup() of a registered printer = %+v, %t", p, ok)
+	}
+
+	path := filepath.Join(t.TempDir(), "printers.yaml")
+	defs := "printers:\n- resource: widgets.example.com\n  columns:\n  - name: color\n    jsonPath: .spec.color\n"
+	if err := os.WriteFile(path, []byte(defs), 0600); err != nil {
+		t.Fatal(err)
+	}
+	fromFile, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, ok := Lookup(widgetGVR, fromFile); !ok || p.Columns[0].Name != "color" {
+		t.Errorf("Lookup() = %+v, %t, want the file to take precedence", p, ok)
+	}
+	if _, ok := Lookup(schema.GroupVersionResource{Group: "other.com", Version: "v1", Resource: "widgets"}, fromFile); ok {
+		t.Error("Lookup() matched a resource of another group")
+	}
+
+	if printers, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err != nil || printers != nil {
+		t.Errorf("Load() of a missing file = %v, %v", printers, err)
+	}
+	if err := os.WriteFile(path, []byte("printers:\n- resource: widgets.example.com\n  colums: []\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Load() of a misspelled field succeeded")
+	}
+}
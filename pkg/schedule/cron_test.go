@@ -0,0 +1,49 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	// Saturday
+	from := time.Date(2026, 10, 17, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{expr: "0 2 * * *", want: time.Date(2026, 10, 18, 2, 0, 0, 0, time.UTC)},
+		{expr: "*/15 * * * *", want: time.Date(2026, 10, 17, 10, 15, 0, 0, time.UTC)},
+		{expr: "5/20 10 * * *", want: time.Date(2026, 10, 17, 10, 25, 0, 0, time.UTC)},
+		{expr: "30 9 * * mon-fri", want: time.Date(2026, 10, 19, 9, 30, 0, 0, time.UTC)},
+		{expr: "0 0 * * 7", want: time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 12 1,15 * *", want: time.Date(2026, 11, 1, 12, 0, 0, 0, time.UTC)},
+		{expr: "0 0 1 jan *", want: time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: either one matching is due
+		{expr: "0 0 20 * sun", want: time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		{expr: "@hourly", want: time.Date(2026, 10, 17, 11, 0, 0, 0, time.UTC)},
+		{expr: "@monthly", want: time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 30 2 *", want: time.Time{}},
+	}
+	for _, tt := range tests {
+		spec, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.expr, err)
+			continue
+		}
+		if got := spec.Next(from); !got.Equal(tt.want) {
+			t.Errorf("Parse(%q).Next() = %v, want %v", tt.expr, got, tt.want)
+		}
+		if !tt.want.IsZero() && !spec.Matches(tt.want) {
+			t.Errorf("Parse(%q).Matches(%v) = false", tt.expr, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "0 0 0 * *", "* * * 13 *", "*/0 * * * *", "5-1 * * * *", "0 0 * * funday", "@sometimes"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", expr)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package schedule
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"kubectl-multi/pkg/platform"
+)
+
+// Task is a kubectl-multi command run on a cron schedule
+type Task struct {
+	Name     string `json:"name"`
+	Schedule string `json:"schedule"`
+	// Args are the kubectl-multi arguments of the command, e.g.
+	// [get pods -A -o json]
+	Args []string `json:"args"`
+	// Dir is the working directory the command runs in, so relative paths
+	// resolve as they did when the task was added
+	Dir     string    `json:"dir,omitempty"`
+	Created time.Time `json:"created"`
+	// LastRun is the outcome of the latest run, nil before the first one
+	LastRun *Run `json:"lastRun,omitempty"`
+}
+
+// Run is the outcome of one run of a task
+type Run struct {
+	Started    time.Time `json:"started"`
+	DurationMs int64     `json:"durationMs"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// file is the layout of the schedules file
+type file struct {
+	Tasks []Task `json:"tasks"`
+}
+
+// Load returns the tasks in the schedules file at path, sorted by name,
+// none when the file does not exist yet
+func Load(path string) ([]Task, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read schedules %s: %v", path, err)
+	}
+	var f file
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse schedules %s: %v", path, err)
+	}
+	sort.Slice(f.Tasks, func(i, j int) bool { return f.Tasks[i].Name < f.Tasks[j].Name })
+	return f.Tasks, nil
+}
+
+// Update reads the tasks at path, lets change modify them and writes them
+// back, holding a file lock so the scheduler recording a run and a user
+// adding a task do not overwrite each other
+func Update(path string, change func([]Task) ([]Task, error)) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create schedules directory: %v", err)
+	}
+	unlock, err := platform.LockFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	tasks, err := Load(path)
+	if err != nil {
+		return err
+	}
+	tasks, err = change(tasks)
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(file{Tasks: tasks})
+	if err != nil {
+		return fmt.Errorf("failed to encode schedules: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write schedules %s: %v", path, err)
+	}
+	return nil
+}
+
+// Find returns the task with the given name, nil when there is none
+func Find(tasks []Task, name string) *Task {
+	for i := range tasks {
+		if tasks[i].Name == name {
+			return &tasks[i]
+		}
+	}
+	return nil
+}
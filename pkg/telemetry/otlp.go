@@ -0,0 +1,160 @@
+package telemetry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OTLP/HTTP JSON encoding of the trace export request, limited to the fields
+// this plugin emits.
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+}
+
+const (
+	spanKindInternal = 1
+	spanKindClient   = 3
+	statusOK         = 1
+	statusError      = 2
+)
+
+// ExportOTLP sends the command span and one child span per API request to an
+// OTLP/HTTP collector, e.g. http://localhost:4318
+func (c *Collector) ExportOTLP(endpoint string, end time.Time, cmdErr error) error {
+	root := otlpSpan{
+		TraceID:           c.TraceID,
+		SpanID:            c.RootID,
+		Name:              "kubectl multi " + c.Command,
+		Kind:              spanKindInternal,
+		StartTimeUnixNano: unixNano(c.Start),
+		EndTimeUnixNano:   unixNano(end),
+		Status:            otlpStatus{Code: statusOK},
+	}
+	if cmdErr != nil {
+		root.Status = otlpStatus{Code: statusError, Message: cmdErr.Error()}
+	}
+
+	spans := []otlpSpan{root}
+	for _, s := range c.Spans() {
+		span := otlpSpan{
+			TraceID:           c.TraceID,
+			SpanID:            s.ID,
+			ParentSpanID:      c.RootID,
+			Name:              s.Name,
+			Kind:              spanKindClient,
+			StartTimeUnixNano: unixNano(s.Start),
+			EndTimeUnixNano:   unixNano(s.End),
+			Attributes: []otlpAttribute{
+				stringAttr("kubestellar.cluster", s.Cluster),
+				stringAttr("http.request.method", s.Method),
+				stringAttr("url.path", s.URL),
+			},
+			Status: otlpStatus{Code: statusOK},
+		}
+		if s.Program != "" {
+			span.Kind = spanKindInternal
+			span.Attributes = []otlpAttribute{
+				stringAttr("kubestellar.cluster", s.Cluster),
+				stringAttr("process.executable.name", s.Program),
+				stringAttr("process.command", s.Subcommand),
+			}
+		}
+		if s.StatusCode != 0 {
+			span.Attributes = append(span.Attributes, intAttr("http.response.status_code", s.StatusCode))
+		}
+		if s.Error != "" || s.StatusCode >= 500 {
+			span.Status = otlpStatus{Code: statusError, Message: s.Error}
+		}
+		spans = append(spans, span)
+	}
+
+	req := otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: []otlpAttribute{
+			stringAttr("service.name", "kubectl-multi"),
+		}},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: "kubectl-multi"},
+			Spans: spans,
+		}},
+	}}}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to encode traces: %v", err)
+	}
+
+	url := strings.TrimSuffix(endpoint, "/") + "/v1/traces"
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to export traces to %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("failed to export traces to %s: %s", url, resp.Status)
+	}
+	return nil
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func stringAttr(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpValue{StringValue: &value}}
+}
+
+func intAttr(key string, value int) otlpAttribute {
+	v := strconv.Itoa(value)
+	return otlpAttribute{Key: key, Value: otlpValue{IntValue: &v}}
+}
@@ -0,0 +1,245 @@
+// Package telemetry counts and times the API calls, and the kubectl or helm
+// subprocesses, made for each cluster, for the --metrics-json summary and for
+// OTLP trace export.
+package telemetry
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// EnvOTLPEndpoint is the standard OpenTelemetry variable naming the collector
+const EnvOTLPEndpoint = "OTEL_EXPORTER_OTLP_ENDPOINT"
+
+// ClusterStats summarizes the API traffic to one cluster
+type ClusterStats struct {
+	Cluster    string  `json:"cluster"`
+	Calls      int     `json:"apiCalls"`
+	Errors     int     `json:"errors"`
+	DurationMS float64 `json:"durationMs"`
+	APITimeMS  float64 `json:"apiTimeMs"`
+	SlowestMS  float64 `json:"slowestCallMs"`
+	// Subprocesses counts kubectl and helm runs, whose own API calls are not
+	// seen by the plugin and are only timed as a whole
+	Subprocesses   int     `json:"subprocesses,omitempty"`
+	SubprocessTime float64 `json:"subprocessTimeMs,omitempty"`
+
+	first time.Time
+	last  time.Time
+}
+
+// Summary is the --metrics-json document
+type Summary struct {
+	Command    string    `json:"command"`
+	Start      time.Time `json:"start"`
+	DurationMS float64   `json:"durationMs"`
+	Calls      int       `json:"apiCalls"`
+	Errors     int       `json:"errors"`
+	// Subprocesses counts kubectl and helm runs across all clusters
+	Subprocesses int            `json:"subprocesses,omitempty"`
+	Error        string         `json:"error,omitempty"`
+	Clusters     []ClusterStats `json:"clusters"`
+}
+
+// Span is one timed API request, or one kubectl or helm subprocess when
+// Program is set
+type Span struct {
+	ID         string
+	Name       string
+	Cluster    string
+	Method     string
+	URL        string
+	StatusCode int
+	Program    string
+	Subcommand string
+	Error      string
+	Start      time.Time
+	End        time.Time
+}
+
+// Collector gathers the stats and spans of one CLI invocation
+type Collector struct {
+	Command string
+	TraceID string
+	RootID  string
+	Start   time.Time
+
+	mu       sync.Mutex
+	clusters map[string]*ClusterStats
+	spans    []Span
+	tracing  bool
+}
+
+var active *Collector
+
+// Enable starts collecting for the given command. Spans are only kept when
+// tracing is true.
+func Enable(command string, tracing bool) *Collector {
+	active = &Collector{
+		Command:  command,
+		TraceID:  randomHex(16),
+		RootID:   randomHex(8),
+		Start:    time.Now(),
+		clusters: map[string]*ClusterStats{},
+		tracing:  tracing,
+	}
+	return active
+}
+
+// Active returns the running collector, or nil when telemetry is off
+func Active() *Collector {
+	return active
+}
+
+// WrapTransport returns a rest.Config WrapTransport hook that attributes
+// requests to the named cluster. It returns nil when telemetry is off.
+func WrapTransport(cluster string) func(http.RoundTripper) http.RoundTripper {
+	c := active
+	if c == nil {
+		return nil
+	}
+	return func(rt http.RoundTripper) http.RoundTripper {
+		return &instrumentedTransport{next: rt, cluster: cluster, collector: c}
+	}
+}
+
+type instrumentedTransport struct {
+	next      http.RoundTripper
+	cluster   string
+	collector *Collector
+}
+
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	span := Span{
+		Name:    "HTTP " + req.Method,
+		Cluster: t.cluster,
+		Method:  req.Method,
+		URL:     req.URL.Path,
+		Start:   start,
+		End:     time.Now(),
+	}
+	if err != nil {
+		span.Error = err.Error()
+	} else {
+		span.StatusCode = resp.StatusCode
+	}
+	t.collector.observe(span)
+	return resp, err
+}
+
+// ObserveCommand records one finished kubectl or helm subprocess run for a
+// cluster. Args are the full command line, program first. It does nothing
+// when telemetry is off.
+func ObserveCommand(cluster string, args []string, start time.Time, err error) {
+	c := active
+	if c == nil || len(args) == 0 {
+		return
+	}
+	span := Span{
+		Name:    "exec " + args[0],
+		Cluster: cluster,
+		Program: args[0],
+		Start:   start,
+		End:     time.Now(),
+	}
+	if len(args) > 1 {
+		span.Subcommand = args[1]
+		span.Name += " " + args[1]
+	}
+	if err != nil {
+		span.Error = err.Error()
+	}
+	c.observe(span)
+}
+
+// observe records one finished request or subprocess. Transport failures and 5xx responses
+// count as errors; 4xx responses such as NotFound are normal API answers.
+func (c *Collector) observe(span Span) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats, ok := c.clusters[span.Cluster]
+	if !ok {
+		stats = &ClusterStats{Cluster: span.Cluster, first: span.Start}
+		c.clusters[span.Cluster] = stats
+	}
+	elapsed := milliseconds(span.End.Sub(span.Start))
+	if span.Error != "" || span.StatusCode >= 500 {
+		stats.Errors++
+	}
+	if span.Program != "" {
+		stats.Subprocesses++
+		stats.SubprocessTime += elapsed
+	} else {
+		stats.Calls++
+		stats.APITimeMS += elapsed
+		if elapsed > stats.SlowestMS {
+			stats.SlowestMS = elapsed
+		}
+	}
+	if span.Start.Before(stats.first) {
+		stats.first = span.Start
+	}
+	if span.End.After(stats.last) {
+		stats.last = span.End
+	}
+
+	if c.tracing {
+		span.ID = randomHex(8)
+		c.spans = append(c.spans, span)
+	}
+}
+
+// Summary returns the per-cluster stats, slowest cluster first
+func (c *Collector) Summary(end time.Time, cmdErr error) Summary {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := Summary{
+		Command:    c.Command,
+		Start:      c.Start.UTC(),
+		DurationMS: milliseconds(end.Sub(c.Start)),
+		Clusters:   []ClusterStats{},
+	}
+	if cmdErr != nil {
+		s.Error = cmdErr.Error()
+	}
+	for _, stats := range c.clusters {
+		cs := *stats
+		cs.DurationMS = milliseconds(cs.last.Sub(cs.first))
+		s.Calls += cs.Calls
+		s.Errors += cs.Errors
+		s.Subprocesses += cs.Subprocesses
+		s.Clusters = append(s.Clusters, cs)
+	}
+	sort.Slice(s.Clusters, func(i, j int) bool {
+		if s.Clusters[i].DurationMS != s.Clusters[j].DurationMS {
+			return s.Clusters[i].DurationMS > s.Clusters[j].DurationMS
+		}
+		return s.Clusters[i].Cluster < s.Clusters[j].Cluster
+	})
+	return s
+}
+
+// Spans returns a copy of the recorded request spans
+func (c *Collector) Spans() []Span {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Span(nil), c.spans...)
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,120 @@
+package telemetry
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSummary(t *testing.T) {
+	c := Enable("get pods", true)
+	defer func() { active = nil }()
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(ms int) time.Time { return base.Add(time.Duration(ms) * time.Millisecond) }
+	spans := []Span{
+		{Cluster: "cluster1", StatusCode: 200, Start: at(0), End: at(10)},
+		{Cluster: "cluster1", StatusCode: 404, Start: at(10), End: at(40)},
+		{Cluster: "cluster2", StatusCode: 503, Start: at(0), End: at(5)},
+		{Cluster: "cluster2", Error: "connection refused", Start: at(5), End: at(6)},
+		{Cluster: "cluster2", Program: "kubectl", Subcommand: "apply", Error: "exit status 1", Start: at(6), End: at(106)},
+	}
+	for _, s := range spans {
+		c.observe(s)
+	}
+
+	summary := c.Summary(at(200), errors.New("failed"))
+	if summary.Calls != 4 || summary.Errors != 3 || summary.Subprocesses != 1 || summary.Error != "failed" {
+		t.Fatalf("Summary() totals = %+v", summary)
+	}
+
+	tests := []struct {
+		cluster      string
+		calls        int
+		errors       int
+		subprocesses int
+		durationMS   float64
+		apiTimeMS    float64
+		slowestMS    float64
+		subprocessMS float64
+	}{
+		// Slowest cluster first
+		{"cluster2", 2, 3, 1, 106, 6, 5, 100},
+		{"cluster1", 2, 0, 0, 40, 40, 30, 0},
+	}
+	if len(summary.Clusters) != len(tests) {
+		t.Fatalf("Summary() has %d clusters, want %d", len(summary.Clusters), len(tests))
+	}
+	for i, tt := range tests {
+		got := summary.Clusters[i]
+		if got.Cluster != tt.cluster || got.Calls != tt.calls || got.Errors != tt.errors || got.Subprocesses != tt.subprocesses ||
+			got.DurationMS != tt.durationMS || got.APITimeMS != tt.apiTimeMS || got.SlowestMS != tt.slowestMS || got.SubprocessTime != tt.subprocessMS {
+			t.Errorf("Clusters[%d] = %+v, want %+v", i, got, tt)
+		}
+	}
+	if n := len(c.Spans()); n != len(spans) {
+		t.Errorf("Spans() kept %d spans, want %d", n, len(spans))
+	}
+}
+
+func TestObserveCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		err      error
+		wantName string
+	}{
+		{name: "kubectl", args: []string{"kubectl", "apply", "-f", "app.yaml"}, wantName: "exec kubectl apply"},
+		{name: "failed helm", args: []string{"helm", "rollback", "ks-core", "2"}, err: errors.New("exit status 1"), wantName: "exec helm rollback"},
+		{name: "program only", args: []string{"helm"}, wantName: "exec helm"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Enable("apply", true)
+			defer func() { active = nil }()
+
+			ObserveCommand("cluster1", tt.args, time.Now(), tt.err)
+			spans := c.Spans()
+			if len(spans) != 1 {
+				t.Fatalf("recorded %d spans, want 1", len(spans))
+			}
+			if spans[0].Name != tt.wantName || spans[0].Program != tt.args[0] || (spans[0].Error != "") != (tt.err != nil) {
+				t.Errorf("span = %+v, want name %q", spans[0], tt.wantName)
+			}
+		})
+	}
+
+	// Without an active collector nothing is recorded
+	ObserveCommand("cluster1", []string{"kubectl", "get"}, time.Now(), nil)
+}
+
+func TestWrapTransport(t *testing.T) {
+	if WrapTransport("cluster1") != nil {
+		t.Fatalf("WrapTransport() without telemetry should be nil")
+	}
+
+	c := Enable("get", false)
+	defer func() { active = nil }()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: WrapTransport("cluster1")(http.DefaultTransport)}
+	resp, err := client.Get(server.URL + "/api/v1/pods")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	summary := c.Summary(time.Now(), nil)
+	if summary.Calls != 1 || summary.Errors != 1 || summary.Clusters[0].Cluster != "cluster1" {
+		t.Errorf("Summary() = %+v, want one failed call on cluster1", summary)
+	}
+	if len(c.Spans()) != 0 {
+		t.Errorf("spans kept without tracing")
+	}
+}
@@ -0,0 +1,161 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/csaupgrade"
+)
+
+// FieldManager is the server-side apply field manager of kubectl-multi writes
+const FieldManager = "kubectl-multi"
+
+// LastAppliedAnnotation is the annotation kubectl apply keeps the applied
+// configuration in; it is maintained so view-last-applied keeps working
+const LastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// clientSideApplyManager is the field manager of kubectl client-side apply
+const clientSideApplyManager = "kubectl-client-side-apply"
+
+// Dry-run modes of write commands
+const (
+	DryRunNone   = "none"
+	DryRunClient = "client"
+	DryRunServer = "server"
+)
+
+// ValidateDryRun checks a --dry-run value
+func ValidateDryRun(dryRun string) error {
+	switch dryRun {
+	case "", DryRunNone, DryRunClient, DryRunServer:
+		return nil
+	}
+	return fmt.Errorf("invalid --dry-run %q, must be \"none\", \"server\", or \"client\"", dryRun)
+}
+
+// ApplyObject server-side applies obj and returns a kubectl-style result line
+// such as "deployment.apps/nginx configured". Namespaced objects without a
+// namespace go to namespace. Fields owned by another manager are a conflict
+// unless force is set, as with kubectl apply --server-side --force-conflicts.
+func ApplyObject(ctx context.Context, client dynamic.Interface, mapper meta.RESTMapper, obj *unstructured.Unstructured, namespace, dryRun string, force bool) (string, error) {
+	obj = obj.DeepCopy()
+	gvk := obj.GroupVersionKind()
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return "", fmt.Errorf("failed to map %s: %v", gvk, err)
+	}
+
+	resource := client.Resource(mapping.Resource)
+	var ri dynamic.ResourceInterface = resource
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		if obj.GetNamespace() == "" {
+			obj.SetNamespace(namespace)
+		}
+		ri = resource.Namespace(obj.GetNamespace())
+	} else {
+		obj.SetNamespace("")
+	}
+
+	if err := setLastApplied(obj); err != nil {
+		return "", err
+	}
+	MarkManaged(obj)
+	name := strings.ToLower(gvk.Kind)
+	if gvk.Group != "" {
+		name += "." + gvk.Group
+	}
+	name += "/" + obj.GetName()
+
+	live, err := ri.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return "", fmt.Errorf("failed to get %s: %v", name, err)
+	}
+	exists := err == nil
+
+	suffix := ""
+	switch dryRun {
+	case DryRunClient:
+		if exists {
+			return name + " configured (dry run)", nil
+		}
+		return name + " created (dry run)", nil
+	case DryRunServer:
+		suffix = " (server dry run)"
+	}
+
+	// Objects created by kubectl apply are owned by its client-side manager;
+	// hand those fields to ours first so removing them from the manifest
+	// prunes them instead of leaving them behind
+	if exists && dryRun != DryRunServer {
+		if live, err = upgradeClientSideApply(ctx, ri, live); err != nil {
+			return "", fmt.Errorf("failed to upgrade field managers of %s: %v", name, err)
+		}
+	}
+
+	data, err := json.Marshal(obj.Object)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode %s: %v", name, err)
+	}
+	opts := metav1.PatchOptions{FieldManager: FieldManager, Force: &force}
+	if dryRun == DryRunServer {
+		opts.DryRun = []string{metav1.DryRunAll}
+	}
+	applied, err := ri.Patch(ctx, obj.GetName(), types.ApplyPatchType, data, opts)
+	if apierrors.IsConflict(err) {
+		return "", fmt.Errorf("failed to apply %s: %v\nhint: rerun with --force-conflicts to take ownership of the conflicting fields", name, err)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to apply %s: %v", name, err)
+	}
+
+	switch {
+	case !exists:
+		return name + " created" + suffix, nil
+	case applied.GetResourceVersion() == live.GetResourceVersion():
+		return name + " unchanged" + suffix, nil
+	default:
+		return name + " configured" + suffix, nil
+	}
+}
+
+// upgradeClientSideApply moves the fields kubectl client-side apply manages
+// on live, including applies through --kubectl-fallback, to FieldManager and
+// returns the updated object
+func upgradeClientSideApply(ctx context.Context, ri dynamic.ResourceInterface, live *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	patch, err := csaupgrade.UpgradeManagedFieldsPatch(live, sets.New(clientSideApplyManager, FieldManager), FieldManager)
+	if err != nil || patch == nil {
+		return live, err
+	}
+	return ri.Patch(ctx, live.GetName(), types.JSONPatchType, patch, metav1.PatchOptions{FieldManager: FieldManager})
+}
+
+// setLastApplied records obj, without the annotation itself, in the
+// last-applied-configuration annotation the way kubectl apply does
+func setLastApplied(obj *unstructured.Unstructured) error {
+	annotations := obj.GetAnnotations()
+	delete(annotations, LastAppliedAnnotation)
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+	obj.SetAnnotations(annotations)
+
+	data, err := json.Marshal(obj.Object)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s %s: %v", obj.GetKind(), obj.GetName(), err)
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[LastAppliedAnnotation] = string(data)
+	obj.SetAnnotations(annotations)
+	return nil
+}
@@ -0,0 +1,227 @@
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestValidateDryRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		dryRun  string
+		wantErr bool
+	}{
+		{name: "empty", dryRun: ""},
+		{name: "none", dryRun: DryRunNone},
+		{name: "client", dryRun: DryRunClient},
+		{name: "server", dryRun: DryRunServer},
+		{name: "kubectl boolean", dryRun: "true", wantErr: true},
+		{name: "unknown", dryRun: "all", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateDryRun(tt.dryRun); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateDryRun(%q) error = %v, wantErr %v", tt.dryRun, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func testApplyObject(kind, namespace, name, resourceVersion string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	obj.SetResourceVersion(resourceVersion)
+	return obj
+}
+
+func TestApplyObject(t *testing.T) {
+	csaLive := testApplyObject("ConfigMap", "default", "web", "5")
+	csaLive.SetManagedFields([]metav1.ManagedFieldsEntry{{
+		Manager:    clientSideApplyManager,
+		Operation:  metav1.ManagedFieldsOperationUpdate,
+		APIVersion: "v1",
+		FieldsType: "FieldsV1",
+		FieldsV1:   &metav1.FieldsV1{Raw: []byte(`{"f:data":{"f:key":{}}}`)},
+	}})
+	fallbackLive := csaLive.DeepCopy()
+	fallbackLive.SetManagedFields([]metav1.ManagedFieldsEntry{{
+		Manager:    FieldManager,
+		Operation:  metav1.ManagedFieldsOperationUpdate,
+		APIVersion: "v1",
+		FieldsType: "FieldsV1",
+		FieldsV1:   &metav1.FieldsV1{Raw: []byte(`{"f:data":{"f:key":{}}}`)},
+	}})
+
+	tests := []struct {
+		name        string
+		obj         *unstructured.Unstructured
+		live        *unstructured.Unstructured
+		dryRun      string
+		applied     string
+		applyErr    error
+		want        string
+		wantErr     string
+		wantPatches []types.PatchType
+		wantNS      string
+	}{
+		{
+			name:        "created in default namespace",
+			obj:         testApplyObject("ConfigMap", "", "web", ""),
+			applied:     "1",
+			want:        "configmap/web created",
+			wantPatches: []types.PatchType{types.ApplyPatchType},
+			wantNS:      "default",
+		},
+		{
+			name:        "unchanged",
+			obj:         testApplyObject("ConfigMap", "default", "web", ""),
+			live:        testApplyObject("ConfigMap", "default", "web", "5"),
+			applied:     "5",
+			want:        "configmap/web unchanged",
+			wantPatches: []types.PatchType{types.ApplyPatchType},
+			wantNS:      "default",
+		},
+		{
+			name:        "configured",
+			obj:         testApplyObject("ConfigMap", "default", "web", ""),
+			live:        testApplyObject("ConfigMap", "default", "web", "5"),
+			applied:     "6",
+			want:        "configmap/web configured",
+			wantPatches: []types.PatchType{types.ApplyPatchType},
+			wantNS:      "default",
+		},
+		{
+			name:   "client dry run sends nothing",
+			obj:    testApplyObject("ConfigMap", "default", "web", ""),
+			live:   testApplyObject("ConfigMap", "default", "web", "5"),
+			dryRun: DryRunClient,
+			want:   "configmap/web configured (dry run)",
+		},
+		{
+			name:        "server dry run",
+			obj:         testApplyObject("ConfigMap", "default", "web", ""),
+			dryRun:      DryRunServer,
+			applied:     "1",
+			want:        "configmap/web created (server dry run)",
+			wantPatches: []types.PatchType{types.ApplyPatchType},
+			wantNS:      "default",
+		},
+		{
+			name:        "cluster-scoped object drops the namespace",
+			obj:         testApplyObject("Namespace", "default", "team-a", ""),
+			applied:     "1",
+			want:        "namespace/team-a created",
+			wantPatches: []types.PatchType{types.ApplyPatchType},
+		},
+		{
+			name:        "conflict suggests --force-conflicts",
+			obj:         testApplyObject("ConfigMap", "default", "web", ""),
+			live:        testApplyObject("ConfigMap", "default", "web", "5"),
+			applyErr:    apierrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, "web", nil),
+			wantErr:     "--force-conflicts",
+			wantPatches: []types.PatchType{types.ApplyPatchType},
+			wantNS:      "default",
+		},
+		{
+			name:        "client-side apply fields are migrated first",
+			obj:         testApplyObject("ConfigMap", "default", "web", ""),
+			live:        csaLive,
+			applied:     "7",
+			want:        "configmap/web configured",
+			wantPatches: []types.PatchType{types.JSONPatchType, types.ApplyPatchType},
+			wantNS:      "default",
+		},
+		{
+			name:        "fields of kubectl-fallback applies are migrated first",
+			obj:         testApplyObject("ConfigMap", "default", "web", ""),
+			live:        fallbackLive,
+			applied:     "7",
+			want:        "configmap/web configured",
+			wantPatches: []types.PatchType{types.JSONPatchType, types.ApplyPatchType},
+			wantNS:      "default",
+		},
+		{
+			name:    "unknown kind",
+			obj:     testApplyObject("Widget", "default", "web", ""),
+			wantErr: "failed to map",
+		},
+	}
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+	listKinds := map[schema.GroupVersionResource]string{
+		{Version: "v1", Resource: "configmaps"}: "ConfigMapList",
+		{Version: "v1", Resource: "namespaces"}: "NamespaceList",
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var objects []runtime.Object
+			if tt.live != nil {
+				objects = append(objects, tt.live)
+			}
+			client := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objects...)
+			var patches []types.PatchType
+			client.PrependReactor("patch", "*", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				patch := action.(clienttesting.PatchAction)
+				patches = append(patches, patch.GetPatchType())
+				if patch.GetNamespace() != tt.wantNS {
+					t.Errorf("patch namespace = %q, want %q", patch.GetNamespace(), tt.wantNS)
+				}
+				if patch.GetPatchType() != types.ApplyPatchType {
+					return false, nil, nil
+				}
+				var sent unstructured.Unstructured
+				if err := json.Unmarshal(patch.GetPatch(), &sent.Object); err != nil {
+					t.Fatalf("apply patch is not an object: %v", err)
+				}
+				if _, ok := sent.GetAnnotations()[LastAppliedAnnotation]; !ok {
+					t.Errorf("apply patch lacks the %s annotation", LastAppliedAnnotation)
+				}
+				if !IsManaged(&sent) {
+					t.Errorf("apply patch lacks the %s annotation", AppliedByAnnotation)
+				}
+				if strings.Contains(sent.GetAnnotations()[LastAppliedAnnotation], AppliedByAnnotation) {
+					t.Errorf("%s records the %s annotation", LastAppliedAnnotation, AppliedByAnnotation)
+				}
+				if tt.applyErr != nil {
+					return true, nil, tt.applyErr
+				}
+				sent.SetResourceVersion(tt.applied)
+				return true, &sent, nil
+			})
+
+			got, err := ApplyObject(context.TODO(), client, mapper, tt.obj, "default", tt.dryRun, false)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ApplyObject() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("ApplyObject() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ApplyObject() = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(patches, tt.wantPatches) {
+				t.Errorf("patch types = %v, want %v", patches, tt.wantPatches)
+			}
+		})
+	}
+}
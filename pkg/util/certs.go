@@ -0,0 +1,65 @@
+package util
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CertificateExpiry returns the earliest expiry among the PEM certificates
+// of data, such as a leaf certificate and its chain, and the subject of the
+// certificate expiring then
+func CertificateExpiry(data []byte) (time.Time, string, error) {
+	var expiry time.Time
+	subject := ""
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return time.Time{}, "", fmt.Errorf("invalid certificate: %v", err)
+		}
+		if expiry.IsZero() || cert.NotAfter.Before(expiry) {
+			expiry, subject = cert.NotAfter, cert.Subject.CommonName
+		}
+	}
+	if expiry.IsZero() {
+		return time.Time{}, "", fmt.Errorf("no PEM certificate found")
+	}
+	return expiry, subject, nil
+}
+
+// TokenExpiry returns the exp claim of a JWT bearer token, such as a
+// ServiceAccount token, without verifying it. It returns false for tokens
+// that are not JWTs or never expire, like legacy ServiceAccount tokens.
+func TokenExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		Exp *json.Number `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == nil {
+		return time.Time{}, false
+	}
+	exp, err := claims.Exp.Int64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(exp, 0), true
+}
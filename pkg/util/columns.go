@@ -0,0 +1,137 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SelectColumns returns a TableWriter keeping only the named columns of the
+// tables written to tw, in the given order. The first tab-separated line of
+// each table is its header, against which the names are matched without
+// regard to case; a line without a tab or a Flush ends the table. Flush
+// returns an error when a table lacks one of the columns.
+func SelectColumns(tw TableWriter, columns []string) TableWriter {
+	picker := &columnPicker{columns: columns}
+	if sink, ok := tw.(*tableSink); ok {
+		sink.columns = picker
+		return sink
+	}
+	return &columnWriter{out: tw, picker: picker}
+}
+
+// ParseColumns splits a --columns value into upper-case column names
+func ParseColumns(value string) ([]string, error) {
+	var columns []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToUpper(strings.TrimSpace(name))
+		if name == "" {
+			return nil, fmt.Errorf("invalid column list %q: empty column name", value)
+		}
+		columns = append(columns, name)
+	}
+	return columns, nil
+}
+
+// columnPicker maps the cells of each table row to the selected columns,
+// learning their positions from the header
+type columnPicker struct {
+	columns []string
+	// picks are the header positions of the columns, nil before a header
+	picks []int
+	err   error
+}
+
+// reset starts a new table, whose next row is a header
+func (p *columnPicker) reset() {
+	p.picks = nil
+}
+
+// pick returns the selected cells of a row, or false when the table lacks
+// a selected column
+func (p *columnPicker) pick(cells []string) ([]string, bool) {
+	if p.picks == nil {
+		picks, err := columnPositions(cells, p.columns)
+		if err != nil && p.err == nil {
+			p.err = err
+		}
+		p.picks = picks
+	}
+	if len(p.picks) == 0 {
+		// The rows of a table lacking a column are dropped
+		return nil, false
+	}
+	picked := make([]string, len(p.picks))
+	for i, pos := range p.picks {
+		if pos < len(cells) {
+			picked[i] = cells[pos]
+		}
+	}
+	return picked, true
+}
+
+func columnPositions(header, columns []string) ([]int, error) {
+	positions := map[string]int{}
+	for i, name := range header {
+		positions[strings.ToUpper(strings.TrimSpace(name))] = i
+	}
+	picks := make([]int, 0, len(columns))
+	for _, name := range columns {
+		pos, ok := positions[name]
+		if !ok {
+			available := make([]string, len(header))
+			for i, name := range header {
+				available[i] = strings.TrimSpace(name)
+			}
+			return []int{}, fmt.Errorf("column %s is not in the table, whose columns are %s", name, strings.Join(available, ","))
+		}
+		picks = append(picks, pos)
+	}
+	return picks, nil
+}
+
+// columnWriter applies a columnPicker to the lines written to an aligned
+// text TableWriter
+type columnWriter struct {
+	out     TableWriter
+	picker  *columnPicker
+	partial bytes.Buffer
+}
+
+func (w *columnWriter) Write(p []byte) (int, error) {
+	w.partial.Write(p)
+	for {
+		line, err := w.partial.ReadString('\n')
+		if err == io.EOF {
+			// Keep the incomplete line for the next write
+			w.partial.WriteString(line)
+			return len(p), nil
+		}
+		w.writeLine(strings.TrimSuffix(line, "\n"))
+	}
+}
+
+func (w *columnWriter) writeLine(line string) {
+	if !strings.Contains(line, "\t") {
+		w.picker.reset()
+		fmt.Fprintln(w.out, line)
+		return
+	}
+	if cells, ok := w.picker.pick(strings.Split(line, "\t")); ok {
+		fmt.Fprintln(w.out, strings.Join(cells, "\t"))
+	}
+}
+
+func (w *columnWriter) Flush() error {
+	if w.partial.Len() > 0 {
+		line := w.partial.String()
+		w.partial.Reset()
+		w.writeLine(line)
+	}
+	w.picker.reset()
+	if err := w.out.Flush(); err != nil {
+		return err
+	}
+	return w.picker.err
+}
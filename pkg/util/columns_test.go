@@ -0,0 +1,90 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSelectColumns(t *testing.T) {
+	const table = "CLUSTER\tNAME\tREADY\tSTATUS\tAGE\ncluster1\tweb-1\t1/1\tRunning\t5d\ncluster2\tweb-2\t0/1\tPending\t2m\n"
+	tests := []struct {
+		name    string
+		format  string
+		columns []string
+		input   string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "aligned text, reordered",
+			columns: []string{"STATUS", "CLUSTER", "NAME"},
+			input:   table,
+			want:    "STATUS   CLUSTER   NAME\nRunning  cluster1  web-1\nPending  cluster2  web-2\n",
+		},
+		{
+			name:    "csv",
+			format:  TableFormatCSV,
+			columns: []string{"CLUSTER", "AGE"},
+			input:   table,
+			want:    "CLUSTER,AGE\ncluster1,5d\ncluster2,2m\n",
+		},
+		{
+			name:    "single markdown column",
+			format:  TableFormatMarkdown,
+			columns: []string{"NAME"},
+			input:   table,
+			want:    "| NAME |\n| --- |\n| web-1 |\n| web-2 |\n\n",
+		},
+		{
+			name:    "each table has its header",
+			columns: []string{"NAME", "AGE"},
+			input:   "==> Pods\nCLUSTER\tNAME\tAGE\ncluster1\tweb-1\t5d\n==> Services\nCLUSTER\tNAME\tTYPE\tAGE\ncluster1\tweb\tClusterIP\t9d\n",
+			want:    "==> Pods\nNAME   AGE\nweb-1  5d\n==> Services\nNAME  AGE\nweb   9d\n",
+		},
+		{
+			name:    "missing column",
+			columns: []string{"CLUSTER", "IP"},
+			input:   table,
+			wantErr: "column IP is not in the table, whose columns are CLUSTER,NAME,READY,STATUS,AGE",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			w := SelectColumns(NewTableWriter(&out, tt.format), tt.columns)
+			// Rows may arrive in several writes
+			for _, part := range strings.SplitAfter(tt.input, "\t") {
+				fmt.Fprint(w, part)
+			}
+			err := w.Flush()
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("Flush() error = %v, want %q", err, tt.wantErr)
+				}
+				if strings.Contains(out.String(), "cluster1") {
+					t.Errorf("output = %q, want no rows", out.String())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Flush() error = %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("output = %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseColumns(t *testing.T) {
+	got, err := ParseColumns("cluster, Name,STATUS")
+	if err != nil || !reflect.DeepEqual(got, []string{"CLUSTER", "NAME", "STATUS"}) {
+		t.Errorf("ParseColumns() = %v, %v", got, err)
+	}
+	if _, err := ParseColumns("CLUSTER,,NAME"); err == nil {
+		t.Error("ParseColumns() accepted an empty column name")
+	}
+}
@@ -191,17 +191,42 @@ func GetPVCStorageClass(pvc *corev1.PersistentVolumeClaim) string {
 	return "<none>"
 }
 
-// DiscoverGVR discovers the GroupVersionResource for a given resource type
+// DiscoverGVR discovers the GroupVersionResource for a given resource type.
+// With a cached discovery client, a type missing from a stale cache reloads
+// the cache once before falling back to the common defaults.
 func DiscoverGVR(discoveryClient discovery.DiscoveryInterface, resourceType string) (schema.GroupVersionResource, bool, error) {
+	// Normalize the resource type (handle plurals and common aliases)
+	normalizedType := normalizeResourceType(resourceType)
+
+	gvr, namespaced, found, err := findGVR(discoveryClient, normalizedType)
+	if err != nil {
+		return schema.GroupVersionResource{}, false, err
+	}
+	if !found {
+		if cached, ok := discoveryClient.(discovery.CachedDiscoveryInterface); ok && !cached.Fresh() {
+			cached.Invalidate()
+			gvr, namespaced, found, err = findGVR(discoveryClient, normalizedType)
+			if err != nil {
+				return schema.GroupVersionResource{}, false, err
+			}
+		}
+	}
+	if found {
+		return gvr, namespaced, nil
+	}
+
+	// If not found, try some common defaults
+	return getDefaultGVR(normalizedType), true, nil
+}
+
+// findGVR searches the API resources served by the cluster for the type
+func findGVR(discoveryClient discovery.DiscoveryInterface, normalizedType string) (schema.GroupVersionResource, bool, bool, error) {
 	// Get all API resources
 	_, apiResourceLists, err := discoveryClient.ServerGroupsAndResources()
 	if err != nil {
-		return schema.GroupVersionResource{}, false, fmt.Errorf("failed to discover API resources: %v", err)
+		return schema.GroupVersionResource{}, false, false, fmt.Errorf("failed to discover API resources: %v", err)
 	}
 
-	// Normalize the resource type (handle plurals and common aliases)
-	normalizedType := normalizeResourceType(resourceType)
-
 	// Search through all API resources
 	for _, apiResourceList := range apiResourceLists {
 		gv, err := schema.ParseGroupVersion(apiResourceList.GroupVersion)
@@ -212,14 +237,11 @@ func DiscoverGVR(discoveryClient discovery.DiscoveryInterface, resourceType stri
 		for _, apiResource := range apiResourceList.APIResources {
 			// Check if this matches our resource type
 			if matchesResourceType(apiResource, normalizedType) {
-				gvr := gv.WithResource(apiResource.Name)
-				return gvr, apiResource.Namespaced, nil
+				return gv.WithResource(apiResource.Name), apiResource.Namespaced, true, nil
 			}
 		}
 	}
-
-	// If not found, try some common defaults
-	return getDefaultGVR(normalizedType), true, nil
+	return schema.GroupVersionResource{}, false, false, nil
 }
 
 // normalizeResourceType converts common resource type aliases to standard forms
@@ -0,0 +1,69 @@
+package util
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+// staleDiscovery is a cached discovery client whose cache lacks the
+// resources the server added since it was written
+type staleDiscovery struct {
+	*fakediscovery.FakeDiscovery
+	fresh       bool
+	served      []*metav1.APIResourceList
+	invalidated int
+}
+
+func (d *staleDiscovery) Fresh() bool { return d.fresh }
+
+func (d *staleDiscovery) Invalidate() {
+	d.invalidated++
+	d.fresh = true
+	d.Resources = d.served
+}
+
+func TestDiscoverGVR(t *testing.T) {
+	core := &metav1.APIResourceList{GroupVersion: "v1", APIResources: []metav1.APIResource{
+		{Name: "pods", SingularName: "pod", ShortNames: []string{"po"}, Kind: "Pod", Namespaced: true},
+	}}
+	widgets := &metav1.APIResourceList{GroupVersion: "example.com/v1", APIResources: []metav1.APIResource{
+		{Name: "widgets", SingularName: "widget", Kind: "Widget"},
+	}}
+
+	tests := []struct {
+		name            string
+		resourceType    string
+		fresh           bool
+		want            schema.GroupVersionResource
+		wantNamespaced  bool
+		wantInvalidated int
+	}{
+		{name: "cached type", resourceType: "po", want: schema.GroupVersionResource{Version: "v1", Resource: "pods"}, wantNamespaced: true},
+		{name: "new CRD reloads a stale cache", resourceType: "widget", want: schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}, wantInvalidated: 1},
+		{name: "fresh cache is trusted", resourceType: "widget", fresh: true, want: schema.GroupVersionResource{Version: "v1", Resource: "widgets"}, wantNamespaced: true},
+		{name: "unknown type falls back after one reload", resourceType: "deploy", want: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, wantNamespaced: true, wantInvalidated: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			disc := &staleDiscovery{
+				FakeDiscovery: &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: []*metav1.APIResourceList{core}}},
+				fresh:         tt.fresh,
+				served:        []*metav1.APIResourceList{core, widgets},
+			}
+			gvr, namespaced, err := DiscoverGVR(disc, tt.resourceType)
+			if err != nil {
+				t.Fatalf("DiscoverGVR() error = %v", err)
+			}
+			if gvr != tt.want || namespaced != tt.wantNamespaced {
+				t.Errorf("DiscoverGVR() = %v, %v, want %v, %v", gvr, namespaced, tt.want, tt.wantNamespaced)
+			}
+			if disc.invalidated != tt.wantInvalidated {
+				t.Errorf("Invalidate() called %d times, want %d", disc.invalidated, tt.wantInvalidated)
+			}
+		})
+	}
+}
@@ -4,11 +4,16 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
+	"runtime/debug"
 	"strings"
+	"sync"
 
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericiooptions"
 	"k8s.io/kubectl/pkg/cmd"
+
+	"kubectl-multi/pkg/config"
 )
 
 // CommandInfo holds information about a kubectl command
@@ -57,17 +62,97 @@ func GetKubectlRootInfo() (*CommandInfo, error) {
 	}, nil
 }
 
-// executeKubectlHelp creates kubectl command objects and extracts help text programmatically
+// kubectlModule is the module whose commands the help text comes from
+const kubectlModule = "k8s.io/kubectl"
+
+var (
+	// helpCache memoizes the help text of each command for the process
+	helpCacheMu sync.Mutex
+	helpCache   = map[string]string{}
+
+	// kubectlCmdOnce builds the kubectl command tree on the first cache miss
+	kubectlCmdOnce sync.Once
+	kubectlCmd     *cobra.Command
+)
+
+// executeKubectlHelp returns the kubectl help text of a command ("" for the
+// root). The text is built from the kubectl library linked into the plugin,
+// so no kubectl binary or cluster is needed, and is cached in memory and
+// under config.HelpCacheDir per kubectl version, so later --help calls skip
+// building the kubectl command tree.
 func executeKubectlHelp(command string) (string, error) {
-	ioStreams := genericiooptions.IOStreams{In: os.Stdin, Out: &bytes.Buffer{}, ErrOut: &bytes.Buffer{}}
+	helpCacheMu.Lock()
+	defer helpCacheMu.Unlock()
+	if text, ok := helpCache[command]; ok {
+		return text, nil
+	}
+
+	file := helpCacheFile(config.HelpCacheDir(), kubectlVersion(), command)
+	if file != "" {
+		if data, err := os.ReadFile(file); err == nil {
+			helpCache[command] = string(data)
+			return string(data), nil
+		}
+	}
+
+	text, err := generateKubectlHelp(command)
+	if err != nil {
+		return "", err
+	}
+	helpCache[command] = text
+	if file != "" {
+		// The cache only saves time; failing to write it is not an error
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err == nil {
+			_ = os.WriteFile(file, []byte(text), 0644)
+		}
+	}
+	return text, nil
+}
 
-	// Create kubectl options
-	kubectlOptions := cmd.KubectlOptions{
-		IOStreams: ioStreams,
+// helpCacheFile returns the file caching the help text of a command for a
+// kubectl version, or "" when the version is unknown and nothing is cached
+func helpCacheFile(dir, version, command string) string {
+	if version == "" {
+		return ""
 	}
+	name := command
+	if name == "" {
+		name = "kubectl"
+	}
+	return filepath.Join(dir, version, name+".txt")
+}
+
+// kubectlVersion returns the version of the kubectl library in the build,
+// or "" when the build carries no module information
+func kubectlVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == kubectlModule {
+			if dep.Replace != nil {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	return ""
+}
+
+// generateKubectlHelp creates kubectl command objects and extracts help text programmatically
+func generateKubectlHelp(command string) (string, error) {
+	kubectlCmdOnce.Do(func() {
+		ioStreams := genericiooptions.IOStreams{In: os.Stdin, Out: &bytes.Buffer{}, ErrOut: &bytes.Buffer{}}
+
+		// Create kubectl options
+		kubectlOptions := cmd.KubectlOptions{
+			IOStreams: ioStreams,
+		}
 
-	// Create the kubectl command
-	kubectlCmd := cmd.NewKubectlCommand(kubectlOptions)
+		// Create the kubectl command
+		kubectlCmd = cmd.NewKubectlCommand(kubectlOptions)
+	})
 
 	// Find the specific subcommand
 	var targetCmd *cobra.Command
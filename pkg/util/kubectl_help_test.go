@@ -0,0 +1,66 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"kubectl-multi/pkg/config"
+)
+
+func TestHelpCacheFile(t *testing.T) {
+	tests := []struct {
+		version, command, want string
+	}{
+		{"v0.29.0", "get", filepath.Join("cache", "v0.29.0", "get.txt")},
+		{"v0.29.0", "", filepath.Join("cache", "v0.29.0", "kubectl.txt")},
+		{"", "get", ""},
+	}
+	for _, tt := range tests {
+		if got := helpCacheFile("cache", tt.version, tt.command); got != tt.want {
+			t.Errorf("helpCacheFile(%q, %q) = %q, want %q", tt.version, tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestExecuteKubectlHelpCache(t *testing.T) {
+	version := kubectlVersion()
+	if version == "" {
+		t.Skip("build carries no kubectl module version")
+	}
+	t.Setenv(config.EnvConfigPath, filepath.Join(t.TempDir(), "kubectl-multi.yaml"))
+	resetHelpCache := func() {
+		helpCacheMu.Lock()
+		helpCache = map[string]string{}
+		helpCacheMu.Unlock()
+	}
+	resetHelpCache()
+	defer resetHelpCache()
+
+	text, err := executeKubectlHelp("cordon")
+	if err != nil {
+		t.Fatalf("executeKubectlHelp() error = %v", err)
+	}
+	if !strings.Contains(text, "kubectl cordon") {
+		t.Errorf("help text does not mention kubectl cordon:\n%s", text)
+	}
+	file := helpCacheFile(config.HelpCacheDir(), version, "cordon")
+	cached, err := os.ReadFile(file)
+	if err != nil || string(cached) != text {
+		t.Fatalf("cache file %s = %q, %v; want the help text", file, cached, err)
+	}
+
+	// A later process reads the cache instead of building the commands
+	if err := os.WriteFile(file, []byte("cached help"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	resetHelpCache()
+	if text, err := executeKubectlHelp("cordon"); err != nil || text != "cached help" {
+		t.Errorf("executeKubectlHelp() = %q, %v; want the cached text", text, err)
+	}
+
+	if _, err := executeKubectlHelp("no-such-command"); err == nil {
+		t.Errorf("executeKubectlHelp() of an unknown command succeeded")
+	}
+}
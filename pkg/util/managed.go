@@ -0,0 +1,81 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// AppliedByAnnotation marks the objects kubectl-multi writes, so they can be
+// told apart from objects created by other tools, e.g. to prune or undo them
+const AppliedByAnnotation = "kubestellar.io/applied-by"
+
+// MarkManaged sets the AppliedByAnnotation on obj
+func MarkManaged(obj metav1.Object) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[AppliedByAnnotation] = FieldManager
+	obj.SetAnnotations(annotations)
+}
+
+// IsManaged reports whether kubectl-multi wrote obj
+func IsManaged(obj interface{ GetAnnotations() map[string]string }) bool {
+	return obj.GetAnnotations()[AppliedByAnnotation] == FieldManager
+}
+
+// ManagedPatch adds the AppliedByAnnotation to a strategic merge, merge or
+// JSON patch of live. Patches of objects that already carry it, and patches
+// that replace all annotations with null, are returned unchanged.
+func ManagedPatch(patchType types.PatchType, patch []byte, live metav1.Object) ([]byte, error) {
+	if IsManaged(live) {
+		return patch, nil
+	}
+	switch patchType {
+	case types.JSONPatchType:
+		var ops []interface{}
+		if err := json.Unmarshal(patch, &ops); err != nil {
+			return nil, fmt.Errorf("failed to decode JSON patch: %v", err)
+		}
+		op := map[string]interface{}{"op": "add"}
+		if live.GetAnnotations() == nil {
+			op["path"] = "/metadata/annotations"
+			op["value"] = map[string]string{AppliedByAnnotation: FieldManager}
+		} else {
+			op["path"] = "/metadata/annotations/" + strings.ReplaceAll(AppliedByAnnotation, "/", "~1")
+			op["value"] = FieldManager
+		}
+		return json.Marshal(append(ops, op))
+	case types.StrategicMergePatchType, types.MergePatchType:
+		var doc map[string]interface{}
+		if err := json.Unmarshal(patch, &doc); err != nil {
+			return nil, fmt.Errorf("failed to decode patch: %v", err)
+		}
+		if doc == nil {
+			doc = map[string]interface{}{}
+		}
+		metadata, ok := doc["metadata"].(map[string]interface{})
+		if !ok {
+			if doc["metadata"] != nil {
+				return patch, nil
+			}
+			metadata = map[string]interface{}{}
+			doc["metadata"] = metadata
+		}
+		annotations, ok := metadata["annotations"].(map[string]interface{})
+		if !ok {
+			if _, set := metadata["annotations"]; set {
+				return patch, nil
+			}
+			annotations = map[string]interface{}{}
+			metadata["annotations"] = annotations
+		}
+		annotations[AppliedByAnnotation] = FieldManager
+		return json.Marshal(doc)
+	}
+	return patch, nil
+}
@@ -0,0 +1,130 @@
+package util
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestMarkManaged(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        map[string]string
+	}{
+		{name: "no annotations", want: map[string]string{AppliedByAnnotation: FieldManager}},
+		{
+			name:        "keeps other annotations",
+			annotations: map[string]string{"team": "web"},
+			want:        map[string]string{"team": "web", AppliedByAnnotation: FieldManager},
+		},
+		{
+			name:        "takes over from another tool",
+			annotations: map[string]string{AppliedByAnnotation: "argocd"},
+			want:        map[string]string{AppliedByAnnotation: FieldManager},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &metav1.ObjectMeta{Annotations: tt.annotations}
+			if IsManaged(obj) {
+				t.Error("IsManaged() = true before MarkManaged()")
+			}
+			MarkManaged(obj)
+			if !reflect.DeepEqual(obj.Annotations, tt.want) {
+				t.Errorf("annotations = %v, want %v", obj.Annotations, tt.want)
+			}
+			if !IsManaged(obj) {
+				t.Error("IsManaged() = false after MarkManaged()")
+			}
+		})
+	}
+}
+
+func TestManagedPatch(t *testing.T) {
+	managed := map[string]string{AppliedByAnnotation: FieldManager}
+	tests := []struct {
+		name        string
+		patchType   types.PatchType
+		patch       string
+		annotations map[string]string
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:      "merge patch",
+			patchType: types.MergePatchType,
+			patch:     `{"data":{"key":"new"}}`,
+			want:      `{"data":{"key":"new"},"metadata":{"annotations":{"kubestellar.io/applied-by":"kubectl-multi"}}}`,
+		},
+		{
+			name:      "strategic merge patch with annotations",
+			patchType: types.StrategicMergePatchType,
+			patch:     `{"metadata":{"annotations":{"team":"web"}}}`,
+			want:      `{"metadata":{"annotations":{"kubestellar.io/applied-by":"kubectl-multi","team":"web"}}}`,
+		},
+		{
+			name:      "patch removing all annotations",
+			patchType: types.MergePatchType,
+			patch:     `{"metadata":{"annotations":null}}`,
+			want:      `{"metadata":{"annotations":null}}`,
+		},
+		{
+			name:        "already managed",
+			patchType:   types.MergePatchType,
+			patch:       `{"data":{"key":"new"}}`,
+			annotations: managed,
+			want:        `{"data":{"key":"new"}}`,
+		},
+		{
+			name:      "json patch without annotations",
+			patchType: types.JSONPatchType,
+			patch:     `[{"op":"replace","path":"/data/key","value":"new"}]`,
+			want:      `[{"op":"replace","path":"/data/key","value":"new"},{"op":"add","path":"/metadata/annotations","value":{"kubestellar.io/applied-by":"kubectl-multi"}}]`,
+		},
+		{
+			name:        "json patch with annotations",
+			patchType:   types.JSONPatchType,
+			patch:       `[]`,
+			annotations: map[string]string{"team": "web"},
+			want:        `[{"op":"add","path":"/metadata/annotations/kubestellar.io~1applied-by","value":"kubectl-multi"}]`,
+		},
+		{
+			name:      "invalid json patch",
+			patchType: types.JSONPatchType,
+			patch:     `{"op":"add"}`,
+			wantErr:   true,
+		},
+		{
+			name:      "invalid merge patch",
+			patchType: types.MergePatchType,
+			patch:     `[]`,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			live := &metav1.ObjectMeta{Annotations: tt.annotations}
+			got, err := ManagedPatch(tt.patchType, []byte(tt.patch), live)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ManagedPatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var gotDoc, wantDoc interface{}
+			if err := json.Unmarshal(got, &gotDoc); err != nil {
+				t.Fatalf("ManagedPatch() returned invalid JSON %s: %v", got, err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &wantDoc); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(gotDoc, wantDoc) {
+				t.Errorf("ManagedPatch() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+	"sigs.k8s.io/yaml"
+
+	"kubectl-multi/pkg/network"
+	"kubectl-multi/pkg/platform"
+)
+
+// ManifestSource is the raw content of one manifest file
+type ManifestSource struct {
+	Path string
+	Data []byte
+}
+
+// ReadManifestSources reads a YAML or JSON file, an http(s) URL, or the
+// .yaml/.yml/.json files of a directory, without decoding them. "-" reads
+// from stdin.
+func ReadManifestSources(path string, recursive bool) ([]ManifestSource, error) {
+	if path == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read stdin: %v", err)
+		}
+		return []ManifestSource{{Path: path, Data: data}}, nil
+	}
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		data, err := readURL(path)
+		if err != nil {
+			return nil, err
+		}
+		return []ManifestSource{{Path: path, Data: data}}, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	if !info.IsDir() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", path, err)
+		}
+		return []ManifestSource{{Path: path, Data: data}}, nil
+	}
+
+	var sources []ManifestSource
+	err = filepath.Walk(path, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			if p != path && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(p)) {
+		case ".yaml", ".yml", ".json":
+		default:
+			return nil
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", p, err)
+		}
+		sources = append(sources, ManifestSource{Path: p, Data: data})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
+// SpoolStdin copies stdin into a temporary manifest file so it can be read
+// more than once. The caller removes the file.
+func SpoolStdin() (string, error) {
+	f, err := platform.CreateTemp("kubectl-multi-stdin-*.yaml")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	defer f.Close()
+	if _, err := io.Copy(f, os.Stdin); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to read stdin: %v", err)
+	}
+	return f.Name(), nil
+}
+
+// SpoolKustomization builds the kustomization directory dir, as kubectl
+// apply -k does, into a temporary manifest file. The caller removes the file.
+func SpoolKustomization(dir string) (string, error) {
+	resources, err := krusty.MakeKustomizer(krusty.MakeDefaultOptions()).Run(filesys.MakeFsOnDisk(), dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to build kustomization %s: %v", dir, err)
+	}
+	data, err := resources.AsYaml()
+	if err != nil {
+		return "", fmt.Errorf("failed to encode kustomization %s: %v", dir, err)
+	}
+	f, err := platform.CreateTemp("kubectl-multi-kustomize-*.yaml")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write kustomization %s: %v", dir, err)
+	}
+	return f.Name(), nil
+}
+
+func readURL(url string) ([]byte, error) {
+	if err := network.CheckOnline("reading " + url); err != nil {
+		return nil, err
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %v", url, err)
+	}
+	return data, nil
+}
+
+// ReadManifests decodes every object in a YAML or JSON file, an http(s) URL,
+// or the .yaml/.yml/.json files of a directory. "-" reads from stdin.
+func ReadManifests(path string, recursive bool) ([]*unstructured.Unstructured, error) {
+	sources, err := ReadManifestSources(path, recursive)
+	if err != nil {
+		return nil, err
+	}
+	var objs []*unstructured.Unstructured
+	for _, src := range sources {
+		fileObjs, err := DecodeManifests(src.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %v", src.Path, err)
+		}
+		objs = append(objs, fileObjs...)
+	}
+	return objs, nil
+}
+
+// DecodeManifests splits a multi-document YAML or JSON stream into objects,
+// skipping empty documents
+func DecodeManifests(data []byte) ([]*unstructured.Unstructured, error) {
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	var objs []*unstructured.Unstructured
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := decoder.Decode(&obj.Object); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		if len(obj.Object) == 0 {
+			continue
+		}
+		if obj.IsList() {
+			items, err := obj.ToList()
+			if err != nil {
+				return nil, err
+			}
+			for i := range items.Items {
+				objs = append(objs, &items.Items[i])
+			}
+			continue
+		}
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
+
+// EncodeManifests renders objects as a multi-document YAML stream
+func EncodeManifests(objs []*unstructured.Unstructured) ([]byte, error) {
+	var buf bytes.Buffer
+	for i, obj := range objs {
+		data, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s %s: %v", obj.GetKind(), obj.GetName(), err)
+		}
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		buf.Write(data)
+	}
+	return buf.Bytes(), nil
+}
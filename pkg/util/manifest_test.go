@@ -0,0 +1,186 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeManifests(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []string
+		wantErr bool
+	}{
+		{name: "empty", data: "", want: nil},
+		{
+			name: "multi document yaml",
+			data: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n---\n---\napiVersion: v1\nkind: Secret\nmetadata:\n  name: b\n",
+			want: []string{"ConfigMap/a", "Secret/b"},
+		},
+		{
+			name: "json",
+			data: `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "a"}}`,
+			want: []string{"ConfigMap/a"},
+		},
+		{
+			name: "list is flattened",
+			data: "apiVersion: v1\nkind: List\nitems:\n- apiVersion: v1\n  kind: ConfigMap\n  metadata:\n    name: a\n- apiVersion: v1\n  kind: ConfigMap\n  metadata:\n    name: b\n",
+			want: []string{"ConfigMap/a", "ConfigMap/b"},
+		},
+		{name: "invalid", data: "kind: [", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objs, err := DecodeManifests([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeManifests() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, obj := range objs {
+				got = append(got, obj.GetKind()+"/"+obj.GetName())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodeManifests() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpoolStdin(t *testing.T) {
+	const manifest = "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n"
+
+	stdin, err := os.CreateTemp(t.TempDir(), "stdin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stdin.WriteString(manifest); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stdin.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	orig := os.Stdin
+	os.Stdin = stdin
+	defer func() { os.Stdin = orig }()
+
+	spooled, err := SpoolStdin()
+	if err != nil {
+		t.Fatalf("SpoolStdin() error = %v", err)
+	}
+	defer os.Remove(spooled)
+
+	// The spooled file can be read any number of times
+	for i := 0; i < 2; i++ {
+		objs, err := ReadManifests(spooled, false)
+		if err != nil {
+			t.Fatalf("ReadManifests() error = %v", err)
+		}
+		if len(objs) != 1 || objs[0].GetName() != "a" {
+			t.Fatalf("read %d: got %d objects, want ConfigMap a", i, len(objs))
+		}
+	}
+}
+
+func TestReadManifestSources(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.yaml":        "kind: A\n",
+		"b.json":        "{}",
+		"notes.txt":     "ignored",
+		"sub/c.yml":     "kind: C\n",
+		"sub/deep/d.ya": "ignored",
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		path      string
+		recursive bool
+		want      []string
+		wantErr   bool
+	}{
+		{name: "single file", path: filepath.Join(dir, "a.yaml"), want: []string{"a.yaml"}},
+		{name: "directory", path: dir, want: []string{"a.yaml", "b.json"}},
+		{name: "recursive directory", path: dir, recursive: true, want: []string{"a.yaml", "b.json", "sub/c.yml"}},
+		{name: "missing", path: filepath.Join(dir, "missing.yaml"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sources, err := ReadManifestSources(tt.path, tt.recursive)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadManifestSources() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, src := range sources {
+				rel, _ := filepath.Rel(dir, src.Path)
+				got = append(got, filepath.ToSlash(rel))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadManifestSources() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpoolKustomization(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "name prefix",
+			files: map[string]string{
+				"kustomization.yaml": "namePrefix: prod-\nresources:\n- cm.yaml\n",
+				"cm.yaml":            "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: web\n",
+			},
+			want: []string{"ConfigMap/prod-web"},
+		},
+		{
+			name:    "no kustomization",
+			files:   map[string]string{"cm.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: web\n"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, data := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			path, err := SpoolKustomization(dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SpoolKustomization() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer os.Remove(path)
+			objs, err := ReadManifests(path, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, obj := range objs {
+				got = append(got, obj.GetKind()+"/"+obj.GetName())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SpoolKustomization() objects = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,461 @@
+package util
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"kubectl-multi/pkg/network"
+	"kubectl-multi/pkg/platform"
+)
+
+// Media types of the manifests an OCI bundle reference may resolve to
+const (
+	ociManifestMediaType    = "application/vnd.oci.image.manifest.v1+json"
+	dockerManifestMediaType = "application/vnd.docker.distribution.manifest.v2+json"
+	ociTitleAnnotation      = "org.opencontainers.image.title"
+)
+
+// maxOCILayerSize bounds the size of one bundle layer read into memory
+const maxOCILayerSize = 64 << 20
+
+// OCIReference is a parsed registry/repository[:tag|@digest] reference
+type OCIReference struct {
+	Registry   string
+	Repository string
+	// Reference is the tag or the digest of the artifact
+	Reference string
+}
+
+// String formats the reference the way it is written on the command line
+func (r OCIReference) String() string {
+	if strings.HasPrefix(r.Reference, "sha256:") {
+		return r.Registry + "/" + r.Repository + "@" + r.Reference
+	}
+	return r.Registry + "/" + r.Repository + ":" + r.Reference
+}
+
+// ParseOCIReference parses an artifact reference such as
+// ghcr.io/org/bundle:v1, oci://ghcr.io/org/bundle or
+// registry.local:5000/bundle@sha256:..., following the docker rules: a
+// first component without a dot or port that is not localhost is a docker.io
+// repository, and the tag defaults to latest.
+func ParseOCIReference(ref string) (OCIReference, error) {
+	name := strings.TrimPrefix(ref, "oci://")
+	var r OCIReference
+	if before, digest, found := strings.Cut(name, "@"); found {
+		name, r.Reference = before, digest
+		if !strings.HasPrefix(digest, "sha256:") || len(digest) != len("sha256:")+64 {
+			return r, fmt.Errorf("invalid OCI reference %q: unsupported digest %q", ref, digest)
+		}
+	} else if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, r.Reference = name[:i], name[i+1:]
+	}
+	if r.Reference == "" {
+		r.Reference = "latest"
+	}
+
+	first, rest, found := strings.Cut(name, "/")
+	if found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		r.Registry, r.Repository = first, rest
+	} else {
+		r.Registry, r.Repository = "docker.io", name
+		if !found {
+			r.Repository = "library/" + name
+		}
+	}
+	if r.Repository == "" || strings.ContainsAny(r.Repository, ": ") || r.Repository != strings.ToLower(r.Repository) {
+		return r, fmt.Errorf("invalid OCI reference %q", ref)
+	}
+	return r, nil
+}
+
+// SpoolOCIArtifact pulls the artifact ref from its registry once and writes
+// the manifests of its layers into a temporary manifest file. The caller
+// removes the file.
+func SpoolOCIArtifact(ref string) (string, error) {
+	parsed, err := ParseOCIReference(ref)
+	if err != nil {
+		return "", err
+	}
+	if err := network.CheckOnline("pulling OCI artifact " + parsed.String()); err != nil {
+		return "", err
+	}
+	data, err := newOCIClient(parsed.Registry).pullManifests(parsed)
+	if err != nil {
+		return "", err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return "", fmt.Errorf("OCI artifact %s contains no .yaml, .yml or .json manifests", parsed)
+	}
+	f, err := platform.CreateTemp("kubectl-multi-oci-*.yaml")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write OCI artifact %s: %v", parsed, err)
+	}
+	return f.Name(), nil
+}
+
+// ociDescriptor is a layer or config of an OCI manifest
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// ociClient reads manifests and blobs from one registry, using the
+// credentials of the docker config for that registry when it asks for them
+type ociClient struct {
+	http     *http.Client
+	baseURL  string
+	registry string
+	token    string
+}
+
+func newOCIClient(registry string) *ociClient {
+	scheme := "https"
+	host, _, _ := strings.Cut(registry, ":")
+	if host == "localhost" || host == "127.0.0.1" {
+		scheme = "http"
+	}
+	apiHost := registry
+	if registry == "docker.io" {
+		apiHost = "registry-1.docker.io"
+	}
+	return &ociClient{http: &http.Client{Timeout: 60 * time.Second}, baseURL: scheme + "://" + apiHost, registry: registry}
+}
+
+// pullManifests reads the manifest of an artifact and returns the content of
+// its layers as one multi-document YAML stream
+func (c *ociClient) pullManifests(ref OCIReference) ([]byte, error) {
+	body, mediaType, err := c.get(fmt.Sprintf("/v2/%s/manifests/%s", ref.Repository, ref.Reference),
+		ociManifestMediaType+", "+dockerManifestMediaType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pull OCI artifact %s: %v", ref, err)
+	}
+	if strings.HasPrefix(ref.Reference, "sha256:") {
+		if err := verifyDigest(body, ref.Reference); err != nil {
+			return nil, fmt.Errorf("manifest of OCI artifact %s: %v", ref, err)
+		}
+	}
+	var manifest struct {
+		MediaType string          `json:"mediaType"`
+		Layers    []ociDescriptor `json:"layers"`
+	}
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest of OCI artifact %s: %v", ref, err)
+	}
+	if manifest.MediaType == "" {
+		manifest.MediaType = mediaType
+	}
+	if manifest.MediaType != ociManifestMediaType && manifest.MediaType != dockerManifestMediaType {
+		return nil, fmt.Errorf("OCI artifact %s is a %s, not a single manifest", ref, manifest.MediaType)
+	}
+
+	var out bytes.Buffer
+	for _, layer := range manifest.Layers {
+		if layer.Size > maxOCILayerSize {
+			return nil, fmt.Errorf("layer %s of OCI artifact %s is larger than %d bytes", layer.Digest, ref, maxOCILayerSize)
+		}
+		blob, _, err := c.get(fmt.Sprintf("/v2/%s/blobs/%s", ref.Repository, layer.Digest), "*/*")
+		if err != nil {
+			return nil, fmt.Errorf("failed to pull layer %s of OCI artifact %s: %v", layer.Digest, ref, err)
+		}
+		if err := verifyDigest(blob, layer.Digest); err != nil {
+			return nil, fmt.Errorf("layer of OCI artifact %s: %v", ref, err)
+		}
+		if err := appendLayerManifests(&out, layer, blob); err != nil {
+			return nil, fmt.Errorf("layer %s of OCI artifact %s: %v", layer.Digest, ref, err)
+		}
+	}
+	return out.Bytes(), nil
+}
+
+// appendLayerManifests appends the manifests of one layer to out: every
+// .yaml, .yml and .json file of a tar or tar+gzip layer in name order, or the
+// layer itself when it is a single manifest file
+func appendLayerManifests(out *bytes.Buffer, layer ociDescriptor, blob []byte) error {
+	isGzip := len(blob) > 2 && blob[0] == 0x1f && blob[1] == 0x8b
+	if isGzip || strings.Contains(layer.MediaType, "tar") {
+		files, err := manifestsFromTar(blob, isGzip)
+		if err != nil {
+			return err
+		}
+		for _, name := range sortedFileNames(files) {
+			if err := appendDocument(out, files[name]); err != nil {
+				return fmt.Errorf("invalid JSON in %s: %v", name, err)
+			}
+		}
+		return nil
+	}
+	if title := layer.Annotations[ociTitleAnnotation]; title != "" && !isManifestFile(title) {
+		return nil
+	}
+	return appendDocument(out, blob)
+}
+
+func manifestsFromTar(blob []byte, isGzip bool) (map[string][]byte, error) {
+	var r io.Reader = bytes.NewReader(blob)
+	if isGzip {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress: %v", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+	files := map[string][]byte{}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %v", err)
+		}
+		if hdr.Typeflag != tar.TypeReg || !isManifestFile(hdr.Name) {
+			continue
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, maxOCILayerSize))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", hdr.Name, err)
+		}
+		files[path.Clean(hdr.Name)] = data
+	}
+}
+
+func isManifestFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+func sortedFileNames(files map[string][]byte) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// appendDocument appends one manifest file to a YAML stream, starting a new
+// document. JSON files are converted, since a stream that starts with JSON
+// is decoded as JSON throughout.
+func appendDocument(out *bytes.Buffer, data []byte) error {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil
+	}
+	if trimmed[0] == '{' || trimmed[0] == '[' {
+		converted, err := yaml.JSONToYAML(trimmed)
+		if err != nil {
+			return err
+		}
+		data = converted
+	}
+	if out.Len() > 0 {
+		out.WriteString("---\n")
+	}
+	out.Write(data)
+	if !bytes.HasSuffix(data, []byte("\n")) {
+		out.WriteString("\n")
+	}
+	return nil
+}
+
+func verifyDigest(data []byte, digest string) error {
+	want, ok := strings.CutPrefix(digest, "sha256:")
+	if !ok {
+		return fmt.Errorf("unsupported digest %q", digest)
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != want {
+		return fmt.Errorf("content does not match digest %s", digest)
+	}
+	return nil
+}
+
+// get reads a registry path, authenticating once when the registry answers
+// 401 with a Bearer or Basic challenge
+func (c *ociClient) get(apiPath, accept string) ([]byte, string, error) {
+	resp, err := c.do(apiPath, accept)
+	if err != nil {
+		return nil, "", err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && c.token == "" {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		if err := c.authenticate(challenge); err != nil {
+			return nil, "", err
+		}
+		if resp, err = c.do(apiPath, accept); err != nil {
+			return nil, "", err
+		}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("%s%s: %s", c.registry, apiPath, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxOCILayerSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	return body, resp.Header.Get("Content-Type"), nil
+}
+
+func (c *ociClient) do(apiPath, accept string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+apiPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	if c.token != "" {
+		req.Header.Set("Authorization", c.token)
+	}
+	return c.http.Do(req)
+}
+
+// authenticate answers a WWW-Authenticate challenge with the docker config
+// credentials of the registry, or anonymously for a Bearer token when there
+// are none, and keeps the resulting Authorization header
+func (c *ociClient) authenticate(challenge string) error {
+	scheme, params := parseAuthChallenge(challenge)
+	user, password := dockerCredentials(c.registry)
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if user == "" {
+			return fmt.Errorf("registry %s requires credentials; log in with docker login or helm registry login", c.registry)
+		}
+		c.token = "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password))
+		return nil
+	case "bearer":
+	default:
+		return fmt.Errorf("registry %s asks for unsupported authentication %q", c.registry, challenge)
+	}
+
+	tokenURL, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return fmt.Errorf("registry %s sent an invalid token realm %q", c.registry, params["realm"])
+	}
+	q := tokenURL.Query()
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			q.Set(key, params[key])
+		}
+	}
+	tokenURL.RawQuery = q.Encode()
+	req, err := http.NewRequest(http.MethodGet, tokenURL.String(), nil)
+	if err != nil {
+		return err
+	}
+	if user != "" {
+		req.SetBasicAuth(user, password)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to get a token for registry %s: %v", c.registry, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get a token for registry %s: %s", c.registry, resp.Status)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return fmt.Errorf("failed to decode the token of registry %s: %v", c.registry, err)
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	c.token = "Bearer " + token.Token
+	return nil
+}
+
+// parseAuthChallenge splits a WWW-Authenticate header such as
+// Bearer realm="https://ghcr.io/token",service="ghcr.io",scope="repository:org/bundle:pull"
+func parseAuthChallenge(header string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	params := map[string]string{}
+	for rest != "" {
+		var key, value string
+		key, rest, _ = strings.Cut(strings.TrimLeft(rest, ", "), "=")
+		if strings.HasPrefix(rest, `"`) {
+			value, rest, _ = strings.Cut(rest[1:], `"`)
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		if key != "" {
+			params[strings.ToLower(strings.TrimSpace(key))] = value
+		}
+	}
+	return scheme, params
+}
+
+// dockerCredentials returns the user and password stored for a registry in
+// the docker config ($DOCKER_CONFIG/config.json or ~/.docker/config.json)
+func dockerCredentials(registry string) (string, string) {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", ""
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		return "", ""
+	}
+	var cfg struct {
+		Auths map[string]struct {
+			Auth string `json:"auth"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return "", ""
+	}
+	keys := []string{registry, "https://" + registry}
+	if registry == "docker.io" {
+		keys = append(keys, "https://index.docker.io/v1/")
+	}
+	for _, key := range keys {
+		entry, ok := cfg.Auths[key]
+		if !ok {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+		if err != nil {
+			continue
+		}
+		user, password, _ := strings.Cut(string(decoded), ":")
+		return user, password
+	}
+	return "", ""
+}
@@ -0,0 +1,150 @@
+package util
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseOCIReference(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	tests := []struct {
+		ref     string
+		want    OCIReference
+		wantErr bool
+	}{
+		{ref: "ghcr.io/org/bundle:v1", want: OCIReference{"ghcr.io", "org/bundle", "v1"}},
+		{ref: "oci://ghcr.io/org/bundle", want: OCIReference{"ghcr.io", "org/bundle", "latest"}},
+		{ref: "localhost:5000/bundle:dev", want: OCIReference{"localhost:5000", "bundle", "dev"}},
+		{ref: "registry.local:5000/team/bundle@" + digest, want: OCIReference{"registry.local:5000", "team/bundle", digest}},
+		{ref: "org/bundle:v1", want: OCIReference{"docker.io", "org/bundle", "v1"}},
+		{ref: "bundle", want: OCIReference{"docker.io", "library/bundle", "latest"}},
+		{ref: "ghcr.io/org/bundle@sha256:abc", wantErr: true},
+		{ref: "ghcr.io/Org/Bundle:v1", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseOCIReference(tt.ref)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseOCIReference(%q) error = %v, wantErr %v", tt.ref, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseOCIReference(%q) = %+v, want %+v", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func sha256Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func tarGz(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	names := make(map[string][]byte, len(files))
+	for name, content := range files {
+		names[name] = []byte(content)
+	}
+	for _, name := range sortedFileNames(names) {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(files[name])), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(files[name]))
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func TestSpoolOCIArtifact(t *testing.T) {
+	archive := tarGz(t, map[string]string{
+		"bundle/b-service.yaml": "apiVersion: v1\nkind: Service\nmetadata:\n  name: web\n",
+		"bundle/a-config.json":  `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"web"}}`,
+		"bundle/README.md":      "not a manifest",
+	})
+	deployment := []byte("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n")
+	readme := []byte("# bundle\n")
+	blobs := map[string][]byte{sha256Digest(archive): archive, sha256Digest(deployment): deployment, sha256Digest(readme): readme}
+
+	manifest, _ := json.Marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"mediaType":     ociManifestMediaType,
+		"layers": []ociDescriptor{
+			{MediaType: "application/vnd.oci.image.layer.v1.tar+gzip", Digest: sha256Digest(archive), Size: int64(len(archive))},
+			{MediaType: "application/yaml", Digest: sha256Digest(deployment), Size: int64(len(deployment)), Annotations: map[string]string{ociTitleAnnotation: "deployment.yaml"}},
+			{MediaType: "text/markdown", Digest: sha256Digest(readme), Size: int64(len(readme)), Annotations: map[string]string{ociTitleAnnotation: "README.md"}},
+		},
+	})
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if r.URL.Query().Get("scope") != "repository:org/bundle:pull" {
+				t.Errorf("token requested for scope %q", r.URL.Query().Get("scope"))
+			}
+			w.Write([]byte(`{"token":"secret"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="test",scope="repository:org/bundle:pull"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.URL.Path == "/v2/org/bundle/manifests/v1":
+			w.Header().Set("Content-Type", ociManifestMediaType)
+			w.Write(manifest)
+		case strings.HasPrefix(r.URL.Path, "/v2/org/bundle/blobs/"):
+			blob, ok := blobs[strings.TrimPrefix(r.URL.Path, "/v2/org/bundle/blobs/")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(blob)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("DOCKER_CONFIG", t.TempDir())
+	registry := strings.TrimPrefix(server.URL, "http://")
+	spooled, err := SpoolOCIArtifact(registry + "/org/bundle:v1")
+	if err != nil {
+		t.Fatalf("SpoolOCIArtifact() error = %v", err)
+	}
+	defer os.Remove(spooled)
+
+	objs, err := ReadManifests(spooled, false)
+	if err != nil {
+		t.Fatalf("ReadManifests() error = %v", err)
+	}
+	var kinds []string
+	for _, obj := range objs {
+		kinds = append(kinds, obj.GetKind())
+	}
+	if got, want := strings.Join(kinds, ","), "ConfigMap,Service,Deployment"; got != want {
+		t.Errorf("kinds in bundle = %s, want %s", got, want)
+	}
+
+	if _, err := SpoolOCIArtifact(registry + "/org/bundle:missing"); err == nil {
+		t.Errorf("SpoolOCIArtifact() of a missing tag succeeded")
+	}
+}
+
+func TestParseAuthChallenge(t *testing.T) {
+	scheme, params := parseAuthChallenge(`Bearer realm="https://ghcr.io/token",service="ghcr.io",scope="repository:org/bundle:pull"`)
+	if scheme != "Bearer" || params["realm"] != "https://ghcr.io/token" || params["service"] != "ghcr.io" || params["scope"] != "repository:org/bundle:pull" {
+		t.Errorf("parseAuthChallenge() = %s %v", scheme, params)
+	}
+}
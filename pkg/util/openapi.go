@@ -0,0 +1,231 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// OpenAPISchemas are the component schemas of an OpenAPI v3 document, by name
+type OpenAPISchemas map[string]map[string]interface{}
+
+// ParseOpenAPISchemas decodes the component schemas of an OpenAPI v3 JSON
+// document, as the API server serves one per group version
+func ParseOpenAPISchemas(data []byte) (OpenAPISchemas, error) {
+	var doc struct {
+		Components struct {
+			Schemas OpenAPISchemas `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode OpenAPI document: %v", err)
+	}
+	return doc.Components.Schemas, nil
+}
+
+// OpenAPIPath is the path of the OpenAPI v3 document of a group version
+func OpenAPIPath(gv schema.GroupVersion) string {
+	if gv.Group == "" {
+		return "api/" + gv.Version
+	}
+	return "apis/" + gv.Group + "/" + gv.Version
+}
+
+// SchemaField is one field of an object schema
+type SchemaField struct {
+	Name        string
+	Type        string
+	Description string
+	Required    bool
+}
+
+// SchemaNode is the schema of a kind or of a field within it
+type SchemaNode struct {
+	schemas OpenAPISchemas
+	node    map[string]interface{}
+}
+
+// ForKind returns the schema of the kind, found by its
+// x-kubernetes-group-version-kind extension
+func (s OpenAPISchemas) ForKind(gvk schema.GroupVersionKind) (SchemaNode, bool) {
+	for _, node := range s {
+		kinds, _ := node["x-kubernetes-group-version-kind"].([]interface{})
+		for _, k := range kinds {
+			m, _ := k.(map[string]interface{})
+			if m["group"] == gvk.Group && m["version"] == gvk.Version && m["kind"] == gvk.Kind {
+				return SchemaNode{schemas: s, node: node}, true
+			}
+		}
+	}
+	return SchemaNode{}, false
+}
+
+// Type names the type of the node the way kubectl explain does, such as
+// string, []Container or map[string]string
+func (n SchemaNode) Type() string {
+	return n.schemas.typeName(n.node)
+}
+
+// Description is the documentation of the node, preferring the text given
+// where a field refers to a shared schema
+func (n SchemaNode) Description() string {
+	if d, ok := n.node["description"].(string); ok && d != "" {
+		return d
+	}
+	resolved, _ := n.schemas.resolve(n.node)
+	d, _ := resolved["description"].(string)
+	return d
+}
+
+// Field returns the schema of a field, looking through arrays so that the
+// fields of list items are reached as in kubectl explain
+func (n SchemaNode) Field(name string) (SchemaNode, bool) {
+	props, _ := n.object()["properties"].(map[string]interface{})
+	field, ok := props[name].(map[string]interface{})
+	if !ok {
+		return SchemaNode{}, false
+	}
+	return SchemaNode{schemas: n.schemas, node: field}, true
+}
+
+// Fields returns the fields of the node, or of its items for an array,
+// sorted by name
+func (n SchemaNode) Fields() []SchemaField {
+	obj := n.object()
+	props, _ := obj["properties"].(map[string]interface{})
+	required := map[string]bool{}
+	if list, ok := obj["required"].([]interface{}); ok {
+		for _, r := range list {
+			if name, ok := r.(string); ok {
+				required[name] = true
+			}
+		}
+	}
+	var fields []SchemaField
+	for name := range props {
+		field, _ := n.Field(name)
+		fields = append(fields, SchemaField{Name: name, Type: field.Type(), Description: field.Description(), Required: required[name]})
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+	return fields
+}
+
+// FieldTypes returns the type of every field below the node, keyed by its
+// dotted path, down to maxDepth levels. Recursive schemas stop where a
+// shared schema would repeat within its own path.
+func (n SchemaNode) FieldTypes(maxDepth int) map[string]string {
+	types := map[string]string{}
+	n.collectFieldTypes("", maxDepth, map[string]bool{}, types)
+	return types
+}
+
+func (n SchemaNode) collectFieldTypes(prefix string, depth int, seen map[string]bool, types map[string]string) {
+	if depth == 0 {
+		return
+	}
+	ref := n.itemRef()
+	if ref != "" {
+		if seen[ref] {
+			return
+		}
+		seen[ref] = true
+		defer delete(seen, ref)
+	}
+	for _, f := range n.Fields() {
+		path := prefix + f.Name
+		types[path] = f.Type
+		field, _ := n.Field(f.Name)
+		field.collectFieldTypes(path+".", depth-1, seen, types)
+	}
+}
+
+// object resolves the node, and the items of arrays, to the schema that
+// holds the properties
+func (n SchemaNode) object() map[string]interface{} {
+	resolved, _ := n.schemas.resolve(n.node)
+	for i := 0; i < 8; i++ {
+		items, ok := resolved["items"].(map[string]interface{})
+		if !ok {
+			break
+		}
+		resolved, _ = n.schemas.resolve(items)
+	}
+	return resolved
+}
+
+// itemRef names the shared schema the node, or its items, refer to
+func (n SchemaNode) itemRef() string {
+	node := n.node
+	for i := 0; i < 8; i++ {
+		resolved, ref := n.schemas.resolve(node)
+		if ref != "" {
+			return ref
+		}
+		items, ok := resolved["items"].(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		node = items
+	}
+	return ""
+}
+
+// resolve follows $ref, directly or wrapped in a single allOf, to the named
+// schema and returns it with its name
+func (s OpenAPISchemas) resolve(node map[string]interface{}) (map[string]interface{}, string) {
+	name := ""
+	for i := 0; i < 8; i++ {
+		ref := schemaRef(node)
+		if ref == "" {
+			break
+		}
+		name = strings.TrimPrefix(ref, "#/components/schemas/")
+		target, ok := s[name]
+		if !ok {
+			break
+		}
+		node = target
+	}
+	return node, name
+}
+
+func schemaRef(node map[string]interface{}) string {
+	if ref, ok := node["$ref"].(string); ok {
+		return ref
+	}
+	if all, ok := node["allOf"].([]interface{}); ok && len(all) == 1 {
+		if m, ok := all[0].(map[string]interface{}); ok {
+			ref, _ := m["$ref"].(string)
+			return ref
+		}
+	}
+	return ""
+}
+
+func (s OpenAPISchemas) typeName(node map[string]interface{}) string {
+	resolved, ref := s.resolve(node)
+	t, _ := resolved["type"].(string)
+	if ref != "" && (t == "" || t == "object") {
+		// io.k8s.api.apps.v1.DeploymentSpec is a DeploymentSpec
+		return ref[strings.LastIndex(ref, ".")+1:]
+	}
+	switch t {
+	case "array":
+		items, _ := resolved["items"].(map[string]interface{})
+		return "[]" + s.typeName(items)
+	case "object":
+		if values, ok := resolved["additionalProperties"].(map[string]interface{}); ok {
+			return "map[string]" + s.typeName(values)
+		}
+		return "Object"
+	case "":
+		if intOrString, _ := resolved["x-kubernetes-int-or-string"].(bool); intOrString {
+			return "IntOrString"
+		}
+		return "Object"
+	}
+	return t
+}
@@ -0,0 +1,114 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const testOpenAPIDocument = `{
+  "openapi": "3.0.0",
+  "components": {
+    "schemas": {
+      "com.example.v1.Widget": {
+        "type": "object",
+        "description": "Widget is a test kind.",
+        "required": ["spec"],
+        "properties": {
+          "spec": {"allOf": [{"$ref": "#/components/schemas/com.example.v1.WidgetSpec"}], "description": "Spec of the widget."},
+          "labels": {"type": "object", "additionalProperties": {"type": "string"}}
+        },
+        "x-kubernetes-group-version-kind": [{"group": "example.com", "kind": "Widget", "version": "v1"}]
+      },
+      "com.example.v1.WidgetSpec": {
+        "type": "object",
+        "description": "WidgetSpec describes a widget.",
+        "properties": {
+          "size": {"type": "integer"},
+          "port": {"x-kubernetes-int-or-string": true},
+          "parts": {"type": "array", "items": {"$ref": "#/components/schemas/com.example.v1.Part"}}
+        }
+      },
+      "com.example.v1.Part": {
+        "type": "object",
+        "properties": {
+          "name": {"type": "string", "description": "Name of the part."},
+          "parts": {"type": "array", "items": {"$ref": "#/components/schemas/com.example.v1.Part"}}
+        }
+      }
+    }
+  }
+}`
+
+func testWidgetSchema(t *testing.T) SchemaNode {
+	t.Helper()
+	schemas, err := ParseOpenAPISchemas([]byte(testOpenAPIDocument))
+	if err != nil {
+		t.Fatalf("ParseOpenAPISchemas() error = %v", err)
+	}
+	node, ok := schemas.ForKind(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"})
+	if !ok {
+		t.Fatal("ForKind() found no Widget")
+	}
+	return node
+}
+
+func TestOpenAPIPath(t *testing.T) {
+	if got := OpenAPIPath(schema.GroupVersion{Version: "v1"}); got != "api/v1" {
+		t.Errorf("OpenAPIPath(v1) = %q", got)
+	}
+	if got := OpenAPIPath(schema.GroupVersion{Group: "apps", Version: "v1"}); got != "apis/apps/v1" {
+		t.Errorf("OpenAPIPath(apps/v1) = %q", got)
+	}
+}
+
+func TestSchemaNodeFields(t *testing.T) {
+	widget := testWidgetSchema(t)
+	if got := widget.Description(); got != "Widget is a test kind." {
+		t.Errorf("Description() = %q", got)
+	}
+	want := []SchemaField{
+		{Name: "labels", Type: "map[string]string"},
+		{Name: "spec", Type: "WidgetSpec", Description: "Spec of the widget.", Required: true},
+	}
+	if got := widget.Fields(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Fields() = %+v, want %+v", got, want)
+	}
+
+	spec, ok := widget.Field("spec")
+	if !ok {
+		t.Fatal("Field(spec) not found")
+	}
+	parts, ok := spec.Field("parts")
+	if !ok || parts.Type() != "[]Part" {
+		t.Fatalf("Field(parts) = %v, %v", parts.Type(), ok)
+	}
+	// The fields of list items are reached through the list
+	name, ok := parts.Field("name")
+	if !ok || name.Type() != "string" || name.Description() != "Name of the part." {
+		t.Errorf("Field(parts.name) = %q %q, %v", name.Type(), name.Description(), ok)
+	}
+	if _, ok := spec.Field("color"); ok {
+		t.Error("Field(color) found a field that does not exist")
+	}
+}
+
+func TestSchemaNodeFieldTypes(t *testing.T) {
+	want := map[string]string{
+		"labels":           "map[string]string",
+		"spec":             "WidgetSpec",
+		"spec.size":        "integer",
+		"spec.port":        "IntOrString",
+		"spec.parts":       "[]Part",
+		"spec.parts.name":  "string",
+		"spec.parts.parts": "[]Part",
+	}
+	// Part refers to itself, so the walk stops where it would repeat
+	if got := testWidgetSchema(t).FieldTypes(10); !reflect.DeepEqual(got, want) {
+		t.Errorf("FieldTypes() = %v, want %v", got, want)
+	}
+	if got := testWidgetSchema(t).FieldTypes(1); len(got) != 2 {
+		t.Errorf("FieldTypes(1) = %v, want the top-level fields", got)
+	}
+}
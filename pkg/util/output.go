@@ -0,0 +1,169 @@
+package util
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"k8s.io/cli-runtime/pkg/printers"
+	"sigs.k8s.io/yaml"
+)
+
+// PrintStructured writes v as indented JSON or as YAML
+func PrintStructured(w io.Writer, format string, v interface{}) error {
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode output: %v", err)
+		}
+		fmt.Fprintln(w, string(data))
+	case "yaml":
+		data, err := yaml.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed to encode output: %v", err)
+		}
+		fmt.Fprint(w, string(data))
+	default:
+		return fmt.Errorf("unsupported output format %q", format)
+	}
+	return nil
+}
+
+// Table export formats accepted by NewTableWriter besides the aligned table
+const (
+	TableFormatCSV      = "csv"
+	TableFormatMarkdown = "markdown"
+)
+
+// TableWriter receives tab-separated table rows, one per line, and writes
+// them out on Flush. *tabwriter.Writer is the aligned-text implementation.
+type TableWriter interface {
+	io.Writer
+	Flush() error
+}
+
+// IsTableExportFormat reports whether format is one of the table export
+// formats (csv or markdown)
+func IsTableExportFormat(format string) bool {
+	return format == TableFormatCSV || format == TableFormatMarkdown
+}
+
+// NewTableWriter returns the TableWriter for an output format: CSV records,
+// Markdown tables, or aligned text for any other format
+func NewTableWriter(out io.Writer, format string) TableWriter {
+	switch format {
+	case TableFormatCSV:
+		return &tableSink{out: out, write: writeCSVTable}
+	case TableFormatMarkdown:
+		return &tableSink{out: out, write: writeMarkdownTable}
+	default:
+		return tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	}
+}
+
+// NewKubectlTableWriter returns an aligned-text TableWriter that pads the
+// columns the way kubectl does, for output that must line up with kubectl's
+func NewKubectlTableWriter(out io.Writer) TableWriter {
+	return printers.GetNewTabWriter(out)
+}
+
+// tableSink buffers tab-separated rows and converts them on Flush. Lines
+// without a tab, such as section titles, end the current table.
+type tableSink struct {
+	out   io.Writer
+	buf   bytes.Buffer
+	write func(out io.Writer, rows [][]string) error
+	// columns, when set, selects the columns of every table
+	columns *columnPicker
+}
+
+func (s *tableSink) Write(p []byte) (int, error) {
+	return s.buf.Write(p)
+}
+
+func (s *tableSink) Flush() error {
+	text := s.buf.String()
+	s.buf.Reset()
+
+	var rows [][]string
+	flushRows := func() error {
+		if len(rows) == 0 {
+			return nil
+		}
+		err := s.write(s.out, rows)
+		rows = nil
+		return err
+	}
+	if s.columns != nil {
+		defer s.columns.reset()
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		if strings.Contains(line, "\t") {
+			cells := strings.Split(line, "\t")
+			for i := range cells {
+				cells[i] = strings.TrimSpace(cells[i])
+			}
+			if s.columns != nil {
+				var ok bool
+				if cells, ok = s.columns.pick(cells); !ok {
+					continue
+				}
+			}
+			rows = append(rows, cells)
+			continue
+		}
+		if s.columns != nil {
+			s.columns.reset()
+		}
+		if err := flushRows(); err != nil {
+			return err
+		}
+		if strings.TrimSpace(line) != "" {
+			fmt.Fprintln(s.out, line)
+		}
+	}
+	if err := flushRows(); err != nil {
+		return err
+	}
+	if s.columns != nil {
+		return s.columns.err
+	}
+	return nil
+}
+
+// writeCSVTable writes the rows, header first, as CSV records
+func writeCSVTable(out io.Writer, rows [][]string) error {
+	w := csv.NewWriter(out)
+	if err := w.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write csv: %v", err)
+	}
+	return nil
+}
+
+// writeMarkdownTable writes the rows as a Markdown table whose header is the
+// first row; short rows are padded and pipes in cells escaped
+func writeMarkdownTable(out io.Writer, rows [][]string) error {
+	width := len(rows[0])
+	line := func(cells []string) {
+		escaped := make([]string, width)
+		for i := range escaped {
+			if i < len(cells) {
+				escaped[i] = strings.ReplaceAll(cells[i], "|", "\\|")
+			}
+		}
+		fmt.Fprintf(out, "| %s |\n", strings.Join(escaped, " | "))
+	}
+
+	line(rows[0])
+	fmt.Fprintf(out, "|%s\n", strings.Repeat(" --- |", width))
+	for _, row := range rows[1:] {
+		line(row)
+	}
+	fmt.Fprintln(out)
+	return nil
+}
@@ -0,0 +1,61 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestNewTableWriter(t *testing.T) {
+	const table = "CLUSTER\tNAME\tSTATUS\ncluster1\tweb-1\tRunning\ncluster2\tweb|2\t\n"
+	tests := []struct {
+		name   string
+		format string
+		input  string
+		want   string
+	}{
+		{
+			name:   "aligned text",
+			format: "",
+			input:  table,
+			want:   "CLUSTER   NAME   STATUS\ncluster1  web-1  Running\ncluster2  web|2  \n",
+		},
+		{
+			name:   "csv",
+			format: TableFormatCSV,
+			input:  "CLUSTER\tLABELS\ncluster1\tenv=prod,region=us\n",
+			want:   "CLUSTER,LABELS\ncluster1,\"env=prod,region=us\"\n",
+		},
+		{
+			name:   "markdown escapes pipes and pads short rows",
+			format: TableFormatMarkdown,
+			input:  "CLUSTER\tNAME\tSTATUS\ncluster1\tweb|2\n",
+			want:   "| CLUSTER | NAME | STATUS |\n| --- | --- | --- |\n| cluster1 | web\\|2 |  |\n\n",
+		},
+		{
+			name:   "titles split csv tables",
+			format: TableFormatCSV,
+			input:  "==> Pods\nCLUSTER\tNAME\ncluster1\tweb\n\n==> Services\nCLUSTER\tNAME\ncluster1\tsvc\n",
+			want:   "==> Pods\nCLUSTER,NAME\ncluster1,web\n==> Services\nCLUSTER,NAME\ncluster1,svc\n",
+		},
+		{
+			name:   "notice without a table",
+			format: TableFormatMarkdown,
+			input:  "No managed clusters found.\n",
+			want:   "No managed clusters found.\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			w := NewTableWriter(&out, tt.format)
+			fmt.Fprint(w, tt.input)
+			if err := w.Flush(); err != nil {
+				t.Fatalf("Flush() error = %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("output = %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
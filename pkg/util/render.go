@@ -0,0 +1,55 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// RenderFormatGoTemplate is the only supported --render engine
+const RenderFormatGoTemplate = "go-template"
+
+// renderFuncs are the helpers available to manifest templates, named after
+// their sprig equivalents
+var renderFuncs = template.FuncMap{
+	"default": func(def, value interface{}) interface{} {
+		if value == nil || value == "" {
+			return def
+		}
+		return value
+	},
+	"required": func(msg string, value interface{}) (interface{}, error) {
+		if value == nil || value == "" {
+			return nil, fmt.Errorf("%s", msg)
+		}
+		return value, nil
+	},
+	"quote":      func(value interface{}) string { return fmt.Sprintf("%q", fmt.Sprint(value)) },
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"trim":       strings.TrimSpace,
+	"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+	"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+}
+
+// RenderManifests evaluates each manifest source as a Go template against
+// data and joins the results into one multi-document YAML stream. Missing
+// map keys are errors so a typo in a variable name cannot render silently.
+func RenderManifests(sources []ManifestSource, data interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	for i, src := range sources {
+		tmpl, err := template.New(src.Path).Funcs(renderFuncs).Option("missingkey=error").Parse(string(src.Data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template %s: %v", src.Path, err)
+		}
+		if i > 0 {
+			buf.WriteString("\n---\n")
+		}
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("failed to render %s: %v", src.Path, err)
+		}
+	}
+	return buf.Bytes(), nil
+}
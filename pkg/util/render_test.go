@@ -0,0 +1,69 @@
+package util
+
+import (
+	"testing"
+)
+
+func TestRenderManifests(t *testing.T) {
+	data := map[string]interface{}{
+		"ClusterName":   "cluster1",
+		"ClusterLabels": map[string]string{"region": "us-east"},
+		"Vars":          map[string]string{"image": "nginx:1.25", "empty": ""},
+	}
+	tests := []struct {
+		name    string
+		sources []ManifestSource
+		want    string
+		wantErr bool
+	}{
+		{name: "no sources", want: ""},
+		{
+			name:    "plain manifest is unchanged",
+			sources: []ManifestSource{{Path: "cm.yaml", Data: []byte("kind: ConfigMap\n")}},
+			want:    "kind: ConfigMap\n",
+		},
+		{
+			name: "fields and functions",
+			sources: []ManifestSource{{Path: "cm.yaml", Data: []byte(
+				`name: {{ .ClusterName | upper }}-{{ index .ClusterLabels "region" }}
+image: {{ .Vars.image | quote }}
+tier: {{ .Vars.empty | default "web" }}
+tag: {{ .Vars.image | trimPrefix "nginx:" | replace "." "-" }}`)}},
+			want: "name: CLUSTER1-us-east\nimage: \"nginx:1.25\"\ntier: web\ntag: 1-25",
+		},
+		{
+			name: "sources joined as documents",
+			sources: []ManifestSource{
+				{Path: "a.yaml", Data: []byte("a: {{ .ClusterName }}")},
+				{Path: "b.yaml", Data: []byte("b: {{ .ClusterName }}")},
+			},
+			want: "a: cluster1\n---\nb: cluster1",
+		},
+		{
+			name:    "missing variable",
+			sources: []ManifestSource{{Path: "cm.yaml", Data: []byte("{{ .Vars.tag }}")}},
+			wantErr: true,
+		},
+		{
+			name:    "required value empty",
+			sources: []ManifestSource{{Path: "cm.yaml", Data: []byte(`{{ required "empty is required" .Vars.empty }}`)}},
+			wantErr: true,
+		},
+		{
+			name:    "parse error",
+			sources: []ManifestSource{{Path: "cm.yaml", Data: []byte("{{ .ClusterName ")}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderManifests(tt.sources, data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RenderManifests() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && string(got) != tt.want {
+				t.Errorf("RenderManifests() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+#!/usr/bin/env bash
+# Creates the kind based KubeStellar environment the e2e tests run against:
+# a KubeFlex hosting cluster with ITS its1 and WDS wds1 installed by the core
+# chart, and the WECs cluster1 and cluster2 registered and labeled
+# location-group=edge. Needs docker, kind, kubectl, helm and clusteradm.
+set -euo pipefail
+
+E2E_CLUSTERS="${E2E_CLUSTERS:-cluster1,cluster2}"
+
+KUBESTELLAR_REF="${KUBESTELLAR_REF:-main}"
+
+for tool in docker kind kubectl helm clusteradm; do
+  if ! command -v "$tool" >/dev/null 2>&1; then
+    echo "e2e-setup: $tool is required" >&2
+    exit 1
+  fi
+done
+
+bash <(curl -fsSL "https://raw.githubusercontent.com/kubestellar/kubestellar/${KUBESTELLAR_REF}/scripts/create-kubestellar-demo-env.sh")
+
+# The WECs must be registered before the tests discover them
+for cluster in ${E2E_CLUSTERS//,/ }; do
+  kubectl --context "${E2E_ITS:-its1}" wait managedcluster "$cluster" --for condition=ManagedClusterConditionAvailable --timeout 5m
+done
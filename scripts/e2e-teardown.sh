@@ -0,0 +1,9 @@
+#!/usr/bin/env bash
+# Deletes the kind clusters created by e2e-setup.sh
+set -euo pipefail
+
+E2E_CLUSTERS="${E2E_CLUSTERS:-cluster1,cluster2}"
+
+for cluster in kubeflex ${E2E_CLUSTERS//,/ }; do
+  kind delete cluster --name "$cluster"
+done
@@ -0,0 +1,187 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The tests run in file order against one environment and clean up after
+// themselves, so they can be rerun with E2E_SKIP_SETUP=1.
+
+func TestGetNodes(t *testing.T) {
+	p, clusters := PluginFromEnv(), ClustersFromEnv()
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "nodes", args: []string{"get", "nodes"}},
+		{name: "namespaces", args: []string{"get", "namespaces", "kube-system"}},
+		{name: "selected clusters", args: []string{"get", "nodes", "--clusters", strings.Join(clusters, ",")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := p.Run(tt.args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if missing := MissingClusters(ClusterRows(out), clusters); len(missing) > 0 {
+				t.Errorf("no rows for %v in:\n%s", missing, out)
+			}
+		})
+	}
+}
+
+func TestApplyGetLogs(t *testing.T) {
+	p, clusters := PluginFromEnv(), ClustersFromEnv()
+	t.Cleanup(func() {
+		if _, err := p.Run("namespace", "delete", "e2e-multi", "--force"); err != nil {
+			t.Logf("cleanup: %v", err)
+		}
+	})
+
+	if _, err := p.Run("apply", "-f", "testdata/echo.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Run("wait", "deployment/e2e-echo", "-n", "e2e-multi", "--for", "condition=Available", "--timeout", "3m"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		args  []string
+		check func(out string) error
+	}{
+		{
+			name: "get deployments",
+			args: []string{"get", "deployments", "-n", "e2e-multi"},
+			check: func(out string) error {
+				for _, c := range clusters {
+					rows := ClusterRows(out)[c]
+					if len(rows) != 1 || rows[0][0] != "e2e-echo" || rows[0][1] != "1/1" {
+						return fmt.Errorf("cluster %s rows = %v, want e2e-echo 1/1", c, rows)
+					}
+				}
+				return nil
+			},
+		},
+		{
+			name: "get pods by selector",
+			args: []string{"get", "pods", "-A", "-l", "app.kubernetes.io/name=e2e-echo"},
+			check: func(out string) error {
+				if missing := MissingClusters(ClusterRows(out), clusters); len(missing) > 0 {
+					return fmt.Errorf("no pods for %v", missing)
+				}
+				return nil
+			},
+		},
+		{
+			name: "logs",
+			args: []string{"logs", "-n", "e2e-multi", "-l", "app.kubernetes.io/name=e2e-echo"},
+			check: func(out string) error {
+				sections := LogSections(out)
+				for _, c := range clusters {
+					if !strings.Contains(sections[c], "hello from kubectl-multi e2e") {
+						return fmt.Errorf("cluster %s logs = %q", c, sections[c])
+					}
+				}
+				return nil
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := p.Run(tt.args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.check(out); err != nil {
+				t.Errorf("%v in:\n%s", err, out)
+			}
+		})
+	}
+}
+
+func TestBindingPolicyCreate(t *testing.T) {
+	p, clusters := PluginFromEnv(), ClustersFromEnv()
+	t.Cleanup(func() {
+		for _, args := range [][]string{
+			{"delete", "bindingpolicy", "e2e-bp", "--ignore-not-found"},
+			{"delete", "-f", "testdata/bp-workload.yaml", "--ignore-not-found"},
+		} {
+			if _, err := p.Kubectl(p.WDS, args...); err != nil {
+				t.Logf("cleanup: %v", err)
+			}
+		}
+	})
+
+	if _, err := p.Kubectl(p.WDS, "apply", "-f", "testdata/bp-workload.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Run("bp", "create", "e2e-bp", "--cluster-selector", "location-group=edge",
+		"--object-selector", "app.kubernetes.io/name=e2e-bp", "--resources", "namespaces,deployments"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := append([]string(nil), clusters...)
+	sort.Strings(want)
+	tests := []struct {
+		name  string
+		check func() error
+	}{
+		{
+			name: "policy resolves to every WEC",
+			check: func() error {
+				out, err := p.Run("bp", "list", "-o", "json")
+				if err != nil {
+					return err
+				}
+				var policies []struct {
+					Name     string   `json:"name"`
+					Clusters []string `json:"clusters"`
+				}
+				if err := json.Unmarshal([]byte(out), &policies); err != nil {
+					return err
+				}
+				for _, policy := range policies {
+					if policy.Name != "e2e-bp" {
+						continue
+					}
+					got := append([]string(nil), policy.Clusters...)
+					sort.Strings(got)
+					if !reflect.DeepEqual(got, want) {
+						return fmt.Errorf("e2e-bp clusters = %v, want %v", got, want)
+					}
+					return nil
+				}
+				return fmt.Errorf("e2e-bp not listed")
+			},
+		},
+		{
+			name: "workload reaches every WEC",
+			check: func() error {
+				out, err := p.Run("get", "deployments", "-n", "e2e-bp")
+				if err != nil {
+					return err
+				}
+				if missing := MissingClusters(ClusterRows(out), clusters); len(missing) > 0 {
+					return fmt.Errorf("e2e-bp not in %v", missing)
+				}
+				return nil
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Eventually(3*time.Minute, 5*time.Second, tt.check); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+// Package e2e drives the kubectl-multi binary against a kind based
+// KubeStellar environment (see make e2e). The helpers in this file parse the
+// aggregated output; the tests themselves only build with the e2e tag.
+package e2e
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Plugin runs the kubectl-multi binary against one environment
+type Plugin struct {
+	Bin        string
+	Kubeconfig string
+	ITS        string
+	WDS        string
+}
+
+// PluginFromEnv reads the environment prepared by scripts/e2e-setup.sh:
+// KUBECTL_MULTI_BIN, E2E_KUBECONFIG, E2E_ITS and E2E_WDS
+func PluginFromEnv() Plugin {
+	return Plugin{
+		Bin:        envOr("KUBECTL_MULTI_BIN", "../../bin/kubectl-multi"),
+		Kubeconfig: os.Getenv("E2E_KUBECONFIG"),
+		ITS:        envOr("E2E_ITS", "its1"),
+		WDS:        envOr("E2E_WDS", "wds1"),
+	}
+}
+
+// ClustersFromEnv returns the WEC names of E2E_CLUSTERS (default cluster1,cluster2)
+func ClustersFromEnv() []string {
+	return strings.Split(envOr("E2E_CLUSTERS", "cluster1,cluster2"), ",")
+}
+
+func envOr(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
+
+// Run runs the plugin with args and returns its stdout. The error includes
+// stderr so failures explain themselves in the test log. Without a terminal
+// to confirm at, fleet mutations are confirmed with --yes.
+func (p Plugin) Run(args ...string) (string, error) {
+	global := []string{"--remote-context", p.ITS, "--wds-context", p.WDS, "--yes"}
+	if p.Kubeconfig != "" {
+		global = append(global, "--kubeconfig", p.Kubeconfig)
+	}
+	cmd := exec.Command(p.Bin, append(args, global...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return stdout.String(), fmt.Errorf("kubectl-multi %s: %v: %s", strings.Join(args, " "), err, stderr.String())
+	}
+	return stdout.String(), nil
+}
+
+// Kubectl runs kubectl against one context, for the setup the plugin does
+// not cover such as writing workloads into the WDS
+func (p Plugin) Kubectl(context string, args ...string) (string, error) {
+	global := []string{"--context", context}
+	if p.Kubeconfig != "" {
+		global = append(global, "--kubeconfig", p.Kubeconfig)
+	}
+	out, err := exec.Command("kubectl", append(global, args...)...).CombinedOutput()
+	if err != nil {
+		return string(out), fmt.Errorf("kubectl %s: %v: %s", strings.Join(args, " "), err, out)
+	}
+	return string(out), nil
+}
+
+// ClusterRows groups the rows of the aggregated tables in out by their
+// CLUSTER column. Header lines and lines outside a table are skipped.
+func ClusterRows(out string) map[string][][]string {
+	rows := map[string][][]string{}
+	inTable := false
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			inTable = false
+		case fields[0] == "CLUSTER":
+			inTable = true
+		case inTable:
+			rows[fields[0]] = append(rows[fields[0]], fields[1:])
+		}
+	}
+	return rows
+}
+
+// LogSections splits the output of the logs command by cluster
+func LogSections(out string) map[string]string {
+	sections := map[string]string{}
+	current := ""
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "=== Cluster: ") {
+			current = strings.Fields(strings.TrimPrefix(line, "=== Cluster: "))[0]
+			sections[current] = ""
+			continue
+		}
+		if current != "" {
+			sections[current] += line + "\n"
+		}
+	}
+	return sections
+}
+
+// MissingClusters returns the clusters of want that have no entry in got
+func MissingClusters[V any](got map[string]V, want []string) []string {
+	var missing []string
+	for _, c := range want {
+		if _, ok := got[c]; !ok {
+			missing = append(missing, c)
+		}
+	}
+	return missing
+}
+
+// Eventually calls check every interval until it succeeds or timeout
+// passes, returning the last error
+func Eventually(timeout, interval time.Duration, check func() error) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		err := check()
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("still failing after %s: %v", timeout, err)
+		}
+		time.Sleep(interval)
+	}
+}
@@ -0,0 +1,102 @@
+package e2e
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClusterRows(t *testing.T) {
+	tests := []struct {
+		name string
+		out  string
+		want map[string][][]string
+	}{
+		{name: "empty", out: "", want: map[string][][]string{}},
+		{
+			name: "one table",
+			out:  "CLUSTER   NAME    READY\ncluster1  web     1/1\ncluster2  web     0/1\ncluster1  db      1/1\n",
+			want: map[string][][]string{"cluster1": {{"web", "1/1"}, {"db", "1/1"}}, "cluster2": {{"web", "0/1"}}},
+		},
+		{
+			name: "text around tables is skipped",
+			out:  "Warning: something\n\nCLUSTER  NAME\ncluster1 a\n\nFailed clusters:\ncluster3 unreachable\n",
+			want: map[string][][]string{"cluster1": {{"a"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClusterRows(tt.out); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ClusterRows() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogSections(t *testing.T) {
+	tests := []struct {
+		name string
+		out  string
+		want map[string]string
+	}{
+		{name: "no sections", out: "Getting logs...\n", want: map[string]string{}},
+		{
+			name: "one section per cluster",
+			out:  "Getting logs...\n\n=== Cluster: cluster1 (Context: cluster1) ===\n--- Pod: echo ---\nhello\n=== Cluster: cluster2 (Context: cluster2) ===\nNo pods\n",
+			want: map[string]string{"cluster1": "--- Pod: echo ---\nhello\n", "cluster2": "No pods\n\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LogSections(tt.out); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LogSections() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMissingClusters(t *testing.T) {
+	tests := []struct {
+		name string
+		got  map[string]string
+		want []string
+	}{
+		{name: "all present", got: map[string]string{"cluster1": "", "cluster2": ""}},
+		{name: "one missing", got: map[string]string{"cluster2": ""}, want: []string{"cluster1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MissingClusters(tt.got, []string{"cluster1", "cluster2"}); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MissingClusters() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventually(t *testing.T) {
+	tests := []struct {
+		name      string
+		failTimes int
+		wantErr   bool
+	}{
+		{name: "immediately", failTimes: 0},
+		{name: "after retries", failTimes: 2},
+		{name: "never", failTimes: 1000, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := Eventually(50*time.Millisecond, time.Millisecond, func() error {
+				calls++
+				if calls <= tt.failTimes {
+					return errors.New("not yet")
+				}
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Eventually() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}